@@ -43,8 +43,12 @@ func (g *Generator) GenerateOperations(api *parser.SmithyAPI) error {
 	return g.generateOperationsFile(api)
 }
 
-// GenerateRouting generates HTTP routing code from the API definition
+// GenerateRouting generates HTTP routing code from the API definition, and the
+// validation code the routing checks the inputs with
 func (g *Generator) GenerateRouting(api *parser.SmithyAPI) error {
+	if err := g.generateValidationFile(api); err != nil {
+		return err
+	}
 	return g.generateRoutingFile(api)
 }
 
@@ -16,6 +16,11 @@ type OperationInfo struct {
 	Errors     []string
 	HTTPMethod string
 	HTTPPath   string
+	// HasValidation is set when the input is a structure with a Validate method
+	HasValidation bool
+	// InputEventStream and OutputEventStream name the fields streamed as events
+	InputEventStream  string
+	OutputEventStream string
 }
 
 // generateOperationsFile generates the operations.go file
@@ -80,12 +85,19 @@ func (g *Generator) collectOperations(api *parser.SmithyAPI, serviceShape *parse
 		if opShape.Input != nil {
 			inputName := parser.GetShapeName(opShape.Input.Target)
 			opInfo.InputType = inputName
+			if inputShape, _ := api.ResolveShape(opShape.Input.Target); inputShape != nil && inputShape.Type == "structure" {
+				opInfo.HasValidation = true
+				opInfo.InputEventStream = g.eventStreamField(inputShape, api)
+			}
 		}
 
 		// Get output type
 		if opShape.Output != nil {
 			outputName := parser.GetShapeName(opShape.Output.Target)
 			opInfo.OutputType = outputName
+			if outputShape, _ := api.ResolveShape(opShape.Output.Target); outputShape != nil {
+				opInfo.OutputEventStream = g.eventStreamField(outputShape, api)
+			}
 		}
 
 		// Get error types
@@ -114,6 +126,17 @@ func (g *Generator) collectOperations(api *parser.SmithyAPI, serviceShape *parse
 	return operations
 }
 
+// eventStreamField returns the Go name of the member of a structure that is
+// streamed as events, or an empty string
+func (g *Generator) eventStreamField(shape *parser.SmithyShape, api *parser.SmithyAPI) string {
+	for fieldName, member := range shape.Members {
+		if target, _ := api.ResolveShape(member.Target); target != nil && target.IsEventStream() {
+			return g.exportFieldName(fieldName)
+		}
+	}
+	return ""
+}
+
 const operationsTemplate = `// Code generated by cmd/codegen. DO NOT EDIT.
 
 package {{.Package}}
@@ -126,7 +149,7 @@ import (
 type ECSAPIInterface interface {
 {{range $name := .OperationNames}}
 {{$op := index $.Operations $name}}
-	// {{$op.Name}} performs the {{$op.Name}} operation
+	// {{$op.Name}} performs the {{$op.Name}} operation{{if $op.InputEventStream}}. {{$op.InputEventStream}} is sent as an event stream.{{end}}{{if $op.OutputEventStream}}. {{$op.OutputEventStream}} is returned as an event stream.{{end}}
 	{{$op.Name}}(ctx context.Context, input *{{$op.InputType}}) (*{{$op.OutputType}}, error)
 {{end}}
 }
@@ -118,6 +118,11 @@ func (r *Router) extractAction(req *http.Request) string {
 {{$op := index $.Operations $name}}
 // handle{{$op.Name}} handles the {{$op.Name}} operation
 func (r *Router) handle{{$op.Name}}(w http.ResponseWriter, req *http.Request) {
+{{- if $op.InputEventStream}}
+	// Event streams are framed in application/vnd.amazon.eventstream, not JSON
+	writeError(w, http.StatusNotImplemented, "NotImplemented", "{{$op.Name}} sends {{$op.InputEventStream}} as an event stream, which is not supported")
+}
+{{- else}}
 	// Parse input
 	var input {{$op.InputType}}
 	if req.ContentLength > 0 {
@@ -126,6 +131,12 @@ func (r *Router) handle{{$op.Name}}(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+{{- if $op.HasValidation}}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
+{{- end}}
 
 	// Call API
 	output, err := r.api.{{$op.Name}}(req.Context(), &input)
@@ -133,10 +144,18 @@ func (r *Router) handle{{$op.Name}}(w http.ResponseWriter, req *http.Request) {
 		writeAPIError(w, err)
 		return
 	}
+{{- if $op.OutputEventStream}}
+	// Event streams are framed in application/vnd.amazon.eventstream, not JSON
+	if output != nil && output.{{$op.OutputEventStream}}.MemberName() != "" {
+		writeError(w, http.StatusNotImplemented, "NotImplemented", "{{$op.Name}} returns {{$op.OutputEventStream}} as an event stream, which is not supported")
+		return
+	}
+{{- end}}
 
 	// Write response
 	writeJSON(w, http.StatusOK, output)
 }
+{{- end}}
 
 {{end}}
 
@@ -18,6 +18,8 @@ type TypeInfo struct {
 	IsRequired  bool
 	IsEnum      bool
 	IsError     bool
+	IsUnion     bool
+	IsStream    bool   // union of the events of an event stream
 	ErrorType   string // "client" or "server"
 	HTTPStatus  int
 	EnumValues  []string
@@ -334,8 +336,10 @@ func (g *Generator) generateStringType(name string, shape *parser.SmithyShape) *
 // generateUnionType generates type info for a union
 func (g *Generator) generateUnionType(name string, shape *parser.SmithyShape, api *parser.SmithyAPI) *TypeInfo {
 	typeInfo := &TypeInfo{
-		Name:   name,
-		GoType: name,
+		Name:     name,
+		GoType:   name,
+		IsUnion:  true,
+		IsStream: shape.IsEventStream(),
 	}
 
 	// Process union members as optional fields
@@ -588,8 +592,10 @@ type Unit = struct{}
 {{range $name := .TypeNames}}
 {{$type := index $.Types $name}}
 {{if eq $type.GoType $type.Name}}
-// {{$type.Name}} represents the {{$type.Name}} structure
-type {{$type.Name}} struct {
+{{if $type.IsStream}}// {{$type.Name}} represents an event of the {{$type.Name}} event stream, only one member is set
+{{else if $type.IsUnion}}// {{$type.Name}} represents the {{$type.Name}} union, only one member is set
+{{else}}// {{$type.Name}} represents the {{$type.Name}} structure
+{{end}}type {{$type.Name}} struct {
 {{range $field := $type.Fields}}
 {{if $field.Comment}}	// {{$field.Comment}}
 {{end}}	{{$field.Name}} {{if $field.IsPointer}}*{{end}}{{$field.GoType}} ` + "`" + `json:"{{$field.JSONName}}{{if not $field.IsRequired}},omitempty{{end}}"` + "`" + `
@@ -611,6 +617,35 @@ func (e {{$type.Name}}) ErrorFault() string {
 	return "{{$type.ErrorType}}"
 }
 {{end}}
+{{if $type.IsUnion}}
+
+// MemberName returns the name of the member set in the union, or an empty string
+func (u *{{$type.Name}}) MemberName() string {
+	switch {
+	case u == nil:
+		return ""
+{{range $field := $type.Fields}}	case u.{{$field.Name}} != nil:
+		return "{{$field.JSONName}}"
+{{end}}	}
+	return ""
+}
+{{range $field := $type.Fields}}
+
+// Is{{$field.Name}} reports whether the {{$field.JSONName}} member of the union is set
+func (u *{{$type.Name}}) Is{{$field.Name}}() bool {
+	return u != nil && u.{{$field.Name}} != nil
+}
+
+// As{{$field.Name}} returns the {{$field.JSONName}} member of the union and whether it is set
+func (u *{{$type.Name}}) As{{$field.Name}}() ({{$field.GoType}}, bool) {
+	if !u.Is{{$field.Name}}() {
+		var zero {{$field.GoType}}
+		return zero, false
+	}
+	return *u.{{$field.Name}}, true
+}
+{{end}}
+{{end}}
 {{else}}
 // {{$type.Name}} represents the {{$type.Name}} type
 type {{$type.Name}} {{$type.GoType}}
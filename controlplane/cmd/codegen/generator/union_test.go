@@ -0,0 +1,122 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nandemo-ya/kecs/controlplane/cmd/codegen/parser"
+)
+
+const unionModel = `{
+	"smithy": "2.0",
+	"shapes": {
+		"com.example#Example": {
+			"type": "service",
+			"operations": [{"target": "com.example#Tail"}, {"target": "com.example#Reset"}]
+		},
+		"com.example#Tail": {
+			"type": "operation",
+			"input": {"target": "com.example#TailRequest"},
+			"output": {"target": "com.example#TailResponse"}
+		},
+		"com.example#Reset": {
+			"type": "operation",
+			"input": {"target": "smithy.api#Unit"},
+			"output": {"target": "smithy.api#Unit"}
+		},
+		"com.example#TailRequest": {
+			"type": "structure",
+			"members": {
+				"filter": {"target": "com.example#Filter"}
+			}
+		},
+		"com.example#TailResponse": {
+			"type": "structure",
+			"members": {
+				"events": {"target": "com.example#TailEvents"}
+			}
+		},
+		"com.example#Filter": {
+			"type": "union",
+			"members": {
+				"prefix": {"target": "com.example#Prefix"},
+				"tags": {"target": "com.example#Tags"}
+			}
+		},
+		"com.example#TailEvents": {
+			"type": "union",
+			"members": {
+				"update": {"target": "com.example#Update"}
+			},
+			"traits": {"smithy.api#streaming": {}}
+		},
+		"com.example#Update": {
+			"type": "structure",
+			"members": {
+				"message": {"target": "com.example#Prefix"}
+			}
+		},
+		"com.example#Prefix": {"type": "string"},
+		"com.example#Tags": {
+			"type": "list",
+			"member": {"target": "com.example#Prefix"}
+		}
+	}
+}`
+
+func TestGenerateUnionsAndEventStreams(t *testing.T) {
+	api, err := parser.ParseSmithy([]byte(unionModel))
+	if err != nil {
+		t.Fatalf("Failed to parse model: %v", err)
+	}
+
+	dir := t.TempDir()
+	gen := New("example", "generated", dir)
+	if err := gen.GenerateTypes(api); err != nil {
+		t.Fatalf("Failed to generate types: %v", err)
+	}
+	if err := gen.GenerateRouting(api); err != nil {
+		t.Fatalf("Failed to generate routing: %v", err)
+	}
+
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		return string(data)
+	}
+
+	types := read("types.go")
+	for _, expected := range []string{
+		"// Filter represents the Filter union, only one member is set",
+		"func (u *Filter) MemberName() string {",
+		"func (u *Filter) IsPrefix() bool {",
+		"func (u *Filter) AsTags() ([]string, bool) {",
+		"// TailEvents represents an event of the TailEvents event stream, only one member is set",
+		"func (u *TailEvents) AsUpdate() (Update, bool) {",
+	} {
+		if !strings.Contains(types, expected) {
+			t.Errorf("types.go does not contain %q:\n%s", expected, types)
+		}
+	}
+
+	routing := read("routing.go")
+	for _, expected := range []string{
+		`if output != nil && output.Events.MemberName() != "" {`,
+		`"Tail returns Events as an event stream, which is not supported"`,
+	} {
+		if !strings.Contains(routing, expected) {
+			t.Errorf("routing.go does not contain %q:\n%s", expected, routing)
+		}
+	}
+
+	// Unit inputs have no Validate method
+	handler := routing[strings.Index(routing, "func (r *Router) handleReset"):]
+	handler = handler[:strings.Index(handler, "\n}\n")]
+	if strings.Contains(handler, "Validate") {
+		t.Errorf("handleReset validates a Unit input:\n%s", handler)
+	}
+}
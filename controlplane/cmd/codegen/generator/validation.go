@@ -0,0 +1,489 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nandemo-ya/kecs/controlplane/cmd/codegen/parser"
+)
+
+// Smithy constraint traits enforced by the generated Validate methods
+const (
+	lengthTrait  = "smithy.api#length"
+	patternTrait = "smithy.api#pattern"
+	rangeTrait   = "smithy.api#range"
+)
+
+// validationGenerator generates the validate methods of the structures
+// reachable from the operation inputs
+type validationGenerator struct {
+	g   *Generator
+	api *parser.SmithyAPI
+	// checked holds the shapes that have constraints, directly or in their members
+	checked map[string]bool
+	// patterns maps regular expressions to the variables compiling them
+	patterns     map[string]string
+	patternOrder []string
+	// structs holds the generated validate methods by type name
+	structs map[string]string
+	pending []string
+	// usesLength selects the import of unicode/utf8
+	usesLength bool
+}
+
+// generateValidationFile generates the validation.go file, which holds a
+// Validate method per operation input that enforces the length, pattern,
+// range and enum constraints of the model. Required members are not enforced
+// as the handlers apply defaults and report missing parameters themselves.
+func (g *Generator) generateValidationFile(api *parser.SmithyAPI) error {
+	serviceShape, _, err := api.GetServiceShape()
+	if err != nil {
+		return fmt.Errorf("failed to get service shape: %w", err)
+	}
+
+	vg := &validationGenerator{
+		g:        g,
+		api:      api,
+		checked:  make(map[string]bool),
+		patterns: make(map[string]string),
+		structs:  make(map[string]string),
+	}
+	vg.collectChecked()
+
+	var inputs []string
+	for _, opRef := range serviceShape.Operations {
+		opShape, exists := api.Shapes[opRef.Target]
+		if !exists || opShape.Type != "operation" || opShape.Input == nil {
+			continue
+		}
+		// Operations without input decode into Unit, which has no methods
+		if shape, _ := api.ResolveShape(opShape.Input.Target); shape == nil || shape.Type != "structure" {
+			continue
+		}
+		inputType := parser.GetShapeName(opShape.Input.Target)
+		inputs = append(inputs, inputType)
+		if vg.checked[opShape.Input.Target] {
+			vg.require(inputType, opShape.Input.Target)
+		}
+	}
+	sort.Strings(inputs)
+
+	for len(vg.pending) > 0 {
+		target := vg.pending[0]
+		vg.pending = vg.pending[1:]
+		vg.generateStruct(target)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by cmd/codegen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", g.packageName)
+	buf.WriteString("import (\n\t\"fmt\"\n")
+	if len(vg.patternOrder) > 0 {
+		buf.WriteString("\t\"regexp\"\n")
+	}
+	buf.WriteString("\t\"strings\"\n")
+	if vg.usesLength {
+		buf.WriteString("\t\"unicode/utf8\"\n")
+	}
+	buf.WriteString(")\n\n")
+	buf.WriteString(validationRuntime)
+
+	if len(vg.patternOrder) > 0 {
+		buf.WriteString("\n// Patterns of the model, except those RE2 does not support\nvar (\n")
+		for _, pattern := range vg.patternOrder {
+			fmt.Fprintf(&buf, "\t%s = regexp.MustCompile(%s)\n", vg.patterns[pattern], strconv.Quote(pattern))
+		}
+		buf.WriteString(")\n")
+	}
+
+	for _, input := range inputs {
+		fmt.Fprintf(&buf, "\n// Validate checks the constraints of the %s model on a %s\n", g.service, input)
+		fmt.Fprintf(&buf, "func (s *%s) Validate() error {\n", input)
+		if _, ok := vg.structs[input]; ok {
+			buf.WriteString("\tv := &validator{}\n\ts.validate(v, \"\")\n\treturn v.err()\n}\n")
+		} else {
+			buf.WriteString("\treturn nil\n}\n")
+		}
+	}
+
+	var names []string
+	for name := range vg.structs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		buf.WriteString("\n")
+		buf.WriteString(vg.structs[name])
+	}
+
+	return g.writeFormattedFile("validation.go", buf.Bytes())
+}
+
+// collectChecked finds the shapes that have constraints, following members
+// until nothing changes so that recursive structures are handled
+func (vg *validationGenerator) collectChecked() {
+	var names []string
+	for name := range vg.api.Shapes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for changed := true; changed; {
+		changed = false
+		for _, name := range names {
+			if !vg.checked[name] && vg.hasChecks(vg.api.Shapes[name]) {
+				vg.checked[name] = true
+				changed = true
+			}
+		}
+	}
+}
+
+// hasChecks reports whether a shape has constraints, given the shapes known to have some
+func (vg *validationGenerator) hasChecks(shape *parser.SmithyShape) bool {
+	if isEnumShape(shape) || hasConstraint(shape.Traits) {
+		return true
+	}
+	switch shape.Type {
+	case "structure", "union":
+		for _, member := range shape.Members {
+			if vg.memberHasChecks(member) {
+				return true
+			}
+		}
+	case "list", "set":
+		return shape.Member != nil && vg.memberHasChecks(shape.Member)
+	case "map":
+		return (shape.Key != nil && vg.memberHasChecks(shape.Key)) || (shape.Value != nil && vg.memberHasChecks(shape.Value))
+	}
+	return false
+}
+
+func (vg *validationGenerator) memberHasChecks(member *parser.SmithyMember) bool {
+	if hasConstraint(member.Traits) {
+		return true
+	}
+	_, name := vg.api.ResolveShape(member.Target)
+	return vg.checked[name]
+}
+
+// require queues the generation of the validate method of a structure
+func (vg *validationGenerator) require(typeName, target string) {
+	if _, ok := vg.structs[typeName]; ok {
+		return
+	}
+	vg.structs[typeName] = ""
+	vg.pending = append(vg.pending, target)
+}
+
+// generateStruct generates the validate method of a structure or union
+func (vg *validationGenerator) generateStruct(target string) {
+	shape, name := vg.api.ResolveShape(target)
+	typeName := parser.GetShapeName(name)
+
+	var fieldNames []string
+	for fieldName := range shape.Members {
+		fieldNames = append(fieldNames, fieldName)
+	}
+	sort.Strings(fieldNames)
+
+	var body bytes.Buffer
+	for _, fieldName := range fieldNames {
+		member := shape.Members[fieldName]
+		if !vg.memberHasChecks(member) {
+			continue
+		}
+		field := vg.g.generateField(fieldName, member, vg.api)
+		if field.GoType == "interface{}" {
+			continue
+		}
+		if shape.Type == "union" {
+			field.IsPointer = true
+		}
+		path := fmt.Sprintf("validationMember(path, %q)", field.JSONName)
+		vg.value(&body, "s."+field.Name, field.IsPointer, !field.IsPointer, member.Target, member.Traits, path, 0)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "func (s *%s) validate(v *validator, path string) {\n", typeName)
+	buf.Write(body.Bytes())
+	buf.WriteString("}\n")
+	vg.structs[typeName] = buf.String()
+}
+
+// value generates the checks of a value of the target shape. Values that are
+// not pointers are skipped when zero if zeroAbsent is set, as required members
+// the client left out decode to zero values.
+func (vg *validationGenerator) value(buf *bytes.Buffer, expr string, pointer, zeroAbsent bool, target string, memberTraits map[string]interface{}, path string, depth int) {
+	shape, name := vg.api.ResolveShape(target)
+	if shape == nil {
+		return
+	}
+	traits := constraintTraits(shape.Traits, memberTraits)
+
+	switch {
+	case shape.Type == "structure" || shape.Type == "union":
+		if !vg.checked[name] {
+			return
+		}
+		vg.require(parser.GetShapeName(name), name)
+		if pointer {
+			fmt.Fprintf(buf, "if %s != nil {\n%s.validate(v, %s)\n}\n", expr, expr, path)
+		} else {
+			fmt.Fprintf(buf, "%s.validate(v, %s)\n", expr, path)
+		}
+	case shape.Type == "list" || shape.Type == "set" || shape.Type == "map":
+		// Only collections that were sent are checked for their length
+		var lengthChecks, checks bytes.Buffer
+		vg.lengthChecks(&lengthChecks, fmt.Sprintf("len(%s)", expr), "", traits, path)
+		if lengthChecks.Len() > 0 {
+			fmt.Fprintf(&checks, "if %s != nil {\n%s}\n", expr, lengthChecks.String())
+		}
+		item := fmt.Sprintf("item%d", depth)
+		index := fmt.Sprintf("i%d", depth)
+		if shape.Type == "map" {
+			index = fmt.Sprintf("key%d", depth)
+			itemPath := fmt.Sprintf("validationKey(%s, string(%s))", path, index)
+			var keyChecks, itemChecks bytes.Buffer
+			if shape.Key != nil {
+				vg.value(&keyChecks, index, false, false, shape.Key.Target, shape.Key.Traits, itemPath, depth+1)
+			}
+			if shape.Value != nil {
+				vg.value(&itemChecks, item, false, false, shape.Value.Target, shape.Value.Traits, itemPath, depth+1)
+			}
+			switch {
+			case itemChecks.Len() > 0:
+				fmt.Fprintf(&checks, "for %s, %s := range %s {\n%s%s}\n", index, item, expr, keyChecks.String(), itemChecks.String())
+			case keyChecks.Len() > 0:
+				fmt.Fprintf(&checks, "for %s := range %s {\n%s}\n", index, expr, keyChecks.String())
+			}
+		} else if shape.Member != nil {
+			var itemChecks bytes.Buffer
+			vg.value(&itemChecks, item, false, false, shape.Member.Target, shape.Member.Traits,
+				fmt.Sprintf("validationItem(%s, %s)", path, index), depth+1)
+			if itemChecks.Len() > 0 {
+				fmt.Fprintf(&checks, "for %s, %s := range %s {\n%s}\n", index, item, expr, itemChecks.String())
+			}
+		}
+		buf.Write(checks.Bytes())
+	default:
+		local := fmt.Sprintf("value%d", depth)
+		var checks bytes.Buffer
+		zero := vg.scalarChecks(&checks, local, shape, traits, path)
+		if checks.Len() == 0 {
+			return
+		}
+		switch {
+		case pointer:
+			fmt.Fprintf(buf, "if %s != nil {\n%s := *%s\n%s}\n", expr, local, expr, checks.String())
+		case zeroAbsent && zero != "":
+			fmt.Fprintf(buf, "if %s := %s; %s != %s {\n%s}\n", local, expr, local, zero, checks.String())
+		default:
+			fmt.Fprintf(buf, "{\n%s := %s\n%s}\n", local, expr, checks.String())
+		}
+	}
+}
+
+// scalarChecks generates the checks of a string, enum, blob or number held
+// by local, and returns the zero value of its type
+func (vg *validationGenerator) scalarChecks(buf *bytes.Buffer, local string, shape *parser.SmithyShape, traits map[string]interface{}, path string) string {
+	switch shape.Type {
+	case "string", "enum":
+		str := fmt.Sprintf("string(%s)", local)
+		if isEnumShape(shape) {
+			values := shape.GetEnumValues()
+			sort.Strings(values)
+			quoted := make([]string, len(values))
+			for i, value := range values {
+				quoted[i] = strconv.Quote(value)
+			}
+			fmt.Fprintf(buf, "switch %s {\ncase %s:\ndefault:\nv.invalid(%s, %s, %q)\n}\n", str, strings.Join(quoted, ", "),
+				local, path, "Member must satisfy enum value set: ["+strings.Join(values, ", ")+"]")
+		}
+		vg.lengthChecks(buf, fmt.Sprintf("utf8.RuneCountInString(%s)", str), local, traits, path)
+		if pattern, ok := traits[patternTrait].(string); ok {
+			if _, err := regexp.Compile(pattern); err == nil {
+				fmt.Fprintf(buf, "if !%s.MatchString(%s) {\nv.invalid(%s, %s, %q)\n}\n", vg.patternVar(pattern), str,
+					local, path, "Member must satisfy regular expression pattern: "+pattern)
+			}
+		}
+		return `""`
+	case "blob":
+		vg.lengthChecks(buf, fmt.Sprintf("len(%s)", local), "", traits, path)
+		return ""
+	case "byte", "short", "integer", "long", "float", "double":
+		vg.rangeChecks(buf, local, shape.Type, traits, path)
+		return "0"
+	}
+	return ""
+}
+
+// lengthChecks generates the checks of the length trait. The value is only
+// reported for strings, collections are reported by path.
+func (vg *validationGenerator) lengthChecks(buf *bytes.Buffer, length, local string, traits map[string]interface{}, path string) {
+	bounds, ok := traits[lengthTrait].(map[string]interface{})
+	if !ok {
+		return
+	}
+	if strings.HasPrefix(length, "utf8.") {
+		vg.usesLength = true
+	}
+	report := func(constraint string) string {
+		if local == "" {
+			return fmt.Sprintf("v.invalidCollection(%s, %q)", path, constraint)
+		}
+		return fmt.Sprintf("v.invalid(%s, %s, %q)", local, path, constraint)
+	}
+	if min, ok := bounds["min"].(float64); ok && min > 0 {
+		fmt.Fprintf(buf, "if %s < %d {\n%s\n}\n", length, int64(min),
+			report(fmt.Sprintf("Member must have length greater than or equal to %d", int64(min))))
+	}
+	if max, ok := bounds["max"].(float64); ok {
+		fmt.Fprintf(buf, "if %s > %d {\n%s\n}\n", length, int64(max),
+			report(fmt.Sprintf("Member must have length less than or equal to %d", int64(max))))
+	}
+}
+
+// rangeChecks generates the checks of the range trait. Bounds the Go type
+// cannot exceed are left out, integers are compared to whole bounds.
+func (vg *validationGenerator) rangeChecks(buf *bytes.Buffer, local, shapeType string, traits map[string]interface{}, path string) {
+	bounds, ok := traits[rangeTrait].(map[string]interface{})
+	if !ok {
+		return
+	}
+	lowest, highest := numericLimits(shapeType)
+	integer := shapeType != "float" && shapeType != "double"
+	if min, ok := bounds["min"].(float64); ok {
+		if integer {
+			min = math.Ceil(min)
+		}
+		if min > lowest {
+			bound := strconv.FormatFloat(min, 'f', -1, 64)
+			fmt.Fprintf(buf, "if %s < %s {\nv.invalid(%s, %s, %q)\n}\n", local, bound, local, path,
+				"Member must have value greater than or equal to "+bound)
+		}
+	}
+	if max, ok := bounds["max"].(float64); ok {
+		if integer {
+			max = math.Floor(max)
+		}
+		if max < highest {
+			bound := strconv.FormatFloat(max, 'f', -1, 64)
+			fmt.Fprintf(buf, "if %s > %s {\nv.invalid(%s, %s, %q)\n}\n", local, bound, local, path,
+				"Member must have value less than or equal to "+bound)
+		}
+	}
+}
+
+// patternVar returns the variable compiling a pattern
+func (vg *validationGenerator) patternVar(pattern string) string {
+	if name, ok := vg.patterns[pattern]; ok {
+		return name
+	}
+	name := fmt.Sprintf("validationPattern%d", len(vg.patternOrder))
+	vg.patterns[pattern] = name
+	vg.patternOrder = append(vg.patternOrder, pattern)
+	return name
+}
+
+// numericLimits returns the range of the Go type of a number shape
+func numericLimits(shapeType string) (float64, float64) {
+	switch shapeType {
+	case "byte":
+		return math.MinInt8, math.MaxInt8
+	case "short":
+		return math.MinInt16, math.MaxInt16
+	case "integer":
+		return math.MinInt32, math.MaxInt32
+	case "long":
+		return math.MinInt64, math.MaxInt64
+	case "float":
+		return -math.MaxFloat32, math.MaxFloat32
+	default:
+		return -math.MaxFloat64, math.MaxFloat64
+	}
+}
+
+// isEnumShape reports whether a shape is an enum. IsEnum only sees enums
+// declared with the Smithy 1.0 enum trait.
+func isEnumShape(shape *parser.SmithyShape) bool {
+	return shape.Type == "enum" || shape.IsEnum()
+}
+
+func hasConstraint(traits map[string]interface{}) bool {
+	for _, trait := range []string{lengthTrait, patternTrait, rangeTrait} {
+		if _, ok := traits[trait]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// constraintTraits merges the constraints of a shape with those of the
+// member targeting it, the member taking precedence
+func constraintTraits(shapeTraits, memberTraits map[string]interface{}) map[string]interface{} {
+	traits := make(map[string]interface{})
+	for _, source := range []map[string]interface{}{shapeTraits, memberTraits} {
+		for _, trait := range []string{lengthTrait, patternTrait, rangeTrait} {
+			if value, ok := source[trait]; ok {
+				traits[trait] = value
+			}
+		}
+	}
+	return traits
+}
+
+const validationRuntime = `// ValidationError is returned by Validate when a request violates the
+// constraints of the API model. Its message follows the AWS format.
+type ValidationError struct {
+	Violations []string
+}
+
+func (e *ValidationError) Error() string {
+	noun := "error"
+	if len(e.Violations) > 1 {
+		noun = "errors"
+	}
+	return fmt.Sprintf("%d validation %s detected: %s", len(e.Violations), noun, strings.Join(e.Violations, "; "))
+}
+
+// validator collects the violations of a request
+type validator struct {
+	violations []string
+}
+
+func (v *validator) invalid(value interface{}, path, constraint string) {
+	v.violations = append(v.violations, fmt.Sprintf("Value '%v' at '%s' failed to satisfy constraint: %s", value, path, constraint))
+}
+
+func (v *validator) invalidCollection(path, constraint string) {
+	v.violations = append(v.violations, fmt.Sprintf("Value at '%s' failed to satisfy constraint: %s", path, constraint))
+}
+
+func (v *validator) err() error {
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return &ValidationError{Violations: v.violations}
+}
+
+func validationMember(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func validationItem(path string, index int) string {
+	return fmt.Sprintf("%s.%d.member", path, index+1)
+}
+
+func validationKey(path, key string) string {
+	return path + "." + key
+}
+`
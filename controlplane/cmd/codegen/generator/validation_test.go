@@ -0,0 +1,115 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nandemo-ya/kecs/controlplane/cmd/codegen/parser"
+)
+
+const validationModel = `{
+	"smithy": "2.0",
+	"shapes": {
+		"com.example#Example": {
+			"type": "service",
+			"operations": [{"target": "com.example#CreateThing"}, {"target": "com.example#Ping"}]
+		},
+		"com.example#CreateThing": {
+			"type": "operation",
+			"input": {"target": "com.example#CreateThingRequest"},
+			"output": {"target": "smithy.api#Unit"}
+		},
+		"com.example#Ping": {
+			"type": "operation",
+			"input": {"target": "com.example#PingRequest"},
+			"output": {"target": "smithy.api#Unit"}
+		},
+		"com.example#CreateThingRequest": {
+			"type": "structure",
+			"members": {
+				"name": {"target": "com.example#ThingName", "traits": {"smithy.api#required": {}}},
+				"count": {"target": "com.example#Count"},
+				"mode": {"target": "com.example#Mode"},
+				"tags": {"target": "com.example#Tags"},
+				"note": {"target": "com.example#Note", "traits": {"smithy.api#pattern": "^(?!x)"}}
+			}
+		},
+		"com.example#PingRequest": {
+			"type": "structure",
+			"members": {
+				"message": {"target": "com.example#Note"}
+			}
+		},
+		"com.example#ThingName": {
+			"type": "string",
+			"traits": {"smithy.api#length": {"min": 1, "max": 32}, "smithy.api#pattern": "^[a-z]+$"}
+		},
+		"com.example#Note": {"type": "string"},
+		"com.example#Count": {
+			"type": "integer",
+			"traits": {"smithy.api#range": {"min": 1, "max": 10000000000}}
+		},
+		"com.example#Mode": {
+			"type": "enum",
+			"members": {
+				"FAST": {"target": "smithy.api#Unit", "traits": {"smithy.api#enumValue": "FAST"}},
+				"SLOW": {"target": "smithy.api#Unit", "traits": {"smithy.api#enumValue": "SLOW"}}
+			}
+		},
+		"com.example#Tags": {
+			"type": "list",
+			"member": {"target": "com.example#Tag"},
+			"traits": {"smithy.api#length": {"max": 5}}
+		},
+		"com.example#Tag": {
+			"type": "structure",
+			"members": {
+				"key": {"target": "com.example#ThingName"}
+			}
+		}
+	}
+}`
+
+func TestGenerateValidation(t *testing.T) {
+	api, err := parser.ParseSmithy([]byte(validationModel))
+	if err != nil {
+		t.Fatalf("Failed to parse model: %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := New("example", "generated", dir).generateValidationFile(api); err != nil {
+		t.Fatalf("Failed to generate validation: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "validation.go"))
+	if err != nil {
+		t.Fatalf("Failed to read validation.go: %v", err)
+	}
+	code := string(data)
+
+	for _, expected := range []string{
+		"func (s *CreateThingRequest) Validate() error {",
+		// Required members that were not sent are left to the handlers
+		`if value0 := s.Name; value0 != "" {`,
+		`"Member must have length less than or equal to 32"`,
+		`validationPattern0 = regexp.MustCompile("^[a-z]+$")`,
+		`"Member must have value greater than or equal to 1"`,
+		`case "FAST", "SLOW":`,
+		`v.invalidCollection(validationMember(path, "tags"), "Member must have length less than or equal to 5")`,
+		`item0.validate(v, validationItem(validationMember(path, "tags"), i0))`,
+		"func (s *Tag) validate(v *validator, path string) {",
+		"func (s *PingRequest) Validate() error {\n\treturn nil\n}",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("validation.go does not contain %q:\n%s", expected, code)
+		}
+	}
+
+	// Bounds beyond the Go type and patterns RE2 cannot compile are left out
+	for _, unexpected := range []string{"10000000000", "(?!x)"} {
+		if strings.Contains(code, unexpected) {
+			t.Errorf("validation.go contains %q", unexpected)
+		}
+	}
+}
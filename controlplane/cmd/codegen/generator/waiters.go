@@ -0,0 +1,328 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/nandemo-ya/kecs/controlplane/cmd/codegen/parser"
+)
+
+// PaginatorInfo holds the tokens of a paginated operation
+type PaginatorInfo struct {
+	Operation     *OperationInfo
+	InputToken    string
+	InputPointer  bool
+	OutputToken   string
+	OutputPointer bool
+	// PageSize is the JSON name of the member limiting the page size
+	PageSize string
+}
+
+// WaiterInfo holds a waiter of an operation
+type WaiterInfo struct {
+	Name      string
+	Operation *OperationInfo
+	MinDelay  int
+	MaxDelay  int
+	Acceptors []AcceptorInfo
+}
+
+// AcceptorInfo holds an acceptor of a waiter as Go expressions
+type AcceptorInfo struct {
+	State      string
+	Matcher    string
+	Path       string
+	Expected   string
+	Comparator string
+}
+
+// GenerateClientHelpers generates the paginators and waiters of the API
+// definition, which call the operations through interfaces that API clients
+// satisfy
+func (g *Generator) GenerateClientHelpers(api *parser.SmithyAPI) error {
+	serviceShape, _, err := api.GetServiceShape()
+	if err != nil {
+		return fmt.Errorf("failed to get service shape: %w", err)
+	}
+	operations := g.collectOperations(api, serviceShape)
+
+	var paginators []*PaginatorInfo
+	var waiters []*WaiterInfo
+	// clients holds the operations that need an API client interface
+	clients := make(map[string]bool)
+	for _, opRef := range serviceShape.Operations {
+		opShape, exists := api.Shapes[opRef.Target]
+		op := operations[parser.GetShapeName(opRef.Target)]
+		if !exists || op == nil || opShape.Traits == nil {
+			continue
+		}
+		if paginator := g.paginatorInfo(api, serviceShape, opShape, op); paginator != nil {
+			paginators = append(paginators, paginator)
+			clients[op.Name] = true
+		}
+		if waitable, ok := opShape.Traits["smithy.waiters#waitable"].(map[string]interface{}); ok {
+			for name, definition := range waitable {
+				if waiter := waiterInfo(name, definition, op); waiter != nil {
+					waiters = append(waiters, waiter)
+				}
+			}
+		}
+	}
+	sort.Slice(paginators, func(i, j int) bool { return paginators[i].Operation.Name < paginators[j].Operation.Name })
+	sort.Slice(waiters, func(i, j int) bool { return waiters[i].Name < waiters[j].Name })
+
+	if len(paginators) > 0 {
+		content, err := g.executeTemplate(template.Must(template.New("paginators").Parse(paginatorsTemplate)), struct {
+			Package    string
+			Paginators []*PaginatorInfo
+		}{g.packageName, paginators})
+		if err != nil {
+			return err
+		}
+		if err := g.writeFormattedFile("paginators.go", content); err != nil {
+			return err
+		}
+	}
+
+	if len(waiters) > 0 {
+		// Paginated operations have their client interface in paginators.go
+		var waiterClients []*OperationInfo
+		for _, waiter := range waiters {
+			if !clients[waiter.Operation.Name] {
+				clients[waiter.Operation.Name] = true
+				waiterClients = append(waiterClients, waiter.Operation)
+			}
+		}
+		content, err := g.executeTemplate(template.Must(template.New("waiters").Parse(waitersTemplate)), struct {
+			Package string
+			Clients []*OperationInfo
+			Waiters []*WaiterInfo
+		}{g.packageName, waiterClients, waiters})
+		if err != nil {
+			return err
+		}
+		if err := g.writeFormattedFile("waiters.go", content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// paginatorInfo returns the paginator of an operation, merging the paginated
+// trait of the operation with that of the service. Only top-level string
+// tokens are supported.
+func (g *Generator) paginatorInfo(api *parser.SmithyAPI, serviceShape, opShape *parser.SmithyShape, op *OperationInfo) *PaginatorInfo {
+	trait, ok := opShape.Traits["smithy.api#paginated"].(map[string]interface{})
+	if !ok || opShape.Input == nil || opShape.Output == nil {
+		return nil
+	}
+	settings := make(map[string]string)
+	if serviceTrait, ok := serviceShape.Traits["smithy.api#paginated"].(map[string]interface{}); ok {
+		for key, value := range serviceTrait {
+			if s, ok := value.(string); ok {
+				settings[key] = s
+			}
+		}
+	}
+	for key, value := range trait {
+		if s, ok := value.(string); ok {
+			settings[key] = s
+		}
+	}
+
+	inputShape, _ := api.ResolveShape(opShape.Input.Target)
+	outputShape, _ := api.ResolveShape(opShape.Output.Target)
+	if inputShape == nil || outputShape == nil {
+		return nil
+	}
+	inputMember, ok := inputShape.Members[settings["inputToken"]]
+	if !ok {
+		return nil
+	}
+	outputMember, ok := outputShape.Members[settings["outputToken"]]
+	if !ok {
+		return nil
+	}
+	inputField := g.generateField(settings["inputToken"], inputMember, api)
+	outputField := g.generateField(settings["outputToken"], outputMember, api)
+	if inputField.GoType != "string" || outputField.GoType != "string" {
+		return nil
+	}
+	return &PaginatorInfo{
+		Operation:     op,
+		InputToken:    inputField.Name,
+		InputPointer:  inputField.IsPointer,
+		OutputToken:   outputField.Name,
+		OutputPointer: outputField.IsPointer,
+		PageSize:      settings["pageSize"],
+	}
+}
+
+// waiterInfo converts a waiter of the smithy.waiters#waitable trait
+func waiterInfo(name string, definition interface{}, op *OperationInfo) *WaiterInfo {
+	fields, ok := definition.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	waiter := &WaiterInfo{Name: name, Operation: op, MinDelay: 2, MaxDelay: 120}
+	if minDelay, ok := fields["minDelay"].(float64); ok {
+		waiter.MinDelay = int(minDelay)
+	}
+	if maxDelay, ok := fields["maxDelay"].(float64); ok {
+		waiter.MaxDelay = int(maxDelay)
+	}
+
+	acceptors, _ := fields["acceptors"].([]interface{})
+	for _, raw := range acceptors {
+		acceptor, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		matcher, _ := acceptor["matcher"].(map[string]interface{})
+		state, _ := acceptor["state"].(string)
+		info := AcceptorInfo{State: goConstant(state)}
+		switch {
+		case matcher["output"] != nil || matcher["inputOutput"] != nil:
+			kind := "output"
+			if matcher["inputOutput"] != nil {
+				kind = "inputOutput"
+			}
+			match, _ := matcher[kind].(map[string]interface{})
+			path, _ := match["path"].(string)
+			expected, _ := match["expected"].(string)
+			comparator, _ := match["comparator"].(string)
+			info.Matcher = map[string]string{"output": "Output", "inputOutput": "InputOutput"}[kind]
+			info.Path = strconv.Quote(path)
+			info.Expected = strconv.Quote(expected)
+			info.Comparator = "waiter." + goConstant(comparator)
+		case matcher["success"] != nil:
+			success, _ := matcher["success"].(bool)
+			info.Matcher = "Succeeded"
+			info.Expected = strconv.Quote(strconv.FormatBool(success))
+		case matcher["errorType"] != nil:
+			errorType, _ := matcher["errorType"].(string)
+			info.Matcher = "ErrorType"
+			info.Expected = strconv.Quote(errorType)
+		default:
+			// A matcher this generator does not know would change the outcome
+			return nil
+		}
+		waiter.Acceptors = append(waiter.Acceptors, info)
+	}
+	if len(waiter.Acceptors) == 0 {
+		return nil
+	}
+	return waiter
+}
+
+// goConstant converts a camel case value of the model to the name of its
+// constant in the waiter package, e.g. anyStringEquals to AnyStringEquals
+func goConstant(value string) string {
+	if value == "" {
+		return value
+	}
+	return strings.ToUpper(value[:1]) + value[1:]
+}
+
+const paginatorsTemplate = `// Code generated by cmd/codegen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	"iter"
+)
+
+{{range $p := .Paginators}}
+{{$op := $p.Operation}}
+// {{$op.Name}}APIClient is a client that can call {{$op.Name}}
+type {{$op.Name}}APIClient interface {
+	{{$op.Name}}(ctx context.Context, input *{{$op.InputType}}) (*{{$op.OutputType}}, error)
+}
+
+// Paginate{{$op.Name}} iterates over the pages of {{$op.Name}},
+// passing the {{$p.OutputToken}} of a page to the call of the next one.{{if $p.PageSize}}
+// The page size is set by the {{$p.PageSize}} member of the input.{{end}}
+// Iteration stops after an error.
+func Paginate{{$op.Name}}(ctx context.Context, client {{$op.Name}}APIClient, input *{{$op.InputType}}) iter.Seq2[*{{$op.OutputType}}, error] {
+	return func(yield func(*{{$op.OutputType}}, error) bool) {
+		var params {{$op.InputType}}
+		if input != nil {
+			params = *input
+		}
+		for {
+			output, err := client.{{$op.Name}}(ctx, &params)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(output, nil) {
+				return
+			}
+{{- if $p.OutputPointer}}
+			next := ""
+			if output.{{$p.OutputToken}} != nil {
+				next = *output.{{$p.OutputToken}}
+			}
+{{- else}}
+			next := output.{{$p.OutputToken}}
+{{- end}}
+			// Stop on a repeated token as the AWS SDKs do
+{{- if $p.InputPointer}}
+			if next == "" || (params.{{$p.InputToken}} != nil && next == *params.{{$p.InputToken}}) {
+				return
+			}
+			params.{{$p.InputToken}} = &next
+{{- else}}
+			if next == "" || next == params.{{$p.InputToken}} {
+				return
+			}
+			params.{{$p.InputToken}} = next
+{{- end}}
+		}
+	}
+}
+{{end}}
+`
+
+const waitersTemplate = `// Code generated by cmd/codegen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsclient/waiter"
+)
+
+{{range $op := .Clients}}
+// {{$op.Name}}APIClient is a client that can call {{$op.Name}}
+type {{$op.Name}}APIClient interface {
+	{{$op.Name}}(ctx context.Context, input *{{$op.InputType}}) (*{{$op.OutputType}}, error)
+}
+{{end}}
+
+{{range $w := .Waiters}}
+{{$op := $w.Operation}}
+// WaitUntil{{$w.Name}} calls {{$op.Name}} until the {{$w.Name}} waiter
+// of the model succeeds or fails, or maxWait elapses. The calls are
+// {{$w.MinDelay}} to {{$w.MaxDelay}} seconds apart unless optFns change the delays.
+func WaitUntil{{$w.Name}}(ctx context.Context, client {{$op.Name}}APIClient, input *{{$op.InputType}}, maxWait time.Duration, optFns ...func(*waiter.Options)) error {
+	opts := waiter.Options{MinDelay: {{$w.MinDelay}} * time.Second, MaxDelay: {{$w.MaxDelay}} * time.Second}
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+	return waiter.Wait(ctx, "{{$w.Name}}", maxWait, opts, input, func(ctx context.Context) (interface{}, error) {
+		return client.{{$op.Name}}(ctx, input)
+	}, waiter{{$w.Name}}Acceptors)
+}
+
+var waiter{{$w.Name}}Acceptors = []waiter.Acceptor{
+{{range $a := $w.Acceptors}}	{State: waiter.{{$a.State}}, Matcher: waiter.{{$a.Matcher}}{{if $a.Path}}, Path: {{$a.Path}}{{end}}, Expected: {{$a.Expected}}{{if $a.Comparator}}, Comparator: {{$a.Comparator}}{{end}}},
+{{end}}}
+{{end}}
+`
@@ -14,20 +14,30 @@ import (
 var (
 	service    = flag.String("service", "ecs", "AWS service name")
 	input      = flag.String("input", "", "Path to AWS API definition JSON file")
+	overlay    = flag.String("overlay", "", "Path to the JSON file of the KECS extensions of the API")
 	output     = flag.String("output", "", "Output directory for generated code")
 	pkgName    = flag.String("package", "generated", "Go package name for generated code")
 	genTypes   = flag.Bool("types", true, "Generate type definitions")
 	genOps     = flag.Bool("operations", true, "Generate operation interfaces")
 	genRouting = flag.Bool("routing", true, "Generate HTTP routing")
 	genClient  = flag.Bool("client", false, "Generate HTTP client")
+	genHelpers = flag.Bool("client-helpers", false, "Generate paginators and waiters")
 )
 
 func main() {
 	flag.Parse()
 
 	if *input == "" {
-		// Default to api-models/<service>.json
-		*input = filepath.Join("api-models", fmt.Sprintf("%s.json", *service))
+		// Default to internal/awsmodels/<service>.json
+		*input = filepath.Join("internal", "awsmodels", fmt.Sprintf("%s.json", *service))
+	}
+
+	if *overlay == "" {
+		// Default to internal/awsmodels/overlays/<file of the input>, if any
+		defaultOverlay := filepath.Join("internal", "awsmodels", "overlays", filepath.Base(*input))
+		if _, err := os.Stat(defaultOverlay); err == nil {
+			*overlay = defaultOverlay
+		}
 	}
 
 	if *output == "" {
@@ -51,6 +61,16 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to parse API definition: %v", err)
 	}
+	if *overlay != "" {
+		log.Printf("Applying KECS extensions from %s", *overlay)
+		extensions, err := parser.ParseSmithyJSON(*overlay)
+		if err != nil {
+			log.Fatalf("Failed to parse overlay: %v", err)
+		}
+		if err := apiDef.ApplyOverlay(extensions); err != nil {
+			log.Fatalf("Failed to apply overlay: %v", err)
+		}
+	}
 
 	// Create generator
 	gen := generator.New(*service, *pkgName, *output)
@@ -84,5 +104,12 @@ func main() {
 		}
 	}
 
+	if *genHelpers {
+		log.Println("Generating paginators and waiters...")
+		if err := gen.GenerateClientHelpers(apiDef); err != nil {
+			log.Fatalf("Failed to generate paginators and waiters: %v", err)
+		}
+	}
+
 	log.Println("Code generation completed successfully")
 }
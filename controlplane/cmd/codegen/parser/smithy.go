@@ -56,7 +56,11 @@ func ParseSmithyJSON(filename string) (*SmithyAPI, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
+	return ParseSmithy(data)
+}
 
+// ParseSmithy parses a Smithy JSON model
+func ParseSmithy(data []byte) (*SmithyAPI, error) {
 	var api SmithyAPI
 	if err := json.Unmarshal(data, &api); err != nil {
 		return nil, fmt.Errorf("failed to parse JSON: %w", err)
@@ -65,6 +69,37 @@ func ParseSmithyJSON(filename string) (*SmithyAPI, error) {
 	return &api, nil
 }
 
+// ApplyOverlay merges the shapes of an overlay model, such as the KECS
+// extensions of an AWS API, into the API definition. Members are added to the
+// structures of the same name and other shapes are added as they are. A
+// member the API definition already has is an error, so an extension that AWS
+// adds to the API is noticed when the models are updated.
+func (api *SmithyAPI) ApplyOverlay(overlay *SmithyAPI) error {
+	if api.Shapes == nil {
+		api.Shapes = make(map[string]*SmithyShape)
+	}
+	for name, overlayShape := range overlay.Shapes {
+		shape, exists := api.Shapes[name]
+		if !exists {
+			api.Shapes[name] = overlayShape
+			continue
+		}
+		if shape.Type != overlayShape.Type || shape.Type != "structure" {
+			return fmt.Errorf("overlay shape %s must be a structure extending a structure", name)
+		}
+		if shape.Members == nil {
+			shape.Members = make(map[string]*SmithyMember)
+		}
+		for memberName, member := range overlayShape.Members {
+			if _, exists := shape.Members[memberName]; exists {
+				return fmt.Errorf("overlay member %s of %s is already defined", memberName, name)
+			}
+			shape.Members[memberName] = member
+		}
+	}
+	return nil
+}
+
 // GetServiceShape returns the service shape from the API definition
 func (api *SmithyAPI) GetServiceShape() (*SmithyShape, string, error) {
 	for name, shape := range api.Shapes {
@@ -277,6 +312,15 @@ func (s *SmithyShape) IsUnion() bool {
 	return s.Type == "union"
 }
 
+// IsEventStream checks if a shape is a union streamed as events
+func (s *SmithyShape) IsEventStream() bool {
+	if s.Type != "union" || s.Traits == nil {
+		return false
+	}
+	_, streaming := s.Traits["smithy.api#streaming"]
+	return streaming
+}
+
 // IsError checks if a shape is an error type
 func (s *SmithyShape) IsError() bool {
 	if s.Traits == nil {
@@ -80,3 +80,36 @@ func TestToCamelCase(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyOverlay(t *testing.T) {
+	api, err := ParseSmithy([]byte(`{"smithy":"2.0","shapes":{
+		"com.amazonaws.ecs#Container":{"type":"structure","members":{"name":{"target":"com.amazonaws.ecs#String"}}},
+		"com.amazonaws.ecs#String":{"type":"string"}
+	}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	overlay, err := ParseSmithy([]byte(`{"smithy":"2.0","shapes":{
+		"com.amazonaws.ecs#Container":{"type":"structure","members":{"restartCount":{"target":"com.amazonaws.ecs#BoxedInteger"}}},
+		"com.amazonaws.ecs#BoxedInteger":{"type":"integer"}
+	}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := api.ApplyOverlay(overlay); err != nil {
+		t.Fatalf("ApplyOverlay() error = %v", err)
+	}
+	members := api.Shapes["com.amazonaws.ecs#Container"].Members
+	if members["name"] == nil || members["restartCount"] == nil {
+		t.Errorf("Container members = %v, want name and restartCount", members)
+	}
+	if api.Shapes["com.amazonaws.ecs#BoxedInteger"] == nil {
+		t.Error("BoxedInteger shape was not added")
+	}
+
+	// Applying it again collides with the members it added
+	if err := api.ApplyOverlay(overlay); err == nil {
+		t.Error("ApplyOverlay() should fail for members that are already defined")
+	}
+}
@@ -0,0 +1,255 @@
+// Package coverage tracks which operations of the ECS model KECS implements
+// and which have a conformance scenario under tests/scenarios.
+package coverage
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	smithy "github.com/nandemo-ya/kecs/controlplane/cmd/codegen/parser"
+)
+
+// Status is the implementation status of an operation
+type Status string
+
+const (
+	// Implemented operations have a method that does the work
+	Implemented Status = "implemented"
+	// Stubbed operations have a method that returns a "not implemented"
+	// error or a mock response marked with a "TODO: Implement" comment
+	Stubbed Status = "stubbed"
+	// Missing operations have no method
+	Missing Status = "missing"
+)
+
+// Scenario is the state of the conformance scenario of an operation
+type Scenario string
+
+const (
+	// ScenarioNone means no scenario file exists
+	ScenarioNone Scenario = "none"
+	// ScenarioTODO means the scenario file still has tagged TODOs
+	ScenarioTODO Scenario = "todo"
+	// ScenarioWritten means the tagged TODOs of the scenario file are resolved
+	ScenarioWritten Scenario = "written"
+)
+
+// Operation is the coverage of an operation of the model
+type Operation struct {
+	Name     string
+	Status   Status
+	Scenario Scenario
+	// Location is the file and line of the method, if any
+	Location string
+}
+
+// Report is the coverage of the operations of a model
+type Report struct {
+	Service    string
+	Operations []Operation
+}
+
+// Operations returns the names of the operations of the model, sorted
+func Operations(api *smithy.SmithyAPI) []string {
+	var names []string
+	for target := range api.GetOperations() {
+		names = append(names, smithy.GetShapeName(target))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Analyze classifies the operations of the model by the methods the
+// receiver type has in the Go files of implDir, and looks up their
+// scenarios in scenarioDir. An empty scenarioDir skips the scenarios.
+func Analyze(api *smithy.SmithyAPI, service, implDir, receiver, scenarioDir string) (*Report, error) {
+	methods, err := scanMethods(implDir, receiver)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Service: service}
+	for _, name := range Operations(api) {
+		op := Operation{Name: name, Status: Missing, Scenario: ScenarioNone}
+		if m, ok := methods[name]; ok {
+			op.Status = m.status
+			op.Location = m.location
+		}
+		if scenarioDir != "" {
+			op.Scenario, err = scenarioState(scenarioDir, name)
+			if err != nil {
+				return nil, err
+			}
+		}
+		report.Operations = append(report.Operations, op)
+	}
+	return report, nil
+}
+
+// Count returns the number of operations with a status
+func (r *Report) Count(status Status) int {
+	n := 0
+	for _, op := range r.Operations {
+		if op.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// ScenarioCount returns the number of operations with a scenario state
+func (r *Report) ScenarioCount(scenario Scenario) int {
+	n := 0
+	for _, op := range r.Operations {
+		if op.Scenario == scenario {
+			n++
+		}
+	}
+	return n
+}
+
+// Percent returns n as a percentage of the operations
+func (r *Report) Percent(n int) float64 {
+	if len(r.Operations) == 0 {
+		return 0
+	}
+	return float64(n) * 100 / float64(len(r.Operations))
+}
+
+// Write writes the report as a table of the operations followed by a summary
+func (r *Report) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "OPERATION\tSTATUS\tSCENARIO\tLOCATION\n")
+	for _, op := range r.Operations {
+		location := op.Location
+		if location == "" {
+			location = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", op.Name, op.Status, op.Scenario, location)
+	}
+	fmt.Fprintf(tw, "\n%s operations: %d\n", strings.ToUpper(r.Service), len(r.Operations))
+	for _, status := range []Status{Implemented, Stubbed, Missing} {
+		n := r.Count(status)
+		fmt.Fprintf(tw, "%s\t%d\t%.1f%%\n", status, n, r.Percent(n))
+	}
+	n := r.ScenarioCount(ScenarioWritten)
+	fmt.Fprintf(tw, "scenarios written\t%d\t%.1f%%\n", n, r.Percent(n))
+	return tw.Flush()
+}
+
+// method is a method of the receiver implementing an operation
+type method struct {
+	status   Status
+	location string
+}
+
+// scanMethods returns the exported methods of the receiver type declared in
+// the non-test Go files of dir
+func scanMethods(dir, receiver string) (map[string]method, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	methods := make(map[string]method)
+	fset := token.NewFileSet()
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil || !fn.Name.IsExported() || receiverName(fn) != receiver {
+				continue
+			}
+			status := Implemented
+			if isStub(fn, file) {
+				status = Stubbed
+			}
+			pos := fset.Position(fn.Pos())
+			methods[fn.Name.Name] = method{
+				status:   status,
+				location: fmt.Sprintf("%s:%d", filepath.Base(pos.Filename), pos.Line),
+			}
+		}
+	}
+	return methods, nil
+}
+
+// receiverName returns the type name of the receiver of a method
+func receiverName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return ""
+	}
+	typ := fn.Recv.List[0].Type
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	if ident, ok := typ.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+// isStub reports whether a method returns a "not implemented" error or is
+// marked with a "TODO: Implement" comment, as the stubs of the API are
+func isStub(fn *ast.FuncDecl, file *ast.File) bool {
+	for _, group := range file.Comments {
+		if group.Pos() < fn.Body.Lbrace || group.End() > fn.Body.Rbrace {
+			continue
+		}
+		for _, comment := range group.List {
+			if strings.HasPrefix(strings.TrimSpace(strings.TrimPrefix(comment.Text, "//")), "TODO: Implement") {
+				return true
+			}
+		}
+	}
+
+	stub := false
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		ret, ok := n.(*ast.ReturnStmt)
+		if !ok || len(ret.Results) == 0 {
+			return !stub
+		}
+		call, ok := ret.Results[len(ret.Results)-1].(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		lit, ok := call.Args[0].(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+		if s, err := strconv.Unquote(lit.Value); err == nil && strings.Contains(s, "not implemented") {
+			stub = true
+		}
+		return !stub
+	})
+	return stub
+}
+
+// scenarioState returns the state of the scenario file of an operation
+func scenarioState(dir, operation string) (Scenario, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ScenarioFile(operation)))
+	if os.IsNotExist(err) {
+		return ScenarioNone, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if strings.Contains(string(data), TODOTag(operation)) {
+		return ScenarioTODO, nil
+	}
+	return ScenarioWritten, nil
+}
@@ -0,0 +1,162 @@
+package coverage
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	smithy "github.com/nandemo-ya/kecs/controlplane/cmd/codegen/parser"
+)
+
+const model = `{
+	"smithy": "2.0",
+	"shapes": {
+		"com.example#Example": {
+			"type": "service",
+			"operations": [
+				{"target": "com.example#CreateWidget"},
+				{"target": "com.example#DeleteWidget"},
+				{"target": "com.example#ListWidgets"},
+				{"target": "com.example#TagWidget"}
+			]
+		},
+		"com.example#CreateWidget": {
+			"type": "operation",
+			"input": {"target": "com.example#CreateWidgetRequest"},
+			"errors": [{"target": "com.example#LimitExceededException"}]
+		},
+		"com.example#DeleteWidget": {"type": "operation"},
+		"com.example#ListWidgets": {"type": "operation"},
+		"com.example#TagWidget": {"type": "operation"},
+		"com.example#CreateWidgetRequest": {
+			"type": "structure",
+			"members": {
+				"name": {"target": "smithy.api#String", "traits": {"smithy.api#required": {}}},
+				"size": {"target": "smithy.api#Integer"}
+			}
+		}
+	}
+}`
+
+const implementation = `package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+type API struct{}
+
+func (a *API) CreateWidget(ctx context.Context) error {
+	if ctx == nil {
+		return errors.New("context is required")
+	}
+	return nil
+}
+
+func (a *API) DeleteWidget(ctx context.Context) error {
+	return fmt.Errorf("DeleteWidget not implemented")
+}
+
+func (a *API) ListWidgets(ctx context.Context) ([]string, error) {
+	// TODO: Implement actual listing
+	// For now, return a mock response
+	return nil, nil
+}
+
+// TagWidget of another type does not count
+type Other struct{}
+
+func (o Other) TagWidget() {}
+`
+
+func TestAnalyze(t *testing.T) {
+	api, err := smithy.ParseSmithy([]byte(model))
+	if err != nil {
+		t.Fatalf("Failed to parse model: %v", err)
+	}
+
+	implDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(implDir, "api.go"), []byte(implementation), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	scenarioDir := t.TempDir()
+	written, err := GenerateSkeletons(api, scenarioDir, "conformance", false)
+	if err != nil {
+		t.Fatalf("Failed to generate skeletons: %v", err)
+	}
+	expectedFiles := []string{"conformance_suite_test.go", "create_widget_test.go", "delete_widget_test.go", "list_widgets_test.go", "tag_widget_test.go"}
+	if strings.Join(written, ",") != strings.Join(expectedFiles, ",") {
+		t.Errorf("Expected files %v, got %v", expectedFiles, written)
+	}
+
+	skeleton, err := os.ReadFile(filepath.Join(scenarioDir, "create_widget_test.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`Describe("CreateWidget", Label("conformance", "CreateWidget"), func() {`,
+		"// TODO(conformance:CreateWidget): call CreateWidget with name",
+		`It("returns LimitExceededException", func() {`,
+	} {
+		if !strings.Contains(string(skeleton), expected) {
+			t.Errorf("Skeleton does not contain %q:\n%s", expected, skeleton)
+		}
+	}
+
+	// A written scenario is kept on the next generation
+	scenario := []byte("package conformance\n")
+	if err := os.WriteFile(filepath.Join(scenarioDir, "create_widget_test.go"), scenario, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if written, err = GenerateSkeletons(api, scenarioDir, "conformance", false); err != nil || len(written) != 0 {
+		t.Errorf("Expected no files to be rewritten, got %v, %v", written, err)
+	}
+
+	report, err := Analyze(api, "example", implDir, "API", scenarioDir)
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+	expected := map[string]Operation{
+		"CreateWidget": {Name: "CreateWidget", Status: Implemented, Scenario: ScenarioWritten, Location: "api.go:11"},
+		"DeleteWidget": {Name: "DeleteWidget", Status: Stubbed, Scenario: ScenarioTODO, Location: "api.go:18"},
+		"ListWidgets":  {Name: "ListWidgets", Status: Stubbed, Scenario: ScenarioTODO, Location: "api.go:22"},
+		"TagWidget":    {Name: "TagWidget", Status: Missing, Scenario: ScenarioTODO},
+	}
+	if len(report.Operations) != len(expected) {
+		t.Fatalf("Expected %d operations, got %d", len(expected), len(report.Operations))
+	}
+	for _, op := range report.Operations {
+		if op != expected[op.Name] {
+			t.Errorf("Expected %+v, got %+v", expected[op.Name], op)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := report.Write(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"implemented        1  25.0%", "stubbed            2  50.0%", "missing            1  25.0%", "scenarios written  1  25.0%"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Report does not contain %q:\n%s", expected, out.String())
+		}
+	}
+}
+
+func TestScenarioFile(t *testing.T) {
+	for operation, expected := range map[string]string{
+		"CreateCluster":              "create_cluster_test.go",
+		"ListTagsForResource":        "list_tags_for_resource_test.go",
+		"PutAccountSettingDefault":   "put_account_setting_default_test.go",
+		"DescribeServiceDeployments": "describe_service_deployments_test.go",
+		"GetHTTPEndpoint":            "get_http_endpoint_test.go",
+	} {
+		if got := ScenarioFile(operation); got != expected {
+			t.Errorf("ScenarioFile(%q) = %q, expected %q", operation, got, expected)
+		}
+	}
+}
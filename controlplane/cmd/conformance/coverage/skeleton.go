@@ -0,0 +1,161 @@
+package coverage
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	smithy "github.com/nandemo-ya/kecs/controlplane/cmd/codegen/parser"
+)
+
+// SuiteFile is the Ginkgo suite of the conformance scenarios
+const SuiteFile = "conformance_suite_test.go"
+
+// ScenarioFile returns the file name of the scenario of an operation,
+// e.g. create_cluster_test.go for CreateCluster
+func ScenarioFile(operation string) string {
+	var b strings.Builder
+	runes := []rune(operation)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String() + "_test.go"
+}
+
+// TODOTag returns the tag of the TODOs of the scenario of an operation. The
+// report counts a scenario as written once its file no longer has the tag.
+func TODOTag(operation string) string {
+	return "TODO(conformance:" + operation + ")"
+}
+
+// skeleton holds what the scenario skeleton of an operation lists
+type skeleton struct {
+	Package   string
+	Operation string
+	Tag       string
+	Required  []string
+	Errors    []string
+}
+
+// GenerateSkeletons writes a scenario skeleton for every operation of the
+// model, and the suite, to dir. Existing files are kept unless overwrite is
+// set, as they hold the scenarios written so far. It returns the files it
+// wrote.
+func GenerateSkeletons(api *smithy.SmithyAPI, dir, pkg string, overwrite bool) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	var written []string
+	write := func(name string, tmpl *template.Template, data interface{}) error {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil && !overwrite {
+			return nil
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return fmt.Errorf("failed to render %s: %w", name, err)
+		}
+		content, err := format.Source(buf.Bytes())
+		if err != nil {
+			return fmt.Errorf("failed to format %s: %w", name, err)
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		written = append(written, name)
+		return nil
+	}
+
+	if err := write(SuiteFile, suiteTemplate, struct{ Package string }{pkg}); err != nil {
+		return nil, err
+	}
+	for target, opShape := range api.GetOperations() {
+		name := smithy.GetShapeName(target)
+		data := skeleton{
+			Package:   pkg,
+			Operation: name,
+			Tag:       TODOTag(name),
+			Required:  requiredMembers(api, opShape),
+		}
+		for _, ref := range opShape.Errors {
+			data.Errors = append(data.Errors, smithy.GetShapeName(ref.Target))
+		}
+		sort.Strings(data.Errors)
+		if err := write(ScenarioFile(name), scenarioTemplate, data); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(written)
+	return written, nil
+}
+
+// requiredMembers returns the JSON names of the required members of the
+// input of an operation, sorted
+func requiredMembers(api *smithy.SmithyAPI, opShape *smithy.SmithyShape) []string {
+	if opShape.Input == nil {
+		return nil
+	}
+	input, _ := api.ResolveShape(opShape.Input.Target)
+	if input == nil {
+		return nil
+	}
+	var required []string
+	for name, member := range input.Members {
+		if member.IsRequired() {
+			required = append(required, member.GetJSONName(name))
+		}
+	}
+	sort.Strings(required)
+	return required
+}
+
+var suiteTemplate = template.Must(template.New("suite").Parse(`package {{.Package}}
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestConformance(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ECS Conformance Suite")
+}
+`))
+
+var scenarioTemplate = template.Must(template.New("scenario").Parse(`// Conformance scenario of {{.Operation}}, generated by cmd/conformance from
+// the ECS model. Replace the {{.Tag}} entries
+// with the scenario; the report counts it as written once none is left.
+
+package {{.Package}}
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("{{.Operation}}", Label("conformance", "{{.Operation}}"), func() {
+	It("succeeds with the required members", func() {
+		// {{.Tag}}: call {{.Operation}}{{if .Required}} with {{range $i, $m := .Required}}{{if $i}}, {{end}}{{$m}}{{end}}{{else}} without members{{end}}
+		// and compare the response with AWS
+		Skip("{{.Tag}}: scenario not written")
+	})
+{{- range .Errors}}
+
+	It("returns {{.}}", func() {
+		// {{$.Tag}}: trigger {{.}} and compare the error with AWS
+		Skip("{{$.Tag}}: scenario not written")
+	})
+{{- end}}
+})
+`))
@@ -0,0 +1,69 @@
+// Command conformance generates the conformance scenario skeletons of the ECS
+// operations and reports which operations are implemented, stubbed or missing.
+//
+// Usage:
+//
+//	conformance generate [-output dir] [-package name] [-overwrite]
+//	conformance report [-api dir] [-scenarios dir]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/nandemo-ya/kecs/controlplane/cmd/conformance/coverage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsmodels"
+)
+
+const defaultScenarios = "../tests/scenarios/conformance"
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	api, err := awsmodels.Load("ecs")
+	if err != nil {
+		log.Fatalf("Failed to load the ECS model: %v", err)
+	}
+
+	switch os.Args[1] {
+	case "generate":
+		flags := flag.NewFlagSet("generate", flag.ExitOnError)
+		output := flags.String("output", defaultScenarios, "Output directory for the scenario skeletons")
+		pkgName := flags.String("package", "conformance", "Go package name of the scenarios")
+		overwrite := flags.Bool("overwrite", false, "Overwrite existing scenario files")
+		flags.Parse(os.Args[2:])
+
+		written, err := coverage.GenerateSkeletons(api, *output, *pkgName, *overwrite)
+		if err != nil {
+			log.Fatalf("Failed to generate scenario skeletons: %v", err)
+		}
+		log.Printf("Wrote %d scenario files to %s", len(written), *output)
+
+	case "report":
+		flags := flag.NewFlagSet("report", flag.ExitOnError)
+		implDir := flags.String("api", "internal/controlplane/api", "Directory of the ECS API implementation")
+		receiver := flags.String("receiver", "DefaultECSAPI", "Type implementing the ECS operations")
+		scenarios := flags.String("scenarios", defaultScenarios, "Directory of the conformance scenarios")
+		flags.Parse(os.Args[2:])
+
+		report, err := coverage.Analyze(api, "ecs", *implDir, *receiver, *scenarios)
+		if err != nil {
+			log.Fatalf("Failed to analyze coverage: %v", err)
+		}
+		if err := report.Write(os.Stdout); err != nil {
+			log.Fatalf("Failed to write report: %v", err)
+		}
+
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: conformance generate|report [flags]")
+	os.Exit(2)
+}
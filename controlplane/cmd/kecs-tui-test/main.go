@@ -185,7 +185,7 @@ var apiTestCmd = &cobra.Command{
 		// Test list services (if clusters exist)
 		if len(clusterArns) > 0 {
 			fmt.Print("Testing ListServices... ")
-			services, err := client.ListServices(ctx, instanceName, clusterArns[0])
+			services, err := client.ListServices(ctx, instanceName, clusterArns[0], "")
 			if err != nil {
 				fmt.Printf("FAILED: %v\n", err)
 			} else {
@@ -0,0 +1,270 @@
+// Package appconfig serves a minimal AWS AppConfig from the control plane:
+// applications, environments and configuration profiles with hosted
+// configuration versions and deployments, and the AppConfig Data API that
+// applications poll their configuration with. LocalStack serves AppConfig in
+// its Pro edition only, this lets feature flag flows be tested without it.
+package appconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsrequest"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
+	"github.com/nandemo-ya/kecs/controlplane/internal/kubernetes/resources"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// Service names of AppConfig, which match their SigV4 signing names
+const (
+	ServiceAppConfig     = "appconfig"
+	ServiceAppConfigData = "appconfigdata"
+)
+
+// InClusterEndpoint is the endpoint tasks reach the control plane, and with it
+// AppConfig, at: the API service, which forwards port 80 to the API port
+const InClusterEndpoint = "http://" + resources.ControlPlaneAPIService + "." + resources.ControlPlaneNamespace + ".svc.cluster.local"
+
+// Configuration profile types
+const (
+	TypeFreeform     = "AWS.Freeform"
+	TypeFeatureFlags = "AWS.AppConfig.FeatureFlags"
+)
+
+// hostedLocation is the location URI of profiles whose versions AppConfig hosts
+const hostedLocation = "hosted"
+
+// maxContentBytes is the size of the largest hosted configuration version
+const maxContentBytes = 2 << 20
+
+const stateFile = "state.json"
+
+// Server serves the AppConfig and AppConfig Data APIs over HTTP
+type Server struct {
+	router *mux.Router
+	// dir holds the state, nothing is persisted when empty
+	dir string
+
+	mu    sync.Mutex
+	state *state
+}
+
+// state is the persisted content of AppConfig
+type state struct {
+	Applications map[string]*application `json:"applications"`
+}
+
+type application struct {
+	ID           string                  `json:"id"`
+	Name         string                  `json:"name"`
+	Description  string                  `json:"description,omitempty"`
+	Environments map[string]*environment `json:"environments"`
+	Profiles     map[string]*profile     `json:"profiles"`
+}
+
+type environment struct {
+	ID          string        `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	Deployments []*deployment `json:"deployments,omitempty"`
+}
+
+type profile struct {
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	LocationURI string           `json:"locationUri"`
+	Type        string           `json:"type"`
+	Versions    []*hostedVersion `json:"versions,omitempty"`
+	// LastVersion is the number of the latest version created, versions
+	// are not renumbered when deleted
+	LastVersion int32 `json:"lastVersion"`
+}
+
+type hostedVersion struct {
+	Number      int32  `json:"number"`
+	Description string `json:"description,omitempty"`
+	ContentType string `json:"contentType"`
+	Label       string `json:"label,omitempty"`
+	Content     []byte `json:"content"`
+}
+
+type deployment struct {
+	Number      int32     `json:"number"`
+	ProfileID   string    `json:"profileId"`
+	Version     int32     `json:"version"`
+	StrategyID  string    `json:"strategyId"`
+	Description string    `json:"description,omitempty"`
+	StartedAt   time.Time `json:"startedAt"`
+}
+
+// operations matches requests to the names of their operations, for the
+// middleware that identifies AWS API calls
+var operations = (&Server{}).routes()
+
+func init() {
+	awsrequest.RegisterRESTService(ServiceAppConfig, operationName)
+	awsrequest.RegisterRESTService(ServiceAppConfigData, operationName)
+}
+
+// operationName returns the AppConfig operation a request calls, or "" for
+// requests KECS does not serve
+func operationName(r *http.Request) string {
+	var match mux.RouteMatch
+	if operations.Match(r, &match) && match.Route != nil {
+		return match.Route.GetName()
+	}
+	return ""
+}
+
+// NewServer creates a server keeping its state in memory
+func NewServer() *Server {
+	s := &Server{state: &state{Applications: make(map[string]*application)}}
+	s.router = s.routes()
+	return s
+}
+
+// Open creates a server keeping its state in dir, loading the state of a
+// previous run
+func Open(dir string) (*Server, error) {
+	s := NewServer()
+	s.dir = dir
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create AppConfig directory: %w", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, stateFile))
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read AppConfig state: %w", err)
+	}
+	if err := json.Unmarshal(data, s.state); err != nil {
+		return nil, fmt.Errorf("failed to decode AppConfig state: %w", err)
+	}
+	if s.state.Applications == nil {
+		s.state.Applications = make(map[string]*application)
+	}
+	return s, nil
+}
+
+// ServeHTTP serves an AppConfig or AppConfig Data API call
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.router.ServeHTTP(w, r)
+}
+
+func (s *Server) routes() *mux.Router {
+	router := mux.NewRouter()
+	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "ResourceNotFoundException",
+			fmt.Sprintf("%s %s is not supported by KECS AppConfig", r.Method, r.URL.Path))
+	})
+	router.MethodNotAllowedHandler = router.NotFoundHandler
+
+	app := "/applications/{application}"
+	env := app + "/environments/{environment}"
+	prof := app + "/configurationprofiles/{profile}"
+
+	router.HandleFunc("/applications", s.createApplication).Methods(http.MethodPost).Name("CreateApplication")
+	router.HandleFunc("/applications", s.listApplications).Methods(http.MethodGet).Name("ListApplications")
+	router.HandleFunc(app, s.getApplication).Methods(http.MethodGet).Name("GetApplication")
+	router.HandleFunc(app, s.deleteApplication).Methods(http.MethodDelete).Name("DeleteApplication")
+
+	router.HandleFunc(app+"/environments", s.createEnvironment).Methods(http.MethodPost).Name("CreateEnvironment")
+	router.HandleFunc(app+"/environments", s.listEnvironments).Methods(http.MethodGet).Name("ListEnvironments")
+	router.HandleFunc(env, s.getEnvironment).Methods(http.MethodGet).Name("GetEnvironment")
+	router.HandleFunc(env, s.deleteEnvironment).Methods(http.MethodDelete).Name("DeleteEnvironment")
+
+	router.HandleFunc(app+"/configurationprofiles", s.createProfile).Methods(http.MethodPost).Name("CreateConfigurationProfile")
+	router.HandleFunc(app+"/configurationprofiles", s.listProfiles).Methods(http.MethodGet).Name("ListConfigurationProfiles")
+	router.HandleFunc(prof, s.getProfile).Methods(http.MethodGet).Name("GetConfigurationProfile")
+	router.HandleFunc(prof, s.deleteProfile).Methods(http.MethodDelete).Name("DeleteConfigurationProfile")
+
+	router.HandleFunc(prof+"/hostedconfigurationversions", s.createHostedVersion).Methods(http.MethodPost).Name("CreateHostedConfigurationVersion")
+	router.HandleFunc(prof+"/hostedconfigurationversions", s.listHostedVersions).Methods(http.MethodGet).Name("ListHostedConfigurationVersions")
+	router.HandleFunc(prof+"/hostedconfigurationversions/{version}", s.getHostedVersion).Methods(http.MethodGet).Name("GetHostedConfigurationVersion")
+	router.HandleFunc(prof+"/hostedconfigurationversions/{version}", s.deleteHostedVersion).Methods(http.MethodDelete).Name("DeleteHostedConfigurationVersion")
+
+	router.HandleFunc(env+"/deployments", s.startDeployment).Methods(http.MethodPost).Name("StartDeployment")
+	router.HandleFunc(env+"/deployments", s.listDeployments).Methods(http.MethodGet).Name("ListDeployments")
+	router.HandleFunc(env+"/deployments/{deployment}", s.getDeployment).Methods(http.MethodGet).Name("GetDeployment")
+
+	// AppConfig Data
+	router.HandleFunc("/configurationsessions", s.startSession).Methods(http.MethodPost).Name("StartConfigurationSession")
+	router.HandleFunc("/configuration", s.getLatestConfiguration).Methods(http.MethodGet).Name("GetLatestConfiguration")
+	return router
+}
+
+// save persists the state, the caller holds s.mu. Failures are logged as
+// the change is already visible to clients.
+func (s *Server) save() {
+	if s.dir == "" {
+		return
+	}
+	data, err := json.Marshal(s.state)
+	if err != nil {
+		logging.Error("Failed to encode AppConfig state", "error", err)
+		return
+	}
+	path := filepath.Join(s.dir, stateFile)
+	if err := os.WriteFile(path+".tmp", data, 0600); err != nil {
+		logging.Error("Failed to write AppConfig state", "error", err)
+		return
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		logging.Error("Failed to write AppConfig state", "error", err)
+	}
+}
+
+// newID returns an identifier of seven characters like those of AppConfig
+func newID() string {
+	return deterministic.Suffix(7)
+}
+
+// sortedByName returns the values of a map ordered by the name key returns
+func sortedByName[T any](items map[string]*T, name func(*T) string) []*T {
+	sorted := make([]*T, 0, len(items))
+	for _, item := range items {
+		sorted = append(sorted, item)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return name(sorted[i]) < name(sorted[j]) })
+	return sorted
+}
+
+// writeJSON writes a response of the REST JSON protocol
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(data)
+}
+
+// writeError writes an error of the REST JSON protocol
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Amzn-Errortype", code)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"__type":  code,
+		"Message": message,
+	})
+}
+
+// decodeJSON decodes the input of a REST JSON request
+func decodeJSON(w http.ResponseWriter, r *http.Request, input interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(input); err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequestException", fmt.Sprintf("Invalid request body: %v", err))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,13 @@
+package appconfig_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestAppConfig(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "AppConfig Suite")
+}
@@ -0,0 +1,198 @@
+package appconfig_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/appconfig"
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsrequest"
+	"github.com/nandemo-ya/kecs/controlplane/internal/kubernetes/resources"
+)
+
+var _ = Describe("Server", func() {
+	var server *appconfig.Server
+
+	call := func(method, path, body string, headers ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	create := func(path, body string) string {
+		rec := call("POST", path, body)
+		Expect(rec.Code).To(Equal(http.StatusCreated), rec.Body.String())
+		var out struct{ Id string }
+		Expect(json.Unmarshal(rec.Body.Bytes(), &out)).To(Succeed())
+		return out.Id
+	}
+
+	poll := func(token string) *httptest.ResponseRecorder {
+		rec := call("GET", "/configuration?configuration_token="+token, "")
+		Expect(rec.Code).To(Equal(http.StatusOK), rec.Body.String())
+		return rec
+	}
+
+	var appID, envID, profileID string
+
+	BeforeEach(func() {
+		server = appconfig.NewServer()
+		appID = create("/applications", `{"Name":"shop"}`)
+		envID = create("/applications/"+appID+"/environments", `{"Name":"dev"}`)
+		profileID = create("/applications/"+appID+"/configurationprofiles", `{"Name":"settings","LocationUri":"hosted"}`)
+	})
+
+	startSession := func() string {
+		rec := call("POST", "/configurationsessions",
+			`{"ApplicationIdentifier":"shop","EnvironmentIdentifier":"dev","ConfigurationProfileIdentifier":"settings"}`)
+		Expect(rec.Code).To(Equal(http.StatusCreated), rec.Body.String())
+		var out struct{ InitialConfigurationToken string }
+		Expect(json.Unmarshal(rec.Body.Bytes(), &out)).To(Succeed())
+		return out.InitialConfigurationToken
+	}
+
+	createVersion := func(content string, headers ...string) *httptest.ResponseRecorder {
+		return call("POST", "/applications/"+appID+"/configurationprofiles/"+profileID+"/hostedconfigurationversions",
+			content, headers...)
+	}
+
+	It("numbers hosted configuration versions", func() {
+		rec := createVersion(`{"timeout":5}`, "VersionLabel", "first")
+		Expect(rec.Code).To(Equal(http.StatusCreated))
+		Expect(rec.Header().Get("Version-Number")).To(Equal("1"))
+		Expect(rec.Header().Get("Configuration-Profile-Id")).To(Equal(profileID))
+		Expect(rec.Body.String()).To(Equal(`{"timeout":5}`))
+
+		Expect(createVersion(`{"timeout":10}`).Header().Get("Version-Number")).To(Equal("2"))
+
+		rec = createVersion(`{}`, "Latest-Version-Number", "1")
+		Expect(rec.Code).To(Equal(http.StatusConflict))
+		Expect(rec.Header().Get("X-Amzn-Errortype")).To(Equal("ConflictException"))
+	})
+
+	It("serves the deployed configuration to sessions", func() {
+		createVersion(`{"timeout":5}`)
+		createVersion(`{"timeout":10}`)
+		token := startSession()
+
+		// Before any deployment the latest version is served
+		rec := poll(token)
+		Expect(rec.Body.String()).To(Equal(`{"timeout":10}`))
+		Expect(rec.Header().Get("Next-Poll-Interval-In-Seconds")).To(Equal("60"))
+		token = rec.Header().Get("Next-Poll-Configuration-Token")
+
+		rec = poll(token)
+		Expect(rec.Body.String()).To(BeEmpty())
+		token = rec.Header().Get("Next-Poll-Configuration-Token")
+
+		rec = call("POST", "/applications/"+appID+"/environments/"+envID+"/deployments",
+			`{"DeploymentStrategyId":"AppConfig.AllAtOnce","ConfigurationProfileId":"`+profileID+`","ConfigurationVersion":"1"}`)
+		Expect(rec.Code).To(Equal(http.StatusCreated), rec.Body.String())
+		Expect(rec.Body.String()).To(ContainSubstring(`"State":"COMPLETE"`))
+
+		Expect(poll(token).Body.String()).To(Equal(`{"timeout":5}`))
+	})
+
+	It("rejects deployments of unknown versions", func() {
+		rec := call("POST", "/applications/"+appID+"/environments/"+envID+"/deployments",
+			`{"DeploymentStrategyId":"AppConfig.AllAtOnce","ConfigurationProfileId":"`+profileID+`","ConfigurationVersion":"3"}`)
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+		Expect(rec.Header().Get("X-Amzn-Errortype")).To(Equal("BadRequestException"))
+	})
+
+	It("reports sessions of unknown resources as not found", func() {
+		rec := call("POST", "/configurationsessions",
+			`{"ApplicationIdentifier":"shop","EnvironmentIdentifier":"prod","ConfigurationProfileIdentifier":"settings"}`)
+		Expect(rec.Code).To(Equal(http.StatusNotFound))
+		Expect(rec.Body.String()).To(ContainSubstring("Environment prod not found"))
+	})
+
+	Context("with a feature flags profile", func() {
+		BeforeEach(func() {
+			profileID = create("/applications/"+appID+"/configurationprofiles",
+				`{"Name":"flags","LocationUri":"hosted","Type":"AWS.AppConfig.FeatureFlags"}`)
+		})
+
+		It("serves the flag values", func() {
+			rec := createVersion(`{"version":"1","flags":{"checkout":{"name":"checkout"},"search":{"name":"search"}},` +
+				`"values":{"checkout":{"enabled":true}}}`)
+			Expect(rec.Code).To(Equal(http.StatusCreated), rec.Body.String())
+
+			rec = call("POST", "/configurationsessions",
+				`{"ApplicationIdentifier":"`+appID+`","EnvironmentIdentifier":"`+envID+`","ConfigurationProfileIdentifier":"flags"}`)
+			var out struct{ InitialConfigurationToken string }
+			Expect(json.Unmarshal(rec.Body.Bytes(), &out)).To(Succeed())
+
+			rec = poll(out.InitialConfigurationToken)
+			Expect(rec.Header().Get("Content-Type")).To(Equal("application/json"))
+			Expect(rec.Body.String()).To(MatchJSON(`{"checkout":{"enabled":true},"search":{"enabled":false}}`))
+		})
+
+		It("rejects invalid flags", func() {
+			rec := createVersion(`{"flags":{}}`)
+			Expect(rec.Code).To(Equal(http.StatusBadRequest))
+			Expect(rec.Body.String()).To(ContainSubstring("version must be"))
+		})
+	})
+
+	It("keeps its state across restarts", func() {
+		dir := GinkgoT().TempDir()
+		var err error
+		server, err = appconfig.Open(dir)
+		Expect(err).NotTo(HaveOccurred())
+		appID = create("/applications", `{"Name":"billing"}`)
+
+		server, err = appconfig.Open(dir)
+		Expect(err).NotTo(HaveOccurred())
+		rec := call("GET", "/applications/"+appID, "")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(ContainSubstring(`"Name":"billing"`))
+	})
+})
+
+var _ = Describe("Operations", func() {
+	identify := func(service, method, path string) awsrequest.Operation {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20251015/us-east-1/"+service+"/aws4_request, SignedHeaders=host, Signature=abc")
+		op, ok := awsrequest.Identify(req)
+		Expect(ok).To(BeTrue())
+		return op
+	}
+
+	It("identifies AppConfig calls by their route", func() {
+		op := identify(appconfig.ServiceAppConfig, "DELETE", "/applications/abc1234/environments/def5678")
+		Expect(op.Service).To(Equal(appconfig.ServiceAppConfig))
+		Expect(op.Name).To(Equal("DeleteEnvironment"))
+		Expect(op.Mutating()).To(BeTrue())
+
+		op = identify(appconfig.ServiceAppConfigData, "GET", "/configuration?configuration_token=abc")
+		Expect(op.Name).To(Equal("GetLatestConfiguration"))
+	})
+
+	It("identifies calls KECS does not serve without name", func() {
+		Expect(identify(appconfig.ServiceAppConfig, "PUT", "/unknown").Name).To(BeEmpty())
+	})
+})
+
+var _ = Describe("InClusterEndpoint", func() {
+	It("should address the API service of the control plane", func() {
+		endpoint, err := url.Parse(appconfig.InClusterEndpoint)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(endpoint.Hostname()).To(Equal(resources.ControlPlaneAPIService + "." + resources.ControlPlaneNamespace + ".svc.cluster.local"))
+
+		// The default HTTP port is the port the API service listens on
+		Expect(endpoint.Scheme).To(Equal("http"))
+		Expect(endpoint.Port()).To(BeEmpty())
+		Expect(resources.DefaultControlPlaneConfig().APIPort).To(BeEquivalentTo(80))
+	})
+})
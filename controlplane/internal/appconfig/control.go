@@ -0,0 +1,608 @@
+package appconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
+)
+
+// applicationOutput is the Application shape of AppConfig
+type applicationOutput struct {
+	Id          string
+	Name        string
+	Description string `json:",omitempty"`
+}
+
+// environmentOutput is the Environment shape of AppConfig
+type environmentOutput struct {
+	ApplicationId string
+	Id            string
+	Name          string
+	Description   string `json:",omitempty"`
+	State         string
+}
+
+// profileOutput is the ConfigurationProfile shape of AppConfig
+type profileOutput struct {
+	ApplicationId string
+	Id            string
+	Name          string
+	Description   string `json:",omitempty"`
+	LocationUri   string
+	Type          string
+}
+
+// hostedVersionOutput is the HostedConfigurationVersionSummary shape of
+// AppConfig
+type hostedVersionOutput struct {
+	ApplicationId          string
+	ConfigurationProfileId string
+	VersionNumber          int32
+	Description            string `json:",omitempty"`
+	ContentType            string
+	VersionLabel           string `json:",omitempty"`
+}
+
+// deploymentOutput is the Deployment shape of AppConfig. Deployments
+// complete as soon as they start, whatever their strategy.
+type deploymentOutput struct {
+	ApplicationId               string
+	EnvironmentId               string
+	ConfigurationProfileId      string
+	DeploymentStrategyId        string
+	DeploymentNumber            int32
+	ConfigurationName           string
+	ConfigurationLocationUri    string
+	ConfigurationVersion        string
+	Description                 string `json:",omitempty"`
+	DeploymentDurationInMinutes int32
+	GrowthType                  string
+	GrowthFactor                float32
+	FinalBakeTimeInMinutes      int32
+	State                       string
+	PercentageComplete          float32
+	StartedAt                   time.Time
+	CompletedAt                 time.Time
+}
+
+type listOutput[T any] struct {
+	Items []T
+}
+
+func (a *application) output() applicationOutput {
+	return applicationOutput{Id: a.ID, Name: a.Name, Description: a.Description}
+}
+
+func (e *environment) output(app *application) environmentOutput {
+	return environmentOutput{
+		ApplicationId: app.ID,
+		Id:            e.ID,
+		Name:          e.Name,
+		Description:   e.Description,
+		State:         "READY_FOR_DEPLOYMENT",
+	}
+}
+
+func (p *profile) output(app *application) profileOutput {
+	return profileOutput{
+		ApplicationId: app.ID,
+		Id:            p.ID,
+		Name:          p.Name,
+		Description:   p.Description,
+		LocationUri:   p.LocationURI,
+		Type:          p.Type,
+	}
+}
+
+func (v *hostedVersion) output(app *application, prof *profile) hostedVersionOutput {
+	return hostedVersionOutput{
+		ApplicationId:          app.ID,
+		ConfigurationProfileId: prof.ID,
+		VersionNumber:          v.Number,
+		Description:            v.Description,
+		ContentType:            v.ContentType,
+		VersionLabel:           v.Label,
+	}
+}
+
+func (d *deployment) output(app *application, env *environment) deploymentOutput {
+	out := deploymentOutput{
+		ApplicationId:          app.ID,
+		EnvironmentId:          env.ID,
+		ConfigurationProfileId: d.ProfileID,
+		DeploymentStrategyId:   d.StrategyID,
+		DeploymentNumber:       d.Number,
+		ConfigurationVersion:   strconv.Itoa(int(d.Version)),
+		Description:            d.Description,
+		GrowthType:             "LINEAR",
+		GrowthFactor:           100,
+		State:                  "COMPLETE",
+		PercentageComplete:     100,
+		StartedAt:              d.StartedAt,
+		CompletedAt:            d.StartedAt,
+	}
+	if prof, ok := app.Profiles[d.ProfileID]; ok {
+		out.ConfigurationName = prof.Name
+		out.ConfigurationLocationUri = prof.LocationURI
+	}
+	return out
+}
+
+// lookupApplication returns the application of the request path or writes
+// a not found error. The caller holds s.mu.
+func (s *Server) lookupApplication(w http.ResponseWriter, r *http.Request) (*application, bool) {
+	id := mux.Vars(r)["application"]
+	app, ok := s.state.Applications[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "ResourceNotFoundException", fmt.Sprintf("Application %s not found", id))
+	}
+	return app, ok
+}
+
+// lookupEnvironment returns the application and environment of the request
+// path or writes a not found error. The caller holds s.mu.
+func (s *Server) lookupEnvironment(w http.ResponseWriter, r *http.Request) (*application, *environment, bool) {
+	app, ok := s.lookupApplication(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+	id := mux.Vars(r)["environment"]
+	env, ok := app.Environments[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "ResourceNotFoundException", fmt.Sprintf("Environment %s not found", id))
+	}
+	return app, env, ok
+}
+
+// lookupProfile returns the application and configuration profile of the
+// request path or writes a not found error. The caller holds s.mu.
+func (s *Server) lookupProfile(w http.ResponseWriter, r *http.Request) (*application, *profile, bool) {
+	app, ok := s.lookupApplication(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+	id := mux.Vars(r)["profile"]
+	prof, ok := app.Profiles[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "ResourceNotFoundException", fmt.Sprintf("Configuration profile %s not found", id))
+	}
+	return app, prof, ok
+}
+
+// requireName writes a bad request error unless name is set
+func requireName(w http.ResponseWriter, name string) bool {
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "BadRequestException", "Name is required")
+		return false
+	}
+	return true
+}
+
+func (s *Server) createApplication(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Name        string
+		Description string
+	}
+	if !decodeJSON(w, r, &input) || !requireName(w, input.Name) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	app := &application{
+		ID:           newID(),
+		Name:         input.Name,
+		Description:  input.Description,
+		Environments: make(map[string]*environment),
+		Profiles:     make(map[string]*profile),
+	}
+	s.state.Applications[app.ID] = app
+	s.save()
+	writeJSON(w, http.StatusCreated, app.output())
+}
+
+func (s *Server) listApplications(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := listOutput[applicationOutput]{Items: []applicationOutput{}}
+	for _, app := range sortedByName(s.state.Applications, func(a *application) string { return a.Name }) {
+		out.Items = append(out.Items, app.output())
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) getApplication(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if app, ok := s.lookupApplication(w, r); ok {
+		writeJSON(w, http.StatusOK, app.output())
+	}
+}
+
+func (s *Server) deleteApplication(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	app, ok := s.lookupApplication(w, r)
+	if !ok {
+		return
+	}
+	delete(s.state.Applications, app.ID)
+	s.save()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) createEnvironment(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Name        string
+		Description string
+	}
+	if !decodeJSON(w, r, &input) || !requireName(w, input.Name) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	app, ok := s.lookupApplication(w, r)
+	if !ok {
+		return
+	}
+	env := &environment{ID: newID(), Name: input.Name, Description: input.Description}
+	app.Environments[env.ID] = env
+	s.save()
+	writeJSON(w, http.StatusCreated, env.output(app))
+}
+
+func (s *Server) listEnvironments(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	app, ok := s.lookupApplication(w, r)
+	if !ok {
+		return
+	}
+	out := listOutput[environmentOutput]{Items: []environmentOutput{}}
+	for _, env := range sortedByName(app.Environments, func(e *environment) string { return e.Name }) {
+		out.Items = append(out.Items, env.output(app))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) getEnvironment(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if app, env, ok := s.lookupEnvironment(w, r); ok {
+		writeJSON(w, http.StatusOK, env.output(app))
+	}
+}
+
+func (s *Server) deleteEnvironment(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	app, env, ok := s.lookupEnvironment(w, r)
+	if !ok {
+		return
+	}
+	delete(app.Environments, env.ID)
+	s.save()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) createProfile(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Name        string
+		Description string
+		LocationUri string
+		Type        string
+	}
+	if !decodeJSON(w, r, &input) || !requireName(w, input.Name) {
+		return
+	}
+	if input.LocationUri != hostedLocation {
+		writeError(w, http.StatusBadRequest, "BadRequestException",
+			fmt.Sprintf("LocationUri %q is not supported, KECS AppConfig hosts configurations only", input.LocationUri))
+		return
+	}
+	switch input.Type {
+	case "":
+		input.Type = TypeFreeform
+	case TypeFreeform, TypeFeatureFlags:
+	default:
+		writeError(w, http.StatusBadRequest, "BadRequestException", fmt.Sprintf("Type %q is not supported", input.Type))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	app, ok := s.lookupApplication(w, r)
+	if !ok {
+		return
+	}
+	prof := &profile{
+		ID:          newID(),
+		Name:        input.Name,
+		Description: input.Description,
+		LocationURI: input.LocationUri,
+		Type:        input.Type,
+	}
+	app.Profiles[prof.ID] = prof
+	s.save()
+	writeJSON(w, http.StatusCreated, prof.output(app))
+}
+
+func (s *Server) listProfiles(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	app, ok := s.lookupApplication(w, r)
+	if !ok {
+		return
+	}
+	out := listOutput[profileOutput]{Items: []profileOutput{}}
+	for _, prof := range sortedByName(app.Profiles, func(p *profile) string { return p.Name }) {
+		out.Items = append(out.Items, prof.output(app))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) getProfile(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if app, prof, ok := s.lookupProfile(w, r); ok {
+		writeJSON(w, http.StatusOK, prof.output(app))
+	}
+}
+
+func (s *Server) deleteProfile(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	app, prof, ok := s.lookupProfile(w, r)
+	if !ok {
+		return
+	}
+	delete(app.Profiles, prof.ID)
+	s.save()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) createHostedVersion(w http.ResponseWriter, r *http.Request) {
+	content, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxContentBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "PayloadTooLargeException",
+				fmt.Sprintf("Configuration content exceeds %d bytes", maxContentBytes))
+			return
+		}
+		writeError(w, http.StatusBadRequest, "BadRequestException", fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		writeError(w, http.StatusBadRequest, "BadRequestException", "Content-Type is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	app, prof, ok := s.lookupProfile(w, r)
+	if !ok {
+		return
+	}
+	if latest := r.Header.Get("Latest-Version-Number"); latest != "" && latest != strconv.Itoa(int(prof.LastVersion)) {
+		writeError(w, http.StatusConflict, "ConflictException",
+			fmt.Sprintf("Latest version is %d, not %s", prof.LastVersion, latest))
+		return
+	}
+	if prof.Type == TypeFeatureFlags {
+		if _, err := featureFlagValues(content); err != nil {
+			writeError(w, http.StatusBadRequest, "BadRequestException", err.Error())
+			return
+		}
+	}
+
+	prof.LastVersion++
+	version := &hostedVersion{
+		Number:      prof.LastVersion,
+		Description: r.Header.Get("Description"),
+		ContentType: contentType,
+		Label:       r.Header.Get("VersionLabel"),
+		Content:     content,
+	}
+	prof.Versions = append(prof.Versions, version)
+	s.save()
+	writeHostedVersion(w, http.StatusCreated, app, prof, version)
+}
+
+// writeHostedVersion writes a hosted configuration version, whose metadata
+// travels in headers and content in the body
+func writeHostedVersion(w http.ResponseWriter, status int, app *application, prof *profile, version *hostedVersion) {
+	w.Header().Set("Application-Id", app.ID)
+	w.Header().Set("Configuration-Profile-Id", prof.ID)
+	w.Header().Set("Version-Number", strconv.Itoa(int(version.Number)))
+	w.Header().Set("Content-Type", version.ContentType)
+	if version.Description != "" {
+		w.Header().Set("Description", version.Description)
+	}
+	if version.Label != "" {
+		w.Header().Set("VersionLabel", version.Label)
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(version.Content)
+}
+
+// lookupHostedVersion returns the hosted configuration version of the
+// request path or writes a not found error. The caller holds s.mu.
+func (s *Server) lookupHostedVersion(w http.ResponseWriter, r *http.Request) (*application, *profile, int, bool) {
+	app, prof, ok := s.lookupProfile(w, r)
+	if !ok {
+		return nil, nil, 0, false
+	}
+	number := mux.Vars(r)["version"]
+	for i, version := range prof.Versions {
+		if strconv.Itoa(int(version.Number)) == number {
+			return app, prof, i, true
+		}
+	}
+	writeError(w, http.StatusNotFound, "ResourceNotFoundException", fmt.Sprintf("Hosted configuration version %s not found", number))
+	return nil, nil, 0, false
+}
+
+func (s *Server) listHostedVersions(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	app, prof, ok := s.lookupProfile(w, r)
+	if !ok {
+		return
+	}
+	out := listOutput[hostedVersionOutput]{Items: []hostedVersionOutput{}}
+	// Newest first, like AppConfig
+	for i := len(prof.Versions) - 1; i >= 0; i-- {
+		out.Items = append(out.Items, prof.Versions[i].output(app, prof))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) getHostedVersion(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if app, prof, i, ok := s.lookupHostedVersion(w, r); ok {
+		writeHostedVersion(w, http.StatusOK, app, prof, prof.Versions[i])
+	}
+}
+
+func (s *Server) deleteHostedVersion(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, prof, i, ok := s.lookupHostedVersion(w, r)
+	if !ok {
+		return
+	}
+	prof.Versions = append(prof.Versions[:i], prof.Versions[i+1:]...)
+	s.save()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) startDeployment(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		DeploymentStrategyId   string
+		ConfigurationProfileId string
+		ConfigurationVersion   string
+		Description            string
+	}
+	if !decodeJSON(w, r, &input) {
+		return
+	}
+	if input.DeploymentStrategyId == "" || input.ConfigurationProfileId == "" || input.ConfigurationVersion == "" {
+		writeError(w, http.StatusBadRequest, "BadRequestException",
+			"DeploymentStrategyId, ConfigurationProfileId and ConfigurationVersion are required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	app, env, ok := s.lookupEnvironment(w, r)
+	if !ok {
+		return
+	}
+	prof, ok := app.Profiles[input.ConfigurationProfileId]
+	if !ok {
+		writeError(w, http.StatusNotFound, "ResourceNotFoundException",
+			fmt.Sprintf("Configuration profile %s not found", input.ConfigurationProfileId))
+		return
+	}
+	if prof.version(input.ConfigurationVersion) == nil {
+		writeError(w, http.StatusBadRequest, "BadRequestException",
+			fmt.Sprintf("Configuration version %s of %s not found", input.ConfigurationVersion, prof.Name))
+		return
+	}
+
+	number, _ := strconv.Atoi(input.ConfigurationVersion)
+	dep := &deployment{
+		Number:      int32(len(env.Deployments) + 1),
+		ProfileID:   prof.ID,
+		Version:     int32(number),
+		StrategyID:  input.DeploymentStrategyId,
+		Description: input.Description,
+		StartedAt:   deterministic.Now().UTC(),
+	}
+	env.Deployments = append(env.Deployments, dep)
+	s.save()
+	writeJSON(w, http.StatusCreated, dep.output(app, env))
+}
+
+func (s *Server) listDeployments(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	app, env, ok := s.lookupEnvironment(w, r)
+	if !ok {
+		return
+	}
+	out := listOutput[deploymentOutput]{Items: []deploymentOutput{}}
+	for i := len(env.Deployments) - 1; i >= 0; i-- {
+		out.Items = append(out.Items, env.Deployments[i].output(app, env))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) getDeployment(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	app, env, ok := s.lookupEnvironment(w, r)
+	if !ok {
+		return
+	}
+	number := mux.Vars(r)["deployment"]
+	for _, dep := range env.Deployments {
+		if strconv.Itoa(int(dep.Number)) == number {
+			writeJSON(w, http.StatusOK, dep.output(app, env))
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "ResourceNotFoundException", fmt.Sprintf("Deployment %s not found", number))
+}
+
+// version returns the hosted version numbered number, nil if there is none
+func (p *profile) version(number string) *hostedVersion {
+	for _, version := range p.Versions {
+		if strconv.Itoa(int(version.Number)) == number {
+			return version
+		}
+	}
+	return nil
+}
+
+// featureFlagValues validates the content of a feature flags configuration
+// and returns the flag values applications receive
+func featureFlagValues(content []byte) ([]byte, error) {
+	var flags struct {
+		Version string                     `json:"version"`
+		Flags   map[string]json.RawMessage `json:"flags"`
+		Values  map[string]json.RawMessage `json:"values"`
+	}
+	if err := json.Unmarshal(content, &flags); err != nil {
+		return nil, fmt.Errorf("content is not valid %s: %v", TypeFeatureFlags, err)
+	}
+	if flags.Version != "1" {
+		return nil, fmt.Errorf("content is not valid %s: version must be \"1\"", TypeFeatureFlags)
+	}
+	for name := range flags.Values {
+		if _, ok := flags.Flags[name]; !ok {
+			return nil, fmt.Errorf("content is not valid %s: value of undefined flag %s", TypeFeatureFlags, name)
+		}
+	}
+	values := make(map[string]json.RawMessage, len(flags.Flags))
+	for name := range flags.Flags {
+		if value, ok := flags.Values[name]; ok {
+			values[name] = value
+		} else {
+			values[name] = json.RawMessage(`{"enabled":false}`)
+		}
+	}
+	return json.Marshal(values)
+}
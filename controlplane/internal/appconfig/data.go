@@ -0,0 +1,162 @@
+package appconfig
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// defaultPollInterval is the poll interval of sessions that do not require
+// one, in seconds
+const defaultPollInterval = 60
+
+// sessionToken is the state of a configuration session. It travels in the
+// configuration tokens rather than being kept by the server, so sessions
+// survive restarts and never expire.
+type sessionToken struct {
+	ApplicationID string `json:"a"`
+	EnvironmentID string `json:"e"`
+	ProfileID     string `json:"p"`
+	Interval      int32  `json:"i"`
+	// Version is the configuration version the client last received
+	Version int32 `json:"v,omitempty"`
+}
+
+func (t sessionToken) encode() string {
+	data, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeSessionToken(token string) (sessionToken, error) {
+	var t sessionToken
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return t, err
+	}
+	err = json.Unmarshal(data, &t)
+	return t, err
+}
+
+func (s *Server) startSession(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		ApplicationIdentifier                string
+		EnvironmentIdentifier                string
+		ConfigurationProfileIdentifier       string
+		RequiredMinimumPollIntervalInSeconds int32
+	}
+	if !decodeJSON(w, r, &input) {
+		return
+	}
+	if input.ApplicationIdentifier == "" || input.EnvironmentIdentifier == "" || input.ConfigurationProfileIdentifier == "" {
+		writeError(w, http.StatusBadRequest, "BadRequestException",
+			"ApplicationIdentifier, EnvironmentIdentifier and ConfigurationProfileIdentifier are required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	app := findByIDOrName(s.state.Applications, input.ApplicationIdentifier, func(a *application) string { return a.Name })
+	if app == nil {
+		writeError(w, http.StatusNotFound, "ResourceNotFoundException", fmt.Sprintf("Application %s not found", input.ApplicationIdentifier))
+		return
+	}
+	env := findByIDOrName(app.Environments, input.EnvironmentIdentifier, func(e *environment) string { return e.Name })
+	if env == nil {
+		writeError(w, http.StatusNotFound, "ResourceNotFoundException", fmt.Sprintf("Environment %s not found", input.EnvironmentIdentifier))
+		return
+	}
+	prof := findByIDOrName(app.Profiles, input.ConfigurationProfileIdentifier, func(p *profile) string { return p.Name })
+	if prof == nil {
+		writeError(w, http.StatusNotFound, "ResourceNotFoundException",
+			fmt.Sprintf("Configuration profile %s not found", input.ConfigurationProfileIdentifier))
+		return
+	}
+
+	interval := input.RequiredMinimumPollIntervalInSeconds
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	token := sessionToken{ApplicationID: app.ID, EnvironmentID: env.ID, ProfileID: prof.ID, Interval: interval}
+	writeJSON(w, http.StatusCreated, map[string]string{"InitialConfigurationToken": token.encode()})
+}
+
+func (s *Server) getLatestConfiguration(w http.ResponseWriter, r *http.Request) {
+	token, err := decodeSessionToken(r.URL.Query().Get("configuration_token"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequestException", "Invalid configuration token")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	app, ok := s.state.Applications[token.ApplicationID]
+	if !ok {
+		writeError(w, http.StatusNotFound, "ResourceNotFoundException", fmt.Sprintf("Application %s not found", token.ApplicationID))
+		return
+	}
+	env, envOK := app.Environments[token.EnvironmentID]
+	prof, profOK := app.Profiles[token.ProfileID]
+	if !envOK || !profOK {
+		writeError(w, http.StatusNotFound, "ResourceNotFoundException", "Configuration session refers to a deleted resource")
+		return
+	}
+
+	version := currentVersion(env, prof)
+	next := token
+	if version != nil {
+		next.Version = version.Number
+	}
+	w.Header().Set("Next-Poll-Configuration-Token", next.encode())
+	w.Header().Set("Next-Poll-Interval-In-Seconds", strconv.Itoa(int(token.Interval)))
+
+	// An empty body tells the client its configuration has not changed
+	if version == nil || version.Number == token.Version {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	content, contentType := version.Content, version.ContentType
+	if prof.Type == TypeFeatureFlags {
+		// Validated when the version was created
+		content, _ = featureFlagValues(content)
+		contentType = "application/json"
+	}
+	w.Header().Set("Content-Type", contentType)
+	if version.Label != "" {
+		w.Header().Set("Version-Label", version.Label)
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(content)
+}
+
+// currentVersion returns the version of prof last deployed to env. Before
+// any deployment it is the latest version, so configurations can be tried
+// without deploying them, nil if there is no version at all.
+func currentVersion(env *environment, prof *profile) *hostedVersion {
+	for i := len(env.Deployments) - 1; i >= 0; i-- {
+		dep := env.Deployments[i]
+		if dep.ProfileID == prof.ID {
+			return prof.version(strconv.Itoa(int(dep.Version)))
+		}
+	}
+	if len(prof.Versions) == 0 {
+		return nil
+	}
+	return prof.Versions[len(prof.Versions)-1]
+}
+
+// findByIDOrName returns the item identified by identifier, which is either
+// its ID or its name like in AppConfig Data
+func findByIDOrName[T any](items map[string]*T, identifier string, name func(*T) string) *T {
+	if item, ok := items[identifier]; ok {
+		return item
+	}
+	for _, item := range items {
+		if name(item) == identifier {
+			return item
+		}
+	}
+	return nil
+}
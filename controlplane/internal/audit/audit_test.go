@@ -0,0 +1,152 @@
+package audit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/audit"
+)
+
+const testAuthorization = "AWS4-HMAC-SHA256 Credential=AKIAALICE/20251015/us-east-1/ecs/aws4_request, SignedHeaders=host;x-amz-date, Signature=abc"
+
+var _ = Describe("Middleware", func() {
+	var (
+		log     *audit.Log
+		handler http.Handler
+		status  int
+		body    string
+		seen    string
+	)
+
+	BeforeEach(func() {
+		log = audit.NewLog(10)
+		status = http.StatusOK
+		body = "{}"
+		handler = log.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			data := make([]byte, 1024)
+			n, _ := r.Body.Read(data)
+			seen = string(data[:n])
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+		}))
+	})
+
+	ecsRequest := func(operation, payload string) *http.Request {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(payload))
+		req.Header.Set("X-Amz-Target", "AmazonEC2ContainerServiceV20141113."+operation)
+		req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+		req.Header.Set("Authorization", testAuthorization)
+		return req
+	}
+
+	It("records mutating calls with the caller and the request", func() {
+		handler.ServeHTTP(httptest.NewRecorder(), ecsRequest("UpdateService", `{"service": "web", "desiredCount": 3}`))
+
+		entries := log.Query(audit.Query{})
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Seq).To(Equal(int64(1)))
+		Expect(entries[0].Service).To(Equal("ecs"))
+		Expect(entries[0].Operation).To(Equal("UpdateService"))
+		Expect(entries[0].AccessKeyID).To(Equal("AKIAALICE"))
+		Expect(entries[0].Request).To(Equal(`{"service":"web","desiredCount":3}`))
+		Expect(entries[0].StatusCode).To(Equal(http.StatusOK))
+		Expect(seen).To(Equal(`{"service": "web", "desiredCount": 3}`), "handler should still read the body")
+	})
+
+	It("names the principal owning the access key", func() {
+		log.SetPrincipalResolver(func(accessKeyID string) string {
+			if accessKeyID == "AKIAALICE" {
+				return "alice"
+			}
+			return ""
+		})
+		handler.ServeHTTP(httptest.NewRecorder(), ecsRequest("CreateCluster", "{}"))
+
+		entries := log.Query(audit.Query{Principal: "alice"})
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Principal).To(Equal("alice"))
+	})
+
+	It("skips read-only calls", func() {
+		handler.ServeHTTP(httptest.NewRecorder(), ecsRequest("DescribeServices", "{}"))
+		handler.ServeHTTP(httptest.NewRecorder(), ecsRequest("ListTasks", "{}"))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+
+		Expect(log.Query(audit.Query{})).To(BeEmpty())
+	})
+
+	It("records the error code of failed calls", func() {
+		status = http.StatusBadRequest
+		body = `{"__type": "com.amazonaws.ecs#ServiceNotFoundException", "message": "Service not found."}`
+		handler.ServeHTTP(httptest.NewRecorder(), ecsRequest("DeleteService", `{"service": "web"}`))
+
+		entries := log.Query(audit.Query{FailedOnly: true})
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].ErrorCode).To(Equal("ServiceNotFoundException"))
+	})
+
+	It("records query protocol calls without the common parameters", func() {
+		status = http.StatusBadRequest
+		body = `<ErrorResponse><Error><Code>TargetGroupNotFound</Code></Error></ErrorResponse>`
+		req := httptest.NewRequest("POST", "/", strings.NewReader("Action=DeleteTargetGroup&Version=2015-12-01&TargetGroupArn=tg"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		entries := log.Query(audit.Query{})
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Service).To(Equal("elasticloadbalancing"))
+		Expect(entries[0].Request).To(Equal("TargetGroupArn=tg"))
+		Expect(entries[0].ErrorCode).To(Equal("TargetGroupNotFound"))
+	})
+})
+
+var _ = Describe("Log", func() {
+	record := func(log *audit.Log, operations ...string) {
+		for _, operation := range operations {
+			log.Record(audit.Entry{Service: "ecs", Operation: operation, StatusCode: http.StatusOK})
+		}
+	}
+
+	It("keeps the newest entries and filters queries", func() {
+		log := audit.NewLog(3)
+		record(log, "CreateCluster", "CreateService", "UpdateService", "UpdateService")
+
+		entries := log.Query(audit.Query{})
+		Expect(entries).To(HaveLen(3))
+		Expect(entries[0].Operation).To(Equal("CreateService"))
+		Expect(log.LastSeq()).To(Equal(int64(4)))
+
+		Expect(log.Query(audit.Query{Operation: "updateservice"})).To(HaveLen(2))
+		Expect(log.Query(audit.Query{AfterSeq: 3})).To(HaveLen(1))
+		Expect(log.Query(audit.Query{Limit: 1})[0].Seq).To(Equal(int64(4)))
+	})
+
+	It("persists entries across restarts and compacts the file", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "audit", "audit.log")
+
+		log, err := audit.OpenLog(path, 2)
+		Expect(err).NotTo(HaveOccurred())
+		record(log, "CreateCluster", "CreateService", "UpdateService", "UpdateService", "DeleteService")
+		Expect(log.Close()).To(Succeed())
+
+		data, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(strings.Count(string(data), "\n")).To(BeNumerically("<=", 4))
+
+		log, err = audit.OpenLog(path, 2)
+		Expect(err).NotTo(HaveOccurred())
+		defer log.Close()
+		entries := log.Query(audit.Query{})
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[1].Operation).To(Equal("DeleteService"))
+
+		// Sequence numbers continue after the persisted entries
+		Expect(log.Record(audit.Entry{Operation: "StopTask"}).Seq).To(Equal(int64(6)))
+	})
+})
@@ -0,0 +1,288 @@
+// Package audit records the mutating AWS API calls made against a KECS
+// instance, so that shared instances can answer who changed what.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// DefaultMaxEntries is the number of entries kept when no limit is configured
+const DefaultMaxEntries = 10000
+
+// Entry is one audited API call
+type Entry struct {
+	// Seq increases by one for every entry and is used to tail the log
+	Seq         int64     `json:"seq"`
+	Time        time.Time `json:"time"`
+	Service     string    `json:"service"`
+	Operation   string    `json:"operation"`
+	AccessKeyID string    `json:"accessKeyId,omitempty"`
+	// Principal is the user owning the access key when credentials are configured
+	Principal string `json:"principal,omitempty"`
+	SourceIP  string `json:"sourceIp,omitempty"`
+	// Request is a summary of the request parameters, truncated to maxRequestSummary bytes
+	Request    string `json:"request,omitempty"`
+	StatusCode int    `json:"statusCode"`
+	ErrorCode  string `json:"errorCode,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// Succeeded reports whether the call succeeded
+func (e Entry) Succeeded() bool {
+	return e.StatusCode < 400
+}
+
+// Query selects entries of the log. Zero values match all entries.
+type Query struct {
+	// AfterSeq returns only entries recorded after the entry with this sequence number
+	AfterSeq    int64
+	Since       time.Time
+	Service     string
+	Operation   string
+	AccessKeyID string
+	Principal   string
+	// FailedOnly returns only calls that returned an error
+	FailedOnly bool
+	// Limit returns at most the newest Limit entries
+	Limit int
+}
+
+func (q Query) matches(e Entry) bool {
+	switch {
+	case e.Seq <= q.AfterSeq:
+		return false
+	case !q.Since.IsZero() && e.Time.Before(q.Since):
+		return false
+	case q.Service != "" && !strings.EqualFold(q.Service, e.Service):
+		return false
+	case q.Operation != "" && !strings.EqualFold(q.Operation, e.Operation):
+		return false
+	case q.AccessKeyID != "" && q.AccessKeyID != e.AccessKeyID:
+		return false
+	case q.Principal != "" && q.Principal != e.Principal:
+		return false
+	case q.FailedOnly && e.Succeeded():
+		return false
+	}
+	return true
+}
+
+// Log keeps the newest entries in memory and appends every entry to a JSON
+// lines file, so the log survives restarts of the control plane. The file is
+// compacted to the entries in memory once it grows to twice the limit.
+type Log struct {
+	mu         sync.RWMutex
+	entries    []Entry
+	maxEntries int
+	nextSeq    int64
+
+	path      string
+	file      *os.File
+	fileLines int
+
+	// principal resolves the principal of an access key, nil when any key is accepted
+	principal func(accessKeyID string) string
+}
+
+// NewLog creates an in-memory log keeping at most maxEntries entries
+func NewLog(maxEntries int) *Log {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	return &Log{maxEntries: maxEntries, nextSeq: 1}
+}
+
+// OpenLog creates a log persisted to path, loading the entries already in the file
+func OpenLog(path string, maxEntries int) (*Log, error) {
+	l := NewLog(maxEntries)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	if err := l.load(path); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	l.path = path
+	l.file = file
+	return l, nil
+}
+
+// load reads the entries of an existing log file
+func (l *Log) load(path string) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// Skip a line torn by a crash
+			continue
+		}
+		l.fileLines++
+		l.append(entry)
+		if entry.Seq >= l.nextSeq {
+			l.nextSeq = entry.Seq + 1
+		}
+	}
+	return scanner.Err()
+}
+
+// SetPrincipalResolver sets the function naming the principal of an access key
+func (l *Log) SetPrincipalResolver(resolve func(accessKeyID string) string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.principal = resolve
+}
+
+// Record assigns the next sequence number to an entry and stores it
+func (l *Log) Record(entry Entry) Entry {
+	if l == nil {
+		return entry
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if entry.Principal == "" && entry.AccessKeyID != "" && l.principal != nil {
+		entry.Principal = l.principal(entry.AccessKeyID)
+	}
+	entry.Seq = l.nextSeq
+	l.nextSeq++
+	l.append(entry)
+	l.persist(entry)
+	return entry
+}
+
+func (l *Log) append(entry Entry) {
+	l.entries = append(l.entries, entry)
+	if len(l.entries) > l.maxEntries {
+		// Copy so the backing array does not grow forever
+		l.entries = append([]Entry(nil), l.entries[len(l.entries)-l.maxEntries:]...)
+	}
+}
+
+// persist appends an entry to the log file. Failures are logged and never fail the API call.
+func (l *Log) persist(entry Entry) {
+	if l.file == nil {
+		return
+	}
+	if l.fileLines >= 2*l.maxEntries {
+		if err := l.compact(); err != nil {
+			logging.Warn("Failed to compact audit log", "path", l.path, "error", err)
+		}
+		// compact rewrote the file with the new entry
+		return
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		logging.Warn("Failed to write audit log", "path", l.path, "error", err)
+		return
+	}
+	l.fileLines++
+}
+
+// compact replaces the log file with the entries kept in memory
+func (l *Log) compact() error {
+	tmpPath := l.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for _, entry := range l.entries {
+		if err := encoder.Encode(entry); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	tmp.Close()
+
+	if err := os.Rename(tmpPath, l.path); err != nil {
+		return err
+	}
+	l.file.Close()
+	l.file, err = os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND, 0600)
+	l.fileLines = len(l.entries)
+	return err
+}
+
+// Query returns the matching entries, oldest first
+func (l *Log) Query(q Query) []Entry {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	result := []Entry{}
+	for _, entry := range l.entries {
+		if q.matches(entry) {
+			result = append(result, entry)
+		}
+	}
+	if q.Limit > 0 && len(result) > q.Limit {
+		result = result[len(result)-q.Limit:]
+	}
+	return result
+}
+
+// LastSeq returns the sequence number of the newest entry
+func (l *Log) LastSeq() int64 {
+	if l == nil {
+		return 0
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.nextSeq - 1
+}
+
+// Close closes the log file
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
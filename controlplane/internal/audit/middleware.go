@@ -0,0 +1,126 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsrequest"
+)
+
+const (
+	// maxRequestSummary bounds the request parameters stored per entry
+	maxRequestSummary = 1024
+	// maxErrorBody bounds the error response read to find the error code
+	maxErrorBody = 4096
+)
+
+// queryProtocolParams are the form parameters that every query protocol request carries
+var queryProtocolParams = []string{"Action", "Version"}
+
+// Middleware returns an HTTP middleware that records every mutating AWS API
+// call. Read-only operations and requests that are not AWS API calls pass
+// through unrecorded.
+func (l *Log) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		op, ok := awsrequest.Identify(r)
+		if !ok || !op.Mutating() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		entry := Entry{
+			Time:        time.Now().UTC(),
+			Service:     op.Service,
+			Operation:   op.Name,
+			AccessKeyID: awsrequest.ParseCredential(r.Header.Get("Authorization")).AccessKeyID,
+			SourceIP:    sourceIP(r),
+		}
+		if op.QueryProtocol {
+			entry.Request = summarizeParams(op.Params)
+		} else if body, err := awsrequest.ReadBody(r); err == nil {
+			entry.Request = summarizeJSON(body)
+		}
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		entry.StatusCode = rec.status
+		entry.DurationMs = time.Since(entry.Time).Milliseconds()
+		if rec.status >= 400 {
+			entry.ErrorCode = awsrequest.ErrorCode(rec.errorBody.Bytes(), op.QueryProtocol)
+		}
+		l.Record(entry)
+	})
+}
+
+// statusRecorder captures the status and the start of error responses
+type statusRecorder struct {
+	http.ResponseWriter
+	status    int
+	errorBody bytes.Buffer
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status >= 400 && r.errorBody.Len() < maxErrorBody {
+		r.errorBody.Write(b[:min(len(b), maxErrorBody-r.errorBody.Len())])
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Flush supports streaming handlers
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// summarizeJSON compacts a JSON request body
+func summarizeJSON(body []byte) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, body); err != nil {
+		return truncate(string(body))
+	}
+	return truncate(buf.String())
+}
+
+// summarizeParams encodes the operation specific parameters of a query protocol request
+func summarizeParams(params url.Values) string {
+	values := url.Values{}
+	for key, value := range params {
+		values[key] = value
+	}
+	for _, key := range queryProtocolParams {
+		values.Del(key)
+	}
+	return truncate(values.Encode())
+}
+
+func truncate(s string) string {
+	if len(s) <= maxRequestSummary {
+		return s
+	}
+	return s[:maxRequestSummary] + "..."
+}
+
+// sourceIP returns the address of the caller
+func sourceIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		ip, _, _ := strings.Cut(forwarded, ",")
+		return strings.TrimSpace(ip)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
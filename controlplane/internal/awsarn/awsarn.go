@@ -0,0 +1,105 @@
+// Package awsarn builds the ARNs of the resources KECS emulates. The
+// partition is configured once per instance; the account ID defaults to the
+// instance account and can be overridden per request by the credentials the
+// caller signed with.
+package awsarn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// Supported partitions
+const (
+	PartitionAWS      = "aws"
+	PartitionAWSCN    = "aws-cn"
+	PartitionAWSUSGov = "aws-us-gov"
+)
+
+// DefaultAccountID is the account ID of instances that do not configure one
+const DefaultAccountID = "000000000000"
+
+var partition atomic.Value
+
+func init() {
+	partition.Store(PartitionAWS)
+}
+
+// ValidatePartition checks that a partition is one KECS supports
+func ValidatePartition(p string) error {
+	switch p {
+	case PartitionAWS, PartitionAWSCN, PartitionAWSUSGov:
+		return nil
+	}
+	return fmt.Errorf("unsupported partition %q, must be one of %s, %s, %s", p, PartitionAWS, PartitionAWSCN, PartitionAWSUSGov)
+}
+
+// SetPartition sets the partition of all ARNs built afterwards
+func SetPartition(p string) error {
+	if err := ValidatePartition(p); err != nil {
+		return err
+	}
+	partition.Store(p)
+	return nil
+}
+
+// Partition returns the configured partition
+func Partition() string {
+	return partition.Load().(string)
+}
+
+// ValidateAccountID checks that an account ID has 12 digits
+func ValidateAccountID(accountID string) error {
+	if len(accountID) != 12 {
+		return fmt.Errorf("account ID must be 12 digits: %s", accountID)
+	}
+	for _, c := range accountID {
+		if c < '0' || c > '9' {
+			return fmt.Errorf("account ID must be 12 digits: %s", accountID)
+		}
+	}
+	return nil
+}
+
+// Build returns the ARN of a resource in the configured partition, e.g.
+// Build("ecs", "us-east-1", "000000000000", "cluster/default")
+func Build(service, region, accountID, resource string) string {
+	return fmt.Sprintf("arn:%s:%s:%s:%s:%s", Partition(), service, region, accountID, resource)
+}
+
+// IsService reports whether s is an ARN of the given service in any
+// partition, so ARNs of other partitions or accounts are recognized as ARNs
+// instead of resource names
+func IsService(s, service string) bool {
+	parts := strings.SplitN(s, ":", 4)
+	return len(parts) == 4 && parts[0] == "arn" && parts[1] != "" && parts[2] == service
+}
+
+// AccountID returns the account ID of an ARN, or an empty string if s is not an ARN
+func AccountID(s string) string {
+	parts := strings.SplitN(s, ":", 6)
+	if len(parts) < 6 || parts[0] != "arn" {
+		return ""
+	}
+	return parts[4]
+}
+
+type contextKey int
+
+const accountIDKey contextKey = iota
+
+// WithAccountID adds the account ID of the caller to the context
+func WithAccountID(ctx context.Context, accountID string) context.Context {
+	return context.WithValue(ctx, accountIDKey, accountID)
+}
+
+// AccountIDFrom returns the account ID of the caller, or fallback if the
+// request was not signed with credentials bound to an account
+func AccountIDFrom(ctx context.Context, fallback string) string {
+	if accountID, ok := ctx.Value(accountIDKey).(string); ok && accountID != "" {
+		return accountID
+	}
+	return fallback
+}
@@ -0,0 +1,13 @@
+package awsarn_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestAWSARN(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "AWS ARN Suite")
+}
@@ -0,0 +1,50 @@
+package awsarn_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
+)
+
+var _ = Describe("ARNs", func() {
+	AfterEach(func() {
+		Expect(awsarn.SetPartition(awsarn.PartitionAWS)).To(Succeed())
+	})
+
+	It("builds ARNs in the configured partition", func() {
+		Expect(awsarn.Build("ecs", "us-east-1", "000000000000", "cluster/default")).
+			To(Equal("arn:aws:ecs:us-east-1:000000000000:cluster/default"))
+
+		Expect(awsarn.SetPartition(awsarn.PartitionAWSCN)).To(Succeed())
+		Expect(awsarn.Build("ecs", "cn-north-1", "111111111111", "cluster/default")).
+			To(Equal("arn:aws-cn:ecs:cn-north-1:111111111111:cluster/default"))
+	})
+
+	It("rejects unknown partitions", func() {
+		Expect(awsarn.SetPartition("aws-iso")).NotTo(Succeed())
+		Expect(awsarn.Partition()).To(Equal(awsarn.PartitionAWS))
+	})
+
+	It("validates account IDs", func() {
+		Expect(awsarn.ValidateAccountID("123456789012")).To(Succeed())
+		Expect(awsarn.ValidateAccountID("12345678901")).NotTo(Succeed())
+		Expect(awsarn.ValidateAccountID("12345678901a")).NotTo(Succeed())
+	})
+
+	It("recognizes ARNs of any partition and account", func() {
+		Expect(awsarn.IsService("arn:aws-us-gov:ecs:us-gov-west-1:222222222222:cluster/default", "ecs")).To(BeTrue())
+		Expect(awsarn.IsService("arn:aws:elasticloadbalancing:us-east-1:000000000000:loadbalancer/app/a/b", "ecs")).To(BeFalse())
+		Expect(awsarn.IsService("default", "ecs")).To(BeFalse())
+		Expect(awsarn.AccountID("arn:aws-cn:ecs:cn-north-1:111111111111:cluster/default")).To(Equal("111111111111"))
+		Expect(awsarn.AccountID("default")).To(BeEmpty())
+	})
+
+	It("prefers the account of the caller", func() {
+		ctx := context.Background()
+		Expect(awsarn.AccountIDFrom(ctx, "000000000000")).To(Equal("000000000000"))
+		Expect(awsarn.AccountIDFrom(awsarn.WithAccountID(ctx, "111111111111"), "000000000000")).To(Equal("111111111111"))
+	})
+})
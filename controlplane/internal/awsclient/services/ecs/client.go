@@ -19,6 +19,15 @@ const (
 	contentType = "application/x-amz-json-1.1"
 )
 
+// The paginators and waiters of the generated package accept the client
+var (
+	_ generated.ListClustersAPIClient     = (*Client)(nil)
+	_ generated.ListServicesAPIClient     = (*Client)(nil)
+	_ generated.ListTasksAPIClient        = (*Client)(nil)
+	_ generated.DescribeServicesAPIClient = (*Client)(nil)
+	_ generated.DescribeTasksAPIClient    = (*Client)(nil)
+)
+
 // Client is an ECS service client
 type Client struct {
 	client   *awsclient.Client
@@ -193,3 +202,8 @@ type awsError struct {
 func (e *awsError) Error() string {
 	return fmt.Sprintf("%s: %s (status: %d)", e.Code, e.Message, e.StatusCode)
 }
+
+// ErrorCode returns the AWS error code, which waiters match errorType acceptors with
+func (e *awsError) ErrorCode() string {
+	return e.Code
+}
@@ -5,12 +5,14 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	"github.com/nandemo-ya/kecs/controlplane/internal/awsclient"
 	"github.com/nandemo-ya/kecs/controlplane/internal/awsclient/services/ecs"
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsclient/waiter"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
 )
 
@@ -167,4 +169,80 @@ var _ = Describe("ECS Client", func() {
 			})
 		})
 	})
+
+	Describe("Paginators and waiters", func() {
+		newClient := func(handler http.HandlerFunc) {
+			server = httptest.NewServer(handler)
+			client = ecs.NewClient(awsclient.Config{
+				Endpoint: server.URL,
+				Credentials: awsclient.Credentials{
+					AccessKeyID:     "test-key",
+					SecretAccessKey: "test-secret",
+				},
+				Region: "us-east-1",
+			})
+		}
+		shortDelays := func(opts *waiter.Options) {
+			opts.MinDelay = time.Millisecond
+			opts.MaxDelay = 5 * time.Millisecond
+		}
+
+		It("should follow the next tokens of ListClusters", func() {
+			var tokens []string
+			newClient(func(w http.ResponseWriter, r *http.Request) {
+				var req generated.ListClustersRequest
+				Expect(json.NewDecoder(r.Body).Decode(&req)).To(Succeed())
+				response := generated.ListClustersResponse{}
+				if req.NextToken == nil {
+					tokens = append(tokens, "")
+					next := "page-2"
+					response.ClusterArns = []string{"arn:aws:ecs:us-east-1:123456789012:cluster/one"}
+					response.NextToken = &next
+				} else {
+					tokens = append(tokens, *req.NextToken)
+					response.ClusterArns = []string{"arn:aws:ecs:us-east-1:123456789012:cluster/two"}
+				}
+				json.NewEncoder(w).Encode(response)
+			})
+
+			var arns []string
+			for page, err := range generated.PaginateListClusters(context.Background(), client, &generated.ListClustersRequest{}) {
+				Expect(err).NotTo(HaveOccurred())
+				arns = append(arns, page.ClusterArns...)
+			}
+			Expect(arns).To(HaveLen(2))
+			Expect(tokens).To(Equal([]string{"", "page-2"}))
+		})
+
+		It("should wait until the tasks run", func() {
+			calls := 0
+			newClient(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				status := "PENDING"
+				if calls > 1 {
+					status = "RUNNING"
+				}
+				json.NewEncoder(w).Encode(generated.DescribeTasksResponse{
+					Tasks: []generated.Task{{LastStatus: &status}},
+				})
+			})
+
+			input := &generated.DescribeTasksRequest{Tasks: []string{"task"}}
+			Expect(generated.WaitUntilTasksRunning(context.Background(), client, input, time.Second, shortDelays)).To(Succeed())
+			Expect(calls).To(Equal(2))
+		})
+
+		It("should fail when a service is missing", func() {
+			newClient(func(w http.ResponseWriter, r *http.Request) {
+				reason := "MISSING"
+				json.NewEncoder(w).Encode(generated.DescribeServicesResponse{
+					Failures: []generated.Failure{{Reason: &reason}},
+				})
+			})
+
+			input := &generated.DescribeServicesRequest{Services: []string{"web"}}
+			err := generated.WaitUntilServicesStable(context.Background(), client, input, time.Second, shortDelays)
+			Expect(err).To(MatchError("ServicesStable waiter reached a failure state"))
+		})
+	})
 })
@@ -0,0 +1,433 @@
+package waiter
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The acceptors of the AWS models select values with JMESPath. This is the
+// subset they use: fields, flatten and filter projections, indexes, the
+// length function, comparisons, boolean operators and literals.
+
+// node is a parsed JMESPath expression
+type node interface {
+	eval(value interface{}) interface{}
+}
+
+// Search evaluates a JMESPath expression on a JSON value
+func Search(expression string, value interface{}) (interface{}, error) {
+	n, err := parse(expression)
+	if err != nil {
+		return nil, err
+	}
+	return n.eval(value), nil
+}
+
+func parse(expression string) (node, error) {
+	p := &jmesParser{input: expression}
+	n, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return nil, p.errorf("unexpected %q", p.input[p.pos:])
+	}
+	return n, nil
+}
+
+type jmesParser struct {
+	input string
+	pos   int
+}
+
+func (p *jmesParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid JMESPath expression %q at %d: %s", p.input, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *jmesParser) skipSpace() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+// consume skips a token if it is next
+func (p *jmesParser) consume(token string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.input[p.pos:], token) {
+		p.pos += len(token)
+		return true
+	}
+	return false
+}
+
+func (p *jmesParser) expression() (node, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.consume("||") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *jmesParser) and() (node, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.consume("&&") {
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *jmesParser) not() (node, error) {
+	p.skipSpace()
+	if strings.HasPrefix(p.input[p.pos:], "!") && !strings.HasPrefix(p.input[p.pos:], "!=") {
+		p.pos++
+		operand, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand}, nil
+	}
+	return p.comparison()
+}
+
+func (p *jmesParser) comparison() (node, error) {
+	left, err := p.path()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.consume(op) {
+			right, err := p.path()
+			if err != nil {
+				return nil, err
+			}
+			return compareNode{op, left, right}, nil
+		}
+	}
+	return left, nil
+}
+
+// path parses a primary expression followed by fields, indexes and projections
+func (p *jmesParser) path() (node, error) {
+	base, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	var steps []step
+	for {
+		switch {
+		case p.consume("."):
+			name, err := p.identifier()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, step{field: name})
+		case p.consume("[]"):
+			steps = append(steps, step{flatten: true})
+		case p.consume("[?"):
+			filter, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			if !p.consume("]") {
+				return nil, p.errorf("expected ]")
+			}
+			steps = append(steps, step{filter: filter})
+		case p.consume("["):
+			p.skipSpace()
+			start := p.pos
+			for p.pos < len(p.input) && (p.input[p.pos] == '-' || unicode.IsDigit(rune(p.input[p.pos]))) {
+				p.pos++
+			}
+			index, err := strconv.Atoi(p.input[start:p.pos])
+			if err != nil || !p.consume("]") {
+				return nil, p.errorf("expected an index")
+			}
+			steps = append(steps, step{index: &index})
+		default:
+			if len(steps) == 0 {
+				return base, nil
+			}
+			return pathNode{base, steps}, nil
+		}
+	}
+}
+
+func (p *jmesParser) primary() (node, error) {
+	p.skipSpace()
+	if p.pos >= len(p.input) {
+		return nil, p.errorf("unexpected end")
+	}
+	switch c := p.input[p.pos]; {
+	case c == '(':
+		p.pos++
+		n, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(")") {
+			return nil, p.errorf("expected )")
+		}
+		return n, nil
+	case c == '@':
+		p.pos++
+		return currentNode{}, nil
+	case c == '`':
+		end := strings.IndexByte(p.input[p.pos+1:], '`')
+		if end < 0 {
+			return nil, p.errorf("unterminated literal")
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(p.input[p.pos+1:p.pos+1+end]), &value); err != nil {
+			return nil, p.errorf("invalid literal: %v", err)
+		}
+		p.pos += end + 2
+		return literalNode{value}, nil
+	case c == '\'':
+		end := strings.IndexByte(p.input[p.pos+1:], '\'')
+		if end < 0 {
+			return nil, p.errorf("unterminated string")
+		}
+		value := p.input[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return literalNode{value}, nil
+	}
+
+	name, err := p.identifier()
+	if err != nil {
+		return nil, err
+	}
+	if !p.consume("(") {
+		return pathNode{currentNode{}, []step{{field: name}}}, nil
+	}
+	var args []node
+	for !p.consume(")") {
+		if len(args) > 0 && !p.consume(",") {
+			return nil, p.errorf("expected , or )")
+		}
+		arg, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	if name != "length" || len(args) != 1 {
+		return nil, p.errorf("unsupported function %s with %d arguments", name, len(args))
+	}
+	return lengthNode{args[0]}, nil
+}
+
+func (p *jmesParser) identifier() (string, error) {
+	p.skipSpace()
+	if p.pos < len(p.input) && p.input[p.pos] == '"' {
+		end := strings.IndexByte(p.input[p.pos+1:], '"')
+		if end < 0 {
+			return "", p.errorf("unterminated identifier")
+		}
+		name := p.input[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return name, nil
+	}
+	start := p.pos
+	for p.pos < len(p.input) {
+		c := rune(p.input[p.pos])
+		if c != '_' && !unicode.IsLetter(c) && (p.pos == start || !unicode.IsDigit(c)) {
+			break
+		}
+		p.pos++
+	}
+	if p.pos == start {
+		return "", p.errorf("expected an identifier")
+	}
+	return p.input[start:p.pos], nil
+}
+
+// step is a field, index or projection applied to a value
+type step struct {
+	field   string
+	index   *int
+	flatten bool
+	filter  node
+}
+
+type pathNode struct {
+	base  node
+	steps []step
+}
+
+func (n pathNode) eval(value interface{}) interface{} {
+	return evalSteps(n.base.eval(value), n.steps)
+}
+
+// evalSteps applies steps to a value. Projections apply the following steps
+// to every element and drop the null results, up to a flatten, which applies
+// to the projected list.
+func evalSteps(value interface{}, steps []step) interface{} {
+	if len(steps) == 0 || value == nil {
+		return value
+	}
+	s, rest := steps[0], steps[1:]
+	switch {
+	case s.flatten || s.filter != nil:
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil
+		}
+		if s.flatten {
+			var flat []interface{}
+			for _, item := range items {
+				if inner, ok := item.([]interface{}); ok {
+					flat = append(flat, inner...)
+				} else {
+					flat = append(flat, item)
+				}
+			}
+			items = flat
+		}
+		each, after := rest, []step(nil)
+		for i, next := range rest {
+			if next.flatten {
+				each, after = rest[:i], rest[i:]
+				break
+			}
+		}
+		projected := []interface{}{}
+		for _, item := range items {
+			if s.filter != nil && !truthy(s.filter.eval(item)) {
+				continue
+			}
+			if result := evalSteps(item, each); result != nil {
+				projected = append(projected, result)
+			}
+		}
+		return evalSteps(projected, after)
+	case s.index != nil:
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil
+		}
+		i := *s.index
+		if i < 0 {
+			i += len(items)
+		}
+		if i < 0 || i >= len(items) {
+			return nil
+		}
+		return evalSteps(items[i], rest)
+	default:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		return evalSteps(object[s.field], rest)
+	}
+}
+
+type currentNode struct{}
+
+func (currentNode) eval(value interface{}) interface{} { return value }
+
+type literalNode struct{ value interface{} }
+
+func (n literalNode) eval(interface{}) interface{} { return n.value }
+
+type lengthNode struct{ arg node }
+
+func (n lengthNode) eval(value interface{}) interface{} {
+	switch v := n.arg.eval(value).(type) {
+	case string:
+		return float64(len([]rune(v)))
+	case []interface{}:
+		return float64(len(v))
+	case map[string]interface{}:
+		return float64(len(v))
+	}
+	return nil
+}
+
+type notNode struct{ operand node }
+
+func (n notNode) eval(value interface{}) interface{} { return !truthy(n.operand.eval(value)) }
+
+type andNode struct{ left, right node }
+
+func (n andNode) eval(value interface{}) interface{} {
+	if left := n.left.eval(value); !truthy(left) {
+		return left
+	}
+	return n.right.eval(value)
+}
+
+type orNode struct{ left, right node }
+
+func (n orNode) eval(value interface{}) interface{} {
+	if left := n.left.eval(value); truthy(left) {
+		return left
+	}
+	return n.right.eval(value)
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+func (n compareNode) eval(value interface{}) interface{} {
+	left, right := n.left.eval(value), n.right.eval(value)
+	switch n.op {
+	case "==":
+		return reflect.DeepEqual(left, right)
+	case "!=":
+		return !reflect.DeepEqual(left, right)
+	}
+	l, lok := left.(float64)
+	r, rok := right.(float64)
+	if !lok || !rok {
+		return nil
+	}
+	switch n.op {
+	case "<":
+		return l < r
+	case "<=":
+		return l <= r
+	case ">":
+		return l > r
+	default:
+		return l >= r
+	}
+}
+
+// truthy follows JMESPath: false, null and empty values are false
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	}
+	return true
+}
@@ -0,0 +1,220 @@
+// Package waiter runs the waiters of the AWS models, as the AWS SDKs do: an
+// operation is called until one of the acceptors of the waiter matches its
+// result, with an exponential backoff between the calls. The Wait functions
+// generated by cmd/codegen call Wait with the acceptors of the model.
+package waiter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"time"
+)
+
+// State is the state a waiter transitions to when an acceptor matches
+type State string
+
+const (
+	// Success ends the wait successfully
+	Success State = "success"
+	// Failure ends the wait with an error
+	Failure State = "failure"
+	// Retry calls the operation again
+	Retry State = "retry"
+)
+
+// Matcher selects what an acceptor is matched against
+type Matcher string
+
+const (
+	// Output matches Path on the output
+	Output Matcher = "output"
+	// InputOutput matches Path on an object with input and output members
+	InputOutput Matcher = "inputOutput"
+	// Succeeded matches whether the operation succeeded, Expected being true or false
+	Succeeded Matcher = "success"
+	// ErrorType matches the error code the operation failed with
+	ErrorType Matcher = "errorType"
+)
+
+// Comparator compares the result of Path to the expected value
+type Comparator string
+
+const (
+	// StringEquals matches a string result
+	StringEquals Comparator = "stringEquals"
+	// BooleanEquals matches a boolean result, Expected being true or false
+	BooleanEquals Comparator = "booleanEquals"
+	// AllStringEquals matches a non-empty list of which every element is Expected
+	AllStringEquals Comparator = "allStringEquals"
+	// AnyStringEquals matches a list of which an element is Expected
+	AnyStringEquals Comparator = "anyStringEquals"
+)
+
+// Acceptor is an acceptor of a waiter
+type Acceptor struct {
+	State      State
+	Matcher    Matcher
+	Path       string
+	Expected   string
+	Comparator Comparator
+}
+
+// Options tune the delays between the calls of a waiter
+type Options struct {
+	// MinDelay is the delay before the second call, doubled up to MaxDelay
+	MinDelay time.Duration
+	MaxDelay time.Duration
+}
+
+// DefaultMaxDelay is the maximum delay of waiters that do not set one
+const DefaultMaxDelay = 120 * time.Second
+
+// Wait calls the operation until an acceptor transitions to success or
+// failure, the operation fails with an error no acceptor matches, or maxWait
+// elapses. name names the waiter in errors.
+func Wait(ctx context.Context, name string, maxWait time.Duration, opts Options, input interface{}, call func(context.Context) (interface{}, error), acceptors []Acceptor) error {
+	if maxWait <= 0 {
+		return fmt.Errorf("maximum wait time of the %s waiter must be greater than zero", name)
+	}
+	if opts.MinDelay <= 0 {
+		opts.MinDelay = 2 * time.Second
+	}
+	if opts.MaxDelay < opts.MinDelay {
+		opts.MaxDelay = max(DefaultMaxDelay, opts.MinDelay)
+	}
+	deadline := time.Now().Add(maxWait)
+
+	for attempt := 1; ; attempt++ {
+		output, err := call(ctx)
+		state, matched, matchErr := evaluate(acceptors, input, output, err)
+		if matchErr != nil {
+			return fmt.Errorf("%s waiter: %w", name, matchErr)
+		}
+		switch {
+		case matched && state == Success:
+			return nil
+		case matched && state == Failure:
+			if err != nil {
+				return fmt.Errorf("%s waiter reached a failure state: %w", name, err)
+			}
+			return fmt.Errorf("%s waiter reached a failure state", name)
+		case !matched && err != nil:
+			return fmt.Errorf("%s waiter: %w", name, err)
+		}
+
+		delay := backoff(attempt, opts)
+		if time.Now().Add(delay).After(deadline) {
+			return fmt.Errorf("exceeded the maximum wait time of %s for the %s waiter", maxWait, name)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the delay after an attempt: MinDelay doubled per attempt up
+// to MaxDelay, with a random jitter above MinDelay
+func backoff(attempt int, opts Options) time.Duration {
+	delay := opts.MaxDelay
+	if attempt < 32 && opts.MinDelay<<(attempt-1) < opts.MaxDelay {
+		delay = opts.MinDelay << (attempt - 1)
+	}
+	if delay > opts.MinDelay {
+		delay = opts.MinDelay + rand.N(delay-opts.MinDelay+1)
+	}
+	return delay
+}
+
+// evaluate returns the state of the first acceptor matching a result
+func evaluate(acceptors []Acceptor, input, output interface{}, callErr error) (State, bool, error) {
+	var document interface{}
+	for _, acceptor := range acceptors {
+		var matched bool
+		switch acceptor.Matcher {
+		case Succeeded:
+			matched = strconv.FormatBool(callErr == nil) == acceptor.Expected
+		case ErrorType:
+			var coded interface{ ErrorCode() string }
+			matched = callErr != nil && errors.As(callErr, &coded) && coded.ErrorCode() == acceptor.Expected
+		case Output, InputOutput:
+			if callErr != nil {
+				continue
+			}
+			if document == nil {
+				var err error
+				if document, err = toJSON(output); err != nil {
+					return "", false, err
+				}
+			}
+			target := document
+			if acceptor.Matcher == InputOutput {
+				in, err := toJSON(input)
+				if err != nil {
+					return "", false, err
+				}
+				target = map[string]interface{}{"input": in, "output": document}
+			}
+			result, err := Search(acceptor.Path, target)
+			if err != nil {
+				return "", false, err
+			}
+			matched = compare(acceptor.Comparator, result, acceptor.Expected)
+		default:
+			return "", false, fmt.Errorf("unsupported matcher %q", acceptor.Matcher)
+		}
+		if matched {
+			return acceptor.State, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+func compare(comparator Comparator, result interface{}, expected string) bool {
+	switch comparator {
+	case StringEquals:
+		s, ok := result.(string)
+		return ok && s == expected
+	case BooleanEquals:
+		b, ok := result.(bool)
+		return ok && strconv.FormatBool(b) == expected
+	case AllStringEquals, AnyStringEquals:
+		items, ok := result.([]interface{})
+		if !ok || len(items) == 0 {
+			return false
+		}
+		for _, item := range items {
+			s, ok := item.(string)
+			equal := ok && s == expected
+			if comparator == AnyStringEquals && equal {
+				return true
+			}
+			if comparator == AllStringEquals && !equal {
+				return false
+			}
+		}
+		return comparator == AllStringEquals
+	}
+	return false
+}
+
+// toJSON converts a value to its generic JSON form, which the paths of the
+// model address by member name
+func toJSON(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the result: %w", err)
+	}
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the result: %w", err)
+	}
+	return document, nil
+}
@@ -0,0 +1,121 @@
+package waiter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func document(t *testing.T, s string) interface{} {
+	var value interface{}
+	require.NoError(t, json.Unmarshal([]byte(s), &value))
+	return value
+}
+
+func TestSearch(t *testing.T) {
+	services := document(t, `{
+		"services": [
+			{"status": "ACTIVE", "runningCount": 2, "desiredCount": 2, "deployments": [{"id": "a"}]},
+			{"status": "ACTIVE", "runningCount": 1, "desiredCount": 2, "deployments": [{"id": "b"}, {"id": "c"}]}
+		],
+		"failures": []
+	}`)
+
+	tests := []struct {
+		expression string
+		expected   interface{}
+	}{
+		{"services[].status", []interface{}{"ACTIVE", "ACTIVE"}},
+		{"failures[].reason", []interface{}{}},
+		{"services[0].desiredCount", float64(2)},
+		{"services[-1].deployments[1].id", "c"},
+		{"length(services)", float64(2)},
+		{"services[?runningCount == desiredCount].deployments[].id", []interface{}{"a"}},
+		{"length(services[?!(length(deployments) == `1` && runningCount == desiredCount)]) == `0`", false},
+		{"services[?runningCount < `2`].status", []interface{}{"ACTIVE"}},
+		{"missing.field", nil},
+		{"services[0].status == 'ACTIVE' || `false`", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			result, err := Search(tt.expression, services)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+
+	_, err := Search("services[", services)
+	assert.Error(t, err)
+	_, err = Search("sort(services)", services)
+	assert.Error(t, err)
+}
+
+type codedError struct{ code string }
+
+func (e codedError) Error() string     { return e.code }
+func (e codedError) ErrorCode() string { return e.code }
+
+func TestWait(t *testing.T) {
+	acceptors := []Acceptor{
+		{State: Failure, Matcher: Output, Path: "tasks[].lastStatus", Expected: "STOPPED", Comparator: AnyStringEquals},
+		{State: Success, Matcher: Output, Path: "tasks[].lastStatus", Expected: "RUNNING", Comparator: AllStringEquals},
+		{State: Retry, Matcher: ErrorType, Expected: "ThrottlingException"},
+	}
+	opts := Options{MinDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+
+	// results returns a call that answers the results in turn
+	results := func(outputs ...interface{}) (func(context.Context) (interface{}, error), *int) {
+		calls := 0
+		return func(context.Context) (interface{}, error) {
+			result := outputs[min(calls, len(outputs)-1)]
+			calls++
+			if err, ok := result.(error); ok {
+				return nil, err
+			}
+			return document(t, result.(string)), nil
+		}, &calls
+	}
+
+	t.Run("succeeds once every task runs", func(t *testing.T) {
+		call, calls := results(
+			`{"tasks": [{"lastStatus": "PENDING"}, {"lastStatus": "RUNNING"}]}`,
+			codedError{"ThrottlingException"},
+			`{"tasks": [{"lastStatus": "RUNNING"}, {"lastStatus": "RUNNING"}]}`,
+		)
+		require.NoError(t, Wait(context.Background(), "TasksRunning", time.Second, opts, nil, call, acceptors))
+		assert.Equal(t, 3, *calls)
+	})
+
+	t.Run("fails when a task stopped", func(t *testing.T) {
+		call, _ := results(`{"tasks": [{"lastStatus": "STOPPED"}]}`)
+		err := Wait(context.Background(), "TasksRunning", time.Second, opts, nil, call, acceptors)
+		assert.EqualError(t, err, "TasksRunning waiter reached a failure state")
+	})
+
+	t.Run("fails on errors no acceptor matches", func(t *testing.T) {
+		call, _ := results(errors.New("connection refused"))
+		err := Wait(context.Background(), "TasksRunning", time.Second, opts, nil, call, acceptors)
+		assert.EqualError(t, err, "TasksRunning waiter: connection refused")
+	})
+
+	t.Run("gives up after the maximum wait time", func(t *testing.T) {
+		call, _ := results(`{"tasks": [{"lastStatus": "PENDING"}]}`)
+		err := Wait(context.Background(), "TasksRunning", 20*time.Millisecond, opts, nil, call, acceptors)
+		assert.ErrorContains(t, err, "exceeded the maximum wait time")
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	opts := Options{MinDelay: 2 * time.Second, MaxDelay: 10 * time.Second}
+	assert.Equal(t, 2*time.Second, backoff(1, opts))
+	for attempt := 2; attempt < 100; attempt++ {
+		delay := backoff(attempt, opts)
+		assert.GreaterOrEqual(t, delay, opts.MinDelay)
+		assert.LessOrEqual(t, delay, opts.MaxDelay)
+	}
+}
@@ -0,0 +1,63 @@
+// Package awsmodels holds the Smithy models of the AWS APIs, as published in
+// aws-sdk-go-v2. They are the input of cmd/codegen, and the models of the
+// JSON protocol services KECS serves itself are embedded to validate responses.
+//
+// The models are downloaded as they are by scripts/download-aws-api-definitions.sh.
+// The members KECS adds to the APIs are kept in the overlays directory and
+// merged into the models by cmd/codegen and Load.
+package awsmodels
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/nandemo-ya/kecs/controlplane/cmd/codegen/parser"
+)
+
+//go:embed ecs.json servicediscovery.json overlays
+var models embed.FS
+
+// modelFiles maps the SigV4 signing names of the embedded models to their files
+var modelFiles = map[string]string{
+	"ecs":              "ecs.json",
+	"servicediscovery": "servicediscovery.json",
+}
+
+// Services returns the signing names of the services whose model is embedded
+func Services() []string {
+	return []string{"ecs", "servicediscovery"}
+}
+
+// Load parses the embedded model of a service
+func Load(service string) (*parser.SmithyAPI, error) {
+	file, ok := modelFiles[service]
+	if !ok {
+		return nil, fmt.Errorf("no model embedded for service %s", service)
+	}
+	data, err := models.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model of %s: %w", service, err)
+	}
+	api, err := parser.ParseSmithy(data)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err = models.ReadFile("overlays/" + file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return api, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read overlay of %s: %w", service, err)
+	}
+	overlay, err := parser.ParseSmithy(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse overlay of %s: %w", service, err)
+	}
+	if err := api.ApplyOverlay(overlay); err != nil {
+		return nil, err
+	}
+	return api, nil
+}
@@ -0,0 +1,281 @@
+// Package awsrequest identifies the AWS API operation and the caller of
+// incoming requests, for middleware that acts on AWS API calls only.
+package awsrequest
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// Service names. They match the SigV4 signing names.
+const (
+	ServiceECS              = "ecs"
+	ServiceELBv2            = "elasticloadbalancing"
+	ServiceServiceDiscovery = "servicediscovery"
+	ServiceEC2              = "ec2"
+	ServiceSTS              = "sts"
+	ServiceCloudFormation   = "cloudformation"
+)
+
+// queryServices are the query protocol services told apart by their signing
+// name. Other form encoded requests are ELBv2 calls.
+var queryServices = map[string]bool{
+	ServiceEC2:            true,
+	ServiceCloudFormation: true,
+	ServiceSTS:            true,
+}
+
+// restServices resolve the operation names of the REST protocol services
+// KECS serves, keyed by signing name
+var restServices = map[string]func(*http.Request) string{}
+
+// RegisterRESTService registers a REST protocol service KECS serves. REST
+// requests are only told apart by their signing name, operation resolves the
+// operation name from the method and path.
+func RegisterRESTService(service string, operation func(*http.Request) string) {
+	restServices[service] = operation
+}
+
+// readOnlyPrefixes are the operation name prefixes of AWS APIs that do not change state
+var readOnlyPrefixes = []string{"Describe", "List", "Get", "BatchGet", "Discover"}
+
+// Operation identifies an AWS API call
+type Operation struct {
+	Service string
+	Name    string
+	// QueryProtocol is set for form encoded requests (ELBv2, EC2), which expect XML responses
+	QueryProtocol bool
+	// Params are the form parameters of query protocol requests
+	Params url.Values
+}
+
+// Mutating reports whether the operation may change state
+func (o Operation) Mutating() bool {
+	for _, prefix := range readOnlyPrefixes {
+		if strings.HasPrefix(o.Name, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// pathService is a service KECS serves under a path prefix
+type pathService struct {
+	prefix  string
+	service string
+}
+
+// pathServices are the services KECS serves under a path prefix
+var pathServices []pathService
+
+// RegisterPathService registers a service KECS serves under a path prefix,
+// such as a Connect service. The operation name is the rest of the path.
+func RegisterPathService(prefix, service string) {
+	pathServices = append(pathServices, pathService{prefix: prefix, service: service})
+}
+
+// RoutedService returns the service the control plane routes a request to,
+// or "" for requests that are forwarded to LocalStack. It is the routing of
+// the API proxy, so that middleware acts on the service that serves a call
+// rather than the one it claims to be signed for.
+func RoutedService(r *http.Request) string {
+	for _, p := range pathServices {
+		if strings.HasPrefix(r.URL.Path, p.prefix) {
+			return p.service
+		}
+	}
+	scope := ParseCredential(r.Header.Get("Authorization")).Service
+	if _, ok := restServices[scope]; ok {
+		return scope
+	}
+	if strings.Contains(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		if queryServices[scope] {
+			return scope
+		}
+		return ServiceELBv2
+	}
+	target := r.Header.Get("X-Amz-Target")
+	switch {
+	case strings.HasPrefix(target, "AmazonEC2ContainerServiceV"):
+		return ServiceECS
+	case strings.HasPrefix(target, "Route53AutoNaming_"):
+		return ServiceServiceDiscovery
+	case strings.HasPrefix(r.URL.Path, "/v1/"):
+		return ServiceECS
+	}
+	return ""
+}
+
+// Identify extracts the service and operation name of an AWS API request,
+// the way the API proxy routes it and its handler reads the operation. It
+// returns false for requests that are not AWS API calls KECS serves, such as
+// calls forwarded to LocalStack. Requests that reach an API handler are always
+// identified, with an empty name when they carry no operation.
+// Query protocol requests carry the operation in the Action parameter, so
+// their body is read and replaced with an equivalent reader.
+func Identify(r *http.Request) (Operation, bool) {
+	service := RoutedService(r)
+	switch service {
+	case "":
+		return Operation{}, false
+	case ServiceECS, ServiceServiceDiscovery:
+		return Operation{Service: service, Name: jsonOperation(r)}, true
+	}
+	if operation, ok := restServices[service]; ok {
+		return Operation{Service: service, Name: operation(r)}, true
+	}
+	for _, p := range pathServices {
+		if p.service == service {
+			return Operation{Service: service, Name: strings.TrimPrefix(r.URL.Path, p.prefix)}, true
+		}
+	}
+
+	op := Operation{Service: service, QueryProtocol: true, Params: url.Values{}}
+	if body, err := ReadBody(r); err == nil {
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			op.Params = values
+		}
+	}
+	op.Name = op.Params.Get("Action")
+	switch {
+	case service == ServiceELBv2 && r.Method != http.MethodPost:
+		// The ELBv2 router reads the body of POST requests only
+		op.Name = jsonOperation(r)
+	case service == ServiceSTS && op.Name == "":
+		// STS parses the query string along with the body
+		op.Name = r.URL.Query().Get("Action")
+	}
+	return op, true
+}
+
+// jsonOperation returns the operation of a JSON protocol request the way the
+// generated routers read it: from X-Amz-Target, the /v1/<Operation> path or
+// the Action query parameter
+func jsonOperation(r *http.Request) string {
+	if parts := strings.Split(r.Header.Get("X-Amz-Target"), "."); len(parts) > 1 {
+		return parts[1]
+	}
+	if path, found := strings.CutPrefix(r.URL.Path, "/v1/"); found {
+		name, _, _ := strings.Cut(path, "/")
+		return name
+	}
+	return r.URL.Query().Get("Action")
+}
+
+// ReadBody reads the request body and replaces it, so that handlers further
+// down the chain can read it again
+func ReadBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, err
+}
+
+// Credential is the credential scope of a SigV4 Authorization header
+type Credential struct {
+	AccessKeyID string
+	Date        string
+	Region      string
+	Service     string
+}
+
+// ParseCredential parses the credential scope of a SigV4 Authorization header,
+// e.g. Credential=AKID/20240101/us-east-1/ecs/aws4_request. It returns an empty
+// credential for unsigned requests.
+func ParseCredential(authorization string) Credential {
+	_, credential, found := strings.Cut(authorization, "Credential=")
+	if !found {
+		return Credential{}
+	}
+	credential, _, _ = strings.Cut(credential, ",")
+	parts := strings.Split(strings.TrimSpace(credential), "/")
+	if len(parts) != 5 || parts[4] != "aws4_request" {
+		return Credential{}
+	}
+	return Credential{
+		AccessKeyID: parts[0],
+		Date:        parts[1],
+		Region:      parts[2],
+		Service:     parts[3],
+	}
+}
+
+// queryErrorResponse is the error document of query protocol services
+type queryErrorResponse struct {
+	XMLName xml.Name `xml:"ErrorResponse"`
+	Error   struct {
+		Type    string `xml:"Type"`
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	} `xml:"Error"`
+	RequestID string `xml:"RequestId"`
+}
+
+// WriteError writes an AWS error in the wire format of the calling service
+func WriteError(w http.ResponseWriter, queryProtocol bool, status int, code, message string) {
+	requestID := deterministic.NewUUID()
+	w.Header().Set("x-amzn-RequestId", requestID)
+
+	if queryProtocol {
+		resp := queryErrorResponse{RequestID: requestID}
+		resp.Error.Type = "Sender"
+		if status >= http.StatusInternalServerError {
+			resp.Error.Type = "Receiver"
+		}
+		resp.Error.Code = code
+		resp.Error.Message = message
+		w.Header().Set("Content-Type", "text/xml")
+		w.WriteHeader(status)
+		if err := xml.NewEncoder(w).Encode(resp); err != nil {
+			logging.Error("Failed to encode error response", "error", err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]string{
+		"__type":  code,
+		"message": message,
+	}); err != nil {
+		logging.Error("Failed to encode error response", "error", err)
+	}
+}
+
+// ErrorCode extracts the error code from a JSON or XML AWS error response
+func ErrorCode(body []byte, queryProtocol bool) string {
+	if queryProtocol {
+		var resp struct {
+			Error struct {
+				Code string `xml:"Code"`
+			} `xml:"Error"`
+		}
+		if err := xml.Unmarshal(body, &resp); err == nil {
+			return resp.Error.Code
+		}
+		return ""
+	}
+
+	var resp struct {
+		Type string `json:"__type"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return ""
+	}
+	// Some services prefix the code with a namespace, e.g. com.amazonaws.ecs#ClientException
+	if _, code, found := strings.Cut(resp.Type, "#"); found {
+		return code
+	}
+	return resp.Type
+}
@@ -0,0 +1,13 @@
+package awsrequest_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestAWSRequest(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "AWSRequest Suite")
+}
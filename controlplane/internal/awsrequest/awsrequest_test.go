@@ -0,0 +1,143 @@
+package awsrequest_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsrequest"
+)
+
+// signedFor sets an Authorization header whose credential scope names service
+func signedFor(req *http.Request, service string) *http.Request {
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20251015/us-east-1/"+service+"/aws4_request, SignedHeaders=host, Signature=abc")
+	return req
+}
+
+func formRequest(method, target, body string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+var _ = Describe("Identify", func() {
+	It("identifies ECS calls by X-Amz-Target", func() {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{}"))
+		req.Header.Set("X-Amz-Target", "AmazonEC2ContainerServiceV20141113.CreateCluster")
+
+		op, ok := awsrequest.Identify(req)
+		Expect(ok).To(BeTrue())
+		Expect(op.Service).To(Equal(awsrequest.ServiceECS))
+		Expect(op.Name).To(Equal("CreateCluster"))
+		Expect(op.QueryProtocol).To(BeFalse())
+	})
+
+	It("identifies ECS calls sent to /v1/<Operation> without target or signature", func() {
+		op, ok := awsrequest.Identify(httptest.NewRequest(http.MethodPost, "/v1/DeleteCluster", strings.NewReader("{}")))
+		Expect(ok).To(BeTrue())
+		Expect(op.Service).To(Equal(awsrequest.ServiceECS))
+		Expect(op.Name).To(Equal("DeleteCluster"))
+		Expect(op.Mutating()).To(BeTrue())
+	})
+
+	It("takes the service from the routed target rather than the signing name", func() {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{}"))
+		req.Header.Set("X-Amz-Target", "AmazonEC2ContainerServiceV20141113.DeleteService")
+
+		op, ok := awsrequest.Identify(signedFor(req, "ecsx"))
+		Expect(ok).To(BeTrue())
+		Expect(op.Service).To(Equal(awsrequest.ServiceECS))
+	})
+
+	It("identifies Service Discovery calls", func() {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{}"))
+		req.Header.Set("X-Amz-Target", "Route53AutoNaming_v20170314.CreateService")
+
+		op, ok := awsrequest.Identify(req)
+		Expect(ok).To(BeTrue())
+		Expect(op.Service).To(Equal(awsrequest.ServiceServiceDiscovery))
+		Expect(op.Name).To(Equal("CreateService"))
+	})
+
+	It("identifies form encoded calls as ELBv2 unless signed for another query protocol service", func() {
+		op, ok := awsrequest.Identify(signedFor(formRequest(http.MethodPost, "/", "Action=CreateLoadBalancer&Name=web"), "ecs"))
+		Expect(ok).To(BeTrue())
+		Expect(op.Service).To(Equal(awsrequest.ServiceELBv2))
+		Expect(op.Name).To(Equal("CreateLoadBalancer"))
+		Expect(op.QueryProtocol).To(BeTrue())
+		Expect(op.Params.Get("Name")).To(Equal("web"))
+
+		for _, service := range []string{awsrequest.ServiceEC2, awsrequest.ServiceCloudFormation, awsrequest.ServiceSTS} {
+			op, ok := awsrequest.Identify(signedFor(formRequest(http.MethodPost, "/", "Action=Anything"), service))
+			Expect(ok).To(BeTrue())
+			Expect(op.Service).To(Equal(service))
+		}
+	})
+
+	It("keeps the body of query protocol calls readable", func() {
+		req := formRequest(http.MethodPost, "/", "Action=DeleteLoadBalancer")
+		_, ok := awsrequest.Identify(req)
+		Expect(ok).To(BeTrue())
+
+		body, err := io.ReadAll(req.Body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(body)).To(Equal("Action=DeleteLoadBalancer"))
+	})
+
+	It("reads the operation from the query string where the handler does", func() {
+		op, ok := awsrequest.Identify(formRequest(http.MethodGet, "/?Action=DeleteListener", ""))
+		Expect(ok).To(BeTrue())
+		Expect(op.Service).To(Equal(awsrequest.ServiceELBv2))
+		Expect(op.Name).To(Equal("DeleteListener"))
+
+		op, ok = awsrequest.Identify(signedFor(formRequest(http.MethodPost, "/?Action=AssumeRole", "RoleArn=x"), awsrequest.ServiceSTS))
+		Expect(ok).To(BeTrue())
+		Expect(op.Name).To(Equal("AssumeRole"))
+
+		// The ELBv2 router reads POST bodies only
+		op, ok = awsrequest.Identify(formRequest(http.MethodPost, "/?Action=DescribeLoadBalancers", "Action=DeleteLoadBalancer"))
+		Expect(ok).To(BeTrue())
+		Expect(op.Name).To(Equal("DeleteLoadBalancer"))
+	})
+
+	It("identifies calls without operation that reach an API handler", func() {
+		op, ok := awsrequest.Identify(formRequest(http.MethodPost, "/", "Version=2015-12-01"))
+		Expect(ok).To(BeTrue())
+		Expect(op.Service).To(Equal(awsrequest.ServiceELBv2))
+		Expect(op.Name).To(BeEmpty())
+		Expect(op.Mutating()).To(BeTrue())
+	})
+
+	It("identifies calls of registered REST services by their signing name", func() {
+		awsrequest.RegisterRESTService("example", func(r *http.Request) string {
+			return r.Method + r.URL.Path
+		})
+		op, ok := awsrequest.Identify(signedFor(httptest.NewRequest(http.MethodDelete, "/things/1", nil), "example"))
+		Expect(ok).To(BeTrue())
+		Expect(op.Service).To(Equal("example"))
+		Expect(op.Name).To(Equal("DELETE/things/1"))
+	})
+
+	It("identifies calls of registered path services by their path", func() {
+		awsrequest.RegisterPathService("/example.v1.Service/", "example.v1")
+		op, ok := awsrequest.Identify(signedFor(httptest.NewRequest(http.MethodPost, "/example.v1.Service/Watch", nil), "ecs"))
+		Expect(ok).To(BeTrue())
+		Expect(op.Service).To(Equal("example.v1"))
+		Expect(op.Name).To(Equal("Watch"))
+	})
+
+	It("does not identify requests forwarded to LocalStack", func() {
+		req := httptest.NewRequest(http.MethodPut, "/bucket/key", strings.NewReader("data"))
+		_, ok := awsrequest.Identify(signedFor(req, "s3"))
+		Expect(ok).To(BeFalse())
+
+		req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{}"))
+		req.Header.Set("X-Amz-Target", "AmazonSSM.GetParameter")
+		_, ok = awsrequest.Identify(signedFor(req, "ssm"))
+		Expect(ok).To(BeFalse())
+	})
+})
@@ -0,0 +1,203 @@
+package awsstub
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Alarm states
+const (
+	alarmStateOK               = "OK"
+	alarmStateAlarm            = "ALARM"
+	alarmStateInsufficientData = "INSUFFICIENT_DATA"
+)
+
+// metricRetention is how long data points are kept, the longest an alarm can
+// evaluate as CloudWatch limits Period * EvaluationPeriods to a day
+const metricRetention = 24 * time.Hour
+
+// comparisons are the threshold comparisons of metric alarms and how they
+// read in state reasons
+var comparisons = map[string]struct {
+	breaches func(value, threshold float64) bool
+	text     string
+}{
+	"GreaterThanOrEqualToThreshold": {func(v, t float64) bool { return v >= t }, "greater than or equal to"},
+	"GreaterThanThreshold":          {func(v, t float64) bool { return v > t }, "greater than"},
+	"LessThanThreshold":             {func(v, t float64) bool { return v < t }, "less than"},
+	"LessThanOrEqualToThreshold":    {func(v, t float64) bool { return v <= t }, "less than or equal to"},
+}
+
+// statistics are the statistics of metric alarms
+var statistics = map[string]func(values []float64) float64{
+	"SampleCount": func(values []float64) float64 { return float64(len(values)) },
+	"Sum":         sum,
+	"Average":     func(values []float64) float64 { return sum(values) / float64(len(values)) },
+	"Minimum": func(values []float64) float64 {
+		m := math.Inf(1)
+		for _, v := range values {
+			m = math.Min(m, v)
+		}
+		return m
+	},
+	"Maximum": func(values []float64) float64 {
+		m := math.Inf(-1)
+		for _, v := range values {
+			m = math.Max(m, v)
+		}
+		return m
+	},
+}
+
+// metricAlarm is a CloudWatch metric alarm
+type metricAlarm struct {
+	Name                    string            `json:"name"`
+	ARN                     string            `json:"arn"`
+	Description             string            `json:"description,omitempty"`
+	Namespace               string            `json:"namespace"`
+	MetricName              string            `json:"metricName"`
+	Dimensions              map[string]string `json:"dimensions,omitempty"`
+	Statistic               string            `json:"statistic"`
+	Unit                    string            `json:"unit,omitempty"`
+	Period                  int               `json:"period"`
+	EvaluationPeriods       int               `json:"evaluationPeriods"`
+	DatapointsToAlarm       int               `json:"datapointsToAlarm"`
+	Threshold               float64           `json:"threshold"`
+	ComparisonOperator      string            `json:"comparisonOperator"`
+	TreatMissingData        string            `json:"treatMissingData"`
+	ActionsEnabled          bool              `json:"actionsEnabled"`
+	AlarmActions            []string          `json:"alarmActions,omitempty"`
+	OKActions               []string          `json:"okActions,omitempty"`
+	InsufficientDataActions []string          `json:"insufficientDataActions,omitempty"`
+	StateValue              string            `json:"stateValue"`
+	StateReason             string            `json:"stateReason"`
+	StateUpdated            time.Time         `json:"stateUpdated"`
+	ConfigurationUpdated    time.Time         `json:"configurationUpdated"`
+	// StateSetUntil is when a state set with SetAlarmState is evaluated again
+	StateSetUntil time.Time `json:"stateSetUntil,omitempty"`
+}
+
+// datapoint is a value published to a metric
+type datapoint struct {
+	Timestamp time.Time
+	Value     float64
+}
+
+// metricKey identifies a metric by its namespace, name and dimensions
+func metricKey(namespace, name string, dimensions map[string]string) string {
+	names := make([]string, 0, len(dimensions))
+	for dimension := range dimensions {
+		names = append(names, dimension)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(namespace + "|" + name)
+	for _, dimension := range names {
+		b.WriteString("|" + dimension + "=" + dimensions[dimension])
+	}
+	return b.String()
+}
+
+// addDatapoints stores data points of a metric and drops those older than
+// metricRetention, the caller holds s.mu
+func (s *Server) addDatapoints(key string, points []datapoint, now time.Time) {
+	series := append(s.metrics[key], points...)
+	kept := series[:0]
+	for _, p := range series {
+		if now.Sub(p.Timestamp) < metricRetention {
+			kept = append(kept, p)
+		}
+	}
+	s.metrics[key] = kept
+}
+
+// evaluateAlarms updates the state of every alarm from its metric, the
+// caller holds s.mu
+func (s *Server) evaluateAlarms(now time.Time) {
+	changed := false
+	for _, alarm := range s.state.Alarms {
+		if now.Before(alarm.StateSetUntil) {
+			continue
+		}
+		state, reason, ok := s.evaluate(alarm, now)
+		if !ok || state == alarm.StateValue {
+			continue
+		}
+		alarm.StateValue = state
+		alarm.StateReason = reason
+		alarm.StateUpdated = now
+		changed = true
+	}
+	if changed {
+		s.save()
+	}
+}
+
+// evaluate returns the state of an alarm from the data points of its last
+// evaluation periods. It returns false when the state is to be kept, which
+// is the case when all data is missing and missing data is ignored.
+func (s *Server) evaluate(alarm *metricAlarm, now time.Time) (string, string, bool) {
+	comparison := comparisons[alarm.ComparisonOperator]
+	statistic := statistics[alarm.Statistic]
+	period := time.Duration(alarm.Period) * time.Second
+	series := s.metrics[metricKey(alarm.Namespace, alarm.MetricName, alarm.Dimensions)]
+
+	breaching, missing := 0, 0
+	var values []string
+	for i := 0; i < alarm.EvaluationPeriods; i++ {
+		end := now.Add(-time.Duration(i) * period)
+		var inPeriod []float64
+		for _, p := range series {
+			if !p.Timestamp.Before(end.Add(-period)) && p.Timestamp.Before(end) {
+				inPeriod = append(inPeriod, p.Value)
+			}
+		}
+		if len(inPeriod) == 0 {
+			missing++
+			continue
+		}
+		value := statistic(inPeriod)
+		values = append(values, strconv.FormatFloat(value, 'f', 1, 64))
+		if comparison.breaches(value, alarm.Threshold) {
+			breaching++
+		}
+	}
+
+	switch alarm.TreatMissingData {
+	case "breaching":
+		breaching += missing
+	case "ignore":
+		if missing == alarm.EvaluationPeriods {
+			return "", "", false
+		}
+	case "notBreaching":
+	default:
+		if missing == alarm.EvaluationPeriods {
+			return alarmStateInsufficientData, fmt.Sprintf("Insufficient Data: %d datapoints were unknown.", missing), true
+		}
+	}
+
+	if breaching >= alarm.DatapointsToAlarm {
+		return alarmStateAlarm, fmt.Sprintf("Threshold Crossed: %d out of the last %d datapoints [%s] were %s the threshold (%s).",
+			breaching, alarm.EvaluationPeriods, strings.Join(values, ", "), comparison.text, formatThreshold(alarm.Threshold)), true
+	}
+	return alarmStateOK, fmt.Sprintf("Threshold Crossed: %d out of the last %d datapoints [%s] were not %s the threshold (%s).",
+		alarm.EvaluationPeriods-breaching, alarm.EvaluationPeriods, strings.Join(values, ", "), comparison.text, formatThreshold(alarm.Threshold)), true
+}
+
+func formatThreshold(threshold float64) string {
+	return strconv.FormatFloat(threshold, 'f', 1, 64)
+}
+
+func sum(values []float64) float64 {
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
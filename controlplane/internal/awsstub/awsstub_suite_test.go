@@ -0,0 +1,13 @@
+package awsstub_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestAWSStub(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "AWS Stub Suite")
+}
@@ -0,0 +1,420 @@
+package awsstub
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
+)
+
+const (
+	cloudWatchVersion   = "2010-08-01"
+	cloudWatchNamespace = "http://monitoring.amazonaws.com/doc/2010-08-01/"
+)
+
+// isCloudWatchRequest reports whether a request is a CloudWatch call of the
+// query protocol, the only protocol the stub serves CloudWatch with
+func isCloudWatchRequest(r *http.Request) bool {
+	if r.Method != http.MethodPost || r.URL.Path != "/" ||
+		!strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		return false
+	}
+	return r.ParseForm() == nil && r.PostForm.Get("Version") == cloudWatchVersion
+}
+
+// handleCloudWatch serves the metric and alarm operations of CloudWatch.
+// Metric data is kept in memory, alarms are persisted with the state and
+// evaluated whenever data is published or alarms are read. Alarm actions are
+// recorded but not invoked.
+func (s *Server) handleCloudWatch(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	form := r.PostForm
+	action := form.Get("Action")
+	now := time.Now()
+	switch action {
+	case "PutMetricData":
+		s.putMetricData(w, form, now)
+	case "PutMetricAlarm":
+		s.putMetricAlarm(w, form, now)
+	case "DescribeAlarms":
+		s.evaluateAlarms(now)
+		s.describeAlarms(w, form)
+	case "DeleteAlarms":
+		for _, name := range members(form, "AlarmNames") {
+			delete(s.state.Alarms, name)
+		}
+		s.save()
+		writeQuery(w, action, nil)
+	case "SetAlarmState":
+		alarm, ok := s.state.Alarms[form.Get("AlarmName")]
+		if !ok {
+			writeQueryError(w, http.StatusNotFound, "ResourceNotFound", fmt.Sprintf("Alarm %s not found", form.Get("AlarmName")))
+			return
+		}
+		state := form.Get("StateValue")
+		if state != alarmStateOK && state != alarmStateAlarm && state != alarmStateInsufficientData {
+			writeQueryError(w, http.StatusBadRequest, "ValidationError", fmt.Sprintf("Invalid state value %q", state))
+			return
+		}
+		// The state holds until the alarm is evaluated again one period later,
+		// as in CloudWatch
+		alarm.StateValue = state
+		alarm.StateReason = form.Get("StateReason")
+		alarm.StateUpdated = now
+		alarm.StateSetUntil = now.Add(time.Duration(alarm.Period) * time.Second)
+		s.save()
+		writeQuery(w, action, nil)
+	default:
+		writeQueryError(w, http.StatusBadRequest, "InvalidAction",
+			fmt.Sprintf("CloudWatch %s is not served by the KECS AWS stub", action))
+	}
+}
+
+// putMetricData stores the data points of a PutMetricData call
+func (s *Server) putMetricData(w http.ResponseWriter, form url.Values, now time.Time) {
+	namespace := form.Get("Namespace")
+	if namespace == "" {
+		writeQueryError(w, http.StatusBadRequest, "MissingParameter", "The parameter Namespace is required.")
+		return
+	}
+
+	points := make(map[string][]datapoint)
+	for i := 1; form.Has(fmt.Sprintf("MetricData.member.%d.MetricName", i)); i++ {
+		prefix := fmt.Sprintf("MetricData.member.%d.", i)
+		timestamp := now
+		if value := form.Get(prefix + "Timestamp"); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				writeQueryError(w, http.StatusBadRequest, "InvalidParameterValue", fmt.Sprintf("Invalid timestamp %q", value))
+				return
+			}
+			timestamp = parsed
+		}
+
+		var values []float64
+		if value := form.Get(prefix + "Value"); value != "" {
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				writeQueryError(w, http.StatusBadRequest, "InvalidParameterValue", fmt.Sprintf("Invalid value %q", value))
+				return
+			}
+			values = append(values, v)
+		}
+		// Values are repeated as often as their count says, 1 by default
+		counts := members(form, prefix+"Counts")
+		for j, value := range members(form, prefix+"Values") {
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				writeQueryError(w, http.StatusBadRequest, "InvalidParameterValue", fmt.Sprintf("Invalid value %q", value))
+				return
+			}
+			count := 1
+			if j < len(counts) {
+				if c, err := strconv.ParseFloat(counts[j], 64); err == nil {
+					count = int(c)
+				}
+			}
+			for ; count > 0; count-- {
+				values = append(values, v)
+			}
+		}
+
+		key := metricKey(namespace, form.Get(prefix+"MetricName"), dimensions(form, prefix+"Dimensions"))
+		for _, v := range values {
+			points[key] = append(points[key], datapoint{Timestamp: timestamp, Value: v})
+		}
+	}
+
+	for key, series := range points {
+		s.addDatapoints(key, series, now)
+	}
+	s.evaluateAlarms(now)
+	writeQuery(w, "PutMetricData", nil)
+}
+
+// putMetricAlarm creates or replaces a metric alarm. A new alarm starts in
+// INSUFFICIENT_DATA and is evaluated right away.
+func (s *Server) putMetricAlarm(w http.ResponseWriter, form url.Values, now time.Time) {
+	name := form.Get("AlarmName")
+	for _, required := range []string{"AlarmName", "MetricName", "Namespace", "Statistic", "Period", "EvaluationPeriods", "Threshold", "ComparisonOperator"} {
+		if form.Get(required) == "" {
+			if required == "Statistic" && form.Get("ExtendedStatistic") != "" {
+				writeQueryError(w, http.StatusBadRequest, "ValidationError", "Extended statistics are not supported by the KECS AWS stub")
+				return
+			}
+			if required == "MetricName" && form.Has("Metrics.member.1.Id") {
+				writeQueryError(w, http.StatusBadRequest, "ValidationError", "Metric math alarms are not supported by the KECS AWS stub")
+				return
+			}
+			writeQueryError(w, http.StatusBadRequest, "MissingParameter", fmt.Sprintf("The parameter %s is required.", required))
+			return
+		}
+	}
+	if _, ok := statistics[form.Get("Statistic")]; !ok {
+		writeQueryError(w, http.StatusBadRequest, "ValidationError", fmt.Sprintf("Invalid statistic %q", form.Get("Statistic")))
+		return
+	}
+	if _, ok := comparisons[form.Get("ComparisonOperator")]; !ok {
+		writeQueryError(w, http.StatusBadRequest, "ValidationError",
+			fmt.Sprintf("Comparison operator %q is not supported by the KECS AWS stub", form.Get("ComparisonOperator")))
+		return
+	}
+	period, err := strconv.Atoi(form.Get("Period"))
+	if err != nil || period <= 0 {
+		writeQueryError(w, http.StatusBadRequest, "ValidationError", "Period must be a positive number of seconds")
+		return
+	}
+	evaluationPeriods, err := strconv.Atoi(form.Get("EvaluationPeriods"))
+	if err != nil || evaluationPeriods <= 0 {
+		writeQueryError(w, http.StatusBadRequest, "ValidationError", "EvaluationPeriods must be a positive number")
+		return
+	}
+	datapointsToAlarm := evaluationPeriods
+	if value := form.Get("DatapointsToAlarm"); value != "" {
+		datapointsToAlarm, err = strconv.Atoi(value)
+		if err != nil || datapointsToAlarm <= 0 || datapointsToAlarm > evaluationPeriods {
+			writeQueryError(w, http.StatusBadRequest, "ValidationError", "DatapointsToAlarm must be between 1 and EvaluationPeriods")
+			return
+		}
+	}
+	if time.Duration(period*evaluationPeriods)*time.Second > metricRetention {
+		writeQueryError(w, http.StatusBadRequest, "ValidationError", "Period * EvaluationPeriods must not exceed one day")
+		return
+	}
+	threshold, err := strconv.ParseFloat(form.Get("Threshold"), 64)
+	if err != nil {
+		writeQueryError(w, http.StatusBadRequest, "ValidationError", fmt.Sprintf("Invalid threshold %q", form.Get("Threshold")))
+		return
+	}
+	treatMissingData := form.Get("TreatMissingData")
+	switch treatMissingData {
+	case "":
+		treatMissingData = "missing"
+	case "missing", "breaching", "notBreaching", "ignore":
+	default:
+		writeQueryError(w, http.StatusBadRequest, "ValidationError", fmt.Sprintf("Invalid TreatMissingData %q", treatMissingData))
+		return
+	}
+
+	alarm := &metricAlarm{
+		Name:                    name,
+		ARN:                     awsarn.Build("cloudwatch", s.region, s.accountID, "alarm:"+name),
+		Description:             form.Get("AlarmDescription"),
+		Namespace:               form.Get("Namespace"),
+		MetricName:              form.Get("MetricName"),
+		Dimensions:              dimensions(form, "Dimensions"),
+		Statistic:               form.Get("Statistic"),
+		Unit:                    form.Get("Unit"),
+		Period:                  period,
+		EvaluationPeriods:       evaluationPeriods,
+		DatapointsToAlarm:       datapointsToAlarm,
+		Threshold:               threshold,
+		ComparisonOperator:      form.Get("ComparisonOperator"),
+		TreatMissingData:        treatMissingData,
+		ActionsEnabled:          form.Get("ActionsEnabled") != "false",
+		AlarmActions:            members(form, "AlarmActions"),
+		OKActions:               members(form, "OKActions"),
+		InsufficientDataActions: members(form, "InsufficientDataActions"),
+		StateValue:              alarmStateInsufficientData,
+		StateReason:             "Unchecked: Initial alarm creation",
+		StateUpdated:            now,
+		ConfigurationUpdated:    now,
+	}
+	if existing, ok := s.state.Alarms[name]; ok {
+		alarm.StateValue = existing.StateValue
+		alarm.StateReason = existing.StateReason
+		alarm.StateUpdated = existing.StateUpdated
+	}
+	s.state.Alarms[name] = alarm
+	s.evaluateAlarms(now)
+	s.save()
+	writeQuery(w, "PutMetricAlarm", nil)
+}
+
+// describeAlarms returns the metric alarms matching the names, name prefix
+// and state of a DescribeAlarms call
+func (s *Server) describeAlarms(w http.ResponseWriter, form url.Values) {
+	names := members(form, "AlarmNames")
+	prefix := form.Get("AlarmNamePrefix")
+	state := form.Get("StateValue")
+	types := members(form, "AlarmTypes")
+
+	result := &describeAlarmsResult{}
+	if len(types) == 0 || contains(types, "MetricAlarm") {
+		for _, name := range s.alarmNames() {
+			alarm := s.state.Alarms[name]
+			if (len(names) > 0 && !contains(names, name)) || !strings.HasPrefix(name, prefix) ||
+				(state != "" && alarm.StateValue != state) {
+				continue
+			}
+			result.MetricAlarms = append(result.MetricAlarms, toXMLAlarm(alarm))
+		}
+	}
+	writeQuery(w, "DescribeAlarms", result)
+}
+
+// alarmNames returns the names of all alarms in order
+func (s *Server) alarmNames() []string {
+	names := make([]string, 0, len(s.state.Alarms))
+	for name := range s.state.Alarms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// members returns the values of a list parameter of the query protocol,
+// given as name.member.1, name.member.2 and so on
+func members(form url.Values, name string) []string {
+	var values []string
+	for i := 1; form.Has(fmt.Sprintf("%s.member.%d", name, i)); i++ {
+		values = append(values, form.Get(fmt.Sprintf("%s.member.%d", name, i)))
+	}
+	return values
+}
+
+// dimensions returns the dimensions of a query protocol parameter
+func dimensions(form url.Values, name string) map[string]string {
+	result := make(map[string]string)
+	for i := 1; form.Has(fmt.Sprintf("%s.member.%d.Name", name, i)); i++ {
+		result[form.Get(fmt.Sprintf("%s.member.%d.Name", name, i))] = form.Get(fmt.Sprintf("%s.member.%d.Value", name, i))
+	}
+	return result
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+type describeAlarmsResult struct {
+	XMLName         xml.Name   `xml:"DescribeAlarmsResult"`
+	MetricAlarms    []xmlAlarm `xml:"MetricAlarms>member"`
+	CompositeAlarms struct{}   `xml:"CompositeAlarms"`
+}
+
+type xmlDimension struct {
+	Name  string `xml:"Name"`
+	Value string `xml:"Value"`
+}
+
+type xmlAlarm struct {
+	AlarmName                          string         `xml:"AlarmName"`
+	AlarmArn                           string         `xml:"AlarmArn"`
+	AlarmDescription                   string         `xml:"AlarmDescription,omitempty"`
+	AlarmConfigurationUpdatedTimestamp string         `xml:"AlarmConfigurationUpdatedTimestamp"`
+	ActionsEnabled                     bool           `xml:"ActionsEnabled"`
+	OKActions                          []string       `xml:"OKActions>member"`
+	AlarmActions                       []string       `xml:"AlarmActions>member"`
+	InsufficientDataActions            []string       `xml:"InsufficientDataActions>member"`
+	StateValue                         string         `xml:"StateValue"`
+	StateReason                        string         `xml:"StateReason"`
+	StateUpdatedTimestamp              string         `xml:"StateUpdatedTimestamp"`
+	MetricName                         string         `xml:"MetricName"`
+	Namespace                          string         `xml:"Namespace"`
+	Statistic                          string         `xml:"Statistic"`
+	Dimensions                         []xmlDimension `xml:"Dimensions>member"`
+	Period                             int            `xml:"Period"`
+	Unit                               string         `xml:"Unit,omitempty"`
+	EvaluationPeriods                  int            `xml:"EvaluationPeriods"`
+	DatapointsToAlarm                  int            `xml:"DatapointsToAlarm"`
+	Threshold                          float64        `xml:"Threshold"`
+	ComparisonOperator                 string         `xml:"ComparisonOperator"`
+	TreatMissingData                   string         `xml:"TreatMissingData"`
+}
+
+func toXMLAlarm(alarm *metricAlarm) xmlAlarm {
+	names := make([]string, 0, len(alarm.Dimensions))
+	for name := range alarm.Dimensions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	dims := make([]xmlDimension, 0, len(names))
+	for _, name := range names {
+		dims = append(dims, xmlDimension{Name: name, Value: alarm.Dimensions[name]})
+	}
+
+	return xmlAlarm{
+		AlarmName:                          alarm.Name,
+		AlarmArn:                           alarm.ARN,
+		AlarmDescription:                   alarm.Description,
+		AlarmConfigurationUpdatedTimestamp: alarm.ConfigurationUpdated.UTC().Format(time.RFC3339Nano),
+		ActionsEnabled:                     alarm.ActionsEnabled,
+		OKActions:                          alarm.OKActions,
+		AlarmActions:                       alarm.AlarmActions,
+		InsufficientDataActions:            alarm.InsufficientDataActions,
+		StateValue:                         alarm.StateValue,
+		StateReason:                        alarm.StateReason,
+		StateUpdatedTimestamp:              alarm.StateUpdated.UTC().Format(time.RFC3339Nano),
+		MetricName:                         alarm.MetricName,
+		Namespace:                          alarm.Namespace,
+		Statistic:                          alarm.Statistic,
+		Dimensions:                         dims,
+		Period:                             alarm.Period,
+		Unit:                               alarm.Unit,
+		EvaluationPeriods:                  alarm.EvaluationPeriods,
+		DatapointsToAlarm:                  alarm.DatapointsToAlarm,
+		Threshold:                          alarm.Threshold,
+		ComparisonOperator:                 alarm.ComparisonOperator,
+		TreatMissingData:                   alarm.TreatMissingData,
+	}
+}
+
+// queryResponse is the envelope of a query protocol response, named after its action
+type queryResponse struct {
+	XMLName          xml.Name
+	Xmlns            string      `xml:"xmlns,attr"`
+	Result           interface{} `xml:",omitempty"`
+	ResponseMetadata struct {
+		RequestID string `xml:"RequestId"`
+	} `xml:"ResponseMetadata"`
+}
+
+// writeQuery writes a query protocol response of action. A result names its
+// own <Action>Result element.
+func writeQuery(w http.ResponseWriter, action string, result interface{}) {
+	response := queryResponse{
+		XMLName: xml.Name{Local: action + "Response"},
+		Xmlns:   cloudWatchNamespace,
+		Result:  result,
+	}
+	response.ResponseMetadata.RequestID = uuid.New().String()
+	writeXML(w, response)
+}
+
+type queryError struct {
+	XMLName xml.Name `xml:"ErrorResponse"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Error   struct {
+		Type    string `xml:"Type"`
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	} `xml:"Error"`
+	RequestID string `xml:"RequestId"`
+}
+
+// writeQueryError writes an error of the query protocol
+func writeQueryError(w http.ResponseWriter, status int, code, message string) {
+	response := queryError{Xmlns: cloudWatchNamespace, RequestID: uuid.New().String()}
+	response.Error.Type = "Sender"
+	response.Error.Code = code
+	response.Error.Message = message
+	w.Header().Set("Content-Type", "text/xml")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(xml.Header))
+	_ = xml.NewEncoder(w).Encode(response)
+}
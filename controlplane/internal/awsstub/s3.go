@@ -0,0 +1,419 @@
+package awsstub
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bucket is an S3 bucket
+type bucket struct {
+	Name    string             `json:"name"`
+	Created time.Time          `json:"created"`
+	Objects map[string]*object `json:"objects"`
+}
+
+// object is an S3 object. Its content is a file of the stub directory, or
+// data when the state is kept in memory.
+type object struct {
+	Key          string            `json:"key"`
+	Size         int64             `json:"size"`
+	ETag         string            `json:"etag"`
+	ContentType  string            `json:"contentType,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	LastModified time.Time         `json:"lastModified"`
+	data         []byte
+}
+
+const s3Namespace = "http://s3.amazonaws.com/doc/2006-03-01/"
+
+// handleS3 serves path-style S3 requests: /bucket and /bucket/key
+func (s *Server) handleS3(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucketName, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	switch {
+	case bucketName == "" && r.Method == http.MethodGet:
+		s.listBuckets(w)
+	case bucketName == "":
+		writeS3Error(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed against this resource.", r.URL.Path)
+	case key == "":
+		s.handleBucket(w, r, bucketName)
+	default:
+		s.handleObject(w, r, bucketName, key)
+	}
+}
+
+func (s *Server) handleBucket(w http.ResponseWriter, r *http.Request, name string) {
+	b, exists := s.state.Buckets[name]
+	if r.Method == http.MethodPut {
+		if exists {
+			writeS3Error(w, http.StatusConflict, "BucketAlreadyOwnedByYou", "Your previous request to create the named bucket succeeded and you already own it.", "/"+name)
+			return
+		}
+		s.state.Buckets[name] = &bucket{Name: name, Created: time.Now().UTC(), Objects: make(map[string]*object)}
+		s.save()
+		w.Header().Set("Location", "/"+name)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if !exists {
+		writeS3Error(w, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist", "/"+name)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodHead:
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		s.listObjects(w, r, b)
+	case http.MethodDelete:
+		if len(b.Objects) > 0 {
+			writeS3Error(w, http.StatusConflict, "BucketNotEmpty", "The bucket you tried to delete is not empty", "/"+name)
+			return
+		}
+		delete(s.state.Buckets, name)
+		if s.dir != "" {
+			os.RemoveAll(s.bucketDir(name))
+		}
+		s.save()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeS3Error(w, http.StatusNotImplemented, "NotImplemented", "The operation is not supported by the KECS AWS stub", "/"+name)
+	}
+}
+
+func (s *Server) handleObject(w http.ResponseWriter, r *http.Request, bucketName, key string) {
+	resource := "/" + bucketName + "/" + key
+	b, ok := s.state.Buckets[bucketName]
+	if !ok {
+		writeS3Error(w, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist", resource)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		if r.Header.Get("X-Amz-Copy-Source") != "" || r.URL.Query().Has("uploadId") {
+			writeS3Error(w, http.StatusNotImplemented, "NotImplemented", "Copies and multipart uploads are not supported by the KECS AWS stub", resource)
+			return
+		}
+		s.putObject(w, r, b, key)
+	case http.MethodGet, http.MethodHead:
+		o, ok := b.Objects[key]
+		if !ok {
+			writeS3Error(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.", resource)
+			return
+		}
+		w.Header().Set("ETag", o.ETag)
+		w.Header().Set("Content-Length", strconv.FormatInt(o.Size, 10))
+		w.Header().Set("Last-Modified", o.LastModified.Format(http.TimeFormat))
+		contentType := o.ContentType
+		if contentType == "" {
+			contentType = "binary/octet-stream"
+		}
+		w.Header().Set("Content-Type", contentType)
+		for name, value := range o.Metadata {
+			w.Header().Set("X-Amz-Meta-"+name, value)
+		}
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		data, err := s.readObject(b.Name, o)
+		if err != nil {
+			writeS3Error(w, http.StatusInternalServerError, "InternalError", err.Error(), resource)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(data)
+	case http.MethodDelete:
+		if _, ok := b.Objects[key]; ok {
+			delete(b.Objects, key)
+			if s.dir != "" {
+				os.Remove(s.objectPath(b.Name, key))
+			}
+			s.save()
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeS3Error(w, http.StatusNotImplemented, "NotImplemented", "The operation is not supported by the KECS AWS stub", resource)
+	}
+}
+
+func (s *Server) putObject(w http.ResponseWriter, r *http.Request, b *bucket, key string) {
+	body, err := readPayload(r)
+	if err != nil {
+		writeS3Error(w, http.StatusBadRequest, "IncompleteBody", err.Error(), "/"+b.Name+"/"+key)
+		return
+	}
+
+	sum := md5.Sum(body)
+	o := &object{
+		Key:          key,
+		Size:         int64(len(body)),
+		ETag:         `"` + hex.EncodeToString(sum[:]) + `"`,
+		ContentType:  r.Header.Get("Content-Type"),
+		LastModified: time.Now().UTC(),
+	}
+	for name, values := range r.Header {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, "x-amz-meta-") && len(values) > 0 {
+			if o.Metadata == nil {
+				o.Metadata = make(map[string]string)
+			}
+			o.Metadata[strings.TrimPrefix(name, "x-amz-meta-")] = values[0]
+		}
+	}
+
+	if s.dir == "" {
+		o.data = body
+	} else {
+		path := s.objectPath(b.Name, key)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			writeS3Error(w, http.StatusInternalServerError, "InternalError", err.Error(), "/"+b.Name+"/"+key)
+			return
+		}
+		if err := os.WriteFile(path, body, 0600); err != nil {
+			writeS3Error(w, http.StatusInternalServerError, "InternalError", err.Error(), "/"+b.Name+"/"+key)
+			return
+		}
+	}
+	b.Objects[key] = o
+	s.save()
+
+	w.Header().Set("ETag", o.ETag)
+	w.WriteHeader(http.StatusOK)
+}
+
+// readPayload reads the body of an upload. SDKs stream uploads in
+// aws-chunked encoding, whose chunk framing and trailers are dropped.
+func readPayload(r *http.Request) ([]byte, error) {
+	chunked := strings.Contains(r.Header.Get("Content-Encoding"), "aws-chunked") ||
+		strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-")
+	if !chunked {
+		return io.ReadAll(r.Body)
+	}
+
+	var body bytes.Buffer
+	reader := bufio.NewReader(r.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("invalid aws-chunked payload: %w", err)
+		}
+		sizeField, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeField, 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid aws-chunked chunk size %q", sizeField)
+		}
+		if size == 0 {
+			// Trailers, such as checksums, follow the last chunk
+			return body.Bytes(), nil
+		}
+		if _, err := io.CopyN(&body, reader, size); err != nil {
+			return nil, fmt.Errorf("invalid aws-chunked payload: %w", err)
+		}
+		if _, err := reader.Discard(2); err != nil {
+			return nil, fmt.Errorf("invalid aws-chunked payload: %w", err)
+		}
+	}
+}
+
+func (s *Server) readObject(bucketName string, o *object) ([]byte, error) {
+	if s.dir == "" {
+		return o.data, nil
+	}
+	return os.ReadFile(s.objectPath(bucketName, o.Key))
+}
+
+func (s *Server) bucketDir(bucketName string) string {
+	return filepath.Join(s.dir, "objects", bucketName)
+}
+
+// objectPath returns the file of an object, named by the hash of its key as
+// keys may contain any character
+func (s *Server) objectPath(bucketName, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.bucketDir(bucketName), hex.EncodeToString(sum[:]))
+}
+
+type listAllMyBucketsResult struct {
+	XMLName xml.Name      `xml:"ListAllMyBucketsResult"`
+	Xmlns   string        `xml:"xmlns,attr"`
+	Owner   owner         `xml:"Owner"`
+	Buckets []bucketEntry `xml:"Buckets>Bucket"`
+}
+
+type owner struct {
+	ID          string `xml:"ID"`
+	DisplayName string `xml:"DisplayName"`
+}
+
+type bucketEntry struct {
+	Name         string `xml:"Name"`
+	CreationDate string `xml:"CreationDate"`
+}
+
+func (s *Server) listBuckets(w http.ResponseWriter) {
+	result := listAllMyBucketsResult{Xmlns: s3Namespace, Owner: owner{ID: s.accountID, DisplayName: "kecs"}}
+	names := make([]string, 0, len(s.state.Buckets))
+	for name := range s.state.Buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		result.Buckets = append(result.Buckets, bucketEntry{
+			Name:         name,
+			CreationDate: s.state.Buckets[name].Created.Format(time.RFC3339),
+		})
+	}
+	writeXML(w, result)
+}
+
+type listBucketResult struct {
+	XMLName               xml.Name       `xml:"ListBucketResult"`
+	Xmlns                 string         `xml:"xmlns,attr"`
+	Name                  string         `xml:"Name"`
+	Prefix                string         `xml:"Prefix"`
+	Delimiter             string         `xml:"Delimiter,omitempty"`
+	MaxKeys               int            `xml:"MaxKeys"`
+	IsTruncated           bool           `xml:"IsTruncated"`
+	Marker                *string        `xml:"Marker,omitempty"`
+	NextMarker            string         `xml:"NextMarker,omitempty"`
+	KeyCount              *int           `xml:"KeyCount,omitempty"`
+	ContinuationToken     string         `xml:"ContinuationToken,omitempty"`
+	NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
+	StartAfter            string         `xml:"StartAfter,omitempty"`
+	Contents              []objectEntry  `xml:"Contents"`
+	CommonPrefixes        []commonPrefix `xml:"CommonPrefixes"`
+}
+
+type objectEntry struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	Size         int64  `xml:"Size"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+type commonPrefix struct {
+	Prefix string `xml:"Prefix"`
+}
+
+// listObjects serves ListObjects and ListObjectsV2. Pages continue after the
+// last key or common prefix of the previous page.
+func (s *Server) listObjects(w http.ResponseWriter, r *http.Request, b *bucket) {
+	query := r.URL.Query()
+	v2 := query.Get("list-type") == "2"
+	prefix, delimiter := query.Get("prefix"), query.Get("delimiter")
+	maxKeys := 1000
+	if value := query.Get("max-keys"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 && n < maxKeys {
+			maxKeys = n
+		}
+	}
+	after := query.Get("marker")
+	if v2 {
+		after = query.Get("start-after")
+		if token := query.Get("continuation-token"); token != "" {
+			after = token
+		}
+	}
+
+	keys := make([]string, 0, len(b.Objects))
+	for key := range b.Objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := listBucketResult{Xmlns: s3Namespace, Name: b.Name, Prefix: prefix, Delimiter: delimiter, MaxKeys: maxKeys}
+	seenPrefixes := make(map[string]bool)
+	last := ""
+	count := 0
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) || key <= after {
+			continue
+		}
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+				common := key[:len(prefix)+i+len(delimiter)]
+				if seenPrefixes[common] || common <= after {
+					continue
+				}
+				if count == maxKeys {
+					result.IsTruncated = true
+					break
+				}
+				seenPrefixes[common] = true
+				result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{Prefix: common})
+				last = common
+				count++
+				continue
+			}
+		}
+		if count == maxKeys {
+			result.IsTruncated = true
+			break
+		}
+		o := b.Objects[key]
+		result.Contents = append(result.Contents, objectEntry{
+			Key:          key,
+			LastModified: o.LastModified.Format("2006-01-02T15:04:05.000Z"),
+			ETag:         o.ETag,
+			Size:         o.Size,
+			StorageClass: "STANDARD",
+		})
+		last = key
+		count++
+	}
+
+	if v2 {
+		result.KeyCount = &count
+		result.ContinuationToken = query.Get("continuation-token")
+		result.StartAfter = query.Get("start-after")
+		if result.IsTruncated {
+			result.NextContinuationToken = last
+		}
+	} else {
+		marker := query.Get("marker")
+		result.Marker = &marker
+		if result.IsTruncated {
+			result.NextMarker = last
+		}
+	}
+	writeXML(w, result)
+}
+
+type s3Error struct {
+	XMLName  xml.Name `xml:"Error"`
+	Code     string   `xml:"Code"`
+	Message  string   `xml:"Message"`
+	Resource string   `xml:"Resource"`
+}
+
+func writeS3Error(w http.ResponseWriter, status int, code, message, resource string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(xml.Header))
+	_ = xml.NewEncoder(w).Encode(s3Error{Code: code, Message: message, Resource: resource})
+}
+
+func writeXML(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(xml.Header))
+	_ = xml.NewEncoder(w).Encode(data)
+}
@@ -0,0 +1,223 @@
+package awsstub
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
+	"github.com/nandemo-ya/kecs/controlplane/internal/common"
+	smapi "github.com/nandemo-ya/kecs/controlplane/internal/secretsmanager/generated"
+)
+
+// secret is a Secrets Manager secret, only its current version is kept
+type secret struct {
+	Name         string    `json:"name"`
+	ARN          string    `json:"arn"`
+	Description  string    `json:"description,omitempty"`
+	SecretString *string   `json:"secretString,omitempty"`
+	SecretBinary []byte    `json:"secretBinary,omitempty"`
+	VersionID    string    `json:"versionId"`
+	Created      time.Time `json:"created"`
+	Changed      time.Time `json:"changed"`
+}
+
+// handleSecretsManager serves the secret operations of Secrets Manager
+func (s *Server) handleSecretsManager(w http.ResponseWriter, r *http.Request, operation string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch operation {
+	case "CreateSecret":
+		var input smapi.CreateSecretRequest
+		if !decodeJSON(w, r, &input) {
+			return
+		}
+		if input.Name == "" {
+			writeJSONError(w, http.StatusBadRequest, "InvalidParameterException", "Secret name must not be empty.")
+			return
+		}
+		if _, ok := s.state.Secrets[input.Name]; ok {
+			writeJSONError(w, http.StatusBadRequest, "ResourceExistsException", fmt.Sprintf("The operation failed because the secret %s already exists.", input.Name))
+			return
+		}
+		now := time.Now().UTC()
+		sec := &secret{
+			Name:         input.Name,
+			ARN:          awsarn.Build("secretsmanager", s.region, s.accountID, "secret:"+input.Name+"-"+randomHex(3)),
+			SecretString: input.SecretString,
+			SecretBinary: input.SecretBinary,
+			VersionID:    versionID(input.ClientRequestToken),
+			Created:      now,
+			Changed:      now,
+		}
+		if input.Description != nil {
+			sec.Description = *input.Description
+		}
+		s.state.Secrets[sec.Name] = sec
+		s.save()
+		writeJSON(w, &smapi.CreateSecretResponse{ARN: ptr(sec.ARN), Name: ptr(sec.Name), VersionId: ptr(sec.VersionID)})
+	case "GetSecretValue":
+		var input smapi.GetSecretValueRequest
+		if !decodeJSON(w, r, &input) {
+			return
+		}
+		sec := s.findSecret(w, input.SecretId)
+		if sec == nil {
+			return
+		}
+		if (input.VersionId != nil && *input.VersionId != sec.VersionID) ||
+			(input.VersionStage != nil && *input.VersionStage != "AWSCURRENT") {
+			writeJSONError(w, http.StatusBadRequest, "ResourceNotFoundException", "Secrets Manager can't find the specified secret value for the version.")
+			return
+		}
+		writeJSON(w, &smapi.GetSecretValueResponse{
+			ARN:           ptr(sec.ARN),
+			Name:          ptr(sec.Name),
+			SecretString:  sec.SecretString,
+			SecretBinary:  sec.SecretBinary,
+			VersionId:     ptr(sec.VersionID),
+			VersionStages: []string{"AWSCURRENT"},
+			CreatedDate:   &common.UnixTime{Time: sec.Changed},
+		})
+	case "PutSecretValue":
+		var input smapi.PutSecretValueRequest
+		if !decodeJSON(w, r, &input) {
+			return
+		}
+		sec := s.findSecret(w, input.SecretId)
+		if sec == nil {
+			return
+		}
+		sec.update(input.SecretString, input.SecretBinary, input.ClientRequestToken)
+		s.save()
+		writeJSON(w, &smapi.PutSecretValueResponse{
+			ARN:           ptr(sec.ARN),
+			Name:          ptr(sec.Name),
+			VersionId:     ptr(sec.VersionID),
+			VersionStages: []string{"AWSCURRENT"},
+		})
+	case "UpdateSecret":
+		var input smapi.UpdateSecretRequest
+		if !decodeJSON(w, r, &input) {
+			return
+		}
+		sec := s.findSecret(w, input.SecretId)
+		if sec == nil {
+			return
+		}
+		if input.Description != nil {
+			sec.Description = *input.Description
+		}
+		response := &smapi.UpdateSecretResponse{ARN: ptr(sec.ARN), Name: ptr(sec.Name)}
+		if input.SecretString != nil || input.SecretBinary != nil {
+			sec.update(input.SecretString, input.SecretBinary, input.ClientRequestToken)
+			response.VersionId = ptr(sec.VersionID)
+		}
+		s.save()
+		writeJSON(w, response)
+	case "DescribeSecret":
+		var input smapi.DescribeSecretRequest
+		if !decodeJSON(w, r, &input) {
+			return
+		}
+		sec := s.findSecret(w, input.SecretId)
+		if sec == nil {
+			return
+		}
+		writeJSON(w, &smapi.DescribeSecretResponse{
+			ARN:                ptr(sec.ARN),
+			Name:               ptr(sec.Name),
+			Description:        optional(sec.Description),
+			CreatedDate:        &common.UnixTime{Time: sec.Created},
+			LastChangedDate:    &common.UnixTime{Time: sec.Changed},
+			VersionIdsToStages: map[string][]string{sec.VersionID: {"AWSCURRENT"}},
+		})
+	case "ListSecrets":
+		var input smapi.ListSecretsRequest
+		if !decodeJSON(w, r, &input) {
+			return
+		}
+		names := make([]string, 0, len(s.state.Secrets))
+		for name := range s.state.Secrets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		result := &smapi.ListSecretsResponse{SecretList: []smapi.SecretListEntry{}}
+		for _, name := range names {
+			sec := s.state.Secrets[name]
+			result.SecretList = append(result.SecretList, smapi.SecretListEntry{
+				ARN:                    ptr(sec.ARN),
+				Name:                   ptr(sec.Name),
+				Description:            optional(sec.Description),
+				CreatedDate:            &common.UnixTime{Time: sec.Created},
+				LastChangedDate:        &common.UnixTime{Time: sec.Changed},
+				SecretVersionsToStages: map[string][]string{sec.VersionID: {"AWSCURRENT"}},
+			})
+		}
+		writeJSON(w, result)
+	case "DeleteSecret":
+		var input smapi.DeleteSecretRequest
+		if !decodeJSON(w, r, &input) {
+			return
+		}
+		sec := s.findSecret(w, input.SecretId)
+		if sec == nil {
+			return
+		}
+		// There is no recovery window, secrets are deleted right away
+		delete(s.state.Secrets, sec.Name)
+		s.save()
+		writeJSON(w, &smapi.DeleteSecretResponse{
+			ARN:          ptr(sec.ARN),
+			Name:         ptr(sec.Name),
+			DeletionDate: &common.UnixTime{Time: time.Now().UTC()},
+		})
+	default:
+		writeJSONError(w, http.StatusBadRequest, "UnknownOperationException",
+			fmt.Sprintf("Secrets Manager operation %s is not supported by the KECS AWS stub", operation))
+	}
+}
+
+// findSecret looks a secret up by name or ARN and writes the error of a
+// missing one
+func (s *Server) findSecret(w http.ResponseWriter, id string) *secret {
+	if sec, ok := s.state.Secrets[id]; ok {
+		return sec
+	}
+	for _, sec := range s.state.Secrets {
+		if sec.ARN == id {
+			return sec
+		}
+	}
+	writeJSONError(w, http.StatusBadRequest, "ResourceNotFoundException", "Secrets Manager can't find the specified secret.")
+	return nil
+}
+
+// update replaces the value of a secret with a new current version
+func (sec *secret) update(secretString *string, secretBinary []byte, token *string) {
+	sec.SecretString = secretString
+	sec.SecretBinary = secretBinary
+	sec.VersionID = versionID(token)
+	sec.Changed = time.Now().UTC()
+}
+
+// versionID returns the client request token, which names the version, or
+// a random UUID
+func versionID(token *string) string {
+	if token != nil && *token != "" {
+		return *token
+	}
+	return uuid.New().String()
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,216 @@
+package awsstub
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
+	"github.com/nandemo-ya/kecs/controlplane/internal/common"
+	ssmapi "github.com/nandemo-ya/kecs/controlplane/internal/ssm/generated"
+)
+
+// parameter is an SSM parameter
+type parameter struct {
+	Name         string    `json:"name"`
+	Type         string    `json:"type"`
+	Value        string    `json:"value"`
+	Description  string    `json:"description,omitempty"`
+	DataType     string    `json:"dataType,omitempty"`
+	Version      int64     `json:"version"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+// handleSSM serves the parameter operations of SSM
+func (s *Server) handleSSM(w http.ResponseWriter, r *http.Request, operation string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch operation {
+	case "PutParameter":
+		var input ssmapi.PutParameterRequest
+		if decodeJSON(w, r, &input) {
+			s.putParameter(w, &input)
+		}
+	case "GetParameter":
+		var input ssmapi.GetParameterRequest
+		if !decodeJSON(w, r, &input) {
+			return
+		}
+		p, ok := s.state.Parameters[input.Name]
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "ParameterNotFound", fmt.Sprintf("Parameter %s not found.", input.Name))
+			return
+		}
+		writeJSON(w, &ssmapi.GetParameterResult{Parameter: s.toParameter(p)})
+	case "GetParameters":
+		var input ssmapi.GetParametersRequest
+		if !decodeJSON(w, r, &input) {
+			return
+		}
+		result := &ssmapi.GetParametersResult{Parameters: []ssmapi.Parameter{}, InvalidParameters: []string{}}
+		for _, name := range input.Names {
+			if p, ok := s.state.Parameters[name]; ok {
+				result.Parameters = append(result.Parameters, *s.toParameter(p))
+			} else {
+				result.InvalidParameters = append(result.InvalidParameters, name)
+			}
+		}
+		writeJSON(w, result)
+	case "GetParametersByPath":
+		var input ssmapi.GetParametersByPathRequest
+		if !decodeJSON(w, r, &input) {
+			return
+		}
+		recursive := input.Recursive != nil && *input.Recursive
+		result := &ssmapi.GetParametersByPathResult{Parameters: []ssmapi.Parameter{}}
+		for _, name := range s.parameterNames() {
+			if inPath(name, input.Path, recursive) {
+				result.Parameters = append(result.Parameters, *s.toParameter(s.state.Parameters[name]))
+			}
+		}
+		writeJSON(w, result)
+	case "DescribeParameters":
+		var input ssmapi.DescribeParametersRequest
+		if !decodeJSON(w, r, &input) {
+			return
+		}
+		result := &ssmapi.DescribeParametersResult{Parameters: []ssmapi.ParameterMetadata{}}
+		for _, name := range s.parameterNames() {
+			p := s.state.Parameters[name]
+			parameterType := ssmapi.ParameterType(p.Type)
+			result.Parameters = append(result.Parameters, ssmapi.ParameterMetadata{
+				ARN:              ptr(s.parameterARN(p.Name)),
+				Name:             ptr(p.Name),
+				Type:             &parameterType,
+				Description:      optional(p.Description),
+				DataType:         optional(p.DataType),
+				Version:          ptr(p.Version),
+				LastModifiedDate: &common.UnixTime{Time: p.LastModified},
+			})
+		}
+		writeJSON(w, result)
+	case "DeleteParameter":
+		var input ssmapi.DeleteParameterRequest
+		if !decodeJSON(w, r, &input) {
+			return
+		}
+		if _, ok := s.state.Parameters[input.Name]; !ok {
+			writeJSONError(w, http.StatusBadRequest, "ParameterNotFound", fmt.Sprintf("Parameter %s not found.", input.Name))
+			return
+		}
+		delete(s.state.Parameters, input.Name)
+		s.save()
+		writeJSON(w, struct{}{})
+	case "DeleteParameters":
+		var input ssmapi.DeleteParametersRequest
+		if !decodeJSON(w, r, &input) {
+			return
+		}
+		result := &ssmapi.DeleteParametersResult{DeletedParameters: []string{}, InvalidParameters: []string{}}
+		for _, name := range input.Names {
+			if _, ok := s.state.Parameters[name]; ok {
+				delete(s.state.Parameters, name)
+				result.DeletedParameters = append(result.DeletedParameters, name)
+			} else {
+				result.InvalidParameters = append(result.InvalidParameters, name)
+			}
+		}
+		s.save()
+		writeJSON(w, result)
+	default:
+		writeJSONError(w, http.StatusBadRequest, "UnknownOperationException",
+			fmt.Sprintf("SSM operation %s is not supported by the KECS AWS stub", operation))
+	}
+}
+
+func (s *Server) putParameter(w http.ResponseWriter, input *ssmapi.PutParameterRequest) {
+	if input.Name == "" {
+		writeJSONError(w, http.StatusBadRequest, "ValidationException", "Parameter name must not be empty.")
+		return
+	}
+	existing, exists := s.state.Parameters[input.Name]
+	if exists && (input.Overwrite == nil || !*input.Overwrite) {
+		writeJSONError(w, http.StatusBadRequest, "ParameterAlreadyExists", "The parameter already exists. To overwrite this value, set the overwrite option in the request to true.")
+		return
+	}
+
+	p := &parameter{Name: input.Name, Value: input.Value, Version: 1, LastModified: time.Now().UTC()}
+	if exists {
+		p.Version = existing.Version + 1
+		p.Type, p.Description, p.DataType = existing.Type, existing.Description, existing.DataType
+	}
+	if input.Type != nil {
+		p.Type = string(*input.Type)
+	}
+	if p.Type == "" {
+		p.Type = "String"
+	}
+	if input.Description != nil {
+		p.Description = *input.Description
+	}
+	if input.DataType != nil {
+		p.DataType = *input.DataType
+	}
+	s.state.Parameters[p.Name] = p
+	s.save()
+
+	tier := ssmapi.ParameterTier("Standard")
+	writeJSON(w, &ssmapi.PutParameterResult{Version: ptr(p.Version), Tier: &tier})
+}
+
+func (s *Server) toParameter(p *parameter) *ssmapi.Parameter {
+	parameterType := ssmapi.ParameterType(p.Type)
+	dataType := p.DataType
+	if dataType == "" {
+		dataType = "text"
+	}
+	return &ssmapi.Parameter{
+		ARN:              ptr(s.parameterARN(p.Name)),
+		Name:             ptr(p.Name),
+		Type:             &parameterType,
+		Value:            ptr(p.Value),
+		Version:          ptr(p.Version),
+		DataType:         ptr(dataType),
+		LastModifiedDate: &common.UnixTime{Time: p.LastModified},
+	}
+}
+
+func (s *Server) parameterARN(name string) string {
+	return awsarn.Build("ssm", s.region, s.accountID, "parameter/"+strings.TrimPrefix(name, "/"))
+}
+
+// parameterNames returns the names of all parameters in order
+func (s *Server) parameterNames() []string {
+	names := make([]string, 0, len(s.state.Parameters))
+	for name := range s.state.Parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// inPath reports whether a parameter is below path, directly unless recursive
+func inPath(name, path string, recursive bool) bool {
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+	if !strings.HasPrefix(name, path) {
+		return false
+	}
+	return recursive || !strings.Contains(strings.TrimPrefix(name, path), "/")
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// optional returns nil for an empty string, which is then left out
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,181 @@
+// Package awsstub is a minimal built-in replacement of LocalStack for the
+// AWS services the KECS integrations need: S3 objects, SSM parameters,
+// Secrets Manager secrets and CloudWatch metrics and alarms. It runs inside
+// the control plane and answers on the LocalStack port, so clients reach it
+// through the usual LocalStack endpoint. Everything else LocalStack offers is
+// not available.
+package awsstub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/localstack"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+const stateFile = "state.json"
+
+// Server serves the stubbed services over HTTP
+type Server struct {
+	region    string
+	accountID string
+	// dir holds the state and the S3 objects, nothing is persisted when empty
+	dir string
+
+	mu    sync.Mutex
+	state *state
+	// metrics are the recent data points of each metric, kept in memory only
+	metrics map[string][]datapoint
+}
+
+// state is the persisted content of the stubbed services
+type state struct {
+	Parameters map[string]*parameter   `json:"parameters"`
+	Secrets    map[string]*secret      `json:"secrets"`
+	Buckets    map[string]*bucket      `json:"buckets"`
+	Alarms     map[string]*metricAlarm `json:"alarms"`
+}
+
+// New creates a stub server keeping its state in dir. The state of a
+// previous run in dir is loaded, an empty dir keeps the state in memory.
+func New(dir, region, accountID string) (*Server, error) {
+	s := &Server{
+		region:    region,
+		accountID: accountID,
+		dir:       dir,
+		state: &state{
+			Parameters: make(map[string]*parameter),
+			Secrets:    make(map[string]*secret),
+			Buckets:    make(map[string]*bucket),
+			Alarms:     make(map[string]*metricAlarm),
+		},
+		metrics: make(map[string][]datapoint),
+	}
+	if dir == "" {
+		return s, nil
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create AWS stub directory: %w", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, stateFile))
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read AWS stub state: %w", err)
+	}
+	if err := json.Unmarshal(data, s.state); err != nil {
+		return nil, fmt.Errorf("failed to decode AWS stub state: %w", err)
+	}
+	if s.state.Alarms == nil {
+		s.state.Alarms = make(map[string]*metricAlarm)
+	}
+	for _, b := range s.state.Buckets {
+		if b.Objects == nil {
+			b.Objects = make(map[string]*object)
+		}
+	}
+	return s, nil
+}
+
+// ServeHTTP dispatches a request to the service it is addressed to. JSON
+// protocol services are told apart by X-Amz-Target, CloudWatch query
+// protocol calls by their form, and everything else is S3.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == localstack.HealthCheckPath {
+		s.handleHealth(w)
+		return
+	}
+
+	target := r.Header.Get("X-Amz-Target")
+	if target == "" && isCloudWatchRequest(r) {
+		s.handleCloudWatch(w, r)
+		return
+	}
+	if target == "" {
+		s.handleS3(w, r)
+		return
+	}
+	prefix, operation, _ := strings.Cut(target, ".")
+	switch prefix {
+	case "AmazonSSM":
+		s.handleSSM(w, r, operation)
+	case "secretsmanager":
+		s.handleSecretsManager(w, r, operation)
+	default:
+		writeJSONError(w, http.StatusBadRequest, "UnknownOperationException",
+			fmt.Sprintf("%s is not served by the KECS AWS stub, which supports %s only", target, strings.Join(localstack.StubServices, ", ")))
+	}
+}
+
+// handleHealth answers like the LocalStack health endpoint
+func (s *Server) handleHealth(w http.ResponseWriter) {
+	services := make(map[string]string, len(localstack.StubServices))
+	for _, service := range localstack.StubServices {
+		services[service] = "running"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"services": services,
+		"edition":  "kecs-stub",
+	})
+}
+
+// save persists the state, the caller holds s.mu. Failures are logged as
+// the change is already visible to clients.
+func (s *Server) save() {
+	if s.dir == "" {
+		return
+	}
+	data, err := json.Marshal(s.state)
+	if err != nil {
+		logging.Error("Failed to encode AWS stub state", "error", err)
+		return
+	}
+	path := filepath.Join(s.dir, stateFile)
+	if err := os.WriteFile(path+".tmp", data, 0600); err != nil {
+		logging.Error("Failed to write AWS stub state", "error", err)
+		return
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		logging.Error("Failed to write AWS stub state", "error", err)
+	}
+}
+
+// writeJSON writes a response of the JSON 1.1 protocol
+func writeJSON(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	w.WriteHeader(http.StatusOK)
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(data)
+}
+
+// writeJSONError writes an error of the JSON 1.1 protocol
+func writeJSONError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"__type":  code,
+		"message": message,
+	})
+}
+
+// decodeJSON decodes the input of a JSON 1.1 request, an empty body is an
+// empty input
+func decodeJSON(w http.ResponseWriter, r *http.Request, input interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(input); err != nil && !errors.Is(err, io.EOF) {
+		writeJSONError(w, http.StatusBadRequest, "SerializationException", fmt.Sprintf("Invalid JSON: %v", err))
+		return false
+	}
+	return true
+}
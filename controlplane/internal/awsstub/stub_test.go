@@ -0,0 +1,373 @@
+package awsstub_test
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsstub"
+)
+
+var _ = Describe("AWS stub", func() {
+	var (
+		dir    string
+		server *httptest.Server
+	)
+
+	start := func() {
+		stub, err := awsstub.New(dir, "us-east-1", "000000000000")
+		Expect(err).NotTo(HaveOccurred())
+		server = httptest.NewServer(stub)
+	}
+
+	call := func(target string, input interface{}, output interface{}) int {
+		body, err := json.Marshal(input)
+		Expect(err).NotTo(HaveOccurred())
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/", strings.NewReader(string(body)))
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+		req.Header.Set("X-Amz-Target", target)
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		if output != nil {
+			Expect(json.NewDecoder(resp.Body).Decode(output)).To(Succeed())
+		}
+		return resp.StatusCode
+	}
+
+	do := func(method, path string, body string, header http.Header) (*http.Response, string) {
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		Expect(err).NotTo(HaveOccurred())
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		Expect(err).NotTo(HaveOccurred())
+		return resp, string(data)
+	}
+
+	query := func(action string, params map[string]string) (*http.Response, string) {
+		form := url.Values{"Action": {action}, "Version": {"2010-08-01"}}
+		for name, value := range params {
+			form.Set(name, value)
+		}
+		return do(http.MethodPost, "/", form.Encode(), http.Header{"Content-Type": {"application/x-www-form-urlencoded; charset=utf-8"}})
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		start()
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("answers the LocalStack health check with its services", func() {
+		resp, body := do(http.MethodGet, "/_localstack/health", "", nil)
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		var health struct {
+			Services map[string]string `json:"services"`
+		}
+		Expect(json.Unmarshal([]byte(body), &health)).To(Succeed())
+		Expect(health.Services).To(Equal(map[string]string{"s3": "running", "ssm": "running", "secretsmanager": "running", "cloudwatch": "running"}))
+	})
+
+	It("rejects services it does not serve", func() {
+		var out map[string]string
+		Expect(call("Logs_20140328.PutLogEvents", map[string]string{}, &out)).To(Equal(http.StatusBadRequest))
+		Expect(out["__type"]).To(Equal("UnknownOperationException"))
+	})
+
+	Describe("SSM", func() {
+		It("stores parameters and versions overwrites", func() {
+			var put map[string]interface{}
+			Expect(call("AmazonSSM.PutParameter", map[string]interface{}{"Name": "/app/db/host", "Value": "db", "Type": "String"}, &put)).To(Equal(http.StatusOK))
+			Expect(put["Version"]).To(BeNumerically("==", 1))
+
+			var exists map[string]string
+			Expect(call("AmazonSSM.PutParameter", map[string]interface{}{"Name": "/app/db/host", "Value": "db2"}, &exists)).To(Equal(http.StatusBadRequest))
+			Expect(exists["__type"]).To(Equal("ParameterAlreadyExists"))
+
+			Expect(call("AmazonSSM.PutParameter", map[string]interface{}{"Name": "/app/db/host", "Value": "db2", "Overwrite": true}, &put)).To(Equal(http.StatusOK))
+			Expect(put["Version"]).To(BeNumerically("==", 2))
+
+			var got struct {
+				Parameter struct {
+					Name    string
+					Value   string
+					Type    string
+					ARN     string
+					Version int
+				}
+			}
+			Expect(call("AmazonSSM.GetParameter", map[string]string{"Name": "/app/db/host"}, &got)).To(Equal(http.StatusOK))
+			Expect(got.Parameter.Value).To(Equal("db2"))
+			Expect(got.Parameter.Type).To(Equal("String"))
+			Expect(got.Parameter.Version).To(Equal(2))
+			Expect(got.Parameter.ARN).To(Equal("arn:aws:ssm:us-east-1:000000000000:parameter/app/db/host"))
+		})
+
+		It("returns parameters by path", func() {
+			for _, name := range []string{"/app/a", "/app/b", "/app/nested/c", "/other/d"} {
+				Expect(call("AmazonSSM.PutParameter", map[string]interface{}{"Name": name, "Value": "v"}, nil)).To(Equal(http.StatusOK))
+			}
+			var out struct {
+				Parameters []struct{ Name string }
+			}
+			Expect(call("AmazonSSM.GetParametersByPath", map[string]interface{}{"Path": "/app"}, &out)).To(Equal(http.StatusOK))
+			Expect(out.Parameters).To(HaveLen(2))
+
+			Expect(call("AmazonSSM.GetParametersByPath", map[string]interface{}{"Path": "/app", "Recursive": true}, &out)).To(Equal(http.StatusOK))
+			Expect(out.Parameters).To(HaveLen(3))
+		})
+
+		It("reports missing parameters", func() {
+			var out map[string]string
+			Expect(call("AmazonSSM.GetParameter", map[string]string{"Name": "/missing"}, &out)).To(Equal(http.StatusBadRequest))
+			Expect(out["__type"]).To(Equal("ParameterNotFound"))
+
+			var many struct{ InvalidParameters []string }
+			Expect(call("AmazonSSM.GetParameters", map[string][]string{"Names": {"/missing"}}, &many)).To(Equal(http.StatusOK))
+			Expect(many.InvalidParameters).To(ConsistOf("/missing"))
+		})
+	})
+
+	Describe("Secrets Manager", func() {
+		It("serves secrets by name and ARN", func() {
+			var created struct{ ARN, Name, VersionId string }
+			Expect(call("secretsmanager.CreateSecret", map[string]string{"Name": "db-password", "SecretString": "s3cret"}, &created)).To(Equal(http.StatusOK))
+			Expect(created.ARN).To(HavePrefix("arn:aws:secretsmanager:us-east-1:000000000000:secret:db-password-"))
+
+			var value struct{ SecretString, VersionId string }
+			Expect(call("secretsmanager.GetSecretValue", map[string]string{"SecretId": created.ARN}, &value)).To(Equal(http.StatusOK))
+			Expect(value.SecretString).To(Equal("s3cret"))
+			Expect(value.VersionId).To(Equal(created.VersionId))
+
+			Expect(call("secretsmanager.PutSecretValue", map[string]string{"SecretId": "db-password", "SecretString": "rotated"}, nil)).To(Equal(http.StatusOK))
+			Expect(call("secretsmanager.GetSecretValue", map[string]string{"SecretId": "db-password"}, &value)).To(Equal(http.StatusOK))
+			Expect(value.SecretString).To(Equal("rotated"))
+			Expect(value.VersionId).NotTo(Equal(created.VersionId))
+
+			var exists map[string]string
+			Expect(call("secretsmanager.CreateSecret", map[string]string{"Name": "db-password", "SecretString": "x"}, &exists)).To(Equal(http.StatusBadRequest))
+			Expect(exists["__type"]).To(Equal("ResourceExistsException"))
+		})
+
+		It("deletes secrets", func() {
+			Expect(call("secretsmanager.CreateSecret", map[string]string{"Name": "token", "SecretString": "t"}, nil)).To(Equal(http.StatusOK))
+			Expect(call("secretsmanager.DeleteSecret", map[string]string{"SecretId": "token"}, nil)).To(Equal(http.StatusOK))
+
+			var out map[string]string
+			Expect(call("secretsmanager.GetSecretValue", map[string]string{"SecretId": "token"}, &out)).To(Equal(http.StatusBadRequest))
+			Expect(out["__type"]).To(Equal("ResourceNotFoundException"))
+		})
+	})
+
+	Describe("CloudWatch", func() {
+		type alarm struct {
+			AlarmName   string
+			AlarmArn    string
+			StateValue  string
+			StateReason string
+		}
+		describe := func(params map[string]string) []alarm {
+			resp, body := query("DescribeAlarms", params)
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			var result struct {
+				Alarms []alarm `xml:"DescribeAlarmsResult>MetricAlarms>member"`
+			}
+			Expect(xml.Unmarshal([]byte(body), &result)).To(Succeed())
+			return result.Alarms
+		}
+		putCPU := func(value string, ago time.Duration) {
+			resp, _ := query("PutMetricData", map[string]string{
+				"Namespace":                                     "AWS/ECS",
+				"MetricData.member.1.MetricName":                "CPUUtilization",
+				"MetricData.member.1.Value":                     value,
+				"MetricData.member.1.Unit":                      "Percent",
+				"MetricData.member.1.Timestamp":                 time.Now().Add(-ago).UTC().Format(time.RFC3339),
+				"MetricData.member.1.Dimensions.member.1.Name":  "ClusterName",
+				"MetricData.member.1.Dimensions.member.1.Value": "default",
+				"MetricData.member.1.Dimensions.member.2.Name":  "ServiceName",
+				"MetricData.member.1.Dimensions.member.2.Value": "web",
+			})
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		}
+
+		BeforeEach(func() {
+			resp, body := query("PutMetricAlarm", map[string]string{
+				"AlarmName":                 "web-cpu-high",
+				"Namespace":                 "AWS/ECS",
+				"MetricName":                "CPUUtilization",
+				"Dimensions.member.1.Name":  "ServiceName",
+				"Dimensions.member.1.Value": "web",
+				"Dimensions.member.2.Name":  "ClusterName",
+				"Dimensions.member.2.Value": "default",
+				"Statistic":                 "Average",
+				"Period":                    "60",
+				"EvaluationPeriods":         "2",
+				"Threshold":                 "70",
+				"ComparisonOperator":        "GreaterThanThreshold",
+				"AlarmActions.member.1":     "arn:aws:autoscaling:us-east-1:000000000000:scalingPolicy:web-out",
+			})
+			Expect(resp.StatusCode).To(Equal(http.StatusOK), body)
+		})
+
+		It("starts alarms without data in INSUFFICIENT_DATA", func() {
+			alarms := describe(map[string]string{"AlarmNames.member.1": "web-cpu-high"})
+			Expect(alarms).To(HaveLen(1))
+			Expect(alarms[0].AlarmArn).To(Equal("arn:aws:cloudwatch:us-east-1:000000000000:alarm:web-cpu-high"))
+			Expect(alarms[0].StateValue).To(Equal("INSUFFICIENT_DATA"))
+		})
+
+		It("evaluates alarms against the statistic of their periods", func() {
+			putCPU("90", 90*time.Second)
+			putCPU("100", 20*time.Second)
+			putCPU("80", 10*time.Second)
+
+			alarms := describe(map[string]string{"StateValue": "ALARM"})
+			Expect(alarms).To(HaveLen(1))
+			Expect(alarms[0].StateReason).To(Equal(
+				"Threshold Crossed: 2 out of the last 2 datapoints [100.0, 90.0] were greater than the threshold (70.0)."))
+
+			By("going back to OK once a period is below the threshold")
+			putCPU("10", 5*time.Second)
+			putCPU("10", 15*time.Second)
+			Expect(describe(nil)[0].StateValue).To(Equal("OK"))
+		})
+
+		It("sets and deletes alarms", func() {
+			resp, _ := query("SetAlarmState", map[string]string{"AlarmName": "web-cpu-high", "StateValue": "ALARM", "StateReason": "testing"})
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(describe(nil)[0].StateReason).To(Equal("testing"))
+
+			resp, _ = query("DeleteAlarms", map[string]string{"AlarmNames.member.1": "web-cpu-high"})
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(describe(nil)).To(BeEmpty())
+
+			resp, body := query("SetAlarmState", map[string]string{"AlarmName": "web-cpu-high", "StateValue": "OK", "StateReason": "x"})
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+			Expect(body).To(ContainSubstring("<Code>ResourceNotFound</Code>"))
+		})
+
+		It("rejects alarms it cannot evaluate", func() {
+			resp, body := query("PutMetricAlarm", map[string]string{
+				"AlarmName":          "anomaly",
+				"Namespace":          "AWS/ECS",
+				"MetricName":         "CPUUtilization",
+				"Statistic":          "Average",
+				"Period":             "60",
+				"EvaluationPeriods":  "1",
+				"Threshold":          "1",
+				"ComparisonOperator": "LessThanLowerThreshold",
+			})
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+			Expect(body).To(ContainSubstring("<Code>ValidationError</Code>"))
+		})
+
+		It("keeps alarms across restarts", func() {
+			server.Close()
+			start()
+			Expect(describe(nil)).To(HaveLen(1))
+		})
+	})
+
+	Describe("S3", func() {
+		BeforeEach(func() {
+			resp, _ := do(http.MethodPut, "/artifacts", "", nil)
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		})
+
+		It("stores objects", func() {
+			resp, _ := do(http.MethodPut, "/artifacts/env/app.env", "FOO=bar\n", http.Header{
+				"Content-Type":   {"text/plain"},
+				"X-Amz-Meta-App": {"web"},
+			})
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(resp.Header.Get("ETag")).NotTo(BeEmpty())
+
+			resp, body := do(http.MethodGet, "/artifacts/env/app.env", "", nil)
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(body).To(Equal("FOO=bar\n"))
+			Expect(resp.Header.Get("Content-Type")).To(Equal("text/plain"))
+			Expect(resp.Header.Get("X-Amz-Meta-App")).To(Equal("web"))
+
+			resp, _ = do(http.MethodDelete, "/artifacts/env/app.env", "", nil)
+			Expect(resp.StatusCode).To(Equal(http.StatusNoContent))
+			resp, body = do(http.MethodGet, "/artifacts/env/app.env", "", nil)
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+			Expect(body).To(ContainSubstring("<Code>NoSuchKey</Code>"))
+		})
+
+		It("decodes aws-chunked uploads", func() {
+			payload := "7;chunk-signature=abc\r\nFOO=bar\r\n0;chunk-signature=def\r\nx-amz-checksum-crc32:AAAAAA==\r\n\r\n"
+			resp, _ := do(http.MethodPut, "/artifacts/app.env", payload, http.Header{
+				"Content-Encoding":     {"aws-chunked"},
+				"X-Amz-Content-Sha256": {"STREAMING-UNSIGNED-PAYLOAD-TRAILER"},
+			})
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			_, body := do(http.MethodGet, "/artifacts/app.env", "", nil)
+			Expect(body).To(Equal("FOO=bar"))
+		})
+
+		It("lists objects with prefixes", func() {
+			for _, key := range []string{"a.txt", "dir/b.txt", "dir/c.txt"} {
+				resp, _ := do(http.MethodPut, "/artifacts/"+key, "x", nil)
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			}
+
+			var result struct {
+				KeyCount       int
+				Contents       []struct{ Key string }
+				CommonPrefixes []struct{ Prefix string }
+			}
+			_, body := do(http.MethodGet, "/artifacts?list-type=2&delimiter=/", "", nil)
+			Expect(xml.Unmarshal([]byte(body), &result)).To(Succeed())
+			Expect(result.KeyCount).To(Equal(2))
+			Expect(result.Contents).To(HaveLen(1))
+			Expect(result.Contents[0].Key).To(Equal("a.txt"))
+			Expect(result.CommonPrefixes).To(HaveLen(1))
+			Expect(result.CommonPrefixes[0].Prefix).To(Equal("dir/"))
+
+			resp, _ := do(http.MethodDelete, "/artifacts", "", nil)
+			Expect(resp.StatusCode).To(Equal(http.StatusConflict))
+		})
+
+		It("reports missing buckets", func() {
+			resp, body := do(http.MethodGet, "/missing/key", "", nil)
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+			Expect(body).To(ContainSubstring("<Code>NoSuchBucket</Code>"))
+		})
+	})
+
+	It("keeps its state across restarts", func() {
+		Expect(call("AmazonSSM.PutParameter", map[string]interface{}{"Name": "/kept", "Value": "v"}, nil)).To(Equal(http.StatusOK))
+		resp, _ := do(http.MethodPut, "/kept", "", nil)
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		resp, _ = do(http.MethodPut, "/kept/object", "content", nil)
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+		server.Close()
+		start()
+
+		var got struct{ Parameter struct{ Value string } }
+		Expect(call("AmazonSSM.GetParameter", map[string]string{"Name": "/kept"}, &got)).To(Equal(http.StatusOK))
+		Expect(got.Parameter.Value).To(Equal("v"))
+		_, body := do(http.MethodGet, "/kept/object", "", nil)
+		Expect(body).To(Equal("content"))
+	})
+})
@@ -0,0 +1,181 @@
+// Package backup exports the storage state of an instance to S3 and restores
+// it, on demand or on a cron schedule.
+package backup
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/version"
+)
+
+// SchemaVersion is the version of the backup format written by this release.
+// It is increased whenever a stored resource changes incompatibly; backups
+// of a newer schema are rejected instead of being restored partially.
+const SchemaVersion = 1
+
+// minSchemaVersion is the oldest backup schema this release restores
+const minSchemaVersion = 1
+
+// Backup is the storage state of an instance. Tasks, task logs, container
+// instances and load balancer targets are runtime state that the instance
+// recreates, so they are not part of it.
+type Backup struct {
+	SchemaVersion int       `json:"schemaVersion"`
+	KECSVersion   string    `json:"kecsVersion"`
+	CreatedAt     time.Time `json:"createdAt"`
+
+	AccountSettings []*storage.AccountSetting    `json:"accountSettings,omitempty"`
+	Clusters        []*storage.Cluster           `json:"clusters,omitempty"`
+	TaskDefinitions []*storage.TaskDefinition    `json:"taskDefinitions,omitempty"`
+	Services        []*storage.Service           `json:"services,omitempty"`
+	TaskSets        []*storage.TaskSet           `json:"taskSets,omitempty"`
+	LoadBalancers   []*storage.ELBv2LoadBalancer `json:"loadBalancers,omitempty"`
+	TargetGroups    []*storage.ELBv2TargetGroup  `json:"targetGroups,omitempty"`
+	Listeners       []*storage.ELBv2Listener     `json:"listeners,omitempty"`
+	Rules           []*storage.ELBv2Rule         `json:"rules,omitempty"`
+}
+
+// exportPageSize is the page size task definitions are read with
+const exportPageSize = 100
+
+// Export reads the state of store into a backup. Load balancers and target
+// groups are those of region.
+func Export(ctx context.Context, store storage.Storage, region string) (*Backup, error) {
+	b := &Backup{
+		SchemaVersion: SchemaVersion,
+		KECSVersion:   version.GetVersion(),
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	nextToken := ""
+	for {
+		settings, token, err := store.AccountSettingStore().List(ctx, storage.AccountSettingFilters{NextToken: nextToken})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list account settings: %w", err)
+		}
+		b.AccountSettings = append(b.AccountSettings, settings...)
+		if token == "" {
+			break
+		}
+		nextToken = token
+	}
+
+	clusters, err := store.ClusterStore().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+	b.Clusters = clusters
+
+	nextToken = ""
+	for {
+		revisions, token, err := store.TaskDefinitionStore().List(ctx, storage.TaskDefinitionFilters{}, exportPageSize, nextToken)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list task definitions: %w", err)
+		}
+		for _, revision := range revisions {
+			taskDef, err := store.TaskDefinitionStore().GetByARN(ctx, revision.ARN)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get task definition %s: %w", revision.ARN, err)
+			}
+			b.TaskDefinitions = append(b.TaskDefinitions, taskDef)
+		}
+		if token == "" {
+			break
+		}
+		nextToken = token
+	}
+
+	for _, cluster := range clusters {
+		services, _, err := store.ServiceStore().List(ctx, cluster.ARN, storage.ServiceFilters{}, 0, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list services of cluster %s: %w", cluster.Name, err)
+		}
+		for _, service := range services {
+			b.Services = append(b.Services, service)
+			taskSets, err := store.TaskSetStore().List(ctx, service.ARN, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list task sets of service %s: %w", service.ServiceName, err)
+			}
+			b.TaskSets = append(b.TaskSets, taskSets...)
+		}
+	}
+
+	if elbv2 := store.ELBv2Store(); elbv2 != nil {
+		if err := exportELBv2(ctx, elbv2, region, b); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// exportELBv2 adds the load balancers, target groups, listeners and rules of
+// a region to a backup
+func exportELBv2(ctx context.Context, store storage.ELBv2Store, region string, b *Backup) error {
+	loadBalancers, err := store.ListLoadBalancers(ctx, region)
+	if err != nil {
+		return fmt.Errorf("failed to list load balancers: %w", err)
+	}
+	b.LoadBalancers = loadBalancers
+	for _, lb := range loadBalancers {
+		listeners, err := store.ListListeners(ctx, lb.ARN)
+		if err != nil {
+			return fmt.Errorf("failed to list listeners of %s: %w", lb.Name, err)
+		}
+		b.Listeners = append(b.Listeners, listeners...)
+		for _, listener := range listeners {
+			rules, err := store.ListRules(ctx, listener.ARN)
+			if err != nil {
+				return fmt.Errorf("failed to list rules of %s: %w", listener.ARN, err)
+			}
+			b.Rules = append(b.Rules, rules...)
+		}
+	}
+
+	targetGroups, err := store.ListTargetGroups(ctx, region)
+	if err != nil {
+		return fmt.Errorf("failed to list target groups: %w", err)
+	}
+	b.TargetGroups = targetGroups
+	return nil
+}
+
+// Encode writes a backup as gzipped JSON
+func Encode(w io.Writer, b *Backup) error {
+	gz := gzip.NewWriter(w)
+	if err := json.NewEncoder(gz).Encode(b); err != nil {
+		gz.Close()
+		return fmt.Errorf("failed to encode backup: %w", err)
+	}
+	return gz.Close()
+}
+
+// Decode reads a backup written by Encode and checks that this release can
+// restore its schema version
+func Decode(r io.Reader) (*Backup, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a KECS backup: %w", err)
+	}
+	defer gz.Close()
+
+	var b Backup
+	if err := json.NewDecoder(gz).Decode(&b); err != nil {
+		return nil, fmt.Errorf("not a KECS backup: %w", err)
+	}
+	switch {
+	case b.SchemaVersion == 0:
+		return nil, fmt.Errorf("not a KECS backup: no schema version")
+	case b.SchemaVersion > SchemaVersion:
+		return nil, fmt.Errorf("backup schema version %d was written by KECS %s and is newer than version %d supported by KECS %s, upgrade KECS to restore it",
+			b.SchemaVersion, b.KECSVersion, SchemaVersion, version.GetVersion())
+	case b.SchemaVersion < minSchemaVersion:
+		return nil, fmt.Errorf("backup schema version %d is no longer supported, the oldest supported version is %d", b.SchemaVersion, minSchemaVersion)
+	}
+	return &b, nil
+}
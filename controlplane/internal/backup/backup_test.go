@@ -0,0 +1,136 @@
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/mocks"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+var _ = Describe("Backup", func() {
+	const clusterARN = "arn:aws:ecs:us-east-1:000000000000:cluster/default"
+
+	newStore := func() *mocks.MockStorage {
+		store := mocks.NewMockStorage()
+		store.SetClusterStore(mocks.NewMockClusterStore())
+		store.SetTaskDefinitionStore(mocks.NewMockTaskDefinitionStore())
+		store.SetServiceStore(mocks.NewMockServiceStore())
+		store.SetTaskSetStore(mocks.NewMockTaskSetStore())
+		store.SetAccountSettingStore(mocks.NewMockAccountSettingStore())
+		return store
+	}
+
+	encodeRaw := func(doc map[string]interface{}) *bytes.Buffer {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		Expect(json.NewEncoder(gz).Encode(doc)).To(Succeed())
+		Expect(gz.Close()).To(Succeed())
+		return &buf
+	}
+
+	Describe("Decode", func() {
+		It("should read what Encode writes", func() {
+			var buf bytes.Buffer
+			Expect(Encode(&buf, &Backup{SchemaVersion: SchemaVersion, KECSVersion: "v1.0.0"})).To(Succeed())
+			b, err := Decode(&buf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(b.KECSVersion).To(Equal("v1.0.0"))
+		})
+
+		It("should reject backups of a newer schema version", func() {
+			_, err := Decode(encodeRaw(map[string]interface{}{"schemaVersion": SchemaVersion + 1, "kecsVersion": "v9.0.0"}))
+			Expect(err).To(MatchError(ContainSubstring("upgrade KECS to restore it")))
+		})
+
+		It("should reject documents without a schema version", func() {
+			_, err := Decode(encodeRaw(map[string]interface{}{"clusters": []string{}}))
+			Expect(err).To(MatchError(ContainSubstring("no schema version")))
+		})
+
+		It("should reject data that is not a backup", func() {
+			_, err := Decode(bytes.NewBufferString("{}"))
+			Expect(err).To(MatchError(ContainSubstring("not a KECS backup")))
+		})
+	})
+
+	Describe("Export and Restore", func() {
+		var (
+			ctx    context.Context
+			source *mocks.MockStorage
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			source = newStore()
+			Expect(source.AccountSettingStore().Upsert(ctx, &storage.AccountSetting{
+				Name: "containerInsights", Value: "enabled", PrincipalARN: "arn:aws:iam::000000000000:root", IsDefault: true,
+			})).To(Succeed())
+			Expect(source.ClusterStore().Create(ctx, &storage.Cluster{ARN: clusterARN, Name: "default", Status: "ACTIVE"})).To(Succeed())
+			taskDef, err := source.TaskDefinitionStore().Register(ctx, &storage.TaskDefinition{Family: "web", ContainerDefinitions: `[{"name":"app","image":"nginx"}]`})
+			Expect(err).NotTo(HaveOccurred())
+			for _, service := range []*storage.Service{
+				{ServiceName: "web", Status: "ACTIVE", DesiredCount: 2, RunningCount: 2},
+				{ServiceName: "old", Status: "INACTIVE"},
+			} {
+				service.ARN = "arn:aws:ecs:us-east-1:000000000000:service/default/" + service.ServiceName
+				service.ClusterARN = clusterARN
+				service.TaskDefinitionARN = taskDef.ARN
+				Expect(source.ServiceStore().Create(ctx, service)).To(Succeed())
+			}
+		})
+
+		It("should restore the exported resources into an empty instance", func() {
+			b, err := Export(ctx, source, "us-east-1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(b.SchemaVersion).To(Equal(SchemaVersion))
+			Expect(b.Clusters).To(HaveLen(1))
+			Expect(b.TaskDefinitions).To(HaveLen(1))
+			Expect(b.Services).To(HaveLen(2))
+
+			target := newStore()
+			result, err := Restore(ctx, target, b)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Restored).To(Equal(map[string]int{
+				KindAccountSettings: 1, KindClusters: 1, KindTaskDefinitions: 1, KindServices: 1,
+			}))
+			Expect(result.ServiceARNs).To(ConsistOf("arn:aws:ecs:us-east-1:000000000000:service/default/web"))
+
+			service, err := target.ServiceStore().Get(ctx, clusterARN, "web")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(service.Status).To(Equal("PROVISIONING"))
+			Expect(service.DesiredCount).To(Equal(2))
+			Expect(service.RunningCount).To(BeZero())
+
+			_, err = target.ServiceStore().Get(ctx, clusterARN, "old")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should skip resources that already exist", func() {
+			b, err := Export(ctx, source, "us-east-1")
+			Expect(err).NotTo(HaveOccurred())
+
+			result, err := Restore(ctx, source, b)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Skipped).To(Equal(map[string]int{KindClusters: 1, KindTaskDefinitions: 1, KindServices: 1}))
+			Expect(result.ServiceARNs).To(BeEmpty())
+		})
+
+		It("should warn about task definitions restored under another revision", func() {
+			b := &Backup{
+				SchemaVersion: SchemaVersion,
+				TaskDefinitions: []*storage.TaskDefinition{
+					{ARN: "arn:aws:ecs:us-east-1:000000000000:task-definition/api:3", Family: "api", Revision: 3, Status: "ACTIVE"},
+				},
+			}
+			result, err := Restore(ctx, newStore(), b)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Warnings).To(ConsistOf("task definition api:3 was restored as revision 1"))
+		})
+	})
+})
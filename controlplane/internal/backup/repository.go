@@ -0,0 +1,109 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// objectPrefix and objectSuffix frame the timestamp in backup object
+	// names, so that names sort by creation time
+	objectPrefix    = "kecs-backup-"
+	objectSuffix    = ".json.gz"
+	objectTimestamp = "20060102T150405Z"
+)
+
+// objectKey returns the key a backup created at t is saved under in a prefix
+func objectKey(prefix string, t time.Time) string {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return prefix + objectPrefix + t.UTC().Format(objectTimestamp) + objectSuffix
+}
+
+// isBackupKey reports whether key names a backup directly within prefix
+func isBackupKey(prefix, key string) bool {
+	name := strings.TrimPrefix(key, prefix)
+	return !strings.Contains(name, "/") && strings.HasPrefix(name, objectPrefix) && strings.HasSuffix(name, objectSuffix)
+}
+
+// Save writes a backup to the prefix of dst and returns its location
+func Save(ctx context.Context, objects ObjectStore, dst Location, b *Backup) (Location, error) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, b); err != nil {
+		return Location{}, err
+	}
+	loc := Location{Bucket: dst.Bucket, Key: objectKey(dst.Key, b.CreatedAt)}
+	if err := objects.PutObject(ctx, loc.Bucket, loc.Key, buf.Bytes()); err != nil {
+		return Location{}, fmt.Errorf("failed to save backup to %s: %w", loc, err)
+	}
+	return loc, nil
+}
+
+// List returns the backups in the prefix of dst, newest first
+func List(ctx context.Context, objects ObjectStore, dst Location) ([]Object, error) {
+	prefix := dst.Key
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	all, err := objects.ListObjects(ctx, dst.Bucket, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups in %s: %w", dst, err)
+	}
+	var backups []Object
+	for _, object := range all {
+		if isBackupKey(prefix, object.Key) {
+			backups = append(backups, object)
+		}
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Key > backups[j].Key })
+	return backups, nil
+}
+
+// Prune deletes all but the newest retain backups in the prefix of dst and
+// returns the deleted keys. A retain of zero or less keeps every backup.
+func Prune(ctx context.Context, objects ObjectStore, dst Location, retain int) ([]string, error) {
+	if retain <= 0 {
+		return nil, nil
+	}
+	backups, err := List(ctx, objects, dst)
+	if err != nil {
+		return nil, err
+	}
+	var deleted []string
+	for i := retain; i < len(backups); i++ {
+		if err := objects.DeleteObject(ctx, dst.Bucket, backups[i].Key); err != nil {
+			return deleted, fmt.Errorf("failed to delete backup %s: %w", backups[i].Key, err)
+		}
+		deleted = append(deleted, backups[i].Key)
+	}
+	return deleted, nil
+}
+
+// Load reads the backup at src. When src is a prefix, the newest backup in
+// it is read.
+func Load(ctx context.Context, objects ObjectStore, src Location) (*Backup, Location, error) {
+	if src.IsPrefix() {
+		backups, err := List(ctx, objects, src)
+		if err != nil {
+			return nil, Location{}, err
+		}
+		if len(backups) == 0 {
+			return nil, Location{}, fmt.Errorf("no backups in %s", src)
+		}
+		src = Location{Bucket: src.Bucket, Key: backups[0].Key}
+	}
+	data, err := objects.GetObject(ctx, src.Bucket, src.Key)
+	if err != nil {
+		return nil, Location{}, fmt.Errorf("failed to read backup %s: %w", src, err)
+	}
+	b, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, Location{}, fmt.Errorf("%s: %w", src, err)
+	}
+	return b, src, nil
+}
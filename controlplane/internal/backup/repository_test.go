@@ -0,0 +1,112 @@
+package backup
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsclient"
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsstub"
+)
+
+var _ = Describe("S3 repository", func() {
+	var (
+		ctx     context.Context
+		server  *httptest.Server
+		objects *S3Store
+		dst     Location
+	)
+
+	backupAt := func(t time.Time) *Backup {
+		return &Backup{SchemaVersion: SchemaVersion, KECSVersion: "test", CreatedAt: t}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		stub, err := awsstub.New(GinkgoT().TempDir(), "us-east-1", "000000000000")
+		Expect(err).NotTo(HaveOccurred())
+		server = httptest.NewServer(stub)
+
+		req, err := http.NewRequest(http.MethodPut, server.URL+"/kecs-backups", nil)
+		Expect(err).NotTo(HaveOccurred())
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		resp.Body.Close()
+
+		objects = NewS3Store(server.URL, "us-east-1", awsclient.Credentials{})
+		dst, err = ParseLocation("s3://kecs-backups/dev/")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should save backups and load them back", func() {
+		loc, err := Save(ctx, objects, dst, backupAt(time.Date(2026, 1, 14, 3, 0, 0, 0, time.UTC)))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(loc.String()).To(Equal("s3://kecs-backups/dev/kecs-backup-20260114T030000Z.json.gz"))
+
+		b, loaded, err := Load(ctx, objects, loc)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(loaded).To(Equal(loc))
+		Expect(b.KECSVersion).To(Equal("test"))
+	})
+
+	It("should list, prune and load the newest backups of a prefix", func() {
+		start := time.Date(2026, 1, 10, 3, 0, 0, 0, time.UTC)
+		for day := 0; day < 4; day++ {
+			_, err := Save(ctx, objects, dst, backupAt(start.AddDate(0, 0, day)))
+			Expect(err).NotTo(HaveOccurred())
+		}
+		// Objects that are not backups of the prefix are left alone
+		Expect(objects.PutObject(ctx, "kecs-backups", "dev/notes.txt", []byte("notes"))).To(Succeed())
+		Expect(objects.PutObject(ctx, "kecs-backups", "dev/old/kecs-backup-20250101T000000Z.json.gz", []byte("old"))).To(Succeed())
+
+		deleted, err := Prune(ctx, objects, dst, 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deleted).To(Equal([]string{
+			"dev/kecs-backup-20260111T030000Z.json.gz",
+			"dev/kecs-backup-20260110T030000Z.json.gz",
+		}))
+
+		backups, err := List(ctx, objects, dst)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(backups).To(HaveLen(2))
+		Expect(backups[0].Key).To(Equal("dev/kecs-backup-20260113T030000Z.json.gz"))
+
+		b, loc, err := Load(ctx, objects, dst)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(loc.Key).To(Equal("dev/kecs-backup-20260113T030000Z.json.gz"))
+		Expect(b.CreatedAt).To(Equal(start.AddDate(0, 0, 3)))
+	})
+
+	It("should report missing backups", func() {
+		_, _, err := Load(ctx, objects, Location{Bucket: "kecs-backups", Key: "dev/kecs-backup-20200101T000000Z.json.gz"})
+		Expect(err).To(MatchError(ErrObjectNotFound))
+
+		_, _, err = Load(ctx, objects, Location{Bucket: "kecs-backups", Key: "empty/"})
+		Expect(err).To(MatchError(ContainSubstring("no backups in s3://kecs-backups/empty/")))
+	})
+
+	DescribeTable("ParseLocation",
+		func(uri string, expected Location, prefix bool) {
+			loc, err := ParseLocation(uri)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loc).To(Equal(expected))
+			Expect(loc.IsPrefix()).To(Equal(prefix))
+		},
+		Entry("bucket", "s3://bucket", Location{Bucket: "bucket"}, true),
+		Entry("prefix", "s3://bucket/dev/", Location{Bucket: "bucket", Key: "dev/"}, true),
+		Entry("backup", "s3://bucket/dev/b.json.gz", Location{Bucket: "bucket", Key: "dev/b.json.gz"}, false),
+	)
+
+	It("should reject locations that are not S3 URIs", func() {
+		_, err := ParseLocation("/tmp/backup.json.gz")
+		Expect(err).To(MatchError(ContainSubstring("expected s3://bucket/key")))
+	})
+})
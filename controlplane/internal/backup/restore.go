@@ -0,0 +1,211 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// Resource kinds counted by a RestoreResult
+const (
+	KindAccountSettings = "accountSettings"
+	KindClusters        = "clusters"
+	KindTaskDefinitions = "taskDefinitions"
+	KindServices        = "services"
+	KindTaskSets        = "taskSets"
+	KindLoadBalancers   = "loadBalancers"
+	KindTargetGroups    = "targetGroups"
+	KindListeners       = "listeners"
+	KindRules           = "rules"
+)
+
+// serviceStatusProvisioning is the status restored services are stored with,
+// so that the service reconciler creates their Kubernetes resources
+const serviceStatusProvisioning = "PROVISIONING"
+
+// RestoreResult reports what a restore created. Resources that already exist
+// are skipped and left as they are.
+type RestoreResult struct {
+	Restored map[string]int `json:"restored"`
+	Skipped  map[string]int `json:"skipped"`
+	Warnings []string       `json:"warnings,omitempty"`
+	// ServiceARNs are the restored services, which still have to be
+	// reconciled into Kubernetes
+	ServiceARNs []string `json:"serviceArns,omitempty"`
+}
+
+func (r *RestoreResult) count(kind string, restored bool) {
+	if restored {
+		r.Restored[kind]++
+	} else {
+		r.Skipped[kind]++
+	}
+}
+
+// Restore writes the resources of a backup that do not exist in store.
+// Task definitions get the next free revision of their family; when that is
+// not their original revision, because revisions of the family were purged,
+// the services and task sets of the backup are restored with the new ARN.
+// Services are restored without tasks, as PROVISIONING, and deleted services
+// are left out.
+func Restore(ctx context.Context, store storage.Storage, b *Backup) (*RestoreResult, error) {
+	result := &RestoreResult{Restored: map[string]int{}, Skipped: map[string]int{}}
+
+	for _, setting := range b.AccountSettings {
+		if err := store.AccountSettingStore().Upsert(ctx, setting); err != nil {
+			return result, fmt.Errorf("failed to restore account setting %s: %w", setting.Name, err)
+		}
+		result.count(KindAccountSettings, true)
+	}
+
+	for _, cluster := range b.Clusters {
+		if existing, err := store.ClusterStore().Get(ctx, cluster.Name); err == nil && existing != nil {
+			result.count(KindClusters, false)
+			continue
+		}
+		if err := store.ClusterStore().Create(ctx, cluster); err != nil {
+			return result, fmt.Errorf("failed to restore cluster %s: %w", cluster.Name, err)
+		}
+		result.count(KindClusters, true)
+	}
+
+	taskDefARNs, err := restoreTaskDefinitions(ctx, store, b.TaskDefinitions, result)
+	if err != nil {
+		return result, err
+	}
+	taskDefARN := func(arn string) string {
+		if restored, ok := taskDefARNs[arn]; ok {
+			return restored
+		}
+		return arn
+	}
+
+	for _, service := range b.Services {
+		if service.Status == "DRAINING" || service.Status == "INACTIVE" {
+			continue
+		}
+		if existing, err := store.ServiceStore().Get(ctx, service.ClusterARN, service.ServiceName); err == nil && existing != nil {
+			result.count(KindServices, false)
+			continue
+		}
+		restored := *service
+		restored.TaskDefinitionARN = taskDefARN(service.TaskDefinitionARN)
+		restored.Status = serviceStatusProvisioning
+		restored.RunningCount = 0
+		restored.PendingCount = 0
+		if err := store.ServiceStore().Create(ctx, &restored); err != nil {
+			return result, fmt.Errorf("failed to restore service %s: %w", service.ServiceName, err)
+		}
+		result.count(KindServices, true)
+		result.ServiceARNs = append(result.ServiceARNs, restored.ARN)
+	}
+
+	for _, taskSet := range b.TaskSets {
+		if existing, err := store.TaskSetStore().GetByARN(ctx, taskSet.ARN); err == nil && existing != nil {
+			result.count(KindTaskSets, false)
+			continue
+		}
+		restored := *taskSet
+		restored.TaskDefinition = taskDefARN(taskSet.TaskDefinition)
+		restored.RunningCount = 0
+		restored.PendingCount = 0
+		if err := store.TaskSetStore().Create(ctx, &restored); err != nil {
+			return result, fmt.Errorf("failed to restore task set %s: %w", taskSet.ARN, err)
+		}
+		result.count(KindTaskSets, true)
+	}
+
+	if elbv2 := store.ELBv2Store(); elbv2 != nil {
+		if err := restoreELBv2(ctx, elbv2, b, result); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// restoreTaskDefinitions registers the task definitions of a backup in
+// revision order, returning the ARNs of those restored under another
+// revision by their original ARN
+func restoreTaskDefinitions(ctx context.Context, store storage.Storage, taskDefs []*storage.TaskDefinition, result *RestoreResult) (map[string]string, error) {
+	sorted := make([]*storage.TaskDefinition, len(taskDefs))
+	copy(sorted, taskDefs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Family != sorted[j].Family {
+			return sorted[i].Family < sorted[j].Family
+		}
+		return sorted[i].Revision < sorted[j].Revision
+	})
+
+	renamed := make(map[string]string)
+	for _, taskDef := range sorted {
+		if existing, err := store.TaskDefinitionStore().GetByARN(ctx, taskDef.ARN); err == nil && existing != nil {
+			result.count(KindTaskDefinitions, false)
+			continue
+		}
+		restored := *taskDef
+		registered, err := store.TaskDefinitionStore().Register(ctx, &restored)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore task definition %s: %w", taskDef.ARN, err)
+		}
+		if registered.Revision != taskDef.Revision {
+			renamed[taskDef.ARN] = registered.ARN
+			result.Warnings = append(result.Warnings,
+				fmt.Sprintf("task definition %s:%d was restored as revision %d", taskDef.Family, taskDef.Revision, registered.Revision))
+		}
+		if taskDef.Status == "INACTIVE" {
+			if err := store.TaskDefinitionStore().Deregister(ctx, registered.Family, registered.Revision); err != nil {
+				return nil, fmt.Errorf("failed to deregister restored task definition %s: %w", registered.ARN, err)
+			}
+		}
+		result.count(KindTaskDefinitions, true)
+	}
+	return renamed, nil
+}
+
+// restoreELBv2 creates the load balancers, target groups, listeners and rules
+// of a backup
+func restoreELBv2(ctx context.Context, store storage.ELBv2Store, b *Backup, result *RestoreResult) error {
+	for _, lb := range b.LoadBalancers {
+		if existing, err := store.GetLoadBalancer(ctx, lb.ARN); err == nil && existing != nil {
+			result.count(KindLoadBalancers, false)
+			continue
+		}
+		if err := store.CreateLoadBalancer(ctx, lb); err != nil {
+			return fmt.Errorf("failed to restore load balancer %s: %w", lb.Name, err)
+		}
+		result.count(KindLoadBalancers, true)
+	}
+	for _, tg := range b.TargetGroups {
+		if existing, err := store.GetTargetGroup(ctx, tg.ARN); err == nil && existing != nil {
+			result.count(KindTargetGroups, false)
+			continue
+		}
+		if err := store.CreateTargetGroup(ctx, tg); err != nil {
+			return fmt.Errorf("failed to restore target group %s: %w", tg.Name, err)
+		}
+		result.count(KindTargetGroups, true)
+	}
+	for _, listener := range b.Listeners {
+		if existing, err := store.GetListener(ctx, listener.ARN); err == nil && existing != nil {
+			result.count(KindListeners, false)
+			continue
+		}
+		if err := store.CreateListener(ctx, listener); err != nil {
+			return fmt.Errorf("failed to restore listener %s: %w", listener.ARN, err)
+		}
+		result.count(KindListeners, true)
+	}
+	for _, rule := range b.Rules {
+		if existing, err := store.GetRule(ctx, rule.ARN); err == nil && existing != nil {
+			result.count(KindRules, false)
+			continue
+		}
+		if err := store.CreateRule(ctx, rule); err != nil {
+			return fmt.Errorf("failed to restore rule %s: %w", rule.ARN, err)
+		}
+		result.count(KindRules, true)
+	}
+	return nil
+}
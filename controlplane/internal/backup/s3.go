@@ -0,0 +1,199 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsclient"
+)
+
+// ErrObjectNotFound is returned for objects that do not exist
+var ErrObjectNotFound = errors.New("object not found")
+
+// Object is an object of a bucket
+type Object struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+// ObjectStore stores backups
+type ObjectStore interface {
+	PutObject(ctx context.Context, bucket, key string, body []byte) error
+	GetObject(ctx context.Context, bucket, key string) ([]byte, error)
+	// ListObjects returns the objects whose key starts with prefix
+	ListObjects(ctx context.Context, bucket, prefix string) ([]Object, error)
+	DeleteObject(ctx context.Context, bucket, key string) error
+}
+
+// Location is a bucket and a key or key prefix, s3://bucket/key
+type Location struct {
+	Bucket string
+	Key    string
+}
+
+// ParseLocation parses an s3://bucket/key URI
+func ParseLocation(uri string) (Location, error) {
+	rest, ok := strings.CutPrefix(uri, "s3://")
+	if !ok {
+		return Location{}, fmt.Errorf("invalid S3 location %q: expected s3://bucket/key", uri)
+	}
+	bucket, key, _ := strings.Cut(rest, "/")
+	if bucket == "" {
+		return Location{}, fmt.Errorf("invalid S3 location %q: no bucket", uri)
+	}
+	return Location{Bucket: bucket, Key: key}, nil
+}
+
+// IsPrefix reports whether the location names a prefix rather than a backup
+func (l Location) IsPrefix() bool {
+	return l.Key == "" || strings.HasSuffix(l.Key, "/")
+}
+
+// String returns the s3:// URI of the location
+func (l Location) String() string {
+	return "s3://" + l.Bucket + "/" + l.Key
+}
+
+// S3Store is an ObjectStore on the S3 REST API with path-style addressing,
+// which LocalStack, the AWS stub and S3 serve. Requests are signed when
+// credentials are configured.
+type S3Store struct {
+	client   *awsclient.Client
+	endpoint string
+}
+
+// NewS3Store creates an S3 store for an endpoint, S3 of the region when it is empty
+func NewS3Store(endpoint, region string, credentials awsclient.Credentials) *S3Store {
+	cfg := awsclient.NewDefaultConfig()
+	cfg.Endpoint = endpoint
+	cfg.Region = region
+	cfg.Credentials = credentials
+	cfg.Timeout = 5 * time.Minute
+	client := awsclient.NewClient(cfg)
+	return &S3Store{client: client, endpoint: client.BuildEndpoint("s3")}
+}
+
+// PutObject stores an object
+func (s *S3Store) PutObject(ctx context.Context, bucket, key string, body []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, bucket, key, nil, body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// GetObject reads an object
+func (s *S3Store) GetObject(ctx context.Context, bucket, key string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, bucket, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// listBucketResult is the part of the ListObjectsV2 response the store reads
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// ListObjects lists the objects with a key prefix, page by page
+func (s *S3Store) ListObjects(ctx context.Context, bucket, prefix string) ([]Object, error) {
+	var objects []Object
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+	for {
+		resp, err := s.do(ctx, http.MethodGet, bucket, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode object list: %w", err)
+		}
+		for _, content := range result.Contents {
+			objects = append(objects, Object{Key: content.Key, Size: content.Size, LastModified: content.LastModified})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+}
+
+// DeleteObject deletes an object
+func (s *S3Store) DeleteObject(ctx context.Context, bucket, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, bucket, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// s3Error is the error document of S3
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// do sends a request for a bucket or object, turning error responses into errors
+func (s *S3Store) do(ctx context.Context, method, bucket, key string, query url.Values, body []byte) (*http.Response, error) {
+	endpoint := s.endpoint + "/" + url.PathEscape(bucket)
+	if key != "" {
+		segments := strings.Split(key, "/")
+		for i, segment := range segments {
+			segments[i] = url.PathEscape(segment)
+		}
+		endpoint += "/" + strings.Join(segments, "/")
+	}
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	payloadHash := sha256.Sum256(body)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	resp, err := s.client.DoRequest(ctx, req, "s3")
+	if err != nil {
+		return nil, fmt.Errorf("S3 request failed: %w", err)
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	var s3Err s3Error
+	data, _ := io.ReadAll(resp.Body)
+	_ = xml.Unmarshal(data, &s3Err)
+	if resp.StatusCode == http.StatusNotFound && s3Err.Code != "NoSuchBucket" {
+		return nil, fmt.Errorf("%w: s3://%s/%s", ErrObjectNotFound, bucket, key)
+	}
+	if s3Err.Code == "" {
+		return nil, fmt.Errorf("S3 %s s3://%s/%s failed with status %d", method, bucket, key, resp.StatusCode)
+	}
+	return nil, fmt.Errorf("S3 %s s3://%s/%s failed: %s: %s", method, bucket, key, s3Err.Code, s3Err.Message)
+}
@@ -0,0 +1,51 @@
+package backup
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Schedule", func() {
+	// 2026-01-14 is a Wednesday
+	now := time.Date(2026, 1, 14, 10, 30, 15, 0, time.UTC)
+
+	DescribeTable("Next",
+		func(expr string, expected time.Time) {
+			schedule, err := ParseSchedule(expr)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(schedule.Next(now)).To(Equal(expected))
+		},
+		Entry("every minute", "* * * * *", time.Date(2026, 1, 14, 10, 31, 0, 0, time.UTC)),
+		Entry("daily at 03:00", "0 3 * * *", time.Date(2026, 1, 15, 3, 0, 0, 0, time.UTC)),
+		Entry("@daily", "@daily", time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)),
+		Entry("@hourly", "@hourly", time.Date(2026, 1, 14, 11, 0, 0, 0, time.UTC)),
+		Entry("steps", "*/20 * * * *", time.Date(2026, 1, 14, 10, 40, 0, 0, time.UTC)),
+		Entry("a value with a step", "5/15 * * * *", time.Date(2026, 1, 14, 10, 35, 0, 0, time.UTC)),
+		Entry("ranges and lists", "0 9-11,14 * * *", time.Date(2026, 1, 14, 11, 0, 0, 0, time.UTC)),
+		Entry("weekdays by name", "0 6 * * SAT,SUN", time.Date(2026, 1, 17, 6, 0, 0, 0, time.UTC)),
+		Entry("Sunday as 7", "0 0 * * 7", time.Date(2026, 1, 18, 0, 0, 0, 0, time.UTC)),
+		Entry("months by name", "0 0 1 mar *", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)),
+		Entry("either day field", "0 0 20 * MON", time.Date(2026, 1, 19, 0, 0, 0, 0, time.UTC)),
+		Entry("leap days", "0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)),
+	)
+
+	It("should return the zero time for schedules that never match", func() {
+		schedule, err := ParseSchedule("0 0 31 2 *")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(schedule.Next(now).IsZero()).To(BeTrue())
+	})
+
+	DescribeTable("invalid schedules",
+		func(expr, message string) {
+			_, err := ParseSchedule(expr)
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("too few fields", "0 3 * *", "expected 5 fields"),
+		Entry("out of range", "60 * * * *", "value 60 out of range 0-59"),
+		Entry("reversed range", "0 10-9 * * *", "invalid range"),
+		Entry("zero step", "*/0 * * * *", "invalid step"),
+		Entry("unknown name", "0 0 * * FUN", "invalid value"),
+	)
+})
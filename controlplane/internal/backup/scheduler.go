@@ -0,0 +1,129 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsclient"
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// NewS3StoreFromConfig creates the S3 store backups are written to: the
+// endpoint of backup.endpoint, or the AWS endpoint of the instance, with the
+// credentials of the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY variables
+func NewS3StoreFromConfig() *S3Store {
+	endpoint := config.GetString("backup.endpoint")
+	if endpoint == "" {
+		endpoint = config.GetString("aws.endpointURL")
+	}
+	credentials := awsclient.Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	return NewS3Store(endpoint, config.GetString("aws.defaultRegion"), credentials)
+}
+
+// Scheduler exports the storage state to an S3 prefix on a cron schedule and
+// deletes the backups beyond the retention count
+type Scheduler struct {
+	store       storage.Storage
+	objects     ObjectStore
+	destination Location
+	schedule    *Schedule
+	retain      int
+	region      string
+	done        chan struct{}
+}
+
+// NewScheduler creates a scheduler writing backups of store to destination
+func NewScheduler(store storage.Storage, objects ObjectStore, destination Location, schedule *Schedule, retain int, region string) *Scheduler {
+	return &Scheduler{
+		store:       store,
+		objects:     objects,
+		destination: destination,
+		schedule:    schedule,
+		retain:      retain,
+		region:      region,
+		done:        make(chan struct{}),
+	}
+}
+
+// NewSchedulerFromConfig creates a scheduler from the backup.schedule,
+// backup.destination and backup.retention settings. It returns nil when no
+// schedule is configured.
+func NewSchedulerFromConfig(store storage.Storage) (*Scheduler, error) {
+	expr := config.GetString("backup.schedule")
+	if expr == "" {
+		return nil, nil
+	}
+	schedule, err := ParseSchedule(expr)
+	if err != nil {
+		return nil, err
+	}
+	destination, err := ParseLocation(config.GetString("backup.destination"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup.destination: %w", err)
+	}
+	return NewScheduler(store, NewS3StoreFromConfig(), destination, schedule,
+		config.GetInt("backup.retention"), config.GetString("aws.defaultRegion")), nil
+}
+
+// Start begins taking backups at the times of the schedule
+func (s *Scheduler) Start(ctx context.Context) {
+	go func() {
+		logging.Info("Backup scheduler: Started", "destination", s.destination.String(), "retention", s.retain)
+		for {
+			next := s.schedule.Next(time.Now())
+			if next.IsZero() {
+				logging.Warn("Backup scheduler: Schedule never matches, stopping")
+				return
+			}
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-s.done:
+				timer.Stop()
+				return
+			case <-timer.C:
+				if _, err := s.RunOnce(ctx); err != nil {
+					logging.Error("Backup scheduler: Backup failed", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop halts the scheduler
+func (s *Scheduler) Stop() {
+	close(s.done)
+}
+
+// RunOnce takes a backup and prunes the old ones, returning the location of
+// the new backup
+func (s *Scheduler) RunOnce(ctx context.Context) (Location, error) {
+	b, err := Export(ctx, s.store, s.region)
+	if err != nil {
+		return Location{}, err
+	}
+	loc, err := Save(ctx, s.objects, s.destination, b)
+	if err != nil {
+		return Location{}, err
+	}
+	logging.Info("Backup scheduler: Saved backup", "location", loc.String())
+
+	deleted, err := Prune(ctx, s.objects, s.destination, s.retain)
+	if err != nil {
+		return loc, fmt.Errorf("backup saved to %s, but pruning failed: %w", loc, err)
+	}
+	if len(deleted) > 0 {
+		logging.Info("Backup scheduler: Pruned old backups", "count", len(deleted))
+	}
+	return loc, nil
+}
@@ -0,0 +1,226 @@
+package bench
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+)
+
+// Namespace is where bench Jobs run
+const Namespace = "kecs-system"
+
+// resultPrefix marks the log line carrying the result of a bench Job
+const resultPrefix = "KECS_BENCH_RESULT "
+
+// Metadata of bench Jobs
+const (
+	labelComponent   = "kecs.dev/component"
+	componentBench   = "bench"
+	annotationTarget = "kecs.dev/bench-target"
+)
+
+// ErrNotFound is returned for unknown bench runs
+var ErrNotFound = errors.New("bench run not found")
+
+// jobTTLSeconds keeps finished Jobs long enough for their result to be fetched
+const jobTTLSeconds = 600
+
+// Statuses of a bench run
+const (
+	StatusRunning   = "RUNNING"
+	StatusCompleted = "COMPLETED"
+	StatusFailed    = "FAILED"
+)
+
+// Report is the state of a bench run
+type Report struct {
+	ID      string  `json:"id"`
+	Target  string  `json:"target"`
+	Status  string  `json:"status"`
+	Message string  `json:"message,omitempty"`
+	Result  *Result `json:"result,omitempty"`
+}
+
+// NewJob returns the Job generating the load of spec with the KECS image,
+// which runs the hidden bench-worker command
+func NewJob(image, target string, spec Spec) *batchv1.Job {
+	args := []string{
+		"bench-worker",
+		"--url", spec.URL,
+		"--rps", strconv.Itoa(spec.RPS),
+		"--duration", spec.Duration.String(),
+	}
+	if spec.Host != "" {
+		args = append(args, "--host", spec.Host)
+	}
+	if spec.Timeout > 0 {
+		args = append(args, "--timeout", spec.Timeout.String())
+	}
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "kecs-bench-",
+			Namespace:    Namespace,
+			Labels:       map[string]string{labelComponent: componentBench},
+			Annotations:  map[string]string{annotationTarget: target},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            ptr.To[int32](0),
+			TTLSecondsAfterFinished: ptr.To[int32](jobTTLSeconds),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{labelComponent: componentBench},
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{{
+						Name:            "bench",
+						Image:           image,
+						ImagePullPolicy: corev1.PullIfNotPresent,
+						Command:         []string{"/kecs-server"},
+						Args:            args,
+					}},
+				},
+			},
+		},
+	}
+}
+
+// Start creates the Job of a bench run and returns its ID
+func Start(ctx context.Context, kubeClient kubernetes.Interface, image, target string, spec Spec) (string, error) {
+	if err := spec.Validate(); err != nil {
+		return "", err
+	}
+	job, err := kubeClient.BatchV1().Jobs(Namespace).Create(ctx, NewJob(image, target, spec), metav1.CreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to create bench job: %w", err)
+	}
+	return job.Name, nil
+}
+
+// Get returns the state of a bench run. The result of completed runs is read
+// from the logs of their pod.
+func Get(ctx context.Context, kubeClient kubernetes.Interface, id string) (*Report, error) {
+	job, err := getJob(ctx, kubeClient, id)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{ID: id, Target: job.Annotations[annotationTarget], Status: StatusRunning}
+	switch {
+	case job.Status.Succeeded > 0:
+		logs, err := podLogs(ctx, kubeClient, id)
+		if err != nil {
+			return nil, err
+		}
+		result, err := ParseResult(logs)
+		if err != nil {
+			return nil, err
+		}
+		report.Status = StatusCompleted
+		report.Result = result
+	case job.Status.Failed > 0:
+		report.Status = StatusFailed
+		report.Message = "the bench job failed"
+		if logs, err := podLogs(ctx, kubeClient, id); err == nil {
+			report.Message += ": " + lastLine(logs)
+		}
+	}
+	return report, nil
+}
+
+// Stop deletes the Job of a bench run and its pod
+func Stop(ctx context.Context, kubeClient kubernetes.Interface, id string) error {
+	if _, err := getJob(ctx, kubeClient, id); err != nil {
+		return err
+	}
+	err := kubeClient.BatchV1().Jobs(Namespace).Delete(ctx, id, metav1.DeleteOptions{
+		PropagationPolicy: ptr.To(metav1.DeletePropagationBackground),
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete bench job: %w", err)
+	}
+	return nil
+}
+
+// getJob returns the Job of a bench run, other Jobs are not found
+func getJob(ctx context.Context, kubeClient kubernetes.Interface, id string) (*batchv1.Job, error) {
+	job, err := kubeClient.BatchV1().Jobs(Namespace).Get(ctx, id, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) || (err == nil && job.Labels[labelComponent] != componentBench) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bench job: %w", err)
+	}
+	return job, nil
+}
+
+// podLogs returns the logs of the pod of a bench Job
+func podLogs(ctx context.Context, kubeClient kubernetes.Interface, id string) (string, error) {
+	pods, err := kubeClient.CoreV1().Pods(Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "job-name=" + id,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list bench pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return "", fmt.Errorf("bench job %s has no pod", id)
+	}
+	stream, err := kubeClient.CoreV1().Pods(Namespace).GetLogs(pods.Items[0].Name, &corev1.PodLogOptions{}).Stream(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to read bench logs: %w", err)
+	}
+	defer stream.Close()
+	logs, err := io.ReadAll(stream)
+	if err != nil {
+		return "", fmt.Errorf("failed to read bench logs: %w", err)
+	}
+	return string(logs), nil
+}
+
+// WriteResult writes the result line of a bench run
+func WriteResult(w io.Writer, result Result) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode result: %w", err)
+	}
+	_, err = fmt.Fprintln(w, resultPrefix+string(data))
+	return err
+}
+
+// ParseResult extracts the result written by WriteResult from logs
+func ParseResult(logs string) (*Result, error) {
+	var line string
+	scanner := bufio.NewScanner(strings.NewReader(logs))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), resultPrefix) {
+			line = strings.TrimPrefix(scanner.Text(), resultPrefix)
+		}
+	}
+	if line == "" {
+		return nil, fmt.Errorf("bench logs contain no result")
+	}
+	var result Result
+	if err := json.Unmarshal([]byte(line), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse bench result: %w", err)
+	}
+	return &result, nil
+}
+
+// lastLine returns the last non-empty line of logs
+func lastLine(logs string) string {
+	lines := strings.Split(strings.TrimSpace(logs), "\n")
+	return lines[len(lines)-1]
+}
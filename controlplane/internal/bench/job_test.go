@@ -0,0 +1,107 @@
+package bench_test
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/bench"
+)
+
+var _ = Describe("Job", func() {
+	spec := bench.Spec{
+		URL:      "http://traefik.kecs-system.svc.cluster.local/",
+		Host:     "web-alb.us-east-1.elb.amazonaws.com",
+		RPS:      20,
+		Duration: 2 * time.Minute,
+	}
+
+	It("runs the bench worker of the KECS image", func() {
+		job := bench.NewJob("ghcr.io/nandemo-ya/kecs:v1", "web", spec)
+		Expect(job.Namespace).To(Equal(bench.Namespace))
+		Expect(*job.Spec.BackoffLimit).To(BeZero())
+
+		container := job.Spec.Template.Spec.Containers[0]
+		Expect(container.Image).To(Equal("ghcr.io/nandemo-ya/kecs:v1"))
+		Expect(container.Command).To(Equal([]string{"/kecs-server"}))
+		Expect(container.Args).To(Equal([]string{
+			"bench-worker",
+			"--url", "http://traefik.kecs-system.svc.cluster.local/",
+			"--rps", "20",
+			"--duration", "2m0s",
+			"--host", "web-alb.us-east-1.elb.amazonaws.com",
+		}))
+	})
+
+	It("reports runs from the state of their Job", func() {
+		ctx := context.Background()
+		kubeClient := fake.NewSimpleClientset()
+		// The fake clientset does not generate names
+		kubeClient.PrependReactor("create", "jobs", nameGeneratingReactor("kecs-bench-abcde"))
+
+		id, err := bench.Start(ctx, kubeClient, "kecs:test", "web", spec)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(id).To(Equal("kecs-bench-abcde"))
+
+		report, err := bench.Get(ctx, kubeClient, id)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Status).To(Equal(bench.StatusRunning))
+		Expect(report.Target).To(Equal("web"))
+
+		job, err := kubeClient.BatchV1().Jobs(bench.Namespace).Get(ctx, id, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		job.Status.Failed = 1
+		_, err = kubeClient.BatchV1().Jobs(bench.Namespace).UpdateStatus(ctx, job, metav1.UpdateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		report, err = bench.Get(ctx, kubeClient, id)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Status).To(Equal(bench.StatusFailed))
+
+		Expect(bench.Stop(ctx, kubeClient, id)).To(Succeed())
+		_, err = bench.Get(ctx, kubeClient, id)
+		Expect(err).To(MatchError(bench.ErrNotFound))
+	})
+
+	It("does not report Jobs other than bench runs", func() {
+		ctx := context.Background()
+		kubeClient := fake.NewSimpleClientset(&batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: bench.Namespace},
+		})
+		_, err := bench.Get(ctx, kubeClient, "migrate")
+		Expect(err).To(MatchError(bench.ErrNotFound))
+		Expect(bench.Stop(ctx, kubeClient, "migrate")).To(MatchError(bench.ErrNotFound))
+	})
+
+	It("round-trips results through the logs", func() {
+		var logs bytes.Buffer
+		logs.WriteString("starting load\n")
+		Expect(bench.WriteResult(&logs, bench.Result{Requests: 10, Errors: 1, ErrorRate: 0.1})).To(Succeed())
+
+		result, err := bench.ParseResult(logs.String())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Requests).To(Equal(10))
+		Expect(result.ErrorRate).To(Equal(0.1))
+
+		_, err = bench.ParseResult("panic: boom\n")
+		Expect(err).To(MatchError(ContainSubstring("no result")))
+	})
+})
+
+// nameGeneratingReactor names created Jobs that only have a GenerateName
+func nameGeneratingReactor(name string) k8stesting.ReactionFunc {
+	return func(action k8stesting.Action) (bool, runtime.Object, error) {
+		job := action.(k8stesting.CreateAction).GetObject().(*batchv1.Job)
+		if job.Name == "" {
+			job.Name = name
+		}
+		return false, nil, nil
+	}
+}
@@ -0,0 +1,210 @@
+// Package bench generates HTTP load against services and load balancers to
+// validate autoscaling and load balancer behavior. The load is driven from a
+// Kubernetes Job inside the cluster, so it reaches the same endpoints tasks do.
+package bench
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxInFlight bounds the requests waiting for a response. Requests due while
+// the bound is reached are dropped, so a stalled target cannot exhaust the
+// load generator.
+const maxInFlight = 1000
+
+// statusTransportError counts requests that got no HTTP response
+const statusTransportError = "error"
+
+// Spec describes the load to generate
+type Spec struct {
+	// URL is requested with GET
+	URL string `json:"url"`
+	// Host overrides the Host header, e.g. with the DNS name of a load balancer
+	Host string `json:"host,omitempty"`
+	// RPS is the number of requests started per second
+	RPS int `json:"rps"`
+	// Duration is how long requests are started for
+	Duration time.Duration `json:"duration"`
+	// Timeout bounds each request
+	Timeout time.Duration `json:"timeout,omitempty"`
+}
+
+// Validate checks that the load can be generated
+func (s Spec) Validate() error {
+	if s.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+	if s.RPS <= 0 {
+		return fmt.Errorf("rps must be positive, got %d", s.RPS)
+	}
+	if s.Duration <= 0 {
+		return fmt.Errorf("duration must be positive, got %s", s.Duration)
+	}
+	return nil
+}
+
+// Latency summarizes the response times of a run in milliseconds
+type Latency struct {
+	Min  float64 `json:"minMs"`
+	Mean float64 `json:"meanMs"`
+	P50  float64 `json:"p50Ms"`
+	P90  float64 `json:"p90Ms"`
+	P99  float64 `json:"p99Ms"`
+	Max  float64 `json:"maxMs"`
+}
+
+// Result summarizes a run
+type Result struct {
+	// Requests is the number of requests sent
+	Requests int `json:"requests"`
+	// Errors counts requests without response or with a 4xx or 5xx status
+	Errors    int     `json:"errors"`
+	ErrorRate float64 `json:"errorRate"`
+	// Dropped counts requests not sent because too many were in flight
+	Dropped int `json:"dropped"`
+	// RPS is the achieved rate of completed requests
+	RPS     float64 `json:"rps"`
+	Latency Latency `json:"latency"`
+	// StatusCodes counts the responses per status code, requests without
+	// response are counted as "error"
+	StatusCodes map[string]int `json:"statusCodes"`
+}
+
+// Sample is the outcome of one request
+type Sample struct {
+	Latency time.Duration
+	// StatusCode is 0 when the request got no response
+	StatusCode int
+}
+
+// Run sends requests at the rate of spec until its duration passed or ctx is
+// cancelled, and summarizes the responses
+func Run(ctx context.Context, client *http.Client, spec Spec) Result {
+	ticker := time.NewTicker(time.Second / time.Duration(spec.RPS))
+	defer ticker.Stop()
+	deadline := time.NewTimer(spec.Duration)
+	defer deadline.Stop()
+
+	var (
+		mu       sync.Mutex
+		samples  []Sample
+		wg       sync.WaitGroup
+		dropped  int
+		inFlight = make(chan struct{}, maxInFlight)
+	)
+	started := time.Now()
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-deadline.C:
+			break loop
+		case <-ticker.C:
+			select {
+			case inFlight <- struct{}{}:
+			default:
+				dropped++
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sample := send(ctx, client, spec)
+				<-inFlight
+				mu.Lock()
+				samples = append(samples, sample)
+				mu.Unlock()
+			}()
+		}
+	}
+	wg.Wait()
+	return Summarize(samples, dropped, time.Since(started))
+}
+
+// send performs one request
+func send(ctx context.Context, client *http.Client, spec Spec) Sample {
+	if spec.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, spec.Timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, spec.URL, nil)
+	if err != nil {
+		return Sample{}
+	}
+	if spec.Host != "" {
+		req.Host = spec.Host
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return Sample{Latency: time.Since(start)}
+	}
+	// Read the body so the connection is reused and the latency covers it
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return Sample{Latency: time.Since(start), StatusCode: resp.StatusCode}
+}
+
+// Summarize computes the result of the samples of a run that took elapsed
+func Summarize(samples []Sample, dropped int, elapsed time.Duration) Result {
+	result := Result{
+		Requests:    len(samples),
+		Dropped:     dropped,
+		StatusCodes: make(map[string]int),
+	}
+	var latencies []time.Duration
+	var total time.Duration
+	for _, sample := range samples {
+		if sample.StatusCode == 0 {
+			result.Errors++
+			result.StatusCodes[statusTransportError]++
+			continue
+		}
+		if sample.StatusCode >= 400 {
+			result.Errors++
+		}
+		result.StatusCodes[strconv.Itoa(sample.StatusCode)]++
+		latencies = append(latencies, sample.Latency)
+		total += sample.Latency
+	}
+	if result.Requests > 0 {
+		result.ErrorRate = float64(result.Errors) / float64(result.Requests)
+	}
+	if elapsed > 0 {
+		result.RPS = float64(result.Requests) / elapsed.Seconds()
+	}
+	if len(latencies) == 0 {
+		return result
+	}
+
+	slices.Sort(latencies)
+	result.Latency = Latency{
+		Min:  milliseconds(latencies[0]),
+		Mean: milliseconds(total / time.Duration(len(latencies))),
+		P50:  milliseconds(percentile(latencies, 50)),
+		P90:  milliseconds(percentile(latencies, 90)),
+		P99:  milliseconds(percentile(latencies, 99)),
+		Max:  milliseconds(latencies[len(latencies)-1]),
+	}
+	return result
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(0, rank-1)]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
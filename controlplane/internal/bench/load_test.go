@@ -0,0 +1,91 @@
+package bench_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/bench"
+)
+
+var _ = Describe("Load", func() {
+	Describe("Summarize", func() {
+		It("computes latency percentiles and error rates", func() {
+			var samples []bench.Sample
+			for i := 1; i <= 100; i++ {
+				samples = append(samples, bench.Sample{Latency: time.Duration(i) * time.Millisecond, StatusCode: http.StatusOK})
+			}
+			samples = append(samples,
+				bench.Sample{Latency: 5 * time.Millisecond, StatusCode: http.StatusServiceUnavailable},
+				bench.Sample{Latency: time.Second},
+			)
+
+			result := bench.Summarize(samples, 3, 2*time.Second)
+			Expect(result.Requests).To(Equal(102))
+			Expect(result.Errors).To(Equal(2))
+			Expect(result.ErrorRate).To(BeNumerically("~", 2.0/102, 1e-9))
+			Expect(result.Dropped).To(Equal(3))
+			Expect(result.RPS).To(Equal(51.0))
+			Expect(result.StatusCodes).To(Equal(map[string]int{"200": 100, "503": 1, "error": 1}))
+
+			By("leaving requests without response out of the latencies")
+			Expect(result.Latency.Min).To(Equal(1.0))
+			Expect(result.Latency.P50).To(Equal(50.0))
+			Expect(result.Latency.P90).To(Equal(90.0))
+			Expect(result.Latency.P99).To(Equal(99.0))
+			Expect(result.Latency.Max).To(Equal(100.0))
+		})
+
+		It("handles runs without responses", func() {
+			result := bench.Summarize(nil, 0, time.Second)
+			Expect(result.Requests).To(BeZero())
+			Expect(result.ErrorRate).To(BeZero())
+			Expect(result.Latency).To(Equal(bench.Latency{}))
+		})
+	})
+
+	Describe("Run", func() {
+		It("sends requests at the given rate with the Host header", func() {
+			var requests atomic.Int32
+			var host atomic.Value
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				host.Store(r.Host)
+				if r.URL.Path == "/fail" {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+			}))
+			defer server.Close()
+
+			result := bench.Run(context.Background(), server.Client(), bench.Spec{
+				URL:      server.URL + "/",
+				Host:     "web-alb.us-east-1.elb.amazonaws.com",
+				RPS:      50,
+				Duration: 500 * time.Millisecond,
+			})
+			Expect(result.Requests).To(BeNumerically("~", 25, 5))
+			Expect(int(requests.Load())).To(Equal(result.Requests))
+			Expect(result.Errors).To(BeZero())
+			Expect(host.Load()).To(Equal("web-alb.us-east-1.elb.amazonaws.com"))
+
+			result = bench.Run(context.Background(), server.Client(), bench.Spec{
+				URL:      server.URL + "/fail",
+				RPS:      20,
+				Duration: 200 * time.Millisecond,
+			})
+			Expect(result.ErrorRate).To(Equal(1.0))
+		})
+	})
+
+	It("validates specs", func() {
+		Expect(bench.Spec{URL: "http://web", RPS: 10, Duration: time.Minute}.Validate()).To(Succeed())
+		Expect(bench.Spec{RPS: 10, Duration: time.Minute}.Validate()).To(MatchError(ContainSubstring("url")))
+		Expect(bench.Spec{URL: "http://web", Duration: time.Minute}.Validate()).To(MatchError(ContainSubstring("rps")))
+		Expect(bench.Spec{URL: "http://web", RPS: 10}.Validate()).To(MatchError(ContainSubstring("duration")))
+	})
+})
@@ -0,0 +1,13 @@
+package build_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestBuild(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Build Suite")
+}
@@ -0,0 +1,67 @@
+package build
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// MaxContextSize is the largest build context accepted
+const MaxContextSize = 512 << 20
+
+// ContextStore keeps the uploaded build contexts until their Jobs downloaded
+// them
+type ContextStore struct {
+	dir string
+}
+
+// NewContextStore creates a store keeping contexts in dir, or in a temporary
+// directory when dir is empty
+func NewContextStore(dir string) *ContextStore {
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "kecs-builds")
+	}
+	return &ContextStore{dir: dir}
+}
+
+// Save stores the gzipped tar build context of a build
+func (s *ContextStore) Save(id string, r io.Reader) (int64, error) {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return 0, fmt.Errorf("failed to create build context directory: %w", err)
+	}
+	file, err := os.Create(s.path(id))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create build context: %w", err)
+	}
+	defer file.Close()
+
+	size, err := io.Copy(file, io.LimitReader(r, MaxContextSize+1))
+	if err == nil && size > MaxContextSize {
+		err = fmt.Errorf("build context exceeds %d MiB", MaxContextSize>>20)
+	}
+	if err != nil {
+		os.Remove(s.path(id))
+		return 0, err
+	}
+	return size, nil
+}
+
+// Open returns the build context of a build
+func (s *ContextStore) Open(id string) (*os.File, error) {
+	file, err := os.Open(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return file, err
+}
+
+// Remove deletes the build context of a build
+func (s *ContextStore) Remove(id string) {
+	os.Remove(s.path(id))
+}
+
+func (s *ContextStore) path(id string) string {
+	return filepath.Join(s.dir, filepath.Base(id)+".tar.gz")
+}
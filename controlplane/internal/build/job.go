@@ -0,0 +1,329 @@
+// Package build builds container images from a local build context inside the
+// cluster with kaniko and pushes them to the registry of the instance, so tasks
+// can run them without an external registry.
+package build
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+)
+
+// Namespace is where build Jobs run
+const Namespace = "kecs-system"
+
+// Metadata of build Jobs
+const (
+	labelComponent   = "kecs.dev/component"
+	componentBuild   = "build"
+	annotationSpec   = "kecs.dev/build-spec"
+	annotationResult = "kecs.dev/build-result"
+)
+
+// Containers of build Jobs
+const (
+	fetchContainer    = "fetch-context"
+	executorContainer = "kaniko"
+	workspacePath     = "/workspace"
+	contextFile       = workspacePath + "/context.tar.gz"
+)
+
+// ErrNotFound is returned for unknown builds
+var ErrNotFound = errors.New("build not found")
+
+// jobTTLSeconds keeps finished Jobs long enough for their result to be fetched
+const jobTTLSeconds = 3600
+
+// Statuses of a build
+const (
+	StatusRunning   = "RUNNING"
+	StatusCompleted = "COMPLETED"
+	StatusFailed    = "FAILED"
+)
+
+// Spec describes the image to build and what to do with it
+type Spec struct {
+	// Repository and Tag name the image in the registry of the instance
+	Repository string `json:"repository"`
+	Tag        string `json:"tag,omitempty"`
+	// Dockerfile is the path of the Dockerfile inside the build context
+	Dockerfile string `json:"dockerfile,omitempty"`
+	// Family is the task definition registered with the image, its latest
+	// revision is copied with the image of Container replaced
+	Family    string `json:"family,omitempty"`
+	Container string `json:"container,omitempty"`
+	// Port is exposed by the container of a new task definition
+	Port int32 `json:"port,omitempty"`
+	// Run starts a task of the registered revision in Cluster
+	Run     bool   `json:"run,omitempty"`
+	Cluster string `json:"cluster,omitempty"`
+}
+
+// Validate fills in the defaults of a spec and checks it
+func (s *Spec) Validate() error {
+	if s.Tag == "" {
+		s.Tag = "latest"
+	}
+	if s.Dockerfile == "" {
+		s.Dockerfile = "Dockerfile"
+	}
+	if s.Cluster == "" {
+		s.Cluster = "default"
+	}
+	if s.Repository == "" {
+		return fmt.Errorf("repository is required")
+	}
+	// The registry is that of the instance, only the repository path is given
+	if _, err := name.NewTag("registry.kecs.local/"+s.Repository+":"+s.Tag, name.StrictValidation); err != nil {
+		return fmt.Errorf("invalid repository or tag: %w", err)
+	}
+	if strings.HasPrefix(s.Dockerfile, "/") || strings.Contains(s.Dockerfile, "..") {
+		return fmt.Errorf("dockerfile must be a path inside the build context")
+	}
+	if s.Run && s.Family == "" {
+		return fmt.Errorf("run requires a task definition family")
+	}
+	if s.Port < 0 || s.Port > 65535 {
+		return fmt.Errorf("port must be between 0 and 65535")
+	}
+	return nil
+}
+
+// Image returns the reference of the image of spec in registry
+func (s Spec) Image(registry string) string {
+	return fmt.Sprintf("%s/%s:%s", registry, s.Repository, s.Tag)
+}
+
+// Report is the state of a build
+type Report struct {
+	ID      string `json:"id"`
+	Spec    Spec   `json:"spec"`
+	Image   string `json:"image"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+	Result
+}
+
+// Result is what was registered and started with a built image
+type Result struct {
+	TaskDefinitionArn string `json:"taskDefinitionArn,omitempty"`
+	TaskArn           string `json:"taskArn,omitempty"`
+	// Error fails a build whose image could not be registered or run
+	Error string `json:"error,omitempty"`
+}
+
+// JobOptions configures build Jobs
+type JobOptions struct {
+	// ExecutorImage builds and pushes the image
+	ExecutorImage string
+	// FetchImage downloads the build context, it needs wget
+	FetchImage string
+	// Registry receives the image, it is reached over plain HTTP
+	Registry string
+	// ContextURL is where the build context is downloaded from
+	ContextURL string
+}
+
+// NewJob returns the Job building the image of spec. An init container
+// downloads the build context the control plane received, kaniko builds it
+// and pushes the image.
+func NewJob(id string, spec Spec, opts JobOptions) (*batchv1.Job, error) {
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode build spec: %w", err)
+	}
+	workspace := corev1.VolumeMount{Name: "workspace", MountPath: workspacePath}
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        id,
+			Namespace:   Namespace,
+			Labels:      map[string]string{labelComponent: componentBuild},
+			Annotations: map[string]string{annotationSpec: string(specJSON)},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            ptr.To[int32](0),
+			TTLSecondsAfterFinished: ptr.To[int32](jobTTLSeconds),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{labelComponent: componentBuild},
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					InitContainers: []corev1.Container{{
+						Name:            fetchContainer,
+						Image:           opts.FetchImage,
+						ImagePullPolicy: corev1.PullIfNotPresent,
+						Command:         []string{"wget", "-q", "-O", contextFile, opts.ContextURL},
+						VolumeMounts:    []corev1.VolumeMount{workspace},
+					}},
+					Containers: []corev1.Container{{
+						Name:            executorContainer,
+						Image:           opts.ExecutorImage,
+						ImagePullPolicy: corev1.PullIfNotPresent,
+						Args: []string{
+							"--context=tar://" + contextFile,
+							"--dockerfile=" + spec.Dockerfile,
+							"--destination=" + spec.Image(opts.Registry),
+							"--insecure",
+							"--skip-tls-verify",
+						},
+						VolumeMounts: []corev1.VolumeMount{workspace},
+					}},
+					Volumes: []corev1.Volume{{
+						Name:         "workspace",
+						VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+					}},
+				},
+			},
+		},
+	}, nil
+}
+
+// Start creates the Job of a build
+func Start(ctx context.Context, kubeClient kubernetes.Interface, id string, spec Spec, opts JobOptions) error {
+	job, err := NewJob(id, spec, opts)
+	if err != nil {
+		return err
+	}
+	if _, err := kubeClient.BatchV1().Jobs(Namespace).Create(ctx, job, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create build job: %w", err)
+	}
+	return nil
+}
+
+// Get returns the state of a build. Builds whose image was pushed are
+// RUNNING until their result is stored with SetResult.
+func Get(ctx context.Context, kubeClient kubernetes.Interface, id, registry string) (*Report, error) {
+	job, err := getJob(ctx, kubeClient, id)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{ID: id, Status: StatusRunning}
+	if err := json.Unmarshal([]byte(job.Annotations[annotationSpec]), &report.Spec); err != nil {
+		return nil, fmt.Errorf("failed to decode build spec: %w", err)
+	}
+	report.Image = report.Spec.Image(registry)
+
+	switch {
+	case job.Annotations[annotationResult] != "":
+		if err := json.Unmarshal([]byte(job.Annotations[annotationResult]), &report.Result); err != nil {
+			return nil, fmt.Errorf("failed to decode build result: %w", err)
+		}
+		report.Status = StatusCompleted
+		if report.Error != "" {
+			report.Status = StatusFailed
+			report.Message = report.Error
+		}
+	case job.Status.Failed > 0:
+		report.Status = StatusFailed
+		report.Message = "the build job failed"
+		if logs, err := Logs(ctx, kubeClient, id); err == nil && strings.TrimSpace(logs) != "" {
+			report.Message += ": " + lastLine(logs)
+		}
+	}
+	return report, nil
+}
+
+// Pushed reports whether the image of a build was pushed
+func Pushed(ctx context.Context, kubeClient kubernetes.Interface, id string) (bool, error) {
+	job, err := getJob(ctx, kubeClient, id)
+	if err != nil {
+		return false, err
+	}
+	return job.Status.Succeeded > 0, nil
+}
+
+// SetResult stores what was registered and started with the image of a build,
+// which completes it
+func SetResult(ctx context.Context, kubeClient kubernetes.Interface, id string, result Result) error {
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode build result: %w", err)
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{annotationResult: string(resultJSON)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode build result: %w", err)
+	}
+	if _, err := kubeClient.BatchV1().Jobs(Namespace).Patch(ctx, id, k8stypes.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to store build result: %w", err)
+	}
+	return nil
+}
+
+// Stop deletes the Job of a build and its pod
+func Stop(ctx context.Context, kubeClient kubernetes.Interface, id string) error {
+	if _, err := getJob(ctx, kubeClient, id); err != nil {
+		return err
+	}
+	err := kubeClient.BatchV1().Jobs(Namespace).Delete(ctx, id, metav1.DeleteOptions{
+		PropagationPolicy: ptr.To(metav1.DeletePropagationBackground),
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete build job: %w", err)
+	}
+	return nil
+}
+
+// Logs returns the logs of a build: those of the context download, followed
+// by those of kaniko once it started
+func Logs(ctx context.Context, kubeClient kubernetes.Interface, id string) (string, error) {
+	pods, err := kubeClient.CoreV1().Pods(Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "job-name=" + id,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list build pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return "", fmt.Errorf("build %s has no pod", id)
+	}
+
+	var logs strings.Builder
+	for _, container := range []string{fetchContainer, executorContainer} {
+		stream, err := kubeClient.CoreV1().Pods(Namespace).GetLogs(pods.Items[0].Name, &corev1.PodLogOptions{Container: container}).Stream(ctx)
+		if err != nil {
+			// kaniko has no logs while the context is downloaded
+			continue
+		}
+		_, err = io.Copy(&logs, stream)
+		stream.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to read build logs: %w", err)
+		}
+	}
+	return logs.String(), nil
+}
+
+// getJob returns the Job of a build, other Jobs are not found
+func getJob(ctx context.Context, kubeClient kubernetes.Interface, id string) (*batchv1.Job, error) {
+	job, err := kubeClient.BatchV1().Jobs(Namespace).Get(ctx, id, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) || (err == nil && job.Labels[labelComponent] != componentBuild) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get build job: %w", err)
+	}
+	return job, nil
+}
+
+// lastLine returns the last non-empty line of logs
+func lastLine(logs string) string {
+	lines := strings.Split(strings.TrimSpace(logs), "\n")
+	return lines[len(lines)-1]
+}
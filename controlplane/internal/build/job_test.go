@@ -0,0 +1,128 @@
+package build_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/build"
+)
+
+var _ = Describe("Job", func() {
+	const registry = "registry.kecs.local:5000"
+
+	opts := build.JobOptions{
+		ExecutorImage: "gcr.io/kaniko-project/executor:v1.23.2",
+		FetchImage:    "busybox:1.36",
+		Registry:      registry,
+		ContextURL:    "http://kecs-admin.kecs-system.svc.cluster.local:5374/api/builds/kecs-build-1/context",
+	}
+
+	Describe("Spec", func() {
+		It("fills in defaults", func() {
+			spec := build.Spec{Repository: "api"}
+			Expect(spec.Validate()).To(Succeed())
+			Expect(spec.Tag).To(Equal("latest"))
+			Expect(spec.Dockerfile).To(Equal("Dockerfile"))
+			Expect(spec.Image(registry)).To(Equal("registry.kecs.local:5000/api:latest"))
+		})
+
+		DescribeTable("rejects invalid specs",
+			func(spec build.Spec, message string) {
+				Expect(spec.Validate()).To(MatchError(ContainSubstring(message)))
+			},
+			Entry("no repository", build.Spec{}, "repository is required"),
+			Entry("invalid repository", build.Spec{Repository: "API!"}, "invalid repository"),
+			Entry("dockerfile outside the context", build.Spec{Repository: "api", Dockerfile: "../Dockerfile"}, "inside the build context"),
+			Entry("run without family", build.Spec{Repository: "api", Run: true}, "run requires"),
+		)
+	})
+
+	It("downloads the context and builds it with kaniko", func() {
+		spec := build.Spec{Repository: "api", Tag: "dev", Dockerfile: "docker/Dockerfile"}
+		job, err := build.NewJob("kecs-build-1", spec, opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(job.Namespace).To(Equal(build.Namespace))
+		Expect(*job.Spec.BackoffLimit).To(BeZero())
+
+		podSpec := job.Spec.Template.Spec
+		Expect(podSpec.InitContainers[0].Image).To(Equal("busybox:1.36"))
+		Expect(podSpec.InitContainers[0].Command).To(ContainElement(opts.ContextURL))
+		Expect(podSpec.Containers[0].Image).To(Equal(opts.ExecutorImage))
+		Expect(podSpec.Containers[0].Args).To(ContainElements(
+			"--dockerfile=docker/Dockerfile",
+			"--destination=registry.kecs.local:5000/api:dev",
+			"--insecure",
+		))
+	})
+
+	It("reports builds from the state of their Job", func() {
+		ctx := context.Background()
+		kubeClient := fake.NewSimpleClientset()
+		spec := build.Spec{Repository: "api", Tag: "dev", Family: "api"}
+
+		Expect(build.Start(ctx, kubeClient, "kecs-build-1", spec, opts)).To(Succeed())
+		report, err := build.Get(ctx, kubeClient, "kecs-build-1", registry)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Status).To(Equal(build.StatusRunning))
+		Expect(report.Spec).To(Equal(spec))
+		Expect(report.Image).To(Equal("registry.kecs.local:5000/api:dev"))
+
+		job, err := kubeClient.BatchV1().Jobs(build.Namespace).Get(ctx, "kecs-build-1", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		job.Status.Succeeded = 1
+		_, err = kubeClient.BatchV1().Jobs(build.Namespace).UpdateStatus(ctx, job, metav1.UpdateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		// A pushed image stays RUNNING until its task definition is registered
+		Expect(build.Pushed(ctx, kubeClient, "kecs-build-1")).To(BeTrue())
+		report, err = build.Get(ctx, kubeClient, "kecs-build-1", registry)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Status).To(Equal(build.StatusRunning))
+
+		result := build.Result{TaskDefinitionArn: "arn:aws:ecs:us-east-1:000000000000:task-definition/api:2"}
+		Expect(build.SetResult(ctx, kubeClient, "kecs-build-1", result)).To(Succeed())
+		report, err = build.Get(ctx, kubeClient, "kecs-build-1", registry)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Status).To(Equal(build.StatusCompleted))
+		Expect(report.TaskDefinitionArn).To(Equal(result.TaskDefinitionArn))
+
+		Expect(build.SetResult(ctx, kubeClient, "kecs-build-1", build.Result{Error: "no container app"})).To(Succeed())
+		report, err = build.Get(ctx, kubeClient, "kecs-build-1", registry)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Status).To(Equal(build.StatusFailed))
+		Expect(report.Message).To(Equal("no container app"))
+	})
+
+	It("does not find other Jobs", func() {
+		_, err := build.Get(context.Background(), fake.NewSimpleClientset(), "kecs-build-missing", registry)
+		Expect(err).To(MatchError(build.ErrNotFound))
+	})
+})
+
+var _ = Describe("ContextStore", func() {
+	It("keeps build contexts until they are removed", func() {
+		store := build.NewContextStore(GinkgoT().TempDir())
+		size, err := store.Save("kecs-build-1", strings.NewReader("context"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(size).To(Equal(int64(7)))
+
+		file, err := store.Open("kecs-build-1")
+		Expect(err).NotTo(HaveOccurred())
+		var data bytes.Buffer
+		_, err = io.Copy(&data, file)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(file.Close()).To(Succeed())
+		Expect(data.String()).To(Equal("context"))
+
+		store.Remove("kecs-build-1")
+		_, err = store.Open("kecs-build-1")
+		Expect(err).To(MatchError(build.ErrNotFound))
+	})
+})
@@ -0,0 +1,112 @@
+package build
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+)
+
+// ECSClient is the part of the ECS API built images are registered and run
+// through
+type ECSClient interface {
+	DescribeTaskDefinition(ctx context.Context, req *generated.DescribeTaskDefinitionRequest) (*generated.DescribeTaskDefinitionResponse, error)
+	RegisterTaskDefinition(ctx context.Context, req *generated.RegisterTaskDefinitionRequest) (*generated.RegisterTaskDefinitionResponse, error)
+	RunTask(ctx context.Context, req *generated.RunTaskRequest) (*generated.RunTaskResponse, error)
+}
+
+// TaskDefinitionRequest returns the registration of a task definition running
+// image. The latest revision of the family, current, is copied with the image
+// of the container named in spec replaced; a container may be omitted when
+// the revision has only one. Without a current revision a task definition with
+// a single container is created.
+func TaskDefinitionRequest(current *generated.TaskDefinition, spec Spec, image string) (*generated.RegisterTaskDefinitionRequest, error) {
+	if current == nil {
+		container := spec.Container
+		if container == "" {
+			container = spec.Family
+		}
+		def := generated.ContainerDefinition{
+			Name:      ptr.String(container),
+			Image:     ptr.String(image),
+			Essential: ptr.Bool(true),
+			Memory:    ptr.Int32(512),
+		}
+		if spec.Port > 0 {
+			def.PortMappings = []generated.PortMapping{{ContainerPort: ptr.Int32(spec.Port)}}
+		}
+		return &generated.RegisterTaskDefinitionRequest{
+			Family:               spec.Family,
+			ContainerDefinitions: []generated.ContainerDefinition{def},
+		}, nil
+	}
+
+	// The registration shares the JSON shape of the described revision
+	data, err := json.Marshal(current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy task definition: %w", err)
+	}
+	req := &generated.RegisterTaskDefinitionRequest{}
+	if err := json.Unmarshal(data, req); err != nil {
+		return nil, fmt.Errorf("failed to copy task definition: %w", err)
+	}
+
+	index := -1
+	for i, def := range req.ContainerDefinitions {
+		if (spec.Container == "" && len(req.ContainerDefinitions) == 1) || def.Name != nil && *def.Name == spec.Container {
+			index = i
+		}
+	}
+	switch {
+	case index >= 0:
+		req.ContainerDefinitions[index].Image = ptr.String(image)
+	case spec.Container == "":
+		return nil, fmt.Errorf("task definition %s has %d containers, select one with container", spec.Family, len(req.ContainerDefinitions))
+	default:
+		return nil, fmt.Errorf("task definition %s has no container %s", spec.Family, spec.Container)
+	}
+	return req, nil
+}
+
+// Register registers the task definition of a build whose image was pushed
+// and starts a task of it when requested
+func Register(ctx context.Context, ecs ECSClient, current *generated.TaskDefinition, report *Report) (Result, error) {
+	var result Result
+	if report.Spec.Family == "" {
+		return result, nil
+	}
+
+	req, err := TaskDefinitionRequest(current, report.Spec, report.Image)
+	if err != nil {
+		return result, err
+	}
+	registered, err := ecs.RegisterTaskDefinition(ctx, req)
+	if err != nil {
+		return result, fmt.Errorf("failed to register task definition: %w", err)
+	}
+	result.TaskDefinitionArn = ptr.ToString(registered.TaskDefinition.TaskDefinitionArn)
+	if !report.Spec.Run {
+		return result, nil
+	}
+
+	run, err := ecs.RunTask(ctx, &generated.RunTaskRequest{
+		Cluster:        ptr.String(report.Spec.Cluster),
+		TaskDefinition: result.TaskDefinitionArn,
+		Count:          ptr.Int32(1),
+		StartedBy:      ptr.String("kecs-build/" + report.ID),
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to run task: %w", err)
+	}
+	if len(run.Tasks) == 0 {
+		reason := "no task was started"
+		if len(run.Failures) > 0 && run.Failures[0].Reason != nil {
+			reason = *run.Failures[0].Reason
+		}
+		return result, fmt.Errorf("failed to run task: %s", reason)
+	}
+	result.TaskArn = ptr.ToString(run.Tasks[0].TaskArn)
+	return result, nil
+}
@@ -0,0 +1,95 @@
+package build_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/build"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+)
+
+// fakeECS records the task definitions registered and tasks run
+type fakeECS struct {
+	registered []*generated.RegisterTaskDefinitionRequest
+	run        []*generated.RunTaskRequest
+}
+
+func (f *fakeECS) DescribeTaskDefinition(_ context.Context, req *generated.DescribeTaskDefinitionRequest) (*generated.DescribeTaskDefinitionResponse, error) {
+	return &generated.DescribeTaskDefinitionResponse{}, nil
+}
+
+func (f *fakeECS) RegisterTaskDefinition(_ context.Context, req *generated.RegisterTaskDefinitionRequest) (*generated.RegisterTaskDefinitionResponse, error) {
+	f.registered = append(f.registered, req)
+	return &generated.RegisterTaskDefinitionResponse{TaskDefinition: &generated.TaskDefinition{
+		TaskDefinitionArn: ptr.String("arn:aws:ecs:us-east-1:000000000000:task-definition/" + req.Family + ":2"),
+	}}, nil
+}
+
+func (f *fakeECS) RunTask(_ context.Context, req *generated.RunTaskRequest) (*generated.RunTaskResponse, error) {
+	f.run = append(f.run, req)
+	return &generated.RunTaskResponse{Tasks: []generated.Task{{
+		TaskArn: ptr.String("arn:aws:ecs:us-east-1:000000000000:task/default/0123456789abcdef"),
+	}}}, nil
+}
+
+var _ = Describe("TaskDefinitionRequest", func() {
+	const image = "registry.kecs.local:5000/api:dev"
+
+	current := func() *generated.TaskDefinition {
+		return &generated.TaskDefinition{
+			Family:            ptr.String("api"),
+			TaskDefinitionArn: ptr.String("arn:aws:ecs:us-east-1:000000000000:task-definition/api:1"),
+			Cpu:               ptr.String("256"),
+			ContainerDefinitions: []generated.ContainerDefinition{
+				{Name: ptr.String("app"), Image: ptr.String("api:old")},
+				{Name: ptr.String("proxy"), Image: ptr.String("envoy:v1")},
+			},
+		}
+	}
+
+	It("replaces the image of the selected container in the latest revision", func() {
+		req, err := build.TaskDefinitionRequest(current(), build.Spec{Family: "api", Container: "app"}, image)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(req.Family).To(Equal("api"))
+		Expect(*req.Cpu).To(Equal("256"))
+		Expect(*req.ContainerDefinitions[0].Image).To(Equal(image))
+		Expect(*req.ContainerDefinitions[1].Image).To(Equal("envoy:v1"))
+	})
+
+	It("requires a container when the revision has several", func() {
+		_, err := build.TaskDefinitionRequest(current(), build.Spec{Family: "api"}, image)
+		Expect(err).To(MatchError(ContainSubstring("has 2 containers")))
+
+		_, err = build.TaskDefinitionRequest(current(), build.Spec{Family: "api", Container: "web"}, image)
+		Expect(err).To(MatchError(ContainSubstring("has no container web")))
+	})
+
+	It("creates a task definition for a new family", func() {
+		req, err := build.TaskDefinitionRequest(nil, build.Spec{Family: "api", Port: 8080}, image)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(req.ContainerDefinitions).To(HaveLen(1))
+		Expect(*req.ContainerDefinitions[0].Name).To(Equal("api"))
+		Expect(*req.ContainerDefinitions[0].Image).To(Equal(image))
+		Expect(*req.ContainerDefinitions[0].PortMappings[0].ContainerPort).To(Equal(int32(8080)))
+	})
+
+	It("registers the image and runs a task of it", func() {
+		ecs := &fakeECS{}
+		report := &build.Report{
+			ID:    "kecs-build-1",
+			Spec:  build.Spec{Repository: "api", Family: "api", Run: true, Cluster: "dev"},
+			Image: image,
+		}
+
+		result, err := build.Register(context.Background(), ecs, nil, report)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.TaskDefinitionArn).To(Equal("arn:aws:ecs:us-east-1:000000000000:task-definition/api:2"))
+		Expect(result.TaskArn).To(Equal("arn:aws:ecs:us-east-1:000000000000:task/default/0123456789abcdef"))
+		Expect(ecs.run).To(HaveLen(1))
+		Expect(*ecs.run[0].Cluster).To(Equal("dev"))
+		Expect(ecs.run[0].TaskDefinition).To(Equal(result.TaskDefinitionArn))
+	})
+})
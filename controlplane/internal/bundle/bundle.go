@@ -0,0 +1,255 @@
+// Package bundle creates and reads image bundles for running KECS without
+// internet access. A bundle is a gzipped tar archive of a manifest and the
+// images of an instance as written by docker save.
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FormatVersion is the version of the bundle layout written by Write
+const FormatVersion = 1
+
+const (
+	manifestName = "manifest.json"
+	imagesName   = "images.tar"
+)
+
+// Manifest describes the content of a bundle
+type Manifest struct {
+	FormatVersion int       `json:"formatVersion"`
+	KECSVersion   string    `json:"kecsVersion"`
+	CreatedAt     time.Time `json:"createdAt"`
+	Images        []string  `json:"images"`
+	// LocalStack reports whether the LocalStack image is bundled. Instances
+	// started from a bundle without it run without LocalStack.
+	LocalStack bool `json:"localStack"`
+}
+
+// Missing returns the images that are not in the bundle
+func (m *Manifest) Missing(images []string) []string {
+	bundled := make(map[string]bool, len(m.Images))
+	for _, image := range m.Images {
+		bundled[image] = true
+	}
+	var missing []string
+	for _, image := range images {
+		if !bundled[image] {
+			missing = append(missing, image)
+		}
+	}
+	return missing
+}
+
+// ImageStore saves and loads images, e.g. those of the Docker daemon
+type ImageStore interface {
+	// Exists reports whether an image is in the store
+	Exists(ctx context.Context, image string) (bool, error)
+	// Pull pulls an image into the store
+	Pull(ctx context.Context, image string) error
+	// Save returns a tar stream of images in the format of docker save
+	Save(ctx context.Context, images []string) (io.ReadCloser, error)
+	// Load loads a tar stream written by Save
+	Load(ctx context.Context, r io.Reader) error
+}
+
+// Write saves the images of manifest from store into a bundle at path. The
+// images must be in the store already.
+func Write(ctx context.Context, store ImageStore, path string, manifest Manifest) error {
+	if len(manifest.Images) == 0 {
+		return errors.New("bundle has no images")
+	}
+	manifest.FormatVersion = FormatVersion
+	if manifest.CreatedAt.IsZero() {
+		manifest.CreatedAt = time.Now().UTC()
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	// The size of a tar entry has to be known before its content is written,
+	// so the images are saved to a temporary file next to the bundle first
+	dir := filepath.Dir(path)
+	images, err := os.CreateTemp(dir, ".kecs-images-*.tar")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(images.Name())
+	defer images.Close()
+
+	saved, err := store.Save(ctx, manifest.Images)
+	if err != nil {
+		return fmt.Errorf("failed to save images: %w", err)
+	}
+	size, err := io.Copy(images, saved)
+	saved.Close()
+	if err != nil {
+		return fmt.Errorf("failed to save images: %w", err)
+	}
+	if _, err := images.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	// Write to a temporary file so that a failed write leaves no bundle behind
+	out, err := os.CreateTemp(dir, ".kecs-bundle-*")
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+	defer os.Remove(out.Name())
+	defer out.Close()
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+	if err := writeEntry(tw, manifestName, int64(len(manifestData)), bytes.NewReader(manifestData)); err != nil {
+		return err
+	}
+	if err := writeEntry(tw, imagesName, size, images); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if err := os.Rename(out.Name(), path); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
+
+func writeEntry(tw *tar.Writer, name string, size int64, r io.Reader) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    size,
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := io.CopyN(tw, r, size); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// ReadManifest reads the manifest of the bundle at path
+func ReadManifest(path string) (*Manifest, error) {
+	manifest, _, err := read(path, "")
+	return manifest, err
+}
+
+// Extract reads the bundle at path and writes its images into dir. It
+// returns the manifest and the path of the images tarball, which can be
+// imported into the nodes of a cluster.
+func Extract(path, dir string) (*Manifest, string, error) {
+	return read(path, dir)
+}
+
+// read reads the manifest of a bundle and, when dir is set, extracts its
+// images into dir
+func read(path, dir string) (*Manifest, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s is not a KECS bundle: %w", path, err)
+	}
+	defer gz.Close()
+
+	var manifest *Manifest
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read bundle: %w", err)
+		}
+		switch header.Name {
+		case manifestName:
+			manifest = &Manifest{}
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return nil, "", fmt.Errorf("invalid bundle manifest: %w", err)
+			}
+			if manifest.FormatVersion != FormatVersion {
+				return nil, "", fmt.Errorf("bundle format version %d is not supported, recreate the bundle with this version of kecs", manifest.FormatVersion)
+			}
+			if dir == "" {
+				return manifest, "", nil
+			}
+		case imagesName:
+			if manifest == nil {
+				return nil, "", errors.New("bundle has no manifest")
+			}
+			images := filepath.Join(dir, imagesName)
+			if err := extractFile(tr, images); err != nil {
+				return nil, "", err
+			}
+			return manifest, images, nil
+		}
+	}
+	if manifest == nil {
+		return nil, "", fmt.Errorf("%s is not a KECS bundle: no manifest", path)
+	}
+	return nil, "", errors.New("bundle has no images")
+}
+
+func extractFile(r io.Reader, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to extract images: %w", err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to extract images: %w", err)
+	}
+	return f.Close()
+}
+
+// Load loads the images of an extracted bundle into store unless all of
+// them are there already
+func Load(ctx context.Context, store ImageStore, manifest *Manifest, images string) error {
+	var missing bool
+	for _, image := range manifest.Images {
+		exists, err := store.Exists(ctx, image)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			missing = true
+			break
+		}
+	}
+	if !missing {
+		return nil
+	}
+	f, err := os.Open(images)
+	if err != nil {
+		return fmt.Errorf("failed to open images: %w", err)
+	}
+	defer f.Close()
+	if err := store.Load(ctx, f); err != nil {
+		return fmt.Errorf("failed to load images: %w", err)
+	}
+	return nil
+}
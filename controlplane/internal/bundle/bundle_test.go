@@ -0,0 +1,173 @@
+package bundle_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/bundle"
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/localstack"
+)
+
+// fakeStore saves images as their names, one per line
+type fakeStore struct {
+	images map[string]bool
+	loaded []byte
+}
+
+func (s *fakeStore) Exists(ctx context.Context, image string) (bool, error) {
+	return s.images[image], nil
+}
+
+func (s *fakeStore) Pull(ctx context.Context, image string) error {
+	s.images[image] = true
+	return nil
+}
+
+func (s *fakeStore) Save(ctx context.Context, images []string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(strings.Join(images, "\n"))), nil
+}
+
+func (s *fakeStore) Load(ctx context.Context, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	s.loaded = data
+	return err
+}
+
+var _ = Describe("Bundle", func() {
+	var (
+		ctx   context.Context
+		dir   string
+		path  string
+		store *fakeStore
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		dir = GinkgoT().TempDir()
+		path = filepath.Join(dir, "kecs-bundle.tar.gz")
+		store = &fakeStore{images: map[string]bool{"traefik:v3.0": true, "postgres:16-alpine": true}}
+	})
+
+	It("writes the manifest and images", func() {
+		manifest := bundle.Manifest{KECSVersion: "v1.2.0", Images: []string{"traefik:v3.0", "postgres:16-alpine"}, LocalStack: true}
+		Expect(bundle.Write(ctx, store, path, manifest)).To(Succeed())
+
+		read, err := bundle.ReadManifest(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(read.FormatVersion).To(Equal(bundle.FormatVersion))
+		Expect(read.KECSVersion).To(Equal("v1.2.0"))
+		Expect(read.Images).To(Equal(manifest.Images))
+		Expect(read.LocalStack).To(BeTrue())
+		Expect(read.CreatedAt).NotTo(BeZero())
+
+		extractDir := GinkgoT().TempDir()
+		_, images, err := bundle.Extract(path, extractDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(filepath.Dir(images)).To(Equal(extractDir))
+		Expect(os.ReadFile(images)).To(Equal([]byte("traefik:v3.0\npostgres:16-alpine")))
+	})
+
+	It("leaves no temporary files behind", func() {
+		Expect(bundle.Write(ctx, store, path, bundle.Manifest{Images: []string{"traefik:v3.0"}})).To(Succeed())
+		entries, err := os.ReadDir(dir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Name()).To(Equal("kecs-bundle.tar.gz"))
+	})
+
+	It("rejects bundles without images", func() {
+		Expect(bundle.Write(ctx, store, path, bundle.Manifest{})).NotTo(Succeed())
+		Expect(path).NotTo(BeAnExistingFile())
+	})
+
+	It("rejects files that are not bundles", func() {
+		Expect(os.WriteFile(path, []byte("not a bundle"), 0644)).To(Succeed())
+		_, err := bundle.ReadManifest(path)
+		Expect(err).To(MatchError(ContainSubstring("is not a KECS bundle")))
+	})
+
+	Describe("Load", func() {
+		var (
+			manifest *bundle.Manifest
+			images   string
+		)
+
+		BeforeEach(func() {
+			Expect(bundle.Write(ctx, store, path, bundle.Manifest{Images: []string{"traefik:v3.0", "postgres:16-alpine"}})).To(Succeed())
+			var err error
+			manifest, images, err = bundle.Extract(path, GinkgoT().TempDir())
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("skips stores that have all images", func() {
+			Expect(bundle.Load(ctx, store, manifest, images)).To(Succeed())
+			Expect(store.loaded).To(BeNil())
+		})
+
+		It("loads the images into stores missing one", func() {
+			delete(store.images, "postgres:16-alpine")
+			Expect(bundle.Load(ctx, store, manifest, images)).To(Succeed())
+			Expect(bytes.Contains(store.loaded, []byte("postgres:16-alpine"))).To(BeTrue())
+		})
+	})
+
+	It("reports images missing from the manifest", func() {
+		manifest := bundle.Manifest{Images: []string{"traefik:v3.0", "postgres:16-alpine"}}
+		Expect(manifest.Missing([]string{"traefik:v3.0", "busybox:1.36"})).To(Equal([]string{"busybox:1.36"}))
+		Expect(manifest.Missing(manifest.Images)).To(BeEmpty())
+	})
+})
+
+var _ = Describe("RequiredImages", func() {
+	var cfg *config.Config
+
+	BeforeEach(func() {
+		cfg = &config.Config{}
+		cfg.Server.ControlPlaneImage = "ghcr.io/nandemo-ya/kecs:v1.2.0"
+		cfg.LocalStack.Image = "localstack/localstack"
+		cfg.LocalStack.Version = "4.0"
+	})
+
+	It("lists the nodes and components of an instance", func() {
+		images := bundle.RequiredImages(cfg, true)
+		Expect(images).To(ContainElements(
+			"rancher/k3s:v1.31.4-k3s1",
+			"docker.io/library/registry:2",
+			"docker.io/rancher/mirrored-pause:3.6",
+			"ghcr.io/nandemo-ya/kecs:v1.2.0",
+			"timberio/vector:0.34.0-alpine",
+			"localstack/localstack:4.0",
+		))
+	})
+
+	It("leaves out LocalStack when asked to", func() {
+		Expect(bundle.RequiredImages(cfg, false)).NotTo(ContainElement("localstack/localstack:4.0"))
+	})
+
+	It("leaves out LocalStack when the AWS stub replaces it", func() {
+		cfg.LocalStack.Backend = localstack.BackendStub
+		Expect(bundle.RequiredImages(cfg, true)).NotTo(ContainElement("localstack/localstack:4.0"))
+	})
+
+	It("lists every image once", func() {
+		cfg.Server.ControlPlaneImage = "busybox:1.36"
+		images := bundle.RequiredImages(cfg, true)
+		seen := map[string]bool{}
+		for _, image := range images {
+			Expect(seen).NotTo(HaveKey(image))
+			seen[image] = true
+		}
+	})
+
+	It("defaults the LocalStack image", func() {
+		Expect(bundle.LocalStackImage(&config.Config{})).To(Equal("localstack/localstack:latest"))
+	})
+})
@@ -0,0 +1,69 @@
+package bundle
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
+)
+
+// DockerImageStore is the ImageStore of the Docker daemon k3d runs on
+type DockerImageStore struct {
+	client *client.Client
+}
+
+// NewDockerImageStore connects to the Docker daemon of the environment
+func NewDockerImageStore() (*DockerImageStore, error) {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create docker client: %w", err)
+	}
+	return &DockerImageStore{client: cli}, nil
+}
+
+// Close closes the connection to the Docker daemon
+func (s *DockerImageStore) Close() error {
+	return s.client.Close()
+}
+
+// Exists reports whether an image is in the Docker daemon
+func (s *DockerImageStore) Exists(ctx context.Context, ref string) (bool, error) {
+	if _, err := s.client.ImageInspect(ctx, ref); err != nil {
+		if client.IsErrNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to inspect image %s: %w", ref, err)
+	}
+	return true, nil
+}
+
+// Pull pulls an image into the Docker daemon
+func (s *DockerImageStore) Pull(ctx context.Context, ref string) error {
+	progress, err := s.client.ImagePull(ctx, ref, image.PullOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", ref, err)
+	}
+	defer progress.Close()
+	if err := jsonmessage.DisplayJSONMessagesStream(progress, io.Discard, 0, false, nil); err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", ref, err)
+	}
+	return nil
+}
+
+// Save returns a docker save stream of images
+func (s *DockerImageStore) Save(ctx context.Context, refs []string) (io.ReadCloser, error) {
+	return s.client.ImageSave(ctx, refs)
+}
+
+// Load loads a docker save stream into the Docker daemon
+func (s *DockerImageStore) Load(ctx context.Context, r io.Reader) error {
+	response, err := s.client.ImageLoad(ctx, r, client.ImageLoadWithQuiet(true))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	return jsonmessage.DisplayJSONMessagesStream(response.Body, io.Discard, 0, false, nil)
+}
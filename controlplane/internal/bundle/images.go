@@ -0,0 +1,57 @@
+package bundle
+
+import (
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/host/k3d"
+	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/elbv2"
+	"github.com/nandemo-ya/kecs/controlplane/internal/kubernetes"
+	"github.com/nandemo-ya/kecs/controlplane/internal/kubernetes/resources"
+	"github.com/nandemo-ya/kecs/controlplane/internal/localstack"
+)
+
+// RequiredImages returns the images an instance started with cfg runs, from
+// the k3d nodes to the components deployed into them. The LocalStack image
+// is left out unless withLocalStack is set and cfg uses LocalStack rather
+// than the AWS stub.
+func RequiredImages(cfg *config.Config, withLocalStack bool) []string {
+	images := k3d.NodeImages("")
+	images = append(images, k3d.K3sSystemImages...)
+	images = append(images,
+		cfg.Server.ControlPlaneImage,
+		resources.PostgresImage,
+		resources.NetworkCheckImage,
+		resources.TraefikImage,
+		elbv2.ProxyImage,
+		kubernetes.VectorImage,
+	)
+	if withLocalStack && !cfg.LocalStack.UseStub() {
+		images = append(images, LocalStackImage(cfg))
+	}
+	return unique(images)
+}
+
+// LocalStackImage returns the LocalStack image of cfg
+func LocalStackImage(cfg *config.Config) string {
+	image, version := cfg.LocalStack.Image, cfg.LocalStack.Version
+	if image == "" {
+		image = localstack.DefaultImage
+	}
+	if version == "" {
+		version = localstack.DefaultVersion
+	}
+	return image + ":" + version
+}
+
+// unique drops empty and repeated images, keeping the order of the rest
+func unique(images []string) []string {
+	seen := make(map[string]bool, len(images))
+	result := make([]string, 0, len(images))
+	for _, image := range images {
+		if image == "" || seen[image] {
+			continue
+		}
+		seen[image] = true
+		result = append(result, image)
+	}
+	return result
+}
@@ -0,0 +1,163 @@
+// Package canary shifts the traffic of an ECS service step by step to a green
+// copy of it. The green copy runs as a second service behind its own target
+// group, the listener of the original target group forwards to both with
+// weights. After each step the 5xx rate the routing layer measured for the
+// green target group decides whether to continue or to roll back.
+package canary
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/elbv2"
+)
+
+// Status of a traffic shift
+type Status string
+
+const (
+	// StatusRunning is set while weights are shifted or baked
+	StatusRunning Status = "RUNNING"
+	// StatusCompleted is set once the target weight has baked without errors
+	StatusCompleted Status = "COMPLETED"
+	// StatusRolledBack is set when all traffic was returned to the blue
+	// target group because the green one failed
+	StatusRolledBack Status = "ROLLED_BACK"
+	// StatusFailed is set when the shift could not be carried out
+	StatusFailed Status = "FAILED"
+	// StatusStopped is set when the shift was stopped, the weights of the
+	// last step stay in place
+	StatusStopped Status = "STOPPED"
+)
+
+// GreenSuffix is appended to the names of the service and target group
+// receiving the shifted traffic
+const GreenSuffix = "-green"
+
+// maxTargetGroupName is the longest target group name ELBv2 accepts
+const maxTargetGroupName = 32
+
+// Spec describes a traffic shift
+type Spec struct {
+	Cluster string `json:"cluster"`
+	// Service is the blue service, it must be attached to a target group
+	Service string `json:"service"`
+	// TaskDefinition of the green service, required when it does not exist yet
+	TaskDefinition string `json:"taskDefinition,omitempty"`
+	// Weight is the percentage of traffic the green service should receive,
+	// 0 returns all traffic to the blue service and removes the green one
+	Weight int32 `json:"weight"`
+	// Step is the percentage added per step
+	Step int32 `json:"step,omitempty"`
+	// Bake is how long each step runs before its error rate is evaluated
+	Bake time.Duration `json:"bake,omitempty"`
+	// MaxErrorRate is the share of 5xx responses of the green target group
+	// above which traffic is rolled back
+	MaxErrorRate float64 `json:"maxErrorRate,omitempty"`
+	// MinRequests is the number of requests a step needs before its error
+	// rate is trusted
+	MinRequests int64 `json:"minRequests,omitempty"`
+	// GreenCount is the desired count of the green service
+	GreenCount int32 `json:"greenCount,omitempty"`
+}
+
+// Validate checks a spec and fills in defaults
+func (s *Spec) Validate() error {
+	if s.Service == "" {
+		return fmt.Errorf("service is required")
+	}
+	if s.Cluster == "" {
+		s.Cluster = "default"
+	}
+	if s.Weight < 0 || s.Weight > 100 {
+		return fmt.Errorf("weight must be between 0 and 100, got %d", s.Weight)
+	}
+	if s.Step == 0 {
+		s.Step = 10
+	}
+	if s.Step < 1 || s.Step > 100 {
+		return fmt.Errorf("step must be between 1 and 100, got %d", s.Step)
+	}
+	if s.Bake == 0 {
+		s.Bake = time.Minute
+	}
+	if s.Bake < 0 {
+		return fmt.Errorf("bake must be positive, got %s", s.Bake)
+	}
+	if s.MaxErrorRate == 0 {
+		s.MaxErrorRate = 0.05
+	}
+	if s.MaxErrorRate < 0 || s.MaxErrorRate > 1 {
+		return fmt.Errorf("max error rate must be between 0 and 1, got %g", s.MaxErrorRate)
+	}
+	if s.MinRequests == 0 {
+		s.MinRequests = 20
+	}
+	if s.MinRequests < 0 {
+		return fmt.Errorf("min requests must not be negative, got %d", s.MinRequests)
+	}
+	if s.GreenCount == 0 {
+		s.GreenCount = 1
+	}
+	if s.GreenCount < 0 {
+		return fmt.Errorf("green count must not be negative, got %d", s.GreenCount)
+	}
+	return nil
+}
+
+// Step is the outcome of one weight step
+type Step struct {
+	Weight int32 `json:"weight"`
+	// Traffic the green target group received while the step baked
+	Traffic   elbv2.TargetGroupTraffic `json:"traffic"`
+	ErrorRate float64                  `json:"errorRate"`
+	Time      time.Time                `json:"time"`
+}
+
+// Report is the state of a traffic shift
+type Report struct {
+	Spec   Spec   `json:"spec"`
+	Status Status `json:"status"`
+	// Message explains the status
+	Message string `json:"message,omitempty"`
+	// GreenWeight is the percentage of traffic currently forwarded to green
+	GreenWeight         int32  `json:"greenWeight"`
+	ListenerArn         string `json:"listenerArn,omitempty"`
+	BlueTargetGroupArn  string `json:"blueTargetGroupArn,omitempty"`
+	GreenTargetGroupArn string `json:"greenTargetGroupArn,omitempty"`
+	GreenService        string `json:"greenService,omitempty"`
+	// Steps lists the baked steps in order
+	Steps     []Step    `json:"steps,omitempty"`
+	StartedAt time.Time `json:"startedAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Done reports whether the shift has ended
+func (r Report) Done() bool {
+	return r.Status != StatusRunning
+}
+
+// Weights returns the green weights to step through from current to target.
+// Shifting towards green goes in steps of step, shifting back is immediate.
+func Weights(current, target, step int32) []int32 {
+	if target <= current {
+		if target == current {
+			return nil
+		}
+		return []int32{target}
+	}
+	var weights []int32
+	for weight := current + step; weight < target; weight += step {
+		weights = append(weights, weight)
+	}
+	return append(weights, target)
+}
+
+// GreenTargetGroupName returns the name of the green target group of a blue
+// target group, shortened to fit the ELBv2 limit
+func GreenTargetGroupName(blue string) string {
+	if len(blue)+len(GreenSuffix) > maxTargetGroupName {
+		blue = blue[:maxTargetGroupName-len(GreenSuffix)]
+	}
+	return blue + GreenSuffix
+}
@@ -0,0 +1,333 @@
+package canary_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/canary"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated_elbv2"
+	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/elbv2"
+	"github.com/nandemo-ya/kecs/controlplane/internal/utils"
+)
+
+const (
+	blueArn     = "arn:aws:elasticloadbalancing:us-east-1:000000000000:targetgroup/web/1"
+	listenerArn = "arn:aws:elasticloadbalancing:us-east-1:000000000000:listener/app/lb/1/1"
+	lbArn       = "arn:aws:elasticloadbalancing:us-east-1:000000000000:loadbalancer/app/lb/1"
+)
+
+type fakeECS struct {
+	services map[string]generated.Service
+	created  []generated.CreateServiceRequest
+	deleted  []string
+}
+
+func (f *fakeECS) DescribeServices(ctx context.Context, input *generated.DescribeServicesRequest) (*generated.DescribeServicesResponse, error) {
+	out := &generated.DescribeServicesResponse{}
+	for _, name := range input.Services {
+		if service, ok := f.services[name]; ok {
+			out.Services = append(out.Services, service)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeECS) CreateService(ctx context.Context, input *generated.CreateServiceRequest) (*generated.CreateServiceResponse, error) {
+	f.created = append(f.created, *input)
+	service := generated.Service{
+		ServiceName:   utils.Ptr(input.ServiceName),
+		Status:        utils.Ptr("ACTIVE"),
+		DesiredCount:  input.DesiredCount,
+		RunningCount:  input.DesiredCount,
+		LoadBalancers: input.LoadBalancers,
+	}
+	f.services[input.ServiceName] = service
+	return &generated.CreateServiceResponse{Service: &service}, nil
+}
+
+func (f *fakeECS) UpdateService(ctx context.Context, input *generated.UpdateServiceRequest) (*generated.UpdateServiceResponse, error) {
+	return &generated.UpdateServiceResponse{}, nil
+}
+
+func (f *fakeECS) DeleteService(ctx context.Context, input *generated.DeleteServiceRequest) (*generated.DeleteServiceResponse, error) {
+	f.deleted = append(f.deleted, input.Service)
+	delete(f.services, input.Service)
+	return &generated.DeleteServiceResponse{}, nil
+}
+
+type fakeELB struct {
+	targetGroups map[string]generated_elbv2.TargetGroup
+	listener     generated_elbv2.Listener
+	weights      []int32
+	deleted      []string
+}
+
+func (f *fakeELB) DescribeTargetGroups(ctx context.Context, input *generated_elbv2.DescribeTargetGroupsInput) (*generated_elbv2.DescribeTargetGroupsOutput, error) {
+	out := &generated_elbv2.DescribeTargetGroupsOutput{}
+	for _, tg := range f.targetGroups {
+		for _, arn := range input.TargetGroupArns {
+			if *tg.TargetGroupArn == arn {
+				out.TargetGroups = append(out.TargetGroups, tg)
+			}
+		}
+		for _, name := range input.Names {
+			if *tg.TargetGroupName == name {
+				out.TargetGroups = append(out.TargetGroups, tg)
+			}
+		}
+	}
+	if len(out.TargetGroups) == 0 {
+		return nil, fmt.Errorf("target group not found")
+	}
+	return out, nil
+}
+
+func (f *fakeELB) CreateTargetGroup(ctx context.Context, input *generated_elbv2.CreateTargetGroupInput) (*generated_elbv2.CreateTargetGroupOutput, error) {
+	tg := generated_elbv2.TargetGroup{
+		TargetGroupArn:  utils.Ptr("arn:aws:elasticloadbalancing:us-east-1:000000000000:targetgroup/" + input.Name + "/2"),
+		TargetGroupName: utils.Ptr(input.Name),
+		Port:            input.Port,
+		Protocol:        input.Protocol,
+	}
+	f.targetGroups[*tg.TargetGroupArn] = tg
+	return &generated_elbv2.CreateTargetGroupOutput{TargetGroups: []generated_elbv2.TargetGroup{tg}}, nil
+}
+
+func (f *fakeELB) DeleteTargetGroup(ctx context.Context, input *generated_elbv2.DeleteTargetGroupInput) (*generated_elbv2.DeleteTargetGroupOutput, error) {
+	f.deleted = append(f.deleted, input.TargetGroupArn)
+	delete(f.targetGroups, input.TargetGroupArn)
+	return &generated_elbv2.DeleteTargetGroupOutput{}, nil
+}
+
+func (f *fakeELB) DescribeLoadBalancers(ctx context.Context, input *generated_elbv2.DescribeLoadBalancersInput) (*generated_elbv2.DescribeLoadBalancersOutput, error) {
+	return &generated_elbv2.DescribeLoadBalancersOutput{
+		LoadBalancers: []generated_elbv2.LoadBalancer{{LoadBalancerArn: utils.Ptr(lbArn)}},
+	}, nil
+}
+
+func (f *fakeELB) DescribeListeners(ctx context.Context, input *generated_elbv2.DescribeListenersInput) (*generated_elbv2.DescribeListenersOutput, error) {
+	return &generated_elbv2.DescribeListenersOutput{Listeners: []generated_elbv2.Listener{f.listener}}, nil
+}
+
+func (f *fakeELB) ModifyListener(ctx context.Context, input *generated_elbv2.ModifyListenerInput) (*generated_elbv2.ModifyListenerOutput, error) {
+	f.listener.DefaultActions = input.DefaultActions
+	var weight int32
+	if config := input.DefaultActions[0].ForwardConfig; config != nil {
+		weight = *config.TargetGroups[1].Weight
+	}
+	f.weights = append(f.weights, weight)
+	return &generated_elbv2.ModifyListenerOutput{Listeners: []generated_elbv2.Listener{f.listener}}, nil
+}
+
+// fakeTraffic adds perReading to the traffic of every target group on each
+// reading, so every bake measures perReading
+type fakeTraffic struct {
+	mu         sync.Mutex
+	perReading elbv2.TargetGroupTraffic
+	total      elbv2.TargetGroupTraffic
+}
+
+func (f *fakeTraffic) TargetGroupTraffic(targetGroupArn string) elbv2.TargetGroupTraffic {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.total.Requests += f.perReading.Requests
+	f.total.Errors += f.perReading.Errors
+	return f.total
+}
+
+var _ = Describe("Weights", func() {
+	It("steps towards green", func() {
+		Expect(canary.Weights(0, 30, 10)).To(Equal([]int32{10, 20, 30}))
+		Expect(canary.Weights(10, 25, 10)).To(Equal([]int32{20, 25}))
+		Expect(canary.Weights(0, 100, 50)).To(Equal([]int32{50, 100}))
+	})
+
+	It("shifts back in one step", func() {
+		Expect(canary.Weights(50, 10, 10)).To(Equal([]int32{10}))
+	})
+
+	It("has no steps at the target", func() {
+		Expect(canary.Weights(20, 20, 10)).To(BeEmpty())
+	})
+})
+
+var _ = Describe("GreenTargetGroupName", func() {
+	It("appends the green suffix within the name limit", func() {
+		Expect(canary.GreenTargetGroupName("web")).To(Equal("web-green"))
+		name := canary.GreenTargetGroupName("a-very-long-target-group-name-xx")
+		Expect(name).To(HaveLen(32))
+		Expect(name).To(HaveSuffix("-green"))
+	})
+})
+
+var _ = Describe("Spec", func() {
+	It("fills in defaults", func() {
+		spec := canary.Spec{Service: "web", Weight: 10}
+		Expect(spec.Validate()).To(Succeed())
+		Expect(spec.Cluster).To(Equal("default"))
+		Expect(spec.Step).To(Equal(int32(10)))
+		Expect(spec.Bake).To(Equal(time.Minute))
+		Expect(spec.MaxErrorRate).To(Equal(0.05))
+		Expect(spec.GreenCount).To(Equal(int32(1)))
+	})
+
+	It("rejects weights above 100", func() {
+		spec := canary.Spec{Service: "web", Weight: 110}
+		Expect(spec.Validate()).To(MatchError(ContainSubstring("weight")))
+	})
+})
+
+var _ = Describe("Shifter", func() {
+	var (
+		ecs     *fakeECS
+		elb     *fakeELB
+		traffic *fakeTraffic
+		shifter *canary.Shifter
+		spec    canary.Spec
+	)
+
+	BeforeEach(func() {
+		ecs = &fakeECS{services: map[string]generated.Service{
+			"web": {
+				ServiceName: utils.Ptr("web"),
+				Status:      utils.Ptr("ACTIVE"),
+				LoadBalancers: []generated.LoadBalancer{{
+					ContainerName:  utils.Ptr("app"),
+					ContainerPort:  utils.Ptr(int32(8080)),
+					TargetGroupArn: utils.Ptr(blueArn),
+				}},
+			},
+		}}
+		elb = &fakeELB{
+			targetGroups: map[string]generated_elbv2.TargetGroup{
+				blueArn: {
+					TargetGroupArn:  utils.Ptr(blueArn),
+					TargetGroupName: utils.Ptr("web"),
+					Port:            utils.Ptr(int32(8080)),
+				},
+			},
+			listener: generated_elbv2.Listener{
+				ListenerArn:     utils.Ptr(listenerArn),
+				LoadBalancerArn: utils.Ptr(lbArn),
+				DefaultActions: []generated_elbv2.Action{{
+					Type:           generated_elbv2.ActionTypeEnumFORWARD,
+					TargetGroupArn: utils.Ptr(blueArn),
+				}},
+			},
+		}
+		traffic = &fakeTraffic{}
+		shifter = canary.NewShifter(ecs, elb, traffic)
+		shifter.PollInterval = time.Millisecond
+		spec = canary.Spec{
+			Service:        "web",
+			TaskDefinition: "web:2",
+			Weight:         30,
+			Bake:           time.Millisecond,
+		}
+	})
+
+	It("creates the green service and shifts in steps", func() {
+		traffic.perReading = elbv2.TargetGroupTraffic{Requests: 100, Errors: 1}
+
+		var updates int
+		report := shifter.Run(context.Background(), spec, func(canary.Report) { updates++ })
+
+		Expect(report.Status).To(Equal(canary.StatusCompleted), report.Message)
+		Expect(report.GreenWeight).To(Equal(int32(30)))
+		Expect(elb.weights).To(Equal([]int32{10, 20, 30}))
+		Expect(report.Steps).To(HaveLen(3))
+		Expect(report.Steps[0].Traffic.Requests).To(Equal(int64(100)))
+		Expect(updates).To(BeNumerically(">", 3))
+
+		Expect(ecs.created).To(HaveLen(1))
+		created := ecs.created[0]
+		Expect(created.ServiceName).To(Equal("web-green"))
+		Expect(*created.TaskDefinition).To(Equal("web:2"))
+		Expect(*created.LoadBalancers[0].ContainerName).To(Equal("app"))
+		Expect(*created.LoadBalancers[0].TargetGroupArn).To(Equal(report.GreenTargetGroupArn))
+		Expect(report.GreenTargetGroupArn).To(ContainSubstring("web-green"))
+	})
+
+	It("rolls back when the green 5xx rate is too high", func() {
+		traffic.perReading = elbv2.TargetGroupTraffic{Requests: 100, Errors: 20}
+
+		report := shifter.Run(context.Background(), spec, nil)
+
+		Expect(report.Status).To(Equal(canary.StatusRolledBack))
+		Expect(report.GreenWeight).To(Equal(int32(0)))
+		Expect(elb.weights).To(Equal([]int32{10, 0}))
+		Expect(report.Message).To(ContainSubstring("20.0%"))
+	})
+
+	It("ignores errors of steps with too few requests", func() {
+		traffic.perReading = elbv2.TargetGroupTraffic{Requests: 5, Errors: 5}
+
+		report := shifter.Run(context.Background(), spec, nil)
+
+		Expect(report.Status).To(Equal(canary.StatusCompleted))
+	})
+
+	It("resumes from the current weight", func() {
+		Expect(shifter.Run(context.Background(), spec, nil).Status).To(Equal(canary.StatusCompleted))
+		elb.weights = nil
+
+		spec.Weight = 50
+		report := shifter.Run(context.Background(), spec, nil)
+
+		Expect(report.Status).To(Equal(canary.StatusCompleted))
+		Expect(elb.weights).To(Equal([]int32{40, 50}))
+		Expect(ecs.created).To(HaveLen(1))
+	})
+
+	It("removes the green service at weight 0", func() {
+		Expect(shifter.Run(context.Background(), spec, nil).Status).To(Equal(canary.StatusCompleted))
+		greenArn := elb.listener.DefaultActions[0].ForwardConfig.TargetGroups[1].TargetGroupArn
+
+		spec.Weight = 0
+		report := shifter.Run(context.Background(), spec, nil)
+
+		Expect(report.Status).To(Equal(canary.StatusCompleted))
+		Expect(*elb.listener.DefaultActions[0].TargetGroupArn).To(Equal(blueArn))
+		Expect(elb.listener.DefaultActions[0].ForwardConfig).To(BeNil())
+		Expect(ecs.deleted).To(Equal([]string{"web-green"}))
+		Expect(elb.deleted).To(Equal([]string{*greenArn}))
+	})
+
+	It("requires a task definition for a new green service", func() {
+		spec.TaskDefinition = ""
+
+		report := shifter.Run(context.Background(), spec, nil)
+
+		Expect(report.Status).To(Equal(canary.StatusFailed))
+		Expect(report.Message).To(ContainSubstring("task definition is required"))
+	})
+
+	It("fails for services without a target group", func() {
+		ecs.services["web"] = generated.Service{ServiceName: utils.Ptr("web"), Status: utils.Ptr("ACTIVE")}
+
+		report := shifter.Run(context.Background(), spec, nil)
+
+		Expect(report.Status).To(Equal(canary.StatusFailed))
+		Expect(report.Message).To(ContainSubstring("not attached to a target group"))
+	})
+
+	It("stops when cancelled and keeps the weights", func() {
+		spec.Bake = time.Hour
+		ctx, cancel := context.WithCancel(context.Background())
+		report := shifter.Run(ctx, spec, func(r canary.Report) {
+			if r.GreenWeight == 10 {
+				cancel()
+			}
+		})
+
+		Expect(report.Status).To(Equal(canary.StatusStopped))
+		Expect(report.GreenWeight).To(Equal(int32(10)))
+		Expect(elb.weights).To(Equal([]int32{10}))
+	})
+})
@@ -0,0 +1,520 @@
+package canary
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated_elbv2"
+	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/elbv2"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/utils"
+)
+
+// ECSClient is the part of the ECS API a traffic shift uses
+type ECSClient interface {
+	DescribeServices(ctx context.Context, input *generated.DescribeServicesRequest) (*generated.DescribeServicesResponse, error)
+	CreateService(ctx context.Context, input *generated.CreateServiceRequest) (*generated.CreateServiceResponse, error)
+	UpdateService(ctx context.Context, input *generated.UpdateServiceRequest) (*generated.UpdateServiceResponse, error)
+	DeleteService(ctx context.Context, input *generated.DeleteServiceRequest) (*generated.DeleteServiceResponse, error)
+}
+
+// ELBv2Client is the part of the ELBv2 API a traffic shift uses
+type ELBv2Client interface {
+	DescribeTargetGroups(ctx context.Context, input *generated_elbv2.DescribeTargetGroupsInput) (*generated_elbv2.DescribeTargetGroupsOutput, error)
+	CreateTargetGroup(ctx context.Context, input *generated_elbv2.CreateTargetGroupInput) (*generated_elbv2.CreateTargetGroupOutput, error)
+	DeleteTargetGroup(ctx context.Context, input *generated_elbv2.DeleteTargetGroupInput) (*generated_elbv2.DeleteTargetGroupOutput, error)
+	DescribeLoadBalancers(ctx context.Context, input *generated_elbv2.DescribeLoadBalancersInput) (*generated_elbv2.DescribeLoadBalancersOutput, error)
+	DescribeListeners(ctx context.Context, input *generated_elbv2.DescribeListenersInput) (*generated_elbv2.DescribeListenersOutput, error)
+	ModifyListener(ctx context.Context, input *generated_elbv2.ModifyListenerInput) (*generated_elbv2.ModifyListenerOutput, error)
+}
+
+// Shifter carries out traffic shifts
+type Shifter struct {
+	ecs     ECSClient
+	elb     ELBv2Client
+	traffic elbv2.TrafficReporter
+
+	// PollInterval is how often the green service is checked while it starts
+	PollInterval time.Duration
+	// ReadyTimeout is how long the green service may take to start
+	ReadyTimeout time.Duration
+}
+
+// NewShifter creates a new shifter
+func NewShifter(ecs ECSClient, elb ELBv2Client, traffic elbv2.TrafficReporter) *Shifter {
+	return &Shifter{
+		ecs:          ecs,
+		elb:          elb,
+		traffic:      traffic,
+		PollInterval: 2 * time.Second,
+		ReadyTimeout: 5 * time.Minute,
+	}
+}
+
+// shift is the state of one Run
+type shift struct {
+	*Shifter
+	report   Report
+	update   func(Report)
+	listener generated_elbv2.Listener
+	blue     generated.Service
+	blueTG   generated_elbv2.TargetGroup
+}
+
+// Run shifts traffic as described by spec and returns the final report. The
+// report is passed to update whenever it changes. Cancelling ctx stops the
+// shift and keeps the weights of the last step.
+func (s *Shifter) Run(ctx context.Context, spec Spec, update func(Report)) Report {
+	now := time.Now()
+	sh := &shift{
+		Shifter: s,
+		update:  update,
+		report: Report{
+			Spec:      spec,
+			Status:    StatusRunning,
+			StartedAt: now,
+			UpdatedAt: now,
+		},
+	}
+	if err := spec.Validate(); err != nil {
+		sh.finish(StatusFailed, err.Error())
+		return sh.report
+	}
+	sh.report.Spec = spec
+
+	err := sh.run(ctx)
+	switch {
+	case err == nil:
+	case ctx.Err() != nil:
+		sh.finish(StatusStopped, fmt.Sprintf("Stopped at %d%% green", sh.report.GreenWeight))
+	default:
+		logging.Warn("Traffic shift failed", "cluster", spec.Cluster, "service", spec.Service, "error", err)
+		sh.finish(StatusFailed, err.Error())
+	}
+	return sh.report
+}
+
+func (sh *shift) run(ctx context.Context) error {
+	spec := sh.report.Spec
+	if err := sh.resolveBlue(ctx); err != nil {
+		return err
+	}
+	greenTG := sh.findTargetGroup(ctx, GreenTargetGroupName(deref(sh.blueTG.TargetGroupName)))
+	if greenTG != nil {
+		sh.report.GreenTargetGroupArn = deref(greenTG.TargetGroupArn)
+	}
+	sh.report.GreenWeight = forwardWeight(sh.listener.DefaultActions, sh.report.GreenTargetGroupArn)
+	sh.report.GreenService = spec.Service + GreenSuffix
+	sh.publish()
+
+	if spec.Weight == 0 {
+		return sh.remove(ctx)
+	}
+
+	if greenTG == nil {
+		created, err := sh.createGreenTargetGroup(ctx)
+		if err != nil {
+			return err
+		}
+		greenTG = created
+		sh.report.GreenTargetGroupArn = deref(greenTG.TargetGroupArn)
+	}
+	if err := sh.ensureGreenService(ctx); err != nil {
+		return err
+	}
+	sh.report.Message = "Waiting for the green service to start"
+	sh.publish()
+	if err := sh.waitGreenRunning(ctx); err != nil {
+		return err
+	}
+
+	for _, weight := range Weights(sh.report.GreenWeight, spec.Weight, spec.Step) {
+		if err := sh.setWeight(ctx, weight); err != nil {
+			return err
+		}
+		sh.report.Message = fmt.Sprintf("Baking %d%% green for %s", weight, spec.Bake)
+		sh.publish()
+
+		before := sh.traffic.TargetGroupTraffic(sh.report.GreenTargetGroupArn)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(spec.Bake):
+		}
+		baked := sh.traffic.TargetGroupTraffic(sh.report.GreenTargetGroupArn).Sub(before)
+		sh.report.Steps = append(sh.report.Steps, Step{
+			Weight:    weight,
+			Traffic:   baked,
+			ErrorRate: baked.ErrorRate(),
+			Time:      time.Now(),
+		})
+
+		if baked.Requests >= spec.MinRequests && baked.ErrorRate() > spec.MaxErrorRate {
+			if err := sh.setWeight(ctx, 0); err != nil {
+				return err
+			}
+			sh.finish(StatusRolledBack, fmt.Sprintf("Green 5xx rate %.1f%% at %d%% exceeded %.1f%%, all traffic returned to %s",
+				baked.ErrorRate()*100, weight, spec.MaxErrorRate*100, spec.Service))
+			return nil
+		}
+		sh.publish()
+	}
+
+	sh.finish(StatusCompleted, fmt.Sprintf("%d%% of traffic is forwarded to %s", spec.Weight, sh.report.GreenService))
+	return nil
+}
+
+// resolveBlue finds the target group of the blue service and the listener
+// forwarding to it
+func (sh *shift) resolveBlue(ctx context.Context) error {
+	spec := sh.report.Spec
+	blue, err := sh.describeService(ctx, spec.Service)
+	if err != nil {
+		return err
+	}
+	if blue == nil {
+		return fmt.Errorf("service %s not found in cluster %s", spec.Service, spec.Cluster)
+	}
+	if len(blue.LoadBalancers) == 0 || deref(blue.LoadBalancers[0].TargetGroupArn) == "" {
+		return fmt.Errorf("service %s is not attached to a target group", spec.Service)
+	}
+	sh.blue = *blue
+	blueArn := deref(blue.LoadBalancers[0].TargetGroupArn)
+
+	tgs, err := sh.elb.DescribeTargetGroups(ctx, &generated_elbv2.DescribeTargetGroupsInput{TargetGroupArns: []string{blueArn}})
+	if err != nil {
+		return fmt.Errorf("failed to describe target group %s: %w", blueArn, err)
+	}
+	if len(tgs.TargetGroups) == 0 {
+		return fmt.Errorf("target group %s not found", blueArn)
+	}
+	sh.blueTG = tgs.TargetGroups[0]
+	sh.report.BlueTargetGroupArn = blueArn
+
+	listener, err := sh.findListener(ctx, blueArn)
+	if err != nil {
+		return err
+	}
+	sh.listener = *listener
+	sh.report.ListenerArn = deref(listener.ListenerArn)
+	return nil
+}
+
+// findListener returns the listener whose default action forwards to a
+// target group
+func (sh *shift) findListener(ctx context.Context, targetGroupArn string) (*generated_elbv2.Listener, error) {
+	lbs, err := sh.elb.DescribeLoadBalancers(ctx, &generated_elbv2.DescribeLoadBalancersInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe load balancers: %w", err)
+	}
+	for _, lb := range lbs.LoadBalancers {
+		listeners, err := sh.elb.DescribeListeners(ctx, &generated_elbv2.DescribeListenersInput{LoadBalancerArn: lb.LoadBalancerArn})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe listeners of %s: %w", deref(lb.LoadBalancerArn), err)
+		}
+		for i := range listeners.Listeners {
+			if forwardIndex(listeners.Listeners[i].DefaultActions, targetGroupArn) >= 0 {
+				return &listeners.Listeners[i], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no listener forwards to target group %s", deref(sh.blueTG.TargetGroupName))
+}
+
+// findTargetGroup returns the target group with a name, nil when there is
+// none. DescribeTargetGroups fails for unknown names.
+func (sh *shift) findTargetGroup(ctx context.Context, name string) *generated_elbv2.TargetGroup {
+	tgs, err := sh.elb.DescribeTargetGroups(ctx, &generated_elbv2.DescribeTargetGroupsInput{Names: []string{name}})
+	if err != nil || len(tgs.TargetGroups) == 0 {
+		return nil
+	}
+	return &tgs.TargetGroups[0]
+}
+
+// createGreenTargetGroup creates a target group like the blue one
+func (sh *shift) createGreenTargetGroup(ctx context.Context) (*generated_elbv2.TargetGroup, error) {
+	blue := sh.blueTG
+	name := GreenTargetGroupName(deref(blue.TargetGroupName))
+	out, err := sh.elb.CreateTargetGroup(ctx, &generated_elbv2.CreateTargetGroupInput{
+		Name:                       name,
+		Port:                       blue.Port,
+		Protocol:                   blue.Protocol,
+		TargetType:                 blue.TargetType,
+		VpcId:                      blue.VpcId,
+		HealthCheckEnabled:         blue.HealthCheckEnabled,
+		HealthCheckPath:            blue.HealthCheckPath,
+		HealthCheckPort:            blue.HealthCheckPort,
+		HealthCheckProtocol:        blue.HealthCheckProtocol,
+		HealthCheckIntervalSeconds: blue.HealthCheckIntervalSeconds,
+		HealthCheckTimeoutSeconds:  blue.HealthCheckTimeoutSeconds,
+		HealthyThresholdCount:      blue.HealthyThresholdCount,
+		UnhealthyThresholdCount:    blue.UnhealthyThresholdCount,
+		Matcher:                    blue.Matcher,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create target group %s: %w", name, err)
+	}
+	if len(out.TargetGroups) == 0 {
+		return nil, fmt.Errorf("failed to create target group %s", name)
+	}
+	logging.Info("Created green target group", "name", name, "service", sh.report.Spec.Service)
+	return &out.TargetGroups[0], nil
+}
+
+// ensureGreenService creates the green service, or updates its task
+// definition and count when it exists
+func (sh *shift) ensureGreenService(ctx context.Context) error {
+	spec := sh.report.Spec
+	name := sh.report.GreenService
+	green, err := sh.describeService(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if green != nil {
+		input := &generated.UpdateServiceRequest{
+			Cluster:      &spec.Cluster,
+			Service:      name,
+			DesiredCount: &spec.GreenCount,
+		}
+		if spec.TaskDefinition != "" {
+			input.TaskDefinition = &spec.TaskDefinition
+		}
+		if _, err := sh.ecs.UpdateService(ctx, input); err != nil {
+			return fmt.Errorf("failed to update service %s: %w", name, err)
+		}
+		return nil
+	}
+
+	if spec.TaskDefinition == "" {
+		return fmt.Errorf("task definition is required to create service %s", name)
+	}
+	blueLB := sh.blue.LoadBalancers[0]
+	if _, err := sh.ecs.CreateService(ctx, &generated.CreateServiceRequest{
+		Cluster:        &spec.Cluster,
+		ServiceName:    name,
+		TaskDefinition: &spec.TaskDefinition,
+		DesiredCount:   &spec.GreenCount,
+		LaunchType:     sh.blue.LaunchType,
+		LoadBalancers: []generated.LoadBalancer{{
+			ContainerName:  blueLB.ContainerName,
+			ContainerPort:  blueLB.ContainerPort,
+			TargetGroupArn: &sh.report.GreenTargetGroupArn,
+		}},
+		NetworkConfiguration: sh.blue.NetworkConfiguration,
+	}); err != nil {
+		return fmt.Errorf("failed to create service %s: %w", name, err)
+	}
+	logging.Info("Created green service", "cluster", spec.Cluster, "service", name, "taskDefinition", spec.TaskDefinition)
+	return nil
+}
+
+// waitGreenRunning waits until all desired tasks of the green service run
+func (sh *shift) waitGreenRunning(ctx context.Context) error {
+	name := sh.report.GreenService
+	deadline := time.Now().Add(sh.ReadyTimeout)
+	ticker := time.NewTicker(sh.PollInterval)
+	defer ticker.Stop()
+	for {
+		green, err := sh.describeService(ctx, name)
+		if err != nil {
+			return err
+		}
+		if green != nil && green.RunningCount != nil && green.DesiredCount != nil &&
+			*green.RunningCount > 0 && *green.RunningCount >= *green.DesiredCount {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("service %s did not start within %s", name, sh.ReadyTimeout)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// setWeight forwards weight percent of the listener traffic to green and the
+// rest to blue
+func (sh *shift) setWeight(ctx context.Context, weight int32) error {
+	actions := withForward(sh.listener.DefaultActions, sh.report.BlueTargetGroupArn, weightedForward(sh.listener.DefaultActions,
+		sh.report.BlueTargetGroupArn, sh.report.GreenTargetGroupArn, weight))
+	if err := sh.modifyListener(ctx, actions); err != nil {
+		return err
+	}
+	sh.report.GreenWeight = weight
+	logging.Info("Shifted traffic", "service", sh.report.Spec.Service, "green", sh.report.GreenService, "weight", weight)
+	return nil
+}
+
+// remove returns all traffic to blue and deletes the green service and
+// target group
+func (sh *shift) remove(ctx context.Context) error {
+	spec := sh.report.Spec
+	plain := generated_elbv2.Action{
+		Type:           generated_elbv2.ActionTypeEnumFORWARD,
+		TargetGroupArn: &sh.report.BlueTargetGroupArn,
+	}
+	if err := sh.modifyListener(ctx, withForward(sh.listener.DefaultActions, sh.report.BlueTargetGroupArn, plain)); err != nil {
+		return err
+	}
+	sh.report.GreenWeight = 0
+
+	green, err := sh.describeService(ctx, sh.report.GreenService)
+	if err != nil {
+		return err
+	}
+	if green != nil {
+		if _, err := sh.ecs.DeleteService(ctx, &generated.DeleteServiceRequest{
+			Cluster: &spec.Cluster,
+			Service: sh.report.GreenService,
+			Force:   utils.Ptr(true),
+		}); err != nil {
+			return fmt.Errorf("failed to delete service %s: %w", sh.report.GreenService, err)
+		}
+	}
+	if sh.report.GreenTargetGroupArn != "" {
+		if _, err := sh.elb.DeleteTargetGroup(ctx, &generated_elbv2.DeleteTargetGroupInput{TargetGroupArn: sh.report.GreenTargetGroupArn}); err != nil {
+			return fmt.Errorf("failed to delete target group %s: %w", sh.report.GreenTargetGroupArn, err)
+		}
+	}
+	sh.finish(StatusCompleted, fmt.Sprintf("All traffic is forwarded to %s, the green service was removed", spec.Service))
+	return nil
+}
+
+func (sh *shift) modifyListener(ctx context.Context, actions []generated_elbv2.Action) error {
+	listenerArn := deref(sh.listener.ListenerArn)
+	out, err := sh.elb.ModifyListener(ctx, &generated_elbv2.ModifyListenerInput{
+		ListenerArn:    listenerArn,
+		DefaultActions: actions,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to modify listener %s: %w", listenerArn, err)
+	}
+	sh.listener.DefaultActions = actions
+	if out != nil && len(out.Listeners) > 0 {
+		sh.listener = out.Listeners[0]
+	}
+	return nil
+}
+
+// describeService returns an active service, nil when there is none
+func (sh *shift) describeService(ctx context.Context, name string) (*generated.Service, error) {
+	out, err := sh.ecs.DescribeServices(ctx, &generated.DescribeServicesRequest{
+		Cluster:  &sh.report.Spec.Cluster,
+		Services: []string{name},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe service %s: %w", name, err)
+	}
+	for i := range out.Services {
+		if deref(out.Services[i].Status) == "ACTIVE" {
+			return &out.Services[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (sh *shift) publish() {
+	sh.report.UpdatedAt = time.Now()
+	if sh.update != nil {
+		sh.update(sh.report)
+	}
+}
+
+func (sh *shift) finish(status Status, message string) {
+	sh.report.Status = status
+	sh.report.Message = message
+	sh.publish()
+}
+
+// forwardIndex returns the index of the forward action reaching a target
+// group, -1 when there is none
+func forwardIndex(actions []generated_elbv2.Action, targetGroupArn string) int {
+	for i, action := range actions {
+		if action.Type != generated_elbv2.ActionTypeEnumFORWARD {
+			continue
+		}
+		if deref(action.TargetGroupArn) == targetGroupArn {
+			return i
+		}
+		if action.ForwardConfig != nil {
+			for _, tg := range action.ForwardConfig.TargetGroups {
+				if deref(tg.TargetGroupArn) == targetGroupArn {
+					return i
+				}
+			}
+		}
+	}
+	return -1
+}
+
+// forwardWeight returns the percentage of forwarded traffic a target group
+// receives
+func forwardWeight(actions []generated_elbv2.Action, targetGroupArn string) int32 {
+	if targetGroupArn == "" {
+		return 0
+	}
+	i := forwardIndex(actions, targetGroupArn)
+	if i < 0 {
+		return 0
+	}
+	config := actions[i].ForwardConfig
+	if config == nil {
+		return 100
+	}
+	var total, weight int32
+	for _, tg := range config.TargetGroups {
+		w := int32(1)
+		if tg.Weight != nil {
+			w = *tg.Weight
+		}
+		total += w
+		if deref(tg.TargetGroupArn) == targetGroupArn {
+			weight = w
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return weight * 100 / total
+}
+
+// weightedForward builds a forward action splitting traffic between blue and
+// green. Stickiness of the existing forward action is kept.
+func weightedForward(actions []generated_elbv2.Action, blue, green string, weight int32) generated_elbv2.Action {
+	config := &generated_elbv2.ForwardActionConfig{
+		TargetGroups: []generated_elbv2.TargetGroupTuple{
+			{TargetGroupArn: utils.Ptr(blue), Weight: utils.Ptr(100 - weight)},
+			{TargetGroupArn: utils.Ptr(green), Weight: utils.Ptr(weight)},
+		},
+	}
+	if i := forwardIndex(actions, blue); i >= 0 && actions[i].ForwardConfig != nil {
+		config.TargetGroupStickinessConfig = actions[i].ForwardConfig.TargetGroupStickinessConfig
+	}
+	return generated_elbv2.Action{
+		Type:          generated_elbv2.ActionTypeEnumFORWARD,
+		ForwardConfig: config,
+	}
+}
+
+// withForward returns actions with the forward action reaching blue replaced
+func withForward(actions []generated_elbv2.Action, blue string, forward generated_elbv2.Action) []generated_elbv2.Action {
+	result := make([]generated_elbv2.Action, len(actions))
+	copy(result, actions)
+	if i := forwardIndex(result, blue); i >= 0 {
+		forward.Order = result[i].Order
+		result[i] = forward
+	}
+	return result
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
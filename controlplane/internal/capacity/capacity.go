@@ -0,0 +1,350 @@
+// Package capacity reports how much of the CPU and memory of the nodes of a
+// KECS instance the tasks request, so that users can see how many more tasks
+// fit before they stay PENDING.
+package capacity
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s "k8s.io/client-go/kubernetes"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+)
+
+const (
+	// DefaultCPUThreshold is the CPU utilization in percent above which the
+	// report warns
+	DefaultCPUThreshold = 80.0
+	// DefaultMemoryThreshold is the memory utilization in percent above which
+	// the report warns
+	DefaultMemoryThreshold = 80.0
+
+	// clusterLabel is the ECS cluster of the pods of tasks and services
+	clusterLabel = "kecs.dev/cluster"
+	// managedLabel marks the pods of tasks and services
+	managedLabel = "kecs.dev/managed-by"
+)
+
+// Thresholds are the utilizations in percent above which the report warns
+type Thresholds struct {
+	CPU    float64 `json:"cpu"`
+	Memory float64 `json:"memory"`
+}
+
+// DefaultThresholds returns the default warning thresholds
+func DefaultThresholds() Thresholds {
+	return Thresholds{CPU: DefaultCPUThreshold, Memory: DefaultMemoryThreshold}
+}
+
+// Resources are amounts of CPU in CPU units, 1024 per vCPU as in ECS, and of
+// memory in MiB
+type Resources struct {
+	CPU    int64 `json:"cpu"`
+	Memory int64 `json:"memory"`
+}
+
+// Add adds other to the resources
+func (r *Resources) Add(other Resources) {
+	r.CPU += other.CPU
+	r.Memory += other.Memory
+}
+
+// Utilization is the share of the allocatable resources that is requested,
+// in percent
+type Utilization struct {
+	CPU    float64 `json:"cpu"`
+	Memory float64 `json:"memory"`
+}
+
+// NodeCapacity is the capacity of a node
+type NodeCapacity struct {
+	Name         string      `json:"name"`
+	Architecture string      `json:"architecture,omitempty"`
+	Ready        bool        `json:"ready"`
+	Schedulable  bool        `json:"schedulable"`
+	Allocatable  Resources   `json:"allocatable"`
+	Requested    Resources   `json:"requested"`
+	Available    Resources   `json:"available"`
+	Utilization  Utilization `json:"utilization"`
+	Tasks        int         `json:"tasks"`
+	Pods         int         `json:"pods"`
+}
+
+// ClusterCapacity is what the tasks of an ECS cluster request
+type ClusterCapacity struct {
+	Cluster      string    `json:"cluster"`
+	Requested    Resources `json:"requested"`
+	Tasks        int       `json:"tasks"`
+	PendingTasks int       `json:"pendingTasks"`
+}
+
+// PendingPod is a pod waiting to be scheduled or started
+type PendingPod struct {
+	Name      string    `json:"name"`
+	Namespace string    `json:"namespace"`
+	Cluster   string    `json:"cluster,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	Requested Resources `json:"requested"`
+	Since     time.Time `json:"since"`
+}
+
+// Report is the capacity of an instance
+type Report struct {
+	GeneratedAt time.Time         `json:"generatedAt"`
+	Thresholds  Thresholds        `json:"thresholds"`
+	Allocatable Resources         `json:"allocatable"`
+	Requested   Resources         `json:"requested"`
+	Available   Resources         `json:"available"`
+	Utilization Utilization       `json:"utilization"`
+	Tasks       int               `json:"tasks"`
+	TaskDensity float64           `json:"taskDensity"`
+	Nodes       []NodeCapacity    `json:"nodes"`
+	Clusters    []ClusterCapacity `json:"clusters"`
+	PendingPods []PendingPod      `json:"pendingPods"`
+	Warnings    []string          `json:"warnings"`
+}
+
+// Collect reads the nodes and pods of an instance and builds its report
+func Collect(ctx context.Context, kubeClient k8s.Interface, thresholds Thresholds) (*Report, error) {
+	nodes, err := kubeClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	pods, err := kubeClient.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	return BuildReport(nodes.Items, pods.Items, thresholds, time.Now().UTC()), nil
+}
+
+// BuildReport builds the report of the nodes and pods of an instance. Pods of
+// all namespaces count against the nodes they run on, tasks are the pods of
+// ECS tasks and services. Nodes are sorted by name, clusters by requested
+// CPU, most first.
+func BuildReport(nodes []corev1.Node, pods []corev1.Pod, thresholds Thresholds, now time.Time) *Report {
+	report := &Report{
+		GeneratedAt: now,
+		Thresholds:  thresholds,
+		Nodes:       []NodeCapacity{},
+		Clusters:    []ClusterCapacity{},
+		PendingPods: []PendingPod{},
+		Warnings:    []string{},
+	}
+
+	nodeIndex := make(map[string]int, len(nodes))
+	for i := range nodes {
+		node := &nodes[i]
+		nodeIndex[node.Name] = len(report.Nodes)
+		report.Nodes = append(report.Nodes, NodeCapacity{
+			Name:         node.Name,
+			Architecture: converters.NodeArchitecture(node),
+			Ready:        isNodeReady(node),
+			Schedulable:  !node.Spec.Unschedulable,
+			Allocatable:  toResources(node.Status.Allocatable),
+		})
+	}
+
+	clusters := make(map[string]*ClusterCapacity)
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		requested := podRequests(pod)
+		isTask := pod.Labels[managedLabel] == "kecs"
+
+		var cluster *ClusterCapacity
+		if name := pod.Labels[clusterLabel]; isTask && name != "" {
+			cluster = clusters[name]
+			if cluster == nil {
+				cluster = &ClusterCapacity{Cluster: name}
+				clusters[name] = cluster
+			}
+			cluster.Requested.Add(requested)
+		}
+
+		if idx, ok := nodeIndex[pod.Spec.NodeName]; ok {
+			node := &report.Nodes[idx]
+			node.Requested.Add(requested)
+			node.Pods++
+			if isTask {
+				node.Tasks++
+			}
+		}
+
+		if pod.Status.Phase == corev1.PodPending {
+			reason, message := pendingReason(pod)
+			report.PendingPods = append(report.PendingPods, PendingPod{
+				Name:      pod.Name,
+				Namespace: pod.Namespace,
+				Cluster:   pod.Labels[clusterLabel],
+				Reason:    reason,
+				Message:   message,
+				Requested: requested,
+				Since:     pod.CreationTimestamp.UTC(),
+			})
+			if cluster != nil {
+				cluster.PendingTasks++
+			}
+		} else if isTask {
+			report.Tasks++
+			if cluster != nil {
+				cluster.Tasks++
+			}
+		}
+	}
+
+	schedulable := 0
+	for i := range report.Nodes {
+		node := &report.Nodes[i]
+		node.Available = available(node.Allocatable, node.Requested)
+		node.Utilization = utilization(node.Allocatable, node.Requested)
+		if !node.Ready || !node.Schedulable {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("node %s does not accept tasks (ready: %t, schedulable: %t)", node.Name, node.Ready, node.Schedulable))
+			continue
+		}
+		schedulable++
+		report.Allocatable.Add(node.Allocatable)
+		report.Requested.Add(node.Requested)
+		report.Warnings = append(report.Warnings, thresholdWarnings("node "+node.Name, node.Utilization, thresholds)...)
+	}
+	sort.Slice(report.Nodes, func(i, j int) bool { return report.Nodes[i].Name < report.Nodes[j].Name })
+
+	report.Available = available(report.Allocatable, report.Requested)
+	report.Utilization = utilization(report.Allocatable, report.Requested)
+	report.Warnings = append(report.Warnings, thresholdWarnings("instance", report.Utilization, thresholds)...)
+	if schedulable > 0 {
+		report.TaskDensity = float64(report.Tasks) / float64(schedulable)
+	}
+
+	for _, cluster := range clusters {
+		report.Clusters = append(report.Clusters, *cluster)
+	}
+	sort.Slice(report.Clusters, func(i, j int) bool {
+		if report.Clusters[i].Requested.CPU != report.Clusters[j].Requested.CPU {
+			return report.Clusters[i].Requested.CPU > report.Clusters[j].Requested.CPU
+		}
+		return report.Clusters[i].Cluster < report.Clusters[j].Cluster
+	})
+
+	sort.Slice(report.PendingPods, func(i, j int) bool {
+		return report.PendingPods[i].Since.Before(report.PendingPods[j].Since)
+	})
+	unschedulable := 0
+	for _, pod := range report.PendingPods {
+		if pod.Reason == corev1.PodReasonUnschedulable {
+			unschedulable++
+		}
+	}
+	if unschedulable > 0 {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("%d pods cannot be scheduled", unschedulable))
+	}
+
+	return report
+}
+
+// thresholdWarnings returns the warnings of a utilization above the thresholds
+func thresholdWarnings(subject string, u Utilization, thresholds Thresholds) []string {
+	var warnings []string
+	if thresholds.CPU > 0 && u.CPU > thresholds.CPU {
+		warnings = append(warnings, fmt.Sprintf("%s CPU utilization %.0f%% exceeds %.0f%%", subject, u.CPU, thresholds.CPU))
+	}
+	if thresholds.Memory > 0 && u.Memory > thresholds.Memory {
+		warnings = append(warnings, fmt.Sprintf("%s memory utilization %.0f%% exceeds %.0f%%", subject, u.Memory, thresholds.Memory))
+	}
+	return warnings
+}
+
+// podRequests returns the resources requested by a pod, as the scheduler
+// counts them: its containers and sidecars, at least what its largest init
+// container requests, plus its overhead
+func podRequests(pod *corev1.Pod) Resources {
+	var requests, sidecars, initPeak Resources
+	for _, c := range pod.Spec.Containers {
+		requests.Add(toResources(c.Resources.Requests))
+	}
+	for _, c := range pod.Spec.InitContainers {
+		r := toResources(c.Resources.Requests)
+		if c.RestartPolicy != nil && *c.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+			sidecars.Add(r)
+			continue
+		}
+		// Init containers run one at a time, next to the sidecars started before
+		initPeak.CPU = max(initPeak.CPU, sidecars.CPU+r.CPU)
+		initPeak.Memory = max(initPeak.Memory, sidecars.Memory+r.Memory)
+	}
+	requests.Add(sidecars)
+	requests.CPU = max(requests.CPU, initPeak.CPU)
+	requests.Memory = max(requests.Memory, initPeak.Memory)
+	requests.Add(toResources(pod.Spec.Overhead))
+	return requests
+}
+
+// toResources converts Kubernetes quantities to CPU units and MiB
+func toResources(list corev1.ResourceList) Resources {
+	var r Resources
+	if cpu, ok := list[corev1.ResourceCPU]; ok {
+		r.CPU = cpu.MilliValue() * 1024 / 1000
+	}
+	if memory, ok := list[corev1.ResourceMemory]; ok {
+		r.Memory = memory.Value() / (1024 * 1024)
+	}
+	return r
+}
+
+// available returns the allocatable resources that are not requested
+func available(allocatable, requested Resources) Resources {
+	return Resources{
+		CPU:    max(allocatable.CPU-requested.CPU, 0),
+		Memory: max(allocatable.Memory-requested.Memory, 0),
+	}
+}
+
+// utilization returns the share of the allocatable resources that is requested
+func utilization(allocatable, requested Resources) Utilization {
+	var u Utilization
+	if allocatable.CPU > 0 {
+		u.CPU = percent(requested.CPU, allocatable.CPU)
+	}
+	if allocatable.Memory > 0 {
+		u.Memory = percent(requested.Memory, allocatable.Memory)
+	}
+	return u
+}
+
+// percent returns part of total in percent, rounded to one decimal
+func percent(part, total int64) float64 {
+	return float64(part*1000/total) / 10
+}
+
+// isNodeReady reports whether the Ready condition of a node is true
+func isNodeReady(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// pendingReason returns why a pending pod is waiting: the scheduler's reason
+// while it is not scheduled, else the reason a container is waiting for
+func pendingReason(pod *corev1.Pod) (string, string) {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+			return condition.Reason, condition.Message
+		}
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Waiting != nil {
+			return status.State.Waiting.Reason, status.State.Waiting.Message
+		}
+	}
+	return "", ""
+}
@@ -0,0 +1,167 @@
+package capacity_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/capacity"
+)
+
+var now = time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+func node(name, cpu, memory string) corev1.Node {
+	return corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{corev1.LabelArchStable: "amd64"}},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			},
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+}
+
+func taskPod(name, cluster, nodeName, cpu, memory string, phase corev1.PodPhase) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cluster + "-us-east-1",
+			Labels:    map[string]string{"kecs.dev/cluster": cluster, "kecs.dev/managed-by": "kecs"},
+		},
+		Spec: corev1.PodSpec{
+			NodeName: nodeName,
+			Containers: []corev1.Container{{
+				Name: "app",
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(cpu),
+					corev1.ResourceMemory: resource.MustParse(memory),
+				}},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: phase},
+	}
+}
+
+var _ = Describe("BuildReport", func() {
+	It("reports requested and allocatable resources per node and cluster", func() {
+		nodes := []corev1.Node{node("server-0", "2", "4Gi"), node("agent-0", "1", "2Gi")}
+		pods := []corev1.Pod{
+			taskPod("web-1", "default", "server-0", "500m", "1Gi", corev1.PodRunning),
+			taskPod("web-2", "default", "agent-0", "500m", "512Mi", corev1.PodRunning),
+			taskPod("batch-1", "jobs", "server-0", "1", "1Gi", corev1.PodRunning),
+			taskPod("done-1", "jobs", "server-0", "1", "1Gi", corev1.PodSucceeded),
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system"},
+				Spec: corev1.PodSpec{NodeName: "server-0", Containers: []corev1.Container{{
+					Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("100m"),
+					}},
+				}}},
+				Status: corev1.PodStatus{Phase: corev1.PodRunning},
+			},
+		}
+
+		report := capacity.BuildReport(nodes, pods, capacity.DefaultThresholds(), now)
+
+		Expect(report.GeneratedAt).To(Equal(now))
+		Expect(report.Nodes).To(HaveLen(2))
+		agent, server := report.Nodes[0], report.Nodes[1]
+		Expect(agent.Name).To(Equal("agent-0"))
+		Expect(agent.Allocatable).To(Equal(capacity.Resources{CPU: 1024, Memory: 2048}))
+		Expect(agent.Requested).To(Equal(capacity.Resources{CPU: 512, Memory: 512}))
+		Expect(agent.Utilization).To(Equal(capacity.Utilization{CPU: 50, Memory: 25}))
+		Expect(agent.Tasks).To(Equal(1))
+
+		Expect(server.Requested).To(Equal(capacity.Resources{CPU: 512 + 1024 + 102, Memory: 2048}))
+		Expect(server.Available).To(Equal(capacity.Resources{CPU: 2048 - 1638, Memory: 2048}))
+		Expect(server.Tasks).To(Equal(2))
+		Expect(server.Pods).To(Equal(3))
+		Expect(server.Architecture).To(Equal("amd64"))
+
+		Expect(report.Allocatable).To(Equal(capacity.Resources{CPU: 3072, Memory: 6144}))
+		Expect(report.Tasks).To(Equal(3))
+		Expect(report.TaskDensity).To(Equal(1.5))
+
+		Expect(report.Clusters).To(HaveLen(2))
+		Expect(report.Clusters[0]).To(Equal(capacity.ClusterCapacity{
+			Cluster: "default", Requested: capacity.Resources{CPU: 1024, Memory: 1536}, Tasks: 2,
+		}))
+		Expect(report.Clusters[1].Cluster).To(Equal("jobs"))
+		Expect(report.Warnings).To(BeEmpty())
+	})
+
+	It("warns about utilization above the thresholds and unschedulable pods", func() {
+		nodes := []corev1.Node{node("server-0", "1", "1Gi")}
+		pending := taskPod("web-2", "default", "", "1", "512Mi", corev1.PodPending)
+		pending.Status.Conditions = []corev1.PodCondition{{
+			Type:    corev1.PodScheduled,
+			Status:  corev1.ConditionFalse,
+			Reason:  corev1.PodReasonUnschedulable,
+			Message: "0/1 nodes are available: 1 Insufficient cpu.",
+		}}
+		pods := []corev1.Pod{
+			taskPod("web-1", "default", "server-0", "900m", "256Mi", corev1.PodRunning),
+			pending,
+		}
+
+		report := capacity.BuildReport(nodes, pods, capacity.Thresholds{CPU: 80, Memory: 80}, now)
+
+		Expect(report.PendingPods).To(HaveLen(1))
+		Expect(report.PendingPods[0].Reason).To(Equal(corev1.PodReasonUnschedulable))
+		Expect(report.PendingPods[0].Message).To(ContainSubstring("Insufficient cpu"))
+		Expect(report.PendingPods[0].Cluster).To(Equal("default"))
+		Expect(report.Clusters[0].PendingTasks).To(Equal(1))
+		Expect(report.Clusters[0].Tasks).To(Equal(1))
+		Expect(report.Warnings).To(ConsistOf(
+			"node server-0 CPU utilization 90% exceeds 80%",
+			"instance CPU utilization 90% exceeds 80%",
+			"1 pods cannot be scheduled",
+		))
+	})
+
+	It("leaves nodes that do not accept tasks out of the totals", func() {
+		cordoned := node("agent-0", "4", "8Gi")
+		cordoned.Spec.Unschedulable = true
+		report := capacity.BuildReport([]corev1.Node{node("server-0", "1", "1Gi"), cordoned}, nil, capacity.DefaultThresholds(), now)
+
+		Expect(report.Allocatable).To(Equal(capacity.Resources{CPU: 1024, Memory: 1024}))
+		Expect(report.Warnings).To(ConsistOf("node agent-0 does not accept tasks (ready: true, schedulable: false)"))
+	})
+
+	It("counts init containers and sidecars like the scheduler", func() {
+		always := corev1.ContainerRestartPolicyAlways
+		pod := taskPod("web-1", "default", "server-0", "250m", "256Mi", corev1.PodRunning)
+		pod.Spec.InitContainers = []corev1.Container{
+			{Name: "migrate", Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("1"),
+			}}},
+			{Name: "proxy", RestartPolicy: &always, Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("128Mi"),
+			}}},
+		}
+
+		report := capacity.BuildReport([]corev1.Node{node("server-0", "2", "2Gi")}, []corev1.Pod{pod}, capacity.DefaultThresholds(), now)
+		Expect(report.Nodes[0].Requested).To(Equal(capacity.Resources{CPU: 1024, Memory: 384}))
+	})
+})
+
+var _ = Describe("Collect", func() {
+	It("reads the nodes and pods of the instance", func() {
+		n := node("server-0", "2", "4Gi")
+		p := taskPod("web-1", "default", "server-0", "500m", "1Gi", corev1.PodRunning)
+		client := fake.NewSimpleClientset(&n, &p)
+
+		report, err := capacity.Collect(context.Background(), client, capacity.DefaultThresholds())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Nodes).To(HaveLen(1))
+		Expect(report.Tasks).To(Equal(1))
+	})
+})
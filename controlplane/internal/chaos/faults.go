@@ -0,0 +1,248 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsrequest"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// Service names used to scope fault rules. They match the SigV4 signing names.
+const (
+	ServiceECS              = awsrequest.ServiceECS
+	ServiceELBv2            = awsrequest.ServiceELBv2
+	ServiceServiceDiscovery = awsrequest.ServiceServiceDiscovery
+	ServiceEC2              = awsrequest.ServiceEC2
+)
+
+// maxFaultLatency bounds the injected latency so a typo cannot hang clients forever
+const maxFaultLatency = 5 * time.Minute
+
+// FaultRule describes the faults injected into matching API operations.
+// Latency is applied first, then the request is throttled with probability
+// ThrottleRate or failed with probability ErrorRate.
+type FaultRule struct {
+	// Service limits the rule to one service, empty matches all services
+	Service string `json:"service,omitempty"`
+	// Operation limits the rule to one operation, e.g. RunTask. Empty or "*" matches all operations.
+	Operation string `json:"operation,omitempty"`
+
+	// ErrorRate is the probability (0-1) of failing the request
+	ErrorRate float64 `json:"errorRate,omitempty"`
+	// ErrorCode is the error code returned for failed requests (default: ServerException or InternalFailure)
+	ErrorCode string `json:"errorCode,omitempty"`
+	// StatusCode is the HTTP status of failed requests (default: 500)
+	StatusCode int `json:"statusCode,omitempty"`
+
+	// ThrottleRate is the probability (0-1) of throttling the request
+	ThrottleRate float64 `json:"throttleRate,omitempty"`
+	// RetryAfterSeconds is sent in the Retry-After header of throttled responses
+	RetryAfterSeconds int `json:"retryAfterSeconds,omitempty"`
+
+	// LatencyMs is added to every matching request
+	LatencyMs int `json:"latencyMs,omitempty"`
+	// LatencyJitterMs adds a random extra delay between 0 and LatencyJitterMs
+	LatencyJitterMs int `json:"latencyJitterMs,omitempty"`
+}
+
+// FaultConfig is the fault injection configuration managed through the admin API
+type FaultConfig struct {
+	// Seed makes the injected faults reproducible when non-zero
+	Seed  int64       `json:"seed,omitempty"`
+	Rules []FaultRule `json:"rules"`
+}
+
+// Validate checks the rule values
+func (r *FaultRule) Validate() error {
+	if r.ErrorRate < 0 || r.ErrorRate > 1 {
+		return fmt.Errorf("errorRate must be between 0 and 1, got %v", r.ErrorRate)
+	}
+	if r.ThrottleRate < 0 || r.ThrottleRate > 1 {
+		return fmt.Errorf("throttleRate must be between 0 and 1, got %v", r.ThrottleRate)
+	}
+	if r.StatusCode != 0 && (r.StatusCode < 400 || r.StatusCode > 599) {
+		return fmt.Errorf("statusCode must be an HTTP error status, got %d", r.StatusCode)
+	}
+	if r.RetryAfterSeconds < 0 || r.LatencyMs < 0 || r.LatencyJitterMs < 0 {
+		return fmt.Errorf("retryAfterSeconds, latencyMs and latencyJitterMs must not be negative")
+	}
+	if time.Duration(r.LatencyMs+r.LatencyJitterMs)*time.Millisecond > maxFaultLatency {
+		return fmt.Errorf("injected latency must not exceed %s", maxFaultLatency)
+	}
+	return nil
+}
+
+// matches reports whether the rule applies to an operation
+func (r *FaultRule) matches(service, operation string) bool {
+	if r.Service != "" && !strings.EqualFold(r.Service, service) {
+		return false
+	}
+	return r.Operation == "" || r.Operation == "*" || strings.EqualFold(r.Operation, operation)
+}
+
+// FaultInjector injects latency, errors and throttling into AWS API responses
+type FaultInjector struct {
+	mu     sync.Mutex
+	config FaultConfig
+	rand   *rand.Rand
+	// sleep waits for d or until ctx is done, replaced in tests
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewFaultInjector creates a fault injector without any rules
+func NewFaultInjector() *FaultInjector {
+	return &FaultInjector{
+		config: FaultConfig{Rules: []FaultRule{}},
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+		sleep:  sleepContext,
+	}
+}
+
+// Configure replaces the fault rules
+func (f *FaultInjector) Configure(config FaultConfig) error {
+	for i := range config.Rules {
+		if err := config.Rules[i].Validate(); err != nil {
+			return fmt.Errorf("invalid fault rule %d: %w", i, err)
+		}
+	}
+	if config.Rules == nil {
+		config.Rules = []FaultRule{}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.config = config
+	if config.Seed != 0 {
+		f.rand = rand.New(rand.NewSource(config.Seed))
+	}
+	logging.Info("Configured API fault injection", "rules", len(config.Rules))
+	return nil
+}
+
+// Config returns the current configuration
+func (f *FaultInjector) Config() FaultConfig {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	config := f.config
+	config.Rules = append([]FaultRule{}, f.config.Rules...)
+	return config
+}
+
+// Reset removes all fault rules
+func (f *FaultInjector) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.config = FaultConfig{Rules: []FaultRule{}}
+}
+
+// SetSleepFunc replaces the function used to wait for injected latency
+func (f *FaultInjector) SetSleepFunc(sleep func(ctx context.Context, d time.Duration) error) {
+	f.sleep = sleep
+}
+
+// faultDecision is the outcome of evaluating the rules for one request
+type faultDecision struct {
+	latency    time.Duration
+	throttle   *FaultRule
+	failure    *FaultRule
+	retryAfter int
+}
+
+// decide evaluates the rules for an operation. The first rule that throttles
+// or fails the request wins, latency of all matching rules adds up.
+func (f *FaultInjector) decide(service, operation string) faultDecision {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var decision faultDecision
+	for i := range f.config.Rules {
+		rule := f.config.Rules[i]
+		if !rule.matches(service, operation) {
+			continue
+		}
+		latency := time.Duration(rule.LatencyMs) * time.Millisecond
+		if rule.LatencyJitterMs > 0 {
+			latency += time.Duration(f.rand.Intn(rule.LatencyJitterMs+1)) * time.Millisecond
+		}
+		decision.latency += latency
+
+		if decision.throttle != nil || decision.failure != nil {
+			continue
+		}
+		if rule.ThrottleRate > 0 && f.rand.Float64() < rule.ThrottleRate {
+			decision.throttle = &rule
+			decision.retryAfter = rule.RetryAfterSeconds
+		} else if rule.ErrorRate > 0 && f.rand.Float64() < rule.ErrorRate {
+			decision.failure = &rule
+		}
+	}
+	return decision
+}
+
+// Middleware returns an HTTP middleware that injects faults into AWS API requests.
+// Requests that are not AWS API calls, such as health checks, pass through untouched.
+func (f *FaultInjector) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		op, ok := awsrequest.Identify(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		service, operation, queryProtocol := op.Service, op.Name, op.QueryProtocol
+
+		decision := f.decide(service, operation)
+		if decision.latency > 0 {
+			if err := f.sleep(r.Context(), decision.latency); err != nil {
+				return
+			}
+		}
+
+		switch {
+		case decision.throttle != nil:
+			logging.Debug("Injecting throttling", "service", service, "operation", operation)
+			if decision.retryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(decision.retryAfter))
+			}
+			code := "ThrottlingException"
+			if queryProtocol {
+				code = "Throttling"
+			}
+			awsrequest.WriteError(w, queryProtocol, http.StatusBadRequest, code, "Rate exceeded")
+		case decision.failure != nil:
+			logging.Debug("Injecting error", "service", service, "operation", operation)
+			status := decision.failure.StatusCode
+			if status == 0 {
+				status = http.StatusInternalServerError
+			}
+			code := decision.failure.ErrorCode
+			if code == "" {
+				code = "ServerException"
+				if queryProtocol {
+					code = "InternalFailure"
+				}
+			}
+			awsrequest.WriteError(w, queryProtocol, status, code, "Injected fault for "+operation)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,141 @@
+package chaos_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/chaos"
+)
+
+var _ = Describe("FaultInjector", func() {
+	var (
+		injector *chaos.FaultInjector
+		handler  http.Handler
+		slept    time.Duration
+		calls    int
+	)
+
+	ecsRequest := func(operation string) *http.Request {
+		req := httptest.NewRequest("POST", "/", strings.NewReader("{}"))
+		req.Header.Set("X-Amz-Target", "AmazonEC2ContainerServiceV20141113."+operation)
+		req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+		return req
+	}
+
+	elbv2Request := func(action string) *http.Request {
+		req := httptest.NewRequest("POST", "/", strings.NewReader("Action="+action+"&Version=2015-12-01"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+		return req
+	}
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	BeforeEach(func() {
+		slept = 0
+		calls = 0
+		injector = chaos.NewFaultInjector()
+		injector.SetSleepFunc(func(ctx context.Context, d time.Duration) error {
+			slept += d
+			return nil
+		})
+		handler = injector.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(http.StatusOK)
+		}))
+	})
+
+	It("should pass requests through without rules", func() {
+		Expect(serve(ecsRequest("ListClusters")).Code).To(Equal(http.StatusOK))
+		Expect(calls).To(Equal(1))
+	})
+
+	It("should throttle matching operations with Retry-After", func() {
+		Expect(injector.Configure(chaos.FaultConfig{Rules: []chaos.FaultRule{
+			{Service: "ecs", Operation: "RunTask", ThrottleRate: 1, RetryAfterSeconds: 2},
+		}})).To(Succeed())
+
+		rec := serve(ecsRequest("RunTask"))
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+		Expect(rec.Header().Get("Retry-After")).To(Equal("2"))
+		Expect(rec.Body.String()).To(ContainSubstring(`"__type":"ThrottlingException"`))
+
+		Expect(serve(ecsRequest("ListClusters")).Code).To(Equal(http.StatusOK))
+		Expect(calls).To(Equal(1))
+	})
+
+	It("should return query protocol errors for ELBv2", func() {
+		Expect(injector.Configure(chaos.FaultConfig{Rules: []chaos.FaultRule{
+			{Operation: "DescribeLoadBalancers", ErrorRate: 1},
+		}})).To(Succeed())
+
+		rec := serve(elbv2Request("DescribeLoadBalancers"))
+		Expect(rec.Code).To(Equal(http.StatusInternalServerError))
+		Expect(rec.Body.String()).To(ContainSubstring("<Code>InternalFailure</Code>"))
+		Expect(rec.Body.String()).To(ContainSubstring("<Type>Receiver</Type>"))
+	})
+
+	It("should keep the request body readable for the next handler", func() {
+		Expect(injector.Configure(chaos.FaultConfig{Rules: []chaos.FaultRule{
+			{Operation: "CreateRule", ErrorRate: 1},
+		}})).To(Succeed())
+
+		var action string
+		handler = injector.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.ParseForm()).To(Succeed())
+			action = r.PostForm.Get("Action")
+		}))
+		serve(elbv2Request("DescribeListeners"))
+		Expect(action).To(Equal("DescribeListeners"))
+	})
+
+	It("should inject latency and custom errors", func() {
+		Expect(injector.Configure(chaos.FaultConfig{Rules: []chaos.FaultRule{
+			{LatencyMs: 250},
+			{Service: "ecs", ErrorRate: 1, ErrorCode: "ClientException", StatusCode: 400},
+		}})).To(Succeed())
+
+		rec := serve(ecsRequest("DescribeServices"))
+		Expect(slept).To(Equal(250 * time.Millisecond))
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+		Expect(rec.Body.String()).To(ContainSubstring(`"__type":"ClientException"`))
+	})
+
+	It("should fail a share of requests according to the error rate", func() {
+		Expect(injector.Configure(chaos.FaultConfig{Seed: 42, Rules: []chaos.FaultRule{
+			{ErrorRate: 0.5},
+		}})).To(Succeed())
+
+		failures := 0
+		for i := 0; i < 200; i++ {
+			if serve(ecsRequest("ListTasks")).Code != http.StatusOK {
+				failures++
+			}
+		}
+		Expect(failures).To(BeNumerically("~", 100, 30))
+	})
+
+	It("should ignore requests that are not AWS API calls", func() {
+		Expect(injector.Configure(chaos.FaultConfig{Rules: []chaos.FaultRule{{ErrorRate: 1}}})).To(Succeed())
+		Expect(serve(httptest.NewRequest("GET", "/health", nil)).Code).To(Equal(http.StatusOK))
+	})
+
+	It("should reject invalid rules and reset", func() {
+		err := injector.Configure(chaos.FaultConfig{Rules: []chaos.FaultRule{{ErrorRate: 1.5}}})
+		Expect(err).To(MatchError(ContainSubstring("errorRate")))
+
+		Expect(injector.Configure(chaos.FaultConfig{Rules: []chaos.FaultRule{{ThrottleRate: 1}}})).To(Succeed())
+		injector.Reset()
+		Expect(injector.Config().Rules).To(BeEmpty())
+		Expect(serve(ecsRequest("ListClusters")).Code).To(Equal(http.StatusOK))
+	})
+})
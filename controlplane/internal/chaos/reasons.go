@@ -0,0 +1,80 @@
+package chaos
+
+import (
+	"fmt"
+	"sort"
+)
+
+// StopReason describes how a simulated stop is reported through the ECS API
+type StopReason struct {
+	// StopCode is the ECS TaskStopCode reported by DescribeTasks
+	StopCode string `json:"stopCode"`
+	// StoppedReason is the human readable reason reported by DescribeTasks
+	StoppedReason string `json:"stoppedReason"`
+}
+
+// Stop codes defined by the ECS API
+const (
+	StopCodeTaskFailedToStart         = "TaskFailedToStart"
+	StopCodeEssentialContainerExited  = "EssentialContainerExited"
+	StopCodeUserInitiated             = "UserInitiated"
+	StopCodeServiceSchedulerInitiated = "ServiceSchedulerInitiated"
+	StopCodeSpotInterruption          = "SpotInterruption"
+	StopCodeTerminationNotice         = "TerminationNotice"
+)
+
+// Reason presets accepted by the chaos API and CLI
+const (
+	ReasonRetirement = "retirement"
+	ReasonSpot       = "spot"
+	ReasonDrain      = "drain"
+	ReasonScheduler  = "scheduler"
+	ReasonUser       = "user"
+)
+
+// reasonPresets maps a preset name to the values AWS reports for that kind of stop
+var reasonPresets = map[string]StopReason{
+	ReasonRetirement: {
+		StopCode:      StopCodeTerminationNotice,
+		StoppedReason: "Task stopped because ECS is performing maintenance on the underlying infrastructure hosting the task.",
+	},
+	ReasonSpot: {
+		StopCode:      StopCodeSpotInterruption,
+		StoppedReason: "Your Spot Task was interrupted.",
+	},
+	ReasonDrain: {
+		StopCode:      StopCodeServiceSchedulerInitiated,
+		StoppedReason: "Task stopped because the container instance is being drained.",
+	},
+	ReasonScheduler: {
+		StopCode:      StopCodeServiceSchedulerInitiated,
+		StoppedReason: "Scaling activity initiated by (deployment ecs-svc)",
+	},
+	ReasonUser: {
+		StopCode:      StopCodeUserInitiated,
+		StoppedReason: "Task stopped by user",
+	},
+}
+
+// ResolveReason returns the stop reason for a preset name. A non-empty message
+// overrides the preset's stoppedReason.
+func ResolveReason(name, message string) (StopReason, error) {
+	reason, ok := reasonPresets[name]
+	if !ok {
+		return StopReason{}, fmt.Errorf("unknown stop reason %q, must be one of %v", name, ReasonNames())
+	}
+	if message != "" {
+		reason.StoppedReason = message
+	}
+	return reason, nil
+}
+
+// ReasonNames returns the supported preset names in sorted order
+func ReasonNames() []string {
+	names := make([]string, 0, len(reasonPresets))
+	for name := range reasonPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,186 @@
+// Package chaos simulates AWS-initiated disruptions such as Fargate task
+// retirement, Spot interruptions and container instance draining. Stops are
+// reported with the stopCode and stoppedReason ECS uses, and replacement tasks
+// are started by the regular service reconciliation. It also injects latency,
+// errors and throttling into API responses to exercise client retry logic.
+package chaos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controllers/sync/mappers"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// managedPodSelector selects the pods KECS runs for ECS tasks
+const managedPodSelector = "kecs.dev/managed-by=kecs"
+
+// DrainResult describes the outcome of draining a node
+type DrainResult struct {
+	Node          string   `json:"node"`
+	StoppedPods   []string `json:"stoppedPods"`
+	SkippedPods   []string `json:"skippedPods,omitempty"`
+	StopCode      string   `json:"stopCode"`
+	StoppedReason string   `json:"stoppedReason"`
+}
+
+// Simulator stops tasks and drains nodes on behalf of the chaos API
+type Simulator struct {
+	taskStore  storage.TaskStore
+	kubeClient kubernetes.Interface
+}
+
+// NewSimulator creates a new chaos simulator
+func NewSimulator(taskStore storage.TaskStore, kubeClient kubernetes.Interface) *Simulator {
+	return &Simulator{
+		taskStore:  taskStore,
+		kubeClient: kubeClient,
+	}
+}
+
+// StopTask stops a running task as if AWS had stopped it for the given reason.
+// The task is marked STOPPED in storage right away and its pod is deleted, so
+// that services replace it through the normal reconciliation.
+func (s *Simulator) StopTask(ctx context.Context, clusterARN, taskID string, reason StopReason) (*storage.Task, error) {
+	task, err := s.taskStore.Get(ctx, clusterARN, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	if task == nil {
+		return nil, fmt.Errorf("task not found: %s", taskID)
+	}
+	if task.DesiredStatus == "STOPPED" {
+		return nil, fmt.Errorf("task %s is already stopped", taskID)
+	}
+
+	now := deterministic.Now()
+	task.DesiredStatus = "STOPPED"
+	task.StopCode = reason.StopCode
+	task.StoppedReason = reason.StoppedReason
+	task.StoppingAt = &now
+	task.Version++
+	if err := s.taskStore.Update(ctx, task); err != nil {
+		return nil, fmt.Errorf("failed to update task: %w", err)
+	}
+
+	if s.kubeClient != nil && task.PodName != "" && task.Namespace != "" {
+		if err := s.stopPod(ctx, task.Namespace, task.PodName, reason); err != nil {
+			return nil, err
+		}
+	}
+
+	logging.Info("Simulated task stop",
+		"taskArn", task.ARN,
+		"stopCode", reason.StopCode,
+		"stoppedReason", reason.StoppedReason)
+	return task, nil
+}
+
+// DrainNode cordons a node and stops every KECS task running on it. Pods that
+// do not belong to a task are left alone.
+func (s *Simulator) DrainNode(ctx context.Context, nodeName string, reason StopReason) (*DrainResult, error) {
+	if s.kubeClient == nil {
+		return nil, fmt.Errorf("kubernetes client is not available")
+	}
+
+	if err := s.setUnschedulable(ctx, nodeName, true); err != nil {
+		return nil, err
+	}
+
+	pods, err := s.kubeClient.CoreV1().Pods("").List(ctx, metav1.ListOptions{
+		LabelSelector: managedPodSelector,
+		FieldSelector: "spec.nodeName=" + nodeName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods on node %s: %w", nodeName, err)
+	}
+
+	result := &DrainResult{
+		Node:          nodeName,
+		StoppedPods:   []string{},
+		StopCode:      reason.StopCode,
+		StoppedReason: reason.StoppedReason,
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		name := pod.Namespace + "/" + pod.Name
+		if pod.Spec.NodeName != nodeName || pod.DeletionTimestamp != nil || isFinished(pod) {
+			result.SkippedPods = append(result.SkippedPods, name)
+			continue
+		}
+		if err := s.stopPod(ctx, pod.Namespace, pod.Name, reason); err != nil {
+			return result, err
+		}
+		result.StoppedPods = append(result.StoppedPods, name)
+	}
+
+	logging.Info("Drained node", "node", nodeName, "stoppedPods", len(result.StoppedPods))
+	return result, nil
+}
+
+// UncordonNode makes a drained node schedulable again
+func (s *Simulator) UncordonNode(ctx context.Context, nodeName string) error {
+	if s.kubeClient == nil {
+		return fmt.Errorf("kubernetes client is not available")
+	}
+	return s.setUnschedulable(ctx, nodeName, false)
+}
+
+// stopPod records the stop reason on the pod and deletes it. The sync
+// controller copies the annotations to the task when it sees the deletion.
+func (s *Simulator) stopPod(ctx context.Context, namespace, podName string, reason StopReason) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				mappers.AnnotationStopCode:      reason.StopCode,
+				mappers.AnnotationStoppedReason: reason.StoppedReason,
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build pod patch: %w", err)
+	}
+
+	pods := s.kubeClient.CoreV1().Pods(namespace)
+	if _, err := pods.Patch(ctx, podName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to annotate pod %s/%s: %w", namespace, podName, err)
+	}
+	if err := pods.Delete(ctx, podName, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete pod %s/%s: %w", namespace, podName, err)
+	}
+	return nil
+}
+
+// setUnschedulable cordons or uncordons a node
+func (s *Simulator) setUnschedulable(ctx context.Context, nodeName string, unschedulable bool) error {
+	node, err := s.kubeClient.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get node %s: %w", nodeName, err)
+	}
+	if node.Spec.Unschedulable == unschedulable {
+		return nil
+	}
+	node.Spec.Unschedulable = unschedulable
+	if _, err := s.kubeClient.CoreV1().Nodes().Update(ctx, node, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update node %s: %w", nodeName, err)
+	}
+	return nil
+}
+
+// isFinished reports whether a pod has already terminated
+func isFinished(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+}
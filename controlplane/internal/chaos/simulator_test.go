@@ -0,0 +1,154 @@
+package chaos_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/chaos"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controllers/sync/mappers"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/mocks"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+var _ = Describe("Simulator", func() {
+	const clusterARN = "arn:aws:ecs:us-east-1:000000000000:cluster/default"
+
+	var (
+		ctx        context.Context
+		kubeClient *fake.Clientset
+		taskStore  *mocks.MockTaskStore
+		simulator  *chaos.Simulator
+	)
+
+	newPod := func(name, node string, managed bool) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default-us-east-1", Labels: map[string]string{}},
+			Spec:       corev1.PodSpec{NodeName: node},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+		if managed {
+			pod.Labels["kecs.dev/managed-by"] = "kecs"
+		}
+		return pod
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		kubeClient = fake.NewSimpleClientset(
+			&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a"}},
+			newPod("web-1", "node-a", true),
+			newPod("web-2", "node-b", true),
+			newPod("coredns", "node-a", false),
+		)
+		taskStore = mocks.NewMockTaskStore()
+		simulator = chaos.NewSimulator(taskStore, kubeClient)
+	})
+
+	Describe("ResolveReason", func() {
+		It("should map presets to ECS stop codes", func() {
+			reason, err := chaos.ResolveReason(chaos.ReasonRetirement, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reason.StopCode).To(Equal("TerminationNotice"))
+			Expect(reason.StoppedReason).To(ContainSubstring("maintenance"))
+
+			reason, err = chaos.ResolveReason(chaos.ReasonSpot, "interrupted")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reason).To(Equal(chaos.StopReason{StopCode: "SpotInterruption", StoppedReason: "interrupted"}))
+		})
+
+		It("should reject unknown presets", func() {
+			_, err := chaos.ResolveReason("meteor", "")
+			Expect(err).To(MatchError(ContainSubstring("unknown stop reason")))
+		})
+	})
+
+	Describe("StopTask", func() {
+		BeforeEach(func() {
+			Expect(taskStore.Create(ctx, &storage.Task{
+				ID:            "task-1",
+				ARN:           "arn:aws:ecs:us-east-1:000000000000:task/default/task-1",
+				ClusterARN:    clusterARN,
+				DesiredStatus: "RUNNING",
+				LastStatus:    "RUNNING",
+				PodName:       "web-1",
+				Namespace:     "default-us-east-1",
+			})).To(Succeed())
+		})
+
+		It("should record the stop code and delete the pod", func() {
+			reason, _ := chaos.ResolveReason(chaos.ReasonRetirement, "")
+			task, err := simulator.StopTask(ctx, clusterARN, "task-1", reason)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(task.DesiredStatus).To(Equal("STOPPED"))
+			Expect(task.StopCode).To(Equal("TerminationNotice"))
+			Expect(task.StoppingAt).NotTo(BeNil())
+
+			_, err = kubeClient.CoreV1().Pods("default-us-east-1").Get(ctx, "web-1", metav1.GetOptions{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should refuse to stop a stopped task", func() {
+			reason, _ := chaos.ResolveReason(chaos.ReasonUser, "")
+			_, err := simulator.StopTask(ctx, clusterARN, "task-1", reason)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = simulator.StopTask(ctx, clusterARN, "task-1", reason)
+			Expect(err).To(MatchError(ContainSubstring("already stopped")))
+		})
+	})
+
+	Describe("DrainNode", func() {
+		It("should cordon the node and stop only KECS tasks on it", func() {
+			reason, _ := chaos.ResolveReason(chaos.ReasonDrain, "")
+			result, err := simulator.DrainNode(ctx, "node-a", reason)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.StoppedPods).To(ConsistOf("default-us-east-1/web-1"))
+			Expect(result.StopCode).To(Equal("ServiceSchedulerInitiated"))
+
+			node, err := kubeClient.CoreV1().Nodes().Get(ctx, "node-a", metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(node.Spec.Unschedulable).To(BeTrue())
+
+			_, err = kubeClient.CoreV1().Pods("default-us-east-1").Get(ctx, "web-2", metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = kubeClient.CoreV1().Pods("default-us-east-1").Get(ctx, "coredns", metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should annotate pods with the stop reason before deleting them", func() {
+			var annotations map[string]string
+			kubeClient.PrependReactor("delete", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				pod, err := kubeClient.Tracker().Get(corev1.SchemeGroupVersion.WithResource("pods"), action.GetNamespace(), action.(k8stesting.DeleteAction).GetName())
+				if err == nil {
+					annotations = pod.(*corev1.Pod).Annotations
+				}
+				return false, nil, nil
+			})
+
+			reason, _ := chaos.ResolveReason(chaos.ReasonSpot, "")
+			_, err := simulator.DrainNode(ctx, "node-a", reason)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(annotations).To(HaveKeyWithValue(mappers.AnnotationStopCode, "SpotInterruption"))
+			Expect(annotations).To(HaveKeyWithValue(mappers.AnnotationStoppedReason, reason.StoppedReason))
+		})
+
+		It("should make the node schedulable again on uncordon", func() {
+			reason, _ := chaos.ResolveReason(chaos.ReasonDrain, "")
+			_, err := simulator.DrainNode(ctx, "node-a", reason)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(simulator.UncordonNode(ctx, "node-a")).To(Succeed())
+
+			node, err := kubeClient.CoreV1().Nodes().Get(ctx, "node-a", metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(node.Spec.Unschedulable).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,123 @@
+package cloudformation
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// cdkBootstrapVersion is the value of the /cdk-bootstrap/<qualifier>/version
+// SSM parameters. KECS deploys CDK apps without a bootstrap stack, so it
+// reports a version recent enough for the CheckBootstrapVersion rule.
+const cdkBootstrapVersion = "21"
+
+// cdkBootstrapVersionParameter matches the SSM parameter the CDK default
+// synthesizer reads the bootstrap version from
+var cdkBootstrapVersionParameter = regexp.MustCompile(`^/cdk-bootstrap/[^/]+/version$`)
+
+// maxTemplateSize is the size limit of templates fetched from TemplateURL
+const maxTemplateSize = 1 << 20
+
+// resolveSSMParameter returns the value of an AWS::SSM::Parameter::Value
+// parameter. Only the CDK bootstrap version is supported.
+func resolveSSMParameter(name string) (string, error) {
+	if cdkBootstrapVersionParameter.MatchString(name) {
+		return cdkBootstrapVersion, nil
+	}
+	return "", fmt.Errorf("SSM parameter %s cannot be resolved, only the CDK bootstrap version parameter is supported", name)
+}
+
+// parseS3URL returns the bucket and key of an S3 object URL in the path
+// style (https://s3.region.amazonaws.com/bucket/key), virtual hosted style
+// (https://bucket.s3.region.amazonaws.com/key) or s3://bucket/key form
+func parseS3URL(rawURL string) (bucket, key string, ok bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", false
+	}
+	path := strings.TrimPrefix(u.Path, "/")
+
+	switch u.Scheme {
+	case "s3":
+		return u.Host, path, u.Host != "" && path != ""
+	case "http", "https":
+	default:
+		return "", "", false
+	}
+
+	host := u.Hostname()
+	if !strings.HasSuffix(host, ".amazonaws.com") {
+		return "", "", false
+	}
+	if strings.HasPrefix(host, "s3.") || strings.HasPrefix(host, "s3-") {
+		parts := strings.SplitN(path, "/", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return "", "", false
+		}
+		return parts[0], parts[1], true
+	}
+	if i := strings.Index(host, ".s3."); i > 0 && path != "" {
+		return host[:i], path, true
+	}
+	if i := strings.Index(host, ".s3-"); i > 0 && path != "" {
+		return host[:i], path, true
+	}
+	return "", "", false
+}
+
+// localS3URL maps an S3 object URL to the same object in LocalStack. CDK
+// uploads assets and large templates to its asset bucket, which lives in
+// LocalStack when deploying against KECS.
+func (e *Engine) localS3URL(rawURL string) (string, bool) {
+	if e.s3Endpoint == nil {
+		return "", false
+	}
+	bucket, key, ok := parseS3URL(rawURL)
+	if !ok {
+		return "", false
+	}
+	endpoint, err := e.s3Endpoint()
+	if err != nil || endpoint == "" {
+		return "", false
+	}
+	return fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(endpoint, "/"), bucket, key), true
+}
+
+// fetchTemplate downloads a template given as TemplateURL from LocalStack S3
+func (e *Engine) fetchTemplate(ctx context.Context, templateURL string) (string, error) {
+	if _, _, ok := parseS3URL(templateURL); !ok {
+		return "", validationError("TemplateURL must be an Amazon S3 URL")
+	}
+	localURL, ok := e.localS3URL(templateURL)
+	if !ok {
+		return "", validationError("TemplateURL requires LocalStack S3, which is not available")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, localURL, nil)
+	if err != nil {
+		return "", validationError("invalid TemplateURL: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", validationError("failed to fetch template from %s: %v", templateURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", validationError("failed to fetch template from %s: S3 returned %s", templateURL, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTemplateSize+1))
+	if err != nil {
+		return "", validationError("failed to fetch template from %s: %v", templateURL, err)
+	}
+	if len(body) > maxTemplateSize {
+		return "", validationError("template at %s exceeds the maximum size of %d bytes", templateURL, maxTemplateSize)
+	}
+	return string(body), nil
+}
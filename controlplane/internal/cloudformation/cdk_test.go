@@ -0,0 +1,190 @@
+package cloudformation
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// cdkTemplate is trimmed from the cdk synth output of a stack with a Fargate
+// service
+const cdkTemplate = `{
+  "Resources": {
+    "ClusterEB0386A7": {
+      "Type": "AWS::ECS::Cluster",
+      "Metadata": {"aws:cdk:path": "WebStack/Cluster/Resource"}
+    },
+    "TaskDef54694570": {
+      "Type": "AWS::ECS::TaskDefinition",
+      "Properties": {
+        "Family": "WebStackTaskDef",
+        "Cpu": "256",
+        "Memory": "512",
+        "NetworkMode": "awsvpc",
+        "RequiresCompatibilities": ["FARGATE"],
+        "ContainerDefinitions": [{"Name": "web", "Image": "nginx", "Essential": true}]
+      },
+      "Metadata": {"aws:cdk:path": "WebStack/TaskDef/Resource"}
+    },
+    "ServiceD69D759B": {
+      "Type": "AWS::ECS::Service",
+      "Properties": {
+        "Cluster": {"Ref": "ClusterEB0386A7"},
+        "TaskDefinition": {"Ref": "TaskDef54694570"},
+        "DesiredCount": 1,
+        "LaunchType": "FARGATE"
+      },
+      "Metadata": {"aws:cdk:path": "WebStack/Service/Service"}
+    },
+    "CDKMetadata": {
+      "Type": "AWS::CDK::Metadata",
+      "Properties": {"Analytics": "v2:deflate64:H4sIAAAAAAAA"},
+      "Metadata": {"aws:cdk:path": "WebStack/CDKMetadata/Default"},
+      "Condition": "CDKMetadataAvailable"
+    }
+  },
+  "Conditions": {
+    "CDKMetadataAvailable": {
+      "Fn::Or": [
+        {"Fn::Equals": [{"Ref": "AWS::Region"}, "us-east-1"]},
+        {"Fn::Equals": [{"Ref": "AWS::Region"}, "us-west-2"]}
+      ]
+    }
+  },
+  "Parameters": {
+    "BootstrapVersion": {
+      "Type": "AWS::SSM::Parameter::Value<String>",
+      "Default": "/cdk-bootstrap/hnb659fds/version",
+      "Description": "Version of the CDK Bootstrap resources in this environment, automatically retrieved from SSM Parameter Store. [cdk:skip]"
+    }
+  },
+  "Rules": {
+    "CheckBootstrapVersion": {
+      "Assertions": [{
+        "Assert": {"Fn::Not": [{"Fn::Contains": [["1", "2", "3", "4", "5"], {"Ref": "BootstrapVersion"}]}]},
+        "AssertDescription": "CDK bootstrap stack version 6 required."
+      }]
+    }
+  }
+}`
+
+var _ = Describe("CDK", func() {
+	var (
+		ctx    context.Context
+		ecs    *fakeECS
+		engine *Engine
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		ecs = &fakeECS{}
+		engine = NewEngine(ecs, nil, "us-east-1", "123456789012")
+	})
+
+	It("should deploy a synthesized stack through a change set", func() {
+		changeSet, err := engine.CreateChangeSet(ctx, CreateChangeSetInput{
+			StackName:     "WebStack",
+			ChangeSetName: "cdk-deploy-change-set",
+			ChangeSetType: "CREATE",
+			TemplateBody:  cdkTemplate,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changeSet.Status).To(Equal(StatusCreateComplete))
+		Expect(changeSet.ExecutionStatus).To(Equal(ExecutionAvailable))
+		Expect(changeSet.Parameters).To(HaveKeyWithValue("BootstrapVersion", cdkBootstrapVersion))
+		Expect(changeSet.Changes).To(HaveLen(4))
+		Expect(ecs.calls).To(BeEmpty())
+
+		stacks, err := engine.DescribeStacks("WebStack")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stacks[0].Status).To(Equal(StatusReviewInProgress))
+
+		Expect(engine.ExecuteChangeSet(ctx, "cdk-deploy-change-set", "WebStack")).To(Succeed())
+		changeSet, err = engine.DescribeChangeSet(changeSet.ChangeSetID, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changeSet.ExecutionStatus).To(Equal(ExecutionComplete))
+
+		stacks, err = engine.DescribeStacks("WebStack")
+		Expect(err).NotTo(HaveOccurred())
+		stack := stacks[0]
+		Expect(stack.Status).To(Equal(StatusCreateComplete))
+		Expect(stack.TemplateBody).To(Equal(cdkTemplate))
+		Expect(stack.Events[0].Status).To(Equal(StatusCreateComplete))
+		Expect(stack.Events[0].Type).To(Equal("AWS::CloudFormation::Stack"))
+
+		var metadata *StackResource
+		for _, resource := range stack.Resources {
+			if resource.LogicalID == "CDKMetadata" {
+				metadata = resource
+			}
+		}
+		Expect(metadata).NotTo(BeNil())
+		Expect(metadata.Metadata).To(HaveKeyWithValue("aws:cdk:path", "WebStack/CDKMetadata/Default"))
+
+		err = engine.ExecuteChangeSet(ctx, changeSet.ChangeSetID, "")
+		Expect(err).To(MatchError(ContainSubstring("EXECUTE_COMPLETE")))
+	})
+
+	It("should skip resources whose condition is false", func() {
+		engine = NewEngine(ecs, nil, "eu-central-1", "123456789012")
+		stack, err := engine.CreateStack(ctx, CreateStackInput{StackName: "WebStack", TemplateBody: cdkTemplate})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stack.Status).To(Equal(StatusCreateComplete))
+		Expect(stack.Resources).To(HaveLen(3))
+	})
+
+	It("should reject update change sets", func() {
+		_, err := engine.CreateChangeSet(ctx, CreateChangeSetInput{
+			StackName:     "WebStack",
+			ChangeSetName: "update",
+			ChangeSetType: "UPDATE",
+			TemplateBody:  cdkTemplate,
+		})
+		Expect(err).To(MatchError(ContainSubstring("only supports CREATE change sets")))
+	})
+
+	It("should fetch TemplateURL from LocalStack S3", func() {
+		var requested string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requested = r.URL.Path
+			w.Write([]byte(cdkTemplate))
+		}))
+		defer server.Close()
+		engine.SetS3Endpoint(func() (string, error) { return server.URL, nil })
+
+		stack, err := engine.CreateStack(ctx, CreateStackInput{
+			StackName:   "WebStack",
+			TemplateURL: "https://s3.us-east-1.amazonaws.com/cdk-hnb659fds-assets-123456789012-us-east-1/template.json",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stack.Status).To(Equal(StatusCreateComplete))
+		Expect(requested).To(Equal("/cdk-hnb659fds-assets-123456789012-us-east-1/template.json"))
+	})
+
+	It("should reject SSM parameters other than the bootstrap version", func() {
+		_, err := engine.CreateStack(ctx, CreateStackInput{
+			StackName:    "WebStack",
+			TemplateBody: cdkTemplate,
+			Parameters:   map[string]string{"BootstrapVersion": "/my/parameter"},
+		})
+		Expect(err).To(MatchError(ContainSubstring("SSM parameter /my/parameter cannot be resolved")))
+	})
+
+	DescribeTable("parsing S3 URLs",
+		func(url, bucket, key string, ok bool) {
+			b, k, valid := parseS3URL(url)
+			Expect(valid).To(Equal(ok))
+			Expect(b).To(Equal(bucket))
+			Expect(k).To(Equal(key))
+		},
+		Entry("path style", "https://s3.us-east-1.amazonaws.com/assets/a/b.json", "assets", "a/b.json", true),
+		Entry("legacy path style", "https://s3-us-west-2.amazonaws.com/assets/b.json", "assets", "b.json", true),
+		Entry("virtual hosted style", "https://assets.s3.us-east-1.amazonaws.com/b.json", "assets", "b.json", true),
+		Entry("s3 scheme", "s3://assets/b.json", "assets", "b.json", true),
+		Entry("other host", "https://example.com/assets/b.json", "", "", false),
+		Entry("bucket only", "https://s3.amazonaws.com/assets", "", "", false),
+	)
+})
@@ -0,0 +1,192 @@
+package cloudformation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
+)
+
+// Change set execution statuses
+const (
+	ExecutionAvailable = "AVAILABLE"
+	ExecutionComplete  = "EXECUTE_COMPLETE"
+	ExecutionFailed    = "EXECUTE_FAILED"
+)
+
+// ChangeSet is a CREATE change set. Tools like the CDK CLI deploy through
+// change sets rather than CreateStack; KECS computes the changes of a new
+// stack and provisions it when the change set is executed.
+type ChangeSet struct {
+	ChangeSetID     string
+	ChangeSetName   string
+	StackID         string
+	StackName       string
+	Description     string
+	Status          string
+	StatusReason    string
+	ExecutionStatus string
+	CreationTime    time.Time
+	Parameters      map[string]string
+	Changes         []ResourceChange
+
+	plan *stackPlan
+}
+
+// ResourceChange is a resource a change set adds
+type ResourceChange struct {
+	Action       string
+	LogicalID    string
+	ResourceType string
+}
+
+// CreateChangeSetInput is the input of CreateChangeSet
+type CreateChangeSetInput struct {
+	StackName     string
+	ChangeSetName string
+	// ChangeSetType must be CREATE, updates are not supported
+	ChangeSetType string
+	Description   string
+	TemplateBody  string
+	TemplateURL   string
+	Parameters    map[string]string
+}
+
+// CreateChangeSet validates a template and registers the stack in
+// REVIEW_IN_PROGRESS until the change set is executed
+func (e *Engine) CreateChangeSet(ctx context.Context, input CreateChangeSetInput) (*ChangeSet, error) {
+	if input.StackName == "" || input.ChangeSetName == "" {
+		return nil, validationError("StackName and ChangeSetName are required")
+	}
+	if input.ChangeSetType != "CREATE" {
+		return nil, validationError("ChangeSetType %s is not supported, KECS only supports CREATE change sets. Delete the stack to deploy it again", input.ChangeSetType)
+	}
+	plan, err := e.prepare(ctx, input.TemplateBody, input.TemplateURL, input.Parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	stack, exists := e.stacks[input.StackName]
+	if exists && stack.Status != StatusReviewInProgress {
+		return nil, &Error{Code: "AlreadyExistsException", Message: fmt.Sprintf("Stack [%s] already exists", input.StackName)}
+	}
+	if !exists {
+		stack = e.newStack(input.StackName)
+		stack.setStatus(StatusReviewInProgress, "User Initiated")
+		e.stacks[stack.StackName] = stack
+	}
+	if e.findChangeSet(input.ChangeSetName, stack.StackName) != nil {
+		return nil, &Error{Code: "AlreadyExistsException", Message: fmt.Sprintf("ChangeSet [%s] already exists", input.ChangeSetName)}
+	}
+
+	changeSet := &ChangeSet{
+		ChangeSetID:     awsarn.Build("cloudformation", e.region, e.accountID, fmt.Sprintf("changeSet/%s/%s", input.ChangeSetName, uuid.New().String())),
+		ChangeSetName:   input.ChangeSetName,
+		StackID:         stack.StackID,
+		StackName:       stack.StackName,
+		Description:     input.Description,
+		Status:          StatusCreateComplete,
+		ExecutionStatus: ExecutionAvailable,
+		CreationTime:    time.Now(),
+		Parameters:      parameterStrings(plan.parameters),
+		plan:            plan,
+	}
+	for _, logicalID := range plan.order {
+		changeSet.Changes = append(changeSet.Changes, ResourceChange{
+			Action:       "Add",
+			LogicalID:    logicalID,
+			ResourceType: plan.template.Resources[logicalID].Type,
+		})
+	}
+	e.changeSets[changeSet.ChangeSetID] = changeSet
+	return changeSet.snapshot(), nil
+}
+
+// DescribeChangeSet returns a change set by ARN, or by name and stack name
+func (e *Engine) DescribeChangeSet(name, stackName string) (*ChangeSet, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	changeSet := e.findChangeSet(name, stackName)
+	if changeSet == nil {
+		return nil, &Error{Code: "ChangeSetNotFound", Message: fmt.Sprintf("ChangeSet [%s] does not exist", name)}
+	}
+	return changeSet.snapshot(), nil
+}
+
+// ExecuteChangeSet provisions the stack of a change set. Like CreateStack it
+// returns after the stack reached CREATE_COMPLETE or ROLLBACK_COMPLETE.
+func (e *Engine) ExecuteChangeSet(ctx context.Context, name, stackName string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	changeSet := e.findChangeSet(name, stackName)
+	if changeSet == nil {
+		return &Error{Code: "ChangeSetNotFound", Message: fmt.Sprintf("ChangeSet [%s] does not exist", name)}
+	}
+	if changeSet.ExecutionStatus != ExecutionAvailable {
+		return &Error{Code: "InvalidChangeSetStatus", Message: fmt.Sprintf("ChangeSet [%s] cannot be executed in its current execution status of [%s]", name, changeSet.ExecutionStatus)}
+	}
+	stack := e.findStack(changeSet.StackID)
+	if stack == nil || stack.Status != StatusReviewInProgress {
+		return &Error{Code: "InvalidChangeSetStatus", Message: fmt.Sprintf("Stack of ChangeSet [%s] is not in REVIEW_IN_PROGRESS", name)}
+	}
+
+	e.provision(ctx, stack, changeSet.plan)
+	changeSet.ExecutionStatus = ExecutionComplete
+	if stack.Status != StatusCreateComplete {
+		changeSet.ExecutionStatus = ExecutionFailed
+	}
+	// Executing a change set removes the other change sets of the stack
+	for id, other := range e.changeSets {
+		if other.StackID == stack.StackID && other != changeSet {
+			delete(e.changeSets, id)
+		}
+	}
+	return nil
+}
+
+// DeleteChangeSet deletes a change set that has not been executed
+func (e *Engine) DeleteChangeSet(name, stackName string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	changeSet := e.findChangeSet(name, stackName)
+	if changeSet == nil {
+		return &Error{Code: "ChangeSetNotFound", Message: fmt.Sprintf("ChangeSet [%s] does not exist", name)}
+	}
+	delete(e.changeSets, changeSet.ChangeSetID)
+	return nil
+}
+
+// findChangeSet returns a change set by ARN, or by name within a stack
+// given by name or ID
+func (e *Engine) findChangeSet(name, stackName string) *ChangeSet {
+	if changeSet, ok := e.changeSets[name]; ok {
+		return changeSet
+	}
+	for _, changeSet := range e.changeSets {
+		if changeSet.ChangeSetName == name && (changeSet.StackName == stackName || changeSet.StackID == stackName) {
+			return changeSet
+		}
+	}
+	return nil
+}
+
+// snapshot returns a copy of the change set that is safe to read without the engine lock
+func (c *ChangeSet) snapshot() *ChangeSet {
+	s := *c
+	s.plan = nil
+	s.Changes = append([]ResourceChange(nil), c.Changes...)
+	s.Parameters = make(map[string]string, len(c.Parameters))
+	for k, v := range c.Parameters {
+		s.Parameters[k] = v
+	}
+	return &s
+}
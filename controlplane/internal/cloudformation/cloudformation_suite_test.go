@@ -0,0 +1,13 @@
+package cloudformation
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestCloudFormation(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CloudFormation Suite")
+}
@@ -0,0 +1,106 @@
+package cloudformation
+
+import (
+	"fmt"
+)
+
+// evaluateConditions evaluates the Conditions section of a template. The
+// resolver must already know the parameters, conditions can only refer to
+// parameters, pseudo parameters and other conditions.
+func (r *resolver) evaluateConditions(conditions map[string]interface{}) (map[string]bool, error) {
+	results := make(map[string]bool, len(conditions))
+	evaluating := make(map[string]bool)
+
+	var evaluate func(name string) (bool, error)
+	evaluate = func(name string) (bool, error) {
+		if value, ok := results[name]; ok {
+			return value, nil
+		}
+		definition, ok := conditions[name]
+		if !ok {
+			return false, fmt.Errorf("unresolved condition %s", name)
+		}
+		if evaluating[name] {
+			return false, fmt.Errorf("circular dependency in condition %s", name)
+		}
+		evaluating[name] = true
+		value, err := r.condition(definition, evaluate)
+		if err != nil {
+			return false, fmt.Errorf("condition %s: %w", name, err)
+		}
+		results[name] = value
+		return value, nil
+	}
+
+	for name := range conditions {
+		if _, err := evaluate(name); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// condition evaluates a condition function
+func (r *resolver) condition(definition interface{}, named func(string) (bool, error)) (bool, error) {
+	m, ok := definition.(map[string]interface{})
+	if !ok || len(m) != 1 {
+		return false, fmt.Errorf("a condition must be a single condition function")
+	}
+
+	for function, arg := range m {
+		if function == "Condition" {
+			name, ok := arg.(string)
+			if !ok {
+				return false, fmt.Errorf("Condition expects a condition name")
+			}
+			return named(name)
+		}
+
+		args, ok := arg.([]interface{})
+		if !ok {
+			return false, fmt.Errorf("%s expects a list", function)
+		}
+		switch function {
+		case "Fn::Equals":
+			if len(args) != 2 {
+				return false, fmt.Errorf("Fn::Equals expects two values")
+			}
+			left, err := r.resolve(args[0])
+			if err != nil {
+				return false, err
+			}
+			right, err := r.resolve(args[1])
+			if err != nil {
+				return false, err
+			}
+			return stringValue(left) == stringValue(right), nil
+
+		case "Fn::Not":
+			if len(args) != 1 {
+				return false, fmt.Errorf("Fn::Not expects one condition")
+			}
+			value, err := r.condition(args[0], named)
+			return !value, err
+
+		case "Fn::And", "Fn::Or":
+			if len(args) < 2 {
+				return false, fmt.Errorf("%s expects at least two conditions", function)
+			}
+			for _, a := range args {
+				value, err := r.condition(a, named)
+				if err != nil {
+					return false, err
+				}
+				if function == "Fn::And" && !value {
+					return false, nil
+				}
+				if function == "Fn::Or" && value {
+					return true, nil
+				}
+			}
+			return function == "Fn::And", nil
+		}
+		return false, fmt.Errorf("condition function %s is not supported", function)
+	}
+	return false, nil
+}
@@ -0,0 +1,418 @@
+package cloudformation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsrequest"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated_elbv2"
+	"github.com/nandemo-ya/kecs/controlplane/internal/iampolicy"
+)
+
+// resourceHandler creates and deletes the resources of a type
+type resourceHandler struct {
+	// elbv2 is set for types that are provisioned through the ELBv2 API
+	elbv2  bool
+	create func(ctx context.Context, e *Engine, stack *Stack, logicalID string, properties map[string]interface{}) (string, map[string]interface{}, error)
+	delete func(ctx context.Context, e *Engine, resource *StackResource) error
+}
+
+// resourceHandlers are the resource types KECS provisions
+var resourceHandlers = map[string]resourceHandler{
+	"AWS::ECS::Cluster":                         {create: createCluster, delete: deleteCluster},
+	"AWS::ECS::TaskDefinition":                  {create: createTaskDefinition, delete: deleteTaskDefinition},
+	"AWS::ECS::Service":                         {create: createService, delete: deleteService},
+	"AWS::ElasticLoadBalancingV2::LoadBalancer": {elbv2: true, create: createLoadBalancer, delete: deleteLoadBalancer},
+	"AWS::ElasticLoadBalancingV2::TargetGroup":  {elbv2: true, create: createTargetGroup, delete: deleteTargetGroup},
+	"AWS::ElasticLoadBalancingV2::Listener":     {elbv2: true, create: createListener, delete: deleteListener},
+	"AWS::ElasticLoadBalancingV2::ListenerRule": {elbv2: true, create: createListenerRule, delete: deleteListenerRule},
+	"AWS::CDK::Metadata":                        {create: createCDKMetadata, delete: deleteNothing},
+}
+
+// freeFormProperties hold maps whose keys are user data and are not renamed
+var freeFormProperties = map[string]bool{
+	"Options":      true,
+	"DockerLabels": true,
+	"DriverOpts":   true,
+	"Labels":       true,
+}
+
+// decodeProperties decodes CloudFormation properties into an API request.
+// Property names are the API member names with an upper case first letter,
+// so they are lower cased to match the JSON names of the generated types.
+// stringFields lists members the API models as strings that templates
+// commonly write as numbers.
+func decodeProperties(properties map[string]interface{}, out interface{}, stringFields ...string) error {
+	converted := apiNames(properties).(map[string]interface{})
+	for _, field := range stringFields {
+		if value, ok := converted[field]; ok {
+			converted[field] = stringValue(value)
+		}
+	}
+	data, err := json.Marshal(converted)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("invalid properties: %w", err)
+	}
+	return nil
+}
+
+func apiNames(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			if freeFormProperties[key] {
+				result[lowerFirst(key)] = child
+				continue
+			}
+			result[lowerFirst(key)] = apiNames(child)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, child := range v {
+			result[i] = apiNames(child)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToLower(r)) + s[size:]
+}
+
+// invalidNameChars matches the characters ELBv2 and ECS names cannot contain
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9-]`)
+
+// generatedName returns the name CloudFormation gives resources without an
+// explicit one, shortened to maxLength
+func generatedName(stack *Stack, logicalID string, maxLength int) string {
+	name := invalidNameChars.ReplaceAllString(stack.StackName+"-"+logicalID, "-")
+	if len(name) > maxLength {
+		name = name[:maxLength]
+	}
+	return strings.Trim(name, "-")
+}
+
+// arnResource returns the part of an ARN after the resource type, e.g.
+// app/name/id for load balancer ARNs
+func arnResource(arn, resourceType string) string {
+	if i := strings.Index(arn, ":"+resourceType+"/"); i >= 0 {
+		return arn[i+len(resourceType)+2:]
+	}
+	return arn
+}
+
+func createCluster(ctx context.Context, e *Engine, stack *Stack, logicalID string, properties map[string]interface{}) (string, map[string]interface{}, error) {
+	var req generated.CreateClusterRequest
+	if err := decodeProperties(properties, &req); err != nil {
+		return "", nil, err
+	}
+	if ptr.ToString(req.ClusterName) == "" {
+		req.ClusterName = ptr.String(generatedName(stack, logicalID, 255))
+	}
+
+	if err := authorize(ctx, awsrequest.ServiceECS, "CreateCluster", &req); err != nil {
+		return "", nil, err
+	}
+	resp, err := e.ecs.CreateCluster(ctx, &req)
+	if err != nil {
+		return "", nil, err
+	}
+	arn := ""
+	if resp.Cluster != nil {
+		arn = ptr.ToString(resp.Cluster.ClusterArn)
+	}
+	return *req.ClusterName, map[string]interface{}{"Arn": arn}, nil
+}
+
+func deleteCluster(ctx context.Context, e *Engine, resource *StackResource) error {
+	req := &generated.DeleteClusterRequest{Cluster: resource.PhysicalID}
+	if err := authorize(ctx, awsrequest.ServiceECS, "DeleteCluster", req); err != nil {
+		return err
+	}
+	_, err := e.ecs.DeleteCluster(ctx, req)
+	return err
+}
+
+func createTaskDefinition(ctx context.Context, e *Engine, stack *Stack, logicalID string, properties map[string]interface{}) (string, map[string]interface{}, error) {
+	var req generated.RegisterTaskDefinitionRequest
+	if err := decodeProperties(properties, &req, "cpu", "memory"); err != nil {
+		return "", nil, err
+	}
+	if req.Family == "" {
+		req.Family = generatedName(stack, logicalID, 255)
+	}
+
+	if err := authorize(ctx, awsrequest.ServiceECS, "RegisterTaskDefinition", &req); err != nil {
+		return "", nil, err
+	}
+	resp, err := e.ecs.RegisterTaskDefinition(ctx, &req)
+	if err != nil {
+		return "", nil, err
+	}
+	if resp.TaskDefinition == nil || resp.TaskDefinition.TaskDefinitionArn == nil {
+		return "", nil, fmt.Errorf("task definition %s was registered without an ARN", req.Family)
+	}
+	arn := *resp.TaskDefinition.TaskDefinitionArn
+	return arn, map[string]interface{}{"TaskDefinitionArn": arn}, nil
+}
+
+func deleteTaskDefinition(ctx context.Context, e *Engine, resource *StackResource) error {
+	req := &generated.DeregisterTaskDefinitionRequest{TaskDefinition: resource.PhysicalID}
+	if err := authorize(ctx, awsrequest.ServiceECS, "DeregisterTaskDefinition", req); err != nil {
+		return err
+	}
+	_, err := e.ecs.DeregisterTaskDefinition(ctx, req)
+	return err
+}
+
+func createService(ctx context.Context, e *Engine, stack *Stack, logicalID string, properties map[string]interface{}) (string, map[string]interface{}, error) {
+	var req generated.CreateServiceRequest
+	if err := decodeProperties(properties, &req); err != nil {
+		return "", nil, err
+	}
+	if req.ServiceName == "" {
+		req.ServiceName = generatedName(stack, logicalID, 255)
+	}
+	// Templates often pass the cluster ARN, the ECS API looks clusters up by name
+	if req.Cluster != nil {
+		req.Cluster = ptr.String(arnResource(*req.Cluster, "cluster"))
+	}
+
+	if err := authorize(ctx, awsrequest.ServiceECS, "CreateService", &req); err != nil {
+		return "", nil, err
+	}
+	resp, err := e.ecs.CreateService(ctx, &req)
+	if err != nil {
+		return "", nil, err
+	}
+	if resp.Service == nil || resp.Service.ServiceArn == nil {
+		return "", nil, fmt.Errorf("service %s was created without an ARN", req.ServiceName)
+	}
+	arn := *resp.Service.ServiceArn
+	return arn, map[string]interface{}{
+		"Name":       req.ServiceName,
+		"ServiceArn": arn,
+		"Cluster":    ptr.ToString(req.Cluster),
+	}, nil
+}
+
+func deleteService(ctx context.Context, e *Engine, resource *StackResource) error {
+	// DeleteService takes the service name, the physical ID is the ARN
+	name, _ := resource.Attributes["Name"].(string)
+	if name == "" {
+		name = resource.PhysicalID
+	}
+	req := &generated.DeleteServiceRequest{
+		Service: name,
+		Force:   ptr.Bool(true),
+	}
+	if cluster, _ := resource.Attributes["Cluster"].(string); cluster != "" {
+		req.Cluster = ptr.String(cluster)
+	}
+	if err := authorize(ctx, awsrequest.ServiceECS, "DeleteService", req); err != nil {
+		return err
+	}
+	_, err := e.ecs.DeleteService(ctx, req)
+	return err
+}
+
+func createLoadBalancer(ctx context.Context, e *Engine, stack *Stack, logicalID string, properties map[string]interface{}) (string, map[string]interface{}, error) {
+	var input generated_elbv2.CreateLoadBalancerInput
+	if err := decodeProperties(properties, &input); err != nil {
+		return "", nil, err
+	}
+	if input.Name == "" {
+		input.Name = generatedName(stack, logicalID, 32)
+	}
+
+	if err := authorize(ctx, awsrequest.ServiceELBv2, "CreateLoadBalancer", &input); err != nil {
+		return "", nil, err
+	}
+	resp, err := e.elbv2.CreateLoadBalancer(ctx, &input)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(resp.LoadBalancers) == 0 || resp.LoadBalancers[0].LoadBalancerArn == nil {
+		return "", nil, fmt.Errorf("load balancer %s was created without an ARN", input.Name)
+	}
+	lb := resp.LoadBalancers[0]
+	arn := *lb.LoadBalancerArn
+	return arn, map[string]interface{}{
+		"LoadBalancerArn":       arn,
+		"LoadBalancerName":      input.Name,
+		"LoadBalancerFullName":  arnResource(arn, "loadbalancer"),
+		"DNSName":               ptr.ToString(lb.DNSName),
+		"CanonicalHostedZoneID": ptr.ToString(lb.CanonicalHostedZoneId),
+		"SecurityGroups":        toInterfaces(lb.SecurityGroups),
+	}, nil
+}
+
+func deleteLoadBalancer(ctx context.Context, e *Engine, resource *StackResource) error {
+	input := &generated_elbv2.DeleteLoadBalancerInput{LoadBalancerArn: resource.PhysicalID}
+	if err := authorize(ctx, awsrequest.ServiceELBv2, "DeleteLoadBalancer", input); err != nil {
+		return err
+	}
+	_, err := e.elbv2.DeleteLoadBalancer(ctx, input)
+	return err
+}
+
+func createTargetGroup(ctx context.Context, e *Engine, stack *Stack, logicalID string, properties map[string]interface{}) (string, map[string]interface{}, error) {
+	var input generated_elbv2.CreateTargetGroupInput
+	if err := decodeProperties(properties, &input, "healthCheckPort"); err != nil {
+		return "", nil, err
+	}
+	if input.Name == "" {
+		input.Name = generatedName(stack, logicalID, 32)
+	}
+
+	if err := authorize(ctx, awsrequest.ServiceELBv2, "CreateTargetGroup", &input); err != nil {
+		return "", nil, err
+	}
+	resp, err := e.elbv2.CreateTargetGroup(ctx, &input)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(resp.TargetGroups) == 0 || resp.TargetGroups[0].TargetGroupArn == nil {
+		return "", nil, fmt.Errorf("target group %s was created without an ARN", input.Name)
+	}
+	tg := resp.TargetGroups[0]
+	arn := *tg.TargetGroupArn
+	return arn, map[string]interface{}{
+		"TargetGroupArn":      arn,
+		"TargetGroupName":     input.Name,
+		"TargetGroupFullName": arnResource(arn, "targetgroup"),
+		"LoadBalancerArns":    toInterfaces(tg.LoadBalancerArns),
+	}, nil
+}
+
+func deleteTargetGroup(ctx context.Context, e *Engine, resource *StackResource) error {
+	input := &generated_elbv2.DeleteTargetGroupInput{TargetGroupArn: resource.PhysicalID}
+	if err := authorize(ctx, awsrequest.ServiceELBv2, "DeleteTargetGroup", input); err != nil {
+		return err
+	}
+	_, err := e.elbv2.DeleteTargetGroup(ctx, input)
+	return err
+}
+
+func createListener(ctx context.Context, e *Engine, stack *Stack, logicalID string, properties map[string]interface{}) (string, map[string]interface{}, error) {
+	var input generated_elbv2.CreateListenerInput
+	if err := decodeProperties(properties, &input); err != nil {
+		return "", nil, err
+	}
+
+	if err := authorize(ctx, awsrequest.ServiceELBv2, "CreateListener", &input); err != nil {
+		return "", nil, err
+	}
+	resp, err := e.elbv2.CreateListener(ctx, &input)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(resp.Listeners) == 0 || resp.Listeners[0].ListenerArn == nil {
+		return "", nil, fmt.Errorf("listener %s was created without an ARN", logicalID)
+	}
+	arn := *resp.Listeners[0].ListenerArn
+	return arn, map[string]interface{}{"ListenerArn": arn}, nil
+}
+
+func deleteListener(ctx context.Context, e *Engine, resource *StackResource) error {
+	input := &generated_elbv2.DeleteListenerInput{ListenerArn: resource.PhysicalID}
+	if err := authorize(ctx, awsrequest.ServiceELBv2, "DeleteListener", input); err != nil {
+		return err
+	}
+	_, err := e.elbv2.DeleteListener(ctx, input)
+	return err
+}
+
+func createListenerRule(ctx context.Context, e *Engine, stack *Stack, logicalID string, properties map[string]interface{}) (string, map[string]interface{}, error) {
+	var input generated_elbv2.CreateRuleInput
+	if err := decodeProperties(properties, &input); err != nil {
+		return "", nil, err
+	}
+
+	if err := authorize(ctx, awsrequest.ServiceELBv2, "CreateRule", &input); err != nil {
+		return "", nil, err
+	}
+	resp, err := e.elbv2.CreateRule(ctx, &input)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(resp.Rules) == 0 || resp.Rules[0].RuleArn == nil {
+		return "", nil, fmt.Errorf("listener rule %s was created without an ARN", logicalID)
+	}
+	arn := *resp.Rules[0].RuleArn
+	return arn, map[string]interface{}{"RuleArn": arn}, nil
+}
+
+func deleteListenerRule(ctx context.Context, e *Engine, resource *StackResource) error {
+	input := &generated_elbv2.DeleteRuleInput{RuleArn: resource.PhysicalID}
+	if err := authorize(ctx, awsrequest.ServiceELBv2, "DeleteRule", input); err != nil {
+		return err
+	}
+	_, err := e.elbv2.DeleteRule(ctx, input)
+	return err
+}
+
+// authorize checks a call the engine makes against the policies of the caller
+// of the stack operation, as CloudFormation acts with the permissions of the
+// principal calling it
+func authorize(ctx context.Context, service, operation string, request interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	op := awsrequest.Operation{Service: service, Name: operation}
+	if service == awsrequest.ServiceELBv2 {
+		// ELBv2 calls are authorized by their query parameters
+		var fields map[string]interface{}
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return err
+		}
+		op.QueryProtocol = true
+		op.Params = url.Values{}
+		for name, value := range fields {
+			if s, ok := value.(string); ok {
+				op.Params.Set(strings.ToUpper(name[:1])+name[1:], s)
+			}
+		}
+	}
+	return iampolicy.AuthorizeOnBehalf(ctx, op, body)
+}
+
+func toInterfaces(values []string) []interface{} {
+	result := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		result = append(result, v)
+	}
+	return result
+}
+
+// createCDKMetadata accepts the analytics resource the CDK adds to every
+// stack. Nothing is provisioned for it.
+func createCDKMetadata(ctx context.Context, e *Engine, stack *Stack, logicalID string, properties map[string]interface{}) (string, map[string]interface{}, error) {
+	return uuid.New().String(), map[string]interface{}{}, nil
+}
+
+func deleteNothing(ctx context.Context, e *Engine, resource *StackResource) error {
+	return nil
+}
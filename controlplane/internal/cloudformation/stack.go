@@ -0,0 +1,578 @@
+package cloudformation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated_elbv2"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// Stack, resource and change set statuses
+const (
+	StatusCreateInProgress   = "CREATE_IN_PROGRESS"
+	StatusCreateComplete     = "CREATE_COMPLETE"
+	StatusCreateFailed       = "CREATE_FAILED"
+	StatusReviewInProgress   = "REVIEW_IN_PROGRESS"
+	StatusRollbackInProgress = "ROLLBACK_IN_PROGRESS"
+	StatusRollbackComplete   = "ROLLBACK_COMPLETE"
+	StatusDeleteInProgress   = "DELETE_IN_PROGRESS"
+	StatusDeleteComplete     = "DELETE_COMPLETE"
+	StatusDeleteFailed       = "DELETE_FAILED"
+)
+
+// Error is a CloudFormation API error
+type Error struct {
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func validationError(format string, args ...interface{}) *Error {
+	return &Error{Code: "ValidationError", Message: fmt.Sprintf(format, args...)}
+}
+
+// Stack is a provisioned template
+type Stack struct {
+	StackID      string
+	StackName    string
+	Description  string
+	Status       string
+	StatusReason string
+	CreationTime time.Time
+	Parameters   map[string]string
+	Outputs      []StackOutput
+	// Resources are in creation order
+	Resources    []*StackResource
+	TemplateBody string
+	// Events are the newest first, as DescribeStackEvents returns them
+	Events []StackEvent
+}
+
+// StackOutput is an evaluated template output
+type StackOutput struct {
+	Key         string
+	Value       string
+	Description string
+}
+
+// StackResource is a resource of a stack
+type StackResource struct {
+	LogicalID    string
+	PhysicalID   string
+	Type         string
+	Status       string
+	StatusReason string
+	Timestamp    time.Time
+	// Attributes are the values Fn::GetAtt returns
+	Attributes map[string]interface{}
+	// Metadata is the Metadata attribute of the resource in the template
+	Metadata map[string]interface{}
+}
+
+// StackEvent is a status change of a stack or one of its resources
+type StackEvent struct {
+	EventID      string
+	LogicalID    string
+	PhysicalID   string
+	Type         string
+	Status       string
+	StatusReason string
+	Timestamp    time.Time
+}
+
+// CreateStackInput is the input of CreateStack
+type CreateStackInput struct {
+	StackName    string
+	TemplateBody string
+	// TemplateURL is an S3 URL, it is fetched from LocalStack
+	TemplateURL string
+	Parameters  map[string]string
+}
+
+// Engine provisions stacks through the ECS and ELBv2 APIs. Stacks are kept
+// in memory, the resources they create are stored like any other resource.
+type Engine struct {
+	ecs        generated.ECSAPIInterface
+	elbv2      generated_elbv2.ElasticLoadBalancing_v10API
+	region     string
+	accountID  string
+	s3Endpoint func() (string, error)
+
+	mu         sync.Mutex
+	stacks     map[string]*Stack
+	changeSets map[string]*ChangeSet
+}
+
+// NewEngine creates a stack engine. elbv2 may be nil, templates with ELBv2
+// resources are rejected then.
+func NewEngine(ecs generated.ECSAPIInterface, elbv2 generated_elbv2.ElasticLoadBalancing_v10API, region, accountID string) *Engine {
+	return &Engine{
+		ecs:        ecs,
+		elbv2:      elbv2,
+		region:     region,
+		accountID:  accountID,
+		stacks:     make(map[string]*Stack),
+		changeSets: make(map[string]*ChangeSet),
+	}
+}
+
+// SetS3Endpoint sets the function returning the LocalStack endpoint that
+// TemplateURL and S3 URLs in parameters are mapped to
+func (e *Engine) SetS3Endpoint(endpoint func() (string, error)) {
+	e.s3Endpoint = endpoint
+}
+
+// stackPlan is a validated template with its parameters and conditions resolved
+type stackPlan struct {
+	template     *Template
+	templateBody string
+	order        []string
+	parameters   map[string]interface{}
+	conditions   map[string]bool
+}
+
+// prepare parses and validates a template, resolves its parameters and
+// conditions and orders the resources that are created
+func (e *Engine) prepare(ctx context.Context, templateBody, templateURL string, values map[string]string) (*stackPlan, error) {
+	if templateBody == "" {
+		if templateURL == "" {
+			return nil, validationError("Either TemplateBody or TemplateURL must be specified")
+		}
+		body, err := e.fetchTemplate(ctx, templateURL)
+		if err != nil {
+			return nil, err
+		}
+		templateBody = body
+	}
+
+	template, err := ParseTemplate(templateBody)
+	if err != nil {
+		return nil, validationError("%v", err)
+	}
+	parameters, err := resolveParameters(template, values)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range parameters {
+		if s, ok := value.(string); ok {
+			if local, ok := e.localS3URL(s); ok {
+				parameters[name] = local
+			}
+		}
+	}
+
+	r := &resolver{region: e.region, accountID: e.accountID, parameters: parameters}
+	conditions, err := r.evaluateConditions(template.Conditions)
+	if err != nil {
+		return nil, validationError("Template error: %v", err)
+	}
+
+	// Resources whose condition is false are not part of the stack
+	for logicalID, resource := range template.Resources {
+		if resource.Condition == "" {
+			continue
+		}
+		value, ok := conditions[resource.Condition]
+		if !ok {
+			return nil, validationError("Template error: unresolved condition %s in resource %s", resource.Condition, logicalID)
+		}
+		if !value {
+			delete(template.Resources, logicalID)
+		}
+	}
+
+	if err := e.validateResourceTypes(template); err != nil {
+		return nil, err
+	}
+	order, err := template.CreationOrder()
+	if err != nil {
+		return nil, validationError("%v", err)
+	}
+	return &stackPlan{
+		template:     template,
+		templateBody: templateBody,
+		order:        order,
+		parameters:   parameters,
+		conditions:   conditions,
+	}, nil
+}
+
+// CreateStack provisions the resources of a template in dependency order.
+// When a resource fails the resources created so far are deleted again and
+// the stack is left in ROLLBACK_COMPLETE, as CloudFormation does.
+func (e *Engine) CreateStack(ctx context.Context, input CreateStackInput) (*Stack, error) {
+	if input.StackName == "" {
+		return nil, validationError("StackName is required")
+	}
+	plan, err := e.prepare(ctx, input.TemplateBody, input.TemplateURL, input.Parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, exists := e.stacks[input.StackName]; exists {
+		return nil, &Error{Code: "AlreadyExistsException", Message: fmt.Sprintf("Stack [%s] already exists", input.StackName)}
+	}
+
+	stack := e.newStack(input.StackName)
+	e.stacks[stack.StackName] = stack
+	e.provision(ctx, stack, plan)
+	return stack.snapshot(), nil
+}
+
+// newStack creates an empty stack record
+func (e *Engine) newStack(name string) *Stack {
+	return &Stack{
+		StackID:      awsarn.Build("cloudformation", e.region, e.accountID, fmt.Sprintf("stack/%s/%s", name, uuid.New().String())),
+		StackName:    name,
+		CreationTime: time.Now(),
+	}
+}
+
+// provision creates the resources of a plan. The engine lock must be held.
+func (e *Engine) provision(ctx context.Context, stack *Stack, plan *stackPlan) {
+	template := plan.template
+	stack.Description = template.Description
+	stack.TemplateBody = plan.templateBody
+	stack.Parameters = parameterStrings(plan.parameters)
+	stack.setStatus(StatusCreateInProgress, "User Initiated")
+
+	r := &resolver{
+		region:     e.region,
+		accountID:  e.accountID,
+		stackName:  stack.StackName,
+		stackID:    stack.StackID,
+		parameters: plan.parameters,
+		conditions: plan.conditions,
+		resources:  make(map[string]*StackResource),
+	}
+
+	for _, logicalID := range plan.order {
+		resource := template.Resources[logicalID]
+		stackResource := &StackResource{
+			LogicalID: logicalID,
+			Type:      resource.Type,
+			Metadata:  resource.Metadata,
+		}
+		stack.setResourceStatus(stackResource, StatusCreateInProgress, "")
+
+		if err := e.createResource(ctx, stack, r, stackResource, resource); err != nil {
+			logging.Warn("Failed to create stack resource", "stack", stack.StackName, "resource", logicalID, "error", err)
+			stack.setResourceStatus(stackResource, StatusCreateFailed, err.Error())
+			reason := fmt.Sprintf("The following resource(s) failed to create: [%s]. %s", logicalID, err.Error())
+			stack.setStatus(StatusRollbackInProgress, reason)
+			e.rollback(ctx, stack)
+			stack.Resources = append(stack.Resources, stackResource)
+			stack.setStatus(StatusRollbackComplete, reason)
+			return
+		}
+
+		stack.setResourceStatus(stackResource, StatusCreateComplete, "")
+		stack.Resources = append(stack.Resources, stackResource)
+		r.resources[logicalID] = stackResource
+	}
+
+	outputNames := make([]string, 0, len(template.Outputs))
+	for name := range template.Outputs {
+		outputNames = append(outputNames, name)
+	}
+	sort.Strings(outputNames)
+	for _, name := range outputNames {
+		output := template.Outputs[name]
+		if output.Condition != "" && !plan.conditions[output.Condition] {
+			continue
+		}
+		value, err := r.resolve(output.Value)
+		if err != nil {
+			logging.Warn("Failed to evaluate stack output", "stack", stack.StackName, "output", name, "error", err)
+			continue
+		}
+		stack.Outputs = append(stack.Outputs, StackOutput{Key: name, Value: stringValue(value), Description: output.Description})
+	}
+
+	stack.setStatus(StatusCreateComplete, "")
+	logging.Info("Created CloudFormation stack", "stack", stack.StackName, "resources", len(stack.Resources))
+}
+
+// createResource resolves the properties of a resource and creates it
+func (e *Engine) createResource(ctx context.Context, stack *Stack, r *resolver, stackResource *StackResource, resource Resource) error {
+	resolved, err := r.resolve(resource.Properties)
+	if err != nil {
+		return err
+	}
+	properties, _ := resolved.(map[string]interface{})
+	if properties == nil {
+		properties = make(map[string]interface{})
+	}
+
+	handler := resourceHandlers[resource.Type]
+	physicalID, attributes, err := handler.create(ctx, e, stack, stackResource.LogicalID, properties)
+	if err != nil {
+		return err
+	}
+	stackResource.PhysicalID = physicalID
+	stackResource.Attributes = attributes
+	return nil
+}
+
+// rollback deletes the resources of a stack in reverse creation order
+func (e *Engine) rollback(ctx context.Context, stack *Stack) {
+	for i := len(stack.Resources) - 1; i >= 0; i-- {
+		resource := stack.Resources[i]
+		stack.setResourceStatus(resource, StatusDeleteInProgress, "")
+		if err := resourceHandlers[resource.Type].delete(ctx, e, resource); err != nil {
+			logging.Warn("Failed to delete stack resource during rollback", "stack", stack.StackName, "resource", resource.LogicalID, "error", err)
+			stack.setResourceStatus(resource, StatusDeleteFailed, err.Error())
+			continue
+		}
+		stack.setResourceStatus(resource, StatusDeleteComplete, "")
+	}
+}
+
+// DescribeStacks returns a stack by name or ID, or all stacks when name is empty
+func (e *Engine) DescribeStacks(name string) ([]*Stack, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if name != "" {
+		stack := e.findStack(name)
+		if stack == nil {
+			return nil, validationError("Stack with id %s does not exist", name)
+		}
+		return []*Stack{stack.snapshot()}, nil
+	}
+
+	stacks := make([]*Stack, 0, len(e.stacks))
+	for _, stack := range e.stacks {
+		stacks = append(stacks, stack.snapshot())
+	}
+	sort.Slice(stacks, func(i, j int) bool {
+		return stacks[i].CreationTime.After(stacks[j].CreationTime)
+	})
+	return stacks, nil
+}
+
+// DeleteStack deletes the resources of a stack in reverse creation order.
+// Deleting a stack that does not exist succeeds, as in CloudFormation.
+func (e *Engine) DeleteStack(ctx context.Context, name string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	stack := e.findStack(name)
+	if stack == nil {
+		return nil
+	}
+	stack.setStatus(StatusDeleteInProgress, "User Initiated")
+
+	var failed []string
+	for i := len(stack.Resources) - 1; i >= 0; i-- {
+		resource := stack.Resources[i]
+		if resource.Status == StatusDeleteComplete || resource.Status == StatusCreateFailed {
+			continue
+		}
+		stack.setResourceStatus(resource, StatusDeleteInProgress, "")
+		if err := resourceHandlers[resource.Type].delete(ctx, e, resource); err != nil {
+			logging.Warn("Failed to delete stack resource", "stack", stack.StackName, "resource", resource.LogicalID, "error", err)
+			stack.setResourceStatus(resource, StatusDeleteFailed, err.Error())
+			failed = append(failed, resource.LogicalID)
+			continue
+		}
+		stack.setResourceStatus(resource, StatusDeleteComplete, "")
+	}
+
+	if len(failed) > 0 {
+		stack.setStatus(StatusDeleteFailed, fmt.Sprintf("The following resource(s) failed to delete: [%s].", strings.Join(failed, ", ")))
+		return nil
+	}
+	delete(e.stacks, stack.StackName)
+	for id, changeSet := range e.changeSets {
+		if changeSet.StackID == stack.StackID {
+			delete(e.changeSets, id)
+		}
+	}
+	logging.Info("Deleted CloudFormation stack", "stack", stack.StackName)
+	return nil
+}
+
+// findStack returns a stack by name or ID
+func (e *Engine) findStack(name string) *Stack {
+	if stack, ok := e.stacks[name]; ok {
+		return stack
+	}
+	for _, stack := range e.stacks {
+		if stack.StackID == name {
+			return stack
+		}
+	}
+	return nil
+}
+
+// validateResourceTypes rejects templates with resources KECS cannot provision
+func (e *Engine) validateResourceTypes(template *Template) error {
+	var unsupported []string
+	for logicalID, resource := range template.Resources {
+		handler, ok := resourceHandlers[resource.Type]
+		if !ok {
+			unsupported = append(unsupported, fmt.Sprintf("%s (%s)", logicalID, resource.Type))
+			continue
+		}
+		if handler.elbv2 && e.elbv2 == nil {
+			return validationError("resource %s of type %s requires the ELBv2 API, which is not available", logicalID, resource.Type)
+		}
+	}
+	if len(unsupported) > 0 {
+		sort.Strings(unsupported)
+		return validationError("Template contains resource types KECS does not support: %s", strings.Join(unsupported, ", "))
+	}
+	return nil
+}
+
+// resolveParameters merges the given parameter values with the template
+// defaults and converts them to the types the template declares
+func resolveParameters(template *Template, values map[string]string) (map[string]interface{}, error) {
+	for name := range values {
+		if _, ok := template.Parameters[name]; !ok {
+			return nil, validationError("Parameters: [%s] do not exist in the template", name)
+		}
+	}
+
+	var missing []string
+	parameters := make(map[string]interface{}, len(template.Parameters))
+	for name, parameter := range template.Parameters {
+		value, ok := values[name]
+		if !ok {
+			if parameter.Default == nil {
+				missing = append(missing, name)
+				continue
+			}
+			value = stringValue(parameter.Default)
+		}
+
+		if len(parameter.AllowedValues) > 0 {
+			allowed := false
+			for _, v := range parameter.AllowedValues {
+				if stringValue(v) == value {
+					allowed = true
+					break
+				}
+			}
+			if !allowed {
+				return nil, validationError("Parameter '%s' must be one of AllowedValues", name)
+			}
+		}
+
+		if strings.HasPrefix(parameter.Type, "AWS::SSM::Parameter::Value<") {
+			resolved, err := resolveSSMParameter(value)
+			if err != nil {
+				return nil, validationError("Parameter '%s': %v", name, err)
+			}
+			value = resolved
+		}
+
+		switch {
+		case parameter.Type == "Number":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, validationError("Parameter '%s' must be a number", name)
+			}
+			parameters[name] = n
+		case parameter.Type == "CommaDelimitedList" || strings.HasPrefix(parameter.Type, "List<"):
+			var list []interface{}
+			for _, part := range strings.Split(value, ",") {
+				if part = strings.TrimSpace(part); part != "" {
+					list = append(list, part)
+				}
+			}
+			parameters[name] = list
+		default:
+			parameters[name] = value
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, validationError("Parameters: [%s] must have values", strings.Join(missing, ", "))
+	}
+	return parameters, nil
+}
+
+// parameterStrings formats resolved parameters the way DescribeStacks returns them
+func parameterStrings(parameters map[string]interface{}) map[string]string {
+	result := make(map[string]string, len(parameters))
+	for name, value := range parameters {
+		if list, ok := value.([]interface{}); ok {
+			parts := make([]string, 0, len(list))
+			for _, item := range list {
+				parts = append(parts, stringValue(item))
+			}
+			result[name] = strings.Join(parts, ",")
+		} else {
+			result[name] = stringValue(value)
+		}
+	}
+	return result
+}
+
+// snapshot returns a copy of the stack that is safe to read without the engine lock
+func (s *Stack) snapshot() *Stack {
+	c := *s
+	c.Outputs = append([]StackOutput(nil), s.Outputs...)
+	c.Events = append([]StackEvent(nil), s.Events...)
+	c.Resources = make([]*StackResource, 0, len(s.Resources))
+	for _, resource := range s.Resources {
+		r := *resource
+		c.Resources = append(c.Resources, &r)
+	}
+	c.Parameters = make(map[string]string, len(s.Parameters))
+	for k, v := range s.Parameters {
+		c.Parameters[k] = v
+	}
+	return &c
+}
+
+// setStatus changes the status of the stack and records an event
+func (s *Stack) setStatus(status, reason string) {
+	s.Status = status
+	s.StatusReason = reason
+	s.addEvent(StackEvent{
+		LogicalID:    s.StackName,
+		PhysicalID:   s.StackID,
+		Type:         "AWS::CloudFormation::Stack",
+		Status:       status,
+		StatusReason: reason,
+	})
+}
+
+// setResourceStatus changes the status of a resource and records an event
+func (s *Stack) setResourceStatus(resource *StackResource, status, reason string) {
+	resource.Status = status
+	resource.StatusReason = reason
+	resource.Timestamp = time.Now()
+	s.addEvent(StackEvent{
+		LogicalID:    resource.LogicalID,
+		PhysicalID:   resource.PhysicalID,
+		Type:         resource.Type,
+		Status:       status,
+		StatusReason: reason,
+	})
+}
+
+func (s *Stack) addEvent(event StackEvent) {
+	event.EventID = uuid.New().String()
+	event.Timestamp = time.Now()
+	s.Events = append([]StackEvent{event}, s.Events...)
+}
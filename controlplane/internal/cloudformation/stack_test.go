@@ -0,0 +1,275 @@
+package cloudformation
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated_elbv2"
+	"github.com/nandemo-ya/kecs/controlplane/internal/iampolicy"
+)
+
+// fakeECS records the ECS calls of the engine
+type fakeECS struct {
+	generated.ECSAPIInterface
+	calls       []string
+	services    []*generated.CreateServiceRequest
+	failService bool
+}
+
+func (f *fakeECS) CreateCluster(ctx context.Context, req *generated.CreateClusterRequest) (*generated.CreateClusterResponse, error) {
+	f.calls = append(f.calls, "CreateCluster "+*req.ClusterName)
+	return &generated.CreateClusterResponse{Cluster: &generated.Cluster{
+		ClusterArn: ptr.String("arn:aws:ecs:us-east-1:123456789012:cluster/" + *req.ClusterName),
+	}}, nil
+}
+
+func (f *fakeECS) DeleteCluster(ctx context.Context, req *generated.DeleteClusterRequest) (*generated.DeleteClusterResponse, error) {
+	f.calls = append(f.calls, "DeleteCluster "+req.Cluster)
+	return &generated.DeleteClusterResponse{}, nil
+}
+
+func (f *fakeECS) RegisterTaskDefinition(ctx context.Context, req *generated.RegisterTaskDefinitionRequest) (*generated.RegisterTaskDefinitionResponse, error) {
+	f.calls = append(f.calls, "RegisterTaskDefinition "+req.Family+" "+ptr.ToString(req.Cpu))
+	return &generated.RegisterTaskDefinitionResponse{TaskDefinition: &generated.TaskDefinition{
+		TaskDefinitionArn: ptr.String("arn:aws:ecs:us-east-1:123456789012:task-definition/" + req.Family + ":1"),
+	}}, nil
+}
+
+func (f *fakeECS) DeregisterTaskDefinition(ctx context.Context, req *generated.DeregisterTaskDefinitionRequest) (*generated.DeregisterTaskDefinitionResponse, error) {
+	f.calls = append(f.calls, "DeregisterTaskDefinition "+req.TaskDefinition)
+	return &generated.DeregisterTaskDefinitionResponse{}, nil
+}
+
+func (f *fakeECS) CreateService(ctx context.Context, req *generated.CreateServiceRequest) (*generated.CreateServiceResponse, error) {
+	if f.failService {
+		return nil, errors.New("task definition not found")
+	}
+	f.calls = append(f.calls, "CreateService "+req.ServiceName)
+	f.services = append(f.services, req)
+	return &generated.CreateServiceResponse{Service: &generated.Service{
+		ServiceArn: ptr.String("arn:aws:ecs:us-east-1:123456789012:service/" + *req.Cluster + "/" + req.ServiceName),
+	}}, nil
+}
+
+func (f *fakeECS) DeleteService(ctx context.Context, req *generated.DeleteServiceRequest) (*generated.DeleteServiceResponse, error) {
+	f.calls = append(f.calls, "DeleteService "+req.Service)
+	return &generated.DeleteServiceResponse{}, nil
+}
+
+// fakeELBv2 records the ELBv2 calls of the engine
+type fakeELBv2 struct {
+	generated_elbv2.ElasticLoadBalancing_v10API
+	calls []string
+}
+
+func (f *fakeELBv2) CreateTargetGroup(ctx context.Context, input *generated_elbv2.CreateTargetGroupInput) (*generated_elbv2.CreateTargetGroupOutput, error) {
+	f.calls = append(f.calls, "CreateTargetGroup "+input.Name)
+	return &generated_elbv2.CreateTargetGroupOutput{TargetGroups: []generated_elbv2.TargetGroup{{
+		TargetGroupArn: ptr.String("arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/" + input.Name + "/abc"),
+	}}}, nil
+}
+
+func (f *fakeELBv2) DeleteTargetGroup(ctx context.Context, input *generated_elbv2.DeleteTargetGroupInput) (*generated_elbv2.DeleteTargetGroupOutput, error) {
+	f.calls = append(f.calls, "DeleteTargetGroup "+input.TargetGroupArn)
+	return &generated_elbv2.DeleteTargetGroupOutput{}, nil
+}
+
+const serviceTemplate = `
+Description: Web service
+Parameters:
+  ContainerPort:
+    Type: Number
+    Default: 80
+  Subnets:
+    Type: List<AWS::EC2::Subnet::Id>
+Resources:
+  Cluster:
+    Type: AWS::ECS::Cluster
+  TargetGroup:
+    Type: AWS::ElasticLoadBalancingV2::TargetGroup
+    Properties:
+      Port: !Ref ContainerPort
+      Protocol: HTTP
+      TargetType: ip
+      HealthCheckPath: /healthz
+  TaskDefinition:
+    Type: AWS::ECS::TaskDefinition
+    Properties:
+      Family: web
+      Cpu: 256
+      Memory: 512
+      NetworkMode: awsvpc
+      ContainerDefinitions:
+        - Name: web
+          Image: nginx
+          PortMappings:
+            - ContainerPort: !Ref ContainerPort
+          DockerLabels:
+            Team: platform
+  Service:
+    Type: AWS::ECS::Service
+    Properties:
+      Cluster: !GetAtt Cluster.Arn
+      TaskDefinition: !Ref TaskDefinition
+      DesiredCount: 2
+      NetworkConfiguration:
+        AwsvpcConfiguration:
+          Subnets: !Ref Subnets
+      LoadBalancers:
+        - ContainerName: web
+          ContainerPort: !Ref ContainerPort
+          TargetGroupArn: !Ref TargetGroup
+Outputs:
+  ServiceArn:
+    Value: !Ref Service
+  ClusterName:
+    Description: The cluster
+    Value: !Ref Cluster
+`
+
+var _ = Describe("Engine", func() {
+	var (
+		ctx    context.Context
+		ecs    *fakeECS
+		elbv2  *fakeELBv2
+		engine *Engine
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		ecs = &fakeECS{}
+		elbv2 = &fakeELBv2{}
+		engine = NewEngine(ecs, elbv2, "us-east-1", "123456789012")
+	})
+
+	It("should create resources in dependency order and evaluate outputs", func() {
+		stack, err := engine.CreateStack(ctx, CreateStackInput{
+			StackName:    "demo",
+			TemplateBody: serviceTemplate,
+			Parameters:   map[string]string{"Subnets": "subnet-a, subnet-b"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stack.Status).To(Equal(StatusCreateComplete))
+		Expect(stack.Description).To(Equal("Web service"))
+		Expect(stack.Parameters).To(Equal(map[string]string{"ContainerPort": "80", "Subnets": "subnet-a,subnet-b"}))
+
+		Expect(ecs.calls).To(Equal([]string{
+			"CreateCluster demo-Cluster",
+			"RegisterTaskDefinition web 256",
+			"CreateService demo-Service",
+		}))
+		Expect(elbv2.calls).To(Equal([]string{"CreateTargetGroup demo-TargetGroup"}))
+
+		service := ecs.services[0]
+		Expect(*service.Cluster).To(Equal("demo-Cluster"))
+		Expect(*service.TaskDefinition).To(Equal("arn:aws:ecs:us-east-1:123456789012:task-definition/web:1"))
+		Expect(*service.DesiredCount).To(Equal(int32(2)))
+		Expect(service.NetworkConfiguration.AwsvpcConfiguration.Subnets).To(Equal([]string{"subnet-a", "subnet-b"}))
+		Expect(*service.LoadBalancers[0].ContainerPort).To(Equal(int32(80)))
+		Expect(*service.LoadBalancers[0].TargetGroupArn).To(ContainSubstring("targetgroup/demo-TargetGroup"))
+
+		Expect(stack.Outputs).To(Equal([]StackOutput{
+			{Key: "ClusterName", Value: "demo-Cluster", Description: "The cluster"},
+			{Key: "ServiceArn", Value: "arn:aws:ecs:us-east-1:123456789012:service/demo-Cluster/demo-Service"},
+		}))
+
+		stacks, err := engine.DescribeStacks(stack.StackID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stacks).To(HaveLen(1))
+		Expect(stacks[0].Resources).To(HaveLen(4))
+
+		_, err = engine.CreateStack(ctx, CreateStackInput{StackName: "demo", TemplateBody: serviceTemplate, Parameters: map[string]string{"Subnets": "subnet-a"}})
+		Expect(err).To(MatchError(ContainSubstring("already exists")))
+	})
+
+	It("should delete resources in reverse order", func() {
+		_, err := engine.CreateStack(ctx, CreateStackInput{
+			StackName:    "demo",
+			TemplateBody: serviceTemplate,
+			Parameters:   map[string]string{"Subnets": "subnet-a"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		ecs.calls = nil
+
+		Expect(engine.DeleteStack(ctx, "demo")).To(Succeed())
+		Expect(ecs.calls).To(Equal([]string{
+			"DeleteService demo-Service",
+			"DeregisterTaskDefinition arn:aws:ecs:us-east-1:123456789012:task-definition/web:1",
+			"DeleteCluster demo-Cluster",
+		}))
+		Expect(elbv2.calls).To(ContainElement(HavePrefix("DeleteTargetGroup")))
+
+		_, err = engine.DescribeStacks("demo")
+		Expect(err).To(MatchError(ContainSubstring("does not exist")))
+		Expect(engine.DeleteStack(ctx, "demo")).To(Succeed())
+	})
+
+	It("should roll back created resources when a resource fails", func() {
+		ecs.failService = true
+		stack, err := engine.CreateStack(ctx, CreateStackInput{
+			StackName:    "demo",
+			TemplateBody: serviceTemplate,
+			Parameters:   map[string]string{"Subnets": "subnet-a"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stack.Status).To(Equal(StatusRollbackComplete))
+		Expect(stack.StatusReason).To(ContainSubstring("[Service]"))
+		Expect(ecs.calls).To(ContainElements(
+			"DeregisterTaskDefinition arn:aws:ecs:us-east-1:123456789012:task-definition/web:1",
+			"DeleteCluster demo-Cluster",
+		))
+
+		last := stack.Resources[len(stack.Resources)-1]
+		Expect(last.LogicalID).To(Equal("Service"))
+		Expect(last.Status).To(Equal(StatusCreateFailed))
+	})
+
+	It("should create resources with the permissions of the caller", func() {
+		policy, err := iampolicy.ParsePolicy([]byte(`{"Statement": [
+			{"Effect": "Allow", "Action": ["cloudformation:*", "elasticloadbalancing:*"], "Resource": "*"},
+			{"Effect": "Allow", "Action": ["ecs:CreateCluster", "ecs:DeleteCluster", "ecs:*TaskDefinition"], "Resource": "*"}
+		]}`))
+		Expect(err).NotTo(HaveOccurred())
+		authorizer := iampolicy.NewAuthorizer(func(string) string { return "deployer" },
+			func(string) []*iampolicy.Policy { return []*iampolicy.Policy{policy} }, "us-east-1", "123456789012")
+
+		var stack *Stack
+		handler := authorizer.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			stack, err = engine.CreateStack(r.Context(), CreateStackInput{
+				StackName:    "demo",
+				TemplateBody: serviceTemplate,
+				Parameters:   map[string]string{"Subnets": "subnet-a"},
+			})
+		}))
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("Action=CreateStack&StackName=demo"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential=AKIADEPLOYER/20251015/us-east-1/cloudformation/aws4_request, SignedHeaders=host, Signature=abc")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stack.Status).To(Equal(StatusRollbackComplete))
+		Expect(stack.StatusReason).To(ContainSubstring("is not authorized to perform: ecs:CreateService"))
+		Expect(ecs.calls).NotTo(ContainElement(HavePrefix("CreateService")))
+		Expect(ecs.calls).To(ContainElement("DeleteCluster demo-Cluster"))
+	})
+
+	It("should reject unsupported resource types and missing parameters", func() {
+		_, err := engine.CreateStack(ctx, CreateStackInput{
+			StackName:    "demo",
+			TemplateBody: "Resources:\n  Role:\n    Type: AWS::IAM::Role\n",
+		})
+		Expect(err).To(MatchError(ContainSubstring("Role (AWS::IAM::Role)")))
+
+		_, err = engine.CreateStack(ctx, CreateStackInput{StackName: "demo", TemplateBody: serviceTemplate})
+		Expect(err).To(MatchError(ContainSubstring("[Subnets] must have values")))
+		Expect(ecs.calls).To(BeEmpty())
+	})
+})
@@ -0,0 +1,599 @@
+// Package cloudformation provisions the ECS and ELBv2 resources of
+// CloudFormation templates through the KECS APIs, so that CloudFormation and
+// SAM templates can be tested locally without LocalStack Pro.
+package cloudformation
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Template is a parsed CloudFormation template
+type Template struct {
+	AWSTemplateFormatVersion string                 `json:"AWSTemplateFormatVersion,omitempty"`
+	Description              string                 `json:"Description,omitempty"`
+	Metadata                 map[string]interface{} `json:"Metadata,omitempty"`
+	Parameters               map[string]Parameter   `json:"Parameters,omitempty"`
+	Conditions               map[string]interface{} `json:"Conditions,omitempty"`
+	// Rules are accepted but not evaluated, CDK adds one that checks the
+	// bootstrap version
+	Rules     map[string]interface{} `json:"Rules,omitempty"`
+	Resources map[string]Resource    `json:"Resources"`
+	Outputs   map[string]Output      `json:"Outputs,omitempty"`
+}
+
+// Parameter is a template parameter
+type Parameter struct {
+	Type          string        `json:"Type"`
+	Default       interface{}   `json:"Default,omitempty"`
+	AllowedValues []interface{} `json:"AllowedValues,omitempty"`
+	Description   string        `json:"Description,omitempty"`
+}
+
+// Resource is a template resource
+type Resource struct {
+	Type       string                 `json:"Type"`
+	Properties map[string]interface{} `json:"Properties,omitempty"`
+	// DependsOn is a logical ID or a list of logical IDs
+	DependsOn interface{} `json:"DependsOn,omitempty"`
+	// Condition is the name of the condition that decides whether the
+	// resource is created
+	Condition string                 `json:"Condition,omitempty"`
+	Metadata  map[string]interface{} `json:"Metadata,omitempty"`
+}
+
+// Output is a template output
+type Output struct {
+	Description string      `json:"Description,omitempty"`
+	Value       interface{} `json:"Value"`
+	Condition   string      `json:"Condition,omitempty"`
+}
+
+// shortFormTags maps the YAML short form of intrinsic functions to their full names
+var shortFormTags = map[string]string{
+	"!Ref":         "Ref",
+	"!GetAtt":      "Fn::GetAtt",
+	"!Sub":         "Fn::Sub",
+	"!Join":        "Fn::Join",
+	"!Select":      "Fn::Select",
+	"!Split":       "Fn::Split",
+	"!GetAZs":      "Fn::GetAZs",
+	"!Base64":      "Fn::Base64",
+	"!If":          "Fn::If",
+	"!Equals":      "Fn::Equals",
+	"!Not":         "Fn::Not",
+	"!And":         "Fn::And",
+	"!Or":          "Fn::Or",
+	"!FindInMap":   "Fn::FindInMap",
+	"!ImportValue": "Fn::ImportValue",
+	"!Condition":   "Condition",
+}
+
+// ParseTemplate parses a JSON or YAML template. The YAML short form of
+// intrinsic functions (!Ref, !Sub, ...) is converted to the full form.
+func ParseTemplate(body string) (*Template, error) {
+	var raw interface{}
+	if strings.HasPrefix(strings.TrimSpace(body), "{") {
+		if err := json.Unmarshal([]byte(body), &raw); err != nil {
+			return nil, fmt.Errorf("template format error: %w", err)
+		}
+	} else {
+		var node yaml.Node
+		if err := yaml.Unmarshal([]byte(body), &node); err != nil {
+			return nil, fmt.Errorf("template format error: %w", err)
+		}
+		value, err := yamlNodeValue(&node)
+		if err != nil {
+			return nil, fmt.Errorf("template format error: %w", err)
+		}
+		raw = value
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("template format error: %w", err)
+	}
+	var template Template
+	if err := json.Unmarshal(data, &template); err != nil {
+		return nil, fmt.Errorf("template format error: %w", err)
+	}
+	if len(template.Resources) == 0 {
+		return nil, fmt.Errorf("template format error: at least one Resources member must be defined")
+	}
+	for logicalID, resource := range template.Resources {
+		if resource.Type == "" {
+			return nil, fmt.Errorf("template format error: resource %s has no Type", logicalID)
+		}
+	}
+	return &template, nil
+}
+
+// yamlNodeValue converts a YAML node to JSON compatible values
+func yamlNodeValue(node *yaml.Node) (interface{}, error) {
+	var value interface{}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return yamlNodeValue(node.Content[0])
+	case yaml.AliasNode:
+		return yamlNodeValue(node.Alias)
+	case yaml.MappingNode:
+		m := make(map[string]interface{}, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			v, err := yamlNodeValue(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			m[node.Content[i].Value] = v
+		}
+		value = m
+	case yaml.SequenceNode:
+		s := make([]interface{}, 0, len(node.Content))
+		for _, child := range node.Content {
+			v, err := yamlNodeValue(child)
+			if err != nil {
+				return nil, err
+			}
+			s = append(s, v)
+		}
+		value = s
+	case yaml.ScalarNode:
+		if strings.HasPrefix(node.Tag, "!!") {
+			if err := node.Decode(&value); err != nil {
+				return nil, err
+			}
+		} else {
+			value = node.Value
+		}
+	}
+
+	function, ok := shortFormTags[node.Tag]
+	if !ok {
+		return value, nil
+	}
+	// !GetAtt Resource.Attribute is the short form of [Resource, Attribute]
+	if s, isString := value.(string); isString && function == "Fn::GetAtt" {
+		parts := strings.SplitN(s, ".", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid !GetAtt %q, expected Resource.Attribute", s)
+		}
+		value = []interface{}{parts[0], parts[1]}
+	}
+	return map[string]interface{}{function: value}, nil
+}
+
+// Dependencies returns the logical IDs of the resources a resource references
+// with Ref, Fn::GetAtt or Fn::Sub, or lists in DependsOn
+func (t *Template) Dependencies(logicalID string) []string {
+	resource := t.Resources[logicalID]
+	deps := make(map[string]bool)
+	collectReferences(resource.Properties, deps)
+
+	switch dependsOn := resource.DependsOn.(type) {
+	case string:
+		deps[dependsOn] = true
+	case []interface{}:
+		for _, d := range dependsOn {
+			if s, ok := d.(string); ok {
+				deps[s] = true
+			}
+		}
+	}
+
+	var result []string
+	for dep := range deps {
+		if _, ok := t.Resources[dep]; ok && dep != logicalID {
+			result = append(result, dep)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// CreationOrder sorts the resources so that every resource comes after the
+// resources it depends on. Independent resources are sorted by logical ID.
+func (t *Template) CreationOrder() ([]string, error) {
+	remaining := make(map[string][]string, len(t.Resources))
+	for logicalID := range t.Resources {
+		for _, dep := range t.Dependencies(logicalID) {
+			if _, ok := t.Resources[dep]; !ok {
+				return nil, fmt.Errorf("template format error: unresolved resource dependency %s in resource %s", dep, logicalID)
+			}
+		}
+		remaining[logicalID] = t.Dependencies(logicalID)
+	}
+
+	var order []string
+	created := make(map[string]bool)
+	for len(remaining) > 0 {
+		var ready []string
+		for logicalID, deps := range remaining {
+			satisfied := true
+			for _, dep := range deps {
+				if !created[dep] {
+					satisfied = false
+					break
+				}
+			}
+			if satisfied {
+				ready = append(ready, logicalID)
+			}
+		}
+		if len(ready) == 0 {
+			var cycle []string
+			for logicalID := range remaining {
+				cycle = append(cycle, logicalID)
+			}
+			sort.Strings(cycle)
+			return nil, fmt.Errorf("template format error: circular dependency between resources: %s", strings.Join(cycle, ", "))
+		}
+		sort.Strings(ready)
+		for _, logicalID := range ready {
+			order = append(order, logicalID)
+			created[logicalID] = true
+			delete(remaining, logicalID)
+		}
+	}
+	return order, nil
+}
+
+// subVariable matches the ${Name} and ${Resource.Attribute} variables of Fn::Sub
+var subVariable = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// collectReferences records the logical IDs referenced in a value
+func collectReferences(value interface{}, deps map[string]bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 1 {
+			if ref, ok := v["Ref"].(string); ok {
+				deps[ref] = true
+				return
+			}
+			if getAtt, ok := v["Fn::GetAtt"].([]interface{}); ok && len(getAtt) > 0 {
+				if name, ok := getAtt[0].(string); ok {
+					deps[name] = true
+				}
+			}
+			if sub, ok := v["Fn::Sub"]; ok {
+				var str string
+				switch s := sub.(type) {
+				case string:
+					str = s
+				case []interface{}:
+					if len(s) > 0 {
+						str, _ = s[0].(string)
+					}
+					if len(s) > 1 {
+						collectReferences(s[1], deps)
+					}
+				}
+				for _, match := range subVariable.FindAllStringSubmatch(str, -1) {
+					if !strings.HasPrefix(match[1], "!") {
+						deps[strings.SplitN(match[1], ".", 2)[0]] = true
+					}
+				}
+				return
+			}
+		}
+		for _, child := range v {
+			collectReferences(child, deps)
+		}
+	case []interface{}:
+		for _, child := range v {
+			collectReferences(child, deps)
+		}
+	}
+}
+
+// noValue is the result of Ref AWS::NoValue, the property it is assigned to is removed
+type noValue struct{}
+
+// resolver evaluates intrinsic functions
+type resolver struct {
+	region     string
+	accountID  string
+	stackName  string
+	stackID    string
+	parameters map[string]interface{}
+	conditions map[string]bool
+	resources  map[string]*StackResource
+}
+
+// resolve returns a value with all intrinsic functions evaluated
+func (r *resolver) resolve(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 1 {
+			for key, arg := range v {
+				if key == "Ref" || strings.HasPrefix(key, "Fn::") || key == "Condition" {
+					return r.resolveFunction(key, arg)
+				}
+			}
+		}
+		result := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			resolved, err := r.resolve(child)
+			if err != nil {
+				return nil, err
+			}
+			if _, skip := resolved.(noValue); !skip {
+				result[key] = resolved
+			}
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, 0, len(v))
+		for _, child := range v {
+			resolved, err := r.resolve(child)
+			if err != nil {
+				return nil, err
+			}
+			if _, skip := resolved.(noValue); !skip {
+				result = append(result, resolved)
+			}
+		}
+		return result, nil
+	default:
+		return value, nil
+	}
+}
+
+func (r *resolver) resolveFunction(function string, arg interface{}) (interface{}, error) {
+	switch function {
+	case "Ref":
+		name, ok := arg.(string)
+		if !ok {
+			return nil, fmt.Errorf("Ref expects a string")
+		}
+		return r.ref(name)
+
+	case "Fn::GetAtt":
+		args, ok := arg.([]interface{})
+		if !ok || len(args) != 2 {
+			return nil, fmt.Errorf("Fn::GetAtt expects [resource, attribute]")
+		}
+		name, _ := args[0].(string)
+		attribute, err := r.resolveString(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return r.getAtt(name, attribute)
+
+	case "Fn::Sub":
+		return r.sub(arg)
+
+	case "Fn::Join":
+		args, ok := arg.([]interface{})
+		if !ok || len(args) != 2 {
+			return nil, fmt.Errorf("Fn::Join expects [delimiter, list]")
+		}
+		delimiter, _ := args[0].(string)
+		list, err := r.resolveList(args[1])
+		if err != nil {
+			return nil, err
+		}
+		parts := make([]string, 0, len(list))
+		for _, item := range list {
+			parts = append(parts, stringValue(item))
+		}
+		return strings.Join(parts, delimiter), nil
+
+	case "Fn::Select":
+		args, ok := arg.([]interface{})
+		if !ok || len(args) != 2 {
+			return nil, fmt.Errorf("Fn::Select expects [index, list]")
+		}
+		indexValue, err := r.resolve(args[0])
+		if err != nil {
+			return nil, err
+		}
+		index, err := strconv.Atoi(stringValue(indexValue))
+		if err != nil {
+			return nil, fmt.Errorf("Fn::Select index must be a number")
+		}
+		list, err := r.resolveList(args[1])
+		if err != nil {
+			return nil, err
+		}
+		if index < 0 || index >= len(list) {
+			return nil, fmt.Errorf("Fn::Select index %d is out of range", index)
+		}
+		return list[index], nil
+
+	case "Fn::Split":
+		args, ok := arg.([]interface{})
+		if !ok || len(args) != 2 {
+			return nil, fmt.Errorf("Fn::Split expects [delimiter, string]")
+		}
+		delimiter, _ := args[0].(string)
+		source, err := r.resolveString(args[1])
+		if err != nil {
+			return nil, err
+		}
+		var result []interface{}
+		for _, part := range strings.Split(source, delimiter) {
+			result = append(result, part)
+		}
+		return result, nil
+
+	case "Fn::GetAZs":
+		return []interface{}{r.region + "a", r.region + "b", r.region + "c"}, nil
+
+	case "Fn::Base64":
+		source, err := r.resolveString(arg)
+		if err != nil {
+			return nil, err
+		}
+		return base64.StdEncoding.EncodeToString([]byte(source)), nil
+
+	case "Fn::If":
+		args, ok := arg.([]interface{})
+		if !ok || len(args) != 3 {
+			return nil, fmt.Errorf("Fn::If expects [condition, value if true, value if false]")
+		}
+		name, _ := args[0].(string)
+		value, ok := r.conditions[name]
+		if !ok {
+			return nil, fmt.Errorf("unresolved condition %s in Fn::If", name)
+		}
+		if value {
+			return r.resolve(args[1])
+		}
+		return r.resolve(args[2])
+
+	default:
+		return nil, fmt.Errorf("intrinsic function %s is not supported", function)
+	}
+}
+
+// ref returns the value of a parameter, pseudo parameter or resource
+func (r *resolver) ref(name string) (interface{}, error) {
+	switch name {
+	case "AWS::Region":
+		return r.region, nil
+	case "AWS::AccountId":
+		return r.accountID, nil
+	case "AWS::StackName":
+		return r.stackName, nil
+	case "AWS::StackId":
+		return r.stackID, nil
+	case "AWS::Partition":
+		return "aws", nil
+	case "AWS::URLSuffix":
+		return "amazonaws.com", nil
+	case "AWS::NoValue":
+		return noValue{}, nil
+	}
+	if value, ok := r.parameters[name]; ok {
+		return value, nil
+	}
+	if resource, ok := r.resources[name]; ok {
+		return resource.PhysicalID, nil
+	}
+	return nil, fmt.Errorf("unresolved reference %s", name)
+}
+
+// getAtt returns an attribute of a created resource
+func (r *resolver) getAtt(name, attribute string) (interface{}, error) {
+	resource, ok := r.resources[name]
+	if !ok {
+		return nil, fmt.Errorf("unresolved resource %s in Fn::GetAtt", name)
+	}
+	value, ok := resource.Attributes[attribute]
+	if !ok {
+		return nil, fmt.Errorf("resource %s of type %s has no attribute %s", name, resource.Type, attribute)
+	}
+	return value, nil
+}
+
+func (r *resolver) sub(arg interface{}) (interface{}, error) {
+	var (
+		source    string
+		variables = make(map[string]interface{})
+	)
+	switch v := arg.(type) {
+	case string:
+		source = v
+	case []interface{}:
+		if len(v) != 2 {
+			return nil, fmt.Errorf("Fn::Sub expects a string or [string, variables]")
+		}
+		source, _ = v[0].(string)
+		vars, ok := v[1].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Fn::Sub variables must be a map")
+		}
+		for name, value := range vars {
+			resolved, err := r.resolve(value)
+			if err != nil {
+				return nil, err
+			}
+			variables[name] = resolved
+		}
+	default:
+		return nil, fmt.Errorf("Fn::Sub expects a string or [string, variables]")
+	}
+
+	var subErr error
+	result := subVariable.ReplaceAllStringFunc(source, func(match string) string {
+		name := match[2 : len(match)-1]
+		if strings.HasPrefix(name, "!") {
+			return "${" + name[1:] + "}"
+		}
+		var (
+			value interface{}
+			err   error
+		)
+		if v, ok := variables[name]; ok {
+			value = v
+		} else if parts := strings.SplitN(name, ".", 2); len(parts) == 2 {
+			value, err = r.getAtt(parts[0], parts[1])
+		} else {
+			value, err = r.ref(name)
+		}
+		if err != nil {
+			subErr = err
+			return match
+		}
+		return stringValue(value)
+	})
+	if subErr != nil {
+		return nil, subErr
+	}
+	return result, nil
+}
+
+func (r *resolver) resolveString(value interface{}) (string, error) {
+	resolved, err := r.resolve(value)
+	if err != nil {
+		return "", err
+	}
+	return stringValue(resolved), nil
+}
+
+func (r *resolver) resolveList(value interface{}) ([]interface{}, error) {
+	resolved, err := r.resolve(value)
+	if err != nil {
+		return nil, err
+	}
+	switch v := resolved.(type) {
+	case []interface{}:
+		return v, nil
+	case string:
+		// List parameters are comma delimited strings
+		var result []interface{}
+		for _, part := range strings.Split(v, ",") {
+			result = append(result, strings.TrimSpace(part))
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("expected a list, got %T", resolved)
+	}
+}
+
+// stringValue formats a resolved scalar the way CloudFormation does
+func stringValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
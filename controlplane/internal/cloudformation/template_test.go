@@ -0,0 +1,127 @@
+package cloudformation
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Template", func() {
+	It("should convert YAML short form intrinsic functions", func() {
+		template, err := ParseTemplate(`
+Resources:
+  Service:
+    Type: AWS::ECS::Service
+    Properties:
+      Cluster: !Ref Cluster
+      TaskDefinition: !GetAtt TaskDefinition.TaskDefinitionArn
+      ServiceName: !Sub "${AWS::StackName}-web"
+      DesiredCount: 2
+  Cluster:
+    Type: AWS::ECS::Cluster
+  TaskDefinition:
+    Type: AWS::ECS::TaskDefinition
+`)
+		Expect(err).NotTo(HaveOccurred())
+
+		properties := template.Resources["Service"].Properties
+		Expect(properties["Cluster"]).To(Equal(map[string]interface{}{"Ref": "Cluster"}))
+		Expect(properties["TaskDefinition"]).To(Equal(map[string]interface{}{
+			"Fn::GetAtt": []interface{}{"TaskDefinition", "TaskDefinitionArn"},
+		}))
+		Expect(properties["ServiceName"]).To(Equal(map[string]interface{}{"Fn::Sub": "${AWS::StackName}-web"}))
+		Expect(properties["DesiredCount"]).To(Equal(float64(2)))
+	})
+
+	It("should order resources after their dependencies", func() {
+		template, err := ParseTemplate(`{
+			"Resources": {
+				"Service": {"Type": "AWS::ECS::Service", "Properties": {"Cluster": {"Ref": "Cluster"}, "TaskDefinition": {"Ref": "TaskDefinition"}}},
+				"TaskDefinition": {"Type": "AWS::ECS::TaskDefinition", "DependsOn": "Cluster"},
+				"Cluster": {"Type": "AWS::ECS::Cluster"}
+			}
+		}`)
+		Expect(err).NotTo(HaveOccurred())
+
+		order, err := template.CreationOrder()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(order).To(Equal([]string{"Cluster", "TaskDefinition", "Service"}))
+	})
+
+	It("should reject circular dependencies", func() {
+		template, err := ParseTemplate(`
+Resources:
+  A:
+    Type: AWS::ECS::Cluster
+    DependsOn: B
+  B:
+    Type: AWS::ECS::Cluster
+    DependsOn: A
+`)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = template.CreationOrder()
+		Expect(err).To(MatchError(ContainSubstring("circular dependency")))
+	})
+
+	It("should evaluate intrinsic functions", func() {
+		r := &resolver{
+			region:     "us-east-1",
+			accountID:  "123456789012",
+			stackName:  "demo",
+			parameters: map[string]interface{}{"Env": "dev", "Subnets": []interface{}{"subnet-a", "subnet-b"}},
+			resources: map[string]*StackResource{
+				"Cluster": {PhysicalID: "demo-cluster", Attributes: map[string]interface{}{"Arn": "arn:aws:ecs:us-east-1:123456789012:cluster/demo-cluster"}},
+			},
+		}
+
+		value, err := r.resolve(map[string]interface{}{
+			"Name":    map[string]interface{}{"Fn::Sub": "${AWS::StackName}-${Env}-${Cluster}-${!Literal}"},
+			"Arn":     map[string]interface{}{"Fn::GetAtt": []interface{}{"Cluster", "Arn"}},
+			"Joined":  map[string]interface{}{"Fn::Join": []interface{}{",", map[string]interface{}{"Ref": "Subnets"}}},
+			"First":   map[string]interface{}{"Fn::Select": []interface{}{"0", map[string]interface{}{"Ref": "Subnets"}}},
+			"Removed": map[string]interface{}{"Ref": "AWS::NoValue"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(Equal(map[string]interface{}{
+			"Name":   "demo-dev-demo-cluster-${Literal}",
+			"Arn":    "arn:aws:ecs:us-east-1:123456789012:cluster/demo-cluster",
+			"Joined": "subnet-a,subnet-b",
+			"First":  "subnet-a",
+		}))
+
+		_, err = r.resolve(map[string]interface{}{"Fn::FindInMap": []interface{}{"Map", "a", "b"}})
+		Expect(err).To(MatchError(ContainSubstring("Fn::FindInMap is not supported")))
+	})
+
+	It("should evaluate conditions", func() {
+		r := &resolver{region: "us-east-1", parameters: map[string]interface{}{"Env": "prod"}}
+
+		conditions, err := r.evaluateConditions(map[string]interface{}{
+			"IsProd": map[string]interface{}{"Fn::Equals": []interface{}{map[string]interface{}{"Ref": "Env"}, "prod"}},
+			"IsDev":  map[string]interface{}{"Fn::Not": []interface{}{map[string]interface{}{"Condition": "IsProd"}}},
+			"InUS": map[string]interface{}{"Fn::Or": []interface{}{
+				map[string]interface{}{"Fn::Equals": []interface{}{map[string]interface{}{"Ref": "AWS::Region"}, "us-east-1"}},
+				map[string]interface{}{"Fn::Equals": []interface{}{map[string]interface{}{"Ref": "AWS::Region"}, "us-west-2"}},
+			}},
+			"ProdInUS": map[string]interface{}{"Fn::And": []interface{}{
+				map[string]interface{}{"Condition": "IsProd"},
+				map[string]interface{}{"Condition": "InUS"},
+			}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conditions).To(Equal(map[string]bool{"IsProd": true, "IsDev": false, "InUS": true, "ProdInUS": true}))
+
+		r.conditions = conditions
+		value, err := r.resolve(map[string]interface{}{
+			"Count": map[string]interface{}{"Fn::If": []interface{}{"IsProd", 3, 1}},
+			"Debug": map[string]interface{}{"Fn::If": []interface{}{"IsDev", "true", map[string]interface{}{"Ref": "AWS::NoValue"}}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(Equal(map[string]interface{}{"Count": 3}))
+
+		_, err = r.evaluateConditions(map[string]interface{}{
+			"A": map[string]interface{}{"Condition": "B"},
+			"B": map[string]interface{}{"Condition": "A"},
+		})
+		Expect(err).To(MatchError(ContainSubstring("circular dependency")))
+	})
+})
@@ -0,0 +1,139 @@
+package compat_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/compat"
+)
+
+var validator *compat.Validator
+
+var _ = BeforeSuite(func() {
+	var err error
+	validator, err = compat.NewValidator()
+	Expect(err).NotTo(HaveOccurred())
+})
+
+var _ = Describe("Validator", func() {
+	validate := func(operation, body string) []string {
+		var messages []string
+		for _, violation := range validator.ValidateResponse("ecs", operation, []byte(body)) {
+			messages = append(messages, violation.String())
+		}
+		return messages
+	}
+
+	It("accepts responses that match the model", func() {
+		Expect(validate("DescribeServices", `{
+			"services": [{
+				"serviceName": "web",
+				"launchType": "FARGATE",
+				"desiredCount": 2,
+				"createdAt": 1760486400.123,
+				"deploymentConfiguration": {"deploymentCircuitBreaker": {"enable": true, "rollback": false}},
+				"tags": [{"key": "team", "value": "web"}],
+				"unknownMember": "ignored"
+			}],
+			"failures": []
+		}`)).To(BeEmpty())
+	})
+
+	It("reports missing required members", func() {
+		Expect(validate("ListAttributes", `{"attributes": [{"value": "x"}]}`)).To(ConsistOf(
+			"attributes[0].name: required member is missing",
+		))
+	})
+
+	It("does not require members that have a default", func() {
+		Expect(validate("DescribeServices", `{"services": [{"deploymentConfiguration": {"deploymentCircuitBreaker": {}}}]}`)).To(BeEmpty())
+	})
+
+	It("reports values outside of an enum", func() {
+		Expect(validate("DescribeServices", `{"services": [{"launchType": "fargate"}]}`)).To(ConsistOf(
+			`services[0].launchType: "fargate" is not a value of LaunchType`,
+		))
+	})
+
+	It("reports timestamps that are not epoch seconds", func() {
+		Expect(validate("DescribeServices", `{"services": [{"createdAt": "2025-10-15T00:00:00Z"}]}`)).To(ConsistOf(
+			`services[0].createdAt: expected an epoch-seconds timestamp, got string "2025-10-15T00:00:00Z"`,
+		))
+	})
+
+	It("reports values of the wrong type", func() {
+		Expect(validate("DescribeServices", `{"services": [{"desiredCount": "2", "serviceName": 1}]}`)).To(ConsistOf(
+			`services[0].desiredCount: expected an integer, got string "2"`,
+			"services[0].serviceName: expected a string, got number 1",
+		))
+	})
+
+	It("validates Service Discovery responses", func() {
+		Expect(validator.Knows("servicediscovery", "ListNamespaces")).To(BeTrue())
+		Expect(validator.ValidateResponse("servicediscovery", "ListNamespaces", []byte(`{"Namespaces": [{"Type": "DNS"}]}`))).To(HaveLen(1))
+	})
+
+	It("parses modes", func() {
+		Expect(compat.ParseMode("")).To(Equal(compat.ModeOff))
+		Expect(compat.ParseMode("FAIL")).To(Equal(compat.ModeFail))
+		_, err := compat.ParseMode("strict")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Middleware", func() {
+	var response string
+
+	serve := func(mode compat.Mode, operation string) *httptest.ResponseRecorder {
+		handler := validator.Middleware(mode, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+			w.Write([]byte(response))
+		}))
+		req := httptest.NewRequest("POST", "/", strings.NewReader("{}"))
+		req.Header.Set("X-Amz-Target", "AmazonEC2ContainerServiceV20141113."+operation)
+		req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	BeforeEach(func() {
+		response = `{"services": [{"launchType": "FARGATE"}]}`
+	})
+
+	It("sends matching responses unchanged", func() {
+		rec := serve(compat.ModeFail, "DescribeServices")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(Equal(response))
+	})
+
+	It("replaces responses that do not match in fail mode", func() {
+		response = `{"services": [{"launchType": "fargate"}]}`
+		rec := serve(compat.ModeFail, "DescribeServices")
+		Expect(rec.Code).To(Equal(http.StatusInternalServerError))
+
+		var body map[string]string
+		Expect(json.Unmarshal(rec.Body.Bytes(), &body)).To(Succeed())
+		Expect(body["__type"]).To(Equal("ServerException"))
+		Expect(body["message"]).To(ContainSubstring(`services[0].launchType: "fargate" is not a value of LaunchType`))
+	})
+
+	It("only logs mismatches in log mode", func() {
+		response = `{"services": [{"launchType": "fargate"}]}`
+		rec := serve(compat.ModeLog, "DescribeServices")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(Equal(response))
+	})
+
+	It("passes operations without a model through", func() {
+		response = `not json`
+		rec := serve(compat.ModeFail, "NotAnOperation")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(Equal(response))
+	})
+})
@@ -0,0 +1,116 @@
+package compat
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsrequest"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// Mode is what the strict-compat mode does with responses that do not match the model
+type Mode string
+
+const (
+	// ModeOff does not validate responses
+	ModeOff Mode = "off"
+	// ModeLog logs the mismatches and sends the responses unchanged
+	ModeLog Mode = "log"
+	// ModeFail replaces responses that do not match with a ServerException, e.g. for CI
+	ModeFail Mode = "fail"
+)
+
+// ParseMode parses a strict-compat mode, empty meaning off
+func ParseMode(s string) (Mode, error) {
+	switch Mode(strings.ToLower(s)) {
+	case "", ModeOff:
+		return ModeOff, nil
+	case ModeLog:
+		return ModeLog, nil
+	case ModeFail:
+		return ModeFail, nil
+	}
+	return "", fmt.Errorf("invalid strict-compat mode %q: must be off, log or fail", s)
+}
+
+// Middleware returns an HTTP middleware that validates the successful
+// responses of the operations the validator has a model of. Error responses
+// and other requests pass through unchecked.
+func (v *Validator) Middleware(mode Mode, next http.Handler) http.Handler {
+	if mode == ModeOff {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		op, ok := awsrequest.Identify(r)
+		if !ok || op.QueryProtocol || !v.Knows(op.Service, op.Name) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &responseBuffer{ResponseWriter: w, status: http.StatusOK, hold: mode == ModeFail}
+		next.ServeHTTP(rec, r)
+
+		var violations []Violation
+		if rec.status < 300 {
+			violations = v.ValidateResponse(op.Service, op.Name, rec.body.Bytes())
+		}
+		if len(violations) > 0 {
+			messages := make([]string, len(violations))
+			for i, violation := range violations {
+				messages[i] = violation.String()
+			}
+			logging.Warn("Response does not match the AWS API model",
+				"service", op.Service, "operation", op.Name, "violations", messages)
+			if mode == ModeFail {
+				awsrequest.WriteError(w, false, http.StatusInternalServerError, "ServerException",
+					fmt.Sprintf("strict-compat: response of %s does not match the %s API model: %s",
+						op.Name, op.Service, strings.Join(messages, "; ")))
+				return
+			}
+		}
+		rec.release()
+	})
+}
+
+// responseBuffer captures the response to validate it. When hold is set the
+// response is only sent by release, so that it can be replaced by an error.
+type responseBuffer struct {
+	http.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	hold        bool
+	wroteHeader bool
+}
+
+func (r *responseBuffer) WriteHeader(status int) {
+	if r.wroteHeader {
+		return
+	}
+	r.wroteHeader = true
+	r.status = status
+	if !r.hold {
+		r.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (r *responseBuffer) Write(b []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	r.body.Write(b)
+	if r.hold {
+		return len(b), nil
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// release sends a held response
+func (r *responseBuffer) release() {
+	if !r.hold {
+		return
+	}
+	r.ResponseWriter.WriteHeader(r.status)
+	r.ResponseWriter.Write(r.body.Bytes())
+}
@@ -0,0 +1,299 @@
+// Package compat checks that KECS responses conform to the Smithy models of
+// the AWS APIs it serves. Strict SDK clients fail on responses that omit
+// required members or carry values outside of an enum, so the strict-compat
+// mode validates every response against the model cmd/codegen generates the
+// API from.
+package compat
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/cmd/codegen/parser"
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsmodels"
+)
+
+// maxViolations bounds the violations reported per response
+const maxViolations = 20
+
+// Violation is a mismatch between a response and the model
+type Violation struct {
+	// Path locates the value in the response, e.g. services[0].launchType
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	if v.Path == "" {
+		return v.Message
+	}
+	return v.Path + ": " + v.Message
+}
+
+// Validator validates responses of the JSON protocol services against their models
+type Validator struct {
+	models map[string]*parser.SmithyAPI
+	// outputs maps services and operation names to the output shapes
+	outputs map[string]map[string]string
+	// enums maps enum shapes to their values
+	enums map[string]map[string]bool
+}
+
+// NewValidator creates a validator of the services whose model is embedded
+func NewValidator() (*Validator, error) {
+	v := &Validator{
+		models:  make(map[string]*parser.SmithyAPI),
+		outputs: make(map[string]map[string]string),
+		enums:   make(map[string]map[string]bool),
+	}
+	for _, service := range awsmodels.Services() {
+		model, err := awsmodels.Load(service)
+		if err != nil {
+			return nil, err
+		}
+		v.models[service] = model
+		v.outputs[service] = make(map[string]string)
+		for name, op := range model.GetOperations() {
+			if op.Output != nil && op.Output.Target != "smithy.api#Unit" {
+				v.outputs[service][parser.GetShapeName(name)] = op.Output.Target
+			}
+		}
+		for name, shape := range model.Shapes {
+			// IsEnum only sees enums declared with the Smithy 1.0 enum trait
+			if shape.Type == "enum" || shape.IsEnum() {
+				values := make(map[string]bool)
+				for _, value := range shape.GetEnumValues() {
+					values[value] = true
+				}
+				v.enums[name] = values
+			}
+		}
+	}
+	return v, nil
+}
+
+// Knows reports whether the validator has the model of an operation
+func (v *Validator) Knows(service, operation string) bool {
+	_, ok := v.outputs[service][operation]
+	return ok
+}
+
+// ValidateResponse validates the body of a successful response of an
+// operation. Members that are not in the model are not reported, as clients
+// ignore them.
+func (v *Validator) ValidateResponse(service, operation string, body []byte) []Violation {
+	target, ok := v.outputs[service][operation]
+	if !ok {
+		return nil
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return []Violation{{Message: fmt.Sprintf("response is not valid JSON: %v", err)}}
+	}
+	c := &check{validator: v, model: v.models[service]}
+	c.value(target, nil, value, "")
+	return c.violations
+}
+
+// check collects the violations of one response
+type check struct {
+	validator  *Validator
+	model      *parser.SmithyAPI
+	violations []Violation
+}
+
+func (c *check) report(path, format string, args ...interface{}) {
+	if len(c.violations) < maxViolations {
+		c.violations = append(c.violations, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+}
+
+// value validates a value of the target shape. traits are the traits of the
+// member holding the value, which may override the format of timestamps.
+func (c *check) value(target string, traits map[string]interface{}, value interface{}, path string) {
+	if len(c.violations) >= maxViolations {
+		return
+	}
+	shapeType, shape, name := c.resolve(target)
+
+	switch shapeType {
+	case "structure", "union":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			c.report(path, "expected an object, got %s", describe(value))
+			return
+		}
+		set := 0
+		for _, member := range slices.Sorted(maps.Keys(shape.Members)) {
+			def := shape.Members[member]
+			memberValue, present := object[member]
+			if !present || memberValue == nil {
+				// Clients fill in missing members with a default value
+				if def.IsRequired() && shapeType == "structure" && !hasTrait(def.Traits, "smithy.api#default") {
+					c.report(join(path, member), "required member is missing")
+				}
+				continue
+			}
+			set++
+			c.value(def.Target, def.Traits, memberValue, join(path, member))
+		}
+		if shapeType == "union" && set != 1 {
+			c.report(path, "union must have exactly one member set, got %d", set)
+		}
+	case "list", "set":
+		items, ok := value.([]interface{})
+		if !ok {
+			c.report(path, "expected an array, got %s", describe(value))
+			return
+		}
+		for i, item := range items {
+			if item != nil {
+				c.value(shape.Member.Target, shape.Member.Traits, item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	case "map":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			c.report(path, "expected an object, got %s", describe(value))
+			return
+		}
+		for _, key := range slices.Sorted(maps.Keys(object)) {
+			item := object[key]
+			itemPath := fmt.Sprintf("%s[%s]", path, strconv.Quote(key))
+			c.value(shape.Key.Target, nil, key, itemPath)
+			if item != nil {
+				c.value(shape.Value.Target, shape.Value.Traits, item, itemPath)
+			}
+		}
+	case "string", "enum":
+		s, ok := value.(string)
+		if !ok {
+			c.report(path, "expected a string, got %s", describe(value))
+			return
+		}
+		if values, isEnum := c.validator.enums[name]; isEnum && !values[s] {
+			c.report(path, "%q is not a value of %s", s, parser.GetShapeName(name))
+		}
+	case "blob":
+		s, ok := value.(string)
+		if !ok {
+			c.report(path, "expected a base64 string, got %s", describe(value))
+			return
+		}
+		if _, err := base64.StdEncoding.DecodeString(s); err != nil {
+			c.report(path, "expected a base64 string: %v", err)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			c.report(path, "expected a boolean, got %s", describe(value))
+		}
+	case "byte", "short", "integer", "long", "bigInteger", "intEnum":
+		number, ok := value.(json.Number)
+		if !ok {
+			c.report(path, "expected an integer, got %s", describe(value))
+			return
+		}
+		if _, err := number.Int64(); err != nil && shapeType != "bigInteger" {
+			c.report(path, "expected an integer, got %s", number)
+		}
+	case "float", "double", "bigDecimal":
+		if _, ok := value.(json.Number); !ok {
+			c.report(path, "expected a number, got %s", describe(value))
+		}
+	case "timestamp":
+		c.timestamp(timestampFormat(traits, shape), value, path)
+	case "document", "unit":
+	default:
+		c.report(path, "unknown shape %s", target)
+	}
+}
+
+// timestamp validates a timestamp, which JSON protocols send as epoch seconds by default
+func (c *check) timestamp(format string, value interface{}, path string) {
+	switch format {
+	case "date-time", "http-date":
+		s, ok := value.(string)
+		if !ok {
+			c.report(path, "expected a %s timestamp string, got %s", format, describe(value))
+			return
+		}
+		layout := time.RFC3339Nano
+		if format == "http-date" {
+			layout = http.TimeFormat
+		}
+		if _, err := time.Parse(layout, s); err != nil {
+			c.report(path, "expected a %s timestamp, got %q", format, s)
+		}
+	default:
+		if _, ok := value.(json.Number); !ok {
+			c.report(path, "expected an epoch-seconds timestamp, got %s", describe(value))
+		}
+	}
+}
+
+// resolve returns the type of a shape. Shapes of the Smithy prelude, such as
+// smithy.api#String, are not part of the model and have no shape.
+func (c *check) resolve(target string) (string, *parser.SmithyShape, string) {
+	if shape, name := c.model.ResolveShape(target); shape != nil {
+		return shape.Type, shape, name
+	}
+	name := strings.TrimPrefix(target, "smithy.api#")
+	name = strings.TrimPrefix(name, "Primitive")
+	if name == "BigInteger" || name == "BigDecimal" {
+		return strings.ToLower(name[:1]) + name[1:], nil, target
+	}
+	return strings.ToLower(name), nil, target
+}
+
+func timestampFormat(traits map[string]interface{}, shape *parser.SmithyShape) string {
+	if format, ok := traits["smithy.api#timestampFormat"].(string); ok {
+		return format
+	}
+	if shape != nil {
+		if format, ok := shape.Traits["smithy.api#timestampFormat"].(string); ok {
+			return format
+		}
+	}
+	return "epoch-seconds"
+}
+
+func hasTrait(traits map[string]interface{}, trait string) bool {
+	_, ok := traits[trait]
+	return ok
+}
+
+func join(path, member string) string {
+	if path == "" {
+		return member
+	}
+	return path + "." + member
+}
+
+// describe names the JSON type of a value
+func describe(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return fmt.Sprintf("string %q", v)
+	case json.Number:
+		return "number " + v.String()
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/spf13/viper"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
 	"github.com/nandemo-ya/kecs/controlplane/internal/localstack"
 	"github.com/nandemo-ya/kecs/controlplane/internal/version"
 )
@@ -34,6 +35,7 @@ type ServerConfig struct {
 	Endpoint          string   `yaml:"endpoint" mapstructure:"endpoint"`
 	ControlPlaneImage string   `yaml:"controlPlaneImage" mapstructure:"controlPlaneImage"`
 	ConfigPath        string   `yaml:"configPath" mapstructure:"configPath"`
+	ReadOnly          bool     `yaml:"readOnly" mapstructure:"readOnly"`
 }
 
 // DatabaseConfig represents database configuration
@@ -72,12 +74,14 @@ type FeaturesConfig struct {
 	IAMIntegration   bool `yaml:"iamIntegration" mapstructure:"iamIntegration"`
 	Debug            bool `yaml:"debug" mapstructure:"debug"`
 	IntegrationTest  bool `yaml:"integrationTest" mapstructure:"integrationTest"`
+	Deterministic    bool `yaml:"deterministic" mapstructure:"deterministic"`
 }
 
 // AWSConfig represents AWS-related configuration
 type AWSConfig struct {
 	DefaultRegion string `yaml:"defaultRegion" mapstructure:"defaultRegion"`
 	AccountID     string `yaml:"accountID" mapstructure:"accountID"`
+	Partition     string `yaml:"partition" mapstructure:"partition"`
 	ProxyImage    string `yaml:"proxyImage" mapstructure:"proxyImage"`
 	EndpointURL   string `yaml:"endpointURL" mapstructure:"endpointURL"`
 }
@@ -119,6 +123,7 @@ func InitConfig() error {
 		v.SetDefault("server.allowedOrigins", []string{})
 		v.SetDefault("server.endpoint", "")
 		v.SetDefault("server.controlPlaneImage", computeControlPlaneImage())
+		v.SetDefault("server.readOnly", false) // Reject mutating ECS, ELBv2 and CloudFormation calls
 
 		// Database defaults
 		v.SetDefault("database.type", "postgres")
@@ -146,6 +151,7 @@ func InitConfig() error {
 		v.SetDefault("features.iamIntegration", false) // Disable IAM integration by default
 		v.SetDefault("features.debug", false)
 		v.SetDefault("features.integrationTest", false)
+		v.SetDefault("features.deterministic", false) // Sequential IDs and a fixed clock for reproducible tests
 
 		// Cleanup worker defaults
 		v.SetDefault("cleanup.enabled", true)
@@ -155,10 +161,16 @@ func InitConfig() error {
 		v.SetDefault("cleanup.containerInstance.retention", "1h")
 		v.SetDefault("cleanup.taskSet.retention", "24h")
 		v.SetDefault("cleanup.log.retention", "168h") // 7 days
+		v.SetDefault("cleanup.taskDefinition.enabled", true)
+		v.SetDefault("cleanup.taskDefinition.keepActiveRevisions", 0)    // 0 keeps all ACTIVE revisions
+		v.SetDefault("cleanup.taskDefinition.inactiveRetention", "720h") // 30 days
+		v.SetDefault("cleanup.taskDefinition.taskWindow", "24h")
+		v.SetDefault("cleanup.taskDefinition.dryRun", false)
 
 		// AWS defaults
 		v.SetDefault("aws.defaultRegion", "us-east-1")
 		v.SetDefault("aws.accountID", "000000000000")
+		v.SetDefault("aws.partition", "aws") // aws, aws-cn or aws-us-gov
 		v.SetDefault("aws.proxyImage", "")
 		v.SetDefault("aws.endpointURL", "http://localstack.kecs-system.svc.cluster.local:4566")
 
@@ -167,6 +179,126 @@ func InitConfig() error {
 		v.SetDefault("localstack.useTraefik", true) // Enable Traefik for LocalStack by default
 		v.SetDefault("localstack.image", "localstack/localstack")
 		v.SetDefault("localstack.version", "latest")
+		v.SetDefault("localstack.backend", "localstack") // localstack, or stub to serve S3, SSM and Secrets Manager from the control plane
+
+		// Network defaults
+		v.SetDefault("network.validateSubnets", false)              // Reject subnets unknown to the VPC registry
+		v.SetDefault("network.dynamicHostPortRange", "49153-65535") // Host ports assigned to bridge mode port mappings without hostPort
+
+		// Federation defaults
+		v.SetDefault("federation.ports", "") // Extra API ports to probe for instances, e.g. "8080,9080-9082"
+
+		// Audit defaults
+		v.SetDefault("audit.enabled", true)     // Record mutating API calls
+		v.SetDefault("audit.maxEntries", 10000) // Entries kept in memory and in the audit log file
+
+		// Recording defaults
+		v.SetDefault("recording.enabled", false) // Record API calls with their responses from startup
+
+		// Compatibility defaults
+		v.SetDefault("compat.strictMode", "off") // Validate responses against the AWS API models: off, log or fail
+
+		// Backup defaults
+		v.SetDefault("backup.schedule", "")    // Cron schedule of automatic backups, e.g. "0 3 * * *"; empty disables them
+		v.SetDefault("backup.destination", "") // S3 prefix backups are written to, e.g. s3://kecs-backups/dev/
+		v.SetDefault("backup.retention", 7)    // Backups kept in the destination, 0 keeps all
+		v.SetDefault("backup.endpoint", "")    // S3 endpoint of the destination, the AWS endpoint when empty
+
+		// Notification defaults
+		v.SetDefault("notifications.targets", "")    // Webhook targets of deployment, task and instance events; empty disables them
+		v.SetDefault("notifications.secret", "")     // HMAC key signing the payloads in the X-KECS-Signature-256 header
+		v.SetDefault("notifications.maxRetries", 3)  // Retries of failed deliveries, with exponential backoff from 1s
+		v.SetDefault("notifications.timeout", "10s") // Timeout of a delivery attempt
+
+		// Usage defaults
+		v.SetDefault("usage.vcpuHourPrice", 0.04048) // Price per vCPU-hour of cost estimates, Fargate Linux/x86 in us-east-1
+		v.SetDefault("usage.gbHourPrice", 0.004445)  // Price per GB-hour of cost estimates, Fargate Linux/x86 in us-east-1
+		v.SetDefault("usage.retention", "168h")      // Usage of deleted tasks kept for cost reports
+
+		// Capacity defaults
+		v.SetDefault("capacity.cpuWarningThreshold", 80.0)    // CPU utilization in percent above which the capacity report warns
+		v.SetDefault("capacity.memoryWarningThreshold", 80.0) // Memory utilization in percent above which the capacity report warns
+
+		// Auth defaults
+		v.SetDefault("auth.credentialsFile", "")    // Verify SigV4 signatures against this file when set
+		v.SetDefault("auth.allowUnsigned", false)   // Let requests without Authorization header through
+		v.SetDefault("auth.enforcePolicies", false) // Evaluate the IAM policies of the credentials file
+
+		// RunTask defaults
+		v.SetDefault("runTask.concurrency", 10) // Pods created in parallel per RunTask call
+
+		// Artifact defaults
+		v.SetDefault("artifacts.downloadAttempts", 5) // Attempts per artifact download, with exponential backoff
+
+		// Task definition defaults
+		v.SetDefault("taskDefinition.deduplicateRevisions", false)  // Return the latest revision when its content is registered again
+		v.SetDefault("taskDefinition.protectInUseRevisions", false) // Reject deregistering revisions still used by services, task sets or tasks
+
+		// Cluster defaults
+		v.SetDefault("cluster.cascadeDelete", false) // DeleteCluster drains and deletes the services, task sets and tasks left in the cluster
+
+		// App Mesh defaults
+		v.SetDefault("appMesh.proxyInitImage", "nicolaka/netshoot:latest") // Image with iptables that routes task traffic through the proxy container
+
+		// Volume defaults
+		v.SetDefault("volumes.ebs.storageClass", "") // Storage class of the PVCs backing managed EBS volumes, the cluster default when empty
+
+		// EFS defaults
+		v.SetDefault("efs.nfsServer.enabled", false)                      // Serve EFS volumes from an NFS server in the instance instead of AWS
+		v.SetDefault("efs.nfsServer.image", "erichough/nfs-server:2.2.1") // NFS server image, privileged
+		v.SetDefault("efs.nfsServer.clusterIP", "10.43.0.49")             // Fixed service IP, as kubelet mounts from the node without cluster DNS
+		v.SetDefault("efs.nfsServer.storageSize", "10Gi")                 // Size of the PVC holding the file systems
+		v.SetDefault("efs.accessPoints", "")                              // Access points, e.g. "fsap-1=fs-1:/app:1000:1000:0755"
+
+		// Service reconciler defaults
+		v.SetDefault("serviceReconciler.workers", 2)           // Services applied to Kubernetes concurrently
+		v.SetDefault("serviceReconciler.maxRetries", 5)        // Failed attempts retried before a service is FAILED
+		v.SetDefault("serviceReconciler.retryBaseDelay", "1s") // Backoff of the first retry, doubled per attempt
+		v.SetDefault("serviceReconciler.retryMaxDelay", "1m")  // Upper bound of the retry backoff
+
+		// Load generation defaults
+		v.SetDefault("bench.image", "") // Image of bench Jobs, defaults to the control plane image
+
+		// Image build defaults
+		v.SetDefault("build.executorImage", "gcr.io/kaniko-project/executor:v1.23.2")               // Image building the images of build-and-run
+		v.SetDefault("build.fetchImage", "busybox:1.36")                                            // Image downloading build contexts into the build Jobs
+		v.SetDefault("build.registry", "registry.kecs.local:5000")                                  // Registry of the instance images are pushed to
+		v.SetDefault("build.adminEndpoint", "http://kecs-admin.kecs-system.svc.cluster.local:5374") // In-cluster address build Jobs download their context from
+		v.SetDefault("build.contextDir", "")                                                        // Directory of uploaded build contexts, defaults to a temporary directory
+
+		// Deployment alarm monitor defaults
+		v.SetDefault("deploymentAlarms.interval", "30s") // How often the alarms of rolling out services are checked
+
+		// Service provisioning monitor defaults
+		v.SetDefault("serviceProvisioning.interval", "30s") // How often deployments in progress are checked
+		v.SetDefault("serviceProvisioning.timeout", "10m")  // How long a deployment may run no task before it fails
+
+		// Container Insights collector defaults
+		v.SetDefault("containerInsights.interval", "1m") // How often service metrics are published to CloudWatch
+
+		// Task timeout monitor defaults
+		v.SetDefault("taskTimeout.interval", "15s") // How often tasks are checked against their kecs.dev/max-duration tag
+
+		// Image defaults
+		v.SetDefault("images.pullPolicy", "")           // Pull policy of task containers (Always, IfNotPresent, Never), empty keeps the Kubernetes default
+		v.SetDefault("images.resolveDigests", false)    // Pin image tags to their digests at RegisterTaskDefinition
+		v.SetDefault("images.insecureRegistries", "")   // Registries resolved over plain HTTP, e.g. "k3d-kecs-registry:5000"
+		v.SetDefault("images.checkArchitectures", true) // Check at RunTask that images provide the cpuArchitecture of the runtime platform
+
+		// Task status sync defaults
+		v.SetDefault("taskSync.informer", true)     // Propagate pod status through the shared pod informer instead of a watch per task
+		v.SetDefault("taskSync.resyncPeriod", "5m") // How often the informers re-deliver every pod to repair missed updates
+
+		// Drift detector defaults
+		v.SetDefault("driftDetector.interval", "1m") // How often services are compared against Kubernetes
+		v.SetDefault("driftDetector.repair", true)   // Re-apply drifted services instead of marking them DEGRADED
+
+		// Rate limit defaults
+		v.SetDefault("rateLimit.requestsPerSecond", 0)                 // Default limit of each API operation, 0 disables it
+		v.SetDefault("rateLimit.burst", 0)                             // Burst of the default limit, 0 derives it from the rate
+		v.SetDefault("rateLimit.operations", "")                       // Per-operation limits, e.g. "RunTask=5:10,CreateService=2"
+		v.SetDefault("rateLimit.maxConcurrentKubernetesOperations", 0) // Kubernetes applying operations served at once, 0 is unbounded
+		v.SetDefault("rateLimit.queueTimeout", "5s")                   // Wait for a free slot before throttling
 
 		// Enable environment variable support with KECS prefix only
 		v.SetEnvPrefix("KECS")
@@ -187,11 +319,13 @@ func bindLegacyEnvVars() {
 	v.BindEnv("features.containerMode", "KECS_CONTAINER_MODE")
 	v.BindEnv("features.debug", "KECS_DEBUG")
 	v.BindEnv("features.integrationTest", "KECS_INTEGRATION_TEST")
+	v.BindEnv("features.deterministic", "KECS_DETERMINISTIC")
 	v.BindEnv("server.dataDir", "KECS_DATA_DIR")
 	v.BindEnv("server.logLevel", "KECS_LOG_LEVEL")
 	v.BindEnv("server.configPath", "KECS_CONFIG_PATH")
 	v.BindEnv("aws.defaultRegion", "KECS_DEFAULT_REGION")
 	v.BindEnv("aws.accountID", "KECS_ACCOUNT_ID")
+	v.BindEnv("aws.partition", "KECS_PARTITION")
 	v.BindEnv("server.allowedOrigins", "KECS_ALLOWED_ORIGINS")
 	v.BindEnv("server.endpoint", "KECS_ENDPOINT")
 	v.BindEnv("kubernetes.kubeconfigPath", "KECS_KUBECONFIG_PATH")
@@ -209,6 +343,7 @@ func bindLegacyEnvVars() {
 	v.BindEnv("features.iamIntegration", "KECS_IAM_INTEGRATION")
 	v.BindEnv("localstack.enabled", "KECS_LOCALSTACK_ENABLED")
 	v.BindEnv("localstack.useTraefik", "KECS_LOCALSTACK_USE_TRAEFIK")
+	v.BindEnv("localstack.backend", "KECS_LOCALSTACK_BACKEND")
 	v.BindEnv("server.controlPlaneImage", "KECS_CONTROLPLANE_IMAGE")
 	v.BindEnv("database.type", "KECS_DATABASE_TYPE")
 	v.BindEnv("database.postgres.host", "KECS_POSTGRES_HOST")
@@ -217,6 +352,63 @@ func bindLegacyEnvVars() {
 	v.BindEnv("database.postgres.user", "KECS_POSTGRES_USER")
 	v.BindEnv("database.postgres.password", "KECS_POSTGRES_PASSWORD")
 	v.BindEnv("database.postgres.sslMode", "KECS_POSTGRES_SSLMODE")
+	v.BindEnv("network.validateSubnets", "KECS_VALIDATE_SUBNETS")
+	v.BindEnv("network.dynamicHostPortRange", "KECS_DYNAMIC_HOST_PORT_RANGE")
+	v.BindEnv("federation.ports", "KECS_FEDERATION_PORTS")
+	v.BindEnv("audit.enabled", "KECS_AUDIT_ENABLED")
+	v.BindEnv("audit.maxEntries", "KECS_AUDIT_MAX_ENTRIES")
+	v.BindEnv("recording.enabled", "KECS_RECORDING_ENABLED")
+	v.BindEnv("compat.strictMode", "KECS_STRICT_COMPAT")
+	v.BindEnv("backup.schedule", "KECS_BACKUP_SCHEDULE")
+	v.BindEnv("backup.destination", "KECS_BACKUP_DESTINATION")
+	v.BindEnv("backup.retention", "KECS_BACKUP_RETENTION")
+	v.BindEnv("backup.endpoint", "KECS_BACKUP_ENDPOINT")
+	v.BindEnv("notifications.targets", "KECS_NOTIFICATIONS_TARGETS")
+	v.BindEnv("notifications.secret", "KECS_NOTIFICATIONS_SECRET")
+	v.BindEnv("notifications.maxRetries", "KECS_NOTIFICATIONS_MAX_RETRIES")
+	v.BindEnv("notifications.timeout", "KECS_NOTIFICATIONS_TIMEOUT")
+	v.BindEnv("usage.vcpuHourPrice", "KECS_USAGE_VCPU_HOUR_PRICE")
+	v.BindEnv("usage.gbHourPrice", "KECS_USAGE_GB_HOUR_PRICE")
+	v.BindEnv("capacity.cpuWarningThreshold", "KECS_CAPACITY_CPU_WARNING_THRESHOLD")
+	v.BindEnv("capacity.memoryWarningThreshold", "KECS_CAPACITY_MEMORY_WARNING_THRESHOLD")
+	v.BindEnv("server.readOnly", "KECS_READ_ONLY")
+	v.BindEnv("auth.credentialsFile", "KECS_CREDENTIALS_FILE")
+	v.BindEnv("auth.allowUnsigned", "KECS_AUTH_ALLOW_UNSIGNED")
+	v.BindEnv("auth.enforcePolicies", "KECS_AUTH_ENFORCE_POLICIES")
+	v.BindEnv("runTask.concurrency", "KECS_RUN_TASK_CONCURRENCY")
+	v.BindEnv("artifacts.downloadAttempts", "KECS_ARTIFACT_DOWNLOAD_ATTEMPTS")
+	v.BindEnv("taskDefinition.deduplicateRevisions", "KECS_TASK_DEFINITION_DEDUPLICATE")
+	v.BindEnv("taskDefinition.protectInUseRevisions", "KECS_TASK_DEFINITION_PROTECT_IN_USE")
+	v.BindEnv("appMesh.proxyInitImage", "KECS_APPMESH_PROXY_INIT_IMAGE")
+	v.BindEnv("volumes.ebs.storageClass", "KECS_EBS_STORAGE_CLASS")
+	v.BindEnv("efs.nfsServer.enabled", "KECS_EFS_NFS_SERVER")
+	v.BindEnv("efs.accessPoints", "KECS_EFS_ACCESS_POINTS")
+	v.BindEnv("serviceReconciler.workers", "KECS_SERVICE_RECONCILER_WORKERS")
+	v.BindEnv("serviceReconciler.maxRetries", "KECS_SERVICE_RECONCILER_MAX_RETRIES")
+	v.BindEnv("deploymentAlarms.interval", "KECS_DEPLOYMENT_ALARMS_INTERVAL")
+	v.BindEnv("taskTimeout.interval", "KECS_TASK_TIMEOUT_INTERVAL")
+	v.BindEnv("serviceProvisioning.interval", "KECS_SERVICE_PROVISIONING_INTERVAL")
+	v.BindEnv("serviceProvisioning.timeout", "KECS_SERVICE_PROVISIONING_TIMEOUT")
+	v.BindEnv("containerInsights.interval", "KECS_CONTAINER_INSIGHTS_INTERVAL")
+	v.BindEnv("images.pullPolicy", "KECS_IMAGE_PULL_POLICY")
+	v.BindEnv("images.resolveDigests", "KECS_IMAGE_RESOLVE_DIGESTS")
+	v.BindEnv("images.insecureRegistries", "KECS_IMAGE_INSECURE_REGISTRIES")
+	v.BindEnv("images.checkArchitectures", "KECS_IMAGE_CHECK_ARCHITECTURES")
+	v.BindEnv("taskSync.informer", "KECS_TASK_SYNC_INFORMER")
+	v.BindEnv("taskSync.resyncPeriod", "KECS_TASK_SYNC_RESYNC_PERIOD")
+	v.BindEnv("driftDetector.interval", "KECS_DRIFT_DETECTOR_INTERVAL")
+	v.BindEnv("driftDetector.repair", "KECS_DRIFT_DETECTOR_REPAIR")
+	v.BindEnv("bench.image", "KECS_BENCH_IMAGE")
+	v.BindEnv("build.executorImage", "KECS_BUILD_EXECUTOR_IMAGE")
+	v.BindEnv("build.fetchImage", "KECS_BUILD_FETCH_IMAGE")
+	v.BindEnv("build.registry", "KECS_BUILD_REGISTRY")
+	v.BindEnv("build.adminEndpoint", "KECS_BUILD_ADMIN_ENDPOINT")
+	v.BindEnv("build.contextDir", "KECS_BUILD_CONTEXT_DIR")
+	v.BindEnv("rateLimit.requestsPerSecond", "KECS_RATE_LIMIT_RPS")
+	v.BindEnv("rateLimit.burst", "KECS_RATE_LIMIT_BURST")
+	v.BindEnv("rateLimit.operations", "KECS_RATE_LIMIT_OPERATIONS")
+	v.BindEnv("rateLimit.maxConcurrentKubernetesOperations", "KECS_MAX_CONCURRENT_K8S_OPERATIONS")
+	v.BindEnv("rateLimit.queueTimeout", "KECS_RATE_LIMIT_QUEUE_TIMEOUT")
 }
 
 // DefaultConfig returns the default configuration
@@ -344,6 +536,14 @@ func GetInt(key string) int {
 	return v.GetInt(key)
 }
 
+// GetFloat64 returns a float64 configuration value
+func GetFloat64(key string) float64 {
+	ensureInitialized()
+	mu.RLock()
+	defer mu.RUnlock()
+	return v.GetFloat64(key)
+}
+
 // GetBool returns a bool configuration value
 func GetBool(key string) bool {
 	ensureInitialized()
@@ -426,8 +626,17 @@ func (c *Config) Validate() error {
 	}
 
 	// Validate AWS account ID if specified
-	if c.AWS.AccountID != "" && len(c.AWS.AccountID) != 12 {
-		return fmt.Errorf("AWS account ID must be 12 digits: %s", c.AWS.AccountID)
+	if c.AWS.AccountID != "" {
+		if err := awsarn.ValidateAccountID(c.AWS.AccountID); err != nil {
+			return fmt.Errorf("invalid AWS account ID: %w", err)
+		}
+	}
+
+	// Validate AWS partition if specified
+	if c.AWS.Partition != "" {
+		if err := awsarn.ValidatePartition(c.AWS.Partition); err != nil {
+			return fmt.Errorf("invalid AWS partition: %w", err)
+		}
 	}
 
 	// Validate LocalStack config (always required for KECS)
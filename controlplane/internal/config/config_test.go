@@ -51,6 +51,19 @@ var _ = Describe("Config", func() {
 			// Features should be disabled via environment variables
 			Expect(cfg.LocalStack.Enabled).To(BeFalse())
 		})
+
+		It("should allow selecting the AWS stub via environment variables", func() {
+			config.ResetConfig()
+
+			os.Setenv("KECS_LOCALSTACK_BACKEND", "stub")
+			defer func() {
+				os.Unsetenv("KECS_LOCALSTACK_BACKEND")
+				config.ResetConfig()
+			}()
+
+			cfg := config.DefaultConfig()
+			Expect(cfg.LocalStack.UseStub()).To(BeTrue())
+		})
 	})
 
 	Describe("LoadConfig", func() {
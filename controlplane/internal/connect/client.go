@@ -0,0 +1,148 @@
+package connect
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Client calls the procedures of a Connect service
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the service at baseURL. Streams last as
+// long as their context, so the HTTP client should not set a timeout.
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// CallUnary calls a unary procedure, such as /kecs.v1.KecsService/GetSummary
+func CallUnary[Req, Res any](ctx context.Context, c *Client, procedure string, req *Req) (*Res, error) {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+procedure, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", ContentTypeUnary)
+	httpReq.Header.Set(ProtocolVersionHeader, "1")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, NewError(CodeUnavailable, "%v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxMessageSize))
+	if err != nil {
+		return nil, NewError(CodeUnavailable, "failed to read response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp.StatusCode, body)
+	}
+
+	res := new(Res)
+	if err := json.Unmarshal(body, res); err != nil {
+		return nil, NewError(CodeInternal, "invalid response: %v", err)
+	}
+	return res, nil
+}
+
+// responseError decodes the error of a failed unary call
+func responseError(status int, body []byte) error {
+	var connectErr Error
+	if err := json.Unmarshal(body, &connectErr); err == nil && connectErr.Code != "" {
+		return &connectErr
+	}
+	return NewError(codeFromHTTPStatus(status), "HTTP status %d", status)
+}
+
+// ServerStream receives the messages of a server streaming call
+type ServerStream[Res any] struct {
+	body io.ReadCloser
+	err  error
+}
+
+// CallServerStream calls a server streaming procedure
+func CallServerStream[Req, Res any](ctx context.Context, c *Client, procedure string, req *Req) (*ServerStream[Res], error) {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	var body bytes.Buffer
+	if err := writeEnvelope(&body, 0, payload); err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+procedure, &body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", ContentTypeStream)
+	httpReq.Header.Set(ProtocolVersionHeader, "1")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, NewError(CodeUnavailable, "%v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxMessageSize))
+		return nil, responseError(resp.StatusCode, respBody)
+	}
+	return &ServerStream[Res]{body: resp.Body}, nil
+}
+
+// Receive returns the next message. It returns io.EOF when the stream ended
+// without error, and the error the stream ended with otherwise.
+func (s *ServerStream[Res]) Receive() (*Res, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	flags, payload, err := readEnvelope(s.body)
+	if err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			s.err = NewError(CodeUnavailable, "stream ended without end of stream message")
+		} else {
+			s.err = NewError(CodeUnavailable, "%v", err)
+		}
+		return nil, s.err
+	}
+
+	if flags&flagEndStream != 0 {
+		var end endStreamMessage
+		if err := json.Unmarshal(payload, &end); err != nil {
+			s.err = NewError(CodeInternal, "invalid end of stream message: %v", err)
+		} else if end.Error != nil {
+			s.err = end.Error
+		} else {
+			s.err = io.EOF
+		}
+		return nil, s.err
+	}
+
+	res := new(Res)
+	if err := json.Unmarshal(payload, res); err != nil {
+		s.err = NewError(CodeInternal, "invalid message: %v", err)
+		return nil, s.err
+	}
+	return res, nil
+}
+
+// Close closes the stream
+func (s *ServerStream[Res]) Close() error {
+	return s.body.Close()
+}
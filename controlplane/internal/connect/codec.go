@@ -0,0 +1,268 @@
+package connect
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// codec marshals the messages of procedures
+type codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// jsonCodec is the JSON mapping of protobuf. Messages carry json tags
+// following it.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+// protoCodec is the binary protobuf encoding. Messages are Go structs whose
+// fields carry their field number in a protobuf tag, e.g. `protobuf:"3"`;
+// fields without tag are not encoded. Supported field types are int,
+// int32, int64, bool, string, time.Time as google.protobuf.Timestamp, pointers to
+// them for optional fields, structs for nested messages and slices of these.
+type protoCodec struct{}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (protoCodec) Marshal(v interface{}) ([]byte, error) {
+	value := reflect.ValueOf(v)
+	if value.Kind() == reflect.Pointer {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot marshal %T as protobuf message", v)
+	}
+	return appendMessage(nil, value)
+}
+
+func (protoCodec) Unmarshal(data []byte, v interface{}) error {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot unmarshal protobuf message into %T", v)
+	}
+	return consumeMessage(data, value.Elem())
+}
+
+// fieldNumbers maps the protobuf field numbers of a struct to field indexes
+func fieldNumbers(t reflect.Type) map[protowire.Number]int {
+	numbers := make(map[protowire.Number]int)
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("protobuf")
+		if tag == "" {
+			continue
+		}
+		if n, err := strconv.Atoi(tag); err == nil {
+			numbers[protowire.Number(n)] = i
+		}
+	}
+	return numbers
+}
+
+func appendMessage(b []byte, message reflect.Value) ([]byte, error) {
+	t := message.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("protobuf")
+		if tag == "" {
+			continue
+		}
+		n, err := strconv.Atoi(tag)
+		if err != nil {
+			return nil, fmt.Errorf("invalid protobuf tag of %s.%s: %w", t.Name(), t.Field(i).Name, err)
+		}
+		if b, err = appendField(b, protowire.Number(n), message.Field(i), false); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// appendField appends a field. Fields with the zero value are omitted as in
+// proto3, unless present is set for values of optional fields and lists.
+func appendField(b []byte, n protowire.Number, v reflect.Value, present bool) ([]byte, error) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return b, nil
+		}
+		return appendField(b, n, v.Elem(), true)
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			var err error
+			if b, err = appendField(b, n, v.Index(i), true); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		if v.Int() == 0 && !present {
+			return b, nil
+		}
+		b = protowire.AppendTag(b, n, protowire.VarintType)
+		return protowire.AppendVarint(b, uint64(v.Int())), nil
+	case reflect.Bool:
+		if !v.Bool() && !present {
+			return b, nil
+		}
+		b = protowire.AppendTag(b, n, protowire.VarintType)
+		return protowire.AppendVarint(b, protowire.EncodeBool(v.Bool())), nil
+	case reflect.String:
+		if v.Len() == 0 && !present {
+			return b, nil
+		}
+		b = protowire.AppendTag(b, n, protowire.BytesType)
+		return protowire.AppendString(b, v.String()), nil
+	case reflect.Struct:
+		var payload []byte
+		if v.Type() == timeType {
+			at := v.Interface().(time.Time)
+			if at.IsZero() && !present {
+				return b, nil
+			}
+			payload = appendTimestamp(nil, at)
+		} else {
+			var err error
+			if payload, err = appendMessage(nil, v); err != nil {
+				return nil, err
+			}
+		}
+		b = protowire.AppendTag(b, n, protowire.BytesType)
+		return protowire.AppendBytes(b, payload), nil
+	}
+	return nil, fmt.Errorf("cannot marshal %s as protobuf field", v.Type())
+}
+
+// appendTimestamp encodes a google.protobuf.Timestamp
+func appendTimestamp(b []byte, at time.Time) []byte {
+	if seconds := at.Unix(); seconds != 0 {
+		b = protowire.AppendTag(b, 1, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(seconds))
+	}
+	if nanos := at.Nanosecond(); nanos != 0 {
+		b = protowire.AppendTag(b, 2, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(nanos))
+	}
+	return b
+}
+
+func consumeMessage(data []byte, message reflect.Value) error {
+	numbers := fieldNumbers(message.Type())
+	for len(data) > 0 {
+		n, wireType, size := protowire.ConsumeTag(data)
+		if size < 0 {
+			return protowire.ParseError(size)
+		}
+		data = data[size:]
+
+		index, known := numbers[n]
+		if !known {
+			// Unknown fields are skipped, e.g. those of a newer schema
+			size = protowire.ConsumeFieldValue(n, wireType, data)
+			if size < 0 {
+				return protowire.ParseError(size)
+			}
+			data = data[size:]
+			continue
+		}
+		size, err := consumeField(data, wireType, message.Field(index))
+		if err != nil {
+			return fmt.Errorf("field %d of %s: %w", n, message.Type().Name(), err)
+		}
+		data = data[size:]
+	}
+	return nil
+}
+
+// consumeField decodes a field value into v and returns its size
+func consumeField(data []byte, wireType protowire.Type, v reflect.Value) (int, error) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return consumeField(data, wireType, v.Elem())
+	case reflect.Slice:
+		item := reflect.New(v.Type().Elem()).Elem()
+		size, err := consumeField(data, wireType, item)
+		if err != nil {
+			return 0, err
+		}
+		v.Set(reflect.Append(v, item))
+		return size, nil
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Bool:
+		if wireType != protowire.VarintType {
+			return 0, fmt.Errorf("unexpected wire type %d", wireType)
+		}
+		value, size := protowire.ConsumeVarint(data)
+		if size < 0 {
+			return 0, protowire.ParseError(size)
+		}
+		if v.Kind() == reflect.Bool {
+			v.SetBool(protowire.DecodeBool(value))
+		} else {
+			v.SetInt(int64(value))
+		}
+		return size, nil
+	}
+
+	if wireType != protowire.BytesType {
+		return 0, fmt.Errorf("unexpected wire type %d", wireType)
+	}
+	payload, size := protowire.ConsumeBytes(data)
+	if size < 0 {
+		return 0, protowire.ParseError(size)
+	}
+	switch {
+	case v.Kind() == reflect.String:
+		v.SetString(string(payload))
+	case v.Type() == timeType:
+		at, err := consumeTimestamp(payload)
+		if err != nil {
+			return 0, err
+		}
+		v.Set(reflect.ValueOf(at))
+	case v.Kind() == reflect.Struct:
+		if err := consumeMessage(payload, v); err != nil {
+			return 0, err
+		}
+	default:
+		return 0, fmt.Errorf("cannot unmarshal protobuf field into %s", v.Type())
+	}
+	return size, nil
+}
+
+// consumeTimestamp decodes a google.protobuf.Timestamp
+func consumeTimestamp(data []byte) (time.Time, error) {
+	var seconds, nanos int64
+	for len(data) > 0 {
+		n, wireType, size := protowire.ConsumeTag(data)
+		if size < 0 {
+			return time.Time{}, protowire.ParseError(size)
+		}
+		data = data[size:]
+		if wireType != protowire.VarintType || (n != 1 && n != 2) {
+			size = protowire.ConsumeFieldValue(n, wireType, data)
+		} else {
+			var value uint64
+			value, size = protowire.ConsumeVarint(data)
+			if n == 1 {
+				seconds = int64(value)
+			} else {
+				nanos = int64(int32(value))
+			}
+		}
+		if size < 0 {
+			return time.Time{}, protowire.ParseError(size)
+		}
+		data = data[size:]
+	}
+	return time.Unix(seconds, nanos).UTC(), nil
+}
@@ -0,0 +1,297 @@
+// Package connect implements the Connect protocol (https://connectrpc.com)
+// with the JSON and binary protobuf codecs: unary procedures and server
+// streaming procedures. Procedures can be called by Connect clients generated
+// from the protobuf schema of a service, and unary procedures with a plain
+// JSON POST.
+package connect
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// Content types of the Connect protocol with the JSON codec
+const (
+	ContentTypeUnary  = "application/json"
+	ContentTypeStream = "application/connect+json"
+)
+
+// Content types of the Connect protocol with the protobuf codec, which
+// clients generated from a schema use by default
+const (
+	ContentTypeUnaryProto  = "application/proto"
+	ContentTypeStreamProto = "application/connect+proto"
+)
+
+// unaryCodecs and streamCodecs are the codecs of the content types served
+var (
+	unaryCodecs = map[string]codec{
+		ContentTypeUnary:      jsonCodec{},
+		ContentTypeUnaryProto: protoCodec{},
+	}
+	streamCodecs = map[string]codec{
+		ContentTypeStream:      jsonCodec{},
+		ContentTypeStreamProto: protoCodec{},
+	}
+)
+
+// ProtocolVersionHeader is sent by Connect clients
+const ProtocolVersionHeader = "Connect-Protocol-Version"
+
+// maxMessageSize bounds the size of a single message
+const maxMessageSize = 16 << 20
+
+// flagEndStream marks the last envelope of a stream
+const flagEndStream = 0x02
+
+// Code is a Connect error code
+type Code string
+
+// Error codes
+const (
+	CodeCanceled           Code = "canceled"
+	CodeUnknown            Code = "unknown"
+	CodeInvalidArgument    Code = "invalid_argument"
+	CodeDeadlineExceeded   Code = "deadline_exceeded"
+	CodeNotFound           Code = "not_found"
+	CodeFailedPrecondition Code = "failed_precondition"
+	CodeUnimplemented      Code = "unimplemented"
+	CodeInternal           Code = "internal"
+	CodeUnavailable        Code = "unavailable"
+)
+
+// httpStatus returns the HTTP status unary errors with the code are sent with
+func (c Code) httpStatus() int {
+	switch c {
+	case CodeCanceled:
+		return 499
+	case CodeInvalidArgument, CodeFailedPrecondition:
+		return http.StatusBadRequest
+	case CodeDeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case CodeNotFound:
+		return http.StatusNotFound
+	case CodeUnimplemented:
+		return http.StatusNotImplemented
+	case CodeUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// codeFromHTTPStatus maps the status of a response without error body to a code
+func codeFromHTTPStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidArgument
+	case http.StatusNotFound:
+		return CodeUnimplemented
+	case http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusGatewayTimeout, http.StatusTooManyRequests:
+		return CodeUnavailable
+	default:
+		return CodeUnknown
+	}
+}
+
+// Error is an error with a Connect error code
+type Error struct {
+	Code    Code   `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// NewError creates an error with a code
+func NewError(code Code, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return string(e.Code)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// CodeOf returns the code of an error, unknown for errors without code
+func CodeOf(err error) Code {
+	var connectErr *Error
+	if errors.As(err, &connectErr) {
+		return connectErr.Code
+	}
+	return CodeUnknown
+}
+
+// asError converts an error returned by a procedure to a Connect error
+func asError(ctx context.Context, err error) *Error {
+	var connectErr *Error
+	if errors.As(err, &connectErr) {
+		return connectErr
+	}
+	if ctx.Err() != nil {
+		return &Error{Code: CodeCanceled, Message: ctx.Err().Error()}
+	}
+	return &Error{Code: CodeInternal, Message: err.Error()}
+}
+
+// endStreamMessage is the payload of the last envelope of a stream
+type endStreamMessage struct {
+	Error *Error `json:"error,omitempty"`
+}
+
+// NewUnaryHandler serves a unary procedure
+func NewUnaryHandler[Req, Res any](procedure func(ctx context.Context, req *Req) (*Res, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeUnaryError(w, NewError(CodeUnimplemented, "method %s is not supported", r.Method))
+			return
+		}
+		contentType := mediaType(r.Header.Get("Content-Type"))
+		codec, ok := unaryCodecs[contentType]
+		if !ok {
+			w.Header().Set("Accept-Post", ContentTypeUnary+", "+ContentTypeUnaryProto)
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+
+		req := new(Req)
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxMessageSize))
+		if err != nil {
+			writeUnaryError(w, NewError(CodeInvalidArgument, "failed to read request: %v", err))
+			return
+		}
+		// An empty body is the empty message, also in JSON
+		if _, isJSON := codec.(jsonCodec); !isJSON || len(bytes.TrimSpace(body)) > 0 {
+			if err := codec.Unmarshal(body, req); err != nil {
+				writeUnaryError(w, NewError(CodeInvalidArgument, "invalid request: %v", err))
+				return
+			}
+		}
+
+		res, err := procedure(r.Context(), req)
+		if err != nil {
+			writeUnaryError(w, asError(r.Context(), err))
+			return
+		}
+		payload, err := codec.Marshal(res)
+		if err != nil {
+			writeUnaryError(w, NewError(CodeInternal, "failed to marshal response: %v", err))
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		if _, err := w.Write(payload); err != nil {
+			logging.Debug("Failed to write Connect response", "path", r.URL.Path, "error", err)
+		}
+	})
+}
+
+// writeUnaryError writes the error of a unary procedure
+func writeUnaryError(w http.ResponseWriter, err *Error) {
+	w.Header().Set("Content-Type", ContentTypeUnary)
+	w.WriteHeader(err.Code.httpStatus())
+	json.NewEncoder(w).Encode(err)
+}
+
+// NewServerStreamHandler serves a server streaming procedure. The procedure
+// sends messages until it returns; the error it returns ends the stream.
+func NewServerStreamHandler[Req, Res any](procedure func(ctx context.Context, req *Req, send func(*Res) error) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		contentType := mediaType(r.Header.Get("Content-Type"))
+		codec, ok := streamCodecs[contentType]
+		if !ok {
+			w.Header().Set("Accept-Post", ContentTypeStream+", "+ContentTypeStreamProto)
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+			return
+		}
+		// Streams outlive the server write timeout
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+			logging.Debug("Failed to clear write deadline for Connect stream", "error", err)
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+
+		req := new(Req)
+		var procedureErr error
+		if _, payload, err := readEnvelope(r.Body); err != nil {
+			procedureErr = NewError(CodeInvalidArgument, "failed to read request: %v", err)
+		} else if err := codec.Unmarshal(payload, req); err != nil {
+			procedureErr = NewError(CodeInvalidArgument, "invalid request: %v", err)
+		} else {
+			procedureErr = procedure(r.Context(), req, func(res *Res) error {
+				payload, err := codec.Marshal(res)
+				if err != nil {
+					return err
+				}
+				if err := writeEnvelope(w, 0, payload); err != nil {
+					return err
+				}
+				flusher.Flush()
+				return nil
+			})
+		}
+
+		// The end of stream message is JSON whatever the codec
+		end := endStreamMessage{}
+		if procedureErr != nil {
+			end.Error = asError(r.Context(), procedureErr)
+		}
+		payload, _ := json.Marshal(end)
+		if err := writeEnvelope(w, flagEndStream, payload); err == nil {
+			flusher.Flush()
+		}
+	})
+}
+
+// readEnvelope reads an enveloped message
+func readEnvelope(r io.Reader) (byte, []byte, error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return 0, nil, err
+	}
+	size := binary.BigEndian.Uint32(prefix[1:])
+	if size > maxMessageSize {
+		return 0, nil, fmt.Errorf("message of %d bytes exceeds the limit of %d bytes", size, maxMessageSize)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return prefix[0], payload, nil
+}
+
+// writeEnvelope writes an enveloped message
+func writeEnvelope(w io.Writer, flags byte, payload []byte) error {
+	var prefix [5]byte
+	prefix[0] = flags
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(payload)))
+	if _, err := w.Write(prefix[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// mediaType returns a content type without parameters
+func mediaType(contentType string) string {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	return strings.TrimSpace(strings.ToLower(mediaType))
+}
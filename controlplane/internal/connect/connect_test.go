@@ -0,0 +1,243 @@
+package connect_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/connect"
+)
+
+type echoRequest struct {
+	Text  string `json:"text" protobuf:"1"`
+	Count int    `json:"count" protobuf:"2"`
+}
+
+type echoResponse struct {
+	Text string `json:"text" protobuf:"1"`
+}
+
+// event exercises the field types of the protobuf codec
+type event struct {
+	Name     string     `json:"name" protobuf:"1"`
+	Delta    int32      `json:"delta" protobuf:"2"`
+	Done     bool       `json:"done" protobuf:"3"`
+	At       time.Time  `json:"at" protobuf:"4"`
+	EndedAt  *time.Time `json:"endedAt" protobuf:"5"`
+	ExitCode *int32     `json:"exitCode" protobuf:"6"`
+	Children []event    `json:"children" protobuf:"7"`
+	Tags     []string   `json:"tags" protobuf:"8"`
+	Internal string     `json:"-"`
+}
+
+var _ = Describe("Connect", func() {
+	var (
+		ctx    context.Context
+		server *httptest.Server
+		client *connect.Client
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mux := http.NewServeMux()
+		mux.Handle("/test.v1.EchoService/Echo", connect.NewUnaryHandler(func(ctx context.Context, req *echoRequest) (*echoResponse, error) {
+			if req.Text == "" {
+				return nil, connect.NewError(connect.CodeInvalidArgument, "text is required")
+			}
+			return &echoResponse{Text: req.Text}, nil
+		}))
+		mux.Handle("/test.v1.EchoService/Repeat", connect.NewServerStreamHandler(func(ctx context.Context, req *echoRequest, send func(*echoResponse) error) error {
+			for i := 0; i < req.Count; i++ {
+				if err := send(&echoResponse{Text: req.Text}); err != nil {
+					return err
+				}
+			}
+			if req.Text == "fail" {
+				return connect.NewError(connect.CodeFailedPrecondition, "failed after %d messages", req.Count)
+			}
+			return nil
+		}))
+		server = httptest.NewServer(mux)
+		client = connect.NewClient(server.URL, nil)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("unary procedures", func() {
+		It("should return the response", func() {
+			res, err := connect.CallUnary[echoRequest, echoResponse](ctx, client, "/test.v1.EchoService/Echo", &echoRequest{Text: "hello"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Text).To(Equal("hello"))
+		})
+
+		It("should return the error with its code", func() {
+			_, err := connect.CallUnary[echoRequest, echoResponse](ctx, client, "/test.v1.EchoService/Echo", &echoRequest{})
+			Expect(connect.CodeOf(err)).To(Equal(connect.CodeInvalidArgument))
+			Expect(err.Error()).To(ContainSubstring("text is required"))
+		})
+
+		It("should be callable with a plain JSON POST", func() {
+			resp, err := http.Post(server.URL+"/test.v1.EchoService/Echo", "application/json", strings.NewReader(`{"text":"curl"}`))
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			body, _ := io.ReadAll(resp.Body)
+			Expect(body).To(MatchJSON(`{"text":"curl"}`))
+		})
+
+		It("should send errors with the HTTP status of their code", func() {
+			resp, err := http.Post(server.URL+"/test.v1.EchoService/Echo", "application/json", strings.NewReader(`{}`))
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+			body, _ := io.ReadAll(resp.Body)
+			Expect(body).To(MatchJSON(`{"code":"invalid_argument","message":"text is required"}`))
+		})
+
+		It("should be callable with the protobuf codec", func() {
+			var req []byte
+			req = protowire.AppendTag(req, 1, protowire.BytesType)
+			req = protowire.AppendString(req, "binary")
+			resp, err := http.Post(server.URL+"/test.v1.EchoService/Echo", "application/proto", bytes.NewReader(req))
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(resp.Header.Get("Content-Type")).To(Equal("application/proto"))
+			body, _ := io.ReadAll(resp.Body)
+			Expect(body).To(Equal(req))
+		})
+
+		It("should round trip every field type with the protobuf codec", func() {
+			at := time.Date(2025, 10, 15, 12, 0, 0, 500, time.UTC)
+			exitCode := int32(0)
+			sent := &event{
+				Name: "deploy", Delta: -3, Done: true, At: at, EndedAt: &at, ExitCode: &exitCode,
+				Children: []event{{Name: "child"}, {Delta: 1}},
+				Tags:     []string{"a", ""},
+			}
+			var stored, received *event
+			mux := http.NewServeMux()
+			mux.Handle("/test.v1.EventService/Put", connect.NewUnaryHandler(func(ctx context.Context, req *event) (*event, error) {
+				stored = req
+				return req, nil
+			}))
+			mux.Handle("/test.v1.EventService/Get", connect.NewUnaryHandler(func(ctx context.Context, req *echoRequest) (*event, error) {
+				return stored, nil
+			}))
+			mux.Handle("/test.v1.EventService/Echo", connect.NewUnaryHandler(func(ctx context.Context, req *event) (*event, error) {
+				received = req
+				return req, nil
+			}))
+			eventServer := httptest.NewServer(mux)
+			defer eventServer.Close()
+			post := func(procedure, contentType string, body []byte) []byte {
+				resp, err := http.Post(eventServer.URL+"/test.v1.EventService/"+procedure, contentType, bytes.NewReader(body))
+				Expect(err).NotTo(HaveOccurred())
+				defer resp.Body.Close()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				payload, err := io.ReadAll(resp.Body)
+				Expect(err).NotTo(HaveOccurred())
+				return payload
+			}
+
+			payload, err := json.Marshal(sent)
+			Expect(err).NotTo(HaveOccurred())
+			post("Put", "application/json", payload)
+			encoded := post("Get", "application/proto", nil)
+			Expect(post("Echo", "application/proto", encoded)).To(Equal(encoded))
+
+			Expect(received.Name).To(Equal("deploy"))
+			Expect(received.Delta).To(Equal(int32(-3)))
+			Expect(received.Done).To(BeTrue())
+			Expect(received.At.Equal(at)).To(BeTrue())
+			Expect(received.EndedAt.Equal(at)).To(BeTrue())
+			Expect(received.ExitCode).To(Equal(&exitCode), "optional fields keep their zero value")
+			Expect(received.Children).To(HaveLen(2))
+			Expect(received.Children[1].Delta).To(Equal(int32(1)))
+			Expect(received.Tags).To(Equal([]string{"a", ""}))
+
+			post("Echo", "application/proto", nil)
+			Expect(*received).To(Equal(event{}), "an empty body is the empty message")
+		})
+
+		It("should reject other content types", func() {
+			resp, err := http.Post(server.URL+"/test.v1.EchoService/Echo", "application/xml", strings.NewReader(""))
+			Expect(err).NotTo(HaveOccurred())
+			resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusUnsupportedMediaType))
+			Expect(resp.Header.Get("Accept-Post")).To(ContainSubstring("application/proto"))
+		})
+
+		It("should report unknown procedures as unimplemented", func() {
+			_, err := connect.CallUnary[echoRequest, echoResponse](ctx, client, "/test.v1.EchoService/Missing", &echoRequest{})
+			Expect(connect.CodeOf(err)).To(Equal(connect.CodeUnimplemented))
+		})
+	})
+
+	Describe("server streaming procedures", func() {
+		It("should receive every message and end cleanly", func() {
+			stream, err := connect.CallServerStream[echoRequest, echoResponse](ctx, client, "/test.v1.EchoService/Repeat", &echoRequest{Text: "hi", Count: 3})
+			Expect(err).NotTo(HaveOccurred())
+			defer stream.Close()
+
+			for i := 0; i < 3; i++ {
+				res, err := stream.Receive()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(res.Text).To(Equal("hi"))
+			}
+			_, err = stream.Receive()
+			Expect(err).To(Equal(io.EOF))
+		})
+
+		It("should stream with the protobuf codec", func() {
+			var req []byte
+			req = protowire.AppendTag(req, 1, protowire.BytesType)
+			req = protowire.AppendString(req, "hi")
+			req = protowire.AppendTag(req, 2, protowire.VarintType)
+			req = protowire.AppendVarint(req, 2)
+			envelope := append([]byte{0, 0, 0, 0, byte(len(req))}, req...)
+
+			resp, err := http.Post(server.URL+"/test.v1.EchoService/Repeat", "application/connect+proto", bytes.NewReader(envelope))
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.Header.Get("Content-Type")).To(Equal("application/connect+proto"))
+			body, err := io.ReadAll(resp.Body)
+			Expect(err).NotTo(HaveOccurred())
+
+			var message []byte
+			message = protowire.AppendTag(message, 1, protowire.BytesType)
+			message = protowire.AppendString(message, "hi")
+			for i := 0; i < 2; i++ {
+				Expect(body[0]).To(Equal(byte(0)))
+				Expect(body[5 : 5+len(message)]).To(Equal(message))
+				body = body[5+len(message):]
+			}
+			// The end of stream message is JSON
+			Expect(body[0]).To(Equal(byte(0x02)))
+			Expect(body[5:]).To(MatchJSON(`{}`))
+		})
+
+		It("should end with the error of the procedure", func() {
+			stream, err := connect.CallServerStream[echoRequest, echoResponse](ctx, client, "/test.v1.EchoService/Repeat", &echoRequest{Text: "fail", Count: 1})
+			Expect(err).NotTo(HaveOccurred())
+			defer stream.Close()
+
+			_, err = stream.Receive()
+			Expect(err).NotTo(HaveOccurred())
+			_, err = stream.Receive()
+			Expect(connect.CodeOf(err)).To(Equal(connect.CodeFailedPrecondition))
+			Expect(err.Error()).To(ContainSubstring("failed after 1 messages"))
+		})
+	})
+})
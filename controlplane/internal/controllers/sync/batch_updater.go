@@ -6,8 +6,6 @@ import (
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
 
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
@@ -17,18 +15,20 @@ import (
 type StorageService = storage.Service
 type StorageTask = storage.Task
 
-// TaskUpdater interface for updating task status
+// TaskUpdater interface for updating task status. previousStatus is the
+// status of the task before the sync stored the new one.
 type TaskUpdater interface {
-	UpdateTaskStatus(ctx context.Context, taskARN string, pod *corev1.Pod) error
+	UpdateTaskStatus(ctx context.Context, taskARN, previousStatus string, pod *corev1.Pod) error
 }
 
 // BatchUpdater efficiently batches updates to the storage layer
 type BatchUpdater struct {
 	storage      storage.Storage
 	taskUpdater  TaskUpdater
-	kubeClient   kubernetes.Interface
 	serviceCache map[string]*StorageService // key is service ARN
 	taskCache    map[string]*StorageTask    // key is task ARN
+	podCache     map[string]*corev1.Pod     // key is task ARN
+	statusCache  map[string]string          // key is task ARN, status before the queued updates
 	mu           stdsync.Mutex
 	ticker       *time.Ticker
 	stopCh       chan struct{}
@@ -43,6 +43,8 @@ func NewBatchUpdater(storage storage.Storage, batchSize int, maxDelay time.Durat
 		storage:      storage,
 		serviceCache: make(map[string]*StorageService),
 		taskCache:    make(map[string]*StorageTask),
+		podCache:     make(map[string]*corev1.Pod),
+		statusCache:  make(map[string]string),
 		batchSize:    batchSize,
 		maxDelay:     maxDelay,
 		stopCh:       make(chan struct{}),
@@ -50,10 +52,9 @@ func NewBatchUpdater(storage storage.Storage, batchSize int, maxDelay time.Durat
 	}
 }
 
-// SetTaskUpdater sets the task updater and kubernetes client for the batch updater
-func (b *BatchUpdater) SetTaskUpdater(taskUpdater TaskUpdater, kubeClient kubernetes.Interface) {
+// SetTaskUpdater sets the task updater for the batch updater
+func (b *BatchUpdater) SetTaskUpdater(taskUpdater TaskUpdater) {
 	b.taskUpdater = taskUpdater
-	b.kubeClient = kubeClient
 }
 
 // Start begins the batch update process
@@ -105,8 +106,10 @@ func (b *BatchUpdater) AddServiceUpdate(service *StorageService) {
 	}
 }
 
-// AddTaskUpdate adds a task update to the batch
-func (b *BatchUpdater) AddTaskUpdate(task *StorageTask) {
+// AddTaskUpdate adds a task update to the batch. The pod the task was mapped
+// from, if it still exists, is handed to the task updater once the task is
+// stored, along with previousStatus, the stored status of the task before.
+func (b *BatchUpdater) AddTaskUpdate(task *StorageTask, previousStatus string, pod *corev1.Pod) {
 	if task == nil || task.ARN == "" {
 		return
 	}
@@ -115,6 +118,15 @@ func (b *BatchUpdater) AddTaskUpdate(task *StorageTask) {
 	defer b.mu.Unlock()
 
 	b.taskCache[task.ARN] = task
+	// Coalesced updates are one transition from the first previous status
+	if _, queued := b.statusCache[task.ARN]; !queued {
+		b.statusCache[task.ARN] = previousStatus
+	}
+	if pod != nil {
+		b.podCache[task.ARN] = pod
+	} else {
+		delete(b.podCache, task.ARN)
+	}
 
 	// Trigger immediate flush if batch size reached
 	if len(b.taskCache) >= b.batchSize {
@@ -142,6 +154,10 @@ func (b *BatchUpdater) flush(ctx context.Context) {
 		tasks = append(tasks, task)
 	}
 	b.taskCache = make(map[string]*StorageTask)
+	pods := b.podCache
+	b.podCache = make(map[string]*corev1.Pod)
+	statuses := b.statusCache
+	b.statusCache = make(map[string]string)
 
 	b.mu.Unlock()
 
@@ -159,7 +175,7 @@ func (b *BatchUpdater) flush(ctx context.Context) {
 	if len(tasks) > 0 {
 		logging.Info("Flushing task updates", "count", len(tasks))
 		for _, task := range tasks {
-			if err := b.updateTask(ctx, task); err != nil {
+			if err := b.updateTask(ctx, task, statuses[task.ARN], pods[task.ARN]); err != nil {
 				logging.Error("Failed to update task", "taskARN", task.ARN, "error", err)
 			}
 		}
@@ -178,35 +194,42 @@ func (b *BatchUpdater) updateService(ctx context.Context, service *StorageServic
 		return b.storage.ServiceStore().Create(ctx, service)
 	}
 
-	// Merge with existing service to preserve fields we don't sync
-	mergedService := b.mergeServices(existingService, service)
-	logging.Info("Updating existing service with new state",
+	// Merge with existing service to preserve fields we don't sync, merging
+	// again into the stored service when it was modified meanwhile
+	mergedService, err := storage.UpdateService(ctx, b.storage.ServiceStore(), existingService, func(existing *StorageService) error {
+		b.mergeService(existing, service)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	logging.Info("Updated existing service with new state",
 		"serviceName", service.ServiceName, "runningCount", mergedService.RunningCount, "pendingCount", mergedService.PendingCount)
-	return b.storage.ServiceStore().Update(ctx, mergedService)
+	return nil
 }
 
-// mergeServices merges the synced service data with existing service data
-func (b *BatchUpdater) mergeServices(existing, updated *StorageService) *StorageService {
-	// Start with existing service
-	merged := *existing
-
-	// Update fields that are synced from Kubernetes
-	merged.Status = updated.Status
-	merged.DesiredCount = updated.DesiredCount
-	merged.RunningCount = updated.RunningCount
-	merged.PendingCount = updated.PendingCount
-	merged.UpdatedAt = updated.UpdatedAt
-
-	// Update deployment configuration if provided
-	if updated.DeploymentConfiguration != "" {
-		merged.DeploymentConfiguration = updated.DeploymentConfiguration
+// mergeService merges the synced service data into the existing service
+func (b *BatchUpdater) mergeService(existing, updated *StorageService) {
+	// Update fields that are synced from Kubernetes. The desired count is
+	// set through ECS, and DEGRADED is only cleared by the drift detector.
+	if existing.Status != "DEGRADED" || updated.Status == "DRAINING" || updated.Status == "INACTIVE" {
+		existing.Status = updated.Status
+	}
+	if updated.Status == "INACTIVE" {
+		existing.DesiredCount = updated.DesiredCount
 	}
+	existing.RunningCount = updated.RunningCount
+	existing.PendingCount = updated.PendingCount
+	existing.UpdatedAt = updated.UpdatedAt
 
-	return &merged
+	// Update deployment progress if provided
+	if updated.Deployments != "" {
+		existing.Deployments = updated.Deployments
+	}
 }
 
 // updateTask updates a single task in storage
-func (b *BatchUpdater) updateTask(ctx context.Context, task *StorageTask) error {
+func (b *BatchUpdater) updateTask(ctx context.Context, task *StorageTask, previousStatus string, pod *corev1.Pod) error {
 	logging.Info("Updating task", "taskARN", task.ARN, "clusterARN", task.ClusterARN)
 
 	// Use CreateOrUpdate to avoid duplicate key errors
@@ -217,24 +240,13 @@ func (b *BatchUpdater) updateTask(ctx context.Context, task *StorageTask) error
 
 	logging.Debug("Successfully created or updated task", "taskARN", task.ARN, "lastStatus", task.LastStatus)
 
-	// If TaskUpdater is available and task is RUNNING, get the pod and call UpdateTaskStatus
-	// This will trigger Service Discovery registration
-	if b.taskUpdater != nil && b.kubeClient != nil {
-		if task.LastStatus == "RUNNING" && task.PodName != "" && task.Namespace != "" {
-			// Get the pod from Kubernetes
-			pod, err := b.kubeClient.CoreV1().Pods(task.Namespace).Get(ctx, task.PodName, metav1.GetOptions{})
-			if err == nil && pod != nil {
-				// Call UpdateTaskStatus which will trigger Service Discovery registration
-				logging.Info("Triggering UpdateTaskStatus for Service Discovery registration",
-					"task", task.ARN,
-					"pod", task.PodName,
-					"status", task.LastStatus)
-				if err := b.taskUpdater.UpdateTaskStatus(ctx, task.ARN, pod); err != nil {
-					logging.Warn("Failed to update task status via TaskUpdater",
-						"task", task.ARN,
-						"error", err)
-				}
-			}
+	// The task updater applies the side effects of status changes, such as
+	// Service Discovery registration, port release and log collection
+	if b.taskUpdater != nil && pod != nil {
+		if err := b.taskUpdater.UpdateTaskStatus(ctx, task.ARN, previousStatus, pod); err != nil {
+			logging.Warn("Failed to update task status via TaskUpdater",
+				"task", task.ARN,
+				"error", err)
 		}
 	}
 
@@ -21,6 +21,8 @@ import (
 
 	"github.com/nandemo-ya/kecs/controlplane/internal/config"
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/notifications"
+	"github.com/nandemo-ya/kecs/controlplane/internal/serviceevents"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
 )
 
@@ -47,6 +49,15 @@ type SyncController struct {
 	// Batch updater for efficient storage updates
 	batchUpdater *BatchUpdater
 
+	// Service event recorder (optional)
+	serviceEvents *serviceevents.Recorder
+
+	// Notifier of completed deployments and crashlooping tasks (optional)
+	notifier *notifications.Notifier
+
+	// Drift handler notified about deleted resources of active services (optional)
+	driftHandler DriftHandler
+
 	// Configuration
 	workers      int
 	resyncPeriod time.Duration
@@ -122,11 +133,27 @@ func NewSyncController(
 	return controller
 }
 
+// SetServiceEventRecorder sets the recorder used to publish service events
+func (c *SyncController) SetServiceEventRecorder(recorder *serviceevents.Recorder) {
+	c.serviceEvents = recorder
+}
+
+// SetNotifier sets the notifier of completed deployments and crashlooping tasks
+func (c *SyncController) SetNotifier(notifier *notifications.Notifier) {
+	c.notifier = notifier
+}
+
+// SetDriftHandler sets the handler that restores services whose Deployment
+// was deleted outside of KECS
+func (c *SyncController) SetDriftHandler(handler DriftHandler) {
+	c.driftHandler = handler
+}
+
 // SetTaskUpdater sets the task updater for the controller
-func (c *SyncController) SetTaskUpdater(taskUpdater TaskUpdater, kubeClient k8sclient.Interface) {
+func (c *SyncController) SetTaskUpdater(taskUpdater TaskUpdater) {
 	c.taskUpdater = taskUpdater
 	if c.batchUpdater != nil && taskUpdater != nil {
-		c.batchUpdater.SetTaskUpdater(taskUpdater, kubeClient)
+		c.batchUpdater.SetTaskUpdater(taskUpdater)
 	}
 }
 
@@ -389,9 +416,13 @@ func (c *SyncController) handlePodUpdate(oldObj, newObj interface{}) {
 		return
 	}
 
-	// Only sync if status changed
-	if oldPod.Status.Phase != newPod.Status.Phase ||
-		len(oldPod.Status.ContainerStatuses) != len(newPod.Status.ContainerStatuses) {
+	if c.notifier != nil {
+		c.notifyCrashLoops(oldPod, newPod)
+	}
+
+	// Only sync if status changed. Periodic resyncs re-deliver unchanged
+	// pods, they are synced as well to repair updates that were lost.
+	if oldPod.ResourceVersion == newPod.ResourceVersion || hasPodStatusChanged(oldPod, newPod) {
 		logging.Debug("Pod status changed", "name", newPod.Name, "oldPhase", oldPod.Status.Phase, "newPhase", newPod.Status.Phase)
 		key, err := cache.MetaNamespaceKeyFunc(newPod)
 		if err != nil {
@@ -502,3 +533,60 @@ func hasDeploymentConditionChanged(oldDep, newDep *appsv1.Deployment) bool {
 
 	return false
 }
+
+// hasPodStatusChanged checks if a pod changed in a way that is reflected in its
+// task: phase, IP, readiness, the state and health of a container, or the
+// state of an ephemeral debug container. A container that exited enters the
+// terminated state or restarts, so exit codes are covered as well.
+func hasPodStatusChanged(oldPod, newPod *corev1.Pod) bool {
+	if oldPod.Status.Phase != newPod.Status.Phase ||
+		oldPod.Status.PodIP != newPod.Status.PodIP ||
+		!oldPod.DeletionTimestamp.Equal(newPod.DeletionTimestamp) ||
+		len(oldPod.Status.ContainerStatuses) != len(newPod.Status.ContainerStatuses) ||
+		len(oldPod.Status.EphemeralContainerStatuses) != len(newPod.Status.EphemeralContainerStatuses) {
+		return true
+	}
+
+	if podReady(oldPod) != podReady(newPod) {
+		return true
+	}
+
+	for i, newStatus := range newPod.Status.ContainerStatuses {
+		oldStatus := oldPod.Status.ContainerStatuses[i]
+		if oldStatus.Ready != newStatus.Ready ||
+			oldStatus.RestartCount != newStatus.RestartCount ||
+			containerState(oldStatus.State) != containerState(newStatus.State) {
+			return true
+		}
+	}
+	for i, newStatus := range newPod.Status.EphemeralContainerStatuses {
+		if containerState(oldPod.Status.EphemeralContainerStatuses[i].State) != containerState(newStatus.State) {
+			return true
+		}
+	}
+	return false
+}
+
+// podReady returns the status of the Ready condition of a pod
+func podReady(pod *corev1.Pod) corev1.ConditionStatus {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status
+		}
+	}
+	return corev1.ConditionUnknown
+}
+
+// containerState names the state a container is in
+func containerState(state corev1.ContainerState) string {
+	switch {
+	case state.Running != nil:
+		return "running"
+	case state.Terminated != nil:
+		return "terminated"
+	case state.Waiting != nil:
+		return "waiting:" + state.Waiting.Reason
+	default:
+		return ""
+	}
+}
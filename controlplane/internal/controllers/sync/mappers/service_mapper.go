@@ -1,15 +1,14 @@
 package mappers
 
 import (
-	"encoding/json"
 	"strings"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 
-	"github.com/nandemo-ya/kecs/controlplane/internal/common"
-	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
+	"github.com/nandemo-ya/kecs/controlplane/internal/servicedeployments"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
 )
 
@@ -57,8 +56,14 @@ func (m *ServiceStateMapper) MapDeploymentToServiceStatus(deployment *appsv1.Dep
 		replicas = *deployment.Spec.Replicas
 	}
 
+	// A service scaled to zero stays ACTIVE, ECS only drains deleted services
 	if replicas == 0 {
-		return "DRAINING"
+		return "ACTIVE"
+	}
+
+	// The Deployment controller has not picked up the latest spec yet
+	if deployment.Status.ObservedGeneration < deployment.Generation {
+		return "UPDATING"
 	}
 
 	readyReplicas := deployment.Status.ReadyReplicas
@@ -139,22 +144,26 @@ func (m *ServiceStateMapper) MapDeploymentToService(deployment *appsv1.Deploymen
 	serviceName := m.ExtractServiceNameFromDeployment(deployment.Name)
 	clusterName, region := m.ExtractClusterInfoFromNamespace(deployment.Namespace)
 
-	// Start with existing service or create new one
+	// Start with existing service or create new one. The desired count of
+	// a known service is the one set through ECS; replicas that differ are
+	// drift, left to the drift detector.
+	desired, running, pending := m.MapDeploymentToServiceCounts(deployment)
 	service := existingService
 	if service == nil {
 		service = &storage.Service{
-			ServiceName: serviceName,
-			ClusterARN:  m.generateClusterARN(region, clusterName),
-			Region:      region,
-			CreatedAt:   deployment.CreationTimestamp.Time,
-			LaunchType:  "FARGATE", // Default launch type
+			ServiceName:  serviceName,
+			ClusterARN:   m.generateClusterARN(region, clusterName),
+			Region:       region,
+			CreatedAt:    deployment.CreationTimestamp.Time,
+			LaunchType:   "FARGATE", // Default launch type
+			DesiredCount: int(desired),
 		}
 	}
 
 	// Update status and counts
+	wasFailed := service.Status == "FAILED"
+	wasDegraded := service.Status == "DEGRADED"
 	service.Status = m.MapDeploymentToServiceStatus(deployment)
-	desired, running, pending := m.MapDeploymentToServiceCounts(deployment)
-	service.DesiredCount = int(desired)
 	service.RunningCount = int(running)
 	service.PendingCount = int(pending)
 
@@ -162,42 +171,53 @@ func (m *ServiceStateMapper) MapDeploymentToService(deployment *appsv1.Deploymen
 	service.UpdatedAt = time.Now()
 
 	// Extract task definition from deployment annotations
+	taskDefinitionARN := deployment.Annotations["kecs.dev/task-definition"]
 	if taskDef, exists := deployment.Annotations["ecs.amazonaws.com/task-definition"]; exists {
-		service.TaskDefinitionARN = taskDef
-	}
-
-	// Create deployment info to store in DeploymentConfiguration field
-	deploymentInfo := generated.Deployment{
-		Id:              &deployment.Name,
-		Status:          &service.Status,
-		TaskDefinition:  &service.TaskDefinitionARN,
-		DesiredCount:    int32Ptr(int32(service.DesiredCount)),
-		RunningCount:    int32Ptr(int32(service.RunningCount)),
-		PendingCount:    int32Ptr(int32(service.PendingCount)),
-		CreatedAt:       unixTimePtr(deployment.CreationTimestamp.Time),
-		UpdatedAt:       unixTimePtr(time.Now()),
-		LaunchType:      (*generated.LaunchType)(stringPtr(service.LaunchType)),
-		PlatformVersion: stringPtr(service.PlatformVersion),
-	}
-
-	// Serialize deployment info to JSON for storage
-	deployments := []generated.Deployment{deploymentInfo}
-	if _, err := json.Marshal(deployments); err == nil {
-		// Store deployments in a field - we could use the DeploymentConfiguration field
-		// or add metadata to track this information
-		// For now, we'll store it in DeploymentConfiguration as it's JSON
-		deploymentConfig := map[string]interface{}{
-			"deployments": deployments,
-		}
-		if configJSON, err := json.Marshal(deploymentConfig); err == nil {
-			service.DeploymentConfiguration = string(configJSON)
-		}
+		taskDefinitionARN = taskDef
+	}
+	if taskDefinitionARN != "" {
+		service.TaskDefinitionARN = taskDefinitionARN
+	}
+
+	// The primary deployment runs the pods of the latest ReplicaSet, which
+	// are only known once the Deployment controller observed the spec
+	if reason := m.rolloutFailure(deployment); reason != "" {
+		servicedeployments.Fail(service, reason, service.UpdatedAt)
+	} else if deployment.Status.ObservedGeneration >= deployment.Generation {
+		running := min(deployment.Status.UpdatedReplicas, deployment.Status.ReadyReplicas)
+		pending := deployment.Status.UpdatedReplicas - running
+		servicedeployments.Progress(service, taskDefinitionARN, int(running), int(pending), service.UpdatedAt)
+	}
+
+	// A service whose deployment failed, e.g. after the provisioning timeout,
+	// stays FAILED until a new deployment starts
+	if wasFailed && service.Status != "DRAINING" && servicedeployments.Failed(service) {
+		service.Status = "FAILED"
+	}
+
+	// A service whose resources drifted stays DEGRADED until the drift
+	// detector finds them restored
+	if wasDegraded && service.Status != "DRAINING" {
+		service.Status = "DEGRADED"
 	}
 
 	return service
 }
 
-// generateClusterARN generates an ECS cluster ARN
+// rolloutFailure returns why the rollout of a deployment failed, or ""
+func (m *ServiceStateMapper) rolloutFailure(deployment *appsv1.Deployment) string {
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Status == corev1.ConditionFalse &&
+			strings.Contains(condition.Reason, "ProgressDeadlineExceeded") ||
+			condition.Type == appsv1.DeploymentReplicaFailure && condition.Status == corev1.ConditionTrue {
+			if condition.Message != "" {
+				return condition.Message
+			}
+			return condition.Reason
+		}
+	}
+	return ""
+}
 
 // Helper functions for pointer conversions
 func stringPtr(s string) *string {
@@ -207,22 +227,14 @@ func stringPtr(s string) *string {
 	return &s
 }
 
-func int32Ptr(i int32) *int32 {
-	return &i
-}
-
 func timePtr(t time.Time) *time.Time {
 	return &t
 }
 
-func unixTimePtr(t time.Time) *common.UnixTime {
-	return &common.UnixTime{Time: t}
-}
-
 // generateClusterARN generates an ECS cluster ARN
 func (m *ServiceStateMapper) generateClusterARN(region, clusterName string) string {
 	if region == "" {
 		region = m.region
 	}
-	return "arn:aws:ecs:" + region + ":" + m.accountID + ":cluster/" + clusterName
+	return awsarn.Build("ecs", region, m.accountID, "cluster/"+clusterName)
 }
@@ -8,11 +8,28 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/startupmetrics"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/taskattachments"
 	"github.com/nandemo-ya/kecs/controlplane/internal/utils"
 )
 
+// Pod annotations carrying the ECS stop code and reason of a task that KECS
+// stopped on purpose, e.g. a simulated task retirement
+const (
+	AnnotationStopCode      = "kecs.dev/stop-code"
+	AnnotationStoppedReason = "kecs.dev/stopped-reason"
+)
+
+// Stop code and reason of a task stopped because an essential container exited
+const (
+	StopCodeEssentialContainerExited      = "EssentialContainerExited"
+	StoppedReasonEssentialContainerExited = "Essential container in task exited"
+)
+
 // TaskStateMapper maps Kubernetes pod state to ECS task state
 type TaskStateMapper struct {
 	accountID string
@@ -39,8 +56,13 @@ func (m *TaskStateMapper) MapPodPhaseToTaskStatus(pod *corev1.Pod) (desiredStatu
 
 	switch pod.Status.Phase {
 	case corev1.PodPending:
-		// Check container statuses for more detail
+		// Check container statuses for more detail. A scheduled pod
+		// without containers yet is past PROVISIONING, like a task
+		// placed on an instance
 		if len(pod.Status.ContainerStatuses) == 0 {
+			if isPodScheduled(pod) {
+				return "RUNNING", "PENDING"
+			}
 			return "RUNNING", "PROVISIONING"
 		}
 		// Check if any containers are being created
@@ -52,6 +74,11 @@ func (m *TaskStateMapper) MapPodPhaseToTaskStatus(pod *corev1.Pod) (desiredStatu
 		return "RUNNING", "PROVISIONING"
 
 	case corev1.PodRunning:
+		// An essential container exited, the others are being stopped
+		if converters.ExitedEssentialContainer(pod) != nil {
+			return "STOPPED", "STOPPING"
+		}
+
 		// Check if all containers are ready
 		allReady := true
 		anyRunning := false
@@ -140,30 +167,33 @@ func (m *TaskStateMapper) MapPodToTask(pod *corev1.Pod) *storage.Task {
 	}
 
 	// Create task object
+	timeline := startupmetrics.PodTimeline(pod)
 	task := &storage.Task{
-		ID:                taskID,
-		ARN:               taskARN,
-		ClusterARN:        clusterARN,
-		TaskDefinitionARN: taskDefARN,
-		DesiredStatus:     desiredStatus,
-		LastStatus:        lastStatus,
-		LaunchType:        "FARGATE",
-		CreatedAt:         pod.CreationTimestamp.Time,
-		Connectivity:      "CONNECTED",
-		HealthStatus:      m.extractHealthStatus(pod),
-		Containers:        m.serializeContainers(m.mapPodContainers(pod)),
-		PullStartedAt:     m.getPullStartedTime(pod),
-		PullStoppedAt:     m.getPullStoppedTime(pod),
-		StartedAt:         m.getPodStartTime(pod),
-		StoppedAt:         m.getPodStopTime(pod),
-		StoppingAt:        m.getPodStoppingTime(pod),
-		StoppedReason:     m.getPodStopReason(pod),
-		StartedBy:         startedBy,
-		Version:           1,
-		PodName:           pod.Name,
-		Namespace:         pod.Namespace,
-		AccountID:         m.accountID,
-		Region:            m.region,
+		ID:                 taskID,
+		ARN:                taskARN,
+		ClusterARN:         clusterARN,
+		TaskDefinitionARN:  taskDefARN,
+		DesiredStatus:      desiredStatus,
+		LastStatus:         lastStatus,
+		LaunchType:         "FARGATE",
+		CreatedAt:          pod.CreationTimestamp.Time,
+		Connectivity:       "CONNECTED",
+		HealthStatus:       converters.TaskHealthStatus(pod),
+		Containers:         m.serializeContainers(m.mapPodContainers(pod)),
+		PullStartedAt:      timeline.PullStartedAt,
+		PullStoppedAt:      timeline.PullStoppedAt,
+		StartedAt:          timeline.StartedAt,
+		StoppedAt:          m.getPodStopTime(pod),
+		ExecutionStoppedAt: m.getPodStopTime(pod),
+		StoppingAt:         m.getPodStoppingTime(pod),
+		StopCode:           m.getPodStopCode(pod),
+		StoppedReason:      m.getPodStopReason(pod),
+		StartedBy:          startedBy,
+		Version:            1,
+		PodName:            pod.Name,
+		Namespace:          pod.Namespace,
+		AccountID:          m.accountID,
+		Region:             m.region,
 	}
 
 	// Set connectivity time if pod is running
@@ -192,15 +222,10 @@ func (m *TaskStateMapper) MapPodToTask(pod *corev1.Pod) *storage.Task {
 func (m *TaskStateMapper) mapPodContainers(pod *corev1.Pod) []generated.Container {
 	var containers []generated.Container
 
-	for _, container := range pod.Spec.Containers {
-		// Find corresponding container status
-		var status *corev1.ContainerStatus
-		for j := range pod.Status.ContainerStatuses {
-			if pod.Status.ContainerStatuses[j].Name == container.Name {
-				status = &pod.Status.ContainerStatuses[j]
-				break
-			}
-		}
+	// Containers with a restart policy may run as native sidecars
+	podContainers, statuses := converters.TaskContainers(pod)
+	for i, container := range podContainers {
+		status := statuses[i]
 
 		containerARN := fmt.Sprintf("%s/container/%s", pod.Labels["ecs.amazonaws.com/task-arn"], container.Name)
 		taskARN := pod.Labels["ecs.amazonaws.com/task-arn"]
@@ -211,6 +236,7 @@ func (m *TaskStateMapper) mapPodContainers(pod *corev1.Pod) []generated.Containe
 			ContainerArn:      &containerARN,
 			TaskArn:           &taskARN,
 			NetworkInterfaces: m.getNetworkInterfaces(pod),
+			NetworkBindings:   m.getNetworkBindings(pod, container.Name),
 		}
 
 		// Extract container state details
@@ -219,7 +245,12 @@ func (m *TaskStateMapper) mapPodContainers(pod *corev1.Pod) []generated.Containe
 			taskContainer.Reason = stringPtr(m.getContainerReason(status))
 			taskContainer.RuntimeId = &status.ContainerID
 			taskContainer.ImageDigest = &status.ImageID
+			if status.RestartCount > 0 {
+				taskContainer.RestartCount = &status.RestartCount
+			}
 		}
+		healthStatus := generated.HealthStatus(converters.ContainerHealthStatus(container, status))
+		taskContainer.HealthStatus = &healthStatus
 
 		// Extract resource limits
 		if container.Resources.Limits != nil {
@@ -236,6 +267,39 @@ func (m *TaskStateMapper) mapPodContainers(pod *corev1.Pod) []generated.Containe
 		containers = append(containers, taskContainer)
 	}
 
+	// Ephemeral debug containers follow the containers of the task definition
+	for _, container := range pod.Spec.EphemeralContainers {
+		var status *corev1.ContainerStatus
+		for j := range pod.Status.EphemeralContainerStatuses {
+			if pod.Status.EphemeralContainerStatuses[j].Name == container.Name {
+				// Ephemeral containers have no probes, running ones count as ready
+				ephemeral := pod.Status.EphemeralContainerStatuses[j]
+				ephemeral.Ready = ephemeral.State.Running != nil
+				status = &ephemeral
+				break
+			}
+		}
+
+		containerARN := fmt.Sprintf("%s/container/%s", pod.Labels["ecs.amazonaws.com/task-arn"], container.Name)
+		taskARN := pod.Labels["ecs.amazonaws.com/task-arn"]
+		taskContainer := generated.Container{
+			Name:              &container.Name,
+			Image:             &container.Image,
+			LastStatus:        stringPtr(m.mapContainerStatus(status)),
+			ContainerArn:      &containerARN,
+			TaskArn:           &taskARN,
+			NetworkInterfaces: m.getNetworkInterfaces(pod),
+		}
+		if status != nil {
+			taskContainer.ExitCode = m.getExitCodeInt32(status)
+			taskContainer.Reason = stringPtr(m.getContainerReason(status))
+			taskContainer.RuntimeId = &status.ContainerID
+			taskContainer.ImageDigest = &status.ImageID
+		}
+
+		containers = append(containers, taskContainer)
+	}
+
 	return containers
 }
 
@@ -305,7 +369,7 @@ func (m *TaskStateMapper) generateTaskARN(pod *corev1.Pod) string {
 		taskID = utils.GenerateTaskIDFromString(pod.Name)
 	}
 
-	return fmt.Sprintf("arn:aws:ecs:%s:%s:task/%s/%s", region, m.accountID, clusterName, taskID)
+	return awsarn.Build("ecs", region, m.accountID, fmt.Sprintf("task/%s/%s", clusterName, taskID))
 }
 
 func (m *TaskStateMapper) getClusterARNFromNamespace(namespace string) string {
@@ -314,32 +378,7 @@ func (m *TaskStateMapper) getClusterARNFromNamespace(namespace string) string {
 	if region == "" {
 		region = m.region
 	}
-	return fmt.Sprintf("arn:aws:ecs:%s:%s:cluster/%s", region, m.accountID, clusterName)
-}
-
-func (m *TaskStateMapper) extractHealthStatus(pod *corev1.Pod) string {
-	for _, container := range pod.Status.ContainerStatuses {
-		if !container.Ready && container.State.Running != nil {
-			// Container is running but not ready, might be failing health checks
-			return "UNHEALTHY"
-		}
-	}
-
-	// All containers ready or not running
-	if pod.Status.Phase == corev1.PodRunning {
-		allReady := true
-		for _, container := range pod.Status.ContainerStatuses {
-			if !container.Ready {
-				allReady = false
-				break
-			}
-		}
-		if allReady {
-			return "HEALTHY"
-		}
-	}
-
-	return "UNKNOWN"
+	return awsarn.Build("ecs", region, m.accountID, fmt.Sprintf("cluster/%s", clusterName))
 }
 
 func (m *TaskStateMapper) getExitCodeInt32(status *corev1.ContainerStatus) *int32 {
@@ -390,6 +429,21 @@ func (m *TaskStateMapper) getPodStopTime(pod *corev1.Pod) *time.Time {
 	return nil
 }
 
+// isPodStopped reports whether all containers of a pod terminated
+func isPodStopped(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+}
+
+// isPodScheduled reports whether the scheduler bound a pod to a node
+func isPodScheduled(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
 func (m *TaskStateMapper) getPodStoppingTime(pod *corev1.Pod) *time.Time {
 	if pod.DeletionTimestamp != nil {
 		return &pod.DeletionTimestamp.Time
@@ -397,7 +451,27 @@ func (m *TaskStateMapper) getPodStoppingTime(pod *corev1.Pod) *time.Time {
 	return nil
 }
 
+func (m *TaskStateMapper) getPodStopCode(pod *corev1.Pod) string {
+	if stopCode := pod.Annotations[AnnotationStopCode]; stopCode != "" {
+		return stopCode
+	}
+
+	// Pods whose containers all exited stop without the sync controller
+	if isPodStopped(pod) && converters.ExitedEssentialContainer(pod) != nil {
+		return StopCodeEssentialContainerExited
+	}
+	return ""
+}
+
 func (m *TaskStateMapper) getPodStopReason(pod *corev1.Pod) string {
+	if reason := pod.Annotations[AnnotationStoppedReason]; reason != "" {
+		return reason
+	}
+
+	if isPodStopped(pod) && converters.ExitedEssentialContainer(pod) != nil {
+		return StoppedReasonEssentialContainerExited
+	}
+
 	if pod.Status.Phase == corev1.PodFailed {
 		return pod.Status.Reason
 	}
@@ -416,29 +490,12 @@ func (m *TaskStateMapper) getPodStopReason(pod *corev1.Pod) string {
 	return ""
 }
 
-func (m *TaskStateMapper) getPullStartedTime(pod *corev1.Pod) *time.Time {
-	// This would need to be extracted from pod events
-	// For now, return pod creation time as an approximation
-	return &pod.CreationTimestamp.Time
-}
-
-func (m *TaskStateMapper) getPullStoppedTime(pod *corev1.Pod) *time.Time {
-	// This would need to be extracted from pod events
-	// For now, check if any container is running
-	for _, container := range pod.Status.ContainerStatuses {
-		if container.State.Running != nil {
-			return &container.State.Running.StartedAt.Time
-		}
-	}
-	return nil
-}
-
 func (m *TaskStateMapper) getNetworkInterfaces(pod *corev1.Pod) []generated.NetworkInterface {
 	if pod.Status.PodIP == "" {
 		return nil
 	}
 
-	attachmentID := fmt.Sprintf("eni-%s", pod.UID)
+	attachmentID := taskattachments.ENIAttachmentID(pod)
 	return []generated.NetworkInterface{
 		{
 			AttachmentId:       &attachmentID,
@@ -447,6 +504,30 @@ func (m *TaskStateMapper) getNetworkInterfaces(pod *corev1.Pod) []generated.Netw
 	}
 }
 
+// getNetworkBindings returns the network bindings of a container
+func (m *TaskStateMapper) getNetworkBindings(pod *corev1.Pod, containerName string) []generated.NetworkBinding {
+	var bindings []generated.NetworkBinding
+	for _, binding := range converters.NetworkBindings(pod, containerName, pod.Status.PodIP) {
+		protocol := generated.TransportProtocol(binding.Protocol)
+		networkBinding := generated.NetworkBinding{
+			BindIP:   stringPtr(binding.BindIP),
+			Protocol: &protocol,
+		}
+		if binding.ContainerPortRange != "" {
+			networkBinding.ContainerPortRange = stringPtr(binding.ContainerPortRange)
+			if binding.HostPortRange != "" {
+				networkBinding.HostPortRange = stringPtr(binding.HostPortRange)
+			}
+		} else {
+			containerPort, hostPort := int32(binding.ContainerPort), int32(binding.HostPort)
+			networkBinding.ContainerPort = &containerPort
+			networkBinding.HostPort = &hostPort
+		}
+		bindings = append(bindings, networkBinding)
+	}
+	return bindings
+}
+
 // serializeContainers converts container objects to JSON string for storage
 func (m *TaskStateMapper) serializeContainers(containers []generated.Container) string {
 	if len(containers) == 0 {
@@ -6,6 +6,8 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
 )
 
 func TestMapPodPhaseToTaskStatus(t *testing.T) {
@@ -108,6 +110,32 @@ func TestMapPodPhaseToTaskStatus(t *testing.T) {
 			wantDesired: "RUNNING",
 			wantLast:    "PROVISIONING",
 		},
+		{
+			name: "Pod Pending scheduled to a node",
+			pod: &corev1.Pod{
+				Status: corev1.PodStatus{
+					Phase: corev1.PodPending,
+					Conditions: []corev1.PodCondition{
+						{Type: corev1.PodScheduled, Status: corev1.ConditionTrue},
+					},
+				},
+			},
+			wantDesired: "RUNNING",
+			wantLast:    "PENDING",
+		},
+		{
+			name: "Pod Pending unschedulable",
+			pod: &corev1.Pod{
+				Status: corev1.PodStatus{
+					Phase: corev1.PodPending,
+					Conditions: []corev1.PodCondition{
+						{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: "Unschedulable"},
+					},
+				},
+			},
+			wantDesired: "RUNNING",
+			wantLast:    "PROVISIONING",
+		},
 		{
 			name: "Pod Succeeded",
 			pod: &corev1.Pod{
@@ -155,3 +183,215 @@ func TestMapPodPhaseToTaskStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestMapPodToTaskStopAnnotations(t *testing.T) {
+	mapper := NewTaskStateMapper("123456789012", "us-east-1")
+	now := metav1.NewTime(time.Now())
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "web-1",
+			Namespace:         "default-us-east-1",
+			DeletionTimestamp: &now,
+			Annotations: map[string]string{
+				AnnotationStopCode:      "SpotInterruption",
+				AnnotationStoppedReason: "Your Spot Task was interrupted.",
+			},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+
+	task := mapper.MapPodToTask(pod)
+	if task.StopCode != "SpotInterruption" {
+		t.Errorf("StopCode = %q, want %q", task.StopCode, "SpotInterruption")
+	}
+	if task.StoppedReason != "Your Spot Task was interrupted." {
+		t.Errorf("StoppedReason = %q, want the annotated reason", task.StoppedReason)
+	}
+
+	delete(pod.Annotations, AnnotationStopCode)
+	delete(pod.Annotations, AnnotationStoppedReason)
+	task = mapper.MapPodToTask(pod)
+	if task.StopCode != "" || task.StoppedReason != "Task stopped by user" {
+		t.Errorf("unexpected stop details without annotations: %q %q", task.StopCode, task.StoppedReason)
+	}
+}
+
+func TestMapPodToTaskEphemeralContainers(t *testing.T) {
+	mapper := NewTaskStateMapper("123456789012", "us-east-1")
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default-us-east-1"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "app", Image: "nginx"}},
+			EphemeralContainers: []corev1.EphemeralContainer{{
+				EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "kecs-debug-abcde", Image: "busybox"},
+			}},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			EphemeralContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "kecs-debug-abcde",
+				State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+			}},
+		},
+	}
+
+	containers := mapper.mapPodContainers(pod)
+	if len(containers) != 2 {
+		t.Fatalf("got %d containers, want the task container and the debug container", len(containers))
+	}
+	debug := containers[1]
+	if *debug.Name != "kecs-debug-abcde" || *debug.Image != "busybox" || *debug.LastStatus != "RUNNING" {
+		t.Errorf("unexpected debug container %s %s %s", *debug.Name, *debug.Image, *debug.LastStatus)
+	}
+}
+
+func TestMapPodToTaskRestartingContainers(t *testing.T) {
+	mapper := NewTaskStateMapper("123456789012", "us-east-1")
+
+	sidecar := corev1.ContainerRestartPolicyAlways
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default-us-east-1"},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "agent", Image: "agent", RestartPolicy: &sidecar}},
+			Containers:     []corev1.Container{{Name: "app", Image: "nginx"}},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "app",
+				State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+			}},
+			InitContainerStatuses: []corev1.ContainerStatus{{
+				Name:         "agent",
+				Ready:        true,
+				RestartCount: 2,
+				State:        corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+			}},
+		},
+	}
+
+	containers := mapper.mapPodContainers(pod)
+	if len(containers) != 2 {
+		t.Fatalf("got %d containers, want the task container and the restarting sidecar", len(containers))
+	}
+	if containers[0].RestartCount != nil {
+		t.Errorf("RestartCount = %d, want none for a container that never restarted", *containers[0].RestartCount)
+	}
+	agent := containers[1]
+	if *agent.Name != "agent" || *agent.LastStatus != "RUNNING" {
+		t.Errorf("unexpected sidecar %s %s", *agent.Name, *agent.LastStatus)
+	}
+	if agent.RestartCount == nil || *agent.RestartCount != 2 {
+		t.Errorf("RestartCount = %v, want 2", agent.RestartCount)
+	}
+}
+
+func TestMapPodToTaskHealthStatus(t *testing.T) {
+	mapper := NewTaskStateMapper("123456789012", "us-east-1")
+
+	probe := &corev1.Probe{PeriodSeconds: 5, FailureThreshold: 3}
+	startedAt := metav1.NewTime(time.Now().Add(-time.Hour))
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default-us-east-1"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{Name: "app", Image: "nginx", LivenessProbe: probe},
+				{Name: "log-router", Image: "fluent-bit"},
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "app", Ready: true, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: startedAt}}},
+				{Name: "log-router", Ready: true, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: startedAt}}},
+			},
+		},
+	}
+
+	task := mapper.MapPodToTask(pod)
+	if task.HealthStatus != "HEALTHY" {
+		t.Errorf("HealthStatus = %q, want HEALTHY", task.HealthStatus)
+	}
+	containers := mapper.mapPodContainers(pod)
+	if *containers[0].HealthStatus != "HEALTHY" || *containers[1].HealthStatus != "UNKNOWN" {
+		t.Errorf("container health = %s %s, want HEALTHY UNKNOWN", *containers[0].HealthStatus, *containers[1].HealthStatus)
+	}
+
+	pod.Status.ContainerStatuses[0].Ready = false
+	task = mapper.MapPodToTask(pod)
+	if task.HealthStatus != "UNHEALTHY" {
+		t.Errorf("HealthStatus = %q, want UNHEALTHY once the health check fails", task.HealthStatus)
+	}
+}
+
+func TestMapPodToTaskEssentialContainerExited(t *testing.T) {
+	mapper := NewTaskStateMapper("123456789012", "us-east-1")
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "web-1",
+			Namespace:   "default-us-east-1",
+			Annotations: map[string]string{converters.NonEssentialContainersAnnotation: "init-db"},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			Containers:    []corev1.Container{{Name: "app", Image: "nginx"}, {Name: "init-db", Image: "migrate"}},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "app", Ready: true, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+				{Name: "init-db", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}}},
+			},
+		},
+	}
+
+	// A non-essential container exiting leaves the task running
+	task := mapper.MapPodToTask(pod)
+	if task.LastStatus != "RUNNING" || task.StopCode != "" {
+		t.Errorf("got %s with stop code %q, want RUNNING", task.LastStatus, task.StopCode)
+	}
+
+	pod.Status.ContainerStatuses[0].State = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}}
+	task = mapper.MapPodToTask(pod)
+	if task.DesiredStatus != "STOPPED" || task.LastStatus != "STOPPING" {
+		t.Errorf("got %s/%s, want STOPPED/STOPPING once the essential container exited", task.DesiredStatus, task.LastStatus)
+	}
+
+	pod.Status.Phase = corev1.PodFailed
+	task = mapper.MapPodToTask(pod)
+	if task.StopCode != StopCodeEssentialContainerExited || task.StoppedReason != StoppedReasonEssentialContainerExited {
+		t.Errorf("got stop code %q and reason %q", task.StopCode, task.StoppedReason)
+	}
+}
+
+func TestMapPodToTaskNetworkBindings(t *testing.T) {
+	mapper := NewTaskStateMapper("123456789012", "us-east-1")
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "web-1",
+			Namespace:   "default-us-east-1",
+			Annotations: map[string]string{"ecs.amazonaws.com/network-mode": "bridge"},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:  "app",
+				Image: "nginx",
+				Ports: []corev1.ContainerPort{{ContainerPort: 80, HostPort: 49153, Protocol: corev1.ProtocolTCP}},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.42.0.7"},
+	}
+
+	bindings := mapper.mapPodContainers(pod)[0].NetworkBindings
+	if len(bindings) != 1 {
+		t.Fatalf("got %d network bindings, want 1", len(bindings))
+	}
+	binding := bindings[0]
+	if *binding.BindIP != "0.0.0.0" || *binding.ContainerPort != 80 || *binding.HostPort != 49153 || *binding.Protocol != "tcp" {
+		t.Errorf("unexpected network binding %s %d %d %s", *binding.BindIP, *binding.ContainerPort, *binding.HostPort, *binding.Protocol)
+	}
+}
@@ -2,17 +2,31 @@ package sync
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controllers/sync/mappers"
+	"github.com/nandemo-ya/kecs/controlplane/internal/notifications"
+	"github.com/nandemo-ya/kecs/controlplane/internal/servicedeployments"
+	"github.com/nandemo-ya/kecs/controlplane/internal/serviceevents"
 )
 
+// DriftHandler is notified when a Kubernetes resource of a service that should
+// still be running is deleted
+type DriftHandler interface {
+	ResourceDeleted(serviceARN string)
+}
+
 // syncService syncs a deployment to ECS service state
 func (c *SyncController) syncService(ctx context.Context, key string) error {
 	klog.Infof("syncService called with key: %s", key)
@@ -53,12 +67,18 @@ func (c *SyncController) syncService(ctx context.Context, key string) error {
 	}
 
 	// Get existing service from storage
-	clusterARN := fmt.Sprintf("arn:aws:ecs:%s:%s:cluster/%s", region, c.accountID, clusterName)
+	clusterARN := awsarn.Build("ecs", region, c.accountID, fmt.Sprintf("cluster/%s", clusterName))
 	existingService, err := c.storage.ServiceStore().Get(ctx, clusterARN, serviceName)
 	if err != nil && !isNotFound(err) {
 		return fmt.Errorf("error getting service from storage: %v", err)
 	}
 
+	// Remember previous counts before the mapper updates the stored service
+	prevDesired, prevRunning := -1, -1
+	if existingService != nil {
+		prevDesired, prevRunning = existingService.DesiredCount, existingService.RunningCount
+	}
+
 	// Map deployment to service
 	service := mapper.MapDeploymentToService(deployment, existingService)
 	if service == nil {
@@ -67,14 +87,17 @@ func (c *SyncController) syncService(ctx context.Context, key string) error {
 
 	// Update service ARN if not set
 	if service.ARN == "" {
-		service.ARN = fmt.Sprintf("arn:aws:ecs:%s:%s:service/%s/%s",
-			region, c.accountID, clusterName, serviceName)
+		service.ARN = awsarn.Build("ecs", region, c.accountID, fmt.Sprintf("service/%s/%s", clusterName, serviceName))
 	}
 
 	// Add to batch updater for efficient storage update
 	c.batchUpdater.AddServiceUpdate(service)
 	klog.Infof("Queued service update %s in cluster %s", serviceName, clusterName)
 
+	if c.serviceEvents != nil || c.notifier != nil {
+		c.recordServiceEvents(deployment, service, prevDesired, prevRunning)
+	}
+
 	// Log the sync result
 	klog.Infof("Successfully synced service %s: status=%s, desired=%d, running=%d, pending=%d",
 		serviceName, service.Status, service.DesiredCount, service.RunningCount, service.PendingCount)
@@ -82,6 +105,68 @@ func (c *SyncController) syncService(ctx context.Context, key string) error {
 	return nil
 }
 
+// recordServiceEvents records service events for state transitions observed
+// during sync and notifies completed deployments
+func (c *SyncController) recordServiceEvents(deployment *appsv1.Deployment, service *StorageService, prevDesired, prevRunning int) {
+	deploymentID := servicedeployments.PrimaryID(service)
+
+	// Task placement failures
+	if reason := c.unschedulableReason(deployment); reason != "" {
+		c.serviceEvents.Record(service.ARN, serviceevents.UnableToPlaceTask(service.ServiceName, reason))
+	}
+
+	// Target registration for services behind a load balancer
+	if prevRunning >= 0 && service.RunningCount > prevRunning && service.LoadBalancers != "" && service.LoadBalancers != "null" {
+		var loadBalancers []struct {
+			TargetGroupArn *string `json:"targetGroupArn,omitempty"`
+		}
+		if err := json.Unmarshal([]byte(service.LoadBalancers), &loadBalancers); err == nil {
+			for _, lb := range loadBalancers {
+				if lb.TargetGroupArn != nil && *lb.TargetGroupArn != "" {
+					c.serviceEvents.Record(service.ARN, serviceevents.TargetsRegistered(
+						service.ServiceName, service.RunningCount-prevRunning, *lb.TargetGroupArn))
+				}
+			}
+		}
+	}
+
+	// Steady state is reached when all desired tasks are running
+	wasSteady := prevDesired >= 0 && prevRunning == prevDesired
+	if service.RunningCount == service.DesiredCount && (!wasSteady || prevDesired != service.DesiredCount) {
+		if prevDesired >= 0 {
+			c.serviceEvents.Record(service.ARN, serviceevents.DeploymentCompleted(service.ServiceName, deploymentID))
+			c.notifier.Notify(notifications.DeploymentCompleted(service.ClusterARN, service.ServiceName, service.ARN, deploymentID))
+		}
+		c.serviceEvents.Record(service.ARN, serviceevents.SteadyState(service.ServiceName))
+	}
+}
+
+// unschedulableReason returns the scheduler message of the first unschedulable pod of a deployment
+func (c *SyncController) unschedulableReason(deployment *appsv1.Deployment) string {
+	if c.podLister == nil || deployment.Spec.Selector == nil {
+		return ""
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return ""
+	}
+
+	pods, err := c.podLister.Pods(deployment.Namespace).List(selector)
+	if err != nil {
+		return ""
+	}
+
+	for _, pod := range pods {
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == corev1.PodScheduled && cond.Status == corev1.ConditionFalse && cond.Reason == corev1.PodReasonUnschedulable {
+				return cond.Message
+			}
+		}
+	}
+	return ""
+}
+
 // handleDeletedDeployment handles the case when a deployment is deleted
 func (c *SyncController) handleDeletedDeployment(ctx context.Context, namespace, deploymentName string) error {
 	mapper := mappers.NewServiceStateMapper(c.accountID, c.region)
@@ -92,7 +177,7 @@ func (c *SyncController) handleDeletedDeployment(ctx context.Context, namespace,
 		return nil
 	}
 
-	clusterARN := fmt.Sprintf("arn:aws:ecs:%s:%s:cluster/%s", region, c.accountID, clusterName)
+	clusterARN := awsarn.Build("ecs", region, c.accountID, fmt.Sprintf("cluster/%s", clusterName))
 	service, err := c.storage.ServiceStore().Get(ctx, clusterARN, serviceName)
 	if err != nil {
 		if isNotFound(err) {
@@ -102,6 +187,14 @@ func (c *SyncController) handleDeletedDeployment(ctx context.Context, namespace,
 		return fmt.Errorf("error getting service: %v", err)
 	}
 
+	// A Deployment deleted while the service should still run, e.g. with
+	// kubectl, is drift rather than the end of the service
+	if c.driftHandler != nil && service.Status != "DRAINING" && service.Status != "INACTIVE" {
+		klog.Infof("Deployment of active service %s was deleted, checking for drift", serviceName)
+		c.driftHandler.ResourceDeleted(service.ARN)
+		return nil
+	}
+
 	// Update service to INACTIVE
 	service.Status = "INACTIVE"
 	service.DesiredCount = 0
@@ -0,0 +1,104 @@
+package sync_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controllers/sync"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/mocks"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+var _ = Describe("Service status sync", func() {
+	const (
+		namespace  = "default-us-east-1"
+		clusterARN = "arn:aws:ecs:us-east-1:000000000000:cluster/default"
+	)
+
+	var (
+		ctx          context.Context
+		kubeClient   *fake.Clientset
+		serviceStore *mocks.MockServiceStore
+	)
+
+	BeforeEach(func() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+
+		kubeClient = fake.NewSimpleClientset()
+		mockStorage := mocks.NewMockStorage()
+		serviceStore = mocks.NewMockServiceStore()
+		mockStorage.SetServiceStore(serviceStore)
+		mockStorage.SetTaskStore(mocks.NewMockTaskStore())
+		mockStorage.SetClusterStore(mocks.NewMockClusterStore())
+
+		factory := informers.NewSharedInformerFactory(kubeClient, 0)
+		controller := sync.NewSyncController(
+			kubeClient,
+			mockStorage,
+			factory.Apps().V1().Deployments(),
+			factory.Apps().V1().ReplicaSets(),
+			factory.Core().V1().Pods(),
+			factory.Core().V1().Events(),
+			1,
+			0,
+		)
+		factory.Start(ctx.Done())
+		go func() {
+			defer GinkgoRecover()
+			Expect(controller.Run(ctx)).To(Succeed())
+		}()
+	})
+
+	storedService := func() *storage.Service {
+		service, err := serviceStore.Get(ctx, clusterARN, "web")
+		if err != nil {
+			return nil
+		}
+		return service
+	}
+
+	It("keeps the desired count and DEGRADED status of a drifted service", func() {
+		Expect(serviceStore.Create(ctx, &storage.Service{
+			ARN:               "arn:aws:ecs:us-east-1:000000000000:service/default/web",
+			ServiceName:       "web",
+			ClusterARN:        clusterARN,
+			Region:            "us-east-1",
+			Status:            "DEGRADED",
+			DesiredCount:      3,
+			TaskDefinitionARN: "arn:aws:ecs:us-east-1:000000000000:task-definition/web:1",
+		})).To(Succeed())
+
+		// The Deployment was scaled down behind the back of ECS
+		deployment := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "web",
+				Namespace: namespace,
+				Labels:    map[string]string{"kecs.dev/managed-by": "kecs"},
+			},
+			Spec: appsv1.DeploymentSpec{Replicas: ptr.To(int32(1))},
+			Status: appsv1.DeploymentStatus{
+				Replicas:        1,
+				ReadyReplicas:   1,
+				UpdatedReplicas: 1,
+			},
+		}
+		_, err := kubeClient.AppsV1().Deployments(namespace).Create(ctx, deployment, metav1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		// Service updates are flushed in batches
+		Eventually(storedService, 5*time.Second).Should(HaveField("RunningCount", 1))
+		service := storedService()
+		Expect(service.DesiredCount).To(Equal(3))
+		Expect(service.Status).To(Equal("DEGRADED"))
+	})
+})
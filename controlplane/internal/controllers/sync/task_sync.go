@@ -7,13 +7,20 @@ import (
 	"strings"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/cache"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controllers/sync/mappers"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/notifications"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/taskattachments"
 )
 
 // syncTask syncs a pod to ECS task state
@@ -51,6 +58,15 @@ func (c *SyncController) syncTask(ctx context.Context, key string) error {
 
 	logging.Debug("Mapped pod to task", "taskArn", task.ARN, "status", task.LastStatus)
 
+	// The task stops once an essential container exits, even though its
+	// non-essential containers keep the pod running
+	if pod.Status.Phase == corev1.PodRunning && pod.DeletionTimestamp == nil &&
+		pod.Annotations[mappers.AnnotationStopCode] == "" {
+		if status := converters.ExitedEssentialContainer(pod); status != nil {
+			c.stopEssentialContainerExited(ctx, pod, status.Name)
+		}
+	}
+
 	// Check if task exists in storage
 	existingTask, err := c.storage.TaskStore().Get(ctx, task.ClusterARN, task.ARN)
 	if err != nil && !errors.IsNotFound(err) {
@@ -59,13 +75,31 @@ func (c *SyncController) syncTask(ctx context.Context, key string) error {
 
 	// Track state transitions for cluster count updates
 	var wasRunning, isRunning bool
+	var previousStatus string
 	if existingTask != nil {
-		wasRunning = existingTask.LastStatus == "RUNNING"
+		previousStatus = existingTask.LastStatus
+		wasRunning = previousStatus == "RUNNING"
+		// Keep the startup timeline already recorded, it may be refined with pod events
+		if existingTask.PullStartedAt != nil {
+			task.PullStartedAt = existingTask.PullStartedAt
+		}
+		if existingTask.PullStoppedAt != nil {
+			task.PullStoppedAt = existingTask.PullStoppedAt
+		}
+		if existingTask.StartedAt != nil {
+			task.StartedAt = existingTask.StartedAt
+		}
+		task.Attachments = existingTask.Attachments
 	}
 	isRunning = task.LastStatus == "RUNNING"
 
-	// Add to batch updater for efficient storage update
-	c.batchUpdater.AddTaskUpdate(task)
+	// Attachments follow the pod: attached once it runs, detached once it stops
+	task.Attachments = taskattachments.Sync(task.Attachments, pod, task.LastStatus)
+
+	// Task status is written right away so DescribeTasks follows the pod,
+	// a flush still coalesces the updates queued meanwhile
+	c.batchUpdater.AddTaskUpdate(task, previousStatus, pod)
+	c.batchUpdater.Flush()
 	logging.Debug("Queued task update", "namespace", namespace, "name", name)
 
 	// Update cluster counts if task state changed
@@ -110,23 +144,25 @@ func (c *SyncController) handleDeletedPod(ctx context.Context, namespace, podNam
 	}
 
 	// Generate cluster ARN
-	clusterARN := fmt.Sprintf("arn:aws:ecs:%s:%s:cluster/%s", region, c.accountID, clusterName)
+	clusterARN := awsarn.Build("ecs", region, c.accountID, fmt.Sprintf("cluster/%s", clusterName))
 
 	// Generate the task ARN or find by pod name
 	var task *storage.Task
 	if taskID != "" {
 		// Use the actual task ID if we have it
-		taskARN := fmt.Sprintf("arn:aws:ecs:%s:%s:task/%s/%s", region, c.accountID, clusterName, taskID)
+		taskARN := awsarn.Build("ecs", region, c.accountID, fmt.Sprintf("task/%s/%s", clusterName, taskID))
 		task, err = c.storage.TaskStore().Get(ctx, clusterARN, taskARN)
 	} else {
 		// Fallback: try to find task by pod name in the database
 		// For service-managed pods, the pod name is stored in the pod_name field
 		logging.Info("No task ID in pod labels, searching for task by pod name", "podName", podName)
 
-		// Get all tasks in the cluster and find the one with matching pod name
+		// Get all tasks in the cluster and find the one with matching pod name.
+		// err only reported the pod missing from the cache.
+		err = nil
 		filters := storage.TaskFilters{}
-		tasks, err := c.storage.TaskStore().List(ctx, clusterARN, filters)
-		if err == nil {
+		tasks, listErr := c.storage.TaskStore().List(ctx, clusterARN, filters)
+		if listErr == nil {
 			for _, t := range tasks {
 				if t.PodName == podName {
 					task = t
@@ -160,8 +196,18 @@ func (c *SyncController) handleDeletedPod(ctx context.Context, namespace, podNam
 	previousStatus := task.LastStatus
 	task.DesiredStatus = "STOPPED"
 	task.LastStatus = "STOPPED"
-	task.StoppedReason = "Pod deleted"
+	if pod != nil && pod.Annotations[mappers.AnnotationStopCode] != "" {
+		// The pod was stopped on purpose, report the recorded reason
+		task.StopCode = pod.Annotations[mappers.AnnotationStopCode]
+		task.StoppedReason = pod.Annotations[mappers.AnnotationStoppedReason]
+	} else if task.StopCode == "" {
+		task.StoppedReason = "Pod deleted"
+	}
 	task.StoppedAt = &[]time.Time{time.Now()}[0]
+	if task.ExecutionStoppedAt == nil {
+		task.ExecutionStoppedAt = task.StoppedAt
+	}
+	task.Attachments = taskattachments.Sync(task.Attachments, nil, task.LastStatus)
 
 	// Update all containers to STOPPED
 	var containers []generated.Container
@@ -178,8 +224,9 @@ func (c *SyncController) handleDeletedPod(ctx context.Context, namespace, podNam
 		}
 	}
 
-	// Add to batch updater
-	c.batchUpdater.AddTaskUpdate(task)
+	// Add to batch updater, the pod is gone so its status is not reapplied
+	c.batchUpdater.AddTaskUpdate(task, previousStatus, nil)
+	c.batchUpdater.Flush()
 
 	// Update cluster task count after marking task as stopped
 	if err := c.updateClusterTaskCount(ctx, clusterARN); err != nil {
@@ -193,6 +240,34 @@ func (c *SyncController) handleDeletedPod(ctx context.Context, namespace, podNam
 	return nil
 }
 
+// stopEssentialContainerExited stops the containers of a pod whose essential
+// container exited. The active deadline makes the kubelet kill the remaining
+// containers and fail the pod, which is kept so that the containers report
+// their exit codes; the annotations record the stop code for the task.
+func (c *SyncController) stopEssentialContainerExited(ctx context.Context, pod *corev1.Pod, containerName string) {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				mappers.AnnotationStopCode:      mappers.StopCodeEssentialContainerExited,
+				mappers.AnnotationStoppedReason: mappers.StoppedReasonEssentialContainerExited,
+			},
+		},
+		"spec": map[string]interface{}{
+			"activeDeadlineSeconds": 1,
+		},
+	})
+	if err != nil {
+		return
+	}
+	_, err = c.kubeClient.CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, k8stypes.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		logging.Warn("Failed to stop task after its essential container exited",
+			"pod", pod.Name, "container", containerName, "error", err)
+		return
+	}
+	logging.Info("Stopping task after its essential container exited", "pod", pod.Name, "container", containerName)
+}
+
 // syncPod is the main entry point for pod synchronization
 func (c *SyncController) syncPod(ctx context.Context, key string) error {
 	logging.Debug("Syncing pod", "key", key)
@@ -255,3 +330,42 @@ func (c *SyncController) updateClusterTaskCount(ctx context.Context, clusterARN
 func stringPtr(s string) *string {
 	return &s
 }
+
+// notifyCrashLoops notifies the containers of a pod that entered
+// CrashLoopBackOff since its previous version
+func (c *SyncController) notifyCrashLoops(oldPod, newPod *corev1.Pod) {
+	crashLooping := make(map[string]bool)
+	for _, status := range oldPod.Status.ContainerStatuses {
+		if isCrashLooping(status) {
+			crashLooping[status.Name] = true
+		}
+	}
+
+	var reasons []string
+	for _, status := range newPod.Status.ContainerStatuses {
+		if !isCrashLooping(status) || crashLooping[status.Name] {
+			continue
+		}
+		reason := fmt.Sprintf("container %s restarted %d times", status.Name, status.RestartCount)
+		if last := status.LastTerminationState.Terminated; last != nil {
+			reason += fmt.Sprintf(", last exit code %d", last.ExitCode)
+		}
+		reasons = append(reasons, reason)
+	}
+	if len(reasons) == 0 {
+		return
+	}
+
+	task := mappers.NewTaskStateMapper(c.accountID, c.region).MapPodToTask(newPod)
+	if task == nil {
+		return
+	}
+	serviceName, _ := strings.CutPrefix(task.StartedBy, "ecs-svc/")
+	c.notifier.Notify(notifications.TaskCrashLoop(task.ClusterARN, serviceName, task.ARN, strings.Join(reasons, "; ")))
+}
+
+// isCrashLooping reports whether a container waits to be restarted after
+// crashing repeatedly
+func isCrashLooping(status corev1.ContainerStatus) bool {
+	return status.State.Waiting != nil && status.State.Waiting.Reason == "CrashLoopBackOff"
+}
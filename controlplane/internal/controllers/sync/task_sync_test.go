@@ -0,0 +1,388 @@
+package sync_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	stdsync "sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controllers/sync"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/mocks"
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/notifications"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/types"
+)
+
+// recordingTaskUpdater records the pod phases and previous task statuses it
+// is handed per task
+type recordingTaskUpdater struct {
+	mu       stdsync.Mutex
+	phases   map[string][]corev1.PodPhase
+	previous map[string][]string
+}
+
+func (u *recordingTaskUpdater) UpdateTaskStatus(_ context.Context, taskARN, previousStatus string, pod *corev1.Pod) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.phases[taskARN] = append(u.phases[taskARN], pod.Status.Phase)
+	u.previous[taskARN] = append(u.previous[taskARN], previousStatus)
+	return nil
+}
+
+func (u *recordingTaskUpdater) PreviousStatuses(taskARN string) []string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return append([]string(nil), u.previous[taskARN]...)
+}
+
+func (u *recordingTaskUpdater) Phases(taskARN string) []corev1.PodPhase {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return append([]corev1.PodPhase(nil), u.phases[taskARN]...)
+}
+
+var _ = Describe("Task status sync", func() {
+	const (
+		namespace = "default-us-east-1"
+		taskARN   = "arn:aws:ecs:us-east-1:000000000000:task/default/0123456789abcdef"
+	)
+
+	var (
+		ctx        context.Context
+		kubeClient *fake.Clientset
+		taskStore  *mocks.MockTaskStore
+		updater    *recordingTaskUpdater
+		notified   chan notifications.Event
+	)
+
+	BeforeEach(func() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+
+		kubeClient = fake.NewSimpleClientset()
+		mockStorage := mocks.NewMockStorage()
+		taskStore = mocks.NewMockTaskStore()
+		mockStorage.SetTaskStore(taskStore)
+		mockStorage.SetClusterStore(mocks.NewMockClusterStore())
+		Expect(mockStorage.ClusterStore().Create(ctx, &storage.Cluster{
+			Name: "default",
+			ARN:  "arn:aws:ecs:us-east-1:000000000000:cluster/default",
+		})).To(Succeed())
+
+		factory := informers.NewSharedInformerFactory(kubeClient, 0)
+		controller := sync.NewSyncController(
+			kubeClient,
+			mockStorage,
+			factory.Apps().V1().Deployments(),
+			factory.Apps().V1().ReplicaSets(),
+			factory.Core().V1().Pods(),
+			factory.Core().V1().Events(),
+			1,
+			0,
+		)
+		updater = &recordingTaskUpdater{phases: map[string][]corev1.PodPhase{}, previous: map[string][]string{}}
+		controller.SetTaskUpdater(updater)
+
+		notified = make(chan notifications.Event, 10)
+		webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var event notifications.Event
+			if json.NewDecoder(r.Body).Decode(&event) == nil {
+				notified <- event
+			}
+		}))
+		DeferCleanup(webhook.Close)
+		notifier := notifications.NewNotifier([]notifications.Target{{URL: webhook.URL}}, "", "dev", time.Second, 0)
+		notifier.Start(ctx)
+		controller.SetNotifier(notifier)
+
+		factory.Start(ctx.Done())
+		go func() {
+			defer GinkgoRecover()
+			Expect(controller.Run(ctx)).To(Succeed())
+		}()
+	})
+
+	storedTask := func() *storage.Task {
+		task, err := taskStore.Get(ctx, "", taskARN)
+		if err != nil {
+			return nil
+		}
+		return task
+	}
+
+	It("propagates pod status changes to the task right away", func() {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "task-0123456789abcdef",
+				Namespace: namespace,
+				Labels: map[string]string{
+					"kecs.dev/managed-by":        "kecs",
+					"kecs.dev/task-id":           "0123456789abcdef",
+					"ecs.amazonaws.com/task-arn": taskARN,
+				},
+			},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "busybox"}}},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "app",
+					Ready: true,
+					State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				}},
+			},
+		}
+		pod, err := kubeClient.CoreV1().Pods(namespace).Create(ctx, pod, metav1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(storedTask).Should(HaveField("LastStatus", "RUNNING"))
+		Eventually(func() []corev1.PodPhase { return updater.Phases(taskARN) }).Should(ContainElement(corev1.PodRunning))
+
+		// Only the container state changes, the pod stays Running
+		pod.Status.ContainerStatuses[0].Ready = false
+		pod.Status.ContainerStatuses[0].State = corev1.ContainerState{
+			Terminated: &corev1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"},
+		}
+		_, err = kubeClient.CoreV1().Pods(namespace).UpdateStatus(ctx, pod, metav1.UpdateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(func() string {
+			task := storedTask()
+			if task == nil {
+				return ""
+			}
+			return task.Containers
+		}).Should(ContainSubstring(`"exitCode":137`))
+	})
+
+	It("hands the status before the sync to the task updater", func() {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "task-0123456789abcdef",
+				Namespace: namespace,
+				Labels: map[string]string{
+					"kecs.dev/managed-by":        "kecs",
+					"kecs.dev/task-id":           "0123456789abcdef",
+					"ecs.amazonaws.com/task-arn": taskARN,
+				},
+			},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "busybox"}}},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "app",
+					Ready: true,
+					State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				}},
+			},
+		}
+		pod, err := kubeClient.CoreV1().Pods(namespace).Create(ctx, pod, metav1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(storedTask).Should(HaveField("LastStatus", "RUNNING"))
+		Eventually(func() []string { return updater.PreviousStatuses(taskARN) }).ShouldNot(BeEmpty())
+		Expect(updater.PreviousStatuses(taskARN)[0]).To(BeEmpty())
+
+		pod.Status.Phase = corev1.PodSucceeded
+		pod.Status.ContainerStatuses[0].Ready = false
+		pod.Status.ContainerStatuses[0].State = corev1.ContainerState{
+			Terminated: &corev1.ContainerStateTerminated{ExitCode: 0},
+		}
+		_, err = kubeClient.CoreV1().Pods(namespace).UpdateStatus(ctx, pod, metav1.UpdateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		// The task updater sees the stop as a transition from RUNNING
+		Eventually(storedTask).Should(HaveField("LastStatus", "STOPPED"))
+		Eventually(func() []corev1.PodPhase { return updater.Phases(taskARN) }).Should(ContainElement(corev1.PodSucceeded))
+		phases, previous := updater.Phases(taskARN), updater.PreviousStatuses(taskARN)
+		for i, phase := range phases {
+			if phase == corev1.PodSucceeded {
+				Expect(previous[i]).To(Equal("RUNNING"))
+				break
+			}
+		}
+	})
+
+	It("moves the attachments of the task with its pod", func() {
+		attachment := func(attachmentType string) func() string {
+			return func() string {
+				task := storedTask()
+				if task == nil {
+					return ""
+				}
+				var attachments []types.Attachment
+				if err := json.Unmarshal([]byte(task.Attachments), &attachments); err != nil {
+					return ""
+				}
+				for _, a := range attachments {
+					if *a.Type == attachmentType {
+						return *a.Status
+					}
+				}
+				return ""
+			}
+		}
+
+		// The public IP attachment of the task is kept
+		Expect(taskStore.Create(ctx, &storage.Task{
+			ID:          "0123456789abcdef",
+			ARN:         taskARN,
+			ClusterARN:  "arn:aws:ecs:us-east-1:000000000000:cluster/default",
+			LastStatus:  "PENDING",
+			Attachments: `[{"id":"public-ip-0123456789abcdef","type":"PublicIp","status":"ATTACHED"}]`,
+		})).To(Succeed())
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "task-0123456789abcdef",
+				Namespace: namespace,
+				UID:       "pod-uid",
+				Labels: map[string]string{
+					"kecs.dev/managed-by":        "kecs",
+					"kecs.dev/task-id":           "0123456789abcdef",
+					"ecs.amazonaws.com/task-arn": taskARN,
+				},
+				Annotations: map[string]string{"ecs.amazonaws.com/network-mode": "awsvpc"},
+			},
+			Spec:   corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "busybox"}}},
+			Status: corev1.PodStatus{Phase: corev1.PodPending},
+		}
+		pod, err := kubeClient.CoreV1().Pods(namespace).Create(ctx, pod, metav1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(attachment("ElasticNetworkInterface")).Should(Equal("PRECREATED"))
+
+		pod.Status = corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			PodIP: "10.42.0.7",
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "app",
+				Ready: true,
+				State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+			}},
+		}
+		_, err = kubeClient.CoreV1().Pods(namespace).UpdateStatus(ctx, pod, metav1.UpdateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(attachment("ElasticNetworkInterface")).Should(Equal("ATTACHED"))
+		Expect(storedTask().Attachments).To(ContainSubstring(`"value":"10.42.0.7"`))
+		Expect(attachment("PublicIp")()).To(Equal("ATTACHED"))
+
+		Expect(kubeClient.CoreV1().Pods(namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{})).To(Succeed())
+		Eventually(attachment("ElasticNetworkInterface")).Should(Equal("DETACHED"))
+	})
+
+	It("stops the task once an essential container exits", func() {
+		running := corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "task-0123456789abcdef",
+				Namespace: namespace,
+				Labels: map[string]string{
+					"kecs.dev/managed-by":        "kecs",
+					"kecs.dev/task-id":           "0123456789abcdef",
+					"ecs.amazonaws.com/task-arn": taskARN,
+				},
+				Annotations: map[string]string{converters.NonEssentialContainersAnnotation: "migrate"},
+			},
+			Spec: corev1.PodSpec{
+				RestartPolicy: corev1.RestartPolicyNever,
+				Containers:    []corev1.Container{{Name: "app", Image: "busybox"}, {Name: "migrate", Image: "busybox"}},
+			},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{Name: "app", Ready: true, State: running},
+					{Name: "migrate", Ready: true, State: running},
+				},
+			},
+		}
+		pod, err := kubeClient.CoreV1().Pods(namespace).Create(ctx, pod, metav1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(storedTask).Should(HaveField("LastStatus", "RUNNING"))
+
+		// A non-essential container exiting leaves the task running
+		pod.Status.ContainerStatuses[1].Ready = false
+		pod.Status.ContainerStatuses[1].State = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}}
+		pod, err = kubeClient.CoreV1().Pods(namespace).UpdateStatus(ctx, pod, metav1.UpdateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(func() string { return storedTask().Containers }).Should(ContainSubstring(`"exitCode":0`))
+		Expect(storedTask().LastStatus).To(Equal("RUNNING"))
+
+		// The essential container exiting stops the task
+		pod.Status.ContainerStatuses[0].Ready = false
+		pod.Status.ContainerStatuses[0].State = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}}
+		_, err = kubeClient.CoreV1().Pods(namespace).UpdateStatus(ctx, pod, metav1.UpdateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(func() *int64 {
+			pod, err := kubeClient.CoreV1().Pods(namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+			if err != nil {
+				return nil
+			}
+			return pod.Spec.ActiveDeadlineSeconds
+		}).Should(HaveValue(BeEquivalentTo(1)))
+		Eventually(storedTask).Should(And(
+			HaveField("LastStatus", "STOPPING"),
+			HaveField("StopCode", "EssentialContainerExited"),
+			HaveField("StoppedReason", "Essential container in task exited"),
+		))
+	})
+
+	It("notifies containers that start crashlooping", func() {
+		crashLooping := corev1.ContainerStatus{
+			Name:                 "app",
+			RestartCount:         3,
+			State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}},
+		}
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "task-0123456789abcdef",
+				Namespace: namespace,
+				Labels: map[string]string{
+					"kecs.dev/managed-by":            "kecs",
+					"kecs.dev/task-id":               "0123456789abcdef",
+					"ecs.amazonaws.com/task-arn":     taskARN,
+					"ecs.amazonaws.com/service-name": "web",
+				},
+			},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "busybox"}}},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "app",
+					State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				}},
+			},
+		}
+		pod, err := kubeClient.CoreV1().Pods(namespace).Create(ctx, pod, metav1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(storedTask).ShouldNot(BeNil())
+
+		pod.Status.ContainerStatuses[0] = crashLooping
+		pod, err = kubeClient.CoreV1().Pods(namespace).UpdateStatus(ctx, pod, metav1.UpdateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		var event notifications.Event
+		Eventually(notified).Should(Receive(&event))
+		Expect(event.Type).To(Equal(notifications.EventTaskCrashLoop))
+		Expect(event.Cluster).To(Equal("default"))
+		Expect(event.Service).To(Equal("web"))
+		Expect(event.TaskARN).To(Equal(taskARN))
+		Expect(event.Reason).To(Equal("container app restarted 3 times, last exit code 1"))
+
+		// A container that keeps crashlooping is notified once
+		pod.Status.ContainerStatuses[0].RestartCount = 4
+		_, err = kubeClient.CoreV1().Pods(namespace).UpdateStatus(ctx, pod, metav1.UpdateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Consistently(notified, 200*time.Millisecond).ShouldNot(Receive())
+	})
+})
@@ -0,0 +1,84 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/audit"
+)
+
+// defaultAuditLimit is the number of entries returned when no limit is given
+const defaultAuditLimit = 100
+
+// AuditAPI serves the audit log of mutating API calls
+type AuditAPI struct {
+	log *audit.Log
+}
+
+// AuditResponse is the response of GET /api/audit
+type AuditResponse struct {
+	Entries []audit.Entry `json:"entries"`
+	// LastSeq is the newest sequence number, pass it as afterSeq to poll for new entries
+	LastSeq int64 `json:"lastSeq"`
+}
+
+// NewAuditAPI creates a new audit API handler
+func NewAuditAPI(log *audit.Log) *AuditAPI {
+	return &AuditAPI{log: log}
+}
+
+// RegisterRoutes registers audit API routes
+func (api *AuditAPI) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/audit", api.handleQuery).Methods("GET")
+}
+
+// handleQuery handles GET /api/audit. Entries can be filtered with the
+// afterSeq, since (RFC 3339), service, operation, accessKeyId, principal,
+// failed and limit query parameters.
+func (api *AuditAPI) handleQuery(w http.ResponseWriter, r *http.Request) {
+	if api.log == nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", "Audit logging is disabled")
+		return
+	}
+
+	params := r.URL.Query()
+	query := audit.Query{
+		Service:     params.Get("service"),
+		Operation:   params.Get("operation"),
+		AccessKeyID: params.Get("accessKeyId"),
+		Principal:   params.Get("principal"),
+		FailedOnly:  params.Get("failed") == "true",
+		Limit:       defaultAuditLimit,
+	}
+
+	var err error
+	if v := params.Get("afterSeq"); v != "" {
+		if query.AfterSeq, err = strconv.ParseInt(v, 10, 64); err != nil {
+			sendError(w, http.StatusBadRequest, "InvalidParameterException", "afterSeq must be a number")
+			return
+		}
+	}
+	if v := params.Get("since"); v != "" {
+		if query.Since, err = time.Parse(time.RFC3339, v); err != nil {
+			sendError(w, http.StatusBadRequest, "InvalidParameterException", "since must be an RFC 3339 timestamp")
+			return
+		}
+	}
+	if v := params.Get("limit"); v != "" {
+		if query.Limit, err = strconv.Atoi(v); err != nil || query.Limit < 0 {
+			sendError(w, http.StatusBadRequest, "InvalidParameterException", "limit must be a positive number")
+			return
+		}
+	}
+
+	// Read the sequence first so that entries recorded meanwhile are not skipped by pollers
+	lastSeq := api.log.LastSeq()
+	entries := api.log.Query(query)
+	if len(entries) > 0 && entries[len(entries)-1].Seq > lastSeq {
+		lastSeq = entries[len(entries)-1].Seq
+	}
+	sendJSON(w, AuditResponse{Entries: entries, LastSeq: lastSeq})
+}
@@ -0,0 +1,199 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/backup"
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// ServiceEnqueuer schedules restored services for reconciliation into
+// Kubernetes
+type ServiceEnqueuer interface {
+	Enqueue(serviceARN string)
+}
+
+// BackupAPI saves the storage state of the instance to S3 and restores it
+type BackupAPI struct {
+	storage  storage.Storage
+	objects  backup.ObjectStore
+	region   string
+	services ServiceEnqueuer
+}
+
+// BackupRequest is the request of POST /api/backups
+type BackupRequest struct {
+	// Destination is the S3 prefix of the backup, backup.destination when empty
+	Destination string `json:"destination,omitempty"`
+	// Retain prunes the destination to this many backups after saving
+	Retain int `json:"retain,omitempty"`
+}
+
+// BackupResponse is the response of POST /api/backups
+type BackupResponse struct {
+	Location      string    `json:"location"`
+	SchemaVersion int       `json:"schemaVersion"`
+	CreatedAt     time.Time `json:"createdAt"`
+	Pruned        []string  `json:"pruned,omitempty"`
+}
+
+// BackupListResponse is the response of GET /api/backups
+type BackupListResponse struct {
+	Destination string          `json:"destination"`
+	Backups     []backup.Object `json:"backups"`
+}
+
+// RestoreRequest is the request of POST /api/backups/restore
+type RestoreRequest struct {
+	// From is a backup, or an S3 prefix to restore the newest backup of
+	From string `json:"from"`
+}
+
+// RestoreResponse is the response of POST /api/backups/restore
+type RestoreResponse struct {
+	Location      string    `json:"location"`
+	SchemaVersion int       `json:"schemaVersion"`
+	KECSVersion   string    `json:"kecsVersion"`
+	CreatedAt     time.Time `json:"createdAt"`
+	*backup.RestoreResult
+}
+
+// NewBackupAPI creates a new backup API handler
+func NewBackupAPI(storage storage.Storage, objects backup.ObjectStore, region string) *BackupAPI {
+	return &BackupAPI{storage: storage, objects: objects, region: region}
+}
+
+// SetServiceEnqueuer sets the reconciler restored services are enqueued to
+func (api *BackupAPI) SetServiceEnqueuer(services ServiceEnqueuer) {
+	api.services = services
+}
+
+// RegisterRoutes registers backup API routes
+func (api *BackupAPI) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/backups", api.handleList).Methods("GET")
+	router.HandleFunc("/api/backups", api.handleCreate).Methods("POST")
+	router.HandleFunc("/api/backups/restore", api.handleRestore).Methods("POST")
+}
+
+// destination parses an S3 prefix, the configured destination when empty
+func (api *BackupAPI) destination(uri string) (backup.Location, error) {
+	if uri == "" {
+		uri = config.GetString("backup.destination")
+		if uri == "" {
+			return backup.Location{}, errors.New("destination is required when backup.destination is not configured")
+		}
+	}
+	return backup.ParseLocation(uri)
+}
+
+// handleList handles GET /api/backups?destination=s3://bucket/prefix/
+func (api *BackupAPI) handleList(w http.ResponseWriter, r *http.Request) {
+	dst, err := api.destination(r.URL.Query().Get("destination"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", err.Error())
+		return
+	}
+	backups, err := backup.List(r.Context(), api.objects, dst)
+	if err != nil {
+		sendError(w, http.StatusBadGateway, "ServerException", err.Error())
+		return
+	}
+	if backups == nil {
+		backups = []backup.Object{}
+	}
+	sendJSON(w, BackupListResponse{Destination: dst.String(), Backups: backups})
+}
+
+// handleCreate handles POST /api/backups
+func (api *BackupAPI) handleCreate(w http.ResponseWriter, r *http.Request) {
+	if api.storage == nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", "Storage is not available")
+		return
+	}
+	var req BackupRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendError(w, http.StatusBadRequest, "InvalidParameterException", "Invalid request body: "+err.Error())
+			return
+		}
+	}
+	dst, err := api.destination(req.Destination)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", err.Error())
+		return
+	}
+
+	b, err := backup.Export(r.Context(), api.storage, api.region)
+	if err != nil {
+		logging.Error("Failed to export backup", "error", err)
+		sendError(w, http.StatusInternalServerError, "ServerException", err.Error())
+		return
+	}
+	loc, err := backup.Save(r.Context(), api.objects, dst, b)
+	if err != nil {
+		sendError(w, http.StatusBadGateway, "ServerException", err.Error())
+		return
+	}
+	resp := BackupResponse{Location: loc.String(), SchemaVersion: b.SchemaVersion, CreatedAt: b.CreatedAt}
+	if resp.Pruned, err = backup.Prune(r.Context(), api.objects, dst, req.Retain); err != nil {
+		sendError(w, http.StatusBadGateway, "ServerException", err.Error())
+		return
+	}
+	sendJSON(w, resp)
+}
+
+// handleRestore handles POST /api/backups/restore
+func (api *BackupAPI) handleRestore(w http.ResponseWriter, r *http.Request) {
+	if api.storage == nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", "Storage is not available")
+		return
+	}
+	var req RestoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", "Invalid request body: "+err.Error())
+		return
+	}
+	src, err := backup.ParseLocation(req.From)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", err.Error())
+		return
+	}
+
+	b, loc, err := backup.Load(r.Context(), api.objects, src)
+	if err != nil {
+		if errors.Is(err, backup.ErrObjectNotFound) {
+			sendError(w, http.StatusNotFound, "ResourceNotFoundException", err.Error())
+			return
+		}
+		// Backups of an unsupported schema version end up here as well
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", err.Error())
+		return
+	}
+
+	result, err := backup.Restore(r.Context(), api.storage, b)
+	if err != nil {
+		logging.Error("Failed to restore backup", "location", loc.String(), "error", err)
+		sendError(w, http.StatusInternalServerError, "ServerException", err.Error())
+		return
+	}
+	if api.services != nil {
+		for _, serviceARN := range result.ServiceARNs {
+			api.services.Enqueue(serviceARN)
+		}
+	}
+	logging.Info("Restored backup", "location", loc.String(), "restored", result.Restored, "skipped", result.Skipped)
+	sendJSON(w, RestoreResponse{
+		Location:      loc.String(),
+		SchemaVersion: b.SchemaVersion,
+		KECSVersion:   b.KECSVersion,
+		CreatedAt:     b.CreatedAt,
+		RestoreResult: result,
+	})
+}
@@ -0,0 +1,203 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "k8s.io/client-go/kubernetes"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/bench"
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// loadBalancerEndpoint is the in-cluster address of the Traefik proxy that
+// routes load balancer traffic by Host header
+const loadBalancerEndpoint = "http://traefik.kecs-system.svc.cluster.local"
+
+// BenchAPI runs load generation Jobs against services and load balancers
+type BenchAPI struct {
+	storage    storage.Storage
+	kubeClient k8sclient.Interface
+}
+
+// StartBenchRequest is the body of POST /api/bench. Target is a service
+// name, a load balancer ARN or a URL.
+type StartBenchRequest struct {
+	Cluster  string `json:"cluster"`
+	Target   string `json:"target"`
+	Path     string `json:"path,omitempty"`
+	RPS      int    `json:"rps"`
+	Duration string `json:"duration"`
+	Timeout  string `json:"timeout,omitempty"`
+}
+
+// NewBenchAPI creates a new bench API handler
+func NewBenchAPI(storage storage.Storage, kubeClient k8sclient.Interface) *BenchAPI {
+	return &BenchAPI{storage: storage, kubeClient: kubeClient}
+}
+
+// SetKubeClient sets the Kubernetes client
+func (api *BenchAPI) SetKubeClient(kubeClient k8sclient.Interface) {
+	api.kubeClient = kubeClient
+}
+
+// RegisterRoutes registers bench API routes
+func (api *BenchAPI) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/bench", api.handleStart).Methods("POST")
+	router.HandleFunc("/api/bench/{id}", api.handleGet).Methods("GET")
+	router.HandleFunc("/api/bench/{id}", api.handleStop).Methods("DELETE")
+}
+
+// handleStart handles POST /api/bench. The run continues in the background,
+// its report is polled with GET /api/bench/{id}.
+func (api *BenchAPI) handleStart(w http.ResponseWriter, r *http.Request) {
+	var req StartBenchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", "Invalid request body")
+		return
+	}
+	if req.Target == "" {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", "target is required")
+		return
+	}
+	duration, err := time.ParseDuration(req.Duration)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", "duration must be a duration, e.g. 2m")
+		return
+	}
+	var timeout time.Duration
+	if req.Timeout != "" {
+		if timeout, err = time.ParseDuration(req.Timeout); err != nil {
+			sendError(w, http.StatusBadRequest, "InvalidParameterException", "timeout must be a duration, e.g. 10s")
+			return
+		}
+	}
+	if api.storage == nil || api.kubeClient == nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", "Storage or Kubernetes client is not available")
+		return
+	}
+
+	ctx := r.Context()
+	spec, err := api.resolveTarget(ctx, req.Cluster, req.Target, req.Path)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", err.Error())
+		return
+	}
+	spec.RPS = req.RPS
+	spec.Duration = duration
+	spec.Timeout = timeout
+	if err := spec.Validate(); err != nil {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", err.Error())
+		return
+	}
+
+	image := config.GetString("bench.image")
+	if image == "" {
+		image = config.GetString("server.controlPlaneImage")
+	}
+	id, err := bench.Start(ctx, api.kubeClient, image, req.Target, *spec)
+	if err != nil {
+		logging.Warn("Failed to start bench run", "target", req.Target, "error", err)
+		sendError(w, http.StatusInternalServerError, "ServerException", err.Error())
+		return
+	}
+	logging.Info("Started bench run", "id", id, "target", req.Target, "url", spec.URL, "rps", spec.RPS, "duration", spec.Duration)
+	sendJSON(w, bench.Report{ID: id, Target: req.Target, Status: bench.StatusRunning})
+}
+
+// handleGet handles GET /api/bench/{id}
+func (api *BenchAPI) handleGet(w http.ResponseWriter, r *http.Request) {
+	if api.kubeClient == nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", "Kubernetes client is not available")
+		return
+	}
+	report, err := bench.Get(r.Context(), api.kubeClient, mux.Vars(r)["id"])
+	if err != nil {
+		api.sendBenchError(w, err)
+		return
+	}
+	sendJSON(w, report)
+}
+
+// handleStop handles DELETE /api/bench/{id}
+func (api *BenchAPI) handleStop(w http.ResponseWriter, r *http.Request) {
+	if api.kubeClient == nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", "Kubernetes client is not available")
+		return
+	}
+	id := mux.Vars(r)["id"]
+	if err := bench.Stop(r.Context(), api.kubeClient, id); err != nil {
+		api.sendBenchError(w, err)
+		return
+	}
+	sendJSON(w, map[string]string{"id": id, "status": "stopped"})
+}
+
+// resolveTarget returns the endpoint the load of a target is sent to. Load
+// balancers are reached through the Traefik proxy with their DNS name as
+// Host header, services through the Kubernetes Service KECS creates for
+// services with a load balancer or a public IP.
+func (api *BenchAPI) resolveTarget(ctx context.Context, clusterName, target, path string) (*bench.Spec, error) {
+	if path == "" {
+		path = "/"
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		return &bench.Spec{URL: target}, nil
+	}
+	if strings.HasPrefix(target, "arn:") {
+		if !strings.Contains(target, ":loadbalancer/") {
+			return nil, fmt.Errorf("%s is not a load balancer ARN", target)
+		}
+		lb, err := api.storage.ELBv2Store().GetLoadBalancer(ctx, target)
+		if err != nil || lb == nil {
+			return nil, fmt.Errorf("load balancer not found: %s", target)
+		}
+		return &bench.Spec{URL: loadBalancerEndpoint + path, Host: lb.DNSName}, nil
+	}
+
+	if clusterName == "" {
+		clusterName = "default"
+	}
+	cluster, err := api.storage.ClusterStore().Get(ctx, clusterName)
+	if err != nil || cluster == nil {
+		return nil, fmt.Errorf("cluster not found: %s", clusterName)
+	}
+	service, err := api.storage.ServiceStore().Get(ctx, cluster.ARN, target)
+	if err != nil || service == nil {
+		return nil, fmt.Errorf("service not found: %s", target)
+	}
+	namespace := fmt.Sprintf("%s-%s", cluster.Name, cluster.Region)
+	kubeService, err := api.kubeClient.CoreV1().Services(namespace).Get(ctx, service.ServiceName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("service %s has no endpoint, it needs a load balancer or a public IP", target)
+	}
+	for _, port := range kubeService.Spec.Ports {
+		if port.Protocol == "" || port.Protocol == corev1.ProtocolTCP {
+			return &bench.Spec{URL: fmt.Sprintf("http://%s.%s.svc.cluster.local:%d%s", kubeService.Name, namespace, port.Port, path)}, nil
+		}
+	}
+	return nil, fmt.Errorf("service %s exposes no TCP port", target)
+}
+
+// sendBenchError sends the error of a bench run lookup
+func (api *BenchAPI) sendBenchError(w http.ResponseWriter, err error) {
+	if errors.Is(err, bench.ErrNotFound) {
+		sendError(w, http.StatusNotFound, "ResourceNotFoundException", err.Error())
+		return
+	}
+	sendError(w, http.StatusInternalServerError, "ServerException", err.Error())
+}
@@ -0,0 +1,280 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"k8s.io/apimachinery/pkg/util/rand"
+	k8sclient "k8s.io/client-go/kubernetes"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/build"
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// buildUploadTimeout bounds the upload of a build context, which may take
+// longer than the read timeout of the admin server
+const buildUploadTimeout = 10 * time.Minute
+
+// buildPollInterval is how often the Job of a running build is checked
+const buildPollInterval = 2 * time.Second
+
+// buildWatchTimeout bounds how long a build is watched, so the context of a
+// build whose Job never finishes is removed eventually
+const buildWatchTimeout = time.Hour
+
+// BuildAPI builds images from uploaded build contexts inside the cluster and
+// registers task definitions running them
+type BuildAPI struct {
+	storage    storage.Storage
+	kubeClient k8sclient.Interface
+	ecs        build.ECSClient
+	contexts   *build.ContextStore
+}
+
+// NewBuildAPI creates a new build API handler
+func NewBuildAPI(storage storage.Storage, kubeClient k8sclient.Interface) *BuildAPI {
+	return &BuildAPI{
+		storage:    storage,
+		kubeClient: kubeClient,
+		contexts:   build.NewContextStore(config.GetString("build.contextDir")),
+	}
+}
+
+// SetKubeClient sets the Kubernetes client
+func (api *BuildAPI) SetKubeClient(kubeClient k8sclient.Interface) {
+	api.kubeClient = kubeClient
+}
+
+// SetECSClient sets the ECS API built images are registered and run through
+func (api *BuildAPI) SetECSClient(ecs build.ECSClient) {
+	api.ecs = ecs
+}
+
+// RegisterRoutes registers build API routes
+func (api *BuildAPI) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/builds", api.handleStart).Methods("POST")
+	router.HandleFunc("/api/builds/{id}", api.handleGet).Methods("GET")
+	router.HandleFunc("/api/builds/{id}", api.handleStop).Methods("DELETE")
+	router.HandleFunc("/api/builds/{id}/context", api.handleContext).Methods("GET")
+	router.HandleFunc("/api/builds/{id}/logs", api.handleLogs).Methods("GET")
+}
+
+// handleStart handles POST /api/builds. The body is the gzipped tar build
+// context, the spec is passed as query parameters. The build continues in the
+// background, its report is polled with GET /api/builds/{id}.
+func (api *BuildAPI) handleStart(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	spec := build.Spec{
+		Repository: query.Get("repository"),
+		Tag:        query.Get("tag"),
+		Dockerfile: query.Get("dockerfile"),
+		Family:     query.Get("family"),
+		Container:  query.Get("container"),
+		Cluster:    query.Get("cluster"),
+		Run:        query.Get("run") == "true",
+	}
+	if port := query.Get("port"); port != "" {
+		value, err := strconv.ParseInt(port, 10, 32)
+		if err != nil {
+			sendError(w, http.StatusBadRequest, "InvalidParameterException", "port must be a number")
+			return
+		}
+		spec.Port = int32(value)
+	}
+	if err := spec.Validate(); err != nil {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", err.Error())
+		return
+	}
+	if api.kubeClient == nil || (spec.Family != "" && (api.storage == nil || api.ecs == nil)) {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", "Kubernetes client or ECS API is not available")
+		return
+	}
+
+	if err := http.NewResponseController(w).SetReadDeadline(time.Now().Add(buildUploadTimeout)); err != nil {
+		logging.Debug("Failed to extend read deadline of build upload", "error", err)
+	}
+	id := "kecs-build-" + rand.String(8)
+	size, err := api.contexts.Save(id, r.Body)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", fmt.Sprintf("failed to receive build context: %v", err))
+		return
+	}
+
+	ctx := r.Context()
+	registry := config.GetString("build.registry")
+	err = build.Start(ctx, api.kubeClient, id, spec, build.JobOptions{
+		ExecutorImage: config.GetString("build.executorImage"),
+		FetchImage:    config.GetString("build.fetchImage"),
+		Registry:      registry,
+		ContextURL:    fmt.Sprintf("%s/api/builds/%s/context", config.GetString("build.adminEndpoint"), id),
+	})
+	if err != nil {
+		api.contexts.Remove(id)
+		logging.Warn("Failed to start build", "repository", spec.Repository, "error", err)
+		sendError(w, http.StatusInternalServerError, "ServerException", err.Error())
+		return
+	}
+	logging.Info("Started build", "id", id, "image", spec.Image(registry), "contextBytes", size, "family", spec.Family)
+	go api.watch(id, registry)
+	sendJSON(w, build.Report{ID: id, Spec: spec, Image: spec.Image(registry), Status: build.StatusRunning})
+}
+
+// handleGet handles GET /api/builds/{id}
+func (api *BuildAPI) handleGet(w http.ResponseWriter, r *http.Request) {
+	if api.kubeClient == nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", "Kubernetes client is not available")
+		return
+	}
+	report, err := build.Get(r.Context(), api.kubeClient, mux.Vars(r)["id"], config.GetString("build.registry"))
+	if err != nil {
+		api.sendBuildError(w, err)
+		return
+	}
+	sendJSON(w, report)
+}
+
+// watch follows a build until it is done, whether or not its report is
+// polled. Once the image is pushed, its task definition is registered and its
+// task started. The context of the build is removed when it is done.
+func (api *BuildAPI) watch(id, registry string) {
+	ctx, cancel := context.WithTimeout(context.Background(), buildWatchTimeout)
+	defer cancel()
+	defer api.contexts.Remove(id)
+
+	ticker := time.NewTicker(buildPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			logging.Warn("Stopped watching build that did not finish", "id", id, "timeout", buildWatchTimeout)
+			return
+		case <-ticker.C:
+		}
+
+		report, err := build.Get(ctx, api.kubeClient, id, registry)
+		if errors.Is(err, build.ErrNotFound) {
+			// The build was stopped
+			return
+		}
+		if err != nil {
+			logging.Debug("Failed to get build", "id", id, "error", err)
+			continue
+		}
+		if report.Status != build.StatusRunning {
+			return
+		}
+		pushed, err := build.Pushed(ctx, api.kubeClient, id)
+		if err != nil || !pushed {
+			continue
+		}
+		if err := api.finish(ctx, report); err != nil {
+			logging.Warn("Failed to finish build", "id", id, "error", err)
+			continue
+		}
+		return
+	}
+}
+
+// finish registers the task definition of a build whose image was pushed and
+// runs its task, then stores the result
+func (api *BuildAPI) finish(ctx context.Context, report *build.Report) error {
+	var result build.Result
+	if report.Spec.Family != "" {
+		current, err := api.latestTaskDefinition(ctx, report.Spec.Family)
+		if err != nil {
+			return err
+		}
+		if result, err = build.Register(ctx, api.ecs, current, report); err != nil {
+			logging.Warn("Failed to register built image", "id", report.ID, "image", report.Image, "error", err)
+			result.Error = err.Error()
+		}
+	}
+	logging.Info("Finished build", "id", report.ID, "image", report.Image,
+		"taskDefinition", result.TaskDefinitionArn, "task", result.TaskArn)
+	return build.SetResult(ctx, api.kubeClient, report.ID, result)
+}
+
+// latestTaskDefinition returns the latest revision of a family, or nil when
+// the family has none
+func (api *BuildAPI) latestTaskDefinition(ctx context.Context, family string) (*generated.TaskDefinition, error) {
+	if _, err := api.storage.TaskDefinitionStore().GetLatest(ctx, family); err != nil {
+		if errors.Is(err, storage.ErrResourceNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get task definition: %w", err)
+	}
+	resp, err := api.ecs.DescribeTaskDefinition(ctx, &generated.DescribeTaskDefinitionRequest{TaskDefinition: family})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe task definition: %w", err)
+	}
+	return resp.TaskDefinition, nil
+}
+
+// handleStop handles DELETE /api/builds/{id}
+func (api *BuildAPI) handleStop(w http.ResponseWriter, r *http.Request) {
+	if api.kubeClient == nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", "Kubernetes client is not available")
+		return
+	}
+	id := mux.Vars(r)["id"]
+	if err := build.Stop(r.Context(), api.kubeClient, id); err != nil {
+		api.sendBuildError(w, err)
+		return
+	}
+	api.contexts.Remove(id)
+	sendJSON(w, map[string]string{"id": id, "status": "stopped"})
+}
+
+// handleContext handles GET /api/builds/{id}/context, which the build Jobs
+// download their context from
+func (api *BuildAPI) handleContext(w http.ResponseWriter, r *http.Request) {
+	file, err := api.contexts.Open(mux.Vars(r)["id"])
+	if err != nil {
+		api.sendBuildError(w, err)
+		return
+	}
+	defer file.Close()
+
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(buildUploadTimeout)); err != nil {
+		logging.Debug("Failed to extend write deadline of build context", "error", err)
+	}
+	w.Header().Set("Content-Type", "application/gzip")
+	if _, err := io.Copy(w, file); err != nil {
+		logging.Warn("Failed to send build context", "error", err)
+	}
+}
+
+// handleLogs handles GET /api/builds/{id}/logs
+func (api *BuildAPI) handleLogs(w http.ResponseWriter, r *http.Request) {
+	if api.kubeClient == nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", "Kubernetes client is not available")
+		return
+	}
+	logs, err := build.Logs(r.Context(), api.kubeClient, mux.Vars(r)["id"])
+	if err != nil {
+		api.sendBuildError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if _, err := io.WriteString(w, logs); err != nil {
+		logging.Error("Failed to write build logs", "error", err)
+	}
+}
+
+// sendBuildError sends the error of a build lookup
+func (api *BuildAPI) sendBuildError(w http.ResponseWriter, err error) {
+	if errors.Is(err, build.ErrNotFound) {
+		sendError(w, http.StatusNotFound, "ResourceNotFoundException", err.Error())
+		return
+	}
+	sendError(w, http.StatusInternalServerError, "ServerException", err.Error())
+}
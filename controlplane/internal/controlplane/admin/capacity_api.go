@@ -0,0 +1,61 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	k8sclient "k8s.io/client-go/kubernetes"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/capacity"
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// CapacityAPI reports the remaining capacity of the nodes of the instance
+type CapacityAPI struct {
+	kubeClient k8sclient.Interface
+}
+
+// NewCapacityAPI creates a new capacity API handler
+func NewCapacityAPI(kubeClient k8sclient.Interface) *CapacityAPI {
+	return &CapacityAPI{kubeClient: kubeClient}
+}
+
+// SetKubeClient sets the Kubernetes client
+func (api *CapacityAPI) SetKubeClient(kubeClient k8sclient.Interface) {
+	api.kubeClient = kubeClient
+}
+
+// RegisterRoutes registers capacity API routes
+func (api *CapacityAPI) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/capacity", api.handleCapacity).Methods("GET")
+}
+
+// handleCapacity handles GET /api/capacity
+func (api *CapacityAPI) handleCapacity(w http.ResponseWriter, r *http.Request) {
+	if api.kubeClient == nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", "Kubernetes client is not available")
+		return
+	}
+
+	report, err := capacity.Collect(r.Context(), api.kubeClient, capacityThresholds())
+	if err != nil {
+		logging.Warn("Failed to collect capacity", "error", err)
+		sendError(w, http.StatusInternalServerError, "ServerException", err.Error())
+		return
+	}
+	sendJSON(w, report)
+}
+
+// capacityThresholds returns the thresholds configured with
+// capacity.cpuWarningThreshold and capacity.memoryWarningThreshold
+func capacityThresholds() capacity.Thresholds {
+	thresholds := capacity.DefaultThresholds()
+	if threshold := config.GetFloat64("capacity.cpuWarningThreshold"); threshold > 0 {
+		thresholds.CPU = threshold
+	}
+	if threshold := config.GetFloat64("capacity.memoryWarningThreshold"); threshold > 0 {
+		thresholds.Memory = threshold
+	}
+	return thresholds
+}
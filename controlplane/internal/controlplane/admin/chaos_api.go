@@ -0,0 +1,220 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	k8sclient "k8s.io/client-go/kubernetes"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/chaos"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// ChaosAPI handles fault simulation endpoints
+type ChaosAPI struct {
+	storage       storage.Storage
+	kubeClient    k8sclient.Interface
+	faultInjector *chaos.FaultInjector
+}
+
+// StopTaskRequest is the body of POST /api/chaos/stop-task
+type StopTaskRequest struct {
+	Cluster string `json:"cluster"`
+	Task    string `json:"task"`
+	Reason  string `json:"reason"`
+	Message string `json:"message,omitempty"`
+}
+
+// StopTaskResponse is the response of POST /api/chaos/stop-task
+type StopTaskResponse struct {
+	TaskArn       string `json:"taskArn"`
+	StopCode      string `json:"stopCode"`
+	StoppedReason string `json:"stoppedReason"`
+}
+
+// NodeRequest is the body of the node drain endpoints
+type NodeRequest struct {
+	Node    string `json:"node"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// NewChaosAPI creates a new chaos API handler
+func NewChaosAPI(storage storage.Storage, kubeClient k8sclient.Interface) *ChaosAPI {
+	return &ChaosAPI{
+		storage:    storage,
+		kubeClient: kubeClient,
+	}
+}
+
+// SetKubeClient sets the Kubernetes client
+func (api *ChaosAPI) SetKubeClient(kubeClient k8sclient.Interface) {
+	api.kubeClient = kubeClient
+}
+
+// SetFaultInjector sets the fault injector of the AWS API server
+func (api *ChaosAPI) SetFaultInjector(faultInjector *chaos.FaultInjector) {
+	api.faultInjector = faultInjector
+}
+
+// RegisterRoutes registers chaos API routes
+func (api *ChaosAPI) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/chaos/stop-task", api.handleStopTask).Methods("POST")
+	router.HandleFunc("/api/chaos/drain-node", api.handleDrainNode).Methods("POST")
+	router.HandleFunc("/api/chaos/uncordon-node", api.handleUncordonNode).Methods("POST")
+	router.HandleFunc("/api/chaos/faults", api.handleGetFaults).Methods("GET")
+	router.HandleFunc("/api/chaos/faults", api.handlePutFaults).Methods("PUT")
+	router.HandleFunc("/api/chaos/faults", api.handleDeleteFaults).Methods("DELETE")
+}
+
+// handleStopTask handles POST /api/chaos/stop-task
+func (api *ChaosAPI) handleStopTask(w http.ResponseWriter, r *http.Request) {
+	var req StopTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", "Invalid request body")
+		return
+	}
+	if req.Task == "" {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", "task is required")
+		return
+	}
+	if req.Reason == "" {
+		req.Reason = chaos.ReasonUser
+	}
+	reason, err := chaos.ResolveReason(req.Reason, req.Message)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", err.Error())
+		return
+	}
+	if api.storage == nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", "Storage is not available")
+		return
+	}
+
+	ctx := r.Context()
+	clusterARN := ""
+	if !strings.HasPrefix(req.Task, "arn:") {
+		clusterName := req.Cluster
+		if clusterName == "" {
+			clusterName = "default"
+		}
+		if idx := strings.LastIndex(clusterName, "/"); idx >= 0 {
+			clusterName = clusterName[idx+1:]
+		}
+		cluster, err := api.storage.ClusterStore().Get(ctx, clusterName)
+		if err != nil || cluster == nil {
+			sendError(w, http.StatusNotFound, "ClusterNotFoundException", "Cluster not found: "+clusterName)
+			return
+		}
+		clusterARN = cluster.ARN
+	}
+
+	simulator := chaos.NewSimulator(api.storage.TaskStore(), api.kubeClient)
+	task, err := simulator.StopTask(ctx, clusterARN, req.Task, reason)
+	if err != nil {
+		logging.Warn("Failed to stop task", "task", req.Task, "error", err)
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", err.Error())
+		return
+	}
+
+	sendJSON(w, StopTaskResponse{
+		TaskArn:       task.ARN,
+		StopCode:      task.StopCode,
+		StoppedReason: task.StoppedReason,
+	})
+}
+
+// handleDrainNode handles POST /api/chaos/drain-node
+func (api *ChaosAPI) handleDrainNode(w http.ResponseWriter, r *http.Request) {
+	req, ok := api.decodeNodeRequest(w, r)
+	if !ok {
+		return
+	}
+	if req.Reason == "" {
+		req.Reason = chaos.ReasonDrain
+	}
+	reason, err := chaos.ResolveReason(req.Reason, req.Message)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", err.Error())
+		return
+	}
+
+	var taskStore storage.TaskStore
+	if api.storage != nil {
+		taskStore = api.storage.TaskStore()
+	}
+	result, err := chaos.NewSimulator(taskStore, api.kubeClient).DrainNode(r.Context(), req.Node, reason)
+	if err != nil {
+		logging.Warn("Failed to drain node", "node", req.Node, "error", err)
+		sendError(w, http.StatusInternalServerError, "ServerException", err.Error())
+		return
+	}
+	sendJSON(w, result)
+}
+
+// handleUncordonNode handles POST /api/chaos/uncordon-node
+func (api *ChaosAPI) handleUncordonNode(w http.ResponseWriter, r *http.Request) {
+	req, ok := api.decodeNodeRequest(w, r)
+	if !ok {
+		return
+	}
+	if err := chaos.NewSimulator(nil, api.kubeClient).UncordonNode(r.Context(), req.Node); err != nil {
+		logging.Warn("Failed to uncordon node", "node", req.Node, "error", err)
+		sendError(w, http.StatusInternalServerError, "ServerException", err.Error())
+		return
+	}
+	sendJSON(w, map[string]string{"node": req.Node, "status": "schedulable"})
+}
+
+// handleGetFaults handles GET /api/chaos/faults
+func (api *ChaosAPI) handleGetFaults(w http.ResponseWriter, r *http.Request) {
+	if api.faultInjector == nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", "Fault injection is not available")
+		return
+	}
+	sendJSON(w, api.faultInjector.Config())
+}
+
+// handlePutFaults handles PUT /api/chaos/faults, replacing all fault rules
+func (api *ChaosAPI) handlePutFaults(w http.ResponseWriter, r *http.Request) {
+	if api.faultInjector == nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", "Fault injection is not available")
+		return
+	}
+	var config chaos.FaultConfig
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", "Invalid request body")
+		return
+	}
+	if err := api.faultInjector.Configure(config); err != nil {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", err.Error())
+		return
+	}
+	sendJSON(w, api.faultInjector.Config())
+}
+
+// handleDeleteFaults handles DELETE /api/chaos/faults, removing all fault rules
+func (api *ChaosAPI) handleDeleteFaults(w http.ResponseWriter, r *http.Request) {
+	if api.faultInjector == nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", "Fault injection is not available")
+		return
+	}
+	api.faultInjector.Reset()
+	sendJSON(w, api.faultInjector.Config())
+}
+
+func (api *ChaosAPI) decodeNodeRequest(w http.ResponseWriter, r *http.Request) (*NodeRequest, bool) {
+	var req NodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", "Invalid request body")
+		return nil, false
+	}
+	if req.Node == "" {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", "node is required")
+		return nil, false
+	}
+	return &req, true
+}
@@ -0,0 +1,87 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// ClusterDeleter deletes ECS clusters together with the resources left in them
+type ClusterDeleter interface {
+	CascadeDeleteCluster(ctx context.Context, cluster string, progress func(resource, message string)) (*generated.DeleteClusterResponse, error)
+}
+
+// ClusterDeletionEvent reports a step of a cascading cluster deletion
+type ClusterDeletionEvent struct {
+	Timestamp time.Time `json:"timestamp"`
+	Resource  string    `json:"resource"`
+	Message   string    `json:"message"`
+}
+
+// ClusterAPI provides KECS extensions to the ECS cluster operations
+type ClusterAPI struct {
+	deleter ClusterDeleter
+}
+
+// NewClusterAPI creates a new cluster API handler
+func NewClusterAPI() *ClusterAPI {
+	return &ClusterAPI{}
+}
+
+// SetClusterDeleter sets the ECS API clusters are deleted through
+func (api *ClusterAPI) SetClusterDeleter(deleter ClusterDeleter) {
+	api.deleter = deleter
+}
+
+// RegisterRoutes registers cluster API routes
+func (api *ClusterAPI) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/clusters/{cluster}", api.handleDelete).Methods("DELETE")
+}
+
+// handleDelete handles DELETE /api/clusters/{cluster}. Unlike DeleteCluster
+// it drains and deletes the services, task sets and tasks left in the
+// cluster first. The progress is streamed as server-sent events: "progress"
+// events carry a ClusterDeletionEvent, the final "deleted" event the deleted
+// cluster and an "error" event the reason the deletion stopped.
+func (api *ClusterAPI) handleDelete(w http.ResponseWriter, r *http.Request) {
+	if api.deleter == nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", "ECS API is not available")
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	// Draining many services can outlive the server write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		logging.Debug("Failed to clear write deadline for cluster deletion", "error", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	send := func(event string, data interface{}) {
+		payload, _ := json.Marshal(data)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+		flusher.Flush()
+	}
+
+	resp, err := api.deleter.CascadeDeleteCluster(r.Context(), mux.Vars(r)["cluster"], func(resource, message string) {
+		send("progress", ClusterDeletionEvent{Timestamp: time.Now(), Resource: resource, Message: message})
+	})
+	if err != nil {
+		logging.Error("Failed to delete cluster", "cluster", mux.Vars(r)["cluster"], "error", err)
+		send("error", ErrorResponse{Type: "ServerException", Message: err.Error()})
+		return
+	}
+	send("deleted", resp.Cluster)
+}
@@ -0,0 +1,114 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	k8sclient "k8s.io/client-go/kubernetes"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/debugcontainer"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// DebugAPI attaches ephemeral debug containers to running tasks
+type DebugAPI struct {
+	storage    storage.Storage
+	kubeClient k8sclient.Interface
+}
+
+// DebugContainerRequest is the body of POST /api/debug-containers
+type DebugContainerRequest struct {
+	Cluster string   `json:"cluster"`
+	Task    string   `json:"task"`
+	Image   string   `json:"image"`
+	Command []string `json:"command,omitempty"`
+	// Target is the container whose processes the debug container sees
+	Target string `json:"target,omitempty"`
+	// Name is appended to the kecs-debug- prefix of the container name
+	Name string `json:"name,omitempty"`
+}
+
+// DebugContainerResponse is the response of POST /api/debug-containers
+type DebugContainerResponse struct {
+	TaskArn   string `json:"taskArn"`
+	TaskID    string `json:"taskId"`
+	Container string `json:"container"`
+}
+
+// NewDebugAPI creates a new debug API handler
+func NewDebugAPI(storage storage.Storage, kubeClient k8sclient.Interface) *DebugAPI {
+	return &DebugAPI{
+		storage:    storage,
+		kubeClient: kubeClient,
+	}
+}
+
+// SetKubeClient sets the Kubernetes client
+func (api *DebugAPI) SetKubeClient(kubeClient k8sclient.Interface) {
+	api.kubeClient = kubeClient
+}
+
+// RegisterRoutes registers debug API routes
+func (api *DebugAPI) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/debug-containers", api.handleAttach).Methods("POST")
+}
+
+// handleAttach handles POST /api/debug-containers. The debug container joins
+// the pod of the task and is listed in its containers by DescribeTasks once
+// the pod status reports it.
+func (api *DebugAPI) handleAttach(w http.ResponseWriter, r *http.Request) {
+	var req DebugContainerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", "Invalid request body")
+		return
+	}
+	if req.Task == "" {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", "task is required")
+		return
+	}
+	spec := debugcontainer.Spec{Name: req.Name, Image: req.Image, Command: req.Command, Target: req.Target}
+	if err := spec.Validate(); err != nil {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", err.Error())
+		return
+	}
+	if api.storage == nil || api.kubeClient == nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", "Storage or Kubernetes client is not available")
+		return
+	}
+	if req.Cluster == "" {
+		req.Cluster = "default"
+	}
+
+	ctx := r.Context()
+	cluster, err := api.storage.ClusterStore().Get(ctx, req.Cluster)
+	if err != nil || cluster == nil {
+		sendError(w, http.StatusNotFound, "ClusterNotFoundException", "Cluster not found: "+req.Cluster)
+		return
+	}
+	task, err := api.storage.TaskStore().Get(ctx, cluster.ARN, req.Task)
+	if err != nil || task == nil {
+		sendError(w, http.StatusNotFound, "ResourceNotFoundException", "Task not found: "+req.Task)
+		return
+	}
+	namespace, podName := taskPod(task)
+	if task.LastStatus != "RUNNING" || podName == "" {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", "Task is not running: "+req.Task)
+		return
+	}
+
+	name, err := debugcontainer.Attach(ctx, api.kubeClient, namespace, podName, spec)
+	if err != nil {
+		if errors.Is(err, debugcontainer.ErrPodNotFound) {
+			sendError(w, http.StatusNotFound, "ResourceNotFoundException", err.Error())
+			return
+		}
+		logging.Warn("Failed to attach debug container", "task", task.ARN, "error", err)
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", err.Error())
+		return
+	}
+	logging.Info("Attached debug container", "task", task.ARN, "container", name, "image", spec.Image, "target", spec.Target)
+	sendJSON(w, DebugContainerResponse{TaskArn: task.ARN, TaskID: taskID(task), Container: name})
+}
@@ -0,0 +1,212 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/gorilla/mux"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/devsync"
+	"github.com/nandemo-ya/kecs/controlplane/internal/execcommand"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// maxDevSyncSize is the largest archive of changed files accepted per sync
+const maxDevSyncSize = 64 << 20
+
+// DevAPI syncs local source changes into the containers of running tasks
+type DevAPI struct {
+	storage storage.Storage
+	syncer  *devsync.Syncer
+}
+
+// DevTarget is a container changes are synced into
+type DevTarget struct {
+	TaskArn   string `json:"taskArn"`
+	TaskID    string `json:"taskId"`
+	Container string `json:"container"`
+	// Error is set on sync responses when the task could not be synced
+	Error string `json:"error,omitempty"`
+	// Output is the output of the command run after the sync
+	Output string `json:"output,omitempty"`
+}
+
+// DevSyncResponse is the response of POST /api/dev/sync
+type DevSyncResponse struct {
+	Targets []DevTarget `json:"targets"`
+}
+
+// NewDevAPI creates a new dev API handler
+func NewDevAPI(storage storage.Storage) *DevAPI {
+	return &DevAPI{storage: storage}
+}
+
+// SetSyncer sets the syncer changes are applied with
+func (api *DevAPI) SetSyncer(syncer *devsync.Syncer) {
+	api.syncer = syncer
+}
+
+// RegisterRoutes registers dev API routes
+func (api *DevAPI) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/dev/targets", api.handleTargets).Methods("GET")
+	router.HandleFunc("/api/dev/sync", api.handleSync).Methods("POST")
+}
+
+// handleTargets handles GET /api/dev/targets, listing the containers a sync
+// with the same query parameters goes to
+func (api *DevAPI) handleTargets(w http.ResponseWriter, r *http.Request) {
+	targets, status, err := api.resolveTargets(r)
+	if err != nil {
+		sendStatusError(w, status, err.Error())
+		return
+	}
+	response := DevSyncResponse{Targets: make([]DevTarget, 0, len(targets))}
+	for _, target := range targets {
+		response.Targets = append(response.Targets, target.DevTarget)
+	}
+	sendJSON(w, response)
+}
+
+// handleSync handles POST /api/dev/sync. The body is a gzipped tar of the
+// changed files relative to the path parameter, deleted files are passed as
+// delete parameters and the command parameter runs after the sync. Every
+// running task of the service, or the task, receives the change.
+func (api *DevAPI) handleSync(w http.ResponseWriter, r *http.Request) {
+	if api.syncer == nil {
+		sendStatusError(w, http.StatusServiceUnavailable, "Syncing requires a Kubernetes connection")
+		return
+	}
+	query := r.URL.Query()
+	change := devsync.Change{
+		Dir:     query.Get("path"),
+		Deleted: query["delete"],
+		Command: query.Get("command"),
+	}
+	if err := change.Validate(); err != nil {
+		sendStatusError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	archive, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxDevSyncSize))
+	if err != nil {
+		sendStatusError(w, http.StatusBadRequest, fmt.Sprintf("failed to read changed files: %v", err))
+		return
+	}
+	change.Archive = archive
+
+	targets, status, err := api.resolveTargets(r)
+	if err != nil {
+		sendStatusError(w, status, err.Error())
+		return
+	}
+	response := DevSyncResponse{Targets: make([]DevTarget, 0, len(targets))}
+	for _, target := range targets {
+		result := target.DevTarget
+		output, err := api.syncer.Sync(r.Context(), target.exec, change)
+		result.Output = output
+		if err != nil {
+			logging.Warn("Failed to sync changes", "task", result.TaskArn, "container", result.Container, "error", err)
+			result.Error = err.Error()
+		}
+		response.Targets = append(response.Targets, result)
+	}
+	logging.Debug("Synced changes", "path", change.Dir, "bytes", len(archive), "deleted", len(change.Deleted), "targets", len(targets))
+	sendJSON(w, response)
+}
+
+// devTarget is a resolved container of a running task
+type devTarget struct {
+	DevTarget
+	exec execcommand.Target
+}
+
+// resolveTargets returns the containers addressed by the cluster, service or
+// task and container query parameters
+func (api *DevAPI) resolveTargets(r *http.Request) ([]devTarget, int, error) {
+	if api.storage == nil {
+		return nil, http.StatusServiceUnavailable, fmt.Errorf("storage is not available")
+	}
+	query := r.URL.Query()
+	clusterName := query.Get("cluster")
+	if clusterName == "" {
+		clusterName = "default"
+	}
+	service, taskRef, container := query.Get("service"), query.Get("task"), query.Get("container")
+	if (service == "") == (taskRef == "") {
+		return nil, http.StatusBadRequest, fmt.Errorf("exactly one of service and task is required")
+	}
+
+	ctx := r.Context()
+	cluster, err := api.storage.ClusterStore().Get(ctx, clusterName)
+	if err != nil || cluster == nil {
+		return nil, http.StatusNotFound, fmt.Errorf("cluster not found: %s", clusterName)
+	}
+	tasks, err := api.runningTasks(ctx, cluster.ARN, service, taskRef)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	if len(tasks) == 0 {
+		if service != "" {
+			return nil, http.StatusNotFound, fmt.Errorf("service %s has no running tasks", service)
+		}
+		return nil, http.StatusNotFound, fmt.Errorf("task %s is not running", taskRef)
+	}
+
+	targets := make([]devTarget, 0, len(tasks))
+	for _, task := range tasks {
+		names := taskContainerNames(task)
+		name := container
+		switch {
+		case name == "" && len(names) == 1:
+			name = names[0]
+		case name == "":
+			return nil, http.StatusBadRequest, fmt.Errorf("task %s has %d containers, select one with container", taskID(task), len(names))
+		case !slices.Contains(names, name):
+			return nil, http.StatusBadRequest, fmt.Errorf("task %s has no container %s", taskID(task), name)
+		}
+		namespace, podName := taskPod(task)
+		targets = append(targets, devTarget{
+			DevTarget: DevTarget{TaskArn: task.ARN, TaskID: taskID(task), Container: name},
+			exec:      execcommand.Target{Namespace: namespace, PodName: podName, Container: name},
+		})
+	}
+	return targets, http.StatusOK, nil
+}
+
+// runningTasks returns the running tasks of a service, or the task when it
+// is running
+func (api *DevAPI) runningTasks(ctx context.Context, clusterARN, service, taskRef string) ([]*storage.Task, error) {
+	var tasks []*storage.Task
+	if service != "" {
+		list, err := api.storage.TaskStore().List(ctx, clusterARN, storage.TaskFilters{ServiceName: service})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tasks: %w", err)
+		}
+		tasks = list
+	} else if task, err := api.storage.TaskStore().Get(ctx, clusterARN, taskRef); err == nil && task != nil {
+		tasks = []*storage.Task{task}
+	}
+
+	running := make([]*storage.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if _, podName := taskPod(task); task.LastStatus == "RUNNING" && podName != "" {
+			running = append(running, task)
+		}
+	}
+	return running, nil
+}
+
+// sendStatusError writes an error response whose type follows from the status
+func sendStatusError(w http.ResponseWriter, status int, message string) {
+	errType := "InvalidParameterException"
+	switch status {
+	case http.StatusNotFound:
+		errType = "ResourceNotFoundException"
+	case http.StatusInternalServerError, http.StatusServiceUnavailable:
+		errType = "ServerException"
+	}
+	sendError(w, status, errType, message)
+}
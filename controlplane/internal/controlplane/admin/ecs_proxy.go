@@ -55,7 +55,7 @@ type ProxyRequest struct {
 // handleECSProxy handles all ECS API proxy requests
 func (p *ECSProxy) handleECSProxy(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		p.sendError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "Method not allowed")
+		sendError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "Method not allowed")
 		return
 	}
 
@@ -67,14 +67,14 @@ func (p *ECSProxy) handleECSProxy(w http.ResponseWriter, r *http.Request) {
 	// Get instance API port from config file
 	apiPort, err := p.getInstanceAPIPort(instanceName)
 	if err != nil {
-		p.sendError(w, http.StatusNotFound, "InstanceNotFound", fmt.Sprintf("Instance %s not found: %v", instanceName, err))
+		sendError(w, http.StatusNotFound, "InstanceNotFound", fmt.Sprintf("Instance %s not found: %v", instanceName, err))
 		return
 	}
 
 	// Read request body
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		p.sendError(w, http.StatusBadRequest, "InvalidRequest", "Failed to read request body")
+		sendError(w, http.StatusBadRequest, "InvalidRequest", "Failed to read request body")
 		return
 	}
 	defer r.Body.Close()
@@ -82,7 +82,7 @@ func (p *ECSProxy) handleECSProxy(w http.ResponseWriter, r *http.Request) {
 	// Map endpoint to ECS action
 	action := p.mapEndpointToAction(endpoint)
 	if action == "" {
-		p.sendError(w, http.StatusNotFound, "InvalidEndpoint", fmt.Sprintf("Unknown endpoint: %s", endpoint))
+		sendError(w, http.StatusNotFound, "InvalidEndpoint", fmt.Sprintf("Unknown endpoint: %s", endpoint))
 		return
 	}
 
@@ -93,7 +93,7 @@ func (p *ECSProxy) handleECSProxy(w http.ResponseWriter, r *http.Request) {
 	proxyReq, err := http.NewRequest("POST", apiURL, bytes.NewReader(body))
 	if err != nil {
 		logging.Error("Failed to create proxy request", "error", err)
-		p.sendError(w, http.StatusInternalServerError, "InternalError", "Failed to create proxy request")
+		sendError(w, http.StatusInternalServerError, "InternalError", "Failed to create proxy request")
 		return
 	}
 
@@ -109,7 +109,7 @@ func (p *ECSProxy) handleECSProxy(w http.ResponseWriter, r *http.Request) {
 	resp, err := client.Do(proxyReq)
 	if err != nil {
 		logging.Error("Failed to proxy request", "error", err)
-		p.sendError(w, http.StatusInternalServerError, "InternalError", "Failed to proxy request")
+		sendError(w, http.StatusInternalServerError, "InternalError", "Failed to proxy request")
 		return
 	}
 	defer resp.Body.Close()
@@ -226,7 +226,7 @@ func (p *ECSProxy) handleStopTask(w http.ResponseWriter, r *http.Request) {
 	// Read request body for cluster info
 	var req map[string]string
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		p.sendError(w, http.StatusBadRequest, "InvalidRequest", "Invalid request body")
+		sendError(w, http.StatusBadRequest, "InvalidRequest", "Invalid request body")
 		return
 	}
 
@@ -242,7 +242,7 @@ func (p *ECSProxy) handleStopTask(w http.ResponseWriter, r *http.Request) {
 func (p *ECSProxy) proxySimpleAction(w http.ResponseWriter, r *http.Request, action string) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		p.sendError(w, http.StatusBadRequest, "InvalidRequest", "Failed to read request body")
+		sendError(w, http.StatusBadRequest, "InvalidRequest", "Failed to read request body")
 		return
 	}
 	defer r.Body.Close()
@@ -254,7 +254,7 @@ func (p *ECSProxy) proxySimpleAction(w http.ResponseWriter, r *http.Request, act
 func (p *ECSProxy) proxyWithBody(w http.ResponseWriter, r *http.Request, action string, body interface{}) {
 	bodyBytes, err := json.Marshal(body)
 	if err != nil {
-		p.sendError(w, http.StatusInternalServerError, "InternalError", "Failed to marshal request")
+		sendError(w, http.StatusInternalServerError, "InternalError", "Failed to marshal request")
 		return
 	}
 
@@ -270,7 +270,7 @@ func (p *ECSProxy) proxyRequest(w http.ResponseWriter, r *http.Request, action s
 	// Get instance API port from config file
 	apiPort, err := p.getInstanceAPIPort(instanceName)
 	if err != nil {
-		p.sendError(w, http.StatusNotFound, "InstanceNotFound", fmt.Sprintf("Instance %s not found: %v", instanceName, err))
+		sendError(w, http.StatusNotFound, "InstanceNotFound", fmt.Sprintf("Instance %s not found: %v", instanceName, err))
 		return
 	}
 
@@ -279,7 +279,7 @@ func (p *ECSProxy) proxyRequest(w http.ResponseWriter, r *http.Request, action s
 	proxyReq, err := http.NewRequest("POST", apiURL, bytes.NewReader(body))
 	if err != nil {
 		logging.Error("Failed to create proxy request", "error", err)
-		p.sendError(w, http.StatusInternalServerError, "InternalError", "Failed to create proxy request")
+		sendError(w, http.StatusInternalServerError, "InternalError", "Failed to create proxy request")
 		return
 	}
 
@@ -290,7 +290,7 @@ func (p *ECSProxy) proxyRequest(w http.ResponseWriter, r *http.Request, action s
 	resp, err := client.Do(proxyReq)
 	if err != nil {
 		logging.Error("Failed to proxy request", "error", err)
-		p.sendError(w, http.StatusInternalServerError, "InternalError", "Failed to proxy request")
+		sendError(w, http.StatusInternalServerError, "InternalError", "Failed to proxy request")
 		return
 	}
 	defer resp.Body.Close()
@@ -301,18 +301,6 @@ func (p *ECSProxy) proxyRequest(w http.ResponseWriter, r *http.Request, action s
 	io.Copy(w, resp.Body)
 }
 
-func (p *ECSProxy) sendError(w http.ResponseWriter, status int, errType, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	err := ErrorResponse{
-		Type:    errType,
-		Message: message,
-	}
-	if encErr := json.NewEncoder(w).Encode(err); encErr != nil {
-		logging.Error("Failed to encode error response", "error", encErr)
-	}
-}
-
 // getInstanceAPIPort retrieves the API port for an instance from its config file
 func (p *ECSProxy) getInstanceAPIPort(instanceName string) (int, error) {
 	// Build config file path
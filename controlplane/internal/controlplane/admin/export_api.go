@@ -0,0 +1,88 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/export"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// ExportAPI exports the Kubernetes resources of ECS clusters as manifests
+type ExportAPI struct {
+	storage   storage.Storage
+	region    string
+	accountID string
+}
+
+// ExportManifest is an exported Kubernetes object
+type ExportManifest struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	// FileName is the deterministic file name of the manifest
+	FileName string `json:"fileName"`
+	YAML     string `json:"yaml"`
+}
+
+// ExportResponse is the response of GET /api/export/k8s
+type ExportResponse struct {
+	Cluster   string           `json:"cluster"`
+	Manifests []ExportManifest `json:"manifests"`
+}
+
+// NewExportAPI creates a new export API handler
+func NewExportAPI(storage storage.Storage, region, accountID string) *ExportAPI {
+	return &ExportAPI{storage: storage, region: region, accountID: accountID}
+}
+
+// RegisterRoutes registers export API routes
+func (api *ExportAPI) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/export/k8s", api.handleKubernetes).Methods("GET")
+}
+
+// handleKubernetes handles GET /api/export/k8s?cluster=<name>
+func (api *ExportAPI) handleKubernetes(w http.ResponseWriter, r *http.Request) {
+	if api.storage == nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", "Storage is not available")
+		return
+	}
+	clusterName := r.URL.Query().Get("cluster")
+	if clusterName == "" {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", "cluster is required")
+		return
+	}
+
+	exporter := export.NewExporter(api.storage, converters.NewServiceConverter(api.region, api.accountID))
+	objects, err := exporter.ClusterManifests(r.Context(), clusterName)
+	if err != nil {
+		if errors.Is(err, storage.ErrResourceNotFound) {
+			sendError(w, http.StatusNotFound, "ClusterNotFoundException", "Cluster not found: "+clusterName)
+			return
+		}
+		logging.Error("Failed to export cluster manifests", "cluster", clusterName, "error", err)
+		sendError(w, http.StatusInternalServerError, "ServerException", err.Error())
+		return
+	}
+
+	resp := ExportResponse{Cluster: clusterName, Manifests: make([]ExportManifest, 0, len(objects))}
+	for _, object := range objects {
+		data, err := export.MarshalYAML(object)
+		if err != nil {
+			sendError(w, http.StatusInternalServerError, "ServerException", err.Error())
+			return
+		}
+		resp.Manifests = append(resp.Manifests, ExportManifest{
+			Kind:      object.Kind,
+			Name:      object.Name,
+			Namespace: object.Namespace,
+			FileName:  export.FileName(object),
+			YAML:      string(data),
+		})
+	}
+	sendJSON(w, resp)
+}
@@ -0,0 +1,99 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	k8sclient "k8s.io/client-go/kubernetes"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/importer"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// ImportAPI creates ECS services from the Deployments of a Kubernetes namespace
+type ImportAPI struct {
+	kubeClient k8sclient.Interface
+	ecs        importer.ECSClient
+	region     string
+	accountID  string
+}
+
+// ImportRequest is the request of POST /api/import/k8s
+type ImportRequest struct {
+	Namespace string `json:"namespace"`
+	Cluster   string `json:"cluster"`
+	// DryRun only returns the task definitions and services that would be created
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// ImportResponse is the response of POST /api/import/k8s
+type ImportResponse struct {
+	Namespace string                    `json:"namespace"`
+	Cluster   string                    `json:"cluster"`
+	DryRun    bool                      `json:"dryRun,omitempty"`
+	Services  []*importer.ServiceImport `json:"services"`
+}
+
+// NewImportAPI creates a new import API handler
+func NewImportAPI(kubeClient k8sclient.Interface, region, accountID string) *ImportAPI {
+	return &ImportAPI{kubeClient: kubeClient, region: region, accountID: accountID}
+}
+
+// SetKubeClient sets the Kubernetes client workloads are read with
+func (api *ImportAPI) SetKubeClient(kubeClient k8sclient.Interface) {
+	api.kubeClient = kubeClient
+}
+
+// SetECSClient sets the ECS API imported resources are created through
+func (api *ImportAPI) SetECSClient(ecs importer.ECSClient) {
+	api.ecs = ecs
+}
+
+// RegisterRoutes registers import API routes
+func (api *ImportAPI) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/import/k8s", api.handleKubernetes).Methods("POST")
+}
+
+// handleKubernetes handles POST /api/import/k8s
+func (api *ImportAPI) handleKubernetes(w http.ResponseWriter, r *http.Request) {
+	var req ImportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", "Invalid request body: "+err.Error())
+		return
+	}
+	if req.Namespace == "" || req.Cluster == "" {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", "namespace and cluster are required")
+		return
+	}
+	if api.kubeClient == nil || api.ecs == nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", "Kubernetes client or ECS API is not available")
+		return
+	}
+
+	imp := importer.NewImporter(api.kubeClient, api.ecs, api.region, api.accountID)
+	var (
+		services []*importer.ServiceImport
+		err      error
+	)
+	if req.DryRun {
+		services, err = imp.Plan(r.Context(), req.Namespace, req.Cluster)
+	} else {
+		services, err = imp.Import(r.Context(), req.Namespace, req.Cluster)
+	}
+	if err != nil {
+		logging.Error("Failed to import Kubernetes workloads", "namespace", req.Namespace, "cluster", req.Cluster, "error", err)
+		sendError(w, http.StatusInternalServerError, "ServerException", err.Error())
+		return
+	}
+	if services == nil {
+		services = []*importer.ServiceImport{}
+	}
+
+	sendJSON(w, ImportResponse{
+		Namespace: req.Namespace,
+		Cluster:   req.Cluster,
+		DryRun:    req.DryRun,
+		Services:  services,
+	})
+}
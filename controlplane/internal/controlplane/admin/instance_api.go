@@ -23,6 +23,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -87,14 +88,14 @@ type ErrorResponse struct {
 // handleListInstances handles GET /api/instances
 func (api *InstanceAPI) handleListInstances(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		api.sendError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "Method not allowed")
+		sendError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "Method not allowed")
 		return
 	}
 
 	// Get home directory
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		api.sendError(w, http.StatusInternalServerError, "InternalError", "Failed to get home directory")
+		sendError(w, http.StatusInternalServerError, "InternalError", "Failed to get home directory")
 		return
 	}
 
@@ -104,10 +105,10 @@ func (api *InstanceAPI) handleListInstances(w http.ResponseWriter, r *http.Reque
 	if err != nil {
 		// If directory doesn't exist, return empty list
 		if os.IsNotExist(err) {
-			api.sendJSON(w, []Instance{})
+			sendJSON(w, []Instance{})
 			return
 		}
-		api.sendError(w, http.StatusInternalServerError, "InternalError", "Failed to read instances directory")
+		sendError(w, http.StatusInternalServerError, "InternalError", "Failed to read instances directory")
 		return
 	}
 
@@ -156,13 +157,44 @@ func (api *InstanceAPI) handleListInstances(w http.ResponseWriter, r *http.Reque
 		})
 	}
 
-	api.sendJSON(w, instances)
+	sendJSON(w, instances)
+}
+
+// handleFederation handles GET /api/federation, listing every instance found
+// in the local k3d clusters and on the known ports with aggregated counts
+func (api *InstanceAPI) handleFederation(w http.ResponseWriter, r *http.Request) {
+	if api.manager == nil {
+		sendError(w, http.StatusServiceUnavailable, "ServiceUnavailable", "Instance manager is not available")
+		return
+	}
+
+	instances, err := api.manager.Discover(r.Context())
+	if err != nil {
+		logging.Error("Failed to discover instances", "error", err)
+		sendError(w, http.StatusInternalServerError, "InternalError", "Failed to discover instances")
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := range instances {
+		if instances[i].Status != "running" || instances[i].APIPort == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(inst *instance.FederatedInstance) {
+			defer wg.Done()
+			inst.Clusters, inst.Services, inst.Tasks = api.getInstanceCounts(inst.APIPort)
+		}(&instances[i])
+	}
+	wg.Wait()
+
+	sendJSON(w, instance.NewFederationSummary(instances))
 }
 
 // handleGetInstance handles GET /api/instances/{name}
 func (api *InstanceAPI) handleGetInstance(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		api.sendError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "Method not allowed")
+		sendError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "Method not allowed")
 		return
 	}
 
@@ -171,7 +203,7 @@ func (api *InstanceAPI) handleGetInstance(w http.ResponseWriter, r *http.Request
 
 	// For now, only support "default" instance
 	if name != "default" {
-		api.sendError(w, http.StatusNotFound, "InstanceNotFound", fmt.Sprintf("Instance %s not found", name))
+		sendError(w, http.StatusNotFound, "InstanceNotFound", fmt.Sprintf("Instance %s not found", name))
 		return
 	}
 
@@ -186,37 +218,37 @@ func (api *InstanceAPI) handleGetInstance(w http.ResponseWriter, r *http.Request
 		CreatedAt: time.Now().Add(-24 * time.Hour),
 	}
 
-	api.sendJSON(w, instance)
+	sendJSON(w, instance)
 }
 
 // handleCreateInstance handles POST /api/instances
 func (api *InstanceAPI) handleCreateInstance(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		api.sendError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "Method not allowed")
+		sendError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "Method not allowed")
 		return
 	}
 
 	var req CreateInstanceRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		api.sendError(w, http.StatusBadRequest, "InvalidRequest", "Invalid request body")
+		sendError(w, http.StatusBadRequest, "InvalidRequest", "Invalid request body")
 		return
 	}
 
 	// Validate request
 	if req.Name == "" {
-		api.sendError(w, http.StatusBadRequest, "InvalidParameter", "Instance name is required")
+		sendError(w, http.StatusBadRequest, "InvalidParameter", "Instance name is required")
 		return
 	}
 
 	// For now, return error as multi-instance is not yet supported
 	// In the future, this would create a new k3d cluster
-	api.sendError(w, http.StatusNotImplemented, "NotImplemented", "Multi-instance support coming soon")
+	sendError(w, http.StatusNotImplemented, "NotImplemented", "Multi-instance support coming soon")
 }
 
 // handleDeleteInstance handles DELETE /api/instances/{name}
 func (api *InstanceAPI) handleDeleteInstance(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
-		api.sendError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "Method not allowed")
+		sendError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "Method not allowed")
 		return
 	}
 
@@ -225,17 +257,17 @@ func (api *InstanceAPI) handleDeleteInstance(w http.ResponseWriter, r *http.Requ
 
 	// For now, prevent deletion of default instance
 	if name == "default" {
-		api.sendError(w, http.StatusForbidden, "OperationNotPermitted", "Cannot delete default instance")
+		sendError(w, http.StatusForbidden, "OperationNotPermitted", "Cannot delete default instance")
 		return
 	}
 
-	api.sendError(w, http.StatusNotImplemented, "NotImplemented", "Multi-instance support coming soon")
+	sendError(w, http.StatusNotImplemented, "NotImplemented", "Multi-instance support coming soon")
 }
 
 // handleInstanceHealth handles GET /api/instances/{name}/health
 func (api *InstanceAPI) handleInstanceHealth(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		api.sendError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "Method not allowed")
+		sendError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "Method not allowed")
 		return
 	}
 
@@ -243,7 +275,7 @@ func (api *InstanceAPI) handleInstanceHealth(w http.ResponseWriter, r *http.Requ
 	name := vars["name"]
 
 	if name != "default" {
-		api.sendError(w, http.StatusNotFound, "InstanceNotFound", fmt.Sprintf("Instance %s not found", name))
+		sendError(w, http.StatusNotFound, "InstanceNotFound", fmt.Sprintf("Instance %s not found", name))
 		return
 	}
 
@@ -254,7 +286,7 @@ func (api *InstanceAPI) handleInstanceHealth(w http.ResponseWriter, r *http.Requ
 		"time":    time.Now(),
 	}
 
-	api.sendJSON(w, health)
+	sendJSON(w, health)
 }
 
 // Helper methods
@@ -383,7 +415,7 @@ func (api *InstanceAPI) getServiceCount() int {
 
 	for _, cluster := range clusters {
 		// List all services in the cluster (no filtering)
-		services, _, err := api.storage.ServiceStore().List(ctx, cluster.Name, "", "", 1000, "")
+		services, _, err := api.storage.ServiceStore().List(ctx, cluster.Name, storage.ServiceFilters{}, 1000, "")
 		if err != nil {
 			logging.Error("Failed to count services", "cluster", cluster.Name, "error", err)
 			continue
@@ -421,7 +453,8 @@ func (api *InstanceAPI) getTaskCount() int {
 	return count
 }
 
-func (api *InstanceAPI) sendJSON(w http.ResponseWriter, data interface{}) {
+// sendJSON writes data as a JSON response with status 200
+func sendJSON(w http.ResponseWriter, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(data); err != nil {
@@ -446,19 +479,16 @@ func (api *InstanceAPI) handleGetCreationStatus(w http.ResponseWriter, r *http.R
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(status); err != nil {
 		logging.Error("Failed to encode creation status", "error", err)
-		api.sendError(w, http.StatusInternalServerError, "InternalError", "Failed to encode response")
+		sendError(w, http.StatusInternalServerError, "InternalError", "Failed to encode response")
 	}
 }
 
-func (api *InstanceAPI) sendError(w http.ResponseWriter, status int, errType, message string) {
+// sendError writes an error response in the format of the AWS JSON protocols
+func sendError(w http.ResponseWriter, status int, errType, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	err := ErrorResponse{
-		Type:    errType,
-		Message: message,
-	}
-	if encErr := json.NewEncoder(w).Encode(err); encErr != nil {
-		logging.Error("Failed to encode error response", "error", encErr)
+	if err := json.NewEncoder(w).Encode(ErrorResponse{Type: errType, Message: message}); err != nil {
+		logging.Error("Failed to encode error response", "error", err)
 	}
 }
 
@@ -471,4 +501,7 @@ func (api *InstanceAPI) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/api/instances/{name}", api.handleDeleteInstance).Methods("DELETE")
 	router.HandleFunc("/api/instances/{name}/health", api.handleInstanceHealth).Methods("GET")
 	router.HandleFunc("/api/instances/{name}/creation-status", api.handleGetCreationStatus).Methods("GET")
+	router.HandleFunc("/api/instances/{name}/registries", api.handleGetRegistries).Methods("GET")
+	router.HandleFunc("/api/instances/{name}/registries", api.handleUpdateRegistries).Methods("PUT")
+	router.HandleFunc("/api/federation", api.handleFederation).Methods("GET")
 }
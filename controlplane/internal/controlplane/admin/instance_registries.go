@@ -0,0 +1,116 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/host/instance"
+	"github.com/nandemo-ya/kecs/controlplane/internal/host/k3d"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// redactedSecret replaces registry passwords and tokens in responses. Sending
+// it back keeps the saved secret, so configs can be read, edited and written.
+const redactedSecret = "********"
+
+// registryUpdateTimeout bounds the rolling restart of the nodes of an instance
+const registryUpdateTimeout = 15 * time.Minute
+
+// RegistryUpdateResponse is the response of PUT /api/instances/{name}/registries
+type RegistryUpdateResponse struct {
+	Instance string `json:"instance"`
+	// Status is "applying" while nodes restart, progress is reported by
+	// GET /api/instances/{name}/creation-status
+	Status string `json:"status"`
+}
+
+// handleGetRegistries handles GET /api/instances/{name}/registries
+func (api *InstanceAPI) handleGetRegistries(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	saved, err := instance.LoadInstanceConfig(name)
+	if err != nil {
+		sendError(w, http.StatusNotFound, "InstanceNotFound", err.Error())
+		return
+	}
+	registries := k3d.RegistryConfig{}
+	if saved.Registries != nil {
+		registries = redactRegistries(*saved.Registries)
+	}
+	sendJSON(w, registries)
+}
+
+// handleUpdateRegistries handles PUT /api/instances/{name}/registries. The
+// config is saved right away, nodes of a running instance are restarted one
+// at a time in the background to apply it.
+func (api *InstanceAPI) handleUpdateRegistries(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	var registries k3d.RegistryConfig
+	if err := json.NewDecoder(r.Body).Decode(&registries); err != nil {
+		sendError(w, http.StatusBadRequest, "InvalidRequest", "Invalid request body")
+		return
+	}
+	saved, err := instance.LoadInstanceConfig(name)
+	if err != nil {
+		sendError(w, http.StatusNotFound, "InstanceNotFound", err.Error())
+		return
+	}
+	restoreSecrets(&registries, saved.Registries)
+	if err := registries.Validate(); err != nil {
+		sendError(w, http.StatusBadRequest, "InvalidParameter", err.Error())
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), registryUpdateTimeout)
+		defer cancel()
+		if err := api.manager.UpdateRegistries(ctx, name, &registries); err != nil {
+			logging.Error("Failed to update registry config", "instance", name, "error", err)
+		}
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(RegistryUpdateResponse{Instance: name, Status: "applying"}); err != nil {
+		logging.Error("Failed to encode JSON response", "error", err)
+	}
+}
+
+// redactRegistries returns registries with their secrets replaced
+func redactRegistries(registries k3d.RegistryConfig) k3d.RegistryConfig {
+	if len(registries.Auths) == 0 {
+		return registries
+	}
+	auths := make(map[string]k3d.RegistryAuth, len(registries.Auths))
+	for registry, auth := range registries.Auths {
+		if auth.Password != "" {
+			auth.Password = redactedSecret
+		}
+		if auth.IdentityToken != "" {
+			auth.IdentityToken = redactedSecret
+		}
+		auths[registry] = auth
+	}
+	registries.Auths = auths
+	return registries
+}
+
+// restoreSecrets replaces redacted secrets in registries with the saved ones
+func restoreSecrets(registries *k3d.RegistryConfig, saved *k3d.RegistryConfig) {
+	for registry, auth := range registries.Auths {
+		var previous k3d.RegistryAuth
+		if saved != nil {
+			previous = saved.Auths[registry]
+		}
+		if auth.Password == redactedSecret {
+			auth.Password = previous.Password
+		}
+		if auth.IdentityToken == redactedSecret {
+			auth.IdentityToken = previous.IdentityToken
+		}
+		registries.Auths[registry] = auth
+	}
+}
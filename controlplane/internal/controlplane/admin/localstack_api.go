@@ -0,0 +1,64 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/localstack"
+)
+
+// LocalStackAPI serves the support matrix of the configured LocalStack
+type LocalStackAPI struct {
+	prober func() *localstack.CapabilityProber
+}
+
+// LocalStackCapabilitiesResponse is the response of GET /api/localstack/capabilities
+type LocalStackCapabilitiesResponse struct {
+	*localstack.Capabilities
+	// ProbeError is the error of the last probe, the matrix is the last successful one
+	ProbeError string `json:"probeError,omitempty"`
+}
+
+// NewLocalStackAPI creates a new LocalStack API handler
+func NewLocalStackAPI(prober func() *localstack.CapabilityProber) *LocalStackAPI {
+	return &LocalStackAPI{prober: prober}
+}
+
+// RegisterRoutes registers LocalStack API routes
+func (api *LocalStackAPI) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/localstack/capabilities", api.handleCapabilities).Methods("GET")
+}
+
+// handleCapabilities handles GET /api/localstack/capabilities. The cached
+// matrix is returned unless refresh=true asks to probe LocalStack again.
+func (api *LocalStackAPI) handleCapabilities(w http.ResponseWriter, r *http.Request) {
+	var prober *localstack.CapabilityProber
+	if api.prober != nil {
+		prober = api.prober()
+	}
+	if prober == nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", "LocalStack is not configured")
+		return
+	}
+
+	if r.URL.Query().Get("refresh") == "true" {
+		prober.Probe(r.Context())
+	}
+
+	caps, err := prober.Cached()
+	if caps == nil {
+		message := "LocalStack capabilities have not been probed yet"
+		if err != nil {
+			message = err.Error()
+		}
+		sendError(w, http.StatusServiceUnavailable, "ServerException", message)
+		return
+	}
+
+	resp := LocalStackCapabilitiesResponse{Capabilities: caps}
+	if err != nil {
+		resp.ProbeError = err.Error()
+	}
+	sendJSON(w, resp)
+}
@@ -0,0 +1,145 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	k8sclient "k8s.io/client-go/kubernetes"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logrouting"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// LogRoutingAPI manages the log routing overrides of clusters, which add
+// sinks, sampling and redaction to the Vector configuration of their namespace
+type LogRoutingAPI struct {
+	storage    storage.Storage
+	kubeClient k8sclient.Interface
+}
+
+// LogRoutingRequest is the body of PUT /api/log-routing/{cluster}
+type LogRoutingRequest struct {
+	Sinks      []logrouting.Sink `json:"sinks,omitempty"`
+	SampleRate int               `json:"sampleRate,omitempty"`
+	Redact     []string          `json:"redact,omitempty"`
+}
+
+// LogRoutingResponse is the response of PUT /api/log-routing/{cluster}
+type LogRoutingResponse struct {
+	Override *logrouting.Override `json:"override"`
+	// Config is the Vector configuration rendered with the override
+	Config string `json:"config"`
+	DryRun bool   `json:"dryRun"`
+}
+
+// NewLogRoutingAPI creates a new log routing API handler
+func NewLogRoutingAPI(storage storage.Storage, kubeClient k8sclient.Interface) *LogRoutingAPI {
+	return &LogRoutingAPI{
+		storage:    storage,
+		kubeClient: kubeClient,
+	}
+}
+
+// SetKubeClient sets the Kubernetes client
+func (api *LogRoutingAPI) SetKubeClient(kubeClient k8sclient.Interface) {
+	api.kubeClient = kubeClient
+}
+
+// RegisterRoutes registers log routing API routes
+func (api *LogRoutingAPI) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/log-routing", api.handleList).Methods("GET")
+	router.HandleFunc("/api/log-routing/{cluster}", api.handleGet).Methods("GET")
+	router.HandleFunc("/api/log-routing/{cluster}", api.handlePut).Methods("PUT")
+	router.HandleFunc("/api/log-routing/{cluster}", api.handleDelete).Methods("DELETE")
+}
+
+// handleList handles GET /api/log-routing
+func (api *LogRoutingAPI) handleList(w http.ResponseWriter, r *http.Request) {
+	overrides, err := logrouting.NewManager(api.kubeClient).List(r.Context())
+	if err != nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", err.Error())
+		return
+	}
+	sendJSON(w, overrides)
+}
+
+// handleGet handles GET /api/log-routing/{cluster}
+func (api *LogRoutingAPI) handleGet(w http.ResponseWriter, r *http.Request) {
+	cluster := mux.Vars(r)["cluster"]
+	override, err := logrouting.NewManager(api.kubeClient).Get(r.Context(), cluster)
+	if err != nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", err.Error())
+		return
+	}
+	if override == nil {
+		sendError(w, http.StatusNotFound, "ResourceNotFoundException", "No log routing override for cluster "+cluster)
+		return
+	}
+	sendJSON(w, override)
+}
+
+// handlePut handles PUT /api/log-routing/{cluster}, replacing the override of
+// the cluster. With ?dryRun=true the override is validated and the Vector
+// configuration rendered without applying it.
+func (api *LogRoutingAPI) handlePut(w http.ResponseWriter, r *http.Request) {
+	var req LogRoutingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", "Invalid request body")
+		return
+	}
+	dryRun := false
+	if value := r.URL.Query().Get("dryRun"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			sendError(w, http.StatusBadRequest, "InvalidParameterException", "dryRun must be true or false")
+			return
+		}
+		dryRun = parsed
+	}
+	if api.storage == nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", "Storage is not available")
+		return
+	}
+
+	ctx := r.Context()
+	clusterName := mux.Vars(r)["cluster"]
+	cluster, err := api.storage.ClusterStore().Get(ctx, clusterName)
+	if err != nil || cluster == nil {
+		sendError(w, http.StatusNotFound, "ClusterNotFoundException", "Cluster not found: "+clusterName)
+		return
+	}
+
+	override := logrouting.Override{
+		Cluster:    cluster.Name,
+		Namespace:  fmt.Sprintf("%s-%s", cluster.Name, cluster.Region),
+		Sinks:      req.Sinks,
+		SampleRate: req.SampleRate,
+		Redact:     req.Redact,
+	}
+	if err := override.Validate(); err != nil {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", err.Error())
+		return
+	}
+	config, err := logrouting.NewManager(api.kubeClient).Put(ctx, override, dryRun)
+	if err != nil {
+		logging.Warn("Failed to apply log routing override", "cluster", cluster.Name, "error", err)
+		sendError(w, http.StatusInternalServerError, "ServerException", err.Error())
+		return
+	}
+	sendJSON(w, LogRoutingResponse{Override: &override, Config: config, DryRun: dryRun})
+}
+
+// handleDelete handles DELETE /api/log-routing/{cluster}
+func (api *LogRoutingAPI) handleDelete(w http.ResponseWriter, r *http.Request) {
+	cluster := mux.Vars(r)["cluster"]
+	if err := logrouting.NewManager(api.kubeClient).Delete(r.Context(), cluster); err != nil {
+		logging.Warn("Failed to delete log routing override", "cluster", cluster, "error", err)
+		sendError(w, http.StatusInternalServerError, "ServerException", err.Error())
+		return
+	}
+	sendJSON(w, map[string]string{"cluster": cluster, "status": "deleted"})
+}
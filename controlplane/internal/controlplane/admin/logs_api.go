@@ -18,6 +18,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8sclient "k8s.io/client-go/kubernetes"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
 	"github.com/nandemo-ya/kecs/controlplane/internal/kubernetes"
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
@@ -31,6 +33,11 @@ type LogsAPI struct {
 	upgrader      websocket.Upgrader
 }
 
+// instanceARN returns the ARN of an ECS resource in the account of the instance
+func instanceARN(region, resource string) string {
+	return awsarn.Build("ecs", region, config.GetString("aws.accountID"), resource)
+}
+
 // NewLogsAPI creates a new logs API handler
 func NewLogsAPI(storage storage.Storage, kubeClient k8sclient.Interface) *LogsAPI {
 	var podLogService *kubernetes.PodLogService
@@ -61,6 +68,7 @@ func (api *LogsAPI) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/api/tasks/{taskId}/containers/{containerName}/logs", api.HandleGetLogs).Methods("GET")
 	router.HandleFunc("/api/tasks/{taskId}/containers/{containerName}/logs/stream", api.HandleStreamLogs).Methods("GET")
 	router.HandleFunc("/api/tasks/{taskId}/containers/{containerName}/logs/ws", api.HandleWebSocketLogs).Methods("GET")
+	router.HandleFunc("/api/services/{service}/logs/stream", api.HandleStreamServiceLogs).Methods("GET")
 	// Add /v1/GetTaskLogs endpoint for KECS TUI
 	router.HandleFunc("/v1/GetTaskLogs", api.HandleGetTaskLogs).Methods("POST")
 	logging.Info("Log API endpoints registered successfully")
@@ -85,7 +93,7 @@ func (api *LogsAPI) HandleGetLogs(w http.ResponseWriter, r *http.Request) {
 		if cluster == "" {
 			cluster = "default"
 		}
-		clusterArn := fmt.Sprintf("arn:aws:ecs:us-east-1:000000000000:cluster/%s", cluster)
+		clusterArn := instanceARN("us-east-1", "cluster/"+cluster)
 
 		// First try to find the task by ID
 		task, err := api.storage.TaskStore().Get(r.Context(), clusterArn, taskId)
@@ -113,7 +121,7 @@ func (api *LogsAPI) HandleGetLogs(w http.ResponseWriter, r *http.Request) {
 
 	// Convert task ID to full ARN for storage lookup
 	// Assume default cluster and region for now
-	taskArn := fmt.Sprintf("arn:aws:ecs:us-east-1:000000000000:task/default/%s", taskId)
+	taskArn := instanceARN("us-east-1", "task/default/"+taskId)
 
 	// Parse query parameters
 	query := r.URL.Query()
@@ -300,7 +308,7 @@ func (api *LogsAPI) HandleStreamLogs(w http.ResponseWriter, r *http.Request) {
 	// Try to get the actual pod name and namespace from task storage
 	var namespace, podName string
 	if api.storage != nil && api.storage.TaskStore() != nil {
-		clusterArn := fmt.Sprintf("arn:aws:ecs:%s:000000000000:cluster/%s", region, cluster)
+		clusterArn := instanceARN(region, "cluster/"+cluster)
 
 		// First try to find the task by ID
 		task, err := api.storage.TaskStore().Get(r.Context(), clusterArn, taskId)
@@ -329,7 +337,7 @@ func (api *LogsAPI) HandleStreamLogs(w http.ResponseWriter, r *http.Request) {
 	// Fall back to parsing if not found in storage
 	if namespace == "" || podName == "" {
 		// Use cluster and region from query params
-		taskArn := fmt.Sprintf("arn:aws:ecs:%s:000000000000:task/%s/%s", region, cluster, taskId)
+		taskArn := instanceARN(region, fmt.Sprintf("task/%s/%s", cluster, taskId))
 
 		// Parse task ARN to get namespace and pod name
 		var err error
@@ -424,7 +432,7 @@ func (api *LogsAPI) HandleWebSocketLogs(w http.ResponseWriter, r *http.Request)
 	// Try to get the actual pod name and namespace from task storage
 	var namespace, podName string
 	if api.storage != nil && api.storage.TaskStore() != nil {
-		clusterArn := fmt.Sprintf("arn:aws:ecs:%s:000000000000:cluster/%s", region, cluster)
+		clusterArn := instanceARN(region, "cluster/"+cluster)
 
 		// First try to find the task by ID
 		task, err := api.storage.TaskStore().Get(r.Context(), clusterArn, taskId)
@@ -453,7 +461,7 @@ func (api *LogsAPI) HandleWebSocketLogs(w http.ResponseWriter, r *http.Request)
 	// Fall back to parsing if not found in storage
 	if namespace == "" || podName == "" {
 		// Use cluster and region from query params
-		taskArn := fmt.Sprintf("arn:aws:ecs:%s:000000000000:task/%s/%s", region, cluster, taskId)
+		taskArn := instanceARN(region, fmt.Sprintf("task/%s/%s", cluster, taskId))
 
 		// Parse task ARN to get namespace and pod name
 		var err error
@@ -544,7 +552,7 @@ func parseTaskArn(taskArn string, region string) (namespace, podName string, err
 	// Example: arn:aws:ecs:us-east-1:000000000000:task/default/multi-container-webapp-66dcddbdd8-x7tqc
 
 	// Check if this is actually an ARN format
-	if !strings.HasPrefix(taskArn, "arn:aws:ecs:") {
+	if !awsarn.IsService(taskArn, "ecs") {
 		return "", "", fmt.Errorf("not a valid task ARN format: %s", taskArn)
 	}
 
@@ -645,7 +653,7 @@ func (api *LogsAPI) HandleGetTaskLogs(w http.ResponseWriter, r *http.Request) {
 	// Get task from storage to validate it exists
 	ctx := r.Context()
 	if api.storage != nil && api.storage.TaskStore() != nil {
-		clusterArn := fmt.Sprintf("arn:aws:ecs:us-east-1:000000000000:cluster/%s", req.Cluster)
+		clusterArn := instanceARN("us-east-1", "cluster/"+req.Cluster)
 		_, err := api.storage.TaskStore().Get(ctx, clusterArn, taskID)
 		if err != nil {
 			api.sendError(w, http.StatusNotFound, "ResourceNotFoundException", fmt.Sprintf("Task not found: %s", taskID))
@@ -860,7 +868,7 @@ func extractNamespaceAndPodName(arn string) (namespace, podName string) {
 
 	// Extract region from ARN
 	var region string
-	if strings.HasPrefix(arn, "arn:aws:ecs:") {
+	if awsarn.IsService(arn, "ecs") {
 		arnParts := strings.Split(arn, ":")
 		if len(arnParts) >= 4 {
 			region = arnParts[3] // region is the 4th part
@@ -0,0 +1,109 @@
+package admin
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/gorilla/mux"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/recorder"
+)
+
+// RecordingAPI controls the recording of API calls and serves recordings
+type RecordingAPI struct {
+	recorder *recorder.Recorder
+}
+
+// StartRecordingRequest is the request of POST /api/recording/start
+type StartRecordingRequest struct {
+	// Reset discards the calls recorded before
+	Reset bool `json:"reset"`
+}
+
+// NewRecordingAPI creates a new recording API handler
+func NewRecordingAPI(rec *recorder.Recorder) *RecordingAPI {
+	return &RecordingAPI{recorder: rec}
+}
+
+// RegisterRoutes registers recording API routes
+func (api *RecordingAPI) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/recording", api.handleStatus).Methods("GET")
+	router.HandleFunc("/api/recording/start", api.handleStart).Methods("POST")
+	router.HandleFunc("/api/recording/stop", api.handleStop).Methods("POST")
+	router.HandleFunc("/api/recording/interactions", api.handleDownload).Methods("GET")
+}
+
+// handleStatus handles GET /api/recording
+func (api *RecordingAPI) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if !api.available(w) {
+		return
+	}
+	sendJSON(w, api.recorder.Status())
+}
+
+// handleStart handles POST /api/recording/start
+func (api *RecordingAPI) handleStart(w http.ResponseWriter, r *http.Request) {
+	if !api.available(w) {
+		return
+	}
+	var req StartRecordingRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			sendError(w, http.StatusBadRequest, "InvalidParameterException", "Invalid request body")
+			return
+		}
+	}
+	if err := api.recorder.Start(req.Reset); err != nil {
+		sendError(w, http.StatusInternalServerError, "ServerException", err.Error())
+		return
+	}
+	sendJSON(w, api.recorder.Status())
+}
+
+// handleStop handles POST /api/recording/stop
+func (api *RecordingAPI) handleStop(w http.ResponseWriter, r *http.Request) {
+	if !api.available(w) {
+		return
+	}
+	if err := api.recorder.Stop(); err != nil {
+		sendError(w, http.StatusInternalServerError, "ServerException", err.Error())
+		return
+	}
+	sendJSON(w, api.recorder.Status())
+}
+
+// handleDownload handles GET /api/recording/interactions, streaming the
+// recording file as JSON lines
+func (api *RecordingAPI) handleDownload(w http.ResponseWriter, r *http.Request) {
+	if !api.available(w) {
+		return
+	}
+	file, err := api.recorder.Open()
+	if os.IsNotExist(err) {
+		sendError(w, http.StatusNotFound, "ResourceNotFoundException", "No API calls have been recorded")
+		return
+	}
+	if err != nil {
+		sendError(w, http.StatusInternalServerError, "ServerException", err.Error())
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, file); err != nil {
+		logging.Error("Failed to send recording", "error", err)
+	}
+}
+
+// available reports whether the AWS API server records calls, answering the request when not
+func (api *RecordingAPI) available(w http.ResponseWriter) bool {
+	if api.recorder == nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", "Recording requires a data directory")
+		return false
+	}
+	return true
+}
@@ -10,10 +10,19 @@ import (
 	"github.com/gorilla/mux"
 	k8sclient "k8s.io/client-go/kubernetes"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/audit"
+	"github.com/nandemo-ya/kecs/controlplane/internal/backup"
+	"github.com/nandemo-ya/kecs/controlplane/internal/canary"
+	"github.com/nandemo-ya/kecs/controlplane/internal/chaos"
 	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/devsync"
+	"github.com/nandemo-ya/kecs/controlplane/internal/localstack"
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
 	"github.com/nandemo-ya/kecs/controlplane/internal/middleware"
+	"github.com/nandemo-ya/kecs/controlplane/internal/recorder"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/usage"
 )
 
 // Server represents the HTTP admin server for KECS Control Plane
@@ -26,6 +35,25 @@ type Server struct {
 	instanceAPI      *InstanceAPI
 	ecsProxy         *ECSProxy
 	logsAPI          *LogsAPI
+	chaosAPI         *ChaosAPI
+	benchAPI         *BenchAPI
+	buildAPI         *BuildAPI
+	devAPI           *DevAPI
+	debugAPI         *DebugAPI
+	trafficAPI       *TrafficAPI
+	auditAPI         *AuditAPI
+	recordingAPI     *RecordingAPI
+	startupAPI       *StartupAPI
+	taskDefAPI       *TaskDefinitionAPI
+	localStackAPI    *LocalStackAPI
+	exportAPI        *ExportAPI
+	serviceDiffAPI   *ServiceDiffAPI
+	importAPI        *ImportAPI
+	backupAPI        *BackupAPI
+	clusterAPI       *ClusterAPI
+	usageAPI         *UsageAPI
+	logRoutingAPI    *LogRoutingAPI
+	capacityAPI      *CapacityAPI
 	kubeClient       k8sclient.Interface
 }
 
@@ -63,6 +91,25 @@ func NewServer(port int, storage storage.Storage) *Server {
 
 	// Initialize Logs API with storage (Kubernetes client will be set later)
 	s.logsAPI = NewLogsAPI(storage, nil)
+	s.chaosAPI = NewChaosAPI(storage, nil)
+	s.benchAPI = NewBenchAPI(storage, nil)
+	s.buildAPI = NewBuildAPI(storage, nil)
+	s.devAPI = NewDevAPI(storage)
+	s.debugAPI = NewDebugAPI(storage, nil)
+	s.trafficAPI = NewTrafficAPI()
+	s.auditAPI = NewAuditAPI(nil)
+	s.recordingAPI = NewRecordingAPI(nil)
+	s.startupAPI = NewStartupAPI(storage)
+	s.taskDefAPI = NewTaskDefinitionAPI(storage)
+	s.localStackAPI = NewLocalStackAPI(nil)
+	s.exportAPI = NewExportAPI(storage, cfg.AWS.DefaultRegion, cfg.AWS.AccountID)
+	s.serviceDiffAPI = NewServiceDiffAPI(storage, cfg.AWS.DefaultRegion, cfg.AWS.AccountID)
+	s.importAPI = NewImportAPI(nil, cfg.AWS.DefaultRegion, cfg.AWS.AccountID)
+	s.backupAPI = NewBackupAPI(storage, backup.NewS3StoreFromConfig(), cfg.AWS.DefaultRegion)
+	s.clusterAPI = NewClusterAPI()
+	s.usageAPI = NewUsageAPI(storage, nil)
+	s.logRoutingAPI = NewLogRoutingAPI(storage, nil)
+	s.capacityAPI = NewCapacityAPI(nil)
 
 	return s
 }
@@ -76,6 +123,69 @@ func (s *Server) SetKubeClient(kubeClient k8sclient.Interface) {
 	} else {
 		s.logsAPI.SetKubeClient(kubeClient)
 	}
+	s.chaosAPI.SetKubeClient(kubeClient)
+	s.benchAPI.SetKubeClient(kubeClient)
+	s.buildAPI.SetKubeClient(kubeClient)
+	s.debugAPI.SetKubeClient(kubeClient)
+	s.importAPI.SetKubeClient(kubeClient)
+	s.logRoutingAPI.SetKubeClient(kubeClient)
+	s.capacityAPI.SetKubeClient(kubeClient)
+}
+
+// SetECSAPI sets the ECS API that imported resources are created and built
+// images are registered through
+func (s *Server) SetECSAPI(ecs generated.ECSAPIInterface) {
+	s.importAPI.SetECSClient(ecs)
+	s.buildAPI.SetECSClient(ecs)
+}
+
+// SetClusterDeleter sets the ECS API clusters are deleted through
+func (s *Server) SetClusterDeleter(deleter ClusterDeleter) {
+	s.clusterAPI.SetClusterDeleter(deleter)
+}
+
+// SetServiceEnqueuer sets the reconciler that restored services are
+// enqueued to
+func (s *Server) SetServiceEnqueuer(services ServiceEnqueuer) {
+	s.backupAPI.SetServiceEnqueuer(services)
+}
+
+// SetDevSyncer sets the syncer that applies local source changes to tasks
+func (s *Server) SetDevSyncer(syncer *devsync.Syncer) {
+	s.devAPI.SetSyncer(syncer)
+}
+
+// SetTrafficShifter sets the shifter that moves service traffic to green
+// services
+func (s *Server) SetTrafficShifter(shifter *canary.Shifter) {
+	s.trafficAPI.SetShifter(shifter)
+}
+
+// SetFaultInjector sets the fault injector managed by the chaos API
+func (s *Server) SetFaultInjector(faultInjector *chaos.FaultInjector) {
+	s.chaosAPI.SetFaultInjector(faultInjector)
+}
+
+// SetAuditLog sets the audit log of the AWS API server
+func (s *Server) SetAuditLog(log *audit.Log) {
+	s.auditAPI = NewAuditAPI(log)
+}
+
+// SetRecorder sets the recorder of API calls of the AWS API server
+func (s *Server) SetRecorder(rec *recorder.Recorder) {
+	s.recordingAPI = NewRecordingAPI(rec)
+}
+
+// SetUsageLedger sets the usage records of tasks deleted from storage
+func (s *Server) SetUsageLedger(ledger *usage.Ledger) {
+	s.usageAPI.ledger = ledger
+}
+
+// SetLocalStackCapabilities sets the provider of the LocalStack capability
+// prober. It is a provider because the prober is replaced whenever the
+// LocalStack manager changes.
+func (s *Server) SetLocalStackCapabilities(prober func() *localstack.CapabilityProber) {
+	s.localStackAPI = NewLocalStackAPI(prober)
 }
 
 // SetStorage sets the storage for admin APIs
@@ -86,6 +196,26 @@ func (s *Server) SetStorage(storage storage.Storage) {
 	} else {
 		s.logsAPI = NewLogsAPI(storage, s.logsAPI.kubeClient)
 	}
+	faultInjector := s.chaosAPI.faultInjector
+	s.chaosAPI = NewChaosAPI(storage, s.chaosAPI.kubeClient)
+	s.chaosAPI.SetFaultInjector(faultInjector)
+	s.benchAPI = NewBenchAPI(storage, s.benchAPI.kubeClient)
+	buildECS := s.buildAPI.ecs
+	s.buildAPI = NewBuildAPI(storage, s.buildAPI.kubeClient)
+	s.buildAPI.SetECSClient(buildECS)
+	devSyncer := s.devAPI.syncer
+	s.devAPI = NewDevAPI(storage)
+	s.devAPI.SetSyncer(devSyncer)
+	s.debugAPI = NewDebugAPI(storage, s.debugAPI.kubeClient)
+	s.startupAPI = NewStartupAPI(storage)
+	s.usageAPI = NewUsageAPI(storage, s.usageAPI.ledger)
+	s.taskDefAPI = NewTaskDefinitionAPI(storage)
+	s.exportAPI = NewExportAPI(storage, s.config.AWS.DefaultRegion, s.config.AWS.AccountID)
+	s.serviceDiffAPI = NewServiceDiffAPI(storage, s.config.AWS.DefaultRegion, s.config.AWS.AccountID)
+	backupServices := s.backupAPI.services
+	s.backupAPI = NewBackupAPI(storage, s.backupAPI.objects, s.config.AWS.DefaultRegion)
+	s.backupAPI.SetServiceEnqueuer(backupServices)
+	s.logRoutingAPI = NewLogRoutingAPI(storage, s.logRoutingAPI.kubeClient)
 }
 
 // Start starts the HTTP admin server
@@ -144,6 +274,61 @@ func (s *Server) setupRoutes() http.Handler {
 		logging.Warn("Logs API is nil, not registering routes")
 	}
 
+	// Register chaos simulation endpoints
+	s.chaosAPI.RegisterRoutes(router)
+
+	// Register load generation endpoints
+	s.benchAPI.RegisterRoutes(router)
+
+	// Register image build endpoints
+	s.buildAPI.RegisterRoutes(router)
+
+	// Register source sync endpoints of kecs dev
+	s.devAPI.RegisterRoutes(router)
+
+	// Register debug container endpoints
+	s.debugAPI.RegisterRoutes(router)
+
+	// Register canary traffic shift endpoints
+	s.trafficAPI.RegisterRoutes(router)
+
+	// Register audit log endpoints
+	s.auditAPI.RegisterRoutes(router)
+
+	// Register API call recording endpoints
+	s.recordingAPI.RegisterRoutes(router)
+
+	// Register task startup metrics endpoints
+	s.startupAPI.RegisterRoutes(router)
+
+	// Register task definition inspection endpoints
+	s.taskDefAPI.RegisterRoutes(router)
+
+	// Register LocalStack capability endpoints
+	s.localStackAPI.RegisterRoutes(router)
+
+	// Register manifest export endpoints
+	s.exportAPI.RegisterRoutes(router)
+	s.importAPI.RegisterRoutes(router)
+
+	// Register service diff endpoints
+	s.serviceDiffAPI.RegisterRoutes(router)
+
+	// Register S3 backup and restore endpoints
+	s.backupAPI.RegisterRoutes(router)
+
+	// Register cluster extension endpoints
+	s.clusterAPI.RegisterRoutes(router)
+
+	// Register task usage and cost estimation endpoints
+	s.usageAPI.RegisterRoutes(router)
+
+	// Register log routing override endpoints
+	s.logRoutingAPI.RegisterRoutes(router)
+
+	// Register node capacity endpoints
+	s.capacityAPI.RegisterRoutes(router)
+
 	// Add middleware
 	handler := http.Handler(router)
 
@@ -0,0 +1,75 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/servicediff"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// ServiceDiffAPI compares services with the task definitions or specs they
+// would be updated to
+type ServiceDiffAPI struct {
+	storage   storage.Storage
+	region    string
+	accountID string
+}
+
+// ServiceDiffRequest is the request of POST /api/services/diff
+type ServiceDiffRequest struct {
+	Cluster string `json:"cluster"`
+	Service string `json:"service"`
+	servicediff.Target
+}
+
+// NewServiceDiffAPI creates a new service diff API handler
+func NewServiceDiffAPI(storage storage.Storage, region, accountID string) *ServiceDiffAPI {
+	return &ServiceDiffAPI{storage: storage, region: region, accountID: accountID}
+}
+
+// RegisterRoutes registers service diff API routes
+func (api *ServiceDiffAPI) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/services/diff", api.handleDiff).Methods("POST")
+}
+
+// handleDiff handles POST /api/services/diff
+func (api *ServiceDiffAPI) handleDiff(w http.ResponseWriter, r *http.Request) {
+	if api.storage == nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", "Storage is not available")
+		return
+	}
+	var req ServiceDiffRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", "Invalid request body: "+err.Error())
+		return
+	}
+	if req.Cluster == "" {
+		req.Cluster = "default"
+	}
+	if req.Service == "" {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", "service is required")
+		return
+	}
+
+	differ := servicediff.NewDiffer(api.storage, converters.NewServiceConverter(api.region, api.accountID))
+	report, err := differ.Diff(r.Context(), req.Cluster, req.Service, req.Target)
+	if err != nil {
+		switch {
+		case errors.Is(err, servicediff.ErrInvalidTarget):
+			sendError(w, http.StatusBadRequest, "InvalidParameterException", err.Error())
+		case errors.Is(err, storage.ErrResourceNotFound):
+			sendError(w, http.StatusNotFound, "ResourceNotFoundException", err.Error())
+		default:
+			logging.Error("Failed to diff service", "cluster", req.Cluster, "service", req.Service, "error", err)
+			sendError(w, http.StatusInternalServerError, "ServerException", err.Error())
+		}
+		return
+	}
+	sendJSON(w, report)
+}
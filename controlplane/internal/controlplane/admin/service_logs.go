@@ -0,0 +1,360 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/kubernetes"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// serviceLogsPollInterval is how often a followed service is checked for
+// tasks that started or stopped
+const serviceLogsPollInterval = 2 * time.Second
+
+// ServiceLogLine is a log line of one container of a service task
+type ServiceLogLine struct {
+	TaskID    string    `json:"taskId"`
+	TaskARN   string    `json:"taskArn"`
+	Container string    `json:"container"`
+	Timestamp time.Time `json:"timestamp"`
+	Message   string    `json:"message"`
+}
+
+// ServiceLogTaskEvent reports that a task of a followed service started or
+// stopped
+type ServiceLogTaskEvent struct {
+	TaskID  string `json:"taskId"`
+	TaskARN string `json:"taskArn"`
+	Status  string `json:"status"`
+}
+
+// serviceLogsRequest holds the parsed query of a service log stream
+type serviceLogsRequest struct {
+	cluster *storage.Cluster
+	service string
+	follow  bool
+	tail    int
+	since   *time.Time
+	filter  *regexp.Regexp
+}
+
+// HandleStreamServiceLogs handles GET /api/services/{service}/logs/stream. It
+// streams the logs of all tasks of a service as server-sent events: "log"
+// events carry a ServiceLogLine, "task" events a ServiceLogTaskEvent. The
+// query parameters are cluster (name or ARN, default "default"), follow,
+// tail (lines per container), since (RFC 3339) and filter (a regular
+// expression matched against each line).
+func (api *LogsAPI) HandleStreamServiceLogs(w http.ResponseWriter, r *http.Request) {
+	if api.storage == nil || api.podLogService == nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", "Log streaming is not available")
+		return
+	}
+
+	req, status, err := api.parseServiceLogsRequest(r)
+	if err != nil {
+		errType := "InvalidParameterException"
+		if status == http.StatusNotFound {
+			errType = "ResourceNotFoundException"
+		}
+		sendError(w, status, errType, err.Error())
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	if req.follow {
+		// Followed streams outlive the server write timeout
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+			logging.Debug("Failed to clear write deadline for log stream", "error", err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	send := func(event string, data interface{}) {
+		payload, _ := json.Marshal(data)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+		flusher.Flush()
+	}
+
+	if req.follow {
+		api.followServiceLogs(r.Context(), req, send)
+	} else {
+		api.readServiceLogs(r.Context(), req, send)
+	}
+	fmt.Fprintf(w, "event: close\ndata: stream ended\n\n")
+	flusher.Flush()
+}
+
+// parseServiceLogsRequest validates the query of a service log stream and
+// returns the HTTP status to report on failure
+func (api *LogsAPI) parseServiceLogsRequest(r *http.Request) (*serviceLogsRequest, int, error) {
+	query := r.URL.Query()
+	req := &serviceLogsRequest{
+		service: mux.Vars(r)["service"],
+		follow:  query.Get("follow") == "true",
+	}
+
+	if v := query.Get("tail"); v != "" {
+		tail, err := strconv.Atoi(v)
+		if err != nil || tail < 0 {
+			return nil, http.StatusBadRequest, fmt.Errorf("tail must be a non-negative number")
+		}
+		req.tail = tail
+	}
+	if v := query.Get("since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("since must be an RFC 3339 timestamp")
+		}
+		req.since = &since
+	}
+	if v := query.Get("filter"); v != "" {
+		filter, err := regexp.Compile(v)
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid filter: %v", err)
+		}
+		req.filter = filter
+	}
+
+	clusterName := query.Get("cluster")
+	if clusterName == "" {
+		clusterName = "default"
+	}
+	cluster, err := api.storage.ClusterStore().Get(r.Context(), clusterName)
+	if err != nil {
+		return nil, http.StatusNotFound, fmt.Errorf("cluster not found: %s", clusterName)
+	}
+	if _, err := api.storage.ServiceStore().Get(r.Context(), cluster.ARN, req.service); err != nil {
+		return nil, http.StatusNotFound, fmt.Errorf("service not found: %s", req.service)
+	}
+	req.cluster = cluster
+	return req, 0, nil
+}
+
+// readServiceLogs sends the logs written so far by the running tasks of a
+// service, oldest first
+func (api *LogsAPI) readServiceLogs(ctx context.Context, req *serviceLogsRequest, send func(string, interface{})) {
+	tasks, err := api.listServiceTasks(ctx, req)
+	if err != nil {
+		send("error", err.Error())
+		return
+	}
+
+	var lines []ServiceLogLine
+	for _, task := range tasks {
+		namespace, podName := taskPod(task)
+		for _, container := range api.taskContainers(ctx, task, namespace, podName) {
+			logs, err := api.podLogService.GetLogs(ctx, namespace, podName, container, &kubernetes.LogOptions{
+				TailLines: req.tail,
+				SinceTime: req.since,
+			})
+			if err != nil {
+				logging.Debug("Failed to get container logs", "task", task.ARN, "container", container, "error", err)
+				continue
+			}
+			for _, log := range logs {
+				if line := newServiceLogLine(task, container, log); req.matches(line) {
+					lines = append(lines, line)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].Timestamp.Before(lines[j].Timestamp)
+	})
+	for _, line := range lines {
+		send("log", line)
+	}
+}
+
+// followServiceLogs streams the logs of the tasks of a service until the
+// client disconnects. Tasks that start later are picked up from their first
+// line; tasks that stop are reported once they leave the running tasks.
+func (api *LogsAPI) followServiceLogs(ctx context.Context, req *serviceLogsRequest, send func(string, interface{})) {
+	lines := make(chan ServiceLogLine, 100)
+	// streams records the container streams started, keyed by task ARN and
+	// container name, and running the tasks with at least one stream
+	streams := make(map[string]bool)
+	running := make(map[string]*storage.Task)
+	initial := true
+
+	poll := func() {
+		tasks, err := api.listServiceTasks(ctx, req)
+		if err != nil {
+			logging.Warn("Failed to list service tasks for log stream", "service", req.service, "error", err)
+			return
+		}
+
+		current := make(map[string]bool, len(tasks))
+		for _, task := range tasks {
+			current[task.ARN] = true
+			namespace, podName := taskPod(task)
+			if podName == "" {
+				continue
+			}
+
+			// Tasks found later are streamed from their first line
+			options := &kubernetes.LogOptions{}
+			if initial {
+				options.TailLines = req.tail
+				options.SinceTime = req.since
+			}
+			for _, container := range api.taskContainers(ctx, task, namespace, podName) {
+				key := task.ARN + "/" + container
+				if streams[key] {
+					continue
+				}
+				logs, _, err := api.podLogService.StreamLogs(ctx, namespace, podName, container, options)
+				if err != nil {
+					// The container may still be starting, retried on the next poll
+					logging.Debug("Failed to stream container logs", "task", task.ARN, "container", container, "error", err)
+					continue
+				}
+				streams[key] = true
+				go forwardServiceLogs(ctx, task, container, logs, lines)
+
+				if _, ok := running[task.ARN]; ok {
+					continue
+				}
+				running[task.ARN] = task
+				send("task", ServiceLogTaskEvent{TaskID: taskID(task), TaskARN: task.ARN, Status: "RUNNING"})
+			}
+		}
+
+		for arn, task := range running {
+			if !current[arn] {
+				delete(running, arn)
+				send("task", ServiceLogTaskEvent{TaskID: taskID(task), TaskARN: arn, Status: "STOPPED"})
+			}
+		}
+		initial = false
+	}
+
+	poll()
+	ticker := time.NewTicker(serviceLogsPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case line := <-lines:
+			if req.matches(line) {
+				send("log", line)
+			}
+		case <-ticker.C:
+			poll()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// forwardServiceLogs forwards the log stream of a task container until it ends
+func forwardServiceLogs(ctx context.Context, task *storage.Task, container string, logs <-chan storage.TaskLog, lines chan<- ServiceLogLine) {
+	for log := range logs {
+		select {
+		case lines <- newServiceLogLine(task, container, log):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// listServiceTasks returns the tasks of a service that have not stopped
+func (api *LogsAPI) listServiceTasks(ctx context.Context, req *serviceLogsRequest) ([]*storage.Task, error) {
+	tasks, err := api.storage.TaskStore().List(ctx, req.cluster.ARN, storage.TaskFilters{ServiceName: req.service})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+	active := make([]*storage.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if task.LastStatus != "STOPPED" {
+			active = append(active, task)
+		}
+	}
+	return active, nil
+}
+
+// taskContainers returns the container names of a task, read from the pod
+// when the task does not list them
+func (api *LogsAPI) taskContainers(ctx context.Context, task *storage.Task, namespace, podName string) []string {
+	names := taskContainerNames(task)
+	if len(names) > 0 || api.kubeClient == nil || podName == "" {
+		return names
+	}
+
+	pod, err := api.kubeClient.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return nil
+	}
+	for _, c := range pod.Spec.Containers {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// taskContainerNames returns the container names the task lists
+func taskContainerNames(task *storage.Task) []string {
+	var containers []struct {
+		Name string `json:"name"`
+	}
+	if task.Containers != "" {
+		if err := json.Unmarshal([]byte(task.Containers), &containers); err != nil {
+			logging.Debug("Failed to parse task containers", "task", task.ARN, "error", err)
+		}
+	}
+	var names []string
+	for _, c := range containers {
+		if c.Name != "" {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+// matches reports whether a line passes the filter of the request
+func (req *serviceLogsRequest) matches(line ServiceLogLine) bool {
+	return req.filter == nil || req.filter.MatchString(line.Message)
+}
+
+// taskPod returns the namespace and name of the pod of a task
+func taskPod(task *storage.Task) (namespace, podName string) {
+	if namespace, podName = extractPodInfoFromTaskAttributes(task); namespace != "" && podName != "" {
+		return namespace, podName
+	}
+	return task.Namespace, task.PodName
+}
+
+// taskID returns the ID of a task, the last segment of its ARN
+func taskID(task *storage.Task) string {
+	if id := extractTaskIDFromArn(task.ARN); id != "" {
+		return id
+	}
+	return task.ID
+}
+
+func newServiceLogLine(task *storage.Task, container string, log storage.TaskLog) ServiceLogLine {
+	return ServiceLogLine{
+		TaskID:    taskID(task),
+		TaskARN:   task.ARN,
+		Container: container,
+		Timestamp: log.Timestamp,
+		Message:   log.LogLine,
+	}
+}
@@ -0,0 +1,75 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/startupmetrics"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// StartupAPI reports how long tasks take to start
+type StartupAPI struct {
+	storage storage.Storage
+}
+
+// NewStartupAPI creates a new startup metrics API handler
+func NewStartupAPI(storage storage.Storage) *StartupAPI {
+	return &StartupAPI{storage: storage}
+}
+
+// RegisterRoutes registers startup metrics API routes
+func (api *StartupAPI) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/metrics/startup", api.handleReport).Methods("GET")
+}
+
+// handleReport handles GET /api/metrics/startup. Tasks can be filtered with
+// the cluster, service and since (RFC 3339, matched against the creation
+// time) query parameters.
+func (api *StartupAPI) handleReport(w http.ResponseWriter, r *http.Request) {
+	if api.storage == nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", "Storage is not available")
+		return
+	}
+
+	params := r.URL.Query()
+	var since time.Time
+	if v := params.Get("since"); v != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339, v); err != nil {
+			sendError(w, http.StatusBadRequest, "InvalidParameterException", "since must be an RFC 3339 timestamp")
+			return
+		}
+	}
+
+	ctx := r.Context()
+	clusters, err := api.storage.ClusterStore().List(ctx)
+	if err != nil {
+		sendError(w, http.StatusInternalServerError, "ServerException", "Failed to list clusters")
+		return
+	}
+
+	var tasks []*storage.Task
+	for _, cluster := range clusters {
+		if name := params.Get("cluster"); name != "" && name != cluster.Name && name != cluster.ARN {
+			continue
+		}
+		clusterTasks, err := api.storage.TaskStore().List(ctx, cluster.ARN, storage.TaskFilters{
+			ServiceName: params.Get("service"),
+		})
+		if err != nil {
+			logging.Warn("Failed to list tasks for startup report", "cluster", cluster.Name, "error", err)
+			continue
+		}
+		for _, task := range clusterTasks {
+			if since.IsZero() || !task.CreatedAt.Before(since) {
+				tasks = append(tasks, task)
+			}
+		}
+	}
+
+	sendJSON(w, startupmetrics.BuildReport(tasks))
+}
@@ -0,0 +1,128 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/taskdefgc"
+)
+
+// TaskDefinitionAPI inspects task definition revisions
+type TaskDefinitionAPI struct {
+	storage storage.Storage
+}
+
+// NewTaskDefinitionAPI creates a new task definition API handler
+func NewTaskDefinitionAPI(storage storage.Storage) *TaskDefinitionAPI {
+	return &TaskDefinitionAPI{storage: storage}
+}
+
+// RegisterRoutes registers task definition API routes
+func (api *TaskDefinitionAPI) RegisterRoutes(router *mux.Router) {
+	// ARNs contain a slash, so the revision matches across path segments
+	router.HandleFunc("/api/task-definitions/{taskDefinition:.+}/references", api.handleReferences).Methods("GET")
+	router.HandleFunc("/api/task-definitions/gc", api.handleGCPlan).Methods("GET")
+}
+
+// handleReferences handles GET /api/task-definitions/{taskDefinition}/references,
+// where taskDefinition is family:revision or an ARN. It lists the services,
+// task sets and tasks of all clusters that use the revision, so it can be
+// checked before the revision is deregistered.
+func (api *TaskDefinitionAPI) handleReferences(w http.ResponseWriter, r *http.Request) {
+	if api.storage == nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", "Storage is not available")
+		return
+	}
+
+	ctx := r.Context()
+	taskDef, err := api.getTaskDefinition(ctx, mux.Vars(r)["taskDefinition"])
+	if err != nil {
+		if errors.Is(err, storage.ErrResourceNotFound) {
+			sendError(w, http.StatusNotFound, "ClientException", "Task definition not found")
+			return
+		}
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", err.Error())
+		return
+	}
+
+	refs, err := storage.FindTaskDefinitionReferences(ctx, api.storage, taskDef)
+	if err != nil {
+		logging.Error("Failed to find task definition references", "taskDefinition", taskDef.ARN, "error", err)
+		sendError(w, http.StatusInternalServerError, "ServerException", "Failed to find task definition references")
+		return
+	}
+
+	sendJSON(w, refs)
+}
+
+// handleGCPlan handles GET /api/task-definitions/gc. It reports what the
+// garbage collection policy would deregister and purge, and which revisions
+// it protects, without changing anything. The keepActiveRevisions,
+// inactiveRetention and taskWindow parameters override the configured policy.
+func (api *TaskDefinitionAPI) handleGCPlan(w http.ResponseWriter, r *http.Request) {
+	if api.storage == nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", "Storage is not available")
+		return
+	}
+
+	policy := taskdefgc.PolicyFromConfig()
+	query := r.URL.Query()
+	if value := query.Get("keepActiveRevisions"); value != "" {
+		keep, err := strconv.Atoi(value)
+		if err != nil || keep < 0 {
+			sendError(w, http.StatusBadRequest, "InvalidParameterException", "keepActiveRevisions must be a non-negative integer")
+			return
+		}
+		policy.KeepActiveRevisions = keep
+	}
+	for name, target := range map[string]*time.Duration{
+		"inactiveRetention": &policy.InactiveRetention,
+		"taskWindow":        &policy.TaskWindow,
+	} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration < 0 {
+			sendError(w, http.StatusBadRequest, "InvalidParameterException", fmt.Sprintf("%s must be a duration such as 720h", name))
+			return
+		}
+		*target = duration
+	}
+	policy.DryRun = true
+
+	report, err := taskdefgc.Plan(r.Context(), api.storage, policy, time.Now())
+	if err != nil {
+		logging.Error("Failed to plan task definition garbage collection", "error", err)
+		sendError(w, http.StatusInternalServerError, "ServerException", "Failed to plan task definition garbage collection")
+		return
+	}
+
+	sendJSON(w, report)
+}
+
+// getTaskDefinition resolves a family:revision or an ARN to its revision
+func (api *TaskDefinitionAPI) getTaskDefinition(ctx context.Context, identifier string) (*storage.TaskDefinition, error) {
+	if strings.HasPrefix(identifier, "arn:") {
+		return api.storage.TaskDefinitionStore().GetByARN(ctx, identifier)
+	}
+	family, revisionStr, ok := strings.Cut(identifier, ":")
+	if !ok || family == "" {
+		return nil, fmt.Errorf("task definition must be family:revision or an ARN")
+	}
+	revision, err := strconv.Atoi(revisionStr)
+	if err != nil || revision < 1 {
+		return nil, fmt.Errorf("invalid task definition revision: %s", revisionStr)
+	}
+	return api.storage.TaskDefinitionStore().Get(ctx, family, revision)
+}
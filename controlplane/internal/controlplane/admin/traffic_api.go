@@ -0,0 +1,127 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/mux"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/canary"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// TrafficAPI shifts the traffic of services to a green copy of them. Shifts
+// run in the background, the last shift of every service is kept in memory.
+type TrafficAPI struct {
+	shifter *canary.Shifter
+
+	mu     sync.Mutex
+	shifts map[string]*trafficShift
+}
+
+// trafficShift is a shift started through the API
+type trafficShift struct {
+	report canary.Report
+	cancel context.CancelFunc
+}
+
+// NewTrafficAPI creates a new traffic API handler
+func NewTrafficAPI() *TrafficAPI {
+	return &TrafficAPI{shifts: make(map[string]*trafficShift)}
+}
+
+// SetShifter sets the shifter traffic shifts are carried out with
+func (api *TrafficAPI) SetShifter(shifter *canary.Shifter) {
+	api.shifter = shifter
+}
+
+// RegisterRoutes registers traffic API routes
+func (api *TrafficAPI) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/traffic-shifts", api.handleStart).Methods("POST")
+	router.HandleFunc("/api/traffic-shifts/{cluster}/{service}", api.handleGet).Methods("GET")
+	router.HandleFunc("/api/traffic-shifts/{cluster}/{service}", api.handleStop).Methods("DELETE")
+}
+
+// handleStart handles POST /api/traffic-shifts. The shift outlives the
+// request, its progress is read with GET.
+func (api *TrafficAPI) handleStart(w http.ResponseWriter, r *http.Request) {
+	var spec canary.Spec
+	if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", "Invalid request body")
+		return
+	}
+	if err := spec.Validate(); err != nil {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", err.Error())
+		return
+	}
+	if api.shifter == nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", "ECS or ELBv2 API is not available")
+		return
+	}
+
+	key := spec.Cluster + "/" + spec.Service
+	api.mu.Lock()
+	if current, ok := api.shifts[key]; ok && !current.report.Done() {
+		api.mu.Unlock()
+		sendError(w, http.StatusConflict, "InvalidParameterException", "A traffic shift is already running for service "+spec.Service)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	shift := &trafficShift{
+		report: canary.Report{Spec: spec, Status: canary.StatusRunning},
+		cancel: cancel,
+	}
+	api.shifts[key] = shift
+	api.mu.Unlock()
+
+	logging.Info("Starting traffic shift", "cluster", spec.Cluster, "service", spec.Service, "weight", spec.Weight)
+	go func() {
+		defer cancel()
+		report := api.shifter.Run(ctx, spec, func(report canary.Report) {
+			api.mu.Lock()
+			shift.report = report
+			api.mu.Unlock()
+		})
+		logging.Info("Traffic shift ended", "cluster", spec.Cluster, "service", spec.Service,
+			"status", report.Status, "greenWeight", report.GreenWeight)
+	}()
+
+	sendJSON(w, shift.report)
+}
+
+// handleGet handles GET /api/traffic-shifts/{cluster}/{service}
+func (api *TrafficAPI) handleGet(w http.ResponseWriter, r *http.Request) {
+	shift := api.shift(r)
+	if shift == nil {
+		sendError(w, http.StatusNotFound, "ResourceNotFoundException", "No traffic shift for service "+mux.Vars(r)["service"])
+		return
+	}
+	api.mu.Lock()
+	report := shift.report
+	api.mu.Unlock()
+	sendJSON(w, report)
+}
+
+// handleStop handles DELETE /api/traffic-shifts/{cluster}/{service}. The
+// weights of the last step stay in place.
+func (api *TrafficAPI) handleStop(w http.ResponseWriter, r *http.Request) {
+	shift := api.shift(r)
+	if shift == nil {
+		sendError(w, http.StatusNotFound, "ResourceNotFoundException", "No traffic shift for service "+mux.Vars(r)["service"])
+		return
+	}
+	shift.cancel()
+	api.mu.Lock()
+	report := shift.report
+	api.mu.Unlock()
+	sendJSON(w, report)
+}
+
+func (api *TrafficAPI) shift(r *http.Request) *trafficShift {
+	vars := mux.Vars(r)
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return api.shifts[vars["cluster"]+"/"+vars["service"]]
+}
@@ -0,0 +1,123 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/usage"
+)
+
+// defaultUsagePeriod is the period reported when no since is given
+const defaultUsagePeriod = 24 * time.Hour
+
+// UsageAPI estimates the cost of the tasks run by the instance
+type UsageAPI struct {
+	storage storage.Storage
+	ledger  *usage.Ledger
+}
+
+// NewUsageAPI creates a new usage API handler
+func NewUsageAPI(storage storage.Storage, ledger *usage.Ledger) *UsageAPI {
+	return &UsageAPI{storage: storage, ledger: ledger}
+}
+
+// RegisterRoutes registers usage API routes
+func (api *UsageAPI) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/usage/cost", api.handleCost).Methods("GET")
+}
+
+// handleCost handles GET /api/usage/cost. The period is set with the since
+// and until query parameters (RFC 3339), it defaults to the last 24 hours.
+// Tasks can be filtered with the cluster query parameter.
+func (api *UsageAPI) handleCost(w http.ResponseWriter, r *http.Request) {
+	if api.storage == nil {
+		sendError(w, http.StatusServiceUnavailable, "ServerException", "Storage is not available")
+		return
+	}
+
+	params := r.URL.Query()
+	until := time.Now().UTC()
+	if v := params.Get("until"); v != "" {
+		var err error
+		if until, err = time.Parse(time.RFC3339, v); err != nil {
+			sendError(w, http.StatusBadRequest, "InvalidParameterException", "until must be an RFC 3339 timestamp")
+			return
+		}
+	}
+	since := until.Add(-defaultUsagePeriod)
+	if v := params.Get("since"); v != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339, v); err != nil {
+			sendError(w, http.StatusBadRequest, "InvalidParameterException", "since must be an RFC 3339 timestamp")
+			return
+		}
+	}
+	if !since.Before(until) {
+		sendError(w, http.StatusBadRequest, "InvalidParameterException", "since must be before until")
+		return
+	}
+
+	ctx := r.Context()
+	clusters, err := api.storage.ClusterStore().List(ctx)
+	if err != nil {
+		sendError(w, http.StatusInternalServerError, "ServerException", "Failed to list clusters")
+		return
+	}
+
+	cluster := params.Get("cluster")
+	matches := func(clusterARN string) bool {
+		return cluster == "" || cluster == clusterARN || clusterARN == clusterARNWithName(clusters, cluster)
+	}
+
+	// Archived records first so that the tasks still in storage win
+	var records []usage.Record
+	for _, record := range api.ledger.Records(since) {
+		if matches(record.ClusterARN) {
+			records = append(records, record)
+		}
+	}
+	for _, c := range clusters {
+		if !matches(c.ARN) {
+			continue
+		}
+		tasks, err := api.storage.TaskStore().List(ctx, c.ARN, storage.TaskFilters{})
+		if err != nil {
+			logging.Warn("Failed to list tasks for usage report", "cluster", c.Name, "error", err)
+			continue
+		}
+		for _, task := range tasks {
+			if record, ok := usage.RecordFromTask(task); ok {
+				records = append(records, record)
+			}
+		}
+	}
+
+	sendJSON(w, usage.BuildReport(records, since, until, usagePricing()))
+}
+
+// clusterARNWithName returns the ARN of the cluster with a name, or an empty string
+func clusterARNWithName(clusters []*storage.Cluster, name string) string {
+	for _, c := range clusters {
+		if c.Name == name {
+			return c.ARN
+		}
+	}
+	return ""
+}
+
+// usagePricing returns the prices configured with usage.vcpuHourPrice and usage.gbHourPrice
+func usagePricing() usage.Pricing {
+	pricing := usage.DefaultPricing()
+	if price := config.GetFloat64("usage.vcpuHourPrice"); price > 0 {
+		pricing.VCPUHour = price
+	}
+	if price := config.GetFloat64("usage.gbHourPrice"); price > 0 {
+		pricing.GBHour = price
+	}
+	return pricing
+}
@@ -0,0 +1,226 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// The helpers below back the agent-facing operations (SubmitTaskStateChange,
+// SubmitContainerStateChange, SubmitAttachmentStateChanges) that let external
+// agents and schedulers report task state to KECS.
+
+// stateChangeAcknowledgment is returned by the Submit*StateChange operations
+const stateChangeAcknowledgment = "ACK"
+
+// validTaskStatuses are the lifecycle states an agent may report for a task
+var validTaskStatuses = map[string]bool{
+	"PROVISIONING":   true,
+	"PENDING":        true,
+	"ACTIVATING":     true,
+	"RUNNING":        true,
+	"DEACTIVATING":   true,
+	"STOPPING":       true,
+	"DEPROVISIONING": true,
+	"STOPPED":        true,
+}
+
+// getTaskForStateChange looks up the task an agent reports on
+func (api *DefaultECSAPI) getTaskForStateChange(ctx context.Context, cluster *string, taskIdentifier string) (*storage.Task, error) {
+	if taskIdentifier == "" {
+		return nil, fmt.Errorf("task is required")
+	}
+
+	if awsarn.IsService(taskIdentifier, "ecs") {
+		task, err := api.storage.TaskStore().Get(ctx, "", taskIdentifier)
+		if err != nil || task == nil {
+			return nil, fmt.Errorf("task not found: %s", taskIdentifier)
+		}
+		return task, nil
+	}
+
+	clusterName := "default"
+	if cluster != nil && *cluster != "" {
+		clusterName = extractClusterNameFromARN(*cluster)
+	}
+	clusterObj, err := api.storage.ClusterStore().Get(ctx, clusterName)
+	if err != nil || clusterObj == nil {
+		return nil, fmt.Errorf("cluster not found: %s", clusterName)
+	}
+	task, err := api.storage.TaskStore().Get(ctx, clusterObj.ARN, taskIdentifier)
+	if err != nil || task == nil {
+		return nil, fmt.Errorf("task not found: %s", taskIdentifier)
+	}
+	return task, nil
+}
+
+// applyTaskStatus moves a task to the reported lifecycle status
+func applyTaskStatus(task *storage.Task, status, reason string) error {
+	if !validTaskStatuses[status] {
+		return fmt.Errorf("invalid task status: %s", status)
+	}
+
+	now := deterministic.Now()
+	task.LastStatus = status
+	switch status {
+	case "RUNNING":
+		if task.StartedAt == nil {
+			task.StartedAt = &now
+		}
+		task.Connectivity = "CONNECTED"
+		if task.ConnectivityAt == nil {
+			task.ConnectivityAt = &now
+		}
+	case "DEACTIVATING", "STOPPING", "DEPROVISIONING":
+		task.DesiredStatus = "STOPPED"
+		if task.StoppingAt == nil {
+			task.StoppingAt = &now
+		}
+	case "STOPPED":
+		task.DesiredStatus = "STOPPED"
+		if task.StoppingAt == nil {
+			task.StoppingAt = &now
+		}
+		if task.StoppedAt == nil {
+			task.StoppedAt = &now
+		}
+		if task.StopCode == "" {
+			task.StopCode = "EssentialContainerExited"
+		}
+	}
+	if reason != "" && task.StoppedReason == "" && task.DesiredStatus == "STOPPED" {
+		task.StoppedReason = reason
+	}
+	return nil
+}
+
+// applyContainerStateChanges merges container state reported by an agent into
+// the task's containers. Unknown containers are added.
+func applyContainerStateChanges(task *storage.Task, changes []generated.ContainerStateChange, managedAgents []generated.ManagedAgentStateChange) error {
+	if len(changes) == 0 && len(managedAgents) == 0 {
+		return nil
+	}
+
+	var containers []generated.Container
+	if task.Containers != "" {
+		if err := json.Unmarshal([]byte(task.Containers), &containers); err != nil {
+			return fmt.Errorf("failed to parse task containers: %w", err)
+		}
+	}
+
+	find := func(name string) *generated.Container {
+		for i := range containers {
+			if containers[i].Name != nil && *containers[i].Name == name {
+				return &containers[i]
+			}
+		}
+		containers = append(containers, generated.Container{
+			Name:    ptr.String(name),
+			TaskArn: ptr.String(task.ARN),
+		})
+		return &containers[len(containers)-1]
+	}
+
+	for _, change := range changes {
+		if change.ContainerName == nil || *change.ContainerName == "" {
+			return fmt.Errorf("containerName is required for container state changes")
+		}
+		container := find(*change.ContainerName)
+		if change.Status != nil {
+			container.LastStatus = change.Status
+		}
+		if change.ExitCode != nil {
+			container.ExitCode = change.ExitCode
+		}
+		if change.Reason != nil {
+			container.Reason = change.Reason
+		}
+		if change.RuntimeId != nil {
+			container.RuntimeId = change.RuntimeId
+		}
+		if change.ImageDigest != nil {
+			container.ImageDigest = change.ImageDigest
+		}
+		if change.NetworkBindings != nil {
+			container.NetworkBindings = change.NetworkBindings
+		}
+	}
+
+	for _, change := range managedAgents {
+		container := find(change.ContainerName)
+		name := change.ManagedAgentName
+		var agent *generated.ManagedAgent
+		for i := range container.ManagedAgents {
+			if container.ManagedAgents[i].Name != nil && *container.ManagedAgents[i].Name == name {
+				agent = &container.ManagedAgents[i]
+				break
+			}
+		}
+		if agent == nil {
+			container.ManagedAgents = append(container.ManagedAgents, generated.ManagedAgent{Name: &name})
+			agent = &container.ManagedAgents[len(container.ManagedAgents)-1]
+		}
+		agent.LastStatus = ptr.String(change.Status)
+		agent.Reason = change.Reason
+		if change.Status == "RUNNING" {
+			agent.LastStartedAt = ptr.UnixTime(deterministic.Now())
+		}
+	}
+
+	data, err := json.Marshal(containers)
+	if err != nil {
+		return fmt.Errorf("failed to serialize task containers: %w", err)
+	}
+	task.Containers = string(data)
+	return nil
+}
+
+// applyAttachmentStateChanges updates the status of task attachments. It
+// reports whether any of the changes matched an attachment of the task.
+func applyAttachmentStateChanges(task *storage.Task, changes []generated.AttachmentStateChange) (bool, error) {
+	if len(changes) == 0 || task.Attachments == "" {
+		return false, nil
+	}
+
+	var attachments []generated.Attachment
+	if err := json.Unmarshal([]byte(task.Attachments), &attachments); err != nil {
+		return false, fmt.Errorf("failed to parse task attachments: %w", err)
+	}
+
+	matched := false
+	for _, change := range changes {
+		id := attachmentIDFromARN(change.AttachmentArn)
+		for i := range attachments {
+			if attachments[i].Id != nil && *attachments[i].Id == id {
+				attachments[i].Status = ptr.String(change.Status)
+				matched = true
+			}
+		}
+	}
+	if !matched {
+		return false, nil
+	}
+
+	data, err := json.Marshal(attachments)
+	if err != nil {
+		return false, fmt.Errorf("failed to serialize task attachments: %w", err)
+	}
+	task.Attachments = string(data)
+	return true, nil
+}
+
+// attachmentIDFromARN returns the attachment ID of an attachment ARN, e.g.
+// arn:aws:ecs:us-east-1:000000000000:attachment/<id>. IDs are returned as is.
+func attachmentIDFromARN(arn string) string {
+	if idx := strings.LastIndex(arn, "/"); idx >= 0 {
+		return arn[idx+1:]
+	}
+	return arn
+}
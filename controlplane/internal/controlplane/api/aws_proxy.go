@@ -1,11 +1,16 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/nandemo-ya/kecs/controlplane/internal/localstack"
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
@@ -16,6 +21,21 @@ type AWSProxyHandler struct {
 	localStackManager localstack.Manager
 	reverseProxy      *httputil.ReverseProxy
 	localStackURL     *url.URL
+	prober            *localstack.CapabilityProber
+}
+
+// awsProxyErrorResponse is the error returned for services and operations
+// the configured LocalStack cannot serve. It follows the AWS JSON error shape
+// so SDKs surface the type and message.
+type awsProxyErrorResponse struct {
+	Type      string                    `json:"__type"`
+	Message   string                    `json:"message"`
+	Service   string                    `json:"service"`
+	Operation string                    `json:"operation,omitempty"`
+	Support   localstack.ServiceSupport `json:"support"`
+	Edition   string                    `json:"edition,omitempty"`
+	// Detail is the error LocalStack returned, if any
+	Detail string `json:"detail,omitempty"`
 }
 
 // NewAWSProxyHandler creates a new AWS proxy handler
@@ -64,6 +84,16 @@ func (h *AWSProxyHandler) updateProxyTarget(endpoint string) error {
 
 	h.localStackURL = targetURL
 	h.reverseProxy = httputil.NewSingleHostReverseProxy(targetURL)
+	h.reverseProxy.ModifyResponse = h.rewriteNotImplemented
+
+	// Probe the services of the LocalStack edition in the background, requests
+	// probe again on demand while LocalStack is still starting
+	h.prober = localstack.NewCapabilityProber(endpoint)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		h.prober.Probe(ctx)
+	}()
 
 	// Customize the reverse proxy director
 	originalDirector := h.reverseProxy.Director
@@ -135,14 +165,144 @@ func (h *AWSProxyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	service := h.extractServiceFromRequest(r)
 	logging.Debug("Proxying request for AWS service", "service", service)
 
-	// Note: We don't check if the service is enabled here anymore.
-	// LocalStack will handle unknown or disabled services appropriately.
-	// This allows for more flexibility and reduces maintenance.
+	// Reject services the LocalStack edition does not provide or has not
+	// enabled, instead of letting LocalStack answer with a bare 404 or 501.
+	// Services LocalStack does not report are proxied as before.
+	if service != "unknown" {
+		if caps := h.prober.Capabilities(r.Context()); caps != nil {
+			if capability := caps.Lookup(service); !capability.Usable() {
+				logging.Debug("Rejecting request for unavailable AWS service", "service", service, "support", capability.Support)
+				writeAWSProxyError(w, capabilityErrorStatus(capability), awsProxyErrorResponse{
+					Type:      capabilityErrorType(capability),
+					Message:   capability.Message,
+					Service:   capability.Service,
+					Operation: extractOperationFromRequest(r),
+					Support:   capability.Support,
+					Edition:   caps.Edition,
+				})
+				return
+			}
+		}
+	}
 
 	// Proxy the request to LocalStack
 	h.reverseProxy.ServeHTTP(w, r)
 }
 
+// rewriteNotImplemented replaces the 501 LocalStack returns for operations it
+// does not implement, typically Pro features, with a structured error naming
+// the service and operation
+func (h *AWSProxyHandler) rewriteNotImplemented(resp *http.Response) error {
+	if resp.StatusCode != http.StatusNotImplemented {
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	service := h.extractServiceFromRequest(resp.Request)
+	operation := extractOperationFromRequest(resp.Request)
+	errResp := awsProxyErrorResponse{
+		Type:      "UnsupportedOperationException",
+		Service:   service,
+		Operation: operation,
+		Support:   localstack.ServiceUnsupported,
+		Detail:    localStackErrorMessage(body),
+	}
+	if caps, _ := h.prober.Cached(); caps != nil {
+		errResp.Edition = caps.Edition
+	}
+
+	subject := fmt.Sprintf("service %s", service)
+	if operation != "" {
+		subject = fmt.Sprintf("operation %s of service %s", operation, service)
+	}
+	errResp.Message = fmt.Sprintf("%s is not implemented by LocalStack", subject)
+	if errResp.Edition == localstack.EditionCommunity {
+		errResp.Message += ", it may require LocalStack Pro"
+	}
+
+	data, err := json.Marshal(errResp)
+	if err != nil {
+		return err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	resp.ContentLength = int64(len(data))
+	resp.Header.Set("Content-Length", fmt.Sprint(len(data)))
+	resp.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	resp.Header.Set("X-Amzn-ErrorType", errResp.Type)
+	return nil
+}
+
+// localStackErrorMessage extracts the message of a LocalStack error body
+func localStackErrorMessage(body []byte) string {
+	var jsonErr struct {
+		Message      string `json:"message"`
+		MessageUpper string `json:"Message"`
+	}
+	if err := json.Unmarshal(body, &jsonErr); err == nil {
+		if jsonErr.Message != "" {
+			return jsonErr.Message
+		}
+		return jsonErr.MessageUpper
+	}
+	detail := strings.TrimSpace(string(body))
+	if len(detail) > 512 {
+		detail = detail[:512]
+	}
+	return detail
+}
+
+// writeAWSProxyError writes a structured AWS proxy error
+func writeAWSProxyError(w http.ResponseWriter, status int, errResp awsProxyErrorResponse) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	w.Header().Set("X-Amzn-ErrorType", errResp.Type)
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(errResp); err != nil {
+		logging.Error("Failed to write AWS proxy error", "error", err)
+	}
+}
+
+// capabilityErrorStatus returns the HTTP status for an unusable service
+func capabilityErrorStatus(capability *localstack.ServiceCapability) int {
+	if capability.Support == localstack.ServiceError {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusNotImplemented
+}
+
+// capabilityErrorType returns the AWS error type for an unusable service
+func capabilityErrorType(capability *localstack.ServiceCapability) string {
+	switch capability.Support {
+	case localstack.ServiceDisabled:
+		return "ServiceDisabledException"
+	case localstack.ServiceError:
+		return "ServiceUnavailableException"
+	default:
+		return "UnsupportedServiceException"
+	}
+}
+
+// extractOperationFromRequest determines the AWS operation of a request from
+// the X-Amz-Target header or the Action query parameter. Operations of REST
+// protocol services are not named and return "".
+func extractOperationFromRequest(r *http.Request) string {
+	if target := r.Header.Get("X-Amz-Target"); target != "" {
+		if idx := strings.LastIndex(target, "."); idx >= 0 {
+			return target[idx+1:]
+		}
+	}
+	return r.URL.Query().Get("Action")
+}
+
+// GetCapabilityProber returns the prober of the LocalStack support matrix
+func (h *AWSProxyHandler) GetCapabilityProber() *localstack.CapabilityProber {
+	return h.prober
+}
+
 // extractServiceFromRequest determines which AWS service is being called
 func (h *AWSProxyHandler) extractServiceFromRequest(r *http.Request) string {
 	// Check for service in headers (most reliable)
@@ -161,6 +321,8 @@ func (h *AWSProxyHandler) extractServiceFromRequest(r *http.Request) string {
 				return "dynamodb"
 			case "amazons3":
 				return "s3"
+			case "amazonsqs":
+				return "sqs"
 			case "awsie":
 				return "iam"
 			case "logs":
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/localstack"
+)
+
+// stubLocalStackManager satisfies localstack.Manager for handlers that only
+// check that LocalStack is configured
+type stubLocalStackManager struct {
+	localstack.Manager
+}
+
+var _ = Describe("AWSProxyHandler capabilities", func() {
+	var (
+		mockLocalStack *httptest.Server
+		handler        *AWSProxyHandler
+	)
+
+	request := func(service, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{}"))
+		req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=test/20240101/us-east-1/%s/aws4_request", service))
+		if target != "" {
+			req.Header.Set("X-Amz-Target", target)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	decodeError := func(rec *httptest.ResponseRecorder) awsProxyErrorResponse {
+		var errResp awsProxyErrorResponse
+		Expect(json.Unmarshal(rec.Body.Bytes(), &errResp)).To(Succeed())
+		return errResp
+	}
+
+	BeforeEach(func() {
+		mockLocalStack = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.URL.Path == localstack.HealthCheckPath:
+				fmt.Fprint(w, `{"edition": "community", "services": {"s3": "running", "sqs": "disabled", "dynamodb": "running"}}`)
+			case strings.HasSuffix(r.Header.Get("X-Amz-Target"), ".ImportTable"):
+				w.WriteHeader(http.StatusNotImplemented)
+				fmt.Fprint(w, `{"__type": "InternalFailure", "message": "API action 'ImportTable' for service 'dynamodb' not yet implemented or pro feature"}`)
+			default:
+				fmt.Fprint(w, `{}`)
+			}
+		}))
+
+		handler = &AWSProxyHandler{localStackManager: stubLocalStackManager{}}
+		Expect(handler.updateProxyTarget(mockLocalStack.URL)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		mockLocalStack.Close()
+	})
+
+	It("should proxy services LocalStack supports", func() {
+		rec := request("s3", "")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+	})
+
+	It("should reject Pro-only services on the community edition", func() {
+		rec := request("rds", "")
+		Expect(rec.Code).To(Equal(http.StatusNotImplemented))
+		Expect(rec.Header().Get("X-Amzn-ErrorType")).To(Equal("UnsupportedServiceException"))
+
+		errResp := decodeError(rec)
+		Expect(errResp.Service).To(Equal("rds"))
+		Expect(errResp.Support).To(Equal(localstack.ServiceUnsupported))
+		Expect(errResp.Edition).To(Equal("community"))
+		Expect(errResp.Message).To(ContainSubstring("requires LocalStack Pro"))
+	})
+
+	It("should reject services that are not enabled", func() {
+		rec := request("sqs", "AmazonSQS.SendMessage")
+		Expect(rec.Code).To(Equal(http.StatusNotImplemented))
+
+		errResp := decodeError(rec)
+		Expect(errResp.Type).To(Equal("ServiceDisabledException"))
+		Expect(errResp.Operation).To(Equal("SendMessage"))
+	})
+
+	It("should describe operations LocalStack does not implement", func() {
+		rec := request("dynamodb", "DynamoDB_20120810.ImportTable")
+		Expect(rec.Code).To(Equal(http.StatusNotImplemented))
+
+		errResp := decodeError(rec)
+		Expect(errResp.Type).To(Equal("UnsupportedOperationException"))
+		Expect(errResp.Service).To(Equal("dynamodb"))
+		Expect(errResp.Operation).To(Equal("ImportTable"))
+		Expect(errResp.Message).To(ContainSubstring("may require LocalStack Pro"))
+		Expect(errResp.Detail).To(ContainSubstring("not yet implemented or pro feature"))
+	})
+})
@@ -3,8 +3,11 @@ package api
 import (
 	"context"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
 )
 
 // CreateCapacityProvider implements the CreateCapacityProvider operation
@@ -12,7 +15,7 @@ func (api *DefaultECSAPI) CreateCapacityProvider(ctx context.Context, req *gener
 	// TODO: Implement actual capacity provider creation logic
 	// For now, return a mock response
 	name := req.Name
-	arn := "arn:aws:ecs:" + api.region + ":" + api.accountID + ":capacity-provider/" + name
+	arn := awsarn.Build("ecs", api.region, api.accountFor(ctx), "capacity-provider/"+name)
 
 	resp := &generated.CreateCapacityProviderResponse{
 		CapacityProvider: &generated.CapacityProvider{
@@ -32,7 +35,7 @@ func (api *DefaultECSAPI) DeleteCapacityProvider(ctx context.Context, req *gener
 	// TODO: Implement actual capacity provider deletion logic
 	// For now, return a mock response
 	name := req.CapacityProvider
-	arn := "arn:aws:ecs:" + api.region + ":" + api.accountID + ":capacity-provider/" + name
+	arn := awsarn.Build("ecs", api.region, api.accountFor(ctx), "capacity-provider/"+name)
 
 	resp := &generated.DeleteCapacityProviderResponse{
 		CapacityProvider: &generated.CapacityProvider{
@@ -53,7 +56,7 @@ func (api *DefaultECSAPI) DescribeCapacityProviders(ctx context.Context, req *ge
 
 	if len(req.CapacityProviders) > 0 {
 		for _, name := range req.CapacityProviders {
-			arn := "arn:aws:ecs:" + api.region + ":" + api.accountID + ":capacity-provider/" + name
+			arn := awsarn.Build("ecs", api.region, api.accountFor(ctx), "capacity-provider/"+name)
 			capacityProviders = append(capacityProviders, generated.CapacityProvider{
 				CapacityProviderArn: ptr.String(arn),
 				Name:                ptr.String(name),
@@ -63,12 +66,12 @@ func (api *DefaultECSAPI) DescribeCapacityProviders(ctx context.Context, req *ge
 	} else {
 		// Return default capacity providers if none specified
 		capacityProviders = append(capacityProviders, generated.CapacityProvider{
-			CapacityProviderArn: ptr.String("arn:aws:ecs:" + api.region + ":" + api.accountID + ":capacity-provider/FARGATE"),
+			CapacityProviderArn: ptr.String(awsarn.Build("ecs", api.region, api.accountFor(ctx), "capacity-provider/FARGATE")),
 			Name:                ptr.String("FARGATE"),
 			Status:              (*generated.CapacityProviderStatus)(ptr.String("ACTIVE")),
 		})
 		capacityProviders = append(capacityProviders, generated.CapacityProvider{
-			CapacityProviderArn: ptr.String("arn:aws:ecs:" + api.region + ":" + api.accountID + ":capacity-provider/FARGATE_SPOT"),
+			CapacityProviderArn: ptr.String(awsarn.Build("ecs", api.region, api.accountFor(ctx), "capacity-provider/FARGATE_SPOT")),
 			Name:                ptr.String("FARGATE_SPOT"),
 			Status:              (*generated.CapacityProviderStatus)(ptr.String("ACTIVE")),
 		})
@@ -86,7 +89,7 @@ func (api *DefaultECSAPI) UpdateCapacityProvider(ctx context.Context, req *gener
 	// TODO: Implement actual capacity provider update logic
 	// For now, return a mock response
 	name := req.Name
-	arn := "arn:aws:ecs:" + api.region + ":" + api.accountID + ":capacity-provider/" + name
+	arn := awsarn.Build("ecs", api.region, api.accountFor(ctx), "capacity-provider/"+name)
 
 	// Convert AutoScalingGroupProviderUpdate to AutoScalingGroupProvider
 	autoScalingGroupProvider := &generated.AutoScalingGroupProvider{
@@ -106,3 +109,22 @@ func (api *DefaultECSAPI) UpdateCapacityProvider(ctx context.Context, req *gener
 
 	return resp, nil
 }
+
+// resolveCapacityProviderStrategy returns the capacity provider strategy a
+// RunTask or CreateService call runs with. An explicit launch type or strategy
+// wins, otherwise the cluster default set with PutClusterCapacityProviders
+// applies. It returns nil when tasks run with a plain launch type.
+func resolveCapacityProviderStrategy(cluster *storage.Cluster, launchType *generated.LaunchType, strategy []generated.CapacityProviderStrategyItem) ([]generated.CapacityProviderStrategyItem, error) {
+	if launchType != nil && len(strategy) > 0 {
+		return nil, &generated.InvalidParameterException{
+			Message: ptr.String("Specifying both a launch type and capacity provider strategy is not supported. Remove one and try again."),
+		}
+	}
+	if launchType != nil {
+		return nil, nil
+	}
+	if len(strategy) > 0 {
+		return strategy, nil
+	}
+	return converters.ParseCapacityProviderStrategy(cluster.DefaultCapacityProviderStrategy), nil
+}
@@ -0,0 +1,548 @@
+package api
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/cloudformation"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+const cloudFormationXMLNS = "http://cloudformation.amazonaws.com/doc/2010-05-15/"
+
+// CloudFormationAPI serves the subset of the CloudFormation query API needed
+// to provision ECS and ELBv2 resources from templates
+type CloudFormationAPI struct {
+	engine *cloudformation.Engine
+}
+
+// NewCloudFormationAPI creates a new CloudFormation API handler
+func NewCloudFormationAPI(engine *cloudformation.Engine) *CloudFormationAPI {
+	return &CloudFormationAPI{engine: engine}
+}
+
+// CloudFormation XML response structures
+type cfnStack struct {
+	StackID           string         `xml:"StackId"`
+	StackName         string         `xml:"StackName"`
+	Description       string         `xml:"Description,omitempty"`
+	StackStatus       string         `xml:"StackStatus"`
+	StackStatusReason string         `xml:"StackStatusReason,omitempty"`
+	CreationTime      string         `xml:"CreationTime"`
+	Parameters        []cfnParameter `xml:"Parameters>member"`
+	Outputs           []cfnOutput    `xml:"Outputs>member"`
+}
+
+type cfnParameter struct {
+	ParameterKey   string `xml:"ParameterKey"`
+	ParameterValue string `xml:"ParameterValue"`
+}
+
+type cfnOutput struct {
+	OutputKey   string `xml:"OutputKey"`
+	OutputValue string `xml:"OutputValue"`
+	Description string `xml:"Description,omitempty"`
+}
+
+type cfnStackResource struct {
+	StackName            string `xml:"StackName"`
+	StackID              string `xml:"StackId"`
+	LogicalResourceID    string `xml:"LogicalResourceId"`
+	PhysicalResourceID   string `xml:"PhysicalResourceId,omitempty"`
+	ResourceType         string `xml:"ResourceType"`
+	ResourceStatus       string `xml:"ResourceStatus"`
+	ResourceStatusReason string `xml:"ResourceStatusReason,omitempty"`
+	Timestamp            string `xml:"Timestamp"`
+}
+
+type CreateStackResponse struct {
+	XMLName          xml.Name         `xml:"CreateStackResponse"`
+	XMLNS            string           `xml:"xmlns,attr"`
+	StackID          string           `xml:"CreateStackResult>StackId"`
+	ResponseMetadata ResponseMetadata `xml:"ResponseMetadata"`
+}
+
+type DescribeStacksResponse struct {
+	XMLName          xml.Name         `xml:"DescribeStacksResponse"`
+	XMLNS            string           `xml:"xmlns,attr"`
+	Stacks           []cfnStack       `xml:"DescribeStacksResult>Stacks>member"`
+	ResponseMetadata ResponseMetadata `xml:"ResponseMetadata"`
+}
+
+type DeleteStackResponse struct {
+	XMLName          xml.Name         `xml:"DeleteStackResponse"`
+	XMLNS            string           `xml:"xmlns,attr"`
+	ResponseMetadata ResponseMetadata `xml:"ResponseMetadata"`
+}
+
+type DescribeStackResourcesResponse struct {
+	XMLName          xml.Name           `xml:"DescribeStackResourcesResponse"`
+	XMLNS            string             `xml:"xmlns,attr"`
+	StackResources   []cfnStackResource `xml:"DescribeStackResourcesResult>StackResources>member"`
+	ResponseMetadata ResponseMetadata   `xml:"ResponseMetadata"`
+}
+
+type DescribeStackResourceResponse struct {
+	XMLName          xml.Name               `xml:"DescribeStackResourceResponse"`
+	XMLNS            string                 `xml:"xmlns,attr"`
+	Detail           cfnStackResourceDetail `xml:"DescribeStackResourceResult>StackResourceDetail"`
+	ResponseMetadata ResponseMetadata       `xml:"ResponseMetadata"`
+}
+
+type cfnStackResourceDetail struct {
+	StackName            string `xml:"StackName"`
+	StackID              string `xml:"StackId"`
+	LogicalResourceID    string `xml:"LogicalResourceId"`
+	PhysicalResourceID   string `xml:"PhysicalResourceId,omitempty"`
+	ResourceType         string `xml:"ResourceType"`
+	ResourceStatus       string `xml:"ResourceStatus"`
+	ResourceStatusReason string `xml:"ResourceStatusReason,omitempty"`
+	LastUpdatedTimestamp string `xml:"LastUpdatedTimestamp"`
+	Metadata             string `xml:"Metadata,omitempty"`
+}
+
+type cfnStackEvent struct {
+	StackName            string `xml:"StackName"`
+	StackID              string `xml:"StackId"`
+	EventID              string `xml:"EventId"`
+	LogicalResourceID    string `xml:"LogicalResourceId"`
+	PhysicalResourceID   string `xml:"PhysicalResourceId,omitempty"`
+	ResourceType         string `xml:"ResourceType"`
+	ResourceStatus       string `xml:"ResourceStatus"`
+	ResourceStatusReason string `xml:"ResourceStatusReason,omitempty"`
+	Timestamp            string `xml:"Timestamp"`
+}
+
+type DescribeStackEventsResponse struct {
+	XMLName          xml.Name         `xml:"DescribeStackEventsResponse"`
+	XMLNS            string           `xml:"xmlns,attr"`
+	StackEvents      []cfnStackEvent  `xml:"DescribeStackEventsResult>StackEvents>member"`
+	ResponseMetadata ResponseMetadata `xml:"ResponseMetadata"`
+}
+
+type GetTemplateResponse struct {
+	XMLName          xml.Name         `xml:"GetTemplateResponse"`
+	XMLNS            string           `xml:"xmlns,attr"`
+	TemplateBody     string           `xml:"GetTemplateResult>TemplateBody"`
+	StagesAvailable  []string         `xml:"GetTemplateResult>StagesAvailable>member"`
+	ResponseMetadata ResponseMetadata `xml:"ResponseMetadata"`
+}
+
+type CreateChangeSetResponse struct {
+	XMLName          xml.Name         `xml:"CreateChangeSetResponse"`
+	XMLNS            string           `xml:"xmlns,attr"`
+	ID               string           `xml:"CreateChangeSetResult>Id"`
+	StackID          string           `xml:"CreateChangeSetResult>StackId"`
+	ResponseMetadata ResponseMetadata `xml:"ResponseMetadata"`
+}
+
+type cfnResourceChange struct {
+	Action            string `xml:"Action"`
+	LogicalResourceID string `xml:"LogicalResourceId"`
+	ResourceType      string `xml:"ResourceType"`
+}
+
+type cfnChange struct {
+	Type           string            `xml:"Type"`
+	ResourceChange cfnResourceChange `xml:"ResourceChange"`
+}
+
+type DescribeChangeSetResponse struct {
+	XMLName          xml.Name                `xml:"DescribeChangeSetResponse"`
+	XMLNS            string                  `xml:"xmlns,attr"`
+	Result           describeChangeSetResult `xml:"DescribeChangeSetResult"`
+	ResponseMetadata ResponseMetadata        `xml:"ResponseMetadata"`
+}
+
+type describeChangeSetResult struct {
+	ChangeSetID     string         `xml:"ChangeSetId"`
+	ChangeSetName   string         `xml:"ChangeSetName"`
+	StackID         string         `xml:"StackId"`
+	StackName       string         `xml:"StackName"`
+	Description     string         `xml:"Description,omitempty"`
+	Status          string         `xml:"Status"`
+	StatusReason    string         `xml:"StatusReason,omitempty"`
+	ExecutionStatus string         `xml:"ExecutionStatus"`
+	CreationTime    string         `xml:"CreationTime"`
+	Parameters      []cfnParameter `xml:"Parameters>member"`
+	Changes         []cfnChange    `xml:"Changes>member"`
+}
+
+type ExecuteChangeSetResponse struct {
+	XMLName          xml.Name         `xml:"ExecuteChangeSetResponse"`
+	XMLNS            string           `xml:"xmlns,attr"`
+	ResponseMetadata ResponseMetadata `xml:"ResponseMetadata"`
+}
+
+type DeleteChangeSetResponse struct {
+	XMLName          xml.Name         `xml:"DeleteChangeSetResponse"`
+	XMLNS            string           `xml:"xmlns,attr"`
+	ResponseMetadata ResponseMetadata `xml:"ResponseMetadata"`
+}
+
+type cfnErrorResponse struct {
+	XMLName   xml.Name `xml:"ErrorResponse"`
+	XMLNS     string   `xml:"xmlns,attr"`
+	Type      string   `xml:"Error>Type"`
+	Code      string   `xml:"Error>Code"`
+	Message   string   `xml:"Error>Message"`
+	RequestID string   `xml:"RequestId"`
+}
+
+// ServeHTTP handles form-encoded CloudFormation requests
+func (c *CloudFormationAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		c.writeError(w, http.StatusBadRequest, "InvalidRequest", fmt.Sprintf("Failed to read body: %v", err))
+		return
+	}
+	r.Body.Close()
+
+	values, err := url.ParseQuery(string(bodyBytes))
+	if err != nil {
+		c.writeError(w, http.StatusBadRequest, "InvalidRequest", fmt.Sprintf("Failed to parse form data: %v", err))
+		return
+	}
+
+	action := values.Get("Action")
+	logging.Debug("Processing CloudFormation request", "action", action)
+
+	switch action {
+	case "CreateStack":
+		stack, err := c.engine.CreateStack(r.Context(), cloudformation.CreateStackInput{
+			StackName:    values.Get("StackName"),
+			TemplateBody: values.Get("TemplateBody"),
+			TemplateURL:  values.Get("TemplateURL"),
+			Parameters:   parseStackParameters(values),
+		})
+		if err != nil {
+			c.writeEngineError(w, err)
+			return
+		}
+		c.writeXML(w, &CreateStackResponse{
+			XMLNS:            cloudFormationXMLNS,
+			StackID:          stack.StackID,
+			ResponseMetadata: ResponseMetadata{RequestId: uuid.New().String()},
+		})
+
+	case "DescribeStacks":
+		stacks, err := c.engine.DescribeStacks(values.Get("StackName"))
+		if err != nil {
+			c.writeEngineError(w, err)
+			return
+		}
+		resp := &DescribeStacksResponse{
+			XMLNS:            cloudFormationXMLNS,
+			ResponseMetadata: ResponseMetadata{RequestId: uuid.New().String()},
+		}
+		for _, stack := range stacks {
+			resp.Stacks = append(resp.Stacks, toCFNStack(stack))
+		}
+		c.writeXML(w, resp)
+
+	case "DescribeStackResources":
+		stacks, err := c.engine.DescribeStacks(values.Get("StackName"))
+		if err != nil {
+			c.writeEngineError(w, err)
+			return
+		}
+		resp := &DescribeStackResourcesResponse{
+			XMLNS:            cloudFormationXMLNS,
+			ResponseMetadata: ResponseMetadata{RequestId: uuid.New().String()},
+		}
+		logicalID := values.Get("LogicalResourceId")
+		for _, stack := range stacks {
+			for _, resource := range stack.Resources {
+				if logicalID != "" && resource.LogicalID != logicalID {
+					continue
+				}
+				resp.StackResources = append(resp.StackResources, cfnStackResource{
+					StackName:            stack.StackName,
+					StackID:              stack.StackID,
+					LogicalResourceID:    resource.LogicalID,
+					PhysicalResourceID:   resource.PhysicalID,
+					ResourceType:         resource.Type,
+					ResourceStatus:       resource.Status,
+					ResourceStatusReason: resource.StatusReason,
+					Timestamp:            resource.Timestamp.UTC().Format(time.RFC3339),
+				})
+			}
+		}
+		c.writeXML(w, resp)
+
+	case "DescribeStackResource":
+		stack, ok := c.describeStack(w, values)
+		if !ok {
+			return
+		}
+		logicalID := values.Get("LogicalResourceId")
+		for _, resource := range stack.Resources {
+			if resource.LogicalID != logicalID {
+				continue
+			}
+			detail := cfnStackResourceDetail{
+				StackName:            stack.StackName,
+				StackID:              stack.StackID,
+				LogicalResourceID:    resource.LogicalID,
+				PhysicalResourceID:   resource.PhysicalID,
+				ResourceType:         resource.Type,
+				ResourceStatus:       resource.Status,
+				ResourceStatusReason: resource.StatusReason,
+				LastUpdatedTimestamp: resource.Timestamp.UTC().Format(time.RFC3339),
+			}
+			if len(resource.Metadata) > 0 {
+				if metadata, err := json.Marshal(resource.Metadata); err == nil {
+					detail.Metadata = string(metadata)
+				}
+			}
+			c.writeXML(w, &DescribeStackResourceResponse{
+				XMLNS:            cloudFormationXMLNS,
+				Detail:           detail,
+				ResponseMetadata: ResponseMetadata{RequestId: uuid.New().String()},
+			})
+			return
+		}
+		c.writeError(w, http.StatusBadRequest, "ValidationError", fmt.Sprintf("Resource %s does not exist for stack %s", logicalID, stack.StackName))
+
+	case "DescribeStackEvents":
+		stack, ok := c.describeStack(w, values)
+		if !ok {
+			return
+		}
+		resp := &DescribeStackEventsResponse{
+			XMLNS:            cloudFormationXMLNS,
+			ResponseMetadata: ResponseMetadata{RequestId: uuid.New().String()},
+		}
+		for _, event := range stack.Events {
+			resp.StackEvents = append(resp.StackEvents, cfnStackEvent{
+				StackName:            stack.StackName,
+				StackID:              stack.StackID,
+				EventID:              event.EventID,
+				LogicalResourceID:    event.LogicalID,
+				PhysicalResourceID:   event.PhysicalID,
+				ResourceType:         event.Type,
+				ResourceStatus:       event.Status,
+				ResourceStatusReason: event.StatusReason,
+				Timestamp:            event.Timestamp.UTC().Format(time.RFC3339Nano),
+			})
+		}
+		c.writeXML(w, resp)
+
+	case "GetTemplate":
+		stack, ok := c.describeStack(w, values)
+		if !ok {
+			return
+		}
+		c.writeXML(w, &GetTemplateResponse{
+			XMLNS:            cloudFormationXMLNS,
+			TemplateBody:     stack.TemplateBody,
+			StagesAvailable:  []string{"Original", "Processed"},
+			ResponseMetadata: ResponseMetadata{RequestId: uuid.New().String()},
+		})
+
+	case "CreateChangeSet":
+		changeSet, err := c.engine.CreateChangeSet(r.Context(), cloudformation.CreateChangeSetInput{
+			StackName:     values.Get("StackName"),
+			ChangeSetName: values.Get("ChangeSetName"),
+			ChangeSetType: values.Get("ChangeSetType"),
+			Description:   values.Get("Description"),
+			TemplateBody:  values.Get("TemplateBody"),
+			TemplateURL:   values.Get("TemplateURL"),
+			Parameters:    parseStackParameters(values),
+		})
+		if err != nil {
+			c.writeEngineError(w, err)
+			return
+		}
+		c.writeXML(w, &CreateChangeSetResponse{
+			XMLNS:            cloudFormationXMLNS,
+			ID:               changeSet.ChangeSetID,
+			StackID:          changeSet.StackID,
+			ResponseMetadata: ResponseMetadata{RequestId: uuid.New().String()},
+		})
+
+	case "DescribeChangeSet":
+		changeSet, err := c.engine.DescribeChangeSet(values.Get("ChangeSetName"), values.Get("StackName"))
+		if err != nil {
+			c.writeEngineError(w, err)
+			return
+		}
+		c.writeXML(w, &DescribeChangeSetResponse{
+			XMLNS:            cloudFormationXMLNS,
+			Result:           toCFNChangeSet(changeSet),
+			ResponseMetadata: ResponseMetadata{RequestId: uuid.New().String()},
+		})
+
+	case "ExecuteChangeSet":
+		if err := c.engine.ExecuteChangeSet(r.Context(), values.Get("ChangeSetName"), values.Get("StackName")); err != nil {
+			c.writeEngineError(w, err)
+			return
+		}
+		c.writeXML(w, &ExecuteChangeSetResponse{
+			XMLNS:            cloudFormationXMLNS,
+			ResponseMetadata: ResponseMetadata{RequestId: uuid.New().String()},
+		})
+
+	case "DeleteChangeSet":
+		if err := c.engine.DeleteChangeSet(values.Get("ChangeSetName"), values.Get("StackName")); err != nil {
+			c.writeEngineError(w, err)
+			return
+		}
+		c.writeXML(w, &DeleteChangeSetResponse{
+			XMLNS:            cloudFormationXMLNS,
+			ResponseMetadata: ResponseMetadata{RequestId: uuid.New().String()},
+		})
+
+	case "DeleteStack":
+		if err := c.engine.DeleteStack(r.Context(), values.Get("StackName")); err != nil {
+			c.writeEngineError(w, err)
+			return
+		}
+		c.writeXML(w, &DeleteStackResponse{
+			XMLNS:            cloudFormationXMLNS,
+			ResponseMetadata: ResponseMetadata{RequestId: uuid.New().String()},
+		})
+
+	default:
+		c.writeError(w, http.StatusBadRequest, "InvalidAction", fmt.Sprintf("The action %s is not valid for this web service.", action))
+	}
+}
+
+// describeStack returns the stack named by the StackName parameter, or
+// writes an error response
+func (c *CloudFormationAPI) describeStack(w http.ResponseWriter, values url.Values) (*cloudformation.Stack, bool) {
+	name := values.Get("StackName")
+	if name == "" {
+		c.writeError(w, http.StatusBadRequest, "ValidationError", "StackName is required")
+		return nil, false
+	}
+	stacks, err := c.engine.DescribeStacks(name)
+	if err != nil {
+		c.writeEngineError(w, err)
+		return nil, false
+	}
+	return stacks[0], true
+}
+
+// parseStackParameters parses Parameters.member.N.ParameterKey/ParameterValue
+func parseStackParameters(values url.Values) map[string]string {
+	parameters := make(map[string]string)
+	for i := 1; ; i++ {
+		key := values.Get(fmt.Sprintf("Parameters.member.%d.ParameterKey", i))
+		if key == "" {
+			return parameters
+		}
+		parameters[key] = values.Get(fmt.Sprintf("Parameters.member.%d.ParameterValue", i))
+	}
+}
+
+func toCFNStack(stack *cloudformation.Stack) cfnStack {
+	return cfnStack{
+		StackID:           stack.StackID,
+		StackName:         stack.StackName,
+		Description:       stack.Description,
+		StackStatus:       stack.Status,
+		StackStatusReason: stack.StatusReason,
+		CreationTime:      stack.CreationTime.UTC().Format(time.RFC3339),
+		Parameters:        toCFNParameters(stack.Parameters),
+		Outputs:           toCFNOutputs(stack.Outputs),
+	}
+}
+
+func toCFNChangeSet(changeSet *cloudformation.ChangeSet) describeChangeSetResult {
+	result := describeChangeSetResult{
+		ChangeSetID:     changeSet.ChangeSetID,
+		ChangeSetName:   changeSet.ChangeSetName,
+		StackID:         changeSet.StackID,
+		StackName:       changeSet.StackName,
+		Description:     changeSet.Description,
+		Status:          changeSet.Status,
+		StatusReason:    changeSet.StatusReason,
+		ExecutionStatus: changeSet.ExecutionStatus,
+		CreationTime:    changeSet.CreationTime.UTC().Format(time.RFC3339),
+		Parameters:      toCFNParameters(changeSet.Parameters),
+	}
+	for _, change := range changeSet.Changes {
+		result.Changes = append(result.Changes, cfnChange{
+			Type: "Resource",
+			ResourceChange: cfnResourceChange{
+				Action:            change.Action,
+				LogicalResourceID: change.LogicalID,
+				ResourceType:      change.ResourceType,
+			},
+		})
+	}
+	return result
+}
+
+// toCFNParameters sorts parameters by key
+func toCFNParameters(parameters map[string]string) []cfnParameter {
+	keys := make([]string, 0, len(parameters))
+	for key := range parameters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	result := make([]cfnParameter, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, cfnParameter{ParameterKey: key, ParameterValue: parameters[key]})
+	}
+	return result
+}
+
+func toCFNOutputs(outputs []cloudformation.StackOutput) []cfnOutput {
+	result := make([]cfnOutput, 0, len(outputs))
+	for _, output := range outputs {
+		result = append(result, cfnOutput{
+			OutputKey:   output.Key,
+			OutputValue: output.Value,
+			Description: output.Description,
+		})
+	}
+	return result
+}
+
+func (c *CloudFormationAPI) writeEngineError(w http.ResponseWriter, err error) {
+	var cfnErr *cloudformation.Error
+	if errors.As(err, &cfnErr) {
+		c.writeError(w, http.StatusBadRequest, cfnErr.Code, cfnErr.Message)
+		return
+	}
+	c.writeError(w, http.StatusInternalServerError, "InternalFailure", err.Error())
+}
+
+func (c *CloudFormationAPI) writeError(w http.ResponseWriter, statusCode int, code, message string) {
+	w.Header().Set("Content-Type", "text/xml")
+	w.WriteHeader(statusCode)
+	errType := "Sender"
+	if statusCode >= http.StatusInternalServerError {
+		errType = "Receiver"
+	}
+	resp := &cfnErrorResponse{
+		XMLNS:     cloudFormationXMLNS,
+		Type:      errType,
+		Code:      code,
+		Message:   message,
+		RequestID: uuid.New().String(),
+	}
+	if err := xml.NewEncoder(w).Encode(resp); err != nil {
+		logging.Error("Failed to encode CloudFormation error response", "error", err)
+	}
+}
+
+func (c *CloudFormationAPI) writeXML(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "text/xml")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n"))
+	if err := xml.NewEncoder(w).Encode(data); err != nil {
+		logging.Error("Failed to encode CloudFormation XML response", "error", err)
+	}
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"encoding/json"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// Types of cluster attachments. Like ECS, KECS attaches a scaling policy per
+// Auto Scaling group capacity provider, Fargate providers need none.
+const (
+	attachmentTypeScalingPolicy  = "as_policy"
+	attachmentTypeServiceConnect = "sc"
+)
+
+// Statuses of cluster attachments and of their last update
+const (
+	attachmentStatusPrecreated = "PRECREATED"
+	attachmentStatusAttached   = "ATTACHED"
+	attachmentStatusDetaching  = "DETACHING"
+
+	attachmentsStatusUpdateInProgress = "UPDATE_IN_PROGRESS"
+	attachmentsStatusUpdateComplete   = "UPDATE_COMPLETE"
+)
+
+// attachmentKeyDetails names the detail that identifies an attachment of a type
+var attachmentKeyDetails = map[string]string{
+	attachmentTypeScalingPolicy:  "capacityProviderName",
+	attachmentTypeServiceConnect: "namespace",
+}
+
+// parseClusterAttachments decodes the attachments stored with a cluster
+func parseClusterAttachments(attachmentsJSON string) []generated.Attachment {
+	if attachmentsJSON == "" {
+		return nil
+	}
+	var attachments []generated.Attachment
+	if err := json.Unmarshal([]byte(attachmentsJSON), &attachments); err != nil {
+		return nil
+	}
+	return attachments
+}
+
+// attachmentKey identifies an attachment by its type and key detail
+func attachmentKey(attachment generated.Attachment) string {
+	name := attachmentKeyDetails[ptr.ToString(attachment.Type)]
+	for _, detail := range attachment.Details {
+		if ptr.ToString(detail.Name) == name {
+			return ptr.ToString(attachment.Type) + "/" + ptr.ToString(detail.Value)
+		}
+	}
+	return ptr.ToString(attachment.Type) + "/" + ptr.ToString(attachment.Id)
+}
+
+// desiredClusterAttachments returns the attachments the capacity providers and
+// Service Connect defaults of a cluster need
+func desiredClusterAttachments(cluster *storage.Cluster) []generated.Attachment {
+	var attachments []generated.Attachment
+
+	var capacityProviders []string
+	if cluster.CapacityProviders != "" {
+		_ = json.Unmarshal([]byte(cluster.CapacityProviders), &capacityProviders)
+	}
+	for _, name := range capacityProviders {
+		if converters.LaunchTypeForCapacityProvider(name) == string(generated.LaunchTypeFARGATE) {
+			continue
+		}
+		attachments = append(attachments, generated.Attachment{
+			Type: ptr.String(attachmentTypeScalingPolicy),
+			Details: []generated.KeyValuePair{
+				{Name: ptr.String("capacityProviderName"), Value: ptr.String(name)},
+				{Name: ptr.String("scalingPolicyName"), Value: ptr.String("ECSManagedAutoScalingPolicy-" + deterministic.NewUUID())},
+			},
+		})
+	}
+
+	if defaults := parseServiceConnectDefaults(cluster.ServiceConnectDefaults); defaults != nil && defaults.Namespace != nil {
+		attachments = append(attachments, generated.Attachment{
+			Type: ptr.String(attachmentTypeServiceConnect),
+			Details: []generated.KeyValuePair{
+				{Name: ptr.String("namespace"), Value: ptr.String(*defaults.Namespace)},
+			},
+		})
+	}
+	return attachments
+}
+
+// updateClusterAttachments reconciles the attachments of a cluster with its
+// capacity providers and Service Connect defaults. New attachments start as
+// PRECREATED and removed ones are DETACHING until the update completes.
+func updateClusterAttachments(cluster *storage.Cluster) {
+	current := parseClusterAttachments(cluster.Attachments)
+	existing := make(map[string]generated.Attachment, len(current))
+	for _, attachment := range current {
+		existing[attachmentKey(attachment)] = attachment
+	}
+
+	changed := false
+	var attachments []generated.Attachment
+	desired := make(map[string]bool)
+	for _, attachment := range desiredClusterAttachments(cluster) {
+		key := attachmentKey(attachment)
+		desired[key] = true
+		if prev, ok := existing[key]; ok && ptr.ToString(prev.Status) != attachmentStatusDetaching {
+			attachments = append(attachments, prev)
+			continue
+		}
+		attachment.Id = ptr.String(deterministic.NewUUID())
+		attachment.Status = ptr.String(attachmentStatusPrecreated)
+		attachments = append(attachments, attachment)
+		changed = true
+	}
+	for _, attachment := range current {
+		if desired[attachmentKey(attachment)] {
+			continue
+		}
+		if ptr.ToString(attachment.Status) != attachmentStatusDetaching {
+			attachment.Status = ptr.String(attachmentStatusDetaching)
+			changed = true
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	if !changed {
+		return
+	}
+	cluster.Attachments = marshalClusterAttachments(attachments)
+	cluster.AttachmentsStatus = attachmentsStatusUpdateInProgress
+}
+
+// completeClusterAttachments finishes an attachments update: PRECREATED
+// attachments are attached and DETACHING ones removed. It reports whether the
+// cluster changed.
+func completeClusterAttachments(cluster *storage.Cluster) bool {
+	if cluster.AttachmentsStatus != attachmentsStatusUpdateInProgress {
+		return false
+	}
+
+	var attachments []generated.Attachment
+	for _, attachment := range parseClusterAttachments(cluster.Attachments) {
+		switch ptr.ToString(attachment.Status) {
+		case attachmentStatusDetaching:
+			continue
+		case attachmentStatusPrecreated:
+			attachment.Status = ptr.String(attachmentStatusAttached)
+		}
+		attachments = append(attachments, attachment)
+	}
+	cluster.Attachments = marshalClusterAttachments(attachments)
+	cluster.AttachmentsStatus = attachmentsStatusUpdateComplete
+	return true
+}
+
+// marshalClusterAttachments encodes attachments for storage
+func marshalClusterAttachments(attachments []generated.Attachment) string {
+	if len(attachments) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(attachments)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
+	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/elbv2"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// clusterDeletedReason is the stopped reason of tasks stopped by a cascading cluster deletion
+const clusterDeletedReason = "Cluster deleted"
+
+// CascadeDeleteCluster deletes a cluster after draining and deleting the
+// services, task sets and tasks that remain in it. This is a KECS extension,
+// DeleteCluster on AWS fails while a cluster has services or tasks. progress
+// is called with the ARN of every resource as it is deleted and may be nil.
+func (api *DefaultECSAPI) CascadeDeleteCluster(ctx context.Context, identifier string, progress func(resource, message string)) (*generated.DeleteClusterResponse, error) {
+	cluster, err := api.storage.ClusterStore().Get(ctx, extractClusterNameFromARN(identifier))
+	if err != nil {
+		return nil, fmt.Errorf("cluster not found: %s", identifier)
+	}
+	report := func(resource, message string) {
+		logging.Info("Cascading cluster deletion", "cluster", cluster.Name, "resource", resource, "message", message)
+		if progress != nil {
+			progress(resource, message)
+		}
+	}
+
+	services, _, err := api.storage.ServiceStore().List(ctx, cluster.ARN, storage.ServiceFilters{}, 0, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	for _, service := range services {
+		if err := api.cascadeDeleteService(ctx, cluster, service, report); err != nil {
+			return nil, err
+		}
+	}
+
+	// Standalone tasks, and service tasks whose pods are still terminating
+	tasks, err := api.storage.TaskStore().List(ctx, cluster.ARN, storage.TaskFilters{DesiredStatus: "RUNNING"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+	for _, task := range tasks {
+		api.cascadeStopTask(ctx, cluster, task, report)
+	}
+
+	// The counts are maintained incrementally, reset them now that nothing
+	// is left so that DeleteCluster succeeds
+	cluster, err = api.storage.ClusterStore().Get(ctx, cluster.Name)
+	if err != nil {
+		return nil, fmt.Errorf("cluster not found: %s", identifier)
+	}
+	cluster.ActiveServicesCount = 0
+	cluster.RunningTasksCount = 0
+	cluster.PendingTasksCount = 0
+	if err := api.storage.ClusterStore().Update(ctx, cluster); err != nil {
+		return nil, toECSError(err, "DeleteCluster")
+	}
+
+	report(cluster.ARN, "Deleting cluster")
+	resp, err := api.DeleteCluster(ctx, &generated.DeleteClusterRequest{Cluster: cluster.Name})
+	if err != nil {
+		return nil, err
+	}
+	report(cluster.ARN, "Cluster deleted")
+	return resp, nil
+}
+
+// cascadeDeleteService deletes the task sets and target group Services of a
+// service, then drains and deletes the service itself
+func (api *DefaultECSAPI) cascadeDeleteService(ctx context.Context, cluster *storage.Cluster, service *storage.Service, report func(resource, message string)) error {
+	taskSets, err := api.storage.TaskSetStore().List(ctx, service.ARN, nil)
+	if err != nil {
+		return fmt.Errorf("failed to list task sets of service %s: %w", service.ServiceName, err)
+	}
+	for _, taskSet := range taskSets {
+		report(taskSet.ARN, "Deleting task set")
+		if _, err := api.DeleteTaskSet(ctx, &generated.DeleteTaskSetRequest{
+			Cluster: cluster.Name,
+			Service: service.ServiceName,
+			TaskSet: taskSet.ID,
+			Force:   ptr.Bool(true),
+		}); err != nil {
+			return fmt.Errorf("failed to delete task set %s: %w", taskSet.ID, err)
+		}
+	}
+
+	if k8sIntegration, ok := api.elbv2Integration.(*elbv2.K8sIntegration); ok && service.LoadBalancers != "" && service.LoadBalancers != "null" {
+		var loadBalancers []generated.LoadBalancer
+		if err := json.Unmarshal([]byte(service.LoadBalancers), &loadBalancers); err == nil {
+			for _, lb := range loadBalancers {
+				if lb.TargetGroupArn == nil || *lb.TargetGroupArn == "" {
+					continue
+				}
+				report(*lb.TargetGroupArn, "Deleting target group Service")
+				if err := k8sIntegration.DeleteTargetGroupServiceInNamespace(ctx, *lb.TargetGroupArn, service.Namespace); err != nil {
+					// The namespace deletion removes it as well
+					logging.Warn("Failed to delete target group Service", "targetGroupArn", *lb.TargetGroupArn, "error", err)
+				}
+			}
+		}
+	}
+
+	report(service.ARN, "Draining and deleting service")
+	if _, err := api.DeleteService(ctx, &generated.DeleteServiceRequest{
+		Cluster: ptr.String(cluster.Name),
+		Service: service.ServiceName,
+		Force:   ptr.Bool(true),
+	}); err != nil {
+		return fmt.Errorf("failed to delete service %s: %w", service.ServiceName, err)
+	}
+	return nil
+}
+
+// cascadeStopTask stops a task of a cluster that is being deleted. Tasks that
+// cannot be stopped through Kubernetes are marked STOPPED, their pods go
+// away with the cluster namespace.
+func (api *DefaultECSAPI) cascadeStopTask(ctx context.Context, cluster *storage.Cluster, task *storage.Task, report func(resource, message string)) {
+	report(task.ARN, "Stopping task")
+	_, err := api.StopTask(ctx, &generated.StopTaskRequest{
+		Cluster: ptr.String(cluster.Name),
+		Task:    task.ARN,
+		Reason:  ptr.String(clusterDeletedReason),
+	})
+	if err == nil {
+		return
+	}
+
+	logging.Warn("Failed to stop task, marking it stopped", "task", task.ARN, "error", err)
+	now := deterministic.Now()
+	task.DesiredStatus = "STOPPED"
+	task.LastStatus = "STOPPED"
+	task.StoppedReason = clusterDeletedReason
+	task.StoppingAt = &now
+	task.StoppedAt = &now
+	if err := api.storage.TaskStore().Update(ctx, task); err != nil {
+		logging.Warn("Failed to mark task stopped", "task", task.ARN, "error", err)
+	}
+}
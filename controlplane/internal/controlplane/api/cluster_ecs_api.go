@@ -6,12 +6,12 @@ import (
 	"fmt"
 	"os"
 	"strings"
-	"time"
-
-	"github.com/google/uuid"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
 	"github.com/nandemo-ya/kecs/controlplane/internal/kubernetes"
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
@@ -55,6 +55,7 @@ func (api *DefaultECSAPI) CreateCluster(ctx context.Context, req *generated.Crea
 		if tags, err := parseTags(fmt.Sprintf("cluster:%s", existing.Name), existing.Tags); err == nil {
 			cluster.Tags = tags
 		}
+		cluster.ServiceConnectDefaults = parseServiceConnectDefaults(existing.ServiceConnectDefaults)
 
 		return &generated.CreateClusterResponse{
 			Cluster: cluster,
@@ -62,7 +63,7 @@ func (api *DefaultECSAPI) CreateCluster(ctx context.Context, req *generated.Crea
 	}
 
 	// Generate ARN
-	arn := fmt.Sprintf("arn:aws:ecs:%s:%s:cluster/%s", api.region, api.accountID, clusterName)
+	arn := awsarn.Build("ecs", api.region, api.accountFor(ctx), fmt.Sprintf("cluster/%s", clusterName))
 
 	// In the new design, all ECS clusters share the same k3d cluster (the KECS instance)
 	// We need to determine the KECS instance name
@@ -83,12 +84,12 @@ func (api *DefaultECSAPI) CreateCluster(ctx context.Context, req *generated.Crea
 
 	// Create cluster object
 	cluster := &storage.Cluster{
-		ID:                                uuid.New().String(),
+		ID:                                deterministic.NewUUID(),
 		ARN:                               arn,
 		Name:                              clusterName,
 		Status:                            "ACTIVE",
 		Region:                            api.region,
-		AccountID:                         api.accountID,
+		AccountID:                         api.accountFor(ctx),
 		K8sClusterName:                    k8sClusterName,
 		RegisteredContainerInstancesCount: 0,
 		RunningTasksCount:                 0,
@@ -128,6 +129,27 @@ func (api *DefaultECSAPI) CreateCluster(ctx context.Context, req *generated.Crea
 		}
 		cluster.Tags = string(tagsJSON)
 	}
+	if len(req.CapacityProviders) > 0 || len(req.DefaultCapacityProviderStrategy) > 0 {
+		if err := ValidateCapacityProviders(req.CapacityProviders, req.DefaultCapacityProviderStrategy); err != nil {
+			return nil, err
+		}
+		capacityProvidersJSON, err := json.Marshal(req.CapacityProviders)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal capacity providers: %w", err)
+		}
+		cluster.CapacityProviders = string(capacityProvidersJSON)
+		strategyJSON, err := json.Marshal(req.DefaultCapacityProviderStrategy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal default capacity provider strategy: %w", err)
+		}
+		cluster.DefaultCapacityProviderStrategy = string(strategyJSON)
+	}
+	if req.ServiceConnectDefaults != nil {
+		if err := api.applyServiceConnectDefaults(ctx, cluster, req.ServiceConnectDefaults); err != nil {
+			return nil, err
+		}
+	}
+	updateClusterAttachments(cluster)
 
 	// Save to storage
 	if err := api.storage.ClusterStore().Create(ctx, cluster); err != nil {
@@ -140,14 +162,23 @@ func (api *DefaultECSAPI) CreateCluster(ctx context.Context, req *generated.Crea
 	// Build response
 	response := &generated.CreateClusterResponse{
 		Cluster: &generated.Cluster{
-			ClusterArn:    ptr.String(cluster.ARN),
-			ClusterName:   ptr.String(cluster.Name),
-			Status:        ptr.String(cluster.Status),
-			Settings:      req.Settings,
-			Configuration: req.Configuration,
-			Tags:          req.Tags,
+			ClusterArn:             ptr.String(cluster.ARN),
+			ClusterName:            ptr.String(cluster.Name),
+			Status:                 ptr.String(cluster.Status),
+			Settings:               req.Settings,
+			Configuration:          req.Configuration,
+			Tags:                   req.Tags,
+			ServiceConnectDefaults: parseServiceConnectDefaults(cluster.ServiceConnectDefaults),
+			Attachments:            parseClusterAttachments(cluster.Attachments),
 		},
 	}
+	if cluster.AttachmentsStatus != "" {
+		response.Cluster.AttachmentsStatus = ptr.String(cluster.AttachmentsStatus)
+	}
+	if len(req.CapacityProviders) > 0 {
+		response.Cluster.CapacityProviders = req.CapacityProviders
+		response.Cluster.DefaultCapacityProviderStrategy = req.DefaultCapacityProviderStrategy
+	}
 
 	return response, nil
 }
@@ -175,7 +206,7 @@ func (api *DefaultECSAPI) ListClusters(ctx context.Context, req *generated.ListC
 	clusters, newNextToken, err := api.storage.ClusterStore().ListWithPagination(ctx, limit, nextToken)
 	if err != nil {
 		logging.Error("ListClusters storage error", "error", err)
-		return nil, fmt.Errorf("failed to list clusters: %w", err)
+		return nil, toListError(err, "clusters")
 	}
 
 	// Build cluster ARNs list
@@ -250,6 +281,14 @@ func (api *DefaultECSAPI) DescribeClusters(ctx context.Context, req *generated.D
 			continue
 		}
 
+		// Attachments updates complete by the time the cluster is described again
+		if completeClusterAttachments(cluster) {
+			if err := api.storage.ClusterStore().Update(ctx, cluster); err != nil {
+				logging.Warn("Failed to complete cluster attachments update", "cluster", cluster.Name, "error", err)
+			}
+			invalidateClusterCache(cluster.Name)
+		}
+
 		// Build cluster response
 		clusterResp := generated.Cluster{
 			ClusterArn:                        ptr.String(cluster.ARN),
@@ -259,6 +298,10 @@ func (api *DefaultECSAPI) DescribeClusters(ctx context.Context, req *generated.D
 			RunningTasksCount:                 ptr.Int32(int32(cluster.RunningTasksCount)),
 			PendingTasksCount:                 ptr.Int32(int32(cluster.PendingTasksCount)),
 			ActiveServicesCount:               ptr.Int32(int32(cluster.ActiveServicesCount)),
+			ServiceConnectDefaults:            parseServiceConnectDefaults(cluster.ServiceConnectDefaults),
+		}
+		if cluster.AttachmentsStatus != "" {
+			clusterResp.AttachmentsStatus = ptr.String(cluster.AttachmentsStatus)
 		}
 
 		// Add settings if requested
@@ -286,6 +329,14 @@ func (api *DefaultECSAPI) DescribeClusters(ctx context.Context, req *generated.D
 							clusterResp.Tags = tags
 						}
 					}
+				case generated.ClusterFieldATTACHMENTS:
+					clusterResp.Attachments = parseClusterAttachments(cluster.Attachments)
+				case generated.ClusterFieldSTATISTICS:
+					statistics, err := api.clusterStatistics(ctx, cluster)
+					if err != nil {
+						return nil, err
+					}
+					clusterResp.Statistics = statistics
 				}
 			}
 		}
@@ -319,6 +370,11 @@ func (api *DefaultECSAPI) DeleteCluster(ctx context.Context, req *generated.Dele
 		return nil, fmt.Errorf("cluster not found: %s", req.Cluster)
 	}
 
+	// With cascading deletion enabled the remaining resources are deleted first
+	if (cluster.ActiveServicesCount > 0 || cluster.RunningTasksCount > 0) && config.GetBool("cluster.cascadeDelete") {
+		return api.CascadeDeleteCluster(ctx, cluster.Name, nil)
+	}
+
 	// Check if cluster has active resources
 	if cluster.ActiveServicesCount > 0 {
 		return nil, fmt.Errorf("The cluster cannot be deleted while services are active")
@@ -406,9 +462,10 @@ func (api *DefaultECSAPI) UpdateCluster(ctx context.Context, req *generated.Upda
 
 	// Update service connect defaults if provided
 	if req.ServiceConnectDefaults != nil {
-		// For now, we'll store this in the Configuration field
-		// In a real implementation, this might need a separate field
-		logging.Warn("ServiceConnectDefaults update requested but not fully implemented yet")
+		if err := api.applyServiceConnectDefaults(ctx, cluster, req.ServiceConnectDefaults); err != nil {
+			return nil, err
+		}
+		updateClusterAttachments(cluster)
 	}
 
 	// Update the cluster
@@ -428,6 +485,11 @@ func (api *DefaultECSAPI) UpdateCluster(ctx context.Context, req *generated.Upda
 		RunningTasksCount:                 ptr.Int32(int32(cluster.RunningTasksCount)),
 		PendingTasksCount:                 ptr.Int32(int32(cluster.PendingTasksCount)),
 		ActiveServicesCount:               ptr.Int32(int32(cluster.ActiveServicesCount)),
+		ServiceConnectDefaults:            parseServiceConnectDefaults(cluster.ServiceConnectDefaults),
+		Attachments:                       parseClusterAttachments(cluster.Attachments),
+	}
+	if cluster.AttachmentsStatus != "" {
+		responseCluster.AttachmentsStatus = ptr.String(cluster.AttachmentsStatus)
 	}
 
 	// Add settings if present
@@ -611,6 +673,7 @@ func (api *DefaultECSAPI) PutClusterCapacityProviders(ctx context.Context, req *
 		return nil, fmt.Errorf("failed to marshal default capacity provider strategy: %w", err)
 	}
 	cluster.DefaultCapacityProviderStrategy = string(strategyJSON)
+	updateClusterAttachments(cluster)
 
 	// Update the cluster
 	if err := api.storage.ClusterStore().Update(ctx, cluster); err != nil {
@@ -631,6 +694,10 @@ func (api *DefaultECSAPI) PutClusterCapacityProviders(ctx context.Context, req *
 		RunningTasksCount:                 ptr.Int32(int32(cluster.RunningTasksCount)),
 		PendingTasksCount:                 ptr.Int32(int32(cluster.PendingTasksCount)),
 		ActiveServicesCount:               ptr.Int32(int32(cluster.ActiveServicesCount)),
+		Attachments:                       parseClusterAttachments(cluster.Attachments),
+	}
+	if cluster.AttachmentsStatus != "" {
+		responseCluster.AttachmentsStatus = ptr.String(cluster.AttachmentsStatus)
 	}
 
 	// Add settings if present
@@ -722,7 +789,7 @@ func (api *DefaultECSAPI) deleteK8sClusterAndNamespace(cluster *storage.Cluster)
 // extractClusterNameFromARN extracts cluster name from ARN or returns the input if it's not an ARN
 // ARN format: arn:aws:ecs:region:account-id:cluster/cluster-name
 func extractClusterNameFromARN(identifier string) string {
-	if strings.HasPrefix(identifier, "arn:aws:ecs:") {
+	if awsarn.IsService(identifier, "ecs") {
 		parts := strings.Split(identifier, "/")
 		if len(parts) == 2 && parts[0] != "" && parts[1] != "" {
 			return parts[1]
@@ -736,7 +803,7 @@ func (api *DefaultECSAPI) updateLocalStackState(cluster *storage.Cluster, status
 	ctx := context.Background()
 
 	// Create LocalStack state
-	now := time.Now()
+	now := deterministic.Now()
 	state := &storage.LocalStackState{
 		Deployed:   true,
 		Status:     status,
@@ -2,15 +2,19 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
 	"github.com/nandemo-ya/kecs/controlplane/internal/config"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/mocks"
+	"github.com/nandemo-ya/kecs/controlplane/internal/servicediscovery"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
 )
 
 var _ = Describe("Cluster ECS API", func() {
@@ -147,6 +151,21 @@ var _ = Describe("Cluster ECS API", func() {
 				Expect(clusterCount).To(Equal(1))
 			})
 		})
+
+		Context("when the caller belongs to another account", func() {
+			AfterEach(func() {
+				Expect(awsarn.SetPartition(awsarn.PartitionAWS)).To(Succeed())
+			})
+
+			It("should create the cluster in the account and partition of the caller", func() {
+				Expect(awsarn.SetPartition(awsarn.PartitionAWSCN)).To(Succeed())
+				callerCtx := awsarn.WithAccountID(ctx, "111111111111")
+
+				resp, err := server.ecsAPI.CreateCluster(callerCtx, &generated.CreateClusterRequest{ClusterName: ptr.String("other-account")})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(*resp.Cluster.ClusterArn).To(Equal("arn:aws-cn:ecs:us-east-1:111111111111:cluster/other-account"))
+			})
+		})
 	})
 
 	Describe("ListClusters", func() {
@@ -269,6 +288,57 @@ var _ = Describe("Cluster ECS API", func() {
 		})
 	})
 
+	Describe("DescribeClusters statistics", func() {
+		It("should count tasks and services by state and launch type", func() {
+			mockStorage.SetTaskStore(mocks.NewMockTaskStore())
+			mockStorage.SetServiceStore(mocks.NewMockServiceStore())
+			clusterName := "stats-test"
+			created, err := server.ecsAPI.CreateCluster(ctx, &generated.CreateClusterRequest{ClusterName: &clusterName})
+			Expect(err).NotTo(HaveOccurred())
+			clusterARN := *created.Cluster.ClusterArn
+
+			for i, t := range []struct{ status, launchType string }{
+				{"RUNNING", "FARGATE"}, {"RUNNING", "FARGATE"}, {"PENDING", "EC2"}, {"STOPPED", "FARGATE"}, {"RUNNING", "EXTERNAL"},
+			} {
+				Expect(mockStorage.TaskStore().Create(ctx, &storage.Task{
+					ARN:        fmt.Sprintf("%s-task-%d", clusterARN, i),
+					ID:         fmt.Sprintf("task-%d", i),
+					ClusterARN: clusterARN,
+					LastStatus: t.status,
+					LaunchType: t.launchType,
+				})).To(Succeed())
+			}
+			for i, svc := range []struct{ status, launchType string }{
+				{"ACTIVE", "FARGATE"}, {"DRAINING", "EC2"}, {"INACTIVE", "FARGATE"},
+			} {
+				Expect(mockStorage.ServiceStore().Create(ctx, &storage.Service{
+					ServiceName: fmt.Sprintf("svc-%d", i),
+					ClusterARN:  clusterARN,
+					Status:      svc.status,
+					LaunchType:  svc.launchType,
+				})).To(Succeed())
+			}
+
+			resp, err := server.ecsAPI.DescribeClusters(ctx, &generated.DescribeClustersRequest{
+				Clusters: []string{clusterName},
+				Include:  []generated.ClusterField{generated.ClusterFieldSTATISTICS},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			statistics := map[string]string{}
+			for _, kv := range resp.Clusters[0].Statistics {
+				statistics[*kv.Name] = *kv.Value
+			}
+			Expect(statistics).To(HaveLen(12))
+			Expect(statistics).To(HaveKeyWithValue("runningFargateTasksCount", "2"))
+			Expect(statistics).To(HaveKeyWithValue("pendingEC2TasksCount", "1"))
+			Expect(statistics).To(HaveKeyWithValue("runningExternalTasksCount", "1"))
+			Expect(statistics).To(HaveKeyWithValue("runningEC2TasksCount", "0"))
+			Expect(statistics).To(HaveKeyWithValue("activeFargateServiceCount", "1"))
+			Expect(statistics).To(HaveKeyWithValue("drainingEC2ServiceCount", "1"))
+		})
+	})
+
 	Describe("DeleteCluster", func() {
 		Context("when deleting a cluster", func() {
 			It("should delete an existing cluster", func() {
@@ -364,6 +434,84 @@ var _ = Describe("Cluster ECS API", func() {
 		})
 	})
 
+	Describe("Cascading cluster deletion", func() {
+		var (
+			clusterARN   string
+			serviceStore *mocks.MockServiceStore
+			taskStore    *mocks.MockTaskStore
+		)
+
+		BeforeEach(func() {
+			serviceStore = mocks.NewMockServiceStore()
+			taskStore = mocks.NewMockTaskStore()
+			mockStorage.SetServiceStore(serviceStore)
+			mockStorage.SetTaskStore(taskStore)
+			mockStorage.SetTaskSetStore(mocks.NewMockTaskSetStore())
+
+			clusterName := "cascade"
+			resp, err := server.ecsAPI.CreateCluster(ctx, &generated.CreateClusterRequest{ClusterName: &clusterName})
+			Expect(err).NotTo(HaveOccurred())
+			clusterARN = *resp.Cluster.ClusterArn
+
+			Expect(serviceStore.Create(ctx, &storage.Service{
+				ARN:          "arn:aws:ecs:us-east-1:000000000000:service/cascade/web",
+				ServiceName:  "web",
+				ClusterARN:   clusterARN,
+				DesiredCount: 1,
+				Status:       "ACTIVE",
+			})).To(Succeed())
+			Expect(taskStore.Create(ctx, &storage.Task{
+				ID:            "standalone",
+				ARN:           "arn:aws:ecs:us-east-1:000000000000:task/cascade/standalone",
+				ClusterARN:    clusterARN,
+				DesiredStatus: "RUNNING",
+				LastStatus:    "RUNNING",
+			})).To(Succeed())
+
+			cluster, err := mockClusterStore.Get(ctx, clusterName)
+			Expect(err).NotTo(HaveOccurred())
+			cluster.ActiveServicesCount = 1
+			cluster.RunningTasksCount = 1
+			Expect(mockClusterStore.Update(ctx, cluster)).To(Succeed())
+		})
+
+		It("should delete the services and tasks before the cluster", func() {
+			var resources []string
+			resp, err := server.ecsAPI.(*DefaultECSAPI).CascadeDeleteCluster(ctx, clusterARN, func(resource, message string) {
+				resources = append(resources, resource)
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*resp.Cluster.Status).To(Equal("INACTIVE"))
+
+			Expect(resources).To(ContainElements(
+				"arn:aws:ecs:us-east-1:000000000000:service/cascade/web",
+				"arn:aws:ecs:us-east-1:000000000000:task/cascade/standalone",
+				clusterARN,
+			))
+			_, err = serviceStore.Get(ctx, clusterARN, "web")
+			Expect(err).To(HaveOccurred())
+			task, err := taskStore.Get(ctx, clusterARN, "arn:aws:ecs:us-east-1:000000000000:task/cascade/standalone")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(task.DesiredStatus).To(Equal("STOPPED"))
+			_, err = mockClusterStore.Get(ctx, "cascade")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should cascade DeleteCluster when enabled in the configuration", func() {
+			_, err := server.ecsAPI.DeleteCluster(ctx, &generated.DeleteClusterRequest{Cluster: "cascade"})
+			Expect(err).To(MatchError("The cluster cannot be deleted while services are active"))
+
+			config.Set("cluster.cascadeDelete", true)
+			DeferCleanup(config.Set, "cluster.cascadeDelete", false)
+
+			resp, err := server.ecsAPI.DeleteCluster(ctx, &generated.DeleteClusterRequest{Cluster: "cascade"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*resp.Cluster.Status).To(Equal("INACTIVE"))
+			_, err = serviceStore.Get(ctx, clusterARN, "web")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
 	Describe("UpdateCluster", func() {
 		Context("when updating a cluster", func() {
 			BeforeEach(func() {
@@ -425,6 +573,23 @@ var _ = Describe("Cluster ECS API", func() {
 				Expect(*resp.Cluster.Configuration.ExecuteCommandConfiguration.Logging).To(Equal(generated.ExecuteCommandLoggingDEFAULT))
 			})
 
+			It("should store and clear the default Service Connect namespace", func() {
+				resp, err := server.ecsAPI.UpdateCluster(ctx, &generated.UpdateClusterRequest{
+					Cluster:                "update-test",
+					ServiceConnectDefaults: &generated.ClusterServiceConnectDefaultsRequest{Namespace: "app.local"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.Cluster.ServiceConnectDefaults).NotTo(BeNil())
+				Expect(*resp.Cluster.ServiceConnectDefaults.Namespace).To(Equal("app.local"))
+
+				resp, err = server.ecsAPI.UpdateCluster(ctx, &generated.UpdateClusterRequest{
+					Cluster:                "update-test",
+					ServiceConnectDefaults: &generated.ClusterServiceConnectDefaultsRequest{Namespace: ""},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.Cluster.ServiceConnectDefaults).To(BeNil())
+			})
+
 			It("should fail when cluster does not exist", func() {
 				clusterName := "non-existent"
 				req := &generated.UpdateClusterRequest{
@@ -438,6 +603,60 @@ var _ = Describe("Cluster ECS API", func() {
 		})
 	})
 
+	Describe("ServiceConnectDefaults", func() {
+		BeforeEach(func() {
+			server.ecsAPI.(*DefaultECSAPI).SetServiceDiscoveryManager(
+				servicediscovery.NewManager(nil, "us-east-1", "123456789012", ""))
+		})
+
+		It("should create the Cloud Map namespace and report its ARN", func() {
+			clusterName := "sc-cluster"
+			resp, err := server.ecsAPI.CreateCluster(ctx, &generated.CreateClusterRequest{
+				ClusterName:            &clusterName,
+				ServiceConnectDefaults: &generated.ClusterServiceConnectDefaultsRequest{Namespace: "sc.local"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Cluster.ServiceConnectDefaults).NotTo(BeNil())
+			Expect(*resp.Cluster.ServiceConnectDefaults.Namespace).To(HavePrefix("arn:aws:servicediscovery:us-east-1:123456789012:namespace/ns-"))
+
+			described, err := server.ecsAPI.DescribeClusters(ctx, &generated.DescribeClustersRequest{
+				Clusters: []string{clusterName},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(described.Clusters[0].ServiceConnectDefaults.Namespace).To(Equal(resp.Cluster.ServiceConnectDefaults.Namespace))
+		})
+
+		It("should reject an unknown namespace ARN", func() {
+			clusterName := "sc-missing"
+			_, err := server.ecsAPI.CreateCluster(ctx, &generated.CreateClusterRequest{
+				ClusterName: &clusterName,
+				ServiceConnectDefaults: &generated.ClusterServiceConnectDefaultsRequest{
+					Namespace: "arn:aws:servicediscovery:us-east-1:123456789012:namespace/ns-missing",
+				},
+			})
+			Expect(err).To(BeAssignableToTypeOf(&generated.NamespaceNotFoundException{}))
+		})
+	})
+
+	Describe("inheritServiceConnectNamespace", func() {
+		cluster := &storage.Cluster{ServiceConnectDefaults: `{"namespace":"arn:aws:servicediscovery:us-east-1:123456789012:namespace/ns-default"}`}
+
+		It("should fill in the cluster default namespace", func() {
+			config := inheritServiceConnectNamespace(cluster, &generated.ServiceConnectConfiguration{Enabled: true})
+			Expect(*config.Namespace).To(Equal("arn:aws:servicediscovery:us-east-1:123456789012:namespace/ns-default"))
+		})
+
+		It("should keep an explicit namespace", func() {
+			config := inheritServiceConnectNamespace(cluster, &generated.ServiceConnectConfiguration{Enabled: true, Namespace: ptr.String("other.local")})
+			Expect(*config.Namespace).To(Equal("other.local"))
+		})
+
+		It("should leave disabled configurations alone", func() {
+			config := inheritServiceConnectNamespace(cluster, &generated.ServiceConnectConfiguration{Enabled: false})
+			Expect(config.Namespace).To(BeNil())
+		})
+	})
+
 	Describe("UpdateClusterSettings", func() {
 		Context("when updating cluster settings", func() {
 			BeforeEach(func() {
@@ -550,6 +769,106 @@ var _ = Describe("Cluster ECS API", func() {
 		})
 	})
 
+	Describe("Cluster attachments", func() {
+		describeAttachments := func() generated.Cluster {
+			resp, err := server.ecsAPI.DescribeClusters(ctx, &generated.DescribeClustersRequest{
+				Clusters: []string{"attached"},
+				Include:  []generated.ClusterField{generated.ClusterFieldATTACHMENTS},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Clusters).To(HaveLen(1))
+			return resp.Clusters[0]
+		}
+
+		BeforeEach(func() {
+			_, err := server.ecsAPI.CreateCluster(ctx, &generated.CreateClusterRequest{ClusterName: ptr.String("attached")})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should have no attachments by default", func() {
+			cluster := describeAttachments()
+			Expect(cluster.Attachments).To(BeEmpty())
+			Expect(cluster.AttachmentsStatus).To(BeNil())
+		})
+
+		It("should attach a scaling policy for EC2 capacity providers", func() {
+			resp, err := server.ecsAPI.PutClusterCapacityProviders(ctx, &generated.PutClusterCapacityProvidersRequest{
+				Cluster:           "attached",
+				CapacityProviders: []string{"FARGATE", "EC2"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*resp.Cluster.AttachmentsStatus).To(Equal("UPDATE_IN_PROGRESS"))
+			Expect(resp.Cluster.Attachments).To(HaveLen(1))
+			Expect(*resp.Cluster.Attachments[0].Type).To(Equal("as_policy"))
+			Expect(*resp.Cluster.Attachments[0].Status).To(Equal("PRECREATED"))
+			Expect(*resp.Cluster.Attachments[0].Details[0].Value).To(Equal("EC2"))
+			id := *resp.Cluster.Attachments[0].Id
+
+			cluster := describeAttachments()
+			Expect(*cluster.AttachmentsStatus).To(Equal("UPDATE_COMPLETE"))
+			Expect(cluster.Attachments).To(HaveLen(1))
+			Expect(*cluster.Attachments[0].Id).To(Equal(id))
+			Expect(*cluster.Attachments[0].Status).To(Equal("ATTACHED"))
+
+			// Keeping the provider leaves its attachment alone
+			_, err = server.ecsAPI.PutClusterCapacityProviders(ctx, &generated.PutClusterCapacityProvidersRequest{
+				Cluster:           "attached",
+				CapacityProviders: []string{"EC2"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*describeAttachments().Attachments[0].Id).To(Equal(id))
+		})
+
+		It("should detach the attachments of removed capacity providers", func() {
+			_, err := server.ecsAPI.PutClusterCapacityProviders(ctx, &generated.PutClusterCapacityProvidersRequest{
+				Cluster:           "attached",
+				CapacityProviders: []string{"EC2"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			describeAttachments()
+
+			resp, err := server.ecsAPI.PutClusterCapacityProviders(ctx, &generated.PutClusterCapacityProvidersRequest{
+				Cluster:           "attached",
+				CapacityProviders: []string{},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*resp.Cluster.AttachmentsStatus).To(Equal("UPDATE_IN_PROGRESS"))
+			Expect(*resp.Cluster.Attachments[0].Status).To(Equal("DETACHING"))
+
+			cluster := describeAttachments()
+			Expect(*cluster.AttachmentsStatus).To(Equal("UPDATE_COMPLETE"))
+			Expect(cluster.Attachments).To(BeEmpty())
+		})
+
+		It("should attach the Service Connect namespace", func() {
+			namespace := "arn:aws:servicediscovery:us-east-1:000000000000:namespace/ns-1"
+			resp, err := server.ecsAPI.UpdateCluster(ctx, &generated.UpdateClusterRequest{
+				Cluster:                "attached",
+				ServiceConnectDefaults: &generated.ClusterServiceConnectDefaultsRequest{Namespace: namespace},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Cluster.Attachments).To(HaveLen(1))
+			Expect(*resp.Cluster.Attachments[0].Type).To(Equal("sc"))
+
+			cluster := describeAttachments()
+			Expect(*cluster.Attachments[0].Status).To(Equal("ATTACHED"))
+			Expect(*cluster.Attachments[0].Details[0].Value).To(Equal(namespace))
+		})
+
+		It("should only list attachments when included", func() {
+			_, err := server.ecsAPI.PutClusterCapacityProviders(ctx, &generated.PutClusterCapacityProvidersRequest{
+				Cluster:           "attached",
+				CapacityProviders: []string{"EC2"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			resp, err := server.ecsAPI.DescribeClusters(ctx, &generated.DescribeClustersRequest{Clusters: []string{"attached"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Clusters[0].Attachments).To(BeEmpty())
+			Expect(*resp.Clusters[0].AttachmentsStatus).To(Equal("UPDATE_COMPLETE"))
+		})
+	})
+
 	Describe("extractClusterNameFromARN", func() {
 		It("should extract cluster name from valid ARN", func() {
 			arn := "arn:aws:ecs:us-east-1:000000000000:cluster/my-cluster"
@@ -140,7 +140,8 @@ var _ = Describe("Cluster Pagination", func() {
 				resp, err := ecsAPI.ListClusters(ctx, req)
 				Expect(err).To(HaveOccurred())
 				Expect(resp).To(BeNil())
-				Expect(err.Error()).To(ContainSubstring("invalid pagination token"))
+				Expect(err).To(BeAssignableToTypeOf(&generated.InvalidParameterException{}))
+				Expect(*err.(*generated.InvalidParameterException).Message).To(ContainSubstring("invalid pagination token"))
 			})
 
 			It("should cap maxResults at 100", func() {
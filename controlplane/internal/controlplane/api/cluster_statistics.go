@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// clusterStatisticNames lists the statistics DescribeClusters reports for
+// include=STATISTICS, in the order ECS returns them
+var clusterStatisticNames = []string{
+	"runningEC2TasksCount",
+	"runningFargateTasksCount",
+	"pendingFargateTasksCount",
+	"runningExternalTasksCount",
+	"pendingEC2TasksCount",
+	"pendingExternalTasksCount",
+	"activeEC2ServiceCount",
+	"activeFargateServiceCount",
+	"drainingFargateServiceCount",
+	"activeExternalServiceCount",
+	"drainingEC2ServiceCount",
+	"drainingExternalServiceCount",
+}
+
+// clusterStatistics counts the tasks and services of a cluster by state and
+// launch type
+func (api *DefaultECSAPI) clusterStatistics(ctx context.Context, cluster *storage.Cluster) ([]generated.KeyValuePair, error) {
+	tasks, err := api.storage.TaskStore().List(ctx, cluster.ARN, storage.TaskFilters{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+	services, _, err := api.storage.ServiceStore().List(ctx, cluster.ARN, storage.ServiceFilters{}, 0, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	return computeClusterStatistics(tasks, services), nil
+}
+
+// computeClusterStatistics derives the cluster statistics from its tasks and services
+func computeClusterStatistics(tasks []*storage.Task, services []*storage.Service) []generated.KeyValuePair {
+	counts := make(map[string]int, len(clusterStatisticNames))
+
+	for _, task := range tasks {
+		switch task.LastStatus {
+		case "RUNNING":
+			counts["running"+statisticLaunchType(task.LaunchType)+"TasksCount"]++
+		case "PROVISIONING", "PENDING", "ACTIVATING":
+			counts["pending"+statisticLaunchType(task.LaunchType)+"TasksCount"]++
+		}
+	}
+	for _, service := range services {
+		switch service.Status {
+		case "INACTIVE":
+			// Deleted services are not counted
+		case "DRAINING":
+			counts["draining"+statisticLaunchType(service.LaunchType)+"ServiceCount"]++
+		default:
+			counts["active"+statisticLaunchType(service.LaunchType)+"ServiceCount"]++
+		}
+	}
+
+	statistics := make([]generated.KeyValuePair, 0, len(clusterStatisticNames))
+	for _, name := range clusterStatisticNames {
+		statistics = append(statistics, generated.KeyValuePair{
+			Name:  ptr.String(name),
+			Value: ptr.String(strconv.Itoa(counts[name])),
+		})
+	}
+	return statistics
+}
+
+// statisticLaunchType maps a launch type to the infix of its statistic names.
+// Tasks and services without a launch type count as EC2, the ECS default.
+func statisticLaunchType(launchType string) string {
+	switch launchType {
+	case string(generated.LaunchTypeFARGATE):
+		return "Fargate"
+	case string(generated.LaunchTypeEXTERNAL):
+		return "External"
+	default:
+		return "EC2"
+	}
+}
@@ -0,0 +1,288 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/cloudwatch"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// Metric namespaces the collector publishes to
+const (
+	metricNamespaceECS               = "AWS/ECS"
+	metricNamespaceContainerInsights = "ECS/ContainerInsights"
+)
+
+// podUsage is the CPU and memory a pod uses, from the metrics API
+type podUsage struct {
+	Name   string
+	CPU    resource.Quantity
+	Memory resource.Quantity
+}
+
+// ContainerInsightsCollector publishes the CPU and memory metrics of the
+// services of clusters with containerInsights enabled to CloudWatch, as the
+// AWS/ECS CPUUtilization and MemoryUtilization metrics target tracking
+// scaling policies use and the ECS/ContainerInsights service metrics. Usage
+// is read from the Kubernetes metrics API, reservations from the pods.
+type ContainerInsightsCollector struct {
+	api        *DefaultECSAPI
+	kubeClient kubernetes.Interface
+	interval   time.Duration
+	ticker     *time.Ticker
+	done       chan struct{}
+	// podMetrics returns the usage of the pods of a namespace matching a selector
+	podMetrics func(ctx context.Context, namespace, selector string) ([]podUsage, error)
+}
+
+// NewContainerInsightsCollector creates a collector for the services of api
+func NewContainerInsightsCollector(api *DefaultECSAPI, kubeClient kubernetes.Interface) *ContainerInsightsCollector {
+	c := &ContainerInsightsCollector{
+		api:        api,
+		kubeClient: kubeClient,
+		interval:   config.GetDuration("containerInsights.interval", time.Minute),
+		done:       make(chan struct{}),
+	}
+	c.podMetrics = c.metricsAPIPodUsage
+	return c
+}
+
+// Start begins publishing metrics
+func (c *ContainerInsightsCollector) Start(ctx context.Context) {
+	c.ticker = time.NewTicker(c.interval)
+
+	go func() {
+		logging.Info("Container Insights collector: Started", "interval", c.interval)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-c.done:
+				return
+			case <-c.ticker.C:
+				c.collect(ctx)
+			}
+		}
+	}()
+}
+
+// Stop halts the collector
+func (c *ContainerInsightsCollector) Stop() {
+	if c.ticker != nil {
+		c.ticker.Stop()
+	}
+	close(c.done)
+}
+
+// collect publishes the metrics of every active service of the clusters
+// with Container Insights enabled
+func (c *ContainerInsightsCollector) collect(ctx context.Context) {
+	if c.api.cloudWatchIntegration == nil {
+		return
+	}
+
+	clusters, err := c.api.storage.ClusterStore().List(ctx)
+	if err != nil {
+		logging.Warn("Container Insights collector: Failed to list clusters", "error", err)
+		return
+	}
+	// Metric timestamps follow the wall clock, as CloudWatch reads the
+	// metrics of the latest period, and a tick must not step the
+	// deterministic clock
+	now := time.Now()
+	var ecsData, insightsData []cloudwatch.MetricDatum
+	for _, cluster := range clusters {
+		if !containerInsightsEnabled(cluster) {
+			continue
+		}
+		services, _, err := c.api.storage.ServiceStore().List(ctx, cluster.ARN, storage.ServiceFilters{}, 0, "")
+		if err != nil {
+			logging.Warn("Container Insights collector: Failed to list services", "cluster", cluster.Name, "error", err)
+			continue
+		}
+		for _, service := range services {
+			if service.Status != "ACTIVE" {
+				continue
+			}
+			ecs, insights, err := c.serviceMetrics(ctx, cluster, service, now)
+			if err != nil {
+				logging.Debug("Container Insights collector: Failed to collect service metrics",
+					"service", service.ServiceName, "error", err)
+				continue
+			}
+			ecsData = append(ecsData, ecs...)
+			insightsData = append(insightsData, insights...)
+		}
+	}
+
+	if err := c.api.cloudWatchIntegration.PutMetricData(metricNamespaceECS, ecsData); err != nil {
+		logging.Warn("Container Insights collector: Failed to publish metrics", "namespace", metricNamespaceECS, "error", err)
+	}
+	if err := c.api.cloudWatchIntegration.PutMetricData(metricNamespaceContainerInsights, insightsData); err != nil {
+		logging.Warn("Container Insights collector: Failed to publish metrics", "namespace", metricNamespaceContainerInsights, "error", err)
+	}
+}
+
+// serviceMetrics returns the AWS/ECS and ECS/ContainerInsights data points of
+// a service. CPU is measured in CPU units, 1024 per vCPU, as in ECS.
+func (c *ContainerInsightsCollector) serviceMetrics(ctx context.Context, cluster *storage.Cluster, service *storage.Service, now time.Time) ([]cloudwatch.MetricDatum, []cloudwatch.MetricDatum, error) {
+	_, _, deployment, _, err := c.api.desiredServiceResources(ctx, service)
+	if err != nil {
+		return nil, nil, err
+	}
+	if deployment.Spec.Selector == nil {
+		return nil, nil, fmt.Errorf("deployment %s has no selector", deployment.Name)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, nil, err
+	}
+	pods, err := c.kubeClient.CoreV1().Pods(deployment.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	usages, err := c.podMetrics(ctx, deployment.Namespace, selector.String())
+	if err != nil {
+		return nil, nil, err
+	}
+	usageByPod := make(map[string]podUsage, len(usages))
+	for _, usage := range usages {
+		usageByPod[usage.Name] = usage
+	}
+
+	running := 0
+	var cpuUsed, cpuReserved, memoryUsed, memoryReserved float64
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		running++
+		cpu, memory := podReservation(&pod)
+		cpuReserved += cpu
+		memoryReserved += memory
+		if usage, ok := usageByPod[pod.Name]; ok {
+			cpuUsed += float64(usage.CPU.MilliValue()) * 1024 / 1000
+			memoryUsed += float64(usage.Memory.Value()) / (1024 * 1024)
+		}
+	}
+
+	dimensions := map[string]string{"ClusterName": cluster.Name, "ServiceName": service.ServiceName}
+	datum := func(name string, value float64, unit string) cloudwatch.MetricDatum {
+		return cloudwatch.MetricDatum{MetricName: name, Dimensions: dimensions, Value: value, Unit: unit, Timestamp: now}
+	}
+
+	insights := []cloudwatch.MetricDatum{
+		datum("RunningTaskCount", float64(running), "Count"),
+		datum("DesiredTaskCount", float64(service.DesiredCount), "Count"),
+	}
+	var ecs []cloudwatch.MetricDatum
+	if running == 0 {
+		return ecs, insights, nil
+	}
+	insights = append(insights,
+		datum("CpuUtilized", cpuUsed, "None"),
+		datum("CpuReserved", cpuReserved, "None"),
+		datum("MemoryUtilized", memoryUsed, "Megabytes"),
+		datum("MemoryReserved", memoryReserved, "Megabytes"),
+	)
+	if cpuReserved > 0 {
+		ecs = append(ecs, datum("CPUUtilization", cpuUsed/cpuReserved*100, "Percent"))
+	}
+	if memoryReserved > 0 {
+		ecs = append(ecs, datum("MemoryUtilization", memoryUsed/memoryReserved*100, "Percent"))
+	}
+	return ecs, insights, nil
+}
+
+// podReservation returns the CPU units and MiB of memory reserved by the
+// containers of a pod, their limits or else their requests
+func podReservation(pod *corev1.Pod) (float64, float64) {
+	var cpu, memory float64
+	for _, container := range pod.Spec.Containers {
+		resources := container.Resources.Limits
+		if _, ok := resources[corev1.ResourceCPU]; !ok {
+			resources = container.Resources.Requests
+		}
+		if q, ok := resources[corev1.ResourceCPU]; ok {
+			cpu += float64(q.MilliValue()) * 1024 / 1000
+		}
+		resources = container.Resources.Limits
+		if _, ok := resources[corev1.ResourceMemory]; !ok {
+			resources = container.Resources.Requests
+		}
+		if q, ok := resources[corev1.ResourceMemory]; ok {
+			memory += float64(q.Value()) / (1024 * 1024)
+		}
+	}
+	return cpu, memory
+}
+
+// containerInsightsEnabled reports whether a cluster enables Container
+// Insights, as enabled or enhanced
+func containerInsightsEnabled(cluster *storage.Cluster) bool {
+	settings, err := parseClusterSettings(cluster.Name, cluster.Settings)
+	if err != nil {
+		return false
+	}
+	for _, setting := range settings {
+		if setting.Name != nil && *setting.Name == generated.ClusterSettingNameCONTAINER_INSIGHTS &&
+			setting.Value != nil && (*setting.Value == "enabled" || *setting.Value == "enhanced") {
+			return true
+		}
+	}
+	return false
+}
+
+// podMetricsList is the part of a metrics.k8s.io PodMetricsList the collector reads
+type podMetricsList struct {
+	Items []struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Containers []struct {
+			Usage map[string]resource.Quantity `json:"usage"`
+		} `json:"containers"`
+	} `json:"items"`
+}
+
+// metricsAPIPodUsage reads the usage of pods from the metrics API served by
+// metrics-server, which k3s runs by default
+func (c *ContainerInsightsCollector) metricsAPIPodUsage(ctx context.Context, namespace, selector string) ([]podUsage, error) {
+	data, err := c.kubeClient.Discovery().RESTClient().Get().
+		AbsPath("/apis/metrics.k8s.io/v1beta1/namespaces", namespace, "pods").
+		Param("labelSelector", selector).
+		DoRaw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pod metrics: %w", err)
+	}
+	var list podMetricsList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to decode pod metrics: %w", err)
+	}
+
+	usages := make([]podUsage, 0, len(list.Items))
+	for _, item := range list.Items {
+		usage := podUsage{Name: item.Metadata.Name}
+		for _, container := range item.Containers {
+			if q, ok := container.Usage["cpu"]; ok {
+				usage.CPU.Add(q)
+			}
+			if q, ok := container.Usage["memory"]; ok {
+				usage.Memory.Add(q)
+			}
+		}
+		usages = append(usages, usage)
+	}
+	return usages, nil
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/mocks"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
+	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/cloudwatch"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+var _ = Describe("ContainerInsightsCollector", func() {
+	const (
+		clusterARN = "arn:aws:ecs:us-east-1:000000000000:cluster/default"
+		namespace  = "default-us-east-1"
+	)
+
+	var (
+		ctx              context.Context
+		collector        *ContainerInsightsCollector
+		kubeClient       *fake.Clientset
+		metrics          *cloudwatch.MockIntegration
+		mockClusterStore *mocks.MockClusterStore
+		selectors        []string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockStorage := mocks.NewMockStorage()
+		mockClusterStore = mocks.NewMockClusterStore()
+		mockTaskDefStore := mocks.NewMockTaskDefinitionStore()
+		mockServiceStore := mocks.NewMockServiceStore()
+		mockStorage.SetClusterStore(mockClusterStore)
+		mockStorage.SetTaskDefinitionStore(mockTaskDefStore)
+		mockStorage.SetServiceStore(mockServiceStore)
+
+		Expect(mockClusterStore.Create(ctx, &storage.Cluster{
+			Name:      "default",
+			ARN:       clusterARN,
+			Status:    "ACTIVE",
+			Region:    "us-east-1",
+			AccountID: "000000000000",
+			Settings:  `[{"name":"containerInsights","value":"enabled"}]`,
+		})).To(Succeed())
+		taskDef, err := mockTaskDefStore.Register(ctx, &storage.TaskDefinition{
+			Family:               "web",
+			ContainerDefinitions: `[{"name":"web","image":"nginx","memory":512}]`,
+			CPU:                  "1024",
+			Memory:               "512",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mockServiceStore.Create(ctx, &storage.Service{
+			ARN:               "arn:aws:ecs:us-east-1:000000000000:service/default/web",
+			ServiceName:       "web",
+			ClusterARN:        clusterARN,
+			TaskDefinitionARN: taskDef.ARN,
+			DesiredCount:      2,
+			Status:            "ACTIVE",
+		})).To(Succeed())
+
+		ecsAPI := NewDefaultECSAPI(config.DefaultConfig(), mockStorage).(*DefaultECSAPI)
+		metrics = &cloudwatch.MockIntegration{}
+		ecsAPI.SetCloudWatchIntegration(metrics)
+
+		kubeClient = fake.NewSimpleClientset()
+		collector = NewContainerInsightsCollector(ecsAPI, kubeClient)
+		selectors = nil
+		collector.podMetrics = func(_ context.Context, ns, selector string) ([]podUsage, error) {
+			Expect(ns).To(Equal(namespace))
+			selectors = append(selectors, selector)
+			return []podUsage{
+				{Name: "web-1", CPU: resource.MustParse("500m"), Memory: resource.MustParse("128Mi")},
+				{Name: "web-2", CPU: resource.MustParse("300m"), Memory: resource.MustParse("256Mi")},
+			}, nil
+		}
+
+		for _, name := range []string{"web-1", "web-2"} {
+			_, err := kubeClient.CoreV1().Pods(namespace).Create(ctx, &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+					Labels:    map[string]string{"app": "web", "kecs.dev/service": "web"},
+				},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name: "web",
+					Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("1"),
+						corev1.ResourceMemory: resource.MustParse("512Mi"),
+					}},
+				}}},
+				Status: corev1.PodStatus{Phase: corev1.PodRunning},
+			}, metav1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		}
+	})
+
+	values := func(namespace string) map[string]float64 {
+		result := make(map[string]float64)
+		for _, datum := range metrics.MetricData[namespace] {
+			Expect(datum.Dimensions).To(Equal(map[string]string{"ClusterName": "default", "ServiceName": "web"}))
+			result[datum.MetricName] = datum.Value
+		}
+		return result
+	}
+
+	It("publishes the utilization of services against their reservation", func() {
+		collector.collect(ctx)
+
+		Expect(selectors).To(HaveLen(1))
+		Expect(values(metricNamespaceECS)).To(Equal(map[string]float64{
+			"CPUUtilization":    40,
+			"MemoryUtilization": 37.5,
+		}))
+		Expect(values(metricNamespaceContainerInsights)).To(Equal(map[string]float64{
+			"RunningTaskCount": 2,
+			"DesiredTaskCount": 2,
+			"CpuUtilized":      819.2,
+			"CpuReserved":      2048,
+			"MemoryUtilized":   384,
+			"MemoryReserved":   1024,
+		}))
+	})
+
+	It("timestamps metrics with the wall clock in deterministic mode", func() {
+		deterministic.Enable(true)
+		DeferCleanup(deterministic.Enable, false)
+		next := deterministic.Now().Add(time.Second)
+
+		before := time.Now()
+		collector.collect(ctx)
+
+		Expect(metrics.MetricData[metricNamespaceECS]).NotTo(BeEmpty())
+		for _, datum := range metrics.MetricData[metricNamespaceECS] {
+			Expect(datum.Timestamp).To(BeTemporally(">=", before))
+		}
+		Expect(deterministic.Now()).To(Equal(next), "collecting must not advance the deterministic clock")
+	})
+
+	It("skips clusters without Container Insights", func() {
+		cluster, err := mockClusterStore.Get(ctx, clusterARN)
+		Expect(err).NotTo(HaveOccurred())
+		cluster.Settings = `[{"name":"containerInsights","value":"disabled"}]`
+		Expect(mockClusterStore.Update(ctx, cluster)).To(Succeed())
+
+		collector.collect(ctx)
+
+		Expect(selectors).To(BeEmpty())
+		Expect(metrics.MetricData[metricNamespaceECS]).To(BeEmpty())
+		Expect(metrics.MetricData[metricNamespaceContainerInsights]).To(BeEmpty())
+	})
+})
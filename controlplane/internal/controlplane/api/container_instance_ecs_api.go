@@ -3,10 +3,14 @@ package api
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
 )
 
@@ -21,7 +25,7 @@ func (api *DefaultECSAPI) RegisterContainerInstance(ctx context.Context, req *ge
 
 	containerInstanceArn := req.ContainerInstanceArn
 	if containerInstanceArn == nil {
-		arn := "arn:aws:ecs:" + api.region + ":" + api.accountID + ":container-instance/" + cluster + "/i-1234567890abcdef0"
+		arn := awsarn.Build("ecs", api.region, api.accountFor(ctx), "container-instance/"+cluster+"/i-1234567890abcdef0")
 		containerInstanceArn = ptr.String(arn)
 	}
 
@@ -36,7 +40,7 @@ func (api *DefaultECSAPI) RegisterContainerInstance(ctx context.Context, req *ge
 			RunningTasksCount:    ptr.Int32(0),
 			PendingTasksCount:    ptr.Int32(0),
 			AgentUpdateStatus:    (*generated.AgentUpdateStatus)(ptr.String("NOT_STAGED")),
-			RegisteredAt:         ptr.UnixTime(time.Now()),
+			RegisteredAt:         ptr.UnixTime(deterministic.Now()),
 			RegisteredResources: []generated.Resource{
 				{
 					Name:         ptr.String("CPU"),
@@ -48,16 +52,8 @@ func (api *DefaultECSAPI) RegisterContainerInstance(ctx context.Context, req *ge
 					Type:         ptr.String("INTEGER"),
 					IntegerValue: ptr.Int32(4096),
 				},
-				{
-					Name:           ptr.String("PORTS"),
-					Type:           ptr.String("STRINGSET"),
-					StringSetValue: []string{"22", "80", "443", "2376", "2375", "51678", "51679"},
-				},
-				{
-					Name:           ptr.String("PORTS_UDP"),
-					Type:           ptr.String("STRINGSET"),
-					StringSetValue: []string{},
-				},
+				portResource("PORTS", reservedInstancePorts),
+				portResource("PORTS_UDP", []string{}),
 			},
 			RemainingResources: []generated.Resource{
 				{
@@ -70,16 +66,8 @@ func (api *DefaultECSAPI) RegisterContainerInstance(ctx context.Context, req *ge
 					Type:         ptr.String("INTEGER"),
 					IntegerValue: ptr.Int32(4096),
 				},
-				{
-					Name:           ptr.String("PORTS"),
-					Type:           ptr.String("STRINGSET"),
-					StringSetValue: []string{"22", "80", "443", "2376", "2375", "51678", "51679"},
-				},
-				{
-					Name:           ptr.String("PORTS_UDP"),
-					Type:           ptr.String("STRINGSET"),
-					StringSetValue: []string{},
-				},
+				portResource("PORTS", reservedInstancePorts),
+				portResource("PORTS_UDP", []string{}),
 			},
 			VersionInfo: req.VersionInfo,
 			Attributes:  req.Attributes,
@@ -114,6 +102,7 @@ func (api *DefaultECSAPI) DescribeContainerInstances(ctx context.Context, req *g
 	// For now, return mock responses for requested instances
 	containerInstances := []generated.ContainerInstance{}
 	for i, arn := range req.ContainerInstances {
+		remainingTCP, remainingUDP := api.instancePortsInUse(ctx, arn)
 		containerInstances = append(containerInstances, generated.ContainerInstance{
 			ContainerInstanceArn: ptr.String(arn),
 			Ec2InstanceId:        ptr.String("i-1234567890abcdef" + string(rune('0'+i))),
@@ -122,7 +111,7 @@ func (api *DefaultECSAPI) DescribeContainerInstances(ctx context.Context, req *g
 			AgentConnected:       ptr.Bool(true),
 			RunningTasksCount:    ptr.Int32(0),
 			PendingTasksCount:    ptr.Int32(0),
-			RegisteredAt:         ptr.UnixTime(time.Now().Add(-24 * time.Hour)),
+			RegisteredAt:         ptr.UnixTime(deterministic.Now().Add(-24 * time.Hour)),
 			RegisteredResources: []generated.Resource{
 				{
 					Name:         ptr.String("CPU"),
@@ -134,6 +123,8 @@ func (api *DefaultECSAPI) DescribeContainerInstances(ctx context.Context, req *g
 					Type:         ptr.String("INTEGER"),
 					IntegerValue: ptr.Int32(4096),
 				},
+				portResource("PORTS", reservedInstancePorts),
+				portResource("PORTS_UDP", []string{}),
 			},
 			RemainingResources: []generated.Resource{
 				{
@@ -146,6 +137,8 @@ func (api *DefaultECSAPI) DescribeContainerInstances(ctx context.Context, req *g
 					Type:         ptr.String("INTEGER"),
 					IntegerValue: ptr.Int32(4096),
 				},
+				portResource("PORTS", remainingTCP),
+				portResource("PORTS_UDP", remainingUDP),
 			},
 			VersionInfo: &generated.VersionInfo{
 				AgentVersion:  ptr.String("1.51.0"),
@@ -163,6 +156,42 @@ func (api *DefaultECSAPI) DescribeContainerInstances(ctx context.Context, req *g
 	return resp, nil
 }
 
+// reservedInstancePorts are the TCP ports ECS reserves on container instances
+// for SSH, Docker and the agent
+var reservedInstancePorts = []string{"22", "2375", "2376", "51678", "51679"}
+
+// portResource returns a PORTS or PORTS_UDP resource of a container instance
+func portResource(name string, ports []string) generated.Resource {
+	return generated.Resource{
+		Name:           ptr.String(name),
+		Type:           ptr.String("STRINGSET"),
+		StringSetValue: ports,
+	}
+}
+
+// instancePortsInUse returns the TCP and UDP ports in use on the node of a
+// container instance, named by the last part of its ARN. As in ECS, the
+// remaining PORTS resource lists the reserved ports and the host ports of the
+// tasks on the instance.
+func (api *DefaultECSAPI) instancePortsInUse(ctx context.Context, containerInstanceARN string) (tcp, udp []string) {
+	tcp = append([]string{}, reservedInstancePorts...)
+	udp = []string{}
+
+	taskManager, err := api.taskManager()
+	if err != nil {
+		return tcp, udp
+	}
+	node := containerInstanceARN[strings.LastIndex(containerInstanceARN, "/")+1:]
+	taskTCP, taskUDP := taskManager.HostPortsInUse(ctx, node)
+	for _, port := range taskTCP {
+		if !slices.Contains(tcp, port) {
+			tcp = append(tcp, port)
+		}
+	}
+	udp = append(udp, taskUDP...)
+	return tcp, udp
+}
+
 // ListContainerInstances implements the ListContainerInstances operation
 func (api *DefaultECSAPI) ListContainerInstances(ctx context.Context, req *generated.ListContainerInstancesRequest) (*generated.ListContainerInstancesResponse, error) {
 	// Get cluster name
@@ -245,6 +274,35 @@ func (api *DefaultECSAPI) UpdateContainerInstancesState(ctx context.Context, req
 
 // SubmitContainerStateChange implements the SubmitContainerStateChange operation
 func (api *DefaultECSAPI) SubmitContainerStateChange(ctx context.Context, req *generated.SubmitContainerStateChangeRequest) (*generated.SubmitContainerStateChangeResponse, error) {
-	// TODO: Implement SubmitContainerStateChange
-	return nil, fmt.Errorf("SubmitContainerStateChange not implemented")
+	if req.Task == nil {
+		return nil, fmt.Errorf("task is required")
+	}
+	if req.ContainerName == nil || *req.ContainerName == "" {
+		return nil, fmt.Errorf("containerName is required")
+	}
+	task, err := api.getTaskForStateChange(ctx, req.Cluster, *req.Task)
+	if err != nil {
+		return nil, err
+	}
+
+	change := generated.ContainerStateChange{
+		ContainerName:   req.ContainerName,
+		ExitCode:        req.ExitCode,
+		NetworkBindings: req.NetworkBindings,
+		Reason:          req.Reason,
+		RuntimeId:       req.RuntimeId,
+		Status:          req.Status,
+	}
+	if err := applyContainerStateChanges(task, []generated.ContainerStateChange{change}, nil); err != nil {
+		return nil, err
+	}
+
+	task.Version++
+	if err := api.storage.TaskStore().Update(ctx, task); err != nil {
+		return nil, fmt.Errorf("failed to update task: %w", err)
+	}
+
+	return &generated.SubmitContainerStateChangeResponse{
+		Acknowledgment: ptr.String(stateChangeAcknowledgment),
+	}, nil
 }
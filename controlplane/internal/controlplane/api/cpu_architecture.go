@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s "k8s.io/client-go/kubernetes"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/types"
+)
+
+// imageArchitecturesTimeout bounds the lookup of the architectures of an
+// image, whose registry may not be reachable, e.g. from offline instances
+const imageArchitecturesTimeout = 5 * time.Second
+
+// validateCPUArchitecture checks that the tasks of a task definition whose
+// runtime platform requires a CPU architecture can run: a node of the
+// instance must have the architecture and the images of the containers must
+// be built for it. Images whose manifest can not be read are not checked.
+func (api *DefaultECSAPI) validateCPUArchitecture(ctx context.Context, taskDef *storage.TaskDefinition, kubeClient k8s.Interface) error {
+	arch, err := converters.TaskArchitecture(taskDef)
+	if err != nil {
+		return &generated.InvalidParameterException{Message: ptr.String(err.Error())}
+	}
+	if arch == "" {
+		return nil
+	}
+
+	if kubeClient != nil {
+		nodes, err := kubeClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			logging.Warn("Failed to list nodes to check the CPU architecture", "error", err)
+		} else {
+			var available []string
+			for i := range nodes.Items {
+				if nodeArch := converters.NodeArchitecture(&nodes.Items[i]); nodeArch != "" && !slices.Contains(available, nodeArch) {
+					available = append(available, nodeArch)
+				}
+			}
+			if !slices.Contains(available, arch) {
+				slices.Sort(available)
+				return &generated.InvalidParameterException{Message: ptr.String(fmt.Sprintf(
+					"no nodes with cpuArchitecture %s (%s) to run task definition %s:%d, nodes have [%s]; create the instance with --node-pool %s=1 to add them",
+					converters.PlatformArchitecture(arch), arch, taskDef.Family, taskDef.Revision, strings.Join(available, ", "), arch))}
+			}
+		}
+	}
+
+	if api.architectureResolver == nil {
+		return nil
+	}
+	var containerDefs []types.ContainerDefinition
+	if err := json.Unmarshal([]byte(taskDef.ContainerDefinitions), &containerDefs); err != nil {
+		return fmt.Errorf("failed to parse container definitions: %w", err)
+	}
+	for _, def := range containerDefs {
+		if def.Name == nil || def.Image == nil {
+			continue
+		}
+		lookupCtx, cancel := context.WithTimeout(ctx, imageArchitecturesTimeout)
+		architectures, err := api.architectureResolver.Architectures(lookupCtx, *def.Image)
+		cancel()
+		if err != nil {
+			logging.Debug("Skipping the CPU architecture check of an image", "image", *def.Image, "error", err)
+			continue
+		}
+		if !slices.Contains(architectures, arch) {
+			return &generated.InvalidParameterException{Message: ptr.String(fmt.Sprintf(
+				"image %s of container %s has no manifest for cpuArchitecture %s (%s), it is built for [%s]",
+				*def.Image, *def.Name, converters.PlatformArchitecture(arch), arch, strings.Join(architectures, ", ")))}
+		}
+	}
+	return nil
+}
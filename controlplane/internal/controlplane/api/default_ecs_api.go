@@ -1,8 +1,13 @@
 package api
 
 import (
+	"context"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
 	"github.com/nandemo-ya/kecs/controlplane/internal/config"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/execcommand"
+	"github.com/nandemo-ya/kecs/controlplane/internal/images"
 	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/cloudwatch"
 	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/elbv2"
 	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/iam"
@@ -11,8 +16,11 @@ import (
 	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/ssm"
 	"github.com/nandemo-ya/kecs/controlplane/internal/kubernetes"
 	"github.com/nandemo-ya/kecs/controlplane/internal/localstack"
+	"github.com/nandemo-ya/kecs/controlplane/internal/notifications"
 	"github.com/nandemo-ya/kecs/controlplane/internal/servicediscovery"
+	"github.com/nandemo-ya/kecs/controlplane/internal/serviceevents"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/vpc"
 )
 
 // DefaultECSAPI provides the default implementation of ECS API operations
@@ -34,18 +42,33 @@ type DefaultECSAPI struct {
 	localStackManager         localstack.Manager
 	localStackConfig          *localstack.Config
 	localStackUpdateCallback  func(localstack.Manager) // Callback when LocalStack manager is updated
+	vpcRegistry               *vpc.Registry
+	serviceEvents             *serviceevents.Recorder
+	notifier                  *notifications.Notifier
+	execCommandManager        *execcommand.Manager
+	serviceReconciler         *ServiceReconciler
+	imageResolver             images.Resolver
+	architectureResolver      images.ArchitectureResolver
 }
 
 // NewDefaultECSAPI creates a new default ECS API implementation with storage
 func NewDefaultECSAPI(cfg *config.Config, storage storage.Storage) generated.ECSAPIInterface {
 	return &DefaultECSAPI{
-		config:    cfg,
-		storage:   storage,
-		region:    "us-east-1",    // Default region
-		accountID: "000000000000", // Default account ID (LocalStack standard)
+		config:        cfg,
+		storage:       storage,
+		region:        "us-east-1",    // Default region
+		accountID:     "000000000000", // Default account ID (LocalStack standard)
+		serviceEvents: serviceevents.NewRecorder(serviceevents.DefaultCapacity),
 	}
 }
 
+// accountFor returns the account of the caller, which owns the resources it
+// creates. Callers whose credentials are not bound to an account use the
+// account of the instance.
+func (api *DefaultECSAPI) accountFor(ctx context.Context) string {
+	return awsarn.AccountIDFrom(ctx, api.accountID)
+}
+
 // SetIAMIntegration sets the IAM integration for the ECS API
 func (api *DefaultECSAPI) SetIAMIntegration(iamIntegration iam.Integration) {
 	api.iamIntegration = iamIntegration
@@ -76,6 +99,44 @@ func (api *DefaultECSAPI) SetELBv2Integration(elbv2Integration elbv2.Integration
 	api.elbv2Integration = elbv2Integration
 }
 
+// SetVPCRegistry sets the VPC registry used for subnet validation and zone placement
+func (api *DefaultECSAPI) SetVPCRegistry(registry *vpc.Registry) {
+	api.vpcRegistry = registry
+}
+
+// SetServiceEventRecorder sets the recorder used for DescribeServices events
+func (api *DefaultECSAPI) SetServiceEventRecorder(recorder *serviceevents.Recorder) {
+	api.serviceEvents = recorder
+}
+
+// SetNotifier sets the notifier of failed deployments
+func (api *DefaultECSAPI) SetNotifier(notifier *notifications.Notifier) {
+	api.notifier = notifier
+}
+
+// SetExecCommandManager sets the manager running ExecuteCommand sessions
+func (api *DefaultECSAPI) SetExecCommandManager(manager *execcommand.Manager) {
+	api.execCommandManager = manager
+}
+
+// SetImageResolver sets the resolver that pins the images of registered task
+// definitions to their digests
+func (api *DefaultECSAPI) SetImageResolver(resolver images.Resolver) {
+	api.imageResolver = resolver
+}
+
+// SetArchitectureResolver sets the resolver that looks up the CPU
+// architectures of the images of tasks requiring one
+func (api *DefaultECSAPI) SetArchitectureResolver(resolver images.ArchitectureResolver) {
+	api.architectureResolver = resolver
+}
+
+// SetServiceReconciler sets the reconciler that applies services to Kubernetes
+// in the background. Without one, services are applied in the request path.
+func (api *DefaultECSAPI) SetServiceReconciler(reconciler *ServiceReconciler) {
+	api.serviceReconciler = reconciler
+}
+
 // SetServiceDiscoveryManager sets the service discovery manager for the ECS API
 func (api *DefaultECSAPI) SetServiceDiscoveryManager(serviceDiscoveryManager servicediscovery.Manager) {
 	api.serviceDiscoveryManager = serviceDiscoveryManager
@@ -110,10 +171,11 @@ func (api *DefaultECSAPI) SetLocalStackUpdateCallback(callback func(localstack.M
 // Deprecated: Use NewDefaultECSAPIWithClusterManager instead
 func NewDefaultECSAPIWithConfig(cfg *config.Config, storage storage.Storage, region, accountID string) generated.ECSAPIInterface {
 	return &DefaultECSAPI{
-		config:    cfg,
-		storage:   storage,
-		region:    region,
-		accountID: accountID,
+		config:        cfg,
+		storage:       storage,
+		region:        region,
+		accountID:     accountID,
+		serviceEvents: serviceevents.NewRecorder(serviceevents.DefaultCapacity),
 	}
 }
 
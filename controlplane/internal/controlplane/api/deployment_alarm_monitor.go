@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
+	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/cloudwatch"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/notifications"
+	"github.com/nandemo-ya/kecs/controlplane/internal/servicedeployments"
+	"github.com/nandemo-ya/kecs/controlplane/internal/serviceevents"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// DeploymentAlarmMonitor watches the CloudWatch alarms of services whose
+// deploymentConfiguration enables alarms. A deployment in progress fails once
+// one of its alarms is in the ALARM state, and is rolled back to the task
+// definition it replaced when rollback is enabled.
+type DeploymentAlarmMonitor struct {
+	api      *DefaultECSAPI
+	interval time.Duration
+	ticker   *time.Ticker
+	done     chan struct{}
+}
+
+// NewDeploymentAlarmMonitor creates a monitor for the services of api
+func NewDeploymentAlarmMonitor(api *DefaultECSAPI) *DeploymentAlarmMonitor {
+	return &DeploymentAlarmMonitor{
+		api:      api,
+		interval: config.GetDuration("deploymentAlarms.interval", 30*time.Second),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start begins checking the alarms of deployments in progress
+func (m *DeploymentAlarmMonitor) Start(ctx context.Context) {
+	m.ticker = time.NewTicker(m.interval)
+
+	go func() {
+		logging.Info("Deployment alarm monitor: Started", "interval", m.interval)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-m.done:
+				return
+			case <-m.ticker.C:
+				m.checkDeployments(ctx)
+			}
+		}
+	}()
+}
+
+// Stop halts the monitor
+func (m *DeploymentAlarmMonitor) Stop() {
+	if m.ticker != nil {
+		m.ticker.Stop()
+	}
+	close(m.done)
+}
+
+// checkDeployments checks the alarms of every service rolling out a deployment
+func (m *DeploymentAlarmMonitor) checkDeployments(ctx context.Context) {
+	if m.api.cloudWatchIntegration == nil {
+		return
+	}
+
+	clusters, err := m.api.storage.ClusterStore().List(ctx)
+	if err != nil {
+		logging.Warn("Deployment alarm monitor: Failed to list clusters", "error", err)
+		return
+	}
+	for _, cluster := range clusters {
+		services, _, err := m.api.storage.ServiceStore().List(ctx, cluster.ARN, storage.ServiceFilters{}, 0, "")
+		if err != nil {
+			logging.Warn("Deployment alarm monitor: Failed to list services", "cluster", cluster.Name, "error", err)
+			continue
+		}
+		for _, service := range services {
+			if service.Status == "DRAINING" || service.Status == "INACTIVE" || !servicedeployments.InProgress(service) {
+				continue
+			}
+			alarms := deploymentAlarms(service)
+			if alarms == nil {
+				continue
+			}
+			if err := m.checkService(ctx, service, alarms); err != nil {
+				logging.Warn("Deployment alarm monitor: Failed to check service alarms",
+					"service", service.ServiceName, "error", err)
+			}
+		}
+	}
+}
+
+// checkService fails the deployment of a service once one of its alarms fires
+func (m *DeploymentAlarmMonitor) checkService(ctx context.Context, service *storage.Service, alarms *generated.DeploymentAlarms) error {
+	states, err := m.api.cloudWatchIntegration.GetAlarmStates(alarms.AlarmNames)
+	if err != nil {
+		return err
+	}
+	var firing []string
+	for _, name := range alarms.AlarmNames {
+		if states[name] == cloudwatch.AlarmStateAlarm && !slices.Contains(firing, name) {
+			firing = append(firing, name)
+		}
+	}
+	if len(firing) == 0 {
+		return nil
+	}
+
+	reason := fmt.Sprintf("alarm detected (%s)", strings.Join(firing, ", "))
+	now := deterministic.Now()
+
+	var failedID, rollbackID string
+	service, err = storage.UpdateService(ctx, m.api.storage.ServiceStore(), service, func(current *storage.Service) error {
+		current.UpdatedAt = now
+		if alarms.Rollback {
+			failedID, rollbackID = servicedeployments.Rollback(current, reason, now)
+		} else {
+			failedID = servicedeployments.Fail(current, reason, now)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update service: %w", err)
+	}
+
+	logging.Info("Deployment alarm monitor: Deployment failed",
+		"service", service.ServiceName, "deployment", failedID, "alarms", firing, "rollback", rollbackID)
+	m.api.serviceEvents.Record(service.ARN, serviceevents.DeploymentFailed(service.ServiceName, failedID, reason))
+	m.api.notifier.Notify(notifications.DeploymentFailed(service.ClusterARN, service.ServiceName, service.ARN, failedID, reason))
+	if rollbackID == "" {
+		return nil
+	}
+
+	m.api.serviceEvents.Record(service.ARN, serviceevents.RollingBack(service.ServiceName, rollbackID))
+	if _, err := m.api.applyService(ctx, service); err != nil {
+		return fmt.Errorf("failed to roll back service: %w", err)
+	}
+	return nil
+}
+
+// deploymentAlarms returns the alarms that guard the deployments of a
+// service, or nil when alarm monitoring is not enabled
+func deploymentAlarms(service *storage.Service) *generated.DeploymentAlarms {
+	if service.DeploymentConfiguration == "" || service.DeploymentConfiguration == "null" {
+		return nil
+	}
+	var deploymentConfig generated.DeploymentConfiguration
+	if err := json.Unmarshal([]byte(service.DeploymentConfiguration), &deploymentConfig); err != nil {
+		return nil
+	}
+	alarms := deploymentConfig.Alarms
+	if alarms == nil || !alarms.Enable || len(alarms.AlarmNames) == 0 {
+		return nil
+	}
+	return alarms
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/mocks"
+	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/cloudwatch"
+	"github.com/nandemo-ya/kecs/controlplane/internal/servicedeployments"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+var _ = Describe("DeploymentAlarmMonitor", func() {
+	const (
+		clusterARN = "arn:aws:ecs:us-east-1:000000000000:cluster/default"
+		serviceARN = "arn:aws:ecs:us-east-1:000000000000:service/default/web"
+		taskDefV1  = "arn:aws:ecs:us-east-1:000000000000:task-definition/web:1"
+		taskDefV2  = "arn:aws:ecs:us-east-1:000000000000:task-definition/web:2"
+	)
+
+	var (
+		ctx              context.Context
+		ecsAPI           *DefaultECSAPI
+		reconciler       *ServiceReconciler
+		monitor          *DeploymentAlarmMonitor
+		mockServiceStore *mocks.MockServiceStore
+		alarms           *cloudwatch.MockIntegration
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockStorage := mocks.NewMockStorage()
+		mockClusterStore := mocks.NewMockClusterStore()
+		mockServiceStore = mocks.NewMockServiceStore()
+		mockStorage.SetClusterStore(mockClusterStore)
+		mockStorage.SetServiceStore(mockServiceStore)
+		Expect(mockClusterStore.Create(ctx, &storage.Cluster{
+			Name:   "default",
+			ARN:    clusterARN,
+			Status: "ACTIVE",
+		})).To(Succeed())
+
+		ecsAPI = NewDefaultECSAPI(config.DefaultConfig(), mockStorage).(*DefaultECSAPI)
+		reconciler = NewServiceReconciler(ecsAPI)
+		reconciler.queue = workqueue.NewTypedRateLimitingQueue(
+			workqueue.NewTypedItemExponentialFailureRateLimiter[string](time.Millisecond, 10*time.Millisecond))
+		ecsAPI.SetServiceReconciler(reconciler)
+		DeferCleanup(reconciler.queue.ShutDown)
+
+		alarms = &cloudwatch.MockIntegration{AlarmStates: map[string]string{"high-errors": "OK", "high-latency": "OK"}}
+		ecsAPI.SetCloudWatchIntegration(alarms)
+		monitor = NewDeploymentAlarmMonitor(ecsAPI)
+	})
+
+	// createRollingService stores a service rolling out taskDefV2 over a
+	// completed deployment of taskDefV1
+	createRollingService := func(deploymentConfig string) {
+		now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		service := &storage.Service{
+			ARN:                     serviceARN,
+			ServiceName:             "web",
+			ClusterARN:              clusterARN,
+			TaskDefinitionARN:       taskDefV1,
+			DesiredCount:            2,
+			Status:                  "ACTIVE",
+			DeploymentConfiguration: deploymentConfig,
+		}
+		servicedeployments.Start(service, now)
+		servicedeployments.Progress(service, taskDefV1, 2, 0, now)
+		service.TaskDefinitionARN = taskDefV2
+		servicedeployments.Start(service, now.Add(time.Minute))
+		Expect(mockServiceStore.Create(ctx, service)).To(Succeed())
+	}
+
+	storedService := func() *storage.Service {
+		service, err := mockServiceStore.GetByARN(ctx, serviceARN)
+		Expect(err).NotTo(HaveOccurred())
+		return service
+	}
+
+	eventMessages := func() []string {
+		var messages []string
+		for _, event := range ecsAPI.serviceEvents.Events(serviceARN) {
+			messages = append(messages, event.Message)
+		}
+		return messages
+	}
+
+	It("keeps the deployment in progress while the alarms are OK", func() {
+		createRollingService(`{"alarms":{"alarmNames":["high-errors"],"enable":true,"rollback":true}}`)
+
+		monitor.checkDeployments(ctx)
+
+		Expect(servicedeployments.InProgress(storedService())).To(BeTrue())
+		Expect(reconciler.queue.Len()).To(Equal(0))
+	})
+
+	It("rolls back the deployment once an alarm fires", func() {
+		createRollingService(`{"alarms":{"alarmNames":["high-errors","high-latency"],"enable":true,"rollback":true}}`)
+		alarms.AlarmStates["high-latency"] = cloudwatch.AlarmStateAlarm
+
+		monitor.checkDeployments(ctx)
+
+		service := storedService()
+		Expect(service.TaskDefinitionARN).To(Equal(taskDefV1))
+		deployments := servicedeployments.List(service)
+		Expect(deployments).To(HaveLen(3))
+		Expect(*deployments[0].TaskDefinition).To(Equal(taskDefV1))
+		Expect(*deployments[1].RolloutState).To(Equal(generated.DeploymentRolloutStateFAILED))
+		Expect(*deployments[1].RolloutStateReason).To(ContainSubstring("alarm detected (high-latency)"))
+		Expect(reconciler.queue.Len()).To(Equal(1))
+
+		Expect(eventMessages()).To(ContainElements(
+			ContainSubstring("deployment failed: alarm detected (high-latency)"),
+			ContainSubstring("rolling back to deployment "+*deployments[0].Id),
+		))
+	})
+
+	It("only fails the deployment when rollback is disabled", func() {
+		createRollingService(`{"alarms":{"alarmNames":["high-errors"],"enable":true,"rollback":false}}`)
+		alarms.AlarmStates["high-errors"] = cloudwatch.AlarmStateAlarm
+
+		monitor.checkDeployments(ctx)
+
+		service := storedService()
+		Expect(service.TaskDefinitionARN).To(Equal(taskDefV2))
+		deployments := servicedeployments.List(service)
+		Expect(*deployments[0].RolloutState).To(Equal(generated.DeploymentRolloutStateFAILED))
+		Expect(reconciler.queue.Len()).To(Equal(0))
+		Expect(eventMessages()).To(ContainElement(ContainSubstring("deployment failed: alarm detected (high-errors)")))
+	})
+
+	It("ignores alarms that are not enabled", func() {
+		createRollingService(`{"alarms":{"alarmNames":["high-errors"],"enable":false,"rollback":true}}`)
+		alarms.AlarmStates["high-errors"] = cloudwatch.AlarmStateAlarm
+
+		monitor.checkDeployments(ctx)
+
+		Expect(servicedeployments.InProgress(storedService())).To(BeTrue())
+	})
+})
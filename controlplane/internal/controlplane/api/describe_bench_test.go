@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/mocks"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// storageQueryLatency simulates the database round trip of a storage query
+const storageQueryLatency = 500 * time.Microsecond
+
+// slowTaskStore adds storageQueryLatency to every task lookup
+type slowTaskStore struct {
+	storage.TaskStore
+}
+
+func (s *slowTaskStore) Get(ctx context.Context, cluster, taskID string) (*storage.Task, error) {
+	time.Sleep(storageQueryLatency)
+	return s.TaskStore.Get(ctx, cluster, taskID)
+}
+
+func (s *slowTaskStore) GetMany(ctx context.Context, cluster string, taskIDsOrARNs []string) ([]*storage.Task, error) {
+	time.Sleep(storageQueryLatency)
+	return s.TaskStore.GetMany(ctx, cluster, taskIDsOrARNs)
+}
+
+// BenchmarkDescribeTasks measures describing 100 tasks with DescribeTasks,
+// which looks them up in a single query, against a lookup per task
+func BenchmarkDescribeTasks(b *testing.B) {
+	ctx := context.Background()
+	clusterARN := "arn:aws:ecs:us-east-1:000000000000:cluster/default"
+	mockStorage := mocks.NewMockStorage()
+	mockClusterStore := mocks.NewMockClusterStore()
+	mockTaskStore := mocks.NewMockTaskStore()
+	mockStorage.SetClusterStore(mockClusterStore)
+	mockStorage.SetTaskStore(&slowTaskStore{TaskStore: mockTaskStore})
+	if err := mockClusterStore.Create(ctx, &storage.Cluster{Name: "default", ARN: clusterARN, Status: "ACTIVE"}); err != nil {
+		b.Fatal(err)
+	}
+
+	identifiers := make([]string, maxDescribeTasks)
+	for i := range identifiers {
+		identifiers[i] = fmt.Sprintf("task-%d", i)
+		if err := mockTaskStore.Create(ctx, &storage.Task{
+			ID:            identifiers[i],
+			ARN:           fmt.Sprintf("arn:aws:ecs:us-east-1:000000000000:task/default/%s", identifiers[i]),
+			ClusterARN:    clusterARN,
+			LastStatus:    "RUNNING",
+			DesiredStatus: "RUNNING",
+			CreatedAt:     time.Now(),
+		}); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	ecsAPI := NewDefaultECSAPI(config.DefaultConfig(), mockStorage).(*DefaultECSAPI)
+
+	b.Run("batched", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			resp, err := ecsAPI.DescribeTasks(ctx, &generated.DescribeTasksRequest{Tasks: identifiers})
+			if err != nil {
+				b.Fatal(err)
+			}
+			if len(resp.Tasks) != len(identifiers) {
+				b.Fatalf("described %d tasks, want %d", len(resp.Tasks), len(identifiers))
+			}
+		}
+	})
+
+	b.Run("per-task", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, identifier := range identifiers {
+				if _, err := mockStorage.TaskStore().Get(ctx, clusterARN, identifier); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/serviceevents"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// serviceStatusDegraded is set on services whose Kubernetes resources drifted
+// from their desired state while drift repair is disabled
+const serviceStatusDegraded = "DEGRADED"
+
+// DriftDetector compares the desired state of services in storage against
+// their live Kubernetes resources, e.g. after a Deployment was deleted or
+// scaled with kubectl. Drifted services are re-applied through the service
+// reconciler, or marked DEGRADED when repair is disabled. Services are checked
+// periodically and whenever a watch reports one of their resources deleted.
+type DriftDetector struct {
+	api        *DefaultECSAPI
+	kubeClient kubernetes.Interface
+	interval   time.Duration
+	repair     bool
+	ticker     *time.Ticker
+	pending    chan string
+	done       chan struct{}
+}
+
+// NewDriftDetector creates a drift detector for the services of api
+func NewDriftDetector(api *DefaultECSAPI, kubeClient kubernetes.Interface) *DriftDetector {
+	return &DriftDetector{
+		api:        api,
+		kubeClient: kubeClient,
+		interval:   config.GetDuration("driftDetector.interval", time.Minute),
+		repair:     config.GetBool("driftDetector.repair"),
+		pending:    make(chan string, 100),
+		done:       make(chan struct{}),
+	}
+}
+
+// Start begins checking services for drift
+func (d *DriftDetector) Start(ctx context.Context) {
+	d.ticker = time.NewTicker(d.interval)
+
+	go func() {
+		logging.Info("Drift detector: Started", "interval", d.interval, "repair", d.repair)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-d.done:
+				return
+			case <-d.ticker.C:
+				d.checkServices(ctx)
+			case serviceARN := <-d.pending:
+				d.checkServiceARN(ctx, serviceARN)
+			}
+		}
+	}()
+}
+
+// Stop halts the detector
+func (d *DriftDetector) Stop() {
+	if d.ticker != nil {
+		d.ticker.Stop()
+	}
+	close(d.done)
+}
+
+// ResourceDeleted schedules an immediate check of a service whose Kubernetes
+// resource was deleted. Notifications are dropped while the detector is busy,
+// the periodic check picks those services up.
+func (d *DriftDetector) ResourceDeleted(serviceARN string) {
+	select {
+	case d.pending <- serviceARN:
+	default:
+	}
+}
+
+// checkServices checks every service of every cluster for drift
+func (d *DriftDetector) checkServices(ctx context.Context) {
+	clusters, err := d.api.storage.ClusterStore().List(ctx)
+	if err != nil {
+		logging.Warn("Drift detector: Failed to list clusters", "error", err)
+		return
+	}
+	for _, cluster := range clusters {
+		services, _, err := d.api.storage.ServiceStore().List(ctx, cluster.ARN, storage.ServiceFilters{}, 0, "")
+		if err != nil {
+			logging.Warn("Drift detector: Failed to list services", "cluster", cluster.Name, "error", err)
+			continue
+		}
+		for _, service := range services {
+			if err := d.checkService(ctx, service); err != nil {
+				logging.Warn("Drift detector: Failed to check service", "service", service.ServiceName, "error", err)
+			}
+		}
+	}
+}
+
+// checkServiceARN checks a single service for drift
+func (d *DriftDetector) checkServiceARN(ctx context.Context, serviceARN string) {
+	service, err := d.api.storage.ServiceStore().GetByARN(ctx, serviceARN)
+	if err != nil {
+		if !errors.Is(err, storage.ErrResourceNotFound) {
+			logging.Debug("Drift detector: Failed to get service", "service", serviceARN, "error", err)
+		}
+		return
+	}
+	if err := d.checkService(ctx, service); err != nil {
+		logging.Warn("Drift detector: Failed to check service", "service", service.ServiceName, "error", err)
+	}
+}
+
+// checkService compares a service against its Kubernetes resources and
+// repairs or degrades it when they drifted
+func (d *DriftDetector) checkService(ctx context.Context, service *storage.Service) error {
+	// Services being applied, deleted or that failed are left to the
+	// reconciler, and EXTERNAL services have no resources of their own
+	if service.Status != serviceStatusActive && service.Status != serviceStatusDegraded {
+		return nil
+	}
+	if service.TaskDefinitionARN == "" {
+		return nil
+	}
+
+	drift, err := d.detectDrift(ctx, service)
+	if err != nil {
+		return err
+	}
+	if len(drift) == 0 {
+		if service.Status == serviceStatusDegraded {
+			return d.setStatus(ctx, service, serviceStatusActive)
+		}
+		return nil
+	}
+
+	reason := strings.Join(drift, ", ")
+	logging.Info("Drift detector: Service drifted", "service", service.ServiceName, "drift", reason, "repair", d.repair)
+	if !d.repair {
+		if service.Status == serviceStatusDegraded {
+			return nil
+		}
+		d.api.serviceEvents.Record(service.ARN, serviceevents.DriftDetected(service.ServiceName, reason))
+		return d.setStatus(ctx, service, serviceStatusDegraded)
+	}
+
+	d.api.serviceEvents.Record(service.ARN, serviceevents.DriftDetected(service.ServiceName, reason))
+	d.api.serviceEvents.Record(service.ARN, serviceevents.RestoringResources(service.ServiceName))
+	if _, err := d.api.applyService(ctx, service); err != nil {
+		return fmt.Errorf("failed to restore service: %w", err)
+	}
+	return nil
+}
+
+// detectDrift describes how the live Kubernetes resources of a service differ
+// from its desired state
+func (d *DriftDetector) detectDrift(ctx context.Context, service *storage.Service) ([]string, error) {
+	_, _, deployment, kubeService, err := d.api.desiredServiceResources(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+
+	var drift []string
+	live, err := d.kubeClient.AppsV1().Deployments(deployment.Namespace).Get(ctx, deployment.Name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		drift = append(drift, fmt.Sprintf("deployment %s/%s is missing", deployment.Namespace, deployment.Name))
+	case err != nil:
+		return nil, fmt.Errorf("failed to get deployment: %w", err)
+	case deployment.Spec.Replicas != nil && live.Spec.Replicas != nil && *live.Spec.Replicas != *deployment.Spec.Replicas:
+		drift = append(drift, fmt.Sprintf("deployment %s/%s has %d replicas instead of %d",
+			deployment.Namespace, deployment.Name, *live.Spec.Replicas, *deployment.Spec.Replicas))
+	}
+
+	if kubeService != nil {
+		_, err := d.kubeClient.CoreV1().Services(kubeService.Namespace).Get(ctx, kubeService.Name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			drift = append(drift, fmt.Sprintf("service %s/%s is missing", kubeService.Namespace, kubeService.Name))
+		case err != nil:
+			return nil, fmt.Errorf("failed to get service: %w", err)
+		}
+	}
+	return drift, nil
+}
+
+// setStatus stores a new status for a service
+func (d *DriftDetector) setStatus(ctx context.Context, service *storage.Service, status string) error {
+	_, err := storage.UpdateService(ctx, d.api.storage.ServiceStore(), service, func(current *storage.Service) error {
+		current.Status = status
+		current.UpdatedAt = deterministic.Now()
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update service status: %w", err)
+	}
+	return nil
+}
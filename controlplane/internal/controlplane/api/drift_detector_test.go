@@ -0,0 +1,207 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/mocks"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+var _ = Describe("DriftDetector", func() {
+	const (
+		clusterARN = "arn:aws:ecs:us-east-1:000000000000:cluster/default"
+		serviceARN = "arn:aws:ecs:us-east-1:000000000000:service/default/web"
+		namespace  = "default-us-east-1"
+	)
+
+	var (
+		ctx              context.Context
+		ecsAPI           *DefaultECSAPI
+		reconciler       *ServiceReconciler
+		detector         *DriftDetector
+		kubeClient       *fake.Clientset
+		mockServiceStore *mocks.MockServiceStore
+		taskDefARN       string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockStorage := mocks.NewMockStorage()
+		mockClusterStore := mocks.NewMockClusterStore()
+		mockTaskDefStore := mocks.NewMockTaskDefinitionStore()
+		mockServiceStore = mocks.NewMockServiceStore()
+		mockStorage.SetClusterStore(mockClusterStore)
+		mockStorage.SetTaskDefinitionStore(mockTaskDefStore)
+		mockStorage.SetServiceStore(mockServiceStore)
+
+		Expect(mockClusterStore.Create(ctx, &storage.Cluster{
+			Name:      "default",
+			ARN:       clusterARN,
+			Status:    "ACTIVE",
+			Region:    "us-east-1",
+			AccountID: "000000000000",
+		})).To(Succeed())
+		taskDef, err := mockTaskDefStore.Register(ctx, &storage.TaskDefinition{
+			Family:               "web",
+			ContainerDefinitions: `[{"name":"web","image":"nginx","memory":256,"portMappings":[{"containerPort":80}]}]`,
+			CPU:                  "256",
+			Memory:               "512",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		taskDefARN = taskDef.ARN
+
+		ecsAPI = NewDefaultECSAPI(config.DefaultConfig(), mockStorage).(*DefaultECSAPI)
+		reconciler = NewServiceReconciler(ecsAPI)
+		reconciler.queue = workqueue.NewTypedRateLimitingQueue(
+			workqueue.NewTypedItemExponentialFailureRateLimiter[string](time.Millisecond, 10*time.Millisecond))
+		ecsAPI.SetServiceReconciler(reconciler)
+		DeferCleanup(reconciler.queue.ShutDown)
+
+		kubeClient = fake.NewSimpleClientset()
+		detector = NewDriftDetector(ecsAPI, kubeClient)
+		detector.repair = true
+	})
+
+	createService := func(networkConfig string) {
+		Expect(mockServiceStore.Create(ctx, &storage.Service{
+			ARN:                  serviceARN,
+			ServiceName:          "web",
+			ClusterARN:           clusterARN,
+			TaskDefinitionARN:    taskDefARN,
+			DesiredCount:         2,
+			Status:               "ACTIVE",
+			NetworkConfiguration: networkConfig,
+		})).To(Succeed())
+	}
+
+	createDeployment := func(replicas int32) {
+		_, err := kubeClient.AppsV1().Deployments(namespace).Create(ctx, &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: namespace},
+			Spec:       appsv1.DeploymentSpec{Replicas: ptr.Int32(replicas)},
+		}, metav1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	storedStatus := func() string {
+		service, err := mockServiceStore.GetByARN(ctx, serviceARN)
+		Expect(err).NotTo(HaveOccurred())
+		return service.Status
+	}
+
+	eventMessages := func() []string {
+		var messages []string
+		for _, event := range ecsAPI.serviceEvents.Events(serviceARN) {
+			messages = append(messages, event.Message)
+		}
+		return messages
+	}
+
+	It("leaves services whose resources match their desired state alone", func() {
+		createService("")
+		createDeployment(2)
+
+		detector.checkServices(ctx)
+
+		Expect(reconciler.queue.Len()).To(Equal(0))
+		Expect(eventMessages()).To(BeEmpty())
+		Expect(storedStatus()).To(Equal("ACTIVE"))
+	})
+
+	It("re-applies a service whose Deployment was deleted", func() {
+		createService("")
+
+		detector.checkServices(ctx)
+
+		Expect(reconciler.queue.Len()).To(Equal(1))
+		Expect(eventMessages()).To(ConsistOf(
+			"(service web) drifted from its desired state: deployment default-us-east-1/web is missing.",
+			"(service web) is restoring its resources to the desired state.",
+		))
+	})
+
+	It("re-applies a service whose Deployment was scaled outside of KECS", func() {
+		createService("")
+		createDeployment(5)
+
+		detector.checkServices(ctx)
+
+		Expect(reconciler.queue.Len()).To(Equal(1))
+		Expect(eventMessages()).To(ContainElement(
+			"(service web) drifted from its desired state: deployment default-us-east-1/web has 5 replicas instead of 2."))
+	})
+
+	It("detects a missing Kubernetes Service of a service with a public IP", func() {
+		createService(`{"awsvpcConfiguration":{"subnets":["subnet-1"],"assignPublicIp":"ENABLED"}}`)
+		createDeployment(2)
+
+		detector.checkServices(ctx)
+
+		Expect(eventMessages()).To(ContainElement(
+			"(service web) drifted from its desired state: service default-us-east-1/web is missing."))
+
+		_, err := kubeClient.CoreV1().Services(namespace).Create(ctx, &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: namespace},
+		}, metav1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(detector.detectDrift(ctx, &storage.Service{
+			ServiceName:          "web",
+			ClusterARN:           clusterARN,
+			TaskDefinitionARN:    taskDefARN,
+			DesiredCount:         2,
+			NetworkConfiguration: `{"awsvpcConfiguration":{"subnets":["subnet-1"],"assignPublicIp":"ENABLED"}}`,
+		})).To(BeEmpty())
+	})
+
+	It("marks drifted services DEGRADED when repair is disabled and ACTIVE once they recover", func() {
+		detector.repair = false
+		createService("")
+
+		detector.checkServices(ctx)
+		detector.checkServices(ctx)
+
+		Expect(reconciler.queue.Len()).To(Equal(0))
+		Expect(storedStatus()).To(Equal("DEGRADED"))
+		Expect(eventMessages()).To(HaveLen(1))
+
+		createDeployment(2)
+		detector.checkServices(ctx)
+
+		Expect(storedStatus()).To(Equal("ACTIVE"))
+	})
+
+	It("skips services that are not ACTIVE", func() {
+		createService("")
+		service, err := mockServiceStore.GetByARN(ctx, serviceARN)
+		Expect(err).NotTo(HaveOccurred())
+		service.Status = "DRAINING"
+		Expect(mockServiceStore.Update(ctx, service)).To(Succeed())
+
+		detector.checkServices(ctx)
+
+		Expect(reconciler.queue.Len()).To(Equal(0))
+		Expect(eventMessages()).To(BeEmpty())
+	})
+
+	It("checks a service right away when a watch reports its Deployment deleted", func() {
+		createService("")
+		detectorCtx, cancel := context.WithCancel(ctx)
+		DeferCleanup(cancel)
+		detector.Start(detectorCtx)
+		DeferCleanup(detector.Stop)
+
+		detector.ResourceDeleted(serviceARN)
+
+		Eventually(reconciler.queue.Len).Should(Equal(1))
+	})
+})
@@ -0,0 +1,246 @@
+package api
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/vpc"
+)
+
+const ec2XMLNS = "http://ec2.amazonaws.com/doc/2016-11-15/"
+
+// EC2API serves the subset of the EC2 query API needed to model VPCs and subnets
+type EC2API struct {
+	registry *vpc.Registry
+}
+
+// NewEC2API creates a new EC2 API handler backed by the given VPC registry
+func NewEC2API(registry *vpc.Registry) *EC2API {
+	return &EC2API{registry: registry}
+}
+
+// EC2 XML response structures
+type ec2Vpc struct {
+	VpcID     string `xml:"vpcId"`
+	State     string `xml:"state"`
+	CidrBlock string `xml:"cidrBlock"`
+	IsDefault bool   `xml:"isDefault"`
+}
+
+type ec2Subnet struct {
+	SubnetID         string `xml:"subnetId"`
+	State            string `xml:"state"`
+	VpcID            string `xml:"vpcId"`
+	CidrBlock        string `xml:"cidrBlock"`
+	AvailabilityZone string `xml:"availabilityZone"`
+	DefaultForAz     bool   `xml:"defaultForAz"`
+}
+
+type CreateVpcResponse struct {
+	XMLName   xml.Name `xml:"CreateVpcResponse"`
+	XMLNS     string   `xml:"xmlns,attr"`
+	RequestID string   `xml:"requestId"`
+	Vpc       ec2Vpc   `xml:"vpc"`
+}
+
+type DescribeVpcsResponse struct {
+	XMLName   xml.Name `xml:"DescribeVpcsResponse"`
+	XMLNS     string   `xml:"xmlns,attr"`
+	RequestID string   `xml:"requestId"`
+	Vpcs      []ec2Vpc `xml:"vpcSet>item"`
+}
+
+type CreateSubnetResponse struct {
+	XMLName   xml.Name  `xml:"CreateSubnetResponse"`
+	XMLNS     string    `xml:"xmlns,attr"`
+	RequestID string    `xml:"requestId"`
+	Subnet    ec2Subnet `xml:"subnet"`
+}
+
+type DescribeSubnetsResponse struct {
+	XMLName   xml.Name    `xml:"DescribeSubnetsResponse"`
+	XMLNS     string      `xml:"xmlns,attr"`
+	RequestID string      `xml:"requestId"`
+	Subnets   []ec2Subnet `xml:"subnetSet>item"`
+}
+
+type ec2ErrorResponse struct {
+	XMLName   xml.Name `xml:"Response"`
+	Errors    []ec2Err `xml:"Errors>Error"`
+	RequestID string   `xml:"RequestID"`
+}
+
+type ec2Err struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// ServeHTTP handles form-encoded EC2 requests
+func (e *EC2API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		e.writeError(w, http.StatusBadRequest, "InvalidRequest", fmt.Sprintf("Failed to read body: %v", err))
+		return
+	}
+	r.Body.Close()
+
+	values, err := url.ParseQuery(string(bodyBytes))
+	if err != nil {
+		e.writeError(w, http.StatusBadRequest, "InvalidRequest", fmt.Sprintf("Failed to parse form data: %v", err))
+		return
+	}
+
+	action := values.Get("Action")
+	logging.Debug("Processing EC2 request", "action", action)
+
+	switch action {
+	case "CreateVpc":
+		v, err := e.registry.CreateVpc(values.Get("CidrBlock"))
+		if err != nil {
+			e.writeRegistryError(w, err)
+			return
+		}
+		e.writeXML(w, &CreateVpcResponse{
+			XMLNS:     ec2XMLNS,
+			RequestID: uuid.New().String(),
+			Vpc:       toEC2Vpc(*v),
+		})
+
+	case "DescribeVpcs":
+		vpcs, err := e.registry.DescribeVpcs(parseMemberList(values, "VpcId"))
+		if err != nil {
+			e.writeRegistryError(w, err)
+			return
+		}
+		resp := &DescribeVpcsResponse{XMLNS: ec2XMLNS, RequestID: uuid.New().String()}
+		for _, v := range vpcs {
+			resp.Vpcs = append(resp.Vpcs, toEC2Vpc(v))
+		}
+		e.writeXML(w, resp)
+
+	case "CreateSubnet":
+		s, err := e.registry.CreateSubnet(values.Get("VpcId"), values.Get("CidrBlock"), values.Get("AvailabilityZone"))
+		if err != nil {
+			e.writeRegistryError(w, err)
+			return
+		}
+		e.writeXML(w, &CreateSubnetResponse{
+			XMLNS:     ec2XMLNS,
+			RequestID: uuid.New().String(),
+			Subnet:    toEC2Subnet(*s),
+		})
+
+	case "DescribeSubnets":
+		subnets, err := e.registry.DescribeSubnets(parseMemberList(values, "SubnetId"), parseVpcIDFilter(values))
+		if err != nil {
+			e.writeRegistryError(w, err)
+			return
+		}
+		resp := &DescribeSubnetsResponse{XMLNS: ec2XMLNS, RequestID: uuid.New().String()}
+		for _, s := range subnets {
+			resp.Subnets = append(resp.Subnets, toEC2Subnet(s))
+		}
+		e.writeXML(w, resp)
+
+	default:
+		e.writeError(w, http.StatusBadRequest, "InvalidAction", fmt.Sprintf("The action %s is not valid for this web service.", action))
+	}
+}
+
+// parseMemberList parses EC2 list parameters (e.g. SubnetId.1, SubnetId.2)
+func parseMemberList(values url.Values, prefix string) []string {
+	var result []string
+	for i := 1; ; i++ {
+		val := values.Get(fmt.Sprintf("%s.%d", prefix, i))
+		if val == "" {
+			break
+		}
+		result = append(result, val)
+	}
+	return result
+}
+
+// parseVpcIDFilter extracts the value of a vpc-id filter if present
+func parseVpcIDFilter(values url.Values) string {
+	for i := 1; ; i++ {
+		name := values.Get(fmt.Sprintf("Filter.%d.Name", i))
+		if name == "" {
+			return ""
+		}
+		if name == "vpc-id" {
+			return values.Get(fmt.Sprintf("Filter.%d.Value.1", i))
+		}
+	}
+}
+
+func toEC2Vpc(v vpc.Vpc) ec2Vpc {
+	return ec2Vpc{VpcID: v.VpcID, State: v.State, CidrBlock: v.CidrBlock, IsDefault: v.IsDefault}
+}
+
+func toEC2Subnet(s vpc.Subnet) ec2Subnet {
+	return ec2Subnet{
+		SubnetID:         s.SubnetID,
+		State:            s.State,
+		VpcID:            s.VpcID,
+		CidrBlock:        s.CidrBlock,
+		AvailabilityZone: s.AvailabilityZone,
+		DefaultForAz:     s.DefaultForAz,
+	}
+}
+
+func (e *EC2API) writeRegistryError(w http.ResponseWriter, err error) {
+	var notFound *vpc.NotFoundError
+	if errors.As(err, &notFound) {
+		e.writeError(w, http.StatusBadRequest, notFound.Code, err.Error())
+		return
+	}
+	e.writeError(w, http.StatusBadRequest, "InvalidParameterValue", err.Error())
+}
+
+func (e *EC2API) writeError(w http.ResponseWriter, statusCode int, code, message string) {
+	w.Header().Set("Content-Type", "text/xml")
+	w.WriteHeader(statusCode)
+	resp := &ec2ErrorResponse{
+		Errors:    []ec2Err{{Code: code, Message: message}},
+		RequestID: uuid.New().String(),
+	}
+	if err := xml.NewEncoder(w).Encode(resp); err != nil {
+		logging.Error("Failed to encode EC2 error response", "error", err)
+	}
+}
+
+func (e *EC2API) writeXML(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "text/xml")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n"))
+	if err := xml.NewEncoder(w).Encode(data); err != nil {
+		logging.Error("Failed to encode EC2 XML response", "error", err)
+	}
+}
+
+// validateAwsVpcSubnets checks awsvpc subnets against the VPC registry when subnet validation is enabled
+func (api *DefaultECSAPI) validateAwsVpcSubnets(networkConfig *generated.NetworkConfiguration) error {
+	if api.vpcRegistry == nil || networkConfig == nil || networkConfig.AwsvpcConfiguration == nil {
+		return nil
+	}
+	if !config.GetBool("network.validateSubnets") {
+		return nil
+	}
+
+	if err := api.vpcRegistry.ValidateSubnets(networkConfig.AwsvpcConfiguration.Subnets); err != nil {
+		return &generated.InvalidParameterException{
+			Message: ptr.String(err.Error()),
+		}
+	}
+	return nil
+}
@@ -6,13 +6,14 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/google/uuid"
-
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated_elbv2"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
 	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/elbv2"
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
@@ -31,6 +32,13 @@ const (
 	TargetHealthStateUnavailable   = "unavailable"
 )
 
+const (
+	// defaultSslPolicy is the security policy of HTTPS and TLS listeners created without one
+	defaultSslPolicy = "ELBSecurityPolicy-2016-08"
+	// maxListenerCertificates is the ELBv2 quota of certificates per listener, including the default
+	maxListenerCertificates = 25
+)
+
 // ELBv2APIImpl implements the generated ElasticLoadBalancing_v10API interface
 type ELBv2APIImpl struct {
 	storage          storage.Storage
@@ -49,6 +57,11 @@ func NewELBv2API(storage storage.Storage, elbv2Integration elbv2.Integration, re
 	}
 }
 
+// accountFor returns the account of the caller, or the account of the instance
+func (api *ELBv2APIImpl) accountFor(ctx context.Context) string {
+	return awsarn.AccountIDFrom(ctx, api.accountID)
+}
+
 // CreateLoadBalancer implements the CreateLoadBalancer operation
 func (api *ELBv2APIImpl) CreateLoadBalancer(ctx context.Context, input *generated_elbv2.CreateLoadBalancerInput) (*generated_elbv2.CreateLoadBalancerOutput, error) {
 	if input.Name == "" {
@@ -65,11 +78,10 @@ func (api *ELBv2APIImpl) CreateLoadBalancer(ctx context.Context, input *generate
 	}
 
 	// Generate ARN
-	arn := fmt.Sprintf("arn:aws:elasticloadbalancing:%s:%s:loadbalancer/app/%s/%s",
-		api.region, api.accountID, input.Name, uuid.New().String()[:8])
+	arn := awsarn.Build("elasticloadbalancing", api.region, api.accountFor(ctx), fmt.Sprintf("loadbalancer/app/%s/%s", input.Name, deterministic.Suffix(8)))
 
 	// Generate DNS name
-	dnsName := fmt.Sprintf("%s-%s.%s.elb.amazonaws.com", input.Name, uuid.New().String()[:8], api.region)
+	dnsName := fmt.Sprintf("%s-%s.%s.elb.amazonaws.com", input.Name, deterministic.Suffix(8), api.region)
 
 	// Determine scheme
 	scheme := "internet-facing"
@@ -113,7 +125,7 @@ func (api *ELBv2APIImpl) CreateLoadBalancer(ctx context.Context, input *generate
 	}
 
 	// Create load balancer in storage
-	now := time.Now()
+	now := deterministic.Now()
 	dbLB := &storage.ELBv2LoadBalancer{
 		ARN:                   arn,
 		Name:                  input.Name,
@@ -130,7 +142,7 @@ func (api *ELBv2APIImpl) CreateLoadBalancer(ctx context.Context, input *generate
 		SecurityGroups:        securityGroups,
 		Tags:                  make(map[string]string),
 		Region:                api.region,
-		AccountID:             api.accountID,
+		AccountID:             api.accountFor(ctx),
 	}
 
 	if err := api.storage.ELBv2Store().CreateLoadBalancer(ctx, dbLB); err != nil {
@@ -226,9 +238,19 @@ func (api *ELBv2APIImpl) DescribeLoadBalancers(ctx context.Context, input *gener
 		if err != nil {
 			return nil, err
 		}
+		lbs, nextMarker, err := paginateELBv2(lbs, func(lb *storage.ELBv2LoadBalancer) storage.PageCursor {
+			return storage.NewestFirstCursor(lb.CreatedAt, lb.ARN)
+		}, input.Marker, input.PageSize)
+		if err != nil {
+			return nil, err
+		}
 		for _, lb := range lbs {
 			loadBalancers = append(loadBalancers, convertToLoadBalancer(lb))
 		}
+		return &generated_elbv2.DescribeLoadBalancersOutput{
+			LoadBalancers: loadBalancers,
+			NextMarker:    nextMarker,
+		}, nil
 	}
 
 	return &generated_elbv2.DescribeLoadBalancersOutput{
@@ -236,6 +258,28 @@ func (api *ELBv2APIImpl) DescribeLoadBalancers(ctx context.Context, input *gener
 	}, nil
 }
 
+// paginateELBv2 returns the page of at most pageSize items after marker,
+// newest first, with the marker of the next page. Markers are encoded like
+// the nextTokens of ECS List operations.
+func paginateELBv2[T any](items []T, cursorOf func(T) storage.PageCursor, marker *string, pageSize *int32) ([]T, *string, error) {
+	var token string
+	if marker != nil {
+		token = *marker
+	}
+	var limit int
+	if pageSize != nil {
+		limit = int(*pageSize)
+	}
+	page, next, err := storage.Paginate(items, cursorOf, storage.NewestFirst, limit, token)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid marker: %s", token)
+	}
+	if next == "" {
+		return page, nil, nil
+	}
+	return page, utils.Ptr(next), nil
+}
+
 // CreateTargetGroup implements the CreateTargetGroup operation
 func (api *ELBv2APIImpl) CreateTargetGroup(ctx context.Context, input *generated_elbv2.CreateTargetGroupInput) (*generated_elbv2.CreateTargetGroupOutput, error) {
 	if input.Name == "" {
@@ -252,8 +296,7 @@ func (api *ELBv2APIImpl) CreateTargetGroup(ctx context.Context, input *generated
 	}
 
 	// Generate ARN
-	arn := fmt.Sprintf("arn:aws:elasticloadbalancing:%s:%s:targetgroup/%s/%s",
-		api.region, api.accountID, input.Name, uuid.New().String()[:8])
+	arn := awsarn.Build("elasticloadbalancing", api.region, api.accountFor(ctx), fmt.Sprintf("targetgroup/%s/%s", input.Name, deterministic.Suffix(8)))
 
 	// Default values
 	protocol := "HTTP"
@@ -271,10 +314,13 @@ func (api *ELBv2APIImpl) CreateTargetGroup(ctx context.Context, input *generated
 		vpcId = *input.VpcId
 	}
 
-	targetType := "instance"
+	targetType := elbv2.TargetTypeInstance
 	if input.TargetType != nil {
 		targetType = string(*input.TargetType)
 	}
+	if err := elbv2.ValidateTargetType(targetType); err != nil {
+		return nil, err
+	}
 
 	// Determine health check protocol
 	healthCheckProtocol := protocol // Default to same as protocol
@@ -317,13 +363,14 @@ func (api *ELBv2APIImpl) CreateTargetGroup(ctx context.Context, input *generated
 		matcher = *input.Matcher.HttpCode
 	}
 
-	healthCheckEnabled := true
+	// Health checks of Lambda target groups are disabled by default
+	healthCheckEnabled := targetType != elbv2.TargetTypeLambda
 	if input.HealthCheckEnabled != nil {
 		healthCheckEnabled = *input.HealthCheckEnabled
 	}
 
 	// Create target group in storage
-	now := time.Now()
+	now := deterministic.Now()
 	dbTG := &storage.ELBv2TargetGroup{
 		ARN:                        arn,
 		Name:                       input.Name,
@@ -343,7 +390,7 @@ func (api *ELBv2APIImpl) CreateTargetGroup(ctx context.Context, input *generated
 		LoadBalancerArns:           []string{},
 		Tags:                       make(map[string]string),
 		Region:                     api.region,
-		AccountID:                  api.accountID,
+		AccountID:                  api.accountFor(ctx),
 		CreatedAt:                  now,
 		UpdatedAt:                  now,
 	}
@@ -416,6 +463,39 @@ func (api *ELBv2APIImpl) RegisterTargets(ctx context.Context, input *generated_e
 		return nil, fmt.Errorf("TargetGroupArn is required")
 	}
 
+	targetGroup, err := api.storage.ELBv2Store().GetTargetGroup(ctx, input.TargetGroupArn)
+	if err != nil {
+		return nil, err
+	}
+	if targetGroup == nil {
+		return nil, fmt.Errorf("target group %s not found", input.TargetGroupArn)
+	}
+
+	// Convert to elbv2.Target type for validation and the integration
+	var integrationTargets []elbv2.Target
+	for _, t := range input.Targets {
+		if t.Id != "" {
+			integrationTargets = append(integrationTargets, elbv2.Target{
+				Id:   t.Id,
+				Port: targetPort(targetGroup, t.Port),
+			})
+		}
+	}
+	if err := elbv2.ValidateTargets(targetGroup.TargetType, integrationTargets); err != nil {
+		return nil, fmt.Errorf("InvalidTarget: %w", err)
+	}
+	if targetGroup.TargetType == elbv2.TargetTypeLambda && len(integrationTargets) > 0 {
+		existing, err := api.storage.ELBv2Store().ListTargets(ctx, input.TargetGroupArn)
+		if err != nil {
+			return nil, err
+		}
+		for _, target := range existing {
+			if target.ID != integrationTargets[0].Id {
+				return nil, fmt.Errorf("TooManyTargets: a target group of type lambda can have only one target")
+			}
+		}
+	}
+
 	// Build targets array
 	var targets []*storage.ELBv2Target
 	for _, target := range input.Targets {
@@ -423,10 +503,7 @@ func (api *ELBv2APIImpl) RegisterTargets(ctx context.Context, input *generated_e
 			continue
 		}
 
-		port := int32(80)
-		if target.Port != nil {
-			port = *target.Port
-		}
+		port := targetPort(targetGroup, target.Port)
 
 		az := ""
 		if target.AvailabilityZone != nil {
@@ -441,8 +518,8 @@ func (api *ELBv2APIImpl) RegisterTargets(ctx context.Context, input *generated_e
 			HealthState:       TargetHealthStateRegistering,
 			HealthReason:      "Target.RegistrationInProgress",
 			HealthDescription: "Target registration is in progress",
-			RegisteredAt:      time.Now(),
-			UpdatedAt:         time.Now(),
+			RegisteredAt:      deterministic.Now(),
+			UpdatedAt:         deterministic.Now(),
 		}
 		targets = append(targets, dbTarget)
 	}
@@ -452,25 +529,13 @@ func (api *ELBv2APIImpl) RegisterTargets(ctx context.Context, input *generated_e
 		return nil, err
 	}
 
-	// Convert to elbv2.Target type for integration
-	var integrationTargets []elbv2.Target
-	for _, t := range input.Targets {
-		if t.Id != "" {
-			port := int32(80)
-			if t.Port != nil {
-				port = *t.Port
-			}
-			integrationTargets = append(integrationTargets, elbv2.Target{
-				Id:   t.Id,
-				Port: port,
-			})
-		}
-	}
-
 	// Register targets in Kubernetes
 	if err := api.elbv2Integration.RegisterTargets(ctx, input.TargetGroupArn, integrationTargets); err != nil {
 		return nil, fmt.Errorf("failed to register targets in Kubernetes: %w", err)
 	}
+	if err := api.syncManualTargets(ctx, targetGroup); err != nil {
+		return nil, fmt.Errorf("failed to route to registered targets: %w", err)
+	}
 
 	return &generated_elbv2.RegisterTargetsOutput{}, nil
 }
@@ -529,10 +594,73 @@ func (api *ELBv2APIImpl) DeregisterTargets(ctx context.Context, input *generated
 	if err := api.elbv2Integration.DeregisterTargets(ctx, input.TargetGroupArn, integrationTargets); err != nil {
 		return nil, fmt.Errorf("failed to deregister targets in Kubernetes: %w", err)
 	}
+	if _, ok := api.elbv2Integration.(elbv2.ManualTargetSyncable); ok {
+		if targetGroup, err := api.storage.ELBv2Store().GetTargetGroup(ctx, input.TargetGroupArn); err == nil && targetGroup != nil {
+			if err := api.syncManualTargets(ctx, targetGroup); err != nil {
+				logging.Debug("Failed to route to remaining targets", "targetGroupArn", input.TargetGroupArn, "error", err)
+			}
+		}
+	}
 
 	return &generated_elbv2.DeregisterTargetsOutput{}, nil
 }
 
+// targetPort returns the port of a target, which defaults to the port of the
+// target group. Lambda targets have no port.
+func targetPort(targetGroup *storage.ELBv2TargetGroup, port *int32) int32 {
+	if port != nil {
+		return *port
+	}
+	if targetGroup.TargetType == elbv2.TargetTypeLambda {
+		return 0
+	}
+	if targetGroup.Port > 0 {
+		return targetGroup.Port
+	}
+	return 80
+}
+
+// syncManualTargets routes ip and lambda target groups to their registered
+// targets if the integration supports it. Targets of instance target groups
+// are registered by ECS services and need no routing of their own.
+func (api *ELBv2APIImpl) syncManualTargets(ctx context.Context, targetGroup *storage.ELBv2TargetGroup) error {
+	if targetGroup.TargetType != elbv2.TargetTypeIP && targetGroup.TargetType != elbv2.TargetTypeLambda {
+		return nil
+	}
+	syncable, ok := api.elbv2Integration.(elbv2.ManualTargetSyncable)
+	if !ok {
+		return nil
+	}
+
+	registered, err := api.storage.ELBv2Store().ListTargets(ctx, targetGroup.ARN)
+	if err != nil {
+		return fmt.Errorf("failed to list targets: %w", err)
+	}
+	targets := make([]elbv2.Target, 0, len(registered))
+	for _, target := range registered {
+		targets = append(targets, elbv2.Target{Id: target.ID, Port: target.Port, AvailabilityZone: target.AvailabilityZone})
+	}
+	return syncable.SyncManualTargets(ctx, targetGroup.ARN, targetGroup.TargetType, targetGroup.Port, targets)
+}
+
+// RestoreManualTargets routes ip and lambda target groups to the targets registered before a restart
+func (api *ELBv2APIImpl) RestoreManualTargets(ctx context.Context) error {
+	if _, ok := api.elbv2Integration.(elbv2.ManualTargetSyncable); !ok {
+		return nil
+	}
+
+	targetGroups, err := api.storage.ELBv2Store().ListTargetGroups(ctx, api.region)
+	if err != nil {
+		return fmt.Errorf("failed to list target groups: %w", err)
+	}
+	for _, targetGroup := range targetGroups {
+		if err := api.syncManualTargets(ctx, targetGroup); err != nil {
+			logging.Warn("Failed to restore registered targets", "targetGroupArn", targetGroup.ARN, "error", err)
+		}
+	}
+	return nil
+}
+
 // DescribeTargetHealth implements the DescribeTargetHealth operation
 func (api *ELBv2APIImpl) DescribeTargetHealth(ctx context.Context, input *generated_elbv2.DescribeTargetHealthInput) (*generated_elbv2.DescribeTargetHealthOutput, error) {
 	if input.TargetGroupArn == "" {
@@ -563,8 +691,11 @@ func (api *ELBv2APIImpl) DescribeTargetHealth(ctx context.Context, input *genera
 		}
 		api.storage.ELBv2Store().UpdateTargetHealth(ctx, input.TargetGroupArn, target.ID, targetHealth)
 
-		// Convert port to pointer
-		port := target.Port
+		// Convert port to pointer, Lambda targets have no port
+		var portPtr *int32
+		if port := target.Port; port != 0 {
+			portPtr = &port
+		}
 		az := target.AvailabilityZone
 		var azPtr *string
 		if az != "" {
@@ -577,7 +708,7 @@ func (api *ELBv2APIImpl) DescribeTargetHealth(ctx context.Context, input *genera
 		targetHealthDescriptions = append(targetHealthDescriptions, generated_elbv2.TargetHealthDescription{
 			Target: &generated_elbv2.TargetDescription{
 				Id:               target.ID,
-				Port:             &port,
+				Port:             portPtr,
 				AvailabilityZone: azPtr,
 			},
 			TargetHealth: &generated_elbv2.TargetHealth{
@@ -608,8 +739,7 @@ func (api *ELBv2APIImpl) CreateListener(ctx context.Context, input *generated_el
 	}
 
 	// Generate ARN
-	arn := fmt.Sprintf("arn:aws:elasticloadbalancing:%s:%s:listener/app/%s/%s",
-		api.region, api.accountID, uuid.New().String()[:8], uuid.New().String()[:8])
+	arn := awsarn.Build("elasticloadbalancing", api.region, api.accountFor(ctx), fmt.Sprintf("listener/app/%s/%s", deterministic.Suffix(8), deterministic.Suffix(8)))
 
 	// Default values
 	port := int32(80)
@@ -622,20 +752,64 @@ func (api *ELBv2APIImpl) CreateListener(ctx context.Context, input *generated_el
 		protocol = string(*input.Protocol)
 	}
 
+	if err := elbv2.NewWeightedRoutingManager().ValidateWeightedRouting(input.DefaultActions); err != nil {
+		return nil, fmt.Errorf("invalid forward configuration: %w", err)
+	}
+	if err := elbv2.NewRuleEvaluator().ValidateActions(input.DefaultActions); err != nil {
+		return nil, fmt.Errorf("invalid default actions: %w", err)
+	}
+
+	// The certificate given on creation is the default certificate, additional
+	// certificates for SNI are added with AddListenerCertificates
+	if len(input.Certificates) > 1 {
+		return nil, fmt.Errorf("only the default certificate can be specified when creating a listener, use AddListenerCertificates to add more")
+	}
+	certificates := make([]generated_elbv2.Certificate, 0, 1)
+	if len(input.Certificates) == 1 {
+		if !tlsProtocol(protocol) {
+			return nil, fmt.Errorf("certificates can only be specified for HTTPS and TLS listeners")
+		}
+		if input.Certificates[0].CertificateArn == nil || *input.Certificates[0].CertificateArn == "" {
+			return nil, fmt.Errorf("CertificateArn is required")
+		}
+		certificates = append(certificates, generated_elbv2.Certificate{
+			CertificateArn: input.Certificates[0].CertificateArn,
+			IsDefault:      utils.Ptr(true),
+		})
+	}
+	certificatesJSON, err := json.Marshal(certificates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal certificates: %w", err)
+	}
+	sslPolicy := ""
+	if input.SslPolicy != nil {
+		sslPolicy = *input.SslPolicy
+	} else if tlsProtocol(protocol) {
+		sslPolicy = defaultSslPolicy
+	}
+
+	defaultActionsJSON := []byte("[]")
+	if len(input.DefaultActions) > 0 {
+		defaultActionsJSON, err = json.Marshal(input.DefaultActions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal default actions: %w", err)
+		}
+	}
+
 	// Create listener in storage
-	now := time.Now()
+	now := deterministic.Now()
 	dbListener := &storage.ELBv2Listener{
 		ARN:             arn,
 		LoadBalancerArn: input.LoadBalancerArn,
 		Port:            port,
 		Protocol:        protocol,
-		DefaultActions:  "[]", // JSON encoded empty array
-		SslPolicy:       "",
-		Certificates:    "[]", // JSON encoded empty array
+		DefaultActions:  string(defaultActionsJSON),
+		SslPolicy:       sslPolicy,
+		Certificates:    string(certificatesJSON),
 		AlpnPolicy:      []string{},
 		Tags:            make(map[string]string),
 		Region:          api.region,
-		AccountID:       api.accountID,
+		AccountID:       api.accountFor(ctx),
 		CreatedAt:       now,
 		UpdatedAt:       now,
 	}
@@ -645,28 +819,36 @@ func (api *ELBv2APIImpl) CreateListener(ctx context.Context, input *generated_el
 	}
 
 	// Get default target group ARN from DefaultActions
-	var targetGroupArn string
-	if len(input.DefaultActions) > 0 && input.DefaultActions[0].TargetGroupArn != nil {
-		targetGroupArn = *input.DefaultActions[0].TargetGroupArn
-	}
+	targetGroupArn := forwardTargetGroupArn(input.DefaultActions)
 
 	// Create listener in Kubernetes
 	if _, err := api.elbv2Integration.CreateListener(ctx, input.LoadBalancerArn, port, protocol, targetGroupArn); err != nil {
 		return nil, fmt.Errorf("failed to create listener in Kubernetes: %w", err)
 	}
+	api.syncListenerForwardConfig(ctx, input.LoadBalancerArn, port, input.DefaultActions)
+	if elbv2.ResponseAction(input.DefaultActions) != nil {
+		// Redirects and fixed responses are routed like the rules of the listener
+		api.syncListenerRules(ctx, arn)
+	}
+	if len(certificates) > 0 {
+		api.syncListenerCertificates(ctx, dbListener)
+	}
 
 	// Create response
 	protocolEnum := generated_elbv2.ProtocolEnum(protocol)
+	listener := generated_elbv2.Listener{
+		ListenerArn:     &arn,
+		LoadBalancerArn: &input.LoadBalancerArn,
+		Port:            &port,
+		Protocol:        &protocolEnum,
+		DefaultActions:  input.DefaultActions, // Pass through the actions from input
+		Certificates:    certificates,
+	}
+	if sslPolicy != "" {
+		listener.SslPolicy = &sslPolicy
+	}
 	output := &generated_elbv2.CreateListenerOutput{
-		Listeners: []generated_elbv2.Listener{
-			{
-				ListenerArn:     &arn,
-				LoadBalancerArn: &input.LoadBalancerArn,
-				Port:            &port,
-				Protocol:        &protocolEnum,
-				DefaultActions:  input.DefaultActions, // Pass through the actions from input
-			},
-		},
+		Listeners: []generated_elbv2.Listener{listener},
 	}
 
 	return output, nil
@@ -705,9 +887,55 @@ func (api *ELBv2APIImpl) DeleteListener(ctx context.Context, input *generated_el
 
 // Helper functions and stub implementations for remaining operations
 
-// Stub implementations for all remaining operations
+// AddListenerCertificates adds certificates to the certificate list of an HTTPS
+// or TLS listener. Clients are served the certificate matching their SNI hostname.
 func (api *ELBv2APIImpl) AddListenerCertificates(ctx context.Context, input *generated_elbv2.AddListenerCertificatesInput) (*generated_elbv2.AddListenerCertificatesOutput, error) {
-	return &generated_elbv2.AddListenerCertificatesOutput{}, nil
+	if input.ListenerArn == "" {
+		return nil, fmt.Errorf("ListenerArn is required")
+	}
+	if len(input.Certificates) == 0 {
+		return nil, fmt.Errorf("Certificates is required")
+	}
+
+	listener, err := api.storage.ELBv2Store().GetListener(ctx, input.ListenerArn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get listener: %w", err)
+	}
+	if listener == nil {
+		return nil, fmt.Errorf("listener not found: %s", input.ListenerArn)
+	}
+	if !tlsProtocol(listener.Protocol) {
+		return nil, fmt.Errorf("certificates can only be added to HTTPS and TLS listeners")
+	}
+
+	certificates := listenerCertificates(listener)
+	added := make([]generated_elbv2.Certificate, 0, len(input.Certificates))
+	for _, cert := range input.Certificates {
+		if cert.CertificateArn == nil || *cert.CertificateArn == "" {
+			return nil, fmt.Errorf("CertificateArn is required")
+		}
+		certificate := generated_elbv2.Certificate{
+			CertificateArn: cert.CertificateArn,
+			IsDefault:      utils.Ptr(false),
+		}
+		added = append(added, certificate)
+		if findCertificate(certificates, *cert.CertificateArn) >= 0 {
+			continue
+		}
+		certificates = append(certificates, certificate)
+	}
+	if len(certificates) > maxListenerCertificates {
+		return nil, fmt.Errorf("TooManyCertificates: a listener can have at most %d certificates", maxListenerCertificates)
+	}
+
+	if err := api.saveListenerCertificates(ctx, listener, certificates); err != nil {
+		return nil, err
+	}
+	api.syncListenerCertificates(ctx, listener)
+
+	return &generated_elbv2.AddListenerCertificatesOutput{
+		Certificates: added,
+	}, nil
 }
 
 func (api *ELBv2APIImpl) AddTags(ctx context.Context, input *generated_elbv2.AddTagsInput) (*generated_elbv2.AddTagsOutput, error) {
@@ -825,6 +1053,15 @@ func (api *ELBv2APIImpl) CreateRule(ctx context.Context, input *generated_elbv2.
 	if len(input.Actions) == 0 {
 		return nil, fmt.Errorf("Actions is required")
 	}
+	if err := elbv2.NewRuleEvaluator().ValidateConditions(input.Conditions); err != nil {
+		return nil, fmt.Errorf("invalid rule conditions: %w", err)
+	}
+	if err := elbv2.NewWeightedRoutingManager().ValidateWeightedRouting(input.Actions); err != nil {
+		return nil, fmt.Errorf("invalid forward configuration: %w", err)
+	}
+	if err := elbv2.NewRuleEvaluator().ValidateActions(input.Actions); err != nil {
+		return nil, fmt.Errorf("invalid rule actions: %w", err)
+	}
 
 	// Verify listener exists
 	listener, err := api.storage.ELBv2Store().GetListener(ctx, input.ListenerArn)
@@ -835,15 +1072,10 @@ func (api *ELBv2APIImpl) CreateRule(ctx context.Context, input *generated_elbv2.
 		return nil, fmt.Errorf("listener not found: %s", input.ListenerArn)
 	}
 
-	// Check if priority is already used
-	existingRules, err := api.storage.ELBv2Store().ListRules(ctx, input.ListenerArn)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list existing rules: %w", err)
-	}
-	for _, rule := range existingRules {
-		if rule.Priority == input.Priority {
-			return nil, fmt.Errorf("priority %d is already in use", input.Priority)
-		}
+	// Check the priority is in range and not already used
+	priorityManager := elbv2.NewPriorityManager(api.storage.ELBv2Store())
+	if err := priorityManager.ValidatePriority(ctx, input.ListenerArn, input.Priority, ""); err != nil {
+		return nil, err
 	}
 
 	// Generate ARN - extract load balancer name from ARN
@@ -851,8 +1083,7 @@ func (api *ELBv2APIImpl) CreateRule(ctx context.Context, input *generated_elbv2.
 	if parts := strings.Split(listener.LoadBalancerArn, "/"); len(parts) >= 3 {
 		lbName = parts[2]
 	}
-	ruleArn := fmt.Sprintf("arn:aws:elasticloadbalancing:%s:%s:listener-rule/app/%s/%s/%s",
-		api.region, api.accountID, lbName, uuid.New().String()[:8], uuid.New().String()[:8])
+	ruleArn := awsarn.Build("elasticloadbalancing", api.region, api.accountFor(ctx), fmt.Sprintf("listener-rule/app/%s/%s/%s", lbName, deterministic.Suffix(8), deterministic.Suffix(8)))
 
 	// Marshal conditions and actions
 	conditionsJSON, err := json.Marshal(input.Conditions)
@@ -865,7 +1096,7 @@ func (api *ELBv2APIImpl) CreateRule(ctx context.Context, input *generated_elbv2.
 	}
 
 	// Create rule
-	now := time.Now()
+	now := deterministic.Now()
 	rule := &storage.ELBv2Rule{
 		ARN:         ruleArn,
 		ListenerArn: input.ListenerArn,
@@ -875,7 +1106,7 @@ func (api *ELBv2APIImpl) CreateRule(ctx context.Context, input *generated_elbv2.
 		IsDefault:   false,
 		Tags:        make(map[string]string),
 		Region:      api.region,
-		AccountID:   api.accountID,
+		AccountID:   api.accountFor(ctx),
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
@@ -895,27 +1126,7 @@ func (api *ELBv2APIImpl) CreateRule(ctx context.Context, input *generated_elbv2.
 	}
 
 	// Sync rule to Kubernetes IngressRoute if integration is available
-	if api.elbv2Integration != nil {
-		// Check if the integration supports rule syncing
-		if ruleSyncable, ok := api.elbv2Integration.(elbv2.RuleSyncable); ok {
-			// Get listener details to find load balancer name and port
-			listener, _ := api.storage.ELBv2Store().GetListener(ctx, rule.ListenerArn)
-			if listener != nil {
-				// Extract load balancer name from listener's load balancer ARN
-				lbName := "unknown"
-				if parts := strings.Split(listener.LoadBalancerArn, "/"); len(parts) >= 3 {
-					lbName = parts[2]
-				}
-				// TODO: Get actual port from listener - for now use port 80
-				port := int32(80)
-
-				// Sync rules to IngressRoute
-				if err := ruleSyncable.SyncRulesToListener(ctx, api.storage, rule.ListenerArn, lbName, port); err != nil {
-					logging.Debug("Failed to sync rules to IngressRoute", "error", err)
-				}
-			}
-		}
-	}
+	api.syncListenerRules(ctx, rule.ListenerArn)
 
 	// Return created rule
 	output := &generated_elbv2.CreateRuleOutput{
@@ -941,6 +1152,9 @@ func (api *ELBv2APIImpl) DeleteRule(ctx context.Context, input *generated_elbv2.
 	if input.RuleArn == "" {
 		return nil, fmt.Errorf("RuleArn is required")
 	}
+	if _, ok := defaultRuleListenerArn(input.RuleArn); ok {
+		return nil, fmt.Errorf("cannot delete default rule")
+	}
 
 	// Check if rule exists
 	rule, err := api.storage.ELBv2Store().GetRule(ctx, input.RuleArn)
@@ -965,27 +1179,7 @@ func (api *ELBv2APIImpl) DeleteRule(ctx context.Context, input *generated_elbv2.
 	}
 
 	// Sync rules to Kubernetes IngressRoute if integration is available
-	if api.elbv2Integration != nil {
-		// Check if the integration supports rule syncing
-		if ruleSyncable, ok := api.elbv2Integration.(elbv2.RuleSyncable); ok {
-			// Get listener details to find load balancer name and port
-			listener, _ := api.storage.ELBv2Store().GetListener(ctx, listenerArn)
-			if listener != nil {
-				// Extract load balancer name from listener's load balancer ARN
-				lbName := "unknown"
-				if parts := strings.Split(listener.LoadBalancerArn, "/"); len(parts) >= 3 {
-					lbName = parts[2]
-				}
-				// TODO: Get actual port from listener - for now use port 80
-				port := int32(80)
-
-				// Sync rules to IngressRoute
-				if err := ruleSyncable.SyncRulesToListener(ctx, api.storage, listenerArn, lbName, port); err != nil {
-					logging.Debug("Failed to sync rules to IngressRoute after delete", "error", err)
-				}
-			}
-		}
-	}
+	api.syncListenerRules(ctx, listenerArn)
 
 	return &generated_elbv2.DeleteRuleOutput{}, nil
 }
@@ -1051,16 +1245,7 @@ func (api *ELBv2APIImpl) DescribeListenerCertificates(ctx context.Context, input
 		return nil, fmt.Errorf("listener not found: %s", input.ListenerArn)
 	}
 
-	// Unmarshal certificates from JSON
-	var certificates []generated_elbv2.Certificate
-	if listener.Certificates != "" && listener.Certificates != "[]" {
-		if err := json.Unmarshal([]byte(listener.Certificates), &certificates); err != nil {
-			logging.Debug("Failed to unmarshal listener certificates", "listenerArn", input.ListenerArn, "error", err)
-			certificates = make([]generated_elbv2.Certificate, 0)
-		}
-	} else {
-		certificates = make([]generated_elbv2.Certificate, 0)
-	}
+	certificates := listenerCertificates(listener)
 
 	// Note: Pagination parameters (Marker, PageSize) are ignored for now
 	// since certificate lists are typically small
@@ -1071,6 +1256,7 @@ func (api *ELBv2APIImpl) DescribeListenerCertificates(ctx context.Context, input
 
 func (api *ELBv2APIImpl) DescribeListeners(ctx context.Context, input *generated_elbv2.DescribeListenersInput) (*generated_elbv2.DescribeListenersOutput, error) {
 	var listeners []*storage.ELBv2Listener
+	var nextMarker *string
 	var err error
 
 	// If specific listener ARNs are provided
@@ -1090,6 +1276,12 @@ func (api *ELBv2APIImpl) DescribeListeners(ctx context.Context, input *generated
 		if err != nil {
 			return nil, err
 		}
+		listeners, nextMarker, err = paginateELBv2(listeners, func(l *storage.ELBv2Listener) storage.PageCursor {
+			return storage.NewestFirstCursor(l.CreatedAt, l.ARN)
+		}, input.Marker, input.PageSize)
+		if err != nil {
+			return nil, err
+		}
 	} else {
 		return nil, fmt.Errorf("LoadBalancerArn or ListenerArns must be specified")
 	}
@@ -1101,47 +1293,105 @@ func (api *ELBv2APIImpl) DescribeListeners(ctx context.Context, input *generated
 	}
 
 	return &generated_elbv2.DescribeListenersOutput{
-		Listeners: responseListeners,
+		Listeners:  responseListeners,
+		NextMarker: nextMarker,
 	}, nil
 }
 
 func (api *ELBv2APIImpl) DescribeLoadBalancerAttributes(ctx context.Context, input *generated_elbv2.DescribeLoadBalancerAttributesInput) (*generated_elbv2.DescribeLoadBalancerAttributesOutput, error) {
-	return &generated_elbv2.DescribeLoadBalancerAttributesOutput{}, nil
+	if input.LoadBalancerArn == "" {
+		return nil, fmt.Errorf("LoadBalancerArn is required")
+	}
+
+	lb, err := api.storage.ELBv2Store().GetLoadBalancer(ctx, input.LoadBalancerArn)
+	if err != nil {
+		if err == storage.ErrResourceNotFound {
+			return nil, fmt.Errorf("load balancer not found: %s", input.LoadBalancerArn)
+		}
+		return nil, fmt.Errorf("failed to get load balancer: %w", err)
+	}
+	if lb == nil {
+		return nil, fmt.Errorf("load balancer not found: %s", input.LoadBalancerArn)
+	}
+
+	return &generated_elbv2.DescribeLoadBalancerAttributesOutput{
+		Attributes: loadBalancerAttributes(lb),
+	}, nil
 }
 
 func (api *ELBv2APIImpl) DescribeRules(ctx context.Context, input *generated_elbv2.DescribeRulesInput) (*generated_elbv2.DescribeRulesOutput, error) {
-	var rules []*storage.ELBv2Rule
-	var err error
+	var responseRules []generated_elbv2.Rule
 
 	// If specific rule ARNs are provided
-	if input.RuleArns != nil && len(input.RuleArns) > 0 {
+	if len(input.RuleArns) > 0 {
 		for _, arn := range input.RuleArns {
-			rule, getErr := api.storage.ELBv2Store().GetRule(ctx, arn)
-			if getErr != nil {
-				return nil, getErr
+			if listenerArn, ok := defaultRuleListenerArn(arn); ok {
+				listener, err := api.storage.ELBv2Store().GetListener(ctx, listenerArn)
+				if err != nil || listener == nil {
+					return nil, fmt.Errorf("rule not found: %s", arn)
+				}
+				responseRules = append(responseRules, api.defaultRuleForListener(listener))
+				continue
 			}
-			if rule != nil {
-				rules = append(rules, rule)
+
+			rule, err := api.storage.ELBv2Store().GetRule(ctx, arn)
+			if err != nil {
+				if strings.Contains(err.Error(), "not found") {
+					return nil, fmt.Errorf("rule not found: %s", arn)
+				}
+				return nil, err
+			}
+			if rule == nil {
+				return nil, fmt.Errorf("rule not found: %s", arn)
 			}
+			responseRules = append(responseRules, api.convertToRule(rule))
 		}
 	} else if input.ListenerArn != nil {
-		// Get rules for a specific listener
-		rules, err = api.storage.ELBv2Store().ListRules(ctx, *input.ListenerArn)
+		listener, err := api.storage.ELBv2Store().GetListener(ctx, *input.ListenerArn)
+		if err != nil {
+			return nil, err
+		}
+		if listener == nil {
+			return nil, fmt.Errorf("listener not found: %s", *input.ListenerArn)
+		}
+
+		// Get rules for a specific listener, in evaluation order
+		rules, err := api.storage.ELBv2Store().ListRules(ctx, *input.ListenerArn)
 		if err != nil {
 			return nil, err
 		}
+		sort.Slice(rules, func(i, j int) bool {
+			return rules[i].Priority < rules[j].Priority
+		})
+		for _, rule := range rules {
+			responseRules = append(responseRules, api.convertToRule(rule))
+		}
+
+		// The default rule is evaluated last and carries the listener's default actions
+		responseRules = append(responseRules, api.defaultRuleForListener(listener))
 	} else {
 		return nil, fmt.Errorf("ListenerArn or RuleArns must be specified")
 	}
 
-	// Convert to response format
-	var responseRules []generated_elbv2.Rule
-	for _, rule := range rules {
-		responseRules = append(responseRules, api.convertToRule(rule))
+	// Apply pagination, the marker is the index of the next rule
+	start := 0
+	if input.Marker != nil && *input.Marker != "" {
+		marker, err := strconv.Atoi(*input.Marker)
+		if err != nil || marker < 0 || marker > len(responseRules) {
+			return nil, fmt.Errorf("invalid marker: %s", *input.Marker)
+		}
+		start = marker
+	}
+	end := len(responseRules)
+	var nextMarker *string
+	if input.PageSize != nil && *input.PageSize > 0 && start+int(*input.PageSize) < end {
+		end = start + int(*input.PageSize)
+		nextMarker = utils.Ptr(strconv.Itoa(end))
 	}
 
 	return &generated_elbv2.DescribeRulesOutput{
-		Rules: responseRules,
+		Rules:      responseRules[start:end],
+		NextMarker: nextMarker,
 	}, nil
 }
 
@@ -1228,6 +1478,7 @@ func (api *ELBv2APIImpl) DescribeTargetGroupAttributes(ctx context.Context, inpu
 
 func (api *ELBv2APIImpl) DescribeTargetGroups(ctx context.Context, input *generated_elbv2.DescribeTargetGroupsInput) (*generated_elbv2.DescribeTargetGroupsOutput, error) {
 	var targetGroups []*storage.ELBv2TargetGroup
+	var nextMarker *string
 	var err error
 
 	// If specific ARNs are provided, get those target groups
@@ -1265,6 +1516,12 @@ func (api *ELBv2APIImpl) DescribeTargetGroups(ctx context.Context, input *genera
 		if err != nil {
 			return nil, err
 		}
+		targetGroups, nextMarker, err = paginateELBv2(targetGroups, func(tg *storage.ELBv2TargetGroup) storage.PageCursor {
+			return storage.NewestFirstCursor(tg.CreatedAt, tg.ARN)
+		}, input.Marker, input.PageSize)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Convert to response format
@@ -1275,6 +1532,7 @@ func (api *ELBv2APIImpl) DescribeTargetGroups(ctx context.Context, input *genera
 
 	return &generated_elbv2.DescribeTargetGroupsOutput{
 		TargetGroups: responseTargetGroups,
+		NextMarker:   nextMarker,
 	}, nil
 }
 
@@ -1325,7 +1583,7 @@ func (api *ELBv2APIImpl) ModifyListener(ctx context.Context, input *generated_el
 	}
 
 	// Update listener fields
-	now := time.Now()
+	now := deterministic.Now()
 	if input.Port != nil {
 		listener.Port = *input.Port
 	}
@@ -1335,7 +1593,47 @@ func (api *ELBv2APIImpl) ModifyListener(ctx context.Context, input *generated_el
 	if input.SslPolicy != nil {
 		listener.SslPolicy = *input.SslPolicy
 	}
+	if len(input.Certificates) > 0 {
+		// ModifyListener replaces the default certificate and keeps the SNI certificates
+		if len(input.Certificates) > 1 {
+			return nil, fmt.Errorf("only the default certificate can be specified when modifying a listener")
+		}
+		if !tlsProtocol(listener.Protocol) {
+			return nil, fmt.Errorf("certificates can only be specified for HTTPS and TLS listeners")
+		}
+		defaultArn := input.Certificates[0].CertificateArn
+		if defaultArn == nil || *defaultArn == "" {
+			return nil, fmt.Errorf("CertificateArn is required")
+		}
+		certificates := []generated_elbv2.Certificate{{CertificateArn: defaultArn, IsDefault: utils.Ptr(true)}}
+		for _, cert := range listenerCertificates(listener) {
+			if cert.IsDefault != nil && *cert.IsDefault {
+				continue
+			}
+			if cert.CertificateArn != nil && *cert.CertificateArn == *defaultArn {
+				continue
+			}
+			certificates = append(certificates, cert)
+		}
+		certificatesJSON, err := json.Marshal(certificates)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal certificates: %w", err)
+		}
+		listener.Certificates = string(certificatesJSON)
+	}
+	// Listeners that answered with a redirect or fixed response need their
+	// routes rebuilt when they forward again
+	respondedBefore := false
+	if previous, err := elbv2.NewRuleConverter().ConvertRuleActionsFromJSON(listener.DefaultActions); err == nil {
+		respondedBefore = elbv2.ResponseAction(previous) != nil
+	}
 	if input.DefaultActions != nil {
+		if err := elbv2.NewWeightedRoutingManager().ValidateWeightedRouting(input.DefaultActions); err != nil {
+			return nil, fmt.Errorf("invalid forward configuration: %w", err)
+		}
+		if err := elbv2.NewRuleEvaluator().ValidateActions(input.DefaultActions); err != nil {
+			return nil, fmt.Errorf("invalid default actions: %w", err)
+		}
 		// Convert actions to JSON for storage
 		actionsJSON, err := json.Marshal(input.DefaultActions)
 		if err != nil {
@@ -1353,21 +1651,22 @@ func (api *ELBv2APIImpl) ModifyListener(ctx context.Context, input *generated_el
 	// Update Kubernetes resources if integration is available
 	if api.elbv2Integration != nil {
 		// Get target group ARN from default actions if available
-		var targetGroupArn string
-		if input.DefaultActions != nil && len(input.DefaultActions) > 0 {
-			for _, action := range input.DefaultActions {
-				if action.Type == generated_elbv2.ActionTypeEnum("forward") && action.TargetGroupArn != nil {
-					targetGroupArn = *action.TargetGroupArn
-					break
-				}
-			}
-		}
+		targetGroupArn := forwardTargetGroupArn(input.DefaultActions)
 
 		// Update listener in Kubernetes
 		if _, err := api.elbv2Integration.CreateListener(ctx, listener.LoadBalancerArn, listener.Port, listener.Protocol, targetGroupArn); err != nil {
 			logging.Debug("Failed to update listener in Kubernetes", "error", err)
 			// Don't fail the operation if K8s update fails
 		}
+		if input.DefaultActions != nil {
+			api.syncListenerForwardConfig(ctx, listener.LoadBalancerArn, listener.Port, input.DefaultActions)
+			if respondedBefore || elbv2.ResponseAction(input.DefaultActions) != nil {
+				api.syncListenerRules(ctx, listener.ARN)
+			}
+		}
+		if tlsProtocol(listener.Protocol) || len(input.Certificates) > 0 {
+			api.syncListenerCertificates(ctx, listener)
+		}
 	}
 
 	// Return updated listener
@@ -1446,19 +1745,119 @@ func (api *ELBv2APIImpl) ModifyLoadBalancerAttributes(ctx context.Context, input
 		return nil, fmt.Errorf("load balancer not found: %s", input.LoadBalancerArn)
 	}
 
-	// For now, we simply acknowledge the attributes without storing them
-	// This is sufficient for Terraform which just needs a successful response
-	// In the future, we can extend the storage model to persist these attributes
+	attributes := make(map[string]string, len(lb.Attributes)+len(input.Attributes))
+	for k, v := range lb.Attributes {
+		attributes[k] = v
+	}
+	accessLogsChanged := false
+	for _, attr := range input.Attributes {
+		if attr.Key == nil {
+			continue
+		}
+		value := ""
+		if attr.Value != nil {
+			value = *attr.Value
+		}
+		attributes[*attr.Key] = value
+		if strings.HasPrefix(*attr.Key, "access_logs.s3.") {
+			accessLogsChanged = true
+		}
+	}
+
+	accessLogConfig := elbv2.AccessLogConfigFromAttributes(attributes)
+	if err := accessLogConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid access log configuration: %w", err)
+	}
+
+	// Start or stop delivering access logs before persisting, so that an
+	// unusable configuration is rejected like ELBv2 does
+	if accessLogsChanged && api.elbv2Integration != nil {
+		if configurable, ok := api.elbv2Integration.(elbv2.AccessLogConfigurable); ok {
+			if err := configurable.ConfigureAccessLogs(ctx, lb.ARN, lb.DNSName, accessLogConfig); err != nil {
+				return nil, fmt.Errorf("invalid access log configuration: %w", err)
+			}
+		}
+	}
+
+	lb.Attributes = attributes
+	if err := api.storage.ELBv2Store().UpdateLoadBalancer(ctx, lb); err != nil {
+		return nil, fmt.Errorf("failed to update load balancer attributes: %w", err)
+	}
 
 	return &generated_elbv2.ModifyLoadBalancerAttributesOutput{
-		Attributes: input.Attributes,
+		Attributes: loadBalancerAttributes(lb),
 	}, nil
 }
 
+// RestoreAccessLogs resumes access log delivery for load balancers that had it enabled
+func (api *ELBv2APIImpl) RestoreAccessLogs(ctx context.Context) error {
+	configurable, ok := api.elbv2Integration.(elbv2.AccessLogConfigurable)
+	if !ok {
+		return nil
+	}
+
+	lbs, err := api.storage.ELBv2Store().ListLoadBalancers(ctx, api.region)
+	if err != nil {
+		return fmt.Errorf("failed to list load balancers: %w", err)
+	}
+	for _, lb := range lbs {
+		config := elbv2.AccessLogConfigFromAttributes(lb.Attributes)
+		if !config.Enabled {
+			continue
+		}
+		if err := configurable.ConfigureAccessLogs(ctx, lb.ARN, lb.DNSName, config); err != nil {
+			logging.Warn("Failed to restore access logs", "loadBalancerArn", lb.ARN, "error", err)
+		}
+	}
+	return nil
+}
+
+// defaultLoadBalancerAttributes are the attributes of a new Application Load Balancer
+var defaultLoadBalancerAttributes = map[string]string{
+	elbv2.AttributeAccessLogsEnabled:                  "false",
+	elbv2.AttributeAccessLogsBucket:                   "",
+	elbv2.AttributeAccessLogsPrefix:                   "",
+	"deletion_protection.enabled":                     "false",
+	"idle_timeout.timeout_seconds":                    "60",
+	"load_balancing.cross_zone.enabled":               "true",
+	"routing.http.drop_invalid_header_fields.enabled": "false",
+	"routing.http2.enabled":                           "true",
+}
+
+// loadBalancerAttributes returns the stored attributes of a load balancer merged over the defaults, sorted by key
+func loadBalancerAttributes(lb *storage.ELBv2LoadBalancer) []generated_elbv2.LoadBalancerAttribute {
+	merged := make(map[string]string, len(defaultLoadBalancerAttributes)+len(lb.Attributes))
+	for k, v := range defaultLoadBalancerAttributes {
+		merged[k] = v
+	}
+	for k, v := range lb.Attributes {
+		merged[k] = v
+	}
+
+	keys := make([]string, 0, len(merged))
+	for k := range merged {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attributes := make([]generated_elbv2.LoadBalancerAttribute, 0, len(keys))
+	for _, k := range keys {
+		attributes = append(attributes, generated_elbv2.LoadBalancerAttribute{
+			Key:   utils.Ptr(k),
+			Value: utils.Ptr(merged[k]),
+		})
+	}
+	return attributes
+}
+
 func (api *ELBv2APIImpl) ModifyRule(ctx context.Context, input *generated_elbv2.ModifyRuleInput) (*generated_elbv2.ModifyRuleOutput, error) {
 	if input.RuleArn == "" {
 		return nil, fmt.Errorf("RuleArn is required")
 	}
+	// The actions of the default rule are the listener's default actions
+	if _, ok := defaultRuleListenerArn(input.RuleArn); ok {
+		return nil, fmt.Errorf("cannot modify default rule, use ModifyListener to change its actions")
+	}
 
 	// Get existing rule
 	rule, err := api.storage.ELBv2Store().GetRule(ctx, input.RuleArn)
@@ -1468,6 +1867,9 @@ func (api *ELBv2APIImpl) ModifyRule(ctx context.Context, input *generated_elbv2.
 		}
 		return nil, fmt.Errorf("failed to get rule: %w", err)
 	}
+	if rule == nil {
+		return nil, fmt.Errorf("rule not found: %s", input.RuleArn)
+	}
 
 	// Cannot modify default rule
 	if rule.IsDefault {
@@ -1476,6 +1878,9 @@ func (api *ELBv2APIImpl) ModifyRule(ctx context.Context, input *generated_elbv2.
 
 	// Update conditions if provided
 	if input.Conditions != nil {
+		if err := elbv2.NewRuleEvaluator().ValidateConditions(input.Conditions); err != nil {
+			return nil, fmt.Errorf("invalid rule conditions: %w", err)
+		}
 		conditionsJSON, err := json.Marshal(input.Conditions)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal conditions: %w", err)
@@ -1485,47 +1890,31 @@ func (api *ELBv2APIImpl) ModifyRule(ctx context.Context, input *generated_elbv2.
 
 	// Update actions if provided
 	if input.Actions != nil {
+		if err := elbv2.NewWeightedRoutingManager().ValidateWeightedRouting(input.Actions); err != nil {
+			return nil, fmt.Errorf("invalid forward configuration: %w", err)
+		}
+		if err := elbv2.NewRuleEvaluator().ValidateActions(input.Actions); err != nil {
+			return nil, fmt.Errorf("invalid rule actions: %w", err)
+		}
 		actionsJSON, err := json.Marshal(input.Actions)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal actions: %w", err)
 		}
 		rule.Actions = string(actionsJSON)
 	}
+	rule.UpdatedAt = deterministic.Now()
 
 	// Update rule in storage
 	if err := api.storage.ELBv2Store().UpdateRule(ctx, rule); err != nil {
 		return nil, fmt.Errorf("failed to update rule: %w", err)
 	}
 
-	// Return updated rule
-	var conditions []generated_elbv2.RuleCondition
-	var actions []generated_elbv2.Action
-
-	if input.Conditions != nil {
-		conditions = input.Conditions
-	} else {
-		json.Unmarshal([]byte(rule.Conditions), &conditions)
-	}
-
-	if input.Actions != nil {
-		actions = input.Actions
-	} else {
-		json.Unmarshal([]byte(rule.Actions), &actions)
-	}
-
-	output := &generated_elbv2.ModifyRuleOutput{
-		Rules: []generated_elbv2.Rule{
-			{
-				RuleArn:    &rule.ARN,
-				Priority:   utils.Ptr(fmt.Sprintf("%d", rule.Priority)),
-				Conditions: conditions,
-				Actions:    actions,
-				IsDefault:  utils.Ptr(rule.IsDefault),
-			},
-		},
-	}
+	// Sync rules to Kubernetes IngressRoute if integration is available
+	api.syncListenerRules(ctx, rule.ListenerArn)
 
-	return output, nil
+	return &generated_elbv2.ModifyRuleOutput{
+		Rules: []generated_elbv2.Rule{api.convertToRule(rule)},
+	}, nil
 }
 
 func (api *ELBv2APIImpl) ModifyTargetGroup(ctx context.Context, input *generated_elbv2.ModifyTargetGroupInput) (*generated_elbv2.ModifyTargetGroupOutput, error) {
@@ -1543,7 +1932,7 @@ func (api *ELBv2APIImpl) ModifyTargetGroup(ctx context.Context, input *generated
 	}
 
 	// Update target group fields
-	now := time.Now()
+	now := deterministic.Now()
 	if input.HealthCheckEnabled != nil {
 		targetGroup.HealthCheckEnabled = *input.HealthCheckEnabled
 	}
@@ -1658,7 +2047,44 @@ func (api *ELBv2APIImpl) ModifyTrustStore(ctx context.Context, input *generated_
 	return &generated_elbv2.ModifyTrustStoreOutput{}, nil
 }
 
+// RemoveListenerCertificates removes certificates from the certificate list of a
+// listener. The default certificate can only be replaced with ModifyListener.
 func (api *ELBv2APIImpl) RemoveListenerCertificates(ctx context.Context, input *generated_elbv2.RemoveListenerCertificatesInput) (*generated_elbv2.RemoveListenerCertificatesOutput, error) {
+	if input.ListenerArn == "" {
+		return nil, fmt.Errorf("ListenerArn is required")
+	}
+	if len(input.Certificates) == 0 {
+		return nil, fmt.Errorf("Certificates is required")
+	}
+
+	listener, err := api.storage.ELBv2Store().GetListener(ctx, input.ListenerArn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get listener: %w", err)
+	}
+	if listener == nil {
+		return nil, fmt.Errorf("listener not found: %s", input.ListenerArn)
+	}
+
+	certificates := listenerCertificates(listener)
+	for _, cert := range input.Certificates {
+		if cert.CertificateArn == nil {
+			continue
+		}
+		idx := findCertificate(certificates, *cert.CertificateArn)
+		if idx < 0 {
+			continue
+		}
+		if certificates[idx].IsDefault != nil && *certificates[idx].IsDefault {
+			return nil, fmt.Errorf("OperationNotPermitted: the default certificate %s cannot be removed, use ModifyListener to replace it", *cert.CertificateArn)
+		}
+		certificates = append(certificates[:idx], certificates[idx+1:]...)
+	}
+
+	if err := api.saveListenerCertificates(ctx, listener, certificates); err != nil {
+		return nil, err
+	}
+	api.syncListenerCertificates(ctx, listener)
+
 	return &generated_elbv2.RemoveListenerCertificatesOutput{}, nil
 }
 
@@ -1756,7 +2182,47 @@ func (api *ELBv2APIImpl) SetIpAddressType(ctx context.Context, input *generated_
 }
 
 func (api *ELBv2APIImpl) SetRulePriorities(ctx context.Context, input *generated_elbv2.SetRulePrioritiesInput) (*generated_elbv2.SetRulePrioritiesOutput, error) {
-	return &generated_elbv2.SetRulePrioritiesOutput{}, nil
+	if len(input.RulePriorities) == 0 {
+		return nil, fmt.Errorf("RulePriorities is required")
+	}
+
+	updates := make([]elbv2.RulePriorityUpdate, 0, len(input.RulePriorities))
+	for _, pair := range input.RulePriorities {
+		if pair.RuleArn == nil || *pair.RuleArn == "" {
+			return nil, fmt.Errorf("RuleArn is required")
+		}
+		if _, ok := defaultRuleListenerArn(*pair.RuleArn); ok {
+			return nil, fmt.Errorf("cannot set the priority of default rule")
+		}
+		if pair.Priority == nil {
+			return nil, fmt.Errorf("Priority is required for rule %s", *pair.RuleArn)
+		}
+		updates = append(updates, elbv2.RulePriorityUpdate{
+			RuleArn:  *pair.RuleArn,
+			Priority: *pair.Priority,
+		})
+	}
+
+	if err := elbv2.NewPriorityManager(api.storage.ELBv2Store()).SetRulePriorities(ctx, updates); err != nil {
+		return nil, err
+	}
+
+	// Return the updated rules and resync every affected listener
+	output := &generated_elbv2.SetRulePrioritiesOutput{}
+	synced := make(map[string]bool)
+	for _, update := range updates {
+		rule, err := api.storage.ELBv2Store().GetRule(ctx, update.RuleArn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get rule: %w", err)
+		}
+		output.Rules = append(output.Rules, api.convertToRule(rule))
+		if !synced[rule.ListenerArn] {
+			synced[rule.ListenerArn] = true
+			api.syncListenerRules(ctx, rule.ListenerArn)
+		}
+	}
+
+	return output, nil
 }
 
 func (api *ELBv2APIImpl) SetSecurityGroups(ctx context.Context, input *generated_elbv2.SetSecurityGroupsInput) (*generated_elbv2.SetSecurityGroupsOutput, error) {
@@ -1781,7 +2247,7 @@ func (api *ELBv2APIImpl) SetSecurityGroups(ctx context.Context, input *generated
 
 	// Update security groups
 	lb.SecurityGroups = input.SecurityGroups
-	lb.UpdatedAt = time.Now()
+	lb.UpdatedAt = deterministic.Now()
 
 	// Save to storage
 	if err := api.storage.ELBv2Store().UpdateLoadBalancer(ctx, lb); err != nil {
@@ -1822,7 +2288,7 @@ func (api *ELBv2APIImpl) SetSubnets(ctx context.Context, input *generated_elbv2.
 		lb.IpAddressType = string(*input.IpAddressType)
 	}
 
-	lb.UpdatedAt = time.Now()
+	lb.UpdatedAt = deterministic.Now()
 
 	// Save to storage
 	if err := api.storage.ELBv2Store().UpdateLoadBalancer(ctx, lb); err != nil {
@@ -1873,6 +2339,23 @@ func convertToLoadBalancer(lb *storage.ELBv2LoadBalancer) generated_elbv2.LoadBa
 
 // performHealthCheck performs a health check on a target
 func (api *ELBv2APIImpl) performHealthCheck(ctx context.Context, target *storage.ELBv2Target, targetGroup *storage.ELBv2TargetGroup) string {
+	// Lambda targets are health checked by invoking the function, if enabled
+	if targetGroup != nil && targetGroup.TargetType == elbv2.TargetTypeLambda {
+		if !targetGroup.HealthCheckEnabled {
+			return TargetHealthStateUnavailable
+		}
+		syncable, ok := api.elbv2Integration.(elbv2.ManualTargetSyncable)
+		if !ok {
+			return TargetHealthStateUnavailable
+		}
+		healthState, err := syncable.CheckLambdaTargetHealth(ctx, targetGroup.ARN, targetGroup.HealthCheckPath)
+		if err != nil {
+			logging.Debug("Lambda health check failed for target", "targetId", target.ID, "error", err)
+			return TargetHealthStateUnhealthy
+		}
+		return healthState
+	}
+
 	// Use target group configuration for health check settings
 	if targetGroup == nil || !targetGroup.HealthCheckEnabled {
 		// If health check is disabled or target group is nil, consider target healthy
@@ -2027,6 +2510,8 @@ func (api *ELBv2APIImpl) getHealthReason(healthState string) string {
 		return "Target.RegistrationInProgress"
 	case TargetHealthStateDeregistering:
 		return "Target.DeregistrationInProgress"
+	case TargetHealthStateUnavailable:
+		return "Target.HealthCheckDisabled"
 	default:
 		return "Target.InvalidState"
 	}
@@ -2045,6 +2530,8 @@ func (api *ELBv2APIImpl) getHealthDescription(healthState string) string {
 		return "Target registration is in progress"
 	case TargetHealthStateDeregistering:
 		return "Target deregistration is in progress"
+	case TargetHealthStateUnavailable:
+		return "Health checks are disabled"
 	default:
 		return "Target is in an invalid state"
 	}
@@ -2084,7 +2571,7 @@ func (api *ELBv2APIImpl) updateLoadBalancerToActive(ctx context.Context, arn str
 	}
 
 	lb.State = "active"
-	lb.UpdatedAt = time.Now()
+	lb.UpdatedAt = deterministic.Now()
 
 	return api.storage.ELBv2Store().UpdateLoadBalancer(ctx, lb)
 }
@@ -2112,15 +2599,31 @@ func (api *ELBv2APIImpl) convertToTargetGroup(tg *storage.ELBv2TargetGroup) gene
 func (api *ELBv2APIImpl) convertToListener(listener *storage.ELBv2Listener) generated_elbv2.Listener {
 	// Convert default actions - ensure we always have a non-nil slice
 	defaultActions := make([]generated_elbv2.Action, 0)
-	// TODO: Parse and convert stored default actions JSON
+	if listener.DefaultActions != "" {
+		if err := json.Unmarshal([]byte(listener.DefaultActions), &defaultActions); err != nil {
+			logging.Debug("Failed to unmarshal listener default actions", "listenerArn", listener.ARN, "error", err)
+			defaultActions = make([]generated_elbv2.Action, 0)
+		}
+	}
 
-	return generated_elbv2.Listener{
+	result := generated_elbv2.Listener{
 		ListenerArn:     &listener.ARN,
 		LoadBalancerArn: &listener.LoadBalancerArn,
 		Port:            &listener.Port,
 		Protocol:        (*generated_elbv2.ProtocolEnum)(&listener.Protocol),
 		DefaultActions:  defaultActions,
 	}
+	if listener.SslPolicy != "" {
+		result.SslPolicy = &listener.SslPolicy
+	}
+	// Like ELBv2, listeners only report their default certificate
+	for _, cert := range listenerCertificates(listener) {
+		if cert.IsDefault != nil && *cert.IsDefault {
+			result.Certificates = []generated_elbv2.Certificate{{CertificateArn: cert.CertificateArn}}
+			break
+		}
+	}
+	return result
 }
 
 // convertToRule converts storage rule to API response format
@@ -2155,3 +2658,161 @@ func (api *ELBv2APIImpl) convertToRule(rule *storage.ELBv2Rule) generated_elbv2.
 		IsDefault:  utils.Ptr(rule.IsDefault),
 	}
 }
+
+// defaultRuleSuffix ends the ARN of the implicit default rule of a listener
+const defaultRuleSuffix = "/default"
+
+// defaultRuleForListener returns the implicit default rule of a listener,
+// which forwards requests matching no other rule to the default actions
+func (api *ELBv2APIImpl) defaultRuleForListener(listener *storage.ELBv2Listener) generated_elbv2.Rule {
+	listenerAPI := api.convertToListener(listener)
+	return generated_elbv2.Rule{
+		RuleArn:    utils.Ptr(strings.Replace(listener.ARN, ":listener/", ":listener-rule/", 1) + defaultRuleSuffix),
+		Priority:   utils.Ptr("default"),
+		Conditions: make([]generated_elbv2.RuleCondition, 0),
+		Actions:    listenerAPI.DefaultActions,
+		IsDefault:  utils.Ptr(true),
+	}
+}
+
+// defaultRuleListenerArn returns the listener of a default rule ARN built by
+// defaultRuleForListener. Default rules are not stored, so they are resolved
+// through their listener.
+func defaultRuleListenerArn(ruleArn string) (string, bool) {
+	if !strings.Contains(ruleArn, ":listener-rule/") || !strings.HasSuffix(ruleArn, defaultRuleSuffix) {
+		return "", false
+	}
+	return strings.Replace(strings.TrimSuffix(ruleArn, defaultRuleSuffix), ":listener-rule/", ":listener/", 1), true
+}
+
+// forwardTargetGroupArn returns the target group of the first forward action.
+// For weighted forward actions the first target group of the ForwardConfig is used.
+func forwardTargetGroupArn(actions []generated_elbv2.Action) string {
+	for _, action := range actions {
+		if action.Type != generated_elbv2.ActionTypeEnumFORWARD {
+			continue
+		}
+		if action.TargetGroupArn != nil {
+			return *action.TargetGroupArn
+		}
+		if action.ForwardConfig != nil {
+			for _, tg := range action.ForwardConfig.TargetGroups {
+				if tg.TargetGroupArn != nil {
+					return *tg.TargetGroupArn
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// tlsProtocol reports whether a listener protocol terminates TLS
+func tlsProtocol(protocol string) bool {
+	return protocol == "HTTPS" || protocol == "TLS"
+}
+
+// listenerCertificates returns the certificate list of a listener
+func listenerCertificates(listener *storage.ELBv2Listener) []generated_elbv2.Certificate {
+	certificates := make([]generated_elbv2.Certificate, 0)
+	if listener.Certificates != "" && listener.Certificates != "[]" {
+		if err := json.Unmarshal([]byte(listener.Certificates), &certificates); err != nil {
+			logging.Debug("Failed to unmarshal listener certificates", "listenerArn", listener.ARN, "error", err)
+			return make([]generated_elbv2.Certificate, 0)
+		}
+	}
+	return certificates
+}
+
+// findCertificate returns the index of a certificate in the list, or -1
+func findCertificate(certificates []generated_elbv2.Certificate, certificateArn string) int {
+	for i, cert := range certificates {
+		if cert.CertificateArn != nil && *cert.CertificateArn == certificateArn {
+			return i
+		}
+	}
+	return -1
+}
+
+// saveListenerCertificates stores the certificate list of a listener
+func (api *ELBv2APIImpl) saveListenerCertificates(ctx context.Context, listener *storage.ELBv2Listener, certificates []generated_elbv2.Certificate) error {
+	certificatesJSON, err := json.Marshal(certificates)
+	if err != nil {
+		return fmt.Errorf("failed to marshal certificates: %w", err)
+	}
+	listener.Certificates = string(certificatesJSON)
+	listener.UpdatedAt = deterministic.Now()
+	if err := api.storage.ELBv2Store().UpdateListener(ctx, listener); err != nil {
+		return fmt.Errorf("failed to update listener: %w", err)
+	}
+	return nil
+}
+
+// syncListenerCertificates pushes the certificate list of a listener to the TLS
+// termination layer if the integration supports it. Failures are logged since
+// storage is the source of truth.
+func (api *ELBv2APIImpl) syncListenerCertificates(ctx context.Context, listener *storage.ELBv2Listener) {
+	if api.elbv2Integration == nil {
+		return
+	}
+	syncable, ok := api.elbv2Integration.(elbv2.CertificateSyncable)
+	if !ok {
+		return
+	}
+	var certificates []elbv2.ListenerCertificate
+	for _, cert := range listenerCertificates(listener) {
+		if cert.CertificateArn == nil {
+			continue
+		}
+		certificates = append(certificates, elbv2.ListenerCertificate{
+			Arn:     *cert.CertificateArn,
+			Default: cert.IsDefault != nil && *cert.IsDefault,
+		})
+	}
+	if err := syncable.SyncListenerCertificates(ctx, listener.LoadBalancerArn, listener.Port, certificates); err != nil {
+		logging.Debug("Failed to sync listener certificates", "listenerArn", listener.ARN, "error", err)
+	}
+}
+
+// syncListenerForwardConfig routes the listener's default traffic through a
+// weighted service when its default action forwards to multiple target groups
+// or uses stickiness, and back to the single target group Ingress otherwise
+func (api *ELBv2APIImpl) syncListenerForwardConfig(ctx context.Context, loadBalancerArn string, port int32, actions []generated_elbv2.Action) {
+	if api.elbv2Integration == nil {
+		return
+	}
+	config := elbv2.WeightedForwardConfig(actions)
+	syncable, ok := api.elbv2Integration.(elbv2.ForwardConfigSyncable)
+	if !ok {
+		return
+	}
+	if err := syncable.SyncListenerForwardConfig(ctx, loadBalancerArn, port, config); err != nil {
+		logging.Debug("Failed to sync weighted forward config", "loadBalancerArn", loadBalancerArn, "port", port, "error", err)
+	}
+}
+
+// syncListenerRules pushes the rules of a listener to the routing layer if the
+// integration supports it. Failures are logged since storage is the source of truth.
+func (api *ELBv2APIImpl) syncListenerRules(ctx context.Context, listenerArn string) {
+	if api.elbv2Integration == nil {
+		return
+	}
+	ruleSyncable, ok := api.elbv2Integration.(elbv2.RuleSyncable)
+	if !ok {
+		return
+	}
+
+	listener, _ := api.storage.ELBv2Store().GetListener(ctx, listenerArn)
+	if listener == nil {
+		return
+	}
+
+	// Extract load balancer name from listener's load balancer ARN
+	lbName := "unknown"
+	if parts := strings.Split(listener.LoadBalancerArn, "/"); len(parts) >= 3 {
+		lbName = parts[2]
+	}
+
+	if err := ruleSyncable.SyncRulesToListener(ctx, api.storage, listenerArn, lbName, listener.Port); err != nil {
+		logging.Debug("Failed to sync rules to IngressRoute", "listenerArn", listenerArn, "error", err)
+	}
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -586,6 +587,102 @@ var _ = Describe("ELBv2APIImpl", func() {
 				mockStore.AssertExpectations(GinkgoT())
 				mockIntegration.AssertExpectations(GinkgoT())
 			})
+
+			It("should accept weighted forward actions", func() {
+				stableArn := "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/stable/123456"
+				canaryArn := "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/canary/123456"
+				loadBalancerArn := "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/test-lb-canary/123456"
+				input := &generated_elbv2.CreateListenerInput{
+					LoadBalancerArn: loadBalancerArn,
+					Port:            utils.Ptr(int32(80)),
+					Protocol:        ptrProtocol("HTTP"),
+					DefaultActions: []generated_elbv2.Action{
+						{
+							Type: generated_elbv2.ActionTypeEnumFORWARD,
+							ForwardConfig: &generated_elbv2.ForwardActionConfig{
+								TargetGroups: []generated_elbv2.TargetGroupTuple{
+									{TargetGroupArn: &stableArn, Weight: utils.Ptr(int32(90))},
+									{TargetGroupArn: &canaryArn, Weight: utils.Ptr(int32(10))},
+								},
+								TargetGroupStickinessConfig: &generated_elbv2.TargetGroupStickinessConfig{
+									Enabled:         utils.Ptr(true),
+									DurationSeconds: utils.Ptr(int32(300)),
+								},
+							},
+						},
+					},
+				}
+
+				mockStore.On("GetLoadBalancer", ctx, loadBalancerArn).
+					Return(&storage.ELBv2LoadBalancer{
+						ARN:   loadBalancerArn,
+						Name:  "test-lb-canary",
+						State: "active",
+					}, nil).Once()
+				mockStore.On("CreateListener", ctx, mock.MatchedBy(func(l *storage.ELBv2Listener) bool {
+					return strings.Contains(l.DefaultActions, canaryArn)
+				})).Return(nil).Once()
+
+				// The Ingress falls back to the first weighted target group
+				mockIntegration.On("CreateListener", ctx, loadBalancerArn, int32(80), "HTTP", stableArn).
+					Return(&elbv2.Listener{LoadBalancerArn: loadBalancerArn, Port: 80, Protocol: "HTTP"}, nil).Once()
+
+				output, err := api.CreateListener(ctx, input)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(output.Listeners[0].DefaultActions[0].ForwardConfig.TargetGroups).To(HaveLen(2))
+
+				mockStore.AssertExpectations(GinkgoT())
+				mockIntegration.AssertExpectations(GinkgoT())
+			})
+
+			It("should reject forward actions with invalid weights", func() {
+				loadBalancerArn := "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/test-lb-invalid/123456"
+				input := &generated_elbv2.CreateListenerInput{
+					LoadBalancerArn: loadBalancerArn,
+					DefaultActions: []generated_elbv2.Action{
+						{
+							Type: generated_elbv2.ActionTypeEnumFORWARD,
+							ForwardConfig: &generated_elbv2.ForwardActionConfig{
+								TargetGroups: []generated_elbv2.TargetGroupTuple{
+									{TargetGroupArn: utils.Ptr("tg-1"), Weight: utils.Ptr(int32(1000))},
+								},
+							},
+						},
+					},
+				}
+
+				mockStore.On("GetLoadBalancer", ctx, loadBalancerArn).
+					Return(&storage.ELBv2LoadBalancer{ARN: loadBalancerArn, Name: "test-lb-invalid"}, nil).Once()
+
+				_, err := api.CreateListener(ctx, input)
+
+				Expect(err).To(MatchError(ContainSubstring("invalid forward configuration")))
+				mockStore.AssertNotCalled(GinkgoT(), "CreateListener", mock.Anything, mock.Anything)
+			})
+
+			It("should reject fixed responses with an unsupported status code", func() {
+				loadBalancerArn := "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/test-lb-fixed/123456"
+				input := &generated_elbv2.CreateListenerInput{
+					LoadBalancerArn: loadBalancerArn,
+					DefaultActions: []generated_elbv2.Action{
+						{
+							Type: generated_elbv2.ActionTypeEnumFIXED_RESPONSE,
+							FixedResponseConfig: &generated_elbv2.FixedResponseActionConfig{
+								StatusCode: "301",
+							},
+						},
+					},
+				}
+
+				mockStore.On("GetLoadBalancer", ctx, loadBalancerArn).
+					Return(&storage.ELBv2LoadBalancer{ARN: loadBalancerArn, Name: "test-lb-fixed"}, nil).Once()
+
+				_, err := api.CreateListener(ctx, input)
+
+				Expect(err).To(MatchError(ContainSubstring("invalid default actions")))
+				mockStore.AssertNotCalled(GinkgoT(), "CreateListener", mock.Anything, mock.Anything)
+			})
 		})
 	})
 
@@ -607,6 +704,9 @@ var _ = Describe("ELBv2APIImpl", func() {
 					},
 				}
 
+				mockStore.On("GetTargetGroup", ctx, targetGroupArn).
+					Return(&storage.ELBv2TargetGroup{ARN: targetGroupArn, Port: 80, TargetType: "ip"}, nil).Once()
+
 				// Mock storage register targets
 				mockStore.On("RegisterTargets", ctx, targetGroupArn, mock.MatchedBy(func(targets []*storage.ELBv2Target) bool {
 					return len(targets) == 2 && targets[0].ID == "10.0.1.10" && targets[1].ID == "10.0.1.11"
@@ -631,6 +731,85 @@ var _ = Describe("ELBv2APIImpl", func() {
 		})
 	})
 
+	Describe("RegisterTargets target types", func() {
+		var targetGroupArn string
+
+		BeforeEach(func() {
+			targetGroupArn = "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/test-tg/123456"
+		})
+
+		It("should default the port of ip targets to the target group port", func() {
+			mockStore.On("GetTargetGroup", ctx, targetGroupArn).
+				Return(&storage.ELBv2TargetGroup{ARN: targetGroupArn, Port: 8080, TargetType: "ip"}, nil).Once()
+			mockStore.On("RegisterTargets", ctx, targetGroupArn, mock.MatchedBy(func(targets []*storage.ELBv2Target) bool {
+				return len(targets) == 1 && targets[0].Port == 8080
+			})).Return(nil).Once()
+			mockIntegration.On("RegisterTargets", ctx, targetGroupArn, []elbv2.Target{{Id: "192.168.1.20", Port: 8080}}).
+				Return(nil).Once()
+
+			_, err := api.RegisterTargets(ctx, &generated_elbv2.RegisterTargetsInput{
+				TargetGroupArn: targetGroupArn,
+				Targets:        []generated_elbv2.TargetDescription{{Id: "192.168.1.20"}},
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			mockStore.AssertExpectations(GinkgoT())
+		})
+
+		It("should reject ip targets that are not IP addresses", func() {
+			mockStore.On("GetTargetGroup", ctx, targetGroupArn).
+				Return(&storage.ELBv2TargetGroup{ARN: targetGroupArn, Port: 80, TargetType: "ip"}, nil).Once()
+
+			_, err := api.RegisterTargets(ctx, &generated_elbv2.RegisterTargetsInput{
+				TargetGroupArn: targetGroupArn,
+				Targets:        []generated_elbv2.TargetDescription{{Id: "i-0123456789abcdef0"}},
+			})
+
+			Expect(err).To(MatchError(ContainSubstring("not a valid IP address")))
+		})
+
+		It("should register a single Lambda function without port", func() {
+			functionArn := "arn:aws:lambda:us-east-1:123456789012:function:handler"
+			mockStore.On("GetTargetGroup", ctx, targetGroupArn).
+				Return(&storage.ELBv2TargetGroup{ARN: targetGroupArn, TargetType: "lambda"}, nil).Once()
+			mockStore.On("ListTargets", ctx, targetGroupArn).Return([]*storage.ELBv2Target{}, nil).Once()
+			mockStore.On("RegisterTargets", ctx, targetGroupArn, mock.MatchedBy(func(targets []*storage.ELBv2Target) bool {
+				return len(targets) == 1 && targets[0].ID == functionArn && targets[0].Port == 0
+			})).Return(nil).Once()
+			mockIntegration.On("RegisterTargets", ctx, targetGroupArn, []elbv2.Target{{Id: functionArn}}).
+				Return(nil).Once()
+
+			_, err := api.RegisterTargets(ctx, &generated_elbv2.RegisterTargetsInput{
+				TargetGroupArn: targetGroupArn,
+				Targets:        []generated_elbv2.TargetDescription{{Id: functionArn}},
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			mockStore.AssertExpectations(GinkgoT())
+		})
+
+		It("should reject a second Lambda function", func() {
+			mockStore.On("GetTargetGroup", ctx, targetGroupArn).
+				Return(&storage.ELBv2TargetGroup{ARN: targetGroupArn, TargetType: "lambda"}, nil).Once()
+			mockStore.On("ListTargets", ctx, targetGroupArn).Return([]*storage.ELBv2Target{
+				{ID: "arn:aws:lambda:us-east-1:123456789012:function:first"},
+			}, nil).Once()
+
+			_, err := api.RegisterTargets(ctx, &generated_elbv2.RegisterTargetsInput{
+				TargetGroupArn: targetGroupArn,
+				Targets:        []generated_elbv2.TargetDescription{{Id: "arn:aws:lambda:us-east-1:123456789012:function:second"}},
+			})
+
+			Expect(err).To(MatchError(ContainSubstring("only one target")))
+		})
+
+		It("should report Lambda targets without health checks as unavailable", func() {
+			state := api.performHealthCheck(ctx, &storage.ELBv2Target{ID: "arn:aws:lambda:us-east-1:123456789012:function:handler"},
+				&storage.ELBv2TargetGroup{ARN: targetGroupArn, TargetType: "lambda"})
+			Expect(state).To(Equal(TargetHealthStateUnavailable))
+		})
+	})
+
 	Describe("Phase 3: DeregisterTargets with Kubernetes integration", func() {
 		Context("when deregistering targets", func() {
 			It("should successfully deregister targets", func() {
@@ -1173,6 +1352,278 @@ var _ = Describe("ELBv2APIImpl", func() {
 			})
 		})
 	})
+
+	Describe("Listener rules", func() {
+		var (
+			listenerArn    string
+			targetGroupArn string
+			listener       *storage.ELBv2Listener
+		)
+
+		BeforeEach(func() {
+			listenerArn = "arn:aws:elasticloadbalancing:us-east-1:123456789012:listener/app/test-lb/123456/789"
+			targetGroupArn = "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/default-tg/123456"
+			listener = &storage.ELBv2Listener{
+				ARN:             listenerArn,
+				LoadBalancerArn: "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/test-lb/123456",
+				Port:            80,
+				Protocol:        "HTTP",
+				DefaultActions:  `[{"type":"forward","targetGroupArn":"` + targetGroupArn + `"}]`,
+			}
+		})
+
+		It("should describe rules in priority order followed by the default rule", func() {
+			mockStore.On("GetListener", ctx, listenerArn).Return(listener, nil).Once()
+			mockStore.On("ListRules", ctx, listenerArn).Return([]*storage.ELBv2Rule{
+				{ARN: "rule-b", ListenerArn: listenerArn, Priority: 20, Conditions: "[]", Actions: "[]"},
+				{ARN: "rule-a", ListenerArn: listenerArn, Priority: 10, Conditions: "[]", Actions: "[]"},
+			}, nil).Once()
+
+			output, err := api.DescribeRules(ctx, &generated_elbv2.DescribeRulesInput{ListenerArn: &listenerArn})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output.Rules).To(HaveLen(3))
+			Expect(*output.Rules[0].RuleArn).To(Equal("rule-a"))
+			Expect(*output.Rules[1].RuleArn).To(Equal("rule-b"))
+			Expect(*output.Rules[2].Priority).To(Equal("default"))
+			Expect(*output.Rules[2].IsDefault).To(BeTrue())
+			Expect(*output.Rules[2].Actions[0].TargetGroupArn).To(Equal(targetGroupArn))
+		})
+
+		It("should describe and protect the default rule by its ARN", func() {
+			defaultRuleArn := "arn:aws:elasticloadbalancing:us-east-1:123456789012:listener-rule/app/test-lb/123456/789/default"
+			mockStore.On("GetListener", ctx, listenerArn).Return(listener, nil).Once()
+
+			output, err := api.DescribeRules(ctx, &generated_elbv2.DescribeRulesInput{RuleArns: []string{defaultRuleArn}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output.Rules).To(HaveLen(1))
+			Expect(*output.Rules[0].RuleArn).To(Equal(defaultRuleArn))
+			Expect(*output.Rules[0].IsDefault).To(BeTrue())
+
+			_, err = api.ModifyRule(ctx, &generated_elbv2.ModifyRuleInput{RuleArn: defaultRuleArn})
+			Expect(err).To(MatchError(ContainSubstring("cannot modify default rule")))
+			mockStore.AssertExpectations(GinkgoT())
+		})
+
+		It("should paginate rules", func() {
+			mockStore.On("GetListener", ctx, listenerArn).Return(listener, nil).Twice()
+			mockStore.On("ListRules", ctx, listenerArn).Return([]*storage.ELBv2Rule{
+				{ARN: "rule-a", ListenerArn: listenerArn, Priority: 10, Conditions: "[]", Actions: "[]"},
+				{ARN: "rule-b", ListenerArn: listenerArn, Priority: 20, Conditions: "[]", Actions: "[]"},
+			}, nil).Twice()
+
+			first, err := api.DescribeRules(ctx, &generated_elbv2.DescribeRulesInput{
+				ListenerArn: &listenerArn,
+				PageSize:    utils.Ptr(int32(2)),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(first.Rules).To(HaveLen(2))
+			Expect(first.NextMarker).NotTo(BeNil())
+
+			second, err := api.DescribeRules(ctx, &generated_elbv2.DescribeRulesInput{
+				ListenerArn: &listenerArn,
+				PageSize:    utils.Ptr(int32(2)),
+				Marker:      first.NextMarker,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(second.Rules).To(HaveLen(1))
+			Expect(second.NextMarker).To(BeNil())
+		})
+
+		It("should reject rules with invalid conditions", func() {
+			output, err := api.CreateRule(ctx, &generated_elbv2.CreateRuleInput{
+				ListenerArn: listenerArn,
+				Priority:    10,
+				Conditions: []generated_elbv2.RuleCondition{
+					{SourceIpConfig: &generated_elbv2.SourceIpConditionConfig{Values: []string{"not-an-ip"}}},
+				},
+				Actions: []generated_elbv2.Action{
+					{Type: generated_elbv2.ActionTypeEnumFORWARD, TargetGroupArn: &targetGroupArn},
+				},
+			})
+
+			Expect(err).To(MatchError(ContainSubstring("invalid rule conditions")))
+			Expect(output).To(BeNil())
+		})
+
+		It("should set rule priorities", func() {
+			rule := &storage.ELBv2Rule{ARN: "rule-a", ListenerArn: listenerArn, Priority: 10, Conditions: "[]", Actions: "[]"}
+			mockStore.On("GetRule", ctx, "rule-a").Return(rule, nil)
+			mockStore.On("ListRules", ctx, listenerArn).Return([]*storage.ELBv2Rule{rule}, nil).Once()
+			mockStore.On("UpdateRule", ctx, mock.MatchedBy(func(r *storage.ELBv2Rule) bool {
+				return r.Priority == 30
+			})).Return(nil).Once()
+
+			output, err := api.SetRulePriorities(ctx, &generated_elbv2.SetRulePrioritiesInput{
+				RulePriorities: []generated_elbv2.RulePriorityPair{
+					{RuleArn: utils.Ptr("rule-a"), Priority: utils.Ptr(int32(30))},
+				},
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output.Rules).To(HaveLen(1))
+			Expect(*output.Rules[0].Priority).To(Equal("30"))
+			mockStore.AssertExpectations(GinkgoT())
+		})
+	})
+
+	Describe("Listener certificates", func() {
+		var (
+			listenerArn string
+			defaultCert string
+			listener    *storage.ELBv2Listener
+		)
+
+		BeforeEach(func() {
+			listenerArn = "arn:aws:elasticloadbalancing:us-east-1:123456789012:listener/app/test-lb/123456/789"
+			defaultCert = "arn:aws:acm:us-east-1:123456789012:certificate/default"
+			listener = &storage.ELBv2Listener{
+				ARN:             listenerArn,
+				LoadBalancerArn: "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/test-lb/123456",
+				Port:            443,
+				Protocol:        "HTTPS",
+				Certificates:    `[{"certificateArn":"` + defaultCert + `","isDefault":true}]`,
+			}
+			mockStore.On("GetListener", ctx, listenerArn).Return(listener, nil)
+			mockStore.On("UpdateListener", ctx, listener).Return(nil)
+		})
+
+		It("should add certificates for SNI and describe them with the default", func() {
+			apiCert := "arn:aws:acm:us-east-1:123456789012:certificate/api"
+			output, err := api.AddListenerCertificates(ctx, &generated_elbv2.AddListenerCertificatesInput{
+				ListenerArn:  listenerArn,
+				Certificates: []generated_elbv2.Certificate{{CertificateArn: &apiCert}, {CertificateArn: &apiCert}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output.Certificates).To(HaveLen(2))
+			Expect(*output.Certificates[0].IsDefault).To(BeFalse())
+
+			described, err := api.DescribeListenerCertificates(ctx, &generated_elbv2.DescribeListenerCertificatesInput{ListenerArn: listenerArn})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(described.Certificates).To(HaveLen(2))
+			Expect(*described.Certificates[0].CertificateArn).To(Equal(defaultCert))
+			Expect(*described.Certificates[0].IsDefault).To(BeTrue())
+			Expect(*described.Certificates[1].CertificateArn).To(Equal(apiCert))
+		})
+
+		It("should report only the default certificate on the listener", func() {
+			listener.Certificates = `[{"certificateArn":"` + defaultCert + `","isDefault":true},{"certificateArn":"other","isDefault":false}]`
+			converted := api.convertToListener(listener)
+			Expect(converted.Certificates).To(HaveLen(1))
+			Expect(*converted.Certificates[0].CertificateArn).To(Equal(defaultCert))
+		})
+
+		It("should reject certificates on HTTP listeners", func() {
+			listener.Protocol = "HTTP"
+			_, err := api.AddListenerCertificates(ctx, &generated_elbv2.AddListenerCertificatesInput{
+				ListenerArn:  listenerArn,
+				Certificates: []generated_elbv2.Certificate{{CertificateArn: utils.Ptr("arn:aws:acm:us-east-1:123456789012:certificate/api")}},
+			})
+			Expect(err).To(MatchError(ContainSubstring("HTTPS and TLS listeners")))
+		})
+
+		It("should remove certificates but not the default", func() {
+			listener.Certificates = `[{"certificateArn":"` + defaultCert + `","isDefault":true},{"certificateArn":"other","isDefault":false}]`
+
+			_, err := api.RemoveListenerCertificates(ctx, &generated_elbv2.RemoveListenerCertificatesInput{
+				ListenerArn:  listenerArn,
+				Certificates: []generated_elbv2.Certificate{{CertificateArn: utils.Ptr("other")}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(listener.Certificates).NotTo(ContainSubstring("other"))
+
+			_, err = api.RemoveListenerCertificates(ctx, &generated_elbv2.RemoveListenerCertificatesInput{
+				ListenerArn:  listenerArn,
+				Certificates: []generated_elbv2.Certificate{{CertificateArn: &defaultCert}},
+			})
+			Expect(err).To(MatchError(ContainSubstring("OperationNotPermitted")))
+		})
+
+		It("should replace the default certificate with ModifyListener", func() {
+			listener.Certificates = `[{"certificateArn":"` + defaultCert + `","isDefault":true},{"certificateArn":"other","isDefault":false}]`
+			mockIntegration.On("CreateListener", ctx, listener.LoadBalancerArn, int32(443), "HTTPS", "").Return(&elbv2.Listener{}, nil).Once()
+
+			output, err := api.ModifyListener(ctx, &generated_elbv2.ModifyListenerInput{
+				ListenerArn:  listenerArn,
+				Certificates: []generated_elbv2.Certificate{{CertificateArn: utils.Ptr("replacement")}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*output.Listeners[0].Certificates[0].CertificateArn).To(Equal("replacement"))
+
+			described, err := api.DescribeListenerCertificates(ctx, &generated_elbv2.DescribeListenerCertificatesInput{ListenerArn: listenerArn})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(described.Certificates).To(HaveLen(2))
+			Expect(*described.Certificates[1].CertificateArn).To(Equal("other"))
+		})
+	})
+
+	Describe("Load balancer attributes", func() {
+		var (
+			lbArn string
+			lb    *storage.ELBv2LoadBalancer
+		)
+
+		BeforeEach(func() {
+			lbArn = "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/test-lb/123456"
+			lb = &storage.ELBv2LoadBalancer{ARN: lbArn, Name: "test-lb", DNSName: "test-lb.example.com"}
+		})
+
+		attributeValue := func(attributes []generated_elbv2.LoadBalancerAttribute, key string) string {
+			for _, attr := range attributes {
+				if *attr.Key == key {
+					return *attr.Value
+				}
+			}
+			return ""
+		}
+
+		It("should persist access log attributes", func() {
+			mockStore.On("GetLoadBalancer", ctx, lbArn).Return(lb, nil).Once()
+			mockStore.On("UpdateLoadBalancer", ctx, mock.MatchedBy(func(l *storage.ELBv2LoadBalancer) bool {
+				return l.Attributes["access_logs.s3.enabled"] == "true" && l.Attributes["access_logs.s3.bucket"] == "alb-logs"
+			})).Return(nil).Once()
+
+			output, err := api.ModifyLoadBalancerAttributes(ctx, &generated_elbv2.ModifyLoadBalancerAttributesInput{
+				LoadBalancerArn: lbArn,
+				Attributes: []generated_elbv2.LoadBalancerAttribute{
+					{Key: utils.Ptr("access_logs.s3.enabled"), Value: utils.Ptr("true")},
+					{Key: utils.Ptr("access_logs.s3.bucket"), Value: utils.Ptr("alb-logs")},
+				},
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(attributeValue(output.Attributes, "access_logs.s3.bucket")).To(Equal("alb-logs"))
+			Expect(attributeValue(output.Attributes, "idle_timeout.timeout_seconds")).To(Equal("60"))
+			mockStore.AssertExpectations(GinkgoT())
+		})
+
+		It("should describe stored attributes merged with the defaults", func() {
+			lb.Attributes = map[string]string{"access_logs.s3.enabled": "true", "access_logs.s3.bucket": "alb-logs"}
+			mockStore.On("GetLoadBalancer", ctx, lbArn).Return(lb, nil).Once()
+
+			output, err := api.DescribeLoadBalancerAttributes(ctx, &generated_elbv2.DescribeLoadBalancerAttributesInput{
+				LoadBalancerArn: lbArn,
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(attributeValue(output.Attributes, "access_logs.s3.enabled")).To(Equal("true"))
+			Expect(attributeValue(output.Attributes, "deletion_protection.enabled")).To(Equal("false"))
+		})
+
+		It("should reject enabling access logs without a bucket", func() {
+			mockStore.On("GetLoadBalancer", ctx, lbArn).Return(lb, nil).Once()
+
+			_, err := api.ModifyLoadBalancerAttributes(ctx, &generated_elbv2.ModifyLoadBalancerAttributesInput{
+				LoadBalancerArn: lbArn,
+				Attributes: []generated_elbv2.LoadBalancerAttribute{
+					{Key: utils.Ptr("access_logs.s3.enabled"), Value: utils.Ptr("true")},
+				},
+			})
+
+			Expect(err).To(MatchError(ContainSubstring("invalid access log configuration")))
+			mockStore.AssertNotCalled(GinkgoT(), "UpdateLoadBalancer", mock.Anything, mock.Anything)
+		})
+	})
 })
 
 // Helper functions
@@ -12,7 +12,9 @@ import (
 	"time"
 
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated_elbv2"
+	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/elbv2"
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/utils"
 )
 
 // ELBv2RouterWrapper wraps the generated router to handle form data
@@ -31,6 +33,7 @@ type DescribeLoadBalancersResponse struct {
 
 type DescribeLoadBalancersResult struct {
 	LoadBalancers []LoadBalancer `xml:"LoadBalancers>member"`
+	NextMarker    *string        `xml:"NextMarker,omitempty"`
 }
 
 type CreateLoadBalancerResponse struct {
@@ -86,6 +89,7 @@ type DescribeTargetGroupsResponse struct {
 
 type DescribeTargetGroupsResult struct {
 	TargetGroups []TargetGroup `xml:"TargetGroups>member"`
+	NextMarker   *string       `xml:"NextMarker,omitempty"`
 }
 
 type TargetGroup struct {
@@ -117,6 +121,17 @@ type CreateListenerResult struct {
 	Listeners []Listener `xml:"Listeners>member"`
 }
 
+type ModifyListenerResponse struct {
+	XMLName          xml.Name             `xml:"ModifyListenerResponse"`
+	XMLNS            string               `xml:"xmlns,attr"`
+	Result           ModifyListenerResult `xml:"ModifyListenerResult"`
+	ResponseMetadata ResponseMetadata     `xml:"ResponseMetadata"`
+}
+
+type ModifyListenerResult struct {
+	Listeners []Listener `xml:"Listeners>member"`
+}
+
 type DescribeListenersResponse struct {
 	XMLName          xml.Name                `xml:"DescribeListenersResponse"`
 	XMLNS            string                  `xml:"xmlns,attr"`
@@ -125,7 +140,8 @@ type DescribeListenersResponse struct {
 }
 
 type DescribeListenersResult struct {
-	Listeners []Listener `xml:"Listeners>member"`
+	Listeners  []Listener `xml:"Listeners>member"`
+	NextMarker *string    `xml:"NextMarker,omitempty"`
 }
 
 type Listener struct {
@@ -137,9 +153,43 @@ type Listener struct {
 }
 
 type Action struct {
-	Type           string `xml:"Type"`
+	Type           string         `xml:"Type"`
+	TargetGroupArn string         `xml:"TargetGroupArn,omitempty"`
+	Order          int32          `xml:"Order,omitempty"`
+	ForwardConfig  *ForwardConfig `xml:"ForwardConfig,omitempty"`
+
+	RedirectConfig      *RedirectConfig      `xml:"RedirectConfig,omitempty"`
+	FixedResponseConfig *FixedResponseConfig `xml:"FixedResponseConfig,omitempty"`
+}
+
+type RedirectConfig struct {
+	Protocol   string `xml:"Protocol,omitempty"`
+	Host       string `xml:"Host,omitempty"`
+	Port       string `xml:"Port,omitempty"`
+	Path       string `xml:"Path,omitempty"`
+	Query      string `xml:"Query,omitempty"`
+	StatusCode string `xml:"StatusCode"`
+}
+
+type FixedResponseConfig struct {
+	StatusCode  string `xml:"StatusCode"`
+	ContentType string `xml:"ContentType,omitempty"`
+	MessageBody string `xml:"MessageBody,omitempty"`
+}
+
+type ForwardConfig struct {
+	TargetGroups                []TargetGroupTuple           `xml:"TargetGroups>member"`
+	TargetGroupStickinessConfig *TargetGroupStickinessConfig `xml:"TargetGroupStickinessConfig,omitempty"`
+}
+
+type TargetGroupTuple struct {
 	TargetGroupArn string `xml:"TargetGroupArn"`
-	Order          int32  `xml:"Order,omitempty"`
+	Weight         *int32 `xml:"Weight,omitempty"`
+}
+
+type TargetGroupStickinessConfig struct {
+	Enabled         bool   `xml:"Enabled"`
+	DurationSeconds *int32 `xml:"DurationSeconds,omitempty"`
 }
 
 // RegisterTargets response structures
@@ -262,7 +312,8 @@ type HostHeaderConfig struct {
 }
 
 type HttpHeaderConfig struct {
-	Values []string `xml:"Values>member"`
+	HttpHeaderName string   `xml:"HttpHeaderName"`
+	Values         []string `xml:"Values>member"`
 }
 
 type HttpRequestMethodConfig struct {
@@ -282,6 +333,37 @@ type SourceIpConfig struct {
 	Values []string `xml:"Values>member"`
 }
 
+// ModifyRule response structures
+type ModifyRuleResponse struct {
+	XMLName          xml.Name         `xml:"ModifyRuleResponse"`
+	XMLNS            string           `xml:"xmlns,attr"`
+	Result           ModifyRuleResult `xml:"ModifyRuleResult"`
+	ResponseMetadata ResponseMetadata `xml:"ResponseMetadata"`
+}
+
+type ModifyRuleResult struct {
+	Rules []Rule `xml:"Rules>member"`
+}
+
+// DeleteRule response structures
+type DeleteRuleResponse struct {
+	XMLName          xml.Name         `xml:"DeleteRuleResponse"`
+	XMLNS            string           `xml:"xmlns,attr"`
+	ResponseMetadata ResponseMetadata `xml:"ResponseMetadata"`
+}
+
+// SetRulePriorities response structures
+type SetRulePrioritiesResponse struct {
+	XMLName          xml.Name                `xml:"SetRulePrioritiesResponse"`
+	XMLNS            string                  `xml:"xmlns,attr"`
+	Result           SetRulePrioritiesResult `xml:"SetRulePrioritiesResult"`
+	ResponseMetadata ResponseMetadata        `xml:"ResponseMetadata"`
+}
+
+type SetRulePrioritiesResult struct {
+	Rules []Rule `xml:"Rules>member"`
+}
+
 // DescribeRules response structures
 type DescribeRulesResponse struct {
 	XMLName          xml.Name            `xml:"DescribeRulesResponse"`
@@ -506,14 +588,9 @@ func (w *ELBv2RouterWrapper) Route(resp http.ResponseWriter, req *http.Request)
 				}
 			}
 
-			// Parse Marker if present
-			if marker := values.Get("Marker"); marker != "" {
-				input.Marker = &marker
-			}
-
-			// Parse PageSize if present
-			if pageSize := values.Get("PageSize"); pageSize != "" {
-				// Note: Would need to convert string to int32
+			if err := w.parsePagination(values, &input.Marker, &input.PageSize); err != nil {
+				w.writeError(resp, http.StatusBadRequest, "ValidationError", err.Error())
+				return
 			}
 
 			// Call the API directly
@@ -754,6 +831,11 @@ func (w *ELBv2RouterWrapper) Route(resp http.ResponseWriter, req *http.Request)
 				}
 			}
 
+			if err := w.parsePagination(values, &input.Marker, &input.PageSize); err != nil {
+				w.writeError(resp, http.StatusBadRequest, "ValidationError", err.Error())
+				return
+			}
+
 			// Call the API
 			output, err := w.api.DescribeTargetGroups(req.Context(), input)
 			if err != nil {
@@ -787,37 +869,47 @@ func (w *ELBv2RouterWrapper) Route(resp http.ResponseWriter, req *http.Request)
 			}
 
 			// Parse DefaultActions
-			if actionType := values.Get("DefaultActions.member.1.Type"); actionType != "" {
-				actions := []generated_elbv2.Action{}
-				for i := 1; ; i++ {
-					typeKey := fmt.Sprintf("DefaultActions.member.%d.Type", i)
-					if actionType := values.Get(typeKey); actionType == "" {
-						break
-					} else {
-						action := generated_elbv2.Action{}
-						actionTypeEnum := generated_elbv2.ActionTypeEnum(actionType)
-						action.Type = actionTypeEnum
+			input.DefaultActions = w.parseRuleActions(values, "DefaultActions")
 
-						// Parse TargetGroupArn for forward action
-						if tgArn := values.Get(fmt.Sprintf("DefaultActions.member.%d.TargetGroupArn", i)); tgArn != "" {
-							action.TargetGroupArn = &tgArn
-						}
+			// Call the API
+			output, err := w.api.CreateListener(req.Context(), input)
+			if err != nil {
+				w.writeAPIError(resp, err)
+				return
+			}
 
-						actions = append(actions, action)
-					}
-				}
+			// Convert to XML response
+			xmlResp := w.convertCreateListenerToXML(output)
+			w.writeXML(resp, xmlResp)
+			return
+
+		case "ModifyListener":
+			// Convert form data to ModifyListenerInput
+			input := &generated_elbv2.ModifyListenerInput{
+				ListenerArn: values.Get("ListenerArn"),
+			}
+
+			if protocol := values.Get("Protocol"); protocol != "" {
+				protocolEnum := generated_elbv2.ProtocolEnum(protocol)
+				input.Protocol = &protocolEnum
+			}
+			if portStr := values.Get("Port"); portStr != "" {
+				port, _ := strconv.Atoi(portStr)
+				input.Port = utils.Ptr(int32(port))
+			}
+			if actions := w.parseRuleActions(values, "DefaultActions"); len(actions) > 0 {
 				input.DefaultActions = actions
 			}
 
 			// Call the API
-			output, err := w.api.CreateListener(req.Context(), input)
+			output, err := w.api.ModifyListener(req.Context(), input)
 			if err != nil {
 				w.writeAPIError(resp, err)
 				return
 			}
 
 			// Convert to XML response
-			xmlResp := w.convertCreateListenerToXML(output)
+			xmlResp := w.convertModifyListenerToXML(output)
 			w.writeXML(resp, xmlResp)
 			return
 
@@ -843,6 +935,11 @@ func (w *ELBv2RouterWrapper) Route(resp http.ResponseWriter, req *http.Request)
 				}
 			}
 
+			if err := w.parsePagination(values, &input.Marker, &input.PageSize); err != nil {
+				w.writeError(resp, http.StatusBadRequest, "ValidationError", err.Error())
+				return
+			}
+
 			// Call the API
 			output, err := w.api.DescribeListeners(req.Context(), input)
 			if err != nil {
@@ -1024,77 +1121,9 @@ func (w *ELBv2RouterWrapper) Route(resp http.ResponseWriter, req *http.Request)
 				input.Priority = int32(priority)
 			}
 
-			// Parse Conditions
-			conditions := []generated_elbv2.RuleCondition{}
-			for i := 1; ; i++ {
-				fieldKey := fmt.Sprintf("Conditions.member.%d.Field", i)
-				if field := values.Get(fieldKey); field != "" {
-					condition := generated_elbv2.RuleCondition{
-						Field: &field,
-					}
-
-					// Parse Values for this condition
-					conditionValues := []string{}
-					for j := 1; ; j++ {
-						valueKey := fmt.Sprintf("Conditions.member.%d.Values.member.%d", i, j)
-						if value := values.Get(valueKey); value != "" {
-							conditionValues = append(conditionValues, value)
-						} else {
-							break
-						}
-					}
-					condition.Values = conditionValues
-
-					// Parse PathPatternConfig if present
-					if pathValues := values.Get(fmt.Sprintf("Conditions.member.%d.PathPatternConfig.Values.member.1", i)); pathValues != "" {
-						pathPatternValues := []string{}
-						for j := 1; ; j++ {
-							pathKey := fmt.Sprintf("Conditions.member.%d.PathPatternConfig.Values.member.%d", i, j)
-							if value := values.Get(pathKey); value != "" {
-								pathPatternValues = append(pathPatternValues, value)
-							} else {
-								break
-							}
-						}
-						condition.PathPatternConfig = &generated_elbv2.PathPatternConditionConfig{
-							Values: pathPatternValues,
-						}
-					}
-
-					conditions = append(conditions, condition)
-				} else {
-					break
-				}
-			}
-			input.Conditions = conditions
-
-			// Parse Actions
-			actions := []generated_elbv2.Action{}
-			for i := 1; ; i++ {
-				typeKey := fmt.Sprintf("Actions.member.%d.Type", i)
-				if actionType := values.Get(typeKey); actionType != "" {
-					action := generated_elbv2.Action{}
-					actionTypeEnum := generated_elbv2.ActionTypeEnum(actionType)
-					action.Type = actionTypeEnum
-
-					// Parse TargetGroupArn for forward action
-					if tgArn := values.Get(fmt.Sprintf("Actions.member.%d.TargetGroupArn", i)); tgArn != "" {
-						action.TargetGroupArn = &tgArn
-					}
-
-					// Parse Order if present
-					if orderStr := values.Get(fmt.Sprintf("Actions.member.%d.Order", i)); orderStr != "" {
-						order, _ := strconv.Atoi(orderStr)
-						order32 := int32(order)
-						action.Order = &order32
-					}
-
-					actions = append(actions, action)
-				} else {
-					break
-				}
-			}
-			input.Actions = actions
+			// Parse Conditions and Actions
+			input.Conditions = w.parseRuleConditions(values, "Conditions")
+			input.Actions = w.parseRuleActions(values, "Actions")
 
 			// Call the API
 			output, err := w.api.CreateRule(req.Context(), input)
@@ -1154,6 +1183,88 @@ func (w *ELBv2RouterWrapper) Route(resp http.ResponseWriter, req *http.Request)
 			w.writeXML(resp, xmlResp)
 			return
 
+		case "ModifyRule":
+			// Convert form data to ModifyRuleInput
+			input := &generated_elbv2.ModifyRuleInput{
+				RuleArn: values.Get("RuleArn"),
+			}
+
+			// Conditions and actions are only replaced when present
+			if conditions := w.parseRuleConditions(values, "Conditions"); len(conditions) > 0 {
+				input.Conditions = conditions
+			}
+			if actions := w.parseRuleActions(values, "Actions"); len(actions) > 0 {
+				input.Actions = actions
+			}
+
+			// Call the API
+			output, err := w.api.ModifyRule(req.Context(), input)
+			if err != nil {
+				w.writeAPIError(resp, err)
+				return
+			}
+
+			// Convert to XML response
+			xmlResp := &ModifyRuleResponse{
+				XMLNS:            "http://elasticloadbalancing.amazonaws.com/doc/2015-12-01/",
+				Result:           ModifyRuleResult{Rules: w.convertRulesToXML(output.Rules)},
+				ResponseMetadata: ResponseMetadata{RequestId: "generated-" + fmt.Sprintf("%d", time.Now().Unix())},
+			}
+			w.writeXML(resp, xmlResp)
+			return
+
+		case "DeleteRule":
+			// Convert form data to DeleteRuleInput
+			input := &generated_elbv2.DeleteRuleInput{
+				RuleArn: values.Get("RuleArn"),
+			}
+
+			// Call the API
+			if _, err := w.api.DeleteRule(req.Context(), input); err != nil {
+				w.writeAPIError(resp, err)
+				return
+			}
+
+			// Convert to XML response
+			xmlResp := &DeleteRuleResponse{
+				XMLNS:            "http://elasticloadbalancing.amazonaws.com/doc/2015-12-01/",
+				ResponseMetadata: ResponseMetadata{RequestId: "generated-" + fmt.Sprintf("%d", time.Now().Unix())},
+			}
+			w.writeXML(resp, xmlResp)
+			return
+
+		case "SetRulePriorities":
+			// Convert form data to SetRulePrioritiesInput
+			input := &generated_elbv2.SetRulePrioritiesInput{}
+			for i := 1; ; i++ {
+				ruleArn := values.Get(fmt.Sprintf("RulePriorities.member.%d.RuleArn", i))
+				if ruleArn == "" {
+					break
+				}
+				pair := generated_elbv2.RulePriorityPair{RuleArn: &ruleArn}
+				if priorityStr := values.Get(fmt.Sprintf("RulePriorities.member.%d.Priority", i)); priorityStr != "" {
+					priority, _ := strconv.Atoi(priorityStr)
+					pair.Priority = utils.Ptr(int32(priority))
+				}
+				input.RulePriorities = append(input.RulePriorities, pair)
+			}
+
+			// Call the API
+			output, err := w.api.SetRulePriorities(req.Context(), input)
+			if err != nil {
+				w.writeAPIError(resp, err)
+				return
+			}
+
+			// Convert to XML response
+			xmlResp := &SetRulePrioritiesResponse{
+				XMLNS:            "http://elasticloadbalancing.amazonaws.com/doc/2015-12-01/",
+				Result:           SetRulePrioritiesResult{Rules: w.convertRulesToXML(output.Rules)},
+				ResponseMetadata: ResponseMetadata{RequestId: "generated-" + fmt.Sprintf("%d", time.Now().Unix())},
+			}
+			w.writeXML(resp, xmlResp)
+			return
+
 		case "DescribeLoadBalancerAttributes":
 			// Convert form data to DescribeLoadBalancerAttributesInput
 			input := &generated_elbv2.DescribeLoadBalancerAttributesInput{}
@@ -1449,8 +1560,25 @@ func (w *ELBv2RouterWrapper) writeError(resp http.ResponseWriter, statusCode int
 
 // writeAPIError writes an API error response
 func (w *ELBv2RouterWrapper) writeAPIError(resp http.ResponseWriter, err error) {
-	// Default to internal server error
-	w.writeError(resp, http.StatusInternalServerError, "InternalError", err.Error())
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "rule not found"):
+		w.writeError(resp, http.StatusBadRequest, "RuleNotFound", msg)
+	case strings.Contains(msg, "listener not found"):
+		w.writeError(resp, http.StatusBadRequest, "ListenerNotFound", msg)
+	case strings.Contains(msg, "is already in use"):
+		w.writeError(resp, http.StatusBadRequest, "PriorityInUse", msg)
+	case strings.Contains(msg, "invalid rule conditions"), strings.Contains(msg, "invalid forward configuration"),
+		strings.Contains(msg, "priority must be between"):
+		w.writeError(resp, http.StatusBadRequest, "ValidationError", msg)
+	case strings.Contains(msg, "invalid marker"):
+		w.writeError(resp, http.StatusBadRequest, "ValidationError", msg)
+	case strings.Contains(msg, "invalid access log configuration"):
+		w.writeError(resp, http.StatusBadRequest, "InvalidConfigurationRequest", msg)
+	default:
+		// Default to internal server error
+		w.writeError(resp, http.StatusInternalServerError, "InternalError", msg)
+	}
 }
 
 // writeJSON writes a JSON response
@@ -1478,6 +1606,209 @@ func (w *ELBv2RouterWrapper) writeXML(resp http.ResponseWriter, data interface{}
 	}
 }
 
+// parsePagination parses the Marker and PageSize of a Describe request
+func (w *ELBv2RouterWrapper) parsePagination(values url.Values, marker **string, pageSize **int32) error {
+	if m := values.Get("Marker"); m != "" {
+		*marker = &m
+	}
+	if pageSizeStr := values.Get("PageSize"); pageSizeStr != "" {
+		size, err := strconv.ParseInt(pageSizeStr, 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid PageSize: %s", pageSizeStr)
+		}
+		*pageSize = utils.Ptr(int32(size))
+	}
+	return nil
+}
+
+// parseMemberValues parses a "<prefix>.member.N" list from form values
+func (w *ELBv2RouterWrapper) parseMemberValues(values url.Values, prefix string) []string {
+	var result []string
+	for i := 1; ; i++ {
+		value := values.Get(fmt.Sprintf("%s.member.%d", prefix, i))
+		if value == "" {
+			break
+		}
+		result = append(result, value)
+	}
+	return result
+}
+
+// parseRuleConditions parses rule conditions, both the legacy Field/Values
+// form and the typed condition configurations, from form values
+func (w *ELBv2RouterWrapper) parseRuleConditions(values url.Values, prefix string) []generated_elbv2.RuleCondition {
+	var conditions []generated_elbv2.RuleCondition
+	for i := 1; ; i++ {
+		memberPrefix := fmt.Sprintf("%s.member.%d.", prefix, i)
+		present := false
+		for key := range values {
+			if strings.HasPrefix(key, memberPrefix) {
+				present = true
+				break
+			}
+		}
+		if !present {
+			break
+		}
+
+		condition := generated_elbv2.RuleCondition{
+			Values: w.parseMemberValues(values, memberPrefix+"Values"),
+		}
+		if field := values.Get(memberPrefix + "Field"); field != "" {
+			condition.Field = &field
+		}
+		if v := w.parseMemberValues(values, memberPrefix+"PathPatternConfig.Values"); len(v) > 0 {
+			condition.PathPatternConfig = &generated_elbv2.PathPatternConditionConfig{Values: v}
+		}
+		if v := w.parseMemberValues(values, memberPrefix+"HostHeaderConfig.Values"); len(v) > 0 {
+			condition.HostHeaderConfig = &generated_elbv2.HostHeaderConditionConfig{Values: v}
+		}
+		if name := values.Get(memberPrefix + "HttpHeaderConfig.HttpHeaderName"); name != "" {
+			condition.HttpHeaderConfig = &generated_elbv2.HttpHeaderConditionConfig{
+				HttpHeaderName: &name,
+				Values:         w.parseMemberValues(values, memberPrefix+"HttpHeaderConfig.Values"),
+			}
+		}
+		if v := w.parseMemberValues(values, memberPrefix+"HttpRequestMethodConfig.Values"); len(v) > 0 {
+			condition.HttpRequestMethodConfig = &generated_elbv2.HttpRequestMethodConditionConfig{Values: v}
+		}
+		if v := w.parseMemberValues(values, memberPrefix+"SourceIpConfig.Values"); len(v) > 0 {
+			condition.SourceIpConfig = &generated_elbv2.SourceIpConditionConfig{Values: v}
+		}
+		var pairs []generated_elbv2.QueryStringKeyValuePair
+		for j := 1; ; j++ {
+			pairPrefix := fmt.Sprintf("%sQueryStringConfig.Values.member.%d.", memberPrefix, j)
+			key, hasKey := values[pairPrefix+"Key"]
+			value, hasValue := values[pairPrefix+"Value"]
+			if !hasKey && !hasValue {
+				break
+			}
+			pair := generated_elbv2.QueryStringKeyValuePair{}
+			if hasKey && key[0] != "" {
+				pair.Key = utils.Ptr(key[0])
+			}
+			if hasValue {
+				pair.Value = utils.Ptr(value[0])
+			}
+			pairs = append(pairs, pair)
+		}
+		if len(pairs) > 0 {
+			condition.QueryStringConfig = &generated_elbv2.QueryStringConditionConfig{Values: pairs}
+		}
+
+		conditions = append(conditions, condition)
+	}
+	return conditions
+}
+
+// parseRuleActions parses rule actions from form values
+func (w *ELBv2RouterWrapper) parseRuleActions(values url.Values, prefix string) []generated_elbv2.Action {
+	var actions []generated_elbv2.Action
+	for i := 1; ; i++ {
+		actionType := values.Get(fmt.Sprintf("%s.member.%d.Type", prefix, i))
+		if actionType == "" {
+			break
+		}
+		action := generated_elbv2.Action{
+			Type: generated_elbv2.ActionTypeEnum(actionType),
+		}
+
+		// Parse TargetGroupArn for forward action
+		if tgArn := values.Get(fmt.Sprintf("%s.member.%d.TargetGroupArn", prefix, i)); tgArn != "" {
+			action.TargetGroupArn = &tgArn
+		}
+
+		// Parse Order if present
+		if orderStr := values.Get(fmt.Sprintf("%s.member.%d.Order", prefix, i)); orderStr != "" {
+			order, _ := strconv.Atoi(orderStr)
+			order32 := int32(order)
+			action.Order = &order32
+		}
+
+		action.ForwardConfig = w.parseForwardConfig(values, fmt.Sprintf("%s.member.%d.ForwardConfig.", prefix, i))
+		action.RedirectConfig = parseRedirectConfig(values, fmt.Sprintf("%s.member.%d.RedirectConfig.", prefix, i))
+		action.FixedResponseConfig = parseFixedResponseConfig(values, fmt.Sprintf("%s.member.%d.FixedResponseConfig.", prefix, i))
+
+		actions = append(actions, action)
+	}
+	return actions
+}
+
+// parseForwardConfig parses the weighted target groups and stickiness of a forward action
+func (w *ELBv2RouterWrapper) parseForwardConfig(values url.Values, prefix string) *generated_elbv2.ForwardActionConfig {
+	var config *generated_elbv2.ForwardActionConfig
+	for i := 1; ; i++ {
+		memberPrefix := fmt.Sprintf("%sTargetGroups.member.%d.", prefix, i)
+		tgArn := values.Get(memberPrefix + "TargetGroupArn")
+		if tgArn == "" {
+			break
+		}
+		tuple := generated_elbv2.TargetGroupTuple{TargetGroupArn: utils.Ptr(tgArn)}
+		if weightStr := values.Get(memberPrefix + "Weight"); weightStr != "" {
+			weight, _ := strconv.Atoi(weightStr)
+			tuple.Weight = utils.Ptr(int32(weight))
+		}
+		if config == nil {
+			config = &generated_elbv2.ForwardActionConfig{}
+		}
+		config.TargetGroups = append(config.TargetGroups, tuple)
+	}
+
+	if enabledStr := values.Get(prefix + "TargetGroupStickinessConfig.Enabled"); enabledStr != "" {
+		if config == nil {
+			config = &generated_elbv2.ForwardActionConfig{}
+		}
+		stickiness := &generated_elbv2.TargetGroupStickinessConfig{
+			Enabled: utils.Ptr(enabledStr == "true"),
+		}
+		if durationStr := values.Get(prefix + "TargetGroupStickinessConfig.DurationSeconds"); durationStr != "" {
+			duration, _ := strconv.Atoi(durationStr)
+			stickiness.DurationSeconds = utils.Ptr(int32(duration))
+		}
+		config.TargetGroupStickinessConfig = stickiness
+	}
+	return config
+}
+
+// parseRedirectConfig parses the redirect of a redirect action
+func parseRedirectConfig(values url.Values, prefix string) *generated_elbv2.RedirectActionConfig {
+	statusCode := values.Get(prefix + "StatusCode")
+	if statusCode == "" {
+		return nil
+	}
+	// Fields that are not set keep the value of the request
+	optional := func(field string) *string {
+		if value := values.Get(prefix + field); value != "" {
+			return &value
+		}
+		return nil
+	}
+	return &generated_elbv2.RedirectActionConfig{
+		Protocol:   optional("Protocol"),
+		Host:       optional("Host"),
+		Port:       optional("Port"),
+		Path:       optional("Path"),
+		Query:      optional("Query"),
+		StatusCode: generated_elbv2.RedirectActionStatusCodeEnum(statusCode),
+	}
+}
+
+// parseFixedResponseConfig parses the response of a fixed-response action
+func parseFixedResponseConfig(values url.Values, prefix string) *generated_elbv2.FixedResponseActionConfig {
+	statusCode := values.Get(prefix + "StatusCode")
+	if statusCode == "" {
+		return nil
+	}
+	config := &generated_elbv2.FixedResponseActionConfig{StatusCode: statusCode}
+	if contentType := values.Get(prefix + "ContentType"); contentType != "" {
+		config.ContentType = &contentType
+	}
+	if _, found := values[prefix+"MessageBody"]; found {
+		config.MessageBody = utils.Ptr(values.Get(prefix + "MessageBody"))
+	}
+	return config
+}
+
 // parseTargets parses target descriptions from form values
 func (w *ELBv2RouterWrapper) parseTargets(values url.Values) []generated_elbv2.TargetDescription {
 	targets := []generated_elbv2.TargetDescription{}
@@ -1518,6 +1849,10 @@ func (w *ELBv2RouterWrapper) convertDescribeLoadBalancersToXML(output *generated
 		},
 	}
 
+	if output != nil {
+		resp.Result.NextMarker = output.NextMarker
+	}
+
 	if output != nil && output.LoadBalancers != nil {
 		for _, lb := range output.LoadBalancers {
 			xmlLB := LoadBalancer{}
@@ -1688,6 +2023,10 @@ func (w *ELBv2RouterWrapper) convertDescribeTargetGroupsToXML(output *generated_
 		},
 	}
 
+	if output != nil {
+		resp.Result.NextMarker = output.NextMarker
+	}
+
 	if output != nil && output.TargetGroups != nil {
 		for _, tg := range output.TargetGroups {
 			xmlTG := TargetGroup{}
@@ -1749,33 +2088,8 @@ func (w *ELBv2RouterWrapper) convertCreateListenerToXML(output *generated_elbv2.
 		},
 	}
 
-	if output != nil && output.Listeners != nil {
-		for _, l := range output.Listeners {
-			xmlListener := Listener{}
-			if l.ListenerArn != nil {
-				xmlListener.ListenerArn = *l.ListenerArn
-			}
-			if l.LoadBalancerArn != nil {
-				xmlListener.LoadBalancerArn = *l.LoadBalancerArn
-			}
-			if l.Port != nil {
-				xmlListener.Port = int32(*l.Port)
-			}
-			if l.Protocol != nil {
-				xmlListener.Protocol = string(*l.Protocol)
-			}
-			if l.DefaultActions != nil {
-				for _, action := range l.DefaultActions {
-					xmlAction := Action{}
-					xmlAction.Type = string(action.Type)
-					if action.TargetGroupArn != nil {
-						xmlAction.TargetGroupArn = *action.TargetGroupArn
-					}
-					xmlListener.DefaultActions = append(xmlListener.DefaultActions, xmlAction)
-				}
-			}
-			resp.Result.Listeners = append(resp.Result.Listeners, xmlListener)
-		}
+	if output != nil {
+		resp.Result.Listeners = w.convertListenersToXML(output.Listeners)
 	}
 
 	return resp
@@ -1790,38 +2104,55 @@ func (w *ELBv2RouterWrapper) convertDescribeListenersToXML(output *generated_elb
 		},
 	}
 
-	if output != nil && output.Listeners != nil {
-		for _, l := range output.Listeners {
-			xmlListener := Listener{}
-			if l.ListenerArn != nil {
-				xmlListener.ListenerArn = *l.ListenerArn
-			}
-			if l.LoadBalancerArn != nil {
-				xmlListener.LoadBalancerArn = *l.LoadBalancerArn
-			}
-			if l.Port != nil {
-				xmlListener.Port = int32(*l.Port)
-			}
-			if l.Protocol != nil {
-				xmlListener.Protocol = string(*l.Protocol)
-			}
-			if l.DefaultActions != nil {
-				for _, action := range l.DefaultActions {
-					xmlAction := Action{}
-					xmlAction.Type = string(action.Type)
-					if action.TargetGroupArn != nil {
-						xmlAction.TargetGroupArn = *action.TargetGroupArn
-					}
-					xmlListener.DefaultActions = append(xmlListener.DefaultActions, xmlAction)
-				}
-			}
-			resp.Result.Listeners = append(resp.Result.Listeners, xmlListener)
-		}
+	if output != nil {
+		resp.Result.NextMarker = output.NextMarker
+		resp.Result.Listeners = w.convertListenersToXML(output.Listeners)
+	}
+
+	return resp
+}
+
+// convertModifyListenerToXML converts the API output to XML format
+func (w *ELBv2RouterWrapper) convertModifyListenerToXML(output *generated_elbv2.ModifyListenerOutput) *ModifyListenerResponse {
+	resp := &ModifyListenerResponse{
+		XMLNS: "http://elasticloadbalancing.amazonaws.com/doc/2015-12-01/",
+		ResponseMetadata: ResponseMetadata{
+			RequestId: "generated-" + fmt.Sprintf("%d", time.Now().Unix()),
+		},
+	}
+
+	if output != nil {
+		resp.Result.Listeners = w.convertListenersToXML(output.Listeners)
 	}
 
 	return resp
 }
 
+// convertListenersToXML converts API listeners to their XML representation
+func (w *ELBv2RouterWrapper) convertListenersToXML(listeners []generated_elbv2.Listener) []Listener {
+	var xmlListeners []Listener
+	for _, l := range listeners {
+		xmlListener := Listener{}
+		if l.ListenerArn != nil {
+			xmlListener.ListenerArn = *l.ListenerArn
+		}
+		if l.LoadBalancerArn != nil {
+			xmlListener.LoadBalancerArn = *l.LoadBalancerArn
+		}
+		if l.Port != nil {
+			xmlListener.Port = int32(*l.Port)
+		}
+		if l.Protocol != nil {
+			xmlListener.Protocol = string(*l.Protocol)
+		}
+		for _, action := range l.DefaultActions {
+			xmlListener.DefaultActions = append(xmlListener.DefaultActions, w.convertActionToXML(action))
+		}
+		xmlListeners = append(xmlListeners, xmlListener)
+	}
+	return xmlListeners
+}
+
 // convertRegisterTargetsToXML converts the API output to XML format
 func (w *ELBv2RouterWrapper) convertRegisterTargetsToXML(output *generated_elbv2.RegisterTargetsOutput) *RegisterTargetsResponse {
 	resp := &RegisterTargetsResponse{
@@ -1974,62 +2305,8 @@ func (w *ELBv2RouterWrapper) convertCreateRuleToXML(output *generated_elbv2.Crea
 		},
 	}
 
-	if output != nil && output.Rules != nil {
-		for _, rule := range output.Rules {
-			xmlRule := Rule{}
-			if rule.RuleArn != nil {
-				xmlRule.RuleArn = *rule.RuleArn
-			}
-			if rule.Priority != nil {
-				xmlRule.Priority = *rule.Priority
-			}
-			if rule.IsDefault != nil {
-				xmlRule.IsDefault = *rule.IsDefault
-			}
-
-			// Convert actions
-			for _, action := range rule.Actions {
-				xmlAction := Action{
-					Type: string(action.Type),
-				}
-				if action.TargetGroupArn != nil {
-					xmlAction.TargetGroupArn = *action.TargetGroupArn
-				}
-				if action.Order != nil {
-					xmlAction.Order = *action.Order
-				}
-				xmlRule.Actions = append(xmlRule.Actions, xmlAction)
-			}
-
-			// Convert conditions
-			for _, condition := range rule.Conditions {
-				xmlCondition := RuleCondition{}
-				if condition.Field != nil {
-					xmlCondition.Field = *condition.Field
-				}
-				if condition.Values != nil {
-					xmlCondition.Values = condition.Values
-				}
-
-				// Convert PathPatternConfig
-				if condition.PathPatternConfig != nil && condition.PathPatternConfig.Values != nil {
-					xmlCondition.PathPatternConfig = &PathPatternConfig{
-						Values: condition.PathPatternConfig.Values,
-					}
-				}
-
-				// Convert HostHeaderConfig
-				if condition.HostHeaderConfig != nil && condition.HostHeaderConfig.Values != nil {
-					xmlCondition.HostHeaderConfig = &HostHeaderConfig{
-						Values: condition.HostHeaderConfig.Values,
-					}
-				}
-
-				xmlRule.Conditions = append(xmlRule.Conditions, xmlCondition)
-			}
-
-			resp.Result.Rules = append(resp.Result.Rules, xmlRule)
-		}
+	if output != nil {
+		resp.Result.Rules = w.convertRulesToXML(output.Rules)
 	}
 
 	return resp
@@ -2048,67 +2325,140 @@ func (w *ELBv2RouterWrapper) convertDescribeRulesToXML(output *generated_elbv2.D
 		if output.NextMarker != nil {
 			resp.Result.NextMarker = output.NextMarker
 		}
+		resp.Result.Rules = w.convertRulesToXML(output.Rules)
+	}
 
-		if output.Rules != nil {
-			for _, rule := range output.Rules {
-				xmlRule := Rule{}
-				if rule.RuleArn != nil {
-					xmlRule.RuleArn = *rule.RuleArn
-				}
-				if rule.Priority != nil {
-					xmlRule.Priority = *rule.Priority
-				}
-				if rule.IsDefault != nil {
-					xmlRule.IsDefault = *rule.IsDefault
-				}
+	return resp
+}
 
-				// Convert actions
-				for _, action := range rule.Actions {
-					xmlAction := Action{
-						Type: string(action.Type),
-					}
-					if action.TargetGroupArn != nil {
-						xmlAction.TargetGroupArn = *action.TargetGroupArn
-					}
-					if action.Order != nil {
-						xmlAction.Order = *action.Order
-					}
-					xmlRule.Actions = append(xmlRule.Actions, xmlAction)
-				}
+// convertActionToXML converts an API action to its XML representation
+func (w *ELBv2RouterWrapper) convertActionToXML(action generated_elbv2.Action) Action {
+	xmlAction := Action{
+		Type: string(action.Type),
+	}
+	if action.TargetGroupArn != nil {
+		xmlAction.TargetGroupArn = *action.TargetGroupArn
+	}
+	if action.Order != nil {
+		xmlAction.Order = *action.Order
+	}
+	if action.ForwardConfig != nil {
+		forwardConfig := &ForwardConfig{}
+		for _, tg := range action.ForwardConfig.TargetGroups {
+			tuple := TargetGroupTuple{Weight: tg.Weight}
+			if tg.TargetGroupArn != nil {
+				tuple.TargetGroupArn = *tg.TargetGroupArn
+			}
+			forwardConfig.TargetGroups = append(forwardConfig.TargetGroups, tuple)
+		}
+		if stickiness := action.ForwardConfig.TargetGroupStickinessConfig; stickiness != nil {
+			forwardConfig.TargetGroupStickinessConfig = &TargetGroupStickinessConfig{
+				Enabled:         stickiness.Enabled != nil && *stickiness.Enabled,
+				DurationSeconds: stickiness.DurationSeconds,
+			}
+		}
+		xmlAction.ForwardConfig = forwardConfig
+	}
+	if redirect := action.RedirectConfig; redirect != nil {
+		xmlAction.RedirectConfig = &RedirectConfig{
+			Protocol:   utils.Deref(redirect.Protocol),
+			Host:       utils.Deref(redirect.Host),
+			Port:       utils.Deref(redirect.Port),
+			Path:       utils.Deref(redirect.Path),
+			Query:      utils.Deref(redirect.Query),
+			StatusCode: string(redirect.StatusCode),
+		}
+	}
+	if fixed := action.FixedResponseConfig; fixed != nil {
+		xmlAction.FixedResponseConfig = &FixedResponseConfig{
+			StatusCode:  fixed.StatusCode,
+			ContentType: utils.Deref(fixed.ContentType),
+			MessageBody: utils.Deref(fixed.MessageBody),
+		}
+	}
+	return xmlAction
+}
 
-				// Convert conditions
-				for _, condition := range rule.Conditions {
-					xmlCondition := RuleCondition{}
-					if condition.Field != nil {
-						xmlCondition.Field = *condition.Field
-					}
-					if condition.Values != nil {
-						xmlCondition.Values = condition.Values
-					}
+// convertRulesToXML converts API rules to their XML representation
+func (w *ELBv2RouterWrapper) convertRulesToXML(rules []generated_elbv2.Rule) []Rule {
+	var xmlRules []Rule
+	for _, rule := range rules {
+		xmlRule := Rule{}
+		if rule.RuleArn != nil {
+			xmlRule.RuleArn = *rule.RuleArn
+		}
+		if rule.Priority != nil {
+			xmlRule.Priority = *rule.Priority
+		}
+		if rule.IsDefault != nil {
+			xmlRule.IsDefault = *rule.IsDefault
+		}
 
-					// Convert PathPatternConfig
-					if condition.PathPatternConfig != nil && condition.PathPatternConfig.Values != nil {
-						xmlCondition.PathPatternConfig = &PathPatternConfig{
-							Values: condition.PathPatternConfig.Values,
-						}
-					}
+		// Convert actions
+		for _, action := range rule.Actions {
+			xmlRule.Actions = append(xmlRule.Actions, w.convertActionToXML(action))
+		}
 
-					// Convert HostHeaderConfig
-					if condition.HostHeaderConfig != nil && condition.HostHeaderConfig.Values != nil {
-						xmlCondition.HostHeaderConfig = &HostHeaderConfig{
-							Values: condition.HostHeaderConfig.Values,
-						}
-					}
+		// Convert conditions
+		for _, condition := range rule.Conditions {
+			xmlRule.Conditions = append(xmlRule.Conditions, w.convertRuleConditionToXML(condition))
+		}
 
-					xmlRule.Conditions = append(xmlRule.Conditions, xmlCondition)
-				}
+		xmlRules = append(xmlRules, xmlRule)
+	}
+	return xmlRules
+}
 
-				resp.Result.Rules = append(resp.Result.Rules, xmlRule)
+// convertRuleConditionToXML converts a single rule condition to its XML representation
+func (w *ELBv2RouterWrapper) convertRuleConditionToXML(condition generated_elbv2.RuleCondition) RuleCondition {
+	xmlCondition := RuleCondition{
+		Field:  elbv2.ConditionField(&condition),
+		Values: condition.Values,
+	}
+
+	if condition.PathPatternConfig != nil {
+		xmlCondition.PathPatternConfig = &PathPatternConfig{
+			Values: condition.PathPatternConfig.Values,
+		}
+	}
+	if condition.HostHeaderConfig != nil {
+		xmlCondition.HostHeaderConfig = &HostHeaderConfig{
+			Values: condition.HostHeaderConfig.Values,
+		}
+	}
+	if condition.HttpHeaderConfig != nil {
+		xmlCondition.HttpHeaderConfig = &HttpHeaderConfig{
+			Values: condition.HttpHeaderConfig.Values,
+		}
+		if condition.HttpHeaderConfig.HttpHeaderName != nil {
+			xmlCondition.HttpHeaderConfig.HttpHeaderName = *condition.HttpHeaderConfig.HttpHeaderName
+		}
+	}
+	if condition.HttpRequestMethodConfig != nil {
+		xmlCondition.HttpRequestMethodConfig = &HttpRequestMethodConfig{
+			Values: condition.HttpRequestMethodConfig.Values,
+		}
+	}
+	if condition.QueryStringConfig != nil {
+		xmlCondition.QueryStringConfig = &QueryStringConfig{}
+		for _, kv := range condition.QueryStringConfig.Values {
+			pair := QueryStringKeyValuePair{}
+			if kv.Key != nil {
+				pair.Key = *kv.Key
+			}
+			if kv.Value != nil {
+				pair.Value = *kv.Value
 			}
+			xmlCondition.QueryStringConfig.Values = append(xmlCondition.QueryStringConfig.Values, pair)
+		}
+	}
+	if condition.SourceIpConfig != nil {
+		xmlCondition.SourceIpConfig = &SourceIpConfig{
+			Values: condition.SourceIpConfig.Values,
 		}
 	}
 
-	return resp
+	return xmlCondition
 }
 
 // convertDescribeLoadBalancerAttributesToXML converts the API output to XML format
@@ -1,11 +1,13 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
 )
 
 // toECSError converts internal errors to appropriate ECS API errors
@@ -50,10 +52,21 @@ func toECSError(err error, operation string) error {
 	}
 }
 
+// toListError converts an error of a paginated storage list to the error of
+// the List operation, a nextToken that was not issued by it is a bad parameter
+func toListError(err error, resources string) error {
+	if errors.Is(err, storage.ErrInvalidNextToken) {
+		return &generated.InvalidParameterException{
+			Message: ptr.String(err.Error()),
+		}
+	}
+	return fmt.Errorf("failed to list %s: %w", resources, err)
+}
+
 // extractResourceName attempts to extract the resource name from error messages
 func extractResourceName(errStr string) string {
-	// Try to extract from ARN
-	if idx := strings.Index(errStr, "arn:aws:ecs:"); idx != -1 {
+	// Try to extract from an ECS ARN in any partition (aws, aws-cn, aws-us-gov)
+	if idx := strings.Index(errStr, "arn:aws"); idx != -1 && strings.Contains(errStr[idx:], ":ecs:") {
 		arn := errStr[idx:]
 		// Find the end of the ARN (usually ends with a quote or space)
 		endIdx := strings.IndexAny(arn, "\" \n")
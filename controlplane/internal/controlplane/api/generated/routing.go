@@ -248,6 +248,10 @@ func (r *Router) handleCreateCapacityProvider(w http.ResponseWriter, req *http.R
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.CreateCapacityProvider(req.Context(), &input)
@@ -270,6 +274,10 @@ func (r *Router) handleCreateCluster(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.CreateCluster(req.Context(), &input)
@@ -292,6 +300,10 @@ func (r *Router) handleCreateService(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.CreateService(req.Context(), &input)
@@ -314,6 +326,10 @@ func (r *Router) handleCreateTaskSet(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.CreateTaskSet(req.Context(), &input)
@@ -336,6 +352,10 @@ func (r *Router) handleDeleteAccountSetting(w http.ResponseWriter, req *http.Req
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.DeleteAccountSetting(req.Context(), &input)
@@ -358,6 +378,10 @@ func (r *Router) handleDeleteAttributes(w http.ResponseWriter, req *http.Request
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.DeleteAttributes(req.Context(), &input)
@@ -380,6 +404,10 @@ func (r *Router) handleDeleteCapacityProvider(w http.ResponseWriter, req *http.R
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.DeleteCapacityProvider(req.Context(), &input)
@@ -402,6 +430,10 @@ func (r *Router) handleDeleteCluster(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.DeleteCluster(req.Context(), &input)
@@ -424,6 +456,10 @@ func (r *Router) handleDeleteService(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.DeleteService(req.Context(), &input)
@@ -446,6 +482,10 @@ func (r *Router) handleDeleteTaskDefinitions(w http.ResponseWriter, req *http.Re
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.DeleteTaskDefinitions(req.Context(), &input)
@@ -468,6 +508,10 @@ func (r *Router) handleDeleteTaskSet(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.DeleteTaskSet(req.Context(), &input)
@@ -490,6 +534,10 @@ func (r *Router) handleDeregisterContainerInstance(w http.ResponseWriter, req *h
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.DeregisterContainerInstance(req.Context(), &input)
@@ -512,6 +560,10 @@ func (r *Router) handleDeregisterTaskDefinition(w http.ResponseWriter, req *http
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.DeregisterTaskDefinition(req.Context(), &input)
@@ -534,6 +586,10 @@ func (r *Router) handleDescribeCapacityProviders(w http.ResponseWriter, req *htt
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.DescribeCapacityProviders(req.Context(), &input)
@@ -556,6 +612,10 @@ func (r *Router) handleDescribeClusters(w http.ResponseWriter, req *http.Request
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.DescribeClusters(req.Context(), &input)
@@ -578,6 +638,10 @@ func (r *Router) handleDescribeContainerInstances(w http.ResponseWriter, req *ht
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.DescribeContainerInstances(req.Context(), &input)
@@ -600,6 +664,10 @@ func (r *Router) handleDescribeServiceDeployments(w http.ResponseWriter, req *ht
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.DescribeServiceDeployments(req.Context(), &input)
@@ -622,6 +690,10 @@ func (r *Router) handleDescribeServiceRevisions(w http.ResponseWriter, req *http
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.DescribeServiceRevisions(req.Context(), &input)
@@ -644,6 +716,10 @@ func (r *Router) handleDescribeServices(w http.ResponseWriter, req *http.Request
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.DescribeServices(req.Context(), &input)
@@ -666,6 +742,10 @@ func (r *Router) handleDescribeTaskDefinition(w http.ResponseWriter, req *http.R
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.DescribeTaskDefinition(req.Context(), &input)
@@ -688,6 +768,10 @@ func (r *Router) handleDescribeTaskSets(w http.ResponseWriter, req *http.Request
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.DescribeTaskSets(req.Context(), &input)
@@ -710,6 +794,10 @@ func (r *Router) handleDescribeTasks(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.DescribeTasks(req.Context(), &input)
@@ -732,6 +820,10 @@ func (r *Router) handleDiscoverPollEndpoint(w http.ResponseWriter, req *http.Req
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.DiscoverPollEndpoint(req.Context(), &input)
@@ -754,6 +846,10 @@ func (r *Router) handleExecuteCommand(w http.ResponseWriter, req *http.Request)
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.ExecuteCommand(req.Context(), &input)
@@ -776,6 +872,10 @@ func (r *Router) handleGetTaskProtection(w http.ResponseWriter, req *http.Reques
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.GetTaskProtection(req.Context(), &input)
@@ -798,6 +898,10 @@ func (r *Router) handleListAccountSettings(w http.ResponseWriter, req *http.Requ
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.ListAccountSettings(req.Context(), &input)
@@ -820,6 +924,10 @@ func (r *Router) handleListAttributes(w http.ResponseWriter, req *http.Request)
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.ListAttributes(req.Context(), &input)
@@ -842,6 +950,10 @@ func (r *Router) handleListClusters(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.ListClusters(req.Context(), &input)
@@ -864,6 +976,10 @@ func (r *Router) handleListContainerInstances(w http.ResponseWriter, req *http.R
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.ListContainerInstances(req.Context(), &input)
@@ -886,6 +1002,10 @@ func (r *Router) handleListServiceDeployments(w http.ResponseWriter, req *http.R
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.ListServiceDeployments(req.Context(), &input)
@@ -908,6 +1028,10 @@ func (r *Router) handleListServices(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.ListServices(req.Context(), &input)
@@ -930,6 +1054,10 @@ func (r *Router) handleListServicesByNamespace(w http.ResponseWriter, req *http.
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.ListServicesByNamespace(req.Context(), &input)
@@ -952,6 +1080,10 @@ func (r *Router) handleListTagsForResource(w http.ResponseWriter, req *http.Requ
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.ListTagsForResource(req.Context(), &input)
@@ -974,6 +1106,10 @@ func (r *Router) handleListTaskDefinitionFamilies(w http.ResponseWriter, req *ht
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.ListTaskDefinitionFamilies(req.Context(), &input)
@@ -996,6 +1132,10 @@ func (r *Router) handleListTaskDefinitions(w http.ResponseWriter, req *http.Requ
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.ListTaskDefinitions(req.Context(), &input)
@@ -1018,6 +1158,10 @@ func (r *Router) handleListTasks(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.ListTasks(req.Context(), &input)
@@ -1040,6 +1184,10 @@ func (r *Router) handlePutAccountSetting(w http.ResponseWriter, req *http.Reques
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.PutAccountSetting(req.Context(), &input)
@@ -1062,6 +1210,10 @@ func (r *Router) handlePutAccountSettingDefault(w http.ResponseWriter, req *http
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.PutAccountSettingDefault(req.Context(), &input)
@@ -1084,6 +1236,10 @@ func (r *Router) handlePutAttributes(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.PutAttributes(req.Context(), &input)
@@ -1106,6 +1262,10 @@ func (r *Router) handlePutClusterCapacityProviders(w http.ResponseWriter, req *h
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.PutClusterCapacityProviders(req.Context(), &input)
@@ -1128,6 +1288,10 @@ func (r *Router) handleRegisterContainerInstance(w http.ResponseWriter, req *htt
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.RegisterContainerInstance(req.Context(), &input)
@@ -1150,6 +1314,10 @@ func (r *Router) handleRegisterTaskDefinition(w http.ResponseWriter, req *http.R
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.RegisterTaskDefinition(req.Context(), &input)
@@ -1172,6 +1340,10 @@ func (r *Router) handleRunTask(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.RunTask(req.Context(), &input)
@@ -1194,6 +1366,10 @@ func (r *Router) handleStartTask(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.StartTask(req.Context(), &input)
@@ -1216,6 +1392,10 @@ func (r *Router) handleStopServiceDeployment(w http.ResponseWriter, req *http.Re
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.StopServiceDeployment(req.Context(), &input)
@@ -1238,6 +1418,10 @@ func (r *Router) handleStopTask(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.StopTask(req.Context(), &input)
@@ -1260,6 +1444,10 @@ func (r *Router) handleSubmitAttachmentStateChanges(w http.ResponseWriter, req *
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.SubmitAttachmentStateChanges(req.Context(), &input)
@@ -1282,6 +1470,10 @@ func (r *Router) handleSubmitContainerStateChange(w http.ResponseWriter, req *ht
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.SubmitContainerStateChange(req.Context(), &input)
@@ -1304,6 +1496,10 @@ func (r *Router) handleSubmitTaskStateChange(w http.ResponseWriter, req *http.Re
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.SubmitTaskStateChange(req.Context(), &input)
@@ -1326,6 +1522,10 @@ func (r *Router) handleTagResource(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.TagResource(req.Context(), &input)
@@ -1348,6 +1548,10 @@ func (r *Router) handleUntagResource(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.UntagResource(req.Context(), &input)
@@ -1370,6 +1574,10 @@ func (r *Router) handleUpdateCapacityProvider(w http.ResponseWriter, req *http.R
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.UpdateCapacityProvider(req.Context(), &input)
@@ -1392,6 +1600,10 @@ func (r *Router) handleUpdateCluster(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.UpdateCluster(req.Context(), &input)
@@ -1414,6 +1626,10 @@ func (r *Router) handleUpdateClusterSettings(w http.ResponseWriter, req *http.Re
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.UpdateClusterSettings(req.Context(), &input)
@@ -1436,6 +1652,10 @@ func (r *Router) handleUpdateContainerAgent(w http.ResponseWriter, req *http.Req
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.UpdateContainerAgent(req.Context(), &input)
@@ -1458,6 +1678,10 @@ func (r *Router) handleUpdateContainerInstancesState(w http.ResponseWriter, req
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.UpdateContainerInstancesState(req.Context(), &input)
@@ -1480,6 +1704,10 @@ func (r *Router) handleUpdateService(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.UpdateService(req.Context(), &input)
@@ -1502,6 +1730,10 @@ func (r *Router) handleUpdateServicePrimaryTaskSet(w http.ResponseWriter, req *h
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.UpdateServicePrimaryTaskSet(req.Context(), &input)
@@ -1524,6 +1756,10 @@ func (r *Router) handleUpdateTaskProtection(w http.ResponseWriter, req *http.Req
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.UpdateTaskProtection(req.Context(), &input)
@@ -1546,6 +1782,10 @@ func (r *Router) handleUpdateTaskSet(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.UpdateTaskSet(req.Context(), &input)
@@ -426,6 +426,8 @@ type Container struct {
 
 	Reason *string `json:"reason,omitempty"`
 
+	RestartCount *int32 `json:"restartCount,omitempty"`
+
 	RuntimeId *string `json:"runtimeId,omitempty"`
 
 	TaskArn *string `json:"taskArn,omitempty"`
@@ -0,0 +1,148 @@
+package api_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/mocks"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+var _ = Describe("List pagination", func() {
+	var (
+		ctx         context.Context
+		mockStorage *mocks.MockStorage
+		ecsAPI      generated.ECSAPIInterface
+		clusterARN  string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockStorage = mocks.NewMockStorage()
+		clusterStore := mocks.NewMockClusterStore()
+		mockStorage.SetClusterStore(clusterStore)
+		mockStorage.SetTaskStore(mocks.NewMockTaskStore())
+		mockStorage.SetTaskDefinitionStore(mocks.NewMockTaskDefinitionStore())
+
+		clusterARN = "arn:aws:ecs:us-east-1:000000000000:cluster/default"
+		Expect(clusterStore.Create(ctx, &storage.Cluster{
+			ARN:    clusterARN,
+			Name:   "default",
+			Status: "ACTIVE",
+		})).To(Succeed())
+
+		ecsAPI = api.NewDefaultECSAPIWithConfig(config.DefaultConfig(), mockStorage, "us-east-1", "000000000000")
+	})
+
+	Describe("ListTasks", func() {
+		BeforeEach(func() {
+			// Tasks started by one RunTask call share their creation time
+			createdAt := time.Now()
+			for i := 0; i < 23; i++ {
+				Expect(mockStorage.TaskStore().Create(ctx, &storage.Task{
+					ID:         fmt.Sprintf("task-%02d", i),
+					ARN:        fmt.Sprintf("arn:aws:ecs:us-east-1:000000000000:task/default/task-%02d", i),
+					ClusterARN: clusterARN,
+					CreatedAt:  createdAt.Add(-time.Duration(i/4) * time.Second),
+				})).To(Succeed())
+			}
+		})
+
+		It("returns every task exactly once across pages", func() {
+			var arns []string
+			var nextToken *string
+			pages := 0
+			for {
+				resp, err := ecsAPI.ListTasks(ctx, &generated.ListTasksRequest{
+					MaxResults: ptr.Int32(5),
+					NextToken:  nextToken,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(len(resp.TaskArns)).To(BeNumerically("<=", 5))
+				arns = append(arns, resp.TaskArns...)
+				pages++
+				if resp.NextToken == nil {
+					break
+				}
+				nextToken = resp.NextToken
+			}
+
+			Expect(pages).To(Equal(5))
+			Expect(arns).To(HaveLen(23))
+			seen := map[string]bool{}
+			for _, arn := range arns {
+				Expect(seen[arn]).To(BeFalse(), "duplicate task %s", arn)
+				seen[arn] = true
+			}
+		})
+
+		It("omits the token on an exactly full last page", func() {
+			resp, err := ecsAPI.ListTasks(ctx, &generated.ListTasksRequest{MaxResults: ptr.Int32(23)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.TaskArns).To(HaveLen(23))
+			Expect(resp.NextToken).To(BeNil())
+		})
+
+		It("rejects an invalid next token", func() {
+			_, err := ecsAPI.ListTasks(ctx, &generated.ListTasksRequest{NextToken: ptr.String("task-05")})
+			Expect(err).To(BeAssignableToTypeOf(&generated.InvalidParameterException{}))
+		})
+	})
+
+	Describe("ListTaskDefinitions", func() {
+		BeforeEach(func() {
+			for _, family := range []string{"web", "api"} {
+				for i := 0; i < 11; i++ {
+					_, err := mockStorage.TaskDefinitionStore().Register(ctx, &storage.TaskDefinition{Family: family})
+					Expect(err).NotTo(HaveOccurred())
+				}
+			}
+		})
+
+		It("pages through revisions in family and numeric revision order", func() {
+			var arns []string
+			var nextToken *string
+			for {
+				resp, err := ecsAPI.ListTaskDefinitions(ctx, &generated.ListTaskDefinitionsRequest{
+					MaxResults: ptr.Int32(4),
+					NextToken:  nextToken,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				arns = append(arns, resp.TaskDefinitionArns...)
+				if resp.NextToken == nil {
+					break
+				}
+				nextToken = resp.NextToken
+			}
+
+			Expect(arns).To(HaveLen(22))
+			Expect(arns[8]).To(HaveSuffix("task-definition/api:9"))
+			Expect(arns[9]).To(HaveSuffix("task-definition/api:10"))
+			Expect(arns[11]).To(HaveSuffix("task-definition/web:1"))
+		})
+	})
+	Context("ELBv2 Describe operations", func() {
+		It("should reject a PageSize that is not a number", func() {
+			router := api.NewELBv2RouterWrapper(api.NewELBv2API(mockStorage, nil, "us-east-1", "000000000000"))
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("Action=DescribeLoadBalancers&PageSize=ten"))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			rec := httptest.NewRecorder()
+			router.Route(rec, req)
+
+			Expect(rec.Code).To(Equal(http.StatusBadRequest))
+			Expect(rec.Body.String()).To(ContainSubstring("ValidationError"))
+			Expect(rec.Body.String()).To(ContainSubstring("invalid PageSize"))
+		})
+	})
+})
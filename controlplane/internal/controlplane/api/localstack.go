@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
 )
 
 // LocalStackStatus represents the status of LocalStack integration
@@ -164,7 +166,7 @@ func (s *Server) GetLocalStackDashboard(w http.ResponseWriter, r *http.Request)
 
 	// Count tasks using LocalStack
 	if s.storage != nil {
-		services, _, err := s.storage.ServiceStore().List(r.Context(), "", "", "", 0, "")
+		services, _, err := s.storage.ServiceStore().List(r.Context(), "", storage.ServiceFilters{}, 0, "")
 		if err == nil {
 			resourceMap := make(map[string]bool)
 
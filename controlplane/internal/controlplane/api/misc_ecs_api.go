@@ -2,25 +2,235 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/execcommand"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
 )
 
 // DiscoverPollEndpoint implements the DiscoverPollEndpoint operation
 func (api *DefaultECSAPI) DiscoverPollEndpoint(ctx context.Context, req *generated.DiscoverPollEndpointRequest) (*generated.DiscoverPollEndpointResponse, error) {
-	// TODO: Implement DiscoverPollEndpoint
-	return nil, fmt.Errorf("DiscoverPollEndpoint not implemented")
+	clusterName := "default"
+	if req.Cluster != nil && *req.Cluster != "" {
+		clusterName = extractClusterNameFromARN(*req.Cluster)
+	}
+	if cluster, err := api.storage.ClusterStore().Get(ctx, clusterName); err != nil || cluster == nil {
+		return nil, fmt.Errorf("cluster not found: %s", clusterName)
+	}
+
+	// Agents talk to KECS itself for polling, telemetry and Service Connect
+	endpoint := agentEndpoint()
+	return &generated.DiscoverPollEndpointResponse{
+		Endpoint:               ptr.String(endpoint),
+		TelemetryEndpoint:      ptr.String(endpoint),
+		ServiceConnectEndpoint: ptr.String(endpoint),
+	}, nil
+}
+
+// agentEndpoint returns the URL under which agents reach this control plane
+func agentEndpoint() string {
+	if endpoint := config.GetString("server.endpoint"); endpoint != "" {
+		return strings.TrimSuffix(endpoint, "/") + "/"
+	}
+	port := config.GetInt("server.port")
+	if port == 0 {
+		port = 8080
+	}
+	return fmt.Sprintf("http://localhost:%d/", port)
 }
 
-// ExecuteCommand implements the ExecuteCommand operation
+// ExecuteCommand implements the ExecuteCommand operation. The command runs in
+// the task's pod and its output is delivered to the destinations of the
+// cluster's executeCommandConfiguration.
 func (api *DefaultECSAPI) ExecuteCommand(ctx context.Context, req *generated.ExecuteCommandRequest) (*generated.ExecuteCommandResponse, error) {
-	// TODO: Implement ExecuteCommand
-	return nil, fmt.Errorf("ExecuteCommand not implemented")
+	if req.Task == "" {
+		return nil, fmt.Errorf("task is required")
+	}
+	if req.Command == "" {
+		return nil, fmt.Errorf("command is required")
+	}
+	if api.execCommandManager == nil {
+		return nil, fmt.Errorf("ExecuteCommand requires a Kubernetes connection")
+	}
+
+	clusterName := "default"
+	if req.Cluster != nil && *req.Cluster != "" {
+		clusterName = extractClusterNameFromARN(*req.Cluster)
+	}
+	cluster, err := api.storage.ClusterStore().Get(ctx, clusterName)
+	if err != nil || cluster == nil {
+		return nil, &generated.ClusterNotFoundException{
+			Message: ptr.String(fmt.Sprintf("Cluster not found: %s", clusterName)),
+		}
+	}
+
+	var task *storage.Task
+	if awsarn.IsService(req.Task, "ecs") {
+		task, err = api.storage.TaskStore().Get(ctx, "", req.Task)
+	} else {
+		task, err = api.storage.TaskStore().Get(ctx, cluster.ARN, req.Task)
+	}
+	if err != nil || task == nil {
+		return nil, &generated.InvalidParameterException{
+			Message: ptr.String(fmt.Sprintf("The specified task could not be found: %s", req.Task)),
+		}
+	}
+	if !task.EnableExecuteCommand {
+		return nil, &generated.InvalidParameterException{
+			Message: ptr.String("The execute command failed because execute command was not enabled when the task was run or the execute command agent isn't running. Wait and try again or run a new task with execute command enabled and try again."),
+		}
+	}
+	if task.LastStatus != "RUNNING" || task.PodName == "" {
+		return nil, &generated.InvalidParameterException{
+			Message: ptr.String("The execute command failed because the task is not running."),
+		}
+	}
+
+	container, err := selectExecuteCommandContainer(task, req.Container)
+	if err != nil {
+		return nil, err
+	}
+
+	logConfig := api.executeCommandLogConfig(ctx, cluster, task, ptr.ToString(container.Name))
+	session := api.execCommandManager.Start(execcommand.Target{
+		Namespace: task.Namespace,
+		PodName:   task.PodName,
+		Container: ptr.ToString(container.Name),
+	}, req.Command, logConfig)
+
+	logging.Info("Started ExecuteCommand session",
+		"session", session.ID, "task", task.ARN, "container", ptr.ToString(container.Name))
+
+	return &generated.ExecuteCommandResponse{
+		ClusterArn:    ptr.String(cluster.ARN),
+		ContainerArn:  container.ContainerArn,
+		ContainerName: container.Name,
+		Interactive:   ptr.Bool(req.Interactive),
+		TaskArn:       ptr.String(task.ARN),
+		Session: &generated.Session{
+			SessionId:  ptr.String(session.ID),
+			StreamUrl:  ptr.String(session.StreamURL),
+			TokenValue: ptr.String(session.Token),
+		},
+	}, nil
+}
+
+// selectExecuteCommandContainer picks the container a command runs in. The
+// container may only be omitted for tasks with a single container.
+func selectExecuteCommandContainer(task *storage.Task, name *string) (*generated.Container, error) {
+	var containers []generated.Container
+	if task.Containers != "" {
+		if err := json.Unmarshal([]byte(task.Containers), &containers); err != nil {
+			return nil, fmt.Errorf("failed to parse task containers: %w", err)
+		}
+	}
+
+	if name == nil || *name == "" {
+		if len(containers) != 1 {
+			return nil, &generated.InvalidParameterException{
+				Message: ptr.String("The container name must be specified for tasks with more than one container."),
+			}
+		}
+		return &containers[0], nil
+	}
+	for i := range containers {
+		if ptr.ToString(containers[i].Name) == *name {
+			return &containers[i], nil
+		}
+	}
+	return nil, &generated.InvalidParameterException{
+		Message: ptr.String(fmt.Sprintf("The specified container %s could not be found in the task.", *name)),
+	}
+}
+
+// executeCommandLogConfig resolves where session output goes. OVERRIDE uses
+// the log configuration of the cluster, DEFAULT the awslogs configuration of
+// the container and NONE disables session logging.
+func (api *DefaultECSAPI) executeCommandLogConfig(ctx context.Context, cluster *storage.Cluster, task *storage.Task, containerName string) execcommand.LogConfig {
+	mode := generated.ExecuteCommandLoggingDEFAULT
+	var execConfig *generated.ExecuteCommandConfiguration
+	if config, err := parseClusterConfiguration(cluster.Name, cluster.Configuration); err == nil && config != nil {
+		execConfig = config.ExecuteCommandConfiguration
+	}
+	if execConfig != nil && execConfig.Logging != nil {
+		mode = *execConfig.Logging
+	}
+
+	switch mode {
+	case generated.ExecuteCommandLoggingOVERRIDE:
+		if execConfig.LogConfiguration == nil {
+			return execcommand.LogConfig{}
+		}
+		return execcommand.LogConfig{
+			CloudWatchLogGroupName: ptr.ToString(execConfig.LogConfiguration.CloudWatchLogGroupName),
+			S3BucketName:           ptr.ToString(execConfig.LogConfiguration.S3BucketName),
+			S3KeyPrefix:            ptr.ToString(execConfig.LogConfiguration.S3KeyPrefix),
+		}
+	case generated.ExecuteCommandLoggingDEFAULT:
+		taskDef, err := api.storage.TaskDefinitionStore().GetByARN(ctx, task.TaskDefinitionARN)
+		if err != nil || taskDef == nil {
+			return execcommand.LogConfig{}
+		}
+		var containerDefs []generated.ContainerDefinition
+		if err := json.Unmarshal([]byte(taskDef.ContainerDefinitions), &containerDefs); err != nil {
+			return execcommand.LogConfig{}
+		}
+		for _, def := range containerDefs {
+			if ptr.ToString(def.Name) != containerName || def.LogConfiguration == nil {
+				continue
+			}
+			if def.LogConfiguration.LogDriver == generated.LogDriverAWSLOGS {
+				return execcommand.LogConfig{
+					CloudWatchLogGroupName: def.LogConfiguration.Options["awslogs-group"],
+				}
+			}
+		}
+	}
+	return execcommand.LogConfig{}
 }
 
 // SubmitAttachmentStateChanges implements the SubmitAttachmentStateChanges operation
 func (api *DefaultECSAPI) SubmitAttachmentStateChanges(ctx context.Context, req *generated.SubmitAttachmentStateChangesRequest) (*generated.SubmitAttachmentStateChangesResponse, error) {
-	// TODO: Implement SubmitAttachmentStateChanges
-	return nil, fmt.Errorf("SubmitAttachmentStateChanges not implemented")
+	if len(req.Attachments) == 0 {
+		return nil, fmt.Errorf("attachments is required")
+	}
+
+	clusterName := "default"
+	if req.Cluster != nil && *req.Cluster != "" {
+		clusterName = extractClusterNameFromARN(*req.Cluster)
+	}
+	cluster, err := api.storage.ClusterStore().Get(ctx, clusterName)
+	if err != nil || cluster == nil {
+		return nil, fmt.Errorf("cluster not found: %s", clusterName)
+	}
+
+	tasks, err := api.storage.TaskStore().List(ctx, cluster.ARN, storage.TaskFilters{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+	for _, task := range tasks {
+		matched, err := applyAttachmentStateChanges(task, req.Attachments)
+		if err != nil {
+			logging.Warn("Failed to apply attachment state change", "taskArn", task.ARN, "error", err)
+			continue
+		}
+		if !matched {
+			continue
+		}
+		task.Version++
+		if err := api.storage.TaskStore().Update(ctx, task); err != nil {
+			return nil, fmt.Errorf("failed to update task: %w", err)
+		}
+	}
+
+	return &generated.SubmitAttachmentStateChangesResponse{
+		Acknowledgment: ptr.String(stateChangeAcknowledgment),
+	}, nil
 }
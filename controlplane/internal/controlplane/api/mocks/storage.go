@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
 )
 
@@ -158,6 +160,12 @@ func (m *MockClusterStore) Create(ctx context.Context, cluster *storage.Cluster)
 func (m *MockClusterStore) Get(ctx context.Context, name string) (*storage.Cluster, error) {
 	cluster, exists := m.clusters[name]
 	if !exists {
+		// Clusters can be looked up by ARN as well, as in the real stores
+		for _, c := range m.clusters {
+			if c.ARN == name {
+				return c, nil
+			}
+		}
 		return nil, errors.New("cluster not found")
 	}
 	return cluster, nil
@@ -188,60 +196,13 @@ func (m *MockClusterStore) Delete(ctx context.Context, name string) error {
 }
 
 func (m *MockClusterStore) ListWithPagination(ctx context.Context, limit int, nextToken string) ([]*storage.Cluster, string, error) {
-	// Convert map to slice
 	var allClusters []*storage.Cluster
 	for _, cluster := range m.clusters {
 		allClusters = append(allClusters, cluster)
 	}
-
-	// Sort by ID for consistent ordering (matches DuckDB implementation)
-	sort.Slice(allClusters, func(i, j int) bool {
-		return allClusters[i].ID < allClusters[j].ID
-	})
-
-	// Find starting position based on nextToken
-	start := 0
-	if nextToken != "" {
-		// Validate token exists
-		tokenExists := false
-		for _, cluster := range allClusters {
-			if cluster.ID == nextToken {
-				tokenExists = true
-				break
-			}
-		}
-
-		// If token doesn't exist, return error (like DuckDB implementation)
-		if !tokenExists {
-			return nil, "", fmt.Errorf("invalid pagination token")
-		}
-
-		for i, cluster := range allClusters {
-			if cluster.ID > nextToken {
-				start = i
-				break
-			}
-		}
-	}
-
-	// Get the requested page
-	end := start + limit
-	if end > len(allClusters) {
-		end = len(allClusters)
-	}
-
-	result := allClusters[start:end]
-
-	// Determine next token
-	var newNextToken string
-	if limit > 0 && end < len(allClusters) {
-		// Use the last item's ID as the next token
-		if len(result) > 0 {
-			newNextToken = result[len(result)-1].ID
-		}
-	}
-
-	return result, newNextToken, nil
+	return storage.Paginate(allClusters, func(c *storage.Cluster) storage.PageCursor {
+		return storage.NewestFirstCursor(c.CreatedAt, c.ARN)
+	}, storage.NewestFirst, limit, nextToken)
 }
 
 // MockTaskDefinitionStore implements storage.TaskDefinitionStore for testing
@@ -265,7 +226,10 @@ func (m *MockTaskDefinitionStore) Register(ctx context.Context, taskDef *storage
 
 	// Assign revision number
 	revisions := m.taskDefsByFamily[taskDef.Family]
-	taskDef.Revision = len(revisions) + 1
+	taskDef.Revision = 1
+	if len(revisions) > 0 {
+		taskDef.Revision = revisions[len(revisions)-1].Revision + 1
+	}
 
 	// Set status to ACTIVE if not set
 	if taskDef.Status == "" {
@@ -305,36 +269,74 @@ func (m *MockTaskDefinitionStore) ListFamilies(ctx context.Context, familyPrefix
 	var families []*storage.TaskDefinitionFamily
 	for family, revisions := range m.taskDefsByFamily {
 		// Filter by family prefix
-		if familyPrefix == "" || strings.HasPrefix(family, familyPrefix) {
-			families = append(families, &storage.TaskDefinitionFamily{
-				Family:         family,
-				LatestRevision: len(revisions),
-			})
+		if familyPrefix != "" && !strings.HasPrefix(family, familyPrefix) {
+			continue
+		}
+		active := 0
+		for _, td := range revisions {
+			if td.Status == "ACTIVE" {
+				active++
+			}
+		}
+		if (status == "ACTIVE" && active == 0) || (status == "INACTIVE" && active > 0) {
+			continue
 		}
+		families = append(families, &storage.TaskDefinitionFamily{
+			Family:          family,
+			LatestRevision:  revisions[len(revisions)-1].Revision,
+			ActiveRevisions: active,
+		})
 	}
+	return storage.Paginate(families, func(f *storage.TaskDefinitionFamily) storage.PageCursor {
+		return storage.PageCursor{Family: f.Family}
+	}, storage.ByFamilyRevision, limit, nextToken)
+}
 
-	// Apply limit if specified
-	var newNextToken string
-	if limit > 0 && len(families) > limit {
-		families = families[:limit]
-		newNextToken = "next-token"
+func (m *MockTaskDefinitionStore) List(ctx context.Context, filters storage.TaskDefinitionFilters, limit int, nextToken string) ([]*storage.TaskDefinitionRevision, string, error) {
+	var result []*storage.TaskDefinitionRevision
+	for family, revisions := range m.taskDefsByFamily {
+		if filters.Family != "" && family != filters.Family {
+			continue
+		}
+		for _, td := range revisions {
+			if filters.Status != "" && td.Status != filters.Status {
+				continue
+			}
+			result = append(result, taskDefinitionRevision(td))
+		}
 	}
-
-	return families, newNextToken, nil
+	order := storage.ByFamilyRevision
+	if filters.Descending {
+		order = storage.ByFamilyRevisionDesc
+	}
+	return storage.Paginate(result, revisionCursor, order, limit, nextToken)
 }
 
 func (m *MockTaskDefinitionStore) ListRevisions(ctx context.Context, family string, status string, limit int, nextToken string) ([]*storage.TaskDefinitionRevision, string, error) {
-	revisions := m.taskDefsByFamily[family]
 	var result []*storage.TaskDefinitionRevision
-	for _, td := range revisions {
-		result = append(result, &storage.TaskDefinitionRevision{
-			ARN:      td.ARN,
-			Family:   td.Family,
-			Revision: td.Revision,
-			Status:   td.Status,
-		})
+	for _, td := range m.taskDefsByFamily[family] {
+		if status != "" && td.Status != status {
+			continue
+		}
+		rev := taskDefinitionRevision(td)
+		rev.DeregisteredAt = td.DeregisteredAt
+		result = append(result, rev)
+	}
+	return storage.Paginate(result, revisionCursor, storage.LatestRevisionFirst, limit, nextToken)
+}
+
+func taskDefinitionRevision(td *storage.TaskDefinition) *storage.TaskDefinitionRevision {
+	return &storage.TaskDefinitionRevision{
+		ARN:          td.ARN,
+		Family:       td.Family,
+		Revision:     td.Revision,
+		Status:       td.Status,
+		RegisteredAt: td.RegisteredAt,
 	}
-	return result, "", nil
+}
+
+func revisionCursor(rev *storage.TaskDefinitionRevision) storage.PageCursor {
+	return storage.PageCursor{Family: rev.Family, Revision: rev.Revision}
 }
 
 func (m *MockTaskDefinitionStore) Deregister(ctx context.Context, family string, revision int) error {
@@ -343,7 +345,26 @@ func (m *MockTaskDefinitionStore) Deregister(ctx context.Context, family string,
 	if !exists {
 		return errors.New("task definition not found")
 	}
+	now := time.Now()
 	taskDef.Status = "INACTIVE"
+	taskDef.DeregisteredAt = &now
+	return nil
+}
+
+func (m *MockTaskDefinitionStore) Purge(ctx context.Context, family string, revision int) error {
+	key := fmt.Sprintf("%s:%d", family, revision)
+	taskDef, exists := m.taskDefs[key]
+	if !exists || taskDef.Status != "INACTIVE" {
+		return storage.ErrResourceNotFound
+	}
+	delete(m.taskDefs, key)
+	revisions := m.taskDefsByFamily[family]
+	for i, td := range revisions {
+		if td.Revision == revision {
+			m.taskDefsByFamily[family] = append(revisions[:i:i], revisions[i+1:]...)
+			break
+		}
+	}
 	return nil
 }
 
@@ -356,8 +377,22 @@ func (m *MockTaskDefinitionStore) GetByARN(ctx context.Context, arn string) (*st
 	return nil, errors.New("task definition not found")
 }
 
-// MockServiceStore implements storage.ServiceStore for testing
+func (m *MockTaskDefinitionStore) Update(ctx context.Context, taskDef *storage.TaskDefinition) error {
+	for _, td := range m.taskDefs {
+		if td.ARN == taskDef.ARN {
+			td.Status = taskDef.Status
+			td.Tags = taskDef.Tags
+			return nil
+		}
+	}
+	return storage.ErrResourceNotFound
+}
+
+// MockServiceStore implements storage.ServiceStore for testing. Like the
+// cached Postgres store it hands out copies of the stored services, so that
+// concurrent updates of a service conflict as they do in production.
 type MockServiceStore struct {
+	mu       sync.Mutex
 	services map[string]*storage.Service
 }
 
@@ -368,6 +403,8 @@ func NewMockServiceStore() *MockServiceStore {
 }
 
 func (m *MockServiceStore) Create(ctx context.Context, service *storage.Service) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if m.services == nil {
 		m.services = make(map[string]*storage.Service)
 	}
@@ -375,48 +412,61 @@ func (m *MockServiceStore) Create(ctx context.Context, service *storage.Service)
 	if _, exists := m.services[key]; exists {
 		return errors.New("service already exists")
 	}
-	m.services[key] = service
+	service.Version = 1
+	m.services[key] = service.Copy()
 	return nil
 }
 
 func (m *MockServiceStore) Get(ctx context.Context, cluster, serviceName string) (*storage.Service, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	key := fmt.Sprintf("%s:%s", cluster, serviceName)
 	service, exists := m.services[key]
 	if !exists {
 		return nil, errors.New("service not found")
 	}
-	return service, nil
+	return service.Copy(), nil
 }
 
-func (m *MockServiceStore) List(ctx context.Context, cluster string, serviceName string, launchType string, limit int, nextToken string) ([]*storage.Service, string, error) {
+func (m *MockServiceStore) List(ctx context.Context, cluster string, filters storage.ServiceFilters, limit int, nextToken string) ([]*storage.Service, string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	var results []*storage.Service
 	for _, svc := range m.services {
 		// Apply filters
 		if cluster != "" && svc.ClusterARN != cluster {
 			continue
 		}
-		if serviceName != "" && svc.ServiceName != serviceName {
+		if !filters.Matches(svc) {
 			continue
 		}
-		if launchType != "" && svc.LaunchType != launchType {
-			continue
-		}
-		results = append(results, svc)
+		results = append(results, svc.Copy())
 	}
-	return results, "", nil
+	return storage.Paginate(results, func(svc *storage.Service) storage.PageCursor {
+		return storage.NewestFirstCursor(svc.CreatedAt, svc.ARN)
+	}, storage.NewestFirst, limit, nextToken)
 }
 
 func (m *MockServiceStore) Update(ctx context.Context, service *storage.Service) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	key := fmt.Sprintf("%s:%s", service.ClusterARN, service.ServiceName)
-	if _, exists := m.services[key]; !exists {
+	stored, exists := m.services[key]
+	if !exists {
 		return errors.New("service not found")
 	}
+	if stored.Version != service.Version {
+		return storage.ErrConflict
+	}
 	service.UpdatedAt = time.Now()
-	m.services[key] = service
+	service.Version++
+	m.services[key] = service.Copy()
 	return nil
 }
 
 func (m *MockServiceStore) Delete(ctx context.Context, cluster, serviceName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	key := fmt.Sprintf("%s:%s", cluster, serviceName)
 	if _, exists := m.services[key]; !exists {
 		return errors.New("service not found")
@@ -426,21 +476,43 @@ func (m *MockServiceStore) Delete(ctx context.Context, cluster, serviceName stri
 }
 
 func (m *MockServiceStore) GetByARN(ctx context.Context, arn string) (*storage.Service, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	for _, svc := range m.services {
 		if svc.ARN == arn {
-			return svc, nil
+			return svc.Copy(), nil
 		}
 	}
 	return nil, errors.New("service not found")
 }
 
+func (m *MockServiceStore) GetMany(ctx context.Context, cluster string, serviceNamesOrARNs []string) ([]*storage.Service, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var results []*storage.Service
+	for _, svc := range m.services {
+		if svc.ClusterARN != cluster {
+			continue
+		}
+		for _, identifier := range serviceNamesOrARNs {
+			if svc.ServiceName == identifier || svc.ARN == identifier {
+				results = append(results, svc.Copy())
+				break
+			}
+		}
+	}
+	return results, nil
+}
+
 func (m *MockServiceStore) DeleteMarkedForDeletion(ctx context.Context, clusterARN string, before time.Time) (int, error) {
 	// Mock implementation - just return 0 for tests
 	return 0, nil
 }
 
-// MockTaskStore implements storage.TaskStore for testing
+// MockTaskStore implements storage.TaskStore for testing. It is safe for
+// concurrent use, as RunTask creates tasks in parallel.
 type MockTaskStore struct {
+	mu    sync.Mutex
 	tasks map[string]*storage.Task
 }
 
@@ -451,6 +523,8 @@ func NewMockTaskStore() *MockTaskStore {
 }
 
 func (m *MockTaskStore) Create(ctx context.Context, task *storage.Task) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if m.tasks == nil {
 		m.tasks = make(map[string]*storage.Task)
 	}
@@ -463,8 +537,10 @@ func (m *MockTaskStore) Create(ctx context.Context, task *storage.Task) error {
 }
 
 func (m *MockTaskStore) Get(ctx context.Context, cluster, taskID string) (*storage.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	// Handle both short task ID and full ARN (like DuckDB implementation)
-	if strings.Contains(taskID, "arn:aws:ecs:") {
+	if awsarn.IsService(taskID, "ecs") {
 		// Full ARN provided - search by ARN
 		for _, task := range m.tasks {
 			if task.ARN == taskID {
@@ -490,6 +566,8 @@ func (m *MockTaskStore) Get(ctx context.Context, cluster, taskID string) (*stora
 }
 
 func (m *MockTaskStore) List(ctx context.Context, cluster string, filters storage.TaskFilters) ([]*storage.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	var results []*storage.Task
 	for _, task := range m.tasks {
 		if task.ClusterARN != cluster {
@@ -511,15 +589,15 @@ func (m *MockTaskStore) List(ctx context.Context, cluster string, filters storag
 		results = append(results, task)
 	}
 
-	// Apply MaxResults limit
-	if filters.MaxResults > 0 && len(results) > filters.MaxResults {
-		results = results[:filters.MaxResults]
-	}
-
-	return results, nil
+	results, _, err := storage.Paginate(results, func(task *storage.Task) storage.PageCursor {
+		return storage.NewestFirstCursor(task.CreatedAt, task.ARN)
+	}, storage.NewestFirst, filters.MaxResults, filters.NextToken)
+	return results, err
 }
 
 func (m *MockTaskStore) Update(ctx context.Context, task *storage.Task) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	key := fmt.Sprintf("%s:%s", task.ClusterARN, task.ID)
 	if _, exists := m.tasks[key]; !exists {
 		return errors.New("task not found")
@@ -529,6 +607,8 @@ func (m *MockTaskStore) Update(ctx context.Context, task *storage.Task) error {
 }
 
 func (m *MockTaskStore) Delete(ctx context.Context, cluster, taskID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	key := fmt.Sprintf("%s:%s", cluster, taskID)
 	if _, exists := m.tasks[key]; !exists {
 		return errors.New("task not found")
@@ -538,6 +618,8 @@ func (m *MockTaskStore) Delete(ctx context.Context, cluster, taskID string) erro
 }
 
 func (m *MockTaskStore) GetByARNs(ctx context.Context, arns []string) ([]*storage.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	var results []*storage.Task
 	for _, arn := range arns {
 		for _, task := range m.tasks {
@@ -550,7 +632,24 @@ func (m *MockTaskStore) GetByARNs(ctx context.Context, arns []string) ([]*storag
 	return results, nil
 }
 
+func (m *MockTaskStore) GetMany(ctx context.Context, cluster string, taskIDsOrARNs []string) ([]*storage.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var results []*storage.Task
+	for _, task := range m.tasks {
+		for _, identifier := range taskIDsOrARNs {
+			if task.ARN == identifier || (task.ClusterARN == cluster && task.ID == identifier) {
+				results = append(results, task)
+				break
+			}
+		}
+	}
+	return results, nil
+}
+
 func (m *MockTaskStore) CreateOrUpdate(ctx context.Context, task *storage.Task) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if m.tasks == nil {
 		m.tasks = make(map[string]*storage.Task)
 	}
@@ -560,6 +659,8 @@ func (m *MockTaskStore) CreateOrUpdate(ctx context.Context, task *storage.Task)
 }
 
 func (m *MockTaskStore) DeleteOlderThan(ctx context.Context, clusterARN string, before time.Time, status string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	count := 0
 	toDelete := []string{}
 	for key, task := range m.tasks {
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/types"
+)
+
+// usesFargate reports whether tasks run on Fargate with the launch type or
+// capacity provider strategy
+func usesFargate(launchType *generated.LaunchType, strategy []generated.CapacityProviderStrategyItem) bool {
+	if launchType != nil {
+		return *launchType == generated.LaunchTypeFARGATE
+	}
+	return slices.ContainsFunc(strategy, func(item generated.CapacityProviderStrategyItem) bool {
+		return converters.LaunchTypeForCapacityProvider(item.CapacityProvider) == string(generated.LaunchTypeFARGATE)
+	})
+}
+
+// validatePlatformVersion checks that the platform version of Fargate tasks
+// supports the features of their task definition and returns the version they
+// run with. The ephemeral storage override of RunTask takes precedence over
+// the task definition.
+func validatePlatformVersion(taskDef *storage.TaskDefinition, platformVersion *string, overrides *generated.TaskOverride) (string, error) {
+	var containerDefs []types.ContainerDefinition
+	if err := json.Unmarshal([]byte(taskDef.ContainerDefinitions), &containerDefs); err != nil {
+		return "", fmt.Errorf("failed to parse container definitions: %w", err)
+	}
+	var volumes []types.Volume
+	if taskDef.Volumes != "" {
+		if err := json.Unmarshal([]byte(taskDef.Volumes), &volumes); err != nil {
+			return "", fmt.Errorf("failed to parse volumes: %w", err)
+		}
+	}
+	var ephemeralStorage *types.EphemeralStorage
+	if taskDef.EphemeralStorage != "" {
+		if err := json.Unmarshal([]byte(taskDef.EphemeralStorage), &ephemeralStorage); err != nil {
+			return "", fmt.Errorf("failed to parse ephemeral storage: %w", err)
+		}
+	}
+	if overrides != nil && overrides.EphemeralStorage != nil {
+		sizeInGiB := int(overrides.EphemeralStorage.SizeInGiB)
+		ephemeralStorage = &types.EphemeralStorage{SizeInGiB: &sizeInGiB}
+	}
+
+	version := ptr.ToString(platformVersion)
+	if err := converters.ValidatePlatformVersion(version, containerDefs, volumes, ephemeralStorage); err != nil {
+		return "", &generated.InvalidParameterException{Message: ptr.String(err.Error())}
+	}
+	return converters.ResolvePlatformVersion(version)
+}
@@ -6,19 +6,24 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"net/url"
-	"strings"
 	"time"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/appconfig"
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsrequest"
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
 )
 
 // ProxyHandler handles routing requests to appropriate backends
 type ProxyHandler struct {
-	localStackURL   *url.URL
-	localStackProxy *httputil.ReverseProxy
-	ecsHandler      http.Handler
-	elbv2Handler    http.Handler
-	sdHandler       http.Handler // Service Discovery handler
+	localStackURL    *url.URL
+	localStackProxy  *httputil.ReverseProxy
+	ecsHandler       http.Handler
+	elbv2Handler     http.Handler
+	sdHandler        http.Handler // Service Discovery handler
+	ec2Handler       http.Handler // EC2 VPC/subnet handler (optional)
+	cfnHandler       http.Handler // CloudFormation stack handler (optional)
+	appConfigHandler http.Handler // AppConfig and AppConfig Data handler (optional)
+	stsHandler       http.Handler // STS AssumeRole and GetCallerIdentity handler (optional)
 }
 
 // NewProxyHandler creates a new proxy handler
@@ -64,6 +69,26 @@ func NewProxyHandler(localStackURL string, ecsHandler, elbv2Handler, sdHandler h
 	}, nil
 }
 
+// SetEC2Handler sets the handler for form-encoded EC2 requests
+func (h *ProxyHandler) SetEC2Handler(handler http.Handler) {
+	h.ec2Handler = handler
+}
+
+// SetCloudFormationHandler sets the handler for form-encoded CloudFormation requests
+func (h *ProxyHandler) SetCloudFormationHandler(handler http.Handler) {
+	h.cfnHandler = handler
+}
+
+// SetAppConfigHandler sets the handler for AppConfig and AppConfig Data requests
+func (h *ProxyHandler) SetAppConfigHandler(handler http.Handler) {
+	h.appConfigHandler = handler
+}
+
+// SetSTSHandler sets the handler for form-encoded STS requests
+func (h *ProxyHandler) SetSTSHandler(handler http.Handler) {
+	h.stsHandler = handler
+}
+
 // ServeHTTP implements http.Handler interface with simplified routing logic
 func (h *ProxyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Log incoming request
@@ -74,40 +99,31 @@ func (h *ProxyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		"content-type", r.Header.Get("Content-Type"),
 	)
 
-	// Simplified routing based on headers only (no body reading)
-
-	// Step 1: Check Content-Type for form-encoded APIs (ELBv2, EC2, RDS, etc.)
-	contentType := r.Header.Get("Content-Type")
-	if strings.Contains(contentType, "application/x-www-form-urlencoded") {
-		logging.Info("Routing to ELBv2 handler (form-encoded)", "path", r.URL.Path)
-		h.elbv2Handler.ServeHTTP(w, r)
-		return
-	}
-
-	// Step 2: Check X-Amz-Target header for service routing
-	target := r.Header.Get("X-Amz-Target")
-	if target != "" {
-		// ECS requests
-		if strings.HasPrefix(target, "AmazonEC2ContainerServiceV") {
-			logging.Debug("Routing to ECS handler", "target", target)
-			h.ecsHandler.ServeHTTP(w, r)
-			return
-		}
-
-		// Service Discovery requests
-		if strings.HasPrefix(target, "Route53AutoNaming_") {
-			logging.Debug("Routing to Service Discovery handler", "target", target)
-			h.sdHandler.ServeHTTP(w, r)
-			return
-		}
+	// Routing is shared with the middleware identifying AWS API calls, so
+	// that every call reaching a handler is authenticated and authorized as
+	// the service that serves it. It reads headers only, not the body.
+	service := awsrequest.RoutedService(r)
+	var handler http.Handler
+	switch service {
+	case appconfig.ServiceAppConfig, appconfig.ServiceAppConfigData:
+		// AppConfig is a REST protocol service, only its signing name tells its requests apart
+		handler = h.appConfigHandler
+	case awsrequest.ServiceEC2:
+		handler = h.ec2Handler
+	case awsrequest.ServiceCloudFormation:
+		handler = h.cfnHandler
+	case awsrequest.ServiceSTS:
+		handler = h.stsHandler
+	case awsrequest.ServiceELBv2:
+		handler = h.elbv2Handler
+	case awsrequest.ServiceECS:
+		handler = h.ecsHandler
+	case awsrequest.ServiceServiceDiscovery:
+		handler = h.sdHandler
 	}
-
-	// Step 3: Check path-based routing for non-root paths
-	path := r.URL.Path
-	if strings.HasPrefix(path, "/v1/") {
-		// ECS v1 API endpoints
-		logging.Debug("Routing to ECS handler (v1 path)", "path", path)
-		h.ecsHandler.ServeHTTP(w, r)
+	if handler != nil {
+		logging.Debug("Routing to API handler", "path", r.URL.Path, "service", service)
+		handler.ServeHTTP(w, r)
 		return
 	}
 
@@ -35,6 +35,14 @@ func TestProxyHandler_RouteIntegration(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create proxy handler: %v", err)
 	}
+	proxyHandler.SetAppConfigHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("AppConfig"))
+	}))
+	proxyHandler.SetSTSHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("STS"))
+	}))
 
 	tests := []struct {
 		name         string
@@ -60,6 +68,44 @@ func TestProxyHandler_RouteIntegration(t *testing.T) {
 			}(),
 			expectedBody: "ECS",
 		},
+		{
+			name: "ECS v1 path request should route to ECS",
+			request: func() *http.Request {
+				return httptest.NewRequest("POST", "/v1/ListClusters", bytes.NewReader([]byte("{}")))
+			}(),
+			expectedBody: "ECS",
+		},
+		{
+			name: "Form data request signed for another service should route to ELBv2",
+			request: func() *http.Request {
+				body := "Action=DeleteLoadBalancer&Version=2015-12-01"
+				req := httptest.NewRequest("POST", "/", bytes.NewReader([]byte(body)))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential=test/20240101/us-east-1/ecs/aws4_request, SignedHeaders=host, Signature=abc")
+				return req
+			}(),
+			expectedBody: "ELBv2",
+		},
+		{
+			name: "AppConfig Data request should route to AppConfig",
+			request: func() *http.Request {
+				req := httptest.NewRequest("GET", "/configuration?configuration_token=abc", nil)
+				req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential=test/20240101/us-east-1/appconfigdata/aws4_request, SignedHeaders=host, Signature=abc")
+				return req
+			}(),
+			expectedBody: "AppConfig",
+		},
+		{
+			name: "STS form data request should route to STS",
+			request: func() *http.Request {
+				body := "Action=GetCallerIdentity&Version=2011-06-15"
+				req := httptest.NewRequest("POST", "/", bytes.NewReader([]byte(body)))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential=test/20240101/us-east-1/sts/aws4_request, SignedHeaders=host, Signature=abc")
+				return req
+			}(),
+			expectedBody: "STS",
+		},
 	}
 
 	for _, tt := range tests {
@@ -7,13 +7,17 @@ import (
 	"github.com/nandemo-ya/kecs/controlplane/internal/config"
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/taskdefgc"
+	"github.com/nandemo-ya/kecs/controlplane/internal/usage"
 )
 
 // ResourceCleanupWorker manages cleanup of stale resources
 type ResourceCleanupWorker struct {
 	storage storage.Storage
-	ticker  *time.Ticker
-	done    chan struct{}
+	// usageLedger keeps the usage of stopped tasks after they are deleted
+	usageLedger *usage.Ledger
+	ticker      *time.Ticker
+	done        chan struct{}
 
 	// Configuration
 	enabled           bool
@@ -23,12 +27,15 @@ type ResourceCleanupWorker struct {
 	instanceRetention time.Duration
 	taskSetRetention  time.Duration
 	logRetention      time.Duration
+	taskDefGCEnabled  bool
+	taskDefGCPolicy   taskdefgc.Policy
 }
 
 // NewResourceCleanupWorker creates a new resource cleanup worker
-func NewResourceCleanupWorker(storage storage.Storage) *ResourceCleanupWorker {
+func NewResourceCleanupWorker(storage storage.Storage, usageLedger *usage.Ledger) *ResourceCleanupWorker {
 	return &ResourceCleanupWorker{
 		storage:           storage,
+		usageLedger:       usageLedger,
 		done:              make(chan struct{}),
 		enabled:           config.GetBool("cleanup.enabled"),
 		interval:          config.GetDuration("cleanup.interval", 5*time.Minute),
@@ -37,6 +44,8 @@ func NewResourceCleanupWorker(storage storage.Storage) *ResourceCleanupWorker {
 		instanceRetention: config.GetDuration("cleanup.containerInstance.retention", 1*time.Hour),
 		taskSetRetention:  config.GetDuration("cleanup.taskSet.retention", 24*time.Hour),
 		logRetention:      config.GetDuration("cleanup.log.retention", 7*24*time.Hour),
+		taskDefGCEnabled:  config.GetBool("cleanup.taskDefinition.enabled"),
+		taskDefGCPolicy:   taskdefgc.PolicyFromConfig(),
 	}
 }
 
@@ -57,6 +66,7 @@ func (w *ResourceCleanupWorker) Start(ctx context.Context) {
 			"instanceRetention", w.instanceRetention,
 			"taskSetRetention", w.taskSetRetention,
 			"logRetention", w.logRetention,
+			"taskDefinitionGC", w.taskDefGCEnabled,
 		)
 
 		// Run initial cleanup
@@ -121,6 +131,12 @@ func (w *ResourceCleanupWorker) cleanupResources(ctx context.Context) {
 		logging.Info("Resource cleanup worker: Deleted old logs", "count", count)
 	}
 
+	// Garbage collect task definition revisions
+	if count := w.cleanupTaskDefinitions(ctx); count > 0 {
+		totalDeleted += count
+		logging.Info("Resource cleanup worker: Collected task definition revisions", "count", count)
+	}
+
 	if totalDeleted > 0 {
 		logging.Info("Resource cleanup worker: Cleanup cycle completed", "totalDeleted", totalDeleted)
 	} else {
@@ -141,6 +157,8 @@ func (w *ResourceCleanupWorker) cleanupStoppedTasks(ctx context.Context) int {
 
 	totalDeleted := 0
 	for _, cluster := range clusters {
+		w.archiveTaskUsage(ctx, cluster, cutoff)
+
 		// Clean up STOPPED tasks
 		count, err := w.storage.TaskStore().DeleteOlderThan(ctx, cluster.ARN, cutoff, "STOPPED")
 		if err != nil {
@@ -161,9 +179,38 @@ func (w *ResourceCleanupWorker) cleanupStoppedTasks(ctx context.Context) int {
 		totalDeleted += count
 	}
 
+	if pruned := w.usageLedger.Prune(time.Now()); pruned > 0 {
+		logging.Debug("Resource cleanup worker: Pruned usage records", "count", pruned)
+	}
+
 	return totalDeleted
 }
 
+// archiveTaskUsage records the usage of the tasks of a cluster that are
+// about to be deleted, so that cost reports still include them
+func (w *ResourceCleanupWorker) archiveTaskUsage(ctx context.Context, cluster *storage.Cluster, cutoff time.Time) {
+	if w.usageLedger == nil {
+		return
+	}
+	tasks, err := w.storage.TaskStore().List(ctx, cluster.ARN, storage.TaskFilters{})
+	if err != nil {
+		logging.Warn("Resource cleanup worker: Failed to list tasks for usage records",
+			"cluster", cluster.Name, "error", err)
+		return
+	}
+	var records []usage.Record
+	for _, task := range tasks {
+		// The same tasks DeleteOlderThan removes below
+		if (task.LastStatus != "STOPPED" && task.LastStatus != "DEPROVISIONING") || !task.CreatedAt.Before(cutoff) {
+			continue
+		}
+		if record, ok := usage.RecordFromTask(task); ok && record.Stop != nil {
+			records = append(records, record)
+		}
+	}
+	w.usageLedger.Add(records...)
+}
+
 // cleanupDeletedServices removes services marked for deletion
 func (w *ResourceCleanupWorker) cleanupDeletedServices(ctx context.Context) int {
 	cutoff := time.Now().Add(-w.serviceRetention)
@@ -237,6 +284,37 @@ func (w *ResourceCleanupWorker) cleanupOrphanedTaskSets(ctx context.Context) int
 	return totalDeleted
 }
 
+// cleanupTaskDefinitions deregisters and purges task definition revisions
+// according to the garbage collection policy. A dry run only logs them.
+func (w *ResourceCleanupWorker) cleanupTaskDefinitions(ctx context.Context) int {
+	if !w.taskDefGCEnabled || w.storage.TaskDefinitionStore() == nil {
+		return 0
+	}
+
+	report, err := taskdefgc.Run(ctx, w.storage, w.taskDefGCPolicy, time.Now())
+	if err != nil {
+		logging.Error("Resource cleanup worker: Failed to collect task definition revisions", "error", err)
+		return 0
+	}
+
+	for _, action := range report.Actions {
+		switch {
+		case w.taskDefGCPolicy.DryRun:
+			logging.Info("Resource cleanup worker: Would collect task definition revision (dry run)",
+				"taskDefinition", action.TaskDefinitionARN, "action", action.Action)
+		case action.Error != "":
+			logging.Error("Resource cleanup worker: Failed to collect task definition revision",
+				"taskDefinition", action.TaskDefinitionARN, "action", action.Action, "error", action.Error)
+		}
+	}
+	for _, protection := range report.Protected {
+		logging.Debug("Resource cleanup worker: Task definition revision is protected",
+			"taskDefinition", protection.TaskDefinitionARN, "reason", protection.Reason)
+	}
+
+	return report.Applied()
+}
+
 // cleanupOldTaskLogs removes log entries older than retention period
 func (w *ResourceCleanupWorker) cleanupOldTaskLogs(ctx context.Context) int {
 	// TODO: Implement when TaskLogStore is available
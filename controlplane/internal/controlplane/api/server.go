@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -17,23 +18,43 @@ import (
 
 	"k8s.io/client-go/informers"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/appconfig"
+	"github.com/nandemo-ya/kecs/controlplane/internal/audit"
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
+	"github.com/nandemo-ya/kecs/controlplane/internal/chaos"
+	"github.com/nandemo-ya/kecs/controlplane/internal/cloudformation"
+	"github.com/nandemo-ya/kecs/controlplane/internal/compat"
 	apiconfig "github.com/nandemo-ya/kecs/controlplane/internal/config"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controllers/sync"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
-	_ "github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated_elbv2"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated_elbv2"
 	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
+	"github.com/nandemo-ya/kecs/controlplane/internal/execcommand"
+	"github.com/nandemo-ya/kecs/controlplane/internal/iampolicy"
+	"github.com/nandemo-ya/kecs/controlplane/internal/images"
 	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/cloudwatch"
 	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/elbv2"
 	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/iam"
 	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/s3"
 	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/secretsmanager"
 	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/ssm"
+	"github.com/nandemo-ya/kecs/controlplane/internal/kecsapi"
 	"github.com/nandemo-ya/kecs/controlplane/internal/kubernetes"
 	"github.com/nandemo-ya/kecs/controlplane/internal/localstack"
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
 	"github.com/nandemo-ya/kecs/controlplane/internal/middleware"
+	"github.com/nandemo-ya/kecs/controlplane/internal/notifications"
+	"github.com/nandemo-ya/kecs/controlplane/internal/ratelimit"
+	"github.com/nandemo-ya/kecs/controlplane/internal/readonly"
+	"github.com/nandemo-ya/kecs/controlplane/internal/recorder"
 	"github.com/nandemo-ya/kecs/controlplane/internal/servicediscovery"
+	"github.com/nandemo-ya/kecs/controlplane/internal/serviceevents"
+	"github.com/nandemo-ya/kecs/controlplane/internal/shutdown"
+	"github.com/nandemo-ya/kecs/controlplane/internal/sigv4"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/usage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/vpc"
 )
 
 // Server represents the HTTP API server for KECS Control Plane
@@ -50,6 +71,12 @@ type Server struct {
 	accountID                 string
 	testModeWorker            *TestModeTaskWorker
 	resourceCleanupWorker     *ResourceCleanupWorker
+	serviceReconciler         *ServiceReconciler
+	deploymentAlarmMonitor    *DeploymentAlarmMonitor
+	taskTimeoutMonitor        *TaskTimeoutMonitor
+	provisioningMonitor       *ServiceProvisioningMonitor
+	insightsCollector         *ContainerInsightsCollector
+	driftDetector             *DriftDetector
 	localStackManager         localstack.Manager
 	awsProxyRouter            *AWSProxyRouter
 	iamIntegration            iam.Integration
@@ -67,6 +94,47 @@ type Server struct {
 	informerFactory           informers.SharedInformerFactory
 	proxyHandler              *ProxyHandler // New unified proxy handler
 	kubeClient                k8s.Interface // Kubernetes client
+	vpcRegistry               *vpc.Registry // Simulated VPCs and subnets
+	serviceEvents             *serviceevents.Recorder
+	faultInjector             *chaos.FaultInjector
+	auditLog                  *audit.Log
+	recorder                  *recorder.Recorder
+	compatValidator           *compat.Validator
+	compatMode                compat.Mode
+	usageLedger               *usage.Ledger
+	signatureVerifier         *sigv4.Verifier
+	credentials               *sigv4.Credentials // Static keys and STS sessions
+	rateLimiter               *ratelimit.Limiter
+	authorizer                *iampolicy.Authorizer
+	notifier                  *notifications.Notifier
+	shutdownGate              *shutdown.Gate
+}
+
+// ensureFreshStorage returns an error when the storage already holds clusters
+// or task definitions
+func ensureFreshStorage(ctx context.Context, storage storage.Storage) error {
+	if storage == nil {
+		return nil
+	}
+	if clusterStore := storage.ClusterStore(); clusterStore != nil {
+		clusters, err := clusterStore.List(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list clusters: %w", err)
+		}
+		if len(clusters) > 0 {
+			return fmt.Errorf("deterministic mode requires empty storage, found %d clusters", len(clusters))
+		}
+	}
+	if taskDefinitionStore := storage.TaskDefinitionStore(); taskDefinitionStore != nil {
+		families, _, err := taskDefinitionStore.ListFamilies(ctx, "", "", 1, "")
+		if err != nil {
+			return fmt.Errorf("failed to list task definition families: %w", err)
+		}
+		if len(families) > 0 {
+			return fmt.Errorf("deterministic mode requires empty storage, found task definitions")
+		}
+	}
+	return nil
 }
 
 // NewServer creates a new API server instance
@@ -80,9 +148,31 @@ func NewServer(port int, kubeconfig string, storage storage.Storage, localStackC
 		logging.Info("Running inside Kubernetes cluster - using in-cluster config")
 	}
 
+	// Deterministic mode makes ARNs and timestamps reproducible across runs
+	if apiconfig.GetBool("features.deterministic") {
+		// The sequence and the clock restart on every start, so the IDs of
+		// resources stored by a previous run would be issued again
+		if err := ensureFreshStorage(context.Background(), storage); err != nil {
+			return nil, err
+		}
+		deterministic.Enable(true)
+		logging.Info("Running in deterministic mode - sequential resource IDs and a fixed clock will be used")
+	}
+
 	// Get region and accountID from configuration
 	region := apiconfig.GetString("aws.defaultRegion")
 	accountID := apiconfig.GetString("aws.accountID")
+	if accountID == "" && deterministic.Enabled() {
+		// Without a configured account ID, pin it so ARNs are stable
+		// regardless of the environment
+		accountID = "000000000000"
+	}
+	// All ARNs are built in the partition of the instance
+	if partition := apiconfig.GetString("aws.partition"); partition != "" {
+		if err := awsarn.SetPartition(partition); err != nil {
+			return nil, err
+		}
+	}
 
 	s := &Server{
 		port:       port,
@@ -91,6 +181,58 @@ func NewServer(port int, kubeconfig string, storage storage.Storage, localStackC
 		accountID:  accountID,
 		ecsAPI:     nil, // Will be set after IAM integration
 		storage:    storage,
+		// Shared between the ECS API and the sync controller
+		serviceEvents: serviceevents.NewRecorder(serviceevents.DefaultCapacity),
+		// Rules are managed through the admin API
+		faultInjector: chaos.NewFaultInjector(),
+		auditLog:      newAuditLog(),
+		recorder:      newRecorder(),
+		usageLedger:   newUsageLedger(),
+		shutdownGate:  shutdown.NewGate(),
+	}
+
+	rateLimiter, err := newRateLimiter()
+	if err != nil {
+		return nil, err
+	}
+	s.rateLimiter = rateLimiter
+
+	if s.notifier, err = notifications.NewNotifierFromConfig(); err != nil {
+		return nil, err
+	}
+
+	if s.compatMode, err = compat.ParseMode(apiconfig.GetString("compat.strictMode")); err != nil {
+		return nil, err
+	}
+	if s.compatMode != compat.ModeOff {
+		logging.Info("Validating responses against the AWS API models", "mode", s.compatMode)
+		if s.compatValidator, err = compat.NewValidator(); err != nil {
+			return nil, fmt.Errorf("failed to load AWS API models: %w", err)
+		}
+	}
+
+	verifier, credentials, err := newSignatureVerifier()
+	if err != nil {
+		return nil, err
+	}
+	// STS sessions are held by the credentials even without credentials file
+	s.credentials = credentials
+	if s.credentials == nil {
+		s.credentials, _ = sigv4.NewCredentials(nil)
+	}
+	if verifier != nil {
+		s.signatureVerifier = verifier
+		// Audit entries name the principal owning the access key
+		s.auditLog.SetPrincipalResolver(verifier.Principal)
+		if apiconfig.GetBool("auth.enforcePolicies") {
+			logging.Info("Enforcing IAM policies of the credentials file")
+			s.authorizer = iampolicy.NewAuthorizer(credentials.Principal, credentials.PoliciesFor, region, accountID)
+		}
+	} else if apiconfig.GetBool("auth.enforcePolicies") {
+		return nil, fmt.Errorf("auth.enforcePolicies requires auth.credentialsFile")
+	}
+	if apiconfig.GetBool("server.readOnly") {
+		logging.Info("Read-only mode: rejecting mutating ECS and ELBv2 calls")
 	}
 
 	// Initialize service manager with region and account ID
@@ -171,7 +313,7 @@ func NewServer(port int, kubeconfig string, storage storage.Storage, localStackC
 
 		if kubeClient != nil {
 			// Create informer factory
-			resyncPeriod := 5 * time.Minute
+			resyncPeriod := apiconfig.GetDuration("taskSync.resyncPeriod", 5*time.Minute)
 			informerFactory := informers.NewSharedInformerFactory(kubeClient, resyncPeriod)
 
 			// Get informers
@@ -195,10 +337,12 @@ func NewServer(port int, kubeconfig string, storage storage.Storage, localStackC
 			// Store informer factory to start it later with proper context
 			s.informerFactory = informerFactory
 			s.syncController = syncController
+			s.syncController.SetServiceEventRecorder(s.serviceEvents)
+			s.syncController.SetNotifier(s.notifier)
 
 			// Set TaskManager if available
 			if s.taskManager != nil && s.taskManager.Clientset != nil {
-				s.syncController.SetTaskUpdater(s.taskManager, s.taskManager.Clientset)
+				s.syncController.SetTaskUpdater(s.taskManager)
 				logging.Info("TaskManager set for sync controller")
 			}
 
@@ -214,7 +358,7 @@ func NewServer(port int, kubeconfig string, storage storage.Storage, localStackC
 	}
 
 	// Initialize resource cleanup worker
-	s.resourceCleanupWorker = NewResourceCleanupWorker(storage)
+	s.resourceCleanupWorker = NewResourceCleanupWorker(storage, s.usageLedger)
 
 	// Logs API has been moved to admin server (port 8081)
 
@@ -419,10 +563,32 @@ func NewServer(port int, kubeconfig string, storage storage.Storage, localStackC
 		if storage != nil {
 			elbv2Integration.SetStorage(storage.ELBv2Store())
 		}
+		// Deliver access logs through the S3 integration (if available)
+		if s.s3Integration != nil {
+			elbv2Integration.SetAccessLogUploader(s.s3Integration)
+		}
+		// Resolve the domains of listener certificates through ACM for SNI
+		elbv2Integration.SetCertificateResolver(elbv2.NewACMCertificateResolver(apiconfig.GetString("aws.endpointURL")))
+		// Forward requests for Lambda targets to LocalStack Lambda
+		elbv2Integration.SetLambdaInvoker(elbv2.NewLambdaInvoker(apiconfig.GetString("aws.endpointURL")))
 		s.elbv2Integration = elbv2Integration
 
 		// Initialize ELBv2 API and router with wrapper for form data support
 		elbv2API := NewELBv2API(storage, elbv2Integration, s.region, s.accountID)
+		if impl, ok := elbv2API.(*ELBv2APIImpl); ok && storage != nil && s.s3Integration != nil {
+			go func() {
+				if err := impl.RestoreAccessLogs(context.Background()); err != nil {
+					logging.Warn("Failed to restore ELBv2 access logs", "error", err)
+				}
+			}()
+		}
+		if impl, ok := elbv2API.(*ELBv2APIImpl); ok && storage != nil {
+			go func() {
+				if err := impl.RestoreManualTargets(context.Background()); err != nil {
+					logging.Warn("Failed to restore ELBv2 registered targets", "error", err)
+				}
+			}()
+		}
 		// Use wrapper to handle form data from AWS CLI
 		s.elbv2Router = NewELBv2RouterWrapper(elbv2API)
 
@@ -434,7 +600,34 @@ func NewServer(port int, kubeconfig string, storage storage.Storage, localStackC
 	// Create ECS API with integrations
 	cfg := apiconfig.GetConfig()
 	ecsAPI := NewDefaultECSAPIWithConfig(cfg, storage, s.region, s.accountID)
+	s.vpcRegistry = vpc.NewRegistry(s.region)
 	if defaultAPI, ok := ecsAPI.(*DefaultECSAPI); ok {
+		defaultAPI.SetVPCRegistry(s.vpcRegistry)
+		defaultAPI.SetServiceEventRecorder(s.serviceEvents)
+		defaultAPI.SetNotifier(s.notifier)
+		// CreateService and UpdateService return before Kubernetes resources are applied
+		s.serviceReconciler = NewServiceReconciler(defaultAPI)
+		defaultAPI.SetServiceReconciler(s.serviceReconciler)
+		s.deploymentAlarmMonitor = NewDeploymentAlarmMonitor(defaultAPI)
+		s.taskTimeoutMonitor = NewTaskTimeoutMonitor(defaultAPI)
+		if apiconfig.GetBool("images.resolveDigests") {
+			defaultAPI.SetImageResolver(images.NewRegistryResolver(
+				strings.Split(apiconfig.GetString("images.insecureRegistries"), ",")))
+			logging.Info("Image digest pinning enabled for registered task definitions")
+		}
+		if apiconfig.GetBool("images.checkArchitectures") {
+			defaultAPI.SetArchitectureResolver(images.NewRegistryResolver(
+				strings.Split(apiconfig.GetString("images.insecureRegistries"), ",")))
+		}
+		// Drift is only detected against a real cluster
+		if s.kubeClient != nil && !apiconfig.GetBool("features.testMode") {
+			s.driftDetector = NewDriftDetector(defaultAPI, s.kubeClient)
+			if s.syncController != nil {
+				s.syncController.SetDriftHandler(s.driftDetector)
+			}
+			s.provisioningMonitor = NewServiceProvisioningMonitor(defaultAPI, s.kubeClient)
+			s.insightsCollector = NewContainerInsightsCollector(defaultAPI, s.kubeClient)
+		}
 		if s.serviceManager != nil {
 			defaultAPI.SetServiceManager(s.serviceManager)
 		}
@@ -505,6 +698,18 @@ func NewServer(port int, kubeconfig string, storage storage.Storage, localStackC
 			if err == nil {
 				kubeClient, _ = k8s.NewForConfig(kubeConfig)
 			}
+
+			// ExecuteCommand sessions run through the pod exec subresource
+			if kubeClient != nil {
+				execManager := execcommand.NewManager(execcommand.NewPodExecutor(kubeClient, kubeConfig), s.region)
+				if s.cloudWatchIntegration != nil {
+					execManager.SetLogWriter(s.cloudWatchIntegration)
+				}
+				if s.s3Integration != nil {
+					execManager.SetUploader(s.s3Integration)
+				}
+				defaultAPI.SetExecCommandManager(execManager)
+			}
 		}
 
 		if kubeClient != nil {
@@ -542,7 +747,7 @@ func NewServer(port int, kubeconfig string, storage storage.Storage, localStackC
 
 					// Update SyncController's TaskManager if it exists
 					if s.syncController != nil && taskManagerWithSD.Clientset != nil {
-						s.syncController.SetTaskUpdater(taskManagerWithSD, taskManagerWithSD.Clientset)
+						s.syncController.SetTaskUpdater(taskManagerWithSD)
 						logging.Info("SyncController's TaskManager updated with Service Discovery support")
 					}
 
@@ -583,8 +788,12 @@ func NewServer(port int, kubeconfig string, storage storage.Storage, localStackC
 		}
 
 		// Otherwise handle as ECS request
+		r, ok := withTaskDefinitionTemplate(w, r)
+		if !ok {
+			return
+		}
 		router := generated.NewRouter(s.ecsAPI)
-		router.Route(w, r)
+		router.Route(w, withServiceFilter(r))
 	})
 
 	// Create ELBv2 handler
@@ -619,6 +828,22 @@ func NewServer(port int, kubeconfig string, storage storage.Storage, localStackC
 	if err != nil {
 		return nil, fmt.Errorf("failed to create proxy handler: %w", err)
 	}
+	proxyHandler.SetEC2Handler(NewEC2API(s.vpcRegistry))
+	var elbv2API generated_elbv2.ElasticLoadBalancing_v10API
+	if s.elbv2Router != nil {
+		elbv2API = s.elbv2Router.api
+	}
+	cfnEngine := cloudformation.NewEngine(ecsAPI, elbv2API, s.region, s.accountID)
+	// CDK uploads assets and large templates to S3, which is served by LocalStack
+	cfnEngine.SetS3Endpoint(func() (string, error) {
+		if s.localStackManager == nil {
+			return "", fmt.Errorf("LocalStack is not enabled")
+		}
+		return s.localStackManager.GetEndpoint()
+	})
+	proxyHandler.SetCloudFormationHandler(NewCloudFormationAPI(cfnEngine))
+	proxyHandler.SetAppConfigHandler(newAppConfigServer())
+	proxyHandler.SetSTSHandler(NewSTSAPI(s.credentials, s.accountID, s.signatureVerifier != nil))
 	s.proxyHandler = proxyHandler
 
 	return s, nil
@@ -628,6 +853,9 @@ func NewServer(port int, kubeconfig string, storage storage.Storage, localStackC
 func (s *Server) Start() error {
 	ctx := context.Background()
 
+	// Start the notifier first so no event of the startup is dropped
+	s.notifier.Start(ctx)
+
 	// Recover state if enabled and not in test mode
 	if !apiconfig.GetBool("features.testMode") && apiconfig.GetBool("features.autoRecoverState") {
 		logging.Info("Starting state recovery...")
@@ -650,6 +878,36 @@ func (s *Server) Start() error {
 		s.resourceCleanupWorker.Start(ctx)
 	}
 
+	// Start service reconciler if available
+	if s.serviceReconciler != nil {
+		s.serviceReconciler.Start(ctx)
+	}
+
+	// Start deployment alarm monitor if available
+	if s.deploymentAlarmMonitor != nil {
+		s.deploymentAlarmMonitor.Start(ctx)
+	}
+
+	// Start task timeout monitor if available
+	if s.taskTimeoutMonitor != nil {
+		s.taskTimeoutMonitor.Start(ctx)
+	}
+
+	// Start drift detector if available
+	if s.driftDetector != nil {
+		s.driftDetector.Start(ctx)
+	}
+
+	// Start service provisioning monitor if available
+	if s.provisioningMonitor != nil {
+		s.provisioningMonitor.Start(ctx)
+	}
+
+	// Start Container Insights collector if available
+	if s.insightsCollector != nil {
+		s.insightsCollector.Start(ctx)
+	}
+
 	// Start sync controller if available
 	if s.syncController != nil && s.informerFactory != nil {
 		logging.Info("Starting sync controller...")
@@ -711,6 +969,7 @@ func (s *Server) Start() error {
 
 	logging.Info("Starting API server",
 		"port", s.port)
+	s.notifier.Notify(notifications.InstanceStarted())
 	return s.httpServer.ListenAndServe()
 }
 
@@ -727,9 +986,31 @@ func (s *Server) handleELBv2Request(w http.ResponseWriter, r *http.Request) {
 	s.elbv2Router.Route(w, r)
 }
 
+// Drain stops accepting AWS API calls that change state and waits for the
+// ones in flight, so that they are not cut off halfway through creating their
+// resources. Calls rejected meanwhile get a service unavailable error, which
+// AWS SDKs retry.
+func (s *Server) Drain(ctx context.Context) error {
+	if s.shutdownGate == nil {
+		return nil
+	}
+	logging.Info("Draining API server, mutating API calls are rejected from now on...")
+	if err := s.shutdownGate.Close(ctx); err != nil {
+		return fmt.Errorf("mutating API calls still in flight: %w", err)
+	}
+	logging.Info("API server drained")
+	return nil
+}
+
 // Stop gracefully stops the HTTP server
 func (s *Server) Stop(ctx context.Context) error {
 	logging.Info("Shutting down API server...")
+	s.notifier.Notify(notifications.InstanceStopping())
+
+	// Nothing may enqueue services once the reconciler is stopped
+	if err := s.Drain(ctx); err != nil {
+		logging.Warn("Stopping API server before the API calls in flight completed", "error", err)
+	}
 
 	// Stop test mode worker if running
 	if s.testModeWorker != nil {
@@ -741,6 +1022,37 @@ func (s *Server) Stop(ctx context.Context) error {
 		s.resourceCleanupWorker.Stop()
 	}
 
+	// Stop task timeout monitor
+	if s.taskTimeoutMonitor != nil {
+		s.taskTimeoutMonitor.Stop()
+	}
+
+	// Stop Container Insights collector
+	if s.insightsCollector != nil {
+		s.insightsCollector.Stop()
+	}
+
+	// Stop service provisioning monitor before the reconciler its rollbacks use
+	if s.provisioningMonitor != nil {
+		s.provisioningMonitor.Stop()
+	}
+
+	// Stop drift detector before the reconciler its repairs use
+	if s.driftDetector != nil {
+		s.driftDetector.Stop()
+	}
+
+	// Stop deployment alarm monitor before the reconciler its rollbacks use
+	if s.deploymentAlarmMonitor != nil {
+		s.deploymentAlarmMonitor.Stop()
+	}
+
+	// Stop service reconciler after the queued services are applied, leaving
+	// the ones not applied by the deadline to the next start
+	if s.serviceReconciler != nil {
+		s.serviceReconciler.Stop(ctx)
+	}
+
 	// Stop sync controller if running
 	if s.syncController != nil && s.syncCancelFunc != nil {
 		logging.Info("Stopping sync controller and informers...")
@@ -777,7 +1089,19 @@ func (s *Server) Stop(ctx context.Context) error {
 		logging.Info("KECS_KEEP_CLUSTERS_ON_SHUTDOWN is set (legacy setting, no longer needed)")
 	}
 
-	return s.httpServer.Shutdown(ctx)
+	err := s.httpServer.Shutdown(ctx)
+	if closeErr := s.auditLog.Close(); closeErr != nil {
+		logging.Warn("Failed to close audit log", "error", closeErr)
+	}
+	if closeErr := s.recorder.Stop(); closeErr != nil {
+		logging.Warn("Failed to close API recording", "error", closeErr)
+	}
+	if closeErr := s.usageLedger.Close(); closeErr != nil {
+		logging.Warn("Failed to close usage ledger", "error", closeErr)
+	}
+	// Deliver the queued notifications, the shutdown one included
+	s.notifier.Stop()
+	return err
 }
 
 // RecoverState recovers k3d clusters and Kubernetes resources from storage
@@ -861,7 +1185,7 @@ func (s *Server) recoverServicesForCluster(ctx context.Context, cluster *storage
 	}
 
 	// Get all services for this cluster
-	services, _, err := s.storage.ServiceStore().List(ctx, cluster.Name, "", "", 100, "")
+	services, _, err := s.storage.ServiceStore().List(ctx, cluster.Name, storage.ServiceFilters{}, 100, "")
 	if err != nil {
 		return fmt.Errorf("failed to list services for cluster %s: %w", cluster.Name, err)
 	}
@@ -928,11 +1252,13 @@ func (s *Server) recoverServicesForCluster(ctx context.Context, cluster *storage
 		}
 
 		// Update service status after successful deployment
-		service.RunningCount = 0 // Will be updated by deployment controller
-		service.Status = "ACTIVE"
-		service.UpdatedAt = time.Now()
-
-		if err := s.storage.ServiceStore().Update(ctx, service); err != nil {
+		_, err = storage.UpdateService(ctx, s.storage.ServiceStore(), service, func(current *storage.Service) error {
+			current.RunningCount = 0 // Will be updated by deployment controller
+			current.Status = "ACTIVE"
+			current.UpdatedAt = time.Now()
+			return nil
+		})
+		if err != nil {
 			logging.Error("Failed to update service after recovery",
 				"service", service.ServiceName,
 				"error", err)
@@ -976,7 +1302,7 @@ func (s *Server) scheduleServiceTasks(ctx context.Context, cluster *storage.Clus
 	for i := 0; i < count; i++ {
 		// Generate task ID
 		taskID := uuid.New().String()
-		taskARN := fmt.Sprintf("arn:aws:ecs:%s:%s:task/%s/%s", s.region, s.accountID, cluster.Name, taskID)
+		taskARN := awsarn.Build("ecs", s.region, s.accountID, fmt.Sprintf("task/%s/%s", cluster.Name, taskID))
 
 		// Create task in storage
 		task := &storage.Task{
@@ -1248,14 +1574,27 @@ func (s *Server) SetupRoutes() http.Handler {
 	router.HandleFunc("/api/localstack/status", s.GetLocalStackStatus).Methods("GET")
 	router.HandleFunc("/localstack/dashboard", s.GetLocalStackDashboard).Methods("GET")
 
+	// Fixed responses of ELBv2 listener rules, routed here by Traefik
+	router.Handle(elbv2.FixedResponsePath, elbv2.FixedResponseHandler())
+
 	// Logs API moved to admin server (port 8081)
 
+	// kecs.v1.KecsService, the Connect API the TUI watches resources with
+	if s.storage != nil {
+		kecsService := kecsapi.NewServer(s.storage, 0)
+		if defaultAPI, ok := s.ecsAPI.(*DefaultECSAPI); ok {
+			kecsService.SetTaskLogStreamer(defaultAPI)
+		}
+		path, handler := kecsService.Handler()
+		router.PathPrefix(path).Handler(s.connectMiddleware(handler))
+	}
+
 	// AWS API endpoints (generated) - handle everything else
 	// This should be last as it's a catch-all for AWS API requests
 	logging.Error("DEBUG: Checking proxyHandler", "nil", s.proxyHandler == nil)
 	if s.proxyHandler != nil {
 		logging.Error("DEBUG: Setting up ProxyHandler for / path")
-		router.PathPrefix("/").Handler(s.proxyHandler)
+		router.PathPrefix("/").Handler(s.awsMiddleware(s.proxyHandler))
 	} else {
 		logging.Error("ProxyHandler is nil, cannot set up routing")
 	}
@@ -1270,6 +1609,60 @@ func (s *Server) SetupRoutes() http.Handler {
 	return handler
 }
 
+// awsMiddleware wraps the handler of the AWS APIs in the middleware acting on
+// AWS API calls. It wraps the API proxy only, so that requests to the other
+// routes are never taken for API calls.
+func (s *Server) awsMiddleware(handler http.Handler) http.Handler {
+	if s.compatValidator != nil {
+		// Innermost so injected faults are not reported as mismatches
+		handler = s.compatValidator.Middleware(s.compatMode, handler)
+	}
+	if s.faultInjector != nil {
+		handler = s.faultInjector.Middleware(handler)
+	}
+	if s.rateLimiter != nil {
+		// Outside the fault injector so injected latency does not hold Kubernetes operation slots
+		handler = s.rateLimiter.Middleware(handler)
+	}
+	if s.authorizer != nil {
+		handler = s.authorizer.Middleware(handler)
+	}
+	if apiconfig.GetBool("server.readOnly") {
+		// Inside the signature verifier so unauthenticated callers get signature errors
+		handler = readonly.Middleware(handler)
+	}
+	if s.signatureVerifier != nil {
+		// Outside the fault injector so unauthenticated callers cannot trigger faults
+		handler = s.signatureVerifier.Middleware(handler)
+	}
+	if s.auditLog != nil {
+		// Outside the fault injector so injected failures are audited too
+		handler = s.auditLog.Middleware(handler)
+	}
+	if s.recorder != nil {
+		// Outside the signature verifier so rejected calls are recorded too
+		handler = s.recorder.Middleware(handler)
+	}
+	if s.shutdownGate != nil {
+		// Outermost so the calls awaited on shutdown include everything they do
+		handler = s.shutdownGate.Middleware(handler)
+	}
+	return handler
+}
+
+// connectMiddleware authenticates and authorizes the calls of the Connect API
+// like AWS API calls. The rest of the AWS API middleware acts on AWS API
+// calls only and does not pass streams through.
+func (s *Server) connectMiddleware(handler http.Handler) http.Handler {
+	if s.authorizer != nil {
+		handler = s.authorizer.Middleware(handler)
+	}
+	if s.signatureVerifier != nil {
+		handler = s.signatureVerifier.Middleware(handler)
+	}
+	return handler
+}
+
 // handleHealthCheck handles the health check endpoint
 func (s *Server) handleHealthCheck(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
@@ -1281,6 +1674,173 @@ func (s *Server) GetKubeClient() k8s.Interface {
 	return s.kubeClient
 }
 
+// GetECSAPI returns the ECS API implementation
+func (s *Server) GetECSAPI() generated.ECSAPIInterface {
+	return s.ecsAPI
+}
+
+// GetELBv2API returns the ELBv2 API implementation, nil when ELBv2 is disabled
+func (s *Server) GetELBv2API() generated_elbv2.ElasticLoadBalancing_v10API {
+	if s.elbv2Router == nil {
+		return nil
+	}
+	return s.elbv2Router.api
+}
+
+// GetTrafficReporter returns the traffic the routing layer measured per
+// target group, nil when the ELBv2 integration does not measure it
+func (s *Server) GetTrafficReporter() elbv2.TrafficReporter {
+	reporter, _ := s.elbv2Integration.(elbv2.TrafficReporter)
+	return reporter
+}
+
+// GetFaultInjector returns the fault injector applied to AWS API requests
+func (s *Server) GetFaultInjector() *chaos.FaultInjector {
+	return s.faultInjector
+}
+
+// GetLocalStackCapabilityProber returns the prober of the services the
+// configured LocalStack supports, nil while LocalStack is not configured
+func (s *Server) GetLocalStackCapabilityProber() *localstack.CapabilityProber {
+	if s.awsProxyRouter == nil || s.awsProxyRouter.AWSProxyHandler == nil {
+		return nil
+	}
+	return s.awsProxyRouter.AWSProxyHandler.GetCapabilityProber()
+}
+
+// GetAuditLog returns the audit log of mutating API calls, nil when auditing is disabled
+func (s *Server) GetAuditLog() *audit.Log {
+	return s.auditLog
+}
+
+// newAuditLog creates the audit log configured with audit.enabled and
+// audit.maxEntries. It is persisted under the data directory except in test mode.
+func newAuditLog() *audit.Log {
+	if !apiconfig.GetBool("audit.enabled") {
+		return nil
+	}
+	maxEntries := apiconfig.GetInt("audit.maxEntries")
+	dataDir := apiconfig.GetString("server.dataDir")
+	if dataDir == "" || apiconfig.GetBool("features.testMode") {
+		return audit.NewLog(maxEntries)
+	}
+
+	auditLog, err := audit.OpenLog(filepath.Join(dataDir, "audit", "audit.log"), maxEntries)
+	if err != nil {
+		logging.Warn("Keeping the audit log in memory only", "error", err)
+		return audit.NewLog(maxEntries)
+	}
+	return auditLog
+}
+
+// GetRecorder returns the recorder of API calls, nil without a data directory
+func (s *Server) GetRecorder() *recorder.Recorder {
+	return s.recorder
+}
+
+// newRecorder creates the recorder of API calls writing under the data
+// directory. It starts recording when recording.enabled is set.
+func newRecorder() *recorder.Recorder {
+	dataDir := apiconfig.GetString("server.dataDir")
+	if dataDir == "" || apiconfig.GetBool("features.testMode") {
+		return nil
+	}
+
+	rec := recorder.New(filepath.Join(dataDir, "recordings", "api.jsonl"))
+	if apiconfig.GetBool("recording.enabled") {
+		if err := rec.Start(false); err != nil {
+			logging.Warn("Failed to start recording API calls", "error", err)
+		}
+	}
+	return rec
+}
+
+// GetUsageLedger returns the usage records of tasks deleted from storage
+func (s *Server) GetUsageLedger() *usage.Ledger {
+	return s.usageLedger
+}
+
+// GetServiceReconciler returns the reconciler that applies stored services to
+// Kubernetes, or nil without a Kubernetes cluster
+func (s *Server) GetServiceReconciler() *ServiceReconciler {
+	return s.serviceReconciler
+}
+
+// newUsageLedger creates the ledger of stopped task usage kept for
+// usage.retention. It is persisted under the data directory except in test mode.
+func newUsageLedger() *usage.Ledger {
+	retention := apiconfig.GetDuration("usage.retention", usage.DefaultRetention)
+	dataDir := apiconfig.GetString("server.dataDir")
+	if dataDir == "" || apiconfig.GetBool("features.testMode") {
+		return usage.NewLedger(retention)
+	}
+
+	ledger, err := usage.OpenLedger(filepath.Join(dataDir, "usage", "tasks.log"), retention)
+	if err != nil {
+		logging.Warn("Keeping the usage ledger in memory only", "error", err)
+		return usage.NewLedger(retention)
+	}
+	return ledger
+}
+
+// newAppConfigServer creates the AppConfig server, which keeps hosted
+// configurations under the data directory
+func newAppConfigServer() *appconfig.Server {
+	dataDir := apiconfig.GetString("server.dataDir")
+	if dataDir == "" || apiconfig.GetBool("features.testMode") {
+		return appconfig.NewServer()
+	}
+
+	server, err := appconfig.Open(filepath.Join(dataDir, "appconfig"))
+	if err != nil {
+		logging.Warn("Keeping AppConfig in memory only", "error", err)
+		return appconfig.NewServer()
+	}
+	return server
+}
+
+// newRateLimiter creates the API rate limiter configured under rateLimit. It
+// returns nil when no limit is configured.
+func newRateLimiter() (*ratelimit.Limiter, error) {
+	operations, err := ratelimit.ParseOperationLimits(apiconfig.GetString("rateLimit.operations"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid rateLimit.operations: %w", err)
+	}
+	config := ratelimit.Config{
+		Default: ratelimit.Limit{
+			RequestsPerSecond: apiconfig.GetFloat64("rateLimit.requestsPerSecond"),
+			Burst:             apiconfig.GetInt("rateLimit.burst"),
+		},
+		Operations:                        operations,
+		MaxConcurrentKubernetesOperations: apiconfig.GetInt("rateLimit.maxConcurrentKubernetesOperations"),
+		QueueTimeout:                      apiconfig.GetDuration("rateLimit.queueTimeout", 5*time.Second),
+	}
+	if !config.Enabled() {
+		return nil, nil
+	}
+	logging.Info("Rate limiting API requests",
+		"requestsPerSecond", config.Default.RequestsPerSecond,
+		"operations", len(operations),
+		"maxConcurrentKubernetesOperations", config.MaxConcurrentKubernetesOperations)
+	return ratelimit.NewLimiter(config), nil
+}
+
+// newSignatureVerifier creates the SigV4 verifier configured with
+// auth.credentialsFile. Any access key is accepted when no file is configured.
+func newSignatureVerifier() (*sigv4.Verifier, *sigv4.Credentials, error) {
+	path := apiconfig.GetString("auth.credentialsFile")
+	if path == "" {
+		return nil, nil, nil
+	}
+	credentials, err := sigv4.LoadCredentials(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load credentials: %w", err)
+	}
+	allowUnsigned := apiconfig.GetBool("auth.allowUnsigned")
+	logging.Info("Verifying SigV4 signatures", "credentialsFile", path, "allowUnsigned", allowUnsigned)
+	return sigv4.NewVerifier(credentials, allowUnsigned), credentials, nil
+}
+
 // GetTaskManager returns the task manager
 func (s *Server) GetTaskManager() *kubernetes.TaskManager {
 	return s.taskManager
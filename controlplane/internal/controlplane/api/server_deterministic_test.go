@@ -0,0 +1,47 @@
+package api
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/mocks"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+var _ = Describe("ensureFreshStorage", func() {
+	var (
+		ctx         context.Context
+		mockStorage *mocks.MockStorage
+		clusters    *mocks.MockClusterStore
+		taskDefs    *mocks.MockTaskDefinitionStore
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockStorage = mocks.NewMockStorage()
+		clusters = mocks.NewMockClusterStore()
+		taskDefs = mocks.NewMockTaskDefinitionStore()
+		mockStorage.SetClusterStore(clusters)
+		mockStorage.SetTaskDefinitionStore(taskDefs)
+	})
+
+	It("should accept empty storage", func() {
+		Expect(ensureFreshStorage(ctx, mockStorage)).To(Succeed())
+		Expect(ensureFreshStorage(ctx, nil)).To(Succeed())
+	})
+
+	It("should refuse storage holding clusters", func() {
+		Expect(clusters.Create(ctx, &storage.Cluster{
+			Name: "default", ARN: "arn:aws:ecs:us-east-1:000000000000:cluster/default", Status: "ACTIVE",
+		})).To(Succeed())
+		Expect(ensureFreshStorage(ctx, mockStorage)).To(MatchError(ContainSubstring("deterministic mode requires empty storage")))
+	})
+
+	It("should refuse storage holding task definitions", func() {
+		_, err := taskDefs.Register(ctx, &storage.TaskDefinition{Family: "web", Status: "ACTIVE"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ensureFreshStorage(ctx, mockStorage)).To(MatchError(ContainSubstring("deterministic mode requires empty storage")))
+	})
+})
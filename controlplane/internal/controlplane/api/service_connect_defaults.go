@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/servicediscovery"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// applyServiceConnectDefaults stores the default Service Connect namespace of a
+// cluster. A namespace name that Cloud Map does not know yet is created as a
+// private DNS namespace backed by the cluster's Kubernetes namespace. An empty
+// namespace clears the defaults, as in ECS.
+func (api *DefaultECSAPI) applyServiceConnectDefaults(ctx context.Context, cluster *storage.Cluster, defaults *generated.ClusterServiceConnectDefaultsRequest) error {
+	if defaults.Namespace == "" {
+		cluster.ServiceConnectDefaults = ""
+		return nil
+	}
+
+	namespace := defaults.Namespace
+	if api.serviceDiscoveryManager != nil {
+		resolved, err := api.ensureServiceConnectNamespace(ctx, cluster, defaults.Namespace)
+		if err != nil {
+			return err
+		}
+		namespace = resolved.ARN
+	}
+
+	defaultsJSON, err := json.Marshal(generated.ClusterServiceConnectDefaults{
+		Namespace: ptr.String(namespace),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal service connect defaults: %w", err)
+	}
+	cluster.ServiceConnectDefaults = string(defaultsJSON)
+	return nil
+}
+
+// ensureServiceConnectNamespace looks up a Cloud Map namespace by name or ARN,
+// creating it when a name is given that does not exist yet
+func (api *DefaultECSAPI) ensureServiceConnectNamespace(ctx context.Context, cluster *storage.Cluster, identifier string) (*servicediscovery.Namespace, error) {
+	namespaces, err := api.serviceDiscoveryManager.ListNamespaces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	for _, ns := range namespaces {
+		if ns.ARN == identifier || ns.Name == identifier {
+			return ns, nil
+		}
+	}
+
+	if strings.HasPrefix(identifier, "arn:") {
+		return nil, &generated.NamespaceNotFoundException{
+			Message: ptr.String(fmt.Sprintf("Namespace %s not found", identifier)),
+		}
+	}
+
+	ns, err := api.serviceDiscoveryManager.CreatePrivateDnsNamespace(ctx, identifier, "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create service connect namespace: %w", err)
+	}
+	api.serviceDiscoveryManager.MapK8sNamespace(ns.Name, fmt.Sprintf("%s-%s", cluster.Name, cluster.Region))
+
+	logging.Info("Created default Service Connect namespace for cluster",
+		"cluster", cluster.Name, "namespace", ns.Name, "namespaceArn", ns.ARN)
+	return ns, nil
+}
+
+// parseServiceConnectDefaults parses the stored Service Connect defaults of a cluster
+func parseServiceConnectDefaults(defaultsJSON string) *generated.ClusterServiceConnectDefaults {
+	if defaultsJSON == "" {
+		return nil
+	}
+	var defaults generated.ClusterServiceConnectDefaults
+	if err := json.Unmarshal([]byte(defaultsJSON), &defaults); err != nil {
+		return nil
+	}
+	return &defaults
+}
+
+// inheritServiceConnectNamespace fills in the cluster default namespace for an
+// enabled Service Connect configuration that does not name one
+func inheritServiceConnectNamespace(cluster *storage.Cluster, config *generated.ServiceConnectConfiguration) *generated.ServiceConnectConfiguration {
+	if config == nil || !config.Enabled || (config.Namespace != nil && *config.Namespace != "") {
+		return config
+	}
+	defaults := parseServiceConnectDefaults(cluster.ServiceConnectDefaults)
+	if defaults == nil || defaults.Namespace == nil {
+		return config
+	}
+	inherited := *config
+	inherited.Namespace = ptr.String(*defaults.Namespace)
+	return &inherited
+}
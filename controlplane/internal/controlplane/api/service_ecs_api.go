@@ -3,21 +3,23 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
-	"github.com/google/uuid"
-	appsv1 "k8s.io/api/apps/v1"
-	corev1 "k8s.io/api/core/v1"
-
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
+	"github.com/nandemo-ya/kecs/controlplane/internal/common"
 	"github.com/nandemo-ya/kecs/controlplane/internal/config"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
 	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
 	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/elbv2"
 	"github.com/nandemo-ya/kecs/controlplane/internal/kubernetes"
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/servicedeployments"
+	"github.com/nandemo-ya/kecs/controlplane/internal/serviceevents"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
 )
 
@@ -90,7 +92,7 @@ func (api *DefaultECSAPI) CreateService(ctx context.Context, req *generated.Crea
 		taskDefArn = *req.TaskDefinition
 		logging.Debug("Looking for task definition", "taskDefinition", taskDefArn)
 
-		if !strings.HasPrefix(taskDefArn, "arn:aws:ecs:") {
+		if !awsarn.IsService(taskDefArn, "ecs") {
 			// Check if it's family:revision, family:latest, or just family
 			if strings.Contains(taskDefArn, ":") {
 				parts := strings.SplitN(taskDefArn, ":", 2)
@@ -107,7 +109,7 @@ func (api *DefaultECSAPI) CreateService(ctx context.Context, req *generated.Crea
 					}
 				} else {
 					// family:revision format
-					taskDefArn = fmt.Sprintf("arn:aws:ecs:%s:%s:task-definition/%s", api.region, api.accountID, taskDefArn)
+					taskDefArn = awsarn.Build("ecs", api.region, api.accountFor(ctx), fmt.Sprintf("task-definition/%s", taskDefArn))
 					logging.Debug("Trying to get task definition by ARN", "arn", taskDefArn)
 					taskDef, err = api.storage.TaskDefinitionStore().GetByARN(ctx, taskDefArn)
 				}
@@ -138,7 +140,7 @@ func (api *DefaultECSAPI) CreateService(ctx context.Context, req *generated.Crea
 	}
 
 	// Generate ARNs
-	serviceARN := fmt.Sprintf("arn:aws:ecs:%s:%s:service/%s/%s", api.region, api.accountID, cluster.Name, req.ServiceName)
+	serviceARN := awsarn.Build("ecs", api.region, api.accountFor(ctx), fmt.Sprintf("service/%s/%s", cluster.Name, req.ServiceName))
 	clusterARN := cluster.ARN
 
 	// Check if service already exists
@@ -171,6 +173,16 @@ func (api *DefaultECSAPI) CreateService(ctx context.Context, req *generated.Crea
 		launchType = *req.LaunchType
 	}
 
+	// Without a launch type or strategy the service inherits the cluster default strategy
+	capacityProviderStrategy, err := resolveCapacityProviderStrategy(cluster, req.LaunchType, req.CapacityProviderStrategy)
+	if err != nil {
+		return nil, err
+	}
+	if len(capacityProviderStrategy) > 0 {
+		// Tasks run with the launch type of the first provider, they record their own provider
+		launchType = generated.LaunchType(converters.LaunchTypeForCapacityProvider(capacityProviderStrategy[0].CapacityProvider))
+	}
+
 	schedulingStrategy := generated.SchedulingStrategyREPLICA
 	if req.SchedulingStrategy != nil {
 		schedulingStrategy = *req.SchedulingStrategy
@@ -192,6 +204,14 @@ func (api *DefaultECSAPI) CreateService(ctx context.Context, req *generated.Crea
 		return nil, fmt.Errorf("failed to marshal service registries: %w", err)
 	}
 
+	if err := api.validateAwsVpcSubnets(req.NetworkConfiguration); err != nil {
+		return nil, err
+	}
+
+	if err := api.validateServiceRegistries(ctx, req.ServiceRegistries); err != nil {
+		return nil, err
+	}
+
 	networkConfigJSON, err := json.Marshal(req.NetworkConfiguration)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal network configuration: %w", err)
@@ -217,7 +237,7 @@ func (api *DefaultECSAPI) CreateService(ctx context.Context, req *generated.Crea
 		return nil, fmt.Errorf("failed to marshal placement strategy: %w", err)
 	}
 
-	capacityProviderStrategyJSON, err := json.Marshal(req.CapacityProviderStrategy)
+	capacityProviderStrategyJSON, err := json.Marshal(capacityProviderStrategy)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal capacity provider strategy: %w", err)
 	}
@@ -227,7 +247,25 @@ func (api *DefaultECSAPI) CreateService(ctx context.Context, req *generated.Crea
 		return nil, fmt.Errorf("failed to marshal tags: %w", err)
 	}
 
-	serviceConnectConfigJSON, err := json.Marshal(req.ServiceConnectConfiguration)
+	// Fargate tasks need a platform version supporting their features
+	if taskDef != nil && launchType == generated.LaunchTypeFARGATE {
+		if _, err := validatePlatformVersion(taskDef, req.PlatformVersion, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	// Managed EBS volumes for the volumes configured at launch of the task definition
+	var volumeConfigsJSON string
+	if taskDef != nil {
+		volumeConfigsJSON, err = validateVolumeConfigurations(taskDef, req.VolumeConfigurations)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Service Connect without an explicit namespace uses the cluster default
+	serviceConnectConfig := inheritServiceConnectNamespace(cluster, req.ServiceConnectConfiguration)
+	serviceConnectConfigJSON, err := json.Marshal(serviceConnectConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal service connect configuration: %w", err)
 	}
@@ -237,57 +275,12 @@ func (api *DefaultECSAPI) CreateService(ctx context.Context, req *generated.Crea
 	// So we don't need to check for individual k3d clusters per ECS cluster
 	logging.Info("Creating service in namespace for ECS cluster", "cluster", cluster.Name)
 
-	// Variables for Kubernetes resources (will be nil for EXTERNAL deployment)
-	var deployment *appsv1.Deployment
-	var kubeService *corev1.Service
+	// The Deployment is named after the service; EXTERNAL services have none
+	// as TaskSets handle the actual workload deployment
+	namespace := fmt.Sprintf("%s-%s", cluster.Name, cluster.Region)
 	var deploymentName string
-	var namespace string
-
-	// Only create Kubernetes resources for non-EXTERNAL deployment controller
 	if !isExternalDeployment {
-		// Create service converter and manager
-		// Use ServiceConverterWithLB if ELBv2 integration is available
-		var serviceConverter converters.ServiceConverterInterface
-		if api.elbv2Integration != nil {
-			logging.Info("Using ServiceConverterWithLB for ELBv2 integration",
-				"serviceName", req.ServiceName)
-			serviceConverter = converters.NewServiceConverterWithLB(api.region, api.accountID, api.elbv2Integration)
-		} else {
-			logging.Info("Using standard ServiceConverter",
-				"serviceName", req.ServiceName)
-			serviceConverter = converters.NewServiceConverter(api.region, api.accountID)
-		}
-
-		// Convert ECS service to Kubernetes Deployment
-		storageServiceTemp := &storage.Service{
-			ARN:                serviceARN,
-			ServiceName:        req.ServiceName,
-			TaskDefinitionARN:  taskDefArn,
-			DesiredCount:       int(desiredCount),
-			LaunchType:         string(launchType),
-			SchedulingStrategy: string(schedulingStrategy),
-			LoadBalancers:      string(loadBalancersJSON),
-			ServiceRegistries:  string(serviceRegistriesJSON),
-		}
-		deployment, kubeService, err = serviceConverter.ConvertServiceToDeploymentWithNetworkConfig(
-			storageServiceTemp,
-			taskDef,
-			cluster,
-			req.NetworkConfiguration,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to convert service to deployment: %w", err)
-		}
-
 		deploymentName = req.ServiceName
-		namespace = fmt.Sprintf("%s-%s", cluster.Name, cluster.Region)
-	} else {
-		// For EXTERNAL deployment, we don't create Kubernetes resources
-		// TaskSets will handle the actual workload deployment
-		logging.Info("Service has EXTERNAL deployment controller, skipping Kubernetes resource creation",
-			"serviceName", req.ServiceName)
-		namespace = fmt.Sprintf("%s-%s", cluster.Name, cluster.Region)
-		deploymentName = "" // No deployment for EXTERNAL
 	}
 
 	// Extract optional string values
@@ -331,7 +324,7 @@ func (api *DefaultECSAPI) CreateService(ctx context.Context, req *generated.Crea
 		PendingCount:                  int(desiredCount),
 		LaunchType:                    string(launchType),
 		PlatformVersion:               platformVersion,
-		Status:                        "PENDING",
+		Status:                        serviceStatusProvisioning,
 		RoleARN:                       roleARN,
 		LoadBalancers:                 string(loadBalancersJSON),
 		ServiceRegistries:             string(serviceRegistriesJSON),
@@ -344,16 +337,20 @@ func (api *DefaultECSAPI) CreateService(ctx context.Context, req *generated.Crea
 		Tags:                          string(tagsJSON),
 		SchedulingStrategy:            string(schedulingStrategy),
 		ServiceConnectConfiguration:   string(serviceConnectConfigJSON),
+		VolumeConfigurations:          volumeConfigsJSON,
 		EnableECSManagedTags:          enableECSManagedTags,
 		PropagateTags:                 propagateTags,
 		EnableExecuteCommand:          enableExecuteCommand,
 		HealthCheckGracePeriodSeconds: healthCheckGracePeriod,
 		Region:                        api.region,
-		AccountID:                     api.accountID,
+		AccountID:                     api.accountFor(ctx),
 		DeploymentName:                deploymentName,
 		Namespace:                     namespace,
-		CreatedAt:                     time.Now(),
-		UpdatedAt:                     time.Now(),
+		CreatedAt:                     deterministic.Now(),
+		UpdatedAt:                     deterministic.Now(),
+	}
+	if !isExternalDeployment {
+		servicedeployments.Start(storageService, storageService.CreatedAt)
 	}
 
 	// Save to storage first
@@ -362,7 +359,7 @@ func (api *DefaultECSAPI) CreateService(ctx context.Context, req *generated.Crea
 		return nil, toECSError(err, "CreateService")
 	}
 
-	logging.Info("Service created in storage, proceeding with Kubernetes deployment",
+	logging.Info("Service created in storage, handing it to the reconciler",
 		"service", storageService.ServiceName)
 
 	// Increment cluster's active service count
@@ -372,32 +369,24 @@ func (api *DefaultECSAPI) CreateService(ctx context.Context, req *generated.Crea
 		logging.Warn("Failed to update cluster service count", "error", err)
 	}
 
-	// Create Kubernetes Deployment and Service (only for non-EXTERNAL deployment)
+	// Kubernetes resources are applied by the reconciler (only for non-EXTERNAL deployment)
 	if !isExternalDeployment {
-		serviceManager, err := api.getServiceManager()
+		reconciled, err := api.applyService(ctx, storageService)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create service manager: %w", err)
-		}
-
-		logging.Info("Calling ServiceManager.CreateService",
-			"service", storageService.ServiceName,
-			"deployment", deployment.Name,
-			"namespace", deployment.Namespace)
-		if err := serviceManager.CreateService(ctx, deployment, kubeService, cluster, storageService); err != nil {
-			// Service was created in storage but Kubernetes deployment failed
-			// Update status to indicate failure - get fresh service data first
-			if freshService, getErr := api.storage.ServiceStore().Get(ctx, cluster.ARN, storageService.ServiceName); getErr == nil {
-				freshService.Status = "FAILED"
-				api.storage.ServiceStore().Update(ctx, freshService)
-			}
 			return nil, fmt.Errorf("failed to create kubernetes deployment: %w", err)
 		}
+		storageService = reconciled
 	} else {
 		// For EXTERNAL deployment, service is managed by TaskSets
 		// Update status to ACTIVE since there's no deployment to wait for
-		storageService.Status = "ACTIVE"
-		if err := api.storage.ServiceStore().Update(ctx, storageService); err != nil {
+		updated, err := storage.UpdateService(ctx, api.storage.ServiceStore(), storageService, func(service *storage.Service) error {
+			service.Status = serviceStatusActive
+			return nil
+		})
+		if err != nil {
 			logging.Warn("Failed to update service status", "error", err)
+		} else {
+			storageService = updated
 		}
 	}
 
@@ -445,14 +434,8 @@ func (api *DefaultECSAPI) CreateService(ctx context.Context, req *generated.Crea
 		}
 	}
 
-	// In test mode, create tasks immediately for the service
-	if config.GetBool("features.testMode") && storageService.DesiredCount > 0 {
-		logging.Info("Test mode: Creating tasks for service", "count", storageService.DesiredCount, "service", storageService.ServiceName)
-		if err := api.createTasksForService(ctx, storageService, taskDef, cluster); err != nil {
-			logging.Warn("Failed to create tasks for service in test mode", "error", err)
-			// Don't fail service creation, tasks will be created by the worker
-		}
-	}
+	api.serviceEvents.Record(storageService.ARN,
+		serviceevents.DeploymentStarted(storageService.ServiceName, servicedeployments.PrimaryID(storageService)))
 
 	// Convert storage service to API response
 	responseService := storageServiceToGeneratedService(storageService)
@@ -499,11 +482,16 @@ func (api *DefaultECSAPI) DeleteService(ctx context.Context, req *generated.Dele
 	}
 
 	// Update status to DRAINING before deletion
-	existingService.Status = "DRAINING"
-	existingService.DesiredCount = 0
-	existingService.UpdatedAt = time.Now()
-	if err := api.storage.ServiceStore().Update(ctx, existingService); err != nil {
+	drainingService, err := storage.UpdateService(ctx, api.storage.ServiceStore(), existingService, func(service *storage.Service) error {
+		service.Status = "DRAINING"
+		service.DesiredCount = 0
+		service.UpdatedAt = deterministic.Now()
+		return nil
+	})
+	if err != nil {
 		logging.Warn("Failed to update service status to DRAINING", "error", err)
+	} else {
+		existingService = drainingService
 	}
 
 	// Delete Kubernetes resources
@@ -537,6 +525,8 @@ func (api *DefaultECSAPI) DeleteService(ctx context.Context, req *generated.Dele
 	logging.Info("Successfully deleted service",
 		"service", existingService.ServiceName, "cluster", clusterName)
 
+	api.serviceEvents.Delete(existingService.ARN)
+
 	// Convert back to API response
 	// The service is returned with DRAINING status as per AWS ECS behavior
 	responseService := storageServiceToGeneratedService(existingService)
@@ -546,6 +536,9 @@ func (api *DefaultECSAPI) DeleteService(ctx context.Context, req *generated.Dele
 	}, nil
 }
 
+// maxDescribeServices is the number of services DescribeServices accepts
+const maxDescribeServices = 10
+
 // DescribeServices implements the DescribeServices operation
 func (api *DefaultECSAPI) DescribeServices(ctx context.Context, req *generated.DescribeServicesRequest) (*generated.DescribeServicesResponse, error) {
 	// Default cluster if not specified
@@ -554,7 +547,7 @@ func (api *DefaultECSAPI) DescribeServices(ctx context.Context, req *generated.D
 		clusterName = extractClusterNameFromARN(*req.Cluster)
 	}
 
-	clusterARN := fmt.Sprintf("arn:aws:ecs:%s:%s:cluster/%s", api.region, api.accountID, clusterName)
+	clusterARN := awsarn.Build("ecs", api.region, api.accountFor(ctx), fmt.Sprintf("cluster/%s", clusterName))
 
 	var services []generated.Service
 	var failures []generated.Failure
@@ -566,30 +559,54 @@ func (api *DefaultECSAPI) DescribeServices(ctx context.Context, req *generated.D
 			Failures: failures,
 		}, nil
 	}
+	if len(req.Services) > maxDescribeServices {
+		return nil, &generated.InvalidParameterException{
+			Message: ptr.String(fmt.Sprintf("A maximum of %d services can be described at once.", maxDescribeServices)),
+		}
+	}
 
-	for _, serviceIdentifier := range req.Services {
-		// Extract service name from ARN if necessary
-		serviceName := serviceIdentifier
-		if strings.HasPrefix(serviceIdentifier, "arn:aws:ecs:") {
-			// ARN format: arn:aws:ecs:region:account:service/cluster/service-name
+	// Extract service names from ARNs if necessary
+	// ARN format: arn:aws:ecs:region:account:service/cluster/service-name
+	serviceNames := make([]string, len(req.Services))
+	for i, serviceIdentifier := range req.Services {
+		serviceNames[i] = serviceIdentifier
+		if awsarn.IsService(serviceIdentifier, "ecs") {
 			parts := strings.Split(serviceIdentifier, "/")
 			if len(parts) >= 2 {
-				serviceName = parts[len(parts)-1]
+				serviceNames[i] = parts[len(parts)-1]
 			}
 		}
+	}
 
-		storageService, err := api.storage.ServiceStore().Get(ctx, clusterARN, serviceName)
-		if err != nil {
+	// Look all services up at once
+	found, err := api.storage.ServiceStore().GetMany(ctx, clusterARN, serviceNames)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get services: %w", err)
+	}
+	byName := make(map[string]*storage.Service, len(found))
+	for _, service := range found {
+		byName[service.ServiceName] = service
+	}
+
+	described := make(map[string]bool, len(serviceNames))
+	for _, serviceName := range serviceNames {
+		storageService := byName[serviceName]
+		if storageService == nil {
 			failures = append(failures, generated.Failure{
-				Arn:    ptr.String(fmt.Sprintf("arn:aws:ecs:%s:%s:service/%s/%s", api.region, api.accountID, clusterName, serviceName)),
+				Arn:    ptr.String(awsarn.Build("ecs", api.region, api.accountFor(ctx), fmt.Sprintf("service/%s/%s", clusterName, serviceName))),
 				Reason: ptr.String("MISSING"),
-				Detail: ptr.String(err.Error()),
+				Detail: ptr.String("service not found"),
 			})
 			continue
 		}
+		if described[serviceName] {
+			continue
+		}
+		described[serviceName] = true
 
 		service := storageServiceToGeneratedService(storageService)
 		if service != nil {
+			service.Events = api.generatedServiceEvents(storageService.ARN)
 			services = append(services, *service)
 		}
 	}
@@ -608,7 +625,7 @@ func (api *DefaultECSAPI) ListServices(ctx context.Context, req *generated.ListS
 		clusterName = extractClusterNameFromARN(*req.Cluster)
 	}
 
-	clusterARN := fmt.Sprintf("arn:aws:ecs:%s:%s:cluster/%s", api.region, api.accountID, clusterName)
+	clusterARN := awsarn.Build("ecs", api.region, api.accountFor(ctx), fmt.Sprintf("cluster/%s", clusterName))
 
 	// Set default limit if not specified
 	limit := 100
@@ -616,10 +633,20 @@ func (api *DefaultECSAPI) ListServices(ctx context.Context, req *generated.ListS
 		limit = int(*req.MaxResults)
 	}
 
+	// KECS extension: filter expression on tags, launch type and status
+	filters, err := parseServiceFilter(serviceFilterFrom(ctx))
+	if err != nil {
+		return nil, &generated.InvalidParameterException{Message: ptr.String(err.Error())}
+	}
+
 	// Extract launch type if specified
-	var launchType string
 	if req.LaunchType != nil {
-		launchType = string(*req.LaunchType)
+		if filters.LaunchType != "" && filters.LaunchType != string(*req.LaunchType) {
+			return nil, &generated.InvalidParameterException{
+				Message: ptr.String(fmt.Sprintf("launchType %s conflicts with launchType=%s of the filter", *req.LaunchType, filters.LaunchType)),
+			}
+		}
+		filters.LaunchType = string(*req.LaunchType)
 	}
 
 	// Extract next token if specified
@@ -629,9 +656,9 @@ func (api *DefaultECSAPI) ListServices(ctx context.Context, req *generated.ListS
 	}
 
 	// Get services from storage
-	storageServices, newNextToken, err := api.storage.ServiceStore().List(ctx, clusterARN, "", launchType, limit, nextToken)
+	storageServices, newNextToken, err := api.storage.ServiceStore().List(ctx, clusterARN, filters, limit, nextToken)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list services: %w", err)
+		return nil, toListError(err, "services")
 	}
 
 	// Extract ARNs
@@ -672,9 +699,9 @@ func (api *DefaultECSAPI) ListServicesByNamespace(ctx context.Context, req *gene
 	}
 
 	// List services by namespace
-	services, newNextToken, err := api.storage.ServiceStore().List(ctx, "", "", "", limit, nextToken)
+	services, newNextToken, err := api.storage.ServiceStore().List(ctx, "", storage.ServiceFilters{}, limit, nextToken)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list services: %w", err)
+		return nil, toListError(err, "services")
 	}
 
 	// Filter by namespace
@@ -722,163 +749,210 @@ func (api *DefaultECSAPI) UpdateService(ctx context.Context, req *generated.Upda
 		return nil, fmt.Errorf("service not found: %w", err)
 	}
 
-	// Track if we need to update Kubernetes resources
-	needsKubernetesUpdate := false
-	oldDesiredCount := existingService.DesiredCount
-	oldTaskDefinitionARN := existingService.TaskDefinitionARN
-
-	// Update fields
-	// Note: DesiredCount can be 0 (to scale down to 0 tasks)
-	if req.DesiredCount != nil && int(*req.DesiredCount) != existingService.DesiredCount {
-		logging.Debug("Updating desired count", "from", existingService.DesiredCount, "to", *req.DesiredCount)
-		existingService.DesiredCount = int(*req.DesiredCount)
-		needsKubernetesUpdate = true
-	}
-
-	if req.TaskDefinition != nil && *req.TaskDefinition != existingService.TaskDefinitionARN {
-		// Convert to ARN if necessary
-		var newTaskDefArn string
-		if !strings.HasPrefix(*req.TaskDefinition, "arn:aws:ecs:") {
-			// Check if it's family:revision or just family
-			if strings.Contains(*req.TaskDefinition, ":") {
-				// family:revision format
-				newTaskDefArn = fmt.Sprintf("arn:aws:ecs:%s:%s:task-definition/%s", api.region, api.accountID, *req.TaskDefinition)
-			} else {
-				// Just family - get latest
-				latestTaskDef, err := api.storage.TaskDefinitionStore().GetLatest(ctx, *req.TaskDefinition)
-				if err != nil || latestTaskDef == nil {
-					return nil, fmt.Errorf("task definition not found: %s", *req.TaskDefinition)
+	if err := api.validateAwsVpcSubnets(req.NetworkConfiguration); err != nil {
+		return nil, err
+	}
+
+	// The changes are applied to the stored service again when it was
+	// modified concurrently, e.g. by the reconciler or another UpdateService
+	var (
+		needsKubernetesUpdate bool
+		oldDesiredCount       int
+		deploymentID          string
+		applyErr              error
+	)
+	applyUpdate := func(existingService *storage.Service) error {
+		// Track if we need to update Kubernetes resources
+		needsKubernetesUpdate = false
+		oldDesiredCount = existingService.DesiredCount
+		oldTaskDefinitionARN := existingService.TaskDefinitionARN
+		oldNetworkConfiguration := existingService.NetworkConfiguration
+		oldLoadBalancers := existingService.LoadBalancers
+		oldVolumeConfigurations := existingService.VolumeConfigurations
+
+		// Update fields
+		// Note: DesiredCount can be 0 (to scale down to 0 tasks)
+		if req.DesiredCount != nil && int(*req.DesiredCount) != existingService.DesiredCount {
+			logging.Debug("Updating desired count", "from", existingService.DesiredCount, "to", *req.DesiredCount)
+			existingService.DesiredCount = int(*req.DesiredCount)
+			needsKubernetesUpdate = true
+		}
+
+		if req.TaskDefinition != nil && *req.TaskDefinition != existingService.TaskDefinitionARN {
+			// Convert to ARN if necessary
+			var newTaskDefArn string
+			if !awsarn.IsService(*req.TaskDefinition, "ecs") {
+				// Check if it's family:revision or just family
+				if strings.Contains(*req.TaskDefinition, ":") {
+					// family:revision format
+					newTaskDefArn = awsarn.Build("ecs", api.region, api.accountFor(ctx), fmt.Sprintf("task-definition/%s", *req.TaskDefinition))
+				} else {
+					// Just family - get latest
+					latestTaskDef, err := api.storage.TaskDefinitionStore().GetLatest(ctx, *req.TaskDefinition)
+					if err != nil || latestTaskDef == nil {
+						return fmt.Errorf("task definition not found: %s", *req.TaskDefinition)
+					}
+					newTaskDefArn = latestTaskDef.ARN
 				}
-				newTaskDefArn = latestTaskDef.ARN
+			} else {
+				newTaskDefArn = *req.TaskDefinition
 			}
-		} else {
-			newTaskDefArn = *req.TaskDefinition
+
+			existingService.TaskDefinitionARN = newTaskDefArn
+			needsKubernetesUpdate = true
 		}
 
-		existingService.TaskDefinitionARN = newTaskDefArn
-		needsKubernetesUpdate = true
-	}
+		if req.PlatformVersion != nil && *req.PlatformVersion != "" {
+			existingService.PlatformVersion = *req.PlatformVersion
+		}
 
-	if req.PlatformVersion != nil && *req.PlatformVersion != "" {
-		existingService.PlatformVersion = *req.PlatformVersion
-	}
+		// A new task definition or platform version has to support the features
+		// of the tasks on Fargate
+		if (req.TaskDefinition != nil || req.PlatformVersion != nil) && existingService.LaunchType == string(generated.LaunchTypeFARGATE) {
+			taskDef, err := api.storage.TaskDefinitionStore().GetByARN(ctx, existingService.TaskDefinitionARN)
+			if err == nil && taskDef != nil {
+				if _, err := validatePlatformVersion(taskDef, ptr.String(existingService.PlatformVersion), nil); err != nil {
+					return err
+				}
+			}
+		}
 
-	// Update complex objects if provided
-	if req.NetworkConfiguration != nil {
-		networkConfigJSON, err := json.Marshal(req.NetworkConfiguration)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal network configuration: %w", err)
+		// Update complex objects if provided
+		if req.NetworkConfiguration != nil {
+			networkConfigJSON, err := json.Marshal(req.NetworkConfiguration)
+			if err != nil {
+				return fmt.Errorf("failed to marshal network configuration: %w", err)
+			}
+			existingService.NetworkConfiguration = string(networkConfigJSON)
+			needsKubernetesUpdate = true
 		}
-		existingService.NetworkConfiguration = string(networkConfigJSON)
-		needsKubernetesUpdate = true
-	}
-	if req.DeploymentConfiguration != nil {
-		deploymentConfigJSON, err := json.Marshal(req.DeploymentConfiguration)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal deployment configuration: %w", err)
+		if req.DeploymentConfiguration != nil {
+			deploymentConfigJSON, err := json.Marshal(req.DeploymentConfiguration)
+			if err != nil {
+				return fmt.Errorf("failed to marshal deployment configuration: %w", err)
+			}
+			existingService.DeploymentConfiguration = string(deploymentConfigJSON)
 		}
-		existingService.DeploymentConfiguration = string(deploymentConfigJSON)
-	}
-	if req.PlacementConstraints != nil {
-		placementConstraintsJSON, err := json.Marshal(req.PlacementConstraints)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal placement constraints: %w", err)
+		if req.PlacementConstraints != nil {
+			placementConstraintsJSON, err := json.Marshal(req.PlacementConstraints)
+			if err != nil {
+				return fmt.Errorf("failed to marshal placement constraints: %w", err)
+			}
+			existingService.PlacementConstraints = string(placementConstraintsJSON)
 		}
-		existingService.PlacementConstraints = string(placementConstraintsJSON)
-	}
-	if req.PlacementStrategy != nil {
-		placementStrategyJSON, err := json.Marshal(req.PlacementStrategy)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal placement strategy: %w", err)
+		if req.PlacementStrategy != nil {
+			placementStrategyJSON, err := json.Marshal(req.PlacementStrategy)
+			if err != nil {
+				return fmt.Errorf("failed to marshal placement strategy: %w", err)
+			}
+			existingService.PlacementStrategy = string(placementStrategyJSON)
 		}
-		existingService.PlacementStrategy = string(placementStrategyJSON)
-	}
-	if req.CapacityProviderStrategy != nil {
-		capacityProviderStrategyJSON, err := json.Marshal(req.CapacityProviderStrategy)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal capacity provider strategy: %w", err)
+		if req.CapacityProviderStrategy != nil {
+			capacityProviderStrategyJSON, err := json.Marshal(req.CapacityProviderStrategy)
+			if err != nil {
+				return fmt.Errorf("failed to marshal capacity provider strategy: %w", err)
+			}
+			existingService.CapacityProviderStrategy = string(capacityProviderStrategyJSON)
 		}
-		existingService.CapacityProviderStrategy = string(capacityProviderStrategyJSON)
-	}
-	if req.LoadBalancers != nil {
-		loadBalancersJSON, err := json.Marshal(req.LoadBalancers)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal load balancers: %w", err)
+		if req.LoadBalancers != nil {
+			loadBalancersJSON, err := json.Marshal(req.LoadBalancers)
+			if err != nil {
+				return fmt.Errorf("failed to marshal load balancers: %w", err)
+			}
+			existingService.LoadBalancers = string(loadBalancersJSON)
+			needsKubernetesUpdate = true
 		}
-		existingService.LoadBalancers = string(loadBalancersJSON)
-		needsKubernetesUpdate = true
-	}
-	if req.ServiceRegistries != nil {
-		serviceRegistriesJSON, err := json.Marshal(req.ServiceRegistries)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal service registries: %w", err)
+		if req.ServiceRegistries != nil {
+			serviceRegistriesJSON, err := json.Marshal(req.ServiceRegistries)
+			if err != nil {
+				return fmt.Errorf("failed to marshal service registries: %w", err)
+			}
+			existingService.ServiceRegistries = string(serviceRegistriesJSON)
 		}
-		existingService.ServiceRegistries = string(serviceRegistriesJSON)
-	}
-	if req.ServiceConnectConfiguration != nil {
-		serviceConnectConfigJSON, err := json.Marshal(req.ServiceConnectConfiguration)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal service connect configuration: %w", err)
+		if req.ServiceConnectConfiguration != nil {
+			serviceConnectConfigJSON, err := json.Marshal(inheritServiceConnectNamespace(cluster, req.ServiceConnectConfiguration))
+			if err != nil {
+				return fmt.Errorf("failed to marshal service connect configuration: %w", err)
+			}
+			existingService.ServiceConnectConfiguration = string(serviceConnectConfigJSON)
+		}
+		if req.VolumeConfigurations != nil {
+			taskDef, err := api.storage.TaskDefinitionStore().GetByARN(ctx, existingService.TaskDefinitionARN)
+			if err != nil || taskDef == nil {
+				return fmt.Errorf("task definition not found: %s", existingService.TaskDefinitionARN)
+			}
+			volumeConfigsJSON, err := validateVolumeConfigurations(taskDef, req.VolumeConfigurations)
+			if err != nil {
+				return err
+			}
+			existingService.VolumeConfigurations = volumeConfigsJSON
+			needsKubernetesUpdate = true
 		}
-		existingService.ServiceConnectConfiguration = string(serviceConnectConfigJSON)
-	}
 
-	if req.EnableECSManagedTags != nil {
-		existingService.EnableECSManagedTags = *req.EnableECSManagedTags
-	}
-	if req.EnableExecuteCommand != nil {
-		existingService.EnableExecuteCommand = *req.EnableExecuteCommand
-	}
-	if req.HealthCheckGracePeriodSeconds != nil {
-		existingService.HealthCheckGracePeriodSeconds = int(*req.HealthCheckGracePeriodSeconds)
-	}
+		if req.EnableECSManagedTags != nil {
+			existingService.EnableECSManagedTags = *req.EnableECSManagedTags
+		}
+		if req.EnableExecuteCommand != nil {
+			existingService.EnableExecuteCommand = *req.EnableExecuteCommand
+		}
+		if req.HealthCheckGracePeriodSeconds != nil {
+			existingService.HealthCheckGracePeriodSeconds = int(*req.HealthCheckGracePeriodSeconds)
+		}
 
-	// Update timestamps
-	existingService.UpdatedAt = time.Now()
+		// Update timestamps
+		existingService.UpdatedAt = deterministic.Now()
 
-	// Update Kubernetes resources if needed
-	if needsKubernetesUpdate {
-		// Update status to show update in progress
-		existingService.Status = "PENDING"
+		// A new task definition or network setup replaces the tasks of the
+		// service, which starts a new deployment
+		deploymentID = ""
+		if existingService.TaskDefinitionARN != oldTaskDefinitionARN ||
+			existingService.NetworkConfiguration != oldNetworkConfiguration ||
+			existingService.LoadBalancers != oldLoadBalancers ||
+			existingService.VolumeConfigurations != oldVolumeConfigurations {
+			deploymentID = servicedeployments.Start(existingService, existingService.UpdatedAt)
+		}
 
-		// Get the task definition
-		taskDef := existingService.TaskDefinitionARN
-		taskDefinition, err := api.storage.TaskDefinitionStore().GetByARN(ctx, taskDef)
-		if err != nil {
-			logging.Error("Failed to get task definition", "taskDefinition", taskDef, "error", err)
-			// Restore old values on failure
-			existingService.DesiredCount = oldDesiredCount
-			existingService.TaskDefinitionARN = oldTaskDefinitionARN
-			return nil, fmt.Errorf("failed to get task definition: %w", err)
+		// Kubernetes resources are updated by the reconciler once the new desired
+		// state is stored
+		if needsKubernetesUpdate {
+			existingService.Status = serviceStatusProvisioning
 		}
 
-		// Create service converter and manager
-		converter := converters.NewServiceConverter(api.region, api.accountID)
-		deployment, kubeService, err := converter.ConvertServiceToDeployment(existingService, taskDefinition, cluster)
-		if err != nil {
-			logging.Error("Failed to convert service to deployment", "error", err)
-			return nil, fmt.Errorf("failed to convert service: %w", err)
+		return nil
+	}
+
+	existingService, err = storage.UpdateService(ctx, api.storage.ServiceStore(), existingService, func(service *storage.Service) error {
+		applyErr = applyUpdate(service)
+		return applyErr
+	})
+	if applyErr != nil {
+		return nil, applyErr
+	}
+	if errors.Is(err, storage.ErrConflict) {
+		return nil, &generated.ConflictException{
+			Message: ptr.String(fmt.Sprintf("The service %s is being modified concurrently, retry the update", req.Service)),
 		}
+	}
+	if err != nil {
+		// Convert storage errors to appropriate ECS errors
+		return nil, toECSError(err, "UpdateService")
+	}
 
-		// Create service manager and update Kubernetes resources
-		serviceManager, err := api.getServiceManager()
+	if needsKubernetesUpdate {
+		reconciled, err := api.applyService(ctx, existingService)
 		if err != nil {
-			logging.Error("Failed to create service manager", "error", err)
-			return nil, fmt.Errorf("failed to create service manager: %w", err)
-		}
-		if err := serviceManager.UpdateService(ctx, deployment, kubeService, cluster, existingService); err != nil {
-			logging.Error("Failed to update kubernetes deployment", "error", err)
 			return nil, fmt.Errorf("failed to update kubernetes deployment: %w", err)
 		}
-
-		// Update status to ACTIVE after successful update
-		existingService.Status = "ACTIVE"
+		existingService = reconciled
 	}
 
-	// Single update at the end
-	if err := api.storage.ServiceStore().Update(ctx, existingService); err != nil {
-		// Convert storage errors to appropriate ECS errors
-		return nil, toECSError(err, "UpdateService")
+	if existingService.DesiredCount != oldDesiredCount {
+		api.serviceEvents.Record(existingService.ARN,
+			serviceevents.DesiredCountChanged(existingService.ServiceName, oldDesiredCount, existingService.DesiredCount))
+	}
+	if deploymentID != "" {
+		api.serviceEvents.Record(existingService.ARN,
+			serviceevents.DeploymentStarted(existingService.ServiceName, deploymentID))
 	}
 
 	// Convert back to API response
@@ -1059,7 +1133,7 @@ func (api *DefaultECSAPI) DescribeServiceDeployments(ctx context.Context, req *g
 		// Add deployment ID to deployment
 		deployment.SourceServiceRevisions = []generated.ServiceRevisionSummary{
 			{
-				Arn:                ptr.String(fmt.Sprintf("arn:aws:ecs:%s:%s:service-revision/%s/%s/%s", api.region, api.accountID, clusterName, serviceName, deploymentID)),
+				Arn:                ptr.String(awsarn.Build("ecs", api.region, api.accountFor(ctx), fmt.Sprintf("service-revision/%s/%s/%s", clusterName, serviceName, deploymentID))),
 				RequestedTaskCount: ptr.Int32(int32(service.DesiredCount)),
 				RunningTaskCount:   ptr.Int32(int32(service.RunningCount)),
 				PendingTaskCount:   ptr.Int32(int32(service.PendingCount)),
@@ -1200,6 +1274,12 @@ func (api *DefaultECSAPI) DescribeServiceRevisions(ctx context.Context, req *gen
 
 		// Add revision-specific metadata
 		revision.VolumeConfigurations = []generated.ServiceVolumeConfiguration{}
+		if service.VolumeConfigurations != "" {
+			var volumeConfigs []generated.ServiceVolumeConfiguration
+			if err := json.Unmarshal([]byte(service.VolumeConfigurations), &volumeConfigs); err == nil {
+				revision.VolumeConfigurations = volumeConfigs
+			}
+		}
 
 		revisions = append(revisions, revision)
 	}
@@ -1242,13 +1322,13 @@ func (api *DefaultECSAPI) ListServiceDeployments(ctx context.Context, req *gener
 	// Current deployment
 	currentStatus := generated.ServiceDeploymentStatusSUCCESSFUL
 	currentDeployment := generated.ServiceDeploymentBrief{
-		ServiceDeploymentArn:     ptr.String(fmt.Sprintf("arn:aws:ecs:%s:%s:service-deployment/%s/%s/current", api.region, api.accountID, clusterName, service.ServiceName)),
+		ServiceDeploymentArn:     ptr.String(awsarn.Build("ecs", api.region, api.accountFor(ctx), fmt.Sprintf("service-deployment/%s/%s/current", clusterName, service.ServiceName))),
 		ServiceArn:               ptr.String(service.ARN),
 		ClusterArn:               ptr.String(cluster.ARN),
 		Status:                   &currentStatus,
 		CreatedAt:                ptr.UnixTime(service.UpdatedAt),
 		StartedAt:                ptr.UnixTime(service.UpdatedAt),
-		TargetServiceRevisionArn: ptr.String(fmt.Sprintf("arn:aws:ecs:%s:%s:service-revision/%s/%s/current", api.region, api.accountID, clusterName, service.ServiceName)),
+		TargetServiceRevisionArn: ptr.String(awsarn.Build("ecs", api.region, api.accountFor(ctx), fmt.Sprintf("service-revision/%s/%s/current", clusterName, service.ServiceName))),
 	}
 	deployments = append(deployments, currentDeployment)
 
@@ -1257,14 +1337,14 @@ func (api *DefaultECSAPI) ListServiceDeployments(ctx context.Context, req *gener
 	if service.UpdatedAt.After(service.CreatedAt) {
 		prevStatus := generated.ServiceDeploymentStatusSUCCESSFUL
 		prevDeployment := generated.ServiceDeploymentBrief{
-			ServiceDeploymentArn:     ptr.String(fmt.Sprintf("arn:aws:ecs:%s:%s:service-deployment/%s/%s/previous-1", api.region, api.accountID, clusterName, service.ServiceName)),
+			ServiceDeploymentArn:     ptr.String(awsarn.Build("ecs", api.region, api.accountFor(ctx), fmt.Sprintf("service-deployment/%s/%s/previous-1", clusterName, service.ServiceName))),
 			ServiceArn:               ptr.String(service.ARN),
 			ClusterArn:               ptr.String(cluster.ARN),
 			Status:                   &prevStatus,
 			CreatedAt:                ptr.UnixTime(service.CreatedAt),
 			StartedAt:                ptr.UnixTime(service.CreatedAt),
 			FinishedAt:               ptr.UnixTime(service.UpdatedAt.Add(-1 * time.Hour)), // Simulate finished 1 hour before update
-			TargetServiceRevisionArn: ptr.String(fmt.Sprintf("arn:aws:ecs:%s:%s:service-revision/%s/%s/previous-1", api.region, api.accountID, clusterName, service.ServiceName)),
+			TargetServiceRevisionArn: ptr.String(awsarn.Build("ecs", api.region, api.accountFor(ctx), fmt.Sprintf("service-revision/%s/%s/previous-1", clusterName, service.ServiceName))),
 		}
 		deployments = append(deployments, prevDeployment)
 	}
@@ -1345,6 +1425,24 @@ func (api *DefaultECSAPI) StopServiceDeployment(ctx context.Context, req *genera
 	}, nil
 }
 
+// generatedServiceEvents returns the recorded events of a service in API form, newest first
+func (api *DefaultECSAPI) generatedServiceEvents(serviceARN string) []generated.ServiceEvent {
+	events := api.serviceEvents.Events(serviceARN)
+	if len(events) == 0 {
+		return nil
+	}
+
+	result := make([]generated.ServiceEvent, 0, len(events))
+	for _, event := range events {
+		result = append(result, generated.ServiceEvent{
+			Id:        ptr.String(event.ID),
+			CreatedAt: &common.UnixTime{Time: event.CreatedAt},
+			Message:   ptr.String(event.Message),
+		})
+	}
+	return result
+}
+
 // storageServiceToGeneratedService converts a storage.Service to generated.Service
 func storageServiceToGeneratedService(storageService *storage.Service) *generated.Service {
 	if storageService == nil {
@@ -1367,8 +1465,10 @@ func storageServiceToGeneratedService(storageService *storage.Service) *generate
 		CreatedAt:                     ptr.UnixTime(storageService.CreatedAt),
 	}
 
-	// Set optional fields
-	if storageService.LaunchType != "" {
+	// Set optional fields. As in ECS, services placed by a capacity provider
+	// strategy report the strategy instead of a launch type.
+	capacityProviderStrategy := converters.ParseCapacityProviderStrategy(storageService.CapacityProviderStrategy)
+	if storageService.LaunchType != "" && len(capacityProviderStrategy) == 0 {
 		launchType := generated.LaunchType(storageService.LaunchType)
 		service.LaunchType = &launchType
 	}
@@ -1432,11 +1532,8 @@ func storageServiceToGeneratedService(storageService *storage.Service) *generate
 			service.PlacementStrategy = placementStrategy
 		}
 	}
-	if storageService.CapacityProviderStrategy != "" && storageService.CapacityProviderStrategy != "null" {
-		var capacityProviderStrategy []generated.CapacityProviderStrategyItem
-		if err := json.Unmarshal([]byte(storageService.CapacityProviderStrategy), &capacityProviderStrategy); err == nil {
-			service.CapacityProviderStrategy = capacityProviderStrategy
-		}
+	if len(capacityProviderStrategy) > 0 {
+		service.CapacityProviderStrategy = capacityProviderStrategy
 	}
 	if storageService.Tags != "" && storageService.Tags != "null" {
 		var tags []generated.Tag
@@ -1447,30 +1544,69 @@ func storageServiceToGeneratedService(storageService *storage.Service) *generate
 
 	// Add deployment information
 	// In AWS ECS, there's always at least one deployment representing the current state
-	deployment := generated.Deployment{
-		Id:             ptr.String(fmt.Sprintf("ecs-svc/%s", storageService.ServiceName)),
-		Status:         ptr.String("PRIMARY"),
-		TaskDefinition: ptr.String(storageService.TaskDefinitionARN),
-		DesiredCount:   ptr.Int32(int32(storageService.DesiredCount)),
-		RunningCount:   ptr.Int32(int32(storageService.RunningCount)),
-		PendingCount:   ptr.Int32(int32(storageService.PendingCount)),
-		CreatedAt:      ptr.UnixTime(storageService.CreatedAt),
-		UpdatedAt:      ptr.UnixTime(storageService.UpdatedAt),
-	}
-
-	if storageService.LaunchType != "" {
-		launchType := generated.LaunchType(storageService.LaunchType)
-		deployment.LaunchType = &launchType
+	var serviceConnectConfig *generated.ServiceConnectConfiguration
+	if storageService.ServiceConnectConfiguration != "" && storageService.ServiceConnectConfiguration != "null" {
+		var config generated.ServiceConnectConfiguration
+		if err := json.Unmarshal([]byte(storageService.ServiceConnectConfiguration), &config); err == nil {
+			serviceConnectConfig = &config
+		}
 	}
-	if storageService.PlatformVersion != "" {
-		deployment.PlatformVersion = ptr.String(storageService.PlatformVersion)
+	var volumeConfigs []generated.ServiceVolumeConfiguration
+	if storageService.VolumeConfigurations != "" {
+		var configs []generated.ServiceVolumeConfiguration
+		if err := json.Unmarshal([]byte(storageService.VolumeConfigurations), &configs); err == nil {
+			volumeConfigs = configs
+		}
 	}
-
-	service.Deployments = []generated.Deployment{deployment}
+	deployments := servicedeployments.List(storageService)
+	for i := range deployments {
+		if len(capacityProviderStrategy) > 0 {
+			deployments[i].CapacityProviderStrategy = capacityProviderStrategy
+			deployments[i].LaunchType = nil
+		}
+		deployments[i].ServiceConnectConfiguration = serviceConnectConfig
+		deployments[i].VolumeConfigurations = volumeConfigs
+	}
+	service.Deployments = deployments
 
 	return service
 }
 
+// validateServiceRegistries checks the registries of a service against the
+// Cloud Map services they name. A service with SRV records needs the port
+// to publish, given as port or as containerName and containerPort.
+func (api *DefaultECSAPI) validateServiceRegistries(ctx context.Context, serviceRegistries []generated.ServiceRegistry) error {
+	for _, registry := range serviceRegistries {
+		if (registry.ContainerName == nil) != (registry.ContainerPort == nil) {
+			return &generated.InvalidParameterException{
+				Message: ptr.String("containerName and containerPort must be specified together in a service registry"),
+			}
+		}
+		if api.serviceDiscoveryManager == nil || registry.RegistryArn == nil {
+			continue
+		}
+
+		serviceID := (*registry.RegistryArn)[strings.LastIndex(*registry.RegistryArn, "/")+1:]
+		discoveryService, err := api.serviceDiscoveryManager.GetService(ctx, serviceID)
+		if err != nil {
+			return &generated.InvalidParameterException{
+				Message: ptr.String(fmt.Sprintf("service registry %s does not exist", *registry.RegistryArn)),
+			}
+		}
+		if discoveryService.DNSConfig == nil || registry.Port != nil || registry.ContainerPort != nil {
+			continue
+		}
+		for _, record := range discoveryService.DNSConfig.DNSRecords {
+			if record.Type == "SRV" {
+				return &generated.InvalidParameterException{
+					Message: ptr.String(fmt.Sprintf("service registry %s has SRV records: specify a port, or a containerName and containerPort", *registry.RegistryArn)),
+				}
+			}
+		}
+	}
+	return nil
+}
+
 // registerServiceWithDiscovery registers the service with service discovery
 func (api *DefaultECSAPI) registerServiceWithDiscovery(ctx context.Context, service *storage.Service, serviceRegistries []generated.ServiceRegistry) error {
 	// Check if service discovery manager is available
@@ -1479,6 +1615,7 @@ func (api *DefaultECSAPI) registerServiceWithDiscovery(ctx context.Context, serv
 	}
 
 	// For each service registry
+	registryMetadata := make(map[string]string)
 	for _, registry := range serviceRegistries {
 		if registry.RegistryArn == nil {
 			continue
@@ -1506,10 +1643,6 @@ func (api *DefaultECSAPI) registerServiceWithDiscovery(ctx context.Context, serv
 
 		// Store the service registry information in service metadata
 		// This will be used by tasks when they start
-		if service.ServiceRegistryMetadata == nil {
-			service.ServiceRegistryMetadata = make(map[string]string)
-		}
-
 		containerName := ""
 		if registry.ContainerName != nil {
 			containerName = *registry.ContainerName
@@ -1518,8 +1651,12 @@ func (api *DefaultECSAPI) registerServiceWithDiscovery(ctx context.Context, serv
 		if registry.ContainerPort != nil {
 			containerPort = *registry.ContainerPort
 		}
-		service.ServiceRegistryMetadata[serviceID] = fmt.Sprintf("{\"containerName\":\"%s\",\"containerPort\":%d}",
-			containerName, containerPort)
+		port := int32(0)
+		if registry.Port != nil {
+			port = *registry.Port
+		}
+		registryMetadata[serviceID] = fmt.Sprintf("{\"containerName\":\"%s\",\"containerPort\":%d,\"port\":%d}",
+			containerName, containerPort, port)
 
 		// Update Service Discovery ExternalName to point to actual ECS service
 		// ECS service Kubernetes FQDN format: <service-name>.default-<region>.svc.cluster.local
@@ -1551,7 +1688,16 @@ func (api *DefaultECSAPI) registerServiceWithDiscovery(ctx context.Context, serv
 	}
 
 	// Update service in storage with registry metadata
-	if err := api.storage.ServiceStore().Update(ctx, service); err != nil {
+	_, err := storage.UpdateService(ctx, api.storage.ServiceStore(), service, func(current *storage.Service) error {
+		if current.ServiceRegistryMetadata == nil {
+			current.ServiceRegistryMetadata = make(map[string]string)
+		}
+		for serviceID, metadata := range registryMetadata {
+			current.ServiceRegistryMetadata[serviceID] = metadata
+		}
+		return nil
+	})
+	if err != nil {
 		return fmt.Errorf("failed to update service with registry metadata: %w", err)
 	}
 
@@ -1567,10 +1713,11 @@ func (api *DefaultECSAPI) createTasksForService(ctx context.Context, service *st
 	}
 
 	// In test mode, we create tasks directly in storage without kubernetes resources
+	var startedTaskIDs []string
 	for i := 0; i < service.DesiredCount; i++ {
 		// Generate task ID
-		taskID := uuid.New().String()
-		taskARN := fmt.Sprintf("arn:aws:ecs:%s:%s:task/%s/%s", api.region, api.accountID, cluster.Name, taskID)
+		taskID := deterministic.NewUUID()
+		taskARN := awsarn.Build("ecs", api.region, api.accountFor(ctx), fmt.Sprintf("task/%s/%s", cluster.Name, taskID))
 
 		// Build initial container status using generated.Container type
 		var containers []generated.Container
@@ -1599,7 +1746,7 @@ func (api *DefaultECSAPI) createTasksForService(ctx context.Context, service *st
 		containersJSON, _ := json.Marshal(containers)
 
 		// Create storage task
-		now := time.Now()
+		now := deterministic.Now()
 		task := &storage.Task{
 			ID:                   taskID,
 			ARN:                  taskARN,
@@ -1617,9 +1764,12 @@ func (api *DefaultECSAPI) createTasksForService(ctx context.Context, service *st
 			Group:                fmt.Sprintf("service:%s", service.ServiceName),
 			Containers:           string(containersJSON),
 			Region:               api.region,
-			AccountID:            api.accountID,
+			AccountID:            api.accountFor(ctx),
 			ServiceRegistries:    service.ServiceRegistries, // Propagate service registries
 		}
+		if service.LaunchType == string(generated.LaunchTypeFARGATE) {
+			task.PlatformVersion, _ = converters.ResolvePlatformVersion(service.PlatformVersion)
+		}
 
 		// Save task to storage
 		if err := api.storage.TaskStore().Create(ctx, task); err != nil {
@@ -1627,15 +1777,23 @@ func (api *DefaultECSAPI) createTasksForService(ctx context.Context, service *st
 		}
 
 		logging.Debug("Created task for service in test mode", "taskId", taskID, "service", service.ServiceName)
+		startedTaskIDs = append(startedTaskIDs, taskID)
 	}
 
-	// Update service counts
-	service.RunningCount = service.DesiredCount
-	service.PendingCount = 0
-	service.Status = "ACTIVE"
-	service.UpdatedAt = time.Now()
+	if len(startedTaskIDs) > 0 {
+		api.serviceEvents.Record(service.ARN, serviceevents.TasksStarted(service.ServiceName, startedTaskIDs))
+	}
 
-	if err := api.storage.ServiceStore().Update(ctx, service); err != nil {
+	// Update service counts
+	runningCount := len(startedTaskIDs)
+	_, err := storage.UpdateService(ctx, api.storage.ServiceStore(), service, func(current *storage.Service) error {
+		current.RunningCount = runningCount
+		current.PendingCount = 0
+		current.Status = "ACTIVE"
+		current.UpdatedAt = deterministic.Now()
+		return nil
+	})
+	if err != nil {
 		return fmt.Errorf("failed to update service counts: %w", err)
 	}
 
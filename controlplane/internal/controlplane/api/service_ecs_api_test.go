@@ -2,6 +2,11 @@ package api
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -9,8 +14,12 @@ import (
 
 	"github.com/nandemo-ya/kecs/controlplane/internal/config"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/mocks"
+	"github.com/nandemo-ya/kecs/controlplane/internal/servicediscovery"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage/cache"
+	"github.com/nandemo-ya/kecs/controlplane/internal/vpc"
 )
 
 var _ = Describe("Service ECS API", func() {
@@ -71,6 +80,199 @@ var _ = Describe("Service ECS API", func() {
 		Expect(err).To(BeNil())
 	})
 
+	Describe("DescribeServices", func() {
+		It("should describe services by name and ARN with failures for missing ones", func() {
+			resp, err := server.ecsAPI.DescribeServices(ctx, &generated.DescribeServicesRequest{
+				Services: []string{
+					"arn:aws:ecs:us-east-1:000000000000:service/default/test-service",
+					"missing-service",
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Services).To(HaveLen(1))
+			Expect(*resp.Services[0].ServiceName).To(Equal("test-service"))
+			Expect(resp.Failures).To(HaveLen(1))
+			Expect(*resp.Failures[0].Arn).To(HaveSuffix("service/default/missing-service"))
+			Expect(*resp.Failures[0].Reason).To(Equal("MISSING"))
+		})
+
+		It("should reject more than 10 services", func() {
+			services := make([]string, 11)
+			for i := range services {
+				services[i] = "test-service"
+			}
+			_, err := server.ecsAPI.DescribeServices(ctx, &generated.DescribeServicesRequest{Services: services})
+			Expect(err).To(BeAssignableToTypeOf(&generated.InvalidParameterException{}))
+		})
+	})
+
+	Describe("UpdateService", func() {
+		var racingStore *racingServiceStore
+
+		BeforeEach(func() {
+			racingStore = &racingServiceStore{MockServiceStore: mockServiceStore}
+			mockStorage.SetServiceStore(racingStore)
+		})
+
+		It("should keep a concurrent change to other fields", func() {
+			racingStore.races = 1
+
+			resp, err := server.ecsAPI.UpdateService(ctx, &generated.UpdateServiceRequest{
+				Service:                       "test-service",
+				HealthCheckGracePeriodSeconds: ptr.Int32(60),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*resp.Service.HealthCheckGracePeriodSeconds).To(Equal(int32(60)))
+			Expect(*resp.Service.DesiredCount).To(Equal(int32(5)))
+
+			stored, err := mockServiceStore.Get(ctx, "arn:aws:ecs:us-east-1:000000000000:cluster/default", "test-service")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stored.DesiredCount).To(Equal(5))
+			Expect(stored.HealthCheckGracePeriodSeconds).To(Equal(60))
+			Expect(stored.Version).To(Equal(int64(3)))
+		})
+
+		It("should keep both of two concurrent updates", func() {
+			mockStorage.SetServiceStore(mockServiceStore)
+
+			var wg sync.WaitGroup
+			errs := make([]error, 2)
+			for i, req := range []*generated.UpdateServiceRequest{
+				{Service: "test-service", HealthCheckGracePeriodSeconds: ptr.Int32(60)},
+				{Service: "test-service", EnableExecuteCommand: ptr.Bool(true)},
+			} {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, errs[i] = server.ecsAPI.UpdateService(ctx, req)
+				}()
+			}
+			wg.Wait()
+			Expect(errs).To(HaveEach(BeNil()))
+
+			stored, err := mockServiceStore.Get(ctx, "arn:aws:ecs:us-east-1:000000000000:cluster/default", "test-service")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stored.HealthCheckGracePeriodSeconds).To(Equal(60))
+			Expect(stored.EnableExecuteCommand).To(BeTrue())
+			Expect(stored.Version).To(Equal(int64(3)))
+		})
+
+		It("should detect a stale service read through the storage cache", func() {
+			clusterARN := "arn:aws:ecs:us-east-1:000000000000:cluster/default"
+			mockStorage.SetServiceStore(mockServiceStore)
+			services := cache.NewCachedStorage(mockStorage, 100, time.Minute).ServiceStore()
+
+			first, err := services.Get(ctx, clusterARN, "test-service")
+			Expect(err).NotTo(HaveOccurred())
+			second, err := services.Get(ctx, clusterARN, "test-service")
+			Expect(err).NotTo(HaveOccurred())
+
+			first.DesiredCount = 3
+			Expect(services.Update(ctx, first)).To(Succeed())
+			second.DesiredCount = 7
+			Expect(services.Update(ctx, second)).To(MatchError(storage.ErrConflict))
+
+			stored, err := services.Get(ctx, clusterARN, "test-service")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stored.DesiredCount).To(Equal(3))
+		})
+
+		It("should return a ConflictException when the service keeps changing", func() {
+			racingStore.races = 100
+
+			_, err := server.ecsAPI.UpdateService(ctx, &generated.UpdateServiceRequest{
+				Service:                       "test-service",
+				HealthCheckGracePeriodSeconds: ptr.Int32(60),
+			})
+			Expect(err).To(BeAssignableToTypeOf(&generated.ConflictException{}))
+		})
+
+		It("should reject subnets unknown to the VPC registry", func() {
+			server.ecsAPI.(*DefaultECSAPI).SetVPCRegistry(vpc.NewRegistry("us-east-1"))
+			config.Set("network.validateSubnets", true)
+			DeferCleanup(config.Set, "network.validateSubnets", false)
+
+			_, err := server.ecsAPI.UpdateService(ctx, &generated.UpdateServiceRequest{
+				Service: "test-service",
+				NetworkConfiguration: &generated.NetworkConfiguration{
+					AwsvpcConfiguration: &generated.AwsVpcConfiguration{Subnets: []string{"subnet-unknown"}},
+				},
+			})
+			Expect(err).To(BeAssignableToTypeOf(&generated.InvalidParameterException{}))
+		})
+	})
+
+	Describe("ListServices", func() {
+		BeforeEach(func() {
+			for _, service := range []*storage.Service{
+				{ServiceName: "payments", LaunchType: "FARGATE", Status: "ACTIVE", Tags: `[{"key":"team","value":"payments"},{"key":"env","value":"prod"}]`},
+				{ServiceName: "payments-staging", LaunchType: "EC2", Status: "ACTIVE", Tags: `[{"key":"team","value":"payments"},{"key":"env","value":"staging"}]`},
+				{ServiceName: "search", LaunchType: "FARGATE", Status: "DRAINING", Tags: `[{"key":"team","value":"search team"}]`},
+			} {
+				service.ARN = "arn:aws:ecs:us-east-1:000000000000:service/default/" + service.ServiceName
+				service.ClusterARN = "arn:aws:ecs:us-east-1:000000000000:cluster/default"
+				service.CreatedAt = time.Now()
+				Expect(mockServiceStore.Create(ctx, service)).To(Succeed())
+			}
+		})
+
+		listServices := func(body string) (int, map[string]interface{}) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+			req.Header.Set("X-Amz-Target", "AmazonEC2ContainerServiceV20141113.ListServices")
+			rec := httptest.NewRecorder()
+			generated.NewRouter(server.ecsAPI).Route(rec, withServiceFilter(req))
+			var resp map[string]interface{}
+			Expect(json.Unmarshal(rec.Body.Bytes(), &resp)).To(Succeed())
+			return rec.Code, resp
+		}
+
+		serviceNames := func(resp map[string]interface{}) []string {
+			var names []string
+			for _, arn := range resp["serviceArns"].([]interface{}) {
+				names = append(names, arn.(string)[strings.LastIndex(arn.(string), "/")+1:])
+			}
+			return names
+		}
+
+		It("should list services matching the filter expression", func() {
+			code, resp := listServices(`{"cluster":"default","filter":"tag:team=payments launchType=FARGATE"}`)
+			Expect(code).To(Equal(http.StatusOK))
+			Expect(serviceNames(resp)).To(ConsistOf("payments"))
+
+			_, resp = listServices(`{"cluster":"default","filter":"tag:env status=active"}`)
+			Expect(serviceNames(resp)).To(ConsistOf("payments", "payments-staging"))
+
+			_, resp = listServices(`{"cluster":"default","filter":"tag:team=\"search team\""}`)
+			Expect(serviceNames(resp)).To(ConsistOf("search"))
+		})
+
+		It("should combine the filter with the launch type of the request", func() {
+			_, resp := listServices(`{"cluster":"default","launchType":"EC2","filter":"tag:team=payments"}`)
+			Expect(serviceNames(resp)).To(ConsistOf("payments-staging"))
+		})
+
+		It("should list all services without a filter", func() {
+			_, resp := listServices(`{"cluster":"default"}`)
+			Expect(resp["serviceArns"]).To(HaveLen(4))
+		})
+
+		DescribeTable("invalid filters",
+			func(filter, message string) {
+				_, err := server.ecsAPI.ListServices(
+					context.WithValue(ctx, serviceFilterKey{}, filter),
+					&generated.ListServicesRequest{Cluster: ptr.String("default")},
+				)
+				Expect(err).To(BeAssignableToTypeOf(&generated.InvalidParameterException{}))
+				Expect(*err.(*generated.InvalidParameterException).Message).To(ContainSubstring(message))
+			},
+			Entry("unknown field", "owner=me", "unknown field owner"),
+			Entry("bare word", "payments", "expected tag:KEY[=VALUE]"),
+			Entry("empty tag key", "tag:=x", "tag key is empty"),
+			Entry("invalid launch type", "launchType=LAMBDA", "launch type must be"),
+			Entry("unterminated quote", `tag:team="search`, "unterminated quote"),
+		)
+	})
+
 	Describe("ListServicesByNamespace", func() {
 		Context("when listing services by namespace", func() {
 			BeforeEach(func() {
@@ -364,4 +566,93 @@ var _ = Describe("Service ECS API", func() {
 			})
 		})
 	})
+
+	Describe("storageServiceToGeneratedService", func() {
+		It("should report the capacity provider strategy instead of the launch type", func() {
+			service := storageServiceToGeneratedService(&storage.Service{
+				ServiceName:              "web",
+				LaunchType:               "FARGATE",
+				CapacityProviderStrategy: `[{"capacityProvider":"FARGATE_SPOT","weight":1}]`,
+			})
+			Expect(service.LaunchType).To(BeNil())
+			Expect(service.CapacityProviderStrategy).To(HaveLen(1))
+			Expect(service.CapacityProviderStrategy[0].CapacityProvider).To(Equal("FARGATE_SPOT"))
+			Expect(service.Deployments[0].CapacityProviderStrategy).To(HaveLen(1))
+			Expect(service.Deployments[0].LaunchType).To(BeNil())
+		})
+	})
+
+	Describe("validateServiceRegistries", func() {
+		var registryArn string
+
+		BeforeEach(func() {
+			sdManager := servicediscovery.NewManager(nil, "us-east-1", "000000000000", "")
+			namespace, err := sdManager.CreatePrivateDnsNamespace(ctx, "demo.local", "vpc-123", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sdManager.CreateService(ctx, &servicediscovery.Service{
+				ID:          "srv-backend",
+				Name:        "backend",
+				NamespaceID: namespace.ID,
+				DNSConfig: &servicediscovery.DNSConfig{
+					DNSRecords: []servicediscovery.DNSRecord{{Type: "A", TTL: 60}, {Type: "SRV", TTL: 60}},
+				},
+			})).To(Succeed())
+
+			ecsAPI := server.ecsAPI.(*DefaultECSAPI)
+			ecsAPI.SetServiceDiscoveryManager(sdManager)
+			registryArn = "arn:aws:servicediscovery:us-east-1:000000000000:service/srv-backend"
+		})
+
+		It("should accept SRV registries with a port or container port", func() {
+			ecsAPI := server.ecsAPI.(*DefaultECSAPI)
+			Expect(ecsAPI.validateServiceRegistries(ctx, []generated.ServiceRegistry{
+				{RegistryArn: ptr.String(registryArn), Port: ptr.Int32(8080)},
+			})).To(Succeed())
+			Expect(ecsAPI.validateServiceRegistries(ctx, []generated.ServiceRegistry{
+				{RegistryArn: ptr.String(registryArn), ContainerName: ptr.String("web"), ContainerPort: ptr.Int32(8080)},
+			})).To(Succeed())
+		})
+
+		It("should reject SRV registries without a port", func() {
+			ecsAPI := server.ecsAPI.(*DefaultECSAPI)
+			err := ecsAPI.validateServiceRegistries(ctx, []generated.ServiceRegistry{
+				{RegistryArn: ptr.String(registryArn)},
+			})
+			Expect(err).To(BeAssignableToTypeOf(&generated.InvalidParameterException{}))
+			Expect(*err.(*generated.InvalidParameterException).Message).To(ContainSubstring("has SRV records"))
+
+			err = ecsAPI.validateServiceRegistries(ctx, []generated.ServiceRegistry{
+				{RegistryArn: ptr.String(registryArn), ContainerName: ptr.String("web")},
+			})
+			Expect(*err.(*generated.InvalidParameterException).Message).To(ContainSubstring("must be specified together"))
+
+			err = ecsAPI.validateServiceRegistries(ctx, []generated.ServiceRegistry{
+				{RegistryArn: ptr.String("arn:aws:servicediscovery:us-east-1:000000000000:service/srv-missing"), Port: ptr.Int32(8080)},
+			})
+			Expect(*err.(*generated.InvalidParameterException).Message).To(ContainSubstring("does not exist"))
+		})
+	})
 })
+
+// racingServiceStore lets another writer change the desired count of a
+// service before each of the next races updates, as a concurrent
+// UpdateService would
+type racingServiceStore struct {
+	*mocks.MockServiceStore
+	races int
+}
+
+func (s *racingServiceStore) Update(ctx context.Context, service *storage.Service) error {
+	if s.races > 0 {
+		s.races--
+		other, err := s.GetByARN(ctx, service.ARN)
+		if err != nil {
+			return err
+		}
+		other.DesiredCount = 5
+		if err := s.MockServiceStore.Update(ctx, other); err != nil {
+			return err
+		}
+	}
+	return s.MockServiceStore.Update(ctx, service)
+}
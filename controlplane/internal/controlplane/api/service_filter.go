@@ -0,0 +1,142 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// serviceFilterKey is the context key of the filter expression of a
+// ListServices request. ListServices accepts a KECS extension "filter" member
+// next to the ECS request members, an expression of space separated terms
+// that services must all match:
+//
+//	tag:team=payments tag:env launchType=FARGATE status=ACTIVE
+//
+// tag:KEY matches services with the tag key and any value. Values with spaces
+// are quoted, tag:owner="Platform Team".
+type serviceFilterKey struct{}
+
+// withServiceFilter adds the filter expression of a ListServices request to
+// its context, leaving the body for the generated router to decode
+func withServiceFilter(r *http.Request) *http.Request {
+	if r.Body == nil || !isECSAction(r, "ListServices") {
+		return r
+	}
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return r
+	}
+
+	var extension struct {
+		Filter *string `json:"filter"`
+	}
+	if json.Unmarshal(body, &extension) != nil || extension.Filter == nil {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), serviceFilterKey{}, *extension.Filter))
+}
+
+// serviceFilterFrom returns the filter expression of a ListServices request
+func serviceFilterFrom(ctx context.Context) string {
+	filter, _ := ctx.Value(serviceFilterKey{}).(string)
+	return filter
+}
+
+// isECSAction reports whether a request calls an ECS operation, by target or
+// /v1/ path
+func isECSAction(r *http.Request, action string) bool {
+	if target := r.Header.Get("X-Amz-Target"); target != "" {
+		return strings.HasSuffix(target, "."+action)
+	}
+	return r.URL.Path == "/v1/"+action
+}
+
+// parseServiceFilter parses a ListServices filter expression
+func parseServiceFilter(expr string) (storage.ServiceFilters, error) {
+	var filters storage.ServiceFilters
+	terms, err := splitFilterTerms(expr)
+	if err != nil {
+		return filters, err
+	}
+
+	for _, term := range terms {
+		if tag, ok := strings.CutPrefix(term, "tag:"); ok {
+			key, value, _ := strings.Cut(tag, "=")
+			if key == "" {
+				return filters, fmt.Errorf("invalid filter term %q: tag key is empty", term)
+			}
+			if filters.Tags == nil {
+				filters.Tags = make(map[string]string)
+			}
+			filters.Tags[key] = value
+			continue
+		}
+
+		name, value, ok := strings.Cut(term, "=")
+		if !ok || value == "" {
+			return filters, fmt.Errorf("invalid filter term %q: expected tag:KEY[=VALUE], launchType=VALUE or status=VALUE", term)
+		}
+		switch name {
+		case "launchType":
+			if !isValidLaunchType(value) {
+				return filters, fmt.Errorf("invalid filter term %q: launch type must be EC2, FARGATE or EXTERNAL", term)
+			}
+			filters.LaunchType = value
+		case "status":
+			filters.Status = strings.ToUpper(value)
+		default:
+			return filters, fmt.Errorf("invalid filter term %q: unknown field %s", term, name)
+		}
+	}
+	return filters, nil
+}
+
+// splitFilterTerms splits a filter expression at spaces outside of double
+// quotes, removing the quotes
+func splitFilterTerms(expr string) ([]string, error) {
+	var terms []string
+	var term strings.Builder
+	inTerm, quoted := false, false
+	for _, c := range expr {
+		switch {
+		case c == '"':
+			quoted = !quoted
+			inTerm = true
+		case (c == ' ' || c == '\t') && !quoted:
+			if inTerm {
+				terms = append(terms, term.String())
+				term.Reset()
+				inTerm = false
+			}
+		default:
+			term.WriteRune(c)
+			inTerm = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("invalid filter %q: unterminated quote", expr)
+	}
+	if inTerm {
+		terms = append(terms, term.String())
+	}
+	return terms, nil
+}
+
+// isValidLaunchType reports whether s is an ECS launch type
+func isValidLaunchType(s string) bool {
+	switch generated.LaunchType(s) {
+	case generated.LaunchTypeEC2, generated.LaunchTypeFARGATE, generated.LaunchTypeEXTERNAL:
+		return true
+	}
+	return false
+}
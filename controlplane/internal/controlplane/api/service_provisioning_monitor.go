@@ -0,0 +1,226 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/notifications"
+	"github.com/nandemo-ya/kecs/controlplane/internal/servicedeployments"
+	"github.com/nandemo-ya/kecs/controlplane/internal/serviceevents"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// errNoStalledDeployment stops the update of a service whose stalled
+// deployment was completed or failed meanwhile
+var errNoStalledDeployment = errors.New("service has no stalled deployment")
+
+// containerWaitingErrors are the waiting reasons of containers that will not
+// start without a change to the service
+var containerWaitingErrors = []string{
+	"CrashLoopBackOff",
+	"ErrImagePull",
+	"ImagePullBackOff",
+	"InvalidImageName",
+	"CreateContainerConfigError",
+	"CreateContainerError",
+	"RunContainerError",
+}
+
+// ServiceProvisioningMonitor fails the deployments that run no task within
+// the provisioning timeout, e.g. because their containers crashloop or their
+// image cannot be pulled. A deployment that replaced an earlier one is
+// rolled back to it, the first deployment of a service marks it FAILED. The
+// container errors are recorded in the service events.
+type ServiceProvisioningMonitor struct {
+	api        *DefaultECSAPI
+	kubeClient kubernetes.Interface
+	interval   time.Duration
+	timeout    time.Duration
+	ticker     *time.Ticker
+	done       chan struct{}
+
+	// now is the wall clock the provisioning timeout is measured with
+	now    func() time.Time
+	starts *wallClockStarts
+}
+
+// NewServiceProvisioningMonitor creates a monitor for the services of api.
+// Without a Kubernetes client deployments still fail, without the errors of
+// their containers.
+func NewServiceProvisioningMonitor(api *DefaultECSAPI, kubeClient kubernetes.Interface) *ServiceProvisioningMonitor {
+	return &ServiceProvisioningMonitor{
+		api:        api,
+		kubeClient: kubeClient,
+		interval:   config.GetDuration("serviceProvisioning.interval", 30*time.Second),
+		timeout:    config.GetDuration("serviceProvisioning.timeout", 10*time.Minute),
+		done:       make(chan struct{}),
+		now:        time.Now,
+		starts:     newWallClockStarts(),
+	}
+}
+
+// Start begins checking the deployments in progress
+func (m *ServiceProvisioningMonitor) Start(ctx context.Context) {
+	m.ticker = time.NewTicker(m.interval)
+
+	go func() {
+		logging.Info("Service provisioning monitor: Started", "interval", m.interval, "timeout", m.timeout)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-m.done:
+				return
+			case <-m.ticker.C:
+				m.checkServices(ctx)
+			}
+		}
+	}()
+}
+
+// Stop halts the monitor
+func (m *ServiceProvisioningMonitor) Stop() {
+	if m.ticker != nil {
+		m.ticker.Stop()
+	}
+	close(m.done)
+}
+
+// checkServices fails the stalled deployment of every service
+func (m *ServiceProvisioningMonitor) checkServices(ctx context.Context) {
+	clusters, err := m.api.storage.ClusterStore().List(ctx)
+	if err != nil {
+		logging.Warn("Service provisioning monitor: Failed to list clusters", "error", err)
+		return
+	}
+	now := m.now()
+	defer m.starts.sweep()
+	for _, cluster := range clusters {
+		services, _, err := m.api.storage.ServiceStore().List(ctx, cluster.ARN, storage.ServiceFilters{}, 0, "")
+		if err != nil {
+			logging.Warn("Service provisioning monitor: Failed to list services", "cluster", cluster.Name, "error", err)
+			continue
+		}
+		for _, service := range services {
+			if service.Status == "DRAINING" || service.Status == "INACTIVE" || service.Status == serviceStatusFailed {
+				continue
+			}
+			deploymentID, createdAt, starting := servicedeployments.Starting(service)
+			if !starting || now.Sub(m.starts.start(service.ARN+"/"+deploymentID, createdAt, now)) < m.timeout {
+				continue
+			}
+			if err := m.failService(ctx, service); err != nil {
+				logging.Warn("Service provisioning monitor: Failed to fail deployment",
+					"service", service.ServiceName, "error", err)
+			}
+		}
+	}
+}
+
+// failService fails the stalled deployment of a service and rolls it back
+// when it replaced an earlier deployment
+func (m *ServiceProvisioningMonitor) failService(ctx context.Context, service *storage.Service) error {
+	reason := fmt.Sprintf("tasks failed to start within %s", m.timeout)
+	if containerErrs := m.containerErrors(ctx, service); len(containerErrs) > 0 {
+		reason += ": " + strings.Join(containerErrs, "; ")
+	}
+
+	var failedID, rollbackID string
+	now := deterministic.Now()
+	service, err := storage.UpdateService(ctx, m.api.storage.ServiceStore(), service, func(current *storage.Service) error {
+		failedID, rollbackID = servicedeployments.Rollback(current, reason, now)
+		if failedID == "" {
+			return errNoStalledDeployment
+		}
+		if rollbackID == "" {
+			current.Status = serviceStatusFailed
+		}
+		current.UpdatedAt = now
+		return nil
+	})
+	if errors.Is(err, errNoStalledDeployment) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update service: %w", err)
+	}
+
+	logging.Info("Service provisioning monitor: Deployment failed",
+		"service", service.ServiceName, "deployment", failedID, "reason", reason, "rollback", rollbackID)
+	m.api.serviceEvents.Record(service.ARN, serviceevents.DeploymentFailed(service.ServiceName, failedID, reason))
+	m.api.notifier.Notify(notifications.DeploymentFailed(service.ClusterARN, service.ServiceName, service.ARN, failedID, reason))
+	if rollbackID == "" {
+		return nil
+	}
+
+	m.api.serviceEvents.Record(service.ARN, serviceevents.RollingBack(service.ServiceName, rollbackID))
+	if _, err := m.api.applyService(ctx, service); err != nil {
+		return fmt.Errorf("failed to roll back service: %w", err)
+	}
+	return nil
+}
+
+// containerErrors describes why the containers of the pods of a service do
+// not start
+func (m *ServiceProvisioningMonitor) containerErrors(ctx context.Context, service *storage.Service) []string {
+	if m.kubeClient == nil {
+		return nil
+	}
+	_, _, deployment, _, err := m.api.desiredServiceResources(ctx, service)
+	if err != nil || deployment.Spec.Selector == nil {
+		return nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil
+	}
+	pods, err := m.kubeClient.CoreV1().Pods(deployment.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		logging.Debug("Service provisioning monitor: Failed to list pods", "service", service.ServiceName, "error", err)
+		return nil
+	}
+	return podContainerErrors(pods.Items)
+}
+
+// podContainerErrors describes the container errors of pods, such as image
+// pull failures and the exit codes of crashlooping containers, sorted
+func podContainerErrors(pods []corev1.Pod) []string {
+	var errors []string
+	add := func(message string) {
+		if !slices.Contains(errors, message) {
+			errors = append(errors, message)
+		}
+	}
+	for _, pod := range pods {
+		statuses := append(slices.Clone(pod.Status.InitContainerStatuses), pod.Status.ContainerStatuses...)
+		for _, status := range statuses {
+			switch {
+			case status.State.Waiting != nil && slices.Contains(containerWaitingErrors, status.State.Waiting.Reason):
+				waiting := status.State.Waiting
+				if last := status.LastTerminationState.Terminated; last != nil {
+					add(fmt.Sprintf("container %s: %s (exit code %d)", status.Name, waiting.Reason, last.ExitCode))
+				} else if waiting.Message != "" {
+					add(fmt.Sprintf("container %s: %s (%s)", status.Name, waiting.Reason, waiting.Message))
+				} else {
+					add(fmt.Sprintf("container %s: %s", status.Name, waiting.Reason))
+				}
+			case status.State.Terminated != nil && status.State.Terminated.ExitCode != 0:
+				terminated := status.State.Terminated
+				add(fmt.Sprintf("container %s: %s (exit code %d)", status.Name, terminated.Reason, terminated.ExitCode))
+			}
+		}
+	}
+	slices.Sort(errors)
+	return errors
+}
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/mocks"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
+	"github.com/nandemo-ya/kecs/controlplane/internal/servicedeployments"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+var _ = Describe("ServiceProvisioningMonitor", func() {
+	const (
+		clusterARN = "arn:aws:ecs:us-east-1:000000000000:cluster/default"
+		serviceARN = "arn:aws:ecs:us-east-1:000000000000:service/default/web"
+		namespace  = "default-us-east-1"
+	)
+
+	var (
+		ctx              context.Context
+		ecsAPI           *DefaultECSAPI
+		reconciler       *ServiceReconciler
+		monitor          *ServiceProvisioningMonitor
+		kubeClient       *fake.Clientset
+		mockServiceStore *mocks.MockServiceStore
+		taskDefV1        string
+		taskDefV2        string
+		started          time.Time
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockStorage := mocks.NewMockStorage()
+		mockClusterStore := mocks.NewMockClusterStore()
+		mockTaskDefStore := mocks.NewMockTaskDefinitionStore()
+		mockServiceStore = mocks.NewMockServiceStore()
+		mockStorage.SetClusterStore(mockClusterStore)
+		mockStorage.SetTaskDefinitionStore(mockTaskDefStore)
+		mockStorage.SetServiceStore(mockServiceStore)
+
+		Expect(mockClusterStore.Create(ctx, &storage.Cluster{
+			Name:      "default",
+			ARN:       clusterARN,
+			Status:    "ACTIVE",
+			Region:    "us-east-1",
+			AccountID: "000000000000",
+		})).To(Succeed())
+		for _, target := range []*string{&taskDefV1, &taskDefV2} {
+			taskDef, err := mockTaskDefStore.Register(ctx, &storage.TaskDefinition{
+				Family:               "web",
+				ContainerDefinitions: `[{"name":"web","image":"nginx","memory":256}]`,
+				CPU:                  "256",
+				Memory:               "512",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			*target = taskDef.ARN
+		}
+
+		ecsAPI = NewDefaultECSAPI(config.DefaultConfig(), mockStorage).(*DefaultECSAPI)
+		reconciler = NewServiceReconciler(ecsAPI)
+		reconciler.queue = workqueue.NewTypedRateLimitingQueue(
+			workqueue.NewTypedItemExponentialFailureRateLimiter[string](time.Millisecond, 10*time.Millisecond))
+		ecsAPI.SetServiceReconciler(reconciler)
+		DeferCleanup(reconciler.queue.ShutDown)
+
+		kubeClient = fake.NewSimpleClientset()
+		monitor = NewServiceProvisioningMonitor(ecsAPI, kubeClient)
+		started = time.Now().Add(-monitor.timeout - time.Minute)
+	})
+
+	createService := func(prepare func(service *storage.Service)) {
+		service := &storage.Service{
+			ARN:               serviceARN,
+			ServiceName:       "web",
+			ClusterARN:        clusterARN,
+			TaskDefinitionARN: taskDefV1,
+			DesiredCount:      2,
+			Status:            "PROVISIONING",
+		}
+		servicedeployments.Start(service, started)
+		if prepare != nil {
+			prepare(service)
+		}
+		Expect(mockServiceStore.Create(ctx, service)).To(Succeed())
+	}
+
+	createPod := func(name string, status corev1.ContainerStatus) {
+		_, err := kubeClient.CoreV1().Pods(namespace).Create(ctx, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    map[string]string{"app": "web", "kecs.dev/service": "web"},
+			},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{status}},
+		}, metav1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	storedService := func() *storage.Service {
+		service, err := mockServiceStore.GetByARN(ctx, serviceARN)
+		Expect(err).NotTo(HaveOccurred())
+		return service
+	}
+
+	eventMessages := func() []string {
+		var messages []string
+		for _, event := range ecsAPI.serviceEvents.Events(serviceARN) {
+			messages = append(messages, event.Message)
+		}
+		return messages
+	}
+
+	It("marks a new service whose containers crashloop FAILED", func() {
+		createService(nil)
+		crashLoop := corev1.ContainerStatus{
+			Name:  "web",
+			State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			LastTerminationState: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{Reason: "Error", ExitCode: 1},
+			},
+		}
+		createPod("web-1", crashLoop)
+		createPod("web-2", crashLoop)
+
+		monitor.checkServices(ctx)
+
+		service := storedService()
+		Expect(service.Status).To(Equal("FAILED"))
+		deployments := servicedeployments.List(service)
+		Expect(deployments).To(HaveLen(1))
+		Expect(*deployments[0].RolloutState).To(Equal(generated.DeploymentRolloutStateFAILED))
+		Expect(ptr.ToString(deployments[0].RolloutStateReason)).To(HaveSuffix(
+			"failed: tasks failed to start within 10m0s: container web: CrashLoopBackOff (exit code 1)"))
+		Expect(eventMessages()).To(ConsistOf(HavePrefix("(service web) (deployment ecs-svc/")))
+		Expect(eventMessages()[0]).To(HaveSuffix(
+			"deployment failed: tasks failed to start within 10m0s: container web: CrashLoopBackOff (exit code 1)."))
+		Expect(reconciler.queue.Len()).To(Equal(0))
+
+		By("leaving the failed service alone on the next check")
+		monitor.checkServices(ctx)
+		Expect(eventMessages()).To(HaveLen(1))
+	})
+
+	It("rolls back a deployment that replaced an earlier one", func() {
+		createService(func(service *storage.Service) {
+			servicedeployments.Progress(service, taskDefV1, 2, 0, started)
+			service.TaskDefinitionARN = taskDefV2
+			servicedeployments.Start(service, started)
+		})
+		createPod("web-1", corev1.ContainerStatus{
+			Name: "web",
+			State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{
+				Reason:  "ImagePullBackOff",
+				Message: `Back-off pulling image "nginx:missing"`,
+			}},
+		})
+
+		monitor.checkServices(ctx)
+
+		service := storedService()
+		Expect(service.Status).To(Equal("PROVISIONING"))
+		Expect(service.TaskDefinitionARN).To(Equal(taskDefV1))
+		Expect(servicedeployments.InProgress(service)).To(BeTrue())
+		Expect(eventMessages()).To(HaveLen(2))
+		Expect(eventMessages()).To(ContainElement(HaveSuffix(
+			`deployment failed: tasks failed to start within 10m0s: container web: ImagePullBackOff (Back-off pulling image "nginx:missing").`)))
+		Expect(eventMessages()).To(ContainElement(HavePrefix("(service web) rolling back to deployment ecs-svc/")))
+		Expect(reconciler.queue.Len()).To(Equal(1))
+	})
+
+	It("leaves deployments running a task or within the timeout alone", func() {
+		createService(func(service *storage.Service) {
+			servicedeployments.Progress(service, taskDefV1, 1, 1, started)
+		})
+
+		monitor.checkServices(ctx)
+
+		Expect(storedService().Status).To(Equal("PROVISIONING"))
+		Expect(eventMessages()).To(BeEmpty())
+
+		monitor.timeout = time.Hour
+		Expect(mockServiceStore.Delete(ctx, clusterARN, "web")).To(Succeed())
+		createService(nil)
+
+		monitor.checkServices(ctx)
+
+		Expect(servicedeployments.InProgress(storedService())).To(BeTrue())
+		Expect(eventMessages()).To(BeEmpty())
+	})
+
+	It("measures the provisioning timeout in wall time in deterministic mode", func() {
+		deterministic.Enable(true)
+		DeferCleanup(deterministic.Enable, false)
+
+		// Deployments carry stepping clock timestamps, long before the wall time
+		started = deterministic.Now()
+		createService(nil)
+		next := deterministic.Now().Add(time.Second)
+
+		wallNow := time.Now()
+		monitor.now = func() time.Time { return wallNow }
+		monitor.checkServices(ctx)
+		Expect(storedService().Status).To(Equal("PROVISIONING"))
+		Expect(deterministic.Now()).To(Equal(next), "checks must not advance the deterministic clock")
+
+		wallNow = wallNow.Add(monitor.timeout)
+		monitor.checkServices(ctx)
+		Expect(storedService().Status).To(Equal("FAILED"))
+	})
+})
@@ -0,0 +1,409 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/notifications"
+	"github.com/nandemo-ya/kecs/controlplane/internal/servicedeployments"
+	"github.com/nandemo-ya/kecs/controlplane/internal/serviceevents"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// Service statuses set while the desired state of a service is applied
+const (
+	serviceStatusProvisioning = "PROVISIONING"
+	serviceStatusActive       = "ACTIVE"
+	serviceStatusFailed       = "FAILED"
+)
+
+// errServiceInactive stops the status update of a service that was deleted
+// while its desired state was applied
+var errServiceInactive = errors.New("service is draining or inactive")
+
+// checkpointTimeout bounds how long the services left unapplied on shutdown
+// are checkpointed for once the shutdown deadline has passed
+const checkpointTimeout = 5 * time.Second
+
+// ServiceReconciler applies stored services to Kubernetes outside the request
+// path. CreateService and UpdateService only persist the desired state and
+// enqueue the service; failed attempts are retried with exponential backoff
+// until the service becomes ACTIVE, or FAILED once the retries run out. The
+// queue is keyed by service ARN, so a service is only applied by one worker
+// at a time and changes stored meanwhile are applied together.
+type ServiceReconciler struct {
+	api        *DefaultECSAPI
+	queue      workqueue.TypedRateLimitingInterface[string]
+	workers    int
+	maxRetries int
+	wg         sync.WaitGroup
+	cancel     context.CancelFunc
+
+	// pending holds the services queued or waiting for a retry, which are
+	// checkpointed when the reconciler is stopped before applying them
+	mu      sync.Mutex
+	pending map[string]struct{}
+}
+
+// NewServiceReconciler creates a reconciler for the services of api
+func NewServiceReconciler(api *DefaultECSAPI) *ServiceReconciler {
+	return &ServiceReconciler{
+		api: api,
+		queue: workqueue.NewTypedRateLimitingQueueWithConfig(
+			workqueue.NewTypedItemExponentialFailureRateLimiter[string](
+				config.GetDuration("serviceReconciler.retryBaseDelay", time.Second),
+				config.GetDuration("serviceReconciler.retryMaxDelay", time.Minute),
+			),
+			workqueue.TypedRateLimitingQueueConfig[string]{Name: "services"},
+		),
+		workers:    max(1, config.GetInt("serviceReconciler.workers")),
+		maxRetries: config.GetInt("serviceReconciler.maxRetries"),
+		pending:    make(map[string]struct{}),
+	}
+}
+
+// Enqueue schedules a service for reconciliation
+func (r *ServiceReconciler) Enqueue(serviceARN string) {
+	r.track(serviceARN)
+	r.queue.Add(serviceARN)
+}
+
+// Start resumes the services left PROVISIONING by a previous run and starts
+// the workers
+func (r *ServiceReconciler) Start(ctx context.Context) {
+	r.resumeProvisioning(ctx)
+
+	// Stop interrupts the services being applied through this context when
+	// its deadline passes
+	ctx, r.cancel = context.WithCancel(ctx)
+
+	logging.Info("Starting service reconciler", "workers", r.workers, "maxRetries", r.maxRetries)
+	for i := 0; i < r.workers; i++ {
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			for r.processNextItem(ctx) {
+			}
+		}()
+	}
+}
+
+// Stop drains the queue and waits for the workers to finish. Once ctx is done
+// the services being applied are interrupted instead. Services whose desired
+// state was not applied, including those waiting for a retry, are left
+// PROVISIONING so that the next start resumes them.
+func (r *ServiceReconciler) Stop(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		r.queue.ShutDownWithDrain()
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		logging.Warn("Shutdown deadline passed, interrupting service reconciliation")
+		if r.cancel != nil {
+			r.cancel()
+		}
+		<-done
+	}
+
+	checkpointCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), checkpointTimeout)
+	defer cancel()
+	r.checkpoint(checkpointCtx)
+}
+
+// track records a service as pending
+func (r *ServiceReconciler) track(serviceARN string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending[serviceARN] = struct{}{}
+}
+
+// untrack records that a service is being applied
+func (r *ServiceReconciler) untrack(serviceARN string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pending, serviceARN)
+}
+
+// checkpoint leaves the pending services PROVISIONING
+func (r *ServiceReconciler) checkpoint(ctx context.Context) {
+	r.mu.Lock()
+	pending := r.pending
+	r.pending = make(map[string]struct{})
+	r.mu.Unlock()
+
+	for serviceARN := range pending {
+		service, err := r.api.storage.ServiceStore().GetByARN(ctx, serviceARN)
+		if err != nil {
+			if !errors.Is(err, storage.ErrResourceNotFound) {
+				logging.Warn("Failed to checkpoint service reconciliation", "service", serviceARN, "error", err)
+			}
+			continue
+		}
+		_, err = storage.UpdateService(ctx, r.api.storage.ServiceStore(), service, func(current *storage.Service) error {
+			if current.Status == "DRAINING" || current.Status == "INACTIVE" {
+				return errServiceInactive
+			}
+			current.Status = serviceStatusProvisioning
+			return nil
+		})
+		if errors.Is(err, errServiceInactive) {
+			continue
+		}
+		if err != nil {
+			logging.Warn("Failed to checkpoint service reconciliation", "service", serviceARN, "error", err)
+			continue
+		}
+		logging.Info("Checkpointed service reconciliation for the next start", "service", service.ServiceName)
+	}
+}
+
+// resumeProvisioning enqueues services whose reconciliation was interrupted
+func (r *ServiceReconciler) resumeProvisioning(ctx context.Context) {
+	clusters, err := r.api.storage.ClusterStore().List(ctx)
+	if err != nil {
+		logging.Warn("Failed to list clusters to resume service reconciliation", "error", err)
+		return
+	}
+	for _, cluster := range clusters {
+		services, _, err := r.api.storage.ServiceStore().List(ctx, cluster.ARN, storage.ServiceFilters{}, 0, "")
+		if err != nil {
+			logging.Warn("Failed to list services to resume reconciliation", "cluster", cluster.Name, "error", err)
+			continue
+		}
+		for _, service := range services {
+			if service.Status == serviceStatusProvisioning {
+				logging.Info("Resuming service reconciliation", "service", service.ServiceName, "cluster", cluster.Name)
+				r.Enqueue(service.ARN)
+			}
+		}
+	}
+}
+
+// processNextItem reconciles one queued service, returning false once the
+// queue is shut down
+func (r *ServiceReconciler) processNextItem(ctx context.Context) bool {
+	serviceARN, shutdown := r.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer r.queue.Done(serviceARN)
+	r.untrack(serviceARN)
+
+	if _, err := r.api.reconcileService(ctx, serviceARN); err != nil {
+		if ctx.Err() != nil {
+			// Interrupted by Stop, which checkpoints the service
+			r.track(serviceARN)
+			r.queue.Forget(serviceARN)
+			return true
+		}
+		if r.queue.NumRequeues(serviceARN) < r.maxRetries {
+			logging.Warn("Service reconciliation failed, retrying",
+				"service", serviceARN, "attempt", r.queue.NumRequeues(serviceARN)+1, "error", err)
+			r.track(serviceARN)
+			r.queue.AddRateLimited(serviceARN)
+			return true
+		}
+		r.api.markServiceFailed(ctx, serviceARN, err)
+	}
+	r.queue.Forget(serviceARN)
+	return true
+}
+
+// applyService hands a service whose desired state was stored to the
+// reconciler. Without a reconciler the service is reconciled in the request
+// path and the reconciled service is returned.
+func (api *DefaultECSAPI) applyService(ctx context.Context, service *storage.Service) (*storage.Service, error) {
+	if api.serviceReconciler != nil {
+		api.serviceReconciler.Enqueue(service.ARN)
+		return service, nil
+	}
+
+	reconciled, err := api.reconcileService(ctx, service.ARN)
+	if err != nil {
+		api.markServiceFailed(ctx, service.ARN, err)
+		return nil, err
+	}
+	if reconciled == nil {
+		return service, nil
+	}
+	return reconciled, nil
+}
+
+// reconcileService applies the stored desired state of a service to
+// Kubernetes. Resources that already exist are updated in place, so a failed
+// attempt can simply be retried. It returns nil when the service is gone or
+// being deleted.
+func (api *DefaultECSAPI) reconcileService(ctx context.Context, serviceARN string) (*storage.Service, error) {
+	service, err := api.storage.ServiceStore().GetByARN(ctx, serviceARN)
+	if err != nil {
+		if errors.Is(err, storage.ErrResourceNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get service: %w", err)
+	}
+	if service.Status == "DRAINING" || service.Status == "INACTIVE" {
+		return nil, nil
+	}
+
+	// EXTERNAL services have no Deployment, their TaskSets run the workload
+	if service.TaskDefinitionARN != "" {
+		if err := api.applyServiceResources(ctx, service); err != nil {
+			return nil, err
+		}
+	}
+
+	// Only the status and counts are written back, so a desired state stored
+	// meanwhile is kept and applied by its own queued reconciliation
+	fresh, err := api.storage.ServiceStore().GetByARN(ctx, serviceARN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service: %w", err)
+	}
+	fresh, err = storage.UpdateService(ctx, api.storage.ServiceStore(), fresh, func(current *storage.Service) error {
+		if current.Status == "DRAINING" || current.Status == "INACTIVE" {
+			return errServiceInactive
+		}
+		current.Status = serviceStatusActive
+		// In test mode there are no pods to sync the counts from, so the counts
+		// the service manager set complete the deployment right away
+		if config.GetBool("features.testMode") {
+			current.RunningCount = service.RunningCount
+			current.PendingCount = service.PendingCount
+			servicedeployments.Progress(current, current.TaskDefinitionARN, current.RunningCount, current.PendingCount, deterministic.Now())
+		}
+		return nil
+	})
+	if errors.Is(err, errServiceInactive) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update service status: %w", err)
+	}
+
+	logging.Info("Reconciled service", "service", service.ServiceName, "status", fresh.Status)
+	return fresh, nil
+}
+
+// applyServiceResources creates the Deployment and Service of a service, or
+// updates them once its Deployment exists
+func (api *DefaultECSAPI) applyServiceResources(ctx context.Context, service *storage.Service) error {
+	cluster, taskDef, deployment, kubeService, err := api.desiredServiceResources(ctx, service)
+	if err != nil {
+		return err
+	}
+
+	serviceManager, err := api.getServiceManager()
+	if err != nil {
+		return fmt.Errorf("failed to create service manager: %w", err)
+	}
+	exists, err := serviceManager.DeploymentExists(ctx, deployment, cluster, service)
+	if err != nil {
+		return err
+	}
+	if exists {
+		if err := serviceManager.UpdateService(ctx, deployment, kubeService, cluster, service); err != nil {
+			return fmt.Errorf("failed to update kubernetes deployment: %w", err)
+		}
+		return nil
+	}
+
+	if err := serviceManager.CreateService(ctx, deployment, kubeService, cluster, service); err != nil {
+		return fmt.Errorf("failed to create kubernetes deployment: %w", err)
+	}
+	// In test mode there are no pods to register as tasks
+	if config.GetBool("features.testMode") && service.DesiredCount > 0 {
+		if err := api.createTasksForService(ctx, service, taskDef, cluster); err != nil {
+			logging.Warn("Failed to create tasks for service in test mode", "error", err)
+		}
+	}
+	return nil
+}
+
+// desiredServiceResources converts the stored desired state of a service to
+// its Deployment and, for services with a load balancer or public IP, its
+// Kubernetes Service
+func (api *DefaultECSAPI) desiredServiceResources(ctx context.Context, service *storage.Service) (*storage.Cluster, *storage.TaskDefinition, *appsv1.Deployment, *corev1.Service, error) {
+	cluster, err := api.storage.ClusterStore().Get(ctx, service.ClusterARN)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to get cluster: %w", err)
+	}
+	taskDef, err := api.storage.TaskDefinitionStore().GetByARN(ctx, service.TaskDefinitionARN)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to get task definition: %w", err)
+	}
+
+	var networkConfig *generated.NetworkConfiguration
+	if service.NetworkConfiguration != "" && service.NetworkConfiguration != "null" {
+		networkConfig = &generated.NetworkConfiguration{}
+		if err := json.Unmarshal([]byte(service.NetworkConfiguration), networkConfig); err != nil {
+			return nil, nil, nil, nil, fmt.Errorf("failed to parse network configuration: %w", err)
+		}
+	}
+
+	// Target groups are resolved through ELBv2 when it is available
+	serviceConverter := converters.NewServiceConverter(api.region, api.accountID)
+	var converter converters.ServiceConverterInterface = serviceConverter
+	if api.elbv2Integration != nil {
+		lbConverter := converters.NewServiceConverterWithLB(api.region, api.accountID, api.elbv2Integration)
+		serviceConverter, converter = lbConverter.ServiceConverter, lbConverter
+	}
+	// Map subnets to availability zones for placement
+	if api.vpcRegistry != nil {
+		serviceConverter.SetSubnetZoneResolver(api.vpcRegistry)
+	}
+	deployment, kubeService, err := converter.ConvertServiceToDeploymentWithNetworkConfig(service, taskDef, cluster, networkConfig)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to convert service to deployment: %w", err)
+	}
+	return cluster, taskDef, deployment, kubeService, nil
+}
+
+// markServiceFailed records that the desired state of a service could not be applied
+func (api *DefaultECSAPI) markServiceFailed(ctx context.Context, serviceARN string, reconcileErr error) {
+	logging.Error("Failed to reconcile service", "service", serviceARN, "error", reconcileErr)
+
+	service, err := api.storage.ServiceStore().GetByARN(ctx, serviceARN)
+	if err != nil {
+		return
+	}
+	var deploymentID string
+	_, err = storage.UpdateService(ctx, api.storage.ServiceStore(), service, func(current *storage.Service) error {
+		if current.Status == "DRAINING" || current.Status == "INACTIVE" {
+			return errServiceInactive
+		}
+		current.Status = serviceStatusFailed
+		current.UpdatedAt = deterministic.Now()
+		deploymentID = servicedeployments.Fail(current, reconcileErr.Error(), current.UpdatedAt)
+		if deploymentID == "" {
+			deploymentID = servicedeployments.PrimaryID(current)
+		}
+		return nil
+	})
+	if errors.Is(err, errServiceInactive) {
+		return
+	}
+	if err != nil {
+		logging.Warn("Failed to mark service as failed", "service", service.ServiceName, "error", err)
+	}
+	api.serviceEvents.Record(service.ARN,
+		serviceevents.DeploymentFailed(service.ServiceName, deploymentID, reconcileErr.Error()))
+	api.notifier.Notify(notifications.DeploymentFailed(service.ClusterARN, service.ServiceName, service.ARN,
+		deploymentID, reconcileErr.Error()))
+}
@@ -0,0 +1,223 @@
+package api
+
+import (
+	"context"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/mocks"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+var _ = Describe("ServiceReconciler", func() {
+	const clusterARN = "arn:aws:ecs:us-east-1:000000000000:cluster/default"
+
+	var (
+		ctx              context.Context
+		ecsAPI           *DefaultECSAPI
+		reconciler       *ServiceReconciler
+		mockServiceStore *mocks.MockServiceStore
+		taskDefARN       string
+	)
+
+	BeforeEach(func() {
+		// Test mode simulates the Kubernetes resources
+		os.Setenv("KECS_TEST_MODE", "true")
+		DeferCleanup(os.Unsetenv, "KECS_TEST_MODE")
+
+		ctx = context.Background()
+		mockStorage := mocks.NewMockStorage()
+		mockClusterStore := mocks.NewMockClusterStore()
+		mockTaskDefStore := mocks.NewMockTaskDefinitionStore()
+		mockServiceStore = mocks.NewMockServiceStore()
+		mockStorage.SetClusterStore(mockClusterStore)
+		mockStorage.SetTaskDefinitionStore(mockTaskDefStore)
+		mockStorage.SetServiceStore(mockServiceStore)
+		mockStorage.SetTaskStore(mocks.NewMockTaskStore())
+
+		Expect(mockClusterStore.Create(ctx, &storage.Cluster{
+			Name:      "default",
+			ARN:       clusterARN,
+			Status:    "ACTIVE",
+			Region:    "us-east-1",
+			AccountID: "000000000000",
+		})).To(Succeed())
+		taskDef, err := mockTaskDefStore.Register(ctx, &storage.TaskDefinition{
+			Family:               "web",
+			ContainerDefinitions: `[{"name":"web","image":"nginx","memory":256}]`,
+			CPU:                  "256",
+			Memory:               "512",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		taskDefARN = taskDef.ARN
+
+		ecsAPI = NewDefaultECSAPI(config.DefaultConfig(), mockStorage).(*DefaultECSAPI)
+		reconciler = NewServiceReconciler(ecsAPI)
+		reconciler.queue = workqueue.NewTypedRateLimitingQueue(
+			workqueue.NewTypedItemExponentialFailureRateLimiter[string](time.Millisecond, 10*time.Millisecond))
+		ecsAPI.SetServiceReconciler(reconciler)
+		DeferCleanup(reconciler.queue.ShutDown)
+	})
+
+	createService := func() *generated.Service {
+		resp, err := ecsAPI.CreateService(ctx, &generated.CreateServiceRequest{
+			ServiceName:    "web",
+			TaskDefinition: ptr.String(taskDefARN),
+			DesiredCount:   ptr.Int32(1),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		return resp.Service
+	}
+
+	It("returns PROVISIONING from CreateService and activates the service in the background", func() {
+		service := createService()
+		Expect(*service.Status).To(Equal("PROVISIONING"))
+		Expect(reconciler.queue.Len()).To(Equal(1))
+
+		Expect(reconciler.processNextItem(ctx)).To(BeTrue())
+
+		stored, err := mockServiceStore.Get(ctx, clusterARN, "web")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stored.Status).To(Equal("ACTIVE"))
+	})
+
+	It("returns PROVISIONING from UpdateService until the new desired state is applied", func() {
+		createService()
+		Expect(reconciler.processNextItem(ctx)).To(BeTrue())
+
+		resp, err := ecsAPI.UpdateService(ctx, &generated.UpdateServiceRequest{
+			Service:      "web",
+			DesiredCount: ptr.Int32(3),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*resp.Service.Status).To(Equal("PROVISIONING"))
+
+		Expect(reconciler.processNextItem(ctx)).To(BeTrue())
+
+		stored, err := mockServiceStore.Get(ctx, clusterARN, "web")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stored.Status).To(Equal("ACTIVE"))
+		Expect(stored.DesiredCount).To(Equal(3))
+	})
+
+	It("applies a create and an update stored before it ran at once", func() {
+		createService()
+		_, err := ecsAPI.UpdateService(ctx, &generated.UpdateServiceRequest{
+			Service:      "web",
+			DesiredCount: ptr.Int32(2),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reconciler.queue.Len()).To(Equal(1))
+
+		Expect(reconciler.processNextItem(ctx)).To(BeTrue())
+
+		stored, err := mockServiceStore.Get(ctx, clusterARN, "web")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stored.Status).To(Equal("ACTIVE"))
+		Expect(stored.DesiredCount).To(Equal(2))
+		Expect(reconciler.queue.Len()).To(Equal(0))
+	})
+
+	It("retries failed attempts and marks the service FAILED once the retries run out", func() {
+		reconciler.maxRetries = 2
+		Expect(mockServiceStore.Create(ctx, &storage.Service{
+			ARN:               "arn:aws:ecs:us-east-1:000000000000:service/default/broken",
+			ServiceName:       "broken",
+			ClusterARN:        clusterARN,
+			TaskDefinitionARN: "arn:aws:ecs:us-east-1:000000000000:task-definition/missing:1",
+			Status:            "PROVISIONING",
+		})).To(Succeed())
+		reconciler.Enqueue("arn:aws:ecs:us-east-1:000000000000:service/default/broken")
+
+		for attempt := 0; attempt < 3; attempt++ {
+			Expect(reconciler.processNextItem(ctx)).To(BeTrue())
+			stored, err := mockServiceStore.Get(ctx, clusterARN, "broken")
+			Expect(err).NotTo(HaveOccurred())
+			if attempt < 2 {
+				Expect(stored.Status).To(Equal("PROVISIONING"))
+			} else {
+				Expect(stored.Status).To(Equal("FAILED"))
+			}
+		}
+
+		events := ecsAPI.serviceEvents.Events("arn:aws:ecs:us-east-1:000000000000:service/default/broken")
+		Expect(events).NotTo(BeEmpty())
+		Expect(events[0].Message).To(ContainSubstring("deployment failed"))
+	})
+
+	It("leaves services that are being deleted alone", func() {
+		createService()
+		stored, err := mockServiceStore.Get(ctx, clusterARN, "web")
+		Expect(err).NotTo(HaveOccurred())
+		stored.Status = "DRAINING"
+
+		Expect(reconciler.processNextItem(ctx)).To(BeTrue())
+		Expect(stored.Status).To(Equal("DRAINING"))
+	})
+
+	It("resumes services left PROVISIONING on start", func() {
+		Expect(mockServiceStore.Create(ctx, &storage.Service{
+			ARN:               "arn:aws:ecs:us-east-1:000000000000:service/default/web",
+			ServiceName:       "web",
+			ClusterARN:        clusterARN,
+			TaskDefinitionARN: taskDefARN,
+			Status:            "PROVISIONING",
+		})).To(Succeed())
+
+		reconciler.resumeProvisioning(ctx)
+		Expect(reconciler.queue.Len()).To(Equal(1))
+	})
+
+	Describe("Stop", func() {
+		It("leaves services not applied before the deadline PROVISIONING", func() {
+			createService()
+			Expect(reconciler.processNextItem(ctx)).To(BeTrue())
+			reconciler.Enqueue("arn:aws:ecs:us-east-1:000000000000:service/default/web")
+
+			stopCtx, cancel := context.WithCancel(ctx)
+			cancel()
+			reconciler.Stop(stopCtx)
+
+			stored, err := mockServiceStore.Get(ctx, clusterARN, "web")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stored.Status).To(Equal("PROVISIONING"))
+		})
+
+		It("checkpoints services waiting for a retry", func() {
+			reconciler.maxRetries = 5
+			Expect(mockServiceStore.Create(ctx, &storage.Service{
+				ARN:               "arn:aws:ecs:us-east-1:000000000000:service/default/broken",
+				ServiceName:       "broken",
+				ClusterARN:        clusterARN,
+				TaskDefinitionARN: "arn:aws:ecs:us-east-1:000000000000:task-definition/missing:1",
+				Status:            "ACTIVE",
+			})).To(Succeed())
+			reconciler.Enqueue("arn:aws:ecs:us-east-1:000000000000:service/default/broken")
+			Expect(reconciler.processNextItem(ctx)).To(BeTrue())
+
+			reconciler.Stop(ctx)
+
+			stored, err := mockServiceStore.Get(ctx, clusterARN, "broken")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stored.Status).To(Equal("PROVISIONING"))
+		})
+
+		It("leaves applied services ACTIVE", func() {
+			createService()
+			Expect(reconciler.processNextItem(ctx)).To(BeTrue())
+
+			reconciler.Stop(ctx)
+
+			stored, err := mockServiceStore.Get(ctx, clusterARN, "web")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stored.Status).To(Equal("ACTIVE"))
+		})
+	})
+})
@@ -23,7 +23,7 @@ type ServiceDiscoveryAPI struct {
 func NewServiceDiscoveryAPI(manager servicediscovery.Manager, store storage.Storage, region, accountID string) *ServiceDiscoveryAPI {
 	// Create handler
 	logger := logrus.New()
-	handler := servicediscovery.NewHandler(logger, store, manager)
+	handler := servicediscovery.NewHandler(logger, store, manager, region, accountID)
 
 	// Create router with handler
 	router := generated.NewRouter(handler)
@@ -0,0 +1,277 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsrequest"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
+	"github.com/nandemo-ya/kecs/controlplane/internal/iampolicy"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/sigv4"
+)
+
+const stsXMLNS = "https://sts.amazonaws.com/doc/2011-06-15/"
+
+// sessionNamePattern is the format of role session names
+var sessionNamePattern = regexp.MustCompile(`^[\w+=,.@-]{2,64}$`)
+
+// STSAPI serves AssumeRole and GetCallerIdentity. AssumeRole issues
+// temporary credentials that KECS verifies and authorizes like the static
+// keys of the credentials file.
+type STSAPI struct {
+	credentials *sigv4.Credentials
+	accountID   string
+	// requireRoles only lets the roles of the credentials file be assumed.
+	// Without credentials file any role can be assumed, as any key is accepted.
+	requireRoles bool
+	// now is the wall clock credentials expire by, the one the SigV4
+	// verifier checks them against, also in deterministic mode
+	now func() time.Time
+}
+
+// NewSTSAPI creates a new STS API handler
+func NewSTSAPI(credentials *sigv4.Credentials, accountID string, requireRoles bool) *STSAPI {
+	return &STSAPI{
+		credentials:  credentials,
+		accountID:    accountID,
+		requireRoles: requireRoles,
+		now:          time.Now,
+	}
+}
+
+// STS XML response structures
+type stsCredentials struct {
+	AccessKeyID     string `xml:"AccessKeyId"`
+	SecretAccessKey string `xml:"SecretAccessKey"`
+	SessionToken    string `xml:"SessionToken"`
+	Expiration      string `xml:"Expiration"`
+}
+
+type stsAssumedRoleUser struct {
+	AssumedRoleID string `xml:"AssumedRoleId"`
+	Arn           string `xml:"Arn"`
+}
+
+type AssumeRoleResponse struct {
+	XMLName          xml.Name           `xml:"AssumeRoleResponse"`
+	XMLNS            string             `xml:"xmlns,attr"`
+	Credentials      stsCredentials     `xml:"AssumeRoleResult>Credentials"`
+	AssumedRoleUser  stsAssumedRoleUser `xml:"AssumeRoleResult>AssumedRoleUser"`
+	ResponseMetadata ResponseMetadata   `xml:"ResponseMetadata"`
+}
+
+type GetCallerIdentityResponse struct {
+	XMLName          xml.Name         `xml:"GetCallerIdentityResponse"`
+	XMLNS            string           `xml:"xmlns,attr"`
+	UserID           string           `xml:"GetCallerIdentityResult>UserId"`
+	Account          string           `xml:"GetCallerIdentityResult>Account"`
+	Arn              string           `xml:"GetCallerIdentityResult>Arn"`
+	ResponseMetadata ResponseMetadata `xml:"ResponseMetadata"`
+}
+
+// ServeHTTP handles form-encoded STS requests
+func (s *STSAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		awsrequest.WriteError(w, true, http.StatusBadRequest, "InvalidRequest", fmt.Sprintf("Failed to parse form data: %v", err))
+		return
+	}
+	accessKeyID := awsrequest.ParseCredential(r.Header.Get("Authorization")).AccessKeyID
+
+	action := r.Form.Get("Action")
+	logging.Debug("Processing STS request", "action", action)
+
+	switch action {
+	case "AssumeRole":
+		s.assumeRole(w, r, accessKeyID)
+	case "GetCallerIdentity":
+		s.getCallerIdentity(w, accessKeyID)
+	default:
+		awsrequest.WriteError(w, true, http.StatusBadRequest, "InvalidAction",
+			fmt.Sprintf("The action %s is not supported by KECS", action))
+	}
+}
+
+func (s *STSAPI) assumeRole(w http.ResponseWriter, r *http.Request, accessKeyID string) {
+	roleARN := r.Form.Get("RoleArn")
+	sessionName := r.Form.Get("RoleSessionName")
+	if !sessionNamePattern.MatchString(sessionName) {
+		awsrequest.WriteError(w, true, http.StatusBadRequest, "ValidationError",
+			"1 validation error detected: Value at 'roleSessionName' failed to satisfy constraint: Member must have length between 2 and 64 and satisfy regular expression pattern: [\\w+=,.@-]*")
+		return
+	}
+	roleAccountID, roleName, ok := parseRoleARN(roleARN)
+	if !ok {
+		awsrequest.WriteError(w, true, http.StatusBadRequest, "ValidationError", fmt.Sprintf("%s is invalid", roleARN))
+		return
+	}
+	if r.Form.Get("PolicyArns.member.1.arn") != "" {
+		awsrequest.WriteError(w, true, http.StatusBadRequest, "ValidationError", "PolicyArns are not supported by KECS, use Policy")
+		return
+	}
+
+	var policies []*iampolicy.Policy
+	maxDuration := int32(sigv4.DefaultSessionDuration)
+	role, found := s.credentials.Role(roleName)
+	if found && roleAccountIDOf(role, s.accountID) != roleAccountID {
+		found = false
+	}
+	switch {
+	case found:
+		if !role.Trusts(s.credentials.Principal(accessKeyID)) {
+			s.writeNotAuthorized(w, accessKeyID, roleARN)
+			return
+		}
+		policies = append(policies, role.Policy)
+		if role.MaxSessionDuration > 0 {
+			maxDuration = role.MaxSessionDuration
+		}
+	case s.requireRoles:
+		s.writeNotAuthorized(w, accessKeyID, roleARN)
+		return
+	}
+
+	if document := r.Form.Get("Policy"); document != "" {
+		policy, err := iampolicy.ParsePolicy([]byte(document))
+		if err == nil {
+			err = policy.Validate()
+		}
+		if err != nil {
+			awsrequest.WriteError(w, true, http.StatusBadRequest, "MalformedPolicyDocument", err.Error())
+			return
+		}
+		policies = append(policies, policy)
+	}
+
+	duration := int32(sigv4.DefaultSessionDuration)
+	if value := r.Form.Get("DurationSeconds"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || parsed < sigv4.MinSessionDuration {
+			awsrequest.WriteError(w, true, http.StatusBadRequest, "ValidationError",
+				fmt.Sprintf("1 validation error detected: Value '%s' at 'durationSeconds' failed to satisfy constraint: Member must have value greater than or equal to %d", value, sigv4.MinSessionDuration))
+			return
+		}
+		if int32(parsed) > maxDuration {
+			awsrequest.WriteError(w, true, http.StatusBadRequest, "ValidationError",
+				"The requested DurationSeconds exceeds the MaxSessionDuration set for this role.")
+			return
+		}
+		duration = int32(parsed)
+	}
+
+	session := &sigv4.Session{
+		AccessKeyID:     "ASIA" + strings.ToUpper(deterministic.Suffix(16)),
+		SecretAccessKey: deterministic.Suffix(40),
+		SessionToken:    deterministic.Suffix(64),
+		Expiration:      s.now().Add(time.Duration(duration) * time.Second).UTC(),
+		RoleName:        roleName,
+		Name:            sessionName,
+		AccountID:       roleAccountID,
+		Policies:        policies,
+	}
+	s.credentials.AddSession(session)
+	logging.Info("Issued temporary credentials", "role", roleARN, "session", sessionName, "expiration", session.Expiration)
+
+	writeSTSXML(w, &AssumeRoleResponse{
+		XMLNS: stsXMLNS,
+		Credentials: stsCredentials{
+			AccessKeyID:     session.AccessKeyID,
+			SecretAccessKey: session.SecretAccessKey,
+			SessionToken:    session.SessionToken,
+			Expiration:      session.Expiration.Format(time.RFC3339),
+		},
+		AssumedRoleUser: stsAssumedRoleUser{
+			AssumedRoleID: roleID(roleAccountID, roleName) + ":" + sessionName,
+			Arn:           iampolicy.PrincipalARN(session.Principal(), roleAccountID),
+		},
+		ResponseMetadata: ResponseMetadata{RequestId: deterministic.NewUUID()},
+	})
+}
+
+func (s *STSAPI) getCallerIdentity(w http.ResponseWriter, accessKeyID string) {
+	resp := &GetCallerIdentityResponse{
+		XMLNS:            stsXMLNS,
+		ResponseMetadata: ResponseMetadata{RequestId: deterministic.NewUUID()},
+	}
+	if session, found := s.credentials.Session(accessKeyID); found {
+		resp.Account = session.AccountID
+		resp.Arn = iampolicy.PrincipalARN(session.Principal(), session.AccountID)
+		resp.UserID = roleID(session.AccountID, session.RoleName) + ":" + session.Name
+	} else if principal := s.credentials.Principal(accessKeyID); principal != "" {
+		resp.Account = s.credentials.AccountID(accessKeyID)
+		if resp.Account == "" {
+			resp.Account = s.accountID
+		}
+		resp.Arn = iampolicy.PrincipalARN(principal, resp.Account)
+		resp.UserID = uniqueID("AIDA", resp.Arn)
+	} else {
+		// Any access key is accepted without credentials file, it stands for the account
+		resp.Account = s.accountID
+		resp.Arn = awsarn.Build("iam", "", s.accountID, "root")
+		resp.UserID = s.accountID
+	}
+	writeSTSXML(w, resp)
+}
+
+// writeNotAuthorized reports a role that does not exist or does not trust the caller
+func (s *STSAPI) writeNotAuthorized(w http.ResponseWriter, accessKeyID, roleARN string) {
+	principal := s.credentials.Principal(accessKeyID)
+	accountID := s.credentials.AccountID(accessKeyID)
+	if accountID == "" {
+		accountID = s.accountID
+	}
+	awsrequest.WriteError(w, true, http.StatusForbidden, "AccessDenied",
+		fmt.Sprintf("User: %s is not authorized to perform: sts:AssumeRole on resource: %s",
+			iampolicy.PrincipalARN(principal, accountID), roleARN))
+}
+
+// parseRoleARN returns the account and name of a role ARN, e.g.
+// arn:aws:iam::000000000000:role/path/name
+func parseRoleARN(roleARN string) (accountID, name string, ok bool) {
+	parts := strings.SplitN(roleARN, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] != "iam" || !strings.HasPrefix(parts[5], "role/") {
+		return "", "", false
+	}
+	if awsarn.ValidateAccountID(parts[4]) != nil {
+		return "", "", false
+	}
+	path := strings.TrimPrefix(parts[5], "role/")
+	name = path[strings.LastIndex(path, "/")+1:]
+	return parts[4], name, name != ""
+}
+
+// roleAccountIDOf returns the account of a role of the credentials file
+func roleAccountIDOf(role sigv4.Role, fallback string) string {
+	if role.AccountID != "" {
+		return role.AccountID
+	}
+	return fallback
+}
+
+// roleID returns the unique ID of a role, stable across restarts
+func roleID(accountID, name string) string {
+	return uniqueID("AROA", awsarn.Build("iam", "", accountID, "role/"+name))
+}
+
+// uniqueID derives an IAM unique ID from an ARN
+func uniqueID(prefix, arn string) string {
+	sum := sha256.Sum256([]byte(arn))
+	return prefix + strings.ToUpper(hex.EncodeToString(sum[:])[:17])
+}
+
+func writeSTSXML(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "text/xml")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n"))
+	if err := xml.NewEncoder(w).Encode(data); err != nil {
+		logging.Error("Failed to encode STS XML response", "error", err)
+	}
+}
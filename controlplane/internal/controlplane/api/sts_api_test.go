@@ -0,0 +1,161 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
+	"github.com/nandemo-ya/kecs/controlplane/internal/iampolicy"
+	"github.com/nandemo-ya/kecs/controlplane/internal/sigv4"
+)
+
+func newTestSTSAPI(t *testing.T) *STSAPI {
+	t.Helper()
+	credentials, err := sigv4.NewCredentials([]sigv4.User{
+		{Principal: "alice", Keys: []sigv4.AccessKey{{AccessKeyID: "AKIAALICE", SecretAccessKey: "alice-secret"}}},
+		{Principal: "bob", Keys: []sigv4.AccessKey{{AccessKeyID: "AKIABOB", SecretAccessKey: "bob-secret"}}},
+	}, sigv4.Role{
+		Name:              "deployer",
+		TrustedPrincipals: []string{"alice"},
+		Policy: &iampolicy.Policy{Statement: []iampolicy.Statement{
+			{Effect: iampolicy.EffectAllow, Action: []string{"ecs:*"}, Resource: []string{"*"}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create credentials: %v", err)
+	}
+	api := NewSTSAPI(credentials, "000000000000", true)
+	return api
+}
+
+func callSTS(api *STSAPI, accessKeyID string, params url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/", strings.NewReader(params.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKeyID+"/20240101/us-east-1/sts/aws4_request, SignedHeaders=host, Signature=abc")
+	rec := httptest.NewRecorder()
+	api.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestSTSAPI_AssumeRole(t *testing.T) {
+	api := newTestSTSAPI(t)
+
+	rec := callSTS(api, "AKIAALICE", url.Values{
+		"Action":          {"AssumeRole"},
+		"RoleArn":         {"arn:aws:iam::000000000000:role/deployer"},
+		"RoleSessionName": {"ci"},
+		"Policy":          {`{"Statement":[{"Effect":"Allow","Action":"ecs:UpdateService","Resource":"*"}]}`},
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "<Arn>arn:aws:sts::000000000000:assumed-role/deployer/ci</Arn>") {
+		t.Errorf("Expected the assumed role ARN, got %s", body)
+	}
+
+	start := strings.Index(body, "<AccessKeyId>") + len("<AccessKeyId>")
+	accessKeyID := body[start : start+strings.Index(body[start:], "<")]
+	if !strings.HasPrefix(accessKeyID, "ASIA") {
+		t.Fatalf("Expected a temporary access key, got %s", accessKeyID)
+	}
+	session, found := api.credentials.Session(accessKeyID)
+	if !found {
+		t.Fatalf("Expected session %s to be registered", accessKeyID)
+	}
+	if len(session.Policies) != 2 {
+		t.Errorf("Expected the role and session policies, got %d policies", len(session.Policies))
+	}
+	if until := time.Until(session.Expiration); until < 59*time.Minute || until > time.Hour {
+		t.Errorf("Expected the session to last one hour, expires in %s", until)
+	}
+
+	rec = callSTS(api, accessKeyID, url.Values{"Action": {"GetCallerIdentity"}})
+	if !strings.Contains(rec.Body.String(), "<Arn>arn:aws:sts::000000000000:assumed-role/deployer/ci</Arn>") {
+		t.Errorf("Expected the caller to be the session, got %s", rec.Body.String())
+	}
+}
+
+func TestSTSAPI_AssumeRoleDenied(t *testing.T) {
+	api := newTestSTSAPI(t)
+
+	tests := []struct {
+		name        string
+		accessKeyID string
+		roleARN     string
+		code        int
+		errorCode   string
+	}{
+		{"untrusted user", "AKIABOB", "arn:aws:iam::000000000000:role/deployer", http.StatusForbidden, "AccessDenied"},
+		{"unknown role", "AKIAALICE", "arn:aws:iam::000000000000:role/admin", http.StatusForbidden, "AccessDenied"},
+		{"other account", "AKIAALICE", "arn:aws:iam::111111111111:role/deployer", http.StatusForbidden, "AccessDenied"},
+		{"invalid ARN", "AKIAALICE", "deployer", http.StatusBadRequest, "ValidationError"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := callSTS(api, tt.accessKeyID, url.Values{
+				"Action":          {"AssumeRole"},
+				"RoleArn":         {tt.roleARN},
+				"RoleSessionName": {"ci"},
+			})
+			if rec.Code != tt.code || !strings.Contains(rec.Body.String(), "<Code>"+tt.errorCode+"</Code>") {
+				t.Errorf("Expected %d %s, got %d: %s", tt.code, tt.errorCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestSTSAPI_DurationSeconds(t *testing.T) {
+	api := newTestSTSAPI(t)
+
+	for duration, code := range map[string]int{"900": http.StatusOK, "600": http.StatusBadRequest, "7200": http.StatusBadRequest} {
+		rec := callSTS(api, "AKIAALICE", url.Values{
+			"Action":          {"AssumeRole"},
+			"RoleArn":         {"arn:aws:iam::000000000000:role/deployer"},
+			"RoleSessionName": {"ci"},
+			"DurationSeconds": {duration},
+		})
+		if rec.Code != code {
+			t.Errorf("DurationSeconds %s: expected status %d, got %d: %s", duration, code, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestSTSAPI_AssumeRoleDeterministic(t *testing.T) {
+	deterministic.Enable(true)
+	t.Cleanup(func() { deterministic.Enable(false) })
+	api := newTestSTSAPI(t)
+
+	rec := callSTS(api, "AKIAALICE", url.Values{
+		"Action":          {"AssumeRole"},
+		"RoleArn":         {"arn:aws:iam::000000000000:role/deployer"},
+		"RoleSessionName": {"ci"},
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	body := rec.Body.String()
+	start := strings.Index(body, "<AccessKeyId>") + len("<AccessKeyId>")
+	session, found := api.credentials.Session(body[start : start+strings.Index(body[start:], "<")])
+	if !found {
+		t.Fatalf("Expected the session to be registered")
+	}
+	// The SigV4 verifier checks sessions against the wall clock
+	if until := time.Until(session.Expiration); until < 59*time.Minute || until > time.Hour {
+		t.Errorf("Expected the session to last one hour of wall time, expires in %s", until)
+	}
+}
+
+func TestSTSAPI_GetCallerIdentity(t *testing.T) {
+	api := newTestSTSAPI(t)
+
+	rec := callSTS(api, "AKIABOB", url.Values{"Action": {"GetCallerIdentity"}})
+	body := rec.Body.String()
+	if !strings.Contains(body, "<Arn>arn:aws:iam::000000000000:user/bob</Arn>") || !strings.Contains(body, "<UserId>AIDA") {
+		t.Errorf("Expected the identity of bob, got %s", body)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
 )
 
 // TagResource implements the TagResource operation
@@ -83,6 +84,14 @@ func (api *DefaultECSAPI) TagResource(ctx context.Context, req *generated.TagRes
 
 		// Invalidate cache
 		invalidateClusterCache(clusterName)
+	} else if strings.Contains(resourceArn, ":task-definition/") {
+		taskDef, existingTags, err := api.taskDefinitionTags(ctx, resourceArn)
+		if err != nil {
+			return nil, err
+		}
+		if err := api.updateTaskDefinitionTags(ctx, taskDef, mergeTags(existingTags, req.Tags)); err != nil {
+			return nil, err
+		}
 	} else {
 		// For other resource types, just validate they could exist
 		// In a full implementation, we'd check each resource type
@@ -171,6 +180,14 @@ func (api *DefaultECSAPI) UntagResource(ctx context.Context, req *generated.Unta
 
 		// Invalidate cache
 		invalidateClusterCache(clusterName)
+	} else if strings.Contains(resourceArn, ":task-definition/") {
+		taskDef, existingTags, err := api.taskDefinitionTags(ctx, resourceArn)
+		if err != nil {
+			return nil, err
+		}
+		if err := api.updateTaskDefinitionTags(ctx, taskDef, removeTagKeys(existingTags, req.TagKeys)); err != nil {
+			return nil, err
+		}
 	} else {
 		// For other resource types, just validate they could exist
 		// In a full implementation, we'd check each resource type
@@ -219,7 +236,13 @@ func (api *DefaultECSAPI) ListTagsForResource(ctx context.Context, req *generate
 	} else if strings.Contains(resourceArn, ":task/") {
 		// Empty tags for tasks
 	} else if strings.Contains(resourceArn, ":task-definition/") {
-		// Empty tags for task definitions
+		_, taskDefTags, err := api.taskDefinitionTags(ctx, resourceArn)
+		if err != nil {
+			return nil, err
+		}
+		if taskDefTags != nil {
+			tags = taskDefTags
+		}
 	} else if strings.Contains(resourceArn, ":container-instance/") {
 		// Empty tags for container instances
 	} else if strings.Contains(resourceArn, ":capacity-provider/") {
@@ -234,3 +257,69 @@ func (api *DefaultECSAPI) ListTagsForResource(ctx context.Context, req *generate
 
 	return resp, nil
 }
+
+// taskDefinitionTags returns the task definition revision of an ARN and its tags
+func (api *DefaultECSAPI) taskDefinitionTags(ctx context.Context, taskDefArn string) (*storage.TaskDefinition, []generated.Tag, error) {
+	taskDef, err := api.storage.TaskDefinitionStore().GetByARN(ctx, taskDefArn)
+	if err != nil || taskDef == nil {
+		return nil, nil, fmt.Errorf("The task definition '%s' does not exist", taskDefArn)
+	}
+	tags, err := parseTags(taskDef.ARN, taskDef.Tags)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse existing tags: %w", err)
+	}
+	return taskDef, tags, nil
+}
+
+// updateTaskDefinitionTags replaces the tags of a task definition revision
+func (api *DefaultECSAPI) updateTaskDefinitionTags(ctx context.Context, taskDef *storage.TaskDefinition, tags []generated.Tag) error {
+	tagsJSON := "[]"
+	if len(tags) > 0 {
+		data, err := json.Marshal(tags)
+		if err != nil {
+			return fmt.Errorf("failed to marshal tags: %w", err)
+		}
+		tagsJSON = string(data)
+	}
+
+	updated := *taskDef
+	updated.Tags = tagsJSON
+	if err := api.storage.TaskDefinitionStore().Update(ctx, &updated); err != nil {
+		return fmt.Errorf("failed to update task definition: %w", err)
+	}
+	return nil
+}
+
+// mergeTags adds tags to existing ones, replacing the values of existing
+// keys in place and appending new keys in order
+func mergeTags(existing, tags []generated.Tag) []generated.Tag {
+	merged := make([]generated.Tag, 0, len(existing)+len(tags))
+	index := make(map[string]int, len(existing)+len(tags))
+	for _, tag := range append(existing[:len(existing):len(existing)], tags...) {
+		if tag.Key == nil || tag.Value == nil {
+			continue
+		}
+		if i, ok := index[*tag.Key]; ok {
+			merged[i].Value = tag.Value
+			continue
+		}
+		index[*tag.Key] = len(merged)
+		merged = append(merged, tag)
+	}
+	return merged
+}
+
+// removeTagKeys returns the tags whose keys are not in keys
+func removeTagKeys(tags []generated.Tag, keys []string) []generated.Tag {
+	removed := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		removed[key] = true
+	}
+	var kept []generated.Tag
+	for _, tag := range tags {
+		if tag.Key != nil && !removed[*tag.Key] {
+			kept = append(kept, tag)
+		}
+	}
+	return kept
+}
@@ -7,12 +7,15 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/google/uuid"
-
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/types"
 )
 
 // extractRoleNameFromARN extracts the role name from an IAM role ARN
@@ -49,6 +52,9 @@ func (api *DefaultECSAPI) RegisterTaskDefinition(ctx context.Context, req *gener
 	if len(req.ContainerDefinitions) == 0 {
 		return nil, fmt.Errorf("containerDefinitions is required")
 	}
+	if err := validateTaskDefinitionPlacementConstraints(req.PlacementConstraints); err != nil {
+		return nil, err
+	}
 
 	// Set default values
 	networkMode := generated.NetworkModeBRIDGE
@@ -56,6 +62,22 @@ func (api *DefaultECSAPI) RegisterTaskDefinition(ctx context.Context, req *gener
 		networkMode = *req.NetworkMode
 	}
 
+	if err := validateProxyConfiguration(req, networkMode); err != nil {
+		return nil, err
+	}
+	if err := validatePortMappings(req.ContainerDefinitions, networkMode); err != nil {
+		return nil, err
+	}
+	if err := validateRestartPolicies(req.ContainerDefinitions); err != nil {
+		return nil, err
+	}
+
+	if api.imageResolver != nil {
+		if err := api.pinImageDigests(ctx, req.ContainerDefinitions); err != nil {
+			return nil, err
+		}
+	}
+
 	// Marshal complex fields to JSON
 	containerDefsJSON, err := json.Marshal(req.ContainerDefinitions)
 	if err != nil {
@@ -125,6 +147,20 @@ func (api *DefaultECSAPI) RegisterTaskDefinition(ctx context.Context, req *gener
 		runtimePlatformJSON = string(platformData)
 	}
 
+	ephemeralStorageJSON := ""
+	if req.EphemeralStorage != nil {
+		if req.EphemeralStorage.SizeInGiB < converters.MinEphemeralStorageGiB || req.EphemeralStorage.SizeInGiB > converters.MaxEphemeralStorageGiB {
+			return nil, &generated.ClientException{
+				Message: ptr.String(fmt.Sprintf("ephemeralStorage sizeInGiB must be between %d and %d", converters.MinEphemeralStorageGiB, converters.MaxEphemeralStorageGiB)),
+			}
+		}
+		storageData, err := json.Marshal(req.EphemeralStorage)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal ephemeral storage: %w", err)
+		}
+		ephemeralStorageJSON = string(storageData)
+	}
+
 	// Extract optional string values
 	var taskRoleARN, executionRoleARN, cpu, memory, pidMode, ipcMode string
 	if req.TaskRoleArn != nil {
@@ -148,7 +184,7 @@ func (api *DefaultECSAPI) RegisterTaskDefinition(ctx context.Context, req *gener
 
 	// Create storage task definition
 	storageTaskDef := &storage.TaskDefinition{
-		ID:                      uuid.New().String(),
+		ID:                      deterministic.NewUUID(),
 		Family:                  req.Family,
 		TaskRoleARN:             taskRoleARN,
 		ExecutionRoleARN:        executionRoleARN,
@@ -165,8 +201,34 @@ func (api *DefaultECSAPI) RegisterTaskDefinition(ctx context.Context, req *gener
 		ProxyConfiguration:      proxyConfigJSON,
 		InferenceAccelerators:   inferenceAcceleratorsJSON,
 		RuntimePlatform:         runtimePlatformJSON,
+		EphemeralStorage:        ephemeralStorageJSON,
 		Region:                  api.region,
-		AccountID:               api.accountID,
+		AccountID:               api.accountFor(ctx),
+	}
+
+	// Registering the content of the latest revision again returns that
+	// revision instead of adding an identical one, when enabled
+	if config.GetBool("taskDefinition.deduplicateRevisions") {
+		latest, err := api.storage.TaskDefinitionStore().GetLatest(ctx, req.Family)
+		if err == nil && latest.Status == "ACTIVE" &&
+			storage.TaskDefinitionContentHash(latest) == storage.TaskDefinitionContentHash(storageTaskDef) {
+			logging.Debug("Task definition content unchanged, reusing revision", "family", req.Family, "revision", latest.Revision)
+			// The tags of the request are added to the reused revision
+			tags, err := parseTags(latest.ARN, latest.Tags)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse tags: %w", err)
+			}
+			tags = mergeTags(tags, req.Tags)
+			if len(req.Tags) > 0 {
+				if err := api.updateTaskDefinitionTags(ctx, latest, tags); err != nil {
+					return nil, err
+				}
+			}
+			return &generated.RegisterTaskDefinitionResponse{
+				TaskDefinition: storageTaskDefinitionToGenerated(latest),
+				Tags:           tags,
+			}, nil
+		}
 	}
 
 	// Register the task definition
@@ -184,6 +246,177 @@ func (api *DefaultECSAPI) RegisterTaskDefinition(ctx context.Context, req *gener
 	}, nil
 }
 
+// pinImageDigests replaces the image tags of container definitions with the
+// digests they currently point to, so every task of the revision runs the
+// same image even when the tag is pushed again
+func (api *DefaultECSAPI) pinImageDigests(ctx context.Context, containerDefs []generated.ContainerDefinition) error {
+	for i := range containerDefs {
+		if containerDefs[i].Image == nil || *containerDefs[i].Image == "" {
+			continue
+		}
+		pinned, err := api.imageResolver.Pin(ctx, *containerDefs[i].Image)
+		if err != nil {
+			return &generated.ClientException{Message: ptr.String(err.Error())}
+		}
+		if pinned != *containerDefs[i].Image {
+			logging.Debug("Pinned image to digest", "image", *containerDefs[i].Image, "pinned", pinned)
+		}
+		containerDefs[i].Image = ptr.String(pinned)
+	}
+	return nil
+}
+
+// maxTaskDefinitionPlacementConstraints is the number of placement
+// constraints ECS accepts per task definition
+const maxTaskDefinitionPlacementConstraints = 10
+
+// validateTaskDefinitionPlacementConstraints rejects the constraints ECS does
+// not accept on a task definition. Only memberOf can be declared there,
+// distinctInstance is a RunTask and service constraint.
+func validateTaskDefinitionPlacementConstraints(constraints []generated.TaskDefinitionPlacementConstraint) error {
+	if len(constraints) > maxTaskDefinitionPlacementConstraints {
+		return &generated.ClientException{
+			Message: ptr.String(fmt.Sprintf("Too many placement constraints: %d. A task definition can have at most %d",
+				len(constraints), maxTaskDefinitionPlacementConstraints)),
+		}
+	}
+	for _, constraint := range constraints {
+		if constraint.Type == nil {
+			return &generated.ClientException{
+				Message: ptr.String("Placement constraint type is required"),
+			}
+		}
+		if *constraint.Type != generated.TaskDefinitionPlacementConstraintTypeMEMBER_OF {
+			return &generated.ClientException{
+				Message: ptr.String(fmt.Sprintf("Invalid placement constraint type: %s. Task definitions only support memberOf", *constraint.Type)),
+			}
+		}
+		if constraint.Expression == nil || strings.TrimSpace(*constraint.Expression) == "" {
+			return &generated.ClientException{
+				Message: ptr.String("The memberOf placement constraint requires an expression"),
+			}
+		}
+		if len(*constraint.Expression) > 2000 {
+			return &generated.ClientException{
+				Message: ptr.String("Placement constraint expression must be at most 2000 characters"),
+			}
+		}
+	}
+	return nil
+}
+
+// validateProxyConfiguration rejects a proxyConfiguration ECS would not
+// accept: its properties must be valid App Mesh settings, it requires the
+// awsvpc network mode and must name one of the containers.
+func validateProxyConfiguration(req *generated.RegisterTaskDefinitionRequest, networkMode generated.NetworkMode) error {
+	if req.ProxyConfiguration == nil {
+		return nil
+	}
+
+	var proxyConfig types.ProxyConfiguration
+	data, err := json.Marshal(req.ProxyConfiguration)
+	if err == nil {
+		err = json.Unmarshal(data, &proxyConfig)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to convert proxy configuration: %w", err)
+	}
+	proxy, err := converters.ParseAppMeshProxy(&proxyConfig)
+	if err != nil {
+		return &generated.ClientException{Message: ptr.String(err.Error())}
+	}
+
+	if networkMode != generated.NetworkModeAWSVPC {
+		return &generated.ClientException{
+			Message: ptr.String("Proxy configuration requires the awsvpc network mode"),
+		}
+	}
+	for _, containerDef := range req.ContainerDefinitions {
+		if containerDef.Name != nil && *containerDef.Name == proxy.ContainerName {
+			return nil
+		}
+	}
+	return &generated.ClientException{
+		Message: ptr.String(fmt.Sprintf("Proxy container %s is not defined in the container definitions", proxy.ContainerName)),
+	}
+}
+
+// validatePortMappings rejects port mappings ECS would not accept, e.g. a
+// containerPortRange in the host network mode or together with a hostPort
+func validatePortMappings(containerDefs []generated.ContainerDefinition, networkMode generated.NetworkMode) error {
+	for _, containerDef := range containerDefs {
+		if len(containerDef.PortMappings) == 0 {
+			continue
+		}
+
+		var mappings []types.PortMapping
+		data, err := json.Marshal(containerDef.PortMappings)
+		if err == nil {
+			err = json.Unmarshal(data, &mappings)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to convert port mappings: %w", err)
+		}
+
+		var name string
+		if containerDef.Name != nil {
+			name = *containerDef.Name
+		}
+		if err := converters.ValidatePortMappings(name, mappings, types.NetworkMode(networkMode)); err != nil {
+			return &generated.ClientException{Message: ptr.String(err.Error())}
+		}
+	}
+	return nil
+}
+
+// validateRestartPolicies rejects container restart policies ECS would not
+// accept, e.g. a restartAttemptPeriod shorter than a minute
+func validateRestartPolicies(containerDefs []generated.ContainerDefinition) error {
+	for _, containerDef := range containerDefs {
+		if containerDef.RestartPolicy == nil {
+			continue
+		}
+
+		policy := &types.ContainerRestartPolicy{Enabled: containerDef.RestartPolicy.Enabled}
+		for _, code := range containerDef.RestartPolicy.IgnoredExitCodes {
+			policy.IgnoredExitCodes = append(policy.IgnoredExitCodes, int(code))
+		}
+		if period := containerDef.RestartPolicy.RestartAttemptPeriod; period != nil {
+			policy.RestartAttemptPeriod = ptr.Int(int(*period))
+		}
+
+		var name string
+		if containerDef.Name != nil {
+			name = *containerDef.Name
+		}
+		if err := converters.ValidateRestartPolicy(name, policy); err != nil {
+			return &generated.ClientException{Message: ptr.String(err.Error())}
+		}
+	}
+	return nil
+}
+
+// checkTaskDefinitionUnused rejects deregistering a revision that services,
+// task sets or tasks of any cluster still use
+func (api *DefaultECSAPI) checkTaskDefinitionUnused(ctx context.Context, family string, revision int) error {
+	taskDef, err := api.storage.TaskDefinitionStore().Get(ctx, family, revision)
+	if err != nil {
+		// Missing revisions are reported by Deregister
+		return nil
+	}
+	refs, err := storage.FindTaskDefinitionReferences(ctx, api.storage, taskDef)
+	if err != nil {
+		return fmt.Errorf("failed to find task definition references: %w", err)
+	}
+	if refs.InUse() {
+		return &generated.ClientException{
+			Message: ptr.String(fmt.Sprintf("The task definition %s:%d is in use by %d services, %d task sets and %d tasks",
+				family, revision, len(refs.Services), len(refs.TaskSets), len(refs.Tasks))),
+		}
+	}
+	return nil
+}
+
 // DeregisterTaskDefinition implements the DeregisterTaskDefinition operation
 func (api *DefaultECSAPI) DeregisterTaskDefinition(ctx context.Context, req *generated.DeregisterTaskDefinitionRequest) (*generated.DeregisterTaskDefinitionResponse, error) {
 	if req.TaskDefinition == "" {
@@ -195,7 +428,7 @@ func (api *DefaultECSAPI) DeregisterTaskDefinition(ctx context.Context, req *gen
 	var err error
 
 	// Check if it's an ARN or family:revision format
-	if strings.HasPrefix(req.TaskDefinition, "arn:aws:ecs:") {
+	if awsarn.IsService(req.TaskDefinition, "ecs") {
 		// Parse ARN to get family and revision
 		taskDef, err := api.storage.TaskDefinitionStore().GetByARN(ctx, req.TaskDefinition)
 		if err != nil {
@@ -215,6 +448,12 @@ func (api *DefaultECSAPI) DeregisterTaskDefinition(ctx context.Context, req *gen
 		return nil, fmt.Errorf("task definition must include revision number")
 	}
 
+	if config.GetBool("taskDefinition.protectInUseRevisions") {
+		if err := api.checkTaskDefinitionUnused(ctx, family, revision); err != nil {
+			return nil, err
+		}
+	}
+
 	// Deregister the task definition
 	if err := api.storage.TaskDefinitionStore().Deregister(ctx, family, revision); err != nil {
 		// Check if it's already inactive (for idempotency)
@@ -255,7 +494,7 @@ func (api *DefaultECSAPI) DescribeTaskDefinition(ctx context.Context, req *gener
 	var err error
 
 	// Check if it's an ARN or family:revision format
-	if strings.HasPrefix(req.TaskDefinition, "arn:aws:ecs:") {
+	if awsarn.IsService(req.TaskDefinition, "ecs") {
 		// ARN format
 		taskDef, err = api.storage.TaskDefinitionStore().GetByARN(ctx, req.TaskDefinition)
 	} else if strings.Contains(req.TaskDefinition, ":") {
@@ -282,18 +521,28 @@ func (api *DefaultECSAPI) DescribeTaskDefinition(ctx context.Context, req *gener
 	// Convert to generated response
 	responseTaskDef := storageTaskDefinitionToGenerated(taskDef)
 
-	// Note: Include parameter handling would be here if needed
-	// Currently, generated.TaskDefinition doesn't have a Tags field
-
-	// Note: generated.TaskDefinition doesn't have a Tags field
-	// Tags are handled separately in the API response
-
 	logging.Debug("Response task definition container count",
 		"count", len(responseTaskDef.ContainerDefinitions))
 
-	return &generated.DescribeTaskDefinitionResponse{
+	resp := &generated.DescribeTaskDefinitionResponse{
 		TaskDefinition: responseTaskDef,
-	}, nil
+	}
+
+	// Tags are only returned when requested with include=TAGS
+	for _, field := range req.Include {
+		if field == generated.TaskDefinitionFieldTAGS {
+			tags, err := parseTags(taskDef.ARN, taskDef.Tags)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse tags: %w", err)
+			}
+			resp.Tags = tags
+			if resp.Tags == nil {
+				resp.Tags = []generated.Tag{}
+			}
+		}
+	}
+
+	return resp, nil
 }
 
 // DeleteTaskDefinitions implements the DeleteTaskDefinitions operation
@@ -312,7 +561,7 @@ func (api *DefaultECSAPI) DeleteTaskDefinitions(ctx context.Context, req *genera
 		var err error
 
 		// Parse the identifier
-		if strings.HasPrefix(taskDefIdentifier, "arn:aws:ecs:") {
+		if awsarn.IsService(taskDefIdentifier, "ecs") {
 			// Parse ARN to get family and revision
 			taskDef, err := api.storage.TaskDefinitionStore().GetByARN(ctx, taskDefIdentifier)
 			if err != nil {
@@ -389,9 +638,18 @@ func (api *DefaultECSAPI) ListTaskDefinitionFamilies(ctx context.Context, req *g
 		familyPrefix = *req.FamilyPrefix
 	}
 
+	// Both active and inactive families are listed by default
 	var status string
 	if req.Status != nil {
-		status = string(*req.Status)
+		switch *req.Status {
+		case generated.TaskDefinitionFamilyStatusACTIVE, generated.TaskDefinitionFamilyStatusINACTIVE:
+			status = string(*req.Status)
+		case generated.TaskDefinitionFamilyStatusALL:
+		default:
+			return nil, &generated.InvalidParameterException{
+				Message: ptr.String(fmt.Sprintf("Invalid status: %s. Valid values are ACTIVE, INACTIVE and ALL", *req.Status)),
+			}
+		}
 	}
 
 	limit := 100 // Default limit
@@ -407,7 +665,7 @@ func (api *DefaultECSAPI) ListTaskDefinitionFamilies(ctx context.Context, req *g
 	// Get families from storage
 	families, newNextToken, err := api.storage.TaskDefinitionStore().ListFamilies(ctx, familyPrefix, status, limit, nextToken)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list task definition families: %w", err)
+		return nil, toListError(err, "task definition families")
 	}
 
 	// Convert to response format
@@ -429,15 +687,37 @@ func (api *DefaultECSAPI) ListTaskDefinitionFamilies(ctx context.Context, req *g
 
 // ListTaskDefinitions implements the ListTaskDefinitions operation
 func (api *DefaultECSAPI) ListTaskDefinitions(ctx context.Context, req *generated.ListTaskDefinitionsRequest) (*generated.ListTaskDefinitionsResponse, error) {
-	// Extract parameters
-	var familyPrefix string
+	// Despite its name, familyPrefix selects a single family by its full name
+	var filters storage.TaskDefinitionFilters
 	if req.FamilyPrefix != nil {
-		familyPrefix = *req.FamilyPrefix
+		filters.Family = *req.FamilyPrefix
 	}
 
-	var status string
+	// Only active revisions are listed by default
+	filters.Status = string(generated.TaskDefinitionStatusACTIVE)
 	if req.Status != nil {
-		status = string(*req.Status)
+		switch *req.Status {
+		case generated.TaskDefinitionStatusACTIVE, generated.TaskDefinitionStatusINACTIVE, generated.TaskDefinitionStatusDELETE_IN_PROGRESS:
+			filters.Status = string(*req.Status)
+		default:
+			return nil, &generated.InvalidParameterException{
+				Message: ptr.String(fmt.Sprintf("Invalid status: %s. Valid values are ACTIVE, INACTIVE and DELETE_IN_PROGRESS", *req.Status)),
+			}
+		}
+	}
+
+	// ASC lists families lexicographically and their revisions oldest first,
+	// DESC reverses both
+	if req.Sort != nil {
+		switch *req.Sort {
+		case generated.SortOrderASC:
+		case generated.SortOrderDESC:
+			filters.Descending = true
+		default:
+			return nil, &generated.InvalidParameterException{
+				Message: ptr.String(fmt.Sprintf("Invalid sort: %s. Valid values are ASC and DESC", *req.Sort)),
+			}
+		}
 	}
 
 	limit := 100 // Default limit
@@ -450,51 +730,22 @@ func (api *DefaultECSAPI) ListTaskDefinitions(ctx context.Context, req *generate
 		nextToken = *req.NextToken
 	}
 
-	// Get all families that match the criteria
-	families, _, err := api.storage.TaskDefinitionStore().ListFamilies(ctx, familyPrefix, status, 0, "")
+	revisions, newNextToken, err := api.storage.TaskDefinitionStore().List(ctx, filters, limit, nextToken)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list task definition families: %w", err)
+		return nil, toListError(err, "task definitions")
 	}
 
-	// Collect all revisions for matching families
-	var allRevisions []string
-	for _, family := range families {
-		revisions, _, err := api.storage.TaskDefinitionStore().ListRevisions(ctx, family.Family, status, 0, "")
-		if err != nil {
-			logging.Error("Failed to list revisions for family", "family", family.Family, "error", err)
-			continue
-		}
-		for _, rev := range revisions {
-			allRevisions = append(allRevisions, rev.ARN)
-		}
-	}
-
-	// Sort by ARN (which includes family and revision)
-	// Apply pagination
-	start := 0
-	if nextToken != "" {
-		for i, arn := range allRevisions {
-			if arn > nextToken {
-				start = i
-				break
-			}
-		}
-	}
-
-	end := start + limit
-	if end > len(allRevisions) {
-		end = len(allRevisions)
+	taskDefinitionArns := make([]string, 0, len(revisions))
+	for _, rev := range revisions {
+		taskDefinitionArns = append(taskDefinitionArns, rev.ARN)
 	}
 
-	taskDefinitionArns := allRevisions[start:end]
-
 	response := &generated.ListTaskDefinitionsResponse{
 		TaskDefinitionArns: taskDefinitionArns,
 	}
 
-	// Set next token if there are more results
-	if end < len(allRevisions) {
-		response.NextToken = ptr.String(allRevisions[end-1])
+	if newNextToken != "" {
+		response.NextToken = ptr.String(newNextToken)
 	}
 
 	return response, nil
@@ -605,6 +856,12 @@ func storageTaskDefinitionToGenerated(taskDef *storage.TaskDefinition) *generate
 			response.RuntimePlatform = &platform
 		}
 	}
+	if taskDef.EphemeralStorage != "" {
+		var ephemeralStorage generated.EphemeralStorage
+		if err := json.Unmarshal([]byte(taskDef.EphemeralStorage), &ephemeralStorage); err == nil {
+			response.EphemeralStorage = &ephemeralStorage
+		}
+	}
 
 	return response
 }
@@ -2,6 +2,11 @@ package api
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -10,6 +15,8 @@ import (
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/mocks"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/taskdeftemplate"
 )
 
 var _ = Describe("Task Definition ECS API", func() {
@@ -98,6 +105,369 @@ var _ = Describe("Task Definition ECS API", func() {
 				Expect(err.Error()).To(ContainSubstring("family is required"))
 			})
 		})
+
+		Context("when revision deduplication is enabled", func() {
+			BeforeEach(func() {
+				config.Set("taskDefinition.deduplicateRevisions", true)
+				DeferCleanup(config.Set, "taskDefinition.deduplicateRevisions", false)
+			})
+
+			newRequest := func(image string) *generated.RegisterTaskDefinitionRequest {
+				return &generated.RegisterTaskDefinitionRequest{
+					Family: "dedup",
+					ContainerDefinitions: []generated.ContainerDefinition{
+						{Name: ptr.String("app"), Image: ptr.String(image), Memory: ptr.Int32(256)},
+					},
+				}
+			}
+
+			It("should return the latest revision when its content is registered again", func() {
+				resp1, err := server.ecsAPI.RegisterTaskDefinition(ctx, newRequest("app:v1"))
+				Expect(err).NotTo(HaveOccurred())
+
+				resp2, err := server.ecsAPI.RegisterTaskDefinition(ctx, newRequest("app:v1"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(*resp2.TaskDefinition.Revision).To(Equal(int32(1)))
+				Expect(*resp2.TaskDefinition.TaskDefinitionArn).To(Equal(*resp1.TaskDefinition.TaskDefinitionArn))
+			})
+
+			It("should register a new revision when the content changes", func() {
+				_, err := server.ecsAPI.RegisterTaskDefinition(ctx, newRequest("app:v1"))
+				Expect(err).NotTo(HaveOccurred())
+
+				resp, err := server.ecsAPI.RegisterTaskDefinition(ctx, newRequest("app:v2"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(*resp.TaskDefinition.Revision).To(Equal(int32(2)))
+			})
+
+			It("should register a new revision when the latest one was deregistered", func() {
+				_, err := server.ecsAPI.RegisterTaskDefinition(ctx, newRequest("app:v1"))
+				Expect(err).NotTo(HaveOccurred())
+				_, err = server.ecsAPI.DeregisterTaskDefinition(ctx, &generated.DeregisterTaskDefinitionRequest{TaskDefinition: "dedup:1"})
+				Expect(err).NotTo(HaveOccurred())
+
+				resp, err := server.ecsAPI.RegisterTaskDefinition(ctx, newRequest("app:v1"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(*resp.TaskDefinition.Revision).To(Equal(int32(2)))
+			})
+		})
+		Context("when the task definition has placement constraints", func() {
+			newRequest := func(constraints ...generated.TaskDefinitionPlacementConstraint) *generated.RegisterTaskDefinitionRequest {
+				return &generated.RegisterTaskDefinitionRequest{
+					Family: "placed",
+					ContainerDefinitions: []generated.ContainerDefinition{
+						{Name: ptr.String("app"), Image: ptr.String("app:v1"), Memory: ptr.Int32(256)},
+					},
+					PlacementConstraints: constraints,
+				}
+			}
+			constraintType := func(t string) *generated.TaskDefinitionPlacementConstraintType {
+				v := generated.TaskDefinitionPlacementConstraintType(t)
+				return &v
+			}
+
+			It("should register and describe memberOf constraints", func() {
+				_, err := server.ecsAPI.RegisterTaskDefinition(ctx, newRequest(generated.TaskDefinitionPlacementConstraint{
+					Type:       constraintType("memberOf"),
+					Expression: ptr.String("attribute:ecs.instance-type == t3.large"),
+				}))
+				Expect(err).NotTo(HaveOccurred())
+
+				resp, err := server.ecsAPI.DescribeTaskDefinition(ctx, &generated.DescribeTaskDefinitionRequest{TaskDefinition: "placed:1"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.TaskDefinition.PlacementConstraints).To(HaveLen(1))
+				Expect(*resp.TaskDefinition.PlacementConstraints[0].Expression).To(Equal("attribute:ecs.instance-type == t3.large"))
+			})
+
+			It("should reject constraint types other than memberOf", func() {
+				_, err := server.ecsAPI.RegisterTaskDefinition(ctx, newRequest(generated.TaskDefinitionPlacementConstraint{
+					Type: constraintType("distinctInstance"),
+				}))
+				Expect(err).To(BeAssignableToTypeOf(&generated.ClientException{}))
+				Expect(*err.(*generated.ClientException).Message).To(ContainSubstring("Invalid placement constraint type: distinctInstance"))
+			})
+
+			It("should reject memberOf constraints without an expression", func() {
+				_, err := server.ecsAPI.RegisterTaskDefinition(ctx, newRequest(generated.TaskDefinitionPlacementConstraint{
+					Type: constraintType("memberOf"),
+				}))
+				Expect(err).To(BeAssignableToTypeOf(&generated.ClientException{}))
+				Expect(*err.(*generated.ClientException).Message).To(ContainSubstring("requires an expression"))
+			})
+
+			It("should reject more than 10 constraints", func() {
+				constraints := make([]generated.TaskDefinitionPlacementConstraint, 11)
+				for i := range constraints {
+					constraints[i] = generated.TaskDefinitionPlacementConstraint{
+						Type:       constraintType("memberOf"),
+						Expression: ptr.String("attribute:ecs.os-type == linux"),
+					}
+				}
+				_, err := server.ecsAPI.RegisterTaskDefinition(ctx, newRequest(constraints...))
+				Expect(err).To(BeAssignableToTypeOf(&generated.ClientException{}))
+				Expect(*err.(*generated.ClientException).Message).To(ContainSubstring("Too many placement constraints"))
+			})
+		})
+		Context("when the task definition has a proxy configuration", func() {
+			newRequest := func(containerName string, networkMode generated.NetworkMode, properties ...generated.KeyValuePair) *generated.RegisterTaskDefinitionRequest {
+				proxyType := generated.ProxyConfigurationTypeAPPMESH
+				return &generated.RegisterTaskDefinitionRequest{
+					Family:      "mesh",
+					NetworkMode: &networkMode,
+					ContainerDefinitions: []generated.ContainerDefinition{
+						{Name: ptr.String("app"), Image: ptr.String("app:v1"), Memory: ptr.Int32(256)},
+						{Name: ptr.String("envoy"), Image: ptr.String("envoy:v1"), Memory: ptr.Int32(256)},
+					},
+					ProxyConfiguration: &generated.ProxyConfiguration{
+						Type:          &proxyType,
+						ContainerName: containerName,
+						Properties:    properties,
+					},
+				}
+			}
+			validProperties := []generated.KeyValuePair{
+				{Name: ptr.String("IgnoredUID"), Value: ptr.String("1337")},
+				{Name: ptr.String("ProxyEgressPort"), Value: ptr.String("15001")},
+			}
+
+			It("should register a valid App Mesh proxy configuration", func() {
+				resp, err := server.ecsAPI.RegisterTaskDefinition(ctx, newRequest("envoy", generated.NetworkModeAWSVPC, validProperties...))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.TaskDefinition.ProxyConfiguration).NotTo(BeNil())
+				Expect(resp.TaskDefinition.ProxyConfiguration.ContainerName).To(Equal("envoy"))
+			})
+
+			It("should reject proxy containers missing from the container definitions", func() {
+				_, err := server.ecsAPI.RegisterTaskDefinition(ctx, newRequest("sidecar", generated.NetworkModeAWSVPC, validProperties...))
+				Expect(err).To(BeAssignableToTypeOf(&generated.ClientException{}))
+				Expect(*err.(*generated.ClientException).Message).To(ContainSubstring("Proxy container sidecar is not defined"))
+			})
+
+			It("should require the awsvpc network mode", func() {
+				_, err := server.ecsAPI.RegisterTaskDefinition(ctx, newRequest("envoy", generated.NetworkModeBRIDGE, validProperties...))
+				Expect(err).To(BeAssignableToTypeOf(&generated.ClientException{}))
+				Expect(*err.(*generated.ClientException).Message).To(ContainSubstring("awsvpc"))
+			})
+
+			It("should reject invalid properties", func() {
+				_, err := server.ecsAPI.RegisterTaskDefinition(ctx, newRequest("envoy", generated.NetworkModeAWSVPC,
+					generated.KeyValuePair{Name: ptr.String("IgnoredUID"), Value: ptr.String("1337")},
+					generated.KeyValuePair{Name: ptr.String("ProxyEgressPort"), Value: ptr.String("not-a-port")},
+				))
+				Expect(err).To(BeAssignableToTypeOf(&generated.ClientException{}))
+				Expect(*err.(*generated.ClientException).Message).To(ContainSubstring("ProxyEgressPort"))
+			})
+		})
+		Context("when the task definition has port ranges", func() {
+			newRequest := func(networkMode generated.NetworkMode, mappings ...generated.PortMapping) *generated.RegisterTaskDefinitionRequest {
+				return &generated.RegisterTaskDefinitionRequest{
+					Family:      "ranged",
+					NetworkMode: &networkMode,
+					ContainerDefinitions: []generated.ContainerDefinition{
+						{Name: ptr.String("app"), Image: ptr.String("app:v1"), Memory: ptr.Int32(256), PortMappings: mappings},
+					},
+				}
+			}
+
+			It("should register a containerPortRange", func() {
+				resp, err := server.ecsAPI.RegisterTaskDefinition(ctx, newRequest(generated.NetworkModeBRIDGE,
+					generated.PortMapping{ContainerPortRange: ptr.String("8000-8010")},
+				))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(*resp.TaskDefinition.ContainerDefinitions[0].PortMappings[0].ContainerPortRange).To(Equal("8000-8010"))
+			})
+
+			It("should reject a containerPortRange in the host network mode", func() {
+				_, err := server.ecsAPI.RegisterTaskDefinition(ctx, newRequest(generated.NetworkModeHOST,
+					generated.PortMapping{ContainerPortRange: ptr.String("8000-8010")},
+				))
+				Expect(err).To(BeAssignableToTypeOf(&generated.ClientException{}))
+				Expect(*err.(*generated.ClientException).Message).To(ContainSubstring("awsvpc or bridge"))
+			})
+
+			It("should reject a containerPortRange with a hostPort", func() {
+				_, err := server.ecsAPI.RegisterTaskDefinition(ctx, newRequest(generated.NetworkModeBRIDGE,
+					generated.PortMapping{ContainerPortRange: ptr.String("8000-8010"), HostPort: ptr.Int32(9000)},
+				))
+				Expect(err).To(BeAssignableToTypeOf(&generated.ClientException{}))
+				Expect(*err.(*generated.ClientException).Message).To(ContainSubstring("hostPort"))
+			})
+
+			It("should reject an invalid range", func() {
+				_, err := server.ecsAPI.RegisterTaskDefinition(ctx, newRequest(generated.NetworkModeAWSVPC,
+					generated.PortMapping{ContainerPortRange: ptr.String("8010-8000")},
+				))
+				Expect(err).To(BeAssignableToTypeOf(&generated.ClientException{}))
+				Expect(*err.(*generated.ClientException).Message).To(ContainSubstring("start must be lower than end"))
+			})
+		})
+
+		Context("when a container has a restart policy", func() {
+			newRequest := func(policy *generated.ContainerRestartPolicy) *generated.RegisterTaskDefinitionRequest {
+				return &generated.RegisterTaskDefinitionRequest{
+					Family: "restarting",
+					ContainerDefinitions: []generated.ContainerDefinition{
+						{Name: ptr.String("app"), Image: ptr.String("app:v1"), Memory: ptr.Int32(256), RestartPolicy: policy},
+					},
+				}
+			}
+
+			It("should register the restart policy", func() {
+				resp, err := server.ecsAPI.RegisterTaskDefinition(ctx, newRequest(&generated.ContainerRestartPolicy{
+					Enabled:              true,
+					IgnoredExitCodes:     []int32{0},
+					RestartAttemptPeriod: ptr.Int32(120),
+				}))
+				Expect(err).NotTo(HaveOccurred())
+				policy := resp.TaskDefinition.ContainerDefinitions[0].RestartPolicy
+				Expect(policy).NotTo(BeNil())
+				Expect(policy.Enabled).To(BeTrue())
+				Expect(policy.IgnoredExitCodes).To(Equal([]int32{0}))
+				Expect(*policy.RestartAttemptPeriod).To(Equal(int32(120)))
+			})
+
+			It("should reject a restartAttemptPeriod shorter than a minute", func() {
+				_, err := server.ecsAPI.RegisterTaskDefinition(ctx, newRequest(&generated.ContainerRestartPolicy{
+					Enabled:              true,
+					RestartAttemptPeriod: ptr.Int32(10),
+				}))
+				Expect(err).To(BeAssignableToTypeOf(&generated.ClientException{}))
+				Expect(*err.(*generated.ClientException).Message).To(ContainSubstring("restartAttemptPeriod"))
+			})
+		})
+
+		Context("when the task definition has ephemeral storage", func() {
+			newRequest := func(sizeInGiB int32) *generated.RegisterTaskDefinitionRequest {
+				return &generated.RegisterTaskDefinitionRequest{
+					Family:           "scratch",
+					EphemeralStorage: &generated.EphemeralStorage{SizeInGiB: sizeInGiB},
+					ContainerDefinitions: []generated.ContainerDefinition{
+						{Name: ptr.String("app"), Image: ptr.String("app:v1"), Memory: ptr.Int32(256)},
+					},
+				}
+			}
+
+			It("should return the ephemeral storage", func() {
+				resp, err := server.ecsAPI.RegisterTaskDefinition(ctx, newRequest(50))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.TaskDefinition.EphemeralStorage).NotTo(BeNil())
+				Expect(resp.TaskDefinition.EphemeralStorage.SizeInGiB).To(Equal(int32(50)))
+			})
+
+			It("should reject a size outside of 21 to 200 GiB", func() {
+				_, err := server.ecsAPI.RegisterTaskDefinition(ctx, newRequest(20))
+				Expect(err).To(BeAssignableToTypeOf(&generated.ClientException{}))
+				Expect(*err.(*generated.ClientException).Message).To(ContainSubstring("between 21 and 200"))
+			})
+		})
+
+		Context("when image digests are pinned", func() {
+			var resolver *fakeImageResolver
+
+			BeforeEach(func() {
+				resolver = &fakeImageResolver{digests: map[string]string{"nginx:1.27": "sha256:0123"}}
+				server.ecsAPI.(*DefaultECSAPI).SetImageResolver(resolver)
+			})
+
+			newRequest := func(image string) *generated.RegisterTaskDefinitionRequest {
+				return &generated.RegisterTaskDefinitionRequest{
+					Family: "pinned",
+					ContainerDefinitions: []generated.ContainerDefinition{
+						{Name: ptr.String("app"), Image: ptr.String(image), Memory: ptr.Int32(256)},
+					},
+				}
+			}
+
+			It("should record the digest of the image", func() {
+				resp, err := server.ecsAPI.RegisterTaskDefinition(ctx, newRequest("nginx:1.27"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(*resp.TaskDefinition.ContainerDefinitions[0].Image).To(Equal("nginx:1.27@sha256:0123"))
+			})
+
+			It("should reject images whose digest cannot be resolved", func() {
+				_, err := server.ecsAPI.RegisterTaskDefinition(ctx, newRequest("missing:latest"))
+				Expect(err).To(BeAssignableToTypeOf(&generated.ClientException{}))
+				Expect(*err.(*generated.ClientException).Message).To(ContainSubstring("missing:latest"))
+			})
+		})
+
+		Context("with a template", func() {
+			const template = `{
+				"family": "app",
+				"cpu": "${CPU}",
+				"containerDefinitions": [{
+					"name": "app",
+					"image": "app:${VERSION}",
+					"memory": ${MEMORY},
+					"environment": [{"name": "MESSAGE", "value": "${MESSAGE}"}]
+				}]
+			}`
+
+			register := func(body string) (int, map[string]interface{}) {
+				req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+				req.Header.Set("X-Amz-Target", "AmazonEC2ContainerServiceV20141113.RegisterTaskDefinition")
+				rec := httptest.NewRecorder()
+				if req, ok := withTaskDefinitionTemplate(rec, req); ok {
+					generated.NewRouter(server.ecsAPI).Route(rec, req)
+				}
+				var resp map[string]interface{}
+				Expect(json.Unmarshal(rec.Body.Bytes(), &resp)).To(Succeed())
+				return rec.Code, resp
+			}
+
+			templateRequest := func(variables map[string]string) string {
+				body, err := json.Marshal(map[string]interface{}{
+					"template":          template,
+					"templateName":      "app",
+					"templateVariables": variables,
+					"tags":              []map[string]string{{"key": "env", "value": "prod"}},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				return string(body)
+			}
+
+			It("should register the rendered template with its reference", func() {
+				code, resp := register(templateRequest(map[string]string{
+					"CPU": "256", "VERSION": "1.4.2", "MEMORY": "512", "MESSAGE": `say "hi"`,
+				}))
+				Expect(code).To(Equal(http.StatusOK), fmt.Sprint(resp))
+
+				taskDef, err := mockTaskDefStore.Get(ctx, "app", 1)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(taskDef.CPU).To(Equal("256"))
+				var containers []generated.ContainerDefinition
+				Expect(json.Unmarshal([]byte(taskDef.ContainerDefinitions), &containers)).To(Succeed())
+				Expect(*containers[0].Image).To(Equal("app:1.4.2"))
+				Expect(*containers[0].Memory).To(Equal(int32(512)))
+				Expect(*containers[0].Environment[0].Value).To(Equal(`say "hi"`))
+
+				var tags []generated.Tag
+				Expect(json.Unmarshal([]byte(taskDef.Tags), &tags)).To(Succeed())
+				Expect(tags).To(ConsistOf(
+					generated.Tag{Key: ptr.String("env"), Value: ptr.String("prod")},
+					generated.Tag{Key: ptr.String(taskdeftemplate.NameTag), Value: ptr.String("app")},
+					generated.Tag{Key: ptr.String(taskdeftemplate.DigestTag), Value: ptr.String(taskdeftemplate.Digest(template))},
+				))
+			})
+
+			It("should reject templates with undefined variables", func() {
+				code, resp := register(templateRequest(map[string]string{"CPU": "256", "MEMORY": "512"}))
+				Expect(code).To(Equal(http.StatusBadRequest))
+				Expect(resp["__type"]).To(Equal("ClientException"))
+				Expect(resp["message"]).To(ContainSubstring("MESSAGE, VERSION"))
+
+				_, err := mockTaskDefStore.GetLatest(ctx, "app")
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("should leave requests without a template unchanged", func() {
+				code, resp := register(`{"family":"plain","containerDefinitions":[{"name":"app","image":"nginx:${TAG}","memory":128}]}`)
+				Expect(code).To(Equal(http.StatusOK), fmt.Sprint(resp))
+				taskDef, err := mockTaskDefStore.Get(ctx, "plain", 1)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(taskDef.ContainerDefinitions).To(ContainSubstring("nginx:${TAG}"))
+			})
+		})
 	})
 
 	Describe("DescribeTaskDefinition", func() {
@@ -178,6 +548,70 @@ var _ = Describe("Task Definition ECS API", func() {
 				Expect(err.Error()).To(ContainSubstring("not found"))
 			})
 		})
+
+		Context("when the task definition has tags", func() {
+			var taskDefArn string
+
+			tag := func(key, value string) generated.Tag {
+				return generated.Tag{Key: ptr.String(key), Value: ptr.String(value)}
+			}
+
+			describeTags := func() []generated.Tag {
+				resp, err := server.ecsAPI.DescribeTaskDefinition(ctx, &generated.DescribeTaskDefinitionRequest{
+					TaskDefinition: "tagged",
+					Include:        []generated.TaskDefinitionField{generated.TaskDefinitionFieldTAGS},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				return resp.Tags
+			}
+
+			BeforeEach(func() {
+				resp, err := server.ecsAPI.RegisterTaskDefinition(ctx, &generated.RegisterTaskDefinitionRequest{
+					Family: "tagged",
+					ContainerDefinitions: []generated.ContainerDefinition{
+						{Name: ptr.String("app"), Image: ptr.String("app:latest"), Memory: ptr.Int32(512)},
+					},
+					Tags: []generated.Tag{tag("team", "payments"), tag("env", "dev")},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				taskDefArn = *resp.TaskDefinition.TaskDefinitionArn
+			})
+
+			It("should return the registered tags with include=TAGS only", func() {
+				Expect(describeTags()).To(Equal([]generated.Tag{tag("team", "payments"), tag("env", "dev")}))
+
+				resp, err := server.ecsAPI.DescribeTaskDefinition(ctx, &generated.DescribeTaskDefinitionRequest{TaskDefinition: "tagged"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.Tags).To(BeNil())
+			})
+
+			It("should tag and untag the revision", func() {
+				_, err := server.ecsAPI.TagResource(ctx, &generated.TagResourceRequest{
+					ResourceArn: taskDefArn,
+					Tags:        []generated.Tag{tag("env", "prod"), tag("owner", "alice")},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(describeTags()).To(Equal([]generated.Tag{tag("team", "payments"), tag("env", "prod"), tag("owner", "alice")}))
+
+				_, err = server.ecsAPI.UntagResource(ctx, &generated.UntagResourceRequest{
+					ResourceArn: taskDefArn,
+					TagKeys:     []string{"team"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				list, err := server.ecsAPI.ListTagsForResource(ctx, &generated.ListTagsForResourceRequest{ResourceArn: taskDefArn})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(list.Tags).To(Equal([]generated.Tag{tag("env", "prod"), tag("owner", "alice")}))
+			})
+
+			It("should reject tagging unknown revisions", func() {
+				_, err := server.ecsAPI.TagResource(ctx, &generated.TagResourceRequest{
+					ResourceArn: taskDefArn + "0",
+					Tags:        []generated.Tag{tag("env", "prod")},
+				})
+				Expect(err).To(MatchError(ContainSubstring("does not exist")))
+			})
+		})
 	})
 
 	Describe("DeregisterTaskDefinition", func() {
@@ -242,6 +676,66 @@ var _ = Describe("Task Definition ECS API", func() {
 				Expect(err.Error()).To(ContainSubstring("must include revision"))
 			})
 		})
+
+		Context("when in-use protection is enabled", func() {
+			var taskDefARN string
+
+			BeforeEach(func() {
+				config.Set("taskDefinition.protectInUseRevisions", true)
+				DeferCleanup(config.Set, "taskDefinition.protectInUseRevisions", false)
+
+				mockClusterStore := mocks.NewMockClusterStore()
+				mockServiceStore := mocks.NewMockServiceStore()
+				mockStorage.SetClusterStore(mockClusterStore)
+				mockStorage.SetServiceStore(mockServiceStore)
+				mockStorage.SetTaskStore(mocks.NewMockTaskStore())
+
+				resp, err := server.ecsAPI.RegisterTaskDefinition(ctx, &generated.RegisterTaskDefinitionRequest{
+					Family: "protected",
+					ContainerDefinitions: []generated.ContainerDefinition{
+						{Name: ptr.String("app"), Image: ptr.String("app:latest"), Memory: ptr.Int32(256)},
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				taskDefARN = *resp.TaskDefinition.TaskDefinitionArn
+
+				clusterARN := "arn:aws:ecs:us-east-1:000000000000:cluster/other"
+				Expect(mockClusterStore.Create(ctx, &storage.Cluster{Name: "other", ARN: clusterARN})).To(Succeed())
+				Expect(mockServiceStore.Create(ctx, &storage.Service{
+					ServiceName:       "web",
+					ARN:               "arn:aws:ecs:us-east-1:000000000000:service/other/web",
+					ClusterARN:        clusterARN,
+					TaskDefinitionARN: taskDefARN,
+					Status:            "ACTIVE",
+				})).To(Succeed())
+			})
+
+			It("should reject deregistering a revision used by a service of any cluster", func() {
+				_, err := server.ecsAPI.DeregisterTaskDefinition(ctx, &generated.DeregisterTaskDefinitionRequest{
+					TaskDefinition: "protected:1",
+				})
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(BeAssignableToTypeOf(&generated.ClientException{}))
+				Expect(*err.(*generated.ClientException).Message).To(ContainSubstring("in use by 1 services"))
+
+				taskDef, err := mockTaskDefStore.GetByARN(ctx, taskDefARN)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(taskDef.Status).To(Equal("ACTIVE"))
+			})
+
+			It("should deregister a revision no longer used", func() {
+				service, err := mockStorage.ServiceStore().GetByARN(ctx, "arn:aws:ecs:us-east-1:000000000000:service/other/web")
+				Expect(err).NotTo(HaveOccurred())
+				service.Status = "INACTIVE"
+				Expect(mockStorage.ServiceStore().Update(ctx, service)).To(Succeed())
+
+				resp, err := server.ecsAPI.DeregisterTaskDefinition(ctx, &generated.DeregisterTaskDefinitionRequest{
+					TaskDefinition: taskDefARN,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(*resp.TaskDefinition.Status).To(Equal(generated.TaskDefinitionStatusINACTIVE))
+			})
+		})
 	})
 
 	Describe("ListTaskDefinitionFamilies", func() {
@@ -305,6 +799,34 @@ var _ = Describe("Task Definition ECS API", func() {
 				Expect(resp.Families).To(HaveLen(2))
 				Expect(resp.NextToken).NotTo(BeNil())
 			})
+
+			It("should filter families by status", func() {
+				_, err := server.ecsAPI.DeregisterTaskDefinition(ctx, &generated.DeregisterTaskDefinitionRequest{
+					TaskDefinition: "app-web:1",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				status := generated.TaskDefinitionFamilyStatusACTIVE
+				resp, err := server.ecsAPI.ListTaskDefinitionFamilies(ctx, &generated.ListTaskDefinitionFamiliesRequest{Status: &status})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.Families).To(Equal([]string{"app-api", "worker-batch", "worker-stream"}))
+
+				status = generated.TaskDefinitionFamilyStatusINACTIVE
+				resp, err = server.ecsAPI.ListTaskDefinitionFamilies(ctx, &generated.ListTaskDefinitionFamiliesRequest{Status: &status})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.Families).To(Equal([]string{"app-web"}))
+
+				status = generated.TaskDefinitionFamilyStatusALL
+				resp, err = server.ecsAPI.ListTaskDefinitionFamilies(ctx, &generated.ListTaskDefinitionFamiliesRequest{Status: &status})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.Families).To(HaveLen(4))
+			})
+
+			It("should reject an unknown status", func() {
+				status := generated.TaskDefinitionFamilyStatus("DELETED")
+				_, err := server.ecsAPI.ListTaskDefinitionFamilies(ctx, &generated.ListTaskDefinitionFamiliesRequest{Status: &status})
+				Expect(err).To(BeAssignableToTypeOf(&generated.InvalidParameterException{}))
+			})
 		})
 	})
 
@@ -354,6 +876,78 @@ var _ = Describe("Task Definition ECS API", func() {
 				Expect(resp).NotTo(BeNil())
 				Expect(resp.TaskDefinitionArns).To(HaveLen(3))
 			})
+
+			It("should match the full family name only", func() {
+				_, err := server.ecsAPI.RegisterTaskDefinition(ctx, &generated.RegisterTaskDefinitionRequest{
+					Family: "list-test-other",
+					ContainerDefinitions: []generated.ContainerDefinition{
+						{Name: ptr.String("container"), Image: ptr.String("image:latest"), Memory: ptr.Int32(512)},
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				resp, err := server.ecsAPI.ListTaskDefinitions(ctx, &generated.ListTaskDefinitionsRequest{
+					FamilyPrefix: ptr.String("list-test"),
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.TaskDefinitionArns).To(HaveLen(3))
+
+				resp, err = server.ecsAPI.ListTaskDefinitions(ctx, &generated.ListTaskDefinitionsRequest{
+					FamilyPrefix: ptr.String("list"),
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.TaskDefinitionArns).To(BeEmpty())
+			})
+
+			It("should sort revisions ascending by default and descending on request", func() {
+				resp, err := server.ecsAPI.ListTaskDefinitions(ctx, &generated.ListTaskDefinitionsRequest{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.TaskDefinitionArns[0]).To(HaveSuffix("list-test:1"))
+				Expect(resp.TaskDefinitionArns[2]).To(HaveSuffix("list-test:3"))
+
+				sort := generated.SortOrderDESC
+				resp, err = server.ecsAPI.ListTaskDefinitions(ctx, &generated.ListTaskDefinitionsRequest{
+					Sort:       &sort,
+					MaxResults: ptr.Int32(2),
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.TaskDefinitionArns).To(HaveLen(2))
+				Expect(resp.TaskDefinitionArns[0]).To(HaveSuffix("list-test:3"))
+				Expect(resp.TaskDefinitionArns[1]).To(HaveSuffix("list-test:2"))
+				Expect(resp.NextToken).NotTo(BeNil())
+
+				resp, err = server.ecsAPI.ListTaskDefinitions(ctx, &generated.ListTaskDefinitionsRequest{
+					Sort:      &sort,
+					NextToken: resp.NextToken,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.TaskDefinitionArns).To(HaveLen(1))
+				Expect(resp.TaskDefinitionArns[0]).To(HaveSuffix("list-test:1"))
+			})
+
+			It("should list only active revisions unless asked for inactive ones", func() {
+				_, err := server.ecsAPI.DeregisterTaskDefinition(ctx, &generated.DeregisterTaskDefinitionRequest{
+					TaskDefinition: "list-test:2",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				resp, err := server.ecsAPI.ListTaskDefinitions(ctx, &generated.ListTaskDefinitionsRequest{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.TaskDefinitionArns).To(HaveLen(2))
+				Expect(resp.TaskDefinitionArns).NotTo(ContainElement(HaveSuffix("list-test:2")))
+
+				status := generated.TaskDefinitionStatusINACTIVE
+				resp, err = server.ecsAPI.ListTaskDefinitions(ctx, &generated.ListTaskDefinitionsRequest{Status: &status})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.TaskDefinitionArns).To(HaveLen(1))
+				Expect(resp.TaskDefinitionArns[0]).To(HaveSuffix("list-test:2"))
+			})
+
+			It("should reject an unknown sort order", func() {
+				sort := generated.SortOrder("RANDOM")
+				_, err := server.ecsAPI.ListTaskDefinitions(ctx, &generated.ListTaskDefinitionsRequest{Sort: &sort})
+				Expect(err).To(BeAssignableToTypeOf(&generated.InvalidParameterException{}))
+			})
 		})
 	})
 
@@ -425,3 +1019,16 @@ var _ = Describe("Task Definition ECS API", func() {
 		})
 	})
 })
+
+// fakeImageResolver pins the images of a fixed set of digests
+type fakeImageResolver struct {
+	digests map[string]string
+}
+
+func (r *fakeImageResolver) Pin(_ context.Context, image string) (string, error) {
+	digest, ok := r.digests[image]
+	if !ok {
+		return "", fmt.Errorf("failed to resolve digest of image %q", image)
+	}
+	return image + "@" + digest, nil
+}
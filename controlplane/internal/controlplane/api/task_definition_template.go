@@ -0,0 +1,93 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/taskdeftemplate"
+)
+
+// taskDefinitionTemplate holds the extension members of a
+// RegisterTaskDefinition request, which register a rendered template instead
+// of the request members:
+//
+//	{
+//	  "template": "{\"family\": \"app\", \"containerDefinitions\": [{\"image\": \"app:${VERSION}\", ...}]}",
+//	  "templateName": "app",
+//	  "templateVariables": {"VERSION": "1.4.2"}
+//	}
+//
+// The template is a RegisterTaskDefinition request with ${NAME} placeholders,
+// as a string or a JSON object. The tags of the request are added to those
+// of the template, with the name and digest of the template for traceability.
+type taskDefinitionTemplate struct {
+	Template  json.RawMessage   `json:"template"`
+	Name      string            `json:"templateName"`
+	Variables map[string]string `json:"templateVariables"`
+	Tags      []generated.Tag   `json:"tags"`
+}
+
+// withTaskDefinitionTemplate replaces the body of a RegisterTaskDefinition
+// request with a template by the rendered template. It writes an error
+// response and returns false when the template can not be rendered.
+func withTaskDefinitionTemplate(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	if r.Body == nil || !isECSAction(r, "RegisterTaskDefinition") {
+		return r, true
+	}
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return r, true
+	}
+
+	var extension taskDefinitionTemplate
+	if json.Unmarshal(body, &extension) != nil || len(extension.Template) == 0 || string(extension.Template) == "null" {
+		return r, true
+	}
+	rendered, err := renderTaskDefinitionTemplate(extension)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "ClientException", err.Error())
+		return r, false
+	}
+	logging.Debug("Rendered task definition template", "template", extension.Name, "variables", len(extension.Variables))
+
+	r = r.Clone(r.Context())
+	r.Body = io.NopCloser(bytes.NewReader(rendered))
+	r.ContentLength = int64(len(rendered))
+	return r, true
+}
+
+// renderTaskDefinitionTemplate renders a template into the body of a
+// RegisterTaskDefinition request, tagged with the template reference
+func renderTaskDefinitionTemplate(extension taskDefinitionTemplate) ([]byte, error) {
+	template := string(extension.Template)
+	if extension.Template[0] == '"' {
+		if err := json.Unmarshal(extension.Template, &template); err != nil {
+			return nil, fmt.Errorf("invalid template: %w", err)
+		}
+	}
+
+	rendered, err := taskdeftemplate.Render(template, extension.Variables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+	var req generated.RegisterTaskDefinitionRequest
+	if err := json.Unmarshal([]byte(rendered), &req); err != nil {
+		return nil, fmt.Errorf("rendered template is not a valid task definition: %w", err)
+	}
+
+	req.Tags = mergeTags(req.Tags, extension.Tags)
+	reference := []generated.Tag{{Key: ptr.String(taskdeftemplate.DigestTag), Value: ptr.String(taskdeftemplate.Digest(template))}}
+	if extension.Name != "" {
+		reference = append(reference, generated.Tag{Key: ptr.String(taskdeftemplate.NameTag), Value: ptr.String(extension.Name)})
+	}
+	req.Tags = mergeTags(req.Tags, reference)
+	return json.Marshal(req)
+}
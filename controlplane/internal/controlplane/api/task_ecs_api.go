@@ -3,23 +3,29 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
-	"time"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
 
 	"github.com/nandemo-ya/kecs/controlplane/internal/artifacts"
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
 	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
 
 	corev1 "k8s.io/api/core/v1"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
 	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/secretsmanager"
+	"github.com/nandemo-ya/kecs/controlplane/internal/kubernetes"
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/taskattachments"
 	"github.com/nandemo-ya/kecs/controlplane/internal/utils"
 )
 
@@ -29,6 +35,9 @@ func (api *DefaultECSAPI) RunTask(ctx context.Context, req *generated.RunTaskReq
 	if req.TaskDefinition == "" {
 		return nil, fmt.Errorf("taskDefinition is required")
 	}
+	if _, err := maxDurationFromTags(req.Tags); err != nil {
+		return nil, &generated.InvalidParameterException{Message: ptr.String(err.Error())}
+	}
 
 	// Get cluster name (default to "default" if not specified)
 	clusterName := "default"
@@ -48,7 +57,7 @@ func (api *DefaultECSAPI) RunTask(ctx context.Context, req *generated.RunTaskReq
 
 	if strings.Contains(taskDefIdentifier, ":") {
 		// family:revision format or ARN
-		if strings.HasPrefix(taskDefIdentifier, "arn:aws:ecs:") {
+		if awsarn.IsService(taskDefIdentifier, "ecs") {
 			taskDef, err = api.storage.TaskDefinitionStore().GetByARN(ctx, taskDefIdentifier)
 		} else {
 			parts := strings.SplitN(taskDefIdentifier, ":", 2)
@@ -76,9 +85,40 @@ func (api *DefaultECSAPI) RunTask(ctx context.Context, req *generated.RunTaskReq
 		return nil, fmt.Errorf("task definition not found: %s", taskDefIdentifier)
 	}
 
+	// Validate awsvpc subnets against the VPC registry
+	if err := api.validateAwsVpcSubnets(req.NetworkConfiguration); err != nil {
+		return nil, err
+	}
+
+	// Managed EBS volumes are provisioned by the converter from the request
+	if _, err := validateVolumeConfigurations(taskDef, req.VolumeConfigurations); err != nil {
+		return nil, err
+	}
+
+	strategy, err := resolveCapacityProviderStrategy(cluster, req.LaunchType, req.CapacityProviderStrategy)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fargate tasks need a platform version supporting their features
+	var platformVersion string
+	if usesFargate(req.LaunchType, strategy) {
+		platformVersion, err = validatePlatformVersion(taskDef, req.PlatformVersion, req.Overrides)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// Tasks placed per capacity provider, to apply the base and weights
+	placed := make(map[string]int)
+
 	// Determine count
 	count := 1
-	if req.Count != nil && *req.Count > 0 {
+	if req.Count != nil {
+		if *req.Count < 1 || *req.Count > maxRunTaskCount {
+			return nil, &generated.InvalidParameterException{
+				Message: ptr.String(fmt.Sprintf("count must be between 1 and %d", maxRunTaskCount)),
+			}
+		}
 		count = int(*req.Count)
 	}
 
@@ -88,8 +128,13 @@ func (api *DefaultECSAPI) RunTask(ctx context.Context, req *generated.RunTaskReq
 		return nil, fmt.Errorf("failed to create task manager: %w", err)
 	}
 
+	// Tasks requiring a CPU architecture need nodes and images providing it
+	if err := api.validateCPUArchitecture(ctx, taskDef, taskManager.Clientset); err != nil {
+		return nil, err
+	}
+
 	// Create task converter with CloudWatch integration
-	taskConverter := converters.NewTaskConverterWithCloudWatch(api.region, api.accountID, api.cloudWatchIntegration)
+	taskConverter := converters.NewTaskConverterWithCloudWatch(api.region, api.accountFor(ctx), api.cloudWatchIntegration)
 
 	// Set artifact manager if S3 integration is available
 	if api.s3Integration != nil {
@@ -97,16 +142,42 @@ func (api *DefaultECSAPI) RunTask(ctx context.Context, req *generated.RunTaskReq
 		taskConverter.SetArtifactManager(artifactManager)
 	}
 
+	// Map subnets to availability zones for placement
+	if api.vpcRegistry != nil {
+		taskConverter.SetSubnetZoneResolver(api.vpcRegistry)
+	}
+
 	// Marshal the request for the converter
 	reqJSON, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
+	// Parameters and secrets are the same for every task, sync them once
+	namespace := getNamespaceFromCluster(cluster)
+	if api.ssmIntegration != nil {
+		if ssmParams := extractSSMParameters(taskDef); len(ssmParams) > 0 {
+			if err := api.ssmIntegration.SyncParameters(ctx, ssmParams, namespace); err != nil {
+				// Tasks proceed even if parameter sync fails
+				logging.Warn("Failed to sync SSM parameters for tasks", "taskDefinition", taskDef.ARN, "error", err)
+			}
+		}
+	}
+	if api.secretsManagerIntegration != nil {
+		if secretARNs := extractSecretsManagerSecrets(taskDef); len(secretARNs) > 0 {
+			if err := api.secretsManagerIntegration.SyncSecrets(ctx, secretARNs, namespace); err != nil {
+				// Tasks proceed even if secret sync fails
+				logging.Warn("Failed to sync Secrets Manager secrets for tasks", "taskDefinition", taskDef.ARN, "error", err)
+			}
+		}
+	}
+
 	var tasks []generated.Task
 	var failures []generated.Failure
+	var pending []runTaskItem
 
-	// Create requested number of tasks
+	// Prepare the requested number of tasks. IDs and placement are assigned
+	// in order, the pods are created in parallel below.
 	for i := 0; i < count; i++ {
 		// Generate task ID
 		taskID, err := utils.GenerateTaskID()
@@ -119,10 +190,10 @@ func (api *DefaultECSAPI) RunTask(ctx context.Context, req *generated.RunTaskReq
 		}
 
 		// Create storage task
-		now := time.Now()
+		now := deterministic.Now()
 		task := &storage.Task{
 			ID:                taskID,
-			ARN:               fmt.Sprintf("arn:aws:ecs:%s:%s:task/%s/%s", api.region, api.accountID, clusterName, taskID),
+			ARN:               awsarn.Build("ecs", api.region, api.accountFor(ctx), fmt.Sprintf("task/%s/%s", clusterName, taskID)),
 			ClusterARN:        cluster.ARN,
 			TaskDefinitionARN: taskDef.ARN,
 			LastStatus:        "PROVISIONING",
@@ -131,7 +202,7 @@ func (api *DefaultECSAPI) RunTask(ctx context.Context, req *generated.RunTaskReq
 			Version:           1,
 			CreatedAt:         now,
 			Region:            api.region,
-			AccountID:         api.accountID,
+			AccountID:         api.accountFor(ctx),
 			CPU:               taskDef.CPU,    // Set from task definition
 			Memory:            taskDef.Memory, // Set from task definition
 		}
@@ -144,9 +215,16 @@ func (api *DefaultECSAPI) RunTask(ctx context.Context, req *generated.RunTaskReq
 			}
 		}
 
-		// Set launch type
+		// Set launch type, or the capacity provider the strategy places the task on
 		if req.LaunchType != nil {
 			task.LaunchType = string(*req.LaunchType)
+		} else if len(strategy) > 0 {
+			task.CapacityProviderName = converters.CapacityProviderForTask(strategy, placed)
+			task.LaunchType = converters.LaunchTypeForCapacityProvider(task.CapacityProviderName)
+			placed[task.CapacityProviderName]++
+		}
+		if task.LaunchType == string(generated.LaunchTypeFARGATE) {
+			task.PlatformVersion = platformVersion
 		}
 
 		// Set started by
@@ -172,33 +250,6 @@ func (api *DefaultECSAPI) RunTask(ctx context.Context, req *generated.RunTaskReq
 			task.EnableExecuteCommand = *req.EnableExecuteCommand
 		}
 
-		// Sync SSM parameters if SSM integration is available
-		if api.ssmIntegration != nil {
-			// Extract SSM parameters from task definition
-			ssmParams := extractSSMParameters(taskDef)
-			if len(ssmParams) > 0 {
-				namespace := getNamespaceFromCluster(cluster)
-				if err := api.ssmIntegration.SyncParameters(ctx, ssmParams, namespace); err != nil {
-					// Log warning but don't fail the task creation
-					// This allows tasks to proceed even if parameter sync fails
-					logging.Warn("Failed to sync SSM parameters for task", "taskId", taskID, "error", err)
-				}
-			}
-		}
-
-		// Sync Secrets Manager secrets if integration is available
-		if api.secretsManagerIntegration != nil {
-			// Extract Secrets Manager secrets from task definition
-			secretsManagerARNs := extractSecretsManagerSecrets(taskDef)
-			if len(secretsManagerARNs) > 0 {
-				namespace := getNamespaceFromCluster(cluster)
-				if err := api.secretsManagerIntegration.SyncSecrets(ctx, secretsManagerARNs, namespace); err != nil {
-					// Log warning but don't fail the task creation
-					logging.Warn("Failed to sync Secrets Manager secrets for task", "taskId", taskID, "error", err)
-				}
-			}
-		}
-
 		// Convert to Kubernetes pod
 		pod, err := taskConverter.ConvertTaskToPod(taskDef, reqJSON, cluster, taskID)
 		if err != nil {
@@ -210,15 +261,31 @@ func (api *DefaultECSAPI) RunTask(ctx context.Context, req *generated.RunTaskReq
 			continue
 		}
 
-		// Extract secrets from the converter
-		secrets := extractSecretsFromPod(pod)
+		pending = append(pending, runTaskItem{
+			task:    task,
+			pod:     pod,
+			secrets: extractSecretsFromPod(pod),
+		})
+	}
 
-		// Create the task
-		if err := taskManager.CreateTask(ctx, pod, task, secrets); err != nil {
+	errs := api.createTasks(ctx, taskManager, pending)
+	for i, item := range pending {
+		if errs[i] != nil {
+			// Tasks whose host ports are taken on every node fail like on
+			// container instances without the ports
+			var portsErr *kubernetes.HostPortsUnavailableError
+			if errors.As(errs[i], &portsErr) {
+				failures = append(failures, generated.Failure{
+					Arn:    ptr.String(item.task.ARN),
+					Reason: ptr.String(portsErr.Reason()),
+					Detail: ptr.String(portsErr.Error()),
+				})
+				continue
+			}
 			failures = append(failures, generated.Failure{
-				Arn:    ptr.String(task.ARN),
+				Arn:    ptr.String(item.task.ARN),
 				Reason: ptr.String("RESOURCE_CREATION_FAILED"),
-				Detail: ptr.String(fmt.Sprintf("Failed to create task: %v", err)),
+				Detail: ptr.String(fmt.Sprintf("Failed to create task: %v", errs[i])),
 			})
 			continue
 		}
@@ -227,7 +294,7 @@ func (api *DefaultECSAPI) RunTask(ctx context.Context, req *generated.RunTaskReq
 		cluster.RunningTasksCount++
 
 		// Convert to generated task
-		genTask := storageTaskToGenerated(task)
+		genTask := storageTaskToGenerated(item.task)
 		if genTask != nil {
 			tasks = append(tasks, *genTask)
 		}
@@ -247,6 +314,37 @@ func (api *DefaultECSAPI) RunTask(ctx context.Context, req *generated.RunTaskReq
 	}, nil
 }
 
+// maxRunTaskCount is the largest count a RunTask call accepts, as in ECS
+const maxRunTaskCount = 10
+
+// runTaskItem is a task prepared by RunTask whose pod is yet to be created
+type runTaskItem struct {
+	task    *storage.Task
+	pod     *corev1.Pod
+	secrets map[string]*converters.SecretInfo
+}
+
+// createTasks creates the pods and stores the tasks of a RunTask call, up to
+// runTask.concurrency at a time. The returned errors are indexed like items.
+func (api *DefaultECSAPI) createTasks(ctx context.Context, taskManager *kubernetes.TaskManager, items []runTaskItem) []error {
+	errs := make([]error, len(items))
+	concurrency := max(1, config.GetInt("runTask.concurrency"))
+	slots := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for i := range items {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			errs[i] = taskManager.CreateTask(ctx, items[i].pod, items[i].task, items[i].secrets)
+		}(i)
+	}
+	wg.Wait()
+	return errs
+}
+
 // StartTask implements the StartTask operation
 func (api *DefaultECSAPI) StartTask(ctx context.Context, req *generated.StartTaskRequest) (*generated.StartTaskResponse, error) {
 	// TODO: Implement StartTask
@@ -276,7 +374,7 @@ func (api *DefaultECSAPI) StopTask(ctx context.Context, req *generated.StopTaskR
 	taskIdentifier := req.Task
 	var task *storage.Task
 
-	if strings.Contains(taskIdentifier, "arn:aws:ecs:") {
+	if awsarn.IsService(taskIdentifier, "ecs") {
 		// Full ARN provided - the DuckDB Get method should handle it directly
 		task, err = api.storage.TaskStore().Get(ctx, "", taskIdentifier)
 	} else {
@@ -300,12 +398,6 @@ func (api *DefaultECSAPI) StopTask(ctx context.Context, req *generated.StopTaskR
 		return nil, fmt.Errorf("failed to convert task")
 	}
 
-	// Create task manager
-	taskManager, err := api.taskManager()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create task manager: %w", err)
-	}
-
 	// Set the reason
 	reason := "Task stopped by user"
 	if req.Reason != nil && *req.Reason != "" {
@@ -313,21 +405,12 @@ func (api *DefaultECSAPI) StopTask(ctx context.Context, req *generated.StopTaskR
 	}
 
 	// Stop the task
-	if err := taskManager.StopTask(ctx, cluster.ARN, task.ID, reason); err != nil {
-		return nil, fmt.Errorf("failed to stop task: %w", err)
-	}
-
-	// Decrement cluster's running tasks count
-	if cluster.RunningTasksCount > 0 {
-		cluster.RunningTasksCount--
-		if err := api.storage.ClusterStore().Update(ctx, cluster); err != nil {
-			// Log error but don't fail task stop
-			logging.Warn("Failed to update cluster task count", "error", err)
-		}
+	if err := api.stopTask(ctx, cluster, task, "", reason); err != nil {
+		return nil, err
 	}
 
 	// Get updated task - reuse the same logic
-	if strings.Contains(taskIdentifier, "arn:aws:ecs:") {
+	if awsarn.IsService(taskIdentifier, "ecs") {
 		// Full ARN provided - the DuckDB Get method should handle it directly
 		task, err = api.storage.TaskStore().Get(ctx, "", taskIdentifier)
 	} else {
@@ -349,12 +432,43 @@ func (api *DefaultECSAPI) StopTask(ctx context.Context, req *generated.StopTaskR
 	}, nil
 }
 
+// stopTask stops a task through the task manager, reporting it with the stop
+// code when one is given, and updates the running tasks count of its cluster
+func (api *DefaultECSAPI) stopTask(ctx context.Context, cluster *storage.Cluster, task *storage.Task, stopCode, reason string) error {
+	taskManager, err := api.taskManager()
+	if err != nil {
+		return fmt.Errorf("failed to create task manager: %w", err)
+	}
+
+	if err := taskManager.StopTaskWithCode(ctx, cluster.ARN, task.ID, stopCode, reason); err != nil {
+		return fmt.Errorf("failed to stop task: %w", err)
+	}
+
+	// Decrement cluster's running tasks count
+	if cluster.RunningTasksCount > 0 {
+		cluster.RunningTasksCount--
+		if err := api.storage.ClusterStore().Update(ctx, cluster); err != nil {
+			// Log error but don't fail task stop
+			logging.Warn("Failed to update cluster task count", "error", err)
+		}
+	}
+	return nil
+}
+
+// maxDescribeTasks is the number of tasks DescribeTasks accepts
+const maxDescribeTasks = 100
+
 // DescribeTasks implements the DescribeTasks operation
 func (api *DefaultECSAPI) DescribeTasks(ctx context.Context, req *generated.DescribeTasksRequest) (*generated.DescribeTasksResponse, error) {
 	// Validate required fields
 	if len(req.Tasks) == 0 {
 		return nil, fmt.Errorf("tasks is required")
 	}
+	if len(req.Tasks) > maxDescribeTasks {
+		return nil, &generated.InvalidParameterException{
+			Message: ptr.String(fmt.Sprintf("A maximum of %d tasks can be described at once.", maxDescribeTasks)),
+		}
+	}
 
 	// Get cluster name (default to "default" if not specified)
 	clusterName := "default"
@@ -368,25 +482,28 @@ func (api *DefaultECSAPI) DescribeTasks(ctx context.Context, req *generated.Desc
 		return nil, fmt.Errorf("cluster not found: %s", clusterName)
 	}
 
+	// Look all tasks up at once: full ARNs match tasks of any cluster, short
+	// IDs those of the requested cluster
+	found, err := api.storage.TaskStore().GetMany(ctx, cluster.ARN, req.Tasks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tasks: %w", err)
+	}
+	byIdentifier := make(map[string]*storage.Task, 2*len(found))
+	for _, task := range found {
+		byIdentifier[task.ARN] = task
+		if task.ClusterARN == cluster.ARN {
+			byIdentifier[task.ID] = task
+		}
+	}
+
 	var tasks []generated.Task
 	var failures []generated.Failure
+	described := make(map[string]bool, len(req.Tasks))
 
 	// Process each task identifier
 	for _, taskIdentifier := range req.Tasks {
-		// If taskIdentifier is a full ARN, extract the cluster ARN from it
-		var task *storage.Task
-		var err error
-
-		if strings.Contains(taskIdentifier, "arn:aws:ecs:") {
-			// Full ARN provided - the DuckDB Get method should handle it directly
-			// We still need to provide a cluster ARN for the Get method, but it will use the full ARN to search
-			task, err = api.storage.TaskStore().Get(ctx, "", taskIdentifier)
-		} else {
-			// Short ID provided - use the cluster ARN from request
-			task, err = api.storage.TaskStore().Get(ctx, cluster.ARN, taskIdentifier)
-		}
-
-		if err != nil || task == nil {
+		task := byIdentifier[taskIdentifier]
+		if task == nil {
 			failures = append(failures, generated.Failure{
 				Arn:    ptr.String(taskIdentifier),
 				Reason: ptr.String("MISSING"),
@@ -395,6 +512,12 @@ func (api *DefaultECSAPI) DescribeTasks(ctx context.Context, req *generated.Desc
 			continue
 		}
 
+		// A task named twice, by ID and ARN for instance, is described once
+		if described[task.ARN] {
+			continue
+		}
+		described[task.ARN] = true
+
 		// Convert to generated task
 		genTask := storageTaskToGenerated(task)
 		if genTask != nil {
@@ -460,20 +583,27 @@ func (api *DefaultECSAPI) ListTasks(ctx context.Context, req *generated.ListTask
 		filters.StartedBy = *req.StartedBy
 	}
 
+	limit := 100 // Default limit
 	if req.MaxResults != nil && *req.MaxResults > 0 {
-		filters.MaxResults = int(*req.MaxResults)
-	} else {
-		filters.MaxResults = 100 // Default limit
+		limit = int(*req.MaxResults)
 	}
 
 	if req.NextToken != nil {
 		filters.NextToken = *req.NextToken
 	}
 
-	// List tasks
+	// One task more than requested tells whether another page follows
+	filters.MaxResults = limit + 1
 	tasks, err := api.storage.TaskStore().List(ctx, cluster.ARN, filters)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list tasks: %w", err)
+		return nil, toListError(err, "tasks")
+	}
+
+	var nextToken string
+	if len(tasks) > limit {
+		tasks = tasks[:limit]
+		last := tasks[limit-1]
+		nextToken = storage.EncodeNextToken(storage.NewestFirstCursor(last.CreatedAt, last.ARN))
 	}
 
 	// Convert to ARNs
@@ -486,9 +616,8 @@ func (api *DefaultECSAPI) ListTasks(ctx context.Context, req *generated.ListTask
 		TaskArns: taskArns,
 	}
 
-	// Set next token if there might be more results
-	if len(taskArns) == filters.MaxResults && len(taskArns) > 0 {
-		response.NextToken = ptr.String(taskArns[len(taskArns)-1])
+	if nextToken != "" {
+		response.NextToken = ptr.String(nextToken)
 	}
 
 	return response, nil
@@ -508,8 +637,56 @@ func (api *DefaultECSAPI) UpdateTaskProtection(ctx context.Context, req *generat
 
 // SubmitTaskStateChange implements the SubmitTaskStateChange operation
 func (api *DefaultECSAPI) SubmitTaskStateChange(ctx context.Context, req *generated.SubmitTaskStateChangeRequest) (*generated.SubmitTaskStateChangeResponse, error) {
-	// TODO: Implement SubmitTaskStateChange
-	return nil, fmt.Errorf("SubmitTaskStateChange not implemented")
+	if req.Task == nil {
+		return nil, fmt.Errorf("task is required")
+	}
+	task, err := api.getTaskForStateChange(ctx, req.Cluster, *req.Task)
+	if err != nil {
+		return nil, err
+	}
+
+	reason := ""
+	if req.Reason != nil {
+		reason = *req.Reason
+	}
+	if req.Status != nil && *req.Status != "" {
+		if task.LastStatus == "STOPPED" && *req.Status != "STOPPED" {
+			// A stopped task never transitions back, acknowledge without changes
+			logging.Debug("Ignoring state change for stopped task", "taskArn", task.ARN, "status", *req.Status)
+			return &generated.SubmitTaskStateChangeResponse{
+				Acknowledgment: ptr.String(stateChangeAcknowledgment),
+			}, nil
+		}
+		if err := applyTaskStatus(task, *req.Status, reason); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.PullStartedAt != nil {
+		task.PullStartedAt = req.PullStartedAt.ToTime()
+	}
+	if req.PullStoppedAt != nil {
+		task.PullStoppedAt = req.PullStoppedAt.ToTime()
+	}
+	if req.ExecutionStoppedAt != nil {
+		task.ExecutionStoppedAt = req.ExecutionStoppedAt.ToTime()
+	}
+	if err := applyContainerStateChanges(task, req.Containers, req.ManagedAgents); err != nil {
+		return nil, err
+	}
+	if _, err := applyAttachmentStateChanges(task, req.Attachments); err != nil {
+		return nil, err
+	}
+
+	task.Version++
+	if err := api.storage.TaskStore().Update(ctx, task); err != nil {
+		return nil, fmt.Errorf("failed to update task: %w", err)
+	}
+
+	logging.Debug("Applied task state change", "taskArn", task.ARN, "lastStatus", task.LastStatus)
+	return &generated.SubmitTaskStateChangeResponse{
+		Acknowledgment: ptr.String(stateChangeAcknowledgment),
+	}, nil
 }
 
 // Helper function to convert storage.Task to generated.Task
@@ -793,21 +970,13 @@ func (m *mockTaskManager) CreateTask(ctx context.Context, pod interface{}, task
 			}
 		}
 
-		// Check for awsvpc network mode and create attachments
-		if networkMode, ok := podObj.Annotations["ecs.amazonaws.com/network-mode"]; ok && networkMode == "awsvpc" {
-			attachments := m.createNetworkAttachments(podObj)
-			if len(attachments) > 0 {
-				attachmentsJSON, err := json.Marshal(attachments)
-				if err == nil {
-					task.Attachments = string(attachmentsJSON)
-				}
-			}
-		}
+		// Precreate the network interface, Service Connect and EBS attachments
+		task.Attachments = taskattachments.Sync(task.Attachments, podObj, task.LastStatus)
 	}
 
 	// Keep the LastStatus that was set by RunTask (PROVISIONING)
 	task.Connectivity = "CONNECTED"
-	now := time.Now()
+	now := deterministic.Now()
 	task.ConnectivityAt = &now
 	return m.storage.TaskStore().Create(ctx, task)
 }
@@ -817,7 +986,7 @@ func (m *mockTaskManager) StopTask(ctx context.Context, cluster, taskID, reason
 	if err != nil {
 		return err
 	}
-	now := time.Now()
+	now := deterministic.Now()
 	task.DesiredStatus = "STOPPED"
 	task.StoppedReason = reason
 	task.StoppingAt = &now
@@ -835,7 +1004,8 @@ func (m *mockTaskManager) createContainersFromPod(pod *corev1.Pod, task *storage
 		podIP = "10.0.0.1"
 	}
 
-	for _, container := range pod.Spec.Containers {
+	podContainers, _ := converters.TaskContainers(pod)
+	for _, container := range podContainers {
 		genContainer := generated.Container{
 			Name:       ptr.String(container.Name),
 			Image:      ptr.String(container.Image),
@@ -847,7 +1017,7 @@ func (m *mockTaskManager) createContainersFromPod(pod *corev1.Pod, task *storage
 		if networkMode := pod.Annotations["ecs.amazonaws.com/network-mode"]; networkMode == "awsvpc" {
 			genContainer.NetworkInterfaces = []generated.NetworkInterface{
 				{
-					AttachmentId:       ptr.String(fmt.Sprintf("eni-attach-%s", pod.UID)),
+					AttachmentId:       ptr.String(taskattachments.ENIAttachmentID(pod)),
 					PrivateIpv4Address: ptr.String(podIP),
 				},
 			}
@@ -868,52 +1038,3 @@ func (m *mockTaskManager) createContainersFromPod(pod *corev1.Pod, task *storage
 
 	return containers
 }
-
-// createNetworkAttachments creates network attachments for awsvpc mode
-func (m *mockTaskManager) createNetworkAttachments(pod *corev1.Pod) []generated.Attachment {
-	var attachments []generated.Attachment
-
-	// Get network configuration from annotations
-	subnets := pod.Annotations["ecs.amazonaws.com/subnets"]
-	privateIP := pod.Status.PodIP
-	if privateIP == "" {
-		privateIP = "10.0.0.1"
-	}
-
-	// Create elastic network interface attachment
-	var details []generated.KeyValuePair
-	if subnets != "" {
-		details = append(details, generated.KeyValuePair{
-			Name:  ptr.String("subnetId"),
-			Value: ptr.String(strings.Split(subnets, ",")[0]), // Use first subnet
-		})
-	}
-	details = append(details,
-		generated.KeyValuePair{
-			Name:  ptr.String("networkInterfaceId"),
-			Value: ptr.String(fmt.Sprintf("eni-%s", pod.UID)),
-		},
-		generated.KeyValuePair{
-			Name:  ptr.String("macAddress"),
-			Value: ptr.String("02:00:00:00:00:01"),
-		},
-		generated.KeyValuePair{
-			Name:  ptr.String("privateDnsName"),
-			Value: ptr.String(fmt.Sprintf("ip-%s.ec2.internal", strings.ReplaceAll(privateIP, ".", "-"))),
-		},
-		generated.KeyValuePair{
-			Name:  ptr.String("privateIPv4Address"),
-			Value: ptr.String(privateIP),
-		},
-	)
-
-	attachment := generated.Attachment{
-		Id:      ptr.String(fmt.Sprintf("eni-attach-%s", pod.UID)),
-		Type:    ptr.String("ElasticNetworkInterface"),
-		Status:  ptr.String("ATTACHED"),
-		Details: details,
-	}
-
-	attachments = append(attachments, attachment)
-	return attachments
-}
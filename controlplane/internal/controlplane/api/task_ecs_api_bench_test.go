@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/mocks"
+	"github.com/nandemo-ya/kecs/controlplane/internal/kubernetes"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// podCreateLatency simulates the API server round trip of a pod creation
+const podCreateLatency = 5 * time.Millisecond
+
+// BenchmarkRunTask measures a RunTask call launching 10 tasks, serially and
+// with the default runTask.concurrency
+func BenchmarkRunTask(b *testing.B) {
+	os.Setenv("KECS_TEST_MODE", "true")
+	defer os.Unsetenv("KECS_TEST_MODE")
+
+	for _, concurrency := range []int{1, 10} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			config.Set("runTask.concurrency", concurrency)
+			defer config.Set("runTask.concurrency", 10)
+
+			ctx := context.Background()
+			mockStorage := mocks.NewMockStorage()
+			mockClusterStore := mocks.NewMockClusterStore()
+			mockTaskDefStore := mocks.NewMockTaskDefinitionStore()
+			mockStorage.SetClusterStore(mockClusterStore)
+			mockStorage.SetTaskDefinitionStore(mockTaskDefStore)
+			mockStorage.SetTaskStore(mocks.NewMockTaskStore())
+			if err := mockClusterStore.Create(ctx, &storage.Cluster{
+				Name:   "default",
+				ARN:    "arn:aws:ecs:us-east-1:000000000000:cluster/default",
+				Status: "ACTIVE",
+				Region: "us-east-1",
+			}); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := mockTaskDefStore.Register(ctx, &storage.TaskDefinition{
+				Family:               "nginx",
+				ContainerDefinitions: `[{"name":"nginx","image":"nginx:latest","memory":512}]`,
+			}); err != nil {
+				b.Fatal(err)
+			}
+
+			taskManager, err := kubernetes.NewTaskManager(mockStorage)
+			if err != nil {
+				b.Fatal(err)
+			}
+			taskManager.Clientset = newSlowPodsClientset(podCreateLatency)
+
+			ecsAPI := NewDefaultECSAPI(config.DefaultConfig(), mockStorage).(*DefaultECSAPI)
+			ecsAPI.taskManagerInstance = taskManager
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				resp, err := ecsAPI.RunTask(ctx, &generated.RunTaskRequest{
+					TaskDefinition: "nginx",
+					Count:          ptr.Int32(10),
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(resp.Failures) > 0 {
+					b.Fatalf("unexpected failures: %v", *resp.Failures[0].Detail)
+				}
+			}
+		})
+	}
+}
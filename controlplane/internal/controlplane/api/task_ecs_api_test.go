@@ -2,16 +2,25 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 
 	"github.com/nandemo-ya/kecs/controlplane/internal/config"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/mocks"
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/kubernetes"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
 )
 
@@ -130,6 +139,460 @@ var _ = Describe("Task ECS API", func() {
 				Expect(err.Error()).To(ContainSubstring("taskDefinition is required"))
 			})
 		})
+
+		Context("when running a batch of tasks", func() {
+			It("should reject a count outside 1 to 10", func() {
+				for _, count := range []int32{0, 11} {
+					_, err := server.ecsAPI.RunTask(ctx, &generated.RunTaskRequest{
+						TaskDefinition: "nginx:1",
+						Count:          ptr.Int32(count),
+					})
+					Expect(err).To(BeAssignableToTypeOf(&generated.InvalidParameterException{}), fmt.Sprint(count))
+				}
+			})
+
+			It("should create the pods in parallel", func() {
+				clientset := newSlowPodsClientset(20 * time.Millisecond)
+				taskManager, err := kubernetes.NewTaskManager(mockStorage)
+				Expect(err).NotTo(HaveOccurred())
+				taskManager.Clientset = clientset
+				server.ecsAPI.(*DefaultECSAPI).taskManagerInstance = taskManager
+
+				resp, err := server.ecsAPI.RunTask(ctx, &generated.RunTaskRequest{
+					TaskDefinition: "nginx:1",
+					Count:          ptr.Int32(10),
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.Tasks).To(HaveLen(10))
+				Expect(resp.Failures).To(BeEmpty())
+
+				Expect(clientset.Peak()).To(BeNumerically(">", 1))
+			})
+		})
+
+		Context("when the cluster has a default capacity provider strategy", func() {
+			BeforeEach(func() {
+				cluster, err := mockClusterStore.Get(ctx, "default")
+				Expect(err).NotTo(HaveOccurred())
+				cluster.DefaultCapacityProviderStrategy = `[{"capacityProvider":"FARGATE","base":1,"weight":1},{"capacityProvider":"FARGATE_SPOT","weight":3}]`
+				Expect(mockClusterStore.Update(ctx, cluster)).To(Succeed())
+			})
+
+			It("should place tasks by the cluster default strategy", func() {
+				resp, err := server.ecsAPI.RunTask(ctx, &generated.RunTaskRequest{
+					TaskDefinition: "nginx:1",
+					Count:          ptr.Int32(5),
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.Tasks).To(HaveLen(5))
+
+				providers := map[string]int{}
+				for _, task := range resp.Tasks {
+					Expect(task.CapacityProviderName).NotTo(BeNil())
+					Expect(string(*task.LaunchType)).To(Equal("FARGATE"))
+					providers[*task.CapacityProviderName]++
+				}
+				Expect(providers).To(Equal(map[string]int{"FARGATE": 2, "FARGATE_SPOT": 3}))
+			})
+
+			It("should let an explicit launch type override the default", func() {
+				launchType := generated.LaunchTypeEC2
+				resp, err := server.ecsAPI.RunTask(ctx, &generated.RunTaskRequest{
+					TaskDefinition: "nginx:1",
+					LaunchType:     &launchType,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.Tasks[0].CapacityProviderName).To(BeNil())
+				Expect(string(*resp.Tasks[0].LaunchType)).To(Equal("EC2"))
+			})
+
+			It("should reject both a launch type and a strategy", func() {
+				launchType := generated.LaunchTypeFARGATE
+				_, err := server.ecsAPI.RunTask(ctx, &generated.RunTaskRequest{
+					TaskDefinition:           "nginx:1",
+					LaunchType:               &launchType,
+					CapacityProviderStrategy: []generated.CapacityProviderStrategyItem{{CapacityProvider: "FARGATE"}},
+				})
+				var invalidParameter *generated.InvalidParameterException
+				Expect(errors.As(err, &invalidParameter)).To(BeTrue())
+				Expect(*invalidParameter.Message).To(ContainSubstring("Specifying both a launch type and capacity provider strategy"))
+			})
+		})
+
+		Context("when the task definition has a volume configured at launch", func() {
+			BeforeEach(func() {
+				_, err := mockTaskDefStore.Register(ctx, &storage.TaskDefinition{
+					ARN:                  "arn:aws:ecs:us-east-1:000000000000:task-definition/db:1",
+					Family:               "db",
+					Revision:             1,
+					Status:               "ACTIVE",
+					ContainerDefinitions: `[{"name":"db","image":"postgres:16","memory":512,"mountPoints":[{"sourceVolume":"data","containerPath":"/var/lib/postgresql/data"}]}]`,
+					Volumes:              `[{"name":"data","configuredAtLaunch":true},{"name":"scratch"}]`,
+					Region:               "us-east-1",
+					AccountID:            "000000000000",
+				})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("should provision a volume claim for the managed EBS volume", func() {
+				clientset := fake.NewSimpleClientset()
+				taskManager, err := kubernetes.NewTaskManager(mockStorage)
+				Expect(err).NotTo(HaveOccurred())
+				taskManager.Clientset = clientset
+				server.ecsAPI.(*DefaultECSAPI).taskManagerInstance = taskManager
+
+				resp, err := server.ecsAPI.RunTask(ctx, &generated.RunTaskRequest{
+					TaskDefinition: "db:1",
+					VolumeConfigurations: []generated.TaskVolumeConfiguration{{
+						Name: "data",
+						ManagedEBSVolume: &generated.TaskManagedEBSVolumeConfiguration{
+							RoleArn:    "arn:aws:iam::000000000000:role/ecsInfrastructureRole",
+							SizeInGiB:  ptr.Int32(20),
+							VolumeType: ptr.String("gp3"),
+							Iops:       ptr.Int32(3000),
+						},
+					}},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.Tasks).To(HaveLen(1))
+
+				pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(pods.Items).To(HaveLen(1))
+				volumes := pods.Items[0].Spec.Volumes
+				Expect(volumes[0].Name).To(Equal("data"))
+				Expect(volumes[0].Ephemeral).NotTo(BeNil())
+				template := volumes[0].Ephemeral.VolumeClaimTemplate
+				Expect(template.Spec.Resources.Requests.Storage().String()).To(Equal("20Gi"))
+				Expect(template.Annotations).To(HaveKeyWithValue("kecs.dev/ebs-iops", "3000"))
+				Expect(volumes[1].EmptyDir).NotTo(BeNil())
+			})
+
+			It("should reject a configuration of a volume not configured at launch", func() {
+				_, err := server.ecsAPI.RunTask(ctx, &generated.RunTaskRequest{
+					TaskDefinition: "db:1",
+					VolumeConfigurations: []generated.TaskVolumeConfiguration{{
+						Name:             "scratch",
+						ManagedEBSVolume: &generated.TaskManagedEBSVolumeConfiguration{SizeInGiB: ptr.Int32(1)},
+					}},
+				})
+				var invalidParameter *generated.InvalidParameterException
+				Expect(errors.As(err, &invalidParameter)).To(BeTrue())
+				Expect(*invalidParameter.Message).To(ContainSubstring("not configured at launch"))
+			})
+
+			It("should reject a managed EBS volume without size or snapshot", func() {
+				_, err := server.ecsAPI.RunTask(ctx, &generated.RunTaskRequest{
+					TaskDefinition: "db:1",
+					VolumeConfigurations: []generated.TaskVolumeConfiguration{{
+						Name:             "data",
+						ManagedEBSVolume: &generated.TaskManagedEBSVolumeConfiguration{},
+					}},
+				})
+				var invalidParameter *generated.InvalidParameterException
+				Expect(errors.As(err, &invalidParameter)).To(BeTrue())
+				Expect(*invalidParameter.Message).To(ContainSubstring("requires sizeInGiB or snapshotId"))
+			})
+		})
+
+		Context("when the task definition maps ports in the bridge network mode", func() {
+			BeforeEach(func() {
+				_, err := mockTaskDefStore.Register(ctx, &storage.TaskDefinition{
+					ARN:         "arn:aws:ecs:us-east-1:000000000000:task-definition/media:1",
+					Family:      "media",
+					Revision:    1,
+					Status:      "ACTIVE",
+					NetworkMode: "bridge",
+					ContainerDefinitions: `[{"name":"media","image":"media:v1","memory":512,"portMappings":[
+						{"containerPort":80,"hostPort":0},
+						{"containerPort":443,"hostPort":8443},
+						{"containerPortRange":"10000-10004","protocol":"udp"}
+					]}]`,
+					Region:    "us-east-1",
+					AccountID: "000000000000",
+				})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("should assign dynamic host ports and report them as network bindings", func() {
+				clientset := fake.NewSimpleClientset()
+				taskManager, err := kubernetes.NewTaskManager(mockStorage)
+				Expect(err).NotTo(HaveOccurred())
+				taskManager.Clientset = clientset
+				server.ecsAPI.(*DefaultECSAPI).taskManagerInstance = taskManager
+
+				_, err = server.ecsAPI.RunTask(ctx, &generated.RunTaskRequest{TaskDefinition: "media:1"})
+				Expect(err).NotTo(HaveOccurred())
+
+				pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(pods.Items).To(HaveLen(1))
+				pod := pods.Items[0]
+				Expect(pod.Spec.Containers[0].Ports).To(HaveLen(7))
+				for _, port := range pod.Spec.Containers[0].Ports {
+					Expect(port.HostPort).NotTo(BeZero())
+				}
+
+				pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "media"}}
+				containers := taskManager.GetContainerStatuses(&pod)
+				Expect(containers).To(HaveLen(1))
+				bindings := containers[0].NetworkBindings
+				Expect(bindings).To(HaveLen(3))
+				Expect(bindings[0].ContainerPortRange).To(Equal("10000-10004"))
+				Expect(bindings[0].HostPortRange).To(MatchRegexp(`^\d+-\d+$`))
+				Expect(bindings[0].Protocol).To(Equal("udp"))
+				Expect(bindings[1].ContainerPort).To(Equal(80))
+				Expect(bindings[1].HostPort).To(BeNumerically(">=", 49153))
+				Expect(bindings[2].ContainerPort).To(Equal(443))
+				Expect(bindings[2].HostPort).To(Equal(8443))
+			})
+		})
+
+		Context("when the task definition requires a CPU architecture", func() {
+			var clientset *fake.Clientset
+
+			BeforeEach(func() {
+				_, err := mockTaskDefStore.Register(ctx, &storage.TaskDefinition{
+					ARN:                  "arn:aws:ecs:us-east-1:000000000000:task-definition/arm:1",
+					Family:               "arm",
+					Revision:             1,
+					Status:               "ACTIVE",
+					ContainerDefinitions: `[{"name":"app","image":"app:v1","memory":512}]`,
+					RuntimePlatform:      `{"cpuArchitecture":"ARM64","operatingSystemFamily":"LINUX"}`,
+					Region:               "us-east-1",
+					AccountID:            "000000000000",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				clientset = fake.NewSimpleClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{
+					Name:   "k3d-kecs-dev-server-0",
+					Labels: map[string]string{corev1.LabelArchStable: "amd64"},
+				}})
+				taskManager, err := kubernetes.NewTaskManager(mockStorage)
+				Expect(err).NotTo(HaveOccurred())
+				taskManager.Clientset = clientset
+				server.ecsAPI.(*DefaultECSAPI).taskManagerInstance = taskManager
+			})
+
+			It("should reject the task without nodes of the architecture", func() {
+				_, err := server.ecsAPI.RunTask(ctx, &generated.RunTaskRequest{TaskDefinition: "arm:1"})
+				Expect(err).To(BeAssignableToTypeOf(&generated.InvalidParameterException{}))
+				Expect(*err.(*generated.InvalidParameterException).Message).To(ContainSubstring("no nodes with cpuArchitecture ARM64"))
+				Expect(*err.(*generated.InvalidParameterException).Message).To(ContainSubstring("--node-pool arm64=1"))
+			})
+
+			It("should run the task on a node pool of the architecture", func() {
+				_, err := clientset.CoreV1().Nodes().Create(ctx, &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+					Name: "k3d-kecs-dev-agent-0",
+					Labels: map[string]string{
+						corev1.LabelArchStable:              "amd64",
+						converters.CPUArchitectureNodeLabel: "arm64",
+					},
+				}}, metav1.CreateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+
+				resp, err := server.ecsAPI.RunTask(ctx, &generated.RunTaskRequest{TaskDefinition: "arm:1"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.Tasks).To(HaveLen(1))
+			})
+
+			It("should reject images without a manifest for the architecture", func() {
+				_, err := clientset.CoreV1().Nodes().Create(ctx, &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+					Name:   "k3d-kecs-dev-agent-0",
+					Labels: map[string]string{converters.CPUArchitectureNodeLabel: "arm64"},
+				}}, metav1.CreateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				server.ecsAPI.(*DefaultECSAPI).SetArchitectureResolver(architectureResolverFunc(
+					func(ctx context.Context, image string) ([]string, error) {
+						return []string{"amd64"}, nil
+					}))
+
+				_, err = server.ecsAPI.RunTask(ctx, &generated.RunTaskRequest{TaskDefinition: "arm:1"})
+				Expect(err).To(BeAssignableToTypeOf(&generated.InvalidParameterException{}))
+				Expect(*err.(*generated.InvalidParameterException).Message).To(ContainSubstring("image app:v1 of container app has no manifest for cpuArchitecture ARM64"))
+			})
+		})
+
+		Context("when Fargate tasks use features of a platform version", func() {
+			fargate := generated.LaunchTypeFARGATE
+
+			BeforeEach(func() {
+				_, err := mockTaskDefStore.Register(ctx, &storage.TaskDefinition{
+					ARN:                  "arn:aws:ecs:us-east-1:000000000000:task-definition/shared:1",
+					Family:               "shared",
+					Revision:             1,
+					Status:               "ACTIVE",
+					NetworkMode:          "awsvpc",
+					ContainerDefinitions: `[{"name":"app","image":"app:v1","memory":512}]`,
+					Volumes:              `[{"name":"data","efsVolumeConfiguration":{"fileSystemId":"fs-12345678"}}]`,
+					Region:               "us-east-1",
+					AccountID:            "000000000000",
+				})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("should reject EFS volumes before platform version 1.4.0", func() {
+				_, err := server.ecsAPI.RunTask(ctx, &generated.RunTaskRequest{
+					TaskDefinition:  "shared:1",
+					LaunchType:      &fargate,
+					PlatformVersion: ptr.String("1.3.0"),
+				})
+				Expect(err).To(BeAssignableToTypeOf(&generated.InvalidParameterException{}))
+				Expect(*err.(*generated.InvalidParameterException).Message).To(ContainSubstring("1.4.0 or later is required for EFS volumes"))
+			})
+
+			It("should reject unknown platform versions", func() {
+				_, err := server.ecsAPI.RunTask(ctx, &generated.RunTaskRequest{
+					TaskDefinition:  "nginx:1",
+					LaunchType:      &fargate,
+					PlatformVersion: ptr.String("2.0.0"),
+				})
+				Expect(err).To(BeAssignableToTypeOf(&generated.InvalidParameterException{}))
+			})
+
+			It("should reject an ephemeral storage override before platform version 1.4.0", func() {
+				_, err := server.ecsAPI.RunTask(ctx, &generated.RunTaskRequest{
+					TaskDefinition:  "nginx:1",
+					LaunchType:      &fargate,
+					PlatformVersion: ptr.String("1.3.0"),
+					Overrides:       &generated.TaskOverride{EphemeralStorage: &generated.EphemeralStorage{SizeInGiB: 40}},
+				})
+				Expect(err).To(BeAssignableToTypeOf(&generated.InvalidParameterException{}))
+				Expect(*err.(*generated.InvalidParameterException).Message).To(ContainSubstring("ephemeralStorage"))
+			})
+
+			It("should run LATEST as platform version 1.4.0", func() {
+				resp, err := server.ecsAPI.RunTask(ctx, &generated.RunTaskRequest{
+					TaskDefinition:  "shared:1",
+					LaunchType:      &fargate,
+					PlatformVersion: ptr.String("LATEST"),
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.Tasks).To(HaveLen(1))
+				Expect(*resp.Tasks[0].PlatformVersion).To(Equal("1.4.0"))
+			})
+
+			It("should not gate EC2 tasks", func() {
+				resp, err := server.ecsAPI.RunTask(ctx, &generated.RunTaskRequest{
+					TaskDefinition:  "shared:1",
+					PlatformVersion: ptr.String("1.3.0"),
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.Tasks).To(HaveLen(1))
+				Expect(resp.Tasks[0].PlatformVersion).To(BeNil())
+			})
+		})
+
+		Context("when bridge mode tasks map the same host port", func() {
+			var (
+				clientset   *fake.Clientset
+				taskManager *kubernetes.TaskManager
+			)
+
+			BeforeEach(func() {
+				for _, def := range []struct{ family, networkMode, portMappings string }{
+					{"web", "bridge", `[{"containerPort":80,"hostPort":8080}]`},
+					{"dns", "bridge", `[{"containerPort":53,"hostPort":5353,"protocol":"udp"}]`},
+					{"vpc", "awsvpc", `[{"containerPort":80,"hostPort":80}]`},
+				} {
+					_, err := mockTaskDefStore.Register(ctx, &storage.TaskDefinition{
+						ARN:                  "arn:aws:ecs:us-east-1:000000000000:task-definition/" + def.family + ":1",
+						Family:               def.family,
+						Revision:             1,
+						Status:               "ACTIVE",
+						NetworkMode:          def.networkMode,
+						ContainerDefinitions: `[{"name":"app","image":"app:v1","memory":512,"portMappings":` + def.portMappings + `}]`,
+						Region:               "us-east-1",
+						AccountID:            "000000000000",
+					})
+					Expect(err).NotTo(HaveOccurred())
+				}
+
+				clientset = fake.NewSimpleClientset()
+				var err error
+				taskManager, err = kubernetes.NewTaskManager(mockStorage)
+				Expect(err).NotTo(HaveOccurred())
+				taskManager.Clientset = clientset
+				server.ecsAPI.(*DefaultECSAPI).taskManagerInstance = taskManager
+			})
+
+			It("should fail the tasks that find the port taken", func() {
+				resp, err := server.ecsAPI.RunTask(ctx, &generated.RunTaskRequest{
+					TaskDefinition: "web:1",
+					Count:          ptr.Int32(2),
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.Tasks).To(HaveLen(1))
+				Expect(resp.Failures).To(HaveLen(1))
+				Expect(*resp.Failures[0].Reason).To(Equal("RESOURCE:PORTS"))
+				Expect(*resp.Failures[0].Detail).To(ContainSubstring("8080"))
+
+				pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(pods.Items).To(HaveLen(1))
+				Expect(pods.Items[0].Spec.Containers[0].Ports[0].HostPort).To(Equal(int32(8080)))
+			})
+
+			It("should report RESOURCE:PORTS_UDP for UDP ports", func() {
+				resp, err := server.ecsAPI.RunTask(ctx, &generated.RunTaskRequest{
+					TaskDefinition: "dns:1",
+					Count:          ptr.Int32(2),
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.Tasks).To(HaveLen(1))
+				Expect(resp.Failures).To(HaveLen(1))
+				Expect(*resp.Failures[0].Reason).To(Equal("RESOURCE:PORTS_UDP"))
+			})
+
+			It("should free the port when the task stops", func() {
+				resp, err := server.ecsAPI.RunTask(ctx, &generated.RunTaskRequest{TaskDefinition: "web:1"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.Tasks).To(HaveLen(1))
+
+				_, err = server.ecsAPI.StopTask(ctx, &generated.StopTaskRequest{Task: *resp.Tasks[0].TaskArn})
+				Expect(err).NotTo(HaveOccurred())
+
+				resp, err = server.ecsAPI.RunTask(ctx, &generated.RunTaskRequest{TaskDefinition: "web:1"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.Tasks).To(HaveLen(1))
+				Expect(resp.Failures).To(BeEmpty())
+			})
+
+			It("should report the used ports as remaining resources of the container instance", func() {
+				_, err := server.ecsAPI.RunTask(ctx, &generated.RunTaskRequest{TaskDefinition: "web:1"})
+				Expect(err).NotTo(HaveOccurred())
+
+				resp, err := server.ecsAPI.DescribeContainerInstances(ctx, &generated.DescribeContainerInstancesRequest{
+					ContainerInstances: []string{"arn:aws:ecs:us-east-1:000000000000:container-instance/default/node-1"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.ContainerInstances).To(HaveLen(1))
+
+				var ports []string
+				for _, resource := range resp.ContainerInstances[0].RemainingResources {
+					if *resource.Name == "PORTS" {
+						ports = resource.StringSetValue
+					}
+				}
+				Expect(ports).To(ContainElements("22", "8080"))
+			})
+
+			It("should not bind host ports in the awsvpc network mode", func() {
+				resp, err := server.ecsAPI.RunTask(ctx, &generated.RunTaskRequest{
+					TaskDefinition: "vpc:1",
+					Count:          ptr.Int32(2),
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.Tasks).To(HaveLen(2))
+				Expect(resp.Failures).To(BeEmpty())
+
+				pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				for _, pod := range pods.Items {
+					Expect(pod.Spec.Containers[0].Ports[0].HostPort).To(BeZero())
+				}
+			})
+		})
 	})
 
 	Describe("StopTask", func() {
@@ -331,6 +794,25 @@ var _ = Describe("Task ECS API", func() {
 				Expect(resp.Failures).To(HaveLen(1))
 				Expect(*resp.Failures[0].Reason).To(Equal("MISSING"))
 			})
+
+			It("should describe a task named by ID and ARN once", func() {
+				resp, err := server.ecsAPI.DescribeTasks(ctx, &generated.DescribeTasksRequest{
+					Tasks: []string{"task-1", "arn:aws:ecs:us-east-1:000000000000:task/default/task-1", "task-3"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.Tasks).To(HaveLen(2))
+				Expect(*resp.Tasks[0].TaskArn).To(HaveSuffix("task-1"))
+				Expect(*resp.Tasks[1].TaskArn).To(HaveSuffix("task-3"))
+			})
+
+			It("should reject more than 100 tasks", func() {
+				identifiers := make([]string, 101)
+				for i := range identifiers {
+					identifiers[i] = fmt.Sprintf("task-%d", i)
+				}
+				_, err := server.ecsAPI.DescribeTasks(ctx, &generated.DescribeTasksRequest{Tasks: identifiers})
+				Expect(err).To(BeAssignableToTypeOf(&generated.InvalidParameterException{}))
+			})
 		})
 	})
 
@@ -457,4 +939,178 @@ var _ = Describe("Task ECS API", func() {
 			})
 		})
 	})
+
+	Describe("Agent state changes", func() {
+		const taskARN = "arn:aws:ecs:us-east-1:000000000000:task/default/agent-task"
+
+		BeforeEach(func() {
+			task := &storage.Task{
+				ID:                "agent-task",
+				ARN:               taskARN,
+				ClusterARN:        "arn:aws:ecs:us-east-1:000000000000:cluster/default",
+				TaskDefinitionARN: "arn:aws:ecs:us-east-1:000000000000:task-definition/nginx:1",
+				LastStatus:        "PENDING",
+				DesiredStatus:     "RUNNING",
+				LaunchType:        "EC2",
+				Containers:        `[{"name":"nginx","lastStatus":"PENDING"}]`,
+				Attachments:       `[{"id":"eni-attachment-1","type":"ElasticNetworkInterface","status":"PRECREATED"}]`,
+				Version:           1,
+				CreatedAt:         time.Now(),
+			}
+			Expect(mockTaskStore.Create(ctx, task)).To(Succeed())
+		})
+
+		describeTask := func() *generated.Task {
+			resp, err := server.ecsAPI.DescribeTasks(ctx, &generated.DescribeTasksRequest{Tasks: []string{"agent-task"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Tasks).To(HaveLen(1))
+			return &resp.Tasks[0]
+		}
+
+		It("should return KECS as the poll endpoint", func() {
+			resp, err := server.ecsAPI.DiscoverPollEndpoint(ctx, &generated.DiscoverPollEndpointRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*resp.Endpoint).To(HavePrefix("http"))
+			Expect(*resp.TelemetryEndpoint).To(Equal(*resp.Endpoint))
+
+			_, err = server.ecsAPI.DiscoverPollEndpoint(ctx, &generated.DiscoverPollEndpointRequest{Cluster: ptr.String("missing")})
+			Expect(err).To(MatchError(ContainSubstring("cluster not found")))
+		})
+
+		It("should apply task state changes", func() {
+			resp, err := server.ecsAPI.SubmitTaskStateChange(ctx, &generated.SubmitTaskStateChangeRequest{
+				Task:   ptr.String("agent-task"),
+				Status: ptr.String("RUNNING"),
+				Containers: []generated.ContainerStateChange{
+					{ContainerName: ptr.String("nginx"), Status: ptr.String("RUNNING"), RuntimeId: ptr.String("abc123")},
+				},
+				Attachments: []generated.AttachmentStateChange{
+					{AttachmentArn: "arn:aws:ecs:us-east-1:000000000000:attachment/eni-attachment-1", Status: "ATTACHED"},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*resp.Acknowledgment).To(Equal("ACK"))
+
+			task := describeTask()
+			Expect(*task.LastStatus).To(Equal("RUNNING"))
+			Expect(task.StartedAt).NotTo(BeNil())
+			Expect(*task.Containers[0].LastStatus).To(Equal("RUNNING"))
+			Expect(*task.Containers[0].RuntimeId).To(Equal("abc123"))
+			Expect(*task.Attachments[0].Status).To(Equal("ATTACHED"))
+		})
+
+		It("should stop tasks and ignore later transitions", func() {
+			_, err := server.ecsAPI.SubmitTaskStateChange(ctx, &generated.SubmitTaskStateChangeRequest{
+				Task:   ptr.String(taskARN),
+				Status: ptr.String("STOPPED"),
+				Reason: ptr.String("Essential container in task exited"),
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = server.ecsAPI.SubmitTaskStateChange(ctx, &generated.SubmitTaskStateChangeRequest{
+				Task:   ptr.String(taskARN),
+				Status: ptr.String("RUNNING"),
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			task := describeTask()
+			Expect(*task.LastStatus).To(Equal("STOPPED"))
+			Expect(*task.DesiredStatus).To(Equal("STOPPED"))
+			Expect(*task.StoppedReason).To(Equal("Essential container in task exited"))
+			Expect(task.StoppedAt).NotTo(BeNil())
+		})
+
+		It("should reject unknown task statuses", func() {
+			_, err := server.ecsAPI.SubmitTaskStateChange(ctx, &generated.SubmitTaskStateChangeRequest{
+				Task:   ptr.String("agent-task"),
+				Status: ptr.String("EXPLODED"),
+			})
+			Expect(err).To(MatchError(ContainSubstring("invalid task status")))
+		})
+
+		It("should apply container state changes", func() {
+			exitCode := int32(137)
+			_, err := server.ecsAPI.SubmitContainerStateChange(ctx, &generated.SubmitContainerStateChangeRequest{
+				Task:          ptr.String("agent-task"),
+				ContainerName: ptr.String("nginx"),
+				Status:        ptr.String("STOPPED"),
+				ExitCode:      &exitCode,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			task := describeTask()
+			Expect(*task.Containers[0].LastStatus).To(Equal("STOPPED"))
+			Expect(*task.Containers[0].ExitCode).To(Equal(int32(137)))
+		})
+
+		It("should apply attachment state changes across the cluster", func() {
+			_, err := server.ecsAPI.SubmitAttachmentStateChanges(ctx, &generated.SubmitAttachmentStateChangesRequest{
+				Attachments: []generated.AttachmentStateChange{{AttachmentArn: "eni-attachment-1", Status: "DELETED"}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*describeTask().Attachments[0].Status).To(Equal("DELETED"))
+		})
+	})
 })
+
+// slowPodsClientset delays pod creation like a real API server. The fake
+// clientset serializes its reactors, so the delay is added in front of it.
+type slowPodsClientset struct {
+	*fake.Clientset
+	delay time.Duration
+
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func newSlowPodsClientset(delay time.Duration) *slowPodsClientset {
+	return &slowPodsClientset{Clientset: fake.NewSimpleClientset(), delay: delay}
+}
+
+// Peak returns the largest number of pod creations that were in flight at once
+func (c *slowPodsClientset) Peak() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.peak
+}
+
+func (c *slowPodsClientset) CoreV1() corev1client.CoreV1Interface {
+	return &slowCoreV1{CoreV1Interface: c.Clientset.CoreV1(), clientset: c}
+}
+
+type slowCoreV1 struct {
+	corev1client.CoreV1Interface
+	clientset *slowPodsClientset
+}
+
+func (s *slowCoreV1) Pods(namespace string) corev1client.PodInterface {
+	return &slowPods{PodInterface: s.CoreV1Interface.Pods(namespace), clientset: s.clientset}
+}
+
+type slowPods struct {
+	corev1client.PodInterface
+	clientset *slowPodsClientset
+}
+
+func (p *slowPods) Create(ctx context.Context, pod *corev1.Pod, opts metav1.CreateOptions) (*corev1.Pod, error) {
+	c := p.clientset
+	c.mu.Lock()
+	c.inFlight++
+	c.peak = max(c.peak, c.inFlight)
+	c.mu.Unlock()
+
+	time.Sleep(c.delay)
+
+	c.mu.Lock()
+	c.inFlight--
+	c.mu.Unlock()
+	return p.PodInterface.Create(ctx, pod, opts)
+}
+
+// architectureResolverFunc resolves the architectures of images with a function
+type architectureResolverFunc func(ctx context.Context, image string) ([]string, error)
+
+func (f architectureResolverFunc) Architectures(ctx context.Context, image string) ([]string, error) {
+	return f(ctx, image)
+}
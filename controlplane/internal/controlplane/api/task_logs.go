@@ -22,12 +22,16 @@ import (
 	"net/http"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/connect"
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/kecsapi"
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
 )
 
@@ -126,41 +130,9 @@ func (api *DefaultECSAPI) HandleGetTaskLogs(w http.ResponseWriter, r *http.Reque
 
 // getPodLogs retrieves logs from a Kubernetes pod
 func getPodLogs(ctx context.Context, clientset kubernetes.Interface, namespace, podName string, req GetTaskLogsRequest) ([]LogEntry, error) {
-	// First try to get the pod directly by name
-	pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+	pod, err := findTaskPod(ctx, clientset, namespace, podName)
 	if err != nil {
-		// If not found, try to find by task-id label
-		logging.Debug("Pod not found by name, trying to find by task-id label",
-			"namespace", namespace,
-			"podName", podName)
-
-		labelSelector := fmt.Sprintf("kecs.dev/task-id=%s", podName)
-		pods, listErr := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
-			LabelSelector: labelSelector,
-		})
-
-		if listErr != nil || len(pods.Items) == 0 {
-			// Last attempt: find pod with matching name pattern
-			// For service tasks, the pod name might be a deployment pod
-			pods, listErr = clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
-			if listErr != nil {
-				return nil, fmt.Errorf("failed to list pods: %w", listErr)
-			}
-
-			// Find pod that contains the task ID or matches the pattern
-			for _, p := range pods.Items {
-				if p.Name == podName || strings.Contains(p.Name, podName) {
-					pod = &p
-					break
-				}
-			}
-
-			if pod == nil {
-				return nil, fmt.Errorf("pod not found: %s in namespace %s", podName, namespace)
-			}
-		} else {
-			pod = &pods.Items[0]
-		}
+		return nil, err
 	}
 
 	logging.Debug("Found pod for logs",
@@ -189,8 +161,9 @@ func getPodLogs(ctx context.Context, clientset kubernetes.Interface, namespace,
 
 	var allLogs []LogEntry
 
-	// Get logs from each container
-	for _, container := range pod.Spec.Containers {
+	// Get logs from each container, including the sidecars of containers with a restart policy
+	podContainers, _ := converters.TaskContainers(pod)
+	for _, container := range podContainers {
 		opts.Container = container.Name
 
 		// Get log stream
@@ -229,6 +202,124 @@ func getPodLogs(ctx context.Context, clientset kubernetes.Interface, namespace,
 	return allLogs, nil
 }
 
+// StreamTaskLogs streams the logs of the containers of a task to
+// kecs.v1.KecsService clients. With follow the stream lasts until the
+// containers exit or the client goes away.
+func (api *DefaultECSAPI) StreamTaskLogs(ctx context.Context, cluster, taskArn string, tail int64, follow bool, send func(kecsapi.LogEntry) error) error {
+	taskID := extractTaskIDFromArn(taskArn)
+	if taskID == "" {
+		return connect.NewError(connect.CodeInvalidArgument, "invalid task ARN")
+	}
+	if _, err := api.storage.TaskStore().Get(ctx, cluster, taskID); err != nil {
+		return connect.NewError(connect.CodeNotFound, "task not found: %s", taskID)
+	}
+
+	clientset, err := api.getKubernetesClient()
+	if err != nil {
+		logging.Error("Failed to get Kubernetes client", "error", err)
+		return connect.NewError(connect.CodeUnavailable, "failed to connect to Kubernetes")
+	}
+	namespace, podName := extractNamespaceAndPodName(taskArn)
+	pod, err := findTaskPod(ctx, clientset, namespace, podName)
+	if err != nil {
+		return connect.NewError(connect.CodeNotFound, "%v", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Containers are read concurrently so that followed streams interleave
+	entries := make(chan LogEntry)
+	var wg sync.WaitGroup
+	podContainers, _ := converters.TaskContainers(pod)
+	for _, container := range podContainers {
+		opts := &corev1.PodLogOptions{
+			Container:  container.Name,
+			Timestamps: true,
+			Follow:     follow,
+		}
+		if tail > 0 {
+			opts.TailLines = &tail
+		}
+		stream, err := clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, opts).Stream(ctx)
+		if err != nil {
+			logging.Warn("Failed to get logs for container", "container", container.Name, "error", err)
+			continue
+		}
+
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			defer stream.Close()
+			scanner := bufio.NewScanner(stream)
+			for scanner.Scan() {
+				select {
+				case entries <- parseLogLine(scanner.Text(), name, true):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(container.Name)
+	}
+	go func() {
+		wg.Wait()
+		close(entries)
+	}()
+
+	for entry := range entries {
+		if err := send(kecsapi.LogEntry{
+			Timestamp: entry.Timestamp,
+			Container: entry.Container,
+			Level:     entry.Level,
+			Message:   entry.Message,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findTaskPod finds the pod of a task by name, task-id label or name pattern
+func findTaskPod(ctx context.Context, clientset kubernetes.Interface, namespace, podName string) (*corev1.Pod, error) {
+	// First try to get the pod directly by name
+	pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		// If not found, try to find by task-id label
+		logging.Debug("Pod not found by name, trying to find by task-id label",
+			"namespace", namespace,
+			"podName", podName)
+
+		labelSelector := fmt.Sprintf("kecs.dev/task-id=%s", podName)
+		pods, listErr := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: labelSelector,
+		})
+
+		if listErr != nil || len(pods.Items) == 0 {
+			// Last attempt: find pod with matching name pattern
+			// For service tasks, the pod name might be a deployment pod
+			pods, listErr = clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+			if listErr != nil {
+				return nil, fmt.Errorf("failed to list pods: %w", listErr)
+			}
+
+			// Find pod that contains the task ID or matches the pattern
+			for _, p := range pods.Items {
+				if p.Name == podName || strings.Contains(p.Name, podName) {
+					pod = &p
+					break
+				}
+			}
+
+			if pod == nil {
+				return nil, fmt.Errorf("pod not found: %s in namespace %s", podName, namespace)
+			}
+		} else {
+			pod = &pods.Items[0]
+		}
+	}
+	return pod, nil
+}
+
 // parseLogLine parses a log line into a LogEntry
 func parseLogLine(line, containerName string, hasTimestamp bool) LogEntry {
 	entry := LogEntry{
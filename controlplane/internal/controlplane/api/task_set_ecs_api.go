@@ -6,10 +6,10 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/google/uuid"
-
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
 )
 
@@ -28,8 +28,8 @@ func (api *DefaultECSAPI) CreateTaskSet(ctx context.Context, req *generated.Crea
 	}
 
 	// Build ARNs
-	clusterARN := fmt.Sprintf("arn:aws:ecs:%s:%s:cluster/%s", api.region, api.accountID, cluster)
-	serviceARN := fmt.Sprintf("arn:aws:ecs:%s:%s:service/%s/%s", api.region, api.accountID, cluster, service)
+	clusterARN := awsarn.Build("ecs", api.region, api.accountFor(ctx), fmt.Sprintf("cluster/%s", cluster))
+	serviceARN := awsarn.Build("ecs", api.region, api.accountFor(ctx), fmt.Sprintf("service/%s/%s", cluster, service))
 
 	// Verify service exists and get desired count
 	serviceObj, err := api.storage.ServiceStore().GetByARN(ctx, serviceARN)
@@ -37,6 +37,10 @@ func (api *DefaultECSAPI) CreateTaskSet(ctx context.Context, req *generated.Crea
 		return nil, fmt.Errorf("service not found: %s", service)
 	}
 
+	if err := api.validateAwsVpcSubnets(req.NetworkConfiguration); err != nil {
+		return nil, err
+	}
+
 	// Default scale if not provided
 	scale := req.Scale
 	if scale == nil {
@@ -58,8 +62,8 @@ func (api *DefaultECSAPI) CreateTaskSet(ctx context.Context, req *generated.Crea
 	}
 
 	// Generate task set ID
-	taskSetId := "ts-" + uuid.New().String()[:8]
-	taskSetARN := fmt.Sprintf("arn:aws:ecs:%s:%s:task-set/%s/%s/%s", api.region, api.accountID, cluster, service, taskSetId)
+	taskSetId := "ts-" + deterministic.Suffix(8)
+	taskSetARN := awsarn.Build("ecs", api.region, api.accountFor(ctx), fmt.Sprintf("task-set/%s/%s/%s", cluster, service, taskSetId))
 
 	// Create storage object
 	storageTaskSet := &storage.TaskSet{
@@ -77,7 +81,7 @@ func (api *DefaultECSAPI) CreateTaskSet(ctx context.Context, req *generated.Crea
 		PendingCount:         0,
 		RunningCount:         0,
 		Region:               api.region,
-		AccountID:            api.accountID,
+		AccountID:            api.accountFor(ctx),
 	}
 
 	// Marshal complex fields to JSON
@@ -201,7 +205,7 @@ func (api *DefaultECSAPI) DeleteTaskSet(ctx context.Context, req *generated.Dele
 	}
 
 	// Build service ARN
-	serviceARN := fmt.Sprintf("arn:aws:ecs:%s:%s:service/%s/%s", api.region, api.accountID, cluster, service)
+	serviceARN := awsarn.Build("ecs", api.region, api.accountFor(ctx), fmt.Sprintf("service/%s/%s", cluster, service))
 
 	// Get task set from storage
 	storageTaskSet, err := api.storage.TaskSetStore().Get(ctx, serviceARN, taskSet)
@@ -265,7 +269,7 @@ func (api *DefaultECSAPI) DescribeTaskSets(ctx context.Context, req *generated.D
 	}
 
 	// Build service ARN
-	serviceARN := fmt.Sprintf("arn:aws:ecs:%s:%s:service/%s/%s", api.region, api.accountID, cluster, service)
+	serviceARN := awsarn.Build("ecs", api.region, api.accountFor(ctx), fmt.Sprintf("service/%s/%s", cluster, service))
 
 	// Get task sets from storage
 	storageTaskSets, err := api.storage.TaskSetStore().List(ctx, serviceARN, req.TaskSets)
@@ -384,7 +388,7 @@ func (api *DefaultECSAPI) DescribeTaskSets(ctx context.Context, req *generated.D
 		for _, requestedID := range req.TaskSets {
 			if !foundIDs[requestedID] {
 				failures = append(failures, generated.Failure{
-					Arn:    ptr.String(fmt.Sprintf("arn:aws:ecs:%s:%s:task-set/%s/%s/%s", api.region, api.accountID, cluster, service, requestedID)),
+					Arn:    ptr.String(awsarn.Build("ecs", api.region, api.accountFor(ctx), fmt.Sprintf("task-set/%s/%s/%s", cluster, service, requestedID))),
 					Reason: ptr.String("MISSING"),
 					Detail: ptr.String("Task set not found"),
 				})
@@ -416,7 +420,7 @@ func (api *DefaultECSAPI) UpdateTaskSet(ctx context.Context, req *generated.Upda
 	}
 
 	// Build service ARN
-	serviceARN := fmt.Sprintf("arn:aws:ecs:%s:%s:service/%s/%s", api.region, api.accountID, cluster, service)
+	serviceARN := awsarn.Build("ecs", api.region, api.accountFor(ctx), fmt.Sprintf("service/%s/%s", cluster, service))
 
 	// Get task set from storage
 	storageTaskSet, err := api.storage.TaskSetStore().Get(ctx, serviceARN, taskSet)
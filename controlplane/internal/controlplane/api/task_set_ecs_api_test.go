@@ -13,6 +13,7 @@ import (
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/mocks"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/vpc"
 )
 
 var _ = Describe("TaskSetEcsApi", func() {
@@ -115,6 +116,19 @@ var _ = Describe("TaskSetEcsApi", func() {
 			Expect(resp).To(BeNil())
 		})
 
+		It("should reject subnets unknown to the VPC registry", func() {
+			ecsAPI.(*DefaultECSAPI).SetVPCRegistry(vpc.NewRegistry(region))
+			config.Set("network.validateSubnets", true)
+			DeferCleanup(config.Set, "network.validateSubnets", false)
+
+			req.NetworkConfiguration = &generated.NetworkConfiguration{
+				AwsvpcConfiguration: &generated.AwsVpcConfiguration{Subnets: []string{"subnet-unknown"}},
+			}
+			resp, err := ecsAPI.CreateTaskSet(ctx, req)
+			Expect(err).To(BeAssignableToTypeOf(&generated.InvalidParameterException{}))
+			Expect(resp).To(BeNil())
+		})
+
 		It("should use default scale when not provided", func() {
 			req.Scale = nil
 			resp, err := ecsAPI.CreateTaskSet(ctx, req)
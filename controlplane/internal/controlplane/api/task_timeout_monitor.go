@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+const (
+	// MaxDurationTag is the KECS extension tag limiting how long a task runs,
+	// as a Go duration such as 90m or a number of seconds
+	MaxDurationTag = "kecs.dev/max-duration"
+
+	// StoppedReasonTaskTimedOut prefixes the stopped reason of the tasks
+	// stopped once they ran longer than their maximum duration. Their stop
+	// code is UserInitiated, as the user set the duration.
+	StoppedReasonTaskTimedOut = "TaskTimedOut"
+)
+
+// TaskTimeoutMonitor stops the tasks that run longer than the maximum
+// duration of their kecs.dev/max-duration tag, as batch jobs with a timeout.
+// The duration counts from the creation of the task.
+type TaskTimeoutMonitor struct {
+	api      *DefaultECSAPI
+	interval time.Duration
+	ticker   *time.Ticker
+	done     chan struct{}
+
+	// now is the wall clock the maximum durations are measured with
+	now    func() time.Time
+	starts *wallClockStarts
+}
+
+// NewTaskTimeoutMonitor creates a monitor for the tasks of api
+func NewTaskTimeoutMonitor(api *DefaultECSAPI) *TaskTimeoutMonitor {
+	return &TaskTimeoutMonitor{
+		api:      api,
+		interval: config.GetDuration("taskTimeout.interval", 15*time.Second),
+		done:     make(chan struct{}),
+		now:      time.Now,
+		starts:   newWallClockStarts(),
+	}
+}
+
+// Start begins checking the running tasks
+func (m *TaskTimeoutMonitor) Start(ctx context.Context) {
+	m.ticker = time.NewTicker(m.interval)
+
+	go func() {
+		logging.Info("Task timeout monitor: Started", "interval", m.interval)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-m.done:
+				return
+			case <-m.ticker.C:
+				m.checkTasks(ctx)
+			}
+		}
+	}()
+}
+
+// Stop halts the monitor
+func (m *TaskTimeoutMonitor) Stop() {
+	if m.ticker != nil {
+		m.ticker.Stop()
+	}
+	close(m.done)
+}
+
+// checkTasks stops every task that outlived its maximum duration
+func (m *TaskTimeoutMonitor) checkTasks(ctx context.Context) {
+	clusters, err := m.api.storage.ClusterStore().List(ctx)
+	if err != nil {
+		logging.Warn("Task timeout monitor: Failed to list clusters", "error", err)
+		return
+	}
+	now := m.now()
+	defer m.starts.sweep()
+	for _, cluster := range clusters {
+		tasks, err := m.api.storage.TaskStore().List(ctx, cluster.ARN, storage.TaskFilters{DesiredStatus: "RUNNING"})
+		if err != nil {
+			logging.Warn("Task timeout monitor: Failed to list tasks", "cluster", cluster.Name, "error", err)
+			continue
+		}
+		for _, task := range tasks {
+			maxDuration, err := taskMaxDuration(task)
+			if err != nil {
+				logging.Warn("Task timeout monitor: Ignoring invalid maximum duration", "task", task.ARN, "error", err)
+				continue
+			}
+			if maxDuration == 0 || now.Sub(m.starts.start(task.ARN, task.CreatedAt, now)) < maxDuration {
+				continue
+			}
+
+			reason := fmt.Sprintf("%s: Task exceeded its maximum duration of %s", StoppedReasonTaskTimedOut, maxDuration)
+			if err := m.api.stopTask(ctx, cluster, task, string(generated.TaskStopCodeUSER_INITIATED), reason); err != nil {
+				logging.Warn("Task timeout monitor: Failed to stop task", "task", task.ARN, "error", err)
+				continue
+			}
+			logging.Info("Task timeout monitor: Stopped task", "task", task.ARN, "maxDuration", maxDuration)
+		}
+	}
+}
+
+// taskMaxDuration returns the maximum duration of a task, zero without the tag
+func taskMaxDuration(task *storage.Task) (time.Duration, error) {
+	if task.Tags == "" {
+		return 0, nil
+	}
+	var tags []generated.Tag
+	if err := json.Unmarshal([]byte(task.Tags), &tags); err != nil {
+		return 0, nil
+	}
+	return maxDurationFromTags(tags)
+}
+
+// maxDurationFromTags parses the kecs.dev/max-duration tag, zero without it
+func maxDurationFromTags(tags []generated.Tag) (time.Duration, error) {
+	for _, tag := range tags {
+		if ptr.ToString(tag.Key) != MaxDurationTag {
+			continue
+		}
+		value := ptr.ToString(tag.Value)
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			seconds, convErr := strconv.Atoi(value)
+			if convErr != nil {
+				return 0, fmt.Errorf("invalid %s tag %q: expected a duration such as 90m or a number of seconds", MaxDurationTag, value)
+			}
+			d = time.Duration(seconds) * time.Second
+		}
+		if d <= 0 {
+			return 0, fmt.Errorf("invalid %s tag %q: must be positive", MaxDurationTag, value)
+		}
+		return d, nil
+	}
+	return 0, nil
+}
+
+// wallClockStarts tells background monitors when a resource started in wall
+// time. Stored timestamps are wall times, except in deterministic mode where
+// they are readings of the stepping clock that say nothing about the time
+// passed. There the time the resource was first observed stands in for them.
+type wallClockStarts struct {
+	seen     map[string]time.Time
+	observed map[string]time.Time
+}
+
+func newWallClockStarts() *wallClockStarts {
+	return &wallClockStarts{
+		seen:     make(map[string]time.Time),
+		observed: make(map[string]time.Time),
+	}
+}
+
+// start returns when the resource id started, given its stored timestamp
+// and the current wall time
+func (s *wallClockStarts) start(id string, stored, now time.Time) time.Time {
+	if !deterministic.Enabled() {
+		return stored
+	}
+	started, ok := s.seen[id]
+	if !ok {
+		started = now
+	}
+	s.observed[id] = started
+	return started
+}
+
+// sweep forgets the resources that were not observed since the last sweep
+func (s *wallClockStarts) sweep() {
+	s.seen, s.observed = s.observed, make(map[string]time.Time)
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/mocks"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+var _ = Describe("TaskTimeoutMonitor", func() {
+	const clusterARN = "arn:aws:ecs:us-east-1:000000000000:cluster/default"
+
+	var (
+		ctx           context.Context
+		ecsAPI        *DefaultECSAPI
+		monitor       *TaskTimeoutMonitor
+		mockTaskStore *mocks.MockTaskStore
+	)
+
+	createTask := func(id, tags string, age time.Duration) {
+		Expect(mockTaskStore.Create(ctx, &storage.Task{
+			ID:            id,
+			ARN:           "arn:aws:ecs:us-east-1:000000000000:task/default/" + id,
+			ClusterARN:    clusterARN,
+			LastStatus:    "RUNNING",
+			DesiredStatus: "RUNNING",
+			Tags:          tags,
+			CreatedAt:     time.Now().Add(-age),
+		})).To(Succeed())
+	}
+
+	getTask := func(id string) *storage.Task {
+		task, err := mockTaskStore.Get(ctx, clusterARN, id)
+		Expect(err).NotTo(HaveOccurred())
+		return task
+	}
+
+	BeforeEach(func() {
+		os.Setenv("KECS_TEST_MODE", "true")
+		ctx = context.Background()
+		mockStorage := mocks.NewMockStorage()
+		mockClusterStore := mocks.NewMockClusterStore()
+		mockTaskStore = mocks.NewMockTaskStore()
+		mockStorage.SetClusterStore(mockClusterStore)
+		mockStorage.SetTaskStore(mockTaskStore)
+		Expect(mockClusterStore.Create(ctx, &storage.Cluster{
+			Name:              "default",
+			ARN:               clusterARN,
+			Status:            "ACTIVE",
+			RunningTasksCount: 3,
+		})).To(Succeed())
+
+		ecsAPI = NewDefaultECSAPI(config.DefaultConfig(), mockStorage).(*DefaultECSAPI)
+		monitor = NewTaskTimeoutMonitor(ecsAPI)
+	})
+
+	It("stops the tasks running longer than their maximum duration", func() {
+		createTask("expired", `[{"key":"kecs.dev/max-duration","value":"30m"}]`, time.Hour)
+		createTask("expired-seconds", `[{"key":"kecs.dev/max-duration","value":"60"}]`, 2*time.Minute)
+		createTask("within", `[{"key":"kecs.dev/max-duration","value":"2h"}]`, time.Hour)
+		createTask("untagged", `[{"key":"team","value":"batch"}]`, 24*time.Hour)
+
+		monitor.checkTasks(ctx)
+
+		expired := getTask("expired")
+		Expect(expired.DesiredStatus).To(Equal("STOPPED"))
+		Expect(expired.StopCode).To(Equal("UserInitiated"))
+		Expect(expired.StoppedReason).To(Equal("TaskTimedOut: Task exceeded its maximum duration of 30m0s"))
+		Expect(getTask("expired-seconds").StopCode).To(Equal("UserInitiated"))
+
+		Expect(getTask("within").DesiredStatus).To(Equal("RUNNING"))
+		Expect(getTask("untagged").DesiredStatus).To(Equal("RUNNING"))
+
+		described, err := ecsAPI.DescribeTasks(ctx, &generated.DescribeTasksRequest{Tasks: []string{"expired"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(described.Tasks).To(HaveLen(1))
+		Expect(*described.Tasks[0].StopCode).To(Equal(generated.TaskStopCodeUSER_INITIATED))
+	})
+
+	It("ignores tasks with an invalid maximum duration", func() {
+		createTask("invalid", `[{"key":"kecs.dev/max-duration","value":"soon"}]`, time.Hour)
+
+		monitor.checkTasks(ctx)
+
+		Expect(getTask("invalid").DesiredStatus).To(Equal("RUNNING"))
+	})
+
+	It("measures the maximum duration in wall time in deterministic mode", func() {
+		deterministic.Enable(true)
+		DeferCleanup(deterministic.Enable, false)
+
+		// Tasks carry stepping clock timestamps, long before the wall time
+		Expect(mockTaskStore.Create(ctx, &storage.Task{
+			ID:            "deterministic",
+			ARN:           "arn:aws:ecs:us-east-1:000000000000:task/default/deterministic",
+			ClusterARN:    clusterARN,
+			LastStatus:    "RUNNING",
+			DesiredStatus: "RUNNING",
+			Tags:          `[{"key":"kecs.dev/max-duration","value":"30m"}]`,
+			CreatedAt:     deterministic.Now(),
+		})).To(Succeed())
+		next := deterministic.Now().Add(time.Second)
+
+		wallNow := time.Now()
+		monitor.now = func() time.Time { return wallNow }
+		monitor.checkTasks(ctx)
+		Expect(getTask("deterministic").DesiredStatus).To(Equal("RUNNING"))
+		Expect(deterministic.Now()).To(Equal(next), "checks must not advance the deterministic clock")
+
+		wallNow = wallNow.Add(31 * time.Minute)
+		monitor.checkTasks(ctx)
+		Expect(getTask("deterministic").StopCode).To(Equal("UserInitiated"))
+	})
+
+	It("rejects RunTask with an invalid maximum duration", func() {
+		_, err := ecsAPI.RunTask(ctx, &generated.RunTaskRequest{
+			TaskDefinition: "batch:1",
+			Tags:           []generated.Tag{{Key: ptr.String(MaxDurationTag), Value: ptr.String("-5m")}},
+		})
+		var invalid *generated.InvalidParameterException
+		Expect(errors.As(err, &invalid)).To(BeTrue())
+		Expect(*invalid.Message).To(ContainSubstring("must be positive"))
+	})
+})
@@ -10,6 +10,7 @@ import (
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/taskattachments"
 )
 
 // TestModeTaskWorker manages task lifecycle in test mode
@@ -142,7 +143,9 @@ func (w *TestModeTaskWorker) processTasks(ctx context.Context) {
 					if task.StoppedReason == "" {
 						task.StoppedReason = "Task stopped"
 					}
-					task.StopCode = "TaskStoppedByUser"
+					if task.StopCode == "" {
+						task.StopCode = "TaskStoppedByUser"
+					}
 
 					// Update container status to STOPPED
 					var containers []generated.Container
@@ -187,6 +190,7 @@ func (w *TestModeTaskWorker) processTasks(ctx context.Context) {
 
 			// Update the task if changed
 			if updated {
+				task.Attachments = taskattachments.Sync(task.Attachments, nil, task.LastStatus)
 				task.Version++
 				if err := w.storage.TaskStore().Update(ctx, task); err != nil {
 					logging.Error("Test mode worker: Failed to update task", "taskId", task.ID, "error", err)
@@ -5,6 +5,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
 )
 
@@ -67,7 +68,7 @@ func ValidateClusterName(name string) error {
 func ValidateClusterARN(arn string) error {
 	// Basic ARN format validation
 	// Format: arn:aws:ecs:region:account-id:cluster/cluster-name
-	if !strings.HasPrefix(arn, "arn:aws:ecs:") {
+	if !awsarn.IsService(arn, "ecs") {
 		return fmt.Errorf("invalid ARN format")
 	}
 
@@ -166,7 +167,7 @@ func ValidateExecuteCommandConfiguration(config *generated.ExecuteCommandConfigu
 	// Validate KMS key ID format if provided
 	if config.KmsKeyId != nil && *config.KmsKeyId != "" {
 		// Basic validation - in real AWS, this would validate against actual KMS keys
-		if !strings.HasPrefix(*config.KmsKeyId, "arn:aws:kms:") &&
+		if !awsarn.IsService(*config.KmsKeyId, "kms") &&
 			!regexp.MustCompile(`^[a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12}$`).MatchString(*config.KmsKeyId) {
 			return fmt.Errorf("Invalid parameter: Invalid KMS key ID format")
 		}
@@ -214,7 +215,7 @@ func ValidateResourceARN(arn string) error {
 		return fmt.Errorf("resource ARN is required")
 	}
 
-	if !strings.HasPrefix(arn, "arn:aws:ecs:") {
+	if !awsarn.IsService(arn, "ecs") {
 		return fmt.Errorf("invalid resource ARN format")
 	}
 
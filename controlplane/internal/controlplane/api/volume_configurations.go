@@ -0,0 +1,43 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/types"
+)
+
+// validateVolumeConfigurations validates the volumeConfigurations of RunTask,
+// CreateService or UpdateService against the volumes configured at launch of
+// the task definition. It returns the configurations as JSON, empty
+// when the request has none.
+func validateVolumeConfigurations[T generated.TaskVolumeConfiguration | generated.ServiceVolumeConfiguration](taskDef *storage.TaskDefinition, volumeConfigs []T) (string, error) {
+	if len(volumeConfigs) == 0 {
+		return "", nil
+	}
+
+	data, err := json.Marshal(volumeConfigs)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal volume configurations: %w", err)
+	}
+	var configs []types.VolumeConfiguration
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return "", fmt.Errorf("failed to parse volume configurations: %w", err)
+	}
+
+	var volumes []types.Volume
+	if taskDef.Volumes != "" {
+		if err := json.Unmarshal([]byte(taskDef.Volumes), &volumes); err != nil {
+			return "", fmt.Errorf("failed to parse volumes: %w", err)
+		}
+	}
+
+	if err := converters.ValidateVolumeConfigurations(volumes, configs); err != nil {
+		return "", &generated.InvalidParameterException{Message: ptr.String(err.Error())}
+	}
+	return string(data), nil
+}
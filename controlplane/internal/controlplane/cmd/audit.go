@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/audit"
+)
+
+var (
+	auditInstance    string
+	auditLines       int
+	auditFollow      bool
+	auditService     string
+	auditOperation   string
+	auditAccessKeyID string
+	auditPrincipal   string
+	auditFailed      bool
+	auditSince       time.Duration
+	auditJSON        bool
+)
+
+// auditPollInterval is how often `kecs audit tail --follow` polls for new entries
+const auditPollInterval = 2 * time.Second
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Inspect the audit log of API mutations",
+	Long: `KECS records every mutating AWS API call made against an instance: the
+operation, the caller's access key, a summary of the request and the result.
+Read-only calls such as Describe* and List* are not recorded.`,
+}
+
+var auditTailCmd = &cobra.Command{
+	Use:   "tail",
+	Short: "Show the latest audit log entries",
+	Example: `  kecs audit tail
+  kecs audit tail --follow --operation UpdateService
+  kecs audit tail --access-key AKIAEXAMPLE --since 1h --json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		adminPort, err := resolveAdminPort(ctx, auditInstance)
+		if err != nil {
+			return err
+		}
+
+		params := url.Values{}
+		params.Set("limit", strconv.Itoa(auditLines))
+		if auditService != "" {
+			params.Set("service", auditService)
+		}
+		if auditOperation != "" {
+			params.Set("operation", auditOperation)
+		}
+		if auditAccessKeyID != "" {
+			params.Set("accessKeyId", auditAccessKeyID)
+		}
+		if auditPrincipal != "" {
+			params.Set("principal", auditPrincipal)
+		}
+		if auditFailed {
+			params.Set("failed", "true")
+		}
+		if auditSince > 0 {
+			params.Set("since", time.Now().Add(-auditSince).UTC().Format(time.RFC3339))
+		}
+
+		printer := newAuditPrinter(auditJSON)
+		defer printer.flush()
+		for {
+			resp, err := fetchAuditEntries(ctx, adminPort, params)
+			if err != nil {
+				return err
+			}
+			for _, entry := range resp.Entries {
+				printer.print(entry)
+			}
+			if !auditFollow {
+				return nil
+			}
+			printer.flush()
+
+			// Only poll for entries recorded after the ones already shown
+			params.Set("afterSeq", strconv.FormatInt(resp.LastSeq, 10))
+			params.Set("limit", "0")
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(auditPollInterval):
+			}
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(auditCmd)
+	auditCmd.AddCommand(auditTailCmd)
+
+	auditCmd.PersistentFlags().StringVar(&auditInstance, "instance", "", "KECS instance to target (default: the only running instance)")
+
+	auditTailCmd.Flags().IntVarP(&auditLines, "lines", "n", 20, "Number of entries to show")
+	auditTailCmd.Flags().BoolVarP(&auditFollow, "follow", "f", false, "Keep printing new entries as they are recorded")
+	auditTailCmd.Flags().StringVar(&auditService, "service", "", "Only show calls to a service, e.g. ecs or elasticloadbalancing")
+	auditTailCmd.Flags().StringVar(&auditOperation, "operation", "", "Only show calls of an operation, e.g. UpdateService")
+	auditTailCmd.Flags().StringVar(&auditAccessKeyID, "access-key", "", "Only show calls signed with an access key")
+	auditTailCmd.Flags().StringVar(&auditPrincipal, "principal", "", "Only show calls made by a principal of the credentials file")
+	auditTailCmd.Flags().BoolVar(&auditFailed, "failed", false, "Only show calls that returned an error")
+	auditTailCmd.Flags().DurationVar(&auditSince, "since", 0, "Only show calls made within a duration, e.g. 30m")
+	auditTailCmd.Flags().BoolVar(&auditJSON, "json", false, "Print entries as JSON lines")
+}
+
+// auditResponse is the response of GET /api/audit
+type auditResponse struct {
+	Entries []audit.Entry `json:"entries"`
+	LastSeq int64         `json:"lastSeq"`
+}
+
+// fetchAuditEntries queries the audit API of an instance
+func fetchAuditEntries(ctx context.Context, adminPort int, params url.Values) (*auditResponse, error) {
+	endpoint := fmt.Sprintf("http://localhost:%d/api/audit?%s", adminPort, params.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to KECS admin API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err == nil && apiErr.Message != "" {
+			return nil, fmt.Errorf("%s", apiErr.Message)
+		}
+		return nil, fmt.Errorf("request failed with status %d", resp.StatusCode)
+	}
+
+	var result auditResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &result, nil
+}
+
+// auditPrinter prints audit entries as a table or as JSON lines
+type auditPrinter struct {
+	json    bool
+	writer  *tabwriter.Writer
+	encoder *json.Encoder
+	header  bool
+}
+
+func newAuditPrinter(asJSON bool) *auditPrinter {
+	return &auditPrinter{
+		json:    asJSON,
+		writer:  tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0),
+		encoder: json.NewEncoder(os.Stdout),
+	}
+}
+
+func (p *auditPrinter) print(entry audit.Entry) {
+	if p.json {
+		p.encoder.Encode(entry)
+		return
+	}
+	if !p.header {
+		fmt.Fprintln(p.writer, "TIME\tSERVICE\tOPERATION\tCALLER\tRESULT\tDURATION\tREQUEST")
+		p.header = true
+	}
+
+	result := strconv.Itoa(entry.StatusCode)
+	if entry.ErrorCode != "" {
+		result += " " + entry.ErrorCode
+	}
+	caller := entry.AccessKeyID
+	if entry.Principal != "" {
+		caller = entry.Principal
+	}
+	if caller == "" {
+		caller = "-"
+	}
+	request := entry.Request
+	if len(request) > 80 {
+		request = request[:77] + "..."
+	}
+	fmt.Fprintf(p.writer, "%s\t%s\t%s\t%s\t%s\t%dms\t%s\n",
+		entry.Time.Local().Format("2006-01-02 15:04:05"),
+		entry.Service, entry.Operation, caller, result, entry.DurationMs, request)
+}
+
+func (p *auditPrinter) flush() {
+	p.writer.Flush()
+}
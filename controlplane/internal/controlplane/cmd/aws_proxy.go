@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/proxy"
+)
+
+var awsProxyListen string
+
+var awsProxyCmd = &cobra.Command{
+	Use:   "aws-proxy",
+	Short: "Run the AWS SDK proxy sidecar",
+	Long: `Run the AWS SDK proxy that is injected as a sidecar into ECS tasks.
+It forwards AWS API calls to LocalStack and is configured with the environment
+variables set from the kecs.io/aws-proxy-* pod annotations.`,
+	Hidden: true,
+	RunE:   runAWSProxy,
+}
+
+func init() {
+	RootCmd.AddCommand(awsProxyCmd)
+
+	awsProxyCmd.Flags().StringVar(&awsProxyListen, "listen", ":4566", "Address to listen on")
+}
+
+func runAWSProxy(cmd *cobra.Command, args []string) error {
+	cfg, err := proxy.SidecarConfigFromEnv(os.Getenv)
+	if err != nil {
+		return err
+	}
+	sidecar, err := proxy.NewAWSProxySidecar(cfg)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Addr:              awsProxyListen,
+		Handler:           sidecar,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		logging.Info("Starting AWS proxy", "listen", awsProxyListen, "localstack", cfg.LocalStackEndpoint, "cache", cfg.CacheEnabled)
+		errCh <- server.ListenAndServe()
+	}()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("AWS proxy failed: %w", err)
+		}
+		return nil
+	case <-sigCh:
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return server.Shutdown(ctx)
+	}
+}
@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	backupInstance    string
+	backupDestination string
+	backupRetain      int
+	restoreFrom       string
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Back up the state of a KECS instance to S3",
+	Long: `Back up the clusters, task definitions, services, task sets, account settings
+and ELBv2 resources of a KECS instance to an S3 bucket of LocalStack or AWS.
+Backups are restored with kecs restore.
+
+Instances take backups automatically when backup.schedule is configured,
+e.g. KECS_BACKUP_SCHEDULE="0 3 * * *" with
+KECS_BACKUP_DESTINATION=s3://kecs-backups/dev/ and KECS_BACKUP_RETENTION=7.`,
+}
+
+var backupCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Back up the instance now",
+	Example: `  kecs backup create --destination s3://kecs-backups/dev/
+  kecs backup create --destination s3://kecs-backups/dev/ --retain 7`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		adminPort, err := resolveAdminPort(ctx, backupInstance)
+		if err != nil {
+			return err
+		}
+
+		var resp struct {
+			Location string   `json:"location"`
+			Pruned   []string `json:"pruned"`
+		}
+		body := map[string]interface{}{"destination": backupDestination, "retain": backupRetain}
+		if err := callBackupAPI(ctx, adminPort, http.MethodPost, "/api/backups", body, &resp); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Backup saved to %s\n", resp.Location)
+		for _, key := range resp.Pruned {
+			fmt.Printf("    pruned %s\n", key)
+		}
+		return nil
+	},
+}
+
+var backupListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "List the backups of a destination, newest first",
+	Example: `  kecs backup list --destination s3://kecs-backups/dev/`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		adminPort, err := resolveAdminPort(ctx, backupInstance)
+		if err != nil {
+			return err
+		}
+
+		var resp struct {
+			Destination string `json:"destination"`
+			Backups     []struct {
+				Key          string    `json:"key"`
+				Size         int64     `json:"size"`
+				LastModified time.Time `json:"lastModified"`
+			} `json:"backups"`
+		}
+		path := "/api/backups?destination=" + url.QueryEscape(backupDestination)
+		if err := callBackupAPI(ctx, adminPort, http.MethodGet, path, nil, &resp); err != nil {
+			return err
+		}
+		if len(resp.Backups) == 0 {
+			fmt.Printf("No backups in %s\n", resp.Destination)
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KEY\tSIZE\tCREATED")
+		for _, b := range resp.Backups {
+			fmt.Fprintf(w, "%s\t%d\t%s\n", b.Key, b.Size, b.LastModified.Local().Format(time.RFC3339))
+		}
+		return w.Flush()
+	},
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore a backup into a KECS instance",
+	Long: `Restore a backup taken with kecs backup create or by scheduled backups.
+--from names a backup, or a prefix to restore the newest backup in it.
+
+Resources that already exist in the instance are left as they are. Restored
+services are provisioned again by the instance and start their tasks from
+scratch. Backups of a schema version newer than the instance supports are
+rejected.`,
+	Example: `  kecs restore --from s3://kecs-backups/dev/kecs-backup-20260101T030000Z.json.gz
+  kecs restore --from s3://kecs-backups/dev/`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		adminPort, err := resolveAdminPort(ctx, backupInstance)
+		if err != nil {
+			return err
+		}
+
+		var resp struct {
+			Location      string         `json:"location"`
+			SchemaVersion int            `json:"schemaVersion"`
+			KECSVersion   string         `json:"kecsVersion"`
+			CreatedAt     time.Time      `json:"createdAt"`
+			Restored      map[string]int `json:"restored"`
+			Skipped       map[string]int `json:"skipped"`
+			Warnings      []string       `json:"warnings"`
+		}
+		body := map[string]string{"from": restoreFrom}
+		if err := callBackupAPI(ctx, adminPort, http.MethodPost, "/api/backups/restore", body, &resp); err != nil {
+			return err
+		}
+
+		fmt.Printf("✅ Restored %s (schema version %d, KECS %s, created %s)\n",
+			resp.Location, resp.SchemaVersion, resp.KECSVersion, resp.CreatedAt.Local().Format(time.RFC3339))
+		kinds := make([]string, 0, len(resp.Restored)+len(resp.Skipped))
+		for kind := range resp.Restored {
+			kinds = append(kinds, kind)
+		}
+		for kind := range resp.Skipped {
+			if _, ok := resp.Restored[kind]; !ok {
+				kinds = append(kinds, kind)
+			}
+		}
+		sort.Strings(kinds)
+		for _, kind := range kinds {
+			fmt.Printf("    %s: %d restored, %d already existed\n", kind, resp.Restored[kind], resp.Skipped[kind])
+		}
+		for _, warning := range resp.Warnings {
+			fmt.Printf("    warning: %s\n", warning)
+		}
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(backupCmd)
+	RootCmd.AddCommand(restoreCmd)
+	backupCmd.AddCommand(backupCreateCmd)
+	backupCmd.AddCommand(backupListCmd)
+
+	backupCmd.PersistentFlags().StringVar(&backupInstance, "instance", "", "KECS instance to target (default: the only running instance)")
+	backupCmd.PersistentFlags().StringVar(&backupDestination, "destination", "", "S3 prefix of the backups, e.g. s3://kecs-backups/dev/ (default: backup.destination of the instance)")
+	backupCreateCmd.Flags().IntVar(&backupRetain, "retain", 0, "Delete all but this many backups of the destination after saving (default: keep all)")
+
+	restoreCmd.Flags().StringVar(&backupInstance, "instance", "", "KECS instance to target (default: the only running instance)")
+	restoreCmd.Flags().StringVar(&restoreFrom, "from", "", "Backup to restore, s3://bucket/key, or a prefix to restore its newest backup")
+	restoreCmd.MarkFlagRequired("from")
+}
+
+// callBackupAPI calls the backup API of an instance and decodes its response
+// into out
+func callBackupAPI(ctx context.Context, adminPort int, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	endpoint := fmt.Sprintf("http://localhost:%d%s", adminPort, path)
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 10 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to KECS admin API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err == nil && apiErr.Message != "" {
+			return fmt.Errorf("%s", apiErr.Message)
+		}
+		return fmt.Errorf("request failed with status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/bench"
+)
+
+// benchPollInterval is how often the report of a bench run is polled
+const benchPollInterval = 2 * time.Second
+
+var (
+	benchInstance string
+	benchCluster  string
+	benchTarget   string
+	benchPath     string
+	benchRPS      int
+	benchDuration time.Duration
+	benchTimeout  time.Duration
+
+	benchWorkerURL      string
+	benchWorkerHost     string
+	benchWorkerRPS      int
+	benchWorkerDuration time.Duration
+	benchWorkerTimeout  time.Duration
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Generate HTTP load against a service or load balancer",
+	Long: `Generate HTTP load against a service or load balancer to validate autoscaling
+and load balancer behavior. The load is driven by a Kubernetes Job inside the
+cluster, so it reaches the same endpoints as other tasks. When the run ends,
+its latency percentiles, error rate and status codes are reported.
+
+The target is a service name, a load balancer ARN or a URL. Services need a
+load balancer or a public IP to be reachable.`,
+	Example: `  kecs bench --target web --rps 50 --duration 2m
+  kecs bench --target arn:aws:elasticloadbalancing:us-east-1:000000000000:loadbalancer/app/web-alb/0123456789abcdef --rps 200 --path /health
+  kecs bench --target http://web.default-us-east-1.svc.cluster.local:8080/ --rps 10 --duration 30s`,
+	RunE: runBench,
+}
+
+var benchWorkerCmd = &cobra.Command{
+	Use:    "bench-worker",
+	Short:  "Generate the load of a bench run",
+	Long:   `Generate the load of a bench run and print its result. It runs in the bench Jobs started by 'kecs bench'.`,
+	Hidden: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		spec := bench.Spec{
+			URL:      benchWorkerURL,
+			Host:     benchWorkerHost,
+			RPS:      benchWorkerRPS,
+			Duration: benchWorkerDuration,
+			Timeout:  benchWorkerTimeout,
+		}
+		if err := spec.Validate(); err != nil {
+			return err
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		fmt.Fprintf(os.Stderr, "Sending %d requests per second to %s for %s\n", spec.RPS, spec.URL, spec.Duration)
+		result := bench.Run(ctx, &http.Client{}, spec)
+		return bench.WriteResult(os.Stdout, result)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(benchCmd)
+	RootCmd.AddCommand(benchWorkerCmd)
+
+	benchCmd.Flags().StringVar(&benchInstance, "instance", "", "KECS instance to target (default: the only running instance)")
+	benchCmd.Flags().StringVar(&benchCluster, "cluster", "default", "Cluster of the target service")
+	benchCmd.Flags().StringVar(&benchTarget, "target", "", "Service name, load balancer ARN or URL to send load to (required)")
+	benchCmd.Flags().StringVar(&benchPath, "path", "/", "Path requested on services and load balancers")
+	benchCmd.Flags().IntVar(&benchRPS, "rps", 10, "Requests started per second")
+	benchCmd.Flags().DurationVar(&benchDuration, "duration", time.Minute, "How long to generate load, e.g. 2m")
+	benchCmd.Flags().DurationVar(&benchTimeout, "timeout", 10*time.Second, "Timeout of each request")
+
+	benchWorkerCmd.Flags().StringVar(&benchWorkerURL, "url", "", "URL to request")
+	benchWorkerCmd.Flags().StringVar(&benchWorkerHost, "host", "", "Host header of the requests")
+	benchWorkerCmd.Flags().IntVar(&benchWorkerRPS, "rps", 10, "Requests started per second")
+	benchWorkerCmd.Flags().DurationVar(&benchWorkerDuration, "duration", time.Minute, "How long to generate load")
+	benchWorkerCmd.Flags().DurationVar(&benchWorkerTimeout, "timeout", 10*time.Second, "Timeout of each request")
+}
+
+func runBench(cmd *cobra.Command, args []string) error {
+	if benchTarget == "" {
+		return fmt.Errorf("--target is required")
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	adminPort, err := resolveAdminPort(ctx, benchInstance)
+	if err != nil {
+		return err
+	}
+
+	var report bench.Report
+	err = callAdminAPI(ctx, adminPort, http.MethodPost, "/api/bench", map[string]interface{}{
+		"cluster":  benchCluster,
+		"target":   benchTarget,
+		"path":     benchPath,
+		"rps":      benchRPS,
+		"duration": benchDuration.String(),
+		"timeout":  benchTimeout.String(),
+	}, &report)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Bench run %s: %d requests per second against %s for %s\n", report.ID, benchRPS, benchTarget, benchDuration)
+
+	ticker := time.NewTicker(benchPollInterval)
+	defer ticker.Stop()
+	for report.Status == bench.StatusRunning {
+		select {
+		case <-ctx.Done():
+			// The run is stopped with a fresh context, ctx is cancelled already
+			stopCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := callAdminAPI(stopCtx, adminPort, http.MethodDelete, "/api/bench/"+report.ID, nil, nil); err != nil {
+				return fmt.Errorf("failed to stop bench run %s: %w", report.ID, err)
+			}
+			fmt.Printf("Bench run %s stopped\n", report.ID)
+			return nil
+		case <-ticker.C:
+		}
+		if err := callAdminAPI(ctx, adminPort, http.MethodGet, "/api/bench/"+report.ID, nil, &report); err != nil {
+			return err
+		}
+	}
+
+	if report.Status == bench.StatusFailed {
+		return fmt.Errorf("bench run %s failed: %s", report.ID, report.Message)
+	}
+	printBenchResult(os.Stdout, report.Result)
+	return nil
+}
+
+// printBenchResult prints the summary of a completed bench run
+func printBenchResult(w io.Writer, result *bench.Result) {
+	if result == nil {
+		return
+	}
+	codes := make([]string, 0, len(result.StatusCodes))
+	for code := range result.StatusCodes {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	counts := make([]string, 0, len(codes))
+	for _, code := range codes {
+		counts = append(counts, fmt.Sprintf("%s=%d", code, result.StatusCodes[code]))
+	}
+
+	fmt.Fprintf(w, "Requests:      %d (%d dropped)\n", result.Requests, result.Dropped)
+	fmt.Fprintf(w, "Achieved rate: %.1f rps\n", result.RPS)
+	fmt.Fprintf(w, "Errors:        %d (%.2f%%)\n", result.Errors, result.ErrorRate*100)
+	fmt.Fprintf(w, "Status codes:  %s\n", strings.Join(counts, " "))
+	fmt.Fprintf(w, "Latency:       min %.1fms  mean %.1fms  p50 %.1fms  p90 %.1fms  p99 %.1fms  max %.1fms\n",
+		result.Latency.Min, result.Latency.Mean, result.Latency.P50, result.Latency.P90, result.Latency.P99, result.Latency.Max)
+}
+
+// callAdminAPI sends a JSON request to the admin API of an instance
+func callAdminAPI(ctx context.Context, adminPort int, method, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("http://localhost:%d%s", adminPort, path), reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to KECS admin API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err == nil && apiErr.Message != "" {
+			return fmt.Errorf("%s", apiErr.Message)
+		}
+		return fmt.Errorf("request failed with status %d", resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/bench"
+)
+
+var _ = Describe("Bench", func() {
+	It("should print the summary of a bench run", func() {
+		var out bytes.Buffer
+		printBenchResult(&out, &bench.Result{
+			Requests:    200,
+			Errors:      3,
+			ErrorRate:   0.015,
+			RPS:         49.8,
+			StatusCodes: map[string]int{"503": 2, "200": 197, "error": 1},
+			Latency:     bench.Latency{Min: 1.2, Mean: 5.31, P50: 4.1, P90: 9.8, P99: 25, Max: 120.5},
+		})
+		Expect(out.String()).To(Equal(`Requests:      200 (0 dropped)
+Achieved rate: 49.8 rps
+Errors:        3 (1.50%)
+Status codes:  200=197 503=2 error=1
+Latency:       min 1.2ms  mean 5.3ms  p50 4.1ms  p90 9.8ms  p99 25.0ms  max 120.5ms
+`))
+	})
+})
@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/moby/go-archive"
+	"github.com/moby/go-archive/compression"
+	"github.com/moby/patternmatcher/ignorefile"
+	"github.com/spf13/cobra"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/build"
+)
+
+// buildPollInterval is how often the report of a build is polled
+const buildPollInterval = 2 * time.Second
+
+var (
+	buildInstance   string
+	buildContextDir string
+	buildDockerfile string
+	buildRepository string
+	buildTag        string
+	buildFamily     string
+	buildContainer  string
+	buildPort       int
+	buildCluster    string
+	buildRun        bool
+	buildTimeout    time.Duration
+)
+
+var buildAndRunCmd = &cobra.Command{
+	Use:   "build-and-run",
+	Short: "Build an image from a local Dockerfile and run it as a task",
+	Long: `Build an image from a local Dockerfile inside the cluster and run it as a task,
+without pushing it to an external registry. The build context is uploaded to
+the instance, built with kaniko and pushed to the registry of the instance.
+
+The latest revision of the task definition family is registered again with the
+image of its container replaced, or a task definition with a single container
+is created for a new family. A task of the new revision is started unless
+--run=false is given. Files matched by .dockerignore are not uploaded.`,
+	Example: `  kecs build-and-run
+  kecs build-and-run --context ./api --family api --container app --tag dev
+  kecs build-and-run --repository worker --family worker --port 8080 --run=false`,
+	RunE: runBuildAndRun,
+}
+
+func init() {
+	RootCmd.AddCommand(buildAndRunCmd)
+
+	buildAndRunCmd.Flags().StringVar(&buildInstance, "instance", "", "KECS instance to build in (default: the only running instance)")
+	buildAndRunCmd.Flags().StringVar(&buildContextDir, "context", ".", "Directory of the build context")
+	buildAndRunCmd.Flags().StringVarP(&buildDockerfile, "file", "f", "Dockerfile", "Path of the Dockerfile inside the build context")
+	buildAndRunCmd.Flags().StringVar(&buildRepository, "repository", "", "Repository of the image (default: name of the context directory)")
+	buildAndRunCmd.Flags().StringVar(&buildTag, "tag", "latest", "Tag of the image")
+	buildAndRunCmd.Flags().StringVar(&buildFamily, "family", "", "Task definition family registered with the image (default: the repository)")
+	buildAndRunCmd.Flags().StringVar(&buildContainer, "container", "", "Container whose image is replaced, required for task definitions with several containers")
+	buildAndRunCmd.Flags().IntVar(&buildPort, "port", 0, "Container port of a new task definition")
+	buildAndRunCmd.Flags().StringVar(&buildCluster, "cluster", "default", "Cluster the task is started in")
+	buildAndRunCmd.Flags().BoolVar(&buildRun, "run", true, "Start a task of the registered task definition")
+	buildAndRunCmd.Flags().DurationVar(&buildTimeout, "timeout", 15*time.Minute, "Timeout of the build")
+}
+
+func runBuildAndRun(cmd *cobra.Command, args []string) error {
+	contextDir, err := filepath.Abs(buildContextDir)
+	if err != nil {
+		return fmt.Errorf("invalid build context: %w", err)
+	}
+	if _, err := os.Stat(filepath.Join(contextDir, buildDockerfile)); err != nil {
+		return fmt.Errorf("dockerfile not found in build context: %w", err)
+	}
+	repository := buildRepository
+	if repository == "" {
+		repository = repositoryName(filepath.Base(contextDir))
+	}
+	family := buildFamily
+	if family == "" {
+		family = repository
+	}
+	spec := build.Spec{
+		Repository: repository,
+		Tag:        buildTag,
+		Dockerfile: filepath.ToSlash(buildDockerfile),
+		Family:     family,
+		Container:  buildContainer,
+		Port:       int32(buildPort),
+		Cluster:    buildCluster,
+		Run:        buildRun,
+	}
+	if err := spec.Validate(); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, buildTimeout)
+	defer cancel()
+
+	adminPort, err := resolveAdminPort(ctx, buildInstance)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Uploading build context %s\n", contextDir)
+	report, err := uploadBuildContext(ctx, adminPort, contextDir, spec)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Building %s (build %s)\n", report.Image, report.ID)
+
+	ticker := time.NewTicker(buildPollInterval)
+	defer ticker.Stop()
+	for report.Status == build.StatusRunning {
+		select {
+		case <-ctx.Done():
+			// The build is stopped with a fresh context, ctx is cancelled already
+			stopCtx, stopCancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer stopCancel()
+			if err := callAdminAPI(stopCtx, adminPort, http.MethodDelete, "/api/builds/"+report.ID, nil, nil); err != nil {
+				return fmt.Errorf("failed to stop build %s: %w", report.ID, err)
+			}
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("build %s did not finish within %s", report.ID, buildTimeout)
+			}
+			fmt.Printf("Build %s stopped\n", report.ID)
+			return nil
+		case <-ticker.C:
+		}
+		if err := callAdminAPI(ctx, adminPort, http.MethodGet, "/api/builds/"+report.ID, nil, report); err != nil {
+			return err
+		}
+	}
+
+	if report.Status == build.StatusFailed {
+		if logs, err := buildLogs(ctx, adminPort, report.ID); err == nil {
+			fmt.Fprint(os.Stderr, logs)
+		}
+		return fmt.Errorf("build %s failed: %s", report.ID, report.Message)
+	}
+	fmt.Printf("Pushed %s\n", report.Image)
+	if report.TaskDefinitionArn != "" {
+		fmt.Printf("Registered task definition %s\n", report.TaskDefinitionArn)
+	}
+	if report.TaskArn != "" {
+		fmt.Printf("Started task %s\n", report.TaskArn)
+	}
+	return nil
+}
+
+// repositoryPattern matches the characters not allowed in repository names
+var repositoryPattern = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// repositoryName derives a repository name from a directory name
+func repositoryName(dir string) string {
+	name := strings.Trim(repositoryPattern.ReplaceAllString(strings.ToLower(dir), "-"), "-._")
+	if name == "" {
+		return "app"
+	}
+	return name
+}
+
+// buildContextExcludes returns the .dockerignore patterns of a build context
+func buildContextExcludes(contextDir string) ([]string, error) {
+	file, err := os.Open(filepath.Join(contextDir, ".dockerignore"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .dockerignore: %w", err)
+	}
+	defer file.Close()
+	patterns, err := ignorefile.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .dockerignore: %w", err)
+	}
+	return patterns, nil
+}
+
+// uploadBuildContext streams the build context to the build API of an
+// instance, which starts the build
+func uploadBuildContext(ctx context.Context, adminPort int, contextDir string, spec build.Spec) (*build.Report, error) {
+	excludes, err := buildContextExcludes(contextDir)
+	if err != nil {
+		return nil, err
+	}
+	tarball, err := archive.TarWithOptions(contextDir, &archive.TarOptions{
+		ExcludePatterns: excludes,
+		Compression:     compression.Gzip,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive build context: %w", err)
+	}
+	defer tarball.Close()
+
+	query := url.Values{}
+	query.Set("repository", spec.Repository)
+	query.Set("tag", spec.Tag)
+	query.Set("dockerfile", spec.Dockerfile)
+	query.Set("family", spec.Family)
+	query.Set("container", spec.Container)
+	query.Set("cluster", spec.Cluster)
+	query.Set("run", strconv.FormatBool(spec.Run))
+	if spec.Port > 0 {
+		query.Set("port", strconv.Itoa(int(spec.Port)))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("http://localhost:%d/api/builds?%s", adminPort, query.Encode()), tarball)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/gzip")
+
+	// The upload is bounded by ctx, large contexts take longer than the
+	// timeout of the other admin requests
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload build context: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err == nil && apiErr.Message != "" {
+			return nil, fmt.Errorf("%s", apiErr.Message)
+		}
+		return nil, fmt.Errorf("request failed with status %d", resp.StatusCode)
+	}
+	var report build.Report
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &report, nil
+}
+
+// buildLogs returns the logs of a build
+func buildLogs(ctx context.Context, adminPort int, id string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("http://localhost:%d/api/builds/%s/logs", adminPort, id), nil)
+	if err != nil {
+		return "", err
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("request failed with status %d", resp.StatusCode)
+	}
+	logs, err := io.ReadAll(resp.Body)
+	return string(logs), err
+}
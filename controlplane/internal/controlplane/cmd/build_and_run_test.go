@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("build-and-run", func() {
+	DescribeTable("derives repository names from directories",
+		func(dir, repository string) {
+			Expect(repositoryName(dir)).To(Equal(repository))
+		},
+		Entry("plain", "api", "api"),
+		Entry("upper case and spaces", "My Service", "my-service"),
+		Entry("no valid characters", "..", "app"),
+	)
+
+	It("reads the .dockerignore patterns of the build context", func() {
+		dir := GinkgoT().TempDir()
+		Expect(buildContextExcludes(dir)).To(BeEmpty())
+
+		Expect(os.WriteFile(filepath.Join(dir, ".dockerignore"), []byte("# deps\nnode_modules\n*.log\n"), 0o644)).To(Succeed())
+		Expect(buildContextExcludes(dir)).To(Equal([]string{"node_modules", "*.log"}))
+	})
+})
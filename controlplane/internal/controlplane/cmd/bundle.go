@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/bundle"
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/version"
+)
+
+var (
+	bundleOutput            string
+	bundleConfigFile        string
+	bundleWithoutLocalStack bool
+	bundleExtraImages       []string
+	bundleList              bool
+)
+
+var bundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Manage image bundles for offline instances",
+}
+
+var bundleCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create an archive of the images an instance needs",
+	Long: `Create an archive of all images a KECS instance runs: the k3d nodes, the
+control plane, Traefik, Vector, the k3s system pods and LocalStack. Images
+missing from Docker are pulled first, so run it on a machine with internet
+access and copy the archive to the offline machine:
+
+  kecs start --offline --bundle kecs-bundle.tar.gz
+
+Without LocalStack the bundle is several hundred megabytes smaller. Instances
+started from it serve the ECS and ELBv2 APIs of the control plane only; AWS
+APIs backed by LocalStack, such as S3, SSM or CloudWatch Logs, are not
+available unless they are started with --aws-backend stub, which serves S3,
+SSM and Secrets Manager from the control plane.
+
+Images of tasks can be added with --image, or pushed to the KECS registry at
+localhost:5000 once the instance runs.`,
+	Example: `  kecs bundle create
+  kecs bundle create --output kecs-offline.tar.gz --without-localstack
+  kecs bundle create --image nginx:1.27 --image redis:7
+  kecs bundle create --list`,
+	RunE: runBundleCreate,
+}
+
+func init() {
+	RootCmd.AddCommand(bundleCmd)
+	bundleCmd.AddCommand(bundleCreateCmd)
+
+	flags := bundleCreateCmd.Flags()
+	flags.StringVarP(&bundleOutput, "output", "o", "", "Path of the bundle (default: kecs-bundle-<version>.tar.gz)")
+	flags.StringVar(&bundleConfigFile, "config", "", "Configuration file of the instances, e.g. with another control plane or LocalStack image")
+	flags.BoolVar(&bundleWithoutLocalStack, "without-localstack", false, "Leave out LocalStack, offline instances then run without it")
+	flags.StringArrayVar(&bundleExtraImages, "image", nil, "Additional image to bundle, e.g. of a task")
+	flags.BoolVar(&bundleList, "list", false, "Print the images of the bundle without creating it")
+}
+
+func runBundleCreate(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig(bundleConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	images := bundleImages(cfg, !bundleWithoutLocalStack, bundleExtraImages)
+	if bundleList {
+		for _, image := range images {
+			fmt.Println(image)
+		}
+		return nil
+	}
+
+	output := bundleOutput
+	if output == "" {
+		output = defaultBundleName(version.GetVersion())
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	store, err := bundle.NewDockerImageStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	for _, image := range images {
+		exists, err := store.Exists(ctx, image)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		fmt.Printf("Pulling %s\n", image)
+		if err := store.Pull(ctx, image); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Saving %d images to %s\n", len(images), output)
+	manifest := bundle.Manifest{
+		KECSVersion: version.GetVersion(),
+		Images:      images,
+		LocalStack:  !bundleWithoutLocalStack && !cfg.LocalStack.UseStub(),
+	}
+	if err := bundle.Write(ctx, store, output, manifest); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(output)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created %s (%.1f MB)\n", output, float64(info.Size())/(1024*1024))
+	fmt.Printf("Start an offline instance with: kecs start --offline --bundle %s\n", output)
+	return nil
+}
+
+// bundleImages returns the images of a bundle: those the instances of cfg
+// need followed by the extra ones
+func bundleImages(cfg *config.Config, withLocalStack bool, extra []string) []string {
+	images := bundle.RequiredImages(cfg, withLocalStack)
+	seen := make(map[string]bool, len(images))
+	for _, image := range images {
+		seen[image] = true
+	}
+	for _, image := range extra {
+		image = strings.TrimSpace(image)
+		if image == "" || seen[image] {
+			continue
+		}
+		seen[image] = true
+		images = append(images, image)
+	}
+	return images
+}
+
+// defaultBundleName names the bundle of a version, e.g. kecs-bundle-v1.2.0.tar.gz
+func defaultBundleName(ver string) string {
+	ver = strings.NewReplacer("/", "-", "+", "-").Replace(ver)
+	if ver == "" {
+		return "kecs-bundle.tar.gz"
+	}
+	return "kecs-bundle-" + ver + ".tar.gz"
+}
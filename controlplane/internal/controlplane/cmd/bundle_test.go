@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+)
+
+var _ = Describe("bundle create", func() {
+	It("appends extra images once", func() {
+		cfg := &config.Config{}
+		cfg.Server.ControlPlaneImage = "ghcr.io/nandemo-ya/kecs:v1.2.0"
+		images := bundleImages(cfg, false, []string{"nginx:1.27", " nginx:1.27 ", "", "traefik:v3.0"})
+		Expect(images[len(images)-1]).To(Equal("nginx:1.27"))
+		Expect(images).NotTo(ContainElement(HavePrefix("localstack/")))
+	})
+
+	DescribeTable("names bundles by version",
+		func(ver, name string) {
+			Expect(defaultBundleName(ver)).To(Equal(name))
+		},
+		Entry("release", "v1.2.0", "kecs-bundle-v1.2.0.tar.gz"),
+		Entry("build metadata", "v1.2.0+abc", "kecs-bundle-v1.2.0-abc.tar.gz"),
+		Entry("no version", "", "kecs-bundle.tar.gz"),
+	)
+})
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/chaos"
+)
+
+var (
+	chaosInstance string
+	chaosCluster  string
+	chaosTask     string
+	chaosMessage  string
+
+	chaosStopReason  string
+	chaosDrainReason string
+)
+
+var chaosCmd = &cobra.Command{
+	Use:   "chaos",
+	Short: "Simulate AWS-initiated task stops and instance drains",
+	Long: `Simulate disruptions that AWS causes in a real ECS environment, such as
+Fargate task retirement, Spot interruptions and container instance draining.
+
+Stopped tasks report the same stopCode and stoppedReason values as ECS, and
+services start replacement tasks through their normal reconciliation.`,
+}
+
+var chaosStopTaskCmd = &cobra.Command{
+	Use:   "stop-task",
+	Short: "Stop a task as if AWS had stopped it",
+	Example: `  kecs chaos stop-task --cluster default --task 0123456789abcdef --reason retirement
+  kecs chaos stop-task --task arn:aws:ecs:us-east-1:000000000000:task/default/0123456789abcdef --reason spot`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if chaosTask == "" {
+			return fmt.Errorf("--task is required")
+		}
+		if _, err := chaos.ResolveReason(chaosStopReason, chaosMessage); err != nil {
+			return err
+		}
+
+		var resp map[string]interface{}
+		err := postChaosRequest(cmd.Context(), "/api/chaos/stop-task", map[string]string{
+			"cluster": chaosCluster,
+			"task":    chaosTask,
+			"reason":  chaosStopReason,
+			"message": chaosMessage,
+		}, &resp)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Stopped task %v\n", resp["taskArn"])
+		fmt.Printf("  stopCode:      %v\n", resp["stopCode"])
+		fmt.Printf("  stoppedReason: %v\n", resp["stoppedReason"])
+		return nil
+	},
+}
+
+var chaosDrainNodeCmd = &cobra.Command{
+	Use:   "drain-node <node>",
+	Short: "Cordon a node and stop the tasks running on it",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := chaos.ResolveReason(chaosDrainReason, chaosMessage); err != nil {
+			return err
+		}
+
+		var result chaos.DrainResult
+		err := postChaosRequest(cmd.Context(), "/api/chaos/drain-node", map[string]string{
+			"node":    args[0],
+			"reason":  chaosDrainReason,
+			"message": chaosMessage,
+		}, &result)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Node %s cordoned, stopped %d task(s) with stopCode %s\n",
+			result.Node, len(result.StoppedPods), result.StopCode)
+		for _, pod := range result.StoppedPods {
+			fmt.Printf("  %s\n", pod)
+		}
+		return nil
+	},
+}
+
+var chaosUncordonNodeCmd = &cobra.Command{
+	Use:   "uncordon-node <node>",
+	Short: "Make a drained node schedulable again",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := postChaosRequest(cmd.Context(), "/api/chaos/uncordon-node", map[string]string{"node": args[0]}, nil); err != nil {
+			return err
+		}
+		fmt.Printf("Node %s uncordoned\n", args[0])
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(chaosCmd)
+	chaosCmd.AddCommand(chaosStopTaskCmd)
+	chaosCmd.AddCommand(chaosDrainNodeCmd)
+	chaosCmd.AddCommand(chaosUncordonNodeCmd)
+
+	reasons := strings.Join(chaos.ReasonNames(), ", ")
+	chaosCmd.PersistentFlags().StringVar(&chaosInstance, "instance", "", "KECS instance to target (default: the only running instance)")
+
+	chaosStopTaskCmd.Flags().StringVar(&chaosCluster, "cluster", "default", "Cluster name or ARN")
+	chaosStopTaskCmd.Flags().StringVar(&chaosTask, "task", "", "Task ID or ARN to stop")
+	chaosStopTaskCmd.Flags().StringVar(&chaosStopReason, "reason", chaos.ReasonRetirement, "Stop reason ("+reasons+")")
+	chaosStopTaskCmd.Flags().StringVar(&chaosMessage, "message", "", "Override the stoppedReason message")
+
+	chaosDrainNodeCmd.Flags().StringVar(&chaosDrainReason, "reason", chaos.ReasonDrain, "Stop reason ("+reasons+")")
+	chaosDrainNodeCmd.Flags().StringVar(&chaosMessage, "message", "", "Override the stoppedReason message")
+}
+
+// postChaosRequest sends a request to the chaos API of the target instance
+func postChaosRequest(ctx context.Context, path string, body interface{}, out interface{}) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	adminPort, err := resolveAdminPort(ctx, chaosInstance)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("http://localhost:%d%s", adminPort, path), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to KECS admin API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err == nil && apiErr.Message != "" {
+			return fmt.Errorf("%s", apiErr.Message)
+		}
+		return fmt.Errorf("request failed with status %d", resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
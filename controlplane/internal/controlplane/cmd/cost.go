@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/usage"
+)
+
+var (
+	costInstance string
+	costCluster  string
+	costSince    time.Duration
+	costJSON     bool
+)
+
+var costCmd = &cobra.Command{
+	Use:   "cost",
+	Short: "Estimate what the tasks of an instance would cost on Fargate",
+}
+
+var costReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Report task vCPU-seconds, GB-seconds and estimated cost per cluster and service",
+	Long: `Report the vCPU and memory reserved by tasks over a period, as vCPU-seconds
+and GB-seconds, and what they would cost on Fargate. Tasks are billed from
+the start of their image pull until they stop, per second with a one minute
+minimum. Tasks without cpu or memory count as the smallest Fargate size.
+
+Prices default to Fargate Linux/x86 on-demand in us-east-1 and are set with
+usage.vcpuHourPrice and usage.gbHourPrice. The usage of deleted tasks is kept
+for usage.retention.`,
+	Example: `  kecs cost report
+  kecs cost report --since 1h --cluster default
+  kecs cost report --since 168h --json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		adminPort, err := resolveAdminPort(ctx, costInstance)
+		if err != nil {
+			return err
+		}
+
+		params := url.Values{}
+		params.Set("since", time.Now().Add(-costSince).UTC().Format(time.RFC3339))
+		if costCluster != "" {
+			params.Set("cluster", costCluster)
+		}
+
+		report, err := fetchCostReport(ctx, adminPort, params)
+		if err != nil {
+			return err
+		}
+		if costJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(report)
+		}
+		printCostReport(report)
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(costCmd)
+	costCmd.AddCommand(costReportCmd)
+
+	costCmd.PersistentFlags().StringVar(&costInstance, "instance", "", "KECS instance to target (default: the only running instance)")
+
+	costReportCmd.Flags().StringVar(&costCluster, "cluster", "", "Only include tasks of a cluster")
+	costReportCmd.Flags().DurationVar(&costSince, "since", 24*time.Hour, "Report the usage within a duration until now, e.g. 24h")
+	costReportCmd.Flags().BoolVar(&costJSON, "json", false, "Print the report as JSON")
+}
+
+// fetchCostReport queries the usage API of an instance
+func fetchCostReport(ctx context.Context, adminPort int, params url.Values) (*usage.Report, error) {
+	endpoint := fmt.Sprintf("http://localhost:%d/api/usage/cost?%s", adminPort, params.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to KECS admin API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err == nil && apiErr.Message != "" {
+			return nil, fmt.Errorf("%s", apiErr.Message)
+		}
+		return nil, fmt.Errorf("request failed with status %d", resp.StatusCode)
+	}
+
+	var report usage.Report
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &report, nil
+}
+
+func printCostReport(report *usage.Report) {
+	fmt.Printf("Usage from %s to %s ($%g per vCPU-hour, $%g per GB-hour)\n\n",
+		report.Since.Local().Format(time.DateTime), report.Until.Local().Format(time.DateTime),
+		report.Pricing.VCPUHour, report.Pricing.GBHour)
+	if report.Total.Tasks == 0 {
+		fmt.Println("No tasks ran in this period")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CLUSTER\tSERVICE\tTASKS\tVCPU-HOURS\tGB-HOURS\tESTIMATED COST")
+	printRow := func(cluster, service string, u usage.Usage) {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%.2f\t%.2f\t$%.4f\n", cluster, service, u.Tasks,
+			u.VCPUSeconds/3600, u.GBSeconds/3600, u.EstimatedCost)
+	}
+	for _, cluster := range report.Clusters {
+		name := cluster.ClusterARN[strings.LastIndex(cluster.ClusterARN, "/")+1:]
+		for _, service := range cluster.Services {
+			serviceName := service.Service
+			if serviceName == "" {
+				serviceName = "(standalone tasks)"
+			}
+			printRow(name, serviceName, service.Usage)
+		}
+		printRow(name, "TOTAL", cluster.Usage)
+	}
+	printRow("ALL", "TOTAL", report.Total)
+	w.Flush()
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/admin"
+)
+
+var (
+	debugInstance string
+	debugCluster  string
+	debugImage    string
+	debugTarget   string
+	debugName     string
+)
+
+var debugCmd = &cobra.Command{
+	Use:   "debug TASK [-- COMMAND...]",
+	Short: "Attach a temporary debug container to a running task",
+	Long: `Attach an ephemeral debug container to a running task. The container is not
+part of the task definition, it runs next to the containers of the task with
+the given image and shares their network. With --target it also sees the
+processes of that container.
+
+The debug container is listed by DescribeTasks as kecs-debug-<name> and can
+be entered with ExecuteCommand. It stays until the task stops.`,
+	Example: `  kecs debug 0123456789abcdef --target app
+  kecs debug 0123456789abcdef --image nicolaka/netshoot --name net
+  kecs debug 0123456789abcdef --image busybox -- sleep 3600`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runDebug,
+}
+
+func init() {
+	RootCmd.AddCommand(debugCmd)
+
+	debugCmd.Flags().StringVar(&debugInstance, "instance", "", "KECS instance of the task (default: the only running instance)")
+	debugCmd.Flags().StringVar(&debugCluster, "cluster", "default", "Cluster of the task")
+	debugCmd.Flags().StringVar(&debugImage, "image", "busybox:1.36", "Image of the debug container")
+	debugCmd.Flags().StringVar(&debugTarget, "target", "", "Container whose processes the debug container sees")
+	debugCmd.Flags().StringVar(&debugName, "name", "", "Name appended to kecs-debug- (default: random)")
+}
+
+func runDebug(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	adminPort, err := resolveAdminPort(ctx, debugInstance)
+	if err != nil {
+		return err
+	}
+
+	req := admin.DebugContainerRequest{
+		Cluster: debugCluster,
+		Task:    args[0],
+		Image:   debugImage,
+		Command: args[1:],
+		Target:  debugTarget,
+		Name:    debugName,
+	}
+	var resp admin.DebugContainerResponse
+	if err := callAdminAPI(ctx, adminPort, http.MethodPost, "/api/debug-containers", req, &resp); err != nil {
+		return err
+	}
+
+	fmt.Printf("Attached debug container %s to task %s\n", resp.Container, resp.TaskID)
+	fmt.Printf("Open a shell with:\n  aws ecs execute-command --cluster %s --task %s --container %s --interactive --command sh\n",
+		debugCluster, resp.TaskID, resp.Container)
+	return nil
+}
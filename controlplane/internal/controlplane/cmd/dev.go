@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/admin"
+	"github.com/nandemo-ya/kecs/controlplane/internal/devsync"
+)
+
+// devRestartCommand asks the main process of a container to reload
+const devRestartCommand = "kill -HUP 1"
+
+var (
+	devInstance    string
+	devCluster     string
+	devService     string
+	devTask        string
+	devContainer   string
+	devLocalDir    string
+	devRemoteDir   string
+	devExec        string
+	devRestart     bool
+	devIgnore      []string
+	devInitialSync bool
+	devDebounce    time.Duration
+)
+
+var devCmd = &cobra.Command{
+	Use:   "dev",
+	Short: "Sync local source changes into running task containers",
+	Long: `Watch a local directory and copy every change into a container of the running
+tasks of a service, or of a single task, without rebuilding the image.
+
+Changed files are unpacked with tar below --path inside the container, deleted
+files are removed. Containers therefore need sh and tar. After each sync the
+command given with --exec runs in the container, --restart sends SIGHUP to the
+main process instead. Files matched by .dockerignore or --ignore are not
+synced. Syncing stops with Ctrl-C.`,
+	Example: `  kecs dev --service api --path /app
+  kecs dev --service web --container app --local ./src --path /usr/src/app/src --restart
+  kecs dev --task 0123456789abcdef --path /app --exec "npm run build"`,
+	RunE: runDev,
+}
+
+func init() {
+	RootCmd.AddCommand(devCmd)
+
+	devCmd.Flags().StringVar(&devInstance, "instance", "", "KECS instance to sync into (default: the only running instance)")
+	devCmd.Flags().StringVar(&devCluster, "cluster", "default", "Cluster of the service or task")
+	devCmd.Flags().StringVar(&devService, "service", "", "Service whose running tasks receive the changes")
+	devCmd.Flags().StringVar(&devTask, "task", "", "Task ARN or ID receiving the changes")
+	devCmd.Flags().StringVar(&devContainer, "container", "", "Container receiving the changes, required for tasks with several containers")
+	devCmd.Flags().StringVar(&devLocalDir, "local", ".", "Local directory to watch")
+	devCmd.Flags().StringVar(&devRemoteDir, "path", "", "Absolute directory in the container the local directory is synced to")
+	devCmd.Flags().StringVar(&devExec, "exec", "", "Command run in the container after each sync")
+	devCmd.Flags().BoolVar(&devRestart, "restart", false, "Send SIGHUP to the main process of the container after each sync")
+	devCmd.Flags().StringSliceVar(&devIgnore, "ignore", []string{".git"}, "Patterns of files not to sync, in .dockerignore syntax")
+	devCmd.Flags().BoolVar(&devInitialSync, "initial-sync", true, "Sync the whole local directory before watching it")
+	devCmd.Flags().DurationVar(&devDebounce, "debounce", 300*time.Millisecond, "Time without changes before a batch of changes is synced")
+	devCmd.MarkFlagRequired("path")
+	devCmd.MarkFlagsMutuallyExclusive("service", "task")
+	devCmd.MarkFlagsOneRequired("service", "task")
+	devCmd.MarkFlagsMutuallyExclusive("exec", "restart")
+}
+
+func runDev(cmd *cobra.Command, args []string) error {
+	if !path.IsAbs(devRemoteDir) {
+		return fmt.Errorf("--path must be an absolute directory in the container")
+	}
+	localDir, err := filepath.Abs(devLocalDir)
+	if err != nil {
+		return fmt.Errorf("invalid local directory: %w", err)
+	}
+	if info, err := os.Stat(localDir); err != nil || !info.IsDir() {
+		return fmt.Errorf("local directory %s does not exist", localDir)
+	}
+	excludes, err := buildContextExcludes(localDir)
+	if err != nil {
+		return err
+	}
+	watcher, err := devsync.NewWatcher(localDir, append(excludes, devIgnore...), devDebounce)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	adminPort, err := resolveAdminPort(ctx, devInstance)
+	if err != nil {
+		return err
+	}
+
+	query := devQuery()
+	var targets admin.DevSyncResponse
+	if err := callAdminAPI(ctx, adminPort, http.MethodGet, "/api/dev/targets?"+query.Encode(), nil, &targets); err != nil {
+		return err
+	}
+	for _, target := range targets.Targets {
+		fmt.Printf("Syncing %s into container %s of task %s\n", localDir, target.Container, target.TaskID)
+	}
+
+	sync := func(batch devsync.Batch) error {
+		return devSync(ctx, adminPort, localDir, query, batch)
+	}
+	if devInitialSync {
+		files, err := watcher.Files()
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", localDir, err)
+		}
+		if err := sync(devsync.Batch{Changed: files}); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println("Watching for changes, press Ctrl-C to stop")
+	if err := watcher.Run(ctx, sync); err != nil {
+		return err
+	}
+	fmt.Println("Stopped syncing")
+	return nil
+}
+
+// devQuery returns the query parameters addressing the sync targets
+func devQuery() url.Values {
+	query := url.Values{}
+	query.Set("cluster", devCluster)
+	if devService != "" {
+		query.Set("service", devService)
+	} else {
+		query.Set("task", devTask)
+	}
+	if devContainer != "" {
+		query.Set("container", devContainer)
+	}
+	query.Set("path", devRemoteDir)
+	switch {
+	case devRestart:
+		query.Set("command", devRestartCommand)
+	case devExec != "":
+		query.Set("command", devExec)
+	}
+	return query
+}
+
+// devSync sends a batch of changes to the dev API and prints the result of
+// every target. Failures of single targets are reported without stopping.
+func devSync(ctx context.Context, adminPort int, localDir string, query url.Values, batch devsync.Batch) error {
+	var body []byte
+	if len(batch.Changed) > 0 {
+		archive, err := devsync.Archive(localDir, batch.Changed)
+		if err != nil {
+			return err
+		}
+		body = archive
+	}
+	query = devSyncQuery(query, batch)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("http://localhost:%d/api/dev/sync?%s", adminPort, query.Encode()), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/gzip")
+
+	client := &http.Client{Timeout: 2 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("failed to connect to KECS admin API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr admin.ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err == nil && apiErr.Message != "" {
+			// Tasks may be replaced while syncing, keep watching
+			fmt.Fprintf(os.Stderr, "Sync failed: %s\n", apiErr.Message)
+			return nil
+		}
+		return fmt.Errorf("request failed with status %d", resp.StatusCode)
+	}
+	var result admin.DevSyncResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	summary := devSyncSummary(batch)
+	for _, target := range result.Targets {
+		if target.Error != "" {
+			fmt.Fprintf(os.Stderr, "[%s] sync failed: %s\n", target.TaskID, target.Error)
+		} else {
+			fmt.Printf("[%s] synced %s\n", target.TaskID, summary)
+		}
+		if output := strings.TrimSpace(target.Output); output != "" {
+			fmt.Println(output)
+		}
+	}
+	return nil
+}
+
+// devSyncQuery adds the deleted files of a batch to the query of a sync
+func devSyncQuery(query url.Values, batch devsync.Batch) url.Values {
+	result := url.Values{}
+	for key, values := range query {
+		result[key] = values
+	}
+	for _, file := range batch.Deleted {
+		result.Add("delete", file)
+	}
+	return result
+}
+
+// devSyncSummary describes a batch of changes
+func devSyncSummary(batch devsync.Batch) string {
+	var parts []string
+	if n := len(batch.Changed); n == 1 {
+		parts = append(parts, batch.Changed[0])
+	} else if n > 1 {
+		parts = append(parts, fmt.Sprintf("%d files", n))
+	}
+	if n := len(batch.Deleted); n == 1 {
+		parts = append(parts, "deleted "+batch.Deleted[0])
+	} else if n > 1 {
+		parts = append(parts, fmt.Sprintf("deleted %d files", n))
+	}
+	return strings.Join(parts, ", ")
+}
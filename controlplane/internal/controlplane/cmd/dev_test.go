@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"net/url"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/devsync"
+)
+
+var _ = Describe("dev", func() {
+	It("adds deleted files to the sync query", func() {
+		query := url.Values{"service": {"api"}, "path": {"/app"}}
+		result := devSyncQuery(query, devsync.Batch{Deleted: []string{"a.js", "lib/b.js"}})
+		Expect(result["delete"]).To(Equal([]string{"a.js", "lib/b.js"}))
+		Expect(result.Get("service")).To(Equal("api"))
+		Expect(query).NotTo(HaveKey("delete"))
+	})
+
+	DescribeTable("summarizes batches",
+		func(batch devsync.Batch, summary string) {
+			Expect(devSyncSummary(batch)).To(Equal(summary))
+		},
+		Entry("single file", devsync.Batch{Changed: []string{"main.go"}}, "main.go"),
+		Entry("several files", devsync.Batch{Changed: []string{"a", "b"}, Deleted: []string{"c"}}, "2 files, deleted c"),
+		Entry("deletions", devsync.Batch{Deleted: []string{"a", "b"}}, "deleted 2 files"),
+	)
+})
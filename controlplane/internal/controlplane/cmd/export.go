@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportInstance  string
+	exportCluster   string
+	exportOutputDir string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export ECS resources to other formats",
+}
+
+var exportK8sCmd = &cobra.Command{
+	Use:   "k8s",
+	Short: "Export the Kubernetes manifests KECS creates for a cluster",
+	Long: `Export the Namespace, Deployments, Services, Secrets and ConfigMaps KECS
+creates for the services of a cluster, as plain manifests that can be checked
+into a GitOps repository. The manifests are rendered by the same converters
+KECS uses, and objects are named and ordered deterministically so that
+exports of the same state are identical.
+
+Secrets and ConfigMaps KECS syncs from Secrets Manager and SSM Parameter Store
+are exported with their keys and empty values.`,
+	Example: `  kecs export k8s --cluster default
+  kecs export k8s --cluster default --output-dir ./manifests`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		adminPort, err := resolveAdminPort(ctx, exportInstance)
+		if err != nil {
+			return err
+		}
+
+		resp, err := fetchKubernetesExport(ctx, adminPort, exportCluster)
+		if err != nil {
+			return err
+		}
+
+		if exportOutputDir == "" {
+			return writeManifestStream(os.Stdout, resp.Manifests)
+		}
+		if err := os.MkdirAll(exportOutputDir, 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+		for _, manifest := range resp.Manifests {
+			path := filepath.Join(exportOutputDir, manifest.FileName)
+			if err := os.WriteFile(path, []byte(manifest.YAML), 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", path, err)
+			}
+		}
+		fmt.Printf("Exported %d manifests of cluster %s to %s\n", len(resp.Manifests), resp.Cluster, exportOutputDir)
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(exportCmd)
+	exportCmd.AddCommand(exportK8sCmd)
+
+	exportCmd.PersistentFlags().StringVar(&exportInstance, "instance", "", "KECS instance to target (default: the only running instance)")
+
+	exportK8sCmd.Flags().StringVar(&exportCluster, "cluster", "", "ECS cluster to export")
+	exportK8sCmd.Flags().StringVarP(&exportOutputDir, "output-dir", "o", "", "Write one file per manifest to a directory instead of stdout")
+	exportK8sCmd.MarkFlagRequired("cluster")
+}
+
+// exportManifest is a manifest of GET /api/export/k8s
+type exportManifest struct {
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	FileName string `json:"fileName"`
+	YAML     string `json:"yaml"`
+}
+
+// exportResponse is the response of GET /api/export/k8s
+type exportResponse struct {
+	Cluster   string           `json:"cluster"`
+	Manifests []exportManifest `json:"manifests"`
+}
+
+// fetchKubernetesExport queries the export API of an instance
+func fetchKubernetesExport(ctx context.Context, adminPort int, cluster string) (*exportResponse, error) {
+	endpoint := fmt.Sprintf("http://localhost:%d/api/export/k8s?%s", adminPort, url.Values{"cluster": {cluster}}.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to KECS admin API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err == nil && apiErr.Message != "" {
+			return nil, fmt.Errorf("%s", apiErr.Message)
+		}
+		return nil, fmt.Errorf("request failed with status %d", resp.StatusCode)
+	}
+
+	var result exportResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &result, nil
+}
+
+// writeManifestStream writes manifests as a multi-document YAML stream
+func writeManifestStream(w io.Writer, manifests []exportManifest) error {
+	for i, manifest := range manifests {
+		if i > 0 {
+			if _, err := io.WriteString(w, "---\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := io.WriteString(w, manifest.YAML); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	importInstance  string
+	importNamespace string
+	importCluster   string
+	importDryRun    bool
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import existing workloads as ECS resources",
+}
+
+var importK8sCmd = &cobra.Command{
+	Use:   "k8s",
+	Short: "Create ECS services from the Deployments of a Kubernetes namespace",
+	Long: `Create an ECS task definition and service in KECS for every Deployment of a
+namespace in the KECS cluster. Containers keep their image, command, ports and
+environment. Probes become container health checks, resource limits become
+cpu and memory, ConfigMap references are inlined and Secrets synced from
+Secrets Manager or SSM Parameter Store become ECS secrets.
+
+Deployments that are exposed by a Kubernetes Service are created with a public
+IP so that KECS exposes them again. Parts of a Deployment without an ECS
+equivalent are reported as warnings. The original Deployments are left as is.`,
+	Example: `  kecs import k8s --namespace foo --cluster default
+  kecs import k8s --namespace foo --cluster default --dry-run`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		adminPort, err := resolveAdminPort(ctx, importInstance)
+		if err != nil {
+			return err
+		}
+
+		resp, err := postKubernetesImport(ctx, adminPort, importRequest{
+			Namespace: importNamespace,
+			Cluster:   importCluster,
+			DryRun:    importDryRun,
+		})
+		if err != nil {
+			return err
+		}
+
+		if importDryRun {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(resp.Services)
+		}
+
+		failed := 0
+		for _, service := range resp.Services {
+			if service.Error != "" {
+				failed++
+				fmt.Printf("❌ %s: %s\n", service.Deployment, service.Error)
+			} else {
+				fmt.Printf("✅ %s: %s\n", service.Deployment, service.ServiceArn)
+			}
+			for _, warning := range service.Warnings {
+				fmt.Printf("    warning: %s\n", warning)
+			}
+		}
+		if len(resp.Services) == 0 {
+			fmt.Printf("No Deployments found in namespace %s\n", resp.Namespace)
+		}
+		if failed > 0 {
+			return fmt.Errorf("failed to import %d of %d Deployments", failed, len(resp.Services))
+		}
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(importCmd)
+	importCmd.AddCommand(importK8sCmd)
+
+	importCmd.PersistentFlags().StringVar(&importInstance, "instance", "", "KECS instance to target (default: the only running instance)")
+
+	importK8sCmd.Flags().StringVar(&importNamespace, "namespace", "", "Kubernetes namespace to import Deployments from")
+	importK8sCmd.Flags().StringVar(&importCluster, "cluster", "default", "ECS cluster to create the services in")
+	importK8sCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Print the task definitions and services without creating them")
+	importK8sCmd.MarkFlagRequired("namespace")
+}
+
+// importRequest is the request of POST /api/import/k8s
+type importRequest struct {
+	Namespace string `json:"namespace"`
+	Cluster   string `json:"cluster"`
+	DryRun    bool   `json:"dryRun,omitempty"`
+}
+
+// importedService is a Deployment of POST /api/import/k8s. The generated
+// task definition and service are kept raw so that dry runs print them as is.
+type importedService struct {
+	Deployment        string          `json:"deployment"`
+	TaskDefinition    json.RawMessage `json:"taskDefinition"`
+	Service           json.RawMessage `json:"service"`
+	Warnings          []string        `json:"warnings,omitempty"`
+	TaskDefinitionArn string          `json:"taskDefinitionArn,omitempty"`
+	ServiceArn        string          `json:"serviceArn,omitempty"`
+	Error             string          `json:"error,omitempty"`
+}
+
+// importResponse is the response of POST /api/import/k8s
+type importResponse struct {
+	Namespace string             `json:"namespace"`
+	Cluster   string             `json:"cluster"`
+	Services  []*importedService `json:"services"`
+}
+
+// postKubernetesImport calls the import API of an instance
+func postKubernetesImport(ctx context.Context, adminPort int, body importRequest) (*importResponse, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+	endpoint := fmt.Sprintf("http://localhost:%d/api/import/k8s", adminPort)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 2 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to KECS admin API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err == nil && apiErr.Message != "" {
+			return nil, fmt.Errorf("%s", apiErr.Message)
+		}
+		return nil, fmt.Errorf("request failed with status %d", resp.StatusCode)
+	}
+
+	var result importResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &result, nil
+}
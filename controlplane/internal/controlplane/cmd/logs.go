@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	logsInstance   string
+	logsCluster    string
+	logsService    string
+	logsFollow     bool
+	logsSince      time.Duration
+	logsTail       int
+	logsFilter     string
+	logsTimestamps bool
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Show the logs of all tasks of a service",
+	Long: `Show the container logs of every task of a service, each line prefixed with
+its task ID and container name. With --follow, tasks that start later are
+picked up and tasks that stop are reported as they come and go.`,
+	Example: `  kecs logs --service web
+  kecs logs -f --service web --cluster default
+  kecs logs --service web --since 10m --filter 'ERROR|WARN'`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if logsService == "" {
+			return fmt.Errorf("--service is required")
+		}
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		adminPort, err := resolveAdminPort(ctx, logsInstance)
+		if err != nil {
+			return err
+		}
+
+		params := url.Values{}
+		params.Set("cluster", logsCluster)
+		if logsFollow {
+			params.Set("follow", "true")
+		}
+		if logsSince > 0 {
+			params.Set("since", time.Now().Add(-logsSince).UTC().Format(time.RFC3339))
+		}
+		if logsTail > 0 {
+			params.Set("tail", strconv.Itoa(logsTail))
+		}
+		if logsFilter != "" {
+			params.Set("filter", logsFilter)
+		}
+
+		printer := &serviceLogPrinter{out: os.Stdout, status: os.Stderr, timestamps: logsTimestamps}
+		return streamServiceLogs(ctx, adminPort, logsService, params, printer)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(logsCmd)
+
+	logsCmd.Flags().StringVar(&logsInstance, "instance", "", "KECS instance to target (default: the only running instance)")
+	logsCmd.Flags().StringVar(&logsCluster, "cluster", "default", "Cluster of the service")
+	logsCmd.Flags().StringVar(&logsService, "service", "", "Service whose task logs to show (required)")
+	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Keep streaming logs, including those of tasks started later")
+	logsCmd.Flags().DurationVar(&logsSince, "since", 0, "Only show lines written within a duration, e.g. 10m")
+	logsCmd.Flags().IntVar(&logsTail, "tail", 0, "Number of recent lines to show per container (default: all)")
+	logsCmd.Flags().StringVar(&logsFilter, "filter", "", "Only show lines matching a regular expression")
+	logsCmd.Flags().BoolVar(&logsTimestamps, "timestamps", false, "Prefix lines with their timestamp")
+}
+
+// serviceLogLine is a "log" event of the service log stream
+type serviceLogLine struct {
+	TaskID    string    `json:"taskId"`
+	Container string    `json:"container"`
+	Timestamp time.Time `json:"timestamp"`
+	Message   string    `json:"message"`
+}
+
+// serviceLogTaskEvent is a "task" event of the service log stream
+type serviceLogTaskEvent struct {
+	TaskID string `json:"taskId"`
+	Status string `json:"status"`
+}
+
+// streamServiceLogs prints the service log stream of an instance until it
+// ends or ctx is cancelled
+func streamServiceLogs(ctx context.Context, adminPort int, service string, params url.Values, printer *serviceLogPrinter) error {
+	endpoint := fmt.Sprintf("http://localhost:%d/api/services/%s/logs/stream?%s",
+		adminPort, url.PathEscape(service), params.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// No client timeout, a followed stream lasts until interrupted
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to KECS admin API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err == nil && apiErr.Message != "" {
+			return fmt.Errorf("%s", apiErr.Message)
+		}
+		return fmt.Errorf("request failed with status %d", resp.StatusCode)
+	}
+
+	err = readServerSentEvents(resp.Body, printer.handle)
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// readServerSentEvents calls handle with the name and data of each event of
+// a server-sent event stream
+func readServerSentEvents(r io.Reader, handle func(event, data string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var event string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if len(data) > 0 {
+				if err := handle(event, strings.Join(data, "\n")); err != nil {
+					return err
+				}
+			}
+			event, data = "", nil
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read log stream: %w", err)
+	}
+	return nil
+}
+
+// serviceLogPrinter prints log lines to out and task changes to status
+type serviceLogPrinter struct {
+	out        io.Writer
+	status     io.Writer
+	timestamps bool
+}
+
+// handle prints one event of the service log stream
+func (p *serviceLogPrinter) handle(event, data string) error {
+	switch event {
+	case "log":
+		var line serviceLogLine
+		if err := json.Unmarshal([]byte(data), &line); err != nil {
+			return fmt.Errorf("failed to parse log line: %w", err)
+		}
+		prefix := shortTaskID(line.TaskID) + "/" + line.Container
+		if p.timestamps {
+			prefix = line.Timestamp.Local().Format(time.RFC3339) + " " + prefix
+		}
+		fmt.Fprintf(p.out, "%s | %s\n", prefix, line.Message)
+	case "task":
+		var task serviceLogTaskEvent
+		if err := json.Unmarshal([]byte(data), &task); err != nil {
+			return fmt.Errorf("failed to parse task event: %w", err)
+		}
+		switch task.Status {
+		case "RUNNING":
+			fmt.Fprintf(p.status, "==> task %s started\n", shortTaskID(task.TaskID))
+		case "STOPPED":
+			fmt.Fprintf(p.status, "==> task %s stopped\n", shortTaskID(task.TaskID))
+		}
+	case "error":
+		var message string
+		if err := json.Unmarshal([]byte(data), &message); err != nil {
+			message = data
+		}
+		return fmt.Errorf("%s", message)
+	}
+	return nil
+}
+
+// shortTaskID shortens a task ID the way the ECS console does
+func shortTaskID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
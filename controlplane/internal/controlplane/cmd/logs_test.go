@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Logs", func() {
+	Describe("readServerSentEvents", func() {
+		It("should pass each event with its name and data", func() {
+			stream := "event: log\ndata: first\n\n: comment\nevent: task\ndata: a\ndata: b\n\nevent: close\ndata: stream ended\n\n"
+
+			var events []string
+			err := readServerSentEvents(strings.NewReader(stream), func(event, data string) error {
+				events = append(events, event+"="+data)
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(Equal([]string{"log=first", "task=a\nb", "close=stream ended"}))
+		})
+	})
+
+	Describe("serviceLogPrinter", func() {
+		var (
+			out, status *bytes.Buffer
+			printer     *serviceLogPrinter
+		)
+
+		BeforeEach(func() {
+			out, status = &bytes.Buffer{}, &bytes.Buffer{}
+			printer = &serviceLogPrinter{out: out, status: status}
+		})
+
+		It("should prefix log lines with the task ID and container", func() {
+			Expect(printer.handle("log", `{"taskId":"0123456789abcdef","container":"app","timestamp":"2024-01-20T10:30:45Z","message":"GET / 200"}`)).To(Succeed())
+			Expect(out.String()).To(Equal("01234567/app | GET / 200\n"))
+		})
+
+		It("should report tasks starting and stopping separately from the logs", func() {
+			Expect(printer.handle("task", `{"taskId":"0123456789abcdef","status":"RUNNING"}`)).To(Succeed())
+			Expect(printer.handle("task", `{"taskId":"0123456789abcdef","status":"STOPPED"}`)).To(Succeed())
+			Expect(out.String()).To(BeEmpty())
+			Expect(status.String()).To(Equal("==> task 01234567 started\n==> task 01234567 stopped\n"))
+		})
+
+		It("should return stream errors", func() {
+			Expect(printer.handle("error", `"failed to list tasks"`)).To(MatchError("failed to list tasks"))
+		})
+	})
+})
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/kubernetes"
+	"github.com/nandemo-ya/kecs/controlplane/internal/portforward"
+)
+
+var (
+	portInstance      string
+	portCluster       string
+	portContainerPort int
+	portLocalPort     int
+)
+
+var portCmd = &cobra.Command{
+	Use:   "port <service>",
+	Short: "Map a service port to localhost",
+	Long: `Make a published port of an ECS service reachable on localhost.
+
+The service's Kubernetes Service is inspected: a NodePort is mapped through the
+k3d load balancer, otherwise a port-forward is started. Each service port gets a
+stable local port that is kept in the routing table of the instance, so mapping
+it again, e.g. after a restart, reuses the same port.`,
+	Example: `  kecs port web
+  kecs port web --cluster staging --container-port 8080
+  kecs port list`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		manager, err := newRouteManager(portInstance, true)
+		if err != nil {
+			return err
+		}
+		route, err := manager.MapService(ctx, portCluster, args[0], portContainerPort, portLocalPort)
+		if err != nil {
+			return fmt.Errorf("failed to map service port: %w", err)
+		}
+
+		fmt.Printf("%s/%s:%d -> localhost:%d (%s)\n",
+			route.Cluster, route.Service, route.ContainerPort, route.LocalPort, describeRouteMethod(route))
+		fmt.Printf("Routing table: %s\n", manager.RoutesFile())
+		return nil
+	},
+}
+
+var portListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the service ports mapped to localhost",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// The routing table is read from disk, no cluster access is needed
+		manager, err := newRouteManager(portInstance, false)
+		if err != nil {
+			return err
+		}
+		routes, err := manager.ListRoutes()
+		if err != nil {
+			return err
+		}
+		printRoutes(os.Stdout, routes)
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(portCmd)
+	portCmd.AddCommand(portListCmd)
+
+	portCmd.PersistentFlags().StringVar(&portInstance, "instance", "", "KECS instance to target (default: the configured instance)")
+	portCmd.Flags().StringVar(&portCluster, "cluster", "default", "Cluster of the service")
+	portCmd.Flags().IntVar(&portContainerPort, "container-port", 0, "Service or container port to map (default: the first published port)")
+	portCmd.Flags().IntVar(&portLocalPort, "local-port", 0, "Local port to use (default: the port assigned to the service port)")
+}
+
+// newRouteManager creates the port forward manager of an instance, with a
+// Kubernetes client when the cluster is accessed
+func newRouteManager(instanceName string, withClient bool) (*portforward.Manager, error) {
+	if instanceName == "" {
+		instanceName = getInstanceName()
+	}
+	if !withClient {
+		return portforward.NewManager(instanceName, nil), nil
+	}
+
+	kubeconfigPath := getKubeconfigPath(instanceName)
+	if _, err := os.Stat(kubeconfigPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("kubeconfig not found for instance %s. Is KECS running?", instanceName)
+	}
+	restConfig, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build config: %w", err)
+	}
+	k8sClient, err := kubernetes.NewClient(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return portforward.NewManager(instanceName, k8sClient), nil
+}
+
+// printRoutes prints the routing table
+func printRoutes(out io.Writer, routes []*portforward.Route) {
+	if len(routes) == 0 {
+		fmt.Fprintln(out, "No service ports mapped")
+		return
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CLUSTER\tSERVICE\tCONTAINER PORT\tLOCAL ADDRESS\tVIA")
+	for _, route := range routes {
+		fmt.Fprintf(w, "%s\t%s\t%d\tlocalhost:%d\t%s\n",
+			route.Cluster, route.Service, route.ContainerPort, route.LocalPort, describeRouteMethod(route))
+	}
+	w.Flush()
+}
+
+// describeRouteMethod describes how a route reaches its service
+func describeRouteMethod(route *portforward.Route) string {
+	if route.Method == portforward.RouteMethodNodePort {
+		return fmt.Sprintf("NodePort %d", route.NodePort)
+	}
+	return "port-forward"
+}
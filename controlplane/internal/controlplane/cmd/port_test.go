@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/portforward"
+)
+
+var _ = Describe("Port", func() {
+	Describe("printRoutes", func() {
+		It("should print each route with how it is reached", func() {
+			var out bytes.Buffer
+			printRoutes(&out, []*portforward.Route{
+				{Cluster: "default", Service: "api", ContainerPort: 8080, LocalPort: 41234, Method: portforward.RouteMethodNodePort, NodePort: 30080},
+				{Cluster: "default", Service: "web", ContainerPort: 80, LocalPort: 45678, Method: portforward.RouteMethodPortForward},
+			})
+
+			lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+			Expect(lines).To(HaveLen(3))
+			Expect(string(lines[0])).To(MatchRegexp(`^CLUSTER\s+SERVICE\s+CONTAINER PORT\s+LOCAL ADDRESS\s+VIA$`))
+			Expect(string(lines[1])).To(MatchRegexp(`^default\s+api\s+8080\s+localhost:41234\s+NodePort 30080$`))
+			Expect(string(lines[2])).To(MatchRegexp(`^default\s+web\s+80\s+localhost:45678\s+port-forward$`))
+		})
+
+		It("should say when no ports are mapped", func() {
+			var out bytes.Buffer
+			printRoutes(&out, nil)
+			Expect(out.String()).To(Equal("No service ports mapped\n"))
+		})
+	})
+})
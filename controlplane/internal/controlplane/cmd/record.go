@@ -0,0 +1,298 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/spf13/cobra"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/recorder"
+)
+
+var (
+	recordInstance string
+	recordReset    bool
+	recordOutput   string
+
+	replayEndpoint  string
+	replayAccessKey string
+	replaySecretKey string
+	replayRegion    string
+	replayOperation string
+	replayJSON      bool
+)
+
+var recordCmd = &cobra.Command{
+	Use:   "record",
+	Short: "Record the API calls made against an instance",
+	Long: `Record every AWS API call made against an instance with its response, to debug
+client behavior or to capture traffic for 'kecs replay'. Recordings are written
+to the data directory of the instance as JSON lines. Credentials are never
+recorded: signatures and security tokens are dropped, and passwords, secrets
+and tokens in request and response bodies are redacted.
+
+Recording can also be turned on from startup with KECS_RECORDING_ENABLED=true.`,
+}
+
+var recordStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start recording API calls",
+	Example: `  kecs record start
+  kecs record start --reset --instance dev`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var status recorder.Status
+		if err := callRecordingAPI(cmd.Context(), http.MethodPost, "/api/recording/start", map[string]bool{"reset": recordReset}, &status); err != nil {
+			return err
+		}
+		fmt.Println("Recording API calls")
+		printRecordingStatus(status)
+		return nil
+	},
+}
+
+var recordStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop recording API calls",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var status recorder.Status
+		if err := callRecordingAPI(cmd.Context(), http.MethodPost, "/api/recording/stop", nil, &status); err != nil {
+			return err
+		}
+		fmt.Println("Stopped recording API calls")
+		printRecordingStatus(status)
+		return nil
+	},
+}
+
+var recordStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether API calls are recorded",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var status recorder.Status
+		if err := callRecordingAPI(cmd.Context(), http.MethodGet, "/api/recording", nil, &status); err != nil {
+			return err
+		}
+		printRecordingStatus(status)
+		return nil
+	},
+}
+
+var recordExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Download the recorded API calls",
+	Example: `  kecs record export -o traffic.jsonl
+  kecs record export | jq -c 'select(.statusCode >= 400)'`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		adminPort, err := resolveAdminPort(ctx, recordInstance)
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://localhost:%d/api/recording/interactions", adminPort), nil)
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to connect to KECS admin API: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			var apiErr struct {
+				Message string `json:"message"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&apiErr); err == nil && apiErr.Message != "" {
+				return fmt.Errorf("%s", apiErr.Message)
+			}
+			return fmt.Errorf("request failed with status %d", resp.StatusCode)
+		}
+
+		if recordOutput == "" || recordOutput == "-" {
+			_, err = io.Copy(os.Stdout, resp.Body)
+			return err
+		}
+		file, err := os.OpenFile(recordOutput, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", recordOutput, err)
+		}
+		defer file.Close()
+		written, err := io.Copy(file, resp.Body)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", recordOutput, err)
+		}
+		fmt.Fprintf(os.Stderr, "Saved the recording to %s (%d bytes)\n", recordOutput, written)
+		return nil
+	},
+}
+
+var replayCmd = &cobra.Command{
+	Use:   "replay FILE",
+	Short: "Replay recorded API calls against an endpoint",
+	Long: `Re-issue the API calls of a recording, in order, against an endpoint and
+report the calls that were answered with a different status or error code than
+recorded. Response bodies are not compared as they carry generated ARNs and
+timestamps. The command fails when a call does not match, so replaying captured
+traffic against a new KECS release works as a regression test.
+
+Calls are sent unsigned unless an access key is given, e.g. for instances
+started with a credentials file. Redacted values are sent as REDACTED.`,
+	Example: `  kecs record export -o traffic.jsonl
+  kecs replay traffic.jsonl --endpoint http://localhost:5373
+  kecs replay traffic.jsonl --endpoint http://localhost:5383 --access-key AKIAALICE --secret-key alice-secret`,
+	Args: cobra.ExactArgs(1),
+	RunE: runReplay,
+}
+
+func init() {
+	RootCmd.AddCommand(recordCmd)
+	RootCmd.AddCommand(replayCmd)
+	recordCmd.AddCommand(recordStartCmd, recordStopCmd, recordStatusCmd, recordExportCmd)
+
+	recordCmd.PersistentFlags().StringVar(&recordInstance, "instance", "", "KECS instance to target (default: the only running instance)")
+	recordStartCmd.Flags().BoolVar(&recordReset, "reset", false, "Discard the calls recorded before")
+	recordExportCmd.Flags().StringVarP(&recordOutput, "output", "o", "", "File to write the recording to (default: stdout)")
+
+	replayCmd.Flags().StringVar(&replayEndpoint, "endpoint", "", "Endpoint to replay the calls against, e.g. http://localhost:5373")
+	replayCmd.Flags().StringVar(&replayAccessKey, "access-key", "", "Access key to sign the calls with (default: $AWS_ACCESS_KEY_ID)")
+	replayCmd.Flags().StringVar(&replaySecretKey, "secret-key", "", "Secret key to sign the calls with (default: $AWS_SECRET_ACCESS_KEY)")
+	replayCmd.Flags().StringVar(&replayRegion, "region", recorder.DefaultRegion, "Region to sign calls recorded unsigned with")
+	replayCmd.Flags().StringVar(&replayOperation, "operation", "", "Only replay calls of an operation, e.g. RegisterTaskDefinition")
+	replayCmd.Flags().BoolVar(&replayJSON, "json", false, "Print the results as JSON lines")
+	replayCmd.MarkFlagRequired("endpoint")
+}
+
+func runReplay(cmd *cobra.Command, args []string) error {
+	interactions, err := recorder.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	if replayOperation != "" {
+		var selected []recorder.Interaction
+		for _, interaction := range interactions {
+			if strings.EqualFold(interaction.Operation, replayOperation) {
+				selected = append(selected, interaction)
+			}
+		}
+		interactions = selected
+	}
+	if len(interactions) == 0 {
+		return fmt.Errorf("no API calls to replay in %s", args[0])
+	}
+
+	opts := recorder.ReplayOptions{
+		Endpoint: replayEndpoint,
+		Client:   &http.Client{Timeout: 30 * time.Second},
+		Region:   replayRegion,
+	}
+	accessKey, secretKey := replayAccessKey, replaySecretKey
+	if accessKey == "" {
+		accessKey, secretKey = os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	}
+	if accessKey != "" && secretKey != "" {
+		opts.Credentials = &aws.Credentials{AccessKeyID: accessKey, SecretAccessKey: secretKey}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	results, err := recorder.Replay(ctx, interactions, opts)
+	if err != nil && ctx.Err() == nil {
+		return err
+	}
+
+	mismatches := printReplayResults(results)
+	if ctx.Err() != nil {
+		return fmt.Errorf("replay interrupted after %d of %d calls", len(results), len(interactions))
+	}
+	if mismatches > 0 {
+		return fmt.Errorf("%d of %d calls were answered differently than recorded", mismatches, len(results))
+	}
+	return nil
+}
+
+// printReplayResults prints every result as JSON or the calls that did not
+// match as a table, and returns the number of mismatches
+func printReplayResults(results []recorder.Result) int {
+	mismatches, skipped := 0, 0
+	encoder := json.NewEncoder(os.Stdout)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, result := range results {
+		if result.Skipped {
+			skipped++
+		}
+		if !result.Matched() {
+			mismatches++
+		}
+		if replayJSON {
+			encoder.Encode(result)
+			continue
+		}
+		if result.Matched() {
+			continue
+		}
+		if mismatches == 1 {
+			fmt.Fprintln(w, "TIME\tSERVICE\tOPERATION\tRECORDED\tREPLAYED")
+		}
+		replayed := result.Error
+		if replayed == "" {
+			replayed = formatReplayStatus(result.StatusCode, result.ErrorCode)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			result.Interaction.Time.Local().Format("2006-01-02 15:04:05"),
+			result.Interaction.Service, result.Interaction.Operation,
+			formatReplayStatus(result.Interaction.StatusCode, result.Interaction.ErrorCode), replayed)
+	}
+	w.Flush()
+
+	if !replayJSON {
+		if mismatches > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("Replayed %d calls: %d matched, %d differed, %d skipped\n",
+			len(results)-skipped, len(results)-skipped-mismatches, mismatches, skipped)
+	}
+	return mismatches
+}
+
+func formatReplayStatus(status int, errorCode string) string {
+	if errorCode == "" {
+		return fmt.Sprintf("%d", status)
+	}
+	return fmt.Sprintf("%d %s", status, errorCode)
+}
+
+// callRecordingAPI calls the recording API of the instance selected with --instance
+func callRecordingAPI(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	adminPort, err := resolveAdminPort(ctx, recordInstance)
+	if err != nil {
+		return err
+	}
+	return callAdminAPI(ctx, adminPort, method, path, body, out)
+}
+
+func printRecordingStatus(status recorder.Status) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+	fmt.Fprintf(w, "Recording:\t%t\n", status.Recording)
+	if status.Since != nil {
+		fmt.Fprintf(w, "Since:\t%s\n", status.Since.Local().Format("2006-01-02 15:04:05"))
+		fmt.Fprintf(w, "Calls:\t%d\n", status.Interactions)
+	}
+	fmt.Fprintf(w, "File:\t%s (%d bytes)\n", status.Path, status.SizeBytes)
+}
@@ -5,11 +5,13 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
@@ -17,10 +19,14 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsstub"
+	"github.com/nandemo-ya/kecs/controlplane/internal/backup"
+	"github.com/nandemo-ya/kecs/controlplane/internal/canary"
 	"github.com/nandemo-ya/kecs/controlplane/internal/config"
 	apiconfig "github.com/nandemo-ya/kecs/controlplane/internal/config"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/admin"
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api"
+	"github.com/nandemo-ya/kecs/controlplane/internal/devsync"
 	"github.com/nandemo-ya/kecs/controlplane/internal/kubernetes"
 	"github.com/nandemo-ya/kecs/controlplane/internal/localstack"
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
@@ -183,12 +189,66 @@ func runServer(cmd *cobra.Command) {
 	if err != nil {
 		log.Fatalf("Failed to initialize API server: %v", err)
 	}
+
+	// With the stub backend the control plane serves S3, SSM and Secrets
+	// Manager on the LocalStack port itself
+	var awsStubServer *http.Server
+	if localstackConfig != nil && localstackConfig.UseStub() {
+		stub, err := awsstub.New(filepath.Join(cfg.Server.DataDir, "awsstub"), cfg.AWS.DefaultRegion, cfg.AWS.AccountID)
+		if err != nil {
+			log.Fatalf("Failed to initialize AWS stub: %v", err)
+		}
+		port := localstackConfig.EdgePort
+		if port == 0 {
+			port = localstack.DefaultEdgePort
+		}
+		awsStubServer = &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: stub}
+		go func() {
+			logging.Info("Starting AWS stub", "port", port, "services", localstack.StubServices)
+			if err := awsStubServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logging.Error("AWS stub error", "error", err)
+			}
+		}()
+	}
 	adminServer := admin.NewServer(cfg.Server.AdminPort, cachedStorage)
+	if apiServer != nil {
+		adminServer.SetFaultInjector(apiServer.GetFaultInjector())
+		adminServer.SetAuditLog(apiServer.GetAuditLog())
+		adminServer.SetRecorder(apiServer.GetRecorder())
+		adminServer.SetUsageLedger(apiServer.GetUsageLedger())
+		adminServer.SetLocalStackCapabilities(apiServer.GetLocalStackCapabilityProber)
+		if reconciler := apiServer.GetServiceReconciler(); reconciler != nil {
+			adminServer.SetServiceEnqueuer(reconciler)
+		}
+		if ecsAPI := apiServer.GetECSAPI(); ecsAPI != nil {
+			adminServer.SetECSAPI(ecsAPI)
+			if deleter, ok := ecsAPI.(admin.ClusterDeleter); ok {
+				adminServer.SetClusterDeleter(deleter)
+			}
+			elbv2API, reporter := apiServer.GetELBv2API(), apiServer.GetTrafficReporter()
+			if elbv2API != nil && reporter != nil {
+				adminServer.SetTrafficShifter(canary.NewShifter(ecsAPI, elbv2API, reporter))
+			}
+		}
+	}
+
+	// Take scheduled backups when backup.schedule is configured
+	backupScheduler, err := backup.NewSchedulerFromConfig(cachedStorage)
+	if err != nil {
+		log.Fatalf("Failed to configure scheduled backups: %v", err)
+	}
+	if backupScheduler != nil {
+		backupScheduler.Start(ctx)
+		defer backupScheduler.Stop()
+	}
 
 	// Set Kubernetes client for admin server if available
 	if apiServer != nil && apiServer.GetKubeClient() != nil {
 		adminServer.SetKubeClient(apiServer.GetKubeClient())
 		logging.Info("Kubernetes client is available for admin server")
+		if restConfig, err := kubernetes.GetKubeConfig(); err == nil {
+			adminServer.SetDevSyncer(devsync.NewSyncer(devsync.NewPodExecutor(apiServer.GetKubeClient(), restConfig)))
+		}
 	} else {
 		logging.Info("Kubernetes client is not available",
 			"apiServerNil", apiServer == nil,
@@ -406,6 +466,11 @@ func runServer(cmd *cobra.Command) {
 		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
 		defer shutdownCancel()
 
+		// Let mutating API calls in flight complete before their state is persisted
+		if err := apiServer.Drain(shutdownCtx); err != nil {
+			logging.Warn("Persisting state before the API calls in flight completed", "error", err)
+		}
+
 		// Persist state before shutdown
 		if cachedStorage != nil {
 			logging.Info("Persisting state before shutdown...")
@@ -468,6 +533,13 @@ func runServer(cmd *cobra.Command) {
 				"error", err)
 		}
 
+		if awsStubServer != nil {
+			if err := awsStubServer.Shutdown(shutdownCtx); err != nil {
+				logging.Error("Error during AWS stub shutdown",
+					"error", err)
+			}
+		}
+
 		// Stop webhook server if running
 		if webhookServer != nil {
 			if err := webhookServer.Shutdown(); err != nil {
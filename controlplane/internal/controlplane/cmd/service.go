@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/canary"
+)
+
+// shiftPollInterval is how often the report of a traffic shift is polled
+const shiftPollInterval = 2 * time.Second
+
+var (
+	shiftInstance       string
+	shiftCluster        string
+	shiftService        string
+	shiftGreen          string
+	shiftTaskDefinition string
+	shiftStep           string
+	shiftBake           time.Duration
+	shiftMaxErrorRate   string
+	shiftMinRequests    int64
+	shiftGreenCount     int32
+)
+
+var serviceCmd = &cobra.Command{
+	Use:   "service",
+	Short: "Inspect and manage the traffic of ECS services",
+}
+
+var serviceShiftTrafficCmd = &cobra.Command{
+	Use:   "shift-traffic",
+	Short: "Shift traffic of a service to a green copy step by step",
+	Long: `Shift a share of the traffic of a service behind a load balancer to a green
+copy of it, as in a canary release.
+
+The green copy runs as the service <service>-green behind its own target group
+<target group>-green, both are created on the first shift. The listener
+forwarding to the target group of the service is switched to weighted
+forwarding and the green weight raised by --step up to --green. Each step
+bakes for --bake while the routing layer counts the 5xx responses of the green
+target group. When their share exceeds --max-5xx-rate, all traffic is returned
+to the service and the shift ends as ROLLED_BACK.
+
+Running the command again continues from the current weight. --green 0%
+returns all traffic to the service and removes the green copy. Ctrl-C stops
+the shift and keeps the weights of the last step.`,
+	Example: `  kecs service shift-traffic --service web --green 10% --task-definition web:2
+  kecs service shift-traffic --service web --green 100% --step 25% --bake 2m
+  kecs service shift-traffic --service web --green 0%`,
+	RunE: runServiceShiftTraffic,
+}
+
+func init() {
+	RootCmd.AddCommand(serviceCmd)
+	serviceCmd.AddCommand(serviceShiftTrafficCmd)
+
+	flags := serviceShiftTrafficCmd.Flags()
+	flags.StringVar(&shiftInstance, "instance", "", "KECS instance of the service (default: the only running instance)")
+	flags.StringVar(&shiftCluster, "cluster", "default", "Cluster of the service")
+	flags.StringVar(&shiftService, "service", "", "Service whose traffic is shifted")
+	flags.StringVar(&shiftGreen, "green", "", "Share of traffic the green copy should receive, e.g. 10%")
+	flags.StringVar(&shiftTaskDefinition, "task-definition", "", "Task definition of the green copy, required on the first shift")
+	flags.StringVar(&shiftStep, "step", "10%", "Share of traffic added per step")
+	flags.DurationVar(&shiftBake, "bake", time.Minute, "How long each step runs before its 5xx rate is checked")
+	flags.StringVar(&shiftMaxErrorRate, "max-5xx-rate", "5%", "5xx rate of the green copy that triggers a rollback")
+	flags.Int64Var(&shiftMinRequests, "min-requests", 20, "Requests a step needs before its 5xx rate is trusted")
+	flags.Int32Var(&shiftGreenCount, "green-count", 1, "Desired count of the green copy")
+	serviceShiftTrafficCmd.MarkFlagRequired("service")
+	serviceShiftTrafficCmd.MarkFlagRequired("green")
+}
+
+func runServiceShiftTraffic(cmd *cobra.Command, args []string) error {
+	green, err := parsePercent(shiftGreen)
+	if err != nil {
+		return fmt.Errorf("invalid --green: %w", err)
+	}
+	step, err := parsePercent(shiftStep)
+	if err != nil {
+		return fmt.Errorf("invalid --step: %w", err)
+	}
+	maxErrorRate, err := parseRate(shiftMaxErrorRate)
+	if err != nil {
+		return fmt.Errorf("invalid --max-5xx-rate: %w", err)
+	}
+	spec := canary.Spec{
+		Cluster:        shiftCluster,
+		Service:        shiftService,
+		TaskDefinition: shiftTaskDefinition,
+		Weight:         green,
+		Step:           step,
+		Bake:           shiftBake,
+		MaxErrorRate:   maxErrorRate,
+		MinRequests:    shiftMinRequests,
+		GreenCount:     shiftGreenCount,
+	}
+	if err := spec.Validate(); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	adminPort, err := resolveAdminPort(ctx, shiftInstance)
+	if err != nil {
+		return err
+	}
+
+	var report canary.Report
+	if err := callAdminAPI(ctx, adminPort, http.MethodPost, "/api/traffic-shifts", spec, &report); err != nil {
+		return err
+	}
+	fmt.Printf("Shifting %d%% of the traffic of %s to %s%s\n", green, shiftService, shiftService, canary.GreenSuffix)
+
+	path := "/api/traffic-shifts/" + url.PathEscape(spec.Cluster) + "/" + url.PathEscape(spec.Service)
+	ticker := time.NewTicker(shiftPollInterval)
+	defer ticker.Stop()
+	message, steps := "", 0
+	for {
+		for ; steps < len(report.Steps); steps++ {
+			fmt.Println(formatShiftStep(report.Steps[steps]))
+		}
+		if report.Message != message && report.Status == canary.StatusRunning {
+			message = report.Message
+			fmt.Println(message)
+		}
+		if report.Done() {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			// The shift is stopped with a fresh context, ctx is cancelled already
+			stopCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := callAdminAPI(stopCtx, adminPort, http.MethodDelete, path, nil, &report); err != nil {
+				return fmt.Errorf("failed to stop traffic shift: %w", err)
+			}
+			fmt.Printf("Traffic shift stopped, %d%% of the traffic stays on %s\n", report.GreenWeight, report.GreenService)
+			return nil
+		case <-ticker.C:
+		}
+		if err := callAdminAPI(ctx, adminPort, http.MethodGet, path, nil, &report); err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+			return err
+		}
+	}
+
+	switch report.Status {
+	case canary.StatusFailed:
+		return fmt.Errorf("traffic shift failed: %s", report.Message)
+	case canary.StatusRolledBack:
+		return fmt.Errorf("traffic shift rolled back: %s", report.Message)
+	}
+	fmt.Println(report.Message)
+	return nil
+}
+
+// formatShiftStep describes the outcome of a baked step
+func formatShiftStep(step canary.Step) string {
+	return fmt.Sprintf("  %3d%% green: %d requests, %d 5xx (%.1f%%)",
+		step.Weight, step.Traffic.Requests, step.Traffic.Errors, step.ErrorRate*100)
+}
+
+// parsePercent parses a whole percentage such as 10% or 10
+func parsePercent(value string) (int32, error) {
+	percent, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimSpace(value), "%"), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a percentage", value)
+	}
+	if percent < 0 || percent > 100 {
+		return 0, fmt.Errorf("%q is not between 0%% and 100%%", value)
+	}
+	return int32(percent), nil
+}
+
+// parseRate parses a percentage such as 2.5% into a fraction. Values without
+// a percent sign are fractions already.
+func parseRate(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	trimmed := strings.TrimSuffix(value, "%")
+	rate, err := strconv.ParseFloat(trimmed, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a percentage", value)
+	}
+	if trimmed != value {
+		rate /= 100
+	}
+	if rate < 0 || rate > 1 {
+		return 0, fmt.Errorf("%q is not between 0%% and 100%%", value)
+	}
+	return rate, nil
+}
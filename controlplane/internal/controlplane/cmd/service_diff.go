@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/admin"
+	"github.com/nandemo-ya/kecs/controlplane/internal/servicediff"
+)
+
+var (
+	diffInstance       string
+	diffCluster        string
+	diffService        string
+	diffTaskDefinition string
+	diffSpecFile       string
+)
+
+var serviceDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show what an update would change in a running service",
+	Long: `Compare the live state of a service with the state an update would produce,
+without changing anything.
+
+The target is a registered task definition (--task-definition family:revision,
+a family for its latest revision, or an ARN) and/or a JSON spec file (--spec).
+The spec is either a RegisterTaskDefinition request, compared as an
+unregistered revision, or a service spec with the desiredCount, taskDefinition,
+launchType, platformVersion, loadBalancers and networkConfiguration fields of
+UpdateService.
+
+The ECS level changes list the desired count, load balancers, task settings
+and, per container, the image, ports, environment variables and secrets. The
+Kubernetes changes are the fields of the Deployment and Service KECS would
+create for the target that differ from those of the current state.`,
+	Example: `  kecs service diff --service web --task-definition app:42
+  kecs service diff --service web --spec taskdef.json
+  kecs service diff --cluster prod --service web --spec service.json --task-definition app`,
+	RunE: runServiceDiff,
+}
+
+func init() {
+	serviceCmd.AddCommand(serviceDiffCmd)
+
+	flags := serviceDiffCmd.Flags()
+	flags.StringVar(&diffInstance, "instance", "", "KECS instance of the service (default: the only running instance)")
+	flags.StringVar(&diffCluster, "cluster", "default", "Cluster of the service")
+	flags.StringVar(&diffService, "service", "", "Service to compare")
+	flags.StringVar(&diffTaskDefinition, "task-definition", "", "Task definition to compare with")
+	flags.StringVar(&diffSpecFile, "spec", "", "JSON file with a task definition or service spec to compare with")
+	serviceDiffCmd.MarkFlagRequired("service")
+}
+
+func runServiceDiff(cmd *cobra.Command, args []string) error {
+	if diffTaskDefinition == "" && diffSpecFile == "" {
+		return fmt.Errorf("--task-definition or --spec is required")
+	}
+	target := servicediff.Target{TaskDefinition: diffTaskDefinition}
+	if diffSpecFile != "" {
+		data, err := os.ReadFile(diffSpecFile)
+		if err != nil {
+			return fmt.Errorf("failed to read spec: %w", err)
+		}
+		if !json.Valid(data) {
+			return fmt.Errorf("spec %s is not valid JSON", diffSpecFile)
+		}
+		target.Spec = data
+	}
+
+	ctx := context.Background()
+	adminPort, err := resolveAdminPort(ctx, diffInstance)
+	if err != nil {
+		return err
+	}
+
+	req := admin.ServiceDiffRequest{Cluster: diffCluster, Service: diffService, Target: target}
+	var report servicediff.Report
+	if err := callAdminAPI(ctx, adminPort, http.MethodPost, "/api/services/diff", req, &report); err != nil {
+		return err
+	}
+	fmt.Print(formatServiceDiff(&report))
+	return nil
+}
+
+// formatServiceDiff renders a diff report grouped by section
+func formatServiceDiff(report *servicediff.Report) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Service %s (cluster %s): %s -> %s\n", report.Service, report.Cluster,
+		report.CurrentTaskDefinition, report.TargetTaskDefinition)
+	if len(report.Changes) == 0 && len(report.KubernetesChanges) == 0 {
+		b.WriteString("No changes\n")
+		return b.String()
+	}
+
+	writeChanges := func(title string, changes []servicediff.Change) {
+		if len(changes) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n%s:\n", title)
+		section := ""
+		for _, change := range changes {
+			if change.Section != section {
+				section = change.Section
+				fmt.Fprintf(&b, "  %s\n", section)
+			}
+			fmt.Fprintf(&b, "    %s: %s -> %s\n", change.Field, diffValue(change.Current), diffValue(change.Target))
+		}
+	}
+	writeChanges("ECS changes", report.Changes)
+	writeChanges("Kubernetes changes", report.KubernetesChanges)
+	return b.String()
+}
+
+// diffValue renders a value of a change, unset values as <none>
+func diffValue(value string) string {
+	if value == "" {
+		return "<none>"
+	}
+	return value
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/canary"
+	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/elbv2"
+	"github.com/nandemo-ya/kecs/controlplane/internal/servicediff"
+)
+
+var _ = Describe("service shift-traffic", func() {
+	DescribeTable("parses percentages",
+		func(value string, percent int32) {
+			Expect(parsePercent(value)).To(Equal(percent))
+		},
+		Entry("with percent sign", "10%", int32(10)),
+		Entry("without percent sign", "25", int32(25)),
+		Entry("zero", "0%", int32(0)),
+	)
+
+	It("rejects invalid percentages", func() {
+		_, err := parsePercent("120%")
+		Expect(err).To(HaveOccurred())
+		_, err = parsePercent("ten")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("parses rates as fractions", func() {
+		Expect(parseRate("2.5%")).To(Equal(0.025))
+		Expect(parseRate("0.1")).To(Equal(0.1))
+		_, err := parseRate("150%")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("formats baked steps", func() {
+		step := canary.Step{Weight: 20, Traffic: elbv2.TargetGroupTraffic{Requests: 200, Errors: 3}, ErrorRate: 0.015}
+		Expect(formatShiftStep(step)).To(Equal("   20% green: 200 requests, 3 5xx (1.5%)"))
+	})
+})
+
+var _ = Describe("service diff", func() {
+	It("groups changes by section", func() {
+		report := &servicediff.Report{
+			Cluster:               "default",
+			Service:               "web",
+			CurrentTaskDefinition: "app:41",
+			TargetTaskDefinition:  "app:42",
+			Changes: []servicediff.Change{
+				{Section: "service", Field: "desiredCount", Current: "2", Target: "3"},
+				{Section: "container app", Field: "image", Current: "nginx:1.25", Target: "nginx:1.27"},
+				{Section: "container app", Field: "environment.FEATURE", Target: "on"},
+			},
+			KubernetesChanges: []servicediff.Change{
+				{Section: "Deployment web", Field: "spec.replicas", Current: "2", Target: "3"},
+			},
+		}
+		Expect(formatServiceDiff(report)).To(Equal(`Service web (cluster default): app:41 -> app:42
+
+ECS changes:
+  service
+    desiredCount: 2 -> 3
+  container app
+    image: nginx:1.25 -> nginx:1.27
+    environment.FEATURE: <none> -> on
+
+Kubernetes changes:
+  Deployment web
+    spec.replicas: 2 -> 3
+`))
+	})
+
+	It("reports an unchanged service", func() {
+		report := &servicediff.Report{Cluster: "default", Service: "web", CurrentTaskDefinition: "app:1", TargetTaskDefinition: "app:1"}
+		Expect(formatServiceDiff(report)).To(Equal("Service web (cluster default): app:1 -> app:1\nNo changes\n"))
+	})
+})
@@ -5,12 +5,17 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/compat"
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
 	"github.com/nandemo-ya/kecs/controlplane/internal/host/instance"
 	"github.com/nandemo-ya/kecs/controlplane/internal/host/k3d"
+	"github.com/nandemo-ya/kecs/controlplane/internal/localstack"
 	"github.com/nandemo-ya/kecs/controlplane/internal/utils"
 )
 
@@ -28,6 +33,8 @@ const (
 const (
 	msgInstanceAlreadyRunning = "⚠️  Instance '%s' is already running\n"
 	msgRestartingInstance     = "Restarting stopped instance: %s\n"
+	msgResumingInstance       = "Resuming standby instance: %s\n"
+	msgInstanceResumed        = "\nResumed KECS instance '%s' in %s\n"
 	msgCreatingInstance       = "\n=== Creating KECS instance '%s' ===\n"
 	msgInstanceReady          = "\n🎉 KECS instance '%s' is ready!\n"
 	msgFetchingInstances      = "Fetching KECS instances..."
@@ -46,6 +53,20 @@ var (
 	startAdditionalLocalServices string
 	startTimeout                 time.Duration
 	startTestMode                bool
+	startResume                  bool
+	startAccountID               string
+	startPartition               string
+	startReadOnly                bool
+	startStrictCompat            string
+	startImagePullPolicy         string
+	startPinImageDigests         bool
+	startRegistryConfig          string
+	startRegistryMirrors         []string
+	startInsecureRegistries      []string
+	startOffline                 bool
+	startBundle                  string
+	startAWSBackend              string
+	startNodePools               []string
 )
 
 var startCmd = &cobra.Command{
@@ -67,9 +88,59 @@ func init() {
 	startCmd.Flags().StringVar(&startAdditionalLocalServices, "additional-localstack-services", "", "Additional LocalStack services (comma-separated, e.g., s3,dynamodb,sqs)")
 	startCmd.Flags().DurationVar(&startTimeout, "timeout", 10*time.Minute, "Timeout for cluster creation")
 	startCmd.Flags().BoolVar(&startTestMode, "test-mode", false, "Enable test mode (uses mock cluster instead of real k3d cluster)")
+	startCmd.Flags().StringVar(&startAccountID, "account-id", "", "Account ID of the ARNs of a new instance (default: 000000000000)")
+	startCmd.Flags().StringVar(&startPartition, "partition", "", "Partition of the ARNs of a new instance: aws, aws-cn or aws-us-gov (default: aws)")
+	startCmd.Flags().BoolVar(&startReadOnly, "read-only", false, "Reject mutating ECS and ELBv2 calls, e.g. for shared demo instances")
+	startCmd.Flags().StringVar(&startStrictCompat, "strict-compat", "", "Validate responses against the AWS API models and log (log) or fail (fail) on mismatches, e.g. in CI")
+	startCmd.Flags().StringVar(&startImagePullPolicy, "image-pull-policy", "", "Pull policy of task containers: Always, IfNotPresent or Never (default: Kubernetes default)")
+	startCmd.Flags().BoolVar(&startPinImageDigests, "pin-image-digests", false, "Pin image tags to their digests when task definitions are registered")
+	startCmd.Flags().StringVar(&startRegistryConfig, "registry-config", "", "YAML file of registry mirrors, insecure registries and credentials of a new instance")
+	startCmd.Flags().StringArrayVar(&startRegistryMirrors, "registry-mirror", nil, "Mirror of a registry of a new instance as REGISTRY=ENDPOINT[,ENDPOINT...], e.g. docker.io=https://mirror.example.com")
+	startCmd.Flags().StringArrayVar(&startInsecureRegistries, "insecure-registry", nil, "Registry of a new instance served over HTTP or with an untrusted certificate")
+	startCmd.Flags().BoolVar(&startOffline, "offline", false, "Create an instance that runs without internet access from the images of --bundle")
+	startCmd.Flags().StringVar(&startBundle, "bundle", "", "Image bundle of an offline instance, written by 'kecs bundle create'")
+	startCmd.Flags().StringVar(&startAWSBackend, "aws-backend", "", "Backend of the AWS services of a new instance: localstack, or stub to serve S3, SSM and Secrets Manager from the control plane (default: localstack.backend of the configuration)")
+	startCmd.Flags().StringArrayVar(&startNodePools, "node-pool", nil, "Node pool of a new instance simulating a CPU architecture as ARCH[=COUNT], e.g. arm64=2")
+	startCmd.Flags().BoolVar(&startResume, "resume", false, "Resume an instance stopped with 'kecs stop --keep-data' without redeploying its components")
 }
 
 func runStart(cmd *cobra.Command, args []string) error {
+	if startResume && startInstanceName == "" {
+		return fmt.Errorf("--resume requires --instance")
+	}
+	if startResume && startReadOnly {
+		return fmt.Errorf("--read-only cannot be combined with --resume, which keeps the deployed control plane")
+	}
+	if _, err := compat.ParseMode(startStrictCompat); err != nil {
+		return err
+	}
+	if startResume && startStrictCompat != "" {
+		return fmt.Errorf("--strict-compat cannot be combined with --resume, which keeps the deployed control plane")
+	}
+	if startImagePullPolicy != "" {
+		if _, ok := converters.ParseImagePullPolicy(startImagePullPolicy); !ok {
+			return fmt.Errorf("invalid --image-pull-policy %q: must be Always, IfNotPresent or Never", startImagePullPolicy)
+		}
+	}
+	if startResume && (startImagePullPolicy != "" || startPinImageDigests) {
+		return fmt.Errorf("--image-pull-policy and --pin-image-digests cannot be combined with --resume, which keeps the deployed control plane")
+	}
+	if startBundle != "" && !startOffline {
+		return fmt.Errorf("--bundle requires --offline")
+	}
+	if startAWSBackend != "" && startAWSBackend != localstack.BackendLocalStack && startAWSBackend != localstack.BackendStub {
+		return fmt.Errorf("invalid --aws-backend %q: must be %s or %s", startAWSBackend, localstack.BackendLocalStack, localstack.BackendStub)
+	}
+	registries, err := buildRegistryConfig(startRegistryConfig, startRegistryMirrors, startInsecureRegistries)
+	if err != nil {
+		return err
+	}
+	nodePools, err := parseNodePools(startNodePools)
+	if err != nil {
+		return err
+	}
+	started := time.Now()
+
 	// Create k3d cluster manager to check existing instances
 	manager, err := k3d.NewK3dClusterManager(nil)
 	if err != nil {
@@ -105,6 +176,18 @@ func runStart(cmd *cobra.Command, args []string) error {
 		ApiPort:                      startApiPort,
 		AdminPort:                    startAdminPort,
 		TestMode:                     startTestMode,
+		Resume:                       startResume,
+		AccountID:                    startAccountID,
+		Partition:                    startPartition,
+		ReadOnly:                     startReadOnly,
+		StrictCompat:                 startStrictCompat,
+		ImagePullPolicy:              startImagePullPolicy,
+		PinImageDigests:              startPinImageDigests,
+		Registries:                   registries,
+		Offline:                      startOffline,
+		Bundle:                       startBundle,
+		AWSBackend:                   startAWSBackend,
+		NodePools:                    nodePools,
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), startTimeout)
@@ -116,11 +199,64 @@ func runStart(cmd *cobra.Command, args []string) error {
 	}
 
 	// Show completion message
+	if startResume {
+		fmt.Printf(msgInstanceResumed, opts.InstanceName, time.Since(started).Round(100*time.Millisecond))
+	}
 	showStartCompletionMessage(opts)
 
 	return nil
 }
 
+// buildRegistryConfig merges the registry config file with the mirrors and
+// insecure registries given as flags. It returns nil when nothing is set.
+func buildRegistryConfig(file string, mirrors, insecure []string) (*k3d.RegistryConfig, error) {
+	registries := &k3d.RegistryConfig{}
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read registry config: %w", err)
+		}
+		if err := yaml.Unmarshal(data, registries); err != nil {
+			return nil, fmt.Errorf("failed to parse registry config %s: %w", file, err)
+		}
+	}
+	for _, mirror := range mirrors {
+		registry, endpoints, ok := strings.Cut(mirror, "=")
+		if !ok || registry == "" || endpoints == "" {
+			return nil, fmt.Errorf("invalid --registry-mirror %q: must be REGISTRY=ENDPOINT[,ENDPOINT...]", mirror)
+		}
+		if registries.Mirrors == nil {
+			registries.Mirrors = make(map[string][]string)
+		}
+		registries.Mirrors[registry] = strings.Split(endpoints, ",")
+	}
+	registries.Insecure = append(registries.Insecure, insecure...)
+
+	if registries.IsEmpty() {
+		return nil, nil
+	}
+	if err := registries.Validate(); err != nil {
+		return nil, err
+	}
+	return registries, nil
+}
+
+// parseNodePools parses the node pools given as flags
+func parseNodePools(specs []string) ([]k3d.NodePool, error) {
+	var pools []k3d.NodePool
+	for _, spec := range specs {
+		pool, err := k3d.ParseNodePool(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --node-pool %q: %w", spec, err)
+		}
+		pools = append(pools, pool)
+	}
+	if err := k3d.ValidateNodePools(pools); err != nil {
+		return nil, err
+	}
+	return pools, nil
+}
+
 // determineInstanceToStart handles instance selection and status checking
 // Returns: (instanceName, shouldStart, error)
 func determineInstanceToStart(manager *k3d.K3dClusterManager) (string, bool, error) {
@@ -156,7 +292,11 @@ func determineInstanceToStart(manager *k3d.K3dClusterManager) (string, bool, err
 			return instanceName, false, nil
 		}
 		// For stopped instances, we'll restart them
-		fmt.Printf(msgRestartingInstance, instanceName)
+		if startResume {
+			fmt.Printf(msgResumingInstance, instanceName)
+		} else {
+			fmt.Printf(msgRestartingInstance, instanceName)
+		}
 	}
 
 	return instanceName, true, nil
@@ -4,13 +4,16 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/host/k3d"
 )
 
 var _ = Describe("Start Command Unit Tests", func() {
@@ -178,3 +181,57 @@ var _ = Describe("Start V2 Command Integration Tests", func() {
 		})
 	})
 })
+
+var _ = Describe("buildRegistryConfig", func() {
+	It("returns nil without registry settings", func() {
+		registries, err := buildRegistryConfig("", nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(registries).To(BeNil())
+	})
+
+	It("merges the config file with the flags", func() {
+		file := filepath.Join(GinkgoT().TempDir(), "registries.yaml")
+		Expect(os.WriteFile(file, []byte(`mirrors:
+  docker.io:
+    - https://mirror.example.com
+auths:
+  mirror.example.com:
+    username: ci
+    password: secret
+`), 0600)).To(Succeed())
+
+		registries, err := buildRegistryConfig(file,
+			[]string{"ghcr.io=https://ghcr-mirror.example.com,https://ghcr-backup.example.com"},
+			[]string{"legacy.example.com:5000"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(registries.Mirrors).To(HaveKeyWithValue("docker.io", []string{"https://mirror.example.com"}))
+		Expect(registries.Mirrors).To(HaveKeyWithValue("ghcr.io", []string{"https://ghcr-mirror.example.com", "https://ghcr-backup.example.com"}))
+		Expect(registries.Insecure).To(Equal([]string{"legacy.example.com:5000"}))
+		Expect(registries.Auths["mirror.example.com"].Password).To(Equal("secret"))
+	})
+
+	It("rejects malformed mirrors", func() {
+		_, err := buildRegistryConfig("", []string{"docker.io"}, nil)
+		Expect(err).To(MatchError(ContainSubstring("REGISTRY=ENDPOINT")))
+		_, err = buildRegistryConfig("", []string{"docker.io=mirror.example.com"}, nil)
+		Expect(err).To(MatchError(ContainSubstring("http or https URL")))
+	})
+})
+
+var _ = Describe("parseNodePools", func() {
+	It("parses the node pools", func() {
+		pools, err := parseNodePools([]string{"arm64=2", "amd64"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pools).To(Equal([]k3d.NodePool{
+			{Architecture: "arm64", Count: 2},
+			{Architecture: "amd64", Count: 1},
+		}))
+	})
+
+	It("rejects invalid node pools", func() {
+		_, err := parseNodePools([]string{"arm64=x"})
+		Expect(err).To(MatchError(ContainSubstring(`invalid --node-pool "arm64=x"`)))
+		_, err = parseNodePools([]string{"s390x"})
+		Expect(err).To(MatchError(ContainSubstring("must be amd64 or arm64")))
+	})
+})
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/startupmetrics"
+)
+
+var (
+	startupReportInstance string
+	startupReportCluster  string
+	startupReportService  string
+	startupReportSince    time.Duration
+	startupReportJSON     bool
+)
+
+var startupReportCmd = &cobra.Command{
+	Use:   "startup-report",
+	Short: "Summarize task startup time per service and per image",
+	Long: `Report how long tasks take from creation until all their containers run,
+and how much of that is spent pulling images, as P50/P95 per service and per
+image. Use it to measure the effect of smaller images and faster probes.`,
+	Example: `  kecs startup-report
+  kecs startup-report --cluster default --since 1h
+  kecs startup-report --service web --json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		adminPort, err := resolveAdminPort(ctx, startupReportInstance)
+		if err != nil {
+			return err
+		}
+
+		params := url.Values{}
+		if startupReportCluster != "" {
+			params.Set("cluster", startupReportCluster)
+		}
+		if startupReportService != "" {
+			params.Set("service", startupReportService)
+		}
+		if startupReportSince > 0 {
+			params.Set("since", time.Now().Add(-startupReportSince).UTC().Format(time.RFC3339))
+		}
+
+		report, err := fetchStartupReport(ctx, adminPort, params)
+		if err != nil {
+			return err
+		}
+		if startupReportJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(report)
+		}
+		printStartupReport(report)
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(startupReportCmd)
+
+	startupReportCmd.Flags().StringVar(&startupReportInstance, "instance", "", "KECS instance to target (default: the only running instance)")
+	startupReportCmd.Flags().StringVar(&startupReportCluster, "cluster", "", "Only include tasks of a cluster")
+	startupReportCmd.Flags().StringVar(&startupReportService, "service", "", "Only include tasks of a service")
+	startupReportCmd.Flags().DurationVar(&startupReportSince, "since", 0, "Only include tasks created within a duration, e.g. 1h")
+	startupReportCmd.Flags().BoolVar(&startupReportJSON, "json", false, "Print the report as JSON")
+}
+
+// fetchStartupReport queries the startup metrics API of an instance
+func fetchStartupReport(ctx context.Context, adminPort int, params url.Values) (*startupmetrics.Report, error) {
+	endpoint := fmt.Sprintf("http://localhost:%d/api/metrics/startup?%s", adminPort, params.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to KECS admin API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err == nil && apiErr.Message != "" {
+			return nil, fmt.Errorf("%s", apiErr.Message)
+		}
+		return nil, fmt.Errorf("request failed with status %d", resp.StatusCode)
+	}
+
+	var report startupmetrics.Report
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &report, nil
+}
+
+func printStartupReport(report *startupmetrics.Report) {
+	if report.Tasks == 0 {
+		fmt.Println("No started tasks found")
+		return
+	}
+	fmt.Printf("%d started tasks\n\n", report.Tasks)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	printGroups := func(title string, groups []startupmetrics.Group) {
+		if len(groups) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "%s\tTASKS\tSTARTUP P50\tSTARTUP P95\tSTARTUP MAX\tPULL P50\tPULL P95\n", title)
+		for _, g := range groups {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\t%s\n", g.Name, g.Startup.Count,
+				formatMs(g.Startup.P50Ms), formatMs(g.Startup.P95Ms), formatMs(g.Startup.MaxMs),
+				formatMs(g.Pull.P50Ms), formatMs(g.Pull.P95Ms))
+		}
+		fmt.Fprintln(w)
+	}
+	printGroups("SERVICE", report.Services)
+	printGroups("IMAGE", report.Images)
+	w.Flush()
+}
+
+// formatMs formats milliseconds with a precision that suits startup times
+func formatMs(ms int64) string {
+	if ms == 0 {
+		return "-"
+	}
+	d := time.Duration(ms) * time.Millisecond
+	if d < time.Second {
+		return d.String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
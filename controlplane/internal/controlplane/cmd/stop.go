@@ -12,19 +12,26 @@ import (
 
 var (
 	stopInstanceName string
+	stopKeepData     bool
 )
 
 var stopCmd = &cobra.Command{
 	Use:   "stop",
 	Short: "Stop KECS instance",
-	Long:  `Stop the KECS instance by stopping its k3d cluster. The instance can be restarted later with the start command.`,
-	RunE:  runStop,
+	Long: `Stop the KECS instance by stopping its k3d cluster. The instance can be restarted later with the start command.
+
+With --keep-data the instance is kept as a warm standby: the control plane,
+LocalStack and their cached images stay in the k3d cluster, and
+'kecs start --resume' brings the instance back in seconds instead of
+redeploying them.`,
+	RunE: runStop,
 }
 
 func init() {
 	RootCmd.AddCommand(stopCmd)
 
 	stopCmd.Flags().StringVar(&stopInstanceName, "instance", "", "KECS instance name to stop (required)")
+	stopCmd.Flags().BoolVar(&stopKeepData, "keep-data", false, "Keep the instance as a warm standby for 'kecs start --resume'")
 }
 
 func runStop(cmd *cobra.Command, args []string) error {
@@ -68,7 +75,7 @@ func runStop(cmd *cobra.Command, args []string) error {
 
 	// Stop the instance
 	fmt.Println("Stopping k3d cluster...")
-	if err := manager.Stop(ctx, stopInstanceName); err != nil {
+	if err := manager.StopWithOptions(ctx, stopInstanceName, instance.StopOptions{KeepData: stopKeepData}); err != nil {
 		if err.Error() == fmt.Sprintf("instance '%s' does not exist", stopInstanceName) {
 			fmt.Printf("KECS instance '%s' does not exist\n", stopInstanceName)
 			return nil
@@ -81,7 +88,11 @@ func runStop(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Printf("✅ KECS instance '%s' has been stopped\n", stopInstanceName)
-	fmt.Println("Instance data preserved. Use 'kecs start' to restart the instance.")
+	if stopKeepData {
+		fmt.Printf("Instance kept as a warm standby. Use 'kecs start --instance %s --resume' to resume it.\n", stopInstanceName)
+	} else {
+		fmt.Println("Instance data preserved. Use 'kecs start' to restart the instance.")
+	}
 
 	return nil
 }
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/host/instance"
+)
+
+var (
+	upgradeImage      string
+	upgradeTimeout    time.Duration
+	upgradeNoRollback bool
+)
+
+var upgradeInstanceCmd = &cobra.Command{
+	Use:   "upgrade-instance <name>",
+	Short: "Upgrade the control plane of a running KECS instance",
+	Long: `Replace the control plane of a running instance with a newer image instead of
+recreating the instance. The new control plane migrates the storage schema
+when it starts and the upgrade waits until it is ready and its admin API is
+healthy. ECS tasks keep running while the control plane restarts; the ECS
+API is unavailable for the few seconds that takes.
+
+The image defaults to the control plane image of this CLI version. A failed
+upgrade is rolled back to the previous image unless --no-rollback is set.`,
+	Example: `  kecs upgrade-instance dev
+  kecs upgrade-instance dev --image ghcr.io/nandemo-ya/kecs:v0.9.0`,
+	Args: cobra.ExactArgs(1),
+	RunE: runUpgradeInstance,
+}
+
+func init() {
+	RootCmd.AddCommand(upgradeInstanceCmd)
+
+	upgradeInstanceCmd.Flags().StringVar(&upgradeImage, "image", "", "Control plane image to upgrade to (default: the image of this CLI version)")
+	upgradeInstanceCmd.Flags().DurationVar(&upgradeTimeout, "timeout", 5*time.Minute, "Timeout for the new control plane to become healthy")
+	upgradeInstanceCmd.Flags().BoolVar(&upgradeNoRollback, "no-rollback", false, "Keep a failed upgrade in place instead of rolling back")
+}
+
+func runUpgradeInstance(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	instanceName := args[0]
+
+	image := upgradeImage
+	if image == "" {
+		cfg, err := config.LoadConfig("")
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		image = cfg.Server.ControlPlaneImage
+	}
+
+	manager, err := instance.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create instance manager: %w", err)
+	}
+
+	fmt.Printf("Upgrading KECS instance '%s'\n", instanceName)
+	started := time.Now()
+	result, err := manager.UpgradeControlPlane(ctx, instanceName, instance.UpgradeOptions{
+		Image:      image,
+		Timeout:    upgradeTimeout,
+		NoRollback: upgradeNoRollback,
+	}, func(message string) {
+		fmt.Println(message + "...")
+	})
+	if err != nil {
+		return err
+	}
+
+	if !result.Upgraded {
+		fmt.Printf("✅ KECS instance '%s' already runs %s\n", instanceName, result.Image)
+		return nil
+	}
+	fmt.Printf("✅ KECS instance '%s' upgraded from %s to %s in %s\n", instanceName,
+		result.PreviousImage, result.Image, time.Since(started).Round(time.Second))
+	return nil
+}
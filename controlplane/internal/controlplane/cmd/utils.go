@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"strings"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/client"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/host/instance"
 )
 
 // isPortAvailable checks if a port is available on the host
@@ -118,3 +121,37 @@ func findFirstColon(s string) int {
 	}
 	return -1
 }
+
+// resolveAdminPort returns the admin port of the named instance, or of the only
+// running instance when no name is given
+func resolveAdminPort(ctx context.Context, instanceName string) (int, error) {
+	manager, err := instance.NewManager()
+	if err != nil {
+		return 0, fmt.Errorf("failed to create instance manager: %w", err)
+	}
+	instances, err := manager.List(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list instances: %w", err)
+	}
+
+	var running []instance.InstanceInfo
+	for _, inst := range instances {
+		if strings.ToLower(inst.Status) != "running" {
+			continue
+		}
+		if instanceName == "" || inst.Name == instanceName {
+			running = append(running, inst)
+		}
+	}
+
+	switch {
+	case len(running) == 1:
+		return running[0].AdminPort, nil
+	case instanceName != "":
+		return 0, fmt.Errorf("instance %q is not running", instanceName)
+	case len(running) == 0:
+		return 0, fmt.Errorf("no running KECS instances found")
+	default:
+		return 0, fmt.Errorf("multiple KECS instances are running, select one with --instance")
+	}
+}
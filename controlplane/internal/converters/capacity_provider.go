@@ -0,0 +1,68 @@
+package converters
+
+import (
+	"encoding/json"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+)
+
+// ParseCapacityProviderStrategy decodes a capacity provider strategy stored as JSON
+func ParseCapacityProviderStrategy(strategyJSON string) []generated.CapacityProviderStrategyItem {
+	if strategyJSON == "" || strategyJSON == "null" {
+		return nil
+	}
+	var strategy []generated.CapacityProviderStrategyItem
+	if err := json.Unmarshal([]byte(strategyJSON), &strategy); err != nil {
+		return nil
+	}
+	return strategy
+}
+
+// LaunchTypeForCapacityProvider returns the launch type tasks placed by a
+// capacity provider run with. Fargate providers run FARGATE tasks, everything
+// else is backed by container instances.
+func LaunchTypeForCapacityProvider(name string) string {
+	switch name {
+	case "FARGATE", "FARGATE_SPOT":
+		return string(generated.LaunchTypeFARGATE)
+	}
+	return string(generated.LaunchTypeEC2)
+}
+
+// CapacityProviderForTask picks the capacity provider of the next task given
+// the number of tasks already placed per provider. As in ECS, the base of each
+// provider is satisfied first, in strategy order, and the remaining tasks are
+// split according to the weights.
+func CapacityProviderForTask(strategy []generated.CapacityProviderStrategyItem, placed map[string]int) string {
+	if len(strategy) == 0 {
+		return ""
+	}
+	for _, item := range strategy {
+		if item.Base != nil && placed[item.CapacityProvider] < int(*item.Base) {
+			return item.CapacityProvider
+		}
+	}
+
+	// Pick the provider furthest behind its share of the tasks above base
+	best := ""
+	var bestShare float64
+	for _, item := range strategy {
+		if item.Weight == nil || *item.Weight <= 0 {
+			continue
+		}
+		aboveBase := placed[item.CapacityProvider]
+		if item.Base != nil {
+			aboveBase -= int(*item.Base)
+		}
+		share := float64(aboveBase) / float64(*item.Weight)
+		if best == "" || share < bestShare {
+			best, bestShare = item.CapacityProvider, share
+		}
+	}
+	if best == "" {
+		// Only providers with a base and no weight, they take no further tasks
+		// in ECS. Keep placing on the first one rather than failing.
+		return strategy[0].CapacityProvider
+	}
+	return best
+}
@@ -0,0 +1,39 @@
+package converters_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+)
+
+var _ = Describe("CapacityProviderForTask", func() {
+	place := func(strategy []generated.CapacityProviderStrategyItem, count int) map[string]int {
+		placed := map[string]int{}
+		for i := 0; i < count; i++ {
+			placed[converters.CapacityProviderForTask(strategy, placed)]++
+		}
+		return placed
+	}
+
+	It("satisfies the base before splitting by weight", func() {
+		strategy := []generated.CapacityProviderStrategyItem{
+			{CapacityProvider: "FARGATE", Base: ptr.Int32(2), Weight: ptr.Int32(1)},
+			{CapacityProvider: "FARGATE_SPOT", Weight: ptr.Int32(4)},
+		}
+		Expect(place(strategy, 2)).To(Equal(map[string]int{"FARGATE": 2}))
+		Expect(place(strategy, 12)).To(Equal(map[string]int{"FARGATE": 4, "FARGATE_SPOT": 8}))
+	})
+
+	It("places on the first provider when no provider has a weight", func() {
+		strategy := []generated.CapacityProviderStrategyItem{{CapacityProvider: "ec2-spot"}}
+		Expect(place(strategy, 3)).To(Equal(map[string]int{"ec2-spot": 3}))
+	})
+
+	It("maps providers to launch types", func() {
+		Expect(converters.LaunchTypeForCapacityProvider("FARGATE_SPOT")).To(Equal("FARGATE"))
+		Expect(converters.LaunchTypeForCapacityProvider("my-asg-provider")).To(Equal("EC2"))
+	})
+})
@@ -0,0 +1,124 @@
+package converters
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/types"
+)
+
+// CPUArchitectureNodeLabel is the CPU architecture of a node of a simulated
+// node pool. The kubelet keeps kubernetes.io/arch at the architecture of the
+// host, so nodes of a pool with another architecture carry this label.
+const CPUArchitectureNodeLabel = "kecs.dev/cpu-architecture"
+
+// cpuArchitectures maps the cpuArchitecture of a runtime platform to the
+// Kubernetes architecture
+var cpuArchitectures = map[string]string{
+	"X86_64": "amd64",
+	"ARM64":  "arm64",
+}
+
+// KubernetesArchitecture returns the Kubernetes architecture of the
+// cpuArchitecture of a runtime platform
+func KubernetesArchitecture(cpuArchitecture string) (string, error) {
+	arch, ok := cpuArchitectures[strings.ToUpper(cpuArchitecture)]
+	if !ok {
+		return "", fmt.Errorf("unsupported cpuArchitecture %q, must be X86_64 or ARM64", cpuArchitecture)
+	}
+	return arch, nil
+}
+
+// PlatformArchitecture returns the cpuArchitecture of a runtime platform for
+// a Kubernetes architecture
+func PlatformArchitecture(arch string) string {
+	for cpuArchitecture, k8sArch := range cpuArchitectures {
+		if k8sArch == arch {
+			return cpuArchitecture
+		}
+	}
+	return strings.ToUpper(arch)
+}
+
+// TaskArchitecture returns the Kubernetes architecture the tasks of a task
+// definition require, empty when its runtime platform does not set one
+func TaskArchitecture(taskDef *storage.TaskDefinition) (string, error) {
+	if taskDef.RuntimePlatform == "" {
+		return "", nil
+	}
+	var platform types.RuntimePlatform
+	if err := json.Unmarshal([]byte(taskDef.RuntimePlatform), &platform); err != nil {
+		return "", fmt.Errorf("failed to parse runtime platform: %w", err)
+	}
+	if platform.CpuArchitecture == nil || *platform.CpuArchitecture == "" {
+		return "", nil
+	}
+	return KubernetesArchitecture(*platform.CpuArchitecture)
+}
+
+// NodeArchitecture returns the CPU architecture of a node, the one of its
+// node pool when it belongs to one
+func NodeArchitecture(node *corev1.Node) string {
+	if arch := node.Labels[CPUArchitectureNodeLabel]; arch != "" {
+		return arch
+	}
+	return node.Labels[corev1.LabelArchStable]
+}
+
+// applyCPUArchitecture requires the nodes a pod is scheduled on to have the
+// CPU architecture of the runtime platform of its task definition. Nodes of
+// a node pool match on CPUArchitectureNodeLabel, the others on
+// kubernetes.io/arch. Node selector terms are ORed, so each existing term is
+// split into one per kind of node.
+func applyCPUArchitecture(spec *corev1.PodSpec, taskDef *storage.TaskDefinition) error {
+	arch, err := TaskArchitecture(taskDef)
+	if err != nil || arch == "" {
+		return err
+	}
+
+	pooled := corev1.NodeSelectorRequirement{
+		Key:      CPUArchitectureNodeLabel,
+		Operator: corev1.NodeSelectorOpIn,
+		Values:   []string{arch},
+	}
+	unpooled := []corev1.NodeSelectorRequirement{
+		{Key: CPUArchitectureNodeLabel, Operator: corev1.NodeSelectorOpDoesNotExist},
+		{Key: corev1.LabelArchStable, Operator: corev1.NodeSelectorOpIn, Values: []string{arch}},
+	}
+
+	if spec.Affinity == nil {
+		spec.Affinity = &corev1.Affinity{}
+	}
+	if spec.Affinity.NodeAffinity == nil {
+		spec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
+	}
+	required := spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if required == nil {
+		required = &corev1.NodeSelector{}
+		spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = required
+	}
+	terms := required.NodeSelectorTerms
+	if len(terms) == 0 {
+		terms = []corev1.NodeSelectorTerm{{}}
+	}
+
+	var split []corev1.NodeSelectorTerm
+	for _, term := range terms {
+		split = append(split,
+			corev1.NodeSelectorTerm{
+				MatchExpressions: append(append([]corev1.NodeSelectorRequirement{}, term.MatchExpressions...), pooled),
+				MatchFields:      term.MatchFields,
+			},
+			corev1.NodeSelectorTerm{
+				MatchExpressions: append(append([]corev1.NodeSelectorRequirement{}, term.MatchExpressions...), unpooled...),
+				MatchFields:      term.MatchFields,
+			},
+		)
+	}
+	required.NodeSelectorTerms = split
+	return nil
+}
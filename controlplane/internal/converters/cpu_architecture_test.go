@@ -0,0 +1,103 @@
+package converters
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/types"
+)
+
+var _ = Describe("CPU architecture", func() {
+	var taskDef *storage.TaskDefinition
+
+	BeforeEach(func() {
+		containerDefs, _ := json.Marshal([]types.ContainerDefinition{
+			{Name: ptr.To("app"), Image: ptr.To("nginx:latest"), Essential: ptr.To(true)},
+		})
+		taskDef = &storage.TaskDefinition{
+			Family:               "arm-task",
+			Revision:             1,
+			ARN:                  "arn:aws:ecs:us-east-1:123456789012:task-definition/arm-task:1",
+			ContainerDefinitions: string(containerDefs),
+			RuntimePlatform:      `{"cpuArchitecture":"ARM64","operatingSystemFamily":"LINUX"}`,
+			Status:               "ACTIVE",
+		}
+	})
+
+	Describe("TaskArchitecture", func() {
+		It("returns the Kubernetes architecture of the runtime platform", func() {
+			Expect(TaskArchitecture(taskDef)).To(Equal("arm64"))
+		})
+
+		It("returns nothing without cpuArchitecture", func() {
+			taskDef.RuntimePlatform = `{"operatingSystemFamily":"LINUX"}`
+			Expect(TaskArchitecture(taskDef)).To(BeEmpty())
+		})
+
+		It("rejects unsupported architectures", func() {
+			taskDef.RuntimePlatform = `{"cpuArchitecture":"S390X"}`
+			_, err := TaskArchitecture(taskDef)
+			Expect(err).To(MatchError(ContainSubstring("must be X86_64 or ARM64")))
+		})
+	})
+
+	Describe("NodeArchitecture", func() {
+		It("prefers the architecture of the node pool", func() {
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+				corev1.LabelArchStable:   "amd64",
+				CPUArchitectureNodeLabel: "arm64",
+			}}}
+			Expect(NodeArchitecture(node)).To(Equal("arm64"))
+
+			delete(node.Labels, CPUArchitectureNodeLabel)
+			Expect(NodeArchitecture(node)).To(Equal("amd64"))
+		})
+	})
+
+	It("requires nodes of the architecture for tasks", func() {
+		converter := NewTaskConverter("us-east-1", "123456789012")
+		pod, err := converter.ConvertTaskToPod(taskDef, []byte(`{}`), &storage.Cluster{Name: "default", Region: "us-east-1"}, "task-id")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(pod.Spec.Affinity).NotTo(BeNil())
+		terms := pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+		Expect(terms).To(HaveLen(2))
+		Expect(terms[0].MatchExpressions).To(ConsistOf(corev1.NodeSelectorRequirement{
+			Key: CPUArchitectureNodeLabel, Operator: corev1.NodeSelectorOpIn, Values: []string{"arm64"},
+		}))
+		Expect(terms[1].MatchExpressions).To(ConsistOf(
+			corev1.NodeSelectorRequirement{Key: CPUArchitectureNodeLabel, Operator: corev1.NodeSelectorOpDoesNotExist},
+			corev1.NodeSelectorRequirement{Key: corev1.LabelArchStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"arm64"}},
+		))
+	})
+
+	It("keeps the requirements of placement constraints in every term", func() {
+		spec := &corev1.PodSpec{Affinity: &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{
+					{Key: "topology.kubernetes.io/zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"us-east-1a"}},
+				}}},
+			},
+		}}}
+		Expect(applyCPUArchitecture(spec, taskDef)).To(Succeed())
+
+		terms := spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+		Expect(terms).To(HaveLen(2))
+		for _, term := range terms {
+			Expect(term.MatchExpressions[0].Key).To(Equal("topology.kubernetes.io/zone"))
+		}
+	})
+
+	It("leaves pods without cpuArchitecture unconstrained", func() {
+		taskDef.RuntimePlatform = ""
+		spec := &corev1.PodSpec{}
+		Expect(applyCPUArchitecture(spec, taskDef)).To(Succeed())
+		Expect(spec.Affinity).To(BeNil())
+	})
+})
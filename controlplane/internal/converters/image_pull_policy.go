@@ -0,0 +1,70 @@
+package converters
+
+import (
+	"encoding/json"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// ImagePullPolicyTag is the cluster tag that overrides the image pull policy
+// of the instance for the tasks and services of a cluster
+const ImagePullPolicyTag = "kecs.dev/image-pull-policy"
+
+// ImagePullPolicy returns the pull policy of the containers of a cluster: the
+// value of its ImagePullPolicyTag, else images.pullPolicy. It returns "" when
+// neither is set, leaving the policy to the converters.
+func ImagePullPolicy(cluster *storage.Cluster) corev1.PullPolicy {
+	if cluster != nil && cluster.Tags != "" {
+		var tags []struct {
+			Key   string `json:"key"`
+			Value string `json:"value"`
+		}
+		if err := json.Unmarshal([]byte(cluster.Tags), &tags); err == nil {
+			for _, tag := range tags {
+				if tag.Key != ImagePullPolicyTag {
+					continue
+				}
+				if policy, ok := ParseImagePullPolicy(tag.Value); ok {
+					return policy
+				}
+				logging.Warn("Ignoring invalid image pull policy tag", "cluster", cluster.Name, "value", tag.Value)
+			}
+		}
+	}
+
+	value := config.GetString("images.pullPolicy")
+	if value == "" {
+		return ""
+	}
+	if policy, ok := ParseImagePullPolicy(value); ok {
+		return policy
+	}
+	logging.Warn("Ignoring invalid image pull policy", "value", value)
+	return ""
+}
+
+// ParseImagePullPolicy parses Always, IfNotPresent or Never, ignoring case
+func ParseImagePullPolicy(value string) (corev1.PullPolicy, bool) {
+	for _, policy := range []corev1.PullPolicy{corev1.PullAlways, corev1.PullIfNotPresent, corev1.PullNever} {
+		if strings.EqualFold(value, string(policy)) {
+			return policy, true
+		}
+	}
+	return "", false
+}
+
+// applyImagePullPolicy sets the image pull policy of a cluster on containers
+func applyImagePullPolicy(containers []corev1.Container, cluster *storage.Cluster) {
+	policy := ImagePullPolicy(cluster)
+	if policy == "" {
+		return
+	}
+	for i := range containers {
+		containers[i].ImagePullPolicy = policy
+	}
+}
@@ -0,0 +1,59 @@
+package converters_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+var _ = Describe("ImagePullPolicy", func() {
+	clusterWithTag := func(value string) *storage.Cluster {
+		return &storage.Cluster{
+			Name: "default",
+			Tags: `[{"key":"kecs.dev/image-pull-policy","value":"` + value + `"}]`,
+		}
+	}
+
+	AfterEach(func() {
+		config.Set("images.pullPolicy", "")
+	})
+
+	It("should leave the policy to Kubernetes when nothing is configured", func() {
+		Expect(converters.ImagePullPolicy(&storage.Cluster{Name: "default"})).To(BeEmpty())
+	})
+
+	It("should use the policy of the instance", func() {
+		config.Set("images.pullPolicy", "always")
+		Expect(converters.ImagePullPolicy(&storage.Cluster{Name: "default"})).To(Equal(corev1.PullAlways))
+	})
+
+	It("should prefer the cluster tag over the policy of the instance", func() {
+		config.Set("images.pullPolicy", "Always")
+		Expect(converters.ImagePullPolicy(clusterWithTag("Never"))).To(Equal(corev1.PullNever))
+	})
+
+	It("should ignore invalid values", func() {
+		config.Set("images.pullPolicy", "Sometimes")
+		Expect(converters.ImagePullPolicy(clusterWithTag("Often"))).To(BeEmpty())
+	})
+
+	It("should set the policy on the containers of tasks", func() {
+		config.Set("images.pullPolicy", "IfNotPresent")
+		converter := converters.NewTaskConverter("us-east-1", "000000000000")
+		taskDef := &storage.TaskDefinition{
+			Family:               "app",
+			Revision:             1,
+			ARN:                  "arn:aws:ecs:us-east-1:000000000000:task-definition/app:1",
+			NetworkMode:          "bridge",
+			ContainerDefinitions: `[{"name":"app","image":"nginx:1.27","essential":true}]`,
+		}
+
+		pod, err := converter.ConvertTaskToPod(taskDef, []byte(`{}`), &storage.Cluster{Name: "default", Region: "us-east-1"}, "0123456789abcdef")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pod.Spec.Containers[0].ImagePullPolicy).To(Equal(corev1.PullIfNotPresent))
+	})
+})
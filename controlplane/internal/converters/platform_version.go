@@ -0,0 +1,126 @@
+package converters
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/types"
+)
+
+// PlatformVersionLatest selects the latest Fargate platform version
+const PlatformVersionLatest = "LATEST"
+
+// Bounds of the ephemeralStorage of Fargate tasks, in GiB
+const (
+	MinEphemeralStorageGiB = 21
+	MaxEphemeralStorageGiB = 200
+)
+
+// platformVersions are the Linux Fargate platform versions, oldest first. The
+// last one is LATEST.
+var platformVersions = []string{"1.0.0", "1.1.0", "1.2.0", "1.3.0", "1.4.0"}
+
+// platformFeature is a task definition feature that requires a minimum
+// platform version
+type platformFeature struct {
+	name       string
+	minVersion string
+	used       func(containerDefs []types.ContainerDefinition, volumes []types.Volume, ephemeralStorage *types.EphemeralStorage) bool
+}
+
+// platformFeatures are the features gated by platform version, as documented
+// for Fargate
+var platformFeatures = []platformFeature{
+	{
+		name:       "secrets",
+		minVersion: "1.3.0",
+		used: func(containerDefs []types.ContainerDefinition, _ []types.Volume, _ *types.EphemeralStorage) bool {
+			return slices.ContainsFunc(containerDefs, func(def types.ContainerDefinition) bool {
+				return len(def.Secrets) > 0
+			})
+		},
+	},
+	{
+		name:       "container dependencies",
+		minVersion: "1.3.0",
+		used: func(containerDefs []types.ContainerDefinition, _ []types.Volume, _ *types.EphemeralStorage) bool {
+			return slices.ContainsFunc(containerDefs, func(def types.ContainerDefinition) bool {
+				return len(def.DependsOn) > 0
+			})
+		},
+	},
+	{
+		name:       "FireLens log routing",
+		minVersion: "1.3.0",
+		used: func(containerDefs []types.ContainerDefinition, _ []types.Volume, _ *types.EphemeralStorage) bool {
+			return slices.ContainsFunc(containerDefs, func(def types.ContainerDefinition) bool {
+				return def.FirelensConfiguration != nil
+			})
+		},
+	},
+	{
+		name:       "EFS volumes",
+		minVersion: "1.4.0",
+		used: func(_ []types.ContainerDefinition, volumes []types.Volume, _ *types.EphemeralStorage) bool {
+			return slices.ContainsFunc(volumes, func(volume types.Volume) bool {
+				return volume.EfsVolumeConfiguration != nil
+			})
+		},
+	},
+	{
+		name:       "ephemeralStorage",
+		minVersion: "1.4.0",
+		used: func(_ []types.ContainerDefinition, _ []types.Volume, ephemeralStorage *types.EphemeralStorage) bool {
+			return ephemeralStorage != nil
+		},
+	},
+	{
+		name:       "the SYS_PTRACE capability",
+		minVersion: "1.4.0",
+		used: func(containerDefs []types.ContainerDefinition, _ []types.Volume, _ *types.EphemeralStorage) bool {
+			return slices.ContainsFunc(containerDefs, func(def types.ContainerDefinition) bool {
+				if def.LinuxParameters == nil || def.LinuxParameters.Capabilities == nil {
+					return false
+				}
+				return slices.ContainsFunc(def.LinuxParameters.Capabilities.Add, func(capability string) bool {
+					return strings.EqualFold(capability, "SYS_PTRACE")
+				})
+			})
+		},
+	},
+}
+
+// ResolvePlatformVersion returns the Fargate platform version tasks run with.
+// An empty version or LATEST is the latest version.
+func ResolvePlatformVersion(version string) (string, error) {
+	if version == "" || version == PlatformVersionLatest {
+		return platformVersions[len(platformVersions)-1], nil
+	}
+	if !slices.Contains(platformVersions, version) {
+		return "", fmt.Errorf("platform version %s does not exist, valid versions are %s and %s",
+			version, strings.Join(platformVersions, ", "), PlatformVersionLatest)
+	}
+	return version, nil
+}
+
+// ValidatePlatformVersion checks that a Fargate platform version supports the
+// features of a task, so tasks fail the way they do on ECS instead of running
+// with features their platform version lacks.
+func ValidatePlatformVersion(version string, containerDefs []types.ContainerDefinition, volumes []types.Volume, ephemeralStorage *types.EphemeralStorage) error {
+	resolved, err := ResolvePlatformVersion(version)
+	if err != nil {
+		return err
+	}
+	index := slices.Index(platformVersions, resolved)
+	for _, feature := range platformFeatures {
+		if index >= slices.Index(platformVersions, feature.minVersion) {
+			continue
+		}
+		if feature.used(containerDefs, volumes, ephemeralStorage) {
+			return fmt.Errorf("the specified platform does not satisfy the task definition's required capabilities: platform version %s or later is required for %s, got %s",
+				feature.minVersion, feature.name, resolved)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package converters_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/types"
+)
+
+var _ = Describe("Platform versions", func() {
+	It("resolves LATEST and the default to the latest version", func() {
+		Expect(converters.ResolvePlatformVersion("")).To(Equal("1.4.0"))
+		Expect(converters.ResolvePlatformVersion("LATEST")).To(Equal("1.4.0"))
+		Expect(converters.ResolvePlatformVersion("1.3.0")).To(Equal("1.3.0"))
+	})
+
+	It("rejects unknown versions", func() {
+		_, err := converters.ResolvePlatformVersion("1.5.0")
+		Expect(err).To(MatchError(ContainSubstring("platform version 1.5.0 does not exist")))
+	})
+
+	It("requires 1.4.0 for EFS volumes", func() {
+		volumes := []types.Volume{{
+			Name:                   strPtr("data"),
+			EfsVolumeConfiguration: &types.EFSVolumeConfiguration{FileSystemId: strPtr("fs-1")},
+		}}
+		err := converters.ValidatePlatformVersion("1.3.0", nil, volumes, nil)
+		Expect(err).To(MatchError(ContainSubstring("platform version 1.4.0 or later is required for EFS volumes, got 1.3.0")))
+		Expect(converters.ValidatePlatformVersion("1.4.0", nil, volumes, nil)).To(Succeed())
+		Expect(converters.ValidatePlatformVersion("LATEST", nil, volumes, nil)).To(Succeed())
+	})
+
+	It("requires 1.4.0 for ephemeralStorage and SYS_PTRACE", func() {
+		size := 50
+		err := converters.ValidatePlatformVersion("1.3.0", nil, nil, &types.EphemeralStorage{SizeInGiB: &size})
+		Expect(err).To(MatchError(ContainSubstring("required for ephemeralStorage")))
+
+		containerDefs := []types.ContainerDefinition{{
+			Name: strPtr("app"),
+			LinuxParameters: &types.LinuxParameters{
+				Capabilities: &types.KernelCapabilities{Add: []string{"SYS_PTRACE"}},
+			},
+		}}
+		err = converters.ValidatePlatformVersion("1.3.0", containerDefs, nil, nil)
+		Expect(err).To(MatchError(ContainSubstring("SYS_PTRACE")))
+	})
+
+	It("requires 1.3.0 for secrets and container dependencies", func() {
+		containerDefs := []types.ContainerDefinition{{
+			Name:      strPtr("app"),
+			DependsOn: []types.ContainerDependency{{ContainerName: strPtr("init"), Condition: strPtr("COMPLETE")}},
+		}}
+		err := converters.ValidatePlatformVersion("1.2.0", containerDefs, nil, nil)
+		Expect(err).To(MatchError(ContainSubstring("required for container dependencies")))
+		Expect(converters.ValidatePlatformVersion("1.3.0", containerDefs, nil, nil)).To(Succeed())
+	})
+})
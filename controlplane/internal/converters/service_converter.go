@@ -19,6 +19,10 @@ import (
 	"github.com/nandemo-ya/kecs/controlplane/internal/types"
 )
 
+// ServiceConnectNamespaceAnnotation records the Cloud Map namespace of the
+// pods of Service Connect services, whose tasks have a Service Connect attachment
+const ServiceConnectNamespaceAnnotation = "kecs.dev/service-connect-namespace"
+
 // SecretInfo holds parsed information from a secret ARN
 type SecretInfo struct {
 	SecretName string
@@ -28,9 +32,10 @@ type SecretInfo struct {
 
 // ServiceConverter converts ECS service definitions to Kubernetes Deployments
 type ServiceConverter struct {
-	region           string
-	accountID        string
-	networkConverter *NetworkConverter
+	region             string
+	accountID          string
+	networkConverter   *NetworkConverter
+	subnetZoneResolver SubnetZoneResolver
 }
 
 // NewServiceConverter creates a new ServiceConverter
@@ -42,6 +47,11 @@ func NewServiceConverter(region, accountID string) *ServiceConverter {
 	}
 }
 
+// SetSubnetZoneResolver sets the resolver used to map awsvpc subnets to availability zones
+func (c *ServiceConverter) SetSubnetZoneResolver(r SubnetZoneResolver) {
+	c.subnetZoneResolver = r
+}
+
 // ConvertServiceToDeployment converts an ECS service to a Kubernetes Deployment
 func (c *ServiceConverter) ConvertServiceToDeployment(
 	service *storage.Service,
@@ -90,6 +100,15 @@ func (c *ServiceConverter) ConvertServiceToDeploymentWithNetworkConfig(
 		}
 	}
 
+	var efsVolumes []types.Volume
+	if taskDef.Volumes != "" {
+		if err := json.Unmarshal([]byte(taskDef.Volumes), &efsVolumes); err == nil {
+			if err := validateEFSVolumes(efsVolumes); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
 	// Determine network mode from task definition
 	networkMode := types.GetNetworkMode(&taskDef.NetworkMode)
 
@@ -98,6 +117,13 @@ func (c *ServiceConverter) ConvertServiceToDeploymentWithNetworkConfig(
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create deployment: %w", err)
 	}
+	applyEFSSubPaths(&deployment.Spec.Template.Spec, efsVolumes)
+
+	// Prefer nodes in the availability zones of the requested subnets
+	if networkConfig != nil && networkConfig.AwsvpcConfiguration != nil {
+		template := &deployment.Spec.Template
+		applySubnetZones(c.subnetZoneResolver, template.Annotations, &template.Spec, networkConfig.AwsvpcConfiguration.Subnets)
+	}
 
 	// Create Service (if needed for load balancing)
 	kubeService, err := c.createKubernetesService(service, containerDefs, cluster, networkConfig, networkMode)
@@ -124,11 +150,21 @@ func (c *ServiceConverter) createDeployment(
 	// Create deployment name (ECS service name with prefix)
 	deploymentName := service.ServiceName
 
+	// Managed EBS volumes of the volumes configured at launch
+	var volumeConfigs []types.VolumeConfiguration
+	if service.VolumeConfigurations != "" {
+		if err := json.Unmarshal([]byte(service.VolumeConfigurations), &volumeConfigs); err != nil {
+			return nil, fmt.Errorf("failed to parse volume configurations: %w", err)
+		}
+	}
+
 	// Create containers from container definitions
-	containers, k8sVolumes, err := c.createContainersAndVolumes(containerDefs, volumes)
+	containers, k8sVolumes, err := c.createContainersAndVolumes(containerDefs, volumes, volumeConfigs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create containers: %w", err)
 	}
+	clearHostPorts(containers, networkMode)
+	applyImagePullPolicy(containers, cluster)
 
 	// Create replica count (desired count)
 	replicas := int32(service.DesiredCount)
@@ -176,6 +212,9 @@ func (c *ServiceConverter) createDeployment(
 		"kecs.dev/scheduling-strategy": service.SchedulingStrategy,
 	}
 
+	// Record the network mode, which the ports of the tasks are bound by
+	annotations["ecs.amazonaws.com/network-mode"] = string(networkMode)
+
 	// Add network configuration annotations
 	if networkConfig != nil {
 		networkAnnotations := c.networkConverter.ConvertNetworkConfiguration(networkConfig, networkMode)
@@ -193,6 +232,14 @@ func (c *ServiceConverter) createDeployment(
 	// Add CloudWatch Logs annotations to pod template
 	c.addCloudWatchLogsAnnotations(podAnnotations, containerDefs)
 
+	// Record port ranges, which are expanded to single container ports, and
+	// the containers that are not essential
+	var typedContainerDefs []types.ContainerDefinition
+	if err := json.Unmarshal([]byte(taskDef.ContainerDefinitions), &typedContainerDefs); err == nil {
+		setPortRangesAnnotation(podAnnotations, typedContainerDefs)
+		setNonEssentialAnnotation(podAnnotations, typedContainerDefs)
+	}
+
 	// Add Service Registry annotations to pod template
 	logging.Info("Checking ServiceRegistries for pod template",
 		"serviceName", service.ServiceName,
@@ -205,6 +252,11 @@ func (c *ServiceConverter) createDeployment(
 			"serviceRegistries", service.ServiceRegistries)
 	}
 
+	// Record the namespace of Service Connect services
+	if namespace := serviceConnectNamespace(service); namespace != "" {
+		podAnnotations[ServiceConnectNamespaceAnnotation] = namespace
+	}
+
 	// Add secret annotations to pod template
 	secretIndex := 0
 	logging.Info("Processing containers for secrets", "containerCount", len(containerDefs))
@@ -265,6 +317,26 @@ func (c *ServiceConverter) createDeployment(
 		},
 	}
 
+	// Run on nodes with the CPU architecture of the runtime platform
+	if err := applyCPUArchitecture(&deployment.Spec.Template.Spec, taskDef); err != nil {
+		return nil, err
+	}
+
+	// The pods of the service are set up as the task converter sets up the
+	// pods of standalone tasks
+	taskConverter := NewTaskConverter(c.region, c.accountID)
+	pod := &corev1.Pod{ObjectMeta: deployment.Spec.Template.ObjectMeta, Spec: deployment.Spec.Template.Spec}
+
+	// Run on the nodes matching the memberOf constraints of the task definition
+	taskConverter.applyPlacementConstraints(pod, taskConverter.taskDefinitionPlacementConstraints(taskDef))
+
+	// Route the task traffic through the proxy container
+	if err := taskConverter.applyProxyConfiguration(pod, taskDef); err != nil {
+		return nil, err
+	}
+	deployment.Spec.Template.ObjectMeta = pod.ObjectMeta
+	deployment.Spec.Template.Spec = pod.Spec
+
 	// Add strategy based on scheduling strategy
 	if service.SchedulingStrategy == "DAEMON" {
 		// For DAEMON services, we should use DaemonSet instead
@@ -286,8 +358,10 @@ func (c *ServiceConverter) createDeployment(
 	return deployment, nil
 }
 
-// createContainersAndVolumes creates Kubernetes containers and volumes from ECS definitions
-func (c *ServiceConverter) createContainersAndVolumes(containerDefs []map[string]interface{}, volumes []map[string]interface{}) ([]corev1.Container, []corev1.Volume, error) {
+// createContainersAndVolumes creates Kubernetes containers and volumes from ECS definitions.
+// Volumes configured at launch get a PVC per task that is deleted with the task, as
+// in ECS services.
+func (c *ServiceConverter) createContainersAndVolumes(containerDefs []map[string]interface{}, volumes []map[string]interface{}, volumeConfigs []types.VolumeConfiguration) ([]corev1.Container, []corev1.Volume, error) {
 	var containers []corev1.Container
 	var k8sVolumes []corev1.Volume
 
@@ -298,8 +372,12 @@ func (c *ServiceConverter) createContainersAndVolumes(containerDefs []map[string
 				Name: name,
 			}
 
-			// Check for host volume configuration
-			if hostConfig, ok := vol["host"].(map[string]interface{}); ok {
+			// Check for a volume configured at launch, then host volume configuration
+			if configuredAtLaunch, _ := vol["configuredAtLaunch"].(bool); configuredAtLaunch {
+				k8sVol.VolumeSource = convertVolumeConfiguredAtLaunch(name, volumeConfigs, true)
+			} else if efsConfig := parseEFSVolumeConfiguration(vol); efsConfig != nil && NFSServerEnabled() {
+				k8sVol.VolumeSource = convertEFSVolumeToNFSServer(efsConfig)
+			} else if hostConfig, ok := vol["host"].(map[string]interface{}); ok {
 				if sourcePath, ok := hostConfig["sourcePath"].(string); ok && sourcePath != "" {
 					// Host path volume
 					k8sVol.VolumeSource = corev1.VolumeSource{
@@ -336,6 +414,24 @@ func (c *ServiceConverter) createContainersAndVolumes(containerDefs []map[string
 	return containers, k8sVolumes, nil
 }
 
+// parseEFSVolumeConfiguration returns the EFS configuration of a volume, nil
+// when the volume has none
+func parseEFSVolumeConfiguration(vol map[string]interface{}) *types.EFSVolumeConfiguration {
+	raw, ok := vol["efsVolumeConfiguration"]
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var efsConfig types.EFSVolumeConfiguration
+	if err := json.Unmarshal(data, &efsConfig); err != nil || efsConfig.FileSystemId == nil {
+		return nil
+	}
+	return &efsConfig
+}
+
 // createContainers creates Kubernetes containers from ECS container definitions
 func (c *ServiceConverter) createContainers(containerDefs []map[string]interface{}) ([]corev1.Container, error) {
 	var containers []corev1.Container
@@ -426,9 +522,19 @@ func (c *ServiceConverter) createContainer(containerDef map[string]interface{})
 						protocol = "TCP"
 					}
 
+					// Every port of a range becomes a container port
+					if portRangeValue, ok := portMap["containerPortRange"].(string); ok {
+						if portRange, err := ParsePortRange(portRangeValue); err == nil {
+							container.Ports = append(container.Ports, expandPortRange(portRange, convertProtocol(protocol))...)
+						}
+						continue
+					}
+
 					if containerPort > 0 {
+						hostPort, _ := portMap["hostPort"].(float64)
 						container.Ports = append(container.Ports, corev1.ContainerPort{
 							ContainerPort: int32(containerPort),
+							HostPort:      int32(hostPort),
 							Protocol:      corev1.Protocol(strings.ToUpper(protocol)),
 						})
 					}
@@ -1093,3 +1199,19 @@ func (c *ServiceConverter) convertHealthCheckToProbe(healthCheck map[string]inte
 
 	return probe
 }
+
+// serviceConnectNamespace returns the namespace of a service with Service
+// Connect enabled, empty otherwise
+func serviceConnectNamespace(service *storage.Service) string {
+	if service.ServiceConnectConfiguration == "" {
+		return ""
+	}
+	var config generated.ServiceConnectConfiguration
+	if err := json.Unmarshal([]byte(service.ServiceConnectConfiguration), &config); err != nil {
+		return ""
+	}
+	if !config.Enabled || config.Namespace == nil {
+		return ""
+	}
+	return *config.Namespace
+}
@@ -0,0 +1,50 @@
+package converters_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+var _ = Describe("ServiceConverter Service Connect", func() {
+	var (
+		converter *converters.ServiceConverter
+		service   *storage.Service
+		cluster   *storage.Cluster
+		taskDef   *storage.TaskDefinition
+	)
+
+	BeforeEach(func() {
+		converter = converters.NewServiceConverter("us-east-1", "123456789012")
+		service = &storage.Service{
+			ServiceName:  "web",
+			DesiredCount: 1,
+			LaunchType:   "FARGATE",
+			ARN:          "arn:aws:ecs:us-east-1:123456789012:service/test-cluster/web",
+		}
+		cluster = &storage.Cluster{Name: "test-cluster", Region: "us-east-1"}
+		taskDef = &storage.TaskDefinition{
+			Family:               "web",
+			Revision:             1,
+			ContainerDefinitions: mustMarshal([]map[string]interface{}{{"name": "app", "image": "nginx:latest"}}),
+		}
+	})
+
+	It("records the namespace of Service Connect services on the pods", func() {
+		service.ServiceConnectConfiguration = `{"enabled":true,"namespace":"internal"}`
+
+		deployment, _, err := converter.ConvertServiceToDeployment(service, taskDef, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deployment.Spec.Template.Annotations).To(HaveKeyWithValue(converters.ServiceConnectNamespaceAnnotation, "internal"))
+	})
+
+	It("does not record a namespace when Service Connect is disabled", func() {
+		service.ServiceConnectConfiguration = `{"enabled":false,"namespace":"internal"}`
+
+		deployment, _, err := converter.ConvertServiceToDeployment(service, taskDef, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deployment.Spec.Template.Annotations).NotTo(HaveKey(converters.ServiceConnectNamespaceAnnotation))
+	})
+})
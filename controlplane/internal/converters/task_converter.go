@@ -15,6 +15,7 @@ import (
 	"k8s.io/utils/ptr"
 
 	"github.com/nandemo-ya/kecs/controlplane/internal/artifacts"
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
 	"github.com/nandemo-ya/kecs/controlplane/internal/config"
 	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/cloudwatch"
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
@@ -31,6 +32,12 @@ type TaskConverter struct {
 	artifactManager       *artifacts.Manager
 	proxyManager          *proxy.Manager
 	networkConverter      *NetworkConverter
+	subnetZoneResolver    SubnetZoneResolver
+}
+
+// SubnetZoneResolver resolves the availability zone a subnet belongs to
+type SubnetZoneResolver interface {
+	ZoneForSubnet(subnetID string) (string, error)
 }
 
 // NewTaskConverter creates a new task converter
@@ -61,6 +68,11 @@ func (c *TaskConverter) SetProxyManager(pm *proxy.Manager) {
 	c.proxyManager = pm
 }
 
+// SetSubnetZoneResolver sets the resolver used to map awsvpc subnets to availability zones
+func (c *TaskConverter) SetSubnetZoneResolver(r SubnetZoneResolver) {
+	c.subnetZoneResolver = r
+}
+
 // ConvertTaskToPod converts an ECS task definition and RunTask request to a Kubernetes Pod
 func (c *TaskConverter) ConvertTaskToPod(
 	taskDef *storage.TaskDefinition,
@@ -92,6 +104,7 @@ func (c *TaskConverter) ConvertTaskToPod(
 		Tags                 []types.Tag                 `json:"tags,omitempty"`
 		EnableExecuteCommand *bool                       `json:"enableExecuteCommand,omitempty"`
 		Overrides            *types.TaskOverride         `json:"overrides,omitempty"`
+		VolumeConfigurations []types.VolumeConfiguration `json:"volumeConfigurations,omitempty"`
 	}
 	if err := json.Unmarshal(runTaskReqJSON, &runTaskReq); err != nil {
 		return nil, fmt.Errorf("failed to parse RunTask request: %w", err)
@@ -110,6 +123,10 @@ func (c *TaskConverter) ConvertTaskToPod(
 		}
 	}
 
+	if err := validateEFSVolumes(volumes); err != nil {
+		return nil, err
+	}
+
 	// Create pod spec
 	pod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -132,7 +149,7 @@ func (c *TaskConverter) ConvertTaskToPod(
 		Spec: corev1.PodSpec{
 			RestartPolicy: corev1.RestartPolicyNever, // ECS tasks don't restart by default
 			Containers:    c.convertContainersWithOverrides(containerDefs, taskDef, runTaskReq.Overrides),
-			Volumes:       c.convertVolumes(volumes),
+			Volumes:       c.convertVolumes(volumes, runTaskReq.VolumeConfigurations),
 		},
 	}
 
@@ -150,6 +167,8 @@ func (c *TaskConverter) ConvertTaskToPod(
 	if networkMode == types.NetworkModeHost {
 		pod.Spec.HostNetwork = true
 	}
+	clearHostPorts(pod.Spec.Containers, networkMode)
+	applyImagePullPolicy(pod.Spec.Containers, cluster)
 
 	// Add network configuration annotations
 	if runTaskReq.NetworkConfiguration != nil && c.networkConverter != nil {
@@ -199,6 +218,11 @@ func (c *TaskConverter) ConvertTaskToPod(
 		pod.Spec.HostIPC = true
 	}
 
+	// Route the task traffic through the proxy container
+	if err := c.applyProxyConfiguration(pod, taskDef); err != nil {
+		return nil, err
+	}
+
 	// Apply task-level resource constraints
 	if taskDef.CPU != "" || taskDef.Memory != "" {
 		c.applyResourceConstraints(pod, taskDef.CPU, taskDef.Memory)
@@ -209,9 +233,24 @@ func (c *TaskConverter) ConvertTaskToPod(
 		c.applyOverrides(pod, runTaskReq.Overrides)
 	}
 
-	// Add placement constraints as node selectors/affinity
-	if runTaskReq.PlacementConstraints != nil {
-		c.applyPlacementConstraints(pod, runTaskReq.PlacementConstraints)
+	// Add placement constraints as node selectors/affinity. Constraints of the
+	// task definition apply to every task, those of the request on top of them.
+	placementConstraints := append(c.taskDefinitionPlacementConstraints(taskDef), runTaskReq.PlacementConstraints...)
+	c.applyPlacementConstraints(pod, placementConstraints)
+
+	// Run on nodes with the CPU architecture of the runtime platform
+	if err := applyCPUArchitecture(&pod.Spec, taskDef); err != nil {
+		return nil, err
+	}
+
+	// Prefer nodes in the availability zones of the requested subnets
+	if runTaskReq.NetworkConfiguration != nil && runTaskReq.NetworkConfiguration.AwsvpcConfiguration != nil {
+		applySubnetZones(c.subnetZoneResolver, pod.Annotations, &pod.Spec, runTaskReq.NetworkConfiguration.AwsvpcConfiguration.Subnets)
+	}
+
+	// Add placement strategies as topology spread constraints
+	if runTaskReq.PlacementStrategy != nil {
+		c.applyPlacementStrategy(pod, runTaskReq.PlacementStrategy)
 	}
 
 	// Add tags as labels
@@ -221,10 +260,17 @@ func (c *TaskConverter) ConvertTaskToPod(
 
 	// Add volume configuration annotations
 	c.addVolumeAnnotations(pod, volumes)
+	applyEFSSubPaths(&pod.Spec, volumes)
 
 	// Add secret annotations
 	c.addSecretAnnotations(pod, containerDefs)
 
+	// Record port ranges, which are expanded to single container ports
+	setPortRangesAnnotation(pod.Annotations, containerDefs)
+
+	// Record the containers whose exit does not stop the task
+	setNonEssentialAnnotation(pod.Annotations, containerDefs)
+
 	// Apply IAM role annotations if specified (for tracking purposes)
 	if taskDef.ExecutionRoleARN != "" {
 		pod.ObjectMeta.Annotations["kecs.dev/execution-role-arn"] = taskDef.ExecutionRoleARN
@@ -237,6 +283,9 @@ func (c *TaskConverter) ConvertTaskToPod(
 		// The annotations set by applyCloudWatchLogsConfiguration will be read by Vector
 	}
 
+	// Restart containers with a restart policy
+	applyRestartPolicies(pod, containerDefs)
+
 	// Add AWS proxy sidecar if proxy manager is available
 	if c.proxyManager != nil && c.proxyManager.GetSidecarProxy() != nil {
 		sidecarProxy := c.proxyManager.GetSidecarProxy()
@@ -342,13 +391,6 @@ func (c *TaskConverter) convertContainers(
 			container.WorkingDir = *def.WorkingDirectory
 		}
 
-		// Essential containers (all containers are essential by default in K8s)
-		// If Essential is false, we might want to handle this differently
-		if def.Essential != nil && !*def.Essential {
-			// Add annotation to track non-essential containers
-			container.Name = container.Name + "-nonessential"
-		}
-
 		// Health check
 		if def.HealthCheck != nil {
 			// Use the same probe for both liveness and readiness
@@ -417,38 +459,39 @@ func (c *TaskConverter) convertEnvironment(env []types.KeyValuePair) []corev1.En
 	return k8sEnv
 }
 
-// convertPortMappings converts ECS port mappings to Kubernetes
+// convertPortMappings converts ECS port mappings to Kubernetes. Every port of a
+// containerPortRange becomes a container port.
 func (c *TaskConverter) convertPortMappings(mappings []types.PortMapping) []corev1.ContainerPort {
 	ports := make([]corev1.ContainerPort, 0, len(mappings))
 
 	for _, m := range mappings {
+		protocol := corev1.ProtocolTCP
+		if m.Protocol != nil {
+			protocol = convertProtocol(*m.Protocol)
+		}
+
+		if m.ContainerPortRange != nil {
+			portRange, err := ParsePortRange(*m.ContainerPortRange)
+			if err != nil {
+				continue
+			}
+			ports = append(ports, expandPortRange(portRange, protocol)...)
+			continue
+		}
+
 		if m.ContainerPort == nil {
 			continue
 		}
 
 		port := corev1.ContainerPort{
 			ContainerPort: int32(*m.ContainerPort),
+			Protocol:      protocol,
 		}
 
 		if m.HostPort != nil {
 			port.HostPort = int32(*m.HostPort)
 		}
 
-		if m.Protocol != nil {
-			switch strings.ToLower(*m.Protocol) {
-			case "tcp":
-				port.Protocol = corev1.ProtocolTCP
-			case "udp":
-				port.Protocol = corev1.ProtocolUDP
-			case "sctp":
-				port.Protocol = corev1.ProtocolSCTP
-			default:
-				port.Protocol = corev1.ProtocolTCP
-			}
-		} else {
-			port.Protocol = corev1.ProtocolTCP
-		}
-
 		if m.Name != nil {
 			port.Name = *m.Name
 		}
@@ -472,8 +515,9 @@ func (c *TaskConverter) convertContainersWithOverrides(
 	return c.convertContainers(containerDefs, taskDef, runTaskReq)
 }
 
-// convertVolumes converts ECS volumes to Kubernetes volumes
-func (c *TaskConverter) convertVolumes(volumes []types.Volume) []corev1.Volume {
+// convertVolumes converts ECS volumes to Kubernetes volumes. Volumes configured
+// at launch take their configuration from the RunTask request.
+func (c *TaskConverter) convertVolumes(volumes []types.Volume, volumeConfigs []types.VolumeConfiguration) []corev1.Volume {
 	k8sVolumes := make([]corev1.Volume, 0, len(volumes))
 
 	for _, vol := range volumes {
@@ -487,6 +531,10 @@ func (c *TaskConverter) convertVolumes(volumes []types.Volume) []corev1.Volume {
 
 		// Handle different volume types
 		switch {
+		case vol.ConfiguredAtLaunch != nil && *vol.ConfiguredAtLaunch:
+			// Managed EBS volume - provisioned as a PVC with the pod
+			k8sVol.VolumeSource = convertVolumeConfiguredAtLaunch(*vol.Name, volumeConfigs, false)
+
 		case vol.Host != nil && vol.Host.SourcePath != nil:
 			// Host volume
 			k8sVol.VolumeSource = corev1.VolumeSource{
@@ -676,7 +724,7 @@ func (c *TaskConverter) getLaunchTypeFromRequest(launchType *string) string {
 
 // generateTaskARN generates a task ARN
 func (c *TaskConverter) generateTaskARN(clusterName, taskID string) string {
-	return fmt.Sprintf("arn:aws:ecs:%s:%s:task/%s/%s", c.region, c.accountID, clusterName, taskID)
+	return awsarn.Build("ecs", c.region, c.accountID, fmt.Sprintf("task/%s/%s", clusterName, taskID))
 }
 
 // applyResourceConstraints applies task-level resource constraints to the pod
@@ -935,6 +983,108 @@ func (c *TaskConverter) applyPlacementConstraints(pod *corev1.Pod, constraints [
 	}
 }
 
+// taskDefinitionPlacementConstraints returns the memberOf constraints declared
+// on a task definition
+func (c *TaskConverter) taskDefinitionPlacementConstraints(taskDef *storage.TaskDefinition) []types.PlacementConstraint {
+	if taskDef.PlacementConstraints == "" {
+		return nil
+	}
+	var declared []types.TaskPlacementConstraint
+	if err := json.Unmarshal([]byte(taskDef.PlacementConstraints), &declared); err != nil {
+		logging.Warn("Failed to parse task definition placement constraints", "taskDefinition", taskDef.ARN, "error", err)
+		return nil
+	}
+
+	var constraints []types.PlacementConstraint
+	for _, constraint := range declared {
+		// Registration only accepts memberOf, revisions registered before it
+		// was validated may hold other types
+		if constraint.Type == nil || *constraint.Type != "memberOf" {
+			continue
+		}
+		constraints = append(constraints, types.PlacementConstraint{
+			Type:       constraint.Type,
+			Expression: constraint.Expression,
+		})
+	}
+	return constraints
+}
+
+// applySubnetZones adds a preferred node affinity for the availability zones of the given subnets
+// to a pod spec, recording the zones in its annotations. Unknown subnets are ignored here;
+// validation happens at the API layer.
+func applySubnetZones(resolver SubnetZoneResolver, annotations map[string]string, spec *corev1.PodSpec, subnets []string) {
+	if resolver == nil || len(subnets) == 0 {
+		return
+	}
+
+	var zones []string
+	seen := make(map[string]bool)
+	for _, subnet := range subnets {
+		zone, err := resolver.ZoneForSubnet(subnet)
+		if err != nil || zone == "" || seen[zone] {
+			continue
+		}
+		seen[zone] = true
+		zones = append(zones, zone)
+	}
+	if len(zones) == 0 {
+		return
+	}
+
+	annotations["kecs.dev/availability-zones"] = strings.Join(zones, ",")
+
+	if spec.Affinity == nil {
+		spec.Affinity = &corev1.Affinity{}
+	}
+	if spec.Affinity.NodeAffinity == nil {
+		spec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
+	}
+	spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(
+		spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+		corev1.PreferredSchedulingTerm{
+			Weight: 100,
+			Preference: corev1.NodeSelectorTerm{
+				MatchExpressions: []corev1.NodeSelectorRequirement{
+					{
+						Key:      "topology.kubernetes.io/zone",
+						Operator: corev1.NodeSelectorOpIn,
+						Values:   zones,
+					},
+				},
+			},
+		},
+	)
+}
+
+// applyPlacementStrategy converts ECS spread placement strategies to topology spread constraints
+func (c *TaskConverter) applyPlacementStrategy(pod *corev1.Pod, strategies []types.PlacementStrategy) {
+	for _, strategy := range strategies {
+		if strategy.Type == nil || *strategy.Type != "spread" || strategy.Field == nil {
+			continue
+		}
+
+		var topologyKey string
+		switch *strategy.Field {
+		case "instanceId", "host":
+			topologyKey = "kubernetes.io/hostname"
+		default:
+			topologyKey = c.convertECSAttributeToK8sLabel(strings.TrimPrefix(*strategy.Field, "attribute:"))
+		}
+
+		pod.Spec.TopologySpreadConstraints = append(pod.Spec.TopologySpreadConstraints, corev1.TopologySpreadConstraint{
+			MaxSkew:           1,
+			TopologyKey:       topologyKey,
+			WhenUnsatisfiable: corev1.ScheduleAnyway,
+			LabelSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"kecs.dev/task-family": pod.Labels["kecs.dev/task-family"],
+				},
+			},
+		})
+	}
+}
+
 // parseMemberOfExpression parses ECS memberOf expressions and converts to node selector or affinity
 func (c *TaskConverter) parseMemberOfExpression(expression string, pod *corev1.Pod) {
 	// ECS expressions examples:
@@ -955,8 +1105,9 @@ func (c *TaskConverter) parseMemberOfExpression(expression string, pod *corev1.P
 	// Convert ECS attribute to Kubernetes label
 	k8sLabel := c.convertECSAttributeToK8sLabel(attribute)
 
-	// For simple equality, use node selector
-	if operator == "==" {
+	// For simple equality, use node selector. An equality conflicting with
+	// one already selected must hold as well, so it goes to node affinity.
+	if existing, ok := pod.Spec.NodeSelector[k8sLabel]; operator == "==" && (!ok || existing == value) {
 		if pod.Spec.NodeSelector == nil {
 			pod.Spec.NodeSelector = make(map[string]string)
 		}
@@ -1066,6 +1217,11 @@ func (c *TaskConverter) convertEFSVolume(efsConfig *types.EFSVolumeConfiguration
 		}
 	}
 
+	// Without AWS the file systems are directories of the in-cluster NFS server
+	if NFSServerEnabled() {
+		return convertEFSVolumeToNFSServer(efsConfig)
+	}
+
 	// EFS is essentially NFS v4, so we can use NFS volume in Kubernetes
 	// The server would be: <file-system-id>.efs.<region>.amazonaws.com
 	server := fmt.Sprintf("%s.efs.%s.amazonaws.com", *efsConfig.FileSystemId, c.region)
@@ -1290,6 +1446,17 @@ func (c *TaskConverter) addSecretAnnotations(pod *corev1.Pod, containerDefs []ty
 		}
 	}
 
+	// Secrets read by artifact downloads are synced the same way
+	if c.artifactManager != nil {
+		for _, containerDef := range containerDefs {
+			for _, secret := range artifactSecrets(containerDef.Artifacts) {
+				annotationKey := fmt.Sprintf("kecs.dev/secret-%d-arn", secretIndex)
+				pod.Annotations[annotationKey] = fmt.Sprintf("%s:%s:%s", artifactInitContainerName(*containerDef.Name), secret.EnvName, secret.ARN)
+				secretIndex++
+			}
+		}
+	}
+
 	// Add total count of secrets
 	if secretIndex > 0 {
 		pod.Annotations["kecs.dev/secret-count"] = fmt.Sprintf("%d", secretIndex)
@@ -1826,9 +1993,10 @@ func (c *TaskConverter) createArtifactInitContainers(containerDefs []types.Conta
 		}
 		volumes = append(volumes, volume)
 
-		// Create init container for downloading artifacts
+		// Create init container for downloading artifacts. A failed download
+		// or checksum fails the init container, so the task never starts.
 		initContainer := corev1.Container{
-			Name:    fmt.Sprintf("artifact-downloader-%s", *def.Name),
+			Name:    artifactInitContainerName(*def.Name),
 			Image:   "amazon/aws-cli:latest", // Use AWS CLI image for S3 support
 			Command: []string{"/bin/sh", "-c"},
 			Args:    []string{c.generateArtifactDownloadScript(def.Artifacts)},
@@ -1839,7 +2007,7 @@ func (c *TaskConverter) createArtifactInitContainers(containerDefs []types.Conta
 				},
 			},
 			// Add environment variables for S3 endpoint if LocalStack is configured
-			Env: c.getArtifactEnvironment(),
+			Env: append(c.getArtifactEnvironment(), c.getArtifactSecretEnvironment(def.Artifacts)...),
 		}
 
 		initContainers = append(initContainers, initContainer)
@@ -1848,38 +2016,238 @@ func (c *TaskConverter) createArtifactInitContainers(containerDefs []types.Conta
 	return initContainers, volumes
 }
 
+// artifactInitContainerName returns the name of the init container that
+// downloads the artifacts of a container
+func artifactInitContainerName(containerName string) string {
+	return fmt.Sprintf("artifact-downloader-%s", containerName)
+}
+
+// artifactRetryFunction is the shell function the download script retries
+// downloads with. It waits twice as long after each failed attempt and gives
+// up after $ARTIFACT_ATTEMPTS attempts. Commands are not echoed, as they may
+// carry secret headers.
+const artifactRetryFunction = `retry() {
+  label="$1"
+  shift
+  attempt=1
+  delay=2
+  until "$@"; do
+    if [ "$attempt" -ge "${ARTIFACT_ATTEMPTS:-1}" ]; then
+      echo "failed to download $label after $attempt attempts" >&2
+      return 1
+    fi
+    echo "failed to download $label, retrying in ${delay}s" >&2
+    sleep "$delay"
+    attempt=$((attempt + 1))
+    delay=$((delay * 2))
+  done
+}`
+
+// artifactHeaderNamePattern matches valid HTTP header names
+var artifactHeaderNamePattern = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+
+// artifactChecksumPattern matches hex encoded checksums
+var artifactChecksumPattern = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+
 // generateArtifactDownloadScript generates a shell script to download artifacts
 func (c *TaskConverter) generateArtifactDownloadScript(artifacts []types.Artifact) string {
-	var commands []string
+	commands := []string{"set -e", artifactRetryFunction}
 
-	for _, artifact := range artifacts {
+	for i, artifact := range artifacts {
 		if artifact.ArtifactUrl == nil || artifact.TargetPath == nil {
 			continue
 		}
 
 		url := *artifact.ArtifactUrl
 		targetPath := filepath.Join("/artifacts", *artifact.TargetPath)
+		extract := artifact.Extract != nil && *artifact.Extract
 
-		// Create directory for target
-		commands = append(commands, fmt.Sprintf("mkdir -p $(dirname %s)", targetPath))
+		// Archives are downloaded next to the volume and unpacked into the target
+		downloadPath := targetPath
+		if extract {
+			downloadPath = fmt.Sprintf("/tmp/artifact-%d", i)
+			commands = append(commands, fmt.Sprintf("mkdir -p %s", targetPath))
+		} else {
+			// Create directory for target
+			commands = append(commands, fmt.Sprintf("mkdir -p $(dirname %s)", targetPath))
+		}
 
 		// Download based on URL type
 		if strings.HasPrefix(url, "s3://") {
-			// Use AWS CLI to download from S3
-			commands = append(commands, fmt.Sprintf("aws s3 cp %s %s", url, targetPath))
+			// Use AWS CLI to download from S3, with the artifact's own
+			// credentials when it has any
+			download := fmt.Sprintf("aws s3 cp %s %s", url, downloadPath)
+			if artifact.CredentialsFrom != nil {
+				prefix := artifactEnvPrefix(i)
+				download = fmt.Sprintf(`env AWS_ACCESS_KEY_ID="$%[1]s_ACCESS_KEY_ID" AWS_SECRET_ACCESS_KEY="$%[1]s_SECRET_ACCESS_KEY" ${%[1]s_SESSION_TOKEN:+AWS_SESSION_TOKEN="$%[1]s_SESSION_TOKEN"} %[2]s`,
+					prefix, download)
+			}
+			commands = append(commands, fmt.Sprintf("retry %s %s", url, download))
 		} else if strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") {
-			// Use curl for HTTP/HTTPS (available in aws-cli image)
-			commands = append(commands, fmt.Sprintf("curl -s -L -o %s %s", targetPath, url))
+			// Use curl for HTTP/HTTPS (available in aws-cli image), failing on
+			// error statuses so they are retried
+			download := fmt.Sprintf("curl -s -L -o %s -f -S", downloadPath)
+			for j, header := range artifact.Headers {
+				if header.Name == nil || !artifactHeaderNamePattern.MatchString(*header.Name) {
+					continue
+				}
+				download += fmt.Sprintf(` -H "%s: $%s"`, *header.Name, artifactHeaderEnvName(i, j))
+			}
+			commands = append(commands, fmt.Sprintf("retry %s %s %s", url, download, url))
+		}
+
+		// Verify the checksum of the download
+		if artifact.Checksum != nil {
+			commands = append(commands, artifactChecksumCommand(url, downloadPath, *artifact.Checksum, artifact.ChecksumType))
+		}
+
+		if extract {
+			commands = append(commands, artifactExtractCommand(url, downloadPath, targetPath), fmt.Sprintf("rm -f %s", downloadPath))
 		}
 
 		// Set permissions if specified
 		if artifact.Permissions != nil {
-			commands = append(commands, fmt.Sprintf("chmod %s %s", *artifact.Permissions, targetPath))
+			if extract {
+				commands = append(commands, fmt.Sprintf("chmod -R %s %s", *artifact.Permissions, targetPath))
+			} else {
+				commands = append(commands, fmt.Sprintf("chmod %s %s", *artifact.Permissions, targetPath))
+			}
 		}
 	}
 
-	// Join all commands with && to ensure they all succeed
-	return strings.Join(commands, " && ")
+	return strings.Join(commands, "\n")
+}
+
+// artifactChecksumCommand returns the command that fails the script when a
+// downloaded artifact does not match its checksum
+func artifactChecksumCommand(url, path, checksum string, checksumType *string) string {
+	method := "sha256"
+	if checksumType != nil {
+		method = strings.ToLower(*checksumType)
+	}
+	if method != "sha256" && method != "md5" {
+		return fmt.Sprintf("echo 'unsupported checksum type %s for %s' >&2 && exit 1", method, url)
+	}
+	if !artifactChecksumPattern.MatchString(checksum) {
+		return fmt.Sprintf("echo 'invalid checksum for %s' >&2 && exit 1", url)
+	}
+	return fmt.Sprintf("echo '%s  %s' | %ssum -c - || { echo 'checksum mismatch for %s' >&2; exit 1; }",
+		strings.ToLower(checksum), path, method, url)
+}
+
+// artifactExtractCommand returns the command that unpacks an archive, its
+// format told by the extension of the URL
+func artifactExtractCommand(url, archivePath, targetPath string) string {
+	name := strings.ToLower(strings.SplitN(url, "?", 2)[0])
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return fmt.Sprintf("unzip -o -q %s -d %s", archivePath, targetPath)
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return fmt.Sprintf("tar -xzf %s -C %s", archivePath, targetPath)
+	default:
+		return fmt.Sprintf("echo 'cannot extract %s: only .zip, .tar.gz and .tgz archives are supported' >&2 && exit 1", url)
+	}
+}
+
+// artifactEnvPrefix returns the prefix of the environment variables that
+// hold the secrets of the i-th artifact of a container
+func artifactEnvPrefix(i int) string {
+	return fmt.Sprintf("ARTIFACT_%d", i)
+}
+
+// artifactHeaderEnvName returns the environment variable holding the value
+// of the j-th header of the i-th artifact
+func artifactHeaderEnvName(i, j int) string {
+	return fmt.Sprintf("%s_HEADER_%d", artifactEnvPrefix(i), j)
+}
+
+// artifactCredentialKeys maps the environment variable suffixes of S3
+// credentials to their keys in the credentials secret
+var artifactCredentialKeys = []struct {
+	suffix string
+	key    string
+}{
+	{"ACCESS_KEY_ID", "accessKeyId"},
+	{"SECRET_ACCESS_KEY", "secretAccessKey"},
+	{"SESSION_TOKEN", "sessionToken"},
+}
+
+// artifactSecret is a secret read by an artifact download, exposed to the
+// download script as an environment variable
+type artifactSecret struct {
+	EnvName string
+	ARN     string
+}
+
+// artifactSecrets returns the secrets the artifacts of a container read
+func artifactSecrets(artifacts []types.Artifact) []artifactSecret {
+	var secrets []artifactSecret
+	for i, artifact := range artifacts {
+		for j, header := range artifact.Headers {
+			if header.ValueFrom != nil {
+				secrets = append(secrets, artifactSecret{EnvName: artifactHeaderEnvName(i, j), ARN: *header.ValueFrom})
+			}
+		}
+		if artifact.CredentialsFrom != nil {
+			// Each credential is read from its JSON key of the secret
+			parts := strings.Split(*artifact.CredentialsFrom, ":")
+			if len(parts) < 7 {
+				continue
+			}
+			base := strings.Join(parts[:7], ":")
+			for _, cred := range artifactCredentialKeys {
+				secrets = append(secrets, artifactSecret{
+					EnvName: artifactEnvPrefix(i) + "_" + cred.suffix,
+					ARN:     fmt.Sprintf("%s:%s::", base, cred.key),
+				})
+			}
+		}
+	}
+	return secrets
+}
+
+// getArtifactSecretEnvironment returns the environment variables that hold
+// the download headers and S3 credentials of artifacts, referencing the
+// Kubernetes secrets synced from Secrets Manager and SSM
+func (c *TaskConverter) getArtifactSecretEnvironment(artifacts []types.Artifact) []corev1.EnvVar {
+	env := []corev1.EnvVar{
+		{
+			Name:  "ARTIFACT_ATTEMPTS",
+			Value: strconv.Itoa(max(1, config.GetInt("artifacts.downloadAttempts"))),
+		},
+	}
+
+	for i, artifact := range artifacts {
+		for j, header := range artifact.Headers {
+			if header.Value != nil && header.ValueFrom == nil {
+				env = append(env, corev1.EnvVar{Name: artifactHeaderEnvName(i, j), Value: *header.Value})
+			}
+		}
+	}
+
+	for _, secret := range artifactSecrets(artifacts) {
+		secretInfo, err := c.parseSecretArn(secret.ARN)
+		if err != nil {
+			logging.Warn("Skipping artifact secret with invalid ARN", "arn", secret.ARN, "error", err)
+			continue
+		}
+		selector := &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{
+				Name: c.getK8sSecretName(secretInfo.Source, secretInfo.SecretName),
+			},
+			Key: secretInfo.Key,
+		}
+		// Temporary credentials are the only ones with a session token
+		if strings.HasSuffix(secret.EnvName, "_SESSION_TOKEN") {
+			selector.Optional = ptr.To(true)
+		}
+		env = append(env, corev1.EnvVar{
+			Name:      secret.EnvName,
+			ValueFrom: &corev1.EnvVarSource{SecretKeyRef: selector},
+		})
+	}
+
+	return env
 }
 
 // getArtifactEnvironment returns environment variables for artifact downloading
@@ -0,0 +1,167 @@
+package converters
+
+import (
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/types"
+)
+
+// Annotations of the PVCs backing managed EBS volumes. Kubernetes has no
+// portable way to request IOPS or throughput, so they are recorded for storage
+// classes or operators that understand them.
+const (
+	EBSSizeAnnotation                = "kecs.dev/ebs-size-gib"
+	EBSVolumeTypeAnnotation          = "kecs.dev/ebs-volume-type"
+	EBSIopsAnnotation                = "kecs.dev/ebs-iops"
+	EBSThroughputAnnotation          = "kecs.dev/ebs-throughput"
+	EBSEncryptedAnnotation           = "kecs.dev/ebs-encrypted"
+	EBSKmsKeyIdAnnotation            = "kecs.dev/ebs-kms-key-id"
+	EBSSnapshotIdAnnotation          = "kecs.dev/ebs-snapshot-id"
+	EBSFilesystemTypeAnnotation      = "kecs.dev/ebs-filesystem-type"
+	EBSDeleteOnTerminationAnnotation = "kecs.dev/ebs-delete-on-termination"
+)
+
+// defaultSnapshotVolumeSizeGiB is the size of volumes created from a snapshot
+// without sizeInGiB, as the snapshot size is unknown
+const defaultSnapshotVolumeSizeGiB = 1
+
+// ValidateVolumeConfigurations validates the volumeConfigurations of RunTask,
+// CreateService and UpdateService against the volumes of the task
+// definition the way ECS does. Every configuration must name a volume that is
+// configuredAtLaunch and needs sizeInGiB or snapshotId.
+func ValidateVolumeConfigurations(volumes []types.Volume, configs []types.VolumeConfiguration) error {
+	seen := make(map[string]bool, len(configs))
+	for _, cfg := range configs {
+		if cfg.Name == "" {
+			return fmt.Errorf("volume configuration name is required")
+		}
+		if seen[cfg.Name] {
+			return fmt.Errorf("volume %s is configured more than once", cfg.Name)
+		}
+		seen[cfg.Name] = true
+
+		vol := findVolume(volumes, cfg.Name)
+		if vol == nil {
+			return fmt.Errorf("volume %s is not defined in the task definition", cfg.Name)
+		}
+		if vol.ConfiguredAtLaunch == nil || !*vol.ConfiguredAtLaunch {
+			return fmt.Errorf("volume %s is not configured at launch in the task definition", cfg.Name)
+		}
+		if cfg.ManagedEBSVolume == nil {
+			return fmt.Errorf("volume %s requires a managedEBSVolume configuration", cfg.Name)
+		}
+		ebs := cfg.ManagedEBSVolume
+		if ebs.SizeInGiB == nil && ebs.SnapshotId == nil {
+			return fmt.Errorf("volume %s requires sizeInGiB or snapshotId", cfg.Name)
+		}
+		if ebs.SizeInGiB != nil && *ebs.SizeInGiB < 1 {
+			return fmt.Errorf("volume %s has an invalid sizeInGiB: %d", cfg.Name, *ebs.SizeInGiB)
+		}
+	}
+	return nil
+}
+
+// findVolume returns the volume of the task definition with the given name
+func findVolume(volumes []types.Volume, name string) *types.Volume {
+	for i := range volumes {
+		if volumes[i].Name != nil && *volumes[i].Name == name {
+			return &volumes[i]
+		}
+	}
+	return nil
+}
+
+// findVolumeConfiguration returns the configuration of a volume configured at launch
+func findVolumeConfiguration(configs []types.VolumeConfiguration, name string) *types.VolumeConfiguration {
+	for i := range configs {
+		if configs[i].Name == name {
+			return &configs[i]
+		}
+	}
+	return nil
+}
+
+// convertManagedEBSVolume converts a managed EBS volume to a generic ephemeral
+// volume. Kubernetes creates the PVC <pod>-<volume> with the pod and deletes it
+// with the pod through its owner reference; StopTask releases the PVCs of
+// volumes that are not deleted on termination.
+func convertManagedEBSVolume(ebs *types.ManagedEBSVolumeConfiguration, deleteOnTermination bool) corev1.VolumeSource {
+	sizeGiB := int32(defaultSnapshotVolumeSizeGiB)
+	if ebs.SizeInGiB != nil {
+		sizeGiB = *ebs.SizeInGiB
+	}
+
+	annotations := map[string]string{
+		EBSSizeAnnotation:                strconv.Itoa(int(sizeGiB)),
+		EBSDeleteOnTerminationAnnotation: strconv.FormatBool(deleteOnTermination),
+	}
+	if ebs.VolumeType != nil {
+		annotations[EBSVolumeTypeAnnotation] = *ebs.VolumeType
+	}
+	if ebs.Iops != nil {
+		annotations[EBSIopsAnnotation] = strconv.Itoa(int(*ebs.Iops))
+	}
+	if ebs.Throughput != nil {
+		annotations[EBSThroughputAnnotation] = strconv.Itoa(int(*ebs.Throughput))
+	}
+	if ebs.Encrypted != nil {
+		annotations[EBSEncryptedAnnotation] = strconv.FormatBool(*ebs.Encrypted)
+	}
+	if ebs.KmsKeyId != nil {
+		annotations[EBSKmsKeyIdAnnotation] = *ebs.KmsKeyId
+	}
+	if ebs.SnapshotId != nil {
+		annotations[EBSSnapshotIdAnnotation] = *ebs.SnapshotId
+	}
+	if ebs.FilesystemType != nil {
+		annotations[EBSFilesystemTypeAnnotation] = *ebs.FilesystemType
+	}
+
+	spec := corev1.PersistentVolumeClaimSpec{
+		AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+		Resources: corev1.VolumeResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceStorage: resource.MustParse(fmt.Sprintf("%dGi", sizeGiB)),
+			},
+		},
+	}
+	if storageClass := config.GetString("volumes.ebs.storageClass"); storageClass != "" {
+		spec.StorageClassName = ptr.To(storageClass)
+	}
+
+	return corev1.VolumeSource{
+		Ephemeral: &corev1.EphemeralVolumeSource{
+			VolumeClaimTemplate: &corev1.PersistentVolumeClaimTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{"kecs.dev/managed-by": "kecs"},
+					Annotations: annotations,
+				},
+				Spec: spec,
+			},
+		},
+	}
+}
+
+// convertVolumeConfiguredAtLaunch converts a volume configured at launch. The
+// volume is an emptyDir when the request does not configure it, as ECS starts
+// such tasks without the volume.
+func convertVolumeConfiguredAtLaunch(name string, configs []types.VolumeConfiguration, alwaysDelete bool) corev1.VolumeSource {
+	cfg := findVolumeConfiguration(configs, name)
+	if cfg == nil || cfg.ManagedEBSVolume == nil {
+		return corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}
+	}
+
+	// Task volumes are deleted on termination unless the policy says otherwise
+	deleteOnTermination := true
+	if !alwaysDelete && cfg.ManagedEBSVolume.TerminationPolicy != nil {
+		deleteOnTermination = cfg.ManagedEBSVolume.TerminationPolicy.DeleteOnTermination
+	}
+	return convertManagedEBSVolume(cfg.ManagedEBSVolume, deleteOnTermination)
+}
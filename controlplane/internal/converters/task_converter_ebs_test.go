@@ -0,0 +1,210 @@
+package converters_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/types"
+)
+
+var _ = Describe("Managed EBS Volumes", func() {
+	var (
+		cluster *storage.Cluster
+		taskDef *storage.TaskDefinition
+	)
+
+	BeforeEach(func() {
+		cluster = &storage.Cluster{
+			Name:   "test-cluster",
+			Region: "us-east-1",
+			Status: "ACTIVE",
+		}
+		taskDef = &storage.TaskDefinition{
+			Family:   "db",
+			Revision: 1,
+			ContainerDefinitions: mustMarshalContainerDefs([]types.ContainerDefinition{
+				{
+					Name:  strPtr("db"),
+					Image: strPtr("postgres:16"),
+					MountPoints: []types.MountPoint{
+						{SourceVolume: strPtr("data"), ContainerPath: strPtr("/var/lib/postgresql/data")},
+					},
+				},
+			}),
+			Volumes: `[{"name":"data","configuredAtLaunch":true},{"name":"scratch"}]`,
+		}
+	})
+
+	findVolume := func(volumes []corev1.Volume, name string) *corev1.Volume {
+		for i := range volumes {
+			if volumes[i].Name == name {
+				return &volumes[i]
+			}
+		}
+		return nil
+	}
+
+	Describe("ValidateVolumeConfigurations", func() {
+		var volumes []types.Volume
+
+		BeforeEach(func() {
+			Expect(json.Unmarshal([]byte(taskDef.Volumes), &volumes)).To(Succeed())
+		})
+
+		It("should accept a sized volume configured at launch", func() {
+			err := converters.ValidateVolumeConfigurations(volumes, []types.VolumeConfiguration{
+				{Name: "data", ManagedEBSVolume: &types.ManagedEBSVolumeConfiguration{SizeInGiB: ptr.To(int32(10))}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should accept a volume restored from a snapshot", func() {
+			err := converters.ValidateVolumeConfigurations(volumes, []types.VolumeConfiguration{
+				{Name: "data", ManagedEBSVolume: &types.ManagedEBSVolumeConfiguration{SnapshotId: strPtr("snap-0123456789abcdef0")}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should reject volumes that are not configured at launch", func() {
+			err := converters.ValidateVolumeConfigurations(volumes, []types.VolumeConfiguration{
+				{Name: "scratch", ManagedEBSVolume: &types.ManagedEBSVolumeConfiguration{SizeInGiB: ptr.To(int32(10))}},
+			})
+			Expect(err).To(MatchError(ContainSubstring("not configured at launch")))
+		})
+
+		It("should reject unknown volumes", func() {
+			err := converters.ValidateVolumeConfigurations(volumes, []types.VolumeConfiguration{
+				{Name: "missing", ManagedEBSVolume: &types.ManagedEBSVolumeConfiguration{SizeInGiB: ptr.To(int32(10))}},
+			})
+			Expect(err).To(MatchError(ContainSubstring("not defined in the task definition")))
+		})
+
+		It("should reject volumes without size or snapshot", func() {
+			err := converters.ValidateVolumeConfigurations(volumes, []types.VolumeConfiguration{
+				{Name: "data", ManagedEBSVolume: &types.ManagedEBSVolumeConfiguration{}},
+			})
+			Expect(err).To(MatchError(ContainSubstring("requires sizeInGiB or snapshotId")))
+		})
+
+		It("should reject a volume configured twice", func() {
+			volumeConfig := types.VolumeConfiguration{Name: "data", ManagedEBSVolume: &types.ManagedEBSVolumeConfiguration{SizeInGiB: ptr.To(int32(10))}}
+			err := converters.ValidateVolumeConfigurations(volumes, []types.VolumeConfiguration{volumeConfig, volumeConfig})
+			Expect(err).To(MatchError(ContainSubstring("configured more than once")))
+		})
+	})
+
+	Describe("TaskConverter", func() {
+		var converter *converters.TaskConverter
+
+		BeforeEach(func() {
+			converter = converters.NewTaskConverter("us-east-1", "123456789012")
+		})
+
+		AfterEach(func() {
+			config.Set("volumes.ebs.storageClass", "")
+		})
+
+		It("should provision a volume claim with the requested size and attributes", func() {
+			config.Set("volumes.ebs.storageClass", "local-path")
+
+			pod, err := converter.ConvertTaskToPod(taskDef, []byte(`{
+				"volumeConfigurations": [{
+					"name": "data",
+					"managedEBSVolume": {
+						"roleArn": "arn:aws:iam::123456789012:role/ecsInfrastructureRole",
+						"sizeInGiB": 50,
+						"volumeType": "io2",
+						"iops": 5000,
+						"throughput": 250,
+						"encrypted": true,
+						"filesystemType": "xfs"
+					}
+				}]
+			}`), cluster, "test-task-123")
+			Expect(err).NotTo(HaveOccurred())
+
+			volume := findVolume(pod.Spec.Volumes, "data")
+			Expect(volume).NotTo(BeNil())
+			Expect(volume.Ephemeral).NotTo(BeNil())
+			template := volume.Ephemeral.VolumeClaimTemplate
+			Expect(template.Spec.AccessModes).To(ConsistOf(corev1.ReadWriteOnce))
+			Expect(template.Spec.Resources.Requests.Storage().String()).To(Equal("50Gi"))
+			Expect(*template.Spec.StorageClassName).To(Equal("local-path"))
+			Expect(template.Annotations).To(HaveKeyWithValue(converters.EBSSizeAnnotation, "50"))
+			Expect(template.Annotations).To(HaveKeyWithValue(converters.EBSVolumeTypeAnnotation, "io2"))
+			Expect(template.Annotations).To(HaveKeyWithValue(converters.EBSIopsAnnotation, "5000"))
+			Expect(template.Annotations).To(HaveKeyWithValue(converters.EBSThroughputAnnotation, "250"))
+			Expect(template.Annotations).To(HaveKeyWithValue(converters.EBSEncryptedAnnotation, "true"))
+			Expect(template.Annotations).To(HaveKeyWithValue(converters.EBSFilesystemTypeAnnotation, "xfs"))
+			Expect(template.Annotations).To(HaveKeyWithValue(converters.EBSDeleteOnTerminationAnnotation, "true"))
+
+			Expect(pod.Spec.Containers[0].VolumeMounts).To(ContainElement(HaveField("Name", "data")))
+			Expect(findVolume(pod.Spec.Volumes, "scratch").EmptyDir).NotTo(BeNil())
+		})
+
+		It("should use the default storage class when none is configured", func() {
+			pod, err := converter.ConvertTaskToPod(taskDef, []byte(`{
+				"volumeConfigurations": [{"name": "data", "managedEBSVolume": {"roleArn": "role", "snapshotId": "snap-1"}}]
+			}`), cluster, "test-task-123")
+			Expect(err).NotTo(HaveOccurred())
+
+			template := findVolume(pod.Spec.Volumes, "data").Ephemeral.VolumeClaimTemplate
+			Expect(template.Spec.StorageClassName).To(BeNil())
+			Expect(template.Spec.Resources.Requests.Storage().String()).To(Equal("1Gi"))
+			Expect(template.Annotations).To(HaveKeyWithValue(converters.EBSSnapshotIdAnnotation, "snap-1"))
+		})
+
+		It("should keep volumes that are not deleted on termination", func() {
+			pod, err := converter.ConvertTaskToPod(taskDef, []byte(`{
+				"volumeConfigurations": [{
+					"name": "data",
+					"managedEBSVolume": {"roleArn": "role", "sizeInGiB": 5, "terminationPolicy": {"deleteOnTermination": false}}
+				}]
+			}`), cluster, "test-task-123")
+			Expect(err).NotTo(HaveOccurred())
+
+			template := findVolume(pod.Spec.Volumes, "data").Ephemeral.VolumeClaimTemplate
+			Expect(template.Annotations).To(HaveKeyWithValue(converters.EBSDeleteOnTerminationAnnotation, "false"))
+		})
+
+		It("should start without the volume when the task does not configure it", func() {
+			pod, err := converter.ConvertTaskToPod(taskDef, []byte("{}"), cluster, "test-task-123")
+			Expect(err).NotTo(HaveOccurred())
+
+			volume := findVolume(pod.Spec.Volumes, "data")
+			Expect(volume.Ephemeral).To(BeNil())
+			Expect(volume.EmptyDir).NotTo(BeNil())
+		})
+	})
+
+	Describe("ServiceConverter", func() {
+		It("should provision a volume claim per task that is always deleted", func() {
+			converter := converters.NewServiceConverter("us-east-1", "123456789012")
+			service := &storage.Service{
+				ServiceName:          "db",
+				DesiredCount:         2,
+				LaunchType:           "FARGATE",
+				ARN:                  "arn:aws:ecs:us-east-1:123456789012:service/test-cluster/db",
+				VolumeConfigurations: `[{"name":"data","managedEBSVolume":{"roleArn":"role","sizeInGiB":8,"volumeType":"gp3"}}]`,
+			}
+
+			deployment, _, err := converter.ConvertServiceToDeployment(service, taskDef, cluster)
+			Expect(err).NotTo(HaveOccurred())
+
+			volume := findVolume(deployment.Spec.Template.Spec.Volumes, "data")
+			Expect(volume).NotTo(BeNil())
+			Expect(volume.Ephemeral).NotTo(BeNil())
+			template := volume.Ephemeral.VolumeClaimTemplate
+			Expect(template.Spec.Resources.Requests.Storage().String()).To(Equal("8Gi"))
+			Expect(template.Annotations).To(HaveKeyWithValue(converters.EBSVolumeTypeAnnotation, "gp3"))
+			Expect(template.Annotations).To(HaveKeyWithValue(converters.EBSDeleteOnTerminationAnnotation, "true"))
+		})
+	})
+})
@@ -0,0 +1,118 @@
+package converters
+
+import (
+	"fmt"
+	"path"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/efs"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/types"
+)
+
+// NFSServerEnabled reports whether EFS volumes are served by the in-cluster
+// NFS server instead of the EFS endpoints of AWS
+func NFSServerEnabled() bool {
+	return config.GetBool("efs.nfsServer.enabled")
+}
+
+// UsesNFSServer reports whether a pod mounts volumes of the in-cluster NFS server
+func UsesNFSServer(spec *corev1.PodSpec) bool {
+	server := config.GetString("efs.nfsServer.clusterIP")
+	for _, vol := range spec.Volumes {
+		if vol.NFS != nil && vol.NFS.Server == server {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveEFSMount resolves an EFS volume to its mount on the in-cluster NFS server
+func resolveEFSMount(efsConfig *types.EFSVolumeConfiguration) (efs.Mount, error) {
+	accessPoints, err := efs.ParseAccessPoints(config.GetString("efs.accessPoints"))
+	if err != nil {
+		return efs.Mount{}, fmt.Errorf("invalid efs.accessPoints: %w", err)
+	}
+
+	var rootDirectory, accessPointID string
+	if efsConfig.RootDirectory != nil {
+		rootDirectory = *efsConfig.RootDirectory
+	}
+	if efsConfig.AuthorizationConfig != nil && efsConfig.AuthorizationConfig.AccessPointId != nil {
+		accessPointID = *efsConfig.AuthorizationConfig.AccessPointId
+	}
+	return efs.Resolve(*efsConfig.FileSystemId, rootDirectory, accessPointID, accessPoints)
+}
+
+// validateEFSVolumes checks that the EFS volumes can be served by the
+// in-cluster NFS server, e.g. that their access points belong to the file system
+func validateEFSVolumes(volumes []types.Volume) error {
+	if !NFSServerEnabled() {
+		return nil
+	}
+	for _, vol := range volumes {
+		if vol.Name == nil || vol.EfsVolumeConfiguration == nil || vol.EfsVolumeConfiguration.FileSystemId == nil {
+			continue
+		}
+		mount, err := resolveEFSMount(vol.EfsVolumeConfiguration)
+		if err != nil {
+			return fmt.Errorf("invalid EFS volume %s: %w", *vol.Name, err)
+		}
+		if auth := vol.EfsVolumeConfiguration.AuthorizationConfig; auth != nil && auth.AccessPointId != nil && mount.SubPath != "" {
+			logging.Warn("EFS access point is not configured, mounting the file system", "accessPoint", *auth.AccessPointId, "fileSystem", *vol.EfsVolumeConfiguration.FileSystemId)
+		}
+	}
+	return nil
+}
+
+// convertEFSVolumeToNFSServer converts an EFS volume to an NFS volume of the
+// in-cluster NFS server. Volumes are validated beforehand.
+func convertEFSVolumeToNFSServer(efsConfig *types.EFSVolumeConfiguration) corev1.VolumeSource {
+	mount, err := resolveEFSMount(efsConfig)
+	if err != nil {
+		return corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}
+	}
+	return corev1.VolumeSource{
+		NFS: &corev1.NFSVolumeSource{
+			Server: config.GetString("efs.nfsServer.clusterIP"),
+			Path:   mount.Path,
+		},
+	}
+}
+
+// applyEFSSubPaths mounts the EFS volumes without access point at the
+// directory of their file system. Kubelet creates the directory on first use,
+// which the NFS server cannot know in advance.
+func applyEFSSubPaths(spec *corev1.PodSpec, volumes []types.Volume) {
+	if !NFSServerEnabled() {
+		return
+	}
+
+	subPaths := make(map[string]string)
+	for _, vol := range volumes {
+		if vol.Name == nil || vol.EfsVolumeConfiguration == nil || vol.EfsVolumeConfiguration.FileSystemId == nil {
+			continue
+		}
+		if mount, err := resolveEFSMount(vol.EfsVolumeConfiguration); err == nil && mount.SubPath != "" {
+			subPaths[*vol.Name] = mount.SubPath
+		}
+	}
+	if len(subPaths) == 0 {
+		return
+	}
+
+	apply := func(containers []corev1.Container) {
+		for i := range containers {
+			for j := range containers[i].VolumeMounts {
+				mount := &containers[i].VolumeMounts[j]
+				if subPath, ok := subPaths[mount.Name]; ok {
+					mount.SubPath = path.Join(subPath, mount.SubPath)
+				}
+			}
+		}
+	}
+	apply(spec.InitContainers)
+	apply(spec.Containers)
+}
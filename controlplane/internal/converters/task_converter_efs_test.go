@@ -0,0 +1,123 @@
+package converters_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/types"
+)
+
+var _ = Describe("EFS Volumes on the in-cluster NFS server", func() {
+	var (
+		cluster *storage.Cluster
+		taskDef *storage.TaskDefinition
+	)
+
+	BeforeEach(func() {
+		config.Set("efs.nfsServer.enabled", true)
+		config.Set("efs.accessPoints", "fsap-app=fs-1:/app:1000:1000")
+
+		cluster = &storage.Cluster{
+			Name:   "test-cluster",
+			Region: "us-east-1",
+			Status: "ACTIVE",
+		}
+		taskDef = &storage.TaskDefinition{
+			Family:   "web",
+			Revision: 1,
+			ContainerDefinitions: mustMarshalContainerDefs([]types.ContainerDefinition{
+				{
+					Name:  strPtr("web"),
+					Image: strPtr("nginx:latest"),
+					MountPoints: []types.MountPoint{
+						{SourceVolume: strPtr("uploads"), ContainerPath: strPtr("/uploads")},
+						{SourceVolume: strPtr("shared"), ContainerPath: strPtr("/shared")},
+					},
+				},
+			}),
+			Volumes: `[
+				{"name":"uploads","efsVolumeConfiguration":{"fileSystemId":"fs-1","authorizationConfig":{"accessPointId":"fsap-app","iam":"ENABLED"},"transitEncryption":"ENABLED"}},
+				{"name":"shared","efsVolumeConfiguration":{"fileSystemId":"fs-2","rootDirectory":"/team"}}
+			]`,
+		}
+	})
+
+	AfterEach(func() {
+		config.Set("efs.nfsServer.enabled", false)
+		config.Set("efs.accessPoints", "")
+	})
+
+	findVolume := func(volumes []corev1.Volume, name string) *corev1.Volume {
+		for i := range volumes {
+			if volumes[i].Name == name {
+				return &volumes[i]
+			}
+		}
+		return nil
+	}
+
+	findMount := func(mounts []corev1.VolumeMount, name string) *corev1.VolumeMount {
+		for i := range mounts {
+			if mounts[i].Name == name {
+				return &mounts[i]
+			}
+		}
+		return nil
+	}
+
+	It("should mount the exports of the NFS server in tasks", func() {
+		converter := converters.NewTaskConverter("us-east-1", "123456789012")
+		pod, err := converter.ConvertTaskToPod(taskDef, []byte("{}"), cluster, "test-task-123")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(converters.UsesNFSServer(&pod.Spec)).To(BeTrue())
+
+		uploads := findVolume(pod.Spec.Volumes, "uploads")
+		Expect(uploads.NFS).NotTo(BeNil())
+		Expect(uploads.NFS.Server).To(Equal("10.43.0.49"))
+		Expect(uploads.NFS.Path).To(Equal("/fs-1/app"))
+		Expect(findMount(pod.Spec.Containers[0].VolumeMounts, "uploads").SubPath).To(BeEmpty())
+
+		shared := findVolume(pod.Spec.Volumes, "shared")
+		Expect(shared.NFS.Path).To(Equal("/"))
+		Expect(findMount(pod.Spec.Containers[0].VolumeMounts, "shared").SubPath).To(Equal("fs-2/team"))
+	})
+
+	It("should mount the exports of the NFS server in services", func() {
+		converter := converters.NewServiceConverter("us-east-1", "123456789012")
+		service := &storage.Service{
+			ServiceName:  "web",
+			DesiredCount: 1,
+			LaunchType:   "FARGATE",
+			ARN:          "arn:aws:ecs:us-east-1:123456789012:service/test-cluster/web",
+		}
+
+		deployment, _, err := converter.ConvertServiceToDeployment(service, taskDef, cluster)
+		Expect(err).NotTo(HaveOccurred())
+
+		spec := deployment.Spec.Template.Spec
+		Expect(findVolume(spec.Volumes, "uploads").NFS.Path).To(Equal("/fs-1/app"))
+		Expect(findMount(spec.Containers[0].VolumeMounts, "shared").SubPath).To(Equal("fs-2/team"))
+	})
+
+	It("should reject an access point of another file system", func() {
+		config.Set("efs.accessPoints", "fsap-app=fs-9:/app")
+
+		converter := converters.NewTaskConverter("us-east-1", "123456789012")
+		_, err := converter.ConvertTaskToPod(taskDef, []byte("{}"), cluster, "test-task-123")
+		Expect(err).To(MatchError(ContainSubstring("invalid EFS volume uploads")))
+	})
+
+	It("should keep the AWS endpoints when the NFS server is disabled", func() {
+		config.Set("efs.nfsServer.enabled", false)
+
+		converter := converters.NewTaskConverter("us-east-1", "123456789012")
+		pod, err := converter.ConvertTaskToPod(taskDef, []byte("{}"), cluster, "test-task-123")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(findVolume(pod.Spec.Volumes, "uploads").NFS.Server).To(Equal("fs-1.efs.us-east-1.amazonaws.com"))
+		Expect(findMount(pod.Spec.Containers[0].VolumeMounts, "shared").SubPath).To(BeEmpty())
+	})
+})
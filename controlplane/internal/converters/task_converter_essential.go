@@ -0,0 +1,62 @@
+package converters
+
+import (
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/types"
+)
+
+// NonEssentialContainersAnnotation lists the containers of a pod whose
+// container definitions are not essential, comma separated
+const NonEssentialContainersAnnotation = "kecs.dev/nonessential-containers"
+
+// setNonEssentialAnnotation records the containers that are not essential.
+// Kubernetes has no notion of essential containers, the sync controller stops
+// the task once one of the others exits.
+func setNonEssentialAnnotation(annotations map[string]string, containerDefs []types.ContainerDefinition) {
+	var names []string
+	for _, def := range containerDefs {
+		if def.Name != nil && def.Essential != nil && !*def.Essential {
+			names = append(names, *def.Name)
+		}
+	}
+	if len(names) > 0 {
+		annotations[NonEssentialContainersAnnotation] = strings.Join(names, ",")
+	}
+}
+
+// IsEssentialContainer reports whether a container of a task pod is essential.
+// Containers are essential unless listed in NonEssentialContainersAnnotation.
+func IsEssentialContainer(pod *corev1.Pod, containerName string) bool {
+	names := pod.Annotations[NonEssentialContainersAnnotation]
+	return names == "" || !slices.Contains(strings.Split(names, ","), containerName)
+}
+
+// ExitedEssentialContainer returns the status of an essential container of a
+// task pod that exited and will not be restarted, nil when there is none.
+// Native sidecars are always restarted, the other containers follow the
+// restart policy of the pod.
+func ExitedEssentialContainer(pod *corev1.Pod) *corev1.ContainerStatus {
+	containers, statuses := TaskContainers(pod)
+	for i, container := range containers {
+		status := statuses[i]
+		if status == nil || status.State.Terminated == nil || IsSidecarContainer(container) {
+			continue
+		}
+		if !IsEssentialContainer(pod, container.Name) {
+			continue
+		}
+		switch pod.Spec.RestartPolicy {
+		case corev1.RestartPolicyNever:
+			return status
+		case corev1.RestartPolicyOnFailure:
+			if status.State.Terminated.ExitCode == 0 {
+				return status
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package converters_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+)
+
+var _ = Describe("Essential Containers", func() {
+	terminated := func(name string, exitCode int32) corev1.ContainerStatus {
+		return corev1.ContainerStatus{
+			Name:  name,
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: exitCode}},
+		}
+	}
+	running := func(name string) corev1.ContainerStatus {
+		return corev1.ContainerStatus{
+			Name:  name,
+			State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+		}
+	}
+
+	newPod := func(restartPolicy corev1.RestartPolicy, statuses ...corev1.ContainerStatus) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{converters.NonEssentialContainersAnnotation: "migrate,agent"},
+			},
+			Spec: corev1.PodSpec{
+				RestartPolicy: restartPolicy,
+				Containers:    []corev1.Container{{Name: "app"}, {Name: "migrate"}, {Name: "agent"}},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning, ContainerStatuses: statuses},
+		}
+	}
+
+	It("should tell essential containers from the annotation", func() {
+		pod := newPod(corev1.RestartPolicyNever)
+		Expect(converters.IsEssentialContainer(pod, "app")).To(BeTrue())
+		Expect(converters.IsEssentialContainer(pod, "migrate")).To(BeFalse())
+		Expect(converters.IsEssentialContainer(pod, "agent")).To(BeFalse())
+		Expect(converters.IsEssentialContainer(&corev1.Pod{}, "app")).To(BeTrue())
+	})
+
+	It("should keep the task running when non-essential containers exit", func() {
+		pod := newPod(corev1.RestartPolicyNever, running("app"), terminated("migrate", 0), terminated("agent", 1))
+		Expect(converters.ExitedEssentialContainer(pod)).To(BeNil())
+	})
+
+	It("should report an essential container that exited", func() {
+		pod := newPod(corev1.RestartPolicyNever, terminated("app", 2), running("agent"))
+		status := converters.ExitedEssentialContainer(pod)
+		Expect(status).NotTo(BeNil())
+		Expect(status.Name).To(Equal("app"))
+	})
+
+	It("should not report essential containers that are restarted", func() {
+		Expect(converters.ExitedEssentialContainer(newPod(corev1.RestartPolicyAlways, terminated("app", 0)))).To(BeNil())
+		Expect(converters.ExitedEssentialContainer(newPod(corev1.RestartPolicyOnFailure, terminated("app", 1)))).To(BeNil())
+		Expect(converters.ExitedEssentialContainer(newPod(corev1.RestartPolicyOnFailure, terminated("app", 0)))).NotTo(BeNil())
+	})
+})
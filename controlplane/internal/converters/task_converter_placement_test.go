@@ -0,0 +1,175 @@
+package converters
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/types"
+)
+
+type fakeZoneResolver map[string]string
+
+func (f fakeZoneResolver) ZoneForSubnet(subnetID string) (string, error) {
+	if zone, ok := f[subnetID]; ok {
+		return zone, nil
+	}
+	return "", fmt.Errorf("subnet %s not found", subnetID)
+}
+
+func newPlacementTestPod() *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      map[string]string{"kecs.dev/task-family": "web"},
+			Annotations: map[string]string{},
+		},
+	}
+}
+
+func TestApplySubnetZones(t *testing.T) {
+	resolver := fakeZoneResolver{
+		"subnet-a":  "us-east-1a",
+		"subnet-a2": "us-east-1a",
+		"subnet-b":  "us-east-1b",
+	}
+
+	pod := newPlacementTestPod()
+	applySubnetZones(resolver, pod.Annotations, &pod.Spec, []string{"subnet-a", "subnet-a2", "subnet-b", "subnet-unknown"})
+
+	assert.Equal(t, "us-east-1a,us-east-1b", pod.Annotations["kecs.dev/availability-zones"])
+	terms := pod.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	assert.Len(t, terms, 1)
+	assert.Equal(t, "topology.kubernetes.io/zone", terms[0].Preference.MatchExpressions[0].Key)
+	assert.Equal(t, []string{"us-east-1a", "us-east-1b"}, terms[0].Preference.MatchExpressions[0].Values)
+}
+
+func TestServiceConverterSubnetZones(t *testing.T) {
+	converter := NewServiceConverter("us-east-1", "123456789012")
+	converter.SetSubnetZoneResolver(fakeZoneResolver{"subnet-b": "us-east-1b"})
+
+	deployment, _, err := converter.ConvertServiceToDeploymentWithNetworkConfig(
+		&storage.Service{ServiceName: "web", DesiredCount: 1},
+		&storage.TaskDefinition{Family: "web", NetworkMode: "awsvpc", ContainerDefinitions: `[{"name":"web","image":"nginx"}]`},
+		&storage.Cluster{Name: "default", Region: "us-east-1"},
+		&generated.NetworkConfiguration{AwsvpcConfiguration: &generated.AwsVpcConfiguration{Subnets: []string{"subnet-b"}}},
+	)
+	assert.NoError(t, err)
+
+	template := deployment.Spec.Template
+	assert.Equal(t, "us-east-1b", template.Annotations["kecs.dev/availability-zones"])
+	terms := template.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	assert.Len(t, terms, 1)
+	assert.Equal(t, []string{"us-east-1b"}, terms[0].Preference.MatchExpressions[0].Values)
+}
+
+func TestApplySubnetZonesWithoutResolver(t *testing.T) {
+	pod := newPlacementTestPod()
+	applySubnetZones(nil, pod.Annotations, &pod.Spec, []string{"subnet-a"})
+
+	assert.Nil(t, pod.Spec.Affinity)
+}
+
+func TestApplyPlacementStrategy(t *testing.T) {
+	converter := NewTaskConverter("us-east-1", "123456789012")
+
+	pod := newPlacementTestPod()
+	converter.applyPlacementStrategy(pod, []types.PlacementStrategy{
+		{Type: ptr.To("spread"), Field: ptr.To("attribute:ecs.availability-zone")},
+		{Type: ptr.To("spread"), Field: ptr.To("instanceId")},
+		{Type: ptr.To("binpack"), Field: ptr.To("memory")},
+	})
+
+	constraints := pod.Spec.TopologySpreadConstraints
+	assert.Len(t, constraints, 2)
+	assert.Equal(t, "topology.kubernetes.io/zone", constraints[0].TopologyKey)
+	assert.Equal(t, corev1.ScheduleAnyway, constraints[0].WhenUnsatisfiable)
+	assert.Equal(t, "kubernetes.io/hostname", constraints[1].TopologyKey)
+	assert.Equal(t, "web", constraints[0].LabelSelector.MatchLabels["kecs.dev/task-family"])
+}
+
+func newPlacementTestTaskDefinition(placementConstraints string) *storage.TaskDefinition {
+	return &storage.TaskDefinition{
+		Family:               "web",
+		Revision:             1,
+		ContainerDefinitions: `[{"name":"app","image":"nginx:latest"}]`,
+		PlacementConstraints: placementConstraints,
+	}
+}
+
+func TestTaskDefinitionPlacementConstraints(t *testing.T) {
+	converter := NewTaskConverter("us-east-1", "123456789012")
+	cluster := &storage.Cluster{Name: "default"}
+	taskDef := newPlacementTestTaskDefinition(`[{"type":"memberOf","expression":"attribute:ecs.instance-type == t3.large"}]`)
+
+	pod, err := converter.ConvertTaskToPod(taskDef, []byte(`{}`), cluster, "task-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "t3.large", pod.Spec.NodeSelector["node.kubernetes.io/instance-type"])
+}
+
+func TestTaskDefinitionPlacementConstraintsMergedWithRequest(t *testing.T) {
+	converter := NewTaskConverter("us-east-1", "123456789012")
+	cluster := &storage.Cluster{Name: "default"}
+	taskDef := newPlacementTestTaskDefinition(`[
+		{"type":"memberOf","expression":"attribute:ecs.instance-type == t3.large"},
+		{"type":"memberOf","expression":"attribute:ecs.availability-zone == us-east-1a"}
+	]`)
+	runTaskReq := `{"placementConstraints":[
+		{"type":"memberOf","expression":"attribute:ecs.os-type == linux"},
+		{"type":"memberOf","expression":"attribute:ecs.availability-zone == us-east-1b"},
+		{"type":"distinctInstance"}
+	]}`
+
+	pod, err := converter.ConvertTaskToPod(taskDef, []byte(runTaskReq), cluster, "task-1")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"node.kubernetes.io/instance-type": "t3.large",
+		"topology.kubernetes.io/zone":      "us-east-1a",
+		"kubernetes.io/os":                 "linux",
+	}, pod.Spec.NodeSelector)
+
+	// The conflicting zone must hold as well rather than replace the first
+	terms := pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	assert.Len(t, terms, 1)
+	assert.Equal(t, []corev1.NodeSelectorRequirement{{
+		Key:      "topology.kubernetes.io/zone",
+		Operator: corev1.NodeSelectorOpIn,
+		Values:   []string{"us-east-1b"},
+	}}, terms[0].MatchExpressions)
+	assert.Len(t, pod.Spec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, 1)
+}
+
+func TestTaskDefinitionPlacementConstraintsIgnoreUnsupportedTypes(t *testing.T) {
+	converter := NewTaskConverter("us-east-1", "123456789012")
+	cluster := &storage.Cluster{Name: "default"}
+	taskDef := newPlacementTestTaskDefinition(`[{"type":"distinctInstance"}]`)
+
+	pod, err := converter.ConvertTaskToPod(taskDef, []byte(`{}`), cluster, "task-1")
+	assert.NoError(t, err)
+	assert.Nil(t, pod.Spec.Affinity)
+	assert.Empty(t, pod.Spec.NodeSelector)
+}
+
+func TestServiceConverterTaskDefinitionPlacementConstraints(t *testing.T) {
+	converter := NewServiceConverter("us-east-1", "123456789012")
+	taskDef := newPlacementTestTaskDefinition(`[
+		{"type":"memberOf","expression":"attribute:ecs.instance-type == t3.large"},
+		{"type":"distinctInstance"}
+	]`)
+
+	deployment, _, err := converter.ConvertServiceToDeployment(
+		&storage.Service{ServiceName: "web", DesiredCount: 1},
+		taskDef,
+		&storage.Cluster{Name: "default", Region: "us-east-1"},
+	)
+	assert.NoError(t, err)
+
+	spec := deployment.Spec.Template.Spec
+	assert.Equal(t, map[string]string{"node.kubernetes.io/instance-type": "t3.large"}, spec.NodeSelector)
+	assert.Nil(t, spec.Affinity.PodAntiAffinity)
+}
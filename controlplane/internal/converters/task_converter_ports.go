@@ -0,0 +1,300 @@
+package converters
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/types"
+)
+
+// PortRangesAnnotation records the containerPortRanges of the containers of a
+// pod. Ranges are expanded to single container ports, the annotation lets
+// DescribeTasks report them as ranges again.
+const PortRangesAnnotation = "kecs.dev/container-port-ranges"
+
+// maxPortRangesPerContainer is the number of containerPortRanges ECS accepts
+// per container
+const maxPortRangesPerContainer = 100
+
+// maxContainerPorts bounds the ports a container exposes, as every port of a
+// range becomes a port of the pod
+const maxContainerPorts = 1000
+
+// PortRange is an inclusive range of ports, written as "8000-8010"
+type PortRange struct {
+	Start int32
+	End   int32
+}
+
+// ParsePortRange parses a port range such as the containerPortRange of a
+// port mapping. The start must be lower than the end, as in ECS.
+func ParsePortRange(s string) (PortRange, error) {
+	startValue, endValue, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if !ok {
+		return PortRange{}, fmt.Errorf("invalid port range %q, expected start-end", s)
+	}
+	start, err := strconv.ParseInt(strings.TrimSpace(startValue), 10, 32)
+	if err != nil || start < 1 || start > 65535 {
+		return PortRange{}, fmt.Errorf("invalid port range %q: start must be between 1 and 65535", s)
+	}
+	end, err := strconv.ParseInt(strings.TrimSpace(endValue), 10, 32)
+	if err != nil || end < 1 || end > 65535 {
+		return PortRange{}, fmt.Errorf("invalid port range %q: end must be between 1 and 65535", s)
+	}
+	if start >= end {
+		return PortRange{}, fmt.Errorf("invalid port range %q: start must be lower than end", s)
+	}
+	return PortRange{Start: int32(start), End: int32(end)}, nil
+}
+
+// String returns the range in the format of ECS
+func (r PortRange) String() string {
+	return fmt.Sprintf("%d-%d", r.Start, r.End)
+}
+
+// Size returns the number of ports of the range
+func (r PortRange) Size() int {
+	return int(r.End-r.Start) + 1
+}
+
+// Contains reports whether the port is within the range
+func (r PortRange) Contains(port int32) bool {
+	return port >= r.Start && port <= r.End
+}
+
+// ContainerPortRange is a containerPortRange of a container with its protocol
+type ContainerPortRange struct {
+	Range    PortRange
+	Protocol corev1.Protocol
+}
+
+// ValidatePortMappings validates the port mappings of a container the way ECS
+// does. A mapping has either a containerPort or a containerPortRange; ranges
+// are only supported in the awsvpc and bridge network modes and get their
+// host ports assigned.
+func ValidatePortMappings(containerName string, mappings []types.PortMapping, networkMode types.NetworkMode) error {
+	ranges, ports := 0, 0
+	for _, m := range mappings {
+		if m.ContainerPortRange == nil {
+			if m.ContainerPort == nil {
+				return fmt.Errorf("port mapping of container %s requires containerPort or containerPortRange", containerName)
+			}
+			if *m.ContainerPort < 1 || *m.ContainerPort > 65535 {
+				return fmt.Errorf("invalid containerPort %d of container %s", *m.ContainerPort, containerName)
+			}
+			ports++
+			continue
+		}
+
+		if m.ContainerPort != nil {
+			return fmt.Errorf("port mapping of container %s cannot have both containerPort and containerPortRange", containerName)
+		}
+		if m.HostPort != nil && *m.HostPort != 0 {
+			return fmt.Errorf("port mapping of container %s cannot have a hostPort with containerPortRange", containerName)
+		}
+		if networkMode != types.NetworkModeAWSVPC && networkMode != types.NetworkModeBridge {
+			return fmt.Errorf("containerPortRange of container %s requires the awsvpc or bridge network mode", containerName)
+		}
+		portRange, err := ParsePortRange(*m.ContainerPortRange)
+		if err != nil {
+			return fmt.Errorf("container %s: %w", containerName, err)
+		}
+		ranges++
+		ports += portRange.Size()
+	}
+
+	if ranges > maxPortRangesPerContainer {
+		return fmt.Errorf("container %s has %d containerPortRanges, at most %d are supported", containerName, ranges, maxPortRangesPerContainer)
+	}
+	if ports > maxContainerPorts {
+		return fmt.Errorf("container %s exposes %d ports, at most %d are supported", containerName, ports, maxContainerPorts)
+	}
+	return nil
+}
+
+// expandPortRange returns a container port for every port of the range
+func expandPortRange(portRange PortRange, protocol corev1.Protocol) []corev1.ContainerPort {
+	ports := make([]corev1.ContainerPort, 0, portRange.Size())
+	for port := portRange.Start; port <= portRange.End; port++ {
+		ports = append(ports, corev1.ContainerPort{
+			ContainerPort: port,
+			Protocol:      protocol,
+		})
+	}
+	return ports
+}
+
+// clearHostPorts removes the host ports of containers in the awsvpc network
+// mode. Their tasks get a network interface of their own, so ECS does not bind
+// their ports on the host.
+func clearHostPorts(containers []corev1.Container, networkMode types.NetworkMode) {
+	if networkMode != types.NetworkModeAWSVPC {
+		return
+	}
+	for i := range containers {
+		for j := range containers[i].Ports {
+			containers[i].Ports[j].HostPort = 0
+		}
+	}
+}
+
+// convertProtocol converts the protocol of an ECS port mapping, TCP by default
+func convertProtocol(protocol string) corev1.Protocol {
+	switch strings.ToLower(protocol) {
+	case "udp":
+		return corev1.ProtocolUDP
+	case "sctp":
+		return corev1.ProtocolSCTP
+	default:
+		return corev1.ProtocolTCP
+	}
+}
+
+// portRangeEntry is a range of the PortRangesAnnotation
+type portRangeEntry struct {
+	Range    string          `json:"range"`
+	Protocol corev1.Protocol `json:"protocol"`
+}
+
+// setPortRangesAnnotation records the containerPortRanges of the containers
+func setPortRangesAnnotation(annotations map[string]string, containerDefs []types.ContainerDefinition) {
+	entries := make(map[string][]portRangeEntry)
+	for _, def := range containerDefs {
+		if def.Name == nil {
+			continue
+		}
+		for _, m := range def.PortMappings {
+			if m.ContainerPortRange == nil {
+				continue
+			}
+			portRange, err := ParsePortRange(*m.ContainerPortRange)
+			if err != nil {
+				continue
+			}
+			protocol := corev1.ProtocolTCP
+			if m.Protocol != nil {
+				protocol = convertProtocol(*m.Protocol)
+			}
+			entries[*def.Name] = append(entries[*def.Name], portRangeEntry{Range: portRange.String(), Protocol: protocol})
+		}
+	}
+	if len(entries) == 0 {
+		return
+	}
+	if data, err := json.Marshal(entries); err == nil {
+		annotations[PortRangesAnnotation] = string(data)
+	}
+}
+
+// ContainerPortRanges returns the containerPortRanges of the containers of a
+// pod, keyed by container name
+func ContainerPortRanges(annotations map[string]string) map[string][]ContainerPortRange {
+	value := annotations[PortRangesAnnotation]
+	if value == "" {
+		return nil
+	}
+	var entries map[string][]portRangeEntry
+	if err := json.Unmarshal([]byte(value), &entries); err != nil {
+		return nil
+	}
+
+	ranges := make(map[string][]ContainerPortRange, len(entries))
+	for container, containerEntries := range entries {
+		for _, entry := range containerEntries {
+			portRange, err := ParsePortRange(entry.Range)
+			if err != nil {
+				continue
+			}
+			ranges[container] = append(ranges[container], ContainerPortRange{Range: portRange, Protocol: entry.Protocol})
+		}
+	}
+	return ranges
+}
+
+// NetworkBindings reports the ports of a container the way DescribeTasks
+// does. The host port equals the container port in the awsvpc and host
+// network modes; the ports of a containerPortRange are reported as one binding
+// with the range of their host ports.
+func NetworkBindings(pod *corev1.Pod, containerName, podIP string) []types.NetworkBinding {
+	var container *corev1.Container
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name == containerName {
+			container = &pod.Spec.Containers[i]
+			break
+		}
+	}
+	if container == nil || len(container.Ports) == 0 {
+		return nil
+	}
+
+	awsvpc := pod.Annotations["ecs.amazonaws.com/network-mode"] == string(types.NetworkModeAWSVPC)
+	bindIP := "0.0.0.0"
+	if awsvpc && podIP != "" {
+		bindIP = podIP
+	}
+	hostPortOf := func(port corev1.ContainerPort) int32 {
+		if port.HostPort != 0 {
+			return port.HostPort
+		}
+		if awsvpc || pod.Spec.HostNetwork {
+			return port.ContainerPort
+		}
+		return 0
+	}
+
+	portRanges := ContainerPortRanges(pod.Annotations)[containerName]
+	inRange := func(port corev1.ContainerPort) bool {
+		for _, portRange := range portRanges {
+			if port.Protocol == portRange.Protocol && portRange.Range.Contains(port.ContainerPort) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var bindings []types.NetworkBinding
+	for _, portRange := range portRanges {
+		binding := types.NetworkBinding{
+			BindIP:             bindIP,
+			ContainerPortRange: portRange.Range.String(),
+			Protocol:           strings.ToLower(string(portRange.Protocol)),
+		}
+		var first, last int32
+		for _, port := range container.Ports {
+			if port.Protocol != portRange.Protocol || !portRange.Range.Contains(port.ContainerPort) {
+				continue
+			}
+			hostPort := hostPortOf(port)
+			if hostPort == 0 {
+				continue
+			}
+			if first == 0 || hostPort < first {
+				first = hostPort
+			}
+			if hostPort > last {
+				last = hostPort
+			}
+		}
+		if first != 0 {
+			binding.HostPortRange = fmt.Sprintf("%d-%d", first, last)
+		}
+		bindings = append(bindings, binding)
+	}
+
+	for _, port := range container.Ports {
+		if inRange(port) {
+			continue
+		}
+		bindings = append(bindings, types.NetworkBinding{
+			BindIP:        bindIP,
+			ContainerPort: int(port.ContainerPort),
+			HostPort:      int(hostPortOf(port)),
+			Protocol:      strings.ToLower(string(port.Protocol)),
+		})
+	}
+	return bindings
+}
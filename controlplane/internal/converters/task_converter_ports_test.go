@@ -0,0 +1,152 @@
+package converters_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/types"
+)
+
+var _ = Describe("Port Ranges", func() {
+	intPtr := func(i int) *int { return &i }
+
+	Describe("ParsePortRange", func() {
+		It("should parse a range", func() {
+			portRange, err := converters.ParsePortRange("8000-8010")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(portRange).To(Equal(converters.PortRange{Start: 8000, End: 8010}))
+			Expect(portRange.Size()).To(Equal(11))
+			Expect(portRange.String()).To(Equal("8000-8010"))
+		})
+
+		DescribeTable("should reject invalid ranges",
+			func(value string) {
+				_, err := converters.ParsePortRange(value)
+				Expect(err).To(HaveOccurred())
+			},
+			Entry("single port", "8000"),
+			Entry("reversed", "8010-8000"),
+			Entry("equal", "8000-8000"),
+			Entry("out of range", "65000-70000"),
+			Entry("zero", "0-10"),
+			Entry("not a number", "a-b"),
+		)
+	})
+
+	Describe("ValidatePortMappings", func() {
+		It("should accept ranges in the bridge and awsvpc network modes", func() {
+			mappings := []types.PortMapping{
+				{ContainerPort: intPtr(80)},
+				{ContainerPortRange: strPtr("8000-8010")},
+			}
+			Expect(converters.ValidatePortMappings("app", mappings, types.NetworkModeBridge)).To(Succeed())
+			Expect(converters.ValidatePortMappings("app", mappings, types.NetworkModeAWSVPC)).To(Succeed())
+		})
+
+		It("should reject ranges in the host network mode", func() {
+			err := converters.ValidatePortMappings("app", []types.PortMapping{{ContainerPortRange: strPtr("8000-8010")}}, types.NetworkModeHost)
+			Expect(err).To(MatchError(ContainSubstring("awsvpc or bridge")))
+		})
+
+		It("should reject both containerPort and containerPortRange", func() {
+			err := converters.ValidatePortMappings("app", []types.PortMapping{
+				{ContainerPort: intPtr(80), ContainerPortRange: strPtr("8000-8010")},
+			}, types.NetworkModeBridge)
+			Expect(err).To(MatchError(ContainSubstring("cannot have both")))
+		})
+
+		It("should reject mappings without a port", func() {
+			err := converters.ValidatePortMappings("app", []types.PortMapping{{}}, types.NetworkModeBridge)
+			Expect(err).To(MatchError(ContainSubstring("requires containerPort or containerPortRange")))
+		})
+
+		It("should reject containers exposing too many ports", func() {
+			err := converters.ValidatePortMappings("app", []types.PortMapping{{ContainerPortRange: strPtr("1024-4096")}}, types.NetworkModeBridge)
+			Expect(err).To(MatchError(ContainSubstring("at most 1000 are supported")))
+		})
+	})
+
+	Describe("Conversion", func() {
+		var (
+			cluster *storage.Cluster
+			taskDef *storage.TaskDefinition
+		)
+
+		BeforeEach(func() {
+			cluster = &storage.Cluster{
+				Name:   "test-cluster",
+				Region: "us-east-1",
+				Status: "ACTIVE",
+			}
+			taskDef = &storage.TaskDefinition{
+				Family:      "media",
+				Revision:    1,
+				NetworkMode: "bridge",
+				ContainerDefinitions: mustMarshalContainerDefs([]types.ContainerDefinition{
+					{
+						Name:  strPtr("media"),
+						Image: strPtr("media:v1"),
+						PortMappings: []types.PortMapping{
+							{ContainerPort: intPtr(80), Name: strPtr("http")},
+							{ContainerPortRange: strPtr("10000-10002"), Protocol: strPtr("udp")},
+						},
+					},
+				}),
+			}
+		})
+
+		It("should expand ranges to container ports of the pod", func() {
+			converter := converters.NewTaskConverter("us-east-1", "123456789012")
+			pod, err := converter.ConvertTaskToPod(taskDef, []byte("{}"), cluster, "test-task-123")
+			Expect(err).NotTo(HaveOccurred())
+
+			ports := pod.Spec.Containers[0].Ports
+			Expect(ports).To(HaveLen(4))
+			Expect(ports[0]).To(Equal(corev1.ContainerPort{Name: "http", ContainerPort: 80, Protocol: corev1.ProtocolTCP}))
+			Expect(ports[1:]).To(HaveEach(HaveField("Protocol", corev1.ProtocolUDP)))
+			Expect(ports[3].ContainerPort).To(Equal(int32(10002)))
+
+			ranges := converters.ContainerPortRanges(pod.Annotations)
+			Expect(ranges["media"]).To(Equal([]converters.ContainerPortRange{
+				{Range: converters.PortRange{Start: 10000, End: 10002}, Protocol: corev1.ProtocolUDP},
+			}))
+		})
+
+		It("should expand ranges in services", func() {
+			converter := converters.NewServiceConverter("us-east-1", "123456789012")
+			service := &storage.Service{
+				ServiceName:  "media",
+				DesiredCount: 1,
+				LaunchType:   "EC2",
+				ARN:          "arn:aws:ecs:us-east-1:123456789012:service/test-cluster/media",
+			}
+
+			deployment, _, err := converter.ConvertServiceToDeployment(service, taskDef, cluster)
+			Expect(err).NotTo(HaveOccurred())
+
+			template := deployment.Spec.Template
+			Expect(template.Spec.Containers[0].Ports).To(HaveLen(4))
+			Expect(converters.ContainerPortRanges(template.Annotations)).To(HaveKey("media"))
+			// The host ports of bridge mode service pods are assigned per pod
+			Expect(template.Annotations).To(HaveKeyWithValue("ecs.amazonaws.com/network-mode", "bridge"))
+		})
+
+		It("should report the ports of a range as one network binding", func() {
+			converter := converters.NewTaskConverter("us-east-1", "123456789012")
+			pod, err := converter.ConvertTaskToPod(taskDef, []byte("{}"), cluster, "test-task-123")
+			Expect(err).NotTo(HaveOccurred())
+			for i, hostPort := range []int32{49153, 49160, 49161, 49162} {
+				pod.Spec.Containers[0].Ports[i].HostPort = hostPort
+			}
+
+			bindings := converters.NetworkBindings(pod, "media", "10.42.0.7")
+			Expect(bindings).To(ConsistOf(
+				types.NetworkBinding{BindIP: "0.0.0.0", ContainerPortRange: "10000-10002", HostPortRange: "49160-49162", Protocol: "udp"},
+				types.NetworkBinding{BindIP: "0.0.0.0", ContainerPort: 80, HostPort: 49153, Protocol: "tcp"},
+			))
+		})
+	})
+})
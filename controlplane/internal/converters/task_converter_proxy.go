@@ -0,0 +1,255 @@
+package converters
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/types"
+)
+
+// proxyInitContainerName is the name of the init container that routes the
+// task traffic through the proxy container
+const proxyInitContainerName = "proxyinit"
+
+// AppMeshProxy is the parsed proxyConfiguration of an APPMESH task definition
+type AppMeshProxy struct {
+	ContainerName      string
+	IgnoredUID         *int64
+	IgnoredGID         *int64
+	AppPorts           []int
+	ProxyIngressPort   int
+	ProxyEgressPort    int
+	EgressIgnoredPorts []int
+	EgressIgnoredIPs   []string
+}
+
+// ParseAppMeshProxy validates a proxyConfiguration the way ECS does at
+// registration. Only the APPMESH type exists; IgnoredUID or IgnoredGID and
+// ProxyEgressPort are required, and the port and address lists are comma
+// separated.
+func ParseAppMeshProxy(proxyConfig *types.ProxyConfiguration) (*AppMeshProxy, error) {
+	if proxyConfig.Type != nil && *proxyConfig.Type != "APPMESH" {
+		return nil, fmt.Errorf("invalid proxy configuration type: %s. Valid values are APPMESH", *proxyConfig.Type)
+	}
+	if proxyConfig.ContainerName == nil || *proxyConfig.ContainerName == "" {
+		return nil, fmt.Errorf("proxy configuration containerName is required")
+	}
+
+	proxy := &AppMeshProxy{ContainerName: *proxyConfig.ContainerName}
+	for _, property := range proxyConfig.Properties {
+		if property.Name == nil {
+			return nil, fmt.Errorf("proxy configuration property name is required")
+		}
+		value := ""
+		if property.Value != nil {
+			value = strings.TrimSpace(*property.Value)
+		}
+		if value == "" {
+			continue
+		}
+
+		var err error
+		switch *property.Name {
+		case "IgnoredUID":
+			proxy.IgnoredUID, err = parseProxyID(value)
+		case "IgnoredGID":
+			proxy.IgnoredGID, err = parseProxyID(value)
+		case "AppPorts":
+			proxy.AppPorts, err = parseProxyPorts(value)
+		case "ProxyIngressPort":
+			proxy.ProxyIngressPort, err = parseProxyPort(value)
+		case "ProxyEgressPort":
+			proxy.ProxyEgressPort, err = parseProxyPort(value)
+		case "EgressIgnoredPorts":
+			proxy.EgressIgnoredPorts, err = parseProxyPorts(value)
+		case "EgressIgnoredIPs":
+			proxy.EgressIgnoredIPs, err = parseProxyAddresses(value)
+		default:
+			return nil, fmt.Errorf("invalid proxy configuration property: %s", *property.Name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy configuration property %s: %w", *property.Name, err)
+		}
+	}
+
+	if proxy.IgnoredUID == nil && proxy.IgnoredGID == nil {
+		return nil, fmt.Errorf("proxy configuration requires IgnoredUID or IgnoredGID")
+	}
+	if proxy.ProxyEgressPort == 0 {
+		return nil, fmt.Errorf("proxy configuration requires ProxyEgressPort")
+	}
+	if len(proxy.AppPorts) > 0 && proxy.ProxyIngressPort == 0 {
+		return nil, fmt.Errorf("proxy configuration requires ProxyIngressPort with AppPorts")
+	}
+	return proxy, nil
+}
+
+func parseProxyID(value string) (*int64, error) {
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return nil, fmt.Errorf("%q is not a user or group ID", value)
+	}
+	return &id, nil
+}
+
+func parseProxyPort(value string) (int, error) {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("%q is not a port", value)
+	}
+	return port, nil
+}
+
+func parseProxyPorts(value string) ([]int, error) {
+	var ports []int
+	for _, part := range strings.Split(value, ",") {
+		port, err := parseProxyPort(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		ports = append(ports, port)
+	}
+	return ports, nil
+}
+
+func parseProxyAddresses(value string) ([]string, error) {
+	var addresses []string
+	for _, part := range strings.Split(value, ",") {
+		address := strings.TrimSpace(part)
+		if net.ParseIP(address) == nil {
+			if _, _, err := net.ParseCIDR(address); err != nil {
+				return nil, fmt.Errorf("%q is not an IP address or CIDR block", address)
+			}
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, nil
+}
+
+// applyProxyConfiguration routes the traffic of the task through the proxy
+// container named by the task definition: the proxy settings are recorded as
+// pod annotations and an init container installs the iptables rules App Mesh
+// would, so mesh sidecars such as Envoy can be tested locally.
+func (c *TaskConverter) applyProxyConfiguration(pod *corev1.Pod, taskDef *storage.TaskDefinition) error {
+	if taskDef.ProxyConfiguration == "" || taskDef.ProxyConfiguration == "null" {
+		return nil
+	}
+	var proxyConfig types.ProxyConfiguration
+	if err := json.Unmarshal([]byte(taskDef.ProxyConfiguration), &proxyConfig); err != nil {
+		return fmt.Errorf("failed to parse proxy configuration: %w", err)
+	}
+	proxy, err := ParseAppMeshProxy(&proxyConfig)
+	if err != nil {
+		return err
+	}
+	if pod.Spec.HostNetwork {
+		return fmt.Errorf("proxy configuration is not supported with the host network mode")
+	}
+
+	var proxyContainer *corev1.Container
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name == proxy.ContainerName {
+			proxyContainer = &pod.Spec.Containers[i]
+			break
+		}
+	}
+	if proxyContainer == nil {
+		return fmt.Errorf("proxy container %s is not defined in the task definition", proxy.ContainerName)
+	}
+
+	// Traffic of the proxy itself must skip the redirection, so it runs as
+	// the ignored user unless its container definition sets one
+	if proxy.IgnoredUID != nil {
+		if proxyContainer.SecurityContext == nil {
+			proxyContainer.SecurityContext = &corev1.SecurityContext{}
+		}
+		if proxyContainer.SecurityContext.RunAsUser == nil {
+			proxyContainer.SecurityContext.RunAsUser = ptr.To(*proxy.IgnoredUID)
+		}
+	}
+
+	pod.Annotations["kecs.dev/proxy-type"] = "APPMESH"
+	pod.Annotations["kecs.dev/proxy-container"] = proxy.ContainerName
+	if proxy.IgnoredUID != nil {
+		pod.Annotations["kecs.dev/proxy-ignored-uid"] = strconv.FormatInt(*proxy.IgnoredUID, 10)
+	}
+	if proxy.IgnoredGID != nil {
+		pod.Annotations["kecs.dev/proxy-ignored-gid"] = strconv.FormatInt(*proxy.IgnoredGID, 10)
+	}
+	if len(proxy.AppPorts) > 0 {
+		pod.Annotations["kecs.dev/proxy-app-ports"] = joinPorts(proxy.AppPorts)
+		pod.Annotations["kecs.dev/proxy-ingress-port"] = strconv.Itoa(proxy.ProxyIngressPort)
+	}
+	pod.Annotations["kecs.dev/proxy-egress-port"] = strconv.Itoa(proxy.ProxyEgressPort)
+	if len(proxy.EgressIgnoredPorts) > 0 {
+		pod.Annotations["kecs.dev/proxy-egress-ignored-ports"] = joinPorts(proxy.EgressIgnoredPorts)
+	}
+	if len(proxy.EgressIgnoredIPs) > 0 {
+		pod.Annotations["kecs.dev/proxy-egress-ignored-ips"] = strings.Join(proxy.EgressIgnoredIPs, ",")
+	}
+
+	// The rules must be in place before any container starts
+	pod.Spec.InitContainers = append(pod.Spec.InitContainers, corev1.Container{
+		Name:    proxyInitContainerName,
+		Image:   config.GetString("appMesh.proxyInitImage"),
+		Command: []string{"sh", "-c", proxy.iptablesScript()},
+		SecurityContext: &corev1.SecurityContext{
+			RunAsUser:    ptr.To(int64(0)),
+			RunAsNonRoot: ptr.To(false),
+			Capabilities: &corev1.Capabilities{
+				Add: []corev1.Capability{"NET_ADMIN", "NET_RAW"},
+			},
+		},
+	})
+	return nil
+}
+
+// iptablesScript returns the iptables rules that redirect inbound traffic of
+// the application ports to the proxy ingress port and all other outbound
+// traffic to the proxy egress port
+func (p *AppMeshProxy) iptablesScript() string {
+	lines := []string{"set -e"}
+
+	if len(p.AppPorts) > 0 {
+		lines = append(lines, "iptables -t nat -N APPMESH_INGRESS")
+		for _, port := range p.AppPorts {
+			lines = append(lines, fmt.Sprintf("iptables -t nat -A APPMESH_INGRESS -p tcp --dport %d -j REDIRECT --to-port %d", port, p.ProxyIngressPort))
+		}
+		lines = append(lines, "iptables -t nat -A PREROUTING -p tcp -m addrtype ! --src-type LOCAL -j APPMESH_INGRESS")
+	}
+
+	lines = append(lines, "iptables -t nat -N APPMESH_EGRESS")
+	if p.IgnoredUID != nil {
+		lines = append(lines, fmt.Sprintf("iptables -t nat -A APPMESH_EGRESS -m owner --uid-owner %d -j RETURN", *p.IgnoredUID))
+	}
+	if p.IgnoredGID != nil {
+		lines = append(lines, fmt.Sprintf("iptables -t nat -A APPMESH_EGRESS -m owner --gid-owner %d -j RETURN", *p.IgnoredGID))
+	}
+	for _, port := range p.EgressIgnoredPorts {
+		lines = append(lines, fmt.Sprintf("iptables -t nat -A APPMESH_EGRESS -p tcp --dport %d -j RETURN", port))
+	}
+	for _, address := range p.EgressIgnoredIPs {
+		lines = append(lines, fmt.Sprintf("iptables -t nat -A APPMESH_EGRESS -d %s -j RETURN", address))
+	}
+	lines = append(lines,
+		fmt.Sprintf("iptables -t nat -A APPMESH_EGRESS -p tcp -j REDIRECT --to-port %d", p.ProxyEgressPort),
+		"iptables -t nat -A OUTPUT -p tcp -m addrtype ! --dst-type LOCAL -j APPMESH_EGRESS",
+	)
+	return strings.Join(lines, "\n")
+}
+
+func joinPorts(ports []int) string {
+	parts := make([]string, len(ports))
+	for i, port := range ports {
+		parts[i] = strconv.Itoa(port)
+	}
+	return strings.Join(parts, ",")
+}
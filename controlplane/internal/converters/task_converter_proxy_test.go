@@ -0,0 +1,165 @@
+package converters_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/types"
+)
+
+var _ = Describe("TaskConverter Proxy Configuration", func() {
+	var (
+		converter *converters.TaskConverter
+		cluster   *storage.Cluster
+	)
+
+	BeforeEach(func() {
+		converter = converters.NewTaskConverter("us-east-1", "123456789012")
+		cluster = &storage.Cluster{
+			Name:   "test-cluster",
+			Region: "us-east-1",
+			Status: "ACTIVE",
+		}
+	})
+
+	property := func(name, value string) types.KeyValuePair {
+		return types.KeyValuePair{Name: strPtr(name), Value: strPtr(value)}
+	}
+
+	newTaskDef := func(proxyConfig *types.ProxyConfiguration) *storage.TaskDefinition {
+		data, err := json.Marshal(proxyConfig)
+		Expect(err).NotTo(HaveOccurred())
+		return &storage.TaskDefinition{
+			Family:      "mesh",
+			Revision:    1,
+			NetworkMode: "awsvpc",
+			ContainerDefinitions: mustMarshalContainerDefs([]types.ContainerDefinition{
+				{Name: strPtr("app"), Image: strPtr("app:latest")},
+				{Name: strPtr("envoy"), Image: strPtr("envoyproxy/envoy:v1.29-latest")},
+			}),
+			ProxyConfiguration: string(data),
+		}
+	}
+
+	It("should route the task traffic through the proxy container", func() {
+		taskDef := newTaskDef(&types.ProxyConfiguration{
+			Type:          strPtr("APPMESH"),
+			ContainerName: strPtr("envoy"),
+			Properties: []types.KeyValuePair{
+				property("IgnoredUID", "1337"),
+				property("AppPorts", "8080,8081"),
+				property("ProxyIngressPort", "15000"),
+				property("ProxyEgressPort", "15001"),
+				property("EgressIgnoredPorts", "22"),
+				property("EgressIgnoredIPs", "169.254.170.2,169.254.169.254/32"),
+			},
+		})
+
+		pod, err := converter.ConvertTaskToPod(taskDef, []byte("{}"), cluster, "test-task-123")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(pod.Annotations).To(HaveKeyWithValue("kecs.dev/proxy-type", "APPMESH"))
+		Expect(pod.Annotations).To(HaveKeyWithValue("kecs.dev/proxy-container", "envoy"))
+		Expect(pod.Annotations).To(HaveKeyWithValue("kecs.dev/proxy-ignored-uid", "1337"))
+		Expect(pod.Annotations).To(HaveKeyWithValue("kecs.dev/proxy-app-ports", "8080,8081"))
+		Expect(pod.Annotations).To(HaveKeyWithValue("kecs.dev/proxy-ingress-port", "15000"))
+		Expect(pod.Annotations).To(HaveKeyWithValue("kecs.dev/proxy-egress-port", "15001"))
+		Expect(pod.Annotations).To(HaveKeyWithValue("kecs.dev/proxy-egress-ignored-ports", "22"))
+		Expect(pod.Annotations).To(HaveKeyWithValue("kecs.dev/proxy-egress-ignored-ips", "169.254.170.2,169.254.169.254/32"))
+
+		Expect(pod.Spec.Containers[1].Name).To(Equal("envoy"))
+		Expect(*pod.Spec.Containers[1].SecurityContext.RunAsUser).To(Equal(int64(1337)))
+
+		Expect(pod.Spec.InitContainers).To(HaveLen(1))
+		initContainer := pod.Spec.InitContainers[0]
+		Expect(initContainer.Name).To(Equal("proxyinit"))
+		Expect(initContainer.SecurityContext.Capabilities.Add).To(ContainElement(BeEquivalentTo("NET_ADMIN")))
+		script := initContainer.Command[2]
+		Expect(script).To(ContainSubstring("iptables -t nat -A APPMESH_INGRESS -p tcp --dport 8080 -j REDIRECT --to-port 15000"))
+		Expect(script).To(ContainSubstring("iptables -t nat -A APPMESH_INGRESS -p tcp --dport 8081 -j REDIRECT --to-port 15000"))
+		Expect(script).To(ContainSubstring("iptables -t nat -A APPMESH_EGRESS -m owner --uid-owner 1337 -j RETURN"))
+		Expect(script).To(ContainSubstring("iptables -t nat -A APPMESH_EGRESS -p tcp --dport 22 -j RETURN"))
+		Expect(script).To(ContainSubstring("iptables -t nat -A APPMESH_EGRESS -d 169.254.169.254/32 -j RETURN"))
+		Expect(script).To(ContainSubstring("iptables -t nat -A APPMESH_EGRESS -p tcp -j REDIRECT --to-port 15001"))
+	})
+
+	It("should only redirect egress traffic without application ports", func() {
+		taskDef := newTaskDef(&types.ProxyConfiguration{
+			ContainerName: strPtr("envoy"),
+			Properties: []types.KeyValuePair{
+				property("IgnoredGID", "1337"),
+				property("ProxyEgressPort", "15001"),
+			},
+		})
+
+		pod, err := converter.ConvertTaskToPod(taskDef, []byte("{}"), cluster, "test-task-123")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pod.Annotations).NotTo(HaveKey("kecs.dev/proxy-app-ports"))
+		Expect(pod.Spec.Containers[1].SecurityContext).To(BeNil())
+		script := pod.Spec.InitContainers[0].Command[2]
+		Expect(script).NotTo(ContainSubstring("APPMESH_INGRESS"))
+		Expect(script).To(ContainSubstring("--gid-owner 1337"))
+	})
+
+	It("should route the traffic of service pods through the proxy container", func() {
+		taskDef := newTaskDef(&types.ProxyConfiguration{
+			ContainerName: strPtr("envoy"),
+			Properties: []types.KeyValuePair{
+				property("IgnoredUID", "1337"),
+				property("ProxyEgressPort", "15001"),
+			},
+		})
+
+		deployment, _, err := converters.NewServiceConverter("us-east-1", "123456789012").ConvertServiceToDeployment(
+			&storage.Service{ServiceName: "mesh", DesiredCount: 1}, taskDef, cluster)
+		Expect(err).NotTo(HaveOccurred())
+
+		template := deployment.Spec.Template
+		Expect(template.Annotations).To(HaveKeyWithValue("kecs.dev/proxy-container", "envoy"))
+		Expect(template.Annotations).To(HaveKeyWithValue("kecs.dev/proxy-egress-port", "15001"))
+		Expect(*template.Spec.Containers[1].SecurityContext.RunAsUser).To(Equal(int64(1337)))
+		Expect(template.Spec.InitContainers).To(HaveLen(1))
+		Expect(template.Spec.InitContainers[0].Name).To(Equal("proxyinit"))
+	})
+
+	It("should fail when the proxy container is not defined", func() {
+		taskDef := newTaskDef(&types.ProxyConfiguration{
+			ContainerName: strPtr("sidecar"),
+			Properties: []types.KeyValuePair{
+				property("IgnoredUID", "1337"),
+				property("ProxyEgressPort", "15001"),
+			},
+		})
+
+		_, err := converter.ConvertTaskToPod(taskDef, []byte("{}"), cluster, "test-task-123")
+		Expect(err).To(MatchError(ContainSubstring("proxy container sidecar is not defined")))
+	})
+
+	It("should reject invalid properties", func() {
+		_, err := converters.ParseAppMeshProxy(&types.ProxyConfiguration{
+			ContainerName: strPtr("envoy"),
+			Properties: []types.KeyValuePair{
+				property("IgnoredUID", "1337"),
+				property("ProxyEgressPort", "15001"),
+				property("EgressIgnoredIPs", "10.0.0.1; reboot"),
+			},
+		})
+		Expect(err).To(MatchError(ContainSubstring("invalid proxy configuration property EgressIgnoredIPs")))
+
+		_, err = converters.ParseAppMeshProxy(&types.ProxyConfiguration{
+			ContainerName: strPtr("envoy"),
+			Properties:    []types.KeyValuePair{property("ProxyEgressPort", "15001")},
+		})
+		Expect(err).To(MatchError(ContainSubstring("requires IgnoredUID or IgnoredGID")))
+
+		_, err = converters.ParseAppMeshProxy(&types.ProxyConfiguration{
+			Type:          strPtr("ISTIO"),
+			ContainerName: strPtr("envoy"),
+		})
+		Expect(err).To(MatchError(ContainSubstring("invalid proxy configuration type")))
+	})
+})
@@ -0,0 +1,121 @@
+package converters
+
+import (
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/types"
+)
+
+const (
+	// minRestartAttemptPeriod and maxRestartAttemptPeriod bound the
+	// restartAttemptPeriod of a container restart policy, in seconds
+	minRestartAttemptPeriod = 60
+	maxRestartAttemptPeriod = 1800
+	// maxIgnoredExitCodes is the number of exit codes a restart policy may ignore
+	maxIgnoredExitCodes = 50
+)
+
+// ValidateRestartPolicy validates the restart policy of a container
+// definition the way ECS does at registration
+func ValidateRestartPolicy(containerName string, policy *types.ContainerRestartPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	if len(policy.IgnoredExitCodes) > maxIgnoredExitCodes {
+		return fmt.Errorf("restart policy of container %s ignores %d exit codes, at most %d are allowed",
+			containerName, len(policy.IgnoredExitCodes), maxIgnoredExitCodes)
+	}
+	if period := policy.RestartAttemptPeriod; period != nil && (*period < minRestartAttemptPeriod || *period > maxRestartAttemptPeriod) {
+		return fmt.Errorf("restartAttemptPeriod of container %s must be between %d and %d seconds",
+			containerName, minRestartAttemptPeriod, maxRestartAttemptPeriod)
+	}
+	return nil
+}
+
+// applyRestartPolicies maps the restart policies of container definitions to
+// the pod of a task. Kubernetes decides restarts per pod rather than per
+// container:
+//
+//   - When every container has an enabled restart policy, the pod restarts
+//     its containers: OnFailure when all policies ignore exit code 0, so that
+//     containers completing successfully stay stopped, Always otherwise.
+//   - Otherwise the containers with an enabled restart policy run as native
+//     sidecars, init containers with restartPolicy Always, which Kubernetes
+//     restarts whatever their exit code while the other containers run.
+//
+// Exit codes other than 0 can not be ignored and the restartAttemptPeriod is
+// replaced by the crash loop back-off of the kubelet.
+func applyRestartPolicies(pod *corev1.Pod, containerDefs []types.ContainerDefinition) {
+	var restarting []string
+	ignoreSuccess := true
+	for _, def := range containerDefs {
+		if def.Name == nil || def.RestartPolicy == nil || !def.RestartPolicy.Enabled {
+			continue
+		}
+		restarting = append(restarting, *def.Name)
+		if !slices.Contains(def.RestartPolicy.IgnoredExitCodes, 0) {
+			ignoreSuccess = false
+		}
+	}
+	if len(restarting) == 0 {
+		return
+	}
+
+	if len(restarting) == len(containerDefs) {
+		pod.Spec.RestartPolicy = corev1.RestartPolicyAlways
+		if ignoreSuccess {
+			pod.Spec.RestartPolicy = corev1.RestartPolicyOnFailure
+		}
+		return
+	}
+
+	containers := make([]corev1.Container, 0, len(pod.Spec.Containers))
+	for _, container := range pod.Spec.Containers {
+		if !slices.Contains(restarting, container.Name) {
+			containers = append(containers, container)
+			continue
+		}
+		container.RestartPolicy = ptr.To(corev1.ContainerRestartPolicyAlways)
+		pod.Spec.InitContainers = append(pod.Spec.InitContainers, container)
+	}
+	pod.Spec.Containers = containers
+}
+
+// TaskContainers returns the containers of a task pod, its containers
+// followed by the native sidecars that run containers with a restart policy,
+// with their statuses, which are nil until the kubelet reports them
+func TaskContainers(pod *corev1.Pod) ([]corev1.Container, []*corev1.ContainerStatus) {
+	var containers []corev1.Container
+	var statuses []*corev1.ContainerStatus
+	add := func(container corev1.Container, all []corev1.ContainerStatus) {
+		containers = append(containers, container)
+		var status *corev1.ContainerStatus
+		for i := range all {
+			if all[i].Name == container.Name {
+				status = &all[i]
+				break
+			}
+		}
+		statuses = append(statuses, status)
+	}
+
+	for _, container := range pod.Spec.Containers {
+		add(container, pod.Status.ContainerStatuses)
+	}
+	for _, container := range pod.Spec.InitContainers {
+		if IsSidecarContainer(container) {
+			add(container, pod.Status.InitContainerStatuses)
+		}
+	}
+	return containers, statuses
+}
+
+// IsSidecarContainer reports whether an init container is a native sidecar,
+// running alongside the containers of the pod
+func IsSidecarContainer(container corev1.Container) bool {
+	return container.RestartPolicy != nil && *container.RestartPolicy == corev1.ContainerRestartPolicyAlways
+}
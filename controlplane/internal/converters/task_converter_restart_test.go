@@ -0,0 +1,138 @@
+package converters_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/types"
+)
+
+var _ = Describe("TaskConverter Restart Policies", func() {
+	var (
+		converter *converters.TaskConverter
+		cluster   *storage.Cluster
+	)
+
+	BeforeEach(func() {
+		converter = converters.NewTaskConverter("us-east-1", "123456789012")
+		cluster = &storage.Cluster{
+			Name:   "test-cluster",
+			Region: "us-east-1",
+			Status: "ACTIVE",
+		}
+	})
+
+	convert := func(containerDefs ...types.ContainerDefinition) *corev1.Pod {
+		taskDef := &storage.TaskDefinition{
+			Family:               "restart",
+			Revision:             1,
+			NetworkMode:          "awsvpc",
+			ContainerDefinitions: mustMarshalContainerDefs(containerDefs),
+		}
+		pod, err := converter.ConvertTaskToPod(taskDef, []byte("{}"), cluster, "test-task-123")
+		Expect(err).NotTo(HaveOccurred())
+		return pod
+	}
+
+	restartPolicy := func(ignoredExitCodes ...int) *types.ContainerRestartPolicy {
+		return &types.ContainerRestartPolicy{Enabled: true, IgnoredExitCodes: ignoredExitCodes}
+	}
+
+	It("should not restart containers without a restart policy", func() {
+		pod := convert(types.ContainerDefinition{Name: strPtr("app"), Image: strPtr("app:latest")})
+		Expect(pod.Spec.RestartPolicy).To(Equal(corev1.RestartPolicyNever))
+		Expect(pod.Spec.InitContainers).To(BeEmpty())
+	})
+
+	It("should not restart containers with a disabled restart policy", func() {
+		pod := convert(types.ContainerDefinition{
+			Name:          strPtr("app"),
+			Image:         strPtr("app:latest"),
+			RestartPolicy: &types.ContainerRestartPolicy{Enabled: false},
+		})
+		Expect(pod.Spec.RestartPolicy).To(Equal(corev1.RestartPolicyNever))
+	})
+
+	It("should always restart the pod when every container restarts", func() {
+		pod := convert(types.ContainerDefinition{Name: strPtr("app"), Image: strPtr("app:latest"), RestartPolicy: restartPolicy()})
+		Expect(pod.Spec.RestartPolicy).To(Equal(corev1.RestartPolicyAlways))
+	})
+
+	It("should restart the pod on failure when every container ignores exit code 0", func() {
+		pod := convert(types.ContainerDefinition{Name: strPtr("app"), Image: strPtr("app:latest"), RestartPolicy: restartPolicy(0, 137)})
+		Expect(pod.Spec.RestartPolicy).To(Equal(corev1.RestartPolicyOnFailure))
+	})
+
+	It("should run restarting containers next to the others as native sidecars", func() {
+		pod := convert(
+			types.ContainerDefinition{Name: strPtr("app"), Image: strPtr("app:latest")},
+			types.ContainerDefinition{Name: strPtr("agent"), Image: strPtr("agent:latest"), Essential: boolPtr(false), RestartPolicy: restartPolicy()},
+		)
+		Expect(pod.Spec.RestartPolicy).To(Equal(corev1.RestartPolicyNever))
+		Expect(pod.Spec.Containers).To(HaveLen(1))
+		Expect(pod.Spec.Containers[0].Name).To(Equal("app"))
+
+		var sidecars []corev1.Container
+		for _, container := range pod.Spec.InitContainers {
+			if converters.IsSidecarContainer(container) {
+				sidecars = append(sidecars, container)
+			}
+		}
+		Expect(sidecars).To(HaveLen(1))
+		Expect(sidecars[0].Name).To(Equal("agent"))
+		Expect(sidecars[0].Image).To(Equal("agent:latest"))
+	})
+
+	Describe("TaskContainers", func() {
+		It("should list the containers followed by the sidecars with their statuses", func() {
+			sidecar := corev1.ContainerRestartPolicyAlways
+			pod := &corev1.Pod{
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{
+						{Name: "secret-sync"},
+						{Name: "agent", RestartPolicy: &sidecar},
+					},
+					Containers: []corev1.Container{{Name: "app"}},
+				},
+				Status: corev1.PodStatus{
+					InitContainerStatuses: []corev1.ContainerStatus{
+						{Name: "secret-sync"},
+						{Name: "agent", RestartCount: 3},
+					},
+				},
+			}
+
+			containers, statuses := converters.TaskContainers(pod)
+			Expect(containers).To(HaveLen(2))
+			Expect(containers[0].Name).To(Equal("app"))
+			Expect(containers[1].Name).To(Equal("agent"))
+			Expect(statuses[0]).To(BeNil())
+			Expect(statuses[1].RestartCount).To(Equal(int32(3)))
+		})
+	})
+
+	Describe("ValidateRestartPolicy", func() {
+		period := func(seconds int) *types.ContainerRestartPolicy {
+			return &types.ContainerRestartPolicy{Enabled: true, RestartAttemptPeriod: &seconds}
+		}
+
+		It("should accept a valid restart policy", func() {
+			Expect(converters.ValidateRestartPolicy("app", nil)).To(Succeed())
+			Expect(converters.ValidateRestartPolicy("app", restartPolicy(0, 1))).To(Succeed())
+			Expect(converters.ValidateRestartPolicy("app", period(300))).To(Succeed())
+		})
+
+		It("should reject a restartAttemptPeriod out of range", func() {
+			Expect(converters.ValidateRestartPolicy("app", period(30))).To(MatchError(ContainSubstring("between 60 and 1800 seconds")))
+			Expect(converters.ValidateRestartPolicy("app", period(3600))).To(MatchError(ContainSubstring("between 60 and 1800 seconds")))
+		})
+
+		It("should reject too many ignored exit codes", func() {
+			codes := make([]int, 51)
+			Expect(converters.ValidateRestartPolicy("app", restartPolicy(codes...))).To(MatchError(ContainSubstring("at most 50")))
+		})
+	})
+})
@@ -232,6 +232,124 @@ var _ = Describe("TaskConverter S3 Integration", func() {
 			// Should have no init containers
 			Expect(pod.Spec.InitContainers).To(HaveLen(0))
 		})
+
+		Context("with authenticated and verified artifacts", func() {
+			const secretARN = "arn:aws:secretsmanager:us-east-1:123456789012:secret:artifact-creds-AbCdEf"
+
+			convert := func(artifacts ...types.Artifact) *corev1.Pod {
+				containerDefs := []types.ContainerDefinition{
+					{Name: stringPtr("app"), Image: stringPtr("nginx:latest"), Memory: intPtr(512), Artifacts: artifacts},
+				}
+				containerDefsJSON, _ := json.Marshal(containerDefs)
+				taskDef.ContainerDefinitions = string(containerDefsJSON)
+				reqJSON, _ := json.Marshal(types.RunTaskRequest{
+					TaskDefinition: stringPtr("test-task:1"),
+					Cluster:        stringPtr("test-cluster"),
+				})
+
+				pod, err := converter.ConvertTaskToPod(taskDef, reqJSON, cluster, "task-123")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pod.Spec.InitContainers).To(HaveLen(1))
+				return pod
+			}
+
+			envByName := func(container corev1.Container) map[string]corev1.EnvVar {
+				env := make(map[string]corev1.EnvVar)
+				for _, e := range container.Env {
+					env[e.Name] = e
+				}
+				return env
+			}
+
+			It("should retry downloads and fail on checksum mismatch", func() {
+				pod := convert(types.Artifact{
+					ArtifactUrl:  stringPtr("https://example.com/app.jar"),
+					TargetPath:   stringPtr("app.jar"),
+					Checksum:     stringPtr("9F86D081884C7D659A2FEAA0C55AD015A3BF4F1B2B0B822CD15D6C15B0F00A08"),
+					ChecksumType: stringPtr("SHA256"),
+				})
+
+				script := pod.Spec.InitContainers[0].Args[0]
+				Expect(script).To(HavePrefix("set -e\nretry() {"))
+				Expect(script).To(ContainSubstring("retry https://example.com/app.jar curl -s -L -o /artifacts/app.jar -f -S https://example.com/app.jar"))
+				Expect(script).To(ContainSubstring("echo '9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08  /artifacts/app.jar' | sha256sum -c -"))
+				Expect(envByName(pod.Spec.InitContainers[0])).To(HaveKey("ARTIFACT_ATTEMPTS"))
+			})
+
+			It("should reject unusable checksums when the task starts", func() {
+				pod := convert(types.Artifact{
+					ArtifactUrl:  stringPtr("https://example.com/app.jar"),
+					TargetPath:   stringPtr("app.jar"),
+					Checksum:     stringPtr("abc; rm -rf /"),
+					ChecksumType: stringPtr("sha256"),
+				})
+				Expect(pod.Spec.InitContainers[0].Args[0]).To(ContainSubstring("echo 'invalid checksum for https://example.com/app.jar' >&2 && exit 1"))
+			})
+
+			It("should send headers read from Secrets Manager", func() {
+				pod := convert(types.Artifact{
+					ArtifactUrl: stringPtr("https://example.com/private.tar.gz"),
+					TargetPath:  stringPtr("private.tar.gz"),
+					Headers: []types.ArtifactHeader{
+						{Name: stringPtr("Authorization"), ValueFrom: stringPtr(secretARN + ":token::")},
+						{Name: stringPtr("X-Client"), Value: stringPtr("kecs")},
+					},
+				})
+
+				initContainer := pod.Spec.InitContainers[0]
+				Expect(initContainer.Args[0]).To(ContainSubstring(`-H "Authorization: $ARTIFACT_0_HEADER_0" -H "X-Client: $ARTIFACT_0_HEADER_1"`))
+
+				env := envByName(initContainer)
+				Expect(env["ARTIFACT_0_HEADER_1"].Value).To(Equal("kecs"))
+				Expect(env["ARTIFACT_0_HEADER_0"].ValueFrom.SecretKeyRef.Name).To(Equal("sm-artifact-creds"))
+				Expect(env["ARTIFACT_0_HEADER_0"].ValueFrom.SecretKeyRef.Key).To(Equal("token"))
+
+				// The secret is synced for the init container
+				Expect(pod.Annotations["kecs.dev/secret-count"]).To(Equal("1"))
+				Expect(pod.Annotations["kecs.dev/secret-0-arn"]).To(Equal("artifact-downloader-app:ARTIFACT_0_HEADER_0:" + secretARN + ":token::"))
+			})
+
+			It("should download S3 artifacts with credentials from Secrets Manager", func() {
+				pod := convert(types.Artifact{
+					ArtifactUrl:     stringPtr("s3://private-bucket/app.conf"),
+					TargetPath:      stringPtr("app.conf"),
+					CredentialsFrom: stringPtr(secretARN),
+				})
+
+				initContainer := pod.Spec.InitContainers[0]
+				Expect(initContainer.Args[0]).To(ContainSubstring(`env AWS_ACCESS_KEY_ID="$ARTIFACT_0_ACCESS_KEY_ID" AWS_SECRET_ACCESS_KEY="$ARTIFACT_0_SECRET_ACCESS_KEY"`))
+				Expect(initContainer.Args[0]).To(ContainSubstring("aws s3 cp s3://private-bucket/app.conf /artifacts/app.conf"))
+
+				env := envByName(initContainer)
+				Expect(env["ARTIFACT_0_ACCESS_KEY_ID"].ValueFrom.SecretKeyRef.Key).To(Equal("accessKeyId"))
+				Expect(env["ARTIFACT_0_SECRET_ACCESS_KEY"].ValueFrom.SecretKeyRef.Key).To(Equal("secretAccessKey"))
+				Expect(*env["ARTIFACT_0_SESSION_TOKEN"].ValueFrom.SecretKeyRef.Optional).To(BeTrue())
+				Expect(pod.Annotations["kecs.dev/secret-count"]).To(Equal("3"))
+			})
+
+			It("should unpack archives into the target path", func() {
+				pod := convert(
+					types.Artifact{
+						ArtifactUrl: stringPtr("https://example.com/site.zip"),
+						TargetPath:  stringPtr("site"),
+						Extract:     boolPtr(true),
+						Permissions: stringPtr("0755"),
+					},
+					types.Artifact{
+						ArtifactUrl: stringPtr("s3://bucket/config.tgz"),
+						TargetPath:  stringPtr("config"),
+						Extract:     boolPtr(true),
+					},
+				)
+
+				script := pod.Spec.InitContainers[0].Args[0]
+				Expect(script).To(ContainSubstring("curl -s -L -o /tmp/artifact-0"))
+				Expect(script).To(ContainSubstring("unzip -o -q /tmp/artifact-0 -d /artifacts/site"))
+				Expect(script).To(ContainSubstring("chmod -R 0755 /artifacts/site"))
+				Expect(script).To(ContainSubstring("aws s3 cp s3://bucket/config.tgz /tmp/artifact-1"))
+				Expect(script).To(ContainSubstring("tar -xzf /tmp/artifact-1 -C /artifacts/config"))
+			})
+		})
 	})
 
 	Describe("Artifact download script generation", func() {
@@ -282,3 +400,7 @@ var _ = Describe("TaskConverter S3 Integration", func() {
 func intPtr(i int) *int {
 	return &i
 }
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -234,7 +234,8 @@ var _ = Describe("TaskConverter", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(pod.Spec.Containers).To(HaveLen(2))
 			Expect(pod.Spec.Containers[0].Name).To(Equal("nginx"))
-			Expect(pod.Spec.Containers[1].Name).To(Equal("sidecar-nonessential"))
+			Expect(pod.Spec.Containers[1].Name).To(Equal("sidecar"))
+			Expect(pod.Annotations).To(HaveKeyWithValue(NonEssentialContainersAnnotation, "sidecar"))
 		})
 	})
 })
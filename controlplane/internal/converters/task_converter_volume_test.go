@@ -248,7 +248,7 @@ func TestConvertVolumes(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := converter.convertVolumes(tt.volumes)
+			result := converter.convertVolumes(tt.volumes, nil)
 			assert.Len(t, result, tt.expectedVolumes)
 			if tt.validate != nil {
 				tt.validate(t, result)
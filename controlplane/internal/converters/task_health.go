@@ -0,0 +1,82 @@
+package converters
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ContainerHealthStatus returns the ECS health status of a task container.
+// Containers without a health check are UNKNOWN. A running container that is
+// not ready is UNKNOWN while its health check is still in its start period
+// and retries, and UNHEALTHY afterwards.
+func ContainerHealthStatus(container corev1.Container, status *corev1.ContainerStatus) string {
+	probe := container.LivenessProbe
+	if probe == nil {
+		probe = container.ReadinessProbe
+	}
+	if probe == nil || status == nil || status.State.Running == nil {
+		return "UNKNOWN"
+	}
+	if status.Ready {
+		return "HEALTHY"
+	}
+
+	// Probes left unset by the converter use the Kubernetes defaults
+	period := probe.PeriodSeconds
+	if period == 0 {
+		period = 10
+	}
+	failureThreshold := probe.FailureThreshold
+	if failureThreshold == 0 {
+		failureThreshold = 3
+	}
+	grace := time.Duration(probe.InitialDelaySeconds+period*failureThreshold) * time.Second
+	if time.Since(status.State.Running.StartedAt.Time) < grace {
+		return "UNKNOWN"
+	}
+	return "UNHEALTHY"
+}
+
+// TaskHealthStatus aggregates the health of the essential containers of a
+// task pod the way ECS does: UNHEALTHY when any of them is unhealthy, HEALTHY
+// when all of them with a health check are healthy and UNKNOWN otherwise,
+// including tasks without health checks.
+func TaskHealthStatus(pod *corev1.Pod) string {
+	checked := 0
+	healthy := true
+	containers, statuses := TaskContainers(pod)
+	for i, container := range containers {
+		if !IsEssentialContainer(pod, container.Name) {
+			continue
+		}
+		if container.LivenessProbe == nil && container.ReadinessProbe == nil {
+			continue
+		}
+		checked++
+		switch ContainerHealthStatus(container, statuses[i]) {
+		case "UNHEALTHY":
+			return "UNHEALTHY"
+		case "UNKNOWN":
+			healthy = false
+		}
+	}
+	if checked > 0 && healthy {
+		return "HEALTHY"
+	}
+	return "UNKNOWN"
+}
+
+// InstanceHealthStatus returns the Cloud Map health status of the instance
+// registered for a task pod. Like ECS, tasks without health checks count as
+// healthy once they run, other tasks follow their aggregated health.
+func InstanceHealthStatus(pod *corev1.Pod) string {
+	containers, _ := TaskContainers(pod)
+	for _, container := range containers {
+		if IsEssentialContainer(pod, container.Name) &&
+			(container.LivenessProbe != nil || container.ReadinessProbe != nil) {
+			return TaskHealthStatus(pod)
+		}
+	}
+	return "HEALTHY"
+}
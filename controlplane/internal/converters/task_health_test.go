@@ -0,0 +1,120 @@
+package converters_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+)
+
+var _ = Describe("Task Health", func() {
+	probe := &corev1.Probe{InitialDelaySeconds: 10, PeriodSeconds: 5, FailureThreshold: 3}
+
+	running := func(name string, ready bool, startedAgo time.Duration) corev1.ContainerStatus {
+		return corev1.ContainerStatus{
+			Name:  name,
+			Ready: ready,
+			State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{
+				StartedAt: metav1.NewTime(time.Now().Add(-startedAgo)),
+			}},
+		}
+	}
+
+	newPod := func(containers []corev1.Container, statuses ...corev1.ContainerStatus) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{converters.NonEssentialContainersAnnotation: "agent"},
+			},
+			Spec:   corev1.PodSpec{Containers: containers},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning, ContainerStatuses: statuses},
+		}
+	}
+
+	Describe("ContainerHealthStatus", func() {
+		checked := corev1.Container{Name: "app", LivenessProbe: probe, ReadinessProbe: probe}
+
+		It("should report containers without a health check as UNKNOWN", func() {
+			status := running("app", true, time.Hour)
+			Expect(converters.ContainerHealthStatus(corev1.Container{Name: "app"}, &status)).To(Equal("UNKNOWN"))
+		})
+
+		It("should report ready containers as HEALTHY", func() {
+			status := running("app", true, time.Hour)
+			Expect(converters.ContainerHealthStatus(checked, &status)).To(Equal("HEALTHY"))
+		})
+
+		It("should report containers within their start period and retries as UNKNOWN", func() {
+			status := running("app", false, 5*time.Second)
+			Expect(converters.ContainerHealthStatus(checked, &status)).To(Equal("UNKNOWN"))
+		})
+
+		It("should report containers failing their health check afterwards as UNHEALTHY", func() {
+			status := running("app", false, time.Minute)
+			Expect(converters.ContainerHealthStatus(checked, &status)).To(Equal("UNHEALTHY"))
+		})
+
+		It("should report containers that do not run as UNKNOWN", func() {
+			Expect(converters.ContainerHealthStatus(checked, nil)).To(Equal("UNKNOWN"))
+			status := corev1.ContainerStatus{Name: "app", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{}}}
+			Expect(converters.ContainerHealthStatus(checked, &status)).To(Equal("UNKNOWN"))
+		})
+	})
+
+	Describe("TaskHealthStatus", func() {
+		containers := []corev1.Container{
+			{Name: "app", LivenessProbe: probe},
+			{Name: "worker", LivenessProbe: probe},
+			{Name: "proxy"},
+			{Name: "agent", LivenessProbe: probe},
+		}
+
+		It("should be HEALTHY when all essential containers with a health check are healthy", func() {
+			pod := newPod(containers,
+				running("app", true, time.Hour),
+				running("worker", true, time.Hour),
+				running("proxy", false, time.Hour),
+				running("agent", false, time.Hour),
+			)
+			Expect(converters.TaskHealthStatus(pod)).To(Equal("HEALTHY"))
+		})
+
+		It("should be UNHEALTHY when any essential container is unhealthy", func() {
+			pod := newPod(containers,
+				running("app", true, time.Hour),
+				running("worker", false, time.Hour),
+			)
+			Expect(converters.TaskHealthStatus(pod)).To(Equal("UNHEALTHY"))
+		})
+
+		It("should be UNKNOWN while a health check is evaluated", func() {
+			pod := newPod(containers,
+				running("app", true, time.Hour),
+				running("worker", false, time.Second),
+			)
+			Expect(converters.TaskHealthStatus(pod)).To(Equal("UNKNOWN"))
+		})
+
+		It("should be UNKNOWN without health checks", func() {
+			pod := newPod([]corev1.Container{{Name: "app"}}, running("app", true, time.Hour))
+			Expect(converters.TaskHealthStatus(pod)).To(Equal("UNKNOWN"))
+		})
+	})
+
+	Describe("InstanceHealthStatus", func() {
+		It("should report tasks without health checks as HEALTHY", func() {
+			pod := newPod([]corev1.Container{{Name: "app"}}, running("app", true, time.Hour))
+			Expect(converters.InstanceHealthStatus(pod)).To(Equal("HEALTHY"))
+		})
+
+		It("should follow the task health with health checks", func() {
+			containers := []corev1.Container{{Name: "app", LivenessProbe: probe}}
+			Expect(converters.InstanceHealthStatus(newPod(containers, running("app", false, time.Second)))).To(Equal("UNKNOWN"))
+			Expect(converters.InstanceHealthStatus(newPod(containers, running("app", false, time.Hour)))).To(Equal("UNHEALTHY"))
+			Expect(converters.InstanceHealthStatus(newPod(containers, running("app", true, time.Hour)))).To(Equal("HEALTHY"))
+		})
+	})
+})
@@ -0,0 +1,111 @@
+// Package debugcontainer attaches ephemeral debug containers to the pods of
+// running tasks. The containers are not part of the task definition, they
+// appear in the containers of the task under their generated name and stay
+// until the task stops.
+package debugcontainer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+// NamePrefix prefixes the names of debug containers, which distinguishes them
+// from the containers of the task definition
+const NamePrefix = "kecs-debug-"
+
+// ErrPodNotFound is returned when the pod of a task no longer exists
+var ErrPodNotFound = errors.New("pod of the task not found")
+
+// Spec describes a debug container
+type Spec struct {
+	// Name is appended to NamePrefix, a random suffix is used when empty
+	Name string
+	// Image of the debug container
+	Image string
+	// Command overrides the entrypoint of the image
+	Command []string
+	// Target is the container whose process namespace the debug container
+	// joins, so its processes can be inspected
+	Target string
+}
+
+// ContainerName returns the name the container of a spec gets
+func (s Spec) ContainerName() string {
+	return NamePrefix + s.Name
+}
+
+// Validate checks a spec and generates its name when none was given
+func (s *Spec) Validate() error {
+	if s.Image == "" {
+		return fmt.Errorf("image is required")
+	}
+	if s.Name == "" {
+		s.Name = rand.String(5)
+	}
+	s.Name = strings.TrimPrefix(s.Name, NamePrefix)
+	if errs := validation.IsDNS1123Label(s.ContainerName()); len(errs) > 0 {
+		return fmt.Errorf("invalid debug container name %s: %s", s.ContainerName(), strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// Attach adds the debug container of a spec to a pod and returns its name.
+// The container keeps stdin and a TTY open, so shells of the image keep
+// running and can be entered with ExecuteCommand.
+func Attach(ctx context.Context, kubeClient kubernetes.Interface, namespace, podName string, spec Spec) (string, error) {
+	if err := spec.Validate(); err != nil {
+		return "", err
+	}
+	pod, err := kubeClient.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return "", ErrPodNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get pod: %w", err)
+	}
+
+	name := spec.ContainerName()
+	if spec.Target != "" && !hasContainer(pod.Spec.Containers, spec.Target) {
+		return "", fmt.Errorf("task has no container %s", spec.Target)
+	}
+	for _, container := range pod.Spec.EphemeralContainers {
+		if container.Name == name {
+			return "", fmt.Errorf("debug container %s already exists", name)
+		}
+	}
+
+	pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, corev1.EphemeralContainer{
+		EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+			Name:                     name,
+			Image:                    spec.Image,
+			Command:                  spec.Command,
+			Stdin:                    true,
+			TTY:                      true,
+			TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+		},
+		TargetContainerName: spec.Target,
+	})
+	if _, err := kubeClient.CoreV1().Pods(namespace).UpdateEphemeralContainers(ctx, podName, pod, metav1.UpdateOptions{}); err != nil {
+		return "", fmt.Errorf("failed to add debug container: %w", err)
+	}
+	return name, nil
+}
+
+// hasContainer reports whether containers include one named name
+func hasContainer(containers []corev1.Container, name string) bool {
+	for _, container := range containers {
+		if container.Name == name {
+			return true
+		}
+	}
+	return false
+}
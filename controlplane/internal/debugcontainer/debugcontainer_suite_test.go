@@ -0,0 +1,13 @@
+package debugcontainer_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestDebugcontainer(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Debugcontainer Suite")
+}
@@ -0,0 +1,73 @@
+package debugcontainer_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/debugcontainer"
+)
+
+var _ = Describe("Attach", func() {
+	const namespace = "default-us-east-1"
+
+	var kubeClient *fake.Clientset
+
+	BeforeEach(func() {
+		kubeClient = fake.NewSimpleClientset(&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: namespace},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "nginx"}}},
+		})
+	})
+
+	It("adds an ephemeral container targeting a task container", func() {
+		name, err := debugcontainer.Attach(context.Background(), kubeClient, namespace, "web-1", debugcontainer.Spec{
+			Name:    "shell",
+			Image:   "busybox",
+			Command: []string{"sh"},
+			Target:  "app",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(name).To(Equal("kecs-debug-shell"))
+
+		pod, err := kubeClient.CoreV1().Pods(namespace).Get(context.Background(), "web-1", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pod.Spec.EphemeralContainers).To(HaveLen(1))
+		container := pod.Spec.EphemeralContainers[0]
+		Expect(container.Name).To(Equal("kecs-debug-shell"))
+		Expect(container.Image).To(Equal("busybox"))
+		Expect(container.Command).To(Equal([]string{"sh"}))
+		Expect(container.TargetContainerName).To(Equal("app"))
+		Expect(container.Stdin).To(BeTrue())
+		Expect(container.TTY).To(BeTrue())
+
+		_, err = debugcontainer.Attach(context.Background(), kubeClient, namespace, "web-1", debugcontainer.Spec{Name: "shell", Image: "busybox"})
+		Expect(err).To(MatchError("debug container kecs-debug-shell already exists"))
+	})
+
+	It("generates names", func() {
+		name, err := debugcontainer.Attach(context.Background(), kubeClient, namespace, "web-1", debugcontainer.Spec{Image: "busybox"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(name).To(MatchRegexp(`^kecs-debug-[a-z0-9]{5}$`))
+	})
+
+	It("rejects unknown targets and pods", func() {
+		_, err := debugcontainer.Attach(context.Background(), kubeClient, namespace, "web-1", debugcontainer.Spec{Image: "busybox", Target: "db"})
+		Expect(err).To(MatchError("task has no container db"))
+
+		_, err = debugcontainer.Attach(context.Background(), kubeClient, namespace, "web-2", debugcontainer.Spec{Image: "busybox"})
+		Expect(err).To(MatchError(debugcontainer.ErrPodNotFound))
+	})
+
+	DescribeTable("validates specs",
+		func(spec debugcontainer.Spec, message string) {
+			Expect(spec.Validate()).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("missing image", debugcontainer.Spec{}, "image is required"),
+		Entry("invalid name", debugcontainer.Spec{Image: "busybox", Name: "Shell_1"}, "invalid debug container name"),
+	)
+})
@@ -0,0 +1,124 @@
+// Package deterministic provides the identifiers and clock used when creating
+// resources. In deterministic mode (features.deterministic) identifiers are
+// sequential and the clock starts at a fixed epoch, so that ARNs and timestamps
+// are reproducible across runs, e.g. for Terraform acceptance test golden files.
+package deterministic
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Epoch is the time the deterministic clock starts at
+var Epoch = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Clock abstracts the current time
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+// SteppingClock returns a fixed start time and advances by a constant step on every call
+type SteppingClock struct {
+	mu      sync.Mutex
+	current time.Time
+	step    time.Duration
+}
+
+// NewSteppingClock creates a clock starting at start that advances by step on every call to Now
+func NewSteppingClock(start time.Time, step time.Duration) *SteppingClock {
+	return &SteppingClock{current: start, step: step}
+}
+
+// Now returns the current clock time and advances the clock
+func (c *SteppingClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.current
+	c.current = c.current.Add(c.step)
+	return now
+}
+
+var (
+	mu       sync.Mutex
+	enabled  bool
+	sequence uint64
+	clock    Clock = realClock{}
+)
+
+// Enable turns deterministic mode on or off. Enabling resets the sequence and
+// installs a stepping clock starting at Epoch, so it must only be enabled on
+// empty storage: the IDs of resources stored by a previous run are issued again.
+func Enable(on bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	enabled = on
+	sequence = 0
+	if on {
+		clock = NewSteppingClock(Epoch, time.Second)
+	} else {
+		clock = realClock{}
+	}
+}
+
+// Enabled reports whether deterministic mode is on
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return enabled
+}
+
+// SetClock replaces the clock used by Now
+func SetClock(c Clock) {
+	mu.Lock()
+	defer mu.Unlock()
+	if c == nil {
+		c = realClock{}
+	}
+	clock = c
+}
+
+// Now returns the current time according to the configured clock
+func Now() time.Time {
+	mu.Lock()
+	c := clock
+	mu.Unlock()
+	return c.Now()
+}
+
+// next returns the next sequence number
+func next() uint64 {
+	mu.Lock()
+	defer mu.Unlock()
+	sequence++
+	return sequence
+}
+
+// NewUUID returns a random UUID, or a sequential one in deterministic mode
+func NewUUID() string {
+	if !Enabled() {
+		return uuid.New().String()
+	}
+	n := next()
+	return fmt.Sprintf("00000000-0000-4000-8000-%012x", n)
+}
+
+// Suffix returns n hex characters, random or sequential in deterministic mode
+func Suffix(n int) string {
+	if Enabled() {
+		return fmt.Sprintf("%0*x", n, next())
+	}
+	b := make([]byte, (n+1)/2)
+	if _, err := rand.Read(b); err != nil {
+		return uuid.New().String()[:n]
+	}
+	return hex.EncodeToString(b)[:n]
+}
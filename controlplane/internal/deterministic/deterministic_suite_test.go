@@ -0,0 +1,13 @@
+package deterministic_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestDeterministic(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Deterministic Suite")
+}
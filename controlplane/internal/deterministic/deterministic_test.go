@@ -0,0 +1,52 @@
+package deterministic_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
+)
+
+var _ = Describe("Deterministic mode", func() {
+	AfterEach(func() {
+		deterministic.Enable(false)
+	})
+
+	It("should generate sequential identifiers", func() {
+		deterministic.Enable(true)
+
+		Expect(deterministic.NewUUID()).To(Equal("00000000-0000-4000-8000-000000000001"))
+		Expect(deterministic.Suffix(8)).To(Equal("00000002"))
+	})
+
+	It("should restart the sequence when re-enabled", func() {
+		deterministic.Enable(true)
+		first := deterministic.Suffix(8)
+
+		deterministic.Enable(true)
+		Expect(deterministic.Suffix(8)).To(Equal(first))
+	})
+
+	It("should use a stepping clock starting at the epoch", func() {
+		deterministic.Enable(true)
+
+		Expect(deterministic.Now()).To(Equal(deterministic.Epoch))
+		Expect(deterministic.Now()).To(Equal(deterministic.Epoch.Add(time.Second)))
+	})
+
+	It("should allow the clock to be replaced", func() {
+		fixed := time.Date(2030, time.June, 1, 12, 0, 0, 0, time.UTC)
+		deterministic.SetClock(deterministic.NewSteppingClock(fixed, 0))
+
+		Expect(deterministic.Now()).To(Equal(fixed))
+		Expect(deterministic.Now()).To(Equal(fixed))
+	})
+
+	It("should generate random identifiers when disabled", func() {
+		Expect(deterministic.Enabled()).To(BeFalse())
+		Expect(deterministic.Suffix(8)).To(HaveLen(8))
+		Expect(deterministic.NewUUID()).NotTo(Equal(deterministic.NewUUID()))
+	})
+})
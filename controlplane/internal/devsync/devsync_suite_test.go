@@ -0,0 +1,13 @@
+package devsync_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestDevsync(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Devsync Suite")
+}
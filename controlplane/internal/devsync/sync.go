@@ -0,0 +1,147 @@
+// Package devsync copies local source changes into the containers of running
+// tasks for "kecs dev". Files are unpacked with tar inside the container
+// through the pod exec subresource, the way kubectl cp does, so containers
+// need tar and sh.
+package devsync
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/execcommand"
+)
+
+// Executor runs a command in a container, feeding it stdin
+type Executor interface {
+	Exec(ctx context.Context, target execcommand.Target, command []string, stdin io.Reader, stdout, stderr io.Writer) error
+}
+
+// podExecutor runs commands through the pod exec subresource
+type podExecutor struct {
+	kubeClient kubernetes.Interface
+	restConfig *rest.Config
+}
+
+// NewPodExecutor creates an executor that runs commands in pod containers
+func NewPodExecutor(kubeClient kubernetes.Interface, restConfig *rest.Config) Executor {
+	return &podExecutor{kubeClient: kubeClient, restConfig: restConfig}
+}
+
+// Exec implements Executor
+func (e *podExecutor) Exec(ctx context.Context, target execcommand.Target, command []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	req := e.kubeClient.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(target.Namespace).
+		Name(target.PodName).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: target.Container,
+			Command:   command,
+			Stdin:     stdin != nil,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(e.restConfig, "POST", req.URL())
+	if err != nil {
+		return fmt.Errorf("failed to create exec session: %w", err)
+	}
+	return executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+}
+
+// Change is a batch of local changes synced into a directory of a container
+type Change struct {
+	// Dir is the absolute directory in the container the files belong to
+	Dir string
+	// Archive is a gzipped tar of the changed files relative to Dir, nil when
+	// only files were deleted
+	Archive []byte
+	// Deleted lists the deleted files relative to Dir
+	Deleted []string
+	// Command runs with sh -c after the files were synced, e.g. to restart
+	// the process of the container
+	Command string
+}
+
+// Validate checks that a change stays inside its directory
+func (c Change) Validate() error {
+	if !path.IsAbs(c.Dir) {
+		return fmt.Errorf("path must be an absolute directory in the container")
+	}
+	for _, file := range c.Deleted {
+		if path.IsAbs(file) || file == "" || strings.HasPrefix(path.Clean(file), "..") {
+			return fmt.Errorf("deleted file %q must be relative to %s", file, c.Dir)
+		}
+	}
+	return nil
+}
+
+// Syncer applies changes to containers
+type Syncer struct {
+	executor Executor
+}
+
+// NewSyncer creates a syncer running its commands with executor
+func NewSyncer(executor Executor) *Syncer {
+	return &Syncer{executor: executor}
+}
+
+// Sync applies a change to a container and returns the output of its command
+func (s *Syncer) Sync(ctx context.Context, target execcommand.Target, change Change) (string, error) {
+	if err := change.Validate(); err != nil {
+		return "", err
+	}
+
+	if len(change.Deleted) > 0 {
+		command := []string{"rm", "-rf", "--"}
+		for _, file := range change.Deleted {
+			command = append(command, path.Join(change.Dir, file))
+		}
+		if err := s.run(ctx, target, command, nil); err != nil {
+			return "", fmt.Errorf("failed to delete files: %w", err)
+		}
+	}
+
+	if len(change.Archive) > 0 {
+		command := []string{"sh", "-c", `mkdir -p "$0" && tar -xzf - -C "$0"`, change.Dir}
+		if err := s.run(ctx, target, command, bytes.NewReader(change.Archive)); err != nil {
+			return "", fmt.Errorf("failed to copy files: %w", err)
+		}
+	}
+
+	if change.Command == "" {
+		return "", nil
+	}
+	var output bytes.Buffer
+	err := s.executor.Exec(ctx, target, []string{"sh", "-c", change.Command}, nil, &output, &output)
+	if err != nil {
+		return output.String(), fmt.Errorf("command failed: %w", err)
+	}
+	return output.String(), nil
+}
+
+// run runs a command and folds its error output into the returned error
+func (s *Syncer) run(ctx context.Context, target execcommand.Target, command []string, stdin io.Reader) error {
+	var stderr bytes.Buffer
+	if err := s.executor.Exec(ctx, target, command, stdin, io.Discard, &stderr); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return fmt.Errorf("%w: %s", err, message)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package devsync_test
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/devsync"
+	"github.com/nandemo-ya/kecs/controlplane/internal/execcommand"
+)
+
+// fakeExecutor records the commands it runs
+type fakeExecutor struct {
+	commands [][]string
+	stdin    [][]byte
+	output   string
+	fail     string
+}
+
+func (e *fakeExecutor) Exec(ctx context.Context, target execcommand.Target, command []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	e.commands = append(e.commands, command)
+	var input []byte
+	if stdin != nil {
+		input, _ = io.ReadAll(stdin)
+	}
+	e.stdin = append(e.stdin, input)
+	if command[0] == e.fail {
+		io.WriteString(stderr, "permission denied\n")
+		return errors.New("exit code 1")
+	}
+	io.WriteString(stdout, e.output)
+	return nil
+}
+
+var _ = Describe("Syncer", func() {
+	var (
+		executor *fakeExecutor
+		syncer   *devsync.Syncer
+		target   execcommand.Target
+	)
+
+	BeforeEach(func() {
+		executor = &fakeExecutor{output: "reloaded\n"}
+		syncer = devsync.NewSyncer(executor)
+		target = execcommand.Target{Namespace: "default-us-east-1", PodName: "api-abc", Container: "app"}
+	})
+
+	It("deletes files, unpacks the archive and runs the command", func() {
+		output, err := syncer.Sync(context.Background(), target, devsync.Change{
+			Dir:     "/app",
+			Archive: []byte("archive"),
+			Deleted: []string{"old.js", "lib/gone.js"},
+			Command: "npm run build",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output).To(Equal("reloaded\n"))
+		Expect(executor.commands).To(Equal([][]string{
+			{"rm", "-rf", "--", "/app/old.js", "/app/lib/gone.js"},
+			{"sh", "-c", `mkdir -p "$0" && tar -xzf - -C "$0"`, "/app"},
+			{"sh", "-c", "npm run build"},
+		}))
+		Expect(executor.stdin[1]).To(Equal([]byte("archive")))
+	})
+
+	It("skips the steps a change does not need", func() {
+		_, err := syncer.Sync(context.Background(), target, devsync.Change{Dir: "/app", Archive: []byte("archive")})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(executor.commands).To(HaveLen(1))
+	})
+
+	It("reports the error output of failed commands", func() {
+		executor.fail = "rm"
+		_, err := syncer.Sync(context.Background(), target, devsync.Change{Dir: "/app", Deleted: []string{"a.js"}})
+		Expect(err).To(MatchError("failed to delete files: exit code 1: permission denied"))
+	})
+
+	DescribeTable("rejects changes leaving their directory",
+		func(change devsync.Change) {
+			_, err := syncer.Sync(context.Background(), target, change)
+			Expect(err).To(HaveOccurred())
+			Expect(executor.commands).To(BeEmpty())
+		},
+		Entry("relative directory", devsync.Change{Dir: "app"}),
+		Entry("absolute deleted file", devsync.Change{Dir: "/app", Deleted: []string{"/etc/passwd"}}),
+		Entry("deleted file outside", devsync.Change{Dir: "/app", Deleted: []string{"lib/../../etc"}}),
+	)
+})
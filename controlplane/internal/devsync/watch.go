@@ -0,0 +1,231 @@
+package devsync
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/moby/patternmatcher"
+)
+
+// Batch is a set of local changes, as slash separated paths relative to the
+// watched directory
+type Batch struct {
+	Changed []string
+	Deleted []string
+}
+
+// Empty reports whether a batch has no changes
+func (b Batch) Empty() bool {
+	return len(b.Changed) == 0 && len(b.Deleted) == 0
+}
+
+// Watcher reports the changes below a local directory in batches
+type Watcher struct {
+	root     string
+	ignore   *patternmatcher.PatternMatcher
+	debounce time.Duration
+}
+
+// NewWatcher creates a watcher of root. Paths matching ignore, in
+// .dockerignore syntax, are not reported. Changes are collected until no
+// further change happened for debounce.
+func NewWatcher(root string, ignore []string, debounce time.Duration) (*Watcher, error) {
+	matcher, err := patternmatcher.New(ignore)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ignore pattern: %w", err)
+	}
+	return &Watcher{root: root, ignore: matcher, debounce: debounce}, nil
+}
+
+// Files returns every file below root that is not ignored
+func (w *Watcher) Files() ([]string, error) {
+	var files []string
+	err := w.walk(w.root, func(rel string, entry fs.DirEntry) {
+		if !entry.IsDir() {
+			files = append(files, rel)
+		}
+	})
+	return files, err
+}
+
+// Run watches root and calls sync for every batch of changes until ctx is
+// done or sync fails
+func (w *Watcher) Run(ctx context.Context, sync func(Batch) error) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch %s: %w", w.root, err)
+	}
+	defer watcher.Close()
+	if err := w.watchDir(watcher, w.root); err != nil {
+		return err
+	}
+
+	pending := map[string]bool{}
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-watcher.Errors:
+			return fmt.Errorf("failed to watch %s: %w", w.root, err)
+		case event := <-watcher.Events:
+			rel, ok := w.rel(event.Name)
+			if !ok {
+				continue
+			}
+			if event.Has(fsnotify.Create) {
+				// Files of new directories are reported with the directory
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := w.watchDir(watcher, event.Name); err != nil {
+						return err
+					}
+					w.walk(event.Name, func(child string, entry fs.DirEntry) {
+						if !entry.IsDir() {
+							pending[child] = true
+						}
+					})
+					timer.Reset(w.debounce)
+					continue
+				}
+			}
+			if event.Has(fsnotify.Chmod) && !event.Has(fsnotify.Write) {
+				continue
+			}
+			pending[rel] = true
+			timer.Reset(w.debounce)
+		case <-timer.C:
+			batch := w.batch(pending)
+			pending = map[string]bool{}
+			if batch.Empty() {
+				continue
+			}
+			if err := sync(batch); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// batch sorts pending paths into changed and deleted files
+func (w *Watcher) batch(pending map[string]bool) Batch {
+	var batch Batch
+	for rel := range pending {
+		info, err := os.Stat(filepath.Join(w.root, filepath.FromSlash(rel)))
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			batch.Deleted = append(batch.Deleted, rel)
+		case err == nil && info.Mode().IsRegular():
+			batch.Changed = append(batch.Changed, rel)
+		}
+	}
+	slices.Sort(batch.Changed)
+	slices.Sort(batch.Deleted)
+	return batch
+}
+
+// watchDir watches dir and the directories below it
+func (w *Watcher) watchDir(watcher *fsnotify.Watcher, dir string) error {
+	if err := watcher.Add(dir); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+	var err error
+	w.walk(dir, func(rel string, entry fs.DirEntry) {
+		if entry.IsDir() && err == nil {
+			err = watcher.Add(filepath.Join(w.root, filepath.FromSlash(rel)))
+		}
+	})
+	return err
+}
+
+// walk calls fn for the entries below dir that are not ignored
+func (w *Watcher) walk(dir string, fn func(rel string, entry fs.DirEntry)) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		rel, ok := w.rel(path)
+		if !ok {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		fn(rel, entry)
+		return nil
+	})
+}
+
+// rel returns the slash separated path of a file relative to root, and false
+// for ignored files
+func (w *Watcher) rel(path string) (string, bool) {
+	rel, err := filepath.Rel(w.root, path)
+	if err != nil || rel == "." {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+	if ignored, err := w.ignore.MatchesOrParentMatches(rel); err != nil || ignored {
+		return "", false
+	}
+	return rel, true
+}
+
+// Archive returns a gzipped tar of files relative to root. Files that vanished
+// since they were reported are skipped.
+func Archive(root string, files []string) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, rel := range files {
+		if err := addFile(tw, root, rel); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to archive files: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to archive files: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// addFile writes a regular file to an archive
+func addFile(tw *tar.Writer, root, rel string) error {
+	file, err := os.Open(filepath.Join(root, filepath.FromSlash(rel)))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return err
+	}
+
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return fmt.Errorf("failed to archive %s: %w", rel, err)
+	}
+	header.Name = rel
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", rel, err)
+	}
+	if _, err := io.Copy(tw, file); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", rel, err)
+	}
+	return nil
+}
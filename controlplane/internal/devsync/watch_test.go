@@ -0,0 +1,100 @@
+package devsync_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/devsync"
+)
+
+// archiveFiles returns the names and contents of the files in an archive
+func archiveFiles(archive []byte) map[string]string {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	Expect(err).NotTo(HaveOccurred())
+	tr := tar.NewReader(gz)
+	files := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		Expect(err).NotTo(HaveOccurred())
+		content, err := io.ReadAll(tr)
+		Expect(err).NotTo(HaveOccurred())
+		files[header.Name] = string(content)
+	}
+}
+
+var _ = Describe("Watcher", func() {
+	var dir string
+
+	write := func(name, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		Expect(os.MkdirAll(filepath.Dir(path), 0o755)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(content), 0o644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		write("main.go", "package main")
+		write("lib/util.go", "package lib")
+		write(".git/HEAD", "ref")
+		write("debug.log", "log")
+	})
+
+	It("lists the files that are not ignored", func() {
+		watcher, err := devsync.NewWatcher(dir, []string{".git", "*.log"}, time.Millisecond)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(watcher.Files()).To(ConsistOf("main.go", "lib/util.go"))
+	})
+
+	It("archives files relative to the directory", func() {
+		archive, err := devsync.Archive(dir, []string{"main.go", "lib/util.go", "missing.go"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(archiveFiles(archive)).To(Equal(map[string]string{
+			"main.go":     "package main",
+			"lib/util.go": "package lib",
+		}))
+	})
+
+	It("reports changed and deleted files in batches", func() {
+		watcher, err := devsync.NewWatcher(dir, []string{".git", "*.log"}, 50*time.Millisecond)
+		Expect(err).NotTo(HaveOccurred())
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		batches := make(chan devsync.Batch, 10)
+		done := make(chan error, 1)
+		go func() {
+			done <- watcher.Run(ctx, func(batch devsync.Batch) error {
+				batches <- batch
+				return nil
+			})
+		}()
+		// Give the watcher time to register its watches
+		time.Sleep(100 * time.Millisecond)
+
+		write("main.go", "package main // changed")
+		write("debug.log", "more log")
+		write("pkg/new/new.go", "package new")
+		Expect(os.Remove(filepath.Join(dir, "lib", "util.go"))).To(Succeed())
+
+		var batch devsync.Batch
+		Eventually(batches, 5*time.Second).Should(Receive(&batch))
+		Consistently(batches, 200*time.Millisecond).ShouldNot(Receive())
+		Expect(batch.Changed).To(ConsistOf("main.go", "pkg/new/new.go"))
+		Expect(batch.Deleted).To(ConsistOf("lib/util.go"))
+
+		cancel()
+		Eventually(done).Should(Receive(BeNil()))
+	})
+})
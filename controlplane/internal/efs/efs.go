@@ -0,0 +1,171 @@
+// Package efs maps EFS file systems and access points to the exports of the
+// in-cluster NFS server, so task definitions with EFS volumes work without AWS.
+// Every file system is a directory under the export root; access points are
+// exported on their own so that their POSIX user applies to every file
+// operation, as in EFS.
+package efs
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ExportRoot is the directory of the NFS server holding the file systems
+const ExportRoot = "/exports"
+
+// idPattern matches file system IDs, which become directory names
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+
+// AccessPoint is an EFS access point served by the NFS server
+type AccessPoint struct {
+	ID            string
+	FileSystemID  string
+	RootDirectory string
+	// UID and GID of the POSIX user every file operation is performed as
+	UID *int64
+	GID *int64
+	// Permissions of the root directory when it is created, e.g. "0755"
+	Permissions string
+}
+
+// ParseAccessPoints parses access points written as
+// "fsap-1=fs-1:/app:1000:1000:0755,fsap-2=fs-2:/data", i.e. the file system,
+// root directory and an optional POSIX user and permissions
+func ParseAccessPoints(spec string) (map[string]AccessPoint, error) {
+	accessPoints := make(map[string]AccessPoint)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, value, ok := strings.Cut(entry, "=")
+		id = strings.TrimSpace(id)
+		if !ok || id == "" {
+			return nil, fmt.Errorf("invalid access point %q, expected fsap-id=fs-id[:rootDirectory[:uid:gid[:permissions]]]", entry)
+		}
+
+		fields := strings.Split(strings.TrimSpace(value), ":")
+		if !idPattern.MatchString(fields[0]) {
+			return nil, fmt.Errorf("invalid file system for access point %s: %q", id, fields[0])
+		}
+		accessPoint := AccessPoint{ID: id, FileSystemID: fields[0], RootDirectory: "/"}
+		if len(fields) > 1 && fields[1] != "" {
+			if strings.ContainsAny(fields[1], "' \t") {
+				return nil, fmt.Errorf("invalid root directory for access point %s: %q", id, fields[1])
+			}
+			accessPoint.RootDirectory = cleanDirectory(fields[1])
+		}
+		switch len(fields) {
+		case 1, 2:
+		case 4, 5:
+			uid, err := strconv.ParseInt(fields[2], 10, 64)
+			if err != nil || uid < 0 {
+				return nil, fmt.Errorf("invalid uid for access point %s: %q", id, fields[2])
+			}
+			gid, err := strconv.ParseInt(fields[3], 10, 64)
+			if err != nil || gid < 0 {
+				return nil, fmt.Errorf("invalid gid for access point %s: %q", id, fields[3])
+			}
+			accessPoint.UID = &uid
+			accessPoint.GID = &gid
+			if len(fields) == 5 {
+				if _, err := strconv.ParseUint(fields[4], 8, 32); err != nil {
+					return nil, fmt.Errorf("invalid permissions for access point %s: %q", id, fields[4])
+				}
+				accessPoint.Permissions = fields[4]
+			}
+		default:
+			return nil, fmt.Errorf("invalid access point %q, expected fsap-id=fs-id[:rootDirectory[:uid:gid[:permissions]]]", entry)
+		}
+		accessPoints[id] = accessPoint
+	}
+	return accessPoints, nil
+}
+
+// Mount is where an EFS volume is mounted from the NFS server. Path is the
+// NFSv4 path of the export; SubPath, when set, is the directory below it
+// that kubelet creates on first use.
+type Mount struct {
+	Path    string
+	SubPath string
+}
+
+// Resolve returns the mount of an EFS volume. Volumes of an access point mount
+// its export, where the rootDirectory of the volume must be empty or "/" as in
+// ECS. Other volumes mount the export root with the file system and root
+// directory as subpath. Access points that are not configured are ignored.
+func Resolve(fileSystemID, rootDirectory, accessPointID string, accessPoints map[string]AccessPoint) (Mount, error) {
+	if !idPattern.MatchString(fileSystemID) {
+		return Mount{}, fmt.Errorf("invalid file system ID: %q", fileSystemID)
+	}
+	if accessPoint, ok := accessPoints[accessPointID]; ok && accessPointID != "" {
+		if accessPoint.FileSystemID != fileSystemID {
+			return Mount{}, fmt.Errorf("access point %s belongs to file system %s, not %s", accessPointID, accessPoint.FileSystemID, fileSystemID)
+		}
+		if rootDirectory != "" && rootDirectory != "/" {
+			return Mount{}, fmt.Errorf("rootDirectory must be omitted or / when access point %s is used", accessPointID)
+		}
+		return Mount{Path: accessPoint.exportPath()}, nil
+	}
+
+	subPath := strings.TrimPrefix(path.Join(fileSystemID, cleanDirectory(rootDirectory)), "/")
+	return Mount{Path: "/", SubPath: subPath}, nil
+}
+
+// Exports renders the /etc/exports of the NFS server. The export root is the
+// NFSv4 pseudo root, which every other export is below.
+func Exports(accessPoints map[string]AccessPoint) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s *(rw,fsid=0,crossmnt,no_subtree_check,no_root_squash,insecure)\n", ExportRoot)
+	for i, accessPoint := range sortedAccessPoints(accessPoints) {
+		options := "no_root_squash"
+		if accessPoint.UID != nil {
+			options = fmt.Sprintf("all_squash,anonuid=%d,anongid=%d", *accessPoint.UID, *accessPoint.GID)
+		}
+		fmt.Fprintf(&b, "%s%s *(rw,fsid=%d,no_subtree_check,insecure,%s)\n", ExportRoot, accessPoint.exportPath(), i+1, options)
+	}
+	return b.String()
+}
+
+// SetupScript renders the script that creates the root directories of the
+// access points with their owner and permissions before the NFS server starts
+func SetupScript(accessPoints map[string]AccessPoint) string {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\nset -e\n")
+	for _, accessPoint := range sortedAccessPoints(accessPoints) {
+		dir := ExportRoot + accessPoint.exportPath()
+		fmt.Fprintf(&b, "mkdir -p '%s'\n", dir)
+		if accessPoint.UID != nil {
+			fmt.Fprintf(&b, "chown %d:%d '%s'\n", *accessPoint.UID, *accessPoint.GID, dir)
+		}
+		if accessPoint.Permissions != "" {
+			fmt.Fprintf(&b, "chmod %s '%s'\n", accessPoint.Permissions, dir)
+		}
+	}
+	return b.String()
+}
+
+// exportPath returns the NFSv4 path of the access point below the export root
+func (a AccessPoint) exportPath() string {
+	return path.Join("/", a.FileSystemID, cleanDirectory(a.RootDirectory))
+}
+
+// sortedAccessPoints returns the access points ordered by ID, so the exports
+// keep their fsid across renders
+func sortedAccessPoints(accessPoints map[string]AccessPoint) []AccessPoint {
+	sorted := make([]AccessPoint, 0, len(accessPoints))
+	for _, accessPoint := range accessPoints {
+		sorted = append(sorted, accessPoint)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	return sorted
+}
+
+// cleanDirectory returns an absolute directory that cannot leave its parent
+func cleanDirectory(dir string) string {
+	return path.Clean("/" + dir)
+}
@@ -0,0 +1,108 @@
+package efs_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/efs"
+)
+
+var _ = Describe("EFS", func() {
+	Describe("ParseAccessPoints", func() {
+		It("should parse access points with and without POSIX user", func() {
+			accessPoints, err := efs.ParseAccessPoints("fsap-app=fs-1:/app:1000:2000:0750, fsap-data=fs-2:/data,fsap-root=fs-3")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(accessPoints).To(HaveLen(3))
+
+			app := accessPoints["fsap-app"]
+			Expect(app.FileSystemID).To(Equal("fs-1"))
+			Expect(app.RootDirectory).To(Equal("/app"))
+			Expect(*app.UID).To(Equal(int64(1000)))
+			Expect(*app.GID).To(Equal(int64(2000)))
+			Expect(app.Permissions).To(Equal("0750"))
+
+			Expect(accessPoints["fsap-data"].UID).To(BeNil())
+			Expect(accessPoints["fsap-root"].RootDirectory).To(Equal("/"))
+		})
+
+		It("should keep root directories inside the file system", func() {
+			accessPoints, err := efs.ParseAccessPoints("fsap-1=fs-1:../../etc")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(accessPoints["fsap-1"].RootDirectory).To(Equal("/etc"))
+		})
+
+		It("should accept an empty spec", func() {
+			accessPoints, err := efs.ParseAccessPoints("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(accessPoints).To(BeEmpty())
+		})
+
+		DescribeTable("should reject invalid access points",
+			func(spec string) {
+				_, err := efs.ParseAccessPoints(spec)
+				Expect(err).To(HaveOccurred())
+			},
+			Entry("without file system", "fsap-1="),
+			Entry("without ID", "=fs-1"),
+			Entry("with a uid only", "fsap-1=fs-1:/app:1000"),
+			Entry("with an invalid gid", "fsap-1=fs-1:/app:1000:staff"),
+			Entry("with invalid permissions", "fsap-1=fs-1:/app:1000:1000:0999"),
+			Entry("with an invalid file system", "fsap-1=../fs-1:/app"),
+			Entry("with a quote in the root directory", "fsap-1=fs-1:/it's"),
+		)
+	})
+
+	Describe("Resolve", func() {
+		var accessPoints map[string]efs.AccessPoint
+
+		BeforeEach(func() {
+			var err error
+			accessPoints, err = efs.ParseAccessPoints("fsap-app=fs-1:/app:1000:1000")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should mount the export of an access point", func() {
+			mount, err := efs.Resolve("fs-1", "", "fsap-app", accessPoints)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mount).To(Equal(efs.Mount{Path: "/fs-1/app"}))
+		})
+
+		It("should mount the root directory of a file system as subpath", func() {
+			mount, err := efs.Resolve("fs-2", "/shared/data", "", accessPoints)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mount).To(Equal(efs.Mount{Path: "/", SubPath: "fs-2/shared/data"}))
+		})
+
+		It("should mount the file system when the access point is not configured", func() {
+			mount, err := efs.Resolve("fs-2", "", "fsap-unknown", accessPoints)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mount).To(Equal(efs.Mount{Path: "/", SubPath: "fs-2"}))
+		})
+
+		It("should reject an access point of another file system", func() {
+			_, err := efs.Resolve("fs-2", "", "fsap-app", accessPoints)
+			Expect(err).To(MatchError(ContainSubstring("belongs to file system fs-1")))
+		})
+
+		It("should reject a root directory with an access point", func() {
+			_, err := efs.Resolve("fs-1", "/other", "fsap-app", accessPoints)
+			Expect(err).To(MatchError(ContainSubstring("rootDirectory must be omitted")))
+		})
+	})
+
+	Describe("Exports", func() {
+		It("should export the access points with their POSIX user", func() {
+			accessPoints, err := efs.ParseAccessPoints("fsap-b=fs-2:/data,fsap-a=fs-1:/app:1000:1000:0755")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(efs.Exports(accessPoints)).To(Equal(
+				"/exports *(rw,fsid=0,crossmnt,no_subtree_check,no_root_squash,insecure)\n" +
+					"/exports/fs-1/app *(rw,fsid=1,no_subtree_check,insecure,all_squash,anonuid=1000,anongid=1000)\n" +
+					"/exports/fs-2/data *(rw,fsid=2,no_subtree_check,insecure,no_root_squash)\n"))
+
+			Expect(efs.SetupScript(accessPoints)).To(Equal("#!/bin/sh\nset -e\n" +
+				"mkdir -p '/exports/fs-1/app'\nchown 1000:1000 '/exports/fs-1/app'\nchmod 0755 '/exports/fs-1/app'\n" +
+				"mkdir -p '/exports/fs-2/data'\n"))
+		})
+	})
+})
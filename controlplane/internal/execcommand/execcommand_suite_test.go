@@ -0,0 +1,13 @@
+package execcommand_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestExecCommand(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ExecCommand Suite")
+}
@@ -0,0 +1,51 @@
+package execcommand
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// podExecutor runs commands through the pod exec subresource
+type podExecutor struct {
+	kubeClient kubernetes.Interface
+	restConfig *rest.Config
+}
+
+// NewPodExecutor creates an executor that runs commands in pod containers
+func NewPodExecutor(kubeClient kubernetes.Interface, restConfig *rest.Config) Executor {
+	return &podExecutor{
+		kubeClient: kubeClient,
+		restConfig: restConfig,
+	}
+}
+
+// Exec implements Executor
+func (e *podExecutor) Exec(ctx context.Context, target Target, command []string, stdout, stderr io.Writer) error {
+	req := e.kubeClient.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(target.Namespace).
+		Name(target.PodName).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: target.Container,
+			Command:   command,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(e.restConfig, "POST", req.URL())
+	if err != nil {
+		return fmt.Errorf("failed to create exec session: %w", err)
+	}
+	return executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+}
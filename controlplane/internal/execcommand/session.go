@@ -0,0 +1,174 @@
+// Package execcommand runs ECS ExecuteCommand sessions in task containers and
+// delivers the session output to the destinations configured on the cluster.
+package execcommand
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// DefaultSessionTimeout bounds how long a command may run before its session
+// is closed and its output delivered
+const DefaultSessionTimeout = 20 * time.Minute
+
+// LogConfig is where the output of a session is delivered. Empty fields
+// disable the corresponding destination.
+type LogConfig struct {
+	CloudWatchLogGroupName string
+	S3BucketName           string
+	S3KeyPrefix            string
+}
+
+// Enabled reports whether the session output goes anywhere
+func (c LogConfig) Enabled() bool {
+	return c.CloudWatchLogGroupName != "" || c.S3BucketName != ""
+}
+
+// Target is the container a command runs in
+type Target struct {
+	Namespace string
+	PodName   string
+	Container string
+}
+
+// Executor runs a command in a container
+type Executor interface {
+	Exec(ctx context.Context, target Target, command []string, stdout, stderr io.Writer) error
+}
+
+// LogWriter writes session output to CloudWatch Logs
+type LogWriter interface {
+	WriteLogEvents(groupName, streamName string, messages []string) error
+}
+
+// Uploader writes session output to S3
+type Uploader interface {
+	UploadFile(ctx context.Context, bucket, key string, reader io.Reader) error
+}
+
+// Session identifies a started ExecuteCommand session
+type Session struct {
+	ID        string
+	StreamURL string
+	Token     string
+}
+
+// Manager starts sessions and ships their output
+type Manager struct {
+	executor Executor
+	region   string
+	timeout  time.Duration
+
+	mu        sync.RWMutex
+	logWriter LogWriter
+	uploader  Uploader
+	wg        sync.WaitGroup
+}
+
+// NewManager creates a session manager that runs commands with executor
+func NewManager(executor Executor, region string) *Manager {
+	return &Manager{
+		executor: executor,
+		region:   region,
+		timeout:  DefaultSessionTimeout,
+	}
+}
+
+// SetLogWriter sets the CloudWatch Logs destination
+func (m *Manager) SetLogWriter(w LogWriter) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.logWriter = w
+}
+
+// SetUploader sets the S3 destination
+func (m *Manager) SetUploader(u Uploader) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.uploader = u
+}
+
+// Start runs command in the target container in the background. KECS has no
+// Session Manager data channel, so the returned session cannot be attached
+// to; the command output is delivered to the configured log destinations
+// once it exits.
+func (m *Manager) Start(target Target, command string, logs LogConfig) *Session {
+	session := &Session{
+		ID:    fmt.Sprintf("ecs-execute-command-%s", deterministic.Suffix(17)),
+		Token: deterministic.Suffix(64),
+	}
+	session.StreamURL = fmt.Sprintf("wss://ssmmessages.%s.amazonaws.com/v1/data-channel/%s?role=publish_subscribe", m.region, session.ID)
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+		defer cancel()
+		m.run(ctx, session.ID, target, command, logs)
+	}()
+
+	return session
+}
+
+// Wait blocks until all started sessions delivered their output
+func (m *Manager) Wait() {
+	m.wg.Wait()
+}
+
+// run executes the command and delivers its output
+func (m *Manager) run(ctx context.Context, sessionID string, target Target, command string, logs LogConfig) {
+	var output bytes.Buffer
+	err := m.executor.Exec(ctx, target, []string{"/bin/sh", "-c", command}, &output, &output)
+	if err != nil {
+		logging.Warn("ExecuteCommand session failed",
+			"session", sessionID, "pod", target.PodName, "container", target.Container, "error", err)
+		fmt.Fprintf(&output, "\n%s\n", err)
+	}
+	if !logs.Enabled() {
+		return
+	}
+
+	m.mu.RLock()
+	logWriter, uploader := m.logWriter, m.uploader
+	m.mu.RUnlock()
+
+	if logs.CloudWatchLogGroupName != "" && logWriter != nil {
+		if err := logWriter.WriteLogEvents(logs.CloudWatchLogGroupName, sessionID, splitLines(output.String())); err != nil {
+			logging.Warn("Failed to write ExecuteCommand session to CloudWatch Logs",
+				"session", sessionID, "logGroup", logs.CloudWatchLogGroupName, "error", err)
+		}
+	}
+	if logs.S3BucketName != "" && uploader != nil {
+		key := ObjectKey(logs.S3KeyPrefix, sessionID)
+		if err := uploader.UploadFile(ctx, logs.S3BucketName, key, bytes.NewReader(output.Bytes())); err != nil {
+			logging.Warn("Failed to upload ExecuteCommand session to S3",
+				"session", sessionID, "bucket", logs.S3BucketName, "key", key, "error", err)
+		}
+	}
+}
+
+// ObjectKey returns the S3 key of a session log, as written by Session Manager
+func ObjectKey(prefix, sessionID string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return sessionID + ".log"
+	}
+	return prefix + "/" + sessionID + ".log"
+}
+
+// splitLines splits output into log events, dropping the trailing newline
+func splitLines(output string) []string {
+	output = strings.TrimRight(output, "\n")
+	if output == "" {
+		return nil
+	}
+	return strings.Split(output, "\n")
+}
@@ -0,0 +1,125 @@
+package execcommand_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/execcommand"
+)
+
+type fakeExecutor struct {
+	output string
+	err    error
+
+	mu      sync.Mutex
+	targets []execcommand.Target
+	command []string
+}
+
+func (e *fakeExecutor) Exec(ctx context.Context, target execcommand.Target, command []string, stdout, stderr io.Writer) error {
+	e.mu.Lock()
+	e.targets = append(e.targets, target)
+	e.command = command
+	e.mu.Unlock()
+	fmt.Fprint(stdout, e.output)
+	return e.err
+}
+
+type fakeLogWriter struct {
+	mu     sync.Mutex
+	events map[string][]string
+}
+
+func (w *fakeLogWriter) WriteLogEvents(groupName, streamName string, messages []string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.events[groupName+"/"+streamName] = messages
+	return nil
+}
+
+type fakeUploader struct {
+	mu      sync.Mutex
+	objects map[string]string
+}
+
+func (u *fakeUploader) UploadFile(ctx context.Context, bucket, key string, reader io.Reader) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.objects[bucket+"/"+key] = string(data)
+	return nil
+}
+
+var _ = Describe("Manager", func() {
+	var (
+		executor  *fakeExecutor
+		logWriter *fakeLogWriter
+		uploader  *fakeUploader
+		manager   *execcommand.Manager
+		target    execcommand.Target
+	)
+
+	BeforeEach(func() {
+		executor = &fakeExecutor{output: "total 0\nbin\n"}
+		logWriter = &fakeLogWriter{events: map[string][]string{}}
+		uploader = &fakeUploader{objects: map[string]string{}}
+		manager = execcommand.NewManager(executor, "us-east-1")
+		manager.SetLogWriter(logWriter)
+		manager.SetUploader(uploader)
+		target = execcommand.Target{Namespace: "default-us-east-1", PodName: "task-1", Container: "app"}
+	})
+
+	It("should run the command through a shell in the target container", func() {
+		session := manager.Start(target, "ls -l", execcommand.LogConfig{})
+		manager.Wait()
+
+		Expect(session.ID).To(HavePrefix("ecs-execute-command-"))
+		Expect(session.StreamURL).To(ContainSubstring(session.ID))
+		Expect(session.Token).NotTo(BeEmpty())
+		Expect(executor.targets).To(Equal([]execcommand.Target{target}))
+		Expect(executor.command).To(Equal([]string{"/bin/sh", "-c", "ls -l"}))
+	})
+
+	It("should deliver the session output to CloudWatch Logs and S3", func() {
+		session := manager.Start(target, "ls", execcommand.LogConfig{
+			CloudWatchLogGroupName: "/ecs/exec",
+			S3BucketName:           "exec-logs",
+			S3KeyPrefix:            "sessions/",
+		})
+		manager.Wait()
+
+		Expect(logWriter.events).To(HaveKeyWithValue("/ecs/exec/"+session.ID, []string{"total 0", "bin"}))
+		Expect(uploader.objects).To(HaveKeyWithValue("exec-logs/sessions/"+session.ID+".log", "total 0\nbin\n"))
+	})
+
+	It("should record command failures in the session output", func() {
+		executor.err = fmt.Errorf("command terminated with exit code 1")
+		session := manager.Start(target, "false", execcommand.LogConfig{CloudWatchLogGroupName: "/ecs/exec"})
+		manager.Wait()
+
+		Expect(logWriter.events["/ecs/exec/"+session.ID]).To(ContainElement("command terminated with exit code 1"))
+	})
+
+	It("should not deliver output when logging is disabled", func() {
+		manager.Start(target, "ls", execcommand.LogConfig{})
+		manager.Wait()
+
+		Expect(logWriter.events).To(BeEmpty())
+		Expect(uploader.objects).To(BeEmpty())
+	})
+
+	Describe("ObjectKey", func() {
+		It("should place the session log under the key prefix", func() {
+			Expect(execcommand.ObjectKey("", "s-1")).To(Equal("s-1.log"))
+			Expect(execcommand.ObjectKey("/exec/", "s-1")).To(Equal("exec/s-1.log"))
+		})
+	})
+})
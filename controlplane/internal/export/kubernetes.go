@@ -0,0 +1,277 @@
+// Package export renders the Kubernetes resources KECS creates for ECS
+// resources as manifests that can be checked into a GitOps repository.
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// PlaceholderAnnotation marks Secrets and ConfigMaps whose data KECS syncs
+// from LocalStack at runtime. The exported objects only list the keys.
+const PlaceholderAnnotation = "kecs.dev/export-placeholder"
+
+// kindOrder is the order objects are written in, dependencies first
+var kindOrder = map[string]int{
+	"Namespace":  0,
+	"Secret":     1,
+	"ConfigMap":  2,
+	"Service":    3,
+	"Deployment": 4,
+}
+
+// Object is an exported Kubernetes object
+type Object struct {
+	Kind      string
+	Name      string
+	Namespace string
+	Object    interface{}
+}
+
+// Exporter renders the Kubernetes objects of ECS clusters
+type Exporter struct {
+	storage   storage.Storage
+	converter converters.ServiceConverterInterface
+}
+
+// NewExporter creates an exporter that converts services with the same
+// converter KECS uses when it creates them
+func NewExporter(storage storage.Storage, converter converters.ServiceConverterInterface) *Exporter {
+	return &Exporter{storage: storage, converter: converter}
+}
+
+// ClusterManifests returns the Namespace, Deployments, Services, Secrets and
+// ConfigMaps KECS creates for the active services of a cluster, sorted by
+// kind and name so that exports of the same state are identical.
+func (e *Exporter) ClusterManifests(ctx context.Context, clusterName string) ([]Object, error) {
+	cluster, err := e.storage.ClusterStore().Get(ctx, clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster %s: %w", clusterName, err)
+	}
+	if cluster == nil {
+		return nil, fmt.Errorf("cluster %s: %w", clusterName, storage.ErrResourceNotFound)
+	}
+
+	services, _, err := e.storage.ServiceStore().List(ctx, cluster.ARN, storage.ServiceFilters{}, 0, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services of cluster %s: %w", clusterName, err)
+	}
+
+	namespace := fmt.Sprintf("%s-%s", cluster.Name, cluster.Region)
+	objects := []Object{{
+		Kind: "Namespace",
+		Name: namespace,
+		Object: &corev1.Namespace{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+			ObjectMeta: metav1.ObjectMeta{Name: namespace},
+		},
+	}}
+	secrets := make(map[string]map[string]bool)
+	configMaps := make(map[string]map[string]bool)
+
+	for _, service := range services {
+		if service.Status == "INACTIVE" || service.Status == "DRAINING" {
+			continue
+		}
+
+		taskDef, err := e.storage.TaskDefinitionStore().GetByARN(ctx, service.TaskDefinitionARN)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get task definition of service %s: %w", service.ServiceName, err)
+		}
+
+		var networkConfig *generated.NetworkConfiguration
+		if service.NetworkConfiguration != "" && service.NetworkConfiguration != "null" {
+			networkConfig = &generated.NetworkConfiguration{}
+			if err := json.Unmarshal([]byte(service.NetworkConfiguration), networkConfig); err != nil {
+				return nil, fmt.Errorf("failed to parse network configuration of service %s: %w", service.ServiceName, err)
+			}
+		}
+
+		deployment, kubeService, err := e.converter.ConvertServiceToDeploymentWithNetworkConfig(service, taskDef, cluster, networkConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert service %s: %w", service.ServiceName, err)
+		}
+
+		deployment.TypeMeta = metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"}
+		deployment.Status = appsv1.DeploymentStatus{}
+		objects = append(objects, Object{Kind: "Deployment", Name: deployment.Name, Namespace: deployment.Namespace, Object: deployment})
+		if kubeService != nil {
+			kubeService.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Service"}
+			kubeService.Status = corev1.ServiceStatus{}
+			objects = append(objects, Object{Kind: "Service", Name: kubeService.Name, Namespace: kubeService.Namespace, Object: kubeService})
+		}
+
+		collectReferences(&deployment.Spec.Template.Spec, secrets, configMaps)
+	}
+
+	for name, keys := range secrets {
+		objects = append(objects, Object{Kind: "Secret", Name: name, Namespace: namespace, Object: placeholderSecret(name, namespace, keys)})
+	}
+	for name, keys := range configMaps {
+		objects = append(objects, Object{Kind: "ConfigMap", Name: name, Namespace: namespace, Object: placeholderConfigMap(name, namespace, keys)})
+	}
+
+	sort.SliceStable(objects, func(i, j int) bool {
+		if objects[i].Kind != objects[j].Kind {
+			return kindOrder[objects[i].Kind] < kindOrder[objects[j].Kind]
+		}
+		return objects[i].Name < objects[j].Name
+	})
+	return objects, nil
+}
+
+// collectReferences records the Secrets and ConfigMaps a pod spec uses, with the keys it reads
+func collectReferences(spec *corev1.PodSpec, secrets, configMaps map[string]map[string]bool) {
+	add := func(refs map[string]map[string]bool, name, key string) {
+		if refs[name] == nil {
+			refs[name] = make(map[string]bool)
+		}
+		if key != "" {
+			refs[name][key] = true
+		}
+	}
+
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, container := range containers {
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+				add(secrets, ref.Name, ref.Key)
+			}
+			if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+				add(configMaps, ref.Name, ref.Key)
+			}
+		}
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.SecretRef != nil {
+				add(secrets, envFrom.SecretRef.Name, "")
+			}
+			if envFrom.ConfigMapRef != nil {
+				add(configMaps, envFrom.ConfigMapRef.Name, "")
+			}
+		}
+	}
+	for _, volume := range spec.Volumes {
+		if volume.Secret != nil {
+			add(secrets, volume.Secret.SecretName, "")
+		}
+		if volume.ConfigMap != nil {
+			add(configMaps, volume.ConfigMap.Name, "")
+		}
+	}
+}
+
+// placeholderSecret returns a Secret with the keys a service reads and empty
+// values, labeled like the Secrets the LocalStack integrations sync
+func placeholderSecret(name, namespace string, keys map[string]bool) *corev1.Secret {
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Labels:      map[string]string{"kecs.io/managed-by": "kecs"},
+			Annotations: map[string]string{PlaceholderAnnotation: "true"},
+		},
+		Type:       corev1.SecretTypeOpaque,
+		StringData: make(map[string]string),
+	}
+	switch {
+	case strings.HasPrefix(name, "sm-"):
+		secret.Labels["kecs.io/source"] = "secretsmanager"
+	case strings.HasPrefix(name, "ssm-"):
+		secret.Labels["kecs.io/source"] = "ssm"
+	}
+	for key := range keys {
+		secret.StringData[key] = ""
+	}
+	return secret
+}
+
+// placeholderConfigMap returns a ConfigMap with the keys a service reads and empty values
+func placeholderConfigMap(name, namespace string, keys map[string]bool) *corev1.ConfigMap {
+	configMap := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Labels:      map[string]string{"kecs.io/managed-by": "kecs"},
+			Annotations: map[string]string{PlaceholderAnnotation: "true"},
+		},
+		Data: make(map[string]string),
+	}
+	for key := range keys {
+		configMap.Data[key] = ""
+	}
+	return configMap
+}
+
+// MarshalYAML renders an object as a manifest without the empty
+// creationTimestamp and status fields Kubernetes fills in
+func MarshalYAML(object Object) ([]byte, error) {
+	data, err := json.Marshal(object.Object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s %s: %w", object.Kind, object.Name, err)
+	}
+	var manifest map[string]interface{}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to marshal %s %s: %w", object.Kind, object.Name, err)
+	}
+	delete(manifest, "status")
+	if metadata, ok := manifest["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "creationTimestamp")
+	}
+	if template, ok := nestedMap(manifest, "spec", "template", "metadata"); ok {
+		delete(template, "creationTimestamp")
+	}
+	return yaml.Marshal(manifest)
+}
+
+// WriteYAML writes objects as a multi-document YAML stream
+func WriteYAML(w io.Writer, objects []Object) error {
+	for i, object := range objects {
+		data, err := MarshalYAML(object)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			if _, err := io.WriteString(w, "---\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FileName returns the deterministic file name of an exported object
+func FileName(object Object) string {
+	return fmt.Sprintf("%s-%s.yaml", strings.ToLower(object.Kind), object.Name)
+}
+
+func nestedMap(m map[string]interface{}, fields ...string) (map[string]interface{}, bool) {
+	for _, field := range fields {
+		next, ok := m[field].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		m = next
+	}
+	return m, true
+}
@@ -0,0 +1,130 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/mocks"
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+var _ = Describe("Exporter", func() {
+	var (
+		ctx      context.Context
+		store    *mocks.MockStorage
+		exporter *Exporter
+	)
+
+	createService := func(name, status string, createdAt time.Time) {
+		taskDef, err := store.TaskDefinitionStore().Register(ctx, &storage.TaskDefinition{
+			Family:      name,
+			NetworkMode: "awsvpc",
+			ContainerDefinitions: `[{
+				"name": "app",
+				"image": "nginx:latest",
+				"portMappings": [{"containerPort": 80, "protocol": "tcp"}],
+				"secrets": [
+					{"name": "DB_PASSWORD", "valueFrom": "arn:aws:secretsmanager:us-east-1:123456789012:secret:db-password-AbCdEf"},
+					{"name": "API_KEY", "valueFrom": "arn:aws:ssm:us-east-1:123456789012:parameter/app/api-key"}
+				]
+			}]`,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(store.ServiceStore().Create(ctx, &storage.Service{
+			ARN:                "arn:aws:ecs:us-east-1:123456789012:service/default/" + name,
+			ServiceName:        name,
+			ClusterARN:         "arn:aws:ecs:us-east-1:123456789012:cluster/default",
+			TaskDefinitionARN:  taskDef.ARN,
+			DesiredCount:       2,
+			LaunchType:         "FARGATE",
+			SchedulingStrategy: "REPLICA",
+			Status:             status,
+			Region:             "us-east-1",
+			AccountID:          "123456789012",
+			CreatedAt:          createdAt,
+		})).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		store = mocks.NewMockStorage()
+		store.SetClusterStore(mocks.NewMockClusterStore())
+		store.SetTaskDefinitionStore(mocks.NewMockTaskDefinitionStore())
+		store.SetServiceStore(mocks.NewMockServiceStore())
+		exporter = NewExporter(store, converters.NewServiceConverter("us-east-1", "123456789012"))
+
+		Expect(store.ClusterStore().Create(ctx, &storage.Cluster{
+			ARN:       "arn:aws:ecs:us-east-1:123456789012:cluster/default",
+			Name:      "default",
+			Status:    "ACTIVE",
+			Region:    "us-east-1",
+			AccountID: "123456789012",
+		})).To(Succeed())
+
+		now := time.Now()
+		createService("web", "ACTIVE", now)
+		createService("api", "ACTIVE", now.Add(time.Second))
+		createService("old", "INACTIVE", now.Add(2*time.Second))
+	})
+
+	It("should export the objects of active services in a deterministic order", func() {
+		objects, err := exporter.ClusterManifests(ctx, "default")
+		Expect(err).NotTo(HaveOccurred())
+
+		var names []string
+		for _, object := range objects {
+			names = append(names, object.Kind+"/"+object.Name)
+		}
+		Expect(names).To(Equal([]string{
+			"Namespace/default-us-east-1",
+			"Secret/sm-db-password",
+			"Secret/ssm-app-api-key",
+			"Deployment/api",
+			"Deployment/web",
+		}))
+	})
+
+	It("should export placeholder secrets with the keys services read", func() {
+		objects, err := exporter.ClusterManifests(ctx, "default")
+		Expect(err).NotTo(HaveOccurred())
+
+		secret, ok := objects[2].Object.(*corev1.Secret)
+		Expect(ok).To(BeTrue())
+		Expect(secret.Namespace).To(Equal("default-us-east-1"))
+		Expect(secret.Annotations).To(HaveKeyWithValue(PlaceholderAnnotation, "true"))
+		Expect(secret.Labels).To(HaveKeyWithValue("kecs.io/source", "ssm"))
+		Expect(secret.StringData).To(Equal(map[string]string{"value": ""}))
+	})
+
+	It("should render identical YAML without runtime fields on every export", func() {
+		render := func() string {
+			objects, err := exporter.ClusterManifests(ctx, "default")
+			Expect(err).NotTo(HaveOccurred())
+			var buf bytes.Buffer
+			Expect(WriteYAML(&buf, objects)).To(Succeed())
+			return buf.String()
+		}
+
+		first := render()
+		Expect(first).To(Equal(render()))
+		Expect(first).To(ContainSubstring("kind: Deployment"))
+		Expect(first).To(ContainSubstring("\n---\n"))
+		Expect(first).NotTo(ContainSubstring("creationTimestamp"))
+		Expect(first).NotTo(ContainSubstring("status:"))
+	})
+
+	It("should name files after the kind and name of objects", func() {
+		Expect(FileName(Object{Kind: "Deployment", Name: "web"})).To(Equal("deployment-web.yaml"))
+	})
+
+	It("should return an error for unknown clusters", func() {
+		_, err := exporter.ClusterManifests(ctx, "missing")
+		Expect(err).To(HaveOccurred())
+	})
+})
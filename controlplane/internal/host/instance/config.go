@@ -21,6 +21,8 @@ import (
 	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/host/k3d"
 )
 
 // InstanceConfig represents the configuration for a KECS instance
@@ -40,6 +42,32 @@ type InstanceConfig struct {
 
 	// Data directory
 	DataDir string `yaml:"dataDir"`
+
+	// ARN identity, the configured defaults when empty
+	AccountID string `yaml:"accountId,omitempty"`
+	Partition string `yaml:"partition,omitempty"`
+
+	// Registry mirrors, insecure registries and credentials of the nodes
+	Registries *k3d.RegistryConfig `yaml:"registries,omitempty"`
+
+	// Agent nodes simulating CPU architectures
+	NodePools []k3d.NodePool `yaml:"nodePools,omitempty"`
+
+	// Offline instances run from the images of Bundle without pulling
+	Offline bool   `yaml:"offline,omitempty"`
+	Bundle  string `yaml:"bundle,omitempty"`
+
+	// Backend of the AWS services, localstack when empty
+	AWSBackend string `yaml:"awsBackend,omitempty"`
+
+	// Standby is set while the instance is stopped with its components kept
+	// deployed, so that it can be resumed without redeploying them
+	Standby *StandbyState `yaml:"standby,omitempty"`
+}
+
+// StandbyState describes an instance stopped with kecs stop --keep-data
+type StandbyState struct {
+	StoppedAt time.Time `yaml:"stoppedAt"`
 }
 
 // SaveInstanceConfig saves the instance configuration to a YAML file
@@ -62,9 +90,16 @@ func SaveInstanceConfig(instanceName string, opts *StartOptions) error {
 		APIPort:                      opts.ApiPort,
 		AdminPort:                    opts.AdminPort,
 		KubePort:                     opts.KubePort,
-		LocalStack:                   true, // LocalStack is always enabled
+		LocalStack:                   !opts.WithoutLocalStack,
 		DataDir:                      opts.DataDir,
 		AdditionalLocalStackServices: opts.AdditionalLocalStackServices,
+		AccountID:                    opts.AccountID,
+		Partition:                    opts.Partition,
+		Registries:                   opts.Registries,
+		NodePools:                    opts.NodePools,
+		Offline:                      opts.Offline,
+		Bundle:                       opts.Bundle,
+		AWSBackend:                   opts.AWSBackend,
 	}
 
 	// If DataDir is empty, set default
@@ -78,11 +113,15 @@ func SaveInstanceConfig(instanceName string, opts *StartOptions) error {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	// Write to file
+	// Write to file, only readable by the user as it may hold registry credentials
 	configPath := filepath.Join(instanceDir, "config.yaml")
-	if err := os.WriteFile(configPath, data, 0644); err != nil {
+	if err := os.WriteFile(configPath, data, 0600); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
+	// Configs saved before credentials could be stored are world-readable
+	if err := os.Chmod(configPath, 0600); err != nil {
+		return fmt.Errorf("failed to restrict config file: %w", err)
+	}
 
 	return nil
 }
@@ -183,3 +222,67 @@ func UpdateInstanceKubePort(instanceName string, kubePort int) error {
 
 	return nil
 }
+
+// UpdateInstanceStandby sets or, when standby is nil, clears the standby state in the saved config
+func UpdateInstanceStandby(instanceName string, standby *StandbyState) error {
+	// Load existing config
+	config, err := LoadInstanceConfig(instanceName)
+	if err != nil {
+		return fmt.Errorf("failed to load instance config: %w", err)
+	}
+
+	config.Standby = standby
+
+	// Marshal to YAML
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	// Write back to file
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	configPath := filepath.Join(home, ".kecs", "instances", instanceName, "config.yaml")
+	if err := os.WriteFile(configPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateInstanceRegistries replaces the registry config in the saved config
+func UpdateInstanceRegistries(instanceName string, registries *k3d.RegistryConfig) error {
+	// Load existing config
+	config, err := LoadInstanceConfig(instanceName)
+	if err != nil {
+		return fmt.Errorf("failed to load instance config: %w", err)
+	}
+
+	config.Registries = registries
+
+	// Marshal to YAML
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	// Write back to file
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	configPath := filepath.Join(home, ".kecs", "instances", instanceName, "config.yaml")
+	if err := os.WriteFile(configPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	// Configs saved before credentials could be stored are world-readable
+	if err := os.Chmod(configPath, 0600); err != nil {
+		return fmt.Errorf("failed to restrict config file: %w", err)
+	}
+
+	return nil
+}
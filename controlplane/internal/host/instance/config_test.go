@@ -0,0 +1,100 @@
+package instance_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/host/instance"
+	"github.com/nandemo-ya/kecs/controlplane/internal/host/k3d"
+)
+
+var _ = Describe("InstanceConfig", func() {
+	BeforeEach(func() {
+		GinkgoT().Setenv("HOME", GinkgoT().TempDir())
+	})
+
+	Describe("SaveInstanceConfig", func() {
+		It("should keep the account ID and partition of the instance", func() {
+			Expect(instance.SaveInstanceConfig("gov", &instance.StartOptions{AccountID: "111111111111", Partition: "aws-us-gov"})).To(Succeed())
+
+			config, err := instance.LoadInstanceConfig("gov")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.AccountID).To(Equal("111111111111"))
+			Expect(config.Partition).To(Equal("aws-us-gov"))
+		})
+
+		It("should keep the offline settings of the instance", func() {
+			Expect(instance.SaveInstanceConfig("airgap", &instance.StartOptions{
+				Offline:           true,
+				Bundle:            "/opt/kecs/kecs-bundle.tar.gz",
+				WithoutLocalStack: true,
+			})).To(Succeed())
+
+			config, err := instance.LoadInstanceConfig("airgap")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Offline).To(BeTrue())
+			Expect(config.Bundle).To(Equal("/opt/kecs/kecs-bundle.tar.gz"))
+			Expect(config.LocalStack).To(BeFalse())
+		})
+
+		It("should keep the AWS backend of the instance", func() {
+			Expect(instance.SaveInstanceConfig("ci", &instance.StartOptions{AWSBackend: "stub"})).To(Succeed())
+
+			config, err := instance.LoadInstanceConfig("ci")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.AWSBackend).To(Equal("stub"))
+			Expect(config.LocalStack).To(BeTrue())
+		})
+	})
+
+	Describe("UpdateInstanceStandby", func() {
+		It("should record and clear the standby state", func() {
+			Expect(instance.SaveInstanceConfig("warm", &instance.StartOptions{ApiPort: 5373, AdminPort: 5374})).To(Succeed())
+
+			stoppedAt := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+			Expect(instance.UpdateInstanceStandby("warm", &instance.StandbyState{StoppedAt: stoppedAt})).To(Succeed())
+			config, err := instance.LoadInstanceConfig("warm")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Standby).NotTo(BeNil())
+			Expect(config.Standby.StoppedAt).To(BeTemporally("==", stoppedAt))
+			Expect(config.APIPort).To(Equal(5373), "other settings should be kept")
+
+			Expect(instance.UpdateInstanceStandby("warm", nil)).To(Succeed())
+			config, err = instance.LoadInstanceConfig("warm")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Standby).To(BeNil())
+		})
+
+		It("should fail for unknown instances", func() {
+			Expect(instance.UpdateInstanceStandby("missing", nil)).NotTo(Succeed())
+		})
+	})
+
+	Describe("UpdateInstanceRegistries", func() {
+		It("should replace the registries and keep the config private", func() {
+			Expect(instance.SaveInstanceConfig("corp", &instance.StartOptions{
+				ApiPort:    5373,
+				Registries: &k3d.RegistryConfig{Insecure: []string{"old.example.com"}},
+			})).To(Succeed())
+
+			registries := &k3d.RegistryConfig{
+				Mirrors: map[string][]string{"docker.io": {"https://mirror.example.com"}},
+				Auths:   map[string]k3d.RegistryAuth{"mirror.example.com": {Username: "ci", Password: "secret"}},
+			}
+			Expect(instance.UpdateInstanceRegistries("corp", registries)).To(Succeed())
+
+			config, err := instance.LoadInstanceConfig("corp")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Registries).To(Equal(registries))
+			Expect(config.APIPort).To(Equal(5373), "other settings should be kept")
+
+			info, err := os.Stat(filepath.Join(os.Getenv("HOME"), ".kecs", "instances", "corp", "config.yaml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+		})
+	})
+})
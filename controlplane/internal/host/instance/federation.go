@@ -0,0 +1,254 @@
+// Copyright 2025 The KECS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// Sources an instance can be discovered from
+const (
+	// SourceK3d marks instances backed by a local k3d cluster
+	SourceK3d = "k3d"
+	// SourcePort marks instances found by probing a known port, e.g. a
+	// `kecs server` started by a CI job outside of k3d
+	SourcePort = "port"
+)
+
+// maxKnownPorts bounds the ports of federation.ports, each of which is probed
+// on every discovery
+const maxKnownPorts = 256
+
+// portInstancePrefix prefixes the names of instances discovered on a known port
+const portInstancePrefix = "localhost:"
+
+// probeTimeout bounds the health probe of a known port
+const probeTimeout = 2 * time.Second
+
+// FederatedInstance is an instance as seen by the federation view
+type FederatedInstance struct {
+	Name      string `json:"name"`
+	Source    string `json:"source"`
+	Status    string `json:"status"`
+	Endpoint  string `json:"endpoint,omitempty"`
+	APIPort   int    `json:"apiPort"`
+	AdminPort int    `json:"adminPort,omitempty"`
+	Clusters  int    `json:"clusters"`
+	Services  int    `json:"services"`
+	Tasks     int    `json:"tasks"`
+}
+
+// FederationSummary aggregates the resources of all discovered instances
+type FederationSummary struct {
+	Instances        []FederatedInstance `json:"instances"`
+	TotalInstances   int                 `json:"totalInstances"`
+	RunningInstances int                 `json:"runningInstances"`
+	Clusters         int                 `json:"clusters"`
+	Services         int                 `json:"services"`
+	Tasks            int                 `json:"tasks"`
+}
+
+// NewFederationSummary sums up the counts of the given instances
+func NewFederationSummary(instances []FederatedInstance) FederationSummary {
+	summary := FederationSummary{
+		Instances:      instances,
+		TotalInstances: len(instances),
+	}
+	if summary.Instances == nil {
+		summary.Instances = []FederatedInstance{}
+	}
+	for _, inst := range instances {
+		if strings.EqualFold(inst.Status, "running") {
+			summary.RunningInstances++
+		}
+		summary.Clusters += inst.Clusters
+		summary.Services += inst.Services
+		summary.Tasks += inst.Tasks
+	}
+	return summary
+}
+
+// ParsePortList parses a comma separated list of ports and port ranges,
+// e.g. "5373,8080-8082". The result is sorted and free of duplicates, lists of
+// more than 256 ports are rejected.
+func ParsePortList(spec string) ([]int, error) {
+	seen := make(map[int]bool)
+	var ports []int
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		first, last := field, field
+		if from, to, found := strings.Cut(field, "-"); found {
+			first, last = strings.TrimSpace(from), strings.TrimSpace(to)
+		}
+		start, err := parsePort(first)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parsePort(last)
+		if err != nil {
+			return nil, err
+		}
+		if end < start {
+			return nil, fmt.Errorf("invalid port range: %s", field)
+		}
+		if end-start >= maxKnownPorts {
+			return nil, fmt.Errorf("port range %s has more than %d ports", field, maxKnownPorts)
+		}
+		for port := start; port <= end; port++ {
+			if !seen[port] {
+				seen[port] = true
+				ports = append(ports, port)
+			}
+		}
+		if len(ports) > maxKnownPorts {
+			return nil, fmt.Errorf("port list has more than %d ports", maxKnownPorts)
+		}
+	}
+	sort.Ints(ports)
+	return ports, nil
+}
+
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(s)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port: %s", s)
+	}
+	return port, nil
+}
+
+// KnownPorts returns the API ports configured with federation.ports
+// (KECS_FEDERATION_PORTS). Invalid values are logged and ignored.
+func KnownPorts() []int {
+	ports, err := ParsePortList(config.GetString("federation.ports"))
+	if err != nil {
+		logging.Warn("Ignoring invalid federation ports", "error", err)
+		return nil
+	}
+	return ports
+}
+
+// PortInstanceName returns the name of the instance discovered on a port
+func PortInstanceName(port int) string {
+	return fmt.Sprintf("%s%d", portInstancePrefix, port)
+}
+
+// PortFromInstanceName returns the API port of an instance discovered on a
+// known port, and false for any other instance name
+func PortFromInstanceName(name string) (int, bool) {
+	if !strings.HasPrefix(name, portInstancePrefix) {
+		return 0, false
+	}
+	port, err := parsePort(strings.TrimPrefix(name, portInstancePrefix))
+	if err != nil {
+		return 0, false
+	}
+	return port, true
+}
+
+// ProbePort reports whether a KECS API server answers health checks on the port
+func ProbePort(ctx context.Context, port int) bool {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://localhost:%d/health", port), nil)
+	if err != nil {
+		return false
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+// DiscoverPortInstances probes the given API ports concurrently and returns an
+// instance for every port that answers. Ports in exclude, typically those of
+// k3d instances, are skipped.
+func DiscoverPortInstances(ctx context.Context, ports []int, exclude map[int]bool) []FederatedInstance {
+	found := make([]*FederatedInstance, len(ports))
+	var wg sync.WaitGroup
+	for i, port := range ports {
+		if exclude[port] {
+			continue
+		}
+		wg.Add(1)
+		go func(i, port int) {
+			defer wg.Done()
+			if !ProbePort(ctx, port) {
+				return
+			}
+			found[i] = &FederatedInstance{
+				Name:     PortInstanceName(port),
+				Source:   SourcePort,
+				Status:   "running",
+				Endpoint: fmt.Sprintf("http://localhost:%d", port),
+				APIPort:  port,
+			}
+		}(i, port)
+	}
+	wg.Wait()
+
+	instances := []FederatedInstance{}
+	for _, inst := range found {
+		if inst != nil {
+			instances = append(instances, *inst)
+		}
+	}
+	return instances
+}
+
+// Discover lists the k3d instances together with the instances answering on
+// the known ports. Resource counts are left for the caller to fill in.
+func (m *Manager) Discover(ctx context.Context) ([]FederatedInstance, error) {
+	infos, err := m.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := make([]FederatedInstance, 0, len(infos))
+	k3dPorts := make(map[int]bool)
+	for _, info := range infos {
+		inst := FederatedInstance{
+			Name:      info.Name,
+			Source:    SourceK3d,
+			Status:    strings.ToLower(info.Status),
+			APIPort:   info.ApiPort,
+			AdminPort: info.AdminPort,
+		}
+		if info.ApiPort > 0 {
+			inst.Endpoint = fmt.Sprintf("http://localhost:%d", info.ApiPort)
+			k3dPorts[info.ApiPort] = true
+		}
+		instances = append(instances, inst)
+	}
+
+	instances = append(instances, DiscoverPortInstances(ctx, KnownPorts(), k3dPorts)...)
+	return instances, nil
+}
@@ -0,0 +1,127 @@
+package instance_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/host/instance"
+)
+
+var _ = Describe("Federation", func() {
+	Describe("ParsePortList", func() {
+		It("should parse ports and ranges", func() {
+			ports, err := instance.ParsePortList("8080, 5373-5375,8080")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ports).To(Equal([]int{5373, 5374, 5375, 8080}))
+		})
+
+		It("should return no ports for an empty list", func() {
+			ports, err := instance.ParsePortList("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ports).To(BeEmpty())
+		})
+
+		It("should reject invalid ports", func() {
+			_, err := instance.ParsePortList("abc")
+			Expect(err).To(HaveOccurred())
+			_, err = instance.ParsePortList("70000")
+			Expect(err).To(HaveOccurred())
+			_, err = instance.ParsePortList("9000-8000")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should reject lists of too many ports", func() {
+			_, err := instance.ParsePortList("1-65535")
+			Expect(err).To(HaveOccurred())
+			_, err = instance.ParsePortList("8000-8199,9000-9199")
+			Expect(err).To(HaveOccurred())
+			ports, err := instance.ParsePortList("8000-8255")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ports).To(HaveLen(256))
+		})
+	})
+
+	Describe("port instance names", func() {
+		It("should round-trip the port", func() {
+			port, ok := instance.PortFromInstanceName(instance.PortInstanceName(8080))
+			Expect(ok).To(BeTrue())
+			Expect(port).To(Equal(8080))
+		})
+
+		It("should not match k3d instance names", func() {
+			_, ok := instance.PortFromInstanceName("dev")
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("DiscoverPortInstances", func() {
+		var (
+			server *httptest.Server
+			port   int
+		)
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/health" {
+					w.WriteHeader(http.StatusOK)
+					return
+				}
+				w.WriteHeader(http.StatusNotFound)
+			}))
+			u, err := url.Parse(server.URL)
+			Expect(err).NotTo(HaveOccurred())
+			port, err = strconv.Atoi(u.Port())
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should return the ports answering health checks", func() {
+			closed := httptest.NewServer(http.NotFoundHandler())
+			closedURL, _ := url.Parse(closed.URL)
+			closedPort, _ := strconv.Atoi(closedURL.Port())
+			closed.Close()
+
+			instances := instance.DiscoverPortInstances(context.Background(), []int{port, closedPort}, nil)
+			Expect(instances).To(HaveLen(1))
+			Expect(instances[0].Name).To(Equal(instance.PortInstanceName(port)))
+			Expect(instances[0].Source).To(Equal(instance.SourcePort))
+			Expect(instances[0].Status).To(Equal("running"))
+			Expect(instances[0].APIPort).To(Equal(port))
+		})
+
+		It("should skip excluded ports", func() {
+			instances := instance.DiscoverPortInstances(context.Background(), []int{port}, map[int]bool{port: true})
+			Expect(instances).To(BeEmpty())
+		})
+	})
+
+	Describe("NewFederationSummary", func() {
+		It("should aggregate counts over all instances", func() {
+			summary := instance.NewFederationSummary([]instance.FederatedInstance{
+				{Name: "dev", Status: "running", Clusters: 2, Services: 3, Tasks: 5},
+				{Name: "ci", Status: "stopped"},
+				{Name: "localhost:8080", Status: "running", Clusters: 1, Services: 1, Tasks: 2},
+			})
+			Expect(summary.TotalInstances).To(Equal(3))
+			Expect(summary.RunningInstances).To(Equal(2))
+			Expect(summary.Clusters).To(Equal(3))
+			Expect(summary.Services).To(Equal(4))
+			Expect(summary.Tasks).To(Equal(7))
+		})
+
+		It("should encode an empty instance list", func() {
+			summary := instance.NewFederationSummary(nil)
+			Expect(summary.Instances).NotTo(BeNil())
+			Expect(summary.TotalInstances).To(BeZero())
+		})
+	})
+})
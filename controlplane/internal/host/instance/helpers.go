@@ -22,6 +22,7 @@ import (
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 
@@ -99,6 +100,8 @@ func (m *Manager) createCluster(ctx context.Context, instanceName string, cfg *c
 
 	// Enable k3d registry
 	m.k3dManager.SetEnableRegistry(true)
+	m.k3dManager.SetRegistryConfig(opts.Registries)
+	m.k3dManager.SetNodePools(opts.NodePools)
 
 	// Create cluster with port mappings
 	if err := m.k3dManager.CreateClusterWithPortMapping(ctx, clusterName, portMappings); err != nil {
@@ -224,6 +227,31 @@ func (m *Manager) deployControlPlane(ctx context.Context, instanceName string, c
 		AdminNodePort:   adminNodePort,                           // NodePort for Admin access
 		LogLevel:        cfg.Server.LogLevel,
 	}
+	// The account ID and partition of the instance are used for all ARNs
+	if opts.AccountID != "" {
+		controlPlaneConfig.ExtraEnvVars = append(controlPlaneConfig.ExtraEnvVars, corev1.EnvVar{Name: "KECS_ACCOUNT_ID", Value: opts.AccountID})
+	}
+	if opts.Partition != "" {
+		controlPlaneConfig.ExtraEnvVars = append(controlPlaneConfig.ExtraEnvVars, corev1.EnvVar{Name: "KECS_PARTITION", Value: opts.Partition})
+	}
+	if opts.ReadOnly {
+		controlPlaneConfig.ExtraEnvVars = append(controlPlaneConfig.ExtraEnvVars, corev1.EnvVar{Name: "KECS_READ_ONLY", Value: "true"})
+	}
+	if opts.StrictCompat != "" {
+		controlPlaneConfig.ExtraEnvVars = append(controlPlaneConfig.ExtraEnvVars, corev1.EnvVar{Name: "KECS_STRICT_COMPAT", Value: opts.StrictCompat})
+	}
+	if opts.ImagePullPolicy != "" {
+		controlPlaneConfig.ExtraEnvVars = append(controlPlaneConfig.ExtraEnvVars, corev1.EnvVar{Name: "KECS_IMAGE_PULL_POLICY", Value: opts.ImagePullPolicy})
+	}
+	if !cfg.LocalStack.Enabled {
+		controlPlaneConfig.ExtraEnvVars = append(controlPlaneConfig.ExtraEnvVars, corev1.EnvVar{Name: "KECS_LOCALSTACK_ENABLED", Value: "false"})
+	}
+	if cfg.LocalStack.UseStub() {
+		controlPlaneConfig.ExtraEnvVars = append(controlPlaneConfig.ExtraEnvVars, corev1.EnvVar{Name: "KECS_LOCALSTACK_BACKEND", Value: localstack.BackendStub})
+	}
+	if opts.PinImageDigests {
+		controlPlaneConfig.ExtraEnvVars = append(controlPlaneConfig.ExtraEnvVars, corev1.EnvVar{Name: "KECS_IMAGE_RESOLVE_DIGESTS", Value: "true"})
+	}
 
 	// Create control plane resources
 	controlPlaneResources := resources.CreateControlPlaneResources(controlPlaneConfig)
@@ -301,6 +329,9 @@ func (m *Manager) deployLocalStack(ctx context.Context, instanceName string, cfg
 		EdgePort:  4566,
 		Image:     cfg.LocalStack.Image,
 		Version:   cfg.LocalStack.Version,
+		// Set for offline instances, which cannot pull the latest version
+		ImagePullPolicy: cfg.LocalStack.ImagePullPolicy,
+		Backend:         cfg.LocalStack.Backend,
 	}
 
 	manager, err := localstack.NewManager(localstackConfig, client, kubeconfig)
@@ -363,8 +394,8 @@ func (m *Manager) waitForReady(ctx context.Context, instanceName string, cfg *co
 		return fmt.Errorf("control plane failed to become ready: %w", err)
 	}
 
-	// Wait for LocalStack if enabled
-	if cfg.LocalStack.Enabled {
+	// Wait for LocalStack if enabled, the AWS stub runs in the control plane
+	if cfg.LocalStack.Enabled && !cfg.LocalStack.UseStub() {
 		if err := waitForDeployment(ctx, client, "kecs-system", "localstack"); err != nil {
 			return fmt.Errorf("LocalStack failed to become ready: %w", err)
 		}
@@ -373,6 +404,36 @@ func (m *Manager) waitForReady(ctx context.Context, instanceName string, cfg *co
 	return nil
 }
 
+// componentsDeployed reports whether the control plane, and LocalStack when
+// enabled and not replaced by the AWS stub, are still deployed in the k3d
+// cluster of an instance
+func (m *Manager) componentsDeployed(ctx context.Context, instanceName string, cfg *config.Config) (bool, error) {
+	clusterName := fmt.Sprintf("kecs-%s", instanceName)
+	kubeconfig, err := m.k3dManager.GetKubeConfig(ctx, clusterName)
+	if err != nil {
+		return false, fmt.Errorf("failed to get kubeconfig: %w", err)
+	}
+
+	client, err := kubernetes.NewForConfig(kubeconfig)
+	if err != nil {
+		return false, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	deployments := []string{"kecs-server"}
+	if cfg.LocalStack.Enabled && !cfg.LocalStack.UseStub() {
+		deployments = append(deployments, "localstack")
+	}
+	for _, name := range deployments {
+		if _, err := client.AppsV1().Deployments("kecs-system").Get(ctx, name, metav1.GetOptions{}); err != nil {
+			if apierrors.IsNotFound(err) {
+				return false, nil
+			}
+			return false, err
+		}
+	}
+	return true, nil
+}
+
 // waitForDeployment waits for a deployment to be ready
 func waitForDeployment(ctx context.Context, client kubernetes.Interface, namespace, name string) error {
 	// Implementation would check deployment status
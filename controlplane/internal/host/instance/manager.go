@@ -22,9 +22,12 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
 	"github.com/nandemo-ya/kecs/controlplane/internal/config"
 	"github.com/nandemo-ya/kecs/controlplane/internal/host/k3d"
+	"github.com/nandemo-ya/kecs/controlplane/internal/localstack"
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
 )
 
@@ -36,8 +39,28 @@ type StartOptions struct {
 	AdditionalLocalStackServices string // Comma-separated list of additional LocalStack services
 	ApiPort                      int
 	AdminPort                    int
-	KubePort                     int  // Kubernetes API server port (0 for auto-assign)
-	TestMode                     bool // Enable test mode (uses mock cluster)
+	KubePort                     int                 // Kubernetes API server port (0 for auto-assign)
+	TestMode                     bool                // Enable test mode (uses mock cluster)
+	Resume                       bool                // Resume an instance stopped with StopOptions.KeepData without redeploying its components
+	AccountID                    string              // Account ID of the ARNs of the instance, fixed at creation
+	Partition                    string              // Partition of the ARNs of the instance (aws, aws-cn or aws-us-gov), fixed at creation
+	ReadOnly                     bool                // Reject mutating ECS and ELBv2 calls, e.g. for shared demo instances
+	StrictCompat                 string              // Validate responses against the AWS API models: log or fail, empty for off
+	ImagePullPolicy              string              // Pull policy of task containers (Always, IfNotPresent or Never), empty for the Kubernetes default
+	PinImageDigests              bool                // Pin image tags to their digests when task definitions are registered
+	Registries                   *k3d.RegistryConfig // Registry mirrors, insecure registries and credentials of the nodes, fixed at creation and changed with UpdateRegistries
+	Offline                      bool                // Run without internet access from the images of Bundle, fixed at creation
+	Bundle                       string              // Image bundle written by kecs bundle create, required for new offline instances
+	WithoutLocalStack            bool                // Run without LocalStack, set for offline instances whose bundle has no LocalStack
+	AWSBackend                   string              // Backend of the AWS services (localstack or stub), fixed at creation, the configured one when empty
+	NodePools                    []k3d.NodePool      // Agent nodes simulating CPU architectures, fixed at creation
+}
+
+// StopOptions contains options for stopping a KECS instance
+type StopOptions struct {
+	// KeepData keeps the instance warm: its components stay deployed so that
+	// starting it with StartOptions.Resume only has to start the k3d cluster
+	KeepData bool
 }
 
 // CreationStatus represents the status of instance creation
@@ -151,12 +174,56 @@ func (m *Manager) Start(ctx context.Context, opts *StartOptions) error {
 			if opts.DataDir == "" {
 				opts.DataDir = savedConfig.DataDir
 			}
+			if err := applySavedIdentity(opts, savedConfig); err != nil {
+				return err
+			}
+			if opts.Registries != nil {
+				return fmt.Errorf("registries of instance '%s' cannot be changed on start, update them while it runs instead", opts.InstanceName)
+			}
+			opts.Registries = savedConfig.Registries
+			if len(opts.NodePools) > 0 {
+				return fmt.Errorf("node pools of instance '%s' are fixed at creation", opts.InstanceName)
+			}
+			opts.NodePools = savedConfig.NodePools
+			if err := applySavedOffline(opts, savedConfig); err != nil {
+				return err
+			}
+			if err := applySavedAWSBackend(opts, savedConfig); err != nil {
+				return err
+			}
 		}
 
 		// Instance exists but is stopped - restart it
+		if opts.Resume {
+			return m.resumeInstance(ctx, opts)
+		}
 		return m.restartInstance(ctx, opts)
 	}
 
+	if opts.Resume {
+		return fmt.Errorf("instance '%s' does not exist", opts.InstanceName)
+	}
+
+	if opts.AccountID != "" {
+		if err := awsarn.ValidateAccountID(opts.AccountID); err != nil {
+			return err
+		}
+	}
+	if opts.Partition != "" {
+		if err := awsarn.ValidatePartition(opts.Partition); err != nil {
+			return err
+		}
+	}
+	if err := opts.Registries.Validate(); err != nil {
+		return err
+	}
+	if err := k3d.ValidateNodePools(opts.NodePools); err != nil {
+		return err
+	}
+	if opts.Offline && opts.Bundle == "" {
+		return fmt.Errorf("offline instance '%s' needs a bundle, create one with kecs bundle create", opts.InstanceName)
+	}
+
 	// Handle automatic port allocation for NEW instances only
 	if opts.ApiPort == 0 || opts.AdminPort == 0 {
 		allocatedApiPort, allocatedAdminPort, err := m.allocatePorts(ctx, opts.ApiPort, opts.AdminPort)
@@ -177,8 +244,30 @@ func (m *Manager) Start(ctx context.Context, opts *StartOptions) error {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
-	// LocalStack is always enabled
-	cfg.LocalStack.Enabled = true
+	if err := applyAWSBackend(opts, cfg); err != nil {
+		return err
+	}
+
+	// Load the images of an offline instance before anything is pulled
+	var bundleImages string
+	if opts.Offline {
+		if opts.Bundle, err = filepath.Abs(opts.Bundle); err != nil {
+			return fmt.Errorf("invalid bundle path: %w", err)
+		}
+		m.updateStatus(opts.InstanceName, "Loading bundle", "running")
+		manifest, images, cleanup, err := loadBundle(ctx, opts, cfg)
+		if err != nil {
+			m.updateStatus(opts.InstanceName, "Loading bundle", "failed", err.Error())
+			return fmt.Errorf("failed to load bundle: %w", err)
+		}
+		defer cleanup()
+		m.updateStatus(opts.InstanceName, "Loading bundle", "done")
+		opts.WithoutLocalStack = !manifest.LocalStack
+		bundleImages = images
+	}
+
+	// LocalStack is always enabled, except for offline instances without it
+	applyOffline(opts, cfg)
 
 	// Add additional services if specified
 	if opts.AdditionalLocalStackServices != "" {
@@ -214,6 +303,12 @@ func (m *Manager) Start(ctx context.Context, opts *StartOptions) error {
 	}
 	m.updateStatus(opts.InstanceName, "Creating k3d cluster", "done")
 
+	if opts.Offline {
+		if err := m.importImages(ctx, opts.InstanceName, bundleImages); err != nil {
+			return fmt.Errorf("failed to import bundled images: %w", err)
+		}
+	}
+
 	// Get and save the Kubernetes API port after cluster creation
 	kubePort, err := m.k3dManager.GetKubernetesAPIPort(ctx, fmt.Sprintf("kecs-%s", opts.InstanceName))
 	if err != nil {
@@ -324,6 +419,11 @@ func (m *Manager) Start(ctx context.Context, opts *StartOptions) error {
 
 // Stop stops a KECS instance
 func (m *Manager) Stop(ctx context.Context, instanceName string) error {
+	return m.StopWithOptions(ctx, instanceName, StopOptions{})
+}
+
+// StopWithOptions stops a KECS instance with the given options
+func (m *Manager) StopWithOptions(ctx context.Context, instanceName string, opts StopOptions) error {
 	// Check if instance exists
 	exists, err := m.k3dManager.ClusterExists(ctx, instanceName)
 	if err != nil {
@@ -349,6 +449,19 @@ func (m *Manager) Stop(ctx context.Context, instanceName string) error {
 		return fmt.Errorf("failed to stop instance: %w", err)
 	}
 
+	// Record the standby state only once the cluster is stopped, and clear a
+	// stale one otherwise so that resuming does not skip redeployment
+	var standby *StandbyState
+	if opts.KeepData {
+		standby = &StandbyState{StoppedAt: time.Now()}
+	}
+	if err := UpdateInstanceStandby(instanceName, standby); err != nil {
+		if opts.KeepData {
+			return fmt.Errorf("failed to save standby state: %w", err)
+		}
+		logging.Debug("Failed to clear standby state", "instance", instanceName, "error", err)
+	}
+
 	return nil
 }
 
@@ -454,6 +567,39 @@ func (m *Manager) IsRunning(ctx context.Context, instanceName string) (bool, err
 	return m.k3dManager.IsClusterRunning(ctx, instanceName)
 }
 
+// UpdateRegistries replaces the registry mirrors, insecure registries and
+// credentials of an instance. A running instance gets the new config rendered
+// into its nodes, which are restarted one at a time. A stopped instance picks
+// it up when it starts.
+func (m *Manager) UpdateRegistries(ctx context.Context, instanceName string, registries *k3d.RegistryConfig) error {
+	if err := registries.Validate(); err != nil {
+		return err
+	}
+	if registries.IsEmpty() {
+		registries = nil
+	}
+	// Save first, so nodes started later get the config even when applying
+	// it to the running nodes fails
+	if err := UpdateInstanceRegistries(instanceName, registries); err != nil {
+		return err
+	}
+
+	running, err := m.IsRunning(ctx, instanceName)
+	if err != nil {
+		return err
+	}
+	if running {
+		m.updateStatus(instanceName, "Applying registry config", "running")
+		if err := m.k3dManager.ApplyRegistryConfig(ctx, instanceName, registries); err != nil {
+			m.updateStatus(instanceName, "Applying registry config", "failed", err.Error())
+			return fmt.Errorf("failed to apply registry config: %w", err)
+		}
+		m.updateStatus(instanceName, "Applying registry config", "done")
+	}
+	logging.Info("Updated registry config", "instance", instanceName, "running", running)
+	return nil
+}
+
 // Restart restarts a stopped instance (deprecated - use Start instead)
 func (m *Manager) Restart(ctx context.Context, instanceName string) error {
 	// Check if instance exists
@@ -494,6 +640,14 @@ func (m *Manager) Restart(ctx context.Context, instanceName string) error {
 		AdminPort:    savedConfig.AdminPort,
 		// LocalStack is always enabled, no additional services for restart
 		AdditionalLocalStackServices: "",
+		Registries:                   savedConfig.Registries,
+		NodePools:                    savedConfig.NodePools,
+	}
+	if err := applySavedOffline(&opts, savedConfig); err != nil {
+		return err
+	}
+	if err := applySavedAWSBackend(&opts, savedConfig); err != nil {
+		return err
 	}
 
 	// Use restartInstance to handle the restart
@@ -502,15 +656,178 @@ func (m *Manager) Restart(ctx context.Context, instanceName string) error {
 
 // restartInstance restarts a stopped instance and redeploys all components
 func (m *Manager) restartInstance(ctx context.Context, opts *StartOptions) error {
+	cfg, err := prepareRestart(opts)
+	if err != nil {
+		return err
+	}
+
+	if err := m.startStoppedCluster(ctx, opts); err != nil {
+		return err
+	}
+
+	if opts.Offline {
+		if err := m.importBundle(ctx, opts, cfg); err != nil {
+			return fmt.Errorf("failed to import bundled images: %w", err)
+		}
+	}
+
+	if err := m.deployComponents(ctx, opts, cfg); err != nil {
+		return err
+	}
+
+	// Wait for readiness
+	m.updateStatus(opts.InstanceName, "Finalizing", "running")
+	if err := m.waitForReady(ctx, opts.InstanceName, cfg); err != nil {
+		m.updateStatus(opts.InstanceName, "Finalizing", "failed", err.Error())
+		return fmt.Errorf("components failed to become ready: %w", err)
+	}
+	m.updateStatus(opts.InstanceName, "Finalizing", "done")
+
+	// Clear status after successful restart
+	m.statusMu.Lock()
+	delete(m.creationStatus, opts.InstanceName)
+	m.statusMu.Unlock()
+
+	// Don't save config during restart - it was already saved during initial creation
+	// and we've loaded the existing config. Saving here would overwrite the original
+	// port configuration with the potentially modified values.
 
+	return nil
+}
+
+// resumeInstance starts an instance stopped with StopOptions.KeepData. Its
+// components are still deployed in the k3d cluster and their images cached
+// on the nodes, so only the cluster is started and the control plane
+// recovers its state from the data directory. Instances that were not kept
+// warm are restarted with restartInstance.
+func (m *Manager) resumeInstance(ctx context.Context, opts *StartOptions) error {
+	savedConfig, err := LoadInstanceConfig(opts.InstanceName)
+	if err != nil || savedConfig.Standby == nil {
+		logging.Info("Instance was not stopped with --keep-data, restarting it", "instance", opts.InstanceName)
+		return m.restartInstance(ctx, opts)
+	}
+
+	cfg, err := prepareRestart(opts)
+	if err != nil {
+		return err
+	}
+
+	if err := m.startStoppedCluster(ctx, opts); err != nil {
+		return err
+	}
+
+	// Starting the cluster recreates it when the DNS fix workaround is needed
+	deployed, err := m.componentsDeployed(ctx, opts.InstanceName, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to check deployed components: %w", err)
+	}
+	if !deployed {
+		logging.Info("Components are missing after starting the cluster, redeploying them", "instance", opts.InstanceName)
+		if opts.Offline {
+			if err := m.importBundle(ctx, opts, cfg); err != nil {
+				return fmt.Errorf("failed to import bundled images: %w", err)
+			}
+		}
+		if err := m.deployComponents(ctx, opts, cfg); err != nil {
+			return err
+		}
+	}
+
+	m.updateStatus(opts.InstanceName, "Finalizing", "running")
+	if err := m.waitForReady(ctx, opts.InstanceName, cfg); err != nil {
+		m.updateStatus(opts.InstanceName, "Finalizing", "failed", err.Error())
+		return fmt.Errorf("components failed to become ready: %w", err)
+	}
+	m.updateStatus(opts.InstanceName, "Finalizing", "done")
+
+	if err := UpdateInstanceStandby(opts.InstanceName, nil); err != nil {
+		logging.Warn("Failed to clear standby state", "instance", opts.InstanceName, "error", err)
+	}
+
+	m.statusMu.Lock()
+	delete(m.creationStatus, opts.InstanceName)
+	m.statusMu.Unlock()
+
+	return nil
+}
+
+// applySavedIdentity fills the account ID and partition of an existing
+// instance. They are part of every ARN in its storage and cannot be changed.
+func applySavedIdentity(opts *StartOptions, savedConfig *InstanceConfig) error {
+	if opts.AccountID != "" && opts.AccountID != savedConfig.AccountID {
+		return fmt.Errorf("instance '%s' was created with account ID %q, which cannot be changed", opts.InstanceName, savedConfig.AccountID)
+	}
+	if opts.Partition != "" && opts.Partition != savedConfig.Partition {
+		return fmt.Errorf("instance '%s' was created with partition %q, which cannot be changed", opts.InstanceName, savedConfig.Partition)
+	}
+	opts.AccountID = savedConfig.AccountID
+	opts.Partition = savedConfig.Partition
+	return nil
+}
+
+// applySavedOffline fills the offline settings of an existing instance. The
+// bundle may be given again when it was moved.
+func applySavedOffline(opts *StartOptions, savedConfig *InstanceConfig) error {
+	if opts.Offline && !savedConfig.Offline {
+		return fmt.Errorf("instance '%s' was not created offline, which cannot be changed", opts.InstanceName)
+	}
+	opts.Offline = savedConfig.Offline
+	if opts.Bundle == "" {
+		opts.Bundle = savedConfig.Bundle
+	}
+	opts.WithoutLocalStack = savedConfig.Offline && !savedConfig.LocalStack
+	return nil
+}
+
+// applyAWSBackend selects the backend of the AWS services, the configured
+// one unless the options name it
+func applyAWSBackend(opts *StartOptions, cfg *config.Config) error {
+	if opts.AWSBackend == "" {
+		opts.AWSBackend = cfg.LocalStack.Backend
+	}
+	if opts.AWSBackend == "" {
+		opts.AWSBackend = localstack.BackendLocalStack
+	}
+	if opts.AWSBackend != localstack.BackendLocalStack && opts.AWSBackend != localstack.BackendStub {
+		return fmt.Errorf("invalid AWS backend %q, must be %s or %s", opts.AWSBackend, localstack.BackendLocalStack, localstack.BackendStub)
+	}
+	if opts.AWSBackend == localstack.BackendStub && opts.AdditionalLocalStackServices != "" {
+		return fmt.Errorf("the AWS stub only serves %s, additional LocalStack services need the %s backend",
+			strings.Join(localstack.StubServices, ", "), localstack.BackendLocalStack)
+	}
+	cfg.LocalStack.Backend = opts.AWSBackend
+	return nil
+}
+
+// applySavedAWSBackend fills the AWS backend of an existing instance, which
+// keeps its S3 objects, parameters and secrets in it
+func applySavedAWSBackend(opts *StartOptions, savedConfig *InstanceConfig) error {
+	backend := savedConfig.AWSBackend
+	if backend == "" {
+		// Instances created before the stub existed run LocalStack
+		backend = localstack.BackendLocalStack
+	}
+	if opts.AWSBackend != "" && opts.AWSBackend != backend {
+		return fmt.Errorf("instance '%s' was created with the %s AWS backend, which cannot be changed", opts.InstanceName, backend)
+	}
+	opts.AWSBackend = backend
+	return nil
+}
+
+// prepareRestart loads the configuration of a stopped instance and fills
+// the options that were not given from its saved config
+func prepareRestart(opts *StartOptions) (*config.Config, error) {
 	// Load configuration
 	cfg, err := config.LoadConfig(opts.ConfigFile)
 	if err != nil {
-		return fmt.Errorf("failed to load configuration: %w", err)
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if err := applyAWSBackend(opts, cfg); err != nil {
+		return nil, err
 	}
 
-	// LocalStack is always enabled
-	cfg.LocalStack.Enabled = true
+	// LocalStack is always enabled, except for offline instances without it
+	applyOffline(opts, cfg)
 
 	// Add additional services if specified
 	if opts.AdditionalLocalStackServices != "" {
@@ -537,6 +854,9 @@ func (m *Manager) restartInstance(ctx context.Context, opts *StartOptions) error
 		if opts.DataDir == "" {
 			opts.DataDir = savedConfig.DataDir
 		}
+		if err := applySavedIdentity(opts, savedConfig); err != nil {
+			return nil, err
+		}
 	}
 
 	// Set up data directory
@@ -545,6 +865,12 @@ func (m *Manager) restartInstance(ctx context.Context, opts *StartOptions) error
 		opts.DataDir = filepath.Join(home, ".kecs", "instances", opts.InstanceName, "data")
 	}
 
+	return cfg, nil
+}
+
+// startStoppedCluster starts the k3d cluster of a stopped instance with its
+// data directory and port mappings and waits until it is ready
+func (m *Manager) startStoppedCluster(ctx context.Context, opts *StartOptions) error {
 	// Set up volume mounts before starting the cluster
 	volumeMounts := []k3d.VolumeMount{
 		{
@@ -556,6 +882,9 @@ func (m *Manager) restartInstance(ctx context.Context, opts *StartOptions) error
 
 	// Enable k3d registry
 	m.k3dManager.SetEnableRegistry(true)
+	// Keep the registry config in case the cluster has to be recreated
+	m.k3dManager.SetRegistryConfig(opts.Registries)
+	m.k3dManager.SetNodePools(opts.NodePools)
 
 	// Calculate NodePort for API access
 	apiNodePort := int32(opts.ApiPort)
@@ -583,7 +912,7 @@ func (m *Manager) restartInstance(ctx context.Context, opts *StartOptions) error
 		int32(opts.AdminPort): adminNodePort, // Map host Admin port to NodePort for Admin API
 	}
 
-	// Step 1: Start the k3d cluster with port mappings
+	// Start the k3d cluster with port mappings
 	m.updateStatus(opts.InstanceName, "Starting k3d cluster", "running")
 	clusterName := fmt.Sprintf("kecs-%s", opts.InstanceName)
 	if err := m.k3dManager.StartClusterWithPorts(ctx, clusterName, portMappings); err != nil {
@@ -592,7 +921,7 @@ func (m *Manager) restartInstance(ctx context.Context, opts *StartOptions) error
 	}
 	m.updateStatus(opts.InstanceName, "Starting k3d cluster", "done")
 
-	// Step 2: Wait for cluster to be ready
+	// Wait for cluster to be ready
 	m.updateStatus(opts.InstanceName, "Waiting for cluster", "running")
 	if err := m.k3dManager.WaitForClusterReady(ctx, opts.InstanceName); err != nil {
 		m.updateStatus(opts.InstanceName, "Waiting for cluster", "failed", err.Error())
@@ -614,7 +943,13 @@ func (m *Manager) restartInstance(ctx context.Context, opts *StartOptions) error
 		}
 	}
 
-	// Step 3: Recreate namespace (in case it was deleted)
+	return nil
+}
+
+// deployComponents deploys the control plane, Traefik, LocalStack and Vector
+// into the k3d cluster of an instance
+func (m *Manager) deployComponents(ctx context.Context, opts *StartOptions, cfg *config.Config) error {
+	// Recreate namespace (in case it was deleted)
 	m.updateStatus(opts.InstanceName, "Creating namespace", "running")
 	if err := m.createOrUpdateNamespace(ctx, opts.InstanceName); err != nil {
 		m.updateStatus(opts.InstanceName, "Creating namespace", "failed", err.Error())
@@ -622,7 +957,7 @@ func (m *Manager) restartInstance(ctx context.Context, opts *StartOptions) error
 	}
 	m.updateStatus(opts.InstanceName, "Creating namespace", "done")
 
-	// Step 4: Deploy components in parallel
+	// Deploy components in parallel
 	var wg sync.WaitGroup
 	errChan := make(chan error, 5) // Increased for Traefik
 
@@ -690,23 +1025,6 @@ func (m *Manager) restartInstance(ctx context.Context, opts *StartOptions) error
 		return err
 	}
 
-	// Step 5: Wait for readiness
-	m.updateStatus(opts.InstanceName, "Finalizing", "running")
-	if err := m.waitForReady(ctx, opts.InstanceName, cfg); err != nil {
-		m.updateStatus(opts.InstanceName, "Finalizing", "failed", err.Error())
-		return fmt.Errorf("components failed to become ready: %w", err)
-	}
-	m.updateStatus(opts.InstanceName, "Finalizing", "done")
-
-	// Clear status after successful restart
-	m.statusMu.Lock()
-	delete(m.creationStatus, opts.InstanceName)
-	m.statusMu.Unlock()
-
-	// Don't save config during restart - it was already saved during initial creation
-	// and we've loaded the existing config. Saving here would overwrite the original
-	// port configuration with the potentially modified values.
-
 	return nil
 }
 
@@ -0,0 +1,116 @@
+// Copyright 2025 The KECS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/bundle"
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// loadBundle reads the bundle of an offline instance and loads its images
+// into Docker, where k3d finds the node, load balancer and registry images.
+// It returns the manifest and the extracted images tarball, which is removed
+// by the returned cleanup func. In test mode only the manifest is read.
+func loadBundle(ctx context.Context, opts *StartOptions, cfg *config.Config) (*bundle.Manifest, string, func(), error) {
+	noop := func() {}
+	manifest, err := bundle.ReadManifest(opts.Bundle)
+	if err != nil {
+		return nil, "", noop, err
+	}
+	if missing := manifest.Missing(bundle.RequiredImages(cfg, manifest.LocalStack)); len(missing) > 0 {
+		return nil, "", noop, fmt.Errorf("bundle %s lacks images of this version of kecs: %s, recreate it with kecs bundle create",
+			opts.Bundle, strings.Join(missing, ", "))
+	}
+	if opts.TestMode {
+		return manifest, "", noop, nil
+	}
+
+	dir, err := os.MkdirTemp("", "kecs-bundle-")
+	if err != nil {
+		return nil, "", noop, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	logging.Info("Extracting bundle", "bundle", opts.Bundle, "images", len(manifest.Images))
+	manifest, images, err := bundle.Extract(opts.Bundle, dir)
+	if err != nil {
+		cleanup()
+		return nil, "", noop, err
+	}
+
+	store, err := bundle.NewDockerImageStore()
+	if err != nil {
+		cleanup()
+		return nil, "", noop, err
+	}
+	defer store.Close()
+	if err := bundle.Load(ctx, store, manifest, images); err != nil {
+		cleanup()
+		return nil, "", noop, err
+	}
+	return manifest, images, cleanup, nil
+}
+
+// importBundle loads the bundle of an offline instance into Docker and
+// imports its images into the nodes of the instance, whose cluster may have
+// been recreated since the bundle was last imported
+func (m *Manager) importBundle(ctx context.Context, opts *StartOptions, cfg *config.Config) error {
+	if _, err := os.Stat(opts.Bundle); err != nil {
+		// Nodes keep their images while stopped, a moved bundle only matters
+		// when the cluster was recreated
+		logging.Warn("Bundle of offline instance not found, relying on the images on the nodes", "bundle", opts.Bundle, "error", err)
+		return nil
+	}
+	_, images, cleanup, err := loadBundle(ctx, opts, cfg)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	return m.importImages(ctx, opts.InstanceName, images)
+}
+
+// importImages imports an images tarball into the nodes of an instance
+func (m *Manager) importImages(ctx context.Context, instanceName, images string) error {
+	m.updateStatus(instanceName, "Importing bundled images", "running")
+	if images != "" {
+		if err := m.k3dManager.ImportImages(ctx, fmt.Sprintf("kecs-%s", instanceName), []string{images}); err != nil {
+			m.updateStatus(instanceName, "Importing bundled images", "failed", err.Error())
+			return err
+		}
+	}
+	m.updateStatus(instanceName, "Importing bundled images", "done")
+	return nil
+}
+
+// applyOffline keeps an offline instance from pulling: components and tasks
+// use the images on the nodes when they are there. LocalStack only runs when
+// it is bundled, the AWS stub needs no image.
+func applyOffline(opts *StartOptions, cfg *config.Config) {
+	cfg.LocalStack.Enabled = !opts.WithoutLocalStack || cfg.LocalStack.UseStub()
+	if !opts.Offline {
+		return
+	}
+	cfg.LocalStack.ImagePullPolicy = string(corev1.PullIfNotPresent)
+	if opts.ImagePullPolicy == "" {
+		opts.ImagePullPolicy = string(corev1.PullIfNotPresent)
+	}
+}
@@ -0,0 +1,271 @@
+// Copyright 2025 The KECS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/kubernetes/resources"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// controlPlaneContainer is the name of the control plane container of the
+// kecs-server Deployment, next to the PostgreSQL sidecar
+const controlPlaneContainer = "controlplane"
+
+// upgradePollInterval is how often the rollout and health are checked
+const upgradePollInterval = 2 * time.Second
+
+// UpgradeOptions contains options for upgrading the control plane of an instance
+type UpgradeOptions struct {
+	Image string
+	// Timeout bounds the rollout and the health check, 5 minutes when zero
+	Timeout time.Duration
+	// NoRollback leaves a failed upgrade in place for debugging
+	NoRollback bool
+}
+
+// UpgradeResult describes a control plane upgrade
+type UpgradeResult struct {
+	PreviousImage string
+	Image         string
+	// Upgraded is false when the control plane already ran the image
+	Upgraded bool
+}
+
+// UpgradeControlPlane replaces the control plane image of a running instance.
+// The Deployment is recreated with the new image, which migrates the storage
+// schema when it starts and only becomes ready once the migration succeeded.
+// Task pods live in their own namespaces and keep running; the control plane
+// recovers their state from storage. The upgrade is rolled back when the new
+// control plane does not become ready or its admin API is not healthy.
+func (m *Manager) UpgradeControlPlane(ctx context.Context, instanceName string, opts UpgradeOptions, progress func(message string)) (*UpgradeResult, error) {
+	if progress == nil {
+		progress = func(string) {}
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Minute
+	}
+
+	running, err := m.IsRunning(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+	if !running {
+		return nil, fmt.Errorf("instance '%s' is not running", instanceName)
+	}
+
+	kubeconfig, err := m.k3dManager.GetKubeConfig(ctx, fmt.Sprintf("kecs-%s", instanceName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kubeconfig: %w", err)
+	}
+	client, err := kubernetes.NewForConfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	progress(fmt.Sprintf("Updating control plane image to %s", opts.Image))
+	previous, err := UpdateControlPlaneImage(ctx, client, opts.Image)
+	if err != nil {
+		return nil, err
+	}
+	result := &UpgradeResult{PreviousImage: previous, Image: opts.Image, Upgraded: previous != opts.Image}
+	if !result.Upgraded {
+		return result, nil
+	}
+
+	verify := func() error {
+		progress("Waiting for the new control plane to migrate storage and become ready")
+		if err := waitForControlPlaneRollout(ctx, client, opts.Timeout); err != nil {
+			return err
+		}
+		progress("Checking the admin API health")
+		return waitForAdminHealth(ctx, instanceName, opts.Timeout)
+	}
+	if err := verify(); err != nil {
+		if opts.NoRollback {
+			return result, fmt.Errorf("upgrade failed: %w", err)
+		}
+		progress(fmt.Sprintf("Upgrade failed, rolling back to %s", previous))
+		logging.Warn("Control plane upgrade failed, rolling back", "instance", instanceName, "image", opts.Image, "error", err)
+		if _, rollbackErr := UpdateControlPlaneImage(ctx, client, previous); rollbackErr != nil {
+			return result, fmt.Errorf("upgrade failed: %w (rollback failed: %v)", err, rollbackErr)
+		}
+		if rollbackErr := waitForControlPlaneRollout(ctx, client, opts.Timeout); rollbackErr != nil {
+			return result, fmt.Errorf("upgrade failed: %w (rollback did not become ready: %v)", err, rollbackErr)
+		}
+		return result, fmt.Errorf("upgrade failed and was rolled back: %w", err)
+	}
+
+	return result, nil
+}
+
+// UpdateControlPlaneImage sets the image of the control plane container of
+// the kecs-server Deployment and returns the image it replaced. The
+// PostgreSQL sidecar and the rest of the Deployment are left as they are.
+func UpdateControlPlaneImage(ctx context.Context, client kubernetes.Interface, image string) (string, error) {
+	var previous string
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		deployment, err := client.AppsV1().Deployments(resources.ControlPlaneNamespace).Get(ctx, resources.ControlPlaneName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get control plane deployment: %w", err)
+		}
+		containers := deployment.Spec.Template.Spec.Containers
+		for i := range containers {
+			if containers[i].Name != controlPlaneContainer {
+				continue
+			}
+			previous = containers[i].Image
+			if previous == image {
+				return nil
+			}
+			containers[i].Image = image
+			_, err := client.AppsV1().Deployments(resources.ControlPlaneNamespace).Update(ctx, deployment, metav1.UpdateOptions{})
+			return err
+		}
+		return fmt.Errorf("control plane deployment has no %s container", controlPlaneContainer)
+	})
+	return previous, err
+}
+
+// ControlPlaneRolloutStatus reports whether the latest revision of the
+// control plane Deployment is available and no pod of an older revision is
+// left. It fails as soon as a control plane container cannot pull its image
+// or keeps crashing, e.g. because the storage migration failed.
+func ControlPlaneRolloutStatus(ctx context.Context, client kubernetes.Interface) (bool, error) {
+	deployment, err := client.AppsV1().Deployments(resources.ControlPlaneNamespace).Get(ctx, resources.ControlPlaneName, metav1.GetOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to get control plane deployment: %w", err)
+	}
+
+	pods, err := client.CoreV1().Pods(resources.ControlPlaneNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", resources.LabelApp, resources.ControlPlaneName),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to list control plane pods: %w", err)
+	}
+	target := controlPlaneImageOf(deployment.Spec.Template.Spec)
+	for _, pod := range pods.Items {
+		// Pods of the replaced revision are going away
+		if controlPlaneImageOf(pod.Spec) != target {
+			continue
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Name != controlPlaneContainer || status.State.Waiting == nil {
+				continue
+			}
+			switch reason := status.State.Waiting.Reason; reason {
+			case "CrashLoopBackOff", "ErrImagePull", "ImagePullBackOff", "InvalidImageName":
+				return false, fmt.Errorf("control plane pod %s is in %s: %s", pod.Name, reason, status.State.Waiting.Message)
+			}
+		}
+	}
+
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	status := deployment.Status
+	return status.ObservedGeneration >= deployment.Generation &&
+		status.UpdatedReplicas == replicas &&
+		status.Replicas == replicas &&
+		status.AvailableReplicas == replicas, nil
+}
+
+// controlPlaneImageOf returns the image of the control plane container of a pod spec
+func controlPlaneImageOf(spec corev1.PodSpec) string {
+	for _, container := range spec.Containers {
+		if container.Name == controlPlaneContainer {
+			return container.Image
+		}
+	}
+	return ""
+}
+
+// waitForControlPlaneRollout waits until the control plane rollout completed
+func waitForControlPlaneRollout(ctx context.Context, client kubernetes.Interface, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(upgradePollInterval)
+	defer ticker.Stop()
+	for {
+		done, err := ControlPlaneRolloutStatus(ctx, client)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("control plane did not become ready within %s", timeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitForAdminHealth waits until the admin API of an instance reports it is
+// healthy and ready through its host port
+func waitForAdminHealth(ctx context.Context, instanceName string, timeout time.Duration) error {
+	savedConfig, err := LoadInstanceConfig(instanceName)
+	if err != nil {
+		return fmt.Errorf("failed to load instance config: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	healthy := func() error {
+		for _, path := range []string{"/health", "/ready"} {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://localhost:%d%s", savedConfig.AdminPort, path), nil)
+			if err != nil {
+				return err
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				return err
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return fmt.Errorf("%s returned status %d", path, resp.StatusCode)
+			}
+		}
+		return nil
+	}
+
+	ticker := time.NewTicker(upgradePollInterval)
+	defer ticker.Stop()
+	for {
+		err := healthy()
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("admin API is not healthy: %w", err)
+		case <-ticker.C:
+		}
+	}
+}
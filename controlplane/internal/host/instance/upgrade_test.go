@@ -0,0 +1,100 @@
+package instance_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/host/instance"
+)
+
+var _ = Describe("Control plane upgrade", func() {
+	var (
+		ctx    context.Context
+		client *fake.Clientset
+	)
+
+	controlPlanePod := func(name, image string, waiting *corev1.ContainerStateWaiting) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "kecs-system", Labels: map[string]string{"app": "kecs-server"}},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "controlplane", Image: image}}},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "controlplane", State: corev1.ContainerState{Waiting: waiting}},
+			}},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		replicas := int32(1)
+		client = fake.NewSimpleClientset(&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "kecs-server", Namespace: "kecs-system", Generation: 1},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{
+					{Name: "controlplane", Image: "ghcr.io/nandemo-ya/kecs:v0.1.0"},
+					{Name: "postgres", Image: "postgres:16-alpine"},
+				}}},
+			},
+			Status: appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1},
+		})
+	})
+
+	Describe("UpdateControlPlaneImage", func() {
+		It("should replace only the control plane image", func() {
+			previous, err := instance.UpdateControlPlaneImage(ctx, client, "ghcr.io/nandemo-ya/kecs:v0.2.0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(previous).To(Equal("ghcr.io/nandemo-ya/kecs:v0.1.0"))
+
+			deployment, err := client.AppsV1().Deployments("kecs-system").Get(ctx, "kecs-server", metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			containers := deployment.Spec.Template.Spec.Containers
+			Expect(containers[0].Image).To(Equal("ghcr.io/nandemo-ya/kecs:v0.2.0"))
+			Expect(containers[1].Image).To(Equal("postgres:16-alpine"))
+		})
+	})
+
+	Describe("ControlPlaneRolloutStatus", func() {
+		It("should report a completed rollout", func() {
+			done, err := instance.ControlPlaneRolloutStatus(ctx, client)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(done).To(BeTrue())
+		})
+
+		It("should wait until the new revision is observed", func() {
+			deployment, err := client.AppsV1().Deployments("kecs-system").Get(ctx, "kecs-server", metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			deployment.Generation = 2
+			_, err = client.AppsV1().Deployments("kecs-system").Update(ctx, deployment, metav1.UpdateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			done, err := instance.ControlPlaneRolloutStatus(ctx, client)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(done).To(BeFalse())
+		})
+
+		It("should fail when the new control plane keeps crashing", func() {
+			_, err := client.CoreV1().Pods("kecs-system").Create(ctx, controlPlanePod("new", "ghcr.io/nandemo-ya/kecs:v0.1.0",
+				&corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff", Message: "back-off restarting"}), metav1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = instance.ControlPlaneRolloutStatus(ctx, client)
+			Expect(err).To(MatchError(ContainSubstring("CrashLoopBackOff")))
+		})
+
+		It("should ignore pods of the replaced revision", func() {
+			_, err := client.CoreV1().Pods("kecs-system").Create(ctx, controlPlanePod("old", "ghcr.io/nandemo-ya/kecs:v0.0.9",
+				&corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}), metav1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			done, err := instance.ControlPlaneRolloutStatus(ctx, client)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(done).To(BeTrue())
+		})
+	})
+})
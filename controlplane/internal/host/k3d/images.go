@@ -0,0 +1,66 @@
+package k3d
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/k3d-io/k3d/v5/pkg/client"
+	k3d "github.com/k3d-io/k3d/v5/pkg/types"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+const (
+	// DefaultK3sImage is the node image of clusters unless K3dImage is set
+	DefaultK3sImage = "rancher/k3s:v1.31.4-k3s1"
+	// RegistryImage is the image of the KECS registry
+	RegistryImage = "docker.io/library/registry:2"
+)
+
+// K3sSystemImages are the images of the system pods k3s runs in the
+// clusters of DefaultK3sImage. Traefik, servicelb and metrics-server are
+// disabled and not listed.
+var K3sSystemImages = []string{
+	"docker.io/rancher/mirrored-pause:3.6",
+	"docker.io/rancher/mirrored-coredns-coredns:1.12.0",
+	"docker.io/rancher/local-path-provisioner:v0.0.30",
+	"docker.io/rancher/mirrored-library-busybox:1.36.1",
+}
+
+// NodeImages returns the images k3d runs as containers of a cluster: the
+// node image, the load balancer, the tools node and the KECS registry
+func NodeImages(k3sImage string) []string {
+	if k3sImage == "" {
+		k3sImage = DefaultK3sImage
+	}
+	return []string{k3sImage, k3d.GetLoadbalancerImage(), k3d.GetToolsImage(), RegistryImage}
+}
+
+// k3sImage returns the node image of new clusters
+func (k *K3dClusterManager) k3sImage() string {
+	if k.config.K3dImage != "" {
+		return k.config.K3dImage
+	}
+	return DefaultK3sImage
+}
+
+// ImportImages imports image tarballs, as written by docker save, into the
+// containerd of every node of a cluster. Pods find the images there without
+// pulling them.
+func (k *K3dClusterManager) ImportImages(ctx context.Context, clusterName string, tarballs []string) error {
+	if k.config.TestMode {
+		logging.Info("CI/TEST MODE: Simulating image import", "cluster", clusterName)
+		return nil
+	}
+	normalizedName := k.normalizeClusterName(clusterName)
+	cluster, err := client.ClusterGet(ctx, k.runtime, &k3d.Cluster{Name: normalizedName})
+	if err != nil {
+		return fmt.Errorf("failed to get cluster %s: %w", normalizedName, err)
+	}
+	// Streaming into the nodes needs no tools node, whose image may not be
+	// available offline
+	if err := client.ImageImportIntoClusterMulti(ctx, k.runtime, tarballs, cluster, k3d.ImageImportOpts{Mode: k3d.ImportModeDirect}); err != nil {
+		return fmt.Errorf("failed to import images into cluster %s: %w", normalizedName, err)
+	}
+	return nil
+}
@@ -57,6 +57,8 @@ type ClusterManagerConfig struct {
 	K3dImage          string                 `json:"k3dImage,omitempty"`
 	EnableRegistry    bool                   `json:"enableRegistry,omitempty"`
 	RegistryPort      int                    `json:"registryPort,omitempty"`
+	Registries        *RegistryConfig        `json:"registries,omitempty"`
+	NodePools         []NodePool             `json:"nodePools,omitempty"`
 	TestMode          bool                   `json:"testMode,omitempty"`
 }
 
@@ -284,10 +286,7 @@ func (k *K3dClusterManager) createClusterStandardOriginal(ctx context.Context, c
 	}
 
 	// Determine k3s image
-	k3sImage := "rancher/k3s:v1.31.4-k3s1"
-	if k.config.K3dImage != "" {
-		k3sImage = k.config.K3dImage
-	}
+	k3sImage := k.k3sImage()
 
 	// K3s args for minimal setup - disable unnecessary components
 	k3sArgs := []string{
@@ -461,10 +460,7 @@ func (k *K3dClusterManager) createClusterStandardWithPorts(ctx context.Context,
 	}
 
 	// Determine k3s image
-	k3sImage := "rancher/k3s:v1.31.4-k3s1"
-	if k.config.K3dImage != "" {
-		k3sImage = k.config.K3dImage
-	}
+	k3sImage := k.k3sImage()
 
 	// K3s args for minimal setup - disable unnecessary components
 	k3sArgs := []string{
@@ -544,6 +540,13 @@ func (k *K3dClusterManager) createClusterStandardWithPorts(ctx context.Context,
 		},
 	}
 
+	// Agent nodes of the node pools simulating other CPU architectures
+	if len(k.config.NodePools) > 0 {
+		agents := nodePoolAgents(normalizedName, k3sImage, serverNode.Volumes, k.config.NodePools)
+		cluster.Nodes = append(cluster.Nodes, agents...)
+		logging.Info("Adding node pool agents", "cluster", normalizedName, "agents", len(agents))
+	}
+
 	// Registry connection will be handled after cluster creation
 
 	// Create cluster creation options
@@ -1167,7 +1170,7 @@ func (k *K3dClusterManager) GetClusterInfo(ctx context.Context, clusterName stri
 		Version:   version,
 		Metadata: map[string]string{
 			"k3d_cluster_name": normalizedName,
-			"image":            "rancher/k3s:v1.31.4-k3s1",
+			"image":            k.k3sImage(),
 		},
 	}, nil
 }
@@ -1384,10 +1387,7 @@ func (k *K3dClusterManager) CreateClusterOptimized(ctx context.Context, clusterN
 	}
 
 	// Determine k3s image
-	k3sImage := "rancher/k3s:v1.31.4-k3s1"
-	if k.config.K3dImage != "" {
-		k3sImage = k.config.K3dImage
-	}
+	k3sImage := k.k3sImage()
 
 	// Create server node with optimizations
 	serverNode := &k3d.Node{
@@ -1624,7 +1624,7 @@ func (k *K3dClusterManager) ensureRegistry(ctx context.Context) (*k3d.Node, erro
 	// Create registry configuration
 	reg := &k3d.Registry{
 		Host:  registryName, // Use simple name without .localhost suffix
-		Image: "docker.io/library/registry:2",
+		Image: RegistryImage,
 		ExposureOpts: k3d.ExposureOpts{
 			Host: "0.0.0.0",
 			PortMapping: nat.PortMapping{
@@ -1717,64 +1717,62 @@ func (k *K3dClusterManager) configureRegistryForCluster(ctx context.Context, clu
 	// - localhost:5000 (from host machine)
 	// - registry.kecs.local:5000 (from within cluster - preferred)
 	// Use direct IP address for reliability
-	registryConfig := fmt.Sprintf(`mirrors:
-  "localhost:5000":
-    endpoint:
-      - "http://%s:5000"
-  "registry.kecs.local:5000":
-    endpoint:
-      - "http://%s:5000"
-configs:
-  "%s:5000":
-    tls:
-      insecure_skip_verify: true
-`, registryIP, registryIP, registryIP)
-
-	// Get the server node name
-	serverNodeName := fmt.Sprintf("k3d-%s-server-0", clusterName)
-
-	// Get the server node
+	// Mirrors and credentials configured for the instance are added to it
+	renderedConfig, err := RenderRegistries(registryIP, k.config.Registries)
+	if err != nil {
+		return err
+	}
+
+	// Get the server node and the agent nodes of node pools
 	nodes, err := k.runtime.GetNodesByLabel(ctx, map[string]string{
 		k3d.LabelClusterName: clusterName,
-		k3d.LabelRole:        string(k3d.ServerRole),
 	})
-	if err != nil || len(nodes) == 0 {
-		return fmt.Errorf("failed to find server node for cluster %s", clusterName)
+	if err != nil {
+		return fmt.Errorf("failed to find nodes for cluster %s: %w", clusterName, err)
 	}
 
-	serverNode := nodes[0]
+	var configured int
+	for _, node := range nodes {
+		if node.Role != k3d.ServerRole && node.Role != k3d.AgentRole {
+			continue
+		}
 
-	// Write registry config using runtime's WriteToNode method
-	if err := k.runtime.WriteToNode(ctx, []byte(registryConfig), "/etc/rancher/k3s/registries.yaml", 0644, serverNode); err != nil {
-		logging.Warn("Failed to write registry config via runtime, trying alternative method", "error", err)
+		// Write registry config using runtime's WriteToNode method
+		if err := k.runtime.WriteToNode(ctx, renderedConfig, registriesPath, 0644, node); err != nil {
+			logging.Warn("Failed to write registry config via runtime, trying alternative method", "node", node.Name, "error", err)
 
-		// Alternative: use docker exec directly
-		cmd := fmt.Sprintf(`docker exec %s sh -c "mkdir -p /etc/rancher/k3s && echo '%s' > /etc/rancher/k3s/registries.yaml"`, serverNodeName, registryConfig)
-		if output, err := exec.CommandContext(ctx, "sh", "-c", cmd).CombinedOutput(); err != nil {
-			return fmt.Errorf("failed to create registry config: %w, output: %s", err, string(output))
+			// Alternative: use docker exec directly
+			cmd := fmt.Sprintf(`docker exec %s sh -c "mkdir -p /etc/rancher/k3s && echo '%s' > /etc/rancher/k3s/registries.yaml"`, node.Name, string(renderedConfig))
+			if output, err := exec.CommandContext(ctx, "sh", "-c", cmd).CombinedOutput(); err != nil {
+				return fmt.Errorf("failed to create registry config: %w, output: %s", err, string(output))
+			}
 		}
-	}
 
-	logging.Info("Successfully configured k3s registry", "cluster", clusterName)
+		// Restart the node to apply the configuration
+		logging.Info("Restarting k3s to apply registry configuration", "cluster", clusterName, "node", node.Name)
 
-	// Restart the node to apply the configuration
-	logging.Info("Restarting k3s to apply registry configuration", "cluster", clusterName)
+		// Stop and start the node
+		if err := k.runtime.StopNode(ctx, node); err != nil {
+			logging.Warn("Failed to stop node via runtime, trying docker restart", "error", err)
 
-	// Stop and start the node
-	if err := k.runtime.StopNode(ctx, serverNode); err != nil {
-		logging.Warn("Failed to stop node via runtime, trying docker restart", "error", err)
-
-		// Alternative: use docker restart directly
-		cmd := fmt.Sprintf("docker restart %s", serverNodeName)
-		if output, err := exec.CommandContext(ctx, "sh", "-c", cmd).CombinedOutput(); err != nil {
-			return fmt.Errorf("failed to restart k3s container: %w, output: %s", err, string(output))
-		}
-	} else {
-		// Successfully stopped, now start it
-		if err := k.runtime.StartNode(ctx, serverNode); err != nil {
-			return fmt.Errorf("failed to start node after stop: %w", err)
+			// Alternative: use docker restart directly
+			cmd := fmt.Sprintf("docker restart %s", node.Name)
+			if output, err := exec.CommandContext(ctx, "sh", "-c", cmd).CombinedOutput(); err != nil {
+				return fmt.Errorf("failed to restart k3s container: %w, output: %s", err, string(output))
+			}
+		} else {
+			// Successfully stopped, now start it
+			if err := k.runtime.StartNode(ctx, node); err != nil {
+				return fmt.Errorf("failed to start node after stop: %w", err)
+			}
 		}
+		configured++
 	}
+	if configured == 0 {
+		return fmt.Errorf("failed to find server node for cluster %s", clusterName)
+	}
+
+	logging.Info("Successfully configured k3s registry", "cluster", clusterName, "nodes", configured)
 
 	// Wait a bit for k3s to come back up
 	time.Sleep(5 * time.Second)
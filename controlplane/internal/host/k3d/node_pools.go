@@ -0,0 +1,84 @@
+package k3d
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	k3d "github.com/k3d-io/k3d/v5/pkg/types"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+)
+
+// maxNodePoolSize is the number of nodes a node pool may have
+const maxNodePoolSize = 5
+
+// NodePool is a pool of agent nodes simulating a CPU architecture. The nodes
+// run on the architecture of the host, they are labelled with the simulated
+// one so that tasks are placed by the cpuArchitecture of their runtime
+// platform. Images for another architecture run under emulation, e.g. with
+// Rosetta on Apple Silicon.
+type NodePool struct {
+	Architecture string `yaml:"architecture" json:"architecture"`
+	Count        int    `yaml:"count" json:"count"`
+}
+
+// ParseNodePool parses a node pool given as ARCH[=COUNT], e.g. arm64=2. A
+// pool without count has one node.
+func ParseNodePool(spec string) (NodePool, error) {
+	arch, count, hasCount := strings.Cut(spec, "=")
+	pool := NodePool{Architecture: arch, Count: 1}
+	if hasCount {
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			return NodePool{}, fmt.Errorf("invalid node pool %q: count must be a number", spec)
+		}
+		pool.Count = n
+	}
+	return pool, nil
+}
+
+// ValidateNodePools checks the architectures and sizes of node pools
+func ValidateNodePools(pools []NodePool) error {
+	seen := make(map[string]bool)
+	for _, pool := range pools {
+		if pool.Architecture != "amd64" && pool.Architecture != "arm64" {
+			return fmt.Errorf("invalid node pool architecture %q, must be amd64 or arm64", pool.Architecture)
+		}
+		if seen[pool.Architecture] {
+			return fmt.Errorf("node pool %s is given more than once", pool.Architecture)
+		}
+		seen[pool.Architecture] = true
+		if pool.Count < 1 || pool.Count > maxNodePoolSize {
+			return fmt.Errorf("node pool %s must have between 1 and %d nodes", pool.Architecture, maxNodePoolSize)
+		}
+	}
+	return nil
+}
+
+// SetNodePools sets the node pools added to clusters created afterwards
+func (k *K3dClusterManager) SetNodePools(pools []NodePool) {
+	k.config.NodePools = pools
+}
+
+// nodePoolAgents returns the agent nodes of the node pools of a cluster,
+// numbered across pools like the agents k3d creates
+func nodePoolAgents(clusterName, image string, volumes []string, pools []NodePool) []*k3d.Node {
+	var agents []*k3d.Node
+	for _, pool := range pools {
+		for i := 0; i < pool.Count; i++ {
+			agents = append(agents, &k3d.Node{
+				Name:    fmt.Sprintf("k3d-%s-agent-%d", clusterName, len(agents)),
+				Role:    k3d.AgentRole,
+				Image:   image,
+				Restart: true,
+				Volumes: volumes,
+				K3sNodeLabels: map[string]string{
+					"kecs.io/cluster":                   clusterName,
+					converters.CPUArchitectureNodeLabel: pool.Architecture,
+				},
+			})
+		}
+	}
+	return agents
+}
@@ -0,0 +1,85 @@
+package k3d
+
+import (
+	"testing"
+
+	k3d "github.com/k3d-io/k3d/v5/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+)
+
+func TestParseNodePool(t *testing.T) {
+	pool, err := ParseNodePool("arm64=2")
+	require.NoError(t, err)
+	assert.Equal(t, NodePool{Architecture: "arm64", Count: 2}, pool)
+
+	pool, err = ParseNodePool("amd64")
+	require.NoError(t, err)
+	assert.Equal(t, NodePool{Architecture: "amd64", Count: 1}, pool)
+
+	_, err = ParseNodePool("arm64=two")
+	assert.ErrorContains(t, err, "count must be a number")
+}
+
+func TestValidateNodePools(t *testing.T) {
+	tests := []struct {
+		name    string
+		pools   []NodePool
+		wantErr string
+	}{
+		{
+			name: "no pools",
+		},
+		{
+			name:  "both architectures",
+			pools: []NodePool{{Architecture: "amd64", Count: 1}, {Architecture: "arm64", Count: 5}},
+		},
+		{
+			name:    "unknown architecture",
+			pools:   []NodePool{{Architecture: "riscv64", Count: 1}},
+			wantErr: "must be amd64 or arm64",
+		},
+		{
+			name:    "duplicate architecture",
+			pools:   []NodePool{{Architecture: "arm64", Count: 1}, {Architecture: "arm64", Count: 2}},
+			wantErr: "more than once",
+		},
+		{
+			name:    "too many nodes",
+			pools:   []NodePool{{Architecture: "arm64", Count: 6}},
+			wantErr: "between 1 and 5 nodes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateNodePools(tt.pools)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNodePoolAgents(t *testing.T) {
+	agents := nodePoolAgents("kecs-dev", "rancher/k3s:latest", []string{"/data:/data"}, []NodePool{
+		{Architecture: "amd64", Count: 1},
+		{Architecture: "arm64", Count: 2},
+	})
+	require.Len(t, agents, 3)
+
+	assert.Equal(t, "k3d-kecs-dev-agent-0", agents[0].Name)
+	assert.Equal(t, "k3d-kecs-dev-agent-2", agents[2].Name)
+	for _, agent := range agents {
+		assert.Equal(t, k3d.AgentRole, agent.Role)
+		assert.Equal(t, "rancher/k3s:latest", agent.Image)
+		assert.Equal(t, []string{"/data:/data"}, agent.Volumes)
+		assert.Equal(t, "kecs-dev", agent.K3sNodeLabels["kecs.io/cluster"])
+	}
+	assert.Equal(t, "amd64", agents[0].K3sNodeLabels[converters.CPUArchitectureNodeLabel])
+	assert.Equal(t, "arm64", agents[1].K3sNodeLabels[converters.CPUArchitectureNodeLabel])
+}
@@ -0,0 +1,231 @@
+package k3d
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	k3d "github.com/k3d-io/k3d/v5/pkg/types"
+	"gopkg.in/yaml.v3"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// registriesPath is where k3s reads the registry configuration of containerd
+const registriesPath = "/etc/rancher/k3s/registries.yaml"
+
+// localRegistryHosts are the names the KECS registry is pulled from, they
+// always resolve to the KECS registry and cannot be configured
+var localRegistryHosts = []string{"localhost:5000", "registry.kecs.local:5000"}
+
+// RegistryConfig configures where the nodes of an instance pull images from,
+// e.g. the mirrors and proxies of a corporate network
+type RegistryConfig struct {
+	// Mirrors maps a registry, such as docker.io, to the endpoints images of
+	// it are pulled from. Endpoints are tried in order, the registry itself
+	// is tried last.
+	Mirrors map[string][]string `yaml:"mirrors,omitempty" json:"mirrors,omitempty"`
+	// Insecure lists registries served over plain HTTP or with certificates
+	// that are not trusted
+	Insecure []string `yaml:"insecure,omitempty" json:"insecure,omitempty"`
+	// Auths maps a registry or mirror host to its credentials
+	Auths map[string]RegistryAuth `yaml:"auths,omitempty" json:"auths,omitempty"`
+}
+
+// RegistryAuth holds the credentials of a registry, either a username and
+// password or an identity token
+type RegistryAuth struct {
+	Username      string `yaml:"username,omitempty" json:"username,omitempty"`
+	Password      string `yaml:"password,omitempty" json:"password,omitempty"`
+	IdentityToken string `yaml:"identityToken,omitempty" json:"identityToken,omitempty"`
+}
+
+// IsEmpty reports whether the config changes nothing
+func (c *RegistryConfig) IsEmpty() bool {
+	return c == nil || (len(c.Mirrors) == 0 && len(c.Insecure) == 0 && len(c.Auths) == 0)
+}
+
+// Validate checks registry names and mirror endpoints
+func (c *RegistryConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	for registry, endpoints := range c.Mirrors {
+		if err := validateRegistryHost(registry, true); err != nil {
+			return err
+		}
+		if len(endpoints) == 0 {
+			return fmt.Errorf("mirror of %s has no endpoints", registry)
+		}
+		for _, endpoint := range endpoints {
+			u, err := url.Parse(endpoint)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("invalid endpoint %q of mirror %s: must be an http or https URL", endpoint, registry)
+			}
+		}
+	}
+	for _, registry := range c.Insecure {
+		if err := validateRegistryHost(registry, false); err != nil {
+			return err
+		}
+	}
+	for registry, auth := range c.Auths {
+		if err := validateRegistryHost(registry, false); err != nil {
+			return err
+		}
+		if auth.IdentityToken == "" && (auth.Username == "" || auth.Password == "") {
+			return fmt.Errorf("credentials of %s need a username and password or an identity token", registry)
+		}
+	}
+	return nil
+}
+
+// validateRegistryHost checks a registry host with optional port. Mirrors
+// may also be configured for all registries with "*".
+func validateRegistryHost(registry string, allowWildcard bool) error {
+	if registry == "*" && allowWildcard {
+		return nil
+	}
+	if registry == "" || strings.ContainsAny(registry, "/ ") || strings.Contains(registry, "://") {
+		return fmt.Errorf("invalid registry %q: must be a host with optional port", registry)
+	}
+	for _, local := range localRegistryHosts {
+		if registry == local {
+			return fmt.Errorf("registry %s is the KECS registry and cannot be configured", registry)
+		}
+	}
+	return nil
+}
+
+// k3sRegistries is the registries.yaml format of k3s
+type k3sRegistries struct {
+	Mirrors map[string]k3sMirror         `yaml:"mirrors,omitempty"`
+	Configs map[string]k3sRegistryConfig `yaml:"configs,omitempty"`
+}
+
+type k3sMirror struct {
+	Endpoint []string `yaml:"endpoint"`
+}
+
+type k3sRegistryConfig struct {
+	Auth *k3sRegistryAuth `yaml:"auth,omitempty"`
+	TLS  *k3sRegistryTLS  `yaml:"tls,omitempty"`
+}
+
+type k3sRegistryAuth struct {
+	Username      string `yaml:"username,omitempty"`
+	Password      string `yaml:"password,omitempty"`
+	IdentityToken string `yaml:"identity_token,omitempty"`
+}
+
+type k3sRegistryTLS struct {
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify"`
+}
+
+// RenderRegistries renders the k3s registries.yaml of the nodes of an
+// instance. The KECS registry at registryHost is always reachable as
+// localhost:5000 and registry.kecs.local:5000, cfg adds to it.
+func RenderRegistries(registryHost string, cfg *RegistryConfig) ([]byte, error) {
+	local := registryHost + ":5000"
+	registries := k3sRegistries{
+		Mirrors: map[string]k3sMirror{},
+		Configs: map[string]k3sRegistryConfig{
+			local: {TLS: &k3sRegistryTLS{InsecureSkipVerify: true}},
+		},
+	}
+	for _, host := range localRegistryHosts {
+		registries.Mirrors[host] = k3sMirror{Endpoint: []string{"http://" + local}}
+	}
+
+	if cfg != nil {
+		for registry, endpoints := range cfg.Mirrors {
+			registries.Mirrors[registry] = k3sMirror{Endpoint: endpoints}
+		}
+		for _, registry := range cfg.Insecure {
+			config := registries.Configs[registry]
+			config.TLS = &k3sRegistryTLS{InsecureSkipVerify: true}
+			registries.Configs[registry] = config
+			// containerd only speaks HTTP to endpoints given with that scheme,
+			// fall back to it like Docker does for insecure registries
+			if _, ok := registries.Mirrors[registry]; !ok {
+				registries.Mirrors[registry] = k3sMirror{Endpoint: []string{"https://" + registry, "http://" + registry}}
+			}
+		}
+		for registry, auth := range cfg.Auths {
+			config := registries.Configs[registry]
+			config.Auth = &k3sRegistryAuth{
+				Username:      auth.Username,
+				Password:      auth.Password,
+				IdentityToken: auth.IdentityToken,
+			}
+			registries.Configs[registry] = config
+		}
+	}
+
+	data, err := yaml.Marshal(&registries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render registry config: %w", err)
+	}
+	return data, nil
+}
+
+// SetRegistryConfig sets the registry config rendered into the nodes of
+// clusters created afterwards
+func (k *K3dClusterManager) SetRegistryConfig(cfg *RegistryConfig) {
+	k.config.Registries = cfg
+}
+
+// ApplyRegistryConfig renders cfg into the nodes of a running cluster and
+// restarts them one at a time, waiting for the cluster to be ready again
+// before restarting the next one. containerd only reads the registry config
+// when it starts.
+func (k *K3dClusterManager) ApplyRegistryConfig(ctx context.Context, clusterName string, cfg *RegistryConfig) error {
+	k.config.Registries = cfg
+	if k.config.TestMode {
+		logging.Info("CI/TEST MODE: Simulating registry config update", "cluster", clusterName)
+		return nil
+	}
+	normalizedName := k.normalizeClusterName(clusterName)
+
+	registryHost := "k3d-kecs-registry"
+	if registryNode, err := k.ensureRegistry(ctx); err == nil {
+		if ip, err := k.getRegistryIPInClusterNetwork(ctx, normalizedName, registryNode); err == nil {
+			registryHost = ip
+		}
+	}
+	data, err := RenderRegistries(registryHost, cfg)
+	if err != nil {
+		return err
+	}
+
+	nodes, err := k.runtime.GetNodesByLabel(ctx, map[string]string{k3d.LabelClusterName: normalizedName})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes of cluster %s: %w", normalizedName, err)
+	}
+	var restarted int
+	for _, node := range nodes {
+		if node.Role != k3d.ServerRole && node.Role != k3d.AgentRole {
+			continue
+		}
+		if err := k.runtime.WriteToNode(ctx, data, registriesPath, 0644, node); err != nil {
+			return fmt.Errorf("failed to write registry config to node %s: %w", node.Name, err)
+		}
+		logging.Info("Restarting node to apply registry config", "cluster", normalizedName, "node", node.Name)
+		if err := k.runtime.StopNode(ctx, node); err != nil {
+			return fmt.Errorf("failed to stop node %s: %w", node.Name, err)
+		}
+		if err := k.runtime.StartNode(ctx, node); err != nil {
+			return fmt.Errorf("failed to start node %s: %w", node.Name, err)
+		}
+		if err := k.WaitForClusterReady(ctx, clusterName); err != nil {
+			return fmt.Errorf("cluster did not become ready after restarting node %s: %w", node.Name, err)
+		}
+		restarted++
+	}
+	if restarted == 0 {
+		return fmt.Errorf("cluster %s has no nodes", normalizedName)
+	}
+	logging.Info("Applied registry config", "cluster", normalizedName, "nodes", restarted)
+	return nil
+}
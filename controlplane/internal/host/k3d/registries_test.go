@@ -0,0 +1,103 @@
+package k3d
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRenderRegistries(t *testing.T) {
+	t.Run("renders the KECS registry only without config", func(t *testing.T) {
+		data, err := RenderRegistries("172.18.0.3", nil)
+		require.NoError(t, err)
+
+		var rendered k3sRegistries
+		require.NoError(t, yaml.Unmarshal(data, &rendered))
+		assert.Equal(t, []string{"http://172.18.0.3:5000"}, rendered.Mirrors["localhost:5000"].Endpoint)
+		assert.Equal(t, []string{"http://172.18.0.3:5000"}, rendered.Mirrors["registry.kecs.local:5000"].Endpoint)
+		assert.True(t, rendered.Configs["172.18.0.3:5000"].TLS.InsecureSkipVerify)
+		assert.Len(t, rendered.Mirrors, 2)
+	})
+
+	t.Run("adds mirrors, insecure registries and credentials", func(t *testing.T) {
+		data, err := RenderRegistries("172.18.0.3", &RegistryConfig{
+			Mirrors:  map[string][]string{"docker.io": {"https://mirror.corp.example:5000"}},
+			Insecure: []string{"mirror.corp.example:5000", "legacy.corp.example"},
+			Auths: map[string]RegistryAuth{
+				"mirror.corp.example:5000": {Username: "ci", Password: "secret"},
+			},
+		})
+		require.NoError(t, err)
+
+		var rendered k3sRegistries
+		require.NoError(t, yaml.Unmarshal(data, &rendered))
+		assert.Equal(t, []string{"https://mirror.corp.example:5000"}, rendered.Mirrors["docker.io"].Endpoint)
+		assert.Equal(t, []string{"https://legacy.corp.example", "http://legacy.corp.example"}, rendered.Mirrors["legacy.corp.example"].Endpoint)
+
+		mirror := rendered.Configs["mirror.corp.example:5000"]
+		require.NotNil(t, mirror.TLS)
+		assert.True(t, mirror.TLS.InsecureSkipVerify)
+		require.NotNil(t, mirror.Auth)
+		assert.Equal(t, "ci", mirror.Auth.Username)
+		assert.Equal(t, "secret", mirror.Auth.Password)
+		assert.Contains(t, string(data), "insecure_skip_verify: true")
+	})
+}
+
+func TestRegistryConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *RegistryConfig
+		wantErr string
+	}{
+		{
+			name:   "nil config",
+			config: nil,
+		},
+		{
+			name:   "mirror for all registries",
+			config: &RegistryConfig{Mirrors: map[string][]string{"*": {"https://proxy.example.com"}}},
+		},
+		{
+			name:    "endpoint without scheme",
+			config:  &RegistryConfig{Mirrors: map[string][]string{"docker.io": {"mirror.example.com"}}},
+			wantErr: "must be an http or https URL",
+		},
+		{
+			name:    "mirror without endpoints",
+			config:  &RegistryConfig{Mirrors: map[string][]string{"docker.io": nil}},
+			wantErr: "has no endpoints",
+		},
+		{
+			name:    "registry with scheme",
+			config:  &RegistryConfig{Insecure: []string{"http://registry.example.com"}},
+			wantErr: "must be a host",
+		},
+		{
+			name:    "KECS registry",
+			config:  &RegistryConfig{Insecure: []string{"localhost:5000"}},
+			wantErr: "KECS registry",
+		},
+		{
+			name:    "credentials without password",
+			config:  &RegistryConfig{Auths: map[string]RegistryAuth{"registry.example.com": {Username: "ci"}}},
+			wantErr: "username and password",
+		},
+		{
+			name:   "identity token",
+			config: &RegistryConfig{Auths: map[string]RegistryAuth{"registry.example.com": {IdentityToken: "token"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
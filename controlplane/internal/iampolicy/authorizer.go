@@ -0,0 +1,193 @@
+package iampolicy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsrequest"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// unauthorizedActions are allowed whatever the policies, as in AWS
+var unauthorizedActions = map[string]bool{
+	"sts:GetCallerIdentity": true,
+}
+
+// actionPrefixes are the IAM action prefixes of the services calls are routed
+// to, where they differ from the service name
+var actionPrefixes = map[string]string{
+	"appconfigdata": "appconfig",
+}
+
+// Action returns the IAM action of an API call, e.g. ecs:CreateService. The
+// prefix comes from the service the call is routed to, not the one it is
+// signed for, so that a made up signing name does not escape ecs:* policies.
+func Action(op awsrequest.Operation) string {
+	prefix := op.Service
+	if p, ok := actionPrefixes[prefix]; ok {
+		prefix = p
+	}
+	return prefix + ":" + op.Name
+}
+
+// assumedRolePrefix starts the principals of role sessions
+const assumedRolePrefix = "assumed-role/"
+
+// AssumedRolePrincipal returns the principal of a session of a role
+func AssumedRolePrincipal(role, session string) string {
+	return assumedRolePrefix + role + "/" + session
+}
+
+// PrincipalARN returns the ARN of a principal: a user, or a role session
+func PrincipalARN(principal, accountID string) string {
+	if strings.HasPrefix(principal, assumedRolePrefix) {
+		return awsarn.Build("sts", "", accountID, principal)
+	}
+	return awsarn.Build("iam", "", accountID, "user/"+principal)
+}
+
+// Authorizer evaluates the policies of the calling principal against every
+// AWS API call. It relies on the signature having been verified already.
+type Authorizer struct {
+	// principal resolves the principal of an access key
+	principal func(accessKeyID string) string
+	// policies resolves the policies that have to allow the calls of an access key
+	policies  func(accessKeyID string) []*Policy
+	region    string
+	accountID string
+}
+
+// NewAuthorizer creates an authorizer. Access keys without a policy are denied every action.
+func NewAuthorizer(principal func(accessKeyID string) string, policies func(accessKeyID string) []*Policy, region, accountID string) *Authorizer {
+	return &Authorizer{
+		principal: principal,
+		policies:  policies,
+		region:    region,
+		accountID: accountID,
+	}
+}
+
+// Denial describes why a call was denied
+type Denial struct {
+	Principal string
+	Action    string
+	Resource  string
+	Decision  Decision
+}
+
+// Message returns the message AWS returns for a denied call
+func (d *Denial) Message(accountID string) string {
+	reason := "because no identity-based policy allows the " + d.Action + " action"
+	if d.Decision == ExplicitDeny {
+		reason = "with an explicit deny in an identity-based policy"
+	}
+	return fmt.Sprintf("User: %s is not authorized to perform: %s on resource: %s %s",
+		PrincipalARN(d.Principal, accountID), d.Action, d.Resource, reason)
+}
+
+// Authorize checks that every policy allows the principal to perform the
+// action on every resource. It returns nil when the call is allowed.
+func (a *Authorizer) Authorize(principal string, policies []*Policy, action string, resources []string) *Denial {
+	if len(policies) == 0 {
+		policies = []*Policy{nil}
+	}
+	for _, resource := range resources {
+		decision := Allowed
+		for _, policy := range policies {
+			switch policy.Evaluate(action, resource) {
+			case ExplicitDeny:
+				decision = ExplicitDeny
+			case ImplicitDeny:
+				if decision == Allowed {
+					decision = ImplicitDeny
+				}
+			}
+		}
+		if decision != Allowed {
+			return &Denial{Principal: principal, Action: action, Resource: resource, Decision: decision}
+		}
+	}
+	return nil
+}
+
+// Middleware returns an HTTP middleware rejecting AWS API calls that the
+// policy of the caller does not allow. Unsigned requests pass through, they
+// only reach this point when unsigned requests are allowed.
+func (a *Authorizer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		op, ok := awsrequest.Identify(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		accessKeyID := awsrequest.ParseCredential(r.Header.Get("Authorization")).AccessKeyID
+		if accessKeyID == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var body []byte
+		if !op.QueryProtocol {
+			var err error
+			if body, err = awsrequest.ReadBody(r); err != nil {
+				awsrequest.WriteError(w, false, http.StatusBadRequest, "SerializationException", "Failed to read request body")
+				return
+			}
+		}
+
+		// Resources named in the request belong to the account of the caller
+		accountID := awsarn.AccountIDFrom(r.Context(), a.accountID)
+		c := &caller{authorizer: a, accessKeyID: accessKeyID, accountID: accountID}
+		if denial := c.authorize(op, body); denial != nil {
+			logging.Debug("Denying API call", "principal", denial.Principal, "action", denial.Action, "resource", denial.Resource)
+			if op.QueryProtocol {
+				awsrequest.WriteError(w, true, http.StatusForbidden, "AccessDenied", denial.Message(accountID))
+			} else {
+				awsrequest.WriteError(w, false, http.StatusBadRequest, "AccessDeniedException", denial.Message(accountID))
+			}
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), callerKey{}, c)))
+	})
+}
+
+// callerKey is the context key of the caller of an authorized request
+type callerKey struct{}
+
+// caller is the principal an authorized request is made by
+type caller struct {
+	authorizer  *Authorizer
+	accessKeyID string
+	accountID   string
+}
+
+// authorize checks a call against the policies of the caller
+func (c *caller) authorize(op awsrequest.Operation, body []byte) *Denial {
+	action := Action(op)
+	if unauthorizedActions[action] {
+		return nil
+	}
+	a := c.authorizer
+	resources := Resources(op, body, a.region, c.accountID)
+	return a.Authorize(a.principal(c.accessKeyID), a.policies(c.accessKeyID), action, resources)
+}
+
+// AuthorizeOnBehalf checks a call a service makes on behalf of the caller of
+// the request ctx belongs to against the policies of that caller, e.g. the
+// ECS calls CloudFormation makes to provision a stack. It returns nil for
+// requests that were not authorized, e.g. unsigned ones.
+func AuthorizeOnBehalf(ctx context.Context, op awsrequest.Operation, body []byte) error {
+	c, ok := ctx.Value(callerKey{}).(*caller)
+	if !ok {
+		return nil
+	}
+	if denial := c.authorize(op, body); denial != nil {
+		logging.Debug("Denying API call made on behalf of the caller", "principal", denial.Principal, "action", denial.Action, "resource", denial.Resource)
+		return errors.New(denial.Message(c.accountID))
+	}
+	return nil
+}
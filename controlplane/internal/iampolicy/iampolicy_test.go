@@ -0,0 +1,216 @@
+package iampolicy_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsrequest"
+	"github.com/nandemo-ya/kecs/controlplane/internal/iampolicy"
+)
+
+const (
+	region    = "us-east-1"
+	accountID = "000000000000"
+)
+
+const deployerPolicy = `{
+  "Version": "2012-10-17",
+  "Statement": [
+    {"Effect": "Allow", "Action": ["ecs:Describe*", "ecs:List*"], "Resource": "*"},
+    {"Effect": "Allow", "Action": "ecs:UpdateService", "Resource": "arn:aws:ecs:us-east-1:000000000000:service/prod/*"},
+    {"Effect": "Deny", "Action": "ecs:UpdateService", "Resource": "arn:aws:ecs:us-east-1:000000000000:service/prod/db"}
+  ]
+}`
+
+var _ = Describe("Policy", func() {
+	var policy *iampolicy.Policy
+
+	BeforeEach(func() {
+		var err error
+		policy, err = iampolicy.ParsePolicy([]byte(deployerPolicy))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("allows matching actions with wildcards", func() {
+		Expect(policy.Evaluate("ecs:DescribeServices", "*")).To(Equal(iampolicy.Allowed))
+		Expect(policy.Evaluate("ECS:listclusters", "*")).To(Equal(iampolicy.Allowed), "actions are case insensitive")
+		Expect(policy.Evaluate("ecs:UpdateService", "arn:aws:ecs:us-east-1:000000000000:service/prod/web")).To(Equal(iampolicy.Allowed))
+	})
+
+	It("denies actions no statement allows", func() {
+		Expect(policy.Evaluate("ecs:DeleteService", "arn:aws:ecs:us-east-1:000000000000:service/prod/web")).To(Equal(iampolicy.ImplicitDeny))
+		Expect(policy.Evaluate("ecs:UpdateService", "arn:aws:ecs:us-east-1:000000000000:service/staging/web")).To(Equal(iampolicy.ImplicitDeny))
+	})
+
+	It("lets an explicit deny override allows", func() {
+		Expect(policy.Evaluate("ecs:UpdateService", "arn:aws:ecs:us-east-1:000000000000:service/prod/db")).To(Equal(iampolicy.ExplicitDeny))
+	})
+
+	It("supports NotAction", func() {
+		policy, err := iampolicy.ParsePolicy([]byte(`{"Statement": [{"Effect": "Allow", "NotAction": "ecs:Delete*", "Resource": "*"}]}`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(policy.Evaluate("ecs:CreateService", "*")).To(Equal(iampolicy.Allowed))
+		Expect(policy.Evaluate("ecs:DeleteCluster", "*")).To(Equal(iampolicy.ImplicitDeny))
+	})
+
+	It("rejects statements it cannot evaluate", func() {
+		_, err := iampolicy.ParsePolicy([]byte(`{"Statement": [{"Effect": "Allow", "Action": "ecs:*", "Resource": "*", "Condition": {"StringEquals": {"ecs:cluster": "prod"}}}]}`))
+		Expect(err).To(MatchError(ContainSubstring("Condition is not supported")))
+
+		_, err = iampolicy.ParsePolicy([]byte(`{"Statement": [{"Effect": "Allow", "Resource": "*"}]}`))
+		Expect(err).To(MatchError(ContainSubstring("Action")))
+	})
+})
+
+var _ = Describe("Resources", func() {
+	ecs := func(operation, body string) []string {
+		op := awsrequest.Operation{Service: awsrequest.ServiceECS, Name: operation}
+		return iampolicy.Resources(op, []byte(body), region, accountID)
+	}
+
+	It("scopes ECS calls to the most specific resource", func() {
+		Expect(ecs("UpdateService", `{"cluster": "prod", "service": "web"}`)).To(Equal([]string{"arn:aws:ecs:us-east-1:000000000000:service/prod/web"}))
+		Expect(ecs("CreateService", `{"serviceName": "web"}`)).To(Equal([]string{"arn:aws:ecs:us-east-1:000000000000:service/default/web"}))
+		Expect(ecs("StopTask", `{"cluster": "arn:aws:ecs:us-east-1:000000000000:cluster/prod", "task": "abc"}`)).To(Equal([]string{"arn:aws:ecs:us-east-1:000000000000:task/prod/abc"}))
+		Expect(ecs("RunTask", `{"cluster": "prod", "taskDefinition": "web:3"}`)).To(Equal([]string{"arn:aws:ecs:us-east-1:000000000000:task-definition/web:3"}))
+		Expect(ecs("RegisterTaskDefinition", `{"family": "web"}`)).To(Equal([]string{"arn:aws:ecs:us-east-1:000000000000:task-definition/web:*"}))
+		Expect(ecs("CreateCluster", `{"clusterName": "prod"}`)).To(Equal([]string{"arn:aws:ecs:us-east-1:000000000000:cluster/prod"}))
+		Expect(ecs("ListClusters", `{}`)).To(Equal([]string{"*"}))
+	})
+
+	It("uses the ARNs of ELBv2 calls", func() {
+		op := awsrequest.Operation{Service: awsrequest.ServiceELBv2, Name: "RegisterTargets", QueryProtocol: true, Params: url.Values{
+			"TargetGroupArn": {"arn:aws:elasticloadbalancing:us-east-1:000000000000:targetgroup/web/123"},
+		}}
+		Expect(iampolicy.Resources(op, nil, region, accountID)).To(Equal([]string{"arn:aws:elasticloadbalancing:us-east-1:000000000000:targetgroup/web/123"}))
+
+		op = awsrequest.Operation{Service: awsrequest.ServiceELBv2, Name: "CreateLoadBalancer", QueryProtocol: true, Params: url.Values{"Name": {"web"}}}
+		Expect(iampolicy.Resources(op, nil, region, accountID)).To(Equal([]string{"arn:aws:elasticloadbalancing:us-east-1:000000000000:loadbalancer/app/web/*"}))
+	})
+})
+
+var _ = Describe("Authorizer", func() {
+	var (
+		authorizer *iampolicy.Authorizer
+		handler    http.Handler
+		reached    bool
+	)
+
+	BeforeEach(func() {
+		policy, err := iampolicy.ParsePolicy([]byte(deployerPolicy))
+		Expect(err).NotTo(HaveOccurred())
+		sessionPolicy, err := iampolicy.ParsePolicy([]byte(`{"Statement": [{"Effect": "Allow", "Action": "ecs:UpdateService", "Resource": "*"}]}`))
+		Expect(err).NotTo(HaveOccurred())
+		principals := map[string]string{
+			"AKIADEPLOYER": "deployer",
+			"AKIANOPOLICY": "intern",
+			"ASIASESSION":  iampolicy.AssumedRolePrincipal("deployer", "ci"),
+		}
+		policies := map[string][]*iampolicy.Policy{
+			"AKIADEPLOYER": {policy},
+			"ASIASESSION":  {policy, sessionPolicy},
+		}
+		authorizer = iampolicy.NewAuthorizer(func(accessKeyID string) string { return principals[accessKeyID] },
+			func(accessKeyID string) []*iampolicy.Policy { return policies[accessKeyID] }, region, accountID)
+
+		reached = false
+		handler = authorizer.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reached = true
+			w.WriteHeader(http.StatusOK)
+		}))
+	})
+
+	ecsRequest := func(accessKeyID, operation, body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("X-Amz-Target", "AmazonEC2ContainerServiceV20141113."+operation)
+		req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+		req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKeyID+"/20251015/us-east-1/ecs/aws4_request, SignedHeaders=host, Signature=abc")
+		return req
+	}
+
+	It("lets allowed calls through", func() {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, ecsRequest("AKIADEPLOYER", "UpdateService", `{"cluster": "prod", "service": "web"}`))
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(reached).To(BeTrue())
+	})
+
+	It("rejects denied calls with AccessDeniedException", func() {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, ecsRequest("AKIADEPLOYER", "UpdateService", `{"cluster": "prod", "service": "db"}`))
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+		Expect(rec.Body.String()).To(ContainSubstring("AccessDeniedException"))
+		Expect(rec.Body.String()).To(ContainSubstring("User: arn:aws:iam::000000000000:user/deployer is not authorized to perform: ecs:UpdateService on resource: arn:aws:ecs:us-east-1:000000000000:service/prod/db with an explicit deny"))
+		Expect(reached).To(BeFalse())
+	})
+
+	It("denies every call of principals without a policy", func() {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, ecsRequest("AKIANOPOLICY", "ListClusters", `{}`))
+		Expect(rec.Body.String()).To(ContainSubstring("because no identity-based policy allows the ecs:ListClusters action"))
+	})
+
+	It("requires every policy of a session to allow the call", func() {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, ecsRequest("ASIASESSION", "UpdateService", `{"cluster": "prod", "service": "web"}`))
+		Expect(rec.Code).To(Equal(http.StatusOK))
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, ecsRequest("ASIASESSION", "DescribeServices", `{"cluster": "prod", "services": ["web"]}`))
+		Expect(rec.Body.String()).To(ContainSubstring("User: arn:aws:sts::000000000000:assumed-role/deployer/ci is not authorized to perform: ecs:DescribeServices"))
+	})
+
+	It("lets every principal call GetCallerIdentity", func() {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("Action=GetCallerIdentity&Version=2011-06-15"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential=AKIANOPOLICY/20251015/us-east-1/sts/aws4_request, SignedHeaders=host, Signature=abc")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+	})
+
+	It("rejects denied query protocol calls with AccessDenied", func() {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("Action=DeleteLoadBalancer&LoadBalancerArn=arn%3Aaws%3Aelasticloadbalancing%3Aus-east-1%3A000000000000%3Aloadbalancer%2Fapp%2Fweb%2F1"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential=AKIADEPLOYER/20251015/us-east-1/elasticloadbalancing/aws4_request, SignedHeaders=host, Signature=abc")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusForbidden))
+		Expect(rec.Body.String()).To(ContainSubstring("<Code>AccessDenied</Code>"))
+	})
+
+	It("takes the action prefix from the routed service, not the signing name", func() {
+		req := ecsRequest("AKIADEPLOYER", "UpdateService", `{"cluster": "prod", "service": "db"}`)
+		req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential=AKIADEPLOYER/20251015/us-east-1/ecsx/aws4_request, SignedHeaders=host, Signature=abc")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		Expect(rec.Body.String()).To(ContainSubstring("not authorized to perform: ecs:UpdateService"))
+		Expect(reached).To(BeFalse())
+	})
+
+	It("authorizes the calls made on behalf of the caller", func() {
+		var errs []error
+		handler = authorizer.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, service := range []string{"web", "db"} {
+				op := awsrequest.Operation{Service: awsrequest.ServiceECS, Name: "UpdateService"}
+				errs = append(errs, iampolicy.AuthorizeOnBehalf(r.Context(), op, []byte(`{"cluster": "prod", "service": "`+service+`"}`)))
+			}
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), ecsRequest("AKIADEPLOYER", "ListClusters", `{}`))
+
+		Expect(errs).To(HaveLen(2))
+		Expect(errs[0]).NotTo(HaveOccurred())
+		Expect(errs[1]).To(MatchError(ContainSubstring("not authorized to perform: ecs:UpdateService on resource: arn:aws:ecs:us-east-1:000000000000:service/prod/db")))
+	})
+
+	It("does not authorize calls made outside an authorized request", func() {
+		op := awsrequest.Operation{Service: awsrequest.ServiceECS, Name: "DeleteCluster"}
+		Expect(iampolicy.AuthorizeOnBehalf(context.Background(), op, []byte(`{"cluster": "prod"}`))).To(Succeed())
+	})
+})
@@ -0,0 +1,186 @@
+// Package iampolicy evaluates IAM identity policies against AWS API calls, so
+// that least-privilege policies can be tested against KECS before they are
+// applied to real accounts.
+package iampolicy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	EffectAllow = "Allow"
+	EffectDeny  = "Deny"
+)
+
+// StringList is a policy element that is either a single string or a list of strings
+type StringList []string
+
+// UnmarshalJSON accepts both "ecs:*" and ["ecs:*"]
+func (l *StringList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*l = StringList{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("expected a string or a list of strings: %w", err)
+	}
+	*l = list
+	return nil
+}
+
+// UnmarshalYAML accepts both a scalar and a sequence
+func (l *StringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = StringList{node.Value}
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return fmt.Errorf("expected a string or a list of strings: %w", err)
+	}
+	*l = list
+	return nil
+}
+
+// Statement is one statement of a policy document
+type Statement struct {
+	Sid         string     `json:"Sid,omitempty" yaml:"Sid,omitempty"`
+	Effect      string     `json:"Effect" yaml:"Effect"`
+	Action      StringList `json:"Action,omitempty" yaml:"Action,omitempty"`
+	NotAction   StringList `json:"NotAction,omitempty" yaml:"NotAction,omitempty"`
+	Resource    StringList `json:"Resource,omitempty" yaml:"Resource,omitempty"`
+	NotResource StringList `json:"NotResource,omitempty" yaml:"NotResource,omitempty"`
+	// Condition is rejected by Validate, conditions are not evaluated
+	Condition map[string]interface{} `json:"Condition,omitempty" yaml:"Condition,omitempty"`
+}
+
+// Policy is an IAM identity policy document
+type Policy struct {
+	Version   string      `json:"Version,omitempty" yaml:"Version,omitempty"`
+	Statement []Statement `json:"Statement" yaml:"Statement"`
+}
+
+// ParsePolicy parses a JSON policy document
+func ParsePolicy(document []byte) (*Policy, error) {
+	var policy Policy
+	if err := json.Unmarshal(document, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse policy document: %w", err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// Validate checks that every statement can be evaluated
+func (p *Policy) Validate() error {
+	if len(p.Statement) == 0 {
+		return fmt.Errorf("policy has no statements")
+	}
+	for i, stmt := range p.Statement {
+		name := stmt.Sid
+		if name == "" {
+			name = fmt.Sprintf("#%d", i)
+		}
+		switch {
+		case stmt.Effect != EffectAllow && stmt.Effect != EffectDeny:
+			return fmt.Errorf("statement %s: Effect must be Allow or Deny", name)
+		case (len(stmt.Action) == 0) == (len(stmt.NotAction) == 0):
+			return fmt.Errorf("statement %s: exactly one of Action and NotAction is required", name)
+		case (len(stmt.Resource) == 0) == (len(stmt.NotResource) == 0):
+			return fmt.Errorf("statement %s: exactly one of Resource and NotResource is required", name)
+		case len(stmt.Condition) > 0:
+			return fmt.Errorf("statement %s: Condition is not supported", name)
+		}
+	}
+	return nil
+}
+
+// Decision is the result of evaluating a request
+type Decision int
+
+const (
+	// ImplicitDeny means no statement allows the request
+	ImplicitDeny Decision = iota
+	Allowed
+	// ExplicitDeny means a Deny statement matches the request
+	ExplicitDeny
+)
+
+// Evaluate decides whether the policy allows an action on a resource. As in
+// IAM, an explicit deny overrides any allow and requests no statement allows
+// are denied.
+func (p *Policy) Evaluate(action, resource string) Decision {
+	if p == nil {
+		return ImplicitDeny
+	}
+	decision := ImplicitDeny
+	for _, stmt := range p.Statement {
+		if !stmt.matches(action, resource) {
+			continue
+		}
+		if stmt.Effect == EffectDeny {
+			return ExplicitDeny
+		}
+		decision = Allowed
+	}
+	return decision
+}
+
+func (s Statement) matches(action, resource string) bool {
+	actionMatches := matchAny(s.Action, action, true)
+	if len(s.NotAction) > 0 {
+		actionMatches = !matchAny(s.NotAction, action, true)
+	}
+	resourceMatches := matchAny(s.Resource, resource, false)
+	if len(s.NotResource) > 0 {
+		resourceMatches = !matchAny(s.NotResource, resource, false)
+	}
+	return actionMatches && resourceMatches
+}
+
+func matchAny(patterns []string, value string, ignoreCase bool) bool {
+	for _, pattern := range patterns {
+		if ignoreCase {
+			if wildcardMatch(strings.ToLower(pattern), strings.ToLower(value)) {
+				return true
+			}
+		} else if wildcardMatch(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// wildcardMatch matches IAM patterns, where * matches any sequence of
+// characters and ? matches any single character
+func wildcardMatch(pattern, value string) bool {
+	p, v := 0, 0
+	star, mark := -1, 0
+	for v < len(value) {
+		switch {
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == value[v]):
+			p++
+			v++
+		case p < len(pattern) && pattern[p] == '*':
+			star, mark = p, v
+			p++
+		case star >= 0:
+			p = star + 1
+			mark++
+			v = mark
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
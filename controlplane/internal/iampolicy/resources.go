@@ -0,0 +1,169 @@
+package iampolicy
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsrequest"
+)
+
+// defaultCluster is the cluster ECS uses when a request names none
+const defaultCluster = "default"
+
+// Resources returns the ARNs of the resources an API call acts on, the way
+// IAM scopes the action. Calls that are not scoped to a resource, such as
+// ListClusters, return "*".
+func Resources(op awsrequest.Operation, body []byte, region, accountID string) []string {
+	var resources []string
+	switch op.Service {
+	case awsrequest.ServiceECS:
+		resources = ecsResources(op.Name, body, region, accountID)
+	case awsrequest.ServiceELBv2:
+		resources = elbv2Resources(op, region, accountID)
+	case awsrequest.ServiceSTS:
+		// AssumeRole is scoped to the role
+		if roleARN := op.Params.Get("RoleArn"); roleARN != "" {
+			resources = []string{roleARN}
+		}
+	}
+	if len(resources) == 0 {
+		return []string{"*"}
+	}
+	return resources
+}
+
+// ecsResources derives ECS ARNs from the request parameters. The most
+// specific resource wins, e.g. UpdateService is scoped to the service and not
+// to its cluster.
+func ecsResources(operation string, body []byte, region, accountID string) []string {
+	var params map[string]interface{}
+	if len(body) > 0 && json.Unmarshal(body, &params) != nil {
+		return nil
+	}
+	arn := func(resource string) string {
+		if strings.HasPrefix(resource, "arn:") {
+			return resource
+		}
+		return awsarn.Build("ecs", region, accountID, resource)
+	}
+	cluster := stringParam(params, "cluster")
+	if cluster == "" {
+		cluster = defaultCluster
+	}
+	// Cluster scoped resources are named after the cluster, not its ARN
+	if _, name, found := strings.Cut(cluster, ":cluster/"); found {
+		cluster = name
+	}
+	inCluster := func(kind string, ids []string) []string {
+		arns := make([]string, 0, len(ids))
+		for _, id := range ids {
+			if strings.HasPrefix(id, "arn:") {
+				arns = append(arns, id)
+				continue
+			}
+			arns = append(arns, arn(kind+"/"+cluster+"/"+id))
+		}
+		return arns
+	}
+
+	switch {
+	case stringParam(params, "resourceArn") != "":
+		return []string{stringParam(params, "resourceArn")}
+	case stringParam(params, "service") != "":
+		return inCluster("service", []string{stringParam(params, "service")})
+	case stringParam(params, "serviceName") != "":
+		return inCluster("service", []string{stringParam(params, "serviceName")})
+	case len(listParam(params, "services")) > 0:
+		return inCluster("service", listParam(params, "services"))
+	case stringParam(params, "task") != "":
+		return inCluster("task", []string{stringParam(params, "task")})
+	case len(listParam(params, "tasks")) > 0:
+		return inCluster("task", listParam(params, "tasks"))
+	case stringParam(params, "containerInstance") != "":
+		return inCluster("container-instance", []string{stringParam(params, "containerInstance")})
+	case len(listParam(params, "containerInstances")) > 0:
+		return inCluster("container-instance", listParam(params, "containerInstances"))
+	case stringParam(params, "taskDefinition") != "":
+		return []string{arn("task-definition/" + stringParam(params, "taskDefinition"))}
+	case operation == "RegisterTaskDefinition" && stringParam(params, "family") != "":
+		return []string{arn("task-definition/" + stringParam(params, "family") + ":*")}
+	case stringParam(params, "clusterName") != "":
+		return []string{arn("cluster/" + stringParam(params, "clusterName"))}
+	case len(listParam(params, "clusters")) > 0:
+		var arns []string
+		for _, name := range listParam(params, "clusters") {
+			arns = append(arns, arn("cluster/"+name))
+		}
+		return arns
+	case stringParam(params, "cluster") != "":
+		return []string{arn("cluster/" + cluster)}
+	}
+	return nil
+}
+
+// elbv2ARNParams are the parameters holding ELBv2 ARNs, most specific first
+var elbv2ARNParams = []string{"RuleArn", "ListenerArn", "TargetGroupArn", "LoadBalancerArn", "ResourceArn"}
+
+// elbv2Resources collects the ARNs of an ELBv2 call. Created resources are
+// matched by name since their ARN is not known yet.
+func elbv2Resources(op awsrequest.Operation, region, accountID string) []string {
+	params := op.Params
+	for _, name := range elbv2ARNParams {
+		if value := params.Get(name); value != "" {
+			return []string{value}
+		}
+		// Lists are encoded as RuleArns.member.1, RuleArns.member.2, ...
+		if values := memberParams(params, name+"s"); len(values) > 0 {
+			return values
+		}
+	}
+
+	prefix := awsarn.Build("elasticloadbalancing", region, accountID, "")
+	name := params.Get("Name")
+	switch {
+	case name == "":
+		return nil
+	case op.Name == "CreateLoadBalancer":
+		kind := "app"
+		if params.Get("Type") == "network" {
+			kind = "net"
+		}
+		return []string{prefix + "loadbalancer/" + kind + "/" + name + "/*"}
+	case op.Name == "CreateTargetGroup":
+		return []string{prefix + "targetgroup/" + name + "/*"}
+	}
+	return nil
+}
+
+func memberParams(params map[string][]string, name string) []string {
+	var keys []string
+	for key := range params {
+		if strings.HasPrefix(key, name+".member.") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	values := make([]string, 0, len(keys))
+	for _, key := range keys {
+		values = append(values, params[key][0])
+	}
+	return values
+}
+
+func stringParam(params map[string]interface{}, name string) string {
+	value, _ := params[name].(string)
+	return value
+}
+
+func listParam(params map[string]interface{}, name string) []string {
+	items, _ := params[name].([]interface{})
+	var values []string
+	for _, item := range items {
+		if value, ok := item.(string); ok && value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
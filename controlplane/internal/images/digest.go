@@ -0,0 +1,51 @@
+// Package images resolves container image tags to the digests they point to,
+// so task definitions can pin the exact image their tasks run, and looks up
+// the CPU architectures images are built for.
+package images
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// Resolver pins images to digests
+type Resolver interface {
+	// Pin returns image with the digest its tag currently points to appended,
+	// e.g. nginx:1.27@sha256:... Images that already carry a digest are
+	// returned unchanged.
+	Pin(ctx context.Context, image string) (string, error)
+}
+
+// RegistryResolver resolves digests by asking the registry of an image for
+// its manifest. Credentials are taken from the Docker config of the control
+// plane, images are pulled anonymously otherwise.
+type RegistryResolver struct {
+	insecureRegistries []string
+}
+
+// NewRegistryResolver creates a resolver. Registries listed in
+// insecureRegistries, e.g. an in-cluster registry, are reached over plain HTTP.
+func NewRegistryResolver(insecureRegistries []string) *RegistryResolver {
+	return &RegistryResolver{insecureRegistries: insecureRegistries}
+}
+
+// Pin implements Resolver
+func (r *RegistryResolver) Pin(ctx context.Context, image string) (string, error) {
+	ref, err := r.parseReference(image)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := ref.(name.Digest); ok {
+		return image, nil
+	}
+
+	desc, err := remote.Head(ref, remote.WithContext(ctx), remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve digest of image %q: %w", image, err)
+	}
+	return image + "@" + desc.Digest.String(), nil
+}
@@ -0,0 +1,78 @@
+package images_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/images"
+)
+
+var _ = Describe("RegistryResolver", func() {
+	var (
+		ctx      context.Context
+		host     string
+		resolver *images.RegistryResolver
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		server := httptest.NewServer(registry.New())
+		DeferCleanup(server.Close)
+		host = strings.TrimPrefix(server.URL, "http://")
+		resolver = images.NewRegistryResolver([]string{host})
+	})
+
+	pushImage := func(image string) string {
+		img, err := random.Image(64, 1)
+		Expect(err).NotTo(HaveOccurred())
+		ref, err := name.ParseReference(image, name.Insecure)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(remote.Write(ref, img)).To(Succeed())
+		digest, err := img.Digest()
+		Expect(err).NotTo(HaveOccurred())
+		return digest.String()
+	}
+
+	It("appends the digest a tag points to", func() {
+		digest := pushImage(host + "/web:1.0")
+
+		pinned, err := resolver.Pin(ctx, host+"/web:1.0")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pinned).To(Equal(host + "/web:1.0@" + digest))
+	})
+
+	It("follows the tag when it is moved to another image", func() {
+		pushImage(host + "/web:latest")
+		digest := pushImage(host + "/web:latest")
+
+		pinned, err := resolver.Pin(ctx, host+"/web")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pinned).To(Equal(host + "/web@" + digest))
+	})
+
+	It("keeps images that already reference a digest", func() {
+		image := "nginx@sha256:0000000000000000000000000000000000000000000000000000000000000000"
+
+		pinned, err := resolver.Pin(ctx, image)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pinned).To(Equal(image))
+	})
+
+	It("fails for tags the registry does not know", func() {
+		_, err := resolver.Pin(ctx, host+"/missing:1.0")
+		Expect(err).To(MatchError(ContainSubstring(`failed to resolve digest of image "` + host + `/missing:1.0"`)))
+	})
+
+	It("rejects invalid image references", func() {
+		_, err := resolver.Pin(ctx, "Not A Valid Image")
+		Expect(err).To(MatchError(ContainSubstring("invalid image")))
+	})
+})
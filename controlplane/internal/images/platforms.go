@@ -0,0 +1,78 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// ArchitectureResolver looks up the CPU architectures an image is built for
+type ArchitectureResolver interface {
+	// Architectures returns the Kubernetes architectures of the image, e.g.
+	// amd64 and arm64 for a multi-architecture image
+	Architectures(ctx context.Context, image string) ([]string, error)
+}
+
+// Architectures implements ArchitectureResolver. The architectures of an
+// image index are listed from its manifests, the one of a single image is
+// read from its config.
+func (r *RegistryResolver) Architectures(ctx context.Context, image string) ([]string, error) {
+	ref, err := r.parseReference(image)
+	if err != nil {
+		return nil, err
+	}
+
+	desc, err := remote.Get(ref, remote.WithContext(ctx), remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manifest of image %q: %w", image, err)
+	}
+
+	if desc.MediaType.IsIndex() {
+		index, err := desc.ImageIndex()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read index of image %q: %w", image, err)
+		}
+		manifest, err := index.IndexManifest()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read index of image %q: %w", image, err)
+		}
+		var architectures []string
+		for _, m := range manifest.Manifests {
+			// Attestations are listed with an unknown platform
+			if m.Platform == nil || m.Platform.Architecture == "unknown" || slices.Contains(architectures, m.Platform.Architecture) {
+				continue
+			}
+			architectures = append(architectures, m.Platform.Architecture)
+		}
+		return architectures, nil
+	}
+
+	img, err := desc.Image()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image %q: %w", image, err)
+	}
+	config, err := img.ConfigFile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config of image %q: %w", image, err)
+	}
+	return []string{config.Architecture}, nil
+}
+
+// parseReference parses an image reference, for insecure registries over
+// plain HTTP
+func (r *RegistryResolver) parseReference(image string) (name.Reference, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image %q: %w", image, err)
+	}
+	if slices.Contains(r.insecureRegistries, ref.Context().RegistryStr()) {
+		if ref, err = name.ParseReference(image, name.Insecure); err != nil {
+			return nil, fmt.Errorf("invalid image %q: %w", image, err)
+		}
+	}
+	return ref, nil
+}
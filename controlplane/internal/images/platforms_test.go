@@ -0,0 +1,78 @@
+package images_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/images"
+)
+
+var _ = Describe("Architectures", func() {
+	var (
+		ctx      context.Context
+		host     string
+		resolver *images.RegistryResolver
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		server := httptest.NewServer(registry.New())
+		DeferCleanup(server.Close)
+		host = strings.TrimPrefix(server.URL, "http://")
+		resolver = images.NewRegistryResolver([]string{host})
+	})
+
+	imageFor := func(architecture string) v1.Image {
+		img, err := random.Image(64, 1)
+		Expect(err).NotTo(HaveOccurred())
+		config, err := img.ConfigFile()
+		Expect(err).NotTo(HaveOccurred())
+		config.Architecture = architecture
+		img, err = mutate.ConfigFile(img, config)
+		Expect(err).NotTo(HaveOccurred())
+		return img
+	}
+
+	reference := func(image string) name.Reference {
+		ref, err := name.ParseReference(image, name.Insecure)
+		Expect(err).NotTo(HaveOccurred())
+		return ref
+	}
+
+	It("lists the architectures of a multi-architecture image", func() {
+		index := mutate.AppendManifests(empty.Index,
+			mutate.IndexAddendum{Add: imageFor("amd64"), Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "amd64"}}},
+			mutate.IndexAddendum{Add: imageFor("arm64"), Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "arm64"}}},
+			mutate.IndexAddendum{Add: imageFor("unknown"), Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "unknown", Architecture: "unknown"}}},
+		)
+		Expect(remote.WriteIndex(reference(host+"/web:1.0"), index)).To(Succeed())
+
+		architectures, err := resolver.Architectures(ctx, host+"/web:1.0")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(architectures).To(Equal([]string{"amd64", "arm64"}))
+	})
+
+	It("reads the architecture of a single image from its config", func() {
+		Expect(remote.Write(reference(host+"/worker:1.0"), imageFor("arm64"))).To(Succeed())
+
+		architectures, err := resolver.Architectures(ctx, host+"/worker:1.0")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(architectures).To(Equal([]string{"arm64"}))
+	})
+
+	It("fails for images the registry does not know", func() {
+		_, err := resolver.Architectures(ctx, host+"/missing:1.0")
+		Expect(err).To(MatchError(ContainSubstring(`failed to get manifest of image "` + host + `/missing:1.0"`)))
+	})
+})
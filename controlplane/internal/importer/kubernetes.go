@@ -0,0 +1,425 @@
+// Package importer creates ECS resources in KECS from existing Kubernetes
+// workloads, the reverse of the export package.
+package importer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/secretsmanager"
+	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/ssm"
+)
+
+// ImportedFromTag is the tag recording the Deployment a service was imported from
+const ImportedFromTag = "kecs.dev/imported-from"
+
+// ECSClient is the part of the ECS API the importer creates resources with
+type ECSClient interface {
+	RegisterTaskDefinition(ctx context.Context, req *generated.RegisterTaskDefinitionRequest) (*generated.RegisterTaskDefinitionResponse, error)
+	CreateService(ctx context.Context, req *generated.CreateServiceRequest) (*generated.CreateServiceResponse, error)
+}
+
+// ServiceImport is the ECS task definition and service generated for a Deployment
+type ServiceImport struct {
+	Deployment     string                                   `json:"deployment"`
+	TaskDefinition *generated.RegisterTaskDefinitionRequest `json:"taskDefinition"`
+	Service        *generated.CreateServiceRequest          `json:"service"`
+	// Warnings lists the parts of the Deployment that have no ECS equivalent
+	Warnings []string `json:"warnings,omitempty"`
+
+	TaskDefinitionArn string `json:"taskDefinitionArn,omitempty"`
+	ServiceArn        string `json:"serviceArn,omitempty"`
+	Error             string `json:"error,omitempty"`
+}
+
+// Importer converts the Deployments of a namespace into ECS services
+type Importer struct {
+	kubeClient kubernetes.Interface
+	ecs        ECSClient
+	region     string
+	accountID  string
+}
+
+// NewImporter creates an importer that reads workloads with kubeClient and
+// creates ECS resources through ecs
+func NewImporter(kubeClient kubernetes.Interface, ecs ECSClient, region, accountID string) *Importer {
+	return &Importer{
+		kubeClient: kubeClient,
+		ecs:        ecs,
+		region:     region,
+		accountID:  accountID,
+	}
+}
+
+// Plan returns the task definitions and services the Deployments of a
+// namespace map to, sorted by Deployment name. Deployments KECS manages
+// itself are skipped.
+func (i *Importer) Plan(ctx context.Context, namespace, cluster string) ([]*ServiceImport, error) {
+	deployments, err := i.kubeClient.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments in namespace %s: %w", namespace, err)
+	}
+	services, err := i.kubeClient.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services in namespace %s: %w", namespace, err)
+	}
+
+	sort.Slice(deployments.Items, func(a, b int) bool {
+		return deployments.Items[a].Name < deployments.Items[b].Name
+	})
+
+	var imports []*ServiceImport
+	for idx := range deployments.Items {
+		deployment := &deployments.Items[idx]
+		if deployment.Labels["kecs.dev/managed-by"] == "kecs" {
+			continue
+		}
+		imports = append(imports, i.convertDeployment(ctx, deployment, services.Items, cluster))
+	}
+	return imports, nil
+}
+
+// Import registers the planned task definitions and creates the services.
+// A failure is recorded on the import it belongs to and the remaining
+// Deployments are still imported.
+func (i *Importer) Import(ctx context.Context, namespace, cluster string) ([]*ServiceImport, error) {
+	imports, err := i.Plan(ctx, namespace, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, imp := range imports {
+		taskDef, err := i.ecs.RegisterTaskDefinition(ctx, imp.TaskDefinition)
+		if err != nil {
+			imp.Error = fmt.Sprintf("failed to register task definition: %v", err)
+			continue
+		}
+		if taskDef.TaskDefinition != nil {
+			imp.TaskDefinitionArn = ptr.ToString(taskDef.TaskDefinition.TaskDefinitionArn)
+		}
+		if imp.TaskDefinitionArn != "" {
+			imp.Service.TaskDefinition = ptr.String(imp.TaskDefinitionArn)
+		}
+
+		service, err := i.ecs.CreateService(ctx, imp.Service)
+		if err != nil {
+			imp.Error = fmt.Sprintf("failed to create service: %v", err)
+			continue
+		}
+		if service.Service != nil {
+			imp.ServiceArn = ptr.ToString(service.Service.ServiceArn)
+		}
+	}
+	return imports, nil
+}
+
+// convertDeployment maps a Deployment and the Kubernetes Services selecting
+// its pods to an ECS task definition and service
+func (i *Importer) convertDeployment(ctx context.Context, deployment *appsv1.Deployment, services []corev1.Service, cluster string) *ServiceImport {
+	imp := &ServiceImport{Deployment: deployment.Name}
+	podSpec := &deployment.Spec.Template.Spec
+
+	networkMode := generated.NetworkModeAWSVPC
+	taskDef := &generated.RegisterTaskDefinitionRequest{
+		Family:      deployment.Name,
+		NetworkMode: &networkMode,
+	}
+
+	var taskCPU, taskMemory int32
+	for _, container := range podSpec.Containers {
+		def := i.convertContainer(ctx, deployment.Namespace, &container, imp)
+		if def.Cpu != nil {
+			taskCPU += *def.Cpu
+		}
+		if def.Memory != nil {
+			taskMemory += *def.Memory
+		}
+		taskDef.ContainerDefinitions = append(taskDef.ContainerDefinitions, def)
+	}
+	if taskCPU > 0 {
+		taskDef.Cpu = ptr.String(strconv.Itoa(int(taskCPU)))
+	}
+	if taskMemory > 0 {
+		taskDef.Memory = ptr.String(strconv.Itoa(int(taskMemory)))
+	}
+
+	if len(podSpec.InitContainers) > 0 {
+		imp.warn("init containers are not imported")
+	}
+	if len(podSpec.Volumes) > 0 {
+		imp.warn("volumes are not imported, add them to the task definition")
+	}
+
+	desiredCount := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desiredCount = *deployment.Spec.Replicas
+	}
+	imp.TaskDefinition = taskDef
+	imp.Service = &generated.CreateServiceRequest{
+		Cluster:        ptr.String(cluster),
+		ServiceName:    deployment.Name,
+		TaskDefinition: ptr.String(deployment.Name),
+		DesiredCount:   ptr.Int32(desiredCount),
+		Tags: []generated.Tag{{
+			Key:   ptr.String(ImportedFromTag),
+			Value: ptr.String(deployment.Namespace + "/" + deployment.Name),
+		}},
+	}
+
+	// KECS only creates a Kubernetes Service for services with a load
+	// balancer or a public IP, so exposed Deployments get a public IP
+	podLabels := labels.Set(deployment.Spec.Template.Labels)
+	for _, service := range services {
+		if len(service.Spec.Selector) == 0 || !labels.SelectorFromSet(service.Spec.Selector).Matches(podLabels) {
+			continue
+		}
+		assignPublicIP := generated.AssignPublicIpENABLED
+		imp.Service.NetworkConfiguration = &generated.NetworkConfiguration{
+			AwsvpcConfiguration: &generated.AwsVpcConfiguration{
+				AssignPublicIp: &assignPublicIP,
+				Subnets:        []string{},
+			},
+		}
+		break
+	}
+
+	return imp
+}
+
+// convertContainer maps a container to an ECS container definition
+func (i *Importer) convertContainer(ctx context.Context, namespace string, container *corev1.Container, imp *ServiceImport) generated.ContainerDefinition {
+	def := generated.ContainerDefinition{
+		Name:       ptr.String(container.Name),
+		Image:      ptr.String(container.Image),
+		Essential:  ptr.Bool(true),
+		EntryPoint: container.Command,
+		Command:    container.Args,
+	}
+	if container.WorkingDir != "" {
+		def.WorkingDirectory = ptr.String(container.WorkingDir)
+	}
+
+	for _, port := range container.Ports {
+		protocol := generated.TransportProtocolTCP
+		if port.Protocol == corev1.ProtocolUDP {
+			protocol = generated.TransportProtocolUDP
+		}
+		mapping := generated.PortMapping{
+			ContainerPort: ptr.Int32(port.ContainerPort),
+			Protocol:      &protocol,
+		}
+		if port.Name != "" {
+			mapping.Name = ptr.String(port.Name)
+		}
+		def.PortMappings = append(def.PortMappings, mapping)
+	}
+
+	if cpu := resourceQuantity(container.Resources, corev1.ResourceCPU); cpu != nil {
+		// ECS counts 1024 CPU units per vCPU
+		def.Cpu = ptr.Int32(int32(cpu.MilliValue() * 1024 / 1000))
+	}
+	if memory, ok := container.Resources.Limits[corev1.ResourceMemory]; ok {
+		def.Memory = ptr.Int32(int32(memory.Value() / (1024 * 1024)))
+	}
+	if memory, ok := container.Resources.Requests[corev1.ResourceMemory]; ok {
+		def.MemoryReservation = ptr.Int32(int32(memory.Value() / (1024 * 1024)))
+	}
+
+	probe := container.LivenessProbe
+	if probe == nil {
+		probe = container.ReadinessProbe
+	}
+	if probe != nil {
+		if healthCheck, err := convertProbe(probe, container); err != nil {
+			imp.warn(fmt.Sprintf("container %s: %v", container.Name, err))
+		} else {
+			def.HealthCheck = healthCheck
+		}
+	}
+
+	i.convertEnvironment(ctx, namespace, container, &def, imp)
+	return def
+}
+
+// convertEnvironment maps plain variables and ConfigMap references to
+// environment variables, and Secrets synced from Secrets Manager or SSM
+// Parameter Store back to ECS secrets
+func (i *Importer) convertEnvironment(ctx context.Context, namespace string, container *corev1.Container, def *generated.ContainerDefinition, imp *ServiceImport) {
+	configMaps := make(map[string]*corev1.ConfigMap)
+	getConfigMap := func(name string) *corev1.ConfigMap {
+		if cm, ok := configMaps[name]; ok {
+			return cm
+		}
+		cm, err := i.kubeClient.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			imp.warn(fmt.Sprintf("container %s: failed to read configmap %s: %v", container.Name, name, err))
+			cm = nil
+		}
+		configMaps[name] = cm
+		return cm
+	}
+
+	for _, envFrom := range container.EnvFrom {
+		switch {
+		case envFrom.ConfigMapRef != nil:
+			cm := getConfigMap(envFrom.ConfigMapRef.Name)
+			if cm == nil {
+				continue
+			}
+			keys := make([]string, 0, len(cm.Data))
+			for key := range cm.Data {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				def.Environment = append(def.Environment, generated.KeyValuePair{
+					Name:  ptr.String(envFrom.Prefix + key),
+					Value: ptr.String(cm.Data[key]),
+				})
+			}
+		case envFrom.SecretRef != nil:
+			imp.warn(fmt.Sprintf("container %s: envFrom secret %s is not imported, reference its keys as secrets", container.Name, envFrom.SecretRef.Name))
+		}
+	}
+
+	for _, env := range container.Env {
+		switch {
+		case env.ValueFrom == nil:
+			def.Environment = append(def.Environment, generated.KeyValuePair{
+				Name:  ptr.String(env.Name),
+				Value: ptr.String(env.Value),
+			})
+		case env.ValueFrom.ConfigMapKeyRef != nil:
+			ref := env.ValueFrom.ConfigMapKeyRef
+			cm := getConfigMap(ref.Name)
+			if cm == nil {
+				continue
+			}
+			value, ok := cm.Data[ref.Key]
+			if !ok {
+				imp.warn(fmt.Sprintf("container %s: configmap %s has no key %s", container.Name, ref.Name, ref.Key))
+				continue
+			}
+			def.Environment = append(def.Environment, generated.KeyValuePair{
+				Name:  ptr.String(env.Name),
+				Value: ptr.String(value),
+			})
+		case env.ValueFrom.SecretKeyRef != nil:
+			valueFrom, err := i.secretValueFrom(ctx, namespace, env.ValueFrom.SecretKeyRef)
+			if err != nil {
+				imp.warn(fmt.Sprintf("container %s: variable %s: %v", container.Name, env.Name, err))
+				continue
+			}
+			def.Secrets = append(def.Secrets, generated.Secret{Name: env.Name, ValueFrom: valueFrom})
+		default:
+			imp.warn(fmt.Sprintf("container %s: variable %s uses a field reference, which ECS does not support", container.Name, env.Name))
+		}
+	}
+}
+
+// secretValueFrom returns the ARN of the Secrets Manager secret or SSM
+// parameter a Kubernetes Secret was synced from
+func (i *Importer) secretValueFrom(ctx context.Context, namespace string, ref *corev1.SecretKeySelector) (string, error) {
+	secret, err := i.kubeClient.CoreV1().Secrets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret %s: %w", ref.Name, err)
+	}
+
+	if name := secret.Annotations[secretsmanager.SecretAnnotations.SecretName]; name != "" {
+		arn := awsarn.Build("secretsmanager", i.region, i.accountID, fmt.Sprintf("secret:%s", name))
+		if ref.Key != "" && ref.Key != "value" {
+			arn += ":" + ref.Key + "::"
+		}
+		return arn, nil
+	}
+	if name := secret.Annotations[ssm.SecretAnnotations.ParameterName]; name != "" {
+		return awsarn.Build("ssm", i.region, i.accountID, fmt.Sprintf("parameter/%s", strings.TrimPrefix(name, "/"))), nil
+	}
+	return "", fmt.Errorf("secret %s is not synced from Secrets Manager or SSM Parameter Store, store it there and reference it by ARN", ref.Name)
+}
+
+// convertProbe maps a probe to an ECS container health check
+func convertProbe(probe *corev1.Probe, container *corev1.Container) (*generated.HealthCheck, error) {
+	healthCheck := &generated.HealthCheck{}
+	switch {
+	case probe.Exec != nil:
+		healthCheck.Command = append([]string{"CMD"}, probe.Exec.Command...)
+	case probe.HTTPGet != nil:
+		port, err := probePort(probe.HTTPGet.Port.String(), container)
+		if err != nil {
+			return nil, err
+		}
+		scheme := strings.ToLower(string(probe.HTTPGet.Scheme))
+		if scheme == "" {
+			scheme = "http"
+		}
+		path := probe.HTTPGet.Path
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		healthCheck.Command = []string{"CMD-SHELL", fmt.Sprintf("curl -f %s://localhost:%d%s || exit 1", scheme, port, path)}
+	case probe.TCPSocket != nil:
+		port, err := probePort(probe.TCPSocket.Port.String(), container)
+		if err != nil {
+			return nil, err
+		}
+		healthCheck.Command = []string{"CMD-SHELL", fmt.Sprintf("nc -z localhost %d || exit 1", port)}
+	default:
+		return nil, fmt.Errorf("probe type is not supported by ECS health checks")
+	}
+
+	if probe.PeriodSeconds > 0 {
+		healthCheck.Interval = ptr.Int32(probe.PeriodSeconds)
+	}
+	if probe.TimeoutSeconds > 0 {
+		healthCheck.Timeout = ptr.Int32(probe.TimeoutSeconds)
+	}
+	if probe.FailureThreshold > 0 {
+		healthCheck.Retries = ptr.Int32(probe.FailureThreshold)
+	}
+	if probe.InitialDelaySeconds > 0 {
+		healthCheck.StartPeriod = ptr.Int32(probe.InitialDelaySeconds)
+	}
+	return healthCheck, nil
+}
+
+// probePort resolves a numeric or named probe port
+func probePort(port string, container *corev1.Container) (int32, error) {
+	if n, err := strconv.Atoi(port); err == nil {
+		return int32(n), nil
+	}
+	for _, p := range container.Ports {
+		if p.Name == port {
+			return p.ContainerPort, nil
+		}
+	}
+	return 0, fmt.Errorf("probe port %s is not declared by the container", port)
+}
+
+// resourceQuantity returns the limit of a resource, or the request when no limit is set
+func resourceQuantity(resources corev1.ResourceRequirements, name corev1.ResourceName) *resource.Quantity {
+	if q, ok := resources.Limits[name]; ok {
+		return &q
+	}
+	if q, ok := resources.Requests[name]; ok {
+		return &q
+	}
+	return nil
+}
+
+func (imp *ServiceImport) warn(message string) {
+	imp.Warnings = append(imp.Warnings, message)
+}
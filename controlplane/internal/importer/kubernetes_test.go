@@ -0,0 +1,202 @@
+package importer
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+)
+
+// fakeECS records the requests of the importer
+type fakeECS struct {
+	taskDefinitions []*generated.RegisterTaskDefinitionRequest
+	services        []*generated.CreateServiceRequest
+	failService     string
+}
+
+func (f *fakeECS) RegisterTaskDefinition(ctx context.Context, req *generated.RegisterTaskDefinitionRequest) (*generated.RegisterTaskDefinitionResponse, error) {
+	f.taskDefinitions = append(f.taskDefinitions, req)
+	return &generated.RegisterTaskDefinitionResponse{
+		TaskDefinition: &generated.TaskDefinition{
+			TaskDefinitionArn: ptr.String("arn:aws:ecs:us-east-1:123456789012:task-definition/" + req.Family + ":1"),
+		},
+	}, nil
+}
+
+func (f *fakeECS) CreateService(ctx context.Context, req *generated.CreateServiceRequest) (*generated.CreateServiceResponse, error) {
+	if req.ServiceName == f.failService {
+		return nil, errors.New("service already exists")
+	}
+	f.services = append(f.services, req)
+	return &generated.CreateServiceResponse{
+		Service: &generated.Service{
+			ServiceArn: ptr.String("arn:aws:ecs:us-east-1:123456789012:service/default/" + req.ServiceName),
+		},
+	}, nil
+}
+
+var _ = Describe("Importer", func() {
+	var (
+		ctx      context.Context
+		ecs      *fakeECS
+		importer *Importer
+	)
+
+	deployment := func(name string, replicas int32, container corev1.Container) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "foo"},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": name}},
+					Spec:       corev1.PodSpec{Containers: []corev1.Container{container}},
+				},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		ecs = &fakeECS{}
+
+		web := deployment("web", 3, corev1.Container{
+			Name:  "web",
+			Image: "nginx:1.27",
+			Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 80}},
+			Env: []corev1.EnvVar{
+				{Name: "MODE", Value: "production"},
+				{Name: "LOG_LEVEL", ValueFrom: &corev1.EnvVarSource{
+					ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "web-config"},
+						Key:                  "logLevel",
+					},
+				}},
+				{Name: "DB_PASSWORD", ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "sm-db"},
+						Key:                  "password",
+					},
+				}},
+				{Name: "API_KEY", ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "plain"},
+						Key:                  "key",
+					},
+				}},
+			},
+			Resources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("500m"),
+					corev1.ResourceMemory: resource.MustParse("512Mi"),
+				},
+				Requests: corev1.ResourceList{
+					corev1.ResourceMemory: resource.MustParse("256Mi"),
+				},
+			},
+			LivenessProbe: &corev1.Probe{
+				ProbeHandler: corev1.ProbeHandler{
+					HTTPGet: &corev1.HTTPGetAction{Path: "/healthz", Port: intstr.FromString("http")},
+				},
+				InitialDelaySeconds: 10,
+				PeriodSeconds:       15,
+				TimeoutSeconds:      3,
+				FailureThreshold:    4,
+			},
+		})
+		worker := deployment("worker", 1, corev1.Container{
+			Name:          "worker",
+			Image:         "busybox",
+			Command:       []string{"sh", "-c"},
+			Args:          []string{"sleep 3600"},
+			LivenessProbe: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{Exec: &corev1.ExecAction{Command: []string{"true"}}}},
+		})
+		managed := deployment("managed", 1, corev1.Container{Name: "app", Image: "nginx"})
+		managed.Labels = map[string]string{"kecs.dev/managed-by": "kecs"}
+
+		kubeClient := fake.NewSimpleClientset(
+			web, worker, managed,
+			&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "foo"},
+				Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "web"}},
+			},
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "web-config", Namespace: "foo"},
+				Data:       map[string]string{"logLevel": "debug"},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "sm-db",
+					Namespace:   "foo",
+					Annotations: map[string]string{"kecs.io/secretsmanager-secret-name": "db"},
+				},
+			},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "plain", Namespace: "foo"}},
+		)
+		importer = NewImporter(kubeClient, ecs, "us-east-1", "123456789012")
+	})
+
+	It("should map Deployments to task definitions and services", func() {
+		imports, err := importer.Plan(ctx, "foo", "default")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(imports).To(HaveLen(2))
+		Expect(imports[0].Deployment).To(Equal("web"))
+		Expect(imports[1].Deployment).To(Equal("worker"))
+
+		web := imports[0]
+		Expect(web.Service.ServiceName).To(Equal("web"))
+		Expect(*web.Service.Cluster).To(Equal("default"))
+		Expect(*web.Service.DesiredCount).To(Equal(int32(3)))
+		Expect(*web.Service.NetworkConfiguration.AwsvpcConfiguration.AssignPublicIp).To(Equal(generated.AssignPublicIpENABLED))
+
+		Expect(web.TaskDefinition.Family).To(Equal("web"))
+		Expect(*web.TaskDefinition.Cpu).To(Equal("512"))
+		Expect(*web.TaskDefinition.Memory).To(Equal("512"))
+		container := web.TaskDefinition.ContainerDefinitions[0]
+		Expect(*container.Image).To(Equal("nginx:1.27"))
+		Expect(*container.MemoryReservation).To(Equal(int32(256)))
+		Expect(*container.PortMappings[0].ContainerPort).To(Equal(int32(80)))
+		Expect(container.Environment).To(ConsistOf(
+			generated.KeyValuePair{Name: ptr.String("MODE"), Value: ptr.String("production")},
+			generated.KeyValuePair{Name: ptr.String("LOG_LEVEL"), Value: ptr.String("debug")},
+		))
+		Expect(container.Secrets).To(ConsistOf(generated.Secret{
+			Name:      "DB_PASSWORD",
+			ValueFrom: "arn:aws:secretsmanager:us-east-1:123456789012:secret:db:password::",
+		}))
+		Expect(web.Warnings).To(ContainElement(ContainSubstring("secret plain is not synced")))
+
+		Expect(container.HealthCheck.Command).To(Equal([]string{"CMD-SHELL", "curl -f http://localhost:80/healthz || exit 1"}))
+		Expect(*container.HealthCheck.Interval).To(Equal(int32(15)))
+		Expect(*container.HealthCheck.Timeout).To(Equal(int32(3)))
+		Expect(*container.HealthCheck.Retries).To(Equal(int32(4)))
+		Expect(*container.HealthCheck.StartPeriod).To(Equal(int32(10)))
+
+		worker := imports[1].TaskDefinition.ContainerDefinitions[0]
+		Expect(worker.EntryPoint).To(Equal([]string{"sh", "-c"}))
+		Expect(worker.Command).To(Equal([]string{"sleep 3600"}))
+		Expect(worker.HealthCheck.Command).To(Equal([]string{"CMD", "true"}))
+		Expect(imports[1].Service.NetworkConfiguration).To(BeNil())
+	})
+
+	It("should create the services with the registered task definitions", func() {
+		ecs.failService = "worker"
+		imports, err := importer.Import(ctx, "foo", "default")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(ecs.taskDefinitions).To(HaveLen(2))
+		Expect(ecs.services).To(HaveLen(1))
+		Expect(*ecs.services[0].TaskDefinition).To(Equal("arn:aws:ecs:us-east-1:123456789012:task-definition/web:1"))
+		Expect(imports[0].ServiceArn).To(Equal("arn:aws:ecs:us-east-1:123456789012:service/default/web"))
+		Expect(imports[1].Error).To(ContainSubstring("service already exists"))
+	})
+})
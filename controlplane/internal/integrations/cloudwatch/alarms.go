@@ -0,0 +1,103 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// AlarmStateAlarm is the state of an alarm whose threshold is breached
+const AlarmStateAlarm = "ALARM"
+
+// AlarmClient interface for CloudWatch alarm and metric operations (for testing)
+type AlarmClient interface {
+	DescribeAlarmStates(ctx context.Context, alarmNames []string) (map[string]string, error)
+	PutMetricData(ctx context.Context, namespace string, data []MetricDatum) error
+}
+
+// cloudWatchAlarmsClient implements AlarmClient with the CloudWatch query API
+type cloudWatchAlarmsClient struct {
+	endpoint   string
+	httpClient *http.Client
+}
+
+// newCloudWatchAlarmsClient creates a new CloudWatch alarms client
+func newCloudWatchAlarmsClient(endpoint string) AlarmClient {
+	if endpoint == "" {
+		// Use cluster-internal LocalStack service endpoint
+		endpoint = "http://localstack.kecs-system.svc.cluster.local:4566"
+	}
+
+	return &cloudWatchAlarmsClient{
+		endpoint:   endpoint,
+		httpClient: &http.Client{},
+	}
+}
+
+// describeAlarmsResponse is the part of the DescribeAlarms response KECS reads
+type describeAlarmsResponse struct {
+	MetricAlarms    []alarmState `xml:"DescribeAlarmsResult>MetricAlarms>member"`
+	CompositeAlarms []alarmState `xml:"DescribeAlarmsResult>CompositeAlarms>member"`
+}
+
+type alarmState struct {
+	AlarmName  string `xml:"AlarmName"`
+	StateValue string `xml:"StateValue"`
+}
+
+// DescribeAlarmStates returns the state of the named metric and composite
+// alarms. Alarms that do not exist are left out.
+func (c *cloudWatchAlarmsClient) DescribeAlarmStates(ctx context.Context, alarmNames []string) (map[string]string, error) {
+	form := url.Values{}
+	form.Set("Action", "DescribeAlarms")
+	form.Set("Version", "2010-08-01")
+	form.Set("AlarmTypes.member.1", "MetricAlarm")
+	form.Set("AlarmTypes.member.2", "CompositeAlarm")
+	for i, name := range alarmNames {
+		form.Set("AlarmNames.member."+strconv.Itoa(i+1), name)
+	}
+
+	body, err := c.call(ctx, form)
+	if err != nil {
+		return nil, err
+	}
+
+	var result describeAlarmsResponse
+	if err := xml.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	states := make(map[string]string)
+	for _, alarm := range append(result.MetricAlarms, result.CompositeAlarms...) {
+		states[alarm.AlarmName] = alarm.StateValue
+	}
+	return states, nil
+}
+
+// call sends a request of the CloudWatch query API and returns the response body
+func (c *cloudWatchAlarmsClient) call(ctx context.Context, form url.Values) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", c.endpoint+"/", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
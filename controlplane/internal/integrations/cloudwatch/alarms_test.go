@@ -0,0 +1,99 @@
+package cloudwatch_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	kecsCloudWatch "github.com/nandemo-ya/kecs/controlplane/internal/integrations/cloudwatch"
+)
+
+var _ = Describe("Alarm states", func() {
+	It("reads the state of metric and composite alarms from DescribeAlarms", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.ParseForm()).To(Succeed())
+			Expect(r.Form.Get("Action")).To(Equal("DescribeAlarms"))
+			Expect(r.Form.Get("AlarmNames.member.1")).To(Equal("high-errors"))
+			Expect(r.Form.Get("AlarmNames.member.2")).To(Equal("service-health"))
+			w.Write([]byte(`<DescribeAlarmsResponse xmlns="http://monitoring.amazonaws.com/doc/2010-08-01/">
+  <DescribeAlarmsResult>
+    <MetricAlarms>
+      <member><AlarmName>high-errors</AlarmName><StateValue>ALARM</StateValue></member>
+    </MetricAlarms>
+    <CompositeAlarms>
+      <member><AlarmName>service-health</AlarmName><StateValue>OK</StateValue></member>
+    </CompositeAlarms>
+  </DescribeAlarmsResult>
+</DescribeAlarmsResponse>`))
+		}))
+		defer server.Close()
+
+		integration, err := kecsCloudWatch.NewIntegration(nil, nil, &kecsCloudWatch.Config{LocalStackEndpoint: server.URL})
+		Expect(err).NotTo(HaveOccurred())
+
+		states, err := integration.GetAlarmStates([]string{"high-errors", "service-health"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(states).To(Equal(map[string]string{
+			"high-errors":    kecsCloudWatch.AlarmStateAlarm,
+			"service-health": "OK",
+		}))
+	})
+
+	It("returns an error when DescribeAlarms fails", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		integration, err := kecsCloudWatch.NewIntegration(nil, nil, &kecsCloudWatch.Config{LocalStackEndpoint: server.URL})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = integration.GetAlarmStates([]string{"high-errors"})
+		Expect(err).To(MatchError(ContainSubstring("failed to describe alarms")))
+	})
+})
+
+var _ = Describe("Metric data", func() {
+	It("publishes data points with PutMetricData", func() {
+		var forms []map[string]string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.ParseForm()).To(Succeed())
+			form := make(map[string]string)
+			for key := range r.Form {
+				form[key] = r.Form.Get(key)
+			}
+			forms = append(forms, form)
+			w.Write([]byte(`<PutMetricDataResponse xmlns="http://monitoring.amazonaws.com/doc/2010-08-01/"></PutMetricDataResponse>`))
+		}))
+		defer server.Close()
+
+		integration, err := kecsCloudWatch.NewIntegration(nil, nil, &kecsCloudWatch.Config{LocalStackEndpoint: server.URL})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(integration.PutMetricData("AWS/ECS", []kecsCloudWatch.MetricDatum{{
+			MetricName: "CPUUtilization",
+			Dimensions: map[string]string{"ServiceName": "web", "ClusterName": "default"},
+			Value:      42.5,
+			Unit:       "Percent",
+			Timestamp:  time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		}})).To(Succeed())
+
+		Expect(forms).To(HaveLen(1))
+		Expect(forms[0]).To(Equal(map[string]string{
+			"Action":                         "PutMetricData",
+			"Version":                        "2010-08-01",
+			"Namespace":                      "AWS/ECS",
+			"MetricData.member.1.MetricName": "CPUUtilization",
+			"MetricData.member.1.Value":      "42.5",
+			"MetricData.member.1.Unit":       "Percent",
+			"MetricData.member.1.Timestamp":  "2025-01-02T03:04:05Z",
+			"MetricData.member.1.Dimensions.member.1.Name":  "ClusterName",
+			"MetricData.member.1.Dimensions.member.1.Value": "default",
+			"MetricData.member.1.Dimensions.member.2.Name":  "ServiceName",
+			"MetricData.member.1.Dimensions.member.2.Value": "web",
+		}))
+	})
+})
@@ -174,3 +174,44 @@ func (c *cloudWatchLogsClient) PutRetentionPolicy(ctx context.Context, params *c
 
 	return &cloudwatchlogsapi.Unit{}, nil
 }
+
+// PutLogEvents uploads log events to a log stream
+func (c *cloudWatchLogsClient) PutLogEvents(ctx context.Context, params *cloudwatchlogsapi.PutLogEventsRequest) (*cloudwatchlogsapi.PutLogEventsResponse, error) {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "Logs_20140328.PutLogEvents")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if strings.Contains(string(respBody), "ResourceNotFoundException") {
+			return nil, fmt.Errorf("ResourceNotFoundException: log group or stream not found")
+		}
+		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result cloudwatchlogsapi.PutLogEventsResponse
+	if len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, &result); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return &result, nil
+}
@@ -8,6 +8,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 
 	cloudwatchlogsapi "github.com/nandemo-ya/kecs/controlplane/internal/cloudwatchlogs/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
 	"github.com/nandemo-ya/kecs/controlplane/internal/localstack"
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
 )
@@ -15,6 +16,7 @@ import (
 // integration implements the CloudWatch Integration interface
 type integration struct {
 	logsClient        CloudWatchLogsClient
+	alarmsClient      AlarmClient
 	kubeClient        kubernetes.Interface
 	localstackManager localstack.Manager
 	config            *Config
@@ -41,6 +43,7 @@ func NewIntegration(kubeClient kubernetes.Interface, localstackManager localstac
 
 	return &integration{
 		logsClient:        logsClient,
+		alarmsClient:      newCloudWatchAlarmsClient(endpoint),
 		kubeClient:        kubeClient,
 		localstackManager: localstackManager,
 		config:            config,
@@ -59,6 +62,7 @@ func NewIntegrationWithClient(kubeClient kubernetes.Interface, localstackManager
 
 	return &integration{
 		logsClient:        logsClient,
+		alarmsClient:      newCloudWatchAlarmsClient(config.LocalStackEndpoint),
 		kubeClient:        kubeClient,
 		localstackManager: localstackManager,
 		config:            config,
@@ -208,3 +212,63 @@ func (i *integration) ConfigureContainerLogging(taskArn string, containerName st
 		Options:       options,
 	}, nil
 }
+
+// WriteLogEvents writes messages to a log stream, creating the log group and
+// stream as needed
+func (i *integration) WriteLogEvents(groupName, streamName string, messages []string) error {
+	ctx := context.Background()
+
+	_, err := i.logsClient.CreateLogGroup(ctx, &cloudwatchlogsapi.CreateLogGroupRequest{
+		LogGroupName: groupName,
+	})
+	if err != nil && !strings.Contains(err.Error(), "ResourceAlreadyExistsException") {
+		return fmt.Errorf("failed to create log group: %w", err)
+	}
+	_, err = i.logsClient.CreateLogStream(ctx, &cloudwatchlogsapi.CreateLogStreamRequest{
+		LogGroupName:  groupName,
+		LogStreamName: streamName,
+	})
+	if err != nil && !strings.Contains(err.Error(), "ResourceAlreadyExistsException") {
+		return fmt.Errorf("failed to create log stream: %w", err)
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+
+	timestamp := deterministic.Now().UnixMilli()
+	events := make([]cloudwatchlogsapi.InputLogEvent, 0, len(messages))
+	for _, message := range messages {
+		events = append(events, cloudwatchlogsapi.InputLogEvent{
+			Message:   message,
+			Timestamp: timestamp,
+		})
+	}
+	if _, err := i.logsClient.PutLogEvents(ctx, &cloudwatchlogsapi.PutLogEventsRequest{
+		LogGroupName:  groupName,
+		LogStreamName: streamName,
+		LogEvents:     events,
+	}); err != nil {
+		return fmt.Errorf("failed to put log events: %w", err)
+	}
+	return nil
+}
+
+// GetAlarmStates returns the state of the named CloudWatch alarms
+func (i *integration) GetAlarmStates(alarmNames []string) (map[string]string, error) {
+	if len(alarmNames) == 0 {
+		return map[string]string{}, nil
+	}
+	states, err := i.alarmsClient.DescribeAlarmStates(context.Background(), alarmNames)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe alarms: %w", err)
+	}
+	return states, nil
+}
+
+// PutMetricData publishes data points to a CloudWatch metric namespace
+func (i *integration) PutMetricData(namespace string, data []MetricDatum) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return i.alarmsClient.PutMetricData(context.Background(), namespace, data)
+}
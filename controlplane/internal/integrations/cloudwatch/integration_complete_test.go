@@ -123,6 +123,7 @@ type mockCloudWatchLogsTestClient struct {
 	createLogGroupCalls     []*cloudwatchlogsapi.CreateLogGroupRequest
 	createLogStreamCalls    []*cloudwatchlogsapi.CreateLogStreamRequest
 	putRetentionPolicyCalls []*cloudwatchlogsapi.PutRetentionPolicyRequest
+	putLogEventsCalls       []*cloudwatchlogsapi.PutLogEventsRequest
 }
 
 func (m *mockCloudWatchLogsTestClient) CreateLogGroup(ctx context.Context, params *cloudwatchlogsapi.CreateLogGroupRequest) (*cloudwatchlogsapi.Unit, error) {
@@ -143,3 +144,8 @@ func (m *mockCloudWatchLogsTestClient) PutRetentionPolicy(ctx context.Context, p
 	m.putRetentionPolicyCalls = append(m.putRetentionPolicyCalls, params)
 	return &cloudwatchlogsapi.Unit{}, nil
 }
+
+func (m *mockCloudWatchLogsTestClient) PutLogEvents(ctx context.Context, params *cloudwatchlogsapi.PutLogEventsRequest) (*cloudwatchlogsapi.PutLogEventsResponse, error) {
+	m.putLogEventsCalls = append(m.putLogEventsCalls, params)
+	return &cloudwatchlogsapi.PutLogEventsResponse{}, nil
+}
@@ -71,6 +71,13 @@ func (m *mockCloudWatchLogsClient) PutRetentionPolicy(ctx context.Context, param
 	return &cloudwatchlogsapi.Unit{}, nil
 }
 
+func (m *mockCloudWatchLogsClient) PutLogEvents(ctx context.Context, params *cloudwatchlogsapi.PutLogEventsRequest) (*cloudwatchlogsapi.PutLogEventsResponse, error) {
+	if !m.logStreams[params.LogGroupName][params.LogStreamName] {
+		return nil, fmt.Errorf("ResourceNotFoundException: log stream not found")
+	}
+	return &cloudwatchlogsapi.PutLogEventsResponse{}, nil
+}
+
 // mockLocalStackManager is a mock implementation of localstack.Manager
 type mockLocalStackManager struct{}
 
@@ -0,0 +1,63 @@
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// maxMetricDataPerRequest is the number of data points PutMetricData accepts at once
+const maxMetricDataPerRequest = 1000
+
+// MetricDatum is a data point of a CloudWatch metric
+type MetricDatum struct {
+	MetricName string
+	Dimensions map[string]string
+	Value      float64
+	// Unit is a CloudWatch unit such as Percent or Megabytes, None when empty
+	Unit      string
+	Timestamp time.Time
+}
+
+// PutMetricData publishes data points to a metric namespace, in batches of
+// the size PutMetricData accepts
+func (c *cloudWatchAlarmsClient) PutMetricData(ctx context.Context, namespace string, data []MetricDatum) error {
+	for start := 0; start < len(data); start += maxMetricDataPerRequest {
+		end := min(start+maxMetricDataPerRequest, len(data))
+
+		form := url.Values{}
+		form.Set("Action", "PutMetricData")
+		form.Set("Version", "2010-08-01")
+		form.Set("Namespace", namespace)
+		for i, datum := range data[start:end] {
+			prefix := "MetricData.member." + strconv.Itoa(i+1) + "."
+			form.Set(prefix+"MetricName", datum.MetricName)
+			form.Set(prefix+"Value", strconv.FormatFloat(datum.Value, 'f', -1, 64))
+			if datum.Unit != "" {
+				form.Set(prefix+"Unit", datum.Unit)
+			}
+			if !datum.Timestamp.IsZero() {
+				form.Set(prefix+"Timestamp", datum.Timestamp.UTC().Format(time.RFC3339))
+			}
+
+			names := make([]string, 0, len(datum.Dimensions))
+			for name := range datum.Dimensions {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for j, name := range names {
+				dimension := prefix + "Dimensions.member." + strconv.Itoa(j+1) + "."
+				form.Set(dimension+"Name", name)
+				form.Set(dimension+"Value", datum.Dimensions[name])
+			}
+		}
+
+		if _, err := c.call(ctx, form); err != nil {
+			return fmt.Errorf("failed to put metric data: %w", err)
+		}
+	}
+	return nil
+}
@@ -8,6 +8,10 @@ type MockIntegration struct {
 	LogStreamCreated      string
 	CreateLogGroupError   error
 	CreateLogStreamError  error
+	WrittenLogEvents      map[string][]string
+	AlarmStates           map[string]string
+	GetAlarmStatesError   error
+	MetricData            map[string][]MetricDatum
 }
 
 // CreateLogGroup mock implementation
@@ -46,3 +50,36 @@ func (m *MockIntegration) ConfigureContainerLogging(taskArn string, containerNam
 		Options:   options,
 	}, nil
 }
+
+// WriteLogEvents mock implementation
+func (m *MockIntegration) WriteLogEvents(groupName, streamName string, messages []string) error {
+	if m.WrittenLogEvents == nil {
+		m.WrittenLogEvents = make(map[string][]string)
+	}
+	key := groupName + "/" + streamName
+	m.WrittenLogEvents[key] = append(m.WrittenLogEvents[key], messages...)
+	return nil
+}
+
+// GetAlarmStates mock implementation
+func (m *MockIntegration) GetAlarmStates(alarmNames []string) (map[string]string, error) {
+	if m.GetAlarmStatesError != nil {
+		return nil, m.GetAlarmStatesError
+	}
+	states := make(map[string]string)
+	for _, name := range alarmNames {
+		if state, ok := m.AlarmStates[name]; ok {
+			states[name] = state
+		}
+	}
+	return states, nil
+}
+
+// PutMetricData mock implementation
+func (m *MockIntegration) PutMetricData(namespace string, data []MetricDatum) error {
+	if m.MetricData == nil {
+		m.MetricData = make(map[string][]MetricDatum)
+	}
+	m.MetricData[namespace] = append(m.MetricData[namespace], data...)
+	return nil
+}
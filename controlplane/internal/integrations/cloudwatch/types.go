@@ -25,6 +25,18 @@ type Integration interface {
 
 	// ConfigureContainerLogging configures logging for a container in pod spec
 	ConfigureContainerLogging(taskArn string, containerName string, logDriver string, options map[string]string) (*LogConfiguration, error)
+
+	// WriteLogEvents writes messages to a log stream, creating the log group
+	// and stream as needed. Names are used as given, without the group prefix.
+	WriteLogEvents(groupName, streamName string, messages []string) error
+
+	// GetAlarmStates returns the state of the named CloudWatch alarms, such
+	// as OK or ALARM. Alarms that do not exist are left out.
+	GetAlarmStates(alarmNames []string) (map[string]string, error)
+
+	// PutMetricData publishes data points to a CloudWatch metric namespace,
+	// such as the Container Insights metrics of services
+	PutMetricData(namespace string, data []MetricDatum) error
 }
 
 // LogConfiguration represents CloudWatch logging configuration for a container
@@ -60,4 +72,5 @@ type CloudWatchLogsClient interface {
 	DeleteLogGroup(ctx context.Context, params *cloudwatchlogsapi.DeleteLogGroupRequest) (*cloudwatchlogsapi.Unit, error)
 	CreateLogStream(ctx context.Context, params *cloudwatchlogsapi.CreateLogStreamRequest) (*cloudwatchlogsapi.Unit, error)
 	PutRetentionPolicy(ctx context.Context, params *cloudwatchlogsapi.PutRetentionPolicyRequest) (*cloudwatchlogsapi.Unit, error)
+	PutLogEvents(ctx context.Context, params *cloudwatchlogsapi.PutLogEventsRequest) (*cloudwatchlogsapi.PutLogEventsResponse, error)
 }
@@ -0,0 +1,401 @@
+package elbv2
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// Load balancer attributes controlling access logs
+const (
+	AttributeAccessLogsEnabled = "access_logs.s3.enabled"
+	AttributeAccessLogsBucket  = "access_logs.s3.bucket"
+	AttributeAccessLogsPrefix  = "access_logs.s3.prefix"
+)
+
+const (
+	// DefaultAccessLogFlushInterval is how often buffered access logs are uploaded.
+	// ALB publishes every 5 minutes, which is too slow for local pipeline testing.
+	DefaultAccessLogFlushInterval = 30 * time.Second
+	// maxAccessLogBatchLines uploads a batch early once it reaches this many lines
+	maxAccessLogBatchLines = 10000
+)
+
+// AccessLogConfig is the access log configuration of a load balancer
+type AccessLogConfig struct {
+	Enabled bool
+	Bucket  string
+	Prefix  string
+}
+
+// AccessLogConfigFromAttributes extracts the access log configuration from load balancer attributes
+func AccessLogConfigFromAttributes(attributes map[string]string) AccessLogConfig {
+	return AccessLogConfig{
+		Enabled: strings.EqualFold(attributes[AttributeAccessLogsEnabled], "true"),
+		Bucket:  attributes[AttributeAccessLogsBucket],
+		Prefix:  attributes[AttributeAccessLogsPrefix],
+	}
+}
+
+// Validate checks the configuration against the constraints enforced by ELBv2
+func (c AccessLogConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Bucket == "" {
+		return fmt.Errorf("the S3 bucket must be specified when access logs are enabled")
+	}
+	if strings.Contains(c.Prefix, "AWSLogs") {
+		return fmt.Errorf("the access log prefix cannot include 'AWSLogs'")
+	}
+	if strings.HasPrefix(c.Prefix, "/") || strings.HasSuffix(c.Prefix, "/") {
+		return fmt.Errorf("the access log prefix cannot start or end with '/'")
+	}
+	return nil
+}
+
+// AccessLogUploader uploads access log objects to S3
+type AccessLogUploader interface {
+	UploadFile(ctx context.Context, bucket, key string, reader io.Reader) error
+}
+
+// AccessLogConfigurable is an optional interface that integrations can implement
+// to publish load balancer access logs
+type AccessLogConfigurable interface {
+	// ConfigureAccessLogs enables or disables access logs for a load balancer
+	ConfigureAccessLogs(ctx context.Context, loadBalancerArn, dnsName string, config AccessLogConfig) error
+}
+
+// TraefikAccessLogEntry is a Traefik access log line in JSON format
+type TraefikAccessLogEntry struct {
+	StartUTC              time.Time `json:"StartUTC"`
+	Duration              int64     `json:"Duration"`
+	OriginDuration        int64     `json:"OriginDuration"`
+	ClientHost            string    `json:"ClientHost"`
+	ClientPort            string    `json:"ClientPort"`
+	RequestHost           string    `json:"RequestHost"`
+	RequestPort           string    `json:"RequestPort"`
+	RequestMethod         string    `json:"RequestMethod"`
+	RequestPath           string    `json:"RequestPath"`
+	RequestProtocol       string    `json:"RequestProtocol"`
+	RequestScheme         string    `json:"RequestScheme"`
+	RequestContentSize    int64     `json:"RequestContentSize"`
+	DownstreamStatus      int       `json:"DownstreamStatus"`
+	DownstreamContentSize int64     `json:"DownstreamContentSize"`
+	OriginStatus          int       `json:"OriginStatus"`
+	ServiceAddr           string    `json:"ServiceAddr"`
+	ServiceName           string    `json:"ServiceName"`
+	TLSCipher             string    `json:"TLSCipher"`
+	TLSVersion            string    `json:"TLSVersion"`
+	UserAgent             string    `json:"request_User-Agent"`
+}
+
+// ParseTraefikAccessLog parses a Traefik JSON access log line. Lines that are
+// not access log entries, such as Traefik's own log output, return false.
+func ParseTraefikAccessLog(line []byte) (*TraefikAccessLogEntry, bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 || line[0] != '{' {
+		return nil, false
+	}
+	var entry TraefikAccessLogEntry
+	if err := json.Unmarshal(line, &entry); err != nil || entry.RequestHost == "" || entry.StartUTC.IsZero() {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// FormatAccessLogEntry formats an entry as an Application Load Balancer access log line
+func FormatAccessLogEntry(entry *TraefikAccessLogEntry, loadBalancerArn, targetGroupArn string) string {
+	scheme := entry.RequestScheme
+	if scheme == "" {
+		scheme = "http"
+	}
+	logType := scheme
+	if scheme == "https" {
+		logType = "h2"
+		if entry.RequestProtocol != "HTTP/2.0" {
+			logType = "https"
+		}
+	}
+
+	host := entry.RequestHost
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	port := entry.RequestPort
+	if _, err := strconv.Atoi(port); err != nil {
+		port = "80"
+		if scheme == "https" {
+			port = "443"
+		}
+	}
+
+	end := entry.StartUTC.Add(time.Duration(entry.Duration))
+	target := dashIfEmpty(entry.ServiceAddr)
+	targetStatus := "-"
+	targetProcessing := "-1"
+	if entry.OriginStatus > 0 {
+		targetStatus = strconv.Itoa(entry.OriginStatus)
+		targetProcessing = formatSeconds(entry.OriginDuration)
+	}
+	requestProcessing := formatSeconds(entry.Duration - entry.OriginDuration)
+
+	fields := []string{
+		logType,
+		end.UTC().Format("2006-01-02T15:04:05.000000Z"),
+		accessLogLoadBalancerID(loadBalancerArn),
+		net.JoinHostPort(entry.ClientHost, dashIfEmpty(entry.ClientPort)),
+		target,
+		requestProcessing,
+		targetProcessing,
+		"0.000",
+		strconv.Itoa(entry.DownstreamStatus),
+		targetStatus,
+		strconv.FormatInt(entry.RequestContentSize, 10),
+		strconv.FormatInt(entry.DownstreamContentSize, 10),
+		quote(fmt.Sprintf("%s %s://%s:%s%s %s", entry.RequestMethod, scheme, host, port, entry.RequestPath, entry.RequestProtocol)),
+		quote(dashIfEmpty(entry.UserAgent)),
+		dashIfEmpty(entry.TLSCipher),
+		dashIfEmpty(entry.TLSVersion),
+		dashIfEmpty(targetGroupArn),
+		quote(fmt.Sprintf("Root=1-%08x-%s", entry.StartUTC.Unix(), deterministic.Suffix(24))),
+		quote(dashIfEmpty(host)),
+		quote("-"),
+		"-",
+		entry.StartUTC.UTC().Format("2006-01-02T15:04:05.000000Z"),
+		quote("forward"),
+		quote("-"),
+		quote("-"),
+		quote(target),
+		quote(targetStatus),
+		quote("-"),
+		quote("-"),
+	}
+	return strings.Join(fields, " ")
+}
+
+// AccessLogObjectKey returns the S3 key ALB uses for a log file delivered at the given time
+func AccessLogObjectKey(prefix, accountID, region, loadBalancerArn string, t time.Time, ip string) string {
+	t = t.UTC()
+	lbID := strings.ReplaceAll(accessLogLoadBalancerID(loadBalancerArn), "/", ".")
+	file := fmt.Sprintf("%s_elasticloadbalancing_%s_%s_%s_%s_%s.log.gz",
+		accountID, region, lbID, t.Format("20060102T1504Z"), ip, deterministic.Suffix(8))
+	return path.Join(prefix, "AWSLogs", accountID, "elasticloadbalancing", region,
+		t.Format("2006"), t.Format("01"), t.Format("02"), file)
+}
+
+// accessLogLoadBalancerID returns the app/<name>/<id> part of a load balancer ARN
+func accessLogLoadBalancerID(loadBalancerArn string) string {
+	if idx := strings.Index(loadBalancerArn, ":loadbalancer/"); idx >= 0 {
+		return loadBalancerArn[idx+len(":loadbalancer/"):]
+	}
+	return loadBalancerArn
+}
+
+func formatSeconds(nanos int64) string {
+	if nanos < 0 {
+		nanos = 0
+	}
+	return strconv.FormatFloat(time.Duration(nanos).Seconds(), 'f', 3, 64)
+}
+
+func dashIfEmpty(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func quote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// accessLogTarget buffers access log lines of a single load balancer
+type accessLogTarget struct {
+	loadBalancerArn string
+	config          AccessLogConfig
+	lines           []string
+}
+
+// AccessLogShipper batches access log lines per load balancer and uploads them
+// gzipped to the configured S3 bucket using the ALB object layout
+type AccessLogShipper struct {
+	uploader  AccessLogUploader
+	accountID string
+	region    string
+	ip        string
+
+	// TargetGroupResolver maps a Traefik service name to a target group ARN
+	TargetGroupResolver func(serviceName string) string
+
+	mu      sync.Mutex
+	targets map[string]*accessLogTarget // lower-case DNS name -> target
+}
+
+// NewAccessLogShipper creates a new access log shipper
+func NewAccessLogShipper(uploader AccessLogUploader, accountID, region string) *AccessLogShipper {
+	return &AccessLogShipper{
+		uploader:  uploader,
+		accountID: accountID,
+		region:    region,
+		ip:        "127.0.0.1",
+		targets:   make(map[string]*accessLogTarget),
+	}
+}
+
+// Configure enables or disables access logs for the load balancer reachable on dnsName.
+// Lines buffered for a load balancer that is disabled are uploaded first.
+func (s *AccessLogShipper) Configure(ctx context.Context, loadBalancerArn, dnsName string, config AccessLogConfig) {
+	key := strings.ToLower(dnsName)
+
+	s.mu.Lock()
+	previous := s.targets[key]
+	if config.Enabled {
+		target := &accessLogTarget{loadBalancerArn: loadBalancerArn, config: config}
+		if previous != nil && previous.config == config {
+			target.lines = previous.lines
+			previous = nil
+		}
+		s.targets[key] = target
+	} else {
+		delete(s.targets, key)
+	}
+	s.mu.Unlock()
+
+	if previous != nil {
+		if err := s.upload(ctx, previous); err != nil {
+			logging.Warn("Failed to upload access logs", "loadBalancerArn", loadBalancerArn, "error", err)
+		}
+	}
+}
+
+// Enabled reports whether access logs are enabled for any load balancer
+func (s *AccessLogShipper) Enabled() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.targets) > 0
+}
+
+// Record buffers an access log entry for the load balancer it was addressed to
+func (s *AccessLogShipper) Record(ctx context.Context, entry *TraefikAccessLogEntry) {
+	host := entry.RequestHost
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	s.mu.Lock()
+	target, ok := s.targets[strings.ToLower(host)]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	targetGroupArn := ""
+	if s.TargetGroupResolver != nil {
+		targetGroupArn = s.TargetGroupResolver(entry.ServiceName)
+	}
+	target.lines = append(target.lines, FormatAccessLogEntry(entry, target.loadBalancerArn, targetGroupArn))
+	var batch *accessLogTarget
+	if len(target.lines) >= maxAccessLogBatchLines {
+		batch = &accessLogTarget{loadBalancerArn: target.loadBalancerArn, config: target.config, lines: target.lines}
+		target.lines = nil
+	}
+	s.mu.Unlock()
+
+	if batch != nil {
+		if err := s.upload(ctx, batch); err != nil {
+			logging.Warn("Failed to upload access logs", "loadBalancerArn", batch.loadBalancerArn, "error", err)
+		}
+	}
+}
+
+// Consume reads Traefik log output and records every access log entry until the reader is exhausted
+func (s *AccessLogShipper) Consume(ctx context.Context, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if entry, ok := ParseTraefikAccessLog(scanner.Bytes()); ok {
+			s.Record(ctx, entry)
+		}
+	}
+	return scanner.Err()
+}
+
+// Flush uploads all buffered access log lines
+func (s *AccessLogShipper) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	var batches []*accessLogTarget
+	for _, target := range s.targets {
+		if len(target.lines) == 0 {
+			continue
+		}
+		batches = append(batches, &accessLogTarget{loadBalancerArn: target.loadBalancerArn, config: target.config, lines: target.lines})
+		target.lines = nil
+	}
+	s.mu.Unlock()
+
+	var firstErr error
+	for _, batch := range batches {
+		if err := s.upload(ctx, batch); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Run flushes buffered access logs every interval until the context is cancelled
+func (s *AccessLogShipper) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// Use a fresh context so that the final batch is not lost
+			if err := s.Flush(context.Background()); err != nil {
+				logging.Warn("Failed to upload access logs", "error", err)
+			}
+			return
+		case <-ticker.C:
+			if err := s.Flush(ctx); err != nil {
+				logging.Warn("Failed to upload access logs", "error", err)
+			}
+		}
+	}
+}
+
+// upload gzips a batch and writes it to S3
+func (s *AccessLogShipper) upload(ctx context.Context, batch *accessLogTarget) error {
+	if len(batch.lines) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	for _, line := range batch.lines {
+		if _, err := io.WriteString(gz, line+"\n"); err != nil {
+			return fmt.Errorf("failed to compress access logs: %w", err)
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to compress access logs: %w", err)
+	}
+
+	key := AccessLogObjectKey(batch.config.Prefix, s.accountID, s.region, batch.loadBalancerArn, deterministic.Now(), s.ip)
+	if err := s.uploader.UploadFile(ctx, batch.config.Bucket, key, &buf); err != nil {
+		return fmt.Errorf("failed to upload access logs to s3://%s/%s: %w", batch.config.Bucket, key, err)
+	}
+	logging.Debug("Uploaded access logs", "loadBalancerArn", batch.loadBalancerArn, "bucket", batch.config.Bucket, "key", key, "lines", len(batch.lines))
+	return nil
+}
@@ -0,0 +1,165 @@
+package elbv2_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/elbv2"
+)
+
+// fakeAccessLogUploader records uploaded objects in memory
+type fakeAccessLogUploader struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeAccessLogUploader) UploadFile(ctx context.Context, bucket, key string, reader io.Reader) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.objects[bucket+"/"+key] = data
+	return nil
+}
+
+var _ = Describe("AccessLogs", func() {
+	const (
+		lbArn   = "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/my-alb/50dc6c495c0c9188"
+		tgArn   = "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/web/73e2d6bc24d8a067"
+		dnsName = "my-alb-123.us-east-1.elb.amazonaws.com"
+	)
+
+	traefikLine := `{"StartUTC":"2024-01-01T10:00:00.000000000Z","Duration":15000000,"OriginDuration":12000000,` +
+		`"ClientHost":"10.0.0.5","ClientPort":"51234","RequestHost":"my-alb-123.us-east-1.elb.amazonaws.com",` +
+		`"RequestPort":"-","RequestMethod":"GET","RequestPath":"/api/users?page=2","RequestProtocol":"HTTP/1.1",` +
+		`"RequestScheme":"http","RequestContentSize":0,"DownstreamStatus":200,"DownstreamContentSize":512,` +
+		`"OriginStatus":200,"ServiceAddr":"10.42.0.7:8080","ServiceName":"kecs-system-tg-web-80@kubernetes",` +
+		`"request_User-Agent":"curl/8.0"}`
+
+	Describe("AccessLogConfigFromAttributes", func() {
+		It("should read the access_logs.s3 attributes", func() {
+			config := elbv2.AccessLogConfigFromAttributes(map[string]string{
+				"access_logs.s3.enabled": "true",
+				"access_logs.s3.bucket":  "logs",
+				"access_logs.s3.prefix":  "alb",
+			})
+			Expect(config).To(Equal(elbv2.AccessLogConfig{Enabled: true, Bucket: "logs", Prefix: "alb"}))
+			Expect(config.Validate()).To(Succeed())
+		})
+
+		It("should require a bucket when enabled", func() {
+			config := elbv2.AccessLogConfigFromAttributes(map[string]string{"access_logs.s3.enabled": "true"})
+			Expect(config.Validate()).To(MatchError(ContainSubstring("bucket must be specified")))
+		})
+
+		It("should reject prefixes containing AWSLogs", func() {
+			config := elbv2.AccessLogConfig{Enabled: true, Bucket: "logs", Prefix: "AWSLogs"}
+			Expect(config.Validate()).To(MatchError(ContainSubstring("AWSLogs")))
+		})
+	})
+
+	Describe("ParseTraefikAccessLog", func() {
+		It("should parse JSON access log lines", func() {
+			entry, ok := elbv2.ParseTraefikAccessLog([]byte(traefikLine))
+			Expect(ok).To(BeTrue())
+			Expect(entry.RequestHost).To(Equal(dnsName))
+			Expect(entry.UserAgent).To(Equal("curl/8.0"))
+		})
+
+		It("should skip Traefik's own log output", func() {
+			_, ok := elbv2.ParseTraefikAccessLog([]byte(`2024-01-01T10:00:00Z INF Starting provider`))
+			Expect(ok).To(BeFalse())
+			_, ok = elbv2.ParseTraefikAccessLog([]byte(`{"level":"info","msg":"Configuration loaded"}`))
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("FormatAccessLogEntry", func() {
+		It("should produce an ALB access log line", func() {
+			entry, _ := elbv2.ParseTraefikAccessLog([]byte(traefikLine))
+			line := elbv2.FormatAccessLogEntry(entry, lbArn, tgArn)
+
+			Expect(line).To(HavePrefix("http 2024-01-01T10:00:00.015000Z app/my-alb/50dc6c495c0c9188 10.0.0.5:51234 10.42.0.7:8080 0.003 0.012 0.000 200 200 0 512 "))
+			Expect(line).To(ContainSubstring(`"GET http://my-alb-123.us-east-1.elb.amazonaws.com:80/api/users?page=2 HTTP/1.1" "curl/8.0" - - ` + tgArn))
+			Expect(line).To(ContainSubstring(`"Root=1-`))
+			Expect(line).To(HaveSuffix(`"forward" "-" "-" "10.42.0.7:8080" "200" "-" "-"`))
+		})
+	})
+
+	Describe("AccessLogObjectKey", func() {
+		It("should follow the ALB object layout", func() {
+			t := time.Date(2024, time.February, 15, 23, 40, 0, 0, time.UTC)
+			key := elbv2.AccessLogObjectKey("alb", "123456789012", "us-east-1", lbArn, t, "127.0.0.1")
+
+			Expect(key).To(HavePrefix("alb/AWSLogs/123456789012/elasticloadbalancing/us-east-1/2024/02/15/" +
+				"123456789012_elasticloadbalancing_us-east-1_app.my-alb.50dc6c495c0c9188_20240215T2340Z_127.0.0.1_"))
+			Expect(key).To(HaveSuffix(".log.gz"))
+		})
+	})
+
+	Describe("AccessLogShipper", func() {
+		var (
+			uploader *fakeAccessLogUploader
+			shipper  *elbv2.AccessLogShipper
+			ctx      context.Context
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			uploader = &fakeAccessLogUploader{objects: map[string][]byte{}}
+			shipper = elbv2.NewAccessLogShipper(uploader, "123456789012", "us-east-1")
+			shipper.TargetGroupResolver = func(serviceName string) string {
+				if strings.Contains(serviceName, "-tg-web-") {
+					return tgArn
+				}
+				return ""
+			}
+		})
+
+		It("should upload gzipped batches for configured load balancers only", func() {
+			shipper.Configure(ctx, lbArn, dnsName, elbv2.AccessLogConfig{Enabled: true, Bucket: "logs", Prefix: "alb"})
+
+			other := strings.Replace(traefikLine, dnsName, "other.example.com", 1)
+			input := strings.Join([]string{traefikLine, "not an access log", other, traefikLine}, "\n")
+			Expect(shipper.Consume(ctx, strings.NewReader(input))).To(Succeed())
+			Expect(shipper.Flush(ctx)).To(Succeed())
+
+			Expect(uploader.objects).To(HaveLen(1))
+			for key, data := range uploader.objects {
+				Expect(key).To(HavePrefix("logs/alb/AWSLogs/123456789012/elasticloadbalancing/us-east-1/"))
+
+				gz, err := gzip.NewReader(bytes.NewReader(data))
+				Expect(err).NotTo(HaveOccurred())
+				content, err := io.ReadAll(gz)
+				Expect(err).NotTo(HaveOccurred())
+
+				lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+				Expect(lines).To(HaveLen(2))
+				Expect(lines[0]).To(ContainSubstring(tgArn))
+			}
+		})
+
+		It("should upload buffered lines when access logs are disabled", func() {
+			shipper.Configure(ctx, lbArn, dnsName, elbv2.AccessLogConfig{Enabled: true, Bucket: "logs"})
+			Expect(shipper.Consume(ctx, strings.NewReader(traefikLine))).To(Succeed())
+
+			shipper.Configure(ctx, lbArn, dnsName, elbv2.AccessLogConfig{})
+			Expect(uploader.objects).To(HaveLen(1))
+			Expect(shipper.Enabled()).To(BeFalse())
+
+			Expect(shipper.Consume(ctx, strings.NewReader(traefikLine))).To(Succeed())
+			Expect(shipper.Flush(ctx)).To(Succeed())
+			Expect(uploader.objects).To(HaveLen(1))
+		})
+	})
+})
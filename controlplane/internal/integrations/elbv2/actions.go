@@ -0,0 +1,322 @@
+package elbv2
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated_elbv2"
+	"github.com/nandemo-ya/kecs/controlplane/internal/kubernetes/resources"
+)
+
+// Placeholders of redirect actions, replaced with the parts of the request URL
+const (
+	placeholderProtocol = "#{protocol}"
+	placeholderHost     = "#{host}"
+	placeholderPort     = "#{port}"
+	placeholderPath     = "#{path}"
+	placeholderQuery    = "#{query}"
+)
+
+const (
+	// FixedResponsePath is the path KECS serves the fixed responses of
+	// listener rules on. Traefik routes requests matching a fixed-response
+	// rule there, with the response to return in request headers.
+	FixedResponsePath = "/elbv2/fixed-response"
+
+	// fixedResponseService and fixedResponsePort are the Service of the
+	// control plane in kecs-system that serves FixedResponsePath
+	fixedResponseService = resources.ControlPlaneAPIService
+	fixedResponsePort    = 80
+
+	fixedResponseStatusHeader      = "X-Kecs-Fixed-Response-Status"
+	fixedResponseContentTypeHeader = "X-Kecs-Fixed-Response-Content-Type"
+	fixedResponseBodyHeader        = "X-Kecs-Fixed-Response-Body"
+
+	// maxFixedResponseBodyLength is the longest message body of a fixed-response action
+	maxFixedResponseBodyLength = 1024
+)
+
+// fixedResponseContentTypes are the content types fixed-response actions may return
+var fixedResponseContentTypes = []string{"text/plain", "text/css", "text/html", "application/javascript", "application/json"}
+
+// fixedResponseStatusPattern matches the status codes fixed-response actions may return
+var fixedResponseStatusPattern = regexp.MustCompile(`^[245]\d\d$`)
+
+// redirectURLPattern splits a request URL the way Traefik's redirectRegex
+// middleware sees it, scheme://host[:port]/path[?query], into the parts the
+// placeholders of redirect actions refer to
+var redirectURLPattern = regexp.MustCompile(`^(?P<protocol>https?)://(?P<host>[^/:]+)(?P<portsuffix>:(?P<port>\d+))?/(?P<path>[^?]*)(?P<querysuffix>\?(?P<query>.*))?$`)
+
+// Labels of the middlewares of response actions. The listener label scopes
+// their garbage collection to the routes of one listener.
+const (
+	componentLabel          = "kecs.io/component"
+	listenerLabel           = "kecs.io/listener"
+	responseActionComponent = "elbv2-response-action"
+)
+
+// middlewareGVR is the GroupVersionResource of Traefik's Middleware CRD
+var middlewareGVR = schema.GroupVersionResource{
+	Group:    "traefik.io",
+	Version:  "v1alpha1",
+	Resource: "middlewares",
+}
+
+// ResponseAction returns the redirect or fixed-response action that answers
+// requests instead of forwarding them, or nil when the actions forward. Like
+// ELBv2, the action with the highest order is the one that ends the list.
+func ResponseAction(actions []generated_elbv2.Action) *generated_elbv2.Action {
+	if len(actions) == 0 {
+		return nil
+	}
+	sorted := make([]generated_elbv2.Action, len(actions))
+	copy(sorted, actions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return actionOrder(sorted[i]) < actionOrder(sorted[j])
+	})
+	last := sorted[len(sorted)-1]
+	switch last.Type {
+	case generated_elbv2.ActionTypeEnumREDIRECT, generated_elbv2.ActionTypeEnumFIXED_RESPONSE:
+		return &last
+	}
+	return nil
+}
+
+// actionOrder returns the order of an action, actions without order keep their position
+func actionOrder(action generated_elbv2.Action) int32 {
+	if action.Order == nil {
+		return 0
+	}
+	return *action.Order
+}
+
+// ValidateActions checks redirect and fixed-response actions against the
+// constraints enforced by ELBv2
+func (e *RuleEvaluator) ValidateActions(actions []generated_elbv2.Action) error {
+	for _, action := range actions {
+		switch action.Type {
+		case generated_elbv2.ActionTypeEnumREDIRECT:
+			if err := validateRedirect(action.RedirectConfig); err != nil {
+				return err
+			}
+		case generated_elbv2.ActionTypeEnumFIXED_RESPONSE:
+			if err := validateFixedResponse(action.FixedResponseConfig); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validateRedirect(config *generated_elbv2.RedirectActionConfig) error {
+	if config == nil {
+		return fmt.Errorf("a redirect configuration must be specified for redirect actions")
+	}
+	switch config.StatusCode {
+	case generated_elbv2.RedirectActionStatusCodeEnumHTTP_301, generated_elbv2.RedirectActionStatusCodeEnumHTTP_302:
+	default:
+		return fmt.Errorf("redirect status code '%s' must be HTTP_301 or HTTP_302", config.StatusCode)
+	}
+	if protocol := redirectField(config.Protocol, placeholderProtocol); protocol != placeholderProtocol && protocol != "HTTP" && protocol != "HTTPS" {
+		return fmt.Errorf("redirect protocol '%s' must be HTTP, HTTPS or %s", protocol, placeholderProtocol)
+	}
+	if port := redirectField(config.Port, placeholderPort); port != placeholderPort {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("redirect port '%s' must be between 1 and 65535 or %s", port, placeholderPort)
+		}
+	}
+	if path := redirectField(config.Path, "/"+placeholderPath); !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("redirect path '%s' must start with '/'", path)
+	}
+	if redirectField(config.Protocol, placeholderProtocol) == placeholderProtocol &&
+		redirectField(config.Host, placeholderHost) == placeholderHost &&
+		redirectField(config.Port, placeholderPort) == placeholderPort &&
+		redirectField(config.Path, "/"+placeholderPath) == "/"+placeholderPath &&
+		redirectField(config.Query, placeholderQuery) == placeholderQuery {
+		return fmt.Errorf("a redirect must change at least one of the protocol, host, port, path or query")
+	}
+	return nil
+}
+
+func validateFixedResponse(config *generated_elbv2.FixedResponseActionConfig) error {
+	if config == nil {
+		return fmt.Errorf("a fixed response configuration must be specified for fixed-response actions")
+	}
+	if !fixedResponseStatusPattern.MatchString(config.StatusCode) {
+		return fmt.Errorf("fixed response status code '%s' must be a 2XX, 4XX or 5XX code", config.StatusCode)
+	}
+	if config.ContentType != nil && !slices.Contains(fixedResponseContentTypes, *config.ContentType) {
+		return fmt.Errorf("fixed response content type '%s' must be one of %s", *config.ContentType, strings.Join(fixedResponseContentTypes, ", "))
+	}
+	if config.MessageBody != nil && len(*config.MessageBody) > maxFixedResponseBodyLength {
+		return fmt.Errorf("fixed response message body exceeds the maximum length of %d characters", maxFixedResponseBodyLength)
+	}
+	return nil
+}
+
+// redirectReplacement converts a redirect action into a replacement for
+// redirectURLPattern. The parts of the URL that are not changed keep their
+// value, so that e.g. a request without port is not redirected to an empty one.
+func redirectReplacement(config *generated_elbv2.RedirectActionConfig) string {
+	protocol := redirectField(config.Protocol, placeholderProtocol)
+	host := redirectField(config.Host, placeholderHost)
+	port := redirectField(config.Port, placeholderPort)
+	path := redirectField(config.Path, "/"+placeholderPath)
+	query := redirectField(config.Query, placeholderQuery)
+
+	var b strings.Builder
+	b.WriteString(strings.ToLower(expandPlaceholders(protocol)))
+	b.WriteString("://")
+	b.WriteString(expandPlaceholders(host))
+	switch {
+	case port == placeholderPort:
+		b.WriteString("${portsuffix}")
+	case (protocol == "HTTP" && port == "80") || (protocol == "HTTPS" && port == "443"):
+		// Default ports are left out like browsers do
+	default:
+		b.WriteString(":" + expandPlaceholders(port))
+	}
+	b.WriteString(expandPlaceholders(path))
+	switch {
+	case query == placeholderQuery:
+		b.WriteString("${querysuffix}")
+	case query != "":
+		b.WriteString("?" + expandPlaceholders(query))
+	}
+	return b.String()
+}
+
+// redirectField returns the value of a redirect field, or its default
+func redirectField(value *string, defaultValue string) string {
+	if value == nil || *value == "" {
+		return defaultValue
+	}
+	return *value
+}
+
+// placeholderReplacer maps the placeholders of redirect actions to the
+// groups of redirectURLPattern
+var placeholderReplacer = strings.NewReplacer(
+	"$", "$$",
+	placeholderProtocol, "${protocol}",
+	placeholderHost, "${host}",
+	placeholderPort, "${port}",
+	placeholderPath, "${path}",
+	placeholderQuery, "${query}",
+)
+
+// expandPlaceholders replaces the placeholders of a redirect field with
+// references to the groups of redirectURLPattern, escaping the rest
+func expandPlaceholders(value string) string {
+	return placeholderReplacer.Replace(value)
+}
+
+// writeFixedResponse writes the response of a fixed-response action
+func writeFixedResponse(w http.ResponseWriter, config *generated_elbv2.FixedResponseActionConfig) {
+	status, err := strconv.Atoi(config.StatusCode)
+	if err != nil {
+		status = http.StatusInternalServerError
+	}
+	contentType := "text/plain"
+	if config.ContentType != nil {
+		contentType = *config.ContentType
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	if config.MessageBody != nil {
+		w.Write([]byte(*config.MessageBody))
+	}
+}
+
+// FixedResponseHandler serves the fixed responses Traefik routes to KECS,
+// reading the response from the headers set by the rule's middleware
+func FixedResponseHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		config := &generated_elbv2.FixedResponseActionConfig{StatusCode: r.Header.Get(fixedResponseStatusHeader)}
+		if !fixedResponseStatusPattern.MatchString(config.StatusCode) {
+			http.Error(w, "not a fixed response of a listener rule", http.StatusBadRequest)
+			return
+		}
+		if contentType := r.Header.Get(fixedResponseContentTypeHeader); contentType != "" {
+			config.ContentType = &contentType
+		}
+		if encoded := r.Header.Get(fixedResponseBodyHeader); encoded != "" {
+			body, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				http.Error(w, "invalid fixed response body", http.StatusBadRequest)
+				return
+			}
+			message := string(body)
+			config.MessageBody = &message
+		}
+		writeFixedResponse(w, config)
+	})
+}
+
+// BuildResponseMiddlewares converts a redirect or fixed-response action into
+// the Traefik middlewares that answer the requests of a route. Redirects are
+// answered by Traefik itself, fixed responses by KECS at FixedResponsePath.
+func BuildResponseMiddlewares(name, namespace string, action *generated_elbv2.Action) []*unstructured.Unstructured {
+	switch {
+	case action.Type == generated_elbv2.ActionTypeEnumREDIRECT && action.RedirectConfig != nil:
+		return []*unstructured.Unstructured{
+			buildMiddleware(name+"-redirect", namespace, map[string]interface{}{
+				"redirectRegex": map[string]interface{}{
+					"regex":       redirectURLPattern.String(),
+					"replacement": redirectReplacement(action.RedirectConfig),
+					"permanent":   action.RedirectConfig.StatusCode == generated_elbv2.RedirectActionStatusCodeEnumHTTP_301,
+				},
+			}),
+		}
+	case action.Type == generated_elbv2.ActionTypeEnumFIXED_RESPONSE && action.FixedResponseConfig != nil:
+		config := action.FixedResponseConfig
+		headers := map[string]interface{}{
+			fixedResponseStatusHeader: config.StatusCode,
+		}
+		if config.ContentType != nil {
+			headers[fixedResponseContentTypeHeader] = *config.ContentType
+		}
+		if config.MessageBody != nil {
+			headers[fixedResponseBodyHeader] = base64.StdEncoding.EncodeToString([]byte(*config.MessageBody))
+		}
+		return []*unstructured.Unstructured{
+			buildMiddleware(name+"-fixed-response", namespace, map[string]interface{}{
+				"headers": map[string]interface{}{
+					"customRequestHeaders": headers,
+				},
+			}),
+			buildMiddleware(name+"-fixed-response-path", namespace, map[string]interface{}{
+				"replacePath": map[string]interface{}{
+					"path": FixedResponsePath,
+				},
+			}),
+		}
+	}
+	return nil
+}
+
+func buildMiddleware(name, namespace string, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "traefik.io/v1alpha1",
+			"kind":       "Middleware",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+				"labels": map[string]interface{}{
+					componentLabel: responseActionComponent,
+				},
+			},
+			"spec": spec,
+		},
+	}
+}
@@ -0,0 +1,141 @@
+package elbv2_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated_elbv2"
+	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/elbv2"
+	"github.com/nandemo-ya/kecs/controlplane/internal/utils"
+)
+
+var _ = Describe("Response actions", func() {
+	var evaluator *elbv2.RuleEvaluator
+
+	BeforeEach(func() {
+		evaluator = elbv2.NewRuleEvaluator()
+	})
+
+	httpsRedirect := generated_elbv2.Action{
+		Type: generated_elbv2.ActionTypeEnumREDIRECT,
+		RedirectConfig: &generated_elbv2.RedirectActionConfig{
+			Protocol:   utils.Ptr("HTTPS"),
+			Port:       utils.Ptr("443"),
+			StatusCode: generated_elbv2.RedirectActionStatusCodeEnumHTTP_301,
+		},
+	}
+
+	maintenance := generated_elbv2.Action{
+		Type: generated_elbv2.ActionTypeEnumFIXED_RESPONSE,
+		FixedResponseConfig: &generated_elbv2.FixedResponseActionConfig{
+			StatusCode:  "503",
+			ContentType: utils.Ptr("text/html"),
+			MessageBody: utils.Ptr("<h1>Down for maintenance</h1>"),
+		},
+	}
+
+	// redirect applies the redirectRegex middleware of a redirect action to a
+	// URL the way Traefik does
+	redirect := func(config *generated_elbv2.RedirectActionConfig, url string) string {
+		action := generated_elbv2.Action{Type: generated_elbv2.ActionTypeEnumREDIRECT, RedirectConfig: config}
+		middlewares := elbv2.BuildResponseMiddlewares("rule-abc", "kecs-system", &action)
+		Expect(middlewares).To(HaveLen(1))
+		spec := middlewares[0].Object["spec"].(map[string]interface{})["redirectRegex"].(map[string]interface{})
+		return regexp.MustCompile(spec["regex"].(string)).ReplaceAllString(url, spec["replacement"].(string))
+	}
+
+	Describe("Redirects", func() {
+		It("should redirect HTTP to HTTPS keeping host, path and query", func() {
+			Expect(redirect(httpsRedirect.RedirectConfig, "http://shop.example.com:8080/cart?item=1")).To(Equal("https://shop.example.com/cart?item=1"))
+		})
+
+		It("should substitute placeholders", func() {
+			config := &generated_elbv2.RedirectActionConfig{
+				Host:       utils.Ptr("www.#{host}"),
+				Path:       utils.Ptr("/v2/#{path}"),
+				Query:      utils.Ptr("from=#{host}&#{query}"),
+				StatusCode: generated_elbv2.RedirectActionStatusCodeEnumHTTP_302,
+			}
+			Expect(redirect(config, "http://example.com:8080/users?id=7")).To(Equal("http://www.example.com:8080/v2/users?from=example.com&id=7"))
+		})
+
+		It("should keep a request without port and query without them", func() {
+			config := &generated_elbv2.RedirectActionConfig{
+				Path:       utils.Ptr("/maintenance"),
+				StatusCode: generated_elbv2.RedirectActionStatusCodeEnumHTTP_302,
+			}
+			Expect(redirect(config, "http://example.com/")).To(Equal("http://example.com/maintenance"))
+		})
+	})
+
+	Describe("ValidateActions", func() {
+		It("should accept redirects and fixed responses", func() {
+			Expect(evaluator.ValidateActions([]generated_elbv2.Action{httpsRedirect})).To(Succeed())
+			Expect(evaluator.ValidateActions([]generated_elbv2.Action{maintenance})).To(Succeed())
+		})
+
+		It("should reject redirects that change nothing", func() {
+			err := evaluator.ValidateActions([]generated_elbv2.Action{{
+				Type:           generated_elbv2.ActionTypeEnumREDIRECT,
+				RedirectConfig: &generated_elbv2.RedirectActionConfig{StatusCode: generated_elbv2.RedirectActionStatusCodeEnumHTTP_302},
+			}})
+			Expect(err).To(MatchError(ContainSubstring("must change at least one")))
+		})
+
+		It("should reject invalid fixed responses", func() {
+			err := evaluator.ValidateActions([]generated_elbv2.Action{{
+				Type:                generated_elbv2.ActionTypeEnumFIXED_RESPONSE,
+				FixedResponseConfig: &generated_elbv2.FixedResponseActionConfig{StatusCode: "302"},
+			}})
+			Expect(err).To(MatchError(ContainSubstring("2XX, 4XX or 5XX")))
+
+			err = evaluator.ValidateActions([]generated_elbv2.Action{{
+				Type:                generated_elbv2.ActionTypeEnumFIXED_RESPONSE,
+				FixedResponseConfig: &generated_elbv2.FixedResponseActionConfig{StatusCode: "200", ContentType: utils.Ptr("image/png")},
+			}})
+			Expect(err).To(MatchError(ContainSubstring("content type 'image/png'")))
+		})
+	})
+
+	Describe("BuildResponseMiddlewares", func() {
+		It("should build a permanent redirectRegex middleware", func() {
+			middlewares := elbv2.BuildResponseMiddlewares("rule-abc", "kecs-system", &httpsRedirect)
+			Expect(middlewares).To(HaveLen(1))
+			Expect(middlewares[0].GetName()).To(Equal("rule-abc-redirect"))
+
+			spec := middlewares[0].Object["spec"].(map[string]interface{})["redirectRegex"].(map[string]interface{})
+			Expect(spec["permanent"]).To(BeTrue())
+			// Traefik applies the same regular expression to scheme://host/uri
+			pattern := regexp.MustCompile(spec["regex"].(string))
+			Expect(pattern.ReplaceAllString("http://example.com:8080/a?b=c", spec["replacement"].(string))).To(Equal("https://example.com/a?b=c"))
+		})
+
+		It("should route fixed responses to KECS with the response in headers", func() {
+			middlewares := elbv2.BuildResponseMiddlewares("rule-abc", "kecs-system", &maintenance)
+			Expect(middlewares).To(HaveLen(2))
+
+			headers := middlewares[0].Object["spec"].(map[string]interface{})["headers"].(map[string]interface{})["customRequestHeaders"].(map[string]interface{})
+			req := httptest.NewRequest("GET", elbv2.FixedResponsePath, nil)
+			for name, value := range headers {
+				req.Header.Set(name, value.(string))
+			}
+			Expect(middlewares[1].Object["spec"]).To(HaveKeyWithValue("replacePath", HaveKeyWithValue("path", elbv2.FixedResponsePath)))
+
+			rec := httptest.NewRecorder()
+			elbv2.FixedResponseHandler().ServeHTTP(rec, req)
+			Expect(rec.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(rec.Header().Get("Content-Type")).To(Equal("text/html"))
+			Expect(rec.Body.String()).To(Equal("<h1>Down for maintenance</h1>"))
+		})
+
+		It("should reject requests that were not routed by a rule", func() {
+			rec := httptest.NewRecorder()
+			elbv2.FixedResponseHandler().ServeHTTP(rec, httptest.NewRequest("GET", elbv2.FixedResponsePath, nil))
+			Expect(rec.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+})
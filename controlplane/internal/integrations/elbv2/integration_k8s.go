@@ -1,8 +1,10 @@
 package elbv2
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"net"
 	"os/exec"
 	"strings"
@@ -21,11 +23,16 @@ import (
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
 	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated_elbv2"
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
 )
 
+// ProxyImage is the image of the Traefik proxy deployed per load balancer
+const ProxyImage = "traefik:v3.5.0"
+
 // K8sIntegration implements the Integration interface using Kubernetes Services
 // instead of actual ELBv2 API calls. This avoids the need for LocalStack Pro.
 type K8sIntegration struct {
@@ -43,6 +50,21 @@ type K8sIntegration struct {
 	targetGroups  map[string]*TargetGroup
 	listeners     map[string]*Listener
 	targetHealth  map[string]map[string]*TargetHealth // targetGroupArn -> targetId -> health
+
+	// Access log publishing, started when the first load balancer enables access logs
+	accessLogUploader AccessLogUploader
+	accessLogs        *AccessLogShipper
+
+	// Traffic of target groups counted from the Traefik access log, which is
+	// followed once access logs or traffic are first asked for
+	traffic         *TrafficRecorder
+	traefikLogsOnce sync.Once
+
+	// Resolves the domains of listener certificates for SNI
+	certificateResolver CertificateResolver
+
+	// Serves target groups of Lambda targets registered with RegisterTargets
+	lambdaTargets *LambdaTargetProxy
 }
 
 // NewK8sIntegration creates a new Kubernetes-based ELBv2 integration
@@ -58,6 +80,7 @@ func NewK8sIntegration(region, accountID string) *K8sIntegration {
 		listeners:     make(map[string]*Listener),
 		targetHealth:  make(map[string]map[string]*TargetHealth),
 	}
+	integration.traffic = NewTrafficRecorder(integration.targetGroupForTraefikService)
 	// RuleManager will be initialized when dynamicClient is set
 	return integration
 }
@@ -75,13 +98,17 @@ func (i *K8sIntegration) SetStorage(store storage.ELBv2Store) {
 	i.store = store
 }
 
+// SetAccessLogUploader sets the uploader used to publish access logs to S3
+func (i *K8sIntegration) SetAccessLogUploader(uploader AccessLogUploader) {
+	i.accessLogUploader = uploader
+}
+
 // CreateLoadBalancer creates a virtual load balancer and deploys Traefik
 func (i *K8sIntegration) CreateLoadBalancer(ctx context.Context, name string, subnets []string, securityGroups []string) (*LoadBalancer, error) {
 	logging.Debug("Creating load balancer with Traefik deployment", "name", name)
 
 	// Generate ARN
-	arn := fmt.Sprintf("arn:aws:elasticloadbalancing:%s:%s:loadbalancer/app/%s/%s",
-		i.region, i.accountID, name, generateID())
+	arn := awsarn.Build("elasticloadbalancing", i.region, i.accountID, fmt.Sprintf("loadbalancer/app/%s/%s", name, generateID()))
 
 	// Create virtual load balancer
 	lb := &LoadBalancer{
@@ -165,8 +192,7 @@ func (i *K8sIntegration) CreateTargetGroup(ctx context.Context, name string, por
 	logging.Debug("Creating target group with Kubernetes resources", "name", name)
 
 	// Generate ARN
-	arn := fmt.Sprintf("arn:aws:elasticloadbalancing:%s:%s:targetgroup/%s/%s",
-		i.region, i.accountID, name, generateID())
+	arn := awsarn.Build("elasticloadbalancing", i.region, i.accountID, fmt.Sprintf("targetgroup/%s/%s", name, generateID()))
 
 	// Create virtual target group
 	tg := &TargetGroup{
@@ -218,6 +244,25 @@ func (i *K8sIntegration) DeleteTargetGroup(ctx context.Context, arn string) erro
 func (i *K8sIntegration) RegisterTargets(ctx context.Context, targetGroupArn string, targets []Target) error {
 	logging.Debug("Registering targets with virtual target group", "targetCount", len(targets), "targetGroupArn", targetGroupArn)
 
+	// Target groups created before a restart are only in the database
+	i.mu.RLock()
+	_, exists := i.targetGroups[targetGroupArn]
+	i.mu.RUnlock()
+	if !exists && i.store != nil {
+		if dbTG, err := i.store.GetTargetGroup(ctx, targetGroupArn); err == nil && dbTG != nil {
+			i.mu.Lock()
+			i.targetGroups[targetGroupArn] = &TargetGroup{
+				Arn:        dbTG.ARN,
+				Name:       dbTG.Name,
+				Port:       dbTG.Port,
+				Protocol:   dbTG.Protocol,
+				VpcId:      dbTG.VpcID,
+				TargetType: dbTG.TargetType,
+			}
+			i.mu.Unlock()
+		}
+	}
+
 	i.mu.Lock()
 	if _, exists := i.targetGroups[targetGroupArn]; !exists {
 		i.mu.Unlock()
@@ -349,8 +394,7 @@ func (i *K8sIntegration) CreateListener(ctx context.Context, loadBalancerArn str
 	i.mu.RUnlock()
 
 	// Generate ARN
-	arn := fmt.Sprintf("arn:aws:elasticloadbalancing:%s:%s:listener/app/%s/%s",
-		i.region, i.accountID, getResourceName(loadBalancerArn), generateID())
+	arn := awsarn.Build("elasticloadbalancing", i.region, i.accountID, fmt.Sprintf("listener/app/%s/%s", getResourceName(loadBalancerArn), generateID()))
 
 	// Create virtual listener
 	listener := &Listener{
@@ -443,6 +487,19 @@ func (i *K8sIntegration) DeleteListener(ctx context.Context, arn string) error {
 		}
 	}
 
+	// Delete the weighted forward resources and certificates, if any
+	if lbName != "" {
+		i.deleteWeightedForward(ctx, lbName, listener.Port)
+		i.deleteListenerCertificateSecrets(ctx, lbName, listener.Port, nil)
+	}
+
+	// Delete the middlewares of the listener's response actions
+	if i.dynamicClient != nil {
+		if err := deleteResponseMiddlewares(ctx, i.dynamicClient, arn, nil); err != nil {
+			logging.Debug("Failed to delete response action middlewares of listener", "arn", arn, "error", err)
+		}
+	}
+
 	return nil
 }
 
@@ -809,7 +866,7 @@ func (i *K8sIntegration) createDeployment(ctx context.Context, namespace, traefi
 					Containers: []corev1.Container{
 						{
 							Name:  "traefik",
-							Image: "traefik:v3.5.0",
+							Image: ProxyImage,
 							Args: []string{
 								"--configfile=/config/traefik.yml",
 							},
@@ -1082,6 +1139,36 @@ func (i *K8sIntegration) CreateTargetGroupServiceInNamespace(ctx context.Context
 	return nil
 }
 
+// DeleteTargetGroupServiceInNamespace deletes the Kubernetes Service created for a
+// target group in an ECS cluster namespace. It is a no-op when there is none.
+func (i *K8sIntegration) DeleteTargetGroupServiceInNamespace(ctx context.Context, targetGroupArn, namespace string) error {
+	if i.kubeClient == nil {
+		return nil
+	}
+
+	services, err := i.kubeClient.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "kecs.io/component=target-group",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list target group Services in namespace %s: %w", namespace, err)
+	}
+
+	for _, service := range services.Items {
+		if service.Annotations["kecs.io/elbv2-target-group-arn"] != targetGroupArn {
+			continue
+		}
+		err := i.kubeClient.CoreV1().Services(namespace).Delete(ctx, service.Name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete Service %s for target group in namespace %s: %w", service.Name, namespace, err)
+		}
+		logging.Info("Deleted Service for target group in ECS cluster namespace",
+			"serviceName", service.Name,
+			"namespace", namespace,
+			"targetGroupArn", targetGroupArn)
+	}
+	return nil
+}
+
 // updateTraefikConfigForListener creates Ingress for global Traefik with Host header routing
 func (i *K8sIntegration) updateTraefikConfigForListener(ctx context.Context, lbName, listenerArn string, port int32, protocol, targetGroupName string) error {
 	if i.kubeClient == nil {
@@ -1285,7 +1372,9 @@ func (i *K8sIntegration) createGlobalIngress(ctx context.Context, lbName, lbDNSN
 	// Check if Ingress already exists
 	existing, err := i.kubeClient.NetworkingV1().Ingresses(namespace).Get(ctx, ingressName, metav1.GetOptions{})
 	if err == nil {
-		// Update existing Ingress
+		// Update existing Ingress, keeping the TLS configuration of the listener certificates
+		ingress.Spec.TLS = existing.Spec.TLS
+		ingress.Spec.Rules = listenerIngressRules(ingress.Spec.Rules, lbDNSName, existing.Spec.TLS)
 		existing.Spec = ingress.Spec
 		_, err = i.kubeClient.NetworkingV1().Ingresses(namespace).Update(ctx, existing, metav1.UpdateOptions{})
 		if err != nil {
@@ -1546,6 +1635,272 @@ func (i *K8sIntegration) UpdateIngressRouteForService(ctx context.Context, targe
 	return nil
 }
 
+// SyncListenerForwardConfig routes a listener through a weighted TraefikService.
+// The plain Ingress created for the listener can only reference a single
+// target group, so it is replaced by an IngressRoute matching the same host.
+// A nil config removes the weighted resources again.
+func (i *K8sIntegration) SyncListenerForwardConfig(ctx context.Context, loadBalancerArn string, port int32, config *generated_elbv2.ForwardActionConfig) error {
+	if i.kubeClient == nil || i.dynamicClient == nil {
+		logging.Debug("No Kubernetes clients available, skipping weighted forward sync")
+		return nil
+	}
+
+	lbName := ""
+	if parts := strings.Split(loadBalancerArn, "/"); len(parts) >= 3 {
+		lbName = parts[len(parts)-2]
+	} else {
+		return fmt.Errorf("invalid load balancer ARN format: %s", loadBalancerArn)
+	}
+
+	if config == nil {
+		i.deleteWeightedForward(ctx, lbName, port)
+		return nil
+	}
+
+	namespace := "kecs-system"
+	name := fmt.Sprintf("alb-%s-port-%d", sanitizeName(lbName), port)
+
+	// Keep the host the listener is already reachable on
+	host := ""
+	if ingress, err := i.kubeClient.NetworkingV1().Ingresses(namespace).Get(ctx, name, metav1.GetOptions{}); err == nil {
+		host = ingress.Annotations["kecs.io/elbv2-dns-name"]
+	}
+	if host == "" {
+		i.mu.RLock()
+		if lb, exists := i.loadBalancers[loadBalancerArn]; exists {
+			host = lb.DNSName
+		}
+		i.mu.RUnlock()
+	}
+	if host == "" {
+		return fmt.Errorf("unable to determine host for load balancer %s", loadBalancerArn)
+	}
+
+	var resolver TargetGroupResolver
+	if i.store != nil {
+		resolver = &storageTargetGroupResolver{store: i.store, ctx: ctx}
+	}
+	weightedManager := NewWeightedRoutingManager()
+	services, err := weightedManager.ConvertActionsToWeightedServices([]generated_elbv2.Action{
+		{Type: generated_elbv2.ActionTypeEnumFORWARD, ForwardConfig: config},
+	}, resolver)
+	if err != nil {
+		return fmt.Errorf("failed to convert forward config: %w", err)
+	}
+	if len(services) == 0 {
+		return fmt.Errorf("forward config has no resolvable target groups")
+	}
+
+	traefikService := weightedManager.BuildWeightedTraefikService(name, namespace, services, config)
+	if err := upsertUnstructured(ctx, i.dynamicClient, traefikServiceGVR, traefikService); err != nil {
+		return fmt.Errorf("failed to apply TraefikService: %w", err)
+	}
+
+	ingressRoute := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "traefik.io/v1alpha1",
+			"kind":       "IngressRoute",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+				"annotations": map[string]interface{}{
+					"kecs.io/elbv2-load-balancer": lbName,
+					"kecs.io/elbv2-dns-name":      host,
+				},
+				"labels": map[string]interface{}{
+					"kecs.io/elbv2-load-balancer": lbName,
+					"kecs.io/component":           "elbv2-listener",
+					"kecs.io/traefik-scope":       "global",
+				},
+			},
+			"spec": map[string]interface{}{
+				"entryPoints": []interface{}{"web"},
+				"routes": []interface{}{
+					map[string]interface{}{
+						"match": fmt.Sprintf("Host(`%s`) && PathPrefix(`/`)", host),
+						"kind":  "Rule",
+						"services": []interface{}{
+							map[string]interface{}{
+								"name": name,
+								"kind": "TraefikService",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	ingressRouteGVR := schema.GroupVersionResource{
+		Group:    "traefik.io",
+		Version:  "v1alpha1",
+		Resource: "ingressroutes",
+	}
+	if err := upsertUnstructured(ctx, i.dynamicClient, ingressRouteGVR, ingressRoute); err != nil {
+		return fmt.Errorf("failed to apply IngressRoute: %w", err)
+	}
+
+	// Drop the single target group Ingress so that it does not compete for the host
+	if err := i.deleteGlobalIngress(ctx, lbName, port); err != nil {
+		logging.Debug("Failed to delete Ingress replaced by weighted IngressRoute", "name", name, "error", err)
+	}
+
+	logging.Info("Configured weighted forwarding for listener",
+		"loadBalancer", lbName,
+		"port", port,
+		"targetGroups", len(services),
+		"sticky", stickinessEnabled(config))
+	return nil
+}
+
+// deleteWeightedForward deletes the IngressRoute and TraefikService used for weighted forwarding
+func (i *K8sIntegration) deleteWeightedForward(ctx context.Context, lbName string, port int32) {
+	if i.dynamicClient == nil {
+		return
+	}
+	name := fmt.Sprintf("alb-%s-port-%d", sanitizeName(lbName), port)
+	for _, resource := range []string{"ingressroutes", "traefikservices"} {
+		gvr := schema.GroupVersionResource{Group: "traefik.io", Version: "v1alpha1", Resource: resource}
+		if err := i.dynamicClient.Resource(gvr).Namespace("kecs-system").Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			logging.Debug("Failed to delete weighted forward resource", "resource", resource, "name", name, "error", err)
+		}
+	}
+}
+
+// ConfigureAccessLogs enables or disables access logs for a load balancer.
+// Access logs are collected from the Traefik JSON access log and uploaded
+// to S3 in the ALB access log format.
+func (i *K8sIntegration) ConfigureAccessLogs(ctx context.Context, loadBalancerArn, dnsName string, config AccessLogConfig) error {
+	i.mu.Lock()
+	shipper := i.accessLogs
+	if shipper == nil {
+		if !config.Enabled {
+			i.mu.Unlock()
+			return nil
+		}
+		if i.accessLogUploader == nil {
+			i.mu.Unlock()
+			return fmt.Errorf("S3 integration is not available, cannot deliver access logs")
+		}
+		shipper = NewAccessLogShipper(i.accessLogUploader, i.accountID, i.region)
+		shipper.TargetGroupResolver = i.targetGroupForTraefikService
+		i.accessLogs = shipper
+		// The shipper outlives the request that enabled it
+		go shipper.Run(context.Background(), DefaultAccessLogFlushInterval)
+	}
+	i.mu.Unlock()
+
+	shipper.Configure(ctx, loadBalancerArn, dnsName, config)
+
+	if config.Enabled {
+		i.followTraefikLogs()
+	}
+
+	logging.Info("Configured access logs for load balancer",
+		"loadBalancerArn", loadBalancerArn,
+		"enabled", config.Enabled,
+		"bucket", config.Bucket,
+		"prefix", config.Prefix)
+	return nil
+}
+
+// TargetGroupTraffic implements TrafficReporter. Counting starts with the
+// first call, earlier requests are not included.
+func (i *K8sIntegration) TargetGroupTraffic(targetGroupArn string) TargetGroupTraffic {
+	i.followTraefikLogs()
+	return i.traffic.Traffic(targetGroupArn)
+}
+
+// followTraefikLogs starts following the Traefik access log once
+func (i *K8sIntegration) followTraefikLogs() {
+	if i.kubeClient == nil {
+		return
+	}
+	i.traefikLogsOnce.Do(func() {
+		// The collector outlives the request that started it
+		go i.collectTraefikLogs(context.Background())
+	})
+}
+
+// collectTraefikLogs follows the Traefik logs and records the access log
+// entries, reconnecting when the stream ends
+func (i *K8sIntegration) collectTraefikLogs(ctx context.Context) {
+	since := metav1.NewTime(time.Now())
+	for {
+		next := metav1.NewTime(time.Now())
+		if err := i.streamTraefikLogs(ctx, since); err != nil {
+			logging.Debug("Traefik access log stream ended", "error", err)
+		}
+		since = next
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// streamTraefikLogs streams the logs of the running Traefik pod written since the given time
+func (i *K8sIntegration) streamTraefikLogs(ctx context.Context, since metav1.Time) error {
+	namespace := "kecs-system"
+	pods, err := i.kubeClient.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=traefik",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list Traefik pods: %w", err)
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		stream, err := i.kubeClient.CoreV1().Pods(namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+			Follow:    true,
+			SinceTime: &since,
+		}).Stream(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to stream logs of %s: %w", pod.Name, err)
+		}
+		defer stream.Close()
+		return i.consumeTraefikLogs(ctx, stream)
+	}
+	return fmt.Errorf("no running Traefik pod found")
+}
+
+// consumeTraefikLogs records every access log entry of Traefik log output in
+// the traffic counters and the access logs until the reader is exhausted
+func (i *K8sIntegration) consumeTraefikLogs(ctx context.Context, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		entry, ok := ParseTraefikAccessLog(scanner.Bytes())
+		if !ok {
+			continue
+		}
+		i.traffic.Record(entry)
+		i.mu.RLock()
+		shipper := i.accessLogs
+		i.mu.RUnlock()
+		if shipper != nil {
+			shipper.Record(ctx, entry)
+		}
+	}
+	return scanner.Err()
+}
+
+// targetGroupForTraefikService returns the ARN of the target group a Traefik service name refers to
+func (i *K8sIntegration) targetGroupForTraefikService(serviceName string) string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	for _, tg := range i.targetGroups {
+		name := "-tg-" + sanitizeName(tg.Name)
+		if strings.Contains(serviceName, name+"-") || strings.Contains(serviceName, name+"@") {
+			return tg.Arn
+		}
+	}
+	return ""
+}
+
 // SyncRulesToListener synchronizes ELBv2 rules to Traefik IngressRoute
 func (i *K8sIntegration) SyncRulesToListener(ctx context.Context, storageInstance interface{}, listenerArn string, lbName string, port int32) error {
 	// Cast storage to the correct type
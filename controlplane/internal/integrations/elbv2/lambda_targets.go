@@ -0,0 +1,314 @@
+package elbv2
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// DefaultLambdaTargetBasePort is the first port the control plane listens on
+// for the target groups of Lambda targets
+const DefaultLambdaTargetBasePort = 5380
+
+// maxLambdaTargetGroups bounds the ports tried above the base port
+const maxLambdaTargetGroups = 100
+
+// LambdaInvoker invokes Lambda functions synchronously
+type LambdaInvoker interface {
+	Invoke(ctx context.Context, functionArn string, payload []byte) ([]byte, error)
+}
+
+// lambdaInvoker invokes functions through the Lambda API of LocalStack
+type lambdaInvoker struct {
+	endpoint   string
+	httpClient *http.Client
+}
+
+// NewLambdaInvoker creates an invoker for the Lambda API at the endpoint
+func NewLambdaInvoker(endpoint string) LambdaInvoker {
+	if endpoint == "" {
+		// Use cluster-internal LocalStack service endpoint
+		endpoint = "http://localstack.kecs-system.svc.cluster.local:4566"
+	}
+	return &lambdaInvoker{
+		endpoint:   endpoint,
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Invoke calls the function with the payload and returns its response
+func (l *lambdaInvoker) Invoke(ctx context.Context, functionArn string, payload []byte) ([]byte, error) {
+	endpoint := fmt.Sprintf("%s/2015-03-31/functions/%s/invocations", l.endpoint, url.PathEscape(functionArn))
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Amz-Invocation-Type", "RequestResponse")
+
+	resp, err := l.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	if functionError := resp.Header.Get("X-Amz-Function-Error"); functionError != "" {
+		return nil, fmt.Errorf("function error %s: %s", functionError, string(body))
+	}
+	return body, nil
+}
+
+// albLambdaEvent is the event an Application Load Balancer invokes Lambda targets with
+type albLambdaEvent struct {
+	RequestContext struct {
+		ELB struct {
+			TargetGroupArn string `json:"targetGroupArn"`
+		} `json:"elb"`
+	} `json:"requestContext"`
+	HTTPMethod            string            `json:"httpMethod"`
+	Path                  string            `json:"path"`
+	QueryStringParameters map[string]string `json:"queryStringParameters"`
+	Headers               map[string]string `json:"headers"`
+	Body                  string            `json:"body"`
+	IsBase64Encoded       bool              `json:"isBase64Encoded"`
+}
+
+// albLambdaResponse is the response Lambda targets return to the load balancer
+type albLambdaResponse struct {
+	StatusCode        int                 `json:"statusCode"`
+	StatusDescription string              `json:"statusDescription"`
+	Headers           map[string]string   `json:"headers"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders"`
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded"`
+}
+
+// LambdaTargetHandler forwards HTTP requests to the Lambda function registered
+// with a target group, using the event format of Application Load Balancers
+type LambdaTargetHandler struct {
+	targetGroupArn string
+	invoker        LambdaInvoker
+
+	mu          sync.RWMutex
+	functionArn string
+}
+
+// NewLambdaTargetHandler creates a handler invoking the function for the target group
+func NewLambdaTargetHandler(targetGroupArn, functionArn string, invoker LambdaInvoker) *LambdaTargetHandler {
+	return &LambdaTargetHandler{
+		targetGroupArn: targetGroupArn,
+		invoker:        invoker,
+		functionArn:    functionArn,
+	}
+}
+
+// SetFunction replaces the function registered with the target group
+func (h *LambdaTargetHandler) SetFunction(functionArn string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.functionArn = functionArn
+}
+
+// ServeHTTP invokes the function with the request and writes its response.
+// Like ALB, failed invocations and malformed responses are answered with 502.
+func (h *LambdaTargetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	functionArn := h.functionArn
+	h.mu.RUnlock()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.invoke(r.Context(), functionArn, r, body)
+	if err != nil {
+		logging.Debug("Lambda target invocation failed", "targetGroupArn", h.targetGroupArn, "functionArn", functionArn, "error", err)
+		http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+		return
+	}
+
+	responseBody := []byte(result.Body)
+	if result.IsBase64Encoded {
+		responseBody, err = base64.StdEncoding.DecodeString(result.Body)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+			return
+		}
+	}
+	for name, value := range result.Headers {
+		w.Header().Set(name, value)
+	}
+	for name, values := range result.MultiValueHeaders {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+	w.WriteHeader(result.StatusCode)
+	w.Write(responseBody)
+}
+
+// invoke builds the ALB event for the request and decodes the function response
+func (h *LambdaTargetHandler) invoke(ctx context.Context, functionArn string, r *http.Request, body []byte) (*albLambdaResponse, error) {
+	event := albLambdaEvent{
+		HTTPMethod:            r.Method,
+		Path:                  r.URL.Path,
+		QueryStringParameters: make(map[string]string),
+		Headers:               make(map[string]string),
+	}
+	event.RequestContext.ELB.TargetGroupArn = h.targetGroupArn
+	for name, values := range r.URL.Query() {
+		event.QueryStringParameters[name] = values[len(values)-1]
+	}
+	for name, values := range r.Header {
+		event.Headers[strings.ToLower(name)] = values[len(values)-1]
+	}
+	if r.Host != "" {
+		event.Headers["host"] = r.Host
+	}
+	if utf8.Valid(body) {
+		event.Body = string(body)
+	} else {
+		event.Body = base64.StdEncoding.EncodeToString(body)
+		event.IsBase64Encoded = true
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event: %w", err)
+	}
+	response, err := h.invoker.Invoke(ctx, functionArn, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var result albLambdaResponse
+	if err := json.Unmarshal(response, &result); err != nil {
+		return nil, fmt.Errorf("invalid function response: %w", err)
+	}
+	if result.StatusCode < 100 || result.StatusCode > 599 {
+		return nil, fmt.Errorf("invalid status code %d in function response", result.StatusCode)
+	}
+	return &result, nil
+}
+
+// CheckHealth invokes the function with a health check request and reports
+// whether it answered with the expected status
+func (h *LambdaTargetHandler) CheckHealth(ctx context.Context, path string) bool {
+	h.mu.RLock()
+	functionArn := h.functionArn
+	h.mu.RUnlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return false
+	}
+	req.Header.Set("User-Agent", "ELB-HealthChecker/2.0")
+	result, err := h.invoke(ctx, functionArn, req, nil)
+	return err == nil && result.StatusCode == http.StatusOK
+}
+
+// lambdaTargetServer serves one target group of Lambda targets
+type lambdaTargetServer struct {
+	port    int
+	handler *LambdaTargetHandler
+	server  *http.Server
+}
+
+// LambdaTargetProxy serves each target group of Lambda targets on its own
+// port of the control plane, so the target group Service can route to it
+type LambdaTargetProxy struct {
+	invoker  LambdaInvoker
+	basePort int
+
+	mu      sync.Mutex
+	servers map[string]*lambdaTargetServer // targetGroupArn -> server
+}
+
+// NewLambdaTargetProxy creates a proxy that allocates ports from basePort
+func NewLambdaTargetProxy(invoker LambdaInvoker, basePort int) *LambdaTargetProxy {
+	return &LambdaTargetProxy{
+		invoker:  invoker,
+		basePort: basePort,
+		servers:  make(map[string]*lambdaTargetServer),
+	}
+}
+
+// Serve starts serving the target group with the function and returns its port
+func (p *LambdaTargetProxy) Serve(targetGroupArn, functionArn string) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if existing, ok := p.servers[targetGroupArn]; ok {
+		existing.handler.SetFunction(functionArn)
+		return existing.port, nil
+	}
+
+	used := make(map[int]bool)
+	for _, server := range p.servers {
+		used[server.port] = true
+	}
+	for port := p.basePort; port < p.basePort+maxLambdaTargetGroups; port++ {
+		if used[port] {
+			continue
+		}
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+		if err != nil {
+			continue
+		}
+		handler := NewLambdaTargetHandler(targetGroupArn, functionArn, p.invoker)
+		server := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+				logging.Warn("Lambda target server stopped", "targetGroupArn", targetGroupArn, "error", err)
+			}
+		}()
+		p.servers[targetGroupArn] = &lambdaTargetServer{port: port, handler: handler, server: server}
+		logging.Info("Serving Lambda target group", "targetGroupArn", targetGroupArn, "functionArn", functionArn, "port", port)
+		return port, nil
+	}
+	return 0, fmt.Errorf("no free port for Lambda target group %s", targetGroupArn)
+}
+
+// Stop stops serving the target group
+func (p *LambdaTargetProxy) Stop(targetGroupArn string) {
+	p.mu.Lock()
+	server, ok := p.servers[targetGroupArn]
+	delete(p.servers, targetGroupArn)
+	p.mu.Unlock()
+
+	if ok {
+		server.server.Close()
+	}
+}
+
+// Handler returns the handler of a served target group
+func (p *LambdaTargetProxy) Handler(targetGroupArn string) (*LambdaTargetHandler, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	server, ok := p.servers[targetGroupArn]
+	if !ok {
+		return nil, false
+	}
+	return server.handler, true
+}
@@ -0,0 +1,92 @@
+package elbv2_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/elbv2"
+)
+
+// fakeLambdaInvoker records the events it is invoked with and returns a fixed response
+type fakeLambdaInvoker struct {
+	functionArn string
+	event       map[string]interface{}
+	response    string
+	err         error
+}
+
+func (f *fakeLambdaInvoker) Invoke(ctx context.Context, functionArn string, payload []byte) ([]byte, error) {
+	f.functionArn = functionArn
+	if err := json.Unmarshal(payload, &f.event); err != nil {
+		return nil, err
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []byte(f.response), nil
+}
+
+var _ = Describe("LambdaTargetHandler", func() {
+	const (
+		tgArn       = "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/fn/73e2d6bc24d8a067"
+		functionArn = "arn:aws:lambda:us-east-1:123456789012:function:handler"
+	)
+
+	It("should invoke the function with an ALB event and return its response", func() {
+		invoker := &fakeLambdaInvoker{response: `{"statusCode":201,"headers":{"Content-Type":"text/plain"},"body":"created"}`}
+		handler := elbv2.NewLambdaTargetHandler(tgArn, functionArn, invoker)
+
+		req := httptest.NewRequest(http.MethodPost, "http://my-alb.example.com/items?color=red", strings.NewReader(`{"name":"a"}`))
+		req.Header.Set("X-Custom", "value")
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+
+		Expect(recorder.Code).To(Equal(http.StatusCreated))
+		Expect(recorder.Body.String()).To(Equal("created"))
+		Expect(recorder.Header().Get("Content-Type")).To(Equal("text/plain"))
+
+		Expect(invoker.functionArn).To(Equal(functionArn))
+		Expect(invoker.event["httpMethod"]).To(Equal("POST"))
+		Expect(invoker.event["path"]).To(Equal("/items"))
+		Expect(invoker.event["body"]).To(Equal(`{"name":"a"}`))
+		Expect(invoker.event["queryStringParameters"]).To(HaveKeyWithValue("color", "red"))
+		Expect(invoker.event["headers"]).To(HaveKeyWithValue("x-custom", "value"))
+		Expect(invoker.event["headers"]).To(HaveKeyWithValue("host", "my-alb.example.com"))
+		Expect(invoker.event["requestContext"]).To(HaveKeyWithValue("elb", HaveKeyWithValue("targetGroupArn", tgArn)))
+	})
+
+	It("should decode base64 encoded responses", func() {
+		invoker := &fakeLambdaInvoker{response: `{"statusCode":200,"body":"aGVsbG8=","isBase64Encoded":true}`}
+		handler := elbv2.NewLambdaTargetHandler(tgArn, functionArn, invoker)
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		Expect(recorder.Body.String()).To(Equal("hello"))
+	})
+
+	It("should answer 502 when the invocation fails", func() {
+		handler := elbv2.NewLambdaTargetHandler(tgArn, functionArn, &fakeLambdaInvoker{err: fmt.Errorf("function error")})
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		Expect(recorder.Code).To(Equal(http.StatusBadGateway))
+	})
+
+	It("should answer 502 for malformed responses", func() {
+		handler := elbv2.NewLambdaTargetHandler(tgArn, functionArn, &fakeLambdaInvoker{response: `"not an object"`})
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		Expect(recorder.Code).To(Equal(http.StatusBadGateway))
+	})
+})
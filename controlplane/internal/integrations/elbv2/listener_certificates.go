@@ -0,0 +1,357 @@
+package elbv2
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+const (
+	certificateArnAnnotation     = "kecs.io/elbv2-certificate-arn"
+	certificateDomainsAnnotation = "kecs.io/elbv2-certificate-domains"
+	listenerPortLabel            = "kecs.io/elbv2-listener-port"
+	listenerCertificateComponent = "elbv2-listener-certificate"
+)
+
+// ListenerCertificate is a certificate attached to an HTTPS or TLS listener
+type ListenerCertificate struct {
+	Arn     string
+	Default bool
+}
+
+// CertificateSyncable is an optional interface that integrations can implement
+// to terminate TLS with the certificates of a listener, selecting one per SNI hostname
+type CertificateSyncable interface {
+	// SyncListenerCertificates replaces the certificates served by the listener
+	SyncListenerCertificates(ctx context.Context, loadBalancerArn string, port int32, certificates []ListenerCertificate) error
+}
+
+// CertificateResolver looks up the domain names a certificate is valid for
+type CertificateResolver interface {
+	CertificateDomains(ctx context.Context, certificateArn string) ([]string, error)
+}
+
+// acmCertificateResolver resolves certificates through the ACM API of LocalStack
+type acmCertificateResolver struct {
+	endpoint   string
+	httpClient *http.Client
+}
+
+// NewACMCertificateResolver creates a resolver that describes certificates with ACM
+func NewACMCertificateResolver(endpoint string) CertificateResolver {
+	if endpoint == "" {
+		// Use cluster-internal LocalStack service endpoint
+		endpoint = "http://localstack.kecs-system.svc.cluster.local:4566"
+	}
+	return &acmCertificateResolver{
+		endpoint:   endpoint,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// CertificateDomains returns the domain name and subject alternative names of an ACM certificate
+func (r *acmCertificateResolver) CertificateDomains(ctx context.Context, certificateArn string) ([]string, error) {
+	body, err := json.Marshal(map[string]string{"CertificateArn": certificateArn})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", r.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "CertificateManager.DescribeCertificate")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read error response: %w", err)
+		}
+		if strings.Contains(string(body), "ResourceNotFoundException") {
+			return nil, fmt.Errorf("certificate not found: %s", certificateArn)
+		}
+		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Certificate struct {
+			DomainName              string   `json:"DomainName"`
+			SubjectAlternativeNames []string `json:"SubjectAlternativeNames"`
+		} `json:"Certificate"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return uniqueDomains(append([]string{result.Certificate.DomainName}, result.Certificate.SubjectAlternativeNames...)), nil
+}
+
+// SetCertificateResolver sets the resolver used to look up the domains of listener certificates
+func (i *K8sIntegration) SetCertificateResolver(resolver CertificateResolver) {
+	i.certificateResolver = resolver
+}
+
+// SyncListenerCertificates makes Traefik terminate TLS for the listener with a
+// certificate per attached ACM certificate. Each certificate is issued for the
+// domains of the ACM certificate, so Traefik picks it by SNI, and the default
+// certificate also covers the load balancer DNS name. The domains are added as
+// hosts of the listener Ingress so that they reach the default actions.
+func (i *K8sIntegration) SyncListenerCertificates(ctx context.Context, loadBalancerArn string, port int32, certificates []ListenerCertificate) error {
+	if i.kubeClient == nil {
+		logging.Debug("No kubeClient available, skipping listener certificate sync")
+		return nil
+	}
+
+	lbName := ""
+	if parts := strings.Split(loadBalancerArn, "/"); len(parts) >= 3 {
+		lbName = parts[len(parts)-2]
+	} else {
+		return fmt.Errorf("invalid load balancer ARN format: %s", loadBalancerArn)
+	}
+
+	namespace := "kecs-system"
+	name := fmt.Sprintf("alb-%s-port-%d", sanitizeName(lbName), port)
+
+	ingress, err := i.kubeClient.NetworkingV1().Ingresses(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			logging.Debug("No Ingress for listener, skipping listener certificate sync", "name", name)
+			return nil
+		}
+		return fmt.Errorf("failed to get Ingress: %w", err)
+	}
+	lbDNSName := ingress.Annotations["kecs.io/elbv2-dns-name"]
+
+	var tls []networkingv1.IngressTLS
+	secrets := make(map[string]bool)
+	for _, cert := range certificates {
+		var domains []string
+		if i.certificateResolver != nil {
+			domains, err = i.certificateResolver.CertificateDomains(ctx, cert.Arn)
+			if err != nil {
+				logging.Debug("Failed to resolve certificate domains", "certificateArn", cert.Arn, "error", err)
+			}
+		}
+		if cert.Default && lbDNSName != "" {
+			domains = uniqueDomains(append(domains, lbDNSName))
+		}
+		if len(domains) == 0 {
+			logging.Debug("Certificate has no known domains, not serving it", "certificateArn", cert.Arn)
+			continue
+		}
+
+		secretName := fmt.Sprintf("%s-cert-%s", name, sanitizeName(resourceID(cert.Arn)))
+		if err := i.ensureCertificateSecret(ctx, namespace, secretName, lbName, port, cert.Arn, domains); err != nil {
+			return err
+		}
+		secrets[secretName] = true
+		tls = append(tls, networkingv1.IngressTLS{Hosts: domains, SecretName: secretName})
+	}
+
+	if ingress.Annotations == nil {
+		ingress.Annotations = make(map[string]string)
+	}
+	if len(tls) > 0 {
+		ingress.Annotations["traefik.ingress.kubernetes.io/router.entrypoints"] = "websecure"
+		ingress.Annotations["traefik.ingress.kubernetes.io/router.tls"] = "true"
+	} else {
+		delete(ingress.Annotations, "traefik.ingress.kubernetes.io/router.entrypoints")
+		delete(ingress.Annotations, "traefik.ingress.kubernetes.io/router.tls")
+	}
+	ingress.Spec.TLS = tls
+	ingress.Spec.Rules = listenerIngressRules(ingress.Spec.Rules, lbDNSName, tls)
+	if _, err := i.kubeClient.NetworkingV1().Ingresses(namespace).Update(ctx, ingress, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update Ingress TLS: %w", err)
+	}
+
+	i.deleteListenerCertificateSecrets(ctx, lbName, port, secrets)
+
+	logging.Info("Configured listener certificates",
+		"loadBalancer", lbName,
+		"port", port,
+		"certificates", len(tls))
+	return nil
+}
+
+// ensureCertificateSecret creates the TLS secret of a listener certificate,
+// reissuing it only when the domains of the certificate changed
+func (i *K8sIntegration) ensureCertificateSecret(ctx context.Context, namespace, secretName, lbName string, port int32, certificateArn string, domains []string) error {
+	domainList := strings.Join(domains, ",")
+	existing, err := i.kubeClient.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to get certificate secret: %w", err)
+	}
+	found := err == nil
+	if found && existing.Annotations[certificateDomainsAnnotation] == domainList {
+		return nil
+	}
+
+	certPEM, keyPEM, err := issueCertificate(domains)
+	if err != nil {
+		return fmt.Errorf("failed to issue certificate for %s: %w", certificateArn, err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: namespace,
+			Annotations: map[string]string{
+				certificateArnAnnotation:     certificateArn,
+				certificateDomainsAnnotation: domainList,
+			},
+			Labels: map[string]string{
+				"kecs.io/elbv2-load-balancer": lbName,
+				"kecs.io/component":           listenerCertificateComponent,
+				listenerPortLabel:             fmt.Sprintf("%d", port),
+			},
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       certPEM,
+			corev1.TLSPrivateKeyKey: keyPEM,
+		},
+	}
+
+	if found {
+		existing.Annotations = secret.Annotations
+		existing.Labels = secret.Labels
+		existing.Data = secret.Data
+		if _, err := i.kubeClient.CoreV1().Secrets(namespace).Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update certificate secret: %w", err)
+		}
+	} else if _, err := i.kubeClient.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create certificate secret: %w", err)
+	}
+
+	logging.Debug("Issued listener certificate", "secretName", secretName, "certificateArn", certificateArn, "domains", domainList)
+	return nil
+}
+
+// deleteListenerCertificateSecrets deletes the certificate secrets of a listener that are not kept
+func (i *K8sIntegration) deleteListenerCertificateSecrets(ctx context.Context, lbName string, port int32, keep map[string]bool) {
+	if i.kubeClient == nil {
+		return
+	}
+	namespace := "kecs-system"
+	selector := fmt.Sprintf("kecs.io/component=%s,kecs.io/elbv2-load-balancer=%s,%s=%d", listenerCertificateComponent, lbName, listenerPortLabel, port)
+	list, err := i.kubeClient.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		logging.Debug("Failed to list listener certificate secrets", "error", err)
+		return
+	}
+	for _, secret := range list.Items {
+		if keep[secret.Name] {
+			continue
+		}
+		if err := i.kubeClient.CoreV1().Secrets(namespace).Delete(ctx, secret.Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			logging.Debug("Failed to delete listener certificate secret", "name", secret.Name, "error", err)
+		}
+	}
+}
+
+// listenerIngressRules routes the hosts of the listener certificates to the same
+// backend as the load balancer DNS name, dropping hosts of removed certificates
+func listenerIngressRules(rules []networkingv1.IngressRule, lbDNSName string, tls []networkingv1.IngressTLS) []networkingv1.IngressRule {
+	if len(rules) == 0 {
+		return rules
+	}
+	base := rules[0]
+	for _, rule := range rules {
+		if rule.Host == lbDNSName {
+			base = rule
+			break
+		}
+	}
+
+	result := []networkingv1.IngressRule{base}
+	seen := map[string]bool{base.Host: true}
+	for _, entry := range tls {
+		for _, host := range entry.Hosts {
+			if seen[host] {
+				continue
+			}
+			seen[host] = true
+			result = append(result, networkingv1.IngressRule{Host: host, IngressRuleValue: base.IngressRuleValue})
+		}
+	}
+	return result
+}
+
+// issueCertificate issues a self-signed certificate for the domains. ACM does
+// not export the private keys of its certificates, so KECS serves certificates
+// with the same names instead.
+func issueCertificate(domains []string) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: domains[0], Organization: []string{"KECS"}},
+		DNSNames:              domains,
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
+
+// uniqueDomains returns the non-empty domains in order, without duplicates
+func uniqueDomains(domains []string) []string {
+	seen := make(map[string]bool)
+	result := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if domain == "" || seen[domain] {
+			continue
+		}
+		seen[domain] = true
+		result = append(result, domain)
+	}
+	return result
+}
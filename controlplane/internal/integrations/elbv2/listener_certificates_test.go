@@ -0,0 +1,142 @@
+package elbv2_test
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/elbv2"
+)
+
+// fakeCertificateResolver returns the domains of known certificates
+type fakeCertificateResolver map[string][]string
+
+func (f fakeCertificateResolver) CertificateDomains(ctx context.Context, certificateArn string) ([]string, error) {
+	domains, ok := f[certificateArn]
+	if !ok {
+		return nil, fmt.Errorf("certificate not found: %s", certificateArn)
+	}
+	return domains, nil
+}
+
+var _ = Describe("Listener certificates", func() {
+	const (
+		lbArn       = "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/my-alb/50dc6c495c0c9188"
+		dnsName     = "my-alb-123.us-east-1.elb.amazonaws.com"
+		ingressName = "alb-my-alb-port-443"
+		defaultCert = "arn:aws:acm:us-east-1:123456789012:certificate/default"
+		apiCert     = "arn:aws:acm:us-east-1:123456789012:certificate/api"
+	)
+
+	var (
+		ctx         context.Context
+		client      *fake.Clientset
+		integration *elbv2.K8sIntegration
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		pathType := networkingv1.PathTypePrefix
+		client = fake.NewSimpleClientset(&networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        ingressName,
+				Namespace:   "kecs-system",
+				Annotations: map[string]string{"kecs.io/elbv2-dns-name": dnsName},
+			},
+			Spec: networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{{
+				Host: dnsName,
+				IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{{
+						Path:     "/",
+						PathType: &pathType,
+						Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+							Name: "tg-web",
+							Port: networkingv1.ServiceBackendPort{Number: 443},
+						}},
+					}},
+				}},
+			}}},
+		})
+
+		integration = elbv2.NewK8sIntegration("us-east-1", "123456789012")
+		integration.SetKubernetesClients(client, nil)
+		integration.SetCertificateResolver(fakeCertificateResolver{
+			defaultCert: {"www.example.com"},
+			apiCert:     {"api.example.com", "*.api.example.com"},
+		})
+	})
+
+	secretDNSNames := func(name string) []string {
+		secret, err := client.CoreV1().Secrets("kecs-system").Get(ctx, name, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(secret.Type).To(Equal(corev1.SecretTypeTLS))
+		block, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+		Expect(block).NotTo(BeNil())
+		cert, err := x509.ParseCertificate(block.Bytes)
+		Expect(err).NotTo(HaveOccurred())
+		return cert.DNSNames
+	}
+
+	It("should serve a certificate per SNI hostname", func() {
+		err := integration.SyncListenerCertificates(ctx, lbArn, 443, []elbv2.ListenerCertificate{
+			{Arn: defaultCert, Default: true},
+			{Arn: apiCert},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		ingress, err := client.NetworkingV1().Ingresses("kecs-system").Get(ctx, ingressName, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ingress.Annotations).To(HaveKeyWithValue("traefik.ingress.kubernetes.io/router.tls", "true"))
+		Expect(ingress.Spec.TLS).To(HaveLen(2))
+		Expect(ingress.Spec.TLS[0].Hosts).To(Equal([]string{"www.example.com", dnsName}))
+		Expect(ingress.Spec.TLS[1].Hosts).To(Equal([]string{"api.example.com", "*.api.example.com"}))
+
+		hosts := []string{}
+		for _, rule := range ingress.Spec.Rules {
+			Expect(rule.HTTP.Paths[0].Backend.Service.Name).To(Equal("tg-web"))
+			hosts = append(hosts, rule.Host)
+		}
+		Expect(hosts).To(ConsistOf(dnsName, "www.example.com", "api.example.com", "*.api.example.com"))
+
+		Expect(secretDNSNames(ingress.Spec.TLS[0].SecretName)).To(ConsistOf("www.example.com", dnsName))
+		Expect(secretDNSNames(ingress.Spec.TLS[1].SecretName)).To(ConsistOf("api.example.com", "*.api.example.com"))
+	})
+
+	It("should drop the certificates that were removed", func() {
+		Expect(integration.SyncListenerCertificates(ctx, lbArn, 443, []elbv2.ListenerCertificate{
+			{Arn: defaultCert, Default: true},
+			{Arn: apiCert},
+		})).To(Succeed())
+		Expect(integration.SyncListenerCertificates(ctx, lbArn, 443, []elbv2.ListenerCertificate{
+			{Arn: defaultCert, Default: true},
+		})).To(Succeed())
+
+		ingress, err := client.NetworkingV1().Ingresses("kecs-system").Get(ctx, ingressName, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ingress.Spec.TLS).To(HaveLen(1))
+		Expect(ingress.Spec.Rules).To(HaveLen(2))
+
+		secrets, err := client.CoreV1().Secrets("kecs-system").List(ctx, metav1.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(secrets.Items).To(HaveLen(1))
+	})
+
+	It("should skip certificates whose domains are unknown", func() {
+		Expect(integration.SyncListenerCertificates(ctx, lbArn, 443, []elbv2.ListenerCertificate{
+			{Arn: defaultCert, Default: true},
+			{Arn: "arn:aws:acm:us-east-1:123456789012:certificate/missing"},
+		})).To(Succeed())
+
+		ingress, err := client.NetworkingV1().Ingresses("kecs-system").Get(ctx, ingressName, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ingress.Spec.TLS).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,273 @@
+package elbv2
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// Target types of target groups
+const (
+	TargetTypeInstance = "instance"
+	TargetTypeIP       = "ip"
+	TargetTypeLambda   = "lambda"
+	TargetTypeALB      = "alb"
+)
+
+// ValidateTargetType checks that a target group target type is supported
+func ValidateTargetType(targetType string) error {
+	switch targetType {
+	case TargetTypeInstance, TargetTypeIP, TargetTypeLambda, TargetTypeALB:
+		return nil
+	}
+	return fmt.Errorf("unsupported target type %q", targetType)
+}
+
+// ValidateTargets checks targets against the constraints ELBv2 enforces for the target type
+func ValidateTargets(targetType string, targets []Target) error {
+	switch targetType {
+	case TargetTypeIP:
+		for _, target := range targets {
+			if net.ParseIP(target.Id) == nil {
+				return fmt.Errorf("target %s is not a valid IP address", target.Id)
+			}
+		}
+	case TargetTypeLambda:
+		if len(targets) > 1 {
+			return fmt.Errorf("a target group of type lambda can have only one target")
+		}
+		for _, target := range targets {
+			if !awsarn.IsService(target.Id, "lambda") {
+				return fmt.Errorf("target %s is not a Lambda function ARN", target.Id)
+			}
+			if target.Port != 0 {
+				return fmt.Errorf("a port cannot be specified for Lambda targets")
+			}
+		}
+	}
+	return nil
+}
+
+// ManualTargetSyncable is an optional interface that integrations can implement
+// to route target groups of ip and lambda targets registered with RegisterTargets
+type ManualTargetSyncable interface {
+	// SyncManualTargets routes the target group to the registered targets
+	SyncManualTargets(ctx context.Context, targetGroupArn, targetType string, port int32, targets []Target) error
+	// CheckLambdaTargetHealth invokes the Lambda target of a target group with a health check request
+	CheckLambdaTargetHealth(ctx context.Context, targetGroupArn, path string) (string, error)
+}
+
+// SetLambdaInvoker sets the invoker used to forward requests to Lambda targets
+func (i *K8sIntegration) SetLambdaInvoker(invoker LambdaInvoker) {
+	i.lambdaTargets = NewLambdaTargetProxy(invoker, DefaultLambdaTargetBasePort)
+}
+
+// SyncManualTargets replaces the target group Service in kecs-system, which the
+// listener Ingress routes to, with one that reaches the registered targets.
+// IP targets become the endpoints of a Service without selector. Lambda targets
+// are served by a proxy in the control plane that invokes the function.
+func (i *K8sIntegration) SyncManualTargets(ctx context.Context, targetGroupArn, targetType string, port int32, targets []Target) error {
+	tgName := extractTargetGroupName(targetGroupArn)
+	if tgName == "" {
+		return fmt.Errorf("invalid target group ARN format: %s", targetGroupArn)
+	}
+
+	var selector map[string]string
+	var targetPort int32
+	switch targetType {
+	case TargetTypeIP:
+	case TargetTypeLambda:
+		if i.lambdaTargets == nil {
+			return fmt.Errorf("Lambda integration is not available, cannot route to Lambda targets")
+		}
+		if len(targets) == 0 {
+			i.lambdaTargets.Stop(targetGroupArn)
+			break
+		}
+		proxyPort, err := i.lambdaTargets.Serve(targetGroupArn, targets[0].Id)
+		if err != nil {
+			return err
+		}
+		selector = map[string]string{"app": "kecs-server"}
+		targetPort = int32(proxyPort)
+	default:
+		return nil
+	}
+
+	if i.kubeClient == nil {
+		logging.Debug("No kubeClient available, skipping target group Service sync", "targetGroupArn", targetGroupArn)
+		return nil
+	}
+
+	namespace := "kecs-system"
+	serviceName := fmt.Sprintf("tg-%s", tgName)
+	ports := i.targetGroupServicePorts(targetGroupArn, port)
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      serviceName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"kecs.io/component":    "elbv2-target",
+				"kecs.io/target-group": tgName,
+				"kecs.io/target-type":  targetType,
+			},
+			Annotations: map[string]string{
+				"kecs.io/elbv2-target-group-arn": targetGroupArn,
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: selector,
+		},
+	}
+	for _, servicePort := range ports {
+		backendPort := targetPort
+		if backendPort == 0 {
+			backendPort = servicePort
+		}
+		service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{
+			Name:       fmt.Sprintf("port-%d", servicePort),
+			Port:       servicePort,
+			TargetPort: intstr.FromInt(int(backendPort)),
+			Protocol:   corev1.ProtocolTCP,
+		})
+	}
+
+	existing, err := i.kubeClient.CoreV1().Services(namespace).Get(ctx, serviceName, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get target group Service: %w", err)
+		}
+		if _, err := i.kubeClient.CoreV1().Services(namespace).Create(ctx, service, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create target group Service: %w", err)
+		}
+	} else {
+		// Replaces the ExternalName Service used for ECS managed targets
+		existing.Labels = service.Labels
+		existing.Annotations = service.Annotations
+		existing.Spec.Type = service.Spec.Type
+		existing.Spec.ExternalName = ""
+		existing.Spec.Selector = service.Spec.Selector
+		existing.Spec.Ports = service.Spec.Ports
+		if _, err := i.kubeClient.CoreV1().Services(namespace).Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update target group Service: %w", err)
+		}
+	}
+
+	if targetType == TargetTypeIP {
+		if err := i.syncTargetEndpoints(ctx, namespace, serviceName, service.Labels, ports, targets); err != nil {
+			return err
+		}
+	}
+
+	logging.Info("Synced manually registered targets",
+		"targetGroup", tgName,
+		"targetType", targetType,
+		"targets", len(targets))
+	return nil
+}
+
+// syncTargetEndpoints sets the endpoints of a Service without selector to the IP targets
+func (i *K8sIntegration) syncTargetEndpoints(ctx context.Context, namespace, serviceName string, labels map[string]string, ports []int32, targets []Target) error {
+	// Targets can listen on different ports, so group them by port
+	byPort := make(map[int32][]corev1.EndpointAddress)
+	for _, target := range targets {
+		byPort[target.Port] = append(byPort[target.Port], corev1.EndpointAddress{IP: target.Id})
+	}
+	targetPorts := make([]int32, 0, len(byPort))
+	for targetPort := range byPort {
+		targetPorts = append(targetPorts, targetPort)
+	}
+	sort.Slice(targetPorts, func(a, b int) bool { return targetPorts[a] < targetPorts[b] })
+
+	endpoints := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      serviceName,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+	}
+	for _, targetPort := range targetPorts {
+		subset := corev1.EndpointSubset{Addresses: byPort[targetPort]}
+		for _, servicePort := range ports {
+			subset.Ports = append(subset.Ports, corev1.EndpointPort{
+				Name:     fmt.Sprintf("port-%d", servicePort),
+				Port:     targetPort,
+				Protocol: corev1.ProtocolTCP,
+			})
+		}
+		endpoints.Subsets = append(endpoints.Subsets, subset)
+	}
+
+	existing, err := i.kubeClient.CoreV1().Endpoints(namespace).Get(ctx, serviceName, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get target group endpoints: %w", err)
+		}
+		if _, err := i.kubeClient.CoreV1().Endpoints(namespace).Create(ctx, endpoints, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create target group endpoints: %w", err)
+		}
+		return nil
+	}
+	existing.Labels = endpoints.Labels
+	existing.Subsets = endpoints.Subsets
+	if _, err := i.kubeClient.CoreV1().Endpoints(namespace).Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update target group endpoints: %w", err)
+	}
+	return nil
+}
+
+// targetGroupServicePorts returns the ports the target group Service must expose:
+// the target group port, used by rules and weighted forwarding, and the port of
+// each listener forwarding to the target group, used by the listener Ingress
+func (i *K8sIntegration) targetGroupServicePorts(targetGroupArn string, port int32) []int32 {
+	seen := make(map[int32]bool)
+	if port > 0 {
+		seen[port] = true
+	}
+
+	i.mu.RLock()
+	for _, listener := range i.listeners {
+		for _, action := range listener.DefaultActions {
+			if action.TargetGroupArn == targetGroupArn {
+				seen[listener.Port] = true
+			}
+		}
+	}
+	i.mu.RUnlock()
+
+	if len(seen) == 0 {
+		seen[80] = true
+	}
+	ports := make([]int32, 0, len(seen))
+	for p := range seen {
+		ports = append(ports, p)
+	}
+	sort.Slice(ports, func(a, b int) bool { return ports[a] < ports[b] })
+	return ports
+}
+
+// CheckLambdaTargetHealth invokes the Lambda target of a target group with a health check request
+func (i *K8sIntegration) CheckLambdaTargetHealth(ctx context.Context, targetGroupArn, path string) (string, error) {
+	if i.lambdaTargets == nil {
+		return "", fmt.Errorf("Lambda integration is not available")
+	}
+	handler, ok := i.lambdaTargets.Handler(targetGroupArn)
+	if !ok {
+		return "", fmt.Errorf("target group %s has no Lambda target", targetGroupArn)
+	}
+	if handler.CheckHealth(ctx, path) {
+		return "healthy", nil
+	}
+	return "unhealthy", nil
+}
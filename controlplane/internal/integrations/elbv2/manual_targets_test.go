@@ -0,0 +1,91 @@
+package elbv2_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/elbv2"
+)
+
+var _ = Describe("Manual targets", func() {
+	const tgArn = "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/external/73e2d6bc24d8a067"
+
+	Describe("ValidateTargets", func() {
+		It("should require IP addresses for ip targets", func() {
+			Expect(elbv2.ValidateTargets(elbv2.TargetTypeIP, []elbv2.Target{{Id: "10.0.0.1", Port: 80}})).To(Succeed())
+			Expect(elbv2.ValidateTargets(elbv2.TargetTypeIP, []elbv2.Target{{Id: "my-host", Port: 80}})).NotTo(Succeed())
+		})
+
+		It("should require a single Lambda function without port", func() {
+			fn := "arn:aws:lambda:us-east-1:123456789012:function:handler"
+			Expect(elbv2.ValidateTargets(elbv2.TargetTypeLambda, []elbv2.Target{{Id: fn}})).To(Succeed())
+			Expect(elbv2.ValidateTargets(elbv2.TargetTypeLambda, []elbv2.Target{{Id: fn, Port: 80}})).NotTo(Succeed())
+			Expect(elbv2.ValidateTargets(elbv2.TargetTypeLambda, []elbv2.Target{{Id: fn}, {Id: fn + "2"}})).NotTo(Succeed())
+			Expect(elbv2.ValidateTargets(elbv2.TargetTypeLambda, []elbv2.Target{{Id: "10.0.0.1"}})).NotTo(Succeed())
+		})
+	})
+
+	Describe("SyncManualTargets", func() {
+		var (
+			ctx         context.Context
+			client      *fake.Clientset
+			integration *elbv2.K8sIntegration
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			// The Service created for ECS managed targets
+			client = fake.NewSimpleClientset(&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "tg-external", Namespace: "kecs-system"},
+				Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: "tg-external.default.svc.cluster.local"},
+			})
+			integration = elbv2.NewK8sIntegration("us-east-1", "123456789012")
+			integration.SetKubernetesClients(client, nil)
+		})
+
+		It("should route to the registered IP addresses", func() {
+			err := integration.SyncManualTargets(ctx, tgArn, elbv2.TargetTypeIP, 8080, []elbv2.Target{
+				{Id: "192.168.1.10", Port: 8080},
+				{Id: "192.168.1.11", Port: 8080},
+				{Id: "10.0.0.5", Port: 9000},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			service, err := client.CoreV1().Services("kecs-system").Get(ctx, "tg-external", metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(service.Spec.Type).To(Equal(corev1.ServiceTypeClusterIP))
+			Expect(service.Spec.Selector).To(BeEmpty())
+			Expect(service.Spec.Ports).To(HaveLen(1))
+			Expect(service.Spec.Ports[0].Port).To(Equal(int32(8080)))
+
+			endpoints, err := client.CoreV1().Endpoints("kecs-system").Get(ctx, "tg-external", metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(endpoints.Subsets).To(HaveLen(2))
+			Expect(endpoints.Subsets[0].Ports[0].Port).To(Equal(int32(8080)))
+			Expect(endpoints.Subsets[0].Addresses).To(HaveLen(2))
+			Expect(endpoints.Subsets[1].Ports[0].Port).To(Equal(int32(9000)))
+			Expect(endpoints.Subsets[1].Addresses[0].IP).To(Equal("10.0.0.5"))
+		})
+
+		It("should remove deregistered IP addresses", func() {
+			Expect(integration.SyncManualTargets(ctx, tgArn, elbv2.TargetTypeIP, 80, []elbv2.Target{{Id: "192.168.1.10", Port: 80}})).To(Succeed())
+			Expect(integration.SyncManualTargets(ctx, tgArn, elbv2.TargetTypeIP, 80, nil)).To(Succeed())
+
+			endpoints, err := client.CoreV1().Endpoints("kecs-system").Get(ctx, "tg-external", metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(endpoints.Subsets).To(BeEmpty())
+		})
+
+		It("should require the Lambda integration for Lambda targets", func() {
+			err := integration.SyncManualTargets(ctx, tgArn, elbv2.TargetTypeLambda, 0, []elbv2.Target{
+				{Id: "arn:aws:lambda:us-east-1:123456789012:function:handler"},
+			})
+			Expect(err).To(MatchError(ContainSubstring("Lambda integration is not available")))
+		})
+	})
+})
@@ -120,13 +120,44 @@ func (p *PriorityManager) SetRulePriorities(ctx context.Context, priorities []Ru
 		priorityMap[update.Priority] = update.RuleArn
 	}
 
-	// Update each rule
+	// Load every rule before changing anything so that a missing rule or a
+	// conflict leaves all priorities untouched
+	rules := make([]*storage.ELBv2Rule, 0, len(priorities))
+	listeners := make(map[string]bool)
 	for _, update := range priorities {
 		rule, err := p.store.GetRule(ctx, update.RuleArn)
 		if err != nil {
 			return fmt.Errorf("failed to get rule %s: %w", update.RuleArn, err)
 		}
+		if rule == nil {
+			return fmt.Errorf("rule not found: %s", update.RuleArn)
+		}
+		if rule.IsDefault {
+			return fmt.Errorf("cannot set the priority of default rule %s", update.RuleArn)
+		}
+		rules = append(rules, rule)
+		listeners[rule.ListenerArn] = true
+	}
+
+	// Priorities must not collide with rules that are not part of the update
+	for listenerArn := range listeners {
+		existing, err := p.store.ListRules(ctx, listenerArn)
+		if err != nil {
+			return fmt.Errorf("failed to list rules: %w", err)
+		}
+		for _, rule := range existing {
+			if rule.IsDefault {
+				continue
+			}
+			if _, ok := priorityMap[rule.Priority]; ok && !containsRule(priorities, rule.ARN) {
+				return fmt.Errorf("priority %d is already in use by rule %s", rule.Priority, rule.ARN)
+			}
+		}
+	}
 
+	// Update each rule
+	for i, update := range priorities {
+		rule := rules[i]
 		rule.Priority = update.Priority
 		if err := p.store.UpdateRule(ctx, rule); err != nil {
 			return fmt.Errorf("failed to update rule %s: %w", update.RuleArn, err)
@@ -142,6 +173,16 @@ type RulePriorityUpdate struct {
 	Priority int32
 }
 
+// containsRule reports whether the updates include the rule
+func containsRule(updates []RulePriorityUpdate, ruleArn string) bool {
+	for _, update := range updates {
+		if update.RuleArn == ruleArn {
+			return true
+		}
+	}
+	return false
+}
+
 // AnalyzeRulePriorities analyzes rule priority distribution
 func (p *PriorityManager) AnalyzeRulePriorities(ctx context.Context, listenerArn string) (*PriorityAnalysis, error) {
 	rules, err := p.store.ListRules(ctx, listenerArn)
@@ -234,6 +234,30 @@ var _ = Describe("PriorityManager", func() {
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("duplicate priority"))
 		})
+
+		It("should swap priorities between rules in the same request", func() {
+			updates := []elbv2.RulePriorityUpdate{
+				{RuleArn: "rule1", Priority: 200},
+				{RuleArn: "rule2", Priority: 100},
+			}
+
+			err := manager.SetRulePriorities(ctx, updates)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(store.rules["rule1"].Priority).To(Equal(int32(200)))
+			Expect(store.rules["rule2"].Priority).To(Equal(int32(100)))
+		})
+
+		It("should reject priorities used by rules outside the request", func() {
+			updates := []elbv2.RulePriorityUpdate{
+				{RuleArn: "rule1", Priority: 200},
+			}
+
+			err := manager.SetRulePriorities(ctx, updates)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("already in use"))
+			Expect(store.rules["rule1"].Priority).To(Equal(int32(100)))
+		})
 	})
 
 	Describe("AnalyzeRulePriorities", func() {
@@ -68,7 +68,7 @@ func (c *RuleConverter) convertConditionToMatch(condition *generated_elbv2.RuleC
 	}
 
 	if condition.QueryStringConfig != nil && len(condition.QueryStringConfig.Values) > 0 {
-		return c.convertQueryStringToMatch(condition.QueryStringConfig.Values), nil
+		return c.convertQueryStringToMatch(condition.QueryStringConfig.Values)
 	}
 
 	if condition.SourceIpConfig != nil && len(condition.SourceIpConfig.Values) > 0 {
@@ -106,14 +106,14 @@ func (c *RuleConverter) convertPathPatternToMatch(patterns []string) string {
 		// Convert AWS path patterns to Traefik format
 		if pattern == "*" || pattern == "/*" {
 			pathMatches = append(pathMatches, "PathPrefix(`/`)")
-		} else if strings.HasSuffix(pattern, "*") {
+		} else if strings.HasSuffix(pattern, "*") && !strings.ContainsAny(strings.TrimSuffix(pattern, "*"), "*?") {
 			// Remove trailing * and use PathPrefix
 			prefix := strings.TrimSuffix(pattern, "*")
 			pathMatches = append(pathMatches, fmt.Sprintf("PathPrefix(`%s`)", prefix))
-		} else if strings.Contains(pattern, "*") {
+		} else if strings.ContainsAny(pattern, "*?") {
 			// Complex pattern with wildcards - use PathRegexp
-			// Convert * to .* for regex
-			regexPattern := strings.ReplaceAll(pattern, "*", ".*")
+			// Convert * to .* and ? to . for regex
+			regexPattern := strings.NewReplacer("*", ".*", "?", ".").Replace(pattern)
 			pathMatches = append(pathMatches, fmt.Sprintf("PathRegexp(`^%s$`)", regexPattern))
 		} else {
 			// Exact path match
@@ -137,9 +137,9 @@ func (c *RuleConverter) convertHostHeaderToMatch(hosts []string) string {
 
 	var hostMatches []string
 	for _, host := range hosts {
-		if strings.Contains(host, "*") {
+		if strings.ContainsAny(host, "*?") {
 			// Wildcard host - use HostRegexp
-			regexPattern := strings.ReplaceAll(host, "*", "[^.]+")
+			regexPattern := strings.NewReplacer("*", "[^.]+", "?", "[^.]").Replace(host)
 			hostMatches = append(hostMatches, fmt.Sprintf("HostRegexp(`^%s$`)", regexPattern))
 		} else {
 			// Exact host match
@@ -165,9 +165,9 @@ func (c *RuleConverter) convertHttpHeaderToMatch(config *generated_elbv2.HttpHea
 	headerName := *config.HttpHeaderName
 
 	for _, value := range config.Values {
-		if strings.Contains(value, "*") {
+		if strings.ContainsAny(value, "*?") {
 			// Wildcard value - use HeaderRegexp
-			regexPattern := strings.ReplaceAll(value, "*", ".*")
+			regexPattern := strings.NewReplacer("*", ".*", "?", ".").Replace(value)
 			headerMatches = append(headerMatches, fmt.Sprintf("HeaderRegexp(`%s`, `^%s$`)", headerName, regexPattern))
 		} else {
 			// Exact header match
@@ -203,30 +203,38 @@ func (c *RuleConverter) convertHttpMethodToMatch(methods []string) string {
 }
 
 // convertQueryStringToMatch converts query string conditions to Traefik query matchers
-func (c *RuleConverter) convertQueryStringToMatch(values []generated_elbv2.QueryStringKeyValuePair) string {
+func (c *RuleConverter) convertQueryStringToMatch(values []generated_elbv2.QueryStringKeyValuePair) (string, error) {
 	if len(values) == 0 {
-		return ""
+		return "", nil
 	}
 
 	var queryMatches []string
 	for _, kv := range values {
-		if kv.Key != nil {
-			if kv.Value != nil {
-				// Key-value pair
-				queryMatches = append(queryMatches, fmt.Sprintf("Query(`%s=%s`)", *kv.Key, *kv.Value))
-			} else {
-				// Key exists (any value)
-				queryMatches = append(queryMatches, fmt.Sprintf("Query(`%s`)", *kv.Key))
-			}
+		if kv.Key == nil || *kv.Key == "" {
+			// Traefik can only match query parameters by name. Skipping the value
+			// could leave the rule without a match, which routes all traffic.
+			return "", fmt.Errorf("query string conditions without a key are not supported")
+		}
+		switch {
+		case kv.Value == nil:
+			// Key exists (any value)
+			queryMatches = append(queryMatches, fmt.Sprintf("Query(`%s`)", *kv.Key))
+		case strings.ContainsAny(*kv.Value, "*?"):
+			// Wildcard value - use QueryRegexp
+			regexPattern := strings.NewReplacer("*", ".*", "?", ".").Replace(*kv.Value)
+			queryMatches = append(queryMatches, fmt.Sprintf("QueryRegexp(`%s`, `^%s$`)", *kv.Key, regexPattern))
+		default:
+			// Key-value pair
+			queryMatches = append(queryMatches, fmt.Sprintf("Query(`%s`, `%s`)", *kv.Key, *kv.Value))
 		}
 	}
 
 	if len(queryMatches) == 1 {
-		return queryMatches[0]
+		return queryMatches[0], nil
 	}
 
-	// Multiple query conditions - combine with AND (all must match)
-	return fmt.Sprintf("(%s)", strings.Join(queryMatches, " && "))
+	// Multiple query conditions - combine with OR (any pair must match)
+	return fmt.Sprintf("(%s)", strings.Join(queryMatches, " || ")), nil
 }
 
 // convertSourceIpToMatch converts source IP conditions to Traefik client IP matchers
@@ -276,8 +284,8 @@ func (c *RuleConverter) ExtractTargetGroupFromActions(actions []generated_elbv2.
 			}
 			// Check forward config for weighted targets
 			if action.ForwardConfig != nil && len(action.ForwardConfig.TargetGroups) > 0 {
-				// For now, just use the first target group
-				// TODO: Implement weighted routing support
+				// Weighted distribution is handled by WeightedRoutingManager,
+				// callers that need a single target group get the first one
 				if action.ForwardConfig.TargetGroups[0].TargetGroupArn != nil {
 					return *action.ForwardConfig.TargetGroups[0].TargetGroupArn, nil
 				}
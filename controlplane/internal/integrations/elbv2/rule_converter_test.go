@@ -212,7 +212,7 @@ var _ = Describe("RuleConverter", func() {
 
 				match, err := converter.ConvertRuleToTraefikMatch(conditions)
 				Expect(err).NotTo(HaveOccurred())
-				Expect(match).To(Equal("Query(`version=v2`)"))
+				Expect(match).To(Equal("Query(`version`, `v2`)"))
 			})
 
 			It("should convert key-only query parameter", func() {
@@ -233,7 +233,7 @@ var _ = Describe("RuleConverter", func() {
 				Expect(match).To(Equal("Query(`debug`)"))
 			})
 
-			It("should combine multiple query parameters with AND", func() {
+			It("should combine multiple query parameters with OR", func() {
 				conditions := []generated_elbv2.RuleCondition{
 					{
 						QueryStringConfig: &generated_elbv2.QueryStringConditionConfig{
@@ -252,7 +252,24 @@ var _ = Describe("RuleConverter", func() {
 
 				match, err := converter.ConvertRuleToTraefikMatch(conditions)
 				Expect(err).NotTo(HaveOccurred())
-				Expect(match).To(Equal("(Query(`version=v2`) && Query(`debug`))"))
+				Expect(match).To(Equal("(Query(`version`, `v2`) || Query(`debug`))"))
+			})
+
+			It("should reject query values without a key instead of matching everything", func() {
+				conditions := []generated_elbv2.RuleCondition{
+					{
+						QueryStringConfig: &generated_elbv2.QueryStringConditionConfig{
+							Values: []generated_elbv2.QueryStringKeyValuePair{
+								{
+									Value: utils.Ptr("v2"),
+								},
+							},
+						},
+					},
+				}
+
+				_, err := converter.ConvertRuleToTraefikMatch(conditions)
+				Expect(err).To(MatchError(ContainSubstring("without a key")))
 			})
 		})
 
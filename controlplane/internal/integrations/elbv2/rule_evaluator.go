@@ -0,0 +1,157 @@
+package elbv2
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated_elbv2"
+)
+
+// Condition field names
+const (
+	ConditionFieldPathPattern       = "path-pattern"
+	ConditionFieldHostHeader        = "host-header"
+	ConditionFieldHttpHeader        = "http-header"
+	ConditionFieldHttpRequestMethod = "http-request-method"
+	ConditionFieldQueryString       = "query-string"
+	ConditionFieldSourceIp          = "source-ip"
+)
+
+const (
+	// MaxConditionValues is the maximum number of match evaluations per rule
+	MaxConditionValues = 5
+	// maxConditionValueLength is the maximum length of a single condition value
+	maxConditionValueLength = 128
+)
+
+// RuleEvaluator checks ELBv2 rule conditions and actions against the
+// constraints an Application Load Balancer enforces. Requests are routed by
+// the Traefik rules RuleConverter builds from them.
+type RuleEvaluator struct{}
+
+// NewRuleEvaluator creates a new rule evaluator
+func NewRuleEvaluator() *RuleEvaluator {
+	return &RuleEvaluator{}
+}
+
+// ConditionField returns the field of a condition, taking the typed
+// configuration into account when Field is not set
+func ConditionField(condition *generated_elbv2.RuleCondition) string {
+	switch {
+	case condition.PathPatternConfig != nil:
+		return ConditionFieldPathPattern
+	case condition.HostHeaderConfig != nil:
+		return ConditionFieldHostHeader
+	case condition.HttpHeaderConfig != nil:
+		return ConditionFieldHttpHeader
+	case condition.HttpRequestMethodConfig != nil:
+		return ConditionFieldHttpRequestMethod
+	case condition.QueryStringConfig != nil:
+		return ConditionFieldQueryString
+	case condition.SourceIpConfig != nil:
+		return ConditionFieldSourceIp
+	case condition.Field != nil:
+		return *condition.Field
+	}
+	return ""
+}
+
+// ValidateConditions checks rule conditions against the constraints enforced by ELBv2
+func (e *RuleEvaluator) ValidateConditions(conditions []generated_elbv2.RuleCondition) error {
+	if len(conditions) == 0 {
+		return fmt.Errorf("at least one condition must be specified")
+	}
+
+	seen := make(map[string]bool)
+	total := 0
+	for i := range conditions {
+		condition := &conditions[i]
+		field := ConditionField(condition)
+		if condition.Field != nil && *condition.Field != field {
+			return fmt.Errorf("condition field '%s' does not match the specified configuration '%s'", *condition.Field, field)
+		}
+
+		values := conditionValues(condition)
+		switch field {
+		case ConditionFieldPathPattern, ConditionFieldHostHeader, ConditionFieldHttpRequestMethod, ConditionFieldSourceIp:
+			// Only one condition of these types is allowed per rule
+			if seen[field] {
+				return fmt.Errorf("a rule can only have one '%s' condition", field)
+			}
+		case ConditionFieldHttpHeader:
+			if condition.HttpHeaderConfig == nil || condition.HttpHeaderConfig.HttpHeaderName == nil || *condition.HttpHeaderConfig.HttpHeaderName == "" {
+				return fmt.Errorf("an HTTP header name must be specified for '%s' conditions", field)
+			}
+		case ConditionFieldQueryString:
+			if condition.QueryStringConfig == nil {
+				return fmt.Errorf("a query string configuration must be specified for '%s' conditions", field)
+			}
+			for _, kv := range condition.QueryStringConfig.Values {
+				if kv.Value == nil || *kv.Value == "" {
+					return fmt.Errorf("a value must be specified for each key/value pair of '%s' conditions", field)
+				}
+				// Traefik can only match query parameters by name
+				if kv.Key == nil || *kv.Key == "" {
+					return fmt.Errorf("a key must be specified for each key/value pair of '%s' conditions", field)
+				}
+			}
+		case "":
+			return fmt.Errorf("a condition field or configuration must be specified")
+		default:
+			return fmt.Errorf("condition field '%s' is not supported", field)
+		}
+		seen[field] = true
+
+		if field == ConditionFieldHttpHeader || field == ConditionFieldQueryString || field == ConditionFieldHttpRequestMethod || field == ConditionFieldSourceIp {
+			if len(condition.Values) > 0 {
+				return fmt.Errorf("'%s' conditions must use their configuration instead of 'Values'", field)
+			}
+		}
+
+		if len(values) == 0 {
+			return fmt.Errorf("at least one value must be specified for '%s' conditions", field)
+		}
+		for _, value := range values {
+			if len(value) > maxConditionValueLength {
+				return fmt.Errorf("condition value '%s' exceeds the maximum length of %d characters", value, maxConditionValueLength)
+			}
+			if field == ConditionFieldSourceIp {
+				if _, _, err := net.ParseCIDR(value); err != nil {
+					return fmt.Errorf("source IP '%s' is not a valid CIDR block", value)
+				}
+			}
+		}
+		total += len(values)
+	}
+
+	if total > MaxConditionValues {
+		return fmt.Errorf("a rule can have at most %d condition values, got %d", MaxConditionValues, total)
+	}
+	return nil
+}
+
+// conditionValues returns the string values of a condition. For query string
+// conditions these are the values of their key/value pairs.
+func conditionValues(condition *generated_elbv2.RuleCondition) []string {
+	switch {
+	case condition.PathPatternConfig != nil:
+		return condition.PathPatternConfig.Values
+	case condition.HostHeaderConfig != nil:
+		return condition.HostHeaderConfig.Values
+	case condition.HttpHeaderConfig != nil:
+		return condition.HttpHeaderConfig.Values
+	case condition.HttpRequestMethodConfig != nil:
+		return condition.HttpRequestMethodConfig.Values
+	case condition.SourceIpConfig != nil:
+		return condition.SourceIpConfig.Values
+	case condition.QueryStringConfig != nil:
+		values := make([]string, 0, len(condition.QueryStringConfig.Values))
+		for _, kv := range condition.QueryStringConfig.Values {
+			if kv.Value != nil {
+				values = append(values, *kv.Value)
+			}
+		}
+		return values
+	}
+	return condition.Values
+}
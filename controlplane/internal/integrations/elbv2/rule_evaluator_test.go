@@ -0,0 +1,70 @@
+package elbv2_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated_elbv2"
+	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/elbv2"
+	"github.com/nandemo-ya/kecs/controlplane/internal/utils"
+)
+
+var _ = Describe("RuleEvaluator", func() {
+	var evaluator *elbv2.RuleEvaluator
+
+	BeforeEach(func() {
+		evaluator = elbv2.NewRuleEvaluator()
+	})
+
+	Describe("ValidateConditions", func() {
+		It("should accept valid conditions", func() {
+			err := evaluator.ValidateConditions([]generated_elbv2.RuleCondition{
+				{Field: utils.Ptr("path-pattern"), Values: []string{"/api/*"}},
+				{HttpHeaderConfig: &generated_elbv2.HttpHeaderConditionConfig{
+					HttpHeaderName: utils.Ptr("X-Env"),
+					Values:         []string{"staging"},
+				}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should reject unknown fields", func() {
+			err := evaluator.ValidateConditions([]generated_elbv2.RuleCondition{
+				{Field: utils.Ptr("cookie"), Values: []string{"a"}},
+			})
+			Expect(err).To(MatchError(ContainSubstring("not supported")))
+		})
+
+		It("should reject duplicate path-pattern conditions", func() {
+			err := evaluator.ValidateConditions([]generated_elbv2.RuleCondition{
+				{Field: utils.Ptr("path-pattern"), Values: []string{"/a"}},
+				{PathPatternConfig: &generated_elbv2.PathPatternConditionConfig{Values: []string{"/b"}}},
+			})
+			Expect(err).To(MatchError(ContainSubstring("only have one")))
+		})
+
+		It("should reject invalid source IP ranges", func() {
+			err := evaluator.ValidateConditions([]generated_elbv2.RuleCondition{
+				{SourceIpConfig: &generated_elbv2.SourceIpConditionConfig{Values: []string{"10.0.0.1"}}},
+			})
+			Expect(err).To(MatchError(ContainSubstring("not a valid CIDR")))
+		})
+
+		It("should reject query string values without a key", func() {
+			err := evaluator.ValidateConditions([]generated_elbv2.RuleCondition{
+				{QueryStringConfig: &generated_elbv2.QueryStringConditionConfig{
+					Values: []generated_elbv2.QueryStringKeyValuePair{{Value: utils.Ptr("v2")}},
+				}},
+			})
+			Expect(err).To(MatchError(ContainSubstring("a key must be specified")))
+		})
+
+		It("should reject more than five condition values", func() {
+			err := evaluator.ValidateConditions([]generated_elbv2.RuleCondition{
+				{PathPatternConfig: &generated_elbv2.PathPatternConditionConfig{Values: []string{"/a", "/b", "/c"}}},
+				{HostHeaderConfig: &generated_elbv2.HostHeaderConditionConfig{Values: []string{"a.com", "b.com", "c.com"}}},
+			})
+			Expect(err).To(MatchError(ContainSubstring("at most 5")))
+		})
+	})
+})
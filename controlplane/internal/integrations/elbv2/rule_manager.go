@@ -2,10 +2,13 @@ package elbv2
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 
@@ -65,18 +68,27 @@ func (r *RuleManager) SyncRulesForListener(ctx context.Context, storage storage.
 	}
 
 	// Convert rules to Traefik routes
-	routes, err := r.convertRulesToRoutes(rules, storage, ctx)
+	routes, err := r.convertRulesToRoutes(rules, listenerArn, storage, ctx)
 	if err != nil {
 		return fmt.Errorf("failed to convert rules to routes: %w", err)
 	}
 
-	// Update the IngressRoute with all routes
+	// Update the IngressRoute with all routes, as JSON values like the
+	// rest of unstructured content
 	spec, ok := existingRoute.Object["spec"].(map[string]interface{})
 	if !ok {
 		spec = make(map[string]interface{})
 		existingRoute.Object["spec"] = spec
 	}
-	spec["routes"] = routes
+	routesJSON, err := json.Marshal(routes)
+	if err != nil {
+		return fmt.Errorf("failed to encode routes: %w", err)
+	}
+	var routeValues []interface{}
+	if err := json.Unmarshal(routesJSON, &routeValues); err != nil {
+		return fmt.Errorf("failed to encode routes: %w", err)
+	}
+	spec["routes"] = routeValues
 
 	// Update the IngressRoute
 	_, err = r.dynamicClient.Resource(gvr).Namespace(namespace).Update(ctx, existingRoute, metav1.UpdateOptions{})
@@ -84,6 +96,11 @@ func (r *RuleManager) SyncRulesForListener(ctx context.Context, storage storage.
 		return fmt.Errorf("failed to update IngressRoute: %w", err)
 	}
 
+	// Drop the middlewares of response actions the routes no longer use
+	if err := deleteResponseMiddlewares(ctx, r.dynamicClient, listenerArn, routes); err != nil {
+		logging.Warn("Failed to delete unused response action middlewares", "listenerArn", listenerArn, "error", err)
+	}
+
 	logging.Debug("Successfully synced rules for listener", "ruleCount", len(rules), "listenerArn", listenerArn)
 	return nil
 }
@@ -101,7 +118,7 @@ func (r *RuleManager) RemoveRuleFromListener(ctx context.Context, storage storag
 }
 
 // convertRulesToRoutes converts ELBv2 rules to Traefik routes
-func (r *RuleManager) convertRulesToRoutes(rules []*storage.ELBv2Rule, storageInstance storage.Storage, ctx context.Context) ([]interface{}, error) {
+func (r *RuleManager) convertRulesToRoutes(rules []*storage.ELBv2Rule, listenerArn string, storageInstance storage.Storage, ctx context.Context) ([]interface{}, error) {
 	var routes []interface{}
 
 	// Sort rules by priority (lower number = higher priority)
@@ -120,7 +137,7 @@ func (r *RuleManager) convertRulesToRoutes(rules []*storage.ELBv2Rule, storageIn
 
 	// Convert each rule to a route
 	for _, rule := range sortedRules {
-		route, err := r.convertRuleToRoute(rule, storageInstance, ctx)
+		route, err := r.convertRuleToRoute(rule, listenerArn, storageInstance, ctx)
 		if err != nil {
 			logging.Error("Failed to convert rule", "ruleArn", rule.ARN, "error", err)
 			continue
@@ -130,17 +147,33 @@ func (r *RuleManager) convertRulesToRoutes(rules []*storage.ELBv2Rule, storageIn
 		}
 	}
 
-	// Always add a default catch-all route at the end
+	// Always add a default catch-all route at the end, forwarding to the
+	// listener's default actions when they can be resolved
+	defaultServices := []interface{}{
+		map[string]interface{}{
+			"name": "default-backend",
+			"port": 80,
+		},
+	}
+	var defaultMiddlewares []interface{}
+	if listener, err := storageInstance.ELBv2Store().GetListener(ctx, listenerArn); err == nil && listener != nil && listener.DefaultActions != "" {
+		actions, err := r.converter.ConvertRuleActionsFromJSON(listener.DefaultActions)
+		if err == nil {
+			name := "listener-" + sanitizeName(resourceID(listenerArn))
+			if services, middlewares, err := r.buildRouteActions(ctx, name, listenerArn, actions, storageInstance); err == nil && len(services) > 0 {
+				defaultServices = services
+				defaultMiddlewares = middlewares
+			}
+		}
+	}
 	defaultRoute := map[string]interface{}{
 		"match":    "PathPrefix(`/`)",
 		"kind":     "Rule",
 		"priority": 99999, // Very low priority
-		"services": []interface{}{
-			map[string]interface{}{
-				"name": "default-backend",
-				"port": 80,
-			},
-		},
+		"services": defaultServices,
+	}
+	if len(defaultMiddlewares) > 0 {
+		defaultRoute["middlewares"] = defaultMiddlewares
 	}
 	routes = append(routes, defaultRoute)
 
@@ -148,7 +181,7 @@ func (r *RuleManager) convertRulesToRoutes(rules []*storage.ELBv2Rule, storageIn
 }
 
 // convertRuleToRoute converts a single ELBv2 rule to a Traefik route
-func (r *RuleManager) convertRuleToRoute(rule *storage.ELBv2Rule, storageInstance storage.Storage, ctx context.Context) (map[string]interface{}, error) {
+func (r *RuleManager) convertRuleToRoute(rule *storage.ELBv2Rule, listenerArn string, storageInstance storage.Storage, ctx context.Context) (map[string]interface{}, error) {
 	// Parse conditions
 	conditions, err := r.converter.ConvertRuleConditionsFromJSON(rule.Conditions)
 	if err != nil {
@@ -167,6 +200,90 @@ func (r *RuleManager) convertRuleToRoute(rule *storage.ELBv2Rule, storageInstanc
 		return nil, fmt.Errorf("failed to parse actions: %w", err)
 	}
 
+	traefikServices, middlewares, err := r.buildRouteActions(ctx, "rule-"+sanitizeName(resourceID(rule.ARN)), listenerArn, actions, storageInstance)
+	if err != nil {
+		logging.Debug("Failed to convert actions for rule", "ruleArn", rule.ARN, "error", err)
+		return nil, nil
+	}
+
+	if len(traefikServices) == 0 {
+		logging.Debug("Rule has no supported action, skipping", "ruleArn", rule.ARN)
+		return nil, nil
+	}
+
+	// Build Traefik route
+	route := map[string]interface{}{
+		"match":    match,
+		"kind":     "Rule",
+		"priority": int(rule.Priority),
+		"services": traefikServices,
+	}
+	if len(middlewares) > 0 {
+		route["middlewares"] = middlewares
+	}
+
+	// Add middleware for advanced features (future enhancement)
+	// For now, we'll just add a comment
+	if rule.Priority < 50000 { // Non-default rules
+		if metadata, ok := route["metadata"].(map[string]interface{}); ok {
+			metadata["comment"] = fmt.Sprintf("ELBv2 Rule %s (Priority: %d)", rule.ARN, rule.Priority)
+		} else {
+			route["metadata"] = map[string]interface{}{
+				"comment": fmt.Sprintf("ELBv2 Rule %s (Priority: %d)", rule.ARN, rule.Priority),
+			}
+		}
+	}
+
+	return route, nil
+}
+
+// buildRouteActions converts the actions of a rule to the services and
+// middlewares of a Traefik route. Redirect and fixed-response actions are
+// answered by middlewares named after name and labeled with the listener,
+// forwards by the target groups.
+func (r *RuleManager) buildRouteActions(ctx context.Context, name, listenerArn string, actions []generated_elbv2.Action, storageInstance storage.Storage) ([]interface{}, []interface{}, error) {
+	action := ResponseAction(actions)
+	if action == nil {
+		services, err := r.buildRouteServices(ctx, name, actions, storageInstance)
+		return services, nil, err
+	}
+
+	namespace := "kecs-system"
+	var middlewares []interface{}
+	for _, middleware := range BuildResponseMiddlewares(name, namespace, action) {
+		labels := middleware.GetLabels()
+		labels[listenerLabel] = listenerLabelValue(listenerArn)
+		middleware.SetLabels(labels)
+		if err := upsertUnstructured(ctx, r.dynamicClient, middlewareGVR, middleware); err != nil {
+			return nil, nil, fmt.Errorf("failed to apply Middleware %s: %w", middleware.GetName(), err)
+		}
+		middlewares = append(middlewares, map[string]interface{}{
+			"name":      middleware.GetName(),
+			"namespace": namespace,
+		})
+	}
+	if len(middlewares) == 0 {
+		return nil, nil, nil
+	}
+
+	// Redirects never reach a service, fixed responses are served by KECS
+	service := map[string]interface{}{
+		"name": "noop@internal",
+		"kind": "TraefikService",
+	}
+	if action.Type == generated_elbv2.ActionTypeEnumFIXED_RESPONSE {
+		service = map[string]interface{}{
+			"name": fixedResponseService,
+			"port": fixedResponsePort,
+		}
+	}
+	return []interface{}{service}, middlewares, nil
+}
+
+// buildRouteServices converts forward actions to the services of a Traefik
+// route. Forwards spreading traffic over several target groups, or pinning
+// clients to one, are routed through a weighted TraefikService named name.
+func (r *RuleManager) buildRouteServices(ctx context.Context, name string, actions []generated_elbv2.Action, storageInstance storage.Storage) ([]interface{}, error) {
 	// Create target group resolver for weighted routing
 	resolver := &storageTargetGroupResolver{
 		store: storageInstance.ELBv2Store(),
@@ -176,15 +293,25 @@ func (r *RuleManager) convertRuleToRoute(rule *storage.ELBv2Rule, storageInstanc
 	// Convert actions to weighted services
 	services, err := r.weightedManager.ConvertActionsToWeightedServices(actions, resolver)
 	if err != nil {
-		logging.Debug("Failed to convert actions for rule", "ruleArn", rule.ARN, "error", err)
-		return nil, nil
+		return nil, err
 	}
-
 	if len(services) == 0 {
-		logging.Debug("Rule has no forward action, skipping", "ruleArn", rule.ARN)
 		return nil, nil
 	}
 
+	if forwardConfig := WeightedForwardConfig(actions); forwardConfig != nil {
+		traefikService := r.weightedManager.BuildWeightedTraefikService(name, "kecs-system", services, forwardConfig)
+		if err := upsertUnstructured(ctx, r.dynamicClient, traefikServiceGVR, traefikService); err != nil {
+			return nil, fmt.Errorf("failed to apply TraefikService %s: %w", name, err)
+		}
+		return []interface{}{
+			map[string]interface{}{
+				"name": name,
+				"kind": "TraefikService",
+			},
+		}, nil
+	}
+
 	// Convert weighted services to Traefik service format
 	traefikServices := make([]interface{}, 0, len(services))
 	for _, service := range services {
@@ -212,28 +339,71 @@ func (r *RuleManager) convertRuleToRoute(rule *storage.ELBv2Rule, storageInstanc
 
 		traefikServices = append(traefikServices, svc)
 	}
+	return traefikServices, nil
+}
 
-	// Build Traefik route
-	route := map[string]interface{}{
-		"match":    match,
-		"kind":     "Rule",
-		"priority": int(rule.Priority),
-		"services": traefikServices,
+// deleteResponseMiddlewares deletes the response action middlewares of a
+// listener that none of its routes use, all of them when routes is empty
+func deleteResponseMiddlewares(ctx context.Context, client dynamic.Interface, listenerArn string, routes []interface{}) error {
+	used := make(map[string]bool)
+	for _, route := range routes {
+		route, _ := route.(map[string]interface{})
+		middlewares, _ := route["middlewares"].([]interface{})
+		for _, middleware := range middlewares {
+			if middleware, ok := middleware.(map[string]interface{}); ok {
+				name, _ := middleware["name"].(string)
+				used[name] = true
+			}
+		}
 	}
 
-	// Add middleware for advanced features (future enhancement)
-	// For now, we'll just add a comment
-	if rule.Priority < 50000 { // Non-default rules
-		if metadata, ok := route["metadata"].(map[string]interface{}); ok {
-			metadata["comment"] = fmt.Sprintf("ELBv2 Rule %s (Priority: %d)", rule.ARN, rule.Priority)
-		} else {
-			route["metadata"] = map[string]interface{}{
-				"comment": fmt.Sprintf("ELBv2 Rule %s (Priority: %d)", rule.ARN, rule.Priority),
-			}
+	resource := client.Resource(middlewareGVR).Namespace("kecs-system")
+	list, err := resource.List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s,%s=%s", componentLabel, responseActionComponent, listenerLabel, listenerLabelValue(listenerArn)),
+	})
+	if err != nil {
+		return err
+	}
+	for _, middleware := range list.Items {
+		if used[middleware.GetName()] {
+			continue
 		}
+		if err := resource.Delete(ctx, middleware.GetName(), metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete Middleware %s: %w", middleware.GetName(), err)
+		}
+		logging.Debug("Deleted unused response action middleware", "name", middleware.GetName())
 	}
+	return nil
+}
 
-	return route, nil
+// listenerLabelValue returns the value of the listener label of a listener
+func listenerLabelValue(listenerArn string) string {
+	return sanitizeName(resourceID(listenerArn))
+}
+
+// upsertUnstructured creates the object or replaces the spec of an existing one
+func upsertUnstructured(ctx context.Context, client dynamic.Interface, gvr schema.GroupVersionResource, obj *unstructured.Unstructured) error {
+	resource := client.Resource(gvr).Namespace(obj.GetNamespace())
+	existing, err := resource.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		_, err = resource.Create(ctx, obj, metav1.CreateOptions{})
+		return err
+	}
+	existing.Object["spec"] = obj.Object["spec"]
+	existing.SetLabels(obj.GetLabels())
+	_, err = resource.Update(ctx, existing, metav1.UpdateOptions{})
+	return err
+}
+
+// resourceID returns the trailing ID segment of an ARN
+func resourceID(arn string) string {
+	if idx := strings.LastIndex(arn, "/"); idx >= 0 {
+		return arn[idx+1:]
+	}
+	return arn
 }
 
 // extractNameFromArn extracts the resource name from an ARN
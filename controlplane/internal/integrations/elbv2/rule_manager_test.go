@@ -0,0 +1,117 @@
+package elbv2_test
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated_elbv2"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/mocks"
+	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/elbv2"
+	"github.com/nandemo-ya/kecs/controlplane/internal/kubernetes/resources"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/utils"
+)
+
+var _ = Describe("RuleManager", func() {
+	const (
+		listenerArn = "arn:aws:elasticloadbalancing:us-east-1:000000000000:listener/app/web/0123456789abcdef/fedcba9876543210"
+		ruleArn     = "arn:aws:elasticloadbalancing:us-east-1:000000000000:listener-rule/app/web/0123456789abcdef/fedcba9876543210/1111111111111111"
+	)
+
+	var (
+		ctx           context.Context
+		dynamicClient *dynamicfake.FakeDynamicClient
+		store         *mockELBv2Store
+		mockStorage   *mocks.MockStorage
+		ruleManager   *elbv2.RuleManager
+	)
+
+	middlewareGVR := schema.GroupVersionResource{Group: "traefik.io", Version: "v1alpha1", Resource: "middlewares"}
+	ingressRouteGVR := schema.GroupVersionResource{Group: "traefik.io", Version: "v1alpha1", Resource: "ingressroutes"}
+
+	traefikObject := func(kind, name string, labels map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "traefik.io/v1alpha1",
+			"kind":       kind,
+			"metadata":   map[string]interface{}{"name": name, "namespace": "kecs-system", "labels": labels},
+			"spec":       map[string]interface{}{},
+		}}
+	}
+
+	middlewareNames := func() []string {
+		list, err := dynamicClient.Resource(middlewareGVR).Namespace("kecs-system").List(ctx, metav1.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		var names []string
+		for _, item := range list.Items {
+			names = append(names, item.GetName())
+		}
+		return names
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		// A middleware of another listener is left alone
+		other := traefikObject("Middleware", "rule-2222222222222222-redirect", map[string]interface{}{
+			"kecs.io/component": "elbv2-response-action",
+			"kecs.io/listener":  "0000000000000000",
+		})
+		dynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+			map[schema.GroupVersionResource]string{
+				middlewareGVR:   "MiddlewareList",
+				ingressRouteGVR: "IngressRouteList",
+			},
+			traefikObject("IngressRoute", "listener-web-80", nil), other)
+
+		store = newMockELBv2Store()
+		mockStorage = mocks.NewMockStorage()
+		mockStorage.SetELBv2Store(store)
+		ruleManager = elbv2.NewRuleManager(dynamicClient, store)
+
+		conditions, err := json.Marshal([]generated_elbv2.RuleCondition{{
+			Field:             utils.Ptr("path-pattern"),
+			PathPatternConfig: &generated_elbv2.PathPatternConditionConfig{Values: []string{"/maintenance/*"}},
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		actions, err := json.Marshal([]generated_elbv2.Action{{
+			Type:                generated_elbv2.ActionTypeEnumFIXED_RESPONSE,
+			FixedResponseConfig: &generated_elbv2.FixedResponseActionConfig{StatusCode: "503"},
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		store.rules[ruleArn] = &storage.ELBv2Rule{
+			ARN: ruleArn, ListenerArn: listenerArn, Priority: 10,
+			Conditions: string(conditions), Actions: string(actions),
+		}
+	})
+
+	It("should route fixed responses to the API service of the control plane", func() {
+		Expect(ruleManager.SyncRulesForListener(ctx, mockStorage, listenerArn, "web", 80)).To(Succeed())
+
+		route, err := dynamicClient.Resource(ingressRouteGVR).Namespace("kecs-system").Get(ctx, "listener-web-80", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		routes, _, _ := unstructured.NestedSlice(route.Object, "spec", "routes")
+		Expect(routes).NotTo(BeEmpty())
+		services := routes[0].(map[string]interface{})["services"].([]interface{})
+		Expect(services).To(ConsistOf(HaveKeyWithValue("name", resources.ControlPlaneAPIService)))
+	})
+
+	It("should delete the middlewares of response actions the listener no longer uses", func() {
+		Expect(ruleManager.SyncRulesForListener(ctx, mockStorage, listenerArn, "web", 80)).To(Succeed())
+		Expect(middlewareNames()).To(ConsistOf(
+			"rule-1111111111111111-fixed-response",
+			"rule-1111111111111111-fixed-response-path",
+			"rule-2222222222222222-redirect",
+		))
+
+		delete(store.rules, ruleArn)
+		Expect(ruleManager.SyncRulesForListener(ctx, mockStorage, listenerArn, "web", 80)).To(Succeed())
+		Expect(middlewareNames()).To(ConsistOf("rule-2222222222222222-redirect"))
+	})
+})
@@ -0,0 +1,79 @@
+package elbv2
+
+import (
+	"sync"
+)
+
+// TargetGroupTraffic counts the requests the routing layer forwarded to a
+// target group. The counters only grow, rates are taken from the difference
+// of two readings.
+type TargetGroupTraffic struct {
+	Requests int64 `json:"requests"`
+	// Errors counts responses with a 5xx status, whether returned by a
+	// target or by the routing layer itself
+	Errors int64 `json:"errors"`
+}
+
+// Sub returns the traffic between an earlier reading and t
+func (t TargetGroupTraffic) Sub(earlier TargetGroupTraffic) TargetGroupTraffic {
+	return TargetGroupTraffic{Requests: t.Requests - earlier.Requests, Errors: t.Errors - earlier.Errors}
+}
+
+// ErrorRate returns the share of requests that failed, 0 without requests
+func (t TargetGroupTraffic) ErrorRate() float64 {
+	if t.Requests <= 0 {
+		return 0
+	}
+	return float64(t.Errors) / float64(t.Requests)
+}
+
+// TrafficReporter is an optional interface that integrations can implement to
+// report the traffic of target groups measured by the routing layer
+type TrafficReporter interface {
+	// TargetGroupTraffic returns the traffic a target group received so far
+	TargetGroupTraffic(targetGroupArn string) TargetGroupTraffic
+}
+
+// TrafficRecorder counts requests per target group from Traefik access logs
+type TrafficRecorder struct {
+	// TargetGroupResolver maps a Traefik service name to a target group ARN
+	TargetGroupResolver func(serviceName string) string
+
+	mu      sync.Mutex
+	traffic map[string]TargetGroupTraffic
+}
+
+// NewTrafficRecorder creates a new traffic recorder
+func NewTrafficRecorder(resolver func(serviceName string) string) *TrafficRecorder {
+	return &TrafficRecorder{
+		TargetGroupResolver: resolver,
+		traffic:             make(map[string]TargetGroupTraffic),
+	}
+}
+
+// Record counts an access log entry for the target group it was forwarded to
+func (r *TrafficRecorder) Record(entry *TraefikAccessLogEntry) {
+	if r.TargetGroupResolver == nil {
+		return
+	}
+	targetGroupArn := r.TargetGroupResolver(entry.ServiceName)
+	if targetGroupArn == "" {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	traffic := r.traffic[targetGroupArn]
+	traffic.Requests++
+	if entry.DownstreamStatus >= 500 {
+		traffic.Errors++
+	}
+	r.traffic[targetGroupArn] = traffic
+}
+
+// Traffic returns the traffic recorded for a target group
+func (r *TrafficRecorder) Traffic(targetGroupArn string) TargetGroupTraffic {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.traffic[targetGroupArn]
+}
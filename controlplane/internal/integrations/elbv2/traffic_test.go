@@ -0,0 +1,65 @@
+package elbv2_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/elbv2"
+)
+
+var _ = Describe("TrafficRecorder", func() {
+	const (
+		blueArn  = "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/web/abc"
+		greenArn = "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/web-green/def"
+	)
+
+	var recorder *elbv2.TrafficRecorder
+
+	entry := func(service string, status int) *elbv2.TraefikAccessLogEntry {
+		return &elbv2.TraefikAccessLogEntry{
+			StartUTC:         time.Now(),
+			RequestHost:      "web.example.com",
+			ServiceName:      service,
+			DownstreamStatus: status,
+		}
+	}
+
+	BeforeEach(func() {
+		recorder = elbv2.NewTrafficRecorder(func(serviceName string) string {
+			switch serviceName {
+			case "kecs-system-tg-web-80@kubernetes":
+				return blueArn
+			case "kecs-system-tg-web-green-80@kubernetes":
+				return greenArn
+			}
+			return ""
+		})
+	})
+
+	It("counts requests and 5xx responses per target group", func() {
+		recorder.Record(entry("kecs-system-tg-web-80@kubernetes", 200))
+		recorder.Record(entry("kecs-system-tg-web-green-80@kubernetes", 200))
+		recorder.Record(entry("kecs-system-tg-web-green-80@kubernetes", 503))
+		recorder.Record(entry("kecs-system-tg-web-green-80@kubernetes", 404))
+		recorder.Record(entry("unknown@kubernetes", 500))
+
+		Expect(recorder.Traffic(blueArn)).To(Equal(elbv2.TargetGroupTraffic{Requests: 1}))
+		green := recorder.Traffic(greenArn)
+		Expect(green).To(Equal(elbv2.TargetGroupTraffic{Requests: 3, Errors: 1}))
+		Expect(green.ErrorRate()).To(BeNumerically("~", 1.0/3))
+	})
+
+	It("measures the traffic between two readings", func() {
+		recorder.Record(entry("kecs-system-tg-web-green-80@kubernetes", 500))
+		before := recorder.Traffic(greenArn)
+		recorder.Record(entry("kecs-system-tg-web-green-80@kubernetes", 200))
+		recorder.Record(entry("kecs-system-tg-web-green-80@kubernetes", 200))
+
+		delta := recorder.Traffic(greenArn).Sub(before)
+		Expect(delta).To(Equal(elbv2.TargetGroupTraffic{Requests: 2}))
+		Expect(delta.ErrorRate()).To(BeZero())
+		Expect(elbv2.TargetGroupTraffic{}.ErrorRate()).To(BeZero())
+	})
+})
@@ -3,6 +3,7 @@ package elbv2
 import (
 	"context"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated_elbv2"
 	"github.com/nandemo-ya/kecs/controlplane/internal/localstack"
 )
 
@@ -42,6 +43,13 @@ type Integration interface {
 	CheckTargetHealthWithK8s(ctx context.Context, targetIP string, targetPort int32, targetGroupArn string) (string, error)
 }
 
+// ForwardConfigSyncable is an optional interface that integrations can implement to route a
+// listener's default forward action over several weighted target groups with optional stickiness
+type ForwardConfigSyncable interface {
+	// SyncListenerForwardConfig routes the listener through the weighted forward configuration
+	SyncListenerForwardConfig(ctx context.Context, loadBalancerArn string, port int32, config *generated_elbv2.ForwardActionConfig) error
+}
+
 // RuleSyncable is an optional interface that integrations can implement to support rule syncing
 type RuleSyncable interface {
 	// SyncRulesToListener synchronizes ELBv2 rules to the underlying implementation
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated_elbv2"
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
 )
@@ -39,6 +42,14 @@ type TraefikCookie struct {
 	Secure   bool   `json:"secure"`
 	HTTPOnly bool   `json:"httpOnly"`
 	SameSite string `json:"sameSite"`
+	MaxAge   int32  `json:"maxAge,omitempty"`
+}
+
+// traefikServiceGVR is the GroupVersionResource of Traefik's TraefikService CRD
+var traefikServiceGVR = schema.GroupVersionResource{
+	Group:    "traefik.io",
+	Version:  "v1alpha1",
+	Resource: "traefikservices",
 }
 
 // ConvertActionsToWeightedServices converts ELBv2 forward actions to Traefik weighted services
@@ -107,7 +118,7 @@ func (w *WeightedRoutingManager) convertForwardConfig(config *generated_elbv2.Fo
 		}
 
 		// Add sticky session if configured
-		if config.TargetGroupStickinessConfig != nil && config.TargetGroupStickinessConfig.Enabled != nil && *config.TargetGroupStickinessConfig.Enabled {
+		if stickinessEnabled(config) {
 			service.Sticky = w.createStickyConfig(config.TargetGroupStickinessConfig)
 		}
 
@@ -152,7 +163,7 @@ func (w *WeightedRoutingManager) createStickyConfig(config *generated_elbv2.Targ
 		cookieName = fmt.Sprintf("kecs-sticky-%d", *config.DurationSeconds)
 	}
 
-	return &TraefikSticky{
+	sticky := &TraefikSticky{
 		Cookie: &TraefikCookie{
 			Name:     cookieName,
 			Secure:   true,
@@ -160,6 +171,84 @@ func (w *WeightedRoutingManager) createStickyConfig(config *generated_elbv2.Targ
 			SameSite: "lax",
 		},
 	}
+	if config.DurationSeconds != nil {
+		sticky.Cookie.MaxAge = *config.DurationSeconds
+	}
+	return sticky
+}
+
+// WeightedForwardConfig returns the forward configuration of the first forward
+// action that spreads traffic over several target groups or pins clients to a
+// target group. Plain single target group forwards return nil.
+func WeightedForwardConfig(actions []generated_elbv2.Action) *generated_elbv2.ForwardActionConfig {
+	for _, action := range actions {
+		if action.Type != generated_elbv2.ActionTypeEnumFORWARD || action.ForwardConfig == nil {
+			continue
+		}
+		config := action.ForwardConfig
+		if len(config.TargetGroups) > 1 || stickinessEnabled(config) {
+			return config
+		}
+	}
+	return nil
+}
+
+// BuildWeightedTraefikService builds a TraefikService balancing between the
+// services by weight. Stickiness between target groups can only be expressed
+// on a weighted TraefikService, the per-service sticky setting of an
+// IngressRoute only pins clients to a pod within a target group.
+func (w *WeightedRoutingManager) BuildWeightedTraefikService(name, namespace string, services []TraefikWeightedService, config *generated_elbv2.ForwardActionConfig) *unstructured.Unstructured {
+	weighted := make([]interface{}, 0, len(services))
+	for _, service := range services {
+		weighted = append(weighted, map[string]interface{}{
+			"name":   service.Name,
+			"port":   int64(service.Port),
+			"weight": int64(service.Weight),
+		})
+	}
+
+	spec := map[string]interface{}{
+		"services": weighted,
+	}
+	if config != nil && stickinessEnabled(config) {
+		cookie := w.createStickyConfig(config.TargetGroupStickinessConfig).Cookie
+		cookieSpec := map[string]interface{}{
+			"name":     cookie.Name,
+			"secure":   cookie.Secure,
+			"httpOnly": cookie.HTTPOnly,
+			"sameSite": cookie.SameSite,
+		}
+		if cookie.MaxAge > 0 {
+			cookieSpec["maxAge"] = int64(cookie.MaxAge)
+		}
+		spec["sticky"] = map[string]interface{}{
+			"cookie": cookieSpec,
+		}
+	}
+
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "traefik.io/v1alpha1",
+			"kind":       "TraefikService",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+				"labels": map[string]interface{}{
+					"kecs.io/component": "elbv2-weighted-forward",
+				},
+			},
+			"spec": map[string]interface{}{
+				"weighted": spec,
+			},
+		},
+	}
+}
+
+// stickinessEnabled reports whether target group stickiness is enabled on a forward config
+func stickinessEnabled(config *generated_elbv2.ForwardActionConfig) bool {
+	return config.TargetGroupStickinessConfig != nil &&
+		config.TargetGroupStickinessConfig.Enabled != nil &&
+		*config.TargetGroupStickinessConfig.Enabled
 }
 
 // TargetGroupResolver interface for resolving target group information
@@ -5,6 +5,7 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated_elbv2"
 	"github.com/nandemo-ya/kecs/controlplane/internal/integrations/elbv2"
@@ -360,4 +361,80 @@ var _ = Describe("WeightedRoutingManager", func() {
 			Expect(yaml).To(ContainSubstring("sameSite: lax"))
 		})
 	})
+
+	Describe("WeightedForwardConfig", func() {
+		It("should ignore single target group forwards", func() {
+			actions := []generated_elbv2.Action{
+				{Type: generated_elbv2.ActionTypeEnumFORWARD, TargetGroupArn: utils.Ptr("tg-1")},
+				{Type: generated_elbv2.ActionTypeEnumFORWARD, ForwardConfig: &generated_elbv2.ForwardActionConfig{
+					TargetGroups: []generated_elbv2.TargetGroupTuple{{TargetGroupArn: utils.Ptr("tg-1")}},
+				}},
+			}
+			Expect(elbv2.WeightedForwardConfig(actions)).To(BeNil())
+		})
+
+		It("should return forward configs with multiple target groups or stickiness", func() {
+			weighted := &generated_elbv2.ForwardActionConfig{
+				TargetGroups: []generated_elbv2.TargetGroupTuple{
+					{TargetGroupArn: utils.Ptr("tg-1"), Weight: utils.Ptr(int32(90))},
+					{TargetGroupArn: utils.Ptr("tg-2"), Weight: utils.Ptr(int32(10))},
+				},
+			}
+			Expect(elbv2.WeightedForwardConfig([]generated_elbv2.Action{
+				{Type: generated_elbv2.ActionTypeEnumFORWARD, ForwardConfig: weighted},
+			})).To(Equal(weighted))
+
+			sticky := &generated_elbv2.ForwardActionConfig{
+				TargetGroups: []generated_elbv2.TargetGroupTuple{{TargetGroupArn: utils.Ptr("tg-1")}},
+				TargetGroupStickinessConfig: &generated_elbv2.TargetGroupStickinessConfig{
+					Enabled: utils.Ptr(true),
+				},
+			}
+			Expect(elbv2.WeightedForwardConfig([]generated_elbv2.Action{
+				{Type: generated_elbv2.ActionTypeEnumFORWARD, ForwardConfig: sticky},
+			})).To(Equal(sticky))
+		})
+	})
+
+	Describe("BuildWeightedTraefikService", func() {
+		services := []elbv2.TraefikWeightedService{
+			{Name: "tg-api-v1", Port: 8080, Weight: 90},
+			{Name: "tg-api-v2", Port: 8080, Weight: 10},
+		}
+
+		It("should build a weighted TraefikService", func() {
+			obj := manager.BuildWeightedTraefikService("alb-test-port-80", "kecs-system", services, nil)
+
+			Expect(obj.GetKind()).To(Equal("TraefikService"))
+			Expect(obj.GetName()).To(Equal("alb-test-port-80"))
+			Expect(obj.GetNamespace()).To(Equal("kecs-system"))
+
+			weighted, found, err := unstructured.NestedSlice(obj.Object, "spec", "weighted", "services")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(weighted).To(ConsistOf(
+				map[string]interface{}{"name": "tg-api-v1", "port": int64(8080), "weight": int64(90)},
+				map[string]interface{}{"name": "tg-api-v2", "port": int64(8080), "weight": int64(10)},
+			))
+
+			_, found, _ = unstructured.NestedMap(obj.Object, "spec", "weighted", "sticky")
+			Expect(found).To(BeFalse())
+		})
+
+		It("should add a sticky cookie bounded by the stickiness duration", func() {
+			config := &generated_elbv2.ForwardActionConfig{
+				TargetGroupStickinessConfig: &generated_elbv2.TargetGroupStickinessConfig{
+					Enabled:         utils.Ptr(true),
+					DurationSeconds: utils.Ptr(int32(600)),
+				},
+			}
+			obj := manager.BuildWeightedTraefikService("alb-test-port-80", "kecs-system", services, config)
+
+			cookie, found, err := unstructured.NestedMap(obj.Object, "spec", "weighted", "sticky", "cookie")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(cookie["name"]).To(Equal("kecs-sticky-600"))
+			Expect(cookie["maxAge"]).To(Equal(int64(600)))
+		})
+	})
 })
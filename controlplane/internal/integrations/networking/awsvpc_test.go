@@ -147,7 +147,7 @@ var _ = Describe("AWSVPC Network Mode Integration", func() {
 			Expect(task.Attachments).NotTo(BeEmpty())
 			eniAttachment := task.Attachments[0]
 			Expect(*eniAttachment.Type).To(Equal("ElasticNetworkInterface"))
-			Expect(*eniAttachment.Status).To(Equal("ATTACHED"))
+			Expect(*eniAttachment.Status).To(Equal("PRECREATED"))
 		})
 
 		It("should describe task with network details", func() {
@@ -0,0 +1,49 @@
+package kecsapi
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/connect"
+)
+
+// Client is a client of kecs.v1.KecsService
+type Client struct {
+	conn *connect.Client
+}
+
+// NewClient creates a client for the API server at baseURL, such as
+// http://localhost:5373
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	return &Client{conn: connect.NewClient(baseURL, httpClient)}
+}
+
+// GetSummary counts the resources of the instance
+func (c *Client) GetSummary(ctx context.Context, req *GetSummaryRequest) (*Summary, error) {
+	return connect.CallUnary[GetSummaryRequest, Summary](ctx, c.conn, GetSummaryProcedure, req)
+}
+
+// WatchSummary streams the summary whenever it changes
+func (c *Client) WatchSummary(ctx context.Context, req *WatchSummaryRequest) (*connect.ServerStream[Summary], error) {
+	return connect.CallServerStream[WatchSummaryRequest, Summary](ctx, c.conn, WatchSummaryProcedure, req)
+}
+
+// WatchClusters streams the clusters whenever they change
+func (c *Client) WatchClusters(ctx context.Context, req *WatchClustersRequest) (*connect.ServerStream[ClusterList], error) {
+	return connect.CallServerStream[WatchClustersRequest, ClusterList](ctx, c.conn, WatchClustersProcedure, req)
+}
+
+// WatchServices streams the services whenever they change
+func (c *Client) WatchServices(ctx context.Context, req *WatchServicesRequest) (*connect.ServerStream[ServiceList], error) {
+	return connect.CallServerStream[WatchServicesRequest, ServiceList](ctx, c.conn, WatchServicesProcedure, req)
+}
+
+// WatchTasks streams the tasks whenever they change
+func (c *Client) WatchTasks(ctx context.Context, req *WatchTasksRequest) (*connect.ServerStream[TaskList], error) {
+	return connect.CallServerStream[WatchTasksRequest, TaskList](ctx, c.conn, WatchTasksProcedure, req)
+}
+
+// StreamTaskLogs streams the logs of the containers of a task
+func (c *Client) StreamTaskLogs(ctx context.Context, req *StreamTaskLogsRequest) (*connect.ServerStream[LogEntry], error) {
+	return connect.CallServerStream[StreamTaskLogsRequest, LogEntry](ctx, c.conn, StreamTaskLogsProcedure, req)
+}
@@ -0,0 +1,13 @@
+package kecsapi_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestKecsAPI(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "KECS API Suite")
+}
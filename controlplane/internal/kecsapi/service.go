@@ -0,0 +1,317 @@
+// Package kecsapi implements kecs.v1.KecsService, the Connect API the TUI
+// and other tools use to watch the resources of a KECS instance. Watch
+// procedures stream a snapshot of the watched resources when the stream
+// opens and again whenever they change, so clients do not need to poll.
+package kecsapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsrequest"
+	"github.com/nandemo-ya/kecs/controlplane/internal/connect"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// ServiceName is the fully qualified name of the service
+const ServiceName = "kecs.v1.KecsService"
+
+// AuthService is the service calls are authenticated and authorized as, e.g.
+// IAM policies allow kecs:WatchTasks
+const AuthService = "kecs"
+
+func init() {
+	awsrequest.RegisterPathService("/"+ServiceName+"/", AuthService)
+}
+
+// Procedures of the service
+const (
+	GetSummaryProcedure     = "/" + ServiceName + "/GetSummary"
+	WatchSummaryProcedure   = "/" + ServiceName + "/WatchSummary"
+	WatchClustersProcedure  = "/" + ServiceName + "/WatchClusters"
+	WatchServicesProcedure  = "/" + ServiceName + "/WatchServices"
+	WatchTasksProcedure     = "/" + ServiceName + "/WatchTasks"
+	StreamTaskLogsProcedure = "/" + ServiceName + "/StreamTaskLogs"
+)
+
+// DefaultWatchInterval is how often watched resources are checked for changes
+const DefaultWatchInterval = 500 * time.Millisecond
+
+// TaskLogStreamer streams the logs of task containers
+type TaskLogStreamer interface {
+	StreamTaskLogs(ctx context.Context, cluster, taskArn string, tail int64, follow bool, send func(LogEntry) error) error
+}
+
+// Server implements kecs.v1.KecsService on the storage of the instance
+type Server struct {
+	storage  storage.Storage
+	logs     TaskLogStreamer
+	interval time.Duration
+}
+
+// NewServer creates the server. Watched resources are checked for changes
+// every interval.
+func NewServer(storage storage.Storage, interval time.Duration) *Server {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	return &Server{storage: storage, interval: interval}
+}
+
+// SetTaskLogStreamer sets the source of StreamTaskLogs
+func (s *Server) SetTaskLogStreamer(logs TaskLogStreamer) {
+	s.logs = logs
+}
+
+// Handler returns the path prefix the service is served under and its handler
+func (s *Server) Handler() (string, http.Handler) {
+	mux := http.NewServeMux()
+	mux.Handle(GetSummaryProcedure, connect.NewUnaryHandler(s.GetSummary))
+	mux.Handle(WatchSummaryProcedure, connect.NewServerStreamHandler(s.WatchSummary))
+	mux.Handle(WatchClustersProcedure, connect.NewServerStreamHandler(s.WatchClusters))
+	mux.Handle(WatchServicesProcedure, connect.NewServerStreamHandler(s.WatchServices))
+	mux.Handle(WatchTasksProcedure, connect.NewServerStreamHandler(s.WatchTasks))
+	mux.Handle(StreamTaskLogsProcedure, connect.NewServerStreamHandler(s.StreamTaskLogs))
+	return "/" + ServiceName + "/", mux
+}
+
+// GetSummary counts the resources of the instance
+func (s *Server) GetSummary(ctx context.Context, req *GetSummaryRequest) (*Summary, error) {
+	clusters, err := s.listClusters(ctx)
+	if err != nil {
+		return nil, err
+	}
+	summary := &Summary{Clusters: int32(len(clusters.Clusters))}
+	for _, cluster := range clusters.Clusters {
+		summary.Services += cluster.ActiveServicesCount
+		summary.RunningTasks += cluster.RunningTasksCount
+		summary.PendingTasks += cluster.PendingTasksCount
+	}
+	return summary, nil
+}
+
+// WatchSummary streams the summary whenever it changes
+func (s *Server) WatchSummary(ctx context.Context, req *WatchSummaryRequest, send func(*Summary) error) error {
+	return watch(ctx, s.interval, func(ctx context.Context) (*Summary, error) {
+		return s.GetSummary(ctx, &GetSummaryRequest{})
+	}, send)
+}
+
+// WatchClusters streams the clusters whenever they change
+func (s *Server) WatchClusters(ctx context.Context, req *WatchClustersRequest, send func(*ClusterList) error) error {
+	return watch(ctx, s.interval, s.listClusters, send)
+}
+
+// WatchServices streams the services of a cluster whenever they change
+func (s *Server) WatchServices(ctx context.Context, req *WatchServicesRequest, send func(*ServiceList) error) error {
+	if _, err := s.resolveClusters(ctx, req.Cluster); err != nil {
+		return err
+	}
+	return watch(ctx, s.interval, func(ctx context.Context) (*ServiceList, error) {
+		return s.listServices(ctx, req.Cluster)
+	}, send)
+}
+
+// WatchTasks streams the tasks of a cluster whenever they change
+func (s *Server) WatchTasks(ctx context.Context, req *WatchTasksRequest, send func(*TaskList) error) error {
+	if _, err := s.resolveClusters(ctx, req.Cluster); err != nil {
+		return err
+	}
+	return watch(ctx, s.interval, func(ctx context.Context) (*TaskList, error) {
+		return s.listTasks(ctx, req.Cluster, req.ServiceName)
+	}, send)
+}
+
+// StreamTaskLogs streams the logs of the containers of a task
+func (s *Server) StreamTaskLogs(ctx context.Context, req *StreamTaskLogsRequest, send func(*LogEntry) error) error {
+	if req.TaskArn == "" {
+		return connect.NewError(connect.CodeInvalidArgument, "taskArn is required")
+	}
+	if s.logs == nil {
+		return connect.NewError(connect.CodeUnavailable, "task logs are not available")
+	}
+	cluster := req.Cluster
+	if cluster == "" {
+		cluster = clusterFromTaskArn(req.TaskArn)
+	}
+	return s.logs.StreamTaskLogs(ctx, cluster, req.TaskArn, int64(req.Tail), req.Follow, func(entry LogEntry) error {
+		return send(&entry)
+	})
+}
+
+// watch sends the snapshot when the stream opens and whenever it changes,
+// until the client goes away
+func watch[T any](ctx context.Context, interval time.Duration, snapshot func(context.Context) (*T, error), send func(*T) error) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last []byte
+	for {
+		current, err := snapshot(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		data, err := json.Marshal(current)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(data, last) {
+			if err := send(current); err != nil {
+				return err
+			}
+			last = data
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) listClusters(ctx context.Context) (*ClusterList, error) {
+	clusters, err := s.storage.ClusterStore().List(ctx)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, "failed to list clusters: %v", err)
+	}
+	list := &ClusterList{Clusters: []Cluster{}}
+	for _, cluster := range clusters {
+		list.Clusters = append(list.Clusters, Cluster{
+			ClusterArn:                        cluster.ARN,
+			ClusterName:                       cluster.Name,
+			Status:                            cluster.Status,
+			RegisteredContainerInstancesCount: int32(cluster.RegisteredContainerInstancesCount),
+			RunningTasksCount:                 int32(cluster.RunningTasksCount),
+			PendingTasksCount:                 int32(cluster.PendingTasksCount),
+			ActiveServicesCount:               int32(cluster.ActiveServicesCount),
+		})
+	}
+	sort.Slice(list.Clusters, func(i, j int) bool { return list.Clusters[i].ClusterName < list.Clusters[j].ClusterName })
+	return list, nil
+}
+
+func (s *Server) listServices(ctx context.Context, clusterName string) (*ServiceList, error) {
+	clusters, err := s.resolveClusters(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	list := &ServiceList{Services: []Service{}}
+	for _, cluster := range clusters {
+		services, _, err := s.storage.ServiceStore().List(ctx, cluster.ARN, storage.ServiceFilters{}, 0, "")
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, "failed to list services of cluster %s: %v", cluster.Name, err)
+		}
+		for _, service := range services {
+			list.Services = append(list.Services, Service{
+				ServiceArn:     service.ARN,
+				ServiceName:    service.ServiceName,
+				ClusterArn:     cluster.ARN,
+				Status:         service.Status,
+				DesiredCount:   int32(service.DesiredCount),
+				RunningCount:   int32(service.RunningCount),
+				PendingCount:   int32(service.PendingCount),
+				TaskDefinition: service.TaskDefinitionARN,
+				CreatedAt:      service.CreatedAt,
+			})
+		}
+	}
+	sort.Slice(list.Services, func(i, j int) bool { return list.Services[i].ServiceArn < list.Services[j].ServiceArn })
+	return list, nil
+}
+
+func (s *Server) listTasks(ctx context.Context, clusterName, serviceName string) (*TaskList, error) {
+	clusters, err := s.resolveClusters(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	list := &TaskList{Tasks: []Task{}}
+	for _, cluster := range clusters {
+		tasks, err := s.storage.TaskStore().List(ctx, cluster.ARN, storage.TaskFilters{ServiceName: serviceName})
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, "failed to list tasks of cluster %s: %v", cluster.Name, err)
+		}
+		for _, task := range tasks {
+			list.Tasks = append(list.Tasks, convertTask(task))
+		}
+	}
+	sort.Slice(list.Tasks, func(i, j int) bool { return list.Tasks[i].TaskArn < list.Tasks[j].TaskArn })
+	return list, nil
+}
+
+// resolveClusters returns the named cluster, or all clusters without name
+func (s *Server) resolveClusters(ctx context.Context, clusterName string) ([]*storage.Cluster, error) {
+	if clusterName == "" {
+		clusters, err := s.storage.ClusterStore().List(ctx)
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, "failed to list clusters: %v", err)
+		}
+		return clusters, nil
+	}
+	if strings.HasPrefix(clusterName, "arn:") {
+		clusterName = clusterName[strings.LastIndex(clusterName, "/")+1:]
+	}
+	cluster, err := s.storage.ClusterStore().Get(ctx, clusterName)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeNotFound, "cluster %s not found", clusterName)
+	}
+	return []*storage.Cluster{cluster}, nil
+}
+
+// storedContainer is the part of the stored container status the API reports
+type storedContainer struct {
+	ContainerArn string `json:"containerArn"`
+	Name         string `json:"name"`
+	LastStatus   string `json:"lastStatus"`
+	ExitCode     *int32 `json:"exitCode"`
+	Reason       string `json:"reason"`
+}
+
+func convertTask(task *storage.Task) Task {
+	result := Task{
+		TaskArn:           task.ARN,
+		ClusterArn:        task.ClusterARN,
+		TaskDefinitionArn: task.TaskDefinitionARN,
+		ServiceName:       strings.TrimPrefix(task.Group, "service:"),
+		LastStatus:        task.LastStatus,
+		DesiredStatus:     task.DesiredStatus,
+		HealthStatus:      task.HealthStatus,
+		Cpu:               task.CPU,
+		Memory:            task.Memory,
+		CreatedAt:         task.CreatedAt,
+		StartedAt:         task.StartedAt,
+		StoppedAt:         task.StoppedAt,
+	}
+	if !strings.HasPrefix(task.Group, "service:") {
+		result.ServiceName = ""
+	}
+	var containers []storedContainer
+	if task.Containers != "" && json.Unmarshal([]byte(task.Containers), &containers) == nil {
+		for _, container := range containers {
+			result.Containers = append(result.Containers, Container(container))
+		}
+	}
+	return result
+}
+
+// clusterFromTaskArn returns the cluster of a task ARN of the long format,
+// arn:aws:ecs:region:account:task/cluster/id
+func clusterFromTaskArn(taskArn string) string {
+	_, resource, ok := strings.Cut(taskArn, ":task/")
+	if !ok {
+		return ""
+	}
+	cluster, _, ok := strings.Cut(resource, "/")
+	if !ok {
+		return ""
+	}
+	return cluster
+}
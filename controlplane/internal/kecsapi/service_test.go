@@ -0,0 +1,199 @@
+package kecsapi_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsrequest"
+	"github.com/nandemo-ya/kecs/controlplane/internal/connect"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/mocks"
+	"github.com/nandemo-ya/kecs/controlplane/internal/kecsapi"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// fakeLogStreamer sends a fixed set of log lines
+type fakeLogStreamer struct {
+	cluster string
+	lines   []string
+}
+
+func (f *fakeLogStreamer) StreamTaskLogs(ctx context.Context, cluster, taskArn string, tail int64, follow bool, send func(kecsapi.LogEntry) error) error {
+	f.cluster = cluster
+	for _, line := range f.lines {
+		if err := send(kecsapi.LogEntry{Container: "app", Message: line}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var _ = Describe("KecsService", func() {
+	const (
+		clusterARN = "arn:aws:ecs:us-east-1:000000000000:cluster/default"
+		taskARN    = "arn:aws:ecs:us-east-1:000000000000:task/default/web-1"
+	)
+
+	var (
+		ctx        context.Context
+		cancel     context.CancelFunc
+		tasks      *mocks.MockTaskStore
+		kecsServer *kecsapi.Server
+		server     *httptest.Server
+		client     *kecsapi.Client
+	)
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+		mockStorage := mocks.NewMockStorage()
+		clusters := mocks.NewMockClusterStore()
+		services := mocks.NewMockServiceStore()
+		tasks = mocks.NewMockTaskStore()
+		mockStorage.SetClusterStore(clusters)
+		mockStorage.SetServiceStore(services)
+		mockStorage.SetTaskStore(tasks)
+
+		Expect(clusters.Create(ctx, &storage.Cluster{
+			Name: "default", ARN: clusterARN, Status: "ACTIVE",
+			ActiveServicesCount: 1, RunningTasksCount: 1,
+		})).To(Succeed())
+		Expect(services.Create(ctx, &storage.Service{
+			ServiceName: "web", ARN: "arn:aws:ecs:us-east-1:000000000000:service/default/web",
+			ClusterARN: clusterARN, Status: "ACTIVE", DesiredCount: 1, RunningCount: 1,
+		})).To(Succeed())
+		Expect(tasks.Create(ctx, &storage.Task{
+			ID: "web-1", ARN: taskARN, ClusterARN: clusterARN,
+			LastStatus: "RUNNING", DesiredStatus: "RUNNING",
+			Group: "service:web", StartedBy: "ecs-svc/web",
+			Containers: `[{"name":"app","lastStatus":"RUNNING","exitCode":null}]`,
+		})).To(Succeed())
+
+		kecsServer = kecsapi.NewServer(mockStorage, 20*time.Millisecond)
+		_, handler := kecsServer.Handler()
+		server = httptest.NewServer(handler)
+		client = kecsapi.NewClient(server.URL, nil)
+	})
+
+	AfterEach(func() {
+		cancel()
+		server.Close()
+	})
+
+	It("should summarize the instance", func() {
+		summary, err := client.GetSummary(ctx, &kecsapi.GetSummaryRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*summary).To(Equal(kecsapi.Summary{Clusters: 1, Services: 1, RunningTasks: 1}))
+	})
+
+	It("should serve clients using the protobuf codec", func() {
+		resp, err := http.Post(server.URL+kecsapi.GetSummaryProcedure, "application/proto", nil)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		body, err := io.ReadAll(resp.Body)
+		Expect(err).NotTo(HaveOccurred())
+		// clusters = 1, services = 1, running_tasks = 1
+		Expect(body).To(Equal([]byte{0x08, 0x01, 0x10, 0x01, 0x18, 0x01}))
+	})
+
+	It("should be identified as API calls to authenticate and authorize", func() {
+		op, ok := awsrequest.Identify(httptest.NewRequest(http.MethodPost, kecsapi.WatchTasksProcedure, nil))
+		Expect(ok).To(BeTrue())
+		Expect(op.Service).To(Equal(kecsapi.AuthService))
+		Expect(op.Name).To(Equal("WatchTasks"))
+	})
+
+	It("should stream the clusters when the stream opens", func() {
+		stream, err := client.WatchClusters(ctx, &kecsapi.WatchClustersRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		defer stream.Close()
+
+		list, err := stream.Receive()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list.Clusters).To(HaveLen(1))
+		Expect(list.Clusters[0].ClusterName).To(Equal("default"))
+		Expect(list.Clusters[0].ActiveServicesCount).To(Equal(int32(1)))
+	})
+
+	It("should stream the services of a cluster", func() {
+		stream, err := client.WatchServices(ctx, &kecsapi.WatchServicesRequest{Cluster: clusterARN})
+		Expect(err).NotTo(HaveOccurred())
+		defer stream.Close()
+
+		list, err := stream.Receive()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list.Services).To(HaveLen(1))
+		Expect(list.Services[0].ServiceName).To(Equal("web"))
+		Expect(list.Services[0].ClusterArn).To(Equal(clusterARN))
+	})
+
+	It("should push the tasks again when they change", func() {
+		stream, err := client.WatchTasks(ctx, &kecsapi.WatchTasksRequest{Cluster: "default"})
+		Expect(err).NotTo(HaveOccurred())
+		defer stream.Close()
+
+		list, err := stream.Receive()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list.Tasks).To(HaveLen(1))
+		Expect(list.Tasks[0].ServiceName).To(Equal("web"))
+		Expect(list.Tasks[0].Containers).To(ConsistOf(kecsapi.Container{Name: "app", LastStatus: "RUNNING"}))
+
+		Expect(tasks.Create(ctx, &storage.Task{
+			ID: "batch-1", ARN: "arn:aws:ecs:us-east-1:000000000000:task/default/batch-1", ClusterARN: clusterARN,
+			LastStatus: "PENDING", DesiredStatus: "RUNNING",
+		})).To(Succeed())
+
+		list, err = stream.Receive()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list.Tasks).To(HaveLen(2))
+		Expect(list.Tasks[0].TaskArn).To(HaveSuffix("batch-1"))
+		Expect(list.Tasks[0].ServiceName).To(BeEmpty())
+	})
+
+	It("should fail to watch an unknown cluster", func() {
+		stream, err := client.WatchServices(ctx, &kecsapi.WatchServicesRequest{Cluster: "missing"})
+		Expect(err).NotTo(HaveOccurred())
+		defer stream.Close()
+
+		_, err = stream.Receive()
+		Expect(connect.CodeOf(err)).To(Equal(connect.CodeNotFound))
+	})
+
+	Describe("StreamTaskLogs", func() {
+		It("should be unavailable without log source", func() {
+			stream, err := client.StreamTaskLogs(ctx, &kecsapi.StreamTaskLogsRequest{TaskArn: taskARN})
+			Expect(err).NotTo(HaveOccurred())
+			defer stream.Close()
+
+			_, err = stream.Receive()
+			Expect(connect.CodeOf(err)).To(Equal(connect.CodeUnavailable))
+		})
+
+		It("should stream the log lines of the task", func() {
+			logs := &fakeLogStreamer{lines: []string{"starting", "ready"}}
+			kecsServer.SetTaskLogStreamer(logs)
+
+			stream, err := client.StreamTaskLogs(ctx, &kecsapi.StreamTaskLogsRequest{TaskArn: taskARN})
+			Expect(err).NotTo(HaveOccurred())
+			defer stream.Close()
+
+			var messages []string
+			for {
+				entry, err := stream.Receive()
+				if err == io.EOF {
+					break
+				}
+				Expect(err).NotTo(HaveOccurred())
+				messages = append(messages, entry.Message)
+			}
+			Expect(messages).To(Equal([]string{"starting", "ready"}))
+			// The cluster is taken from the task ARN
+			Expect(logs.cluster).To(Equal("default"))
+		})
+	})
+})
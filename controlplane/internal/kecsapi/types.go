@@ -0,0 +1,122 @@
+package kecsapi
+
+import "time"
+
+// The messages of kecs.v1.KecsService, see proto/kecs/v1/kecs.proto. Field
+// names follow the JSON mapping of protobuf and protobuf tags carry the field
+// numbers of the schema, so that the messages match what Connect clients
+// generated from the schema send and expect with either codec.
+
+// GetSummaryRequest is the request of GetSummary
+type GetSummaryRequest struct{}
+
+// WatchSummaryRequest is the request of WatchSummary
+type WatchSummaryRequest struct{}
+
+// Summary counts the resources of the instance
+type Summary struct {
+	Clusters     int32 `json:"clusters" protobuf:"1"`
+	Services     int32 `json:"services" protobuf:"2"`
+	RunningTasks int32 `json:"runningTasks" protobuf:"3"`
+	PendingTasks int32 `json:"pendingTasks" protobuf:"4"`
+}
+
+// WatchClustersRequest is the request of WatchClusters
+type WatchClustersRequest struct{}
+
+// ClusterList is a snapshot of the clusters of the instance
+type ClusterList struct {
+	Clusters []Cluster `json:"clusters" protobuf:"1"`
+}
+
+// Cluster is an ECS cluster
+type Cluster struct {
+	ClusterArn                        string `json:"clusterArn" protobuf:"1"`
+	ClusterName                       string `json:"clusterName" protobuf:"2"`
+	Status                            string `json:"status" protobuf:"3"`
+	RegisteredContainerInstancesCount int32  `json:"registeredContainerInstancesCount" protobuf:"4"`
+	RunningTasksCount                 int32  `json:"runningTasksCount" protobuf:"5"`
+	PendingTasksCount                 int32  `json:"pendingTasksCount" protobuf:"6"`
+	ActiveServicesCount               int32  `json:"activeServicesCount" protobuf:"7"`
+}
+
+// WatchServicesRequest is the request of WatchServices. Without cluster the
+// services of all clusters are watched.
+type WatchServicesRequest struct {
+	Cluster string `json:"cluster,omitempty" protobuf:"1"`
+}
+
+// ServiceList is a snapshot of services
+type ServiceList struct {
+	Services []Service `json:"services" protobuf:"1"`
+}
+
+// Service is an ECS service
+type Service struct {
+	ServiceArn     string    `json:"serviceArn" protobuf:"1"`
+	ServiceName    string    `json:"serviceName" protobuf:"2"`
+	ClusterArn     string    `json:"clusterArn" protobuf:"3"`
+	Status         string    `json:"status" protobuf:"4"`
+	DesiredCount   int32     `json:"desiredCount" protobuf:"5"`
+	RunningCount   int32     `json:"runningCount" protobuf:"6"`
+	PendingCount   int32     `json:"pendingCount" protobuf:"7"`
+	TaskDefinition string    `json:"taskDefinition" protobuf:"8"`
+	CreatedAt      time.Time `json:"createdAt" protobuf:"9"`
+}
+
+// WatchTasksRequest is the request of WatchTasks. Without cluster the tasks
+// of all clusters are watched, without service name the tasks of all services
+// and standalone tasks.
+type WatchTasksRequest struct {
+	Cluster     string `json:"cluster,omitempty" protobuf:"1"`
+	ServiceName string `json:"serviceName,omitempty" protobuf:"2"`
+}
+
+// TaskList is a snapshot of tasks
+type TaskList struct {
+	Tasks []Task `json:"tasks" protobuf:"1"`
+}
+
+// Task is an ECS task
+type Task struct {
+	TaskArn           string      `json:"taskArn" protobuf:"1"`
+	ClusterArn        string      `json:"clusterArn" protobuf:"2"`
+	TaskDefinitionArn string      `json:"taskDefinitionArn" protobuf:"3"`
+	ServiceName       string      `json:"serviceName,omitempty" protobuf:"4"`
+	LastStatus        string      `json:"lastStatus" protobuf:"5"`
+	DesiredStatus     string      `json:"desiredStatus" protobuf:"6"`
+	HealthStatus      string      `json:"healthStatus,omitempty" protobuf:"7"`
+	Cpu               string      `json:"cpu,omitempty" protobuf:"8"`
+	Memory            string      `json:"memory,omitempty" protobuf:"9"`
+	CreatedAt         time.Time   `json:"createdAt" protobuf:"10"`
+	StartedAt         *time.Time  `json:"startedAt,omitempty" protobuf:"11"`
+	StoppedAt         *time.Time  `json:"stoppedAt,omitempty" protobuf:"12"`
+	Containers        []Container `json:"containers,omitempty" protobuf:"13"`
+}
+
+// Container is a container of a task
+type Container struct {
+	ContainerArn string `json:"containerArn,omitempty" protobuf:"1"`
+	Name         string `json:"name" protobuf:"2"`
+	LastStatus   string `json:"lastStatus,omitempty" protobuf:"3"`
+	ExitCode     *int32 `json:"exitCode,omitempty" protobuf:"4"`
+	Reason       string `json:"reason,omitempty" protobuf:"5"`
+}
+
+// StreamTaskLogsRequest is the request of StreamTaskLogs
+type StreamTaskLogsRequest struct {
+	Cluster string `json:"cluster,omitempty" protobuf:"1"`
+	TaskArn string `json:"taskArn" protobuf:"2"`
+	// Tail is the number of lines sent before following, all when zero
+	Tail int32 `json:"tail,omitempty" protobuf:"3"`
+	// Follow keeps the stream open for new lines
+	Follow bool `json:"follow,omitempty" protobuf:"4"`
+}
+
+// LogEntry is a log line of a task container
+type LogEntry struct {
+	Timestamp time.Time `json:"timestamp" protobuf:"1"`
+	Container string    `json:"container,omitempty" protobuf:"2"`
+	Level     string    `json:"level,omitempty" protobuf:"3"`
+	Message   string    `json:"message" protobuf:"4"`
+}
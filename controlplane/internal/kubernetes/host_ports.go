@@ -0,0 +1,273 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// hostnameLabel is the node label tasks with host ports are pinned with
+const hostnameLabel = "kubernetes.io/hostname"
+
+// HostPortsUnavailableError is returned by CreateTask when no node has the
+// host ports of a task free. RunTask reports it like ECS does for container
+// instances without the ports.
+type HostPortsUnavailableError struct {
+	Port     int32
+	Protocol corev1.Protocol
+}
+
+func (e *HostPortsUnavailableError) Error() string {
+	return fmt.Sprintf("no node has host port %d/%s available", e.Port, e.Protocol)
+}
+
+// Reason returns the failure reason ECS reports for the port
+func (e *HostPortsUnavailableError) Reason() string {
+	if e.Protocol == corev1.ProtocolUDP {
+		return "RESOURCE:PORTS_UDP"
+	}
+	return "RESOURCE:PORTS"
+}
+
+// hostPort is a port bound on a node
+type hostPort struct {
+	port     int32
+	protocol corev1.Protocol
+}
+
+// hostPortReservation is the node and host ports of a task
+type hostPortReservation struct {
+	node  string
+	ports []hostPort
+}
+
+// hostPortTracker tracks the host ports of the tasks KECS placed on each
+// node. The pods of concurrent RunTask calls may not be listed yet, so the
+// ports are reserved when a task is placed until it stops.
+type hostPortTracker struct {
+	mu           sync.Mutex
+	reservations map[string]hostPortReservation // taskARN -> reservation
+}
+
+func newHostPortTracker() *hostPortTracker {
+	return &hostPortTracker{reservations: make(map[string]hostPortReservation)}
+}
+
+// podHostPorts returns the host ports a pod binds. Pods in the host network
+// bind their container ports.
+func podHostPorts(pod *corev1.Pod) []hostPort {
+	var ports []hostPort
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			hp := port.HostPort
+			if pod.Spec.HostNetwork {
+				hp = port.ContainerPort
+			}
+			if hp == 0 {
+				continue
+			}
+			protocol := port.Protocol
+			if protocol == "" {
+				protocol = corev1.ProtocolTCP
+			}
+			ports = append(ports, hostPort{port: hp, protocol: protocol})
+		}
+	}
+	return ports
+}
+
+// placeHostPorts places a task that binds host ports on a node where they are
+// free and pins its pod there. Without nodes, e.g. in test mode, KECS stands
+// for a single node.
+func (tm *TaskManager) placeHostPorts(ctx context.Context, pod *corev1.Pod, task *storage.Task) error {
+	wanted := podHostPorts(pod)
+	if len(wanted) == 0 || tm.hostPorts == nil {
+		return nil
+	}
+
+	nodes := tm.candidateNodes(ctx, pod)
+
+	tm.hostPorts.mu.Lock()
+	defer tm.hostPorts.mu.Unlock()
+
+	used := tm.hostPortsInUseLocked(ctx)
+	var conflict *HostPortsUnavailableError
+	for _, node := range nodes {
+		taken := hostPortConflict(used[node.name], wanted)
+		if taken != nil {
+			if conflict == nil {
+				conflict = &HostPortsUnavailableError{Port: taken.port, Protocol: taken.protocol}
+			}
+			continue
+		}
+
+		tm.hostPorts.reservations[task.ARN] = hostPortReservation{node: node.name, ports: wanted}
+		if node.hostname != "" {
+			if pod.Spec.NodeSelector == nil {
+				pod.Spec.NodeSelector = make(map[string]string)
+			}
+			pod.Spec.NodeSelector[hostnameLabel] = node.hostname
+		}
+		logging.Debug("Placed task with host ports", "task", task.ARN, "node", node.name, "ports", len(wanted))
+		return nil
+	}
+
+	if conflict == nil {
+		// No node matches the placement of the task
+		conflict = &HostPortsUnavailableError{Port: wanted[0].port, Protocol: wanted[0].protocol}
+	}
+	return conflict
+}
+
+// releaseHostPorts releases the host ports reserved for a task
+func (tm *TaskManager) releaseHostPorts(taskARN string) {
+	if tm.hostPorts == nil {
+		return
+	}
+	tm.hostPorts.mu.Lock()
+	defer tm.hostPorts.mu.Unlock()
+	delete(tm.hostPorts.reservations, taskARN)
+}
+
+// hostPortConflict returns the first wanted port that is already used
+func hostPortConflict(used map[hostPort]bool, wanted []hostPort) *hostPort {
+	for i := range wanted {
+		if used[wanted[i]] {
+			return &wanted[i]
+		}
+	}
+	return nil
+}
+
+// placementNode is a node tasks with host ports can be placed on
+type placementNode struct {
+	name     string
+	hostname string
+}
+
+// candidateNodes returns the schedulable nodes matching the node selector of
+// a pod, or the single implicit node when the nodes cannot be listed
+func (tm *TaskManager) candidateNodes(ctx context.Context, pod *corev1.Pod) []placementNode {
+	if tm.Clientset == nil {
+		return []placementNode{{}}
+	}
+	nodeList, err := tm.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil || len(nodeList.Items) == 0 {
+		return []placementNode{{}}
+	}
+
+	var nodes []placementNode
+	for _, node := range nodeList.Items {
+		if node.Spec.Unschedulable || !matchesNodeSelector(node.Labels, pod.Spec.NodeSelector) {
+			continue
+		}
+		hostname := node.Labels[hostnameLabel]
+		if hostname == "" {
+			hostname = node.Name
+		}
+		nodes = append(nodes, placementNode{name: node.Name, hostname: hostname})
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].name < nodes[j].name })
+	return nodes
+}
+
+// matchesNodeSelector reports whether the labels of a node match a selector
+func matchesNodeSelector(labels, selector map[string]string) bool {
+	for key, value := range selector {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// hostPortsInUseLocked returns the host ports used on each node, by the
+// reservations of KECS and the pods running there. The caller holds the
+// tracker lock.
+func (tm *TaskManager) hostPortsInUseLocked(ctx context.Context) map[string]map[hostPort]bool {
+	used := make(map[string]map[hostPort]bool)
+	add := func(node string, ports []hostPort) {
+		if used[node] == nil {
+			used[node] = make(map[hostPort]bool)
+		}
+		for _, port := range ports {
+			used[node][port] = true
+		}
+	}
+
+	for _, reservation := range tm.hostPorts.reservations {
+		add(reservation.node, reservation.ports)
+	}
+
+	if tm.Clientset == nil {
+		return used
+	}
+	pods, err := tm.Clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		logging.Warn("Failed to list pods for host ports", "error", err)
+		return used
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if _, reserved := tm.hostPorts.reservations[pod.Annotations["kecs.dev/task-arn"]]; reserved {
+			continue
+		}
+		node := pod.Spec.NodeName
+		if node == "" {
+			node = pod.Spec.NodeSelector[hostnameLabel]
+		}
+		if node == "" {
+			continue
+		}
+		add(node, podHostPorts(pod))
+	}
+	return used
+}
+
+// HostPortsInUse returns the TCP and UDP host ports tasks use on a node,
+// which DescribeContainerInstances reports as the remaining port resources.
+// Without nodes all tasks run on the single implicit node.
+func (tm *TaskManager) HostPortsInUse(ctx context.Context, node string) (tcp, udp []string) {
+	if tm.hostPorts == nil {
+		return nil, nil
+	}
+	if nodes := tm.candidateNodes(ctx, &corev1.Pod{}); len(nodes) == 1 && nodes[0].name == "" {
+		node = ""
+	}
+
+	tm.hostPorts.mu.Lock()
+	used := tm.hostPortsInUseLocked(ctx)[node]
+	tm.hostPorts.mu.Unlock()
+
+	for port := range used {
+		value := strconv.Itoa(int(port.port))
+		if port.protocol == corev1.ProtocolUDP {
+			udp = append(udp, value)
+		} else {
+			tcp = append(tcp, value)
+		}
+	}
+	sortPorts(tcp)
+	sortPorts(udp)
+	return tcp, udp
+}
+
+// sortPorts sorts ports numerically
+func sortPorts(ports []string) {
+	sort.Slice(ports, func(i, j int) bool {
+		a, _ := strconv.Atoi(ports[i])
+		b, _ := strconv.Atoi(ports[j])
+		return a < b
+	})
+}
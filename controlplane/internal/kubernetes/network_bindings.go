@@ -0,0 +1,144 @@
+package kubernetes
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
+	appconfig "github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/types"
+)
+
+// newHostPortManager creates the port manager of the host ports assigned to
+// bridge mode port mappings without hostPort
+func newHostPortManager() *PortManager {
+	portRange, err := converters.ParsePortRange(appconfig.GetString("network.dynamicHostPortRange"))
+	if err != nil {
+		logging.Warn("Invalid network.dynamicHostPortRange, using 49153-65535", "error", err)
+		portRange = converters.PortRange{Start: 49153, End: 65535}
+	}
+	return NewPortManager(portRange.Start, portRange.End)
+}
+
+// dynamicHostPorts is the port manager shared by the task managers and the
+// pod webhook, so standalone tasks and service pods get distinct host ports
+var dynamicHostPorts = sync.OnceValue(newHostPortManager)
+
+// isBridgeMode reports whether a pod runs a task in the bridge network mode
+func isBridgeMode(pod *corev1.Pod) bool {
+	return pod.Annotations["ecs.amazonaws.com/network-mode"] == string(types.NetworkModeBridge)
+}
+
+// allocateDynamicHostPorts assigns host ports to the ports of bridge mode
+// tasks without hostPort
+func (tm *TaskManager) allocateDynamicHostPorts(pod *corev1.Pod, task *storage.Task) error {
+	if tm.hostPortManager == nil {
+		return nil
+	}
+	return allocateHostPorts(tm.hostPortManager, pod, task.ARN)
+}
+
+// AllocateServicePodHostPorts assigns host ports to the ports of a bridge
+// mode service pod without hostPort. The replicas of a Deployment share their
+// pod template, so the pod webhook assigns them when the pod is created.
+func AllocateServicePodHostPorts(pod *corev1.Pod, taskARN string) error {
+	return allocateHostPorts(dynamicHostPorts(), pod, taskARN)
+}
+
+// ServicePodTaskARN returns the ARN of the task of a service pod with the
+// given task ID, in the region and account of its service. It is empty when
+// the pod does not record its service.
+func ServicePodTaskARN(pod *corev1.Pod, taskID string) string {
+	parts := strings.SplitN(pod.Annotations["kecs.dev/service-arn"], ":", 6)
+	cluster := pod.Labels["kecs.dev/cluster"]
+	if len(parts) < 6 || parts[0] != "arn" || cluster == "" {
+		return ""
+	}
+	return awsarn.Build("ecs", parts[3], parts[4], fmt.Sprintf("task/%s/%s", cluster, taskID))
+}
+
+// allocateHostPorts assigns host ports to the ports of bridge mode tasks
+// without hostPort, as ECS does on container instances. The ports of a
+// containerPortRange get consecutive host ports.
+func allocateHostPorts(ports *PortManager, pod *corev1.Pod, taskARN string) error {
+	if !isBridgeMode(pod) {
+		return nil
+	}
+
+	portRanges := converters.ContainerPortRanges(pod.Annotations)
+	for i := range pod.Spec.Containers {
+		container := &pod.Spec.Containers[i]
+
+		for _, portRange := range portRanges[container.Name] {
+			var indexes []int
+			var containerPorts []int32
+			for j, port := range container.Ports {
+				if port.HostPort == 0 && port.Protocol == portRange.Protocol && portRange.Range.Contains(port.ContainerPort) {
+					indexes = append(indexes, j)
+					containerPorts = append(containerPorts, port.ContainerPort)
+				}
+			}
+			hostPorts, err := ports.AllocatePortRange(taskARN, containerPorts, string(portRange.Protocol))
+			if err != nil {
+				_ = ports.ReleaseTaskPorts(taskARN)
+				return fmt.Errorf("failed to allocate host ports for container %s: %w", container.Name, err)
+			}
+			for k, j := range indexes {
+				container.Ports[j].HostPort = hostPorts[k]
+			}
+		}
+
+		for j := range container.Ports {
+			port := &container.Ports[j]
+			if port.HostPort != 0 {
+				continue
+			}
+			hostPort, err := ports.AllocatePort(taskARN, port.ContainerPort, string(port.Protocol))
+			if err != nil {
+				_ = ports.ReleaseTaskPorts(taskARN)
+				return fmt.Errorf("failed to allocate host port for container %s: %w", container.Name, err)
+			}
+			port.HostPort = hostPort
+		}
+	}
+	return nil
+}
+
+// releaseDynamicHostPorts releases the host ports assigned to a task
+func (tm *TaskManager) releaseDynamicHostPorts(taskARN string) {
+	if tm.hostPortManager == nil {
+		return
+	}
+	if err := tm.hostPortManager.ReleaseTaskPorts(taskARN); err != nil {
+		logging.Warn("Failed to release host ports for task", "task", taskARN, "error", err)
+	}
+}
+
+// releaseTaskPorts releases the dynamic and node host ports of a task
+func (tm *TaskManager) releaseTaskPorts(taskARN string) {
+	tm.releaseDynamicHostPorts(taskARN)
+	tm.releaseHostPorts(taskARN)
+}
+
+// reserveDynamicHostPorts records the host ports of a bridge mode pod that
+// already runs, so they are not assigned to another task
+func (tm *TaskManager) reserveDynamicHostPorts(pod *corev1.Pod, taskARN string) {
+	if tm.hostPortManager == nil || !isBridgeMode(pod) {
+		return
+	}
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			if port.HostPort == 0 {
+				continue
+			}
+			// Fixed host ports outside the dynamic range are not tracked
+			_ = tm.hostPortManager.ReservePort(taskARN, port.HostPort, port.ContainerPort, string(port.Protocol))
+		}
+	}
+}
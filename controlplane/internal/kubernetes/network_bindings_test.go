@@ -0,0 +1,58 @@
+package kubernetes_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/kubernetes"
+)
+
+var _ = Describe("Service pod host ports", func() {
+	newServicePod := func(networkMode string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{"kecs.dev/cluster": "default"},
+				Annotations: map[string]string{
+					"kecs.dev/service-arn":           "arn:aws:ecs:ap-northeast-1:123456789012:service/default/web",
+					"ecs.amazonaws.com/network-mode": networkMode,
+				},
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Name: "web",
+					Ports: []corev1.ContainerPort{
+						{ContainerPort: 80, Protocol: corev1.ProtocolTCP},
+						{ContainerPort: 443, HostPort: 8443, Protocol: corev1.ProtocolTCP},
+					},
+				}},
+			},
+		}
+	}
+
+	It("should build the task ARN in the region and account of the service", func() {
+		Expect(kubernetes.ServicePodTaskARN(newServicePod("bridge"), "abc")).To(
+			Equal("arn:aws:ecs:ap-northeast-1:123456789012:task/default/abc"))
+		Expect(kubernetes.ServicePodTaskARN(&corev1.Pod{}, "abc")).To(BeEmpty())
+	})
+
+	It("should assign distinct host ports to the replicas of bridge mode services", func() {
+		first := newServicePod("bridge")
+		second := newServicePod("bridge")
+		Expect(kubernetes.AllocateServicePodHostPorts(first, "arn:aws:ecs:us-east-1:000000000000:task/default/first")).To(Succeed())
+		Expect(kubernetes.AllocateServicePodHostPorts(second, "arn:aws:ecs:us-east-1:000000000000:task/default/second")).To(Succeed())
+
+		Expect(first.Spec.Containers[0].Ports[0].HostPort).To(BeNumerically(">=", 49153))
+		Expect(second.Spec.Containers[0].Ports[0].HostPort).To(BeNumerically(">=", 49153))
+		Expect(first.Spec.Containers[0].Ports[0].HostPort).NotTo(Equal(second.Spec.Containers[0].Ports[0].HostPort))
+		// Fixed host ports are kept
+		Expect(first.Spec.Containers[0].Ports[1].HostPort).To(Equal(int32(8443)))
+	})
+
+	It("should leave the ports of other network modes alone", func() {
+		pod := newServicePod("awsvpc")
+		Expect(kubernetes.AllocateServicePodHostPorts(pod, "arn:aws:ecs:us-east-1:000000000000:task/default/awsvpc")).To(Succeed())
+		Expect(pod.Spec.Containers[0].Ports[0].HostPort).To(BeZero())
+	})
+})
@@ -0,0 +1,266 @@
+package kubernetes
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+
+	appconfig "github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/efs"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+const (
+	nfsServerNamespace = "kecs-system"
+	nfsServerName      = "kecs-nfs"
+	nfsServerConfigMap = "kecs-nfs-exports"
+	nfsServerPVC       = "kecs-nfs-data"
+	nfsServerPort      = 2049
+)
+
+var (
+	nfsServerMu       sync.Mutex
+	nfsServerDeployed bool
+)
+
+// DeployNFSServerOnce ensures the NFS server serving EFS volumes is deployed
+// once per KECS instance. Unlike Vector it is retried after a failure, as it is
+// deployed when the first task mounts an EFS volume.
+func DeployNFSServerOnce(ctx context.Context, clientset kubernetes.Interface) error {
+	nfsServerMu.Lock()
+	defer nfsServerMu.Unlock()
+	if nfsServerDeployed {
+		return nil
+	}
+
+	logging.Info("Deploying NFS server for EFS volumes (singleton)")
+	if err := EnsureNFSServer(ctx, clientset); err != nil {
+		return err
+	}
+	nfsServerDeployed = true
+	return nil
+}
+
+// ResetNFSServerSingleton resets the singleton state (mainly for testing)
+func ResetNFSServerSingleton() {
+	nfsServerMu.Lock()
+	defer nfsServerMu.Unlock()
+	nfsServerDeployed = false
+}
+
+// EnsureNFSServer ensures the NFS server is deployed in kecs-system namespace
+// with the exports of the configured access points
+func EnsureNFSServer(ctx context.Context, clientset kubernetes.Interface) error {
+	accessPoints, err := efs.ParseAccessPoints(appconfig.GetString("efs.accessPoints"))
+	if err != nil {
+		return fmt.Errorf("invalid efs.accessPoints: %w", err)
+	}
+
+	if err := EnsureNamespace(ctx, clientset, nfsServerNamespace); err != nil {
+		return fmt.Errorf("failed to create namespace: %w", err)
+	}
+
+	data := map[string]string{
+		"exports":  efs.Exports(accessPoints),
+		"setup.sh": efs.SetupScript(accessPoints),
+	}
+	if err := applyNFSServerConfigMap(ctx, clientset, data); err != nil {
+		return fmt.Errorf("failed to apply NFS server ConfigMap: %w", err)
+	}
+
+	if err := createNFSServerPVC(ctx, clientset); err != nil {
+		return fmt.Errorf("failed to create NFS server PVC: %w", err)
+	}
+
+	if err := applyNFSServerDeployment(ctx, clientset, configChecksum(data)); err != nil {
+		return fmt.Errorf("failed to apply NFS server Deployment: %w", err)
+	}
+
+	if err := createNFSServerService(ctx, clientset); err != nil {
+		return fmt.Errorf("failed to create NFS server Service: %w", err)
+	}
+
+	logging.Info("NFS server successfully deployed", "clusterIP", appconfig.GetString("efs.nfsServer.clusterIP"), "accessPoints", len(accessPoints))
+	return nil
+}
+
+func applyNFSServerConfigMap(ctx context.Context, clientset kubernetes.Interface, data map[string]string) error {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      nfsServerConfigMap,
+			Namespace: nfsServerNamespace,
+		},
+		Data: data,
+	}
+
+	_, err := clientset.CoreV1().ConfigMaps(nfsServerNamespace).Create(ctx, cm, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		_, err = clientset.CoreV1().ConfigMaps(nfsServerNamespace).Update(ctx, cm, metav1.UpdateOptions{})
+	}
+	return err
+}
+
+func createNFSServerPVC(ctx context.Context, clientset kubernetes.Interface) error {
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      nfsServerPVC,
+			Namespace: nfsServerNamespace,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: resource.MustParse(appconfig.GetString("efs.nfsServer.storageSize")),
+				},
+			},
+		},
+	}
+
+	_, err := clientset.CoreV1().PersistentVolumeClaims(nfsServerNamespace).Create(ctx, pvc, metav1.CreateOptions{})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+func applyNFSServerDeployment(ctx context.Context, clientset kubernetes.Interface, checksum string) error {
+	replicas := int32(1)
+	privileged := true
+	labels := map[string]string{
+		"app":                nfsServerName,
+		"kecs.dev/component": "efs",
+	}
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      nfsServerName,
+			Namespace: nfsServerNamespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": nfsServerName},
+			},
+			// The data volume can only be mounted by one server at a time
+			Strategy: appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+					Annotations: map[string]string{
+						// Restarts the server when the exports change
+						"kecs.dev/config-checksum": checksum,
+					},
+				},
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{
+						{
+							Name:    "setup",
+							Image:   "busybox:1.36",
+							Command: []string{"sh", "/config/setup.sh"},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "config", MountPath: "/config", ReadOnly: true},
+								{Name: "data", MountPath: efs.ExportRoot},
+							},
+						},
+					},
+					Containers: []corev1.Container{
+						{
+							Name:  "nfs-server",
+							Image: appconfig.GetString("efs.nfsServer.image"),
+							Env: []corev1.EnvVar{
+								// Only NFSv4 is served, so rpcbind and mountd ports are not needed
+								{Name: "NFS_DISABLE_VERSION_3", Value: "1"},
+							},
+							Ports: []corev1.ContainerPort{
+								{Name: "nfs", ContainerPort: nfsServerPort, Protocol: corev1.ProtocolTCP},
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "config", MountPath: "/etc/exports", SubPath: "exports", ReadOnly: true},
+								{Name: "data", MountPath: efs.ExportRoot},
+							},
+							SecurityContext: &corev1.SecurityContext{
+								Privileged: &privileged,
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "config",
+							VolumeSource: corev1.VolumeSource{
+								ConfigMap: &corev1.ConfigMapVolumeSource{
+									LocalObjectReference: corev1.LocalObjectReference{Name: nfsServerConfigMap},
+								},
+							},
+						},
+						{
+							Name: "data",
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: nfsServerPVC},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	_, err := clientset.AppsV1().Deployments(nfsServerNamespace).Create(ctx, deployment, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		_, err = clientset.AppsV1().Deployments(nfsServerNamespace).Update(ctx, deployment, metav1.UpdateOptions{})
+	}
+	return err
+}
+
+func createNFSServerService(ctx context.Context, clientset kubernetes.Interface) error {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      nfsServerName,
+			Namespace: nfsServerNamespace,
+			Labels: map[string]string{
+				"app":                nfsServerName,
+				"kecs.dev/component": "efs",
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			// Kubelet mounts NFS volumes from the node, which cannot resolve
+			// cluster DNS names, so task volumes refer to this fixed address
+			ClusterIP: appconfig.GetString("efs.nfsServer.clusterIP"),
+			Selector:  map[string]string{"app": nfsServerName},
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "nfs",
+					Port:       nfsServerPort,
+					TargetPort: intstr.FromInt32(nfsServerPort),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+		},
+	}
+
+	_, err := clientset.CoreV1().Services(nfsServerNamespace).Create(ctx, service, metav1.CreateOptions{})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+// configChecksum returns a checksum of the NFS server configuration
+func configChecksum(data map[string]string) string {
+	hash := sha256.New()
+	for _, key := range []string{"exports", "setup.sh"} {
+		hash.Write([]byte(key))
+		hash.Write([]byte(data[key]))
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
@@ -0,0 +1,71 @@
+package kubernetes_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/kubernetes"
+)
+
+var _ = Describe("NFS server", func() {
+	var (
+		ctx        context.Context
+		kubeClient *fake.Clientset
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		kubeClient = fake.NewSimpleClientset()
+		config.Set("efs.accessPoints", "fsap-app=fs-1:/app:1000:1000")
+	})
+
+	AfterEach(func() {
+		config.Set("efs.accessPoints", "")
+		kubernetes.ResetNFSServerSingleton()
+	})
+
+	It("should deploy the server with the exports of the access points", func() {
+		Expect(kubernetes.EnsureNFSServer(ctx, kubeClient)).To(Succeed())
+
+		cm, err := kubeClient.CoreV1().ConfigMaps("kecs-system").Get(ctx, "kecs-nfs-exports", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cm.Data["exports"]).To(ContainSubstring("/exports/fs-1/app *(rw,fsid=1,no_subtree_check,insecure,all_squash,anonuid=1000,anongid=1000)"))
+		Expect(cm.Data["setup.sh"]).To(ContainSubstring("chown 1000:1000 '/exports/fs-1/app'"))
+
+		service, err := kubeClient.CoreV1().Services("kecs-system").Get(ctx, "kecs-nfs", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(service.Spec.ClusterIP).To(Equal("10.43.0.49"))
+
+		_, err = kubeClient.CoreV1().PersistentVolumeClaims("kecs-system").Get(ctx, "kecs-nfs-data", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		deployment, err := kubeClient.AppsV1().Deployments("kecs-system").Get(ctx, "kecs-nfs", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deployment.Spec.Template.Spec.InitContainers).To(HaveLen(1))
+		Expect(*deployment.Spec.Template.Spec.Containers[0].SecurityContext.Privileged).To(BeTrue())
+	})
+
+	It("should restart the server when the access points change", func() {
+		Expect(kubernetes.EnsureNFSServer(ctx, kubeClient)).To(Succeed())
+		deployment, err := kubeClient.AppsV1().Deployments("kecs-system").Get(ctx, "kecs-nfs", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		checksum := deployment.Spec.Template.Annotations["kecs.dev/config-checksum"]
+
+		config.Set("efs.accessPoints", "fsap-app=fs-1:/app:1000:1000,fsap-data=fs-2:/data")
+		Expect(kubernetes.EnsureNFSServer(ctx, kubeClient)).To(Succeed())
+
+		deployment, err = kubeClient.AppsV1().Deployments("kecs-system").Get(ctx, "kecs-nfs", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deployment.Spec.Template.Annotations["kecs.dev/config-checksum"]).NotTo(Equal(checksum))
+	})
+
+	It("should fail with invalid access points", func() {
+		config.Set("efs.accessPoints", "fsap-app")
+		Expect(kubernetes.EnsureNFSServer(ctx, kubeClient)).To(MatchError(ContainSubstring("invalid efs.accessPoints")))
+	})
+})
@@ -12,6 +12,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
 )
@@ -67,7 +68,7 @@ func (s *PodLogService) GetLogs(ctx context.Context, namespace, podName, contain
 
 		log := storage.TaskLog{
 			// ID will be generated by storage
-			TaskArn:       fmt.Sprintf("arn:aws:ecs:%s:%s:task/%s/%s", "us-east-1", "000000000000", namespace, podName),
+			TaskArn:       awsarn.Build("ecs", "us-east-1", "000000000000", fmt.Sprintf("task/%s/%s", namespace, podName)),
 			ContainerName: containerName,
 			Timestamp:     timestamp,
 			LogLine:       logLine,
@@ -138,7 +139,7 @@ func (s *PodLogService) StreamLogs(ctx context.Context, namespace, podName, cont
 				timestamp, logLine := parseLogLine(line)
 
 				log := storage.TaskLog{
-					TaskArn:       fmt.Sprintf("arn:aws:ecs:%s:%s:task/%s/%s", "us-east-1", "000000000000", namespace, podName),
+					TaskArn:       awsarn.Build("ecs", "us-east-1", "000000000000", fmt.Sprintf("task/%s/%s", namespace, podName)),
 					ContainerName: containerName,
 					Timestamp:     timestamp,
 					LogLine:       logLine,
@@ -100,6 +100,85 @@ func (pm *PortManager) AllocatePort(taskARN string, containerPort int32, protoco
 	return allocatedPort, nil
 }
 
+// AllocatePortRange allocates consecutive ports for the container ports of a
+// containerPortRange, so they can be reported as a hostPortRange
+func (pm *PortManager) AllocatePortRange(taskARN string, containerPorts []int32, protocol string) ([]int32, error) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	count := int32(len(containerPorts))
+	if count == 0 {
+		return nil, nil
+	}
+
+	// Find the first block of free ports
+	start := int32(-1)
+	for i := pm.portRange.Start; i+count-1 <= pm.portRange.End; i++ {
+		free := true
+		for j := i; j < i+count; j++ {
+			if _, exists := pm.allocations[j]; exists {
+				// Continue after the allocated port
+				i = j
+				free = false
+				break
+			}
+		}
+		if free {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return nil, fmt.Errorf("no %d consecutive ports available in range %d-%d", count, pm.portRange.Start, pm.portRange.End)
+	}
+
+	hostPorts := make([]int32, 0, count)
+	for i, containerPort := range containerPorts {
+		hostPort := start + int32(i)
+		pm.allocations[hostPort] = &PortAllocation{
+			TaskARN:       taskARN,
+			HostPort:      hostPort,
+			ContainerPort: containerPort,
+			Protocol:      protocol,
+		}
+		hostPorts = append(hostPorts, hostPort)
+	}
+	pm.taskPorts[taskARN] = append(pm.taskPorts[taskARN], hostPorts...)
+
+	logging.Info("Allocated port range for task",
+		"taskARN", taskARN,
+		"hostPorts", fmt.Sprintf("%d-%d", start, start+count-1),
+		"protocol", protocol)
+
+	return hostPorts, nil
+}
+
+// ReservePort records a port that a task already uses, e.g. the host port of
+// a pod that survived a restart of KECS
+func (pm *PortManager) ReservePort(taskARN string, hostPort, containerPort int32, protocol string) error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if hostPort < pm.portRange.Start || hostPort > pm.portRange.End {
+		return fmt.Errorf("port %d is outside range %d-%d", hostPort, pm.portRange.Start, pm.portRange.End)
+	}
+	if allocation, exists := pm.allocations[hostPort]; exists {
+		if allocation.TaskARN == taskARN {
+			return nil
+		}
+		return fmt.Errorf("port %d is allocated to task %s", hostPort, allocation.TaskARN)
+	}
+
+	pm.allocations[hostPort] = &PortAllocation{
+		TaskARN:       taskARN,
+		HostPort:      hostPort,
+		ContainerPort: containerPort,
+		Protocol:      protocol,
+	}
+	pm.taskPorts[taskARN] = append(pm.taskPorts[taskARN], hostPort)
+	return nil
+}
+
 // ReleasePort releases a specific port
 func (pm *PortManager) ReleasePort(hostPort int32) error {
 	pm.mu.Lock()
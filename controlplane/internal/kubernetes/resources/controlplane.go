@@ -42,6 +42,10 @@ const (
 	// Internal ports - Control plane always listens on these ports inside the container
 	ControlPlaneInternalAPIPort   = 5373
 	ControlPlaneInternalAdminPort = 5374
+
+	// Images of the sidecar and init containers of the control plane
+	PostgresImage     = "postgres:16-alpine"
+	NetworkCheckImage = "busybox:1.36"
 )
 
 // ControlPlaneResources contains all resources needed for the control plane
@@ -548,7 +552,7 @@ func createPostgresSidecar(config *ControlPlaneConfig) corev1.Container {
 
 	return corev1.Container{
 		Name:  "postgres",
-		Image: "postgres:16-alpine",
+		Image: PostgresImage,
 		Ports: []corev1.ContainerPort{
 			{
 				ContainerPort: 5432,
@@ -698,7 +702,7 @@ func createDeployment(config *ControlPlaneConfig) *appsv1.Deployment {
 					InitContainers: []corev1.Container{
 						{
 							Name:    "wait-for-network",
-							Image:   "busybox:1.36",
+							Image:   NetworkCheckImage,
 							Command: []string{"sh", "-c"},
 							Args:    []string{"echo 'Checking network connectivity...'; nslookup kubernetes.default.svc.cluster.local || true; echo 'Network check complete'"},
 						},
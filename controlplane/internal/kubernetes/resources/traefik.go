@@ -9,6 +9,9 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// TraefikImage is the image of the global Traefik deployment
+const TraefikImage = "traefik:v3.0"
+
 // GetTraefikResources returns all Kubernetes resources needed for the global Traefik deployment
 func GetTraefikResources(namespace string) []interface{} {
 	return []interface{}{
@@ -140,7 +143,13 @@ providers:
 log:
   level: INFO
 
-accessLog: {}`,
+# JSON access logs are parsed by KECS to deliver ELBv2 access logs to S3
+accessLog:
+  format: json
+  fields:
+    headers:
+      names:
+        User-Agent: keep`,
 		},
 	}
 }
@@ -181,7 +190,7 @@ func GetTraefikDeployment(namespace string) *appsv1.Deployment {
 					Containers: []corev1.Container{
 						{
 							Name:  "traefik",
-							Image: "traefik:v3.0",
+							Image: TraefikImage,
 							Args: []string{
 								"--configfile=/config/traefik.yaml",
 							},
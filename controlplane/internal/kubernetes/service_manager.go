@@ -14,6 +14,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
 	"github.com/nandemo-ya/kecs/controlplane/internal/config"
 	generated "github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
 	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
@@ -270,9 +271,7 @@ func (sm *ServiceManager) CreateService(
 			taskStore := sm.storage.TaskStore()
 			for i := 0; i < storageService.DesiredCount; i++ {
 				// Generate task ID and ARN
-				taskID := fmt.Sprintf("arn:aws:ecs:%s:%s:task/%s/%s-%d",
-					storageService.Region, storageService.AccountID, cluster.Name,
-					storageService.ServiceName, i)
+				taskID := awsarn.Build("ecs", storageService.Region, storageService.AccountID, fmt.Sprintf("task/%s/%s-%d", cluster.Name, storageService.ServiceName, i))
 
 				task := &storage.Task{
 					ARN:               taskID,
@@ -379,9 +378,7 @@ func (sm *ServiceManager) UpdateService(
 					if newDesiredCount > oldDesiredCount {
 						// Scale up - create new tasks
 						for i := oldDesiredCount; i < newDesiredCount; i++ {
-							taskID := fmt.Sprintf("arn:aws:ecs:%s:%s:task/%s/%s-%d-%d",
-								storageService.Region, storageService.AccountID, cluster.Name,
-								storageService.ServiceName, time.Now().Unix(), i)
+							taskID := awsarn.Build("ecs", storageService.Region, storageService.AccountID, fmt.Sprintf("task/%s/%s-%d-%d", cluster.Name, storageService.ServiceName, time.Now().Unix(), i))
 
 							task := &storage.Task{
 								ARN:               taskID,
@@ -530,6 +527,42 @@ func (sm *ServiceManager) UpdateService(
 	return nil
 }
 
+// DeploymentExists reports whether the Deployment of an ECS service exists,
+// which decides whether its resources are created or updated. In test mode
+// the simulated Deployment exists once the tasks of the service were created.
+func (sm *ServiceManager) DeploymentExists(
+	ctx context.Context,
+	deployment *appsv1.Deployment,
+	cluster *storage.Cluster,
+	storageService *storage.Service,
+) (bool, error) {
+	if config.GetBool("features.testMode") {
+		if sm.storage == nil {
+			return false, nil
+		}
+		tasks, err := sm.storage.TaskStore().List(ctx, cluster.ARN, storage.TaskFilters{
+			ServiceName: storageService.ServiceName,
+			MaxResults:  1,
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to list service tasks: %w", err)
+		}
+		return len(tasks) > 0, nil
+	}
+
+	if err := sm.initializeClient(); err != nil {
+		return false, fmt.Errorf("failed to initialize kubernetes client: %w", err)
+	}
+	_, err := sm.clientset.AppsV1().Deployments(deployment.Namespace).Get(ctx, deployment.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get deployment: %w", err)
+	}
+	return true, nil
+}
+
 // DeleteService deletes a Kubernetes Deployment and Service for an ECS service
 func (sm *ServiceManager) DeleteService(
 	ctx context.Context,
@@ -773,6 +806,13 @@ func (sm *ServiceManager) ensureKubernetesResources(
 		return fmt.Errorf("failed to ensure namespace: %w", err)
 	}
 
+	// EFS volumes are served by the NFS server, deployed with the first task using it
+	if converters.UsesNFSServer(&deployment.Spec.Template.Spec) {
+		if err := DeployNFSServerOnce(ctx, kubeClient); err != nil {
+			logging.Warn("Failed to deploy NFS server for EFS volumes", "deployment", deployment.Name, "error", err)
+		}
+	}
+
 	// Create or update Deployment
 	if err := sm.createDeployment(ctx, kubeClient, deployment); err != nil {
 		return fmt.Errorf("failed to create/update deployment: %w", err)
@@ -879,15 +919,16 @@ func (sm *ServiceManager) updateServiceStatusSafely(ctx context.Context, cluster
 		"serviceID", freshService.ID,
 		"currentStatus", freshService.Status)
 
-	// Update only the status field
-	freshService.Status = "ACTIVE"
-
 	logging.Debug("About to update service status",
 		"serviceID", freshService.ID,
 		"newStatus", "ACTIVE")
 
-	// Use a simple approach: since we have the fresh service, just update it
-	return sm.storage.ServiceStore().Update(ctx, freshService)
+	// Update only the status field, of the service stored meanwhile if any
+	_, err = storage.UpdateService(ctx, sm.storage.ServiceStore(), freshService, func(service *storage.Service) error {
+		service.Status = "ACTIVE"
+		return nil
+	})
+	return err
 }
 
 // ServiceStatus represents the status of a service
@@ -974,26 +1015,21 @@ func (sm *ServiceManager) registerPodAsTask(ctx context.Context, pod *corev1.Pod
 		return
 	}
 
-	// Use task ID from webhook label if available, otherwise generate from pod name
-	var taskID string
-	if tid, exists := pod.Labels["kecs.dev/task-id"]; exists && tid != "" {
-		taskID = tid
-		logging.Debug("Using task ID from webhook label", "taskId", taskID, "pod", pod.Name)
-	} else {
-		// Fallback to generating from pod name for backward compatibility
-		taskID = utils.GenerateTaskIDFromString(pod.Name)
-		logging.Debug("Generated task ID from pod name", "taskId", taskID, "pod", pod.Name)
-	}
+	taskID := servicePodTaskID(pod)
 
 	// Check if task already exists for this pod
-	taskARN := fmt.Sprintf("arn:aws:ecs:%s:%s:task/%s/%s",
-		service.Region, service.AccountID, cluster.Name, taskID)
+	taskARN := awsarn.Build("ecs", service.Region, service.AccountID, fmt.Sprintf("task/%s/%s", cluster.Name, taskID))
+
+	// Keep the host ports the webhook assigned, e.g. after a restart of KECS
+	if sm.taskManager != nil {
+		sm.taskManager.reserveDynamicHostPorts(pod, taskARN)
+	}
 
 	existingTask, err := sm.storage.TaskStore().Get(ctx, cluster.ARN, taskARN)
 	if err == nil && existingTask != nil {
 		// Task already exists, update status if needed
 		if sm.taskManager != nil {
-			if err := sm.taskManager.UpdateTaskStatus(ctx, taskARN, pod); err != nil {
+			if err := sm.taskManager.updateTaskStatusFromPod(ctx, taskARN, pod); err != nil {
 				logging.Warn("Failed to update task status", "task", taskARN, "error", err)
 			}
 		}
@@ -1043,6 +1079,12 @@ func (sm *ServiceManager) registerPodAsTask(ctx context.Context, pod *corev1.Pod
 		ServiceRegistries: serviceRegistries, // Use Service Registry metadata from pod or service
 	}
 
+	// Place the task on a provider of the service's capacity provider strategy
+	if strategy := converters.ParseCapacityProviderStrategy(service.CapacityProviderStrategy); len(strategy) > 0 {
+		task.CapacityProviderName = converters.CapacityProviderForTask(strategy, sm.tasksPerCapacityProvider(ctx, cluster, service))
+		task.LaunchType = converters.LaunchTypeForCapacityProvider(task.CapacityProviderName)
+	}
+
 	// Create containers info from pod
 	containers := sm.taskManager.GetContainerStatuses(pod)
 	if len(containers) > 0 {
@@ -1059,7 +1101,7 @@ func (sm *ServiceManager) registerPodAsTask(ctx context.Context, pod *corev1.Pod
 			logging.Info("Task already exists, updating status", "pod", pod.Name, "task", taskARN)
 			// Task already exists, update its status instead
 			if sm.taskManager != nil {
-				if err := sm.taskManager.UpdateTaskStatus(ctx, taskARN, pod); err != nil {
+				if err := sm.taskManager.updateTaskStatusFromPod(ctx, taskARN, pod); err != nil {
 					logging.Error("Failed to update existing task status", "task", taskARN, "error", err)
 				}
 				// Still start watching for future updates
@@ -1079,12 +1121,27 @@ func (sm *ServiceManager) registerPodAsTask(ctx context.Context, pod *corev1.Pod
 	}
 }
 
+// servicePodTaskID returns the task ID the webhook labeled a service pod
+// with, or one generated from the pod name for pods created without it
+func servicePodTaskID(pod *corev1.Pod) string {
+	if tid, exists := pod.Labels["kecs.dev/task-id"]; exists && tid != "" {
+		logging.Debug("Using task ID from webhook label", "taskId", tid, "pod", pod.Name)
+		return tid
+	}
+	taskID := utils.GenerateTaskIDFromString(pod.Name)
+	logging.Debug("Generated task ID from pod name", "taskId", taskID, "pod", pod.Name)
+	return taskID
+}
+
 // handlePodDeletion handles when a pod is deleted
 func (sm *ServiceManager) handlePodDeletion(ctx context.Context, pod *corev1.Pod, cluster *storage.Cluster, service *storage.Service) {
-	// Generate deterministic task ID from pod name
-	taskID := utils.GenerateTaskIDFromString(pod.Name)
-	taskARN := fmt.Sprintf("arn:aws:ecs:%s:%s:task/%s/%s",
-		service.Region, service.AccountID, cluster.Name, taskID)
+	taskID := servicePodTaskID(pod)
+	taskARN := awsarn.Build("ecs", service.Region, service.AccountID, fmt.Sprintf("task/%s/%s", cluster.Name, taskID))
+
+	// Service pods are deleted rather than stopped, which frees their host ports
+	if sm.taskManager != nil {
+		sm.taskManager.releaseTaskPorts(taskARN)
+	}
 
 	task, err := sm.storage.TaskStore().Get(ctx, cluster.ARN, taskARN)
 	if err != nil || task == nil {
@@ -1237,3 +1294,22 @@ func (sm *ServiceManager) extractClusterNameFromARN(arn string) string {
 	}
 	return "default"
 }
+
+// tasksPerCapacityProvider counts the running tasks of a service per capacity provider
+func (sm *ServiceManager) tasksPerCapacityProvider(ctx context.Context, cluster *storage.Cluster, service *storage.Service) map[string]int {
+	placed := make(map[string]int)
+	tasks, err := sm.storage.TaskStore().List(ctx, cluster.ARN, storage.TaskFilters{
+		ServiceName:   service.ServiceName,
+		DesiredStatus: "RUNNING",
+	})
+	if err != nil {
+		logging.Debug("Failed to list service tasks for capacity provider placement", "service", service.ServiceName, "error", err)
+		return placed
+	}
+	for _, task := range tasks {
+		if task.CapacityProviderName != "" {
+			placed[task.CapacityProviderName]++
+		}
+	}
+	return placed
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -12,15 +13,19 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
 	appconfig "github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controllers/sync/mappers"
 	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
 	"github.com/nandemo-ya/kecs/controlplane/internal/servicediscovery"
+	"github.com/nandemo-ya/kecs/controlplane/internal/startupmetrics"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/taskattachments"
 	"github.com/nandemo-ya/kecs/controlplane/internal/types"
 )
 
@@ -31,6 +36,8 @@ type TaskManager struct {
 	serviceDiscoveryManager servicediscovery.Manager
 	logCollector            *LogCollector
 	k3dPortManager          *K3dPortManager
+	hostPortManager         *PortManager
+	hostPorts               *hostPortTracker
 }
 
 // NewTaskManager creates a new task manager
@@ -55,6 +62,8 @@ func NewTaskManagerWithServiceDiscovery(storage storage.Storage, sdManager servi
 			serviceDiscoveryManager: sdManager,
 			logCollector:            nil, // Will be initialized when clientset is available
 			k3dPortManager:          nil, // Will be initialized when needed
+			hostPortManager:         dynamicHostPorts(),
+			hostPorts:               newHostPortTracker(),
 		}, nil
 	}
 
@@ -77,6 +86,8 @@ func NewTaskManagerWithServiceDiscovery(storage storage.Storage, sdManager servi
 		Clientset:               clientset,
 		storage:                 storage,
 		serviceDiscoveryManager: sdManager,
+		hostPortManager:         dynamicHostPorts(),
+		hostPorts:               newHostPortTracker(),
 	}
 
 	// Initialize log collector if storage supports it
@@ -140,6 +151,17 @@ func (tm *TaskManager) InitializeClient() error {
 
 // CreateTask creates a new task by deploying a pod
 func (tm *TaskManager) CreateTask(ctx context.Context, pod *corev1.Pod, task *storage.Task, secrets map[string]*converters.SecretInfo) error {
+	// Assign host ports to bridge mode port mappings without hostPort
+	if err := tm.allocateDynamicHostPorts(pod, task); err != nil {
+		return err
+	}
+
+	// Place tasks binding host ports on a node where the ports are free
+	if err := tm.placeHostPorts(ctx, pod, task); err != nil {
+		tm.releaseTaskPorts(task.ARN)
+		return err
+	}
+
 	// In test mode, skip actual pod creation
 	if tm.Clientset == nil {
 		logging.Debug("Kubernetes client not initialized - simulating task creation")
@@ -164,20 +186,13 @@ func (tm *TaskManager) CreateTask(ctx context.Context, pod *corev1.Pod, task *st
 				}
 			}
 
-			// Check for awsvpc network mode and create attachments
-			if networkMode, ok := p.Annotations["ecs.amazonaws.com/network-mode"]; ok && networkMode == "awsvpc" {
-				attachments := tm.createTestNetworkAttachments(p)
-				if len(attachments) > 0 {
-					attachmentsJSON, err := json.Marshal(attachments)
-					if err == nil {
-						task.Attachments = string(attachmentsJSON)
-					}
-				}
-			}
+			// Precreate the network interface, Service Connect and EBS attachments
+			task.Attachments = taskattachments.Sync(task.Attachments, p, task.LastStatus)
 		}
 
 		// Store task in database
 		if err := tm.storage.TaskStore().Create(ctx, task); err != nil {
+			tm.releaseTaskPorts(task.ARN)
 			return fmt.Errorf("failed to store task: %w", err)
 		}
 
@@ -187,6 +202,7 @@ func (tm *TaskManager) CreateTask(ctx context.Context, pod *corev1.Pod, task *st
 	// Create secrets first if any
 	if len(secrets) > 0 {
 		if err := tm.createSecrets(ctx, pod.Namespace, secrets); err != nil {
+			tm.releaseTaskPorts(task.ARN)
 			return fmt.Errorf("failed to create secrets: %w", err)
 		}
 	}
@@ -211,6 +227,7 @@ func (tm *TaskManager) CreateTask(ctx context.Context, pod *corev1.Pod, task *st
 					for _, p := range allocatedPorts {
 						tm.k3dPortManager.GetPortManager().ReleasePort(p)
 					}
+					tm.releaseTaskPorts(task.ARN)
 					return fmt.Errorf("failed to allocate port for container %s: %w", container.Name, err)
 				}
 				allocatedPorts = append(allocatedPorts, hostPort)
@@ -224,6 +241,13 @@ func (tm *TaskManager) CreateTask(ctx context.Context, pod *corev1.Pod, task *st
 		}
 	}
 
+	// EFS volumes are served by the NFS server, deployed with the first task using it
+	if converters.UsesNFSServer(&pod.Spec) {
+		if err := DeployNFSServerOnce(ctx, tm.Clientset); err != nil {
+			logging.Warn("Failed to deploy NFS server for EFS volumes", "task", task.ARN, "error", err)
+		}
+	}
+
 	// Create the pod
 	createdPod, err := tm.Clientset.CoreV1().Pods(pod.Namespace).Create(ctx, pod, metav1.CreateOptions{})
 	if err != nil {
@@ -233,6 +257,7 @@ func (tm *TaskManager) CreateTask(ctx context.Context, pod *corev1.Pod, task *st
 				tm.k3dPortManager.GetPortManager().ReleasePort(p)
 			}
 		}
+		tm.releaseTaskPorts(task.ARN)
 		return fmt.Errorf("failed to create pod: %w", err)
 	}
 
@@ -250,6 +275,7 @@ func (tm *TaskManager) CreateTask(ctx context.Context, pod *corev1.Pod, task *st
 	task.LastStatus = "PENDING"
 	task.Connectivity = "CONNECTED"
 	task.ConnectivityAt = &task.CreatedAt
+	task.Attachments = taskattachments.Sync(task.Attachments, createdPod, task.LastStatus)
 
 	// Add public IP information if assignPublicIp is enabled
 	if assignPublicIp && len(allocatedPorts) > 0 {
@@ -266,6 +292,7 @@ func (tm *TaskManager) CreateTask(ctx context.Context, pod *corev1.Pod, task *st
 	if err := tm.storage.TaskStore().Create(ctx, task); err != nil {
 		// Try to clean up the pod if task storage fails
 		_ = tm.Clientset.CoreV1().Pods(pod.Namespace).Delete(ctx, createdPod.Name, metav1.DeleteOptions{})
+		tm.releaseTaskPorts(task.ARN)
 		return fmt.Errorf("failed to store task: %w", err)
 	}
 
@@ -277,6 +304,13 @@ func (tm *TaskManager) CreateTask(ctx context.Context, pod *corev1.Pod, task *st
 
 // StopTask stops a running task
 func (tm *TaskManager) StopTask(ctx context.Context, cluster, taskID, reason string) error {
+	return tm.StopTaskWithCode(ctx, cluster, taskID, "", reason)
+}
+
+// StopTaskWithCode stops a task and reports it with a stop code, e.g. when
+// KECS stops it on its own. The code is recorded on the pod before it is
+// deleted so that the sync controller keeps it once the task is STOPPED.
+func (tm *TaskManager) StopTaskWithCode(ctx context.Context, cluster, taskID, stopCode, reason string) error {
 	// Get task from storage
 	task, err := tm.storage.TaskStore().Get(ctx, cluster, taskID)
 	if err != nil {
@@ -290,6 +324,9 @@ func (tm *TaskManager) StopTask(ctx context.Context, cluster, taskID, reason str
 	now := time.Now()
 	task.DesiredStatus = "STOPPED"
 	task.StoppedReason = reason
+	if stopCode != "" {
+		task.StopCode = stopCode
+	}
 	task.StoppingAt = &now
 	task.Version++
 
@@ -314,6 +351,9 @@ func (tm *TaskManager) StopTask(ctx context.Context, cluster, taskID, reason str
 		}
 	}
 
+	// Release the host ports of bridge mode port mappings
+	tm.releaseTaskPorts(task.ARN)
+
 	// Collect logs before deleting the pod
 	if tm.logCollector != nil && tm.Clientset != nil && task.PodName != "" && task.Namespace != "" {
 		// Collect logs asynchronously to avoid blocking the stop operation
@@ -322,6 +362,10 @@ func (tm *TaskManager) StopTask(ctx context.Context, cluster, taskID, reason str
 
 	// Delete the pod (skip if no kubernetes client)
 	if tm.Clientset != nil && task.PodName != "" && task.Namespace != "" {
+		tm.retainVolumes(ctx, task.Namespace, task.PodName)
+		if stopCode != "" {
+			tm.annotateStopCode(ctx, task.Namespace, task.PodName, stopCode, reason)
+		}
 		err := tm.Clientset.CoreV1().Pods(task.Namespace).Delete(ctx, task.PodName, metav1.DeleteOptions{})
 		if err != nil && !errors.IsNotFound(err) {
 			return fmt.Errorf("failed to delete pod: %w", err)
@@ -331,21 +375,104 @@ func (tm *TaskManager) StopTask(ctx context.Context, cluster, taskID, reason str
 	return nil
 }
 
-// UpdateTaskStatus updates the status of a task based on pod status
-func (tm *TaskManager) UpdateTaskStatus(ctx context.Context, taskARN string, pod *corev1.Pod) error {
-	task, err := tm.storage.TaskStore().Get(ctx, "", taskARN)
+// annotateStopCode records the stop code and reason of a task on its pod
+func (tm *TaskManager) annotateStopCode(ctx context.Context, namespace, podName, stopCode, reason string) {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				mappers.AnnotationStopCode:      stopCode,
+				mappers.AnnotationStoppedReason: reason,
+			},
+		},
+	})
 	if err != nil {
-		return fmt.Errorf("failed to get task: %w", err)
+		return
 	}
-	if task == nil {
-		return fmt.Errorf("task not found")
+	_, err = tm.Clientset.CoreV1().Pods(namespace).Patch(ctx, podName, k8stypes.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		logging.Warn("Failed to record stop code on pod", "pod", podName, "error", err)
+	}
+}
+
+// retainVolumes keeps the PVCs of the managed EBS volumes of a pod that are
+// not deleted on termination. Kubernetes deletes the PVCs of generic ephemeral
+// volumes with the pod they are owned by, so their owner references are removed.
+func (tm *TaskManager) retainVolumes(ctx context.Context, namespace, podName string) {
+	pod, err := tm.Clientset.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return
 	}
 
-	// Map pod phase to ECS task status
+	for _, vol := range pod.Spec.Volumes {
+		if vol.Ephemeral == nil || vol.Ephemeral.VolumeClaimTemplate == nil {
+			continue
+		}
+		if vol.Ephemeral.VolumeClaimTemplate.Annotations[converters.EBSDeleteOnTerminationAnnotation] != "false" {
+			continue
+		}
+
+		// Generic ephemeral volumes are named <pod>-<volume>
+		claimName := fmt.Sprintf("%s-%s", podName, vol.Name)
+		pvc, err := tm.Clientset.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, claimName, metav1.GetOptions{})
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				logging.Warn("Failed to get volume claim", "claim", claimName, "error", err)
+			}
+			continue
+		}
+		pvc.OwnerReferences = nil
+		if _, err := tm.Clientset.CoreV1().PersistentVolumeClaims(namespace).Update(ctx, pvc, metav1.UpdateOptions{}); err != nil {
+			logging.Warn("Failed to retain volume claim", "claim", claimName, "error", err)
+			continue
+		}
+		logging.Info("Retained volume of stopped task", "claim", claimName, "pod", podName)
+	}
+}
+
+// UpdateTaskStatus applies the side effects of a status change the sync
+// controller stored for a task, such as Service Discovery registration, port
+// release and log collection. The stored status is kept; previousStatus is
+// the status of the task before the sync.
+func (tm *TaskManager) UpdateTaskStatus(ctx context.Context, taskARN, previousStatus string, pod *corev1.Pod) error {
+	task, err := tm.getTask(ctx, taskARN)
+	if err != nil {
+		return err
+	}
+	return tm.applyTaskStatus(ctx, task, previousStatus, pod)
+}
+
+// updateTaskStatusFromPod updates the status of a task based on the pod
+// phase, for the pod watchers running without the sync controller
+func (tm *TaskManager) updateTaskStatusFromPod(ctx context.Context, taskARN string, pod *corev1.Pod) error {
+	task, err := tm.getTask(ctx, taskARN)
+	if err != nil {
+		return err
+	}
 	previousStatus := task.LastStatus
 	task.LastStatus = mapPodPhaseToTaskStatus(pod.Status.Phase)
+	return tm.applyTaskStatus(ctx, task, previousStatus, pod)
+}
+
+// getTask returns a stored task
+func (tm *TaskManager) getTask(ctx context.Context, taskARN string) (*storage.Task, error) {
+	task, err := tm.storage.TaskStore().Get(ctx, "", taskARN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	if task == nil {
+		return nil, fmt.Errorf("task not found")
+	}
+	return task, nil
+}
+
+// applyTaskStatus records the pod state in a task whose status changed from
+// previousStatus and stores it
+func (tm *TaskManager) applyTaskStatus(ctx context.Context, task *storage.Task, previousStatus string, pod *corev1.Pod) error {
 	task.Version++
 
+	// Attachments follow the pod: attached once it runs, detached once it stops
+	task.Attachments = taskattachments.Sync(task.Attachments, pod, task.LastStatus)
+
 	// Update container statuses
 	containers := tm.GetContainerStatuses(pod)
 	containersJSON, err := json.Marshal(containers)
@@ -357,51 +484,71 @@ func (tm *TaskManager) UpdateTaskStatus(ctx context.Context, taskARN string, pod
 	// Update timestamps based on pod status
 	now := time.Now()
 
-	// Set pull timestamps
-	if previousStatus == "PENDING" && task.LastStatus == "PROVISIONING" {
-		task.PullStartedAt = &now
-	}
-	if previousStatus == "PROVISIONING" && (task.LastStatus == "RUNNING" || task.LastStatus == "STOPPED") {
-		task.PullStoppedAt = &now
+	// Record the startup timeline. Kubelet events bracket the image pulls
+	// exactly, they are read once when the task starts running.
+	timeline := startupmetrics.PodTimeline(pod)
+	if task.LastStatus == "RUNNING" && previousStatus != "RUNNING" {
+		timeline.ApplyEvents(tm.podEvents(ctx, pod))
+		task.PullStartedAt = firstTime(timeline.PullStartedAt, task.PullStartedAt)
+		task.PullStoppedAt = firstTime(timeline.PullStoppedAt, task.PullStoppedAt)
+	} else {
+		task.PullStartedAt = firstTime(task.PullStartedAt, timeline.PullStartedAt)
+		task.PullStoppedAt = firstTime(task.PullStoppedAt, timeline.PullStoppedAt)
 	}
 
-	// Set started timestamp
-	if task.StartedAt == nil && pod.Status.StartTime != nil {
+	// Set started timestamp once all containers run
+	if task.StartedAt == nil {
+		task.StartedAt = timeline.StartedAt
+	}
+	if task.StartedAt == nil && task.LastStatus == "RUNNING" && pod.Status.StartTime != nil {
 		startTime := pod.Status.StartTime.Time
 		task.StartedAt = &startTime
 	}
 
+	// Collect logs before the terminated pod is garbage collected
+	if task.LastStatus == "STOPPED" && previousStatus != "STOPPED" && tm.logCollector != nil {
+		logging.Info("Pod terminated, collecting logs", "taskARN", task.ARN, "pod", pod.Name, "phase", pod.Status.Phase)
+		tm.logCollector.CollectLogsBeforeDeletion(ctx, task.ARN, pod.Namespace, pod.Name)
+	}
+
 	// Handle stopped tasks
 	if task.LastStatus == "STOPPED" {
-		task.StoppedAt = &now
-		task.ExecutionStoppedAt = &now
-
-		// Determine stop reason
-		if pod.Status.Reason != "" {
-			task.StopCode = pod.Status.Reason
+		tm.releaseTaskPorts(task.ARN)
+		// The first stop is kept, later updates of the stopped pod resync it
+		if task.StoppedAt == nil {
+			task.StoppedAt = &now
 		}
-		if pod.Status.Message != "" {
-			task.StoppedReason = pod.Status.Message
+		if task.ExecutionStoppedAt == nil {
+			task.ExecutionStoppedAt = task.StoppedAt
 		}
 
-		// Check container statuses for more details
-		for _, cs := range pod.Status.ContainerStatuses {
-			if cs.State.Terminated != nil {
-				if cs.State.Terminated.ExitCode != 0 {
-					task.StopCode = "TaskFailed"
-					task.StoppedReason = cs.State.Terminated.Reason
-					if cs.State.Terminated.Message != "" {
-						task.StoppedReason = cs.State.Terminated.Message
+		// Determine stop reason, unless already recorded
+		if task.StopCode == "" {
+			if pod.Status.Reason != "" {
+				task.StopCode = pod.Status.Reason
+			}
+			if pod.Status.Message != "" {
+				task.StoppedReason = pod.Status.Message
+			}
+
+			// Check container statuses for more details
+			for _, cs := range pod.Status.ContainerStatuses {
+				if cs.State.Terminated != nil {
+					if cs.State.Terminated.ExitCode != 0 {
+						task.StopCode = "TaskFailed"
+						task.StoppedReason = cs.State.Terminated.Reason
+						if cs.State.Terminated.Message != "" {
+							task.StoppedReason = cs.State.Terminated.Message
+						}
+						break
 					}
-					break
 				}
 			}
 		}
 	}
 
 	// Update health status
-	previousHealthStatus := task.HealthStatus
-	task.HealthStatus = tm.getHealthStatus(pod)
+	task.HealthStatus = converters.TaskHealthStatus(pod)
 
 	// Register/deregister with Service Discovery
 	if previousStatus != task.LastStatus {
@@ -422,22 +569,54 @@ func (tm *TaskManager) UpdateTaskStatus(ctx context.Context, taskARN string, pod
 		}
 	}
 
-	// Update health status in Service Discovery if changed
-	if task.LastStatus == "RUNNING" && previousHealthStatus != task.HealthStatus {
-		go tm.updateServiceDiscoveryHealth(context.Background(), task)
+	// Follow the health of running tasks in Service Discovery. The sync
+	// controller stores the task before calling this, so the stored health
+	// can not tell changes apart; unchanged statuses are ignored by the manager.
+	if task.LastStatus == "RUNNING" && previousStatus == task.LastStatus {
+		go tm.updateServiceDiscoveryHealth(context.Background(), task, converters.InstanceHealthStatus(pod))
 	}
 
 	return tm.storage.TaskStore().Update(ctx, task)
 }
 
-// watchPodStatus watches a pod for status changes
+// podEvents returns the events of a pod, empty when they cannot be read
+func (tm *TaskManager) podEvents(ctx context.Context, pod *corev1.Pod) []corev1.Event {
+	if tm.Clientset == nil {
+		return nil
+	}
+	events, err := tm.Clientset.CoreV1().Events(pod.Namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("involvedObject.name", pod.Name).String(),
+	})
+	if err != nil {
+		logging.Debug("Failed to list pod events", "pod", pod.Name, "error", err)
+		return nil
+	}
+	return events.Items
+}
+
+// firstTime returns the first timestamp that is set
+func firstTime(times ...*time.Time) *time.Time {
+	for _, t := range times {
+		if t != nil {
+			return t
+		}
+	}
+	return nil
+}
+
+// watchPodStatus watches a pod for status changes. It is a fallback for when
+// the sync controller's shared pod informer does not propagate pod status.
 func (tm *TaskManager) watchPodStatus(ctx context.Context, taskARN, namespace, podName string) {
+	if appconfig.GetBool("taskSync.informer") {
+		return
+	}
+
 	// First, get the current pod status and update immediately
 	pod, err := tm.Clientset.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
 	if err == nil && pod != nil {
 		// Update task status with current pod state
 		logging.Debug("Updating task status for existing pod", "pod", podName, "phase", pod.Status.Phase)
-		if err := tm.UpdateTaskStatus(ctx, taskARN, pod); err != nil {
+		if err := tm.updateTaskStatusFromPod(ctx, taskARN, pod); err != nil {
 			logging.Error("Failed to update task status for existing pod", "task", taskARN, "namespace", namespace, "pod", podName, "error", err)
 		}
 	}
@@ -462,28 +641,38 @@ func (tm *TaskManager) watchPodStatus(ctx context.Context, taskARN, namespace, p
 		}
 
 		// Update task status
-		if err := tm.UpdateTaskStatus(ctx, taskARN, pod); err != nil {
+		if err := tm.updateTaskStatusFromPod(ctx, taskARN, pod); err != nil {
 			logging.Error("Failed to update task status", "task", taskARN, "namespace", pod.Namespace, "pod", pod.Name, "error", err)
 			// Continue processing other events despite this error
 		}
 
 		// Stop watching if pod is terminated
 		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
-			// Collect logs before pod is garbage collected
-			if tm.logCollector != nil {
-				logging.Info("Pod terminated, collecting logs", "taskARN", taskARN, "pod", pod.Name, "phase", pod.Status.Phase)
-				tm.logCollector.CollectLogsBeforeDeletion(ctx, taskARN, namespace, podName)
-			}
 			return
 		}
 	}
 }
 
-// GetContainerStatuses extracts container status information
+// GetContainerStatuses extracts container status information. Ephemeral
+// debug containers are listed after the containers of the task definition.
 func (tm *TaskManager) GetContainerStatuses(pod *corev1.Pod) []types.Container {
-	containers := make([]types.Container, 0, len(pod.Status.ContainerStatuses))
+	var statuses []corev1.ContainerStatus
+	healthStatuses := make(map[string]string)
+	podContainers, podStatuses := converters.TaskContainers(pod)
+	for i, container := range podContainers {
+		if podStatuses[i] != nil {
+			statuses = append(statuses, *podStatuses[i])
+			healthStatuses[container.Name] = converters.ContainerHealthStatus(container, podStatuses[i])
+		}
+	}
+	for _, cs := range pod.Status.EphemeralContainerStatuses {
+		// Ephemeral containers have no probes, running ones count as ready
+		cs.Ready = cs.State.Running != nil
+		statuses = append(statuses, cs)
+	}
+	containers := make([]types.Container, 0, len(statuses))
 
-	for _, cs := range pod.Status.ContainerStatuses {
+	for _, cs := range statuses {
 		container := types.Container{
 			Name:         cs.Name,
 			ContainerArn: fmt.Sprintf("arn:aws:ecs:container/%s", cs.ContainerID),
@@ -504,11 +693,12 @@ func (tm *TaskManager) GetContainerStatuses(pod *corev1.Pod) []types.Container {
 			container.Reason = cs.State.Waiting.Reason
 		}
 
-		// Health status
-		if cs.Ready {
-			container.HealthStatus = "HEALTHY"
-		} else {
-			container.HealthStatus = "UNHEALTHY"
+		container.NetworkBindings = converters.NetworkBindings(pod, cs.Name, pod.Status.PodIP)
+
+		// Ephemeral containers have no health check
+		container.HealthStatus = "UNKNOWN"
+		if healthStatus, ok := healthStatuses[cs.Name]; ok {
+			container.HealthStatus = healthStatus
 		}
 
 		containers = append(containers, container)
@@ -517,29 +707,6 @@ func (tm *TaskManager) GetContainerStatuses(pod *corev1.Pod) []types.Container {
 	return containers
 }
 
-// getHealthStatus determines the overall health status of the task
-func (tm *TaskManager) getHealthStatus(pod *corev1.Pod) string {
-	allHealthy := true
-	hasUnhealthy := false
-
-	for _, cs := range pod.Status.ContainerStatuses {
-		if !cs.Ready {
-			allHealthy = false
-			if cs.RestartCount > 0 || (cs.State.Terminated != nil && cs.State.Terminated.ExitCode != 0) {
-				hasUnhealthy = true
-				break
-			}
-		}
-	}
-
-	if allHealthy {
-		return "HEALTHY"
-	} else if hasUnhealthy {
-		return "UNHEALTHY"
-	}
-	return "UNKNOWN"
-}
-
 // mapPodPhaseToTaskStatus maps Kubernetes pod phase to ECS task status
 func mapPodPhaseToTaskStatus(phase corev1.PodPhase) string {
 	switch phase {
@@ -656,7 +823,8 @@ func (tm *TaskManager) createTestContainers(pod *corev1.Pod, task *storage.Task)
 	// Use a test IP address
 	podIP := "10.0.0.1"
 
-	for _, container := range pod.Spec.Containers {
+	podContainers, _ := converters.TaskContainers(pod)
+	for _, container := range podContainers {
 		testContainer := types.Container{
 			Name:         container.Name,
 			ContainerArn: fmt.Sprintf("arn:aws:ecs:container/%s", task.ID),
@@ -669,20 +837,14 @@ func (tm *TaskManager) createTestContainers(pod *corev1.Pod, task *storage.Task)
 		if networkMode := pod.Annotations["ecs.amazonaws.com/network-mode"]; networkMode == "awsvpc" {
 			testContainer.NetworkInterfaces = []types.NetworkInterface{
 				{
-					AttachmentId:       fmt.Sprintf("eni-attach-%s", task.ID),
+					AttachmentId:       taskattachments.ENIAttachmentID(pod),
 					PrivateIpv4Address: podIP,
 				},
 			}
 		}
 
 		// Add network bindings from container ports
-		for _, port := range container.Ports {
-			testContainer.NetworkBindings = append(testContainer.NetworkBindings, types.NetworkBinding{
-				ContainerPort: int(port.ContainerPort),
-				Protocol:      string(port.Protocol),
-				BindIP:        podIP,
-			})
-		}
+		testContainer.NetworkBindings = converters.NetworkBindings(pod, container.Name, podIP)
 
 		containers = append(containers, testContainer)
 	}
@@ -690,53 +852,6 @@ func (tm *TaskManager) createTestContainers(pod *corev1.Pod, task *storage.Task)
 	return containers
 }
 
-// createTestNetworkAttachments creates network attachments for test mode
-func (tm *TaskManager) createTestNetworkAttachments(pod *corev1.Pod) []types.Attachment {
-	var attachments []types.Attachment
-
-	// Get network configuration from annotations
-	subnets := pod.Annotations["ecs.amazonaws.com/subnets"]
-	privateIP := "10.0.0.1"
-
-	// Create elastic network interface attachment
-	var details []types.KeyValuePair
-	if subnets != "" {
-		subnetID := strings.Split(subnets, ",")[0]
-		details = append(details, types.KeyValuePair{
-			Name:  ptr("subnetId"),
-			Value: ptr(subnetID),
-		})
-	}
-	details = append(details,
-		types.KeyValuePair{
-			Name:  ptr("networkInterfaceId"),
-			Value: ptr(fmt.Sprintf("eni-%s", pod.UID)),
-		},
-		types.KeyValuePair{
-			Name:  ptr("macAddress"),
-			Value: ptr("02:00:00:00:00:01"),
-		},
-		types.KeyValuePair{
-			Name:  ptr("privateDnsName"),
-			Value: ptr(fmt.Sprintf("ip-%s.ec2.internal", strings.ReplaceAll(privateIP, ".", "-"))),
-		},
-		types.KeyValuePair{
-			Name:  ptr("privateIPv4Address"),
-			Value: ptr(privateIP),
-		},
-	)
-
-	attachment := types.Attachment{
-		Id:      ptr(fmt.Sprintf("eni-attach-%s", pod.UID)),
-		Type:    ptr("ElasticNetworkInterface"),
-		Status:  ptr("ATTACHED"),
-		Details: details,
-	}
-
-	attachments = append(attachments, attachment)
-	return attachments
-}
-
 // registerWithServiceDiscovery registers a task with Service Discovery
 func (tm *TaskManager) registerWithServiceDiscovery(ctx context.Context, task *storage.Task, pod *corev1.Pod) {
 	// Check if Service Discovery manager is available
@@ -801,13 +916,18 @@ func (tm *TaskManager) registerWithServiceDiscovery(ctx context.Context, task *s
 			var metadata struct {
 				ContainerName string `json:"containerName"`
 				ContainerPort int32  `json:"containerPort"`
+				Port          int32  `json:"port"`
 			}
 			if err := json.Unmarshal([]byte(metadataJSON), &metadata); err != nil {
 				logging.Warn("Failed to parse service registry metadata", "serviceID", serviceID, "error", err)
 				continue
 			}
 
-			tm.registerInstance(ctx, task, pod, serviceID, metadata.ContainerName, metadata.ContainerPort, service.ServiceName, clusterName)
+			containerPort := metadata.ContainerPort
+			if metadata.Port > 0 {
+				containerPort = metadata.Port
+			}
+			tm.registerInstance(ctx, task, pod, serviceID, metadata.ContainerName, containerPort, service.ServiceName, clusterName)
 		}
 		return
 	}
@@ -820,6 +940,10 @@ func (tm *TaskManager) registerWithServiceDiscovery(ctx context.Context, task *s
 		if cp, ok := registry["containerPort"].(float64); ok {
 			containerPort = int32(cp)
 		}
+		// With awsvpc the SRV port may be given as port instead
+		if p, ok := registry["port"].(float64); ok && p > 0 {
+			containerPort = int32(p)
+		}
 
 		// Extract service ID from registry ARN
 		// Format: arn:aws:servicediscovery:region:account:service/srv-xxx
@@ -844,24 +968,11 @@ func (tm *TaskManager) registerWithServiceDiscovery(ctx context.Context, task *s
 func (tm *TaskManager) registerInstance(ctx context.Context, task *storage.Task, pod *corev1.Pod,
 	serviceID, containerName string, containerPort int32, serviceName, clusterName string) {
 
-	// Map ECS health status to Service Discovery health status
-	var sdHealthStatus string
-	switch task.HealthStatus {
-	case "HEALTHY":
-		sdHealthStatus = "HEALTHY"
-	case "UNHEALTHY":
-		sdHealthStatus = "UNHEALTHY"
-	case "UNKNOWN":
-		sdHealthStatus = "UNKNOWN"
-	default:
-		sdHealthStatus = "UNKNOWN"
-	}
-
 	// Create instance
 	instance := &servicediscovery.Instance{
 		ID:           pod.Name,
 		ServiceID:    serviceID,
-		HealthStatus: sdHealthStatus,
+		HealthStatus: converters.InstanceHealthStatus(pod),
 		Attributes: map[string]string{
 			"AWS_INSTANCE_IPV4": pod.Status.PodIP,
 			"AWS_INSTANCE_ID":   pod.Name,
@@ -1020,8 +1131,9 @@ func (tm *TaskManager) deregisterFromServiceDiscovery(ctx context.Context, task
 	}
 }
 
-// updateServiceDiscoveryHealth updates the health status of a task in Service Discovery
-func (tm *TaskManager) updateServiceDiscoveryHealth(ctx context.Context, task *storage.Task) {
+// updateServiceDiscoveryHealth updates the health status of the instances
+// registered for a task in Service Discovery
+func (tm *TaskManager) updateServiceDiscoveryHealth(ctx context.Context, task *storage.Task, healthStatus string) {
 	// Check if Service Discovery manager is available
 	if tm.serviceDiscoveryManager == nil {
 		return
@@ -1044,45 +1156,57 @@ func (tm *TaskManager) updateServiceDiscoveryHealth(ctx context.Context, task *s
 		}
 	}
 
-	// Get the service from storage to check for ServiceRegistry metadata
+	// Get the service from storage to find its service registries
 	service, err := tm.storage.ServiceStore().Get(ctx, clusterName, serviceName)
-	if err != nil || service == nil || service.ServiceRegistryMetadata == nil {
+	if err != nil || service == nil {
 		return
 	}
 
-	// Update health status for each service discovery service
-	for serviceID := range service.ServiceRegistryMetadata {
-		// Map ECS health status to Service Discovery health status
-		var sdHealthStatus string
-		switch task.HealthStatus {
-		case "HEALTHY":
-			sdHealthStatus = "HEALTHY"
-		case "UNHEALTHY":
-			sdHealthStatus = "UNHEALTHY"
-		case "UNKNOWN":
-			sdHealthStatus = "UNKNOWN"
-		default:
-			sdHealthStatus = "UNKNOWN"
-		}
-
+	for _, serviceID := range serviceDiscoveryServiceIDs(task, service) {
 		// Update the instance health status (use pod name as instance ID)
-		if err := tm.serviceDiscoveryManager.UpdateInstanceHealthStatus(ctx, serviceID, task.PodName, sdHealthStatus); err != nil {
-			logging.Warn("Failed to update task health status in service discovery",
+		if err := tm.serviceDiscoveryManager.UpdateInstanceHealthStatus(ctx, serviceID, task.PodName, healthStatus); err != nil {
+			logging.Debug("Failed to update task health status in service discovery",
 				"task", task.ARN,
 				"serviceID", serviceID,
 				"instanceID", task.PodName,
-				"healthStatus", sdHealthStatus,
+				"healthStatus", healthStatus,
 				"error", err)
-		} else {
-			logging.Info("Task health status updated in service discovery",
-				"task", task.ARN,
-				"serviceID", serviceID,
-				"instanceID", task.PodName,
-				"healthStatus", sdHealthStatus)
 		}
 	}
 }
 
+// serviceDiscoveryServiceIDs returns the IDs of the Service Discovery
+// services a task registers with, from the service registries of the task or
+// its service, or else the legacy ServiceRegistryMetadata
+func serviceDiscoveryServiceIDs(task *storage.Task, service *storage.Service) []string {
+	registries := task.ServiceRegistries
+	if registries == "" {
+		registries = service.ServiceRegistries
+	}
+
+	var serviceIDs []string
+	var serviceRegistries []map[string]interface{}
+	if registries != "" {
+		if err := json.Unmarshal([]byte(registries), &serviceRegistries); err != nil {
+			logging.Debug("Failed to parse service registries", "task", task.ARN, "error", err)
+		}
+	}
+	for _, registry := range serviceRegistries {
+		// Format: arn:aws:servicediscovery:region:account:service/srv-xxx
+		registryArn, _ := registry["registryArn"].(string)
+		if i := strings.LastIndex(registryArn, "/"); i >= 0 && i < len(registryArn)-1 {
+			serviceIDs = append(serviceIDs, registryArn[i+1:])
+		}
+	}
+	if len(serviceIDs) == 0 {
+		for serviceID := range service.ServiceRegistryMetadata {
+			serviceIDs = append(serviceIDs, serviceID)
+		}
+		sort.Strings(serviceIDs)
+	}
+	return serviceIDs
+}
+
 // addPodInfoToTaskAttributes adds the pod name and namespace to the task's Attributes field
 func (tm *TaskManager) addPodInfoToTaskAttributes(task *storage.Task, podName, namespace string) error {
 	// Parse existing attributes or create new slice
@@ -1136,8 +1260,9 @@ func (tm *TaskManager) RestoreTask(ctx context.Context, task *storage.Task) erro
 
 	// Check if pod already exists
 	podName := fmt.Sprintf("task-%s", task.ID)
-	_, err := tm.Clientset.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+	existingPod, err := tm.Clientset.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
 	if err == nil {
+		tm.reserveDynamicHostPorts(existingPod, task.ARN)
 		logging.Info("Pod already exists, skipping restoration",
 			"pod", podName,
 			"namespace", namespace)
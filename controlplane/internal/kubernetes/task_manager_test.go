@@ -0,0 +1,85 @@
+package kubernetes_test
+
+import (
+	"context"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/mocks"
+	"github.com/nandemo-ya/kecs/controlplane/internal/kubernetes"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+var _ = Describe("TaskManager", func() {
+	const taskARN = "arn:aws:ecs:us-east-1:000000000000:task/default/0123456789abcdef"
+
+	var (
+		ctx         context.Context
+		taskStore   *mocks.MockTaskStore
+		taskManager *kubernetes.TaskManager
+	)
+
+	BeforeEach(func() {
+		os.Setenv("KECS_TEST_MODE", "true")
+		DeferCleanup(os.Unsetenv, "KECS_TEST_MODE")
+
+		ctx = context.Background()
+		mockStorage := mocks.NewMockStorage()
+		taskStore = mocks.NewMockTaskStore()
+		mockStorage.SetTaskStore(taskStore)
+
+		var err error
+		taskManager, err = kubernetes.NewTaskManager(mockStorage)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	storedTask := func() *storage.Task {
+		task, err := taskStore.Get(ctx, "", taskARN)
+		Expect(err).NotTo(HaveOccurred())
+		return task
+	}
+
+	pod := func(phase corev1.PodPhase, state corev1.ContainerState) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "task-0123456789abcdef", Namespace: "default-us-east-1"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "busybox"}}},
+			Status: corev1.PodStatus{
+				Phase:             phase,
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "app", State: state}},
+			},
+		}
+	}
+
+	Describe("UpdateTaskStatus", func() {
+		It("should keep the status the sync stored", func() {
+			Expect(taskStore.Create(ctx, &storage.Task{
+				ID: "0123456789abcdef", ARN: taskARN, LastStatus: "DEPROVISIONING", DesiredStatus: "STOPPED",
+			})).To(Succeed())
+
+			running := corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+			Expect(taskManager.UpdateTaskStatus(ctx, taskARN, "RUNNING", pod(corev1.PodRunning, running))).To(Succeed())
+			Expect(storedTask().LastStatus).To(Equal("DEPROVISIONING"))
+		})
+
+		It("should keep the stop of a stopped task on resync", func() {
+			stoppedAt := time.Now().Add(-time.Hour)
+			Expect(taskStore.Create(ctx, &storage.Task{
+				ID: "0123456789abcdef", ARN: taskARN, LastStatus: "STOPPED", DesiredStatus: "STOPPED",
+				StoppedAt: &stoppedAt, StopCode: "UserInitiated", StoppedReason: "Stopped by user",
+			})).To(Succeed())
+
+			failed := corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Reason: "Error"}}
+			Expect(taskManager.UpdateTaskStatus(ctx, taskARN, "STOPPED", pod(corev1.PodFailed, failed))).To(Succeed())
+
+			task := storedTask()
+			Expect(task.StoppedAt.Equal(stoppedAt)).To(BeTrue())
+			Expect(task.StopCode).To(Equal("UserInitiated"))
+			Expect(task.StoppedReason).To(Equal("Stopped by user"))
+		})
+	})
+})
@@ -14,16 +14,19 @@ import (
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logrouting"
 )
 
 const (
 	vectorNamespace      = "kecs-system"
 	vectorDaemonSet      = "vector"
 	vectorServiceAccount = "vector"
-	vectorConfigMap      = "vector-config"
-	vectorImage          = "timberio/vector:0.34.0-alpine"
+	vectorConfigMap      = logrouting.ConfigMapName
 )
 
+// VectorImage is the image of the Vector DaemonSet collecting task logs
+const VectorImage = "timberio/vector:0.34.0-alpine"
+
 // EnsureVectorDaemonSet ensures Vector DaemonSet is deployed in kecs-system namespace
 func EnsureVectorDaemonSet(ctx context.Context, clientset kubernetes.Interface, localstackEndpoint string, region string) error {
 	logging.Info("Ensuring Vector DaemonSet in kecs-system namespace",
@@ -122,115 +125,12 @@ func createVectorClusterRoleBinding(ctx context.Context, clientset kubernetes.In
 }
 
 func createVectorConfigMap(ctx context.Context, clientset kubernetes.Interface, localstackEndpoint string, region string) error {
-	if localstackEndpoint == "" {
-		localstackEndpoint = "http://localstack.kecs-system.svc.cluster.local:4566"
-	}
-	if region == "" {
-		region = "us-east-1"
-	}
-
-	vectorConfig := fmt.Sprintf(`# Vector configuration for CloudWatch Logs integration
-
-# Input: Collect all container logs
-[sources.kubernetes_logs]
-type = "kubernetes_logs"
-
-# Transform: Filter out system namespaces
-[transforms.filter_namespace]
-type = "filter"
-inputs = ["kubernetes_logs"]
-condition = '''
-!includes(["kecs-system", "kube-system"], .kubernetes.pod_namespace)
-'''
-
-# Transform: Process logs for CloudWatch
-[transforms.process_logs]
-type = "remap"
-inputs = ["filter_namespace"]
-source = '''
-# Extract metadata
-.namespace = string!(.kubernetes.pod_namespace)
-.pod_name = string!(.kubernetes.pod_name)
-.container_name = string!(.kubernetes.container_name)
-
-# Check for CloudWatch log configuration in annotations
-.annotations = .kubernetes.pod_annotations
-
-# Default values
-.log_group = "/kecs/default"
-.log_stream = .namespace + "/" + .pod_name + "/" + .container_name
-.cloudwatch_enabled = false
-
-# Check for container-specific log configuration
-container_prefix = "kecs.dev/container-" + .container_name + "-logs-"
-
-# Extract log configuration from annotations
-if exists(.annotations) {
-  if exists(.annotations."kecs.dev/cloudwatch-logs-enabled") {
-    if .annotations."kecs.dev/cloudwatch-logs-enabled" == "true" {
-      .cloudwatch_enabled = true
-      
-      # Get log group
-      group_key = container_prefix + "group"
-      group_value = get(.annotations, [group_key]) ?? null
-      if group_value != null {
-        .log_group = string!(group_value)
-      }
-      
-      # Get stream prefix
-      stream_key = container_prefix + "stream-prefix"
-      stream_value = get(.annotations, [stream_key]) ?? null
-      if stream_value != null {
-        .log_stream = string!(stream_value) + "/" + .pod_name
-      }
-    }
-  }
-}
-'''
-
-# Route: Only send CloudWatch-enabled logs
-[transforms.route_cloudwatch]
-type = "filter"
-inputs = ["process_logs"]
-condition = '.cloudwatch_enabled == true'
-
-# Optional: Sample logs for debugging (1%% of messages)
-[transforms.sample_debug]
-type = "filter"
-inputs = ["route_cloudwatch"]
-condition = 'random_float(0.0, 1.0) < 0.01'
-
-# Output: Send to CloudWatch Logs via LocalStack
-[sinks.cloudwatch]
-type = "aws_cloudwatch_logs"
-inputs = ["route_cloudwatch"]
-endpoint = "%s"
-region = "%s"
-group_name = "{{ log_group }}"
-stream_name = "{{ log_stream }}"
-create_missing_group = true
-create_missing_stream = true
-encoding.codec = "text"
-
-# Optional: Console output for debugging (sampled)
-[sinks.console_debug]
-type = "console"
-inputs = ["sample_debug"]
-encoding.codec = "json"
-target = "stdout"
-`, localstackEndpoint, region)
-
-	cm := &corev1.ConfigMap{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      vectorConfigMap,
-			Namespace: vectorNamespace,
-		},
-		Data: map[string]string{
-			"vector.toml": vectorConfig,
-		},
+	cm, err := logrouting.ConfigMap(localstackEndpoint, region, nil)
+	if err != nil {
+		return err
 	}
 
-	_, err := clientset.CoreV1().ConfigMaps(vectorNamespace).Create(ctx, cm, metav1.CreateOptions{})
+	_, err = clientset.CoreV1().ConfigMaps(vectorNamespace).Create(ctx, cm, metav1.CreateOptions{})
 	if err != nil && !errors.IsAlreadyExists(err) {
 		return err
 	}
@@ -268,7 +168,7 @@ func createVectorDaemonSet(ctx context.Context, clientset kubernetes.Interface)
 					Containers: []corev1.Container{
 						{
 							Name:  "vector",
-							Image: vectorImage,
+							Image: VectorImage,
 							Env: []corev1.EnvVar{
 								{
 									Name:  "VECTOR_CONFIG_DIR",
@@ -278,6 +178,11 @@ func createVectorDaemonSet(ctx context.Context, clientset kubernetes.Interface)
 									Name:  "VECTOR_LOG",
 									Value: "info",
 								},
+								{
+									// Reload the configuration once log routing overrides change it
+									Name:  "VECTOR_WATCH_CONFIG",
+									Value: "true",
+								},
 								{
 									Name: "VECTOR_SELF_NODE_NAME",
 									ValueFrom: &corev1.EnvVarSource{
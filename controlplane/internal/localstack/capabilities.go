@@ -0,0 +1,276 @@
+package localstack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// ServiceSupport describes whether the configured LocalStack can serve an AWS service
+type ServiceSupport string
+
+const (
+	// ServiceSupported means the service is running or starts on first use
+	ServiceSupported ServiceSupport = "supported"
+	// ServiceDisabled means the service exists but is not enabled in LocalStack
+	ServiceDisabled ServiceSupport = "disabled"
+	// ServiceUnsupported means the service is not available in the LocalStack edition
+	ServiceUnsupported ServiceSupport = "unsupported"
+	// ServiceError means LocalStack failed to start the service
+	ServiceError ServiceSupport = "error"
+	// ServiceUnknown means LocalStack did not report the service
+	ServiceUnknown ServiceSupport = "unknown"
+)
+
+const (
+	// EditionCommunity is the edition reported by the free LocalStack image
+	EditionCommunity = "community"
+
+	// capabilityTTL is how long a probe result is served before probing again
+	capabilityTTL = 5 * time.Minute
+	// capabilityRetryInterval is how long to wait before probing again after a failure
+	capabilityRetryInterval = 30 * time.Second
+)
+
+// ProOnlyServices lists services that only LocalStack Pro provides
+var ProOnlyServices = []string{
+	"appsync",
+	"athena",
+	"batch",
+	"cloudfront",
+	"cognito-identity",
+	"cognito-idp",
+	"docdb",
+	"ecr",
+	"eks",
+	"elasticache",
+	"emr",
+	"glue",
+	"iot",
+	"kafka",
+	"mwaa",
+	"neptune",
+	"rds",
+	"xray",
+}
+
+// serviceAliases maps SigV4 signing names to the service names LocalStack reports
+var serviceAliases = map[string]string{
+	"email":                "ses",
+	"monitoring":           "cloudwatch",
+	"states":               "stepfunctions",
+	"eventbridge":          "events",
+	"elasticloadbalancing": "elbv2",
+}
+
+// ServiceCapability is the support of a single AWS service
+type ServiceCapability struct {
+	Service string         `json:"service"`
+	Support ServiceSupport `json:"support"`
+	// State is the raw state LocalStack reported for the service
+	State   string `json:"state,omitempty"`
+	ProOnly bool   `json:"proOnly"`
+	Message string `json:"message,omitempty"`
+}
+
+// Capabilities is the support matrix of the configured LocalStack
+type Capabilities struct {
+	Edition  string                        `json:"edition"`
+	Version  string                        `json:"version,omitempty"`
+	Endpoint string                        `json:"endpoint"`
+	Services map[string]*ServiceCapability `json:"services"`
+	ProbedAt time.Time                     `json:"probedAt"`
+}
+
+// capabilityHealthResponse is the part of /_localstack/health the probe reads
+type capabilityHealthResponse struct {
+	Services map[string]string `json:"services"`
+	Edition  string            `json:"edition"`
+	Version  string            `json:"version"`
+}
+
+// IsProOnly reports whether a service requires LocalStack Pro
+func IsProOnly(service string) bool {
+	return slices.Contains(ProOnlyServices, canonicalServiceName(service))
+}
+
+// canonicalServiceName returns the name LocalStack reports a service under
+func canonicalServiceName(service string) string {
+	service = strings.ToLower(service)
+	if alias, ok := serviceAliases[service]; ok {
+		return alias
+	}
+	return service
+}
+
+// ProbeCapabilities reads the service states from the LocalStack health
+// endpoint and builds the support matrix. Services missing from the report
+// are unsupported when they are Pro-only and LocalStack is the community
+// edition, unknown otherwise.
+func ProbeCapabilities(ctx context.Context, client *http.Client, endpoint string) (*Capabilities, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+HealthCheckPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to LocalStack: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("LocalStack health endpoint responded with status %d", resp.StatusCode)
+	}
+
+	var health capabilityHealthResponse
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return nil, fmt.Errorf("failed to decode health response: %w", err)
+	}
+
+	caps := &Capabilities{
+		Edition:  strings.ToLower(health.Edition),
+		Version:  health.Version,
+		Endpoint: endpoint,
+		Services: make(map[string]*ServiceCapability),
+		ProbedAt: time.Now(),
+	}
+	if caps.Edition == "" {
+		caps.Edition = "unknown"
+	}
+
+	for service, state := range health.Services {
+		caps.Services[service] = newServiceCapability(service, state)
+	}
+	for _, service := range ProOnlyServices {
+		if _, ok := caps.Services[service]; !ok {
+			caps.Services[service] = caps.missingService(service)
+		}
+	}
+
+	return caps, nil
+}
+
+// newServiceCapability maps a state reported by LocalStack to the support of the service
+func newServiceCapability(service, state string) *ServiceCapability {
+	capability := &ServiceCapability{
+		Service: service,
+		State:   state,
+		ProOnly: IsProOnly(service),
+	}
+
+	switch state {
+	case "running", "available", "initialized", "starting":
+		capability.Support = ServiceSupported
+	case "disabled":
+		capability.Support = ServiceDisabled
+		capability.Message = fmt.Sprintf("service %s is not enabled in LocalStack, add it to localstack.services", service)
+	case "error":
+		capability.Support = ServiceError
+		capability.Message = fmt.Sprintf("LocalStack failed to start service %s, check the LocalStack logs", service)
+	default:
+		capability.Support = ServiceUnknown
+	}
+	return capability
+}
+
+// missingService returns the capability of a service LocalStack did not report
+func (c *Capabilities) missingService(service string) *ServiceCapability {
+	capability := &ServiceCapability{
+		Service: service,
+		Support: ServiceUnknown,
+		ProOnly: IsProOnly(service),
+	}
+	if capability.ProOnly && c.Edition == EditionCommunity {
+		capability.Support = ServiceUnsupported
+		capability.Message = fmt.Sprintf("service %s requires LocalStack Pro, the configured LocalStack is the community edition", service)
+	}
+	return capability
+}
+
+// Lookup returns the capability of a service, resolving signing name aliases
+func (c *Capabilities) Lookup(service string) *ServiceCapability {
+	service = canonicalServiceName(service)
+	if capability, ok := c.Services[service]; ok {
+		return capability
+	}
+	return c.missingService(service)
+}
+
+// Usable reports whether requests for a service should be proxied. Services
+// of unknown support are let through and LocalStack answers them itself.
+func (c *ServiceCapability) Usable() bool {
+	return c.Support == ServiceSupported || c.Support == ServiceUnknown
+}
+
+// CapabilityProber caches the support matrix of a LocalStack endpoint
+type CapabilityProber struct {
+	endpoint   string
+	httpClient *http.Client
+
+	mu         sync.Mutex
+	caps       *Capabilities
+	lastErr    error
+	lastProbed time.Time
+}
+
+// NewCapabilityProber creates a prober for a LocalStack endpoint
+func NewCapabilityProber(endpoint string) *CapabilityProber {
+	return &CapabilityProber{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		httpClient: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+	}
+}
+
+// Probe queries LocalStack and replaces the cached support matrix. The
+// previous matrix is kept when LocalStack cannot be reached.
+func (p *CapabilityProber) Probe(ctx context.Context) (*Capabilities, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.probeLocked(ctx)
+}
+
+func (p *CapabilityProber) probeLocked(ctx context.Context) (*Capabilities, error) {
+	p.lastProbed = time.Now()
+	caps, err := ProbeCapabilities(ctx, p.httpClient, p.endpoint)
+	p.lastErr = err
+	if err != nil {
+		logging.Debug("Failed to probe LocalStack capabilities", "endpoint", p.endpoint, "error", err)
+		return p.caps, err
+	}
+	p.caps = caps
+	logging.Info("Probed LocalStack capabilities", "endpoint", p.endpoint, "edition", caps.Edition, "version", caps.Version, "services", len(caps.Services))
+	return caps, nil
+}
+
+// Capabilities returns the cached support matrix, probing again when it is
+// missing or stale. It returns nil while LocalStack has never answered.
+func (p *CapabilityProber) Capabilities(ctx context.Context) *Capabilities {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	interval := capabilityTTL
+	if p.caps == nil || p.lastErr != nil {
+		interval = capabilityRetryInterval
+	}
+	if time.Since(p.lastProbed) < interval {
+		return p.caps
+	}
+	caps, _ := p.probeLocked(ctx)
+	return caps
+}
+
+// Cached returns the last support matrix without probing, and the error of the last probe
+func (p *CapabilityProber) Cached() (*Capabilities, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.caps, p.lastErr
+}
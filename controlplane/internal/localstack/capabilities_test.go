@@ -0,0 +1,86 @@
+package localstack_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/localstack"
+)
+
+var _ = Describe("CapabilityProber", func() {
+	var (
+		mockServer *httptest.Server
+		health     string
+		status     int
+	)
+
+	BeforeEach(func() {
+		status = http.StatusOK
+		health = `{"edition": "community", "version": "3.8.1", "services": {"s3": "running", "sqs": "available", "sns": "disabled", "lambda": "error"}}`
+		mockServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != localstack.HealthCheckPath {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(status)
+			fmt.Fprint(w, health)
+		}))
+	})
+
+	AfterEach(func() {
+		mockServer.Close()
+	})
+
+	It("should build the support matrix from the health endpoint", func() {
+		caps, err := localstack.NewCapabilityProber(mockServer.URL).Probe(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(caps.Edition).To(Equal("community"))
+		Expect(caps.Version).To(Equal("3.8.1"))
+
+		Expect(caps.Lookup("s3").Support).To(Equal(localstack.ServiceSupported))
+		Expect(caps.Lookup("sqs").Support).To(Equal(localstack.ServiceSupported))
+		Expect(caps.Lookup("sns").Support).To(Equal(localstack.ServiceDisabled))
+		Expect(caps.Lookup("lambda").Support).To(Equal(localstack.ServiceError))
+		Expect(caps.Lookup("lambda").Usable()).To(BeFalse())
+
+		rds := caps.Lookup("rds")
+		Expect(rds.Support).To(Equal(localstack.ServiceUnsupported))
+		Expect(rds.ProOnly).To(BeTrue())
+		Expect(rds.Message).To(ContainSubstring("requires LocalStack Pro"))
+		Expect(caps.Services).To(HaveKey("rds"))
+
+		unreported := caps.Lookup("states")
+		Expect(unreported.Service).To(Equal("stepfunctions"))
+		Expect(unreported.Support).To(Equal(localstack.ServiceUnknown))
+		Expect(unreported.Usable()).To(BeTrue())
+	})
+
+	It("should not mark Pro services unsupported on LocalStack Pro", func() {
+		health = `{"edition": "pro", "services": {"rds": "available"}}`
+		caps, err := localstack.NewCapabilityProber(mockServer.URL).Probe(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(caps.Lookup("rds").Support).To(Equal(localstack.ServiceSupported))
+		Expect(caps.Lookup("elasticache").Support).To(Equal(localstack.ServiceUnknown))
+	})
+
+	It("should keep the last matrix when LocalStack stops answering", func() {
+		prober := localstack.NewCapabilityProber(mockServer.URL)
+		_, err := prober.Probe(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+
+		status = http.StatusInternalServerError
+		caps, err := prober.Probe(context.Background())
+		Expect(err).To(HaveOccurred())
+		Expect(caps).NotTo(BeNil())
+		Expect(caps.Lookup("s3").Support).To(Equal(localstack.ServiceSupported))
+
+		cached, lastErr := prober.Cached()
+		Expect(cached).To(Equal(caps))
+		Expect(lastErr).To(HaveOccurred())
+	})
+})
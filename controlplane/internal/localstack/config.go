@@ -17,6 +17,7 @@ func DefaultConfig() *Config {
 		Namespace:   DefaultNamespace,
 		Port:        DefaultPort,
 		EdgePort:    DefaultEdgePort,
+		Backend:     BackendLocalStack,
 		Resources: ResourceLimits{
 			Memory:      "2Gi",
 			CPU:         "1000m",
@@ -70,6 +71,13 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid edge port: %d", c.EdgePort)
 	}
 
+	// Validate backend
+	switch c.Backend {
+	case "", BackendLocalStack, BackendStub:
+	default:
+		return fmt.Errorf("invalid backend: %s, must be %s or %s", c.Backend, BackendLocalStack, BackendStub)
+	}
+
 	// Validate services
 	for _, service := range c.Services {
 		if !IsValidService(service) {
@@ -111,6 +119,12 @@ func (r *ResourceLimits) Validate() error {
 	return nil
 }
 
+// UseStub reports whether the AWS services are served by the built-in stub
+// instead of LocalStack
+func (c *Config) UseStub() bool {
+	return c.Backend == BackendStub
+}
+
 // GetServicesString returns a comma-separated string of services
 func (c *Config) GetServicesString() string {
 	return strings.Join(c.Services, ",")
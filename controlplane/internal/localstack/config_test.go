@@ -89,6 +89,18 @@ var _ = Describe("LocalStack Configuration", func() {
 				Expect(err).To(HaveOccurred())
 			})
 
+			It("should return error for unknown backend", func() {
+				config.Backend = "moto"
+				err := config.Validate()
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("should accept the stub backend", func() {
+				config.Backend = localstack.BackendStub
+				Expect(config.Validate()).To(Succeed())
+				Expect(config.UseStub()).To(BeTrue())
+			})
+
 			It("should return error for invalid memory limit", func() {
 				config.Resources.Memory = "invalid"
 				err := config.Validate()
@@ -220,8 +220,9 @@ func (km *kubernetesManager) createDeployment(ctx context.Context, config *Confi
 				Spec: corev1.PodSpec{
 					Containers: []corev1.Container{
 						{
-							Name:  "localstack",
-							Image: fmt.Sprintf("%s:%s", config.Image, config.Version),
+							Name:            "localstack",
+							Image:           fmt.Sprintf("%s:%s", config.Image, config.Version),
+							ImagePullPolicy: corev1.PullPolicy(config.ImagePullPolicy),
 							Ports: []corev1.ContainerPort{
 								{
 									Name:          "edge",
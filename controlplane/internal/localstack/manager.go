@@ -32,6 +32,9 @@ func NewManager(config *Config, kubeClient kubernetes.Interface, kubeConfig *res
 	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid config: %w", err)
 	}
+	if config.UseStub() {
+		return newStubManager(config, kubeClient), nil
+	}
 
 	kubeManager := NewKubernetesManager(kubeClient, kubeConfig, config.Namespace)
 
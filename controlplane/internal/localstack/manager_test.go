@@ -7,6 +7,8 @@ import (
 	"context"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
 
@@ -128,4 +130,47 @@ var _ = Describe("LocalStack Manager", func() {
 			})
 		})
 	})
+	Describe("Stub backend", func() {
+		BeforeEach(func() {
+			config.Backend = localstack.BackendStub
+			var err error
+			manager, err = localstack.NewManager(config, kubeClient, restConfig)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should point the localstack service to the control plane", func() {
+			Expect(manager.Start(ctx)).To(Succeed())
+
+			service, err := kubeClient.CoreV1().Services(config.Namespace).Get(ctx, "localstack", metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(service.Spec.Selector).To(Equal(map[string]string{"app": "kecs-server"}))
+			Expect(service.Spec.Ports[0].Port).To(Equal(int32(4566)))
+
+			Expect(manager.IsHealthy()).To(BeTrue())
+			endpoint, err := manager.GetEndpoint()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(endpoint).To(Equal("http://localstack.kecs-system.svc.cluster.local:4566"))
+		})
+
+		It("should repoint a service left by LocalStack", func() {
+			_, err := kubeClient.CoreV1().Services(config.Namespace).Create(ctx, &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "localstack", Namespace: config.Namespace},
+				Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "localstack"}},
+			}, metav1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(manager.Start(ctx)).To(Succeed())
+
+			service, err := kubeClient.CoreV1().Services(config.Namespace).Get(ctx, "localstack", metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(service.Spec.Selector).To(Equal(map[string]string{"app": "kecs-server"}))
+		})
+
+		It("should only serve the stubbed services", func() {
+			services, err := manager.GetEnabledServices()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(services).To(ConsistOf("s3", "ssm", "secretsmanager", "cloudwatch"))
+			Expect(manager.UpdateServices([]string{"dynamodb"})).To(HaveOccurred())
+		})
+	})
 })
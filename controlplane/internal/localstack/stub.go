@@ -0,0 +1,240 @@
+package localstack
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/kubernetes/resources"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// stubManager is the Manager of BackendStub. There is nothing to deploy: the
+// control plane serves the stubbed services on the LocalStack port, and the
+// localstack Service points to the control plane so that clients keep using
+// the LocalStack endpoint.
+type stubManager struct {
+	config     *Config
+	kubeClient kubernetes.Interface
+
+	mu        sync.RWMutex
+	running   bool
+	endpoint  string
+	startedAt time.Time
+}
+
+func newStubManager(config *Config, kubeClient kubernetes.Interface) *stubManager {
+	return &stubManager{
+		config:     config,
+		kubeClient: kubeClient,
+	}
+}
+
+// Start points the localstack Service to the control plane
+func (m *stubManager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.running {
+		return nil
+	}
+
+	logging.Info("Using the built-in AWS stub instead of LocalStack", "services", StubServices)
+	if err := NewKubernetesManager(m.kubeClient, nil, m.config.Namespace).CreateNamespace(ctx); err != nil {
+		return fmt.Errorf("failed to create namespace: %w", err)
+	}
+	if err := m.ensureService(ctx); err != nil {
+		return fmt.Errorf("failed to create service of the AWS stub: %w", err)
+	}
+
+	m.running = true
+	m.startedAt = time.Now()
+	m.endpoint = fmt.Sprintf("http://localstack.%s.svc.cluster.local:%d", m.config.Namespace, m.config.Port)
+	return nil
+}
+
+// ensureService creates the localstack Service selecting the control plane,
+// or repoints one left by LocalStack
+func (m *stubManager) ensureService(ctx context.Context) error {
+	selector := map[string]string{resources.LabelApp: resources.ControlPlaneName}
+	ports := []corev1.ServicePort{
+		{
+			Name:       "edge",
+			Port:       int32(m.config.Port),
+			TargetPort: intstr.FromInt(m.config.EdgePort),
+			Protocol:   corev1.ProtocolTCP,
+		},
+	}
+
+	services := m.kubeClient.CoreV1().Services(m.config.Namespace)
+	existing, err := services.Get(ctx, "localstack", metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		service := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "localstack",
+				Namespace: m.config.Namespace,
+				Labels: map[string]string{
+					LabelApp:       "localstack",
+					LabelComponent: BackendStub,
+					LabelManagedBy: "kecs",
+				},
+			},
+			Spec: corev1.ServiceSpec{
+				Type:     corev1.ServiceTypeClusterIP,
+				Selector: selector,
+				Ports:    ports,
+			},
+		}
+		_, err = services.Create(ctx, service, metav1.CreateOptions{})
+		if errors.IsAlreadyExists(err) {
+			return nil
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if maps.Equal(existing.Spec.Selector, selector) {
+		return nil
+	}
+	existing.Spec.Selector = selector
+	existing.Spec.Ports = ports
+	_, err = services.Update(ctx, existing, metav1.UpdateOptions{})
+	return err
+}
+
+// Stop deletes the localstack Service
+func (m *stubManager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.running {
+		return fmt.Errorf("AWS stub is not running")
+	}
+	err := m.kubeClient.CoreV1().Services(m.config.Namespace).Delete(ctx, "localstack", metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete service of the AWS stub: %w", err)
+	}
+	m.running = false
+	m.endpoint = ""
+	return nil
+}
+
+// Restart recreates the localstack Service
+func (m *stubManager) Restart(ctx context.Context) error {
+	if err := m.Stop(ctx); err != nil {
+		return err
+	}
+	return m.Start(ctx)
+}
+
+// GetStatus returns the status of the stub, which is healthy while running
+func (m *stubManager) GetStatus() (*Status, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	status := &Status{
+		Running:         m.running,
+		Healthy:         m.running,
+		Endpoint:        m.endpoint,
+		EnabledServices: slices.Clone(StubServices),
+		ServiceStatus:   make(map[string]ServiceInfo),
+		LastHealthCheck: time.Now(),
+		Version:         BackendStub,
+	}
+	for _, service := range StubServices {
+		status.ServiceStatus[service] = ServiceInfo{
+			Name:     service,
+			Enabled:  true,
+			Healthy:  m.running,
+			Endpoint: m.endpoint,
+		}
+	}
+	if m.running {
+		status.Uptime = time.Since(m.startedAt)
+	}
+	return status, nil
+}
+
+// UpdateServices fails for services the stub does not serve
+func (m *stubManager) UpdateServices(services []string) error {
+	for _, service := range services {
+		if !slices.Contains(StubServices, service) {
+			return fmt.Errorf("service %s is not served by the AWS stub, use the localstack backend", service)
+		}
+	}
+	return nil
+}
+
+// GetEnabledServices returns the services of the stub
+func (m *stubManager) GetEnabledServices() ([]string, error) {
+	return slices.Clone(StubServices), nil
+}
+
+// GetEndpoint returns the endpoint of the localstack Service
+func (m *stubManager) GetEndpoint() (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if !m.running {
+		return "", fmt.Errorf("AWS stub is not running")
+	}
+	if m.config.ProxyEndpoint != "" && !m.config.ContainerMode {
+		return m.config.ProxyEndpoint, nil
+	}
+	return m.endpoint, nil
+}
+
+// GetServiceEndpoint returns the endpoint of a service of the stub
+func (m *stubManager) GetServiceEndpoint(service string) (string, error) {
+	endpoint, err := m.GetEndpoint()
+	if err != nil {
+		return "", err
+	}
+	return GetServiceURL(endpoint, service), nil
+}
+
+// IsHealthy reports whether the stub runs
+func (m *stubManager) IsHealthy() bool {
+	return m.IsRunning()
+}
+
+// IsRunning reports whether the localstack Service points to the stub
+func (m *stubManager) IsRunning() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.running
+}
+
+// WaitForReady returns once the stub runs, which happens on Start
+func (m *stubManager) WaitForReady(ctx context.Context, timeout time.Duration) error {
+	if !m.IsRunning() {
+		return fmt.Errorf("AWS stub is not running")
+	}
+	return nil
+}
+
+// CheckServiceHealth fails for services the stub does not serve
+func (m *stubManager) CheckServiceHealth(service string) error {
+	if !m.IsRunning() {
+		return fmt.Errorf("AWS stub is not running")
+	}
+	if !slices.Contains(StubServices, service) {
+		return fmt.Errorf("service %s is not served by the AWS stub", service)
+	}
+	return nil
+}
+
+// GetConfig returns the configuration of the stub
+func (m *stubManager) GetConfig() *Config {
+	return m.config
+}
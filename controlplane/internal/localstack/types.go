@@ -68,6 +68,10 @@ type Config struct {
 	Namespace string `yaml:"namespace" json:"namespace"`
 	Port      int    `yaml:"port" json:"port"`
 	EdgePort  int    `yaml:"edge_port" json:"edge_port"`
+	// ImagePullPolicy of the LocalStack container, the Kubernetes default when empty
+	ImagePullPolicy string `yaml:"image_pull_policy" json:"image_pull_policy"`
+	// Backend serving the AWS services, BackendLocalStack when empty
+	Backend string `yaml:"backend" json:"backend"`
 
 	// Resource limits
 	Resources ResourceLimits `yaml:"resources" json:"resources"`
@@ -194,6 +198,23 @@ const (
 	ServiceHealthPath = "/_localstack/health/services"
 )
 
+// Backends serving the AWS services of KECS
+const (
+	// BackendLocalStack deploys LocalStack into the cluster
+	BackendLocalStack = "localstack"
+	// BackendStub serves StubServices from the control plane, for machines
+	// too small for LocalStack
+	BackendStub = "stub"
+)
+
+// StubServices are the services served by BackendStub
+var StubServices = []string{
+	"s3",
+	"ssm",
+	"secretsmanager",
+	"cloudwatch",
+}
+
 // Default services to enable
 var DefaultServices = []string{
 	"s3",
@@ -0,0 +1,13 @@
+package logrouting_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestLogRouting(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Log Routing Suite")
+}
@@ -0,0 +1,205 @@
+package logrouting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// ConfigMap the Vector DaemonSet reads its configuration from. Vector
+// watches the configuration and reloads it once the ConfigMap changes.
+const (
+	ConfigMapNamespace = "kecs-system"
+	ConfigMapName      = "vector-config"
+	ConfigKey          = "vector.toml"
+)
+
+// Annotations of the ConfigMap recording what the configuration is rendered from
+const (
+	OverridesAnnotation          = "kecs.dev/log-routing-overrides"
+	LocalStackEndpointAnnotation = "kecs.dev/localstack-endpoint"
+	RegionAnnotation             = "kecs.dev/region"
+)
+
+// Manager stores the overrides of clusters with the Vector configuration and
+// applies the configuration rendered from them
+type Manager struct {
+	kubeClient kubernetes.Interface
+}
+
+// NewManager creates a manager of the Vector configuration of a cluster
+func NewManager(kubeClient kubernetes.Interface) *Manager {
+	return &Manager{kubeClient: kubeClient}
+}
+
+// ConfigMap returns the ConfigMap of the Vector configuration rendered for
+// overrides. It records the overrides, the LocalStack endpoint and the region
+// so that the configuration can be rendered again.
+func ConfigMap(localstackEndpoint, region string, overrides []Override) (*corev1.ConfigMap, error) {
+	config, err := Render(localstackEndpoint, region, overrides)
+	if err != nil {
+		return nil, err
+	}
+	if localstackEndpoint == "" {
+		localstackEndpoint = DefaultLocalStackEndpoint
+	}
+	if region == "" {
+		region = DefaultRegion
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ConfigMapName,
+			Namespace: ConfigMapNamespace,
+			Annotations: map[string]string{
+				LocalStackEndpointAnnotation: localstackEndpoint,
+				RegionAnnotation:             region,
+			},
+		},
+		Data: map[string]string{ConfigKey: config},
+	}
+	if err := setOverrides(cm, overrides); err != nil {
+		return nil, err
+	}
+	return cm, nil
+}
+
+// List returns the overrides of all clusters, sorted by cluster
+func (m *Manager) List(ctx context.Context) ([]Override, error) {
+	cm, err := m.configMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return overridesOf(cm)
+}
+
+// Get returns the override of a cluster, nil when it has none
+func (m *Manager) Get(ctx context.Context, cluster string) (*Override, error) {
+	overrides, err := m.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range overrides {
+		if overrides[i].Cluster == cluster {
+			return &overrides[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// Put validates the override of a cluster, replacing the one it had, and
+// returns the Vector configuration rendered with it. The configuration is
+// only applied when dryRun is false.
+func (m *Manager) Put(ctx context.Context, override Override, dryRun bool) (string, error) {
+	if err := override.Validate(); err != nil {
+		return "", err
+	}
+	override.UpdatedAt = time.Now().UTC()
+	return m.update(ctx, dryRun, func(overrides []Override) []Override {
+		return append(without(overrides, override.Cluster), override)
+	})
+}
+
+// Delete removes the override of a cluster, its logs are routed to
+// CloudWatch Logs only again
+func (m *Manager) Delete(ctx context.Context, cluster string) error {
+	_, err := m.update(ctx, false, func(overrides []Override) []Override {
+		return without(overrides, cluster)
+	})
+	return err
+}
+
+// update renders the configuration with the changed overrides and applies it
+func (m *Manager) update(ctx context.Context, dryRun bool, change func([]Override) []Override) (string, error) {
+	cm, err := m.configMap(ctx)
+	if err != nil {
+		return "", err
+	}
+	overrides, err := overridesOf(cm)
+	if err != nil {
+		return "", err
+	}
+	overrides = change(overrides)
+
+	config, err := Render(cm.Annotations[LocalStackEndpointAnnotation], cm.Annotations[RegionAnnotation], overrides)
+	if err != nil {
+		return "", err
+	}
+	if dryRun {
+		return config, nil
+	}
+
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[ConfigKey] = config
+	if err := setOverrides(cm, overrides); err != nil {
+		return "", err
+	}
+	if _, err := m.kubeClient.CoreV1().ConfigMaps(ConfigMapNamespace).Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		return "", fmt.Errorf("failed to update Vector configuration: %w", err)
+	}
+	logging.Info("Applied log routing overrides", "overrides", len(overrides))
+	return config, nil
+}
+
+// configMap returns the ConfigMap of the Vector configuration
+func (m *Manager) configMap(ctx context.Context) (*corev1.ConfigMap, error) {
+	if m.kubeClient == nil {
+		return nil, fmt.Errorf("kubernetes client is not available")
+	}
+	cm, err := m.kubeClient.CoreV1().ConfigMaps(ConfigMapNamespace).Get(ctx, ConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Vector configuration: %w", err)
+	}
+	return cm, nil
+}
+
+// overridesOf returns the overrides recorded on a ConfigMap, sorted by cluster
+func overridesOf(cm *corev1.ConfigMap) ([]Override, error) {
+	data := cm.Annotations[OverridesAnnotation]
+	if data == "" {
+		return []Override{}, nil
+	}
+	var overrides []Override
+	if err := json.Unmarshal([]byte(data), &overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse log routing overrides: %w", err)
+	}
+	sort.Slice(overrides, func(i, j int) bool { return overrides[i].Cluster < overrides[j].Cluster })
+	return overrides, nil
+}
+
+// setOverrides records overrides on a ConfigMap
+func setOverrides(cm *corev1.ConfigMap, overrides []Override) error {
+	if cm.Annotations == nil {
+		cm.Annotations = map[string]string{}
+	}
+	if len(overrides) == 0 {
+		delete(cm.Annotations, OverridesAnnotation)
+		return nil
+	}
+	data, err := json.Marshal(overrides)
+	if err != nil {
+		return fmt.Errorf("failed to encode log routing overrides: %w", err)
+	}
+	cm.Annotations[OverridesAnnotation] = string(data)
+	return nil
+}
+
+// without returns overrides without the one of a cluster
+func without(overrides []Override, cluster string) []Override {
+	kept := make([]Override, 0, len(overrides))
+	for _, o := range overrides {
+		if o.Cluster != cluster {
+			kept = append(kept, o)
+		}
+	}
+	return kept
+}
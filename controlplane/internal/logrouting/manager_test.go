@@ -0,0 +1,81 @@
+package logrouting_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/logrouting"
+)
+
+var _ = Describe("Manager", func() {
+	var (
+		ctx        context.Context
+		kubeClient *fake.Clientset
+		manager    *logrouting.Manager
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		cm, err := logrouting.ConfigMap("http://localstack:4566", "eu-west-1", nil)
+		Expect(err).NotTo(HaveOccurred())
+		kubeClient = fake.NewSimpleClientset(cm)
+		manager = logrouting.NewManager(kubeClient)
+	})
+
+	appliedConfig := func() string {
+		cm, err := kubeClient.CoreV1().ConfigMaps(logrouting.ConfigMapNamespace).Get(ctx, logrouting.ConfigMapName, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		return cm.Data[logrouting.ConfigKey]
+	}
+
+	override := logrouting.Override{
+		Cluster:   "web",
+		Namespace: "web-eu-west-1",
+		Sinks:     []logrouting.Sink{{Name: "debug", Type: logrouting.SinkConsole}},
+	}
+
+	It("applies and removes the override of a cluster", func() {
+		config, err := manager.Put(ctx, override, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config).To(ContainSubstring("[sinks.web_eu_west_1_debug]"))
+		Expect(config).To(ContainSubstring(`region = "eu-west-1"`))
+		Expect(appliedConfig()).To(Equal(config))
+
+		stored, err := manager.Get(ctx, "web")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stored).NotTo(BeNil())
+		Expect(stored.Sinks).To(Equal(override.Sinks))
+		Expect(stored.UpdatedAt).NotTo(BeZero())
+
+		Expect(manager.Delete(ctx, "web")).To(Succeed())
+		overrides, err := manager.List(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(overrides).To(BeEmpty())
+		Expect(appliedConfig()).NotTo(ContainSubstring("web_eu_west_1"))
+	})
+
+	It("only renders the configuration on a dry run", func() {
+		config, err := manager.Put(ctx, override, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config).To(ContainSubstring("[sinks.web_eu_west_1_debug]"))
+
+		Expect(appliedConfig()).NotTo(ContainSubstring("web_eu_west_1"))
+		stored, err := manager.Get(ctx, "web")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stored).To(BeNil())
+	})
+
+	It("keeps the applied configuration when an override is invalid", func() {
+		before := appliedConfig()
+		invalid := override
+		invalid.Redact = []string{"[unclosed"}
+
+		_, err := manager.Put(ctx, invalid, false)
+		Expect(err).To(MatchError(ContainSubstring("invalid redact pattern")))
+		Expect(appliedConfig()).To(Equal(before))
+	})
+})
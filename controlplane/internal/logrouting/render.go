@@ -0,0 +1,351 @@
+// Package logrouting renders the configuration of the Vector DaemonSet that
+// collects task logs. Every cluster routes the logs of its containers to
+// CloudWatch Logs in LocalStack. A cluster can override the routing of its
+// namespace with additional sinks such as Loki or S3, sampling, and the
+// redaction of secrets, which apply to the CloudWatch Logs route too.
+package logrouting
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Defaults of the CloudWatch Logs route
+const (
+	DefaultLocalStackEndpoint = "http://localstack.kecs-system.svc.cluster.local:4566"
+	DefaultRegion             = "us-east-1"
+)
+
+// Sink types of overrides
+const (
+	SinkLoki    = "loki"
+	SinkS3      = "aws_s3"
+	SinkHTTP    = "http"
+	SinkConsole = "console"
+)
+
+// sinkNamePattern restricts sink names to what Vector accepts in component IDs
+var sinkNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Sink is an additional destination of the logs of a cluster
+type Sink struct {
+	// Name identifies the sink within the override
+	Name string `json:"name"`
+	// Type is one of loki, aws_s3, http and console
+	Type string `json:"type"`
+	// Endpoint is the URL of Loki or of the HTTP receiver, and overrides the
+	// S3 endpoint, which defaults to LocalStack
+	Endpoint string `json:"endpoint,omitempty"`
+	// Bucket and KeyPrefix locate the objects of an S3 sink
+	Bucket    string `json:"bucket,omitempty"`
+	KeyPrefix string `json:"keyPrefix,omitempty"`
+	// Region of an S3 sink, defaults to the region of KECS
+	Region string `json:"region,omitempty"`
+	// Labels are added to the namespace, pod and container labels of Loki
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// Override customizes the routing of the logs of a cluster
+type Override struct {
+	Cluster   string `json:"cluster"`
+	Namespace string `json:"namespace"`
+	// Sinks receive every log of the cluster, CloudWatch Logs only receives
+	// the logs of containers with the awslogs driver
+	Sinks []Sink `json:"sinks,omitempty"`
+	// SampleRate keeps one of every SampleRate logs, 0 and 1 keep all
+	SampleRate int `json:"sampleRate,omitempty"`
+	// Redact lists regular expressions whose matches are replaced with
+	// [REDACTED] in log messages
+	Redact    []string  `json:"redact,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Validate checks an override before it is applied
+func (o *Override) Validate() error {
+	if o.Cluster == "" {
+		return fmt.Errorf("cluster is required")
+	}
+	if o.Namespace == "" {
+		return fmt.Errorf("namespace is required")
+	}
+	if o.SampleRate < 0 {
+		return fmt.Errorf("sampleRate must not be negative")
+	}
+	for _, pattern := range o.Redact {
+		if pattern == "" {
+			return fmt.Errorf("redact patterns must not be empty")
+		}
+		// Patterns are written as VRL regex literals within TOML literal strings
+		if strings.Contains(pattern, "'") {
+			return fmt.Errorf("redact pattern %q must not contain single quotes, use \\x27 instead", pattern)
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid redact pattern %q: %w", pattern, err)
+		}
+	}
+
+	names := make(map[string]bool, len(o.Sinks))
+	for _, sink := range o.Sinks {
+		if !sinkNamePattern.MatchString(sink.Name) {
+			return fmt.Errorf("invalid sink name %q: use lowercase letters, digits, '-' and '_'", sink.Name)
+		}
+		if names[sink.Name] {
+			return fmt.Errorf("duplicate sink name %q", sink.Name)
+		}
+		names[sink.Name] = true
+
+		switch sink.Type {
+		case SinkLoki, SinkHTTP:
+			if sink.Endpoint == "" {
+				return fmt.Errorf("sink %s: endpoint is required for %s sinks", sink.Name, sink.Type)
+			}
+		case SinkS3:
+			if sink.Bucket == "" {
+				return fmt.Errorf("sink %s: bucket is required for aws_s3 sinks", sink.Name)
+			}
+		case SinkConsole:
+		default:
+			return fmt.Errorf("sink %s: unsupported type %q, expected one of loki, aws_s3, http and console", sink.Name, sink.Type)
+		}
+	}
+	return nil
+}
+
+// Render returns the Vector configuration routing the logs of all clusters
+// to CloudWatch Logs with the overrides applied. The configuration is
+// parsed before it is returned, so that a broken one is never applied.
+func Render(localstackEndpoint, region string, overrides []Override) (string, error) {
+	if localstackEndpoint == "" {
+		localstackEndpoint = DefaultLocalStackEndpoint
+	}
+	if region == "" {
+		region = DefaultRegion
+	}
+	for i := range overrides {
+		if err := overrides[i].Validate(); err != nil {
+			return "", fmt.Errorf("cluster %s: %w", overrides[i].Cluster, err)
+		}
+	}
+	sorted := append([]Override(nil), overrides...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Namespace < sorted[j].Namespace })
+
+	var b strings.Builder
+	b.WriteString(baseConfig)
+
+	cloudwatchInputs := []string{"process_logs"}
+	if len(sorted) > 0 {
+		cloudwatchInputs = []string{"route_namespaces._unmatched"}
+		b.WriteString("\n# Route: Split the logs of clusters with overrides\n")
+		b.WriteString("[transforms.route_namespaces]\ntype = \"route\"\ninputs = [\"process_logs\"]\n")
+		for _, o := range sorted {
+			fmt.Fprintf(&b, "route.%s = '.namespace == %s'\n", componentID(o.Namespace), quote(o.Namespace))
+		}
+		for _, o := range sorted {
+			cloudwatchInputs = append(cloudwatchInputs, renderOverride(&b, o, localstackEndpoint, region))
+		}
+	}
+
+	fmt.Fprintf(&b, cloudwatchConfig, quoteAll(cloudwatchInputs), quote(localstackEndpoint), quote(region))
+
+	config := b.String()
+	var parsed map[string]interface{}
+	if err := toml.Unmarshal([]byte(config), &parsed); err != nil {
+		return "", fmt.Errorf("rendered Vector configuration is invalid: %w", err)
+	}
+	return config, nil
+}
+
+// renderOverride writes the transforms and sinks of an override, and returns
+// the component the logs of its cluster leave them from
+func renderOverride(b *strings.Builder, o Override, localstackEndpoint, region string) string {
+	id := componentID(o.Namespace)
+	current := "route_namespaces." + id
+	fmt.Fprintf(b, "\n# Overrides of cluster %s\n", o.Cluster)
+
+	if len(o.Redact) > 0 {
+		filters := make([]string, 0, len(o.Redact))
+		for _, pattern := range o.Redact {
+			filters = append(filters, "r'"+pattern+"'")
+		}
+		fmt.Fprintf(b, "[transforms.%s_redact]\ntype = \"remap\"\ninputs = [%s]\n", id, quote(current))
+		fmt.Fprintf(b, "source = '''\nif exists(.message) {\n  .message = redact!(.message, filters: [%s])\n}\n'''\n\n",
+			strings.Join(filters, ", "))
+		current = id + "_redact"
+	}
+
+	if o.SampleRate > 1 {
+		fmt.Fprintf(b, "[transforms.%s_sample]\ntype = \"sample\"\ninputs = [%s]\nrate = %d\n\n", id, quote(current), o.SampleRate)
+		current = id + "_sample"
+	}
+
+	for _, sink := range o.Sinks {
+		fmt.Fprintf(b, "[sinks.%s_%s]\ntype = %s\ninputs = [%s]\n", id, componentID(sink.Name), quote(sink.Type), quote(current))
+		switch sink.Type {
+		case SinkLoki:
+			fmt.Fprintf(b, "endpoint = %s\nencoding.codec = \"text\"\n", quote(sink.Endpoint))
+			labels := map[string]string{
+				"namespace": "{{ namespace }}",
+				"pod":       "{{ pod_name }}",
+				"container": "{{ container_name }}",
+			}
+			for k, v := range sink.Labels {
+				labels[k] = v
+			}
+			keys := make([]string, 0, len(labels))
+			for k := range labels {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				fmt.Fprintf(b, "labels.%s = %s\n", quote(k), quote(labels[k]))
+			}
+		case SinkS3:
+			endpoint, sinkRegion, prefix := sink.Endpoint, sink.Region, sink.KeyPrefix
+			if endpoint == "" {
+				endpoint = localstackEndpoint
+			}
+			if sinkRegion == "" {
+				sinkRegion = region
+			}
+			if prefix == "" {
+				prefix = o.Cluster + "/%Y/%m/%d/"
+			}
+			fmt.Fprintf(b, "endpoint = %s\nregion = %s\nbucket = %s\nkey_prefix = %s\ncompression = \"gzip\"\nencoding.codec = \"json\"\n",
+				quote(endpoint), quote(sinkRegion), quote(sink.Bucket), quote(prefix))
+		case SinkHTTP:
+			fmt.Fprintf(b, "uri = %s\nencoding.codec = \"json\"\n", quote(sink.Endpoint))
+		case SinkConsole:
+			b.WriteString("target = \"stdout\"\nencoding.codec = \"json\"\n")
+		}
+		b.WriteString("\n")
+	}
+	return current
+}
+
+// componentID turns a namespace or sink name into part of a Vector component ID
+func componentID(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, strings.ToLower(name))
+}
+
+// quote returns a TOML basic string
+func quote(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+func quoteAll(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, quote(v))
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// baseConfig collects the container logs and extracts their CloudWatch Logs
+// settings from the pod annotations
+const baseConfig = `# Vector configuration for CloudWatch Logs integration
+
+# Input: Collect all container logs
+[sources.kubernetes_logs]
+type = "kubernetes_logs"
+
+# Transform: Filter out system namespaces
+[transforms.filter_namespace]
+type = "filter"
+inputs = ["kubernetes_logs"]
+condition = '''
+!includes(["kecs-system", "kube-system"], .kubernetes.pod_namespace)
+'''
+
+# Transform: Process logs for CloudWatch
+[transforms.process_logs]
+type = "remap"
+inputs = ["filter_namespace"]
+source = '''
+# Extract metadata
+.namespace = string!(.kubernetes.pod_namespace)
+.pod_name = string!(.kubernetes.pod_name)
+.container_name = string!(.kubernetes.container_name)
+
+# Check for CloudWatch log configuration in annotations
+.annotations = .kubernetes.pod_annotations
+
+# Default values
+.log_group = "/kecs/default"
+.log_stream = .namespace + "/" + .pod_name + "/" + .container_name
+.cloudwatch_enabled = false
+
+# Check for container-specific log configuration
+container_prefix = "kecs.dev/container-" + .container_name + "-logs-"
+
+# Extract log configuration from annotations
+if exists(.annotations) {
+  if exists(.annotations."kecs.dev/cloudwatch-logs-enabled") {
+    if .annotations."kecs.dev/cloudwatch-logs-enabled" == "true" {
+      .cloudwatch_enabled = true
+
+      # Get log group
+      group_key = container_prefix + "group"
+      group_value = get(.annotations, [group_key]) ?? null
+      if group_value != null {
+        .log_group = string!(group_value)
+      }
+
+      # Get stream prefix
+      stream_key = container_prefix + "stream-prefix"
+      stream_value = get(.annotations, [stream_key]) ?? null
+      if stream_value != null {
+        .log_stream = string!(stream_value) + "/" + .pod_name
+      }
+    }
+  }
+}
+'''
+`
+
+// cloudwatchConfig routes the logs of containers with CloudWatch Logs
+// enabled to LocalStack. It takes the inputs of the route, the LocalStack
+// endpoint and the region.
+const cloudwatchConfig = `
+# Route: Only send CloudWatch-enabled logs
+[transforms.route_cloudwatch]
+type = "filter"
+inputs = [%s]
+condition = '.cloudwatch_enabled == true'
+
+# Optional: Sample logs for debugging (1%% of messages)
+[transforms.sample_debug]
+type = "filter"
+inputs = ["route_cloudwatch"]
+condition = 'random_float(0.0, 1.0) < 0.01'
+
+# Output: Send to CloudWatch Logs via LocalStack
+[sinks.cloudwatch]
+type = "aws_cloudwatch_logs"
+inputs = ["route_cloudwatch"]
+endpoint = %s
+region = %s
+group_name = "{{ log_group }}"
+stream_name = "{{ log_stream }}"
+create_missing_group = true
+create_missing_stream = true
+encoding.codec = "text"
+
+# Optional: Console output for debugging (sampled)
+[sinks.console_debug]
+type = "console"
+inputs = ["sample_debug"]
+encoding.codec = "json"
+target = "stdout"
+`
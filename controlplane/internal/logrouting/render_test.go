@@ -0,0 +1,103 @@
+package logrouting_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pelletier/go-toml/v2"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/logrouting"
+)
+
+var _ = Describe("Render", func() {
+	parse := func(config string) map[string]interface{} {
+		var parsed map[string]interface{}
+		Expect(toml.Unmarshal([]byte(config), &parsed)).To(Succeed())
+		return parsed
+	}
+
+	component := func(parsed map[string]interface{}, kind, id string) map[string]interface{} {
+		components, ok := parsed[kind].(map[string]interface{})
+		Expect(ok).To(BeTrue(), "no %s", kind)
+		c, ok := components[id].(map[string]interface{})
+		Expect(ok).To(BeTrue(), "no %s.%s", kind, id)
+		return c
+	}
+
+	It("routes all logs to CloudWatch Logs without overrides", func() {
+		config, err := logrouting.Render("", "", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		parsed := parse(config)
+		Expect(component(parsed, "transforms", "route_cloudwatch")["inputs"]).To(Equal([]interface{}{"process_logs"}))
+		cloudwatch := component(parsed, "sinks", "cloudwatch")
+		Expect(cloudwatch["endpoint"]).To(Equal(logrouting.DefaultLocalStackEndpoint))
+		Expect(cloudwatch["region"]).To(Equal(logrouting.DefaultRegion))
+		Expect(config).NotTo(ContainSubstring("route_namespaces"))
+	})
+
+	It("applies the redaction, sampling and sinks of a cluster to its namespace", func() {
+		config, err := logrouting.Render("http://localstack:4566", "ap-northeast-1", []logrouting.Override{{
+			Cluster:    "batch",
+			Namespace:  "batch-ap-northeast-1",
+			SampleRate: 10,
+			Redact:     []string{`password=\S+`, `AKIA[0-9A-Z]{16}`},
+			Sinks: []logrouting.Sink{
+				{Name: "loki", Type: logrouting.SinkLoki, Endpoint: "http://loki:3100", Labels: map[string]string{"team": "data"}},
+				{Name: "archive", Type: logrouting.SinkS3, Bucket: "logs"},
+			},
+		}})
+		Expect(err).NotTo(HaveOccurred())
+
+		parsed := parse(config)
+		route := component(parsed, "transforms", "route_namespaces")
+		Expect(route["route"]).To(HaveKeyWithValue("batch_ap_northeast_1", `.namespace == "batch-ap-northeast-1"`))
+
+		redact := component(parsed, "transforms", "batch_ap_northeast_1_redact")
+		Expect(redact["inputs"]).To(Equal([]interface{}{"route_namespaces.batch_ap_northeast_1"}))
+		Expect(redact["source"]).To(ContainSubstring(`redact!(.message, filters: [r'password=\S+', r'AKIA[0-9A-Z]{16}'])`))
+
+		sample := component(parsed, "transforms", "batch_ap_northeast_1_sample")
+		Expect(sample["inputs"]).To(Equal([]interface{}{"batch_ap_northeast_1_redact"}))
+		Expect(sample["rate"]).To(BeEquivalentTo(10))
+
+		loki := component(parsed, "sinks", "batch_ap_northeast_1_loki")
+		Expect(loki["inputs"]).To(Equal([]interface{}{"batch_ap_northeast_1_sample"}))
+		Expect(loki["labels"]).To(HaveKeyWithValue("team", "data"))
+		Expect(loki["labels"]).To(HaveKeyWithValue("pod", "{{ pod_name }}"))
+
+		archive := component(parsed, "sinks", "batch_ap_northeast_1_archive")
+		Expect(archive["endpoint"]).To(Equal("http://localstack:4566"))
+		Expect(archive["region"]).To(Equal("ap-northeast-1"))
+		Expect(archive["key_prefix"]).To(Equal("batch/%Y/%m/%d/"))
+
+		By("routing the CloudWatch Logs of the cluster through its overrides")
+		Expect(component(parsed, "transforms", "route_cloudwatch")["inputs"]).To(Equal([]interface{}{
+			"route_namespaces._unmatched", "batch_ap_northeast_1_sample",
+		}))
+	})
+
+	DescribeTable("rejects invalid overrides",
+		func(override logrouting.Override, message string) {
+			_, err := logrouting.Render("", "", []logrouting.Override{override})
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("without namespace", logrouting.Override{Cluster: "web"}, "namespace is required"),
+		Entry("with a negative sample rate",
+			logrouting.Override{Cluster: "web", Namespace: "web-us-east-1", SampleRate: -1}, "sampleRate must not be negative"),
+		Entry("with an invalid redact pattern",
+			logrouting.Override{Cluster: "web", Namespace: "web-us-east-1", Redact: []string{"("}}, "invalid redact pattern"),
+		Entry("with a quote in a redact pattern",
+			logrouting.Override{Cluster: "web", Namespace: "web-us-east-1", Redact: []string{"'secret'"}}, "single quotes"),
+		Entry("with an unsupported sink",
+			logrouting.Override{Cluster: "web", Namespace: "web-us-east-1", Sinks: []logrouting.Sink{{Name: "es", Type: "elasticsearch"}}},
+			`unsupported type "elasticsearch"`),
+		Entry("with a Loki sink without endpoint",
+			logrouting.Override{Cluster: "web", Namespace: "web-us-east-1", Sinks: []logrouting.Sink{{Name: "loki", Type: logrouting.SinkLoki}}},
+			"endpoint is required"),
+		Entry("with duplicate sinks",
+			logrouting.Override{Cluster: "web", Namespace: "web-us-east-1", Sinks: []logrouting.Sink{
+				{Name: "out", Type: logrouting.SinkConsole}, {Name: "out", Type: logrouting.SinkConsole},
+			}},
+			`duplicate sink name "out"`),
+	)
+})
@@ -0,0 +1,109 @@
+package notifications
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// EventType is the kind of a notification
+type EventType string
+
+const (
+	// EventDeploymentCompleted is sent when a deployment reaches its desired
+	// count and the service is stable
+	EventDeploymentCompleted EventType = "deployment.completed"
+	// EventDeploymentFailed is sent when a deployment fails, whether or not
+	// it is rolled back
+	EventDeploymentFailed EventType = "deployment.failed"
+	// EventTaskCrashLoop is sent when a container of a task starts crashlooping
+	EventTaskCrashLoop EventType = "task.crashloop"
+	// EventInstanceStarted is sent when the control plane of an instance starts
+	EventInstanceStarted EventType = "instance.started"
+	// EventInstanceStopping is sent when the control plane of an instance shuts down
+	EventInstanceStopping EventType = "instance.stopping"
+)
+
+// EventTypes lists the event types targets can subscribe to
+var EventTypes = []EventType{
+	EventDeploymentCompleted,
+	EventDeploymentFailed,
+	EventTaskCrashLoop,
+	EventInstanceStarted,
+	EventInstanceStopping,
+}
+
+// Event is the payload of webhook notifications
+type Event struct {
+	ID           string    `json:"id"`
+	Type         EventType `json:"type"`
+	Time         time.Time `json:"time"`
+	Instance     string    `json:"instance,omitempty"`
+	Cluster      string    `json:"cluster,omitempty"`
+	Service      string    `json:"service,omitempty"`
+	ServiceARN   string    `json:"serviceArn,omitempty"`
+	DeploymentID string    `json:"deploymentId,omitempty"`
+	TaskARN      string    `json:"taskArn,omitempty"`
+	Reason       string    `json:"reason,omitempty"`
+	Message      string    `json:"message"`
+}
+
+// DeploymentCompleted returns the event of a completed deployment
+func DeploymentCompleted(cluster, serviceName, serviceARN, deploymentID string) Event {
+	return Event{
+		Type:         EventDeploymentCompleted,
+		Cluster:      clusterName(cluster),
+		Service:      serviceName,
+		ServiceARN:   serviceARN,
+		DeploymentID: deploymentID,
+		Message:      fmt.Sprintf("Deployment %s of service %s completed, the service is stable", deploymentID, serviceName),
+	}
+}
+
+// DeploymentFailed returns the event of a failed deployment
+func DeploymentFailed(cluster, serviceName, serviceARN, deploymentID, reason string) Event {
+	return Event{
+		Type:         EventDeploymentFailed,
+		Cluster:      clusterName(cluster),
+		Service:      serviceName,
+		ServiceARN:   serviceARN,
+		DeploymentID: deploymentID,
+		Reason:       reason,
+		Message:      fmt.Sprintf("Deployment %s of service %s failed: %s", deploymentID, serviceName, reason),
+	}
+}
+
+// TaskCrashLoop returns the event of a task with a crashlooping container
+func TaskCrashLoop(cluster, serviceName, taskARN, reason string) Event {
+	message := fmt.Sprintf("Task %s is crashlooping: %s", taskARN, reason)
+	if serviceName != "" {
+		message = fmt.Sprintf("Task %s of service %s is crashlooping: %s", taskARN, serviceName, reason)
+	}
+	return Event{
+		Type:    EventTaskCrashLoop,
+		Cluster: clusterName(cluster),
+		Service: serviceName,
+		TaskARN: taskARN,
+		Reason:  reason,
+		Message: message,
+	}
+}
+
+// InstanceStarted returns the event of a started instance
+func InstanceStarted() Event {
+	return Event{Type: EventInstanceStarted, Message: "KECS instance started"}
+}
+
+// InstanceStopping returns the event of an instance shutting down
+func InstanceStopping() Event {
+	return Event{Type: EventInstanceStopping, Message: "KECS instance is shutting down"}
+}
+
+// clusterName returns the name of a cluster given by name or ARN, the event
+// constructors accept both
+func clusterName(cluster string) string {
+	if _, name, ok := strings.Cut(cluster, ":cluster/"); ok {
+		return name
+	}
+	return cluster
+}
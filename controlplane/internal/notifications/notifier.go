@@ -0,0 +1,255 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+const (
+	// SignatureHeader carries the hex encoded HMAC-SHA256 of the payload,
+	// prefixed with "sha256="
+	SignatureHeader = "X-KECS-Signature-256"
+	// EventHeader carries the event type
+	EventHeader = "X-KECS-Event"
+	// DeliveryHeader carries the event ID, the same for every retry
+	DeliveryHeader = "X-KECS-Delivery"
+
+	// queueSize is the number of deliveries waiting for a worker, events are
+	// dropped beyond it
+	queueSize = 256
+	workers   = 2
+	// drainTimeout bounds how long Stop delivers the queued events
+	drainTimeout = 5 * time.Second
+)
+
+// delivery is an event to post to one target
+type delivery struct {
+	target Target
+	event  Event
+}
+
+// Notifier posts events to webhook targets in the background. Failed
+// deliveries are retried with exponential backoff. A nil notifier drops all
+// events.
+type Notifier struct {
+	targets    []Target
+	secret     string
+	instance   string
+	client     *http.Client
+	maxRetries int
+	backoff    time.Duration
+	now        func() time.Time
+
+	queue    chan delivery
+	done     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewNotifier creates a notifier posting to targets, signing the payloads
+// with secret unless a target has its own
+func NewNotifier(targets []Target, secret, instance string, timeout time.Duration, maxRetries int) *Notifier {
+	return &Notifier{
+		targets:    targets,
+		secret:     secret,
+		instance:   instance,
+		client:     &http.Client{Timeout: timeout},
+		maxRetries: maxRetries,
+		backoff:    time.Second,
+		now:        time.Now,
+		queue:      make(chan delivery, queueSize),
+		done:       make(chan struct{}),
+	}
+}
+
+// NewNotifierFromConfig creates a notifier from the notifications settings.
+// It returns nil when no targets are configured.
+func NewNotifierFromConfig() (*Notifier, error) {
+	targets, err := ParseTargets(config.GetString("notifications.targets"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid notifications.targets: %w", err)
+	}
+	if len(targets) == 0 {
+		return nil, nil
+	}
+	return NewNotifier(targets, config.GetString("notifications.secret"), config.GetString("kubernetes.instanceName"),
+		config.GetDuration("notifications.timeout", 10*time.Second), config.GetInt("notifications.maxRetries")), nil
+}
+
+// Start begins delivering events
+func (n *Notifier) Start(ctx context.Context) {
+	if n == nil {
+		return
+	}
+	logging.Info("Notifier: Started", "targets", len(n.targets))
+	for i := 0; i < workers; i++ {
+		n.wg.Add(1)
+		go func() {
+			defer n.wg.Done()
+			n.run(ctx)
+		}()
+	}
+}
+
+// Stop delivers the queued events, without retries, and halts the notifier
+func (n *Notifier) Stop() {
+	if n == nil {
+		return
+	}
+	n.stopOnce.Do(func() { close(n.done) })
+
+	stopped := make(chan struct{})
+	go func() {
+		n.wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(drainTimeout):
+		logging.Warn("Notifier: Timed out delivering queued events")
+	}
+}
+
+// Notify queues an event for the targets subscribed to it. It never blocks,
+// events are dropped when the queue is full.
+func (n *Notifier) Notify(event Event) {
+	if n == nil {
+		return
+	}
+	event.ID = uuid.New().String()
+	if event.Time.IsZero() {
+		event.Time = n.now().UTC()
+	}
+	if event.Instance == "" {
+		event.Instance = n.instance
+	}
+
+	for _, target := range n.targets {
+		if !target.Matches(event) {
+			continue
+		}
+		select {
+		case n.queue <- delivery{target: target, event: event}:
+		default:
+			logging.Warn("Notifier: Queue is full, dropping event", "type", event.Type, "target", target.URL)
+		}
+	}
+}
+
+// run delivers queued events until the notifier is stopped, then drains the
+// queue
+func (n *Notifier) run(ctx context.Context) {
+	for {
+		select {
+		case d := <-n.queue:
+			n.deliver(ctx, d, n.maxRetries)
+		case <-ctx.Done():
+			return
+		case <-n.done:
+			for {
+				select {
+				case d := <-n.queue:
+					n.deliver(ctx, d, 0)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver posts an event to its target, retrying failed attempts
+func (n *Notifier) deliver(ctx context.Context, d delivery, retries int) {
+	body, err := payload(d.target.Format, d.event)
+	if err != nil {
+		logging.Error("Notifier: Failed to encode event", "type", d.event.Type, "error", err)
+		return
+	}
+
+	backoff := n.backoff
+	for attempt := 0; ; attempt++ {
+		retryable, err := n.post(ctx, d, body)
+		if err == nil {
+			logging.Debug("Notifier: Delivered event", "type", d.event.Type, "target", d.target.URL)
+			return
+		}
+		if !retryable || attempt >= retries {
+			logging.Warn("Notifier: Failed to deliver event",
+				"type", d.event.Type, "target", d.target.URL, "attempts", attempt+1, "error", err)
+			return
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-n.done:
+			// Shutting down, a last attempt is made without waiting
+			timer.Stop()
+			retries = attempt + 1
+		}
+		backoff *= 2
+	}
+}
+
+// post makes one delivery attempt. Connection errors, 429 and 5xx responses
+// are retryable.
+func (n *Notifier) post(ctx context.Context, d delivery, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.target.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "KECS-Notifications")
+	req.Header.Set(EventHeader, string(d.event.Type))
+	req.Header.Set(DeliveryHeader, d.event.ID)
+	secret := d.target.Secret
+	if secret == "" {
+		secret = n.secret
+	}
+	if secret != "" {
+		req.Header.Set(SignatureHeader, Sign(secret, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retryable, fmt.Errorf("target responded with status %d", resp.StatusCode)
+}
+
+// Sign returns the signature header value of a payload: "sha256=" followed by
+// the hex encoded HMAC-SHA256 of the payload keyed with secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// payload encodes an event in the format of a target
+func payload(format Format, event Event) ([]byte, error) {
+	if format == FormatSlack {
+		return json.Marshal(slackMessage(event))
+	}
+	return json.Marshal(event)
+}
@@ -0,0 +1,165 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// receivedRequest is a delivery received by the test endpoint
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+var _ = Describe("Notifier", func() {
+	var (
+		ctx      context.Context
+		server   *httptest.Server
+		mu       sync.Mutex
+		received []receivedRequest
+		failures atomic.Int32
+		status   int
+	)
+
+	requests := func() []receivedRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]receivedRequest(nil), received...)
+	}
+
+	newNotifier := func(targets ...Target) *Notifier {
+		n := NewNotifier(targets, "s3cr3t", "dev", time.Second, 3)
+		n.backoff = time.Millisecond
+		n.Start(ctx)
+		DeferCleanup(n.Stop)
+		return n
+	}
+
+	BeforeEach(func() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+
+		mu.Lock()
+		received = nil
+		mu.Unlock()
+		failures.Store(0)
+		status = http.StatusServiceUnavailable
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			received = append(received, receivedRequest{header: r.Header.Clone(), body: body})
+			mu.Unlock()
+			if failures.Add(-1) >= 0 {
+				w.WriteHeader(status)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	It("posts signed events", func() {
+		n := newNotifier(Target{URL: server.URL, Format: FormatWebhook})
+		n.Notify(DeploymentCompleted("arn:aws:ecs:us-east-1:000000000000:cluster/prod", "web",
+			"arn:aws:ecs:us-east-1:000000000000:service/prod/web", "ecs-svc/1"))
+
+		Eventually(requests).Should(HaveLen(1))
+		req := requests()[0]
+		Expect(req.header.Get(EventHeader)).To(Equal("deployment.completed"))
+		Expect(req.header.Get(SignatureHeader)).To(Equal(Sign("s3cr3t", req.body)))
+
+		var event Event
+		Expect(json.Unmarshal(req.body, &event)).To(Succeed())
+		Expect(event.ID).To(Equal(req.header.Get(DeliveryHeader)))
+		Expect(event.Instance).To(Equal("dev"))
+		Expect(event.Cluster).To(Equal("prod"))
+		Expect(event.Service).To(Equal("web"))
+		Expect(event.DeploymentID).To(Equal("ecs-svc/1"))
+	})
+
+	It("formats events for Slack and signs them with the secret of the target", func() {
+		n := newNotifier(Target{URL: server.URL, Format: FormatSlack, Secret: "other"})
+		n.Notify(DeploymentFailed("prod", "web", "", "ecs-svc/2", "tasks failed to start"))
+
+		Eventually(requests).Should(HaveLen(1))
+		req := requests()[0]
+		Expect(req.header.Get(SignatureHeader)).To(Equal(Sign("other", req.body)))
+
+		var message map[string]string
+		Expect(json.Unmarshal(req.body, &message)).To(Succeed())
+		Expect(message).To(HaveKeyWithValue("text", ContainSubstring(":x: *deployment.failed* Deployment ecs-svc/2 of service web failed")))
+		Expect(message["text"]).To(ContainSubstring("cluster `prod`"))
+	})
+
+	It("retries failed deliveries with the same delivery ID", func() {
+		failures.Store(2)
+		n := newNotifier(Target{URL: server.URL})
+		n.Notify(InstanceStarted())
+
+		Eventually(requests).Should(HaveLen(3))
+		Consistently(requests, 50*time.Millisecond).Should(HaveLen(3))
+		ids := map[string]bool{}
+		for _, req := range requests() {
+			ids[req.header.Get(DeliveryHeader)] = true
+		}
+		Expect(ids).To(HaveLen(1))
+	})
+
+	It("gives up after the retries", func() {
+		failures.Store(10)
+		n := newNotifier(Target{URL: server.URL})
+		n.Notify(InstanceStarted())
+
+		Eventually(requests).Should(HaveLen(4))
+		Consistently(requests, 50*time.Millisecond).Should(HaveLen(4))
+	})
+
+	It("does not retry rejected deliveries", func() {
+		failures.Store(1)
+		status = http.StatusBadRequest
+		n := newNotifier(Target{URL: server.URL})
+		n.Notify(InstanceStarted())
+
+		Eventually(requests).Should(HaveLen(1))
+		Consistently(requests, 50*time.Millisecond).Should(HaveLen(1))
+	})
+
+	It("only posts events to the targets subscribed to them", func() {
+		n := newNotifier(
+			Target{URL: server.URL + "/prod", Clusters: []string{"prod"}},
+			Target{URL: server.URL + "/crashes", Events: []EventType{EventTaskCrashLoop}},
+		)
+		n.Notify(TaskCrashLoop("staging", "web", "arn:aws:ecs:us-east-1:000000000000:task/staging/abc", "container app restarted 3 times"))
+
+		Eventually(requests).Should(HaveLen(1))
+		Consistently(requests, 50*time.Millisecond).Should(HaveLen(1))
+		Expect(requests()[0].header.Get(EventHeader)).To(Equal("task.crashloop"))
+	})
+
+	It("delivers the queued events when stopped", func() {
+		n := NewNotifier([]Target{{URL: server.URL}}, "", "dev", time.Second, 3)
+		n.Start(ctx)
+		n.Notify(InstanceStopping())
+		n.Stop()
+
+		Expect(requests()).To(HaveLen(1))
+		Expect(requests()[0].header.Get(SignatureHeader)).To(BeEmpty())
+	})
+
+	It("drops events when nil", func() {
+		var n *Notifier
+		n.Start(ctx)
+		n.Notify(InstanceStarted())
+		n.Stop()
+	})
+})
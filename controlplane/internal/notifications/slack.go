@@ -0,0 +1,32 @@
+package notifications
+
+import (
+	"fmt"
+	"strings"
+)
+
+// slackEmoji marks the outcome of an event in Slack messages
+var slackEmoji = map[EventType]string{
+	EventDeploymentCompleted: ":white_check_mark:",
+	EventDeploymentFailed:    ":x:",
+	EventTaskCrashLoop:       ":warning:",
+	EventInstanceStarted:     ":rocket:",
+	EventInstanceStopping:    ":octagonal_sign:",
+}
+
+// slackMessage formats an event for a Slack incoming webhook
+func slackMessage(event Event) map[string]string {
+	var context []string
+	if event.Instance != "" {
+		context = append(context, "instance `"+event.Instance+"`")
+	}
+	if event.Cluster != "" {
+		context = append(context, "cluster `"+event.Cluster+"`")
+	}
+
+	text := fmt.Sprintf("%s *%s* %s", slackEmoji[event.Type], event.Type, event.Message)
+	if len(context) > 0 {
+		text += "\n" + strings.Join(context, ", ")
+	}
+	return map[string]string{"text": text}
+}
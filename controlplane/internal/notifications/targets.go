@@ -0,0 +1,92 @@
+package notifications
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Format is the payload format of a target
+type Format string
+
+const (
+	// FormatWebhook posts the event as JSON
+	FormatWebhook Format = "webhook"
+	// FormatSlack posts a message for a Slack incoming webhook
+	FormatSlack Format = "slack"
+)
+
+// Target is an endpoint notifications are posted to
+type Target struct {
+	URL    string
+	Format Format
+	// Clusters restricts the target to the events of these clusters. Instance
+	// lifecycle events are only sent to targets without clusters.
+	Clusters []string
+	// Events restricts the target to these event types, all when empty
+	Events []EventType
+	// Secret signs the payloads, the notifier secret when empty
+	Secret string
+}
+
+// ParseTargets parses targets separated by semicolons or newlines. A target is
+// a URL followed by space separated options:
+//
+//	https://ci.example.com/kecs events=deployment.completed,deployment.failed
+//	https://hooks.slack.com/services/T0/B0/X format=slack clusters=prod,staging
+//
+// The options are format (webhook or slack), clusters, events and secret.
+func ParseTargets(s string) ([]Target, error) {
+	var targets []Target
+	for _, entry := range strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == '\n' }) {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		}
+		target := Target{URL: fields[0], Format: FormatWebhook}
+		if u, err := url.Parse(target.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid target %q: URL must be http or https", target.URL)
+		}
+
+		for _, option := range fields[1:] {
+			name, value, ok := strings.Cut(option, "=")
+			if !ok || value == "" {
+				return nil, fmt.Errorf("invalid option %q of target %s: expected NAME=VALUE", option, target.URL)
+			}
+			switch name {
+			case "format":
+				target.Format = Format(value)
+				if target.Format != FormatWebhook && target.Format != FormatSlack {
+					return nil, fmt.Errorf("invalid format %q of target %s: must be webhook or slack", value, target.URL)
+				}
+			case "clusters":
+				target.Clusters = strings.Split(value, ",")
+			case "events":
+				for _, name := range strings.Split(value, ",") {
+					if !slices.Contains(EventTypes, EventType(name)) {
+						return nil, fmt.Errorf("invalid event %q of target %s", name, target.URL)
+					}
+					target.Events = append(target.Events, EventType(name))
+				}
+			case "secret":
+				target.Secret = value
+			default:
+				return nil, fmt.Errorf("unknown option %q of target %s", name, target.URL)
+			}
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// Matches reports whether the target receives an event
+func (t Target) Matches(event Event) bool {
+	if len(t.Events) > 0 && !slices.Contains(t.Events, event.Type) {
+		return false
+	}
+	if len(t.Clusters) == 0 {
+		return true
+	}
+	return event.Cluster != "" && slices.Contains(t.Clusters, event.Cluster)
+}
@@ -0,0 +1,62 @@
+package notifications
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseTargets", func() {
+	It("parses targets with options", func() {
+		targets, err := ParseTargets("https://ci.example.com/kecs events=deployment.completed,deployment.failed;\n" +
+			"https://hooks.slack.com/services/T0/B0/X format=slack clusters=prod,staging secret=s3cr3t")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(targets).To(Equal([]Target{
+			{
+				URL:    "https://ci.example.com/kecs",
+				Format: FormatWebhook,
+				Events: []EventType{EventDeploymentCompleted, EventDeploymentFailed},
+			},
+			{
+				URL:      "https://hooks.slack.com/services/T0/B0/X",
+				Format:   FormatSlack,
+				Clusters: []string{"prod", "staging"},
+				Secret:   "s3cr3t",
+			},
+		}))
+	})
+
+	It("returns no targets for an empty string", func() {
+		Expect(ParseTargets(" ; ")).To(BeEmpty())
+	})
+
+	DescribeTable("rejects invalid targets",
+		func(s, message string) {
+			_, err := ParseTargets(s)
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("relative URL", "/hooks", "URL must be http or https"),
+		Entry("unsupported scheme", "ftp://example.com/hooks", "URL must be http or https"),
+		Entry("unknown format", "https://example.com format=teams", `invalid format "teams"`),
+		Entry("unknown event", "https://example.com events=task.started", `invalid event "task.started"`),
+		Entry("unknown option", "https://example.com retries=5", `unknown option "retries"`),
+		Entry("option without value", "https://example.com clusters", "expected NAME=VALUE"),
+	)
+})
+
+var _ = Describe("Target", func() {
+	completed := DeploymentCompleted("arn:aws:ecs:us-east-1:000000000000:cluster/prod", "web",
+		"arn:aws:ecs:us-east-1:000000000000:service/prod/web", "ecs-svc/1")
+
+	DescribeTable("matches events",
+		func(target Target, event Event, matches bool) {
+			Expect(target.Matches(event)).To(Equal(matches))
+		},
+		Entry("without restrictions", Target{}, completed, true),
+		Entry("of a subscribed cluster", Target{Clusters: []string{"prod"}}, completed, true),
+		Entry("of another cluster", Target{Clusters: []string{"staging"}}, completed, false),
+		Entry("of a subscribed type", Target{Events: []EventType{EventDeploymentCompleted}}, completed, true),
+		Entry("of another type", Target{Events: []EventType{EventDeploymentFailed}}, completed, false),
+		Entry("of the instance without restrictions", Target{}, InstanceStarted(), true),
+		Entry("of the instance with clusters", Target{Clusters: []string{"prod"}}, InstanceStarted(), false),
+	)
+})
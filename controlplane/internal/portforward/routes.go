@@ -0,0 +1,279 @@
+package portforward
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// RouteMethod is how a local port reaches a service
+type RouteMethod string
+
+const (
+	// RouteMethodNodePort maps the local port to the NodePort of the service
+	// on the k3d load balancer
+	RouteMethodNodePort RouteMethod = "nodePort"
+	// RouteMethodPortForward runs kubectl port-forward to the service
+	RouteMethodPortForward RouteMethod = "portForward"
+)
+
+const (
+	// routePortBase and routePortRange bound the local ports assigned to
+	// routes, clear of the ports picked for plain port forwards
+	routePortBase  = 40000
+	routePortRange = 10000
+)
+
+// Route maps a local port to a published port of a service. Routes are kept
+// in the routing table of the instance, so a service gets the same local
+// port every time it is mapped.
+type Route struct {
+	Cluster       string      `json:"cluster"`
+	Service       string      `json:"service"`
+	ContainerPort int         `json:"containerPort"`
+	LocalPort     int         `json:"localPort"`
+	Method        RouteMethod `json:"method"`
+	NodePort      int         `json:"nodePort,omitempty"`
+	ForwardID     string      `json:"forwardId,omitempty"`
+	UpdatedAt     time.Time   `json:"updatedAt"`
+}
+
+// Key identifies the route of a service port
+func (r *Route) Key() string {
+	return fmt.Sprintf("%s/%s:%d", r.Cluster, r.Service, r.ContainerPort)
+}
+
+// RoutesFile returns the path of the routing table of the instance
+func (m *Manager) RoutesFile() string {
+	return filepath.Join(m.stateDir, "routes.json")
+}
+
+// ListRoutes returns the routing table of the instance ordered by cluster,
+// service and port
+func (m *Manager) ListRoutes() ([]*Route, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.loadRoutes()
+}
+
+// MapService makes a published port of a service reachable on localhost. The
+// port is the service port or container port to map, 0 for the first one.
+// The local port of an earlier mapping of the same port is reused; otherwise
+// localPort is used when set, or a free port derived from the service name.
+// Services with a NodePort are mapped through the k3d load balancer, others
+// through kubectl port-forward.
+func (m *Manager) MapService(ctx context.Context, cluster, serviceName string, port, localPort int) (*Route, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	namespace := clusterNamespace(cluster)
+	service, err := m.k8sClient.Clientset.CoreV1().Services(namespace).Get(ctx, serviceName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service: %w", err)
+	}
+	servicePort, err := findServicePort(service, port)
+	if err != nil {
+		return nil, err
+	}
+
+	routes, err := m.loadRoutes()
+	if err != nil {
+		return nil, err
+	}
+	route := &Route{
+		Cluster:       cluster,
+		Service:       serviceName,
+		ContainerPort: servicePort.TargetPort.IntValue(),
+	}
+	if route.ContainerPort == 0 {
+		route.ContainerPort = int(servicePort.Port)
+	}
+
+	var existing *Route
+	for _, r := range routes {
+		if r.Key() == route.Key() {
+			existing = r
+		} else if localPort != 0 && r.LocalPort == localPort {
+			return nil, fmt.Errorf("local port %d is already used by %s", localPort, r.Key())
+		}
+	}
+	switch {
+	case existing != nil && (localPort == 0 || localPort == existing.LocalPort):
+		route.LocalPort = existing.LocalPort
+	case localPort != 0:
+		route.LocalPort = localPort
+	default:
+		route.LocalPort = stableRoutePort(route.Key(), routes)
+	}
+
+	if servicePort.NodePort != 0 {
+		route.Method = RouteMethodNodePort
+		route.NodePort = int(servicePort.NodePort)
+		if err := m.mapPortWithK3d(ctx, route.LocalPort, route.NodePort); err != nil {
+			return nil, fmt.Errorf("failed to map port with k3d: %w", err)
+		}
+	} else {
+		route.Method = RouteMethodPortForward
+		if existing != nil && existing.Method == RouteMethodPortForward &&
+			existing.LocalPort == route.LocalPort && portReachable(route.LocalPort) {
+			// The port-forward of the earlier mapping is still running
+			route.ForwardID = existing.ForwardID
+		} else if route.ForwardID, err = m.startRouteForward(ctx, namespace, route, int(servicePort.Port)); err != nil {
+			return nil, err
+		}
+	}
+
+	route.UpdatedAt = time.Now()
+	if existing != nil {
+		*existing = *route
+	} else {
+		routes = append(routes, route)
+	}
+	if err := m.saveRoutes(routes); err != nil {
+		return nil, fmt.Errorf("failed to save routing table: %w", err)
+	}
+
+	logging.Info("Mapped service port",
+		"service", route.Key(),
+		"localPort", route.LocalPort,
+		"method", route.Method)
+	return route, nil
+}
+
+// startRouteForward starts a kubectl port-forward for a route and tracks it
+// like the forwards of port-forward start
+func (m *Manager) startRouteForward(ctx context.Context, namespace string, route *Route, servicePort int) (string, error) {
+	forward := &Forward{
+		ID:            fmt.Sprintf("route-%s-%s-%d", route.Cluster, route.Service, route.ContainerPort),
+		Type:          ForwardTypeService,
+		Cluster:       route.Cluster,
+		TargetName:    route.Service,
+		LocalPort:     route.LocalPort,
+		TargetPort:    servicePort,
+		Status:        StatusActive,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+		AutoReconnect: true,
+	}
+
+	forwarder, err := m.startKubectlPortForward(ctx, namespace, fmt.Sprintf("svc/%s", route.Service), route.LocalPort, servicePort, forward)
+	if err != nil {
+		return "", fmt.Errorf("failed to start port forwarding: %w", err)
+	}
+	m.forwarders[forward.ID] = forwarder
+	m.forwards[forward.ID] = forward
+	m.saveState()
+	return forward.ID, nil
+}
+
+// findServicePort returns the port of a service matching a service or
+// container port, or the first port when port is 0
+func findServicePort(service *corev1.Service, port int) (*corev1.ServicePort, error) {
+	if len(service.Spec.Ports) == 0 {
+		return nil, fmt.Errorf("service %s does not publish any ports", service.Name)
+	}
+	if port == 0 {
+		return &service.Spec.Ports[0], nil
+	}
+	for i, servicePort := range service.Spec.Ports {
+		if int(servicePort.Port) == port || servicePort.TargetPort.IntValue() == port {
+			return &service.Spec.Ports[i], nil
+		}
+	}
+	return nil, fmt.Errorf("service %s does not publish port %d", service.Name, port)
+}
+
+// stableRoutePort derives a local port from the route key, so a route gets
+// the same port across instances and restarts, moving on to the next port
+// when it is taken by another route or in use
+func stableRoutePort(key string, routes []*Route) int {
+	taken := make(map[int]bool, len(routes))
+	for _, r := range routes {
+		if r.Key() != key {
+			taken[r.LocalPort] = true
+		}
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	start := int(h.Sum32() % routePortRange)
+	for i := 0; i < routePortRange; i++ {
+		port := routePortBase + (start+i)%routePortRange
+		if taken[port] {
+			continue
+		}
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+		if err != nil {
+			continue
+		}
+		listener.Close()
+		return port
+	}
+	return 0
+}
+
+// portReachable reports whether something listens on a local port
+func portReachable(port int) bool {
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("localhost:%d", port), 500*time.Millisecond)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// clusterNamespace returns the namespace of the tasks and services of a cluster
+func clusterNamespace(cluster string) string {
+	region := config.GetConfig().AWS.DefaultRegion
+	if region == "" {
+		region = "us-east-1" // Fallback to default
+	}
+	return fmt.Sprintf("%s-%s", cluster, region)
+}
+
+// loadRoutes reads the routing table, sorted by cluster, service and port
+func (m *Manager) loadRoutes() ([]*Route, error) {
+	data, err := os.ReadFile(m.RoutesFile())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read routing table: %w", err)
+	}
+
+	var routes []*Route
+	if err := json.Unmarshal(data, &routes); err != nil {
+		return nil, fmt.Errorf("failed to parse routing table: %w", err)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Cluster != routes[j].Cluster {
+			return routes[i].Cluster < routes[j].Cluster
+		}
+		if routes[i].Service != routes[j].Service {
+			return routes[i].Service < routes[j].Service
+		}
+		return routes[i].ContainerPort < routes[j].ContainerPort
+	})
+	return routes, nil
+}
+
+// saveRoutes writes the routing table
+func (m *Manager) saveRoutes(routes []*Route) error {
+	data, err := json.MarshalIndent(routes, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.RoutesFile(), data, 0644)
+}
@@ -0,0 +1,113 @@
+package portforward_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/kubernetes"
+	"github.com/nandemo-ya/kecs/controlplane/internal/portforward"
+)
+
+var _ = Describe("Routes", func() {
+	var (
+		ctx        context.Context
+		manager    *portforward.Manager
+		fakeClient *fake.Clientset
+	)
+
+	createService := func(serviceType corev1.ServiceType, nodePort int32) {
+		service := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default-us-east-1"},
+			Spec: corev1.ServiceSpec{
+				Type: serviceType,
+				Ports: []corev1.ServicePort{
+					{Name: "http", Port: 80, TargetPort: intstr.FromInt(8080), NodePort: nodePort},
+				},
+			},
+		}
+		_, err := fakeClient.CoreV1().Services("default-us-east-1").Create(ctx, service, metav1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		GinkgoT().Setenv("HOME", GinkgoT().TempDir())
+
+		fakeClient = fake.NewSimpleClientset()
+		manager = portforward.NewManager("routes-test", &kubernetes.Client{Clientset: fakeClient})
+		DeferCleanup(manager.Stop)
+	})
+
+	It("should start with an empty routing table", func() {
+		routes, err := manager.ListRoutes()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(routes).To(BeEmpty())
+	})
+
+	It("should fail for unknown services", func() {
+		_, err := manager.MapService(ctx, "default", "missing", 0, 0)
+		Expect(err).To(MatchError(ContainSubstring("failed to get service")))
+	})
+
+	It("should fail for ports the service does not publish", func() {
+		createService(corev1.ServiceTypeClusterIP, 0)
+
+		_, err := manager.MapService(ctx, "default", "web", 9090, 0)
+		Expect(err).To(MatchError(ContainSubstring("does not publish port 9090")))
+	})
+
+	It("should reuse the local port of an earlier mapping", func() {
+		createService(corev1.ServiceTypeClusterIP, 0)
+
+		// Stands in for the port-forward of the earlier mapping
+		listener, err := net.Listen("tcp", "localhost:0")
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(listener.Close)
+		localPort := listener.Addr().(*net.TCPAddr).Port
+
+		existing := []*portforward.Route{{
+			Cluster:       "default",
+			Service:       "web",
+			ContainerPort: 8080,
+			LocalPort:     localPort,
+			Method:        portforward.RouteMethodPortForward,
+			ForwardID:     "route-default-web-8080",
+		}}
+		data, err := json.Marshal(existing)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(manager.RoutesFile(), data, 0644)).To(Succeed())
+
+		// The container port selects the same route as the service port
+		route, err := manager.MapService(ctx, "default", "web", 8080, 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(route.LocalPort).To(Equal(localPort))
+		Expect(route.ForwardID).To(Equal("route-default-web-8080"))
+
+		routes, err := manager.ListRoutes()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(routes).To(HaveLen(1))
+		Expect(routes[0].Key()).To(Equal("default/web:8080"))
+		Expect(routes[0].UpdatedAt).NotTo(BeZero())
+	})
+
+	It("should reject local ports used by other routes", func() {
+		createService(corev1.ServiceTypeClusterIP, 0)
+
+		existing := []*portforward.Route{{Cluster: "default", Service: "api", ContainerPort: 80, LocalPort: 41000}}
+		data, err := json.Marshal(existing)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(manager.RoutesFile(), data, 0644)).To(Succeed())
+
+		_, err = manager.MapService(ctx, "default", "web", 0, 41000)
+		Expect(err).To(MatchError(ContainSubstring("local port 41000 is already used by default/api:80")))
+	})
+})
@@ -6,6 +6,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/appconfig"
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
 )
 
@@ -85,6 +86,10 @@ func (evp *EnvironmentVariableProxy) InjectEnvironmentVariables(pod *corev1.Pod)
 		{Name: "AWS_ENDPOINT_URL_SSM", Value: endpoint},
 		{Name: "AWS_ENDPOINT_URL_SECRETSMANAGER", Value: endpoint},
 		{Name: "AWS_ENDPOINT_URL_ELB", Value: endpoint},
+		// AppConfig and STS are served by KECS itself
+		{Name: "AWS_ENDPOINT_URL_APPCONFIG", Value: appconfig.InClusterEndpoint},
+		{Name: "AWS_ENDPOINT_URL_APPCONFIGDATA", Value: appconfig.InClusterEndpoint},
+		{Name: "AWS_ENDPOINT_URL_STS", Value: appconfig.InClusterEndpoint},
 		{Name: "AWS_ACCESS_KEY_ID", Value: "test"},
 		{Name: "AWS_SECRET_ACCESS_KEY", Value: "test"},
 		{Name: "AWS_DEFAULT_REGION", Value: "us-east-1"},
@@ -7,6 +7,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/appconfig"
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
 )
 
@@ -92,6 +93,10 @@ func (sp *SidecarProxy) CreateProxySidecar(pod *corev1.Pod) *corev1.Container {
 				Name:  "LOCALSTACK_ENDPOINT",
 				Value: endpoint,
 			},
+			{
+				Name:  "KECS_ENDPOINT",
+				Value: appconfig.InClusterEndpoint,
+			},
 			{
 				Name:  "DEBUG",
 				Value: "false",
@@ -131,6 +136,9 @@ func (sp *SidecarProxy) CreateProxySidecar(pod *corev1.Pod) *corev1.Container {
 		},
 	}
 
+	// Pass the traffic shaping annotations on to the sidecar
+	sidecar.Env = append(sidecar.Env, sidecarSettingsEnv(pod.Annotations)...)
+
 	// Add debug mode if requested
 	if pod.Annotations != nil && pod.Annotations["kecs.io/aws-proxy-debug"] == "true" {
 		for i := range sidecar.Env {
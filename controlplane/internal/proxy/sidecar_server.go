@@ -0,0 +1,571 @@
+package proxy
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/appconfig"
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsrequest"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// Annotations shaping the traffic of the AWS proxy sidecar of a pod
+const (
+	// AnnotationCache enables caching of idempotent read operations
+	AnnotationCache = "kecs.io/aws-proxy-cache"
+	// AnnotationCacheTTL is how long cached responses are served, e.g. "30s"
+	AnnotationCacheTTL = "kecs.io/aws-proxy-cache-ttl"
+	// AnnotationMaxRequestBytes limits the size of request bodies
+	AnnotationMaxRequestBytes = "kecs.io/aws-proxy-max-request-bytes"
+	// AnnotationMaxResponseBytes limits the size of response bodies
+	AnnotationMaxResponseBytes = "kecs.io/aws-proxy-max-response-bytes"
+	// AnnotationAllowServices is a comma separated list of the only services the pod may call
+	AnnotationAllowServices = "kecs.io/aws-proxy-allow-services"
+	// AnnotationDenyServices is a comma separated list of services the pod may not call
+	AnnotationDenyServices = "kecs.io/aws-proxy-deny-services"
+)
+
+const (
+	// defaultCacheTTL is how long cached responses are served when no TTL is set
+	defaultCacheTTL = 30 * time.Second
+	// maxCacheEntries bounds the number of cached responses
+	maxCacheEntries = 1024
+	// maxCacheEntryBytes is the size of the largest response that is cached
+	maxCacheEntryBytes = 1 << 20
+
+	// cacheStatusHeader reports whether a response was served from the cache
+	cacheStatusHeader = "X-Kecs-Proxy-Cache"
+)
+
+// sidecarSetting maps a pod annotation to the environment variable the sidecar reads
+type sidecarSetting struct {
+	annotation string
+	env        string
+	validate   func(string) error
+}
+
+var sidecarSettings = []sidecarSetting{
+	{annotation: AnnotationCache, env: "AWS_PROXY_CACHE_ENABLED", validate: validateBool},
+	{annotation: AnnotationCacheTTL, env: "AWS_PROXY_CACHE_TTL", validate: validateDuration},
+	{annotation: AnnotationMaxRequestBytes, env: "AWS_PROXY_MAX_REQUEST_BYTES", validate: validateSize},
+	{annotation: AnnotationMaxResponseBytes, env: "AWS_PROXY_MAX_RESPONSE_BYTES", validate: validateSize},
+	{annotation: AnnotationAllowServices, env: "AWS_PROXY_ALLOW_SERVICES", validate: validateServiceList},
+	{annotation: AnnotationDenyServices, env: "AWS_PROXY_DENY_SERVICES", validate: validateServiceList},
+}
+
+// cacheableOperations lists the idempotent read operations of JSON protocol
+// services that are cached. S3 object GETs are cached when they carry an ETag.
+var cacheableOperations = map[string][]string{
+	"ssm":            {"GetParameter", "GetParameters", "GetParametersByPath"},
+	"secretsmanager": {"GetSecretValue"},
+}
+
+// readOnlyPrefixes are the operation prefixes that do not invalidate the cache
+var readOnlyPrefixes = []string{"Get", "List", "Describe", "Head", "BatchGet"}
+
+// kecsServices are the services proxied to KECS rather than LocalStack
+var kecsServices = []string{appconfig.ServiceAppConfig, appconfig.ServiceAppConfigData, awsrequest.ServiceSTS}
+
+// hopHeaders are not forwarded by the sidecar
+var hopHeaders = []string{
+	"Connection", "Proxy-Connection", "Keep-Alive", "Proxy-Authenticate",
+	"Proxy-Authorization", "Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+// SidecarConfig is the configuration of the AWS proxy sidecar
+type SidecarConfig struct {
+	LocalStackEndpoint string
+	// KECSEndpoint serves the AWS services KECS implements itself, which
+	// are proxied to LocalStack too when it is not set
+	KECSEndpoint string
+
+	CacheEnabled bool
+	CacheTTL     time.Duration
+	// MaxRequestBytes and MaxResponseBytes are disabled when 0
+	MaxRequestBytes  int64
+	MaxResponseBytes int64
+	// AllowServices, when set, is the only services requests are proxied for
+	AllowServices []string
+	DenyServices  []string
+}
+
+// SidecarConfigFromEnv reads the sidecar configuration from the environment
+// variables set by CreateProxySidecar
+func SidecarConfigFromEnv(getenv func(string) string) (*SidecarConfig, error) {
+	cfg := &SidecarConfig{
+		LocalStackEndpoint: getenv("LOCALSTACK_ENDPOINT"),
+		KECSEndpoint:       getenv("KECS_ENDPOINT"),
+		CacheTTL:           defaultCacheTTL,
+	}
+	if cfg.LocalStackEndpoint == "" {
+		return nil, fmt.Errorf("LOCALSTACK_ENDPOINT is not set")
+	}
+
+	for _, setting := range sidecarSettings {
+		value := strings.TrimSpace(getenv(setting.env))
+		if value == "" {
+			continue
+		}
+		if err := setting.validate(value); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", setting.env, err)
+		}
+		switch setting.annotation {
+		case AnnotationCache:
+			cfg.CacheEnabled, _ = strconv.ParseBool(value)
+		case AnnotationCacheTTL:
+			cfg.CacheTTL, _ = time.ParseDuration(value)
+		case AnnotationMaxRequestBytes:
+			cfg.MaxRequestBytes, _ = strconv.ParseInt(value, 10, 64)
+		case AnnotationMaxResponseBytes:
+			cfg.MaxResponseBytes, _ = strconv.ParseInt(value, 10, 64)
+		case AnnotationAllowServices:
+			cfg.AllowServices = parseServiceList(value)
+		case AnnotationDenyServices:
+			cfg.DenyServices = parseServiceList(value)
+		}
+	}
+
+	return cfg, nil
+}
+
+// sidecarSettingsEnv returns the environment variables for the traffic
+// shaping annotations of a pod. Invalid annotations are logged and ignored.
+func sidecarSettingsEnv(annotations map[string]string) []corev1.EnvVar {
+	var env []corev1.EnvVar
+	for _, setting := range sidecarSettings {
+		value, ok := annotations[setting.annotation]
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if err := setting.validate(value); err != nil {
+			logging.Warn("Ignoring invalid AWS proxy annotation", "annotation", setting.annotation, "value", value, "error", err)
+			continue
+		}
+		env = append(env, corev1.EnvVar{Name: setting.env, Value: value})
+	}
+	return env
+}
+
+func validateBool(value string) error {
+	_, err := strconv.ParseBool(value)
+	return err
+}
+
+func validateDuration(value string) error {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	if d <= 0 {
+		return fmt.Errorf("must be positive")
+	}
+	return nil
+}
+
+func validateSize(value string) error {
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return err
+	}
+	if size < 0 {
+		return fmt.Errorf("must not be negative")
+	}
+	return nil
+}
+
+func validateServiceList(value string) error {
+	if len(parseServiceList(value)) == 0 {
+		return fmt.Errorf("no services listed")
+	}
+	return nil
+}
+
+// parseServiceList parses a comma separated list of service signing names
+func parseServiceList(value string) []string {
+	var services []string
+	for _, service := range strings.Split(value, ",") {
+		if service = strings.ToLower(strings.TrimSpace(service)); service != "" {
+			services = append(services, service)
+		}
+	}
+	return services
+}
+
+// AWSProxySidecar forwards the AWS SDK calls of a task to LocalStack. It
+// caches idempotent reads, enforces size limits and filters services.
+type AWSProxySidecar struct {
+	config *SidecarConfig
+	target string
+	client *http.Client
+	// kecsTarget is where kecsServices are proxied to, "" for LocalStack
+	kecsTarget string
+
+	mu    sync.Mutex
+	cache map[string]*cacheEntry
+}
+
+// cacheEntry is a cached LocalStack response
+type cacheEntry struct {
+	service string
+	status  int
+	header  http.Header
+	body    []byte
+	etag    string
+	expires time.Time
+}
+
+// NewAWSProxySidecar creates the AWS proxy sidecar
+func NewAWSProxySidecar(config *SidecarConfig) (*AWSProxySidecar, error) {
+	target, err := url.Parse(config.LocalStackEndpoint)
+	if err != nil || target.Scheme == "" || target.Host == "" {
+		return nil, fmt.Errorf("invalid LocalStack endpoint %q", config.LocalStackEndpoint)
+	}
+	var kecsTarget string
+	if config.KECSEndpoint != "" {
+		kecs, err := url.Parse(config.KECSEndpoint)
+		if err != nil || kecs.Scheme == "" || kecs.Host == "" {
+			return nil, fmt.Errorf("invalid KECS endpoint %q", config.KECSEndpoint)
+		}
+		kecsTarget = strings.TrimSuffix(kecs.String(), "/")
+	}
+
+	return &AWSProxySidecar{
+		config:     config,
+		target:     strings.TrimSuffix(target.String(), "/"),
+		kecsTarget: kecsTarget,
+		client: &http.Client{
+			Timeout: 60 * time.Second,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		cache: make(map[string]*cacheEntry),
+	}, nil
+}
+
+// ServeHTTP proxies an AWS SDK request to LocalStack
+func (p *AWSProxySidecar) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/health" && r.Header.Get("Authorization") == "" {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "ok")
+		return
+	}
+
+	service := requestService(r)
+	if !p.serviceAllowed(service) {
+		writeSidecarError(w, http.StatusForbidden, "AccessDeniedException",
+			fmt.Sprintf("service %s is not allowed by the AWS proxy of this task", service))
+		return
+	}
+
+	if limit := p.config.MaxRequestBytes; limit > 0 {
+		if r.ContentLength > limit {
+			writeRequestTooLarge(w, limit)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeRequestTooLarge(w, maxErr.Limit)
+			return
+		}
+		writeSidecarError(w, http.StatusBadRequest, "InvalidRequestException", "failed to read request body")
+		return
+	}
+
+	operation := requestOperation(r, body)
+	var key string
+	var stale *cacheEntry
+	if p.config.CacheEnabled {
+		if isCacheable(r, service, operation) {
+			key = cacheKey(r, service, body)
+			entry, fresh := p.lookup(key)
+			if fresh {
+				writeCacheEntry(w, entry, "HIT")
+				return
+			}
+			stale = entry
+		} else if !isReadOnly(r, operation) {
+			p.invalidate(service)
+		}
+	}
+
+	target := p.target
+	if p.kecsTarget != "" && slices.Contains(kecsServices, service) {
+		target = p.kecsTarget
+	}
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, target+r.URL.RequestURI(), bytes.NewReader(body))
+	if err != nil {
+		writeSidecarError(w, http.StatusBadGateway, "ServiceUnavailableException", "failed to create LocalStack request")
+		return
+	}
+	req.Header = r.Header.Clone()
+	for _, header := range hopHeaders {
+		req.Header.Del(header)
+	}
+	req.Host = r.Host
+	if stale != nil && stale.etag != "" {
+		req.Header.Set("If-None-Match", stale.etag)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		logging.Warn("Failed to proxy AWS request", "service", service, "operation", operation, "error", err)
+		writeSidecarError(w, http.StatusBadGateway, "ServiceUnavailableException", "failed to reach LocalStack")
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && stale != nil {
+		p.refresh(key, stale)
+		writeCacheEntry(w, stale, "REVALIDATED")
+		return
+	}
+
+	limit := p.config.MaxResponseBytes
+	if limit > 0 && resp.ContentLength > limit {
+		writeResponseTooLarge(w, limit)
+		return
+	}
+	reader := io.Reader(resp.Body)
+	if limit > 0 {
+		reader = io.LimitReader(resp.Body, limit+1)
+	}
+	respBody, err := io.ReadAll(reader)
+	if err != nil {
+		writeSidecarError(w, http.StatusBadGateway, "ServiceUnavailableException", "failed to read LocalStack response")
+		return
+	}
+	if limit > 0 && int64(len(respBody)) > limit {
+		writeResponseTooLarge(w, limit)
+		return
+	}
+
+	cacheStatus := ""
+	if key != "" {
+		cacheStatus = "MISS"
+		if resp.StatusCode == http.StatusOK && len(respBody) <= maxCacheEntryBytes &&
+			(service != "s3" || resp.Header.Get("ETag") != "") {
+			p.store(key, &cacheEntry{
+				service: service,
+				status:  resp.StatusCode,
+				header:  resp.Header.Clone(),
+				body:    respBody,
+				etag:    resp.Header.Get("ETag"),
+			})
+		}
+	}
+
+	for name, values := range resp.Header {
+		if slices.Contains(hopHeaders, http.CanonicalHeaderKey(name)) {
+			continue
+		}
+		w.Header()[name] = values
+	}
+	if cacheStatus != "" {
+		w.Header().Set(cacheStatusHeader, cacheStatus)
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(respBody)))
+	w.WriteHeader(resp.StatusCode)
+	w.Write(respBody)
+}
+
+// serviceAllowed checks a service against the allow and deny lists
+func (p *AWSProxySidecar) serviceAllowed(service string) bool {
+	if slices.Contains(p.config.DenyServices, service) {
+		return false
+	}
+	return len(p.config.AllowServices) == 0 || slices.Contains(p.config.AllowServices, service)
+}
+
+// lookup returns the cached response of a key and whether it is still fresh
+func (p *AWSProxySidecar) lookup(key string) (*cacheEntry, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry, ok := p.cache[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().Before(entry.expires) {
+		return entry, true
+	}
+	// Expired entries are kept only when they can be revalidated
+	if entry.etag == "" {
+		delete(p.cache, key)
+		return nil, false
+	}
+	return entry, false
+}
+
+// store caches a response, evicting the entry closest to expiry when full
+func (p *AWSProxySidecar) store(key string, entry *cacheEntry) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, exists := p.cache[key]; !exists && len(p.cache) >= maxCacheEntries {
+		var oldestKey string
+		var oldest time.Time
+		for k, e := range p.cache {
+			if oldestKey == "" || e.expires.Before(oldest) {
+				oldestKey, oldest = k, e.expires
+			}
+		}
+		delete(p.cache, oldestKey)
+	}
+	entry.expires = time.Now().Add(p.config.CacheTTL)
+	p.cache[key] = entry
+}
+
+// refresh extends a cached response LocalStack reported as not modified
+func (p *AWSProxySidecar) refresh(key string, entry *cacheEntry) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry.expires = time.Now().Add(p.config.CacheTTL)
+	p.cache[key] = entry
+}
+
+// invalidate drops the cached responses of a service after a write to it
+func (p *AWSProxySidecar) invalidate(service string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, entry := range p.cache {
+		if entry.service == service {
+			delete(p.cache, key)
+		}
+	}
+}
+
+// requestService returns the signing name of the service a request is for
+func requestService(r *http.Request) string {
+	// AWS4-HMAC-SHA256 Credential=AKID/YYYYMMDD/region/service/aws4_request
+	if auth := r.Header.Get("Authorization"); strings.Contains(auth, "aws4_request") {
+		parts := strings.Split(auth, "/")
+		if len(parts) >= 5 {
+			return strings.ToLower(parts[len(parts)-2])
+		}
+	}
+	if target := r.Header.Get("X-Amz-Target"); target != "" {
+		prefix, _, _ := strings.Cut(target, ".")
+		prefix, _, _ = strings.Cut(prefix, "_")
+		return strings.ToLower(prefix)
+	}
+	return "unknown"
+}
+
+// requestOperation returns the operation of a JSON or query protocol
+// request, "" for REST protocol requests
+func requestOperation(r *http.Request, body []byte) string {
+	if target := r.Header.Get("X-Amz-Target"); target != "" {
+		if idx := strings.LastIndex(target, "."); idx >= 0 {
+			return target[idx+1:]
+		}
+	}
+	if action := r.URL.Query().Get("Action"); action != "" {
+		return action
+	}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			return values.Get("Action")
+		}
+	}
+	return ""
+}
+
+// requestAccessKey returns the access key a request is signed with
+func requestAccessKey(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	_, credential, found := strings.Cut(auth, "Credential=")
+	if !found {
+		return ""
+	}
+	accessKey, _, _ := strings.Cut(credential, "/")
+	return accessKey
+}
+
+// isCacheable reports whether a response to the request may be cached.
+// Conditional and range requests are left to LocalStack.
+func isCacheable(r *http.Request, service, operation string) bool {
+	if r.Header.Get("If-None-Match") != "" || r.Header.Get("If-Modified-Since") != "" || r.Header.Get("Range") != "" {
+		return false
+	}
+	if service == "s3" {
+		return r.Method == http.MethodGet && operation == ""
+	}
+	return slices.Contains(cacheableOperations[service], operation)
+}
+
+// isReadOnly reports whether a request leaves the cached responses valid
+func isReadOnly(r *http.Request, operation string) bool {
+	if operation == "" {
+		return r.Method == http.MethodGet || r.Method == http.MethodHead
+	}
+	for _, prefix := range readOnlyPrefixes {
+		if strings.HasPrefix(operation, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheKey identifies a request by its service, caller, target and body.
+// Signatures are left out as they change with the request time.
+func cacheKey(r *http.Request, service string, body []byte) string {
+	sum := sha256.Sum256(body)
+	return strings.Join([]string{
+		service,
+		requestAccessKey(r),
+		r.Method,
+		r.Host,
+		r.URL.RequestURI(),
+		r.Header.Get("X-Amz-Target"),
+		hex.EncodeToString(sum[:]),
+	}, "|")
+}
+
+// writeCacheEntry writes a cached response
+func writeCacheEntry(w http.ResponseWriter, entry *cacheEntry, cacheStatus string) {
+	for name, values := range entry.header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(cacheStatusHeader, cacheStatus)
+	w.Header().Set("Content-Length", strconv.Itoa(len(entry.body)))
+	w.WriteHeader(entry.status)
+	w.Write(entry.body)
+}
+
+func writeRequestTooLarge(w http.ResponseWriter, limit int64) {
+	writeSidecarError(w, http.StatusRequestEntityTooLarge, "RequestEntityTooLargeException",
+		fmt.Sprintf("request body exceeds the limit of %d bytes of the AWS proxy", limit))
+}
+
+func writeResponseTooLarge(w http.ResponseWriter, limit int64) {
+	writeSidecarError(w, http.StatusBadGateway, "ResponseTooLargeException",
+		fmt.Sprintf("LocalStack response exceeds the limit of %d bytes of the AWS proxy", limit))
+}
+
+// writeSidecarError writes an error in the AWS JSON error shape
+func writeSidecarError(w http.ResponseWriter, status int, errType, message string) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	w.Header().Set("X-Amzn-ErrorType", errType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"__type": errType, "message": message})
+}
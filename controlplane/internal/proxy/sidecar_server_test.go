@@ -0,0 +1,268 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newTestSidecar starts a fake LocalStack and a sidecar forwarding to it
+func newTestSidecar(t *testing.T, cfg *SidecarConfig, upstream http.HandlerFunc) *AWSProxySidecar {
+	t.Helper()
+	server := httptest.NewServer(upstream)
+	t.Cleanup(server.Close)
+
+	cfg.LocalStackEndpoint = server.URL
+	sidecar, err := NewAWSProxySidecar(cfg)
+	if err != nil {
+		t.Fatalf("NewAWSProxySidecar() error = %v", err)
+	}
+	return sidecar
+}
+
+func awsRequest(method, service, target, body string) *http.Request {
+	req := httptest.NewRequest(method, "http://localhost:4566/", strings.NewReader(body))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=AKIDTEST/20240101/us-east-1/%s/aws4_request, Signature=%d", service, time.Now().UnixNano()))
+	if target != "" {
+		req.Header.Set("X-Amz-Target", target)
+	}
+	return req
+}
+
+func TestAWSProxySidecar_CachesReadOperations(t *testing.T) {
+	var calls atomic.Int32
+	sidecar := newTestSidecar(t, &SidecarConfig{CacheEnabled: true, CacheTTL: time.Minute}, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		fmt.Fprintf(w, `{"Parameter": {"Value": "v%d"}}`, calls.Load())
+	})
+
+	get := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		sidecar.ServeHTTP(rec, awsRequest(http.MethodPost, "ssm", "AmazonSSM.GetParameter", `{"Name": "/app/db"}`))
+		return rec
+	}
+
+	first := get()
+	if got := first.Header().Get(cacheStatusHeader); got != "MISS" {
+		t.Errorf("first cache status = %q, want MISS", got)
+	}
+	second := get()
+	if got := second.Header().Get(cacheStatusHeader); got != "HIT" {
+		t.Errorf("second cache status = %q, want HIT", got)
+	}
+	if second.Body.String() != first.Body.String() || calls.Load() != 1 {
+		t.Errorf("cached response = %q after %d calls, want %q after 1 call", second.Body.String(), calls.Load(), first.Body.String())
+	}
+
+	// Writes to the service invalidate the cache
+	rec := httptest.NewRecorder()
+	sidecar.ServeHTTP(rec, awsRequest(http.MethodPost, "ssm", "AmazonSSM.PutParameter", `{"Name": "/app/db"}`))
+	if got := get().Header().Get(cacheStatusHeader); got != "MISS" {
+		t.Errorf("cache status after PutParameter = %q, want MISS", got)
+	}
+
+	// Operations that are not cacheable are always forwarded
+	calls.Store(0)
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		sidecar.ServeHTTP(rec, awsRequest(http.MethodPost, "ssm", "AmazonSSM.DescribeParameters", `{}`))
+		if got := rec.Header().Get(cacheStatusHeader); got != "" {
+			t.Errorf("DescribeParameters cache status = %q, want none", got)
+		}
+	}
+	if calls.Load() != 2 {
+		t.Errorf("DescribeParameters reached LocalStack %d times, want 2", calls.Load())
+	}
+}
+
+func TestAWSProxySidecar_RevalidatesS3Objects(t *testing.T) {
+	var calls, notModified atomic.Int32
+	sidecar := newTestSidecar(t, &SidecarConfig{CacheEnabled: true, CacheTTL: time.Millisecond}, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Header.Get("If-None-Match") == `"abc"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"abc"`)
+		fmt.Fprint(w, "object")
+	})
+
+	get := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := awsRequest(http.MethodGet, "s3", "", "")
+		req.URL.Path = "/bucket/key"
+		sidecar.ServeHTTP(rec, req)
+		return rec
+	}
+
+	get()
+	time.Sleep(5 * time.Millisecond)
+	rec := get()
+	if got := rec.Header().Get(cacheStatusHeader); got != "REVALIDATED" {
+		t.Errorf("cache status = %q, want REVALIDATED", got)
+	}
+	if rec.Code != http.StatusOK || rec.Body.String() != "object" {
+		t.Errorf("revalidated response = %d %q, want 200 \"object\"", rec.Code, rec.Body.String())
+	}
+	if calls.Load() != 2 || notModified.Load() != 1 {
+		t.Errorf("LocalStack calls = %d (%d not modified), want 2 (1 not modified)", calls.Load(), notModified.Load())
+	}
+}
+
+func TestAWSProxySidecar_ShapesRequests(t *testing.T) {
+	sidecar := newTestSidecar(t, &SidecarConfig{
+		MaxRequestBytes:  16,
+		MaxResponseBytes: 8,
+		AllowServices:    []string{"ssm", "s3"},
+		DenyServices:     []string{"s3"},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") == "AmazonSSM.GetParametersByPath" {
+			fmt.Fprint(w, strings.Repeat("x", 64))
+			return
+		}
+		fmt.Fprint(w, "{}")
+	})
+
+	tests := []struct {
+		name       string
+		req        *http.Request
+		wantStatus int
+		wantType   string
+	}{
+		{
+			name:       "allowed service",
+			req:        awsRequest(http.MethodPost, "ssm", "AmazonSSM.GetParameter", `{}`),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "service not in the allow list",
+			req:        awsRequest(http.MethodPost, "sqs", "AmazonSQS.SendMessage", `{}`),
+			wantStatus: http.StatusForbidden,
+			wantType:   "AccessDeniedException",
+		},
+		{
+			name:       "denied service",
+			req:        awsRequest(http.MethodGet, "s3", "", ""),
+			wantStatus: http.StatusForbidden,
+			wantType:   "AccessDeniedException",
+		},
+		{
+			name:       "request too large",
+			req:        awsRequest(http.MethodPost, "ssm", "AmazonSSM.PutParameter", strings.Repeat("x", 32)),
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantType:   "RequestEntityTooLargeException",
+		},
+		{
+			name:       "response too large",
+			req:        awsRequest(http.MethodPost, "ssm", "AmazonSSM.GetParametersByPath", `{}`),
+			wantStatus: http.StatusBadGateway,
+			wantType:   "ResponseTooLargeException",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			sidecar.ServeHTTP(rec, tt.req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("X-Amzn-ErrorType"); got != tt.wantType {
+				t.Errorf("error type = %q, want %q", got, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestAWSProxySidecar_RoutesAppConfigToKECS(t *testing.T) {
+	kecs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "kecs %s", r.URL.Path)
+	}))
+	t.Cleanup(kecs.Close)
+	sidecar := newTestSidecar(t, &SidecarConfig{KECSEndpoint: kecs.URL}, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "localstack")
+	})
+
+	tests := []struct {
+		service string
+		want    string
+	}{
+		{service: "appconfigdata", want: "kecs /"},
+		{service: "appconfig", want: "kecs /"},
+		{service: "ssm", want: "localstack"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		sidecar.ServeHTTP(rec, awsRequest(http.MethodGet, tt.service, "", ""))
+		if got := rec.Body.String(); got != tt.want {
+			t.Errorf("%s request served by %q, want %q", tt.service, got, tt.want)
+		}
+	}
+}
+
+func TestSidecarConfigFromEnv(t *testing.T) {
+	env := map[string]string{
+		"LOCALSTACK_ENDPOINT":          "http://localstack:4566",
+		"AWS_PROXY_CACHE_ENABLED":      "true",
+		"AWS_PROXY_CACHE_TTL":          "1m",
+		"AWS_PROXY_MAX_REQUEST_BYTES":  "1024",
+		"AWS_PROXY_ALLOW_SERVICES":     "SSM, secretsmanager",
+		"AWS_PROXY_MAX_RESPONSE_BYTES": "",
+	}
+	cfg, err := SidecarConfigFromEnv(func(key string) string { return env[key] })
+	if err != nil {
+		t.Fatalf("SidecarConfigFromEnv() error = %v", err)
+	}
+	if !cfg.CacheEnabled || cfg.CacheTTL != time.Minute || cfg.MaxRequestBytes != 1024 || cfg.MaxResponseBytes != 0 {
+		t.Errorf("SidecarConfigFromEnv() = %+v", cfg)
+	}
+	if strings.Join(cfg.AllowServices, ",") != "ssm,secretsmanager" {
+		t.Errorf("AllowServices = %v, want [ssm secretsmanager]", cfg.AllowServices)
+	}
+
+	env["AWS_PROXY_CACHE_TTL"] = "soon"
+	if _, err := SidecarConfigFromEnv(func(key string) string { return env[key] }); err == nil {
+		t.Error("SidecarConfigFromEnv() with an invalid TTL should fail")
+	}
+}
+
+func TestSidecarProxy_CreateProxySidecar_TrafficAnnotations(t *testing.T) {
+	sp := NewSidecarProxy("http://localstack:4566")
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				AnnotationCache:            "true",
+				AnnotationCacheTTL:         "never",
+				AnnotationDenyServices:     "iam",
+				AnnotationMaxResponseBytes: "1048576",
+			},
+		},
+	}
+
+	env := make(map[string]string)
+	for _, e := range sp.CreateProxySidecar(pod).Env {
+		env[e.Name] = e.Value
+	}
+
+	expected := map[string]string{
+		"AWS_PROXY_CACHE_ENABLED":      "true",
+		"AWS_PROXY_DENY_SERVICES":      "iam",
+		"AWS_PROXY_MAX_RESPONSE_BYTES": "1048576",
+	}
+	for name, value := range expected {
+		if env[name] != value {
+			t.Errorf("%s = %q, want %q", name, env[name], value)
+		}
+	}
+	if _, ok := env["AWS_PROXY_CACHE_TTL"]; ok {
+		t.Error("invalid cache TTL annotation should not be passed to the sidecar")
+	}
+}
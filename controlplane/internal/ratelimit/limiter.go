@@ -0,0 +1,229 @@
+// Package ratelimit throttles AWS API calls per operation and bounds the
+// number of concurrent calls that apply changes to Kubernetes, so load tests
+// cannot destabilize the control plane.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsrequest"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// kubernetesOperations are the ECS operations that create, update or delete
+// Kubernetes resources while the request is being served
+var kubernetesOperations = map[string]bool{
+	"CreateCluster":               true,
+	"DeleteCluster":               true,
+	"RunTask":                     true,
+	"StartTask":                   true,
+	"StopTask":                    true,
+	"CreateService":               true,
+	"UpdateService":               true,
+	"DeleteService":               true,
+	"CreateTaskSet":               true,
+	"UpdateTaskSet":               true,
+	"DeleteTaskSet":               true,
+	"UpdateServicePrimaryTaskSet": true,
+	"ExecuteCommand":              true,
+}
+
+// Limit is a token bucket rate limit
+type Limit struct {
+	// RequestsPerSecond is the sustained rate, 0 means unlimited
+	RequestsPerSecond float64
+	// Burst is the number of requests allowed at once, 0 derives it from the rate
+	Burst int
+}
+
+// burst returns the bucket size, at least one request
+func (l Limit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return int(math.Max(1, math.Ceil(l.RequestsPerSecond)))
+}
+
+// Config configures the limiter
+type Config struct {
+	// Default applies to every operation without an entry in Operations.
+	// Each operation gets its own bucket.
+	Default Limit
+	// Operations overrides the limit of single operations, keyed by operation name
+	Operations map[string]Limit
+	// MaxConcurrentKubernetesOperations bounds the Kubernetes applying
+	// operations served at once, 0 means unbounded
+	MaxConcurrentKubernetesOperations int
+	// QueueTimeout is how long a Kubernetes applying operation waits for a
+	// free slot before it is throttled
+	QueueTimeout time.Duration
+}
+
+// Enabled reports whether the configuration limits anything
+func (c Config) Enabled() bool {
+	return c.Default.RequestsPerSecond > 0 || len(c.Operations) > 0 || c.MaxConcurrentKubernetesOperations > 0
+}
+
+// ParseOperationLimits parses per-operation limits written as
+// "RunTask=5:10,CreateService=2", i.e. requests per second and an optional burst
+func ParseOperationLimits(spec string) (map[string]Limit, error) {
+	limits := make(map[string]Limit)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		operation, value, ok := strings.Cut(entry, "=")
+		operation = strings.TrimSpace(operation)
+		if !ok || operation == "" {
+			return nil, fmt.Errorf("invalid operation limit %q, expected Operation=rate[:burst]", entry)
+		}
+
+		rateValue, burstValue, hasBurst := strings.Cut(strings.TrimSpace(value), ":")
+		rps, err := strconv.ParseFloat(rateValue, 64)
+		if err != nil || rps < 0 {
+			return nil, fmt.Errorf("invalid rate for operation %s: %q", operation, rateValue)
+		}
+		limit := Limit{RequestsPerSecond: rps}
+		if hasBurst {
+			burst, err := strconv.Atoi(burstValue)
+			if err != nil || burst < 0 {
+				return nil, fmt.Errorf("invalid burst for operation %s: %q", operation, burstValue)
+			}
+			limit.Burst = burst
+		}
+		limits[operation] = limit
+	}
+	return limits, nil
+}
+
+// Limiter throttles AWS API requests
+type Limiter struct {
+	config     Config
+	operations map[string]Limit
+
+	mu      sync.Mutex
+	buckets map[string]*rate.Limiter
+
+	// slots holds one token per Kubernetes applying operation in flight
+	slots chan struct{}
+}
+
+// NewLimiter creates a limiter for config
+func NewLimiter(config Config) *Limiter {
+	l := &Limiter{
+		config:     config,
+		operations: make(map[string]Limit, len(config.Operations)),
+		buckets:    make(map[string]*rate.Limiter),
+	}
+	// Operation names are matched case-insensitively, as in fault rules
+	for name, limit := range config.Operations {
+		l.operations[strings.ToLower(name)] = limit
+	}
+	if config.MaxConcurrentKubernetesOperations > 0 {
+		l.slots = make(chan struct{}, config.MaxConcurrentKubernetesOperations)
+	}
+	return l
+}
+
+// bucket returns the token bucket of an operation, nil when it is not rate limited
+func (l *Limiter) bucket(service, operation string) *rate.Limiter {
+	limit, ok := l.operations[strings.ToLower(operation)]
+	if !ok {
+		limit = l.config.Default
+	}
+	if limit.RequestsPerSecond <= 0 {
+		return nil
+	}
+
+	key := service + ":" + operation
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = rate.NewLimiter(rate.Limit(limit.RequestsPerSecond), limit.burst())
+		l.buckets[key] = b
+	}
+	return b
+}
+
+// acquire takes a Kubernetes operation slot, waiting up to the queue timeout.
+// The returned function releases the slot.
+func (l *Limiter) acquire(ctx context.Context) (func(), bool) {
+	release := func() { <-l.slots }
+
+	select {
+	case l.slots <- struct{}{}:
+		return release, true
+	default:
+	}
+	if l.config.QueueTimeout <= 0 {
+		return nil, false
+	}
+
+	timer := time.NewTimer(l.config.QueueTimeout)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return release, true
+	case <-timer.C:
+		return nil, false
+	case <-ctx.Done():
+		return nil, false
+	}
+}
+
+// Middleware returns an HTTP middleware that throttles AWS API requests over
+// their rate limit and Kubernetes applying ECS operations over the
+// concurrency bound. Throttled requests get a ThrottlingException, as from AWS.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		op, ok := awsrequest.Identify(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if b := l.bucket(op.Service, op.Name); b != nil {
+			reservation := b.Reserve()
+			if delay := reservation.Delay(); delay > 0 {
+				reservation.Cancel()
+				logging.Debug("Rate limit exceeded", "service", op.Service, "operation", op.Name)
+				writeThrottle(w, op.QueryProtocol, delay)
+				return
+			}
+		}
+
+		if l.slots != nil && op.Service == awsrequest.ServiceECS && kubernetesOperations[op.Name] {
+			release, ok := l.acquire(r.Context())
+			if !ok {
+				logging.Debug("Too many concurrent Kubernetes operations", "operation", op.Name)
+				writeThrottle(w, op.QueryProtocol, time.Second)
+				return
+			}
+			defer release()
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeThrottle writes a throttling error advising the client to retry after
+// retryAfter, rounded up to whole seconds
+func writeThrottle(w http.ResponseWriter, queryProtocol bool, retryAfter time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	code := "ThrottlingException"
+	if queryProtocol {
+		code = "Throttling"
+	}
+	awsrequest.WriteError(w, queryProtocol, http.StatusBadRequest, code, "Rate exceeded")
+}
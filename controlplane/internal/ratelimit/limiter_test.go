@@ -0,0 +1,196 @@
+package ratelimit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/ratelimit"
+)
+
+var _ = Describe("Limiter", func() {
+	ecsRequest := func(operation string) *http.Request {
+		req := httptest.NewRequest("POST", "/", strings.NewReader("{}"))
+		req.Header.Set("X-Amz-Target", "AmazonEC2ContainerServiceV20141113."+operation)
+		req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+		return req
+	}
+
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	serve := func(handler http.Handler, req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	Describe("ParseOperationLimits", func() {
+		It("parses rates and optional bursts", func() {
+			limits, err := ratelimit.ParseOperationLimits("RunTask=5:10, CreateService=0.5")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(limits).To(HaveKeyWithValue("RunTask", ratelimit.Limit{RequestsPerSecond: 5, Burst: 10}))
+			Expect(limits).To(HaveKeyWithValue("CreateService", ratelimit.Limit{RequestsPerSecond: 0.5}))
+		})
+
+		It("accepts an empty spec", func() {
+			limits, err := ratelimit.ParseOperationLimits("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(limits).To(BeEmpty())
+		})
+
+		It("rejects malformed entries", func() {
+			for _, spec := range []string{"RunTask", "=5", "RunTask=fast", "RunTask=-1", "RunTask=5:x"} {
+				_, err := ratelimit.ParseOperationLimits(spec)
+				Expect(err).To(HaveOccurred(), spec)
+			}
+		})
+	})
+
+	Describe("rate limits", func() {
+		It("throttles an operation over its limit with a ThrottlingException", func() {
+			limiter := ratelimit.NewLimiter(ratelimit.Config{
+				Operations: map[string]ratelimit.Limit{"RunTask": {RequestsPerSecond: 0.01, Burst: 2}},
+			})
+			handler := limiter.Middleware(okHandler)
+
+			Expect(serve(handler, ecsRequest("RunTask")).Code).To(Equal(http.StatusOK))
+			Expect(serve(handler, ecsRequest("RunTask")).Code).To(Equal(http.StatusOK))
+
+			rec := serve(handler, ecsRequest("RunTask"))
+			Expect(rec.Code).To(Equal(http.StatusBadRequest))
+			Expect(rec.Body.String()).To(ContainSubstring("ThrottlingException"))
+			Expect(rec.Body.String()).To(ContainSubstring("Rate exceeded"))
+			Expect(rec.Header().Get("Retry-After")).NotTo(BeEmpty())
+
+			// Other operations are not limited
+			Expect(serve(handler, ecsRequest("ListTasks")).Code).To(Equal(http.StatusOK))
+		})
+
+		It("gives every operation its own bucket under the default limit", func() {
+			limiter := ratelimit.NewLimiter(ratelimit.Config{
+				Default: ratelimit.Limit{RequestsPerSecond: 0.01, Burst: 1},
+			})
+			handler := limiter.Middleware(okHandler)
+
+			Expect(serve(handler, ecsRequest("ListTasks")).Code).To(Equal(http.StatusOK))
+			Expect(serve(handler, ecsRequest("ListTasks")).Code).To(Equal(http.StatusBadRequest))
+			Expect(serve(handler, ecsRequest("ListServices")).Code).To(Equal(http.StatusOK))
+		})
+
+		It("uses the query protocol error code for ELBv2", func() {
+			limiter := ratelimit.NewLimiter(ratelimit.Config{
+				Default: ratelimit.Limit{RequestsPerSecond: 0.01, Burst: 1},
+			})
+			handler := limiter.Middleware(okHandler)
+
+			elbRequest := func() *http.Request {
+				req := httptest.NewRequest("POST", "/", strings.NewReader("Action=DescribeLoadBalancers&Version=2015-12-01"))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				return req
+			}
+			Expect(serve(handler, elbRequest()).Code).To(Equal(http.StatusOK))
+			rec := serve(handler, elbRequest())
+			Expect(rec.Code).To(Equal(http.StatusBadRequest))
+			Expect(rec.Body.String()).To(ContainSubstring("<Code>Throttling</Code>"))
+		})
+
+		It("passes requests that are not AWS API calls", func() {
+			limiter := ratelimit.NewLimiter(ratelimit.Config{
+				Default: ratelimit.Limit{RequestsPerSecond: 0.01, Burst: 1},
+			})
+			handler := limiter.Middleware(okHandler)
+
+			for i := 0; i < 3; i++ {
+				Expect(serve(handler, httptest.NewRequest("GET", "/health", nil)).Code).To(Equal(http.StatusOK))
+			}
+		})
+	})
+
+	Describe("Kubernetes operation pool", func() {
+		var (
+			release  chan struct{}
+			started  chan struct{}
+			blocking http.Handler
+		)
+
+		BeforeEach(func() {
+			release = make(chan struct{})
+			started = make(chan struct{}, 10)
+			blocking = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				started <- struct{}{}
+				<-release
+				w.WriteHeader(http.StatusOK)
+			})
+		})
+
+		It("throttles Kubernetes operations over the bound once the queue timeout passes", func() {
+			limiter := ratelimit.NewLimiter(ratelimit.Config{
+				MaxConcurrentKubernetesOperations: 1,
+				QueueTimeout:                      10 * time.Millisecond,
+			})
+			handler := limiter.Middleware(blocking)
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer GinkgoRecover()
+				Expect(serve(handler, ecsRequest("RunTask")).Code).To(Equal(http.StatusOK))
+			}()
+			Eventually(started).Should(Receive())
+
+			rec := serve(handler, ecsRequest("CreateService"))
+			Expect(rec.Code).To(Equal(http.StatusBadRequest))
+			Expect(rec.Body.String()).To(ContainSubstring("ThrottlingException"))
+			Expect(rec.Header().Get("Retry-After")).To(Equal("1"))
+
+			close(release)
+			wg.Wait()
+		})
+
+		It("lets a queued operation run once a slot frees up", func() {
+			limiter := ratelimit.NewLimiter(ratelimit.Config{
+				MaxConcurrentKubernetesOperations: 1,
+				QueueTimeout:                      5 * time.Second,
+			})
+			handler := limiter.Middleware(blocking)
+
+			codes := make(chan int, 2)
+			for i := 0; i < 2; i++ {
+				go func() {
+					codes <- serve(handler, ecsRequest("RunTask")).Code
+				}()
+			}
+			Eventually(started).Should(Receive())
+			Consistently(started, 50*time.Millisecond).ShouldNot(Receive())
+
+			close(release)
+			Eventually(codes).Should(Receive(Equal(http.StatusOK)))
+			Eventually(codes).Should(Receive(Equal(http.StatusOK)))
+		})
+
+		It("does not bound read-only operations", func() {
+			limiter := ratelimit.NewLimiter(ratelimit.Config{MaxConcurrentKubernetesOperations: 1})
+			handler := limiter.Middleware(blocking)
+
+			done := make(chan int, 2)
+			for i := 0; i < 2; i++ {
+				go func() {
+					done <- serve(handler, ecsRequest("DescribeTasks")).Code
+				}()
+			}
+			Eventually(started).Should(Receive())
+			Eventually(started).Should(Receive())
+			close(release)
+			Eventually(done).Should(Receive(Equal(http.StatusOK)))
+			Eventually(done).Should(Receive(Equal(http.StatusOK)))
+		})
+	})
+})
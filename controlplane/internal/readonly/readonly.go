@@ -0,0 +1,41 @@
+// Package readonly rejects the AWS API calls that change state, so that a
+// shared instance, e.g. for a workshop, can be inspected but not modified.
+package readonly
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsrequest"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// guardedServices are the services whose mutating operations are rejected,
+// by the service a call is routed to rather than the one it is signed for.
+// CloudFormation stacks create and delete ECS and ELBv2 resources.
+var guardedServices = map[string]bool{
+	awsrequest.ServiceECS:            true,
+	awsrequest.ServiceELBv2:          true,
+	awsrequest.ServiceCloudFormation: true,
+}
+
+// Middleware returns an HTTP middleware rejecting mutating ECS, ELBv2 and
+// CloudFormation calls with the access denied error of the calling service.
+// Read-only operations and requests that are not AWS API calls pass through.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		op, ok := awsrequest.Identify(r)
+		if !ok || !guardedServices[op.Service] || !op.Mutating() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		logging.Debug("Rejecting mutating API call in read-only mode", "service", op.Service, "operation", op.Name)
+		message := fmt.Sprintf("%s:%s is not allowed, this KECS instance is read-only", op.Service, op.Name)
+		if op.QueryProtocol {
+			awsrequest.WriteError(w, true, http.StatusForbidden, "AccessDenied", message)
+		} else {
+			awsrequest.WriteError(w, false, http.StatusBadRequest, "AccessDeniedException", message)
+		}
+	})
+}
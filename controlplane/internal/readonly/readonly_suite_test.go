@@ -0,0 +1,13 @@
+package readonly_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestReadOnly(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ReadOnly Suite")
+}
@@ -0,0 +1,105 @@
+package readonly_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/readonly"
+)
+
+var _ = Describe("Middleware", func() {
+	var (
+		handler http.Handler
+		served  []string
+	)
+
+	BeforeEach(func() {
+		served = nil
+		handler = readonly.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			served = append(served, r.URL.Path)
+			w.WriteHeader(http.StatusOK)
+		}))
+	})
+
+	ecsRequest := func(operation string) *http.Request {
+		req := httptest.NewRequest("POST", "/", strings.NewReader("{}"))
+		req.Header.Set("X-Amz-Target", "AmazonEC2ContainerServiceV20141113."+operation)
+		req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+		return req
+	}
+
+	elbv2Request := func(action string) *http.Request {
+		req := httptest.NewRequest("POST", "/", strings.NewReader("Action="+action+"&Version=2015-12-01"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req
+	}
+
+	It("rejects mutating ECS calls with AccessDeniedException", func() {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, ecsRequest("CreateService"))
+
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+		Expect(rec.Body.String()).To(ContainSubstring(`"__type":"AccessDeniedException"`))
+		Expect(rec.Body.String()).To(ContainSubstring("ecs:CreateService is not allowed"))
+		Expect(served).To(BeEmpty())
+	})
+
+	It("rejects mutating ELBv2 calls with AccessDenied", func() {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, elbv2Request("CreateLoadBalancer"))
+
+		Expect(rec.Code).To(Equal(http.StatusForbidden))
+		Expect(rec.Body.String()).To(ContainSubstring("<Code>AccessDenied</Code>"))
+		Expect(served).To(BeEmpty())
+	})
+
+	It("rejects mutating ECS calls signed for another service", func() {
+		req := ecsRequest("DeleteCluster")
+		req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20251015/us-east-1/ecsx/aws4_request, SignedHeaders=host, Signature=abc")
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		Expect(rec.Body.String()).To(ContainSubstring("ecs:DeleteCluster is not allowed"))
+		Expect(served).To(BeEmpty())
+	})
+
+	It("rejects CloudFormation calls that change stacks", func() {
+		for _, action := range []string{"CreateStack", "ExecuteChangeSet", "DeleteStack"} {
+			req := elbv2Request(action)
+			req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20251015/us-east-1/cloudformation/aws4_request, SignedHeaders=host, Signature=abc")
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			Expect(rec.Code).To(Equal(http.StatusForbidden))
+			Expect(rec.Body.String()).To(ContainSubstring("cloudformation:" + action + " is not allowed"))
+		}
+		Expect(served).To(BeEmpty())
+	})
+
+	It("serves reads and requests that are not AWS API calls", func() {
+		for _, req := range []*http.Request{
+			ecsRequest("DescribeServices"),
+			ecsRequest("ListTasks"),
+			elbv2Request("DescribeTargetHealth"),
+			httptest.NewRequest("GET", "/health", nil),
+		} {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			Expect(rec.Code).To(Equal(http.StatusOK))
+		}
+		Expect(served).To(HaveLen(4))
+	})
+
+	It("serves mutating calls of other services", func() {
+		req := httptest.NewRequest("POST", "/", strings.NewReader("{}"))
+		req.Header.Set("X-Amz-Target", "Route53AutoNaming_v20170314.CreateService")
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+	})
+})
@@ -0,0 +1,94 @@
+package recorder
+
+import (
+	"bytes"
+	"net/http"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsrequest"
+)
+
+const (
+	// maxRequestBody bounds the request body stored per interaction
+	maxRequestBody = 1024 * 1024
+	// maxResponseBody bounds the response body stored per interaction
+	maxResponseBody = 64 * 1024
+)
+
+// replayHeaders are the request headers recorded to replay a call
+var replayHeaders = []string{"Content-Type", "X-Amz-Target", "Accept"}
+
+// Middleware returns an HTTP middleware that records every AWS API call while
+// recording is on. Requests that are not AWS API calls pass through unrecorded.
+func (r *Recorder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !r.Recording() {
+			next.ServeHTTP(w, req)
+			return
+		}
+		op, ok := awsrequest.Identify(req)
+		if !ok {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		interaction := Interaction{
+			Time:      time.Now().UTC(),
+			Service:   op.Service,
+			Operation: op.Name,
+			Region:    awsrequest.ParseCredential(req.Header.Get("Authorization")).Region,
+			Method:    req.Method,
+			Path:      req.URL.RequestURI(),
+			Header:    http.Header{},
+		}
+		for _, name := range replayHeaders {
+			if value := req.Header.Get(name); value != "" {
+				interaction.Header.Set(name, value)
+			}
+		}
+		if body, err := awsrequest.ReadBody(req); err == nil {
+			if len(body) > maxRequestBody {
+				interaction.RequestTruncated = true
+			} else {
+				interaction.Request = sanitizeBody(body, op.QueryProtocol)
+			}
+		}
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, req)
+
+		interaction.StatusCode = rec.status
+		interaction.DurationMs = time.Since(interaction.Time).Milliseconds()
+		if rec.status >= 400 {
+			interaction.ErrorCode = awsrequest.ErrorCode(rec.body.Bytes(), op.QueryProtocol)
+		}
+		interaction.Response = sanitizeBody(rec.body.Bytes(), op.QueryProtocol)
+		r.Record(interaction)
+	})
+}
+
+// responseRecorder captures the status and the start of the response
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.body.Len() < maxResponseBody {
+		r.body.Write(b[:min(len(b), maxResponseBody-r.body.Len())])
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Flush supports streaming handlers
+func (r *responseRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
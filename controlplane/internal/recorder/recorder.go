@@ -0,0 +1,210 @@
+// Package recorder records the AWS API calls made against a KECS instance,
+// with their responses, and replays them against another endpoint. Captured
+// traffic is used to debug client behavior and to check that a KECS release
+// still answers real-world calls the way the previous one did.
+package recorder
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// Interaction is one recorded API call
+type Interaction struct {
+	Time      time.Time `json:"time"`
+	Service   string    `json:"service"`
+	Operation string    `json:"operation"`
+	// Region is the region of the SigV4 credential scope, empty for unsigned calls
+	Region string `json:"region,omitempty"`
+	Method string `json:"method"`
+	// Path is the request URI including the query string
+	Path string `json:"path"`
+	// Header holds the headers needed to replay the call, credentials are never recorded
+	Header http.Header `json:"header,omitempty"`
+	// Request is the sanitized request body
+	Request string `json:"request,omitempty"`
+	// RequestTruncated is set when the request body exceeded maxRequestBody, such calls are not replayed
+	RequestTruncated bool   `json:"requestTruncated,omitempty"`
+	StatusCode       int    `json:"statusCode"`
+	ErrorCode        string `json:"errorCode,omitempty"`
+	// Response is the sanitized response body. Bodies that cannot be
+	// sanitized, e.g. ones truncated to maxResponseBody bytes, are REDACTED.
+	Response   string `json:"response,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// Status describes the recorder
+type Status struct {
+	Recording bool   `json:"recording"`
+	Path      string `json:"path"`
+	// Since is when the current recording started
+	Since *time.Time `json:"since,omitempty"`
+	// Interactions is the number of calls recorded since the recording started
+	Interactions int64 `json:"interactions"`
+	// SizeBytes is the size of the recording file
+	SizeBytes int64 `json:"sizeBytes"`
+}
+
+// Recorder appends interactions to a JSON lines file while recording is on.
+// Recording is off until Start is called.
+type Recorder struct {
+	mu           sync.Mutex
+	path         string
+	file         *os.File
+	since        time.Time
+	interactions int64
+}
+
+// New creates a recorder writing to path
+func New(path string) *Recorder {
+	return &Recorder{path: path}
+}
+
+// Path returns the path of the recording file
+func (r *Recorder) Path() string {
+	return r.path
+}
+
+// Start starts recording. New interactions are appended to the recording
+// file unless reset is set, which discards the interactions recorded before.
+func (r *Recorder) Start(reset bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file != nil && !reset {
+		return nil
+	}
+	if r.file != nil {
+		r.file.Close()
+		r.file = nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if reset {
+		flags |= os.O_TRUNC
+	}
+	file, err := os.OpenFile(r.path, flags, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open recording: %w", err)
+	}
+	r.file = file
+	r.since = time.Now().UTC()
+	r.interactions = 0
+	logging.Info("Recording API calls", "path", r.path)
+	return nil
+}
+
+// Stop stops recording and closes the recording file
+func (r *Recorder) Stop() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	logging.Info("Stopped recording API calls", "path", r.path, "interactions", r.interactions)
+	return err
+}
+
+// Recording reports whether calls are recorded
+func (r *Recorder) Recording() bool {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file != nil
+}
+
+// Status returns the state of the recorder
+func (r *Recorder) Status() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := Status{Recording: r.file != nil, Path: r.path}
+	if r.file != nil {
+		since := r.since
+		status.Since = &since
+		status.Interactions = r.interactions
+	}
+	if info, err := os.Stat(r.path); err == nil {
+		status.SizeBytes = info.Size()
+	}
+	return status
+}
+
+// Record appends an interaction to the recording file. Failures are logged
+// and never fail the API call.
+func (r *Recorder) Record(interaction Interaction) {
+	data, err := json.Marshal(interaction)
+	if err != nil {
+		logging.Warn("Failed to encode recorded interaction", "error", err)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return
+	}
+	if _, err := r.file.Write(append(data, '\n')); err != nil {
+		logging.Warn("Failed to write recorded interaction", "path", r.path, "error", err)
+		return
+	}
+	r.interactions++
+}
+
+// Open opens the recording file for reading
+func (r *Recorder) Open() (io.ReadCloser, error) {
+	return os.Open(r.path)
+}
+
+// ReadFile reads the interactions of a recording file
+func ReadFile(path string) ([]Interaction, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording: %w", err)
+	}
+	defer file.Close()
+	return Read(file)
+}
+
+// Read reads interactions from JSON lines
+func Read(r io.Reader) ([]Interaction, error) {
+	var interactions []Interaction
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*maxRequestBody)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var interaction Interaction
+		if err := json.Unmarshal(scanner.Bytes(), &interaction); err != nil {
+			// Skip a line torn by a crash
+			continue
+		}
+		interactions = append(interactions, interaction)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+	return interactions, nil
+}
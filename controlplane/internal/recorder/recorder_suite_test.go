@@ -0,0 +1,13 @@
+package recorder_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestRecorder(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Recorder Suite")
+}
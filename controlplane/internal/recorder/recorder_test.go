@@ -0,0 +1,255 @@
+package recorder_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/recorder"
+	"github.com/nandemo-ya/kecs/controlplane/internal/sigv4"
+)
+
+const testAuthorization = "AWS4-HMAC-SHA256 Credential=AKIAALICE/20251015/eu-west-1/ecs/aws4_request, SignedHeaders=host;x-amz-date, Signature=abc"
+
+// fakeECS answers DeleteCluster with ClusterNotFoundException and every other operation with an empty object
+func fakeECS(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.Header.Get("X-Amz-Target"), ".DeleteCluster") {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type":"ClusterNotFoundException","message":"Cluster not found."}`))
+		return
+	}
+	w.Write([]byte(`{"cluster":{"clusterName":"default"}}`))
+}
+
+func ecsRequest(operation, payload string) *http.Request {
+	req := httptest.NewRequest("POST", "/", strings.NewReader(payload))
+	req.Header.Set("X-Amz-Target", "AmazonEC2ContainerServiceV20141113."+operation)
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("Authorization", testAuthorization)
+	req.Header.Set("X-Amz-Security-Token", "session")
+	return req
+}
+
+var _ = Describe("Recorder", func() {
+	var (
+		rec     *recorder.Recorder
+		handler http.Handler
+		path    string
+	)
+
+	BeforeEach(func() {
+		path = filepath.Join(GinkgoT().TempDir(), "recordings", "api.jsonl")
+		rec = recorder.New(path)
+		handler = rec.Middleware(http.HandlerFunc(fakeECS))
+	})
+
+	AfterEach(func() {
+		Expect(rec.Stop()).To(Succeed())
+	})
+
+	recorded := func() []recorder.Interaction {
+		interactions, err := recorder.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		return interactions
+	}
+
+	It("records nothing until started", func() {
+		handler.ServeHTTP(httptest.NewRecorder(), ecsRequest("CreateCluster", `{"clusterName":"default"}`))
+		Expect(rec.Recording()).To(BeFalse())
+		Expect(path).NotTo(BeAnExistingFile())
+	})
+
+	It("records calls with their responses", func() {
+		Expect(rec.Start(false)).To(Succeed())
+		handler.ServeHTTP(httptest.NewRecorder(), ecsRequest("CreateCluster", `{"clusterName": "default"}`))
+		handler.ServeHTTP(httptest.NewRecorder(), ecsRequest("DeleteCluster", `{"cluster":"missing"}`))
+		// Not an AWS API call
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+
+		interactions := recorded()
+		Expect(interactions).To(HaveLen(2))
+		Expect(interactions[0].Service).To(Equal("ecs"))
+		Expect(interactions[0].Operation).To(Equal("CreateCluster"))
+		Expect(interactions[0].Region).To(Equal("eu-west-1"))
+		Expect(interactions[0].Request).To(Equal(`{"clusterName":"default"}`))
+		Expect(interactions[0].Response).To(Equal(`{"cluster":{"clusterName":"default"}}`))
+		Expect(interactions[0].StatusCode).To(Equal(http.StatusOK))
+		Expect(interactions[1].StatusCode).To(Equal(http.StatusBadRequest))
+		Expect(interactions[1].ErrorCode).To(Equal("ClusterNotFoundException"))
+		Expect(rec.Status().Interactions).To(Equal(int64(2)))
+	})
+
+	It("never records credentials", func() {
+		Expect(rec.Start(false)).To(Succeed())
+		handler.ServeHTTP(httptest.NewRecorder(), ecsRequest("RegisterTaskDefinition", `{
+			"family": "web",
+			"containerDefinitions": [{
+				"name": "web",
+				"environment": [{"name": "DB_PASSWORD", "value": "hunter2"}, {"name": "PORT", "value": "8080"}],
+				"repositoryCredentials": {"credentialsParameter": "arn:aws:secretsmanager:us-east-1:000000000000:secret:registry"}
+			}]
+		}`))
+
+		interactions := recorded()
+		Expect(interactions).To(HaveLen(1))
+		Expect(interactions[0].Header).NotTo(HaveKey("Authorization"))
+		Expect(interactions[0].Header).NotTo(HaveKey("X-Amz-Security-Token"))
+		Expect(interactions[0].Header.Get("X-Amz-Target")).To(Equal("AmazonEC2ContainerServiceV20141113.RegisterTaskDefinition"))
+		Expect(interactions[0].Request).NotTo(ContainSubstring("hunter2"))
+		Expect(interactions[0].Request).To(ContainSubstring(`{"name":"DB_PASSWORD","value":"REDACTED"}`))
+		Expect(interactions[0].Request).To(ContainSubstring(`{"name":"PORT","value":"8080"}`))
+		Expect(interactions[0].Request).To(ContainSubstring("secret:registry"))
+	})
+
+	It("redacts sensitive form parameters", func() {
+		Expect(rec.Start(false)).To(Succeed())
+		form := "Action=AddTags&Version=2015-12-01&Tags.member.1.Key=api_token&Tags.member.1.Value=abc&Tags.member.2.Key=team&Tags.member.2.Value=web"
+		req := httptest.NewRequest("POST", "/", strings.NewReader(form))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		interactions := recorded()
+		Expect(interactions).To(HaveLen(1))
+		Expect(interactions[0].Service).To(Equal("elasticloadbalancing"))
+		Expect(interactions[0].Request).To(ContainSubstring("Tags.member.1.Value=REDACTED"))
+		Expect(interactions[0].Request).To(ContainSubstring("Tags.member.2.Value=web"))
+	})
+
+	It("redacts credentials of XML responses by element name", func() {
+		handler = rec.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/xml")
+			w.Write([]byte(`<GetSessionTokenResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <GetSessionTokenResult>
+    <Credentials>
+      <AccessKeyId>ASIAEXAMPLE</AccessKeyId>
+      <SecretAccessKey>wJalrXUtnFEMI/K7MDENG</SecretAccessKey>
+      <SessionToken>FwoGZXIvYXdzE&amp;EXAMPLE</SessionToken>
+    </Credentials>
+  </GetSessionTokenResult>
+</GetSessionTokenResponse>`))
+		}))
+		Expect(rec.Start(false)).To(Succeed())
+		req := httptest.NewRequest("POST", "/", strings.NewReader("Action=GetSessionToken&Version=2011-06-15"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Authorization", strings.Replace(testAuthorization, "/ecs/", "/sts/", 1))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		interactions := recorded()
+		Expect(interactions).To(HaveLen(1))
+		Expect(interactions[0].Operation).To(Equal("GetSessionToken"))
+		Expect(interactions[0].Request).To(Equal("Action=GetSessionToken&Version=2011-06-15"))
+		Expect(interactions[0].Response).To(ContainSubstring("<AccessKeyId>ASIAEXAMPLE</AccessKeyId>"))
+		Expect(interactions[0].Response).To(ContainSubstring("<SecretAccessKey>REDACTED</SecretAccessKey>"))
+		Expect(interactions[0].Response).To(ContainSubstring("<SessionToken>REDACTED</SessionToken>"))
+		Expect(interactions[0].Response).To(HavePrefix(`<GetSessionTokenResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">`))
+	})
+
+	It("redacts responses that cannot be parsed", func() {
+		handler = rec.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The body is truncated before the redaction could parse it
+			w.Write([]byte(`{"password":"hunter2","padding":"` + strings.Repeat("x", 70*1024) + `"}`))
+		}))
+		Expect(rec.Start(false)).To(Succeed())
+		handler.ServeHTTP(httptest.NewRecorder(), ecsRequest("DescribeClusters", `{}`))
+
+		interactions := recorded()
+		Expect(interactions).To(HaveLen(1))
+		Expect(interactions[0].Response).To(Equal(recorder.Redacted))
+	})
+
+	It("appends to the recording unless reset", func() {
+		Expect(rec.Start(false)).To(Succeed())
+		handler.ServeHTTP(httptest.NewRecorder(), ecsRequest("CreateCluster", `{}`))
+		Expect(rec.Stop()).To(Succeed())
+		Expect(rec.Recording()).To(BeFalse())
+
+		Expect(rec.Start(false)).To(Succeed())
+		handler.ServeHTTP(httptest.NewRecorder(), ecsRequest("CreateCluster", `{}`))
+		Expect(recorded()).To(HaveLen(2))
+
+		Expect(rec.Start(true)).To(Succeed())
+		Expect(recorded()).To(BeEmpty())
+	})
+
+	Describe("Replay", func() {
+		var (
+			target *httptest.Server
+			seen   []string
+		)
+
+		BeforeEach(func() {
+			seen = nil
+			target = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = append(seen, r.Header.Get("X-Amz-Target"))
+				fakeECS(w, r)
+			}))
+			Expect(rec.Start(false)).To(Succeed())
+			handler.ServeHTTP(httptest.NewRecorder(), ecsRequest("CreateCluster", `{"clusterName":"default"}`))
+			handler.ServeHTTP(httptest.NewRecorder(), ecsRequest("DeleteCluster", `{"cluster":"missing"}`))
+		})
+
+		AfterEach(func() {
+			target.Close()
+		})
+
+		It("re-issues the calls in order", func() {
+			results, err := recorder.Replay(context.Background(), recorded(), recorder.ReplayOptions{Endpoint: target.URL})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results).To(HaveLen(2))
+			Expect(seen).To(Equal([]string{
+				"AmazonEC2ContainerServiceV20141113.CreateCluster",
+				"AmazonEC2ContainerServiceV20141113.DeleteCluster",
+			}))
+			for _, result := range results {
+				Expect(result.Matched()).To(BeTrue())
+			}
+			Expect(results[1].ErrorCode).To(Equal("ClusterNotFoundException"))
+		})
+
+		It("reports calls answered differently", func() {
+			interactions := recorded()
+			interactions[1].StatusCode = http.StatusOK
+			interactions[1].ErrorCode = ""
+
+			results, err := recorder.Replay(context.Background(), interactions, recorder.ReplayOptions{Endpoint: target.URL})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results[0].Matched()).To(BeTrue())
+			Expect(results[1].Matched()).To(BeFalse())
+		})
+
+		It("signs the calls when given credentials", func() {
+			creds, err := sigv4.NewCredentials([]sigv4.User{
+				{Principal: "alice", Keys: []sigv4.AccessKey{{AccessKeyID: "AKIAALICE", SecretAccessKey: "alice-secret"}}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			target.Config.Handler = sigv4.NewVerifier(creds, false).Middleware(http.HandlerFunc(fakeECS))
+
+			results, err := recorder.Replay(context.Background(), recorded(), recorder.ReplayOptions{Endpoint: target.URL})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results[0].Matched()).To(BeFalse(), "unsigned calls should be rejected")
+
+			results, err = recorder.Replay(context.Background(), recorded(), recorder.ReplayOptions{
+				Endpoint:    target.URL,
+				Credentials: &aws.Credentials{AccessKeyID: "AKIAALICE", SecretAccessKey: "alice-secret"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results[0].Matched()).To(BeTrue())
+			Expect(results[1].Matched()).To(BeTrue())
+		})
+	})
+
+	It("skips torn lines when reading recordings", func() {
+		interaction, err := json.Marshal(recorder.Interaction{Operation: "CreateCluster"})
+		Expect(err).NotTo(HaveOccurred())
+		interactions, err := recorder.Read(strings.NewReader(string(interaction) + "\n{\"operation\":\"Del"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(interactions).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,121 @@
+package recorder
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsrequest"
+)
+
+// DefaultRegion signs replayed calls that were recorded unsigned
+const DefaultRegion = "us-east-1"
+
+// ReplayOptions configure a replay
+type ReplayOptions struct {
+	// Endpoint is the base URL the calls are re-issued against, e.g. http://localhost:5373
+	Endpoint string
+	// Client sends the calls, http.DefaultClient when nil
+	Client *http.Client
+	// Credentials sign the calls with SigV4, calls are sent unsigned when nil
+	Credentials *aws.Credentials
+	// Region signs calls recorded unsigned, DefaultRegion when empty
+	Region string
+}
+
+// Result is the outcome of one replayed call
+type Result struct {
+	Interaction Interaction `json:"interaction"`
+	StatusCode  int         `json:"statusCode,omitempty"`
+	ErrorCode   string      `json:"errorCode,omitempty"`
+	// Error is set when the call could not be sent
+	Error string `json:"error,omitempty"`
+	// Skipped is set for calls that cannot be replayed, such as truncated requests
+	Skipped    bool  `json:"skipped,omitempty"`
+	DurationMs int64 `json:"durationMs"`
+}
+
+// Matched reports whether the replayed call got the recorded status and error code.
+// Response bodies are not compared as they carry generated ARNs and timestamps.
+func (r Result) Matched() bool {
+	return r.Skipped || (r.Error == "" && r.StatusCode == r.Interaction.StatusCode && r.ErrorCode == r.Interaction.ErrorCode)
+}
+
+// Replay re-issues the interactions in order and reports how each call was
+// answered. It stops early only when ctx is done.
+func Replay(ctx context.Context, interactions []Interaction, opts ReplayOptions) ([]Result, error) {
+	if opts.Endpoint == "" {
+		return nil, fmt.Errorf("endpoint is required")
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	if opts.Region == "" {
+		opts.Region = DefaultRegion
+	}
+
+	results := make([]Result, 0, len(interactions))
+	for _, interaction := range interactions {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		results = append(results, replayOne(ctx, interaction, opts))
+	}
+	return results, nil
+}
+
+func replayOne(ctx context.Context, interaction Interaction, opts ReplayOptions) Result {
+	result := Result{Interaction: interaction}
+	if interaction.RequestTruncated {
+		result.Skipped = true
+		return result
+	}
+
+	url := strings.TrimSuffix(opts.Endpoint, "/") + interaction.Path
+	req, err := http.NewRequestWithContext(ctx, interaction.Method, url, strings.NewReader(interaction.Request))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	for name, values := range interaction.Header {
+		req.Header[name] = values
+	}
+	// Calls of unknown services carry no signing name and are sent unsigned
+	if opts.Credentials != nil && interaction.Service != "" {
+		region := interaction.Region
+		if region == "" {
+			region = opts.Region
+		}
+		hash := sha256.Sum256([]byte(interaction.Request))
+		signer := v4.NewSigner()
+		if err := signer.SignHTTP(ctx, *opts.Credentials, req, hex.EncodeToString(hash[:]), interaction.Service, region, time.Now()); err != nil {
+			result.Error = fmt.Sprintf("failed to sign request: %v", err)
+			return result
+		}
+	}
+
+	start := time.Now()
+	resp, err := opts.Client.Do(req)
+	result.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+		queryProtocol := strings.Contains(interaction.Header.Get("Content-Type"), "application/x-www-form-urlencoded")
+		result.ErrorCode = awsrequest.ErrorCode(body, queryProtocol)
+	}
+	return result
+}
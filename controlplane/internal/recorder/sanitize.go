@@ -0,0 +1,191 @@
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// Redacted replaces the values of sensitive fields
+const Redacted = "REDACTED"
+
+// sensitiveFields are the suffixes of field names whose values are redacted,
+// matched case-insensitively
+var sensitiveFields = []string{
+	"password",
+	"secretstring",
+	"secretbinary",
+	"secretaccesskey",
+	"sessiontoken",
+	"authorizationtoken",
+	"privatekey",
+}
+
+// sensitiveNames are the parts of environment variable and tag names whose
+// values are redacted, e.g. DB_PASSWORD or API_TOKEN
+var sensitiveNames = []string{"password", "passwd", "secret", "token", "credential", "apikey", "api_key", "private_key"}
+
+func isSensitiveField(field string) bool {
+	field = strings.ToLower(field)
+	for _, suffix := range sensitiveFields {
+		if strings.HasSuffix(field, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSensitiveName(name string) bool {
+	name = strings.ToLower(name)
+	for _, part := range sensitiveNames {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizeBody redacts credentials from a JSON, form encoded or XML body.
+// Bodies that cannot be parsed, such as responses truncated at
+// maxResponseBody, are redacted as a whole.
+func sanitizeBody(body []byte, queryProtocol bool) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if queryProtocol {
+		// Query protocol requests are form encoded, their responses XML
+		if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '<' {
+			if sanitized, ok := sanitizeXML(body); ok {
+				return sanitized
+			}
+			return Redacted
+		}
+		if values, err := url.ParseQuery(string(body)); err == nil && len(values) > 0 {
+			return sanitizeParams(values).Encode()
+		}
+		return Redacted
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return Redacted
+	}
+	data, err := json.Marshal(sanitizeValue(value))
+	if err != nil {
+		return Redacted
+	}
+	return string(data)
+}
+
+// sanitizeXML redacts the text of sensitive elements of an XML document,
+// e.g. the SecretAccessKey of STS credentials, and the values of Key/Value
+// pairs with sensitive keys. The rest of the document is kept byte for byte.
+// It fails for documents that are malformed or incomplete.
+func sanitizeXML(body []byte) (string, bool) {
+	type element struct {
+		name string
+		// sensitiveKey is set once a child key with a sensitive name was read
+		sensitiveKey bool
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	var b strings.Builder
+	var copied int64
+	var stack []element
+	for {
+		start := decoder.InputOffset()
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", false
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			stack = append(stack, element{name: t.Name.Local})
+		case xml.EndElement:
+			if len(stack) == 0 || stack[len(stack)-1].name != t.Name.Local {
+				return "", false
+			}
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) == 0 || len(bytes.TrimSpace(t)) == 0 {
+				continue
+			}
+			name := stack[len(stack)-1].name
+			var parent *element
+			if len(stack) > 1 {
+				parent = &stack[len(stack)-2]
+			}
+			switch {
+			case isSensitiveField(name) || (strings.EqualFold(name, "value") && parent != nil && parent.sensitiveKey):
+				b.Write(body[copied:start])
+				b.WriteString(Redacted)
+				copied = decoder.InputOffset()
+			case (strings.EqualFold(name, "key") || strings.EqualFold(name, "name")) && parent != nil:
+				parent.sensitiveKey = isSensitiveName(string(t))
+			}
+		}
+	}
+	if len(stack) > 0 {
+		return "", false
+	}
+	b.Write(body[copied:])
+	return b.String(), true
+}
+
+// sanitizeValue redacts sensitive fields of a decoded JSON value, and the
+// values of name/value pairs with sensitive names such as the environment
+// variables of container definitions
+func sanitizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for field, item := range v {
+			if _, isString := item.(string); isString && isSensitiveField(field) {
+				v[field] = Redacted
+				continue
+			}
+			v[field] = sanitizeValue(item)
+		}
+		for _, pair := range [][2]string{{"name", "value"}, {"key", "value"}, {"Key", "Value"}} {
+			name, ok := v[pair[0]].(string)
+			if _, isString := v[pair[1]].(string); ok && isString && isSensitiveName(name) {
+				v[pair[1]] = Redacted
+			}
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = sanitizeValue(item)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// sanitizeParams redacts sensitive form parameters. Query protocol parameters
+// are flattened, e.g. Tags.member.1.Key, so the last segment is matched.
+func sanitizeParams(params url.Values) url.Values {
+	for key := range params {
+		field := key[strings.LastIndex(key, ".")+1:]
+		if isSensitiveField(field) {
+			params[key] = []string{Redacted}
+			continue
+		}
+		// Redact the value paired with a sensitive key, e.g. Tags.member.1.Value
+		if field == "Value" {
+			name := params.Get(strings.TrimSuffix(key, "Value") + "Key")
+			if isSensitiveName(name) {
+				params[key] = []string{Redacted}
+			}
+		}
+	}
+	return params
+}
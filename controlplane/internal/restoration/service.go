@@ -91,7 +91,7 @@ func (s *Service) logClusters(ctx context.Context, clusters []*storage.Cluster)
 // restoreServices restores all services found in DuckDB
 func (s *Service) restoreServices(ctx context.Context) error {
 	// List all services across all clusters
-	services, _, err := s.storage.ServiceStore().List(ctx, "", "", "", 1000, "")
+	services, _, err := s.storage.ServiceStore().List(ctx, "", storage.ServiceFilters{}, 1000, "")
 	if err != nil {
 		return fmt.Errorf("failed to list services from storage: %w", err)
 	}
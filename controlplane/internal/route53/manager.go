@@ -22,6 +22,9 @@ type Manager struct {
 	// Cache of service records
 	serviceRecords map[string]map[string][]string // zoneID -> service name -> IPs
 
+	// Cache of the per-instance hosts SRV records point to
+	serviceHosts map[string]map[string][]string // zoneID -> service name -> hosts
+
 	// Default VPC configuration
 	defaultVPC *VPCConfig
 }
@@ -32,6 +35,7 @@ func NewManager(client *Client, defaultVPC *VPCConfig) *Manager {
 		client:         client,
 		namespaceZones: make(map[string]string),
 		serviceRecords: make(map[string]map[string][]string),
+		serviceHosts:   make(map[string]map[string][]string),
 		defaultVPC:     defaultVPC,
 	}
 }
@@ -125,6 +129,7 @@ func (m *Manager) DeleteNamespaceZone(ctx context.Context, namespace string) err
 
 	delete(m.namespaceZones, namespace)
 	delete(m.serviceRecords, zoneID)
+	delete(m.serviceHosts, zoneID)
 
 	logging.Info("Deleted hosted zone for namespace", "namespace", namespace)
 	return nil
@@ -186,49 +191,69 @@ func (m *Manager) RegisterServiceWithPorts(ctx context.Context, namespace, servi
 		m.namespaceZones[namespace] = zoneID
 	}
 
-	// Construct the full DNS name for SRV record
-	// Format: _service._proto.name
-	srvName := fmt.Sprintf("_http._tcp.%s.%s", serviceName, namespace)
-	if !strings.HasSuffix(srvName, ".") {
-		srvName = srvName + "."
+	// Cloud Map puts the SRV record on the service name itself, with one
+	// record per instance pointing to a host record of the instance
+	dnsName := fmt.Sprintf("%s.%s", serviceName, namespace)
+	if !strings.HasSuffix(dnsName, ".") {
+		dnsName = dnsName + "."
 	}
 
 	// Convert service targets to SRV targets
 	srvTargets := make([]SRVTarget, 0, len(targets))
+	hosts := make([]string, 0, len(targets))
+	ips := make([]string, 0, len(targets))
 	for _, target := range targets {
+		host := target.Host
+		if !strings.HasSuffix(host, ".") {
+			host = host + "."
+		}
 		srvTargets = append(srvTargets, SRVTarget{
-			Priority: 10,
-			Weight:   10,
+			Priority: 1,
+			Weight:   1,
 			Port:     target.Port,
-			Target:   target.Host,
+			Target:   host,
 		})
+		if target.IP != "" {
+			if err := m.client.UpsertARecord(ctx, zoneID, host, []string{target.IP}); err != nil {
+				return fmt.Errorf("failed to update A record for %s: %w", host, err)
+			}
+			hosts = append(hosts, host)
+			ips = append(ips, target.IP)
+		}
 	}
 
 	// Update SRV record
-	err := m.client.UpsertSRVRecord(ctx, zoneID, srvName, srvTargets)
+	err := m.client.UpsertSRVRecord(ctx, zoneID, dnsName, srvTargets)
 	if err != nil {
 		return fmt.Errorf("failed to update SRV record: %w", err)
 	}
 
-	// Also update A records for the individual hosts
-	ips := make([]string, 0, len(targets))
-	for _, target := range targets {
-		if target.IP != "" {
-			ips = append(ips, target.IP)
-		}
-	}
-
+	// Also update the A record of the service
 	if len(ips) > 0 {
-		dnsName := fmt.Sprintf("%s.%s", serviceName, namespace)
-		if !strings.HasSuffix(dnsName, ".") {
-			dnsName = dnsName + "."
-		}
 		err = m.client.UpsertARecord(ctx, zoneID, dnsName, ips)
 		if err != nil {
 			logging.Warn("Failed to update A record for service", "service", serviceName, "error", err)
 		}
 	}
 
+	// Remove the host records of instances that are gone
+	if m.serviceHosts[zoneID] == nil {
+		m.serviceHosts[zoneID] = make(map[string][]string)
+	}
+	for _, host := range m.serviceHosts[zoneID][serviceName] {
+		if !containsString(hosts, host) {
+			if err := m.client.DeleteRecord(ctx, zoneID, host, types.RRTypeA); err != nil {
+				logging.Warn("Failed to delete A record", "name", host, "error", err)
+			}
+		}
+	}
+	m.serviceHosts[zoneID][serviceName] = hosts
+
+	if m.serviceRecords[zoneID] == nil {
+		m.serviceRecords[zoneID] = make(map[string][]string)
+	}
+	m.serviceRecords[zoneID][serviceName] = ips
+
 	logging.Info("Registered service with ports in Route53", "namespace", namespace, "service", serviceName, "targets", len(targets))
 	return nil
 }
@@ -250,25 +275,26 @@ func (m *Manager) DeregisterService(ctx context.Context, namespace, serviceName
 		dnsName = dnsName + "."
 	}
 
-	srvName := fmt.Sprintf("_http._tcp.%s.%s", serviceName, namespace)
-	if !strings.HasSuffix(srvName, ".") {
-		srvName = srvName + "."
-	}
-
 	// Delete A record
 	err := m.client.DeleteRecord(ctx, zoneID, dnsName, types.RRTypeA)
 	if err != nil {
 		logging.Warn("Failed to delete A record", "name", dnsName, "error", err)
 	}
 
-	// Delete SRV record
-	err = m.client.DeleteRecord(ctx, zoneID, srvName, types.RRTypeSrv)
+	// Delete SRV record and the host records it points to
+	err = m.client.DeleteRecord(ctx, zoneID, dnsName, types.RRTypeSrv)
 	if err != nil {
-		logging.Warn("Failed to delete SRV record", "name", srvName, "error", err)
+		logging.Warn("Failed to delete SRV record", "name", dnsName, "error", err)
+	}
+	for _, host := range m.serviceHosts[zoneID][serviceName] {
+		if err := m.client.DeleteRecord(ctx, zoneID, host, types.RRTypeA); err != nil {
+			logging.Warn("Failed to delete A record", "name", host, "error", err)
+		}
 	}
 
 	// Remove from cache
 	delete(m.serviceRecords[zoneID], serviceName)
+	delete(m.serviceHosts[zoneID], serviceName)
 
 	logging.Info("Deregistered service from Route53", "namespace", namespace, "service", serviceName)
 	return nil
@@ -328,3 +354,12 @@ type ServiceTarget struct {
 	IP   string
 	Port uint16
 }
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,246 @@
+// Package servicedeployments tracks the deployments of ECS services. A new
+// deployment starts when the task definition or the network setup of a
+// service changes and completes once it runs the desired count, so that
+// DescribeServices reports the rolloutState the AWS CLI waiters rely on.
+package servicedeployments
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// Deployment statuses
+const (
+	StatusPrimary = "PRIMARY"
+	StatusActive  = "ACTIVE"
+)
+
+// Start begins a deployment of the current task definition of a service.
+// The previous primary deployment stays ACTIVE until the new one completes.
+// It returns the ID of the new deployment.
+func Start(service *storage.Service, now time.Time) string {
+	deployments := decode(service)
+	for i := range deployments {
+		deployments[i].Status = ptr.String(StatusActive)
+		deployments[i].UpdatedAt = ptr.UnixTime(now)
+	}
+
+	id := fmt.Sprintf("ecs-svc/%019d", now.UnixNano())
+	primary := generated.Deployment{
+		Id:                 ptr.String(id),
+		Status:             ptr.String(StatusPrimary),
+		TaskDefinition:     ptr.String(service.TaskDefinitionARN),
+		DesiredCount:       ptr.Int32(int32(service.DesiredCount)),
+		RunningCount:       ptr.Int32(0),
+		PendingCount:       ptr.Int32(0),
+		RolloutState:       rolloutState(generated.DeploymentRolloutStateIN_PROGRESS),
+		RolloutStateReason: ptr.String(fmt.Sprintf("ECS deployment %s in progress.", id)),
+		CreatedAt:          ptr.UnixTime(now),
+		UpdatedAt:          ptr.UnixTime(now),
+	}
+	if service.LaunchType != "" {
+		launchType := generated.LaunchType(service.LaunchType)
+		primary.LaunchType = &launchType
+	}
+	if service.PlatformVersion != "" {
+		primary.PlatformVersion = ptr.String(service.PlatformVersion)
+	}
+
+	encode(service, append([]generated.Deployment{primary}, deployments...))
+	return id
+}
+
+// Progress records the tasks the primary deployment runs. taskDefinitionARN
+// is the task definition the counts were observed for, observations of an
+// older task definition are ignored. The deployment completes once it runs
+// the desired count, the deployments it replaced are dropped then. It
+// returns the ID of the deployment that completed, or "".
+func Progress(service *storage.Service, taskDefinitionARN string, running, pending int, now time.Time) string {
+	deployments := decode(service)
+	if len(deployments) == 0 {
+		return ""
+	}
+	primary := &deployments[0]
+	if taskDefinitionARN != "" && ptr.ToString(primary.TaskDefinition) != taskDefinitionARN {
+		return ""
+	}
+
+	primary.DesiredCount = ptr.Int32(int32(service.DesiredCount))
+	primary.RunningCount = ptr.Int32(int32(running))
+	primary.PendingCount = ptr.Int32(int32(pending))
+	primary.UpdatedAt = ptr.UnixTime(now)
+
+	completed := ""
+	if state(primary) == generated.DeploymentRolloutStateIN_PROGRESS && running == service.DesiredCount && pending == 0 {
+		completed = ptr.ToString(primary.Id)
+		primary.RolloutState = rolloutState(generated.DeploymentRolloutStateCOMPLETED)
+		primary.RolloutStateReason = ptr.String(fmt.Sprintf("ECS deployment %s completed.", completed))
+		deployments = deployments[:1]
+	}
+	encode(service, deployments)
+	return completed
+}
+
+// Fail marks the primary deployment of a service FAILED. It returns the ID of
+// the deployment, or "" when no deployment is in progress.
+func Fail(service *storage.Service, reason string, now time.Time) string {
+	deployments := decode(service)
+	if len(deployments) == 0 || state(&deployments[0]) != generated.DeploymentRolloutStateIN_PROGRESS {
+		return ""
+	}
+	primary := &deployments[0]
+	id := ptr.ToString(primary.Id)
+	primary.RolloutState = rolloutState(generated.DeploymentRolloutStateFAILED)
+	primary.RolloutStateReason = ptr.String(fmt.Sprintf("ECS deployment %s failed: %s", id, reason))
+	primary.UpdatedAt = ptr.UnixTime(now)
+	encode(service, deployments)
+	return id
+}
+
+// Rollback fails the primary deployment of a service and starts a deployment
+// of the task definition that deployment replaced. It returns the IDs of the
+// failed and of the rollback deployment. The rollback ID is "" when there is
+// no earlier deployment to roll back to.
+func Rollback(service *storage.Service, reason string, now time.Time) (string, string) {
+	failed := Fail(service, reason, now)
+	if failed == "" {
+		return "", ""
+	}
+	deployments := decode(service)
+	if len(deployments) < 2 {
+		return failed, ""
+	}
+	service.TaskDefinitionARN = ptr.ToString(deployments[1].TaskDefinition)
+	return failed, Start(service, now)
+}
+
+// InProgress reports whether the primary deployment of a service is rolling out
+func InProgress(service *storage.Service) bool {
+	deployments := decode(service)
+	return len(deployments) > 0 && state(&deployments[0]) == generated.DeploymentRolloutStateIN_PROGRESS
+}
+
+// Failed reports whether the primary deployment of a service failed
+func Failed(service *storage.Service) bool {
+	deployments := decode(service)
+	return len(deployments) > 0 && state(&deployments[0]) == generated.DeploymentRolloutStateFAILED
+}
+
+// Starting returns the ID and creation time of the primary deployment of a
+// service while it rolls out without running a single task. A deployment
+// that stays starting for too long has stalled.
+func Starting(service *storage.Service) (string, time.Time, bool) {
+	deployments := decode(service)
+	if len(deployments) == 0 || state(&deployments[0]) != generated.DeploymentRolloutStateIN_PROGRESS {
+		return "", time.Time{}, false
+	}
+	primary := &deployments[0]
+	if ptr.ToInt32(primary.DesiredCount) == 0 || ptr.ToInt32(primary.RunningCount) > 0 || primary.CreatedAt == nil {
+		return "", time.Time{}, false
+	}
+	return ptr.ToString(primary.Id), primary.CreatedAt.Time, true
+}
+
+// PrimaryID returns the ID of the primary deployment of a service
+func PrimaryID(service *storage.Service) string {
+	return ptr.ToString(List(service)[0].Id)
+}
+
+// List returns the deployments of a service as DescribeServices reports
+// them, the primary deployment first. Services that were created before
+// deployments were tracked get a single deployment derived from their state.
+func List(service *storage.Service) []generated.Deployment {
+	deployments := decode(service)
+	if len(deployments) == 0 {
+		return []generated.Deployment{legacyDeployment(service)}
+	}
+
+	// Without a rollout in flight the primary deployment runs all tasks of
+	// the service, otherwise the older deployments run the rest
+	primary := &deployments[0]
+	primary.DesiredCount = ptr.Int32(int32(service.DesiredCount))
+	if len(deployments) == 1 {
+		primary.RunningCount = ptr.Int32(int32(service.RunningCount))
+		primary.PendingCount = ptr.Int32(int32(service.PendingCount))
+		return deployments
+	}
+	remaining := service.RunningCount - int(ptr.ToInt32(primary.RunningCount))
+	for i := 1; i < len(deployments); i++ {
+		running := max(0, min(remaining, int(ptr.ToInt32(deployments[i].RunningCount))))
+		if i == len(deployments)-1 {
+			running = max(0, remaining)
+		}
+		deployments[i].RunningCount = ptr.Int32(int32(running))
+		remaining -= running
+	}
+	return deployments
+}
+
+// legacyDeployment derives the deployment of a service without tracked deployments
+func legacyDeployment(service *storage.Service) generated.Deployment {
+	id := fmt.Sprintf("ecs-svc/%s", service.ServiceName)
+	deployment := generated.Deployment{
+		Id:             ptr.String(id),
+		Status:         ptr.String(StatusPrimary),
+		TaskDefinition: ptr.String(service.TaskDefinitionARN),
+		DesiredCount:   ptr.Int32(int32(service.DesiredCount)),
+		RunningCount:   ptr.Int32(int32(service.RunningCount)),
+		PendingCount:   ptr.Int32(int32(service.PendingCount)),
+		CreatedAt:      ptr.UnixTime(service.CreatedAt),
+		UpdatedAt:      ptr.UnixTime(service.UpdatedAt),
+	}
+	switch {
+	case service.Status == "FAILED":
+		deployment.RolloutState = rolloutState(generated.DeploymentRolloutStateFAILED)
+		deployment.RolloutStateReason = ptr.String(fmt.Sprintf("ECS deployment %s failed.", id))
+	case service.Status == "ACTIVE" && service.RunningCount == service.DesiredCount && service.PendingCount == 0:
+		deployment.RolloutState = rolloutState(generated.DeploymentRolloutStateCOMPLETED)
+		deployment.RolloutStateReason = ptr.String(fmt.Sprintf("ECS deployment %s completed.", id))
+	default:
+		deployment.RolloutState = rolloutState(generated.DeploymentRolloutStateIN_PROGRESS)
+		deployment.RolloutStateReason = ptr.String(fmt.Sprintf("ECS deployment %s in progress.", id))
+	}
+	if service.LaunchType != "" {
+		launchType := generated.LaunchType(service.LaunchType)
+		deployment.LaunchType = &launchType
+	}
+	if service.PlatformVersion != "" {
+		deployment.PlatformVersion = ptr.String(service.PlatformVersion)
+	}
+	return deployment
+}
+
+func state(deployment *generated.Deployment) generated.DeploymentRolloutState {
+	if deployment.RolloutState == nil {
+		return ""
+	}
+	return *deployment.RolloutState
+}
+
+func rolloutState(state generated.DeploymentRolloutState) *generated.DeploymentRolloutState {
+	return &state
+}
+
+func decode(service *storage.Service) []generated.Deployment {
+	if service.Deployments == "" || service.Deployments == "null" {
+		return nil
+	}
+	var deployments []generated.Deployment
+	if err := json.Unmarshal([]byte(service.Deployments), &deployments); err != nil {
+		return nil
+	}
+	return deployments
+}
+
+func encode(service *storage.Service, deployments []generated.Deployment) {
+	data, err := json.Marshal(deployments)
+	if err != nil {
+		return
+	}
+	service.Deployments = string(data)
+}
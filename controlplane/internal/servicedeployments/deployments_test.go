@@ -0,0 +1,151 @@
+package servicedeployments_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/servicedeployments"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+var _ = Describe("Deployments", func() {
+	const (
+		taskDefV1 = "arn:aws:ecs:us-east-1:000000000000:task-definition/web:1"
+		taskDefV2 = "arn:aws:ecs:us-east-1:000000000000:task-definition/web:2"
+	)
+
+	var (
+		service *storage.Service
+		now     time.Time
+	)
+
+	BeforeEach(func() {
+		now = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		service = &storage.Service{
+			ServiceName:       "web",
+			TaskDefinitionARN: taskDefV1,
+			DesiredCount:      2,
+			Status:            "ACTIVE",
+		}
+	})
+
+	rolloutState := func(deployment generated.Deployment) generated.DeploymentRolloutState {
+		Expect(deployment.RolloutState).NotTo(BeNil())
+		return *deployment.RolloutState
+	}
+
+	It("should derive a deployment for services without tracked deployments", func() {
+		service.RunningCount = 2
+
+		deployments := servicedeployments.List(service)
+		Expect(deployments).To(HaveLen(1))
+		Expect(ptr.ToString(deployments[0].Id)).To(Equal("ecs-svc/web"))
+		Expect(rolloutState(deployments[0])).To(Equal(generated.DeploymentRolloutStateCOMPLETED))
+	})
+
+	It("should complete a deployment once it runs the desired count", func() {
+		id := servicedeployments.Start(service, now)
+		Expect(servicedeployments.PrimaryID(service)).To(Equal(id))
+		Expect(rolloutState(servicedeployments.List(service)[0])).To(Equal(generated.DeploymentRolloutStateIN_PROGRESS))
+
+		Expect(servicedeployments.Progress(service, taskDefV1, 1, 1, now)).To(BeEmpty())
+		Expect(rolloutState(servicedeployments.List(service)[0])).To(Equal(generated.DeploymentRolloutStateIN_PROGRESS))
+
+		Expect(servicedeployments.Progress(service, taskDefV1, 2, 0, now)).To(Equal(id))
+		service.RunningCount = 2
+		deployments := servicedeployments.List(service)
+		Expect(deployments).To(HaveLen(1))
+		Expect(rolloutState(deployments[0])).To(Equal(generated.DeploymentRolloutStateCOMPLETED))
+		Expect(ptr.ToInt32(deployments[0].RunningCount)).To(Equal(int32(2)))
+	})
+
+	It("should keep the replaced deployment ACTIVE until the new one completes", func() {
+		servicedeployments.Start(service, now)
+		servicedeployments.Progress(service, taskDefV1, 2, 0, now)
+
+		service.TaskDefinitionARN = taskDefV2
+		id := servicedeployments.Start(service, now.Add(time.Minute))
+		servicedeployments.Progress(service, taskDefV2, 1, 0, now.Add(time.Minute))
+		service.RunningCount = 3
+
+		deployments := servicedeployments.List(service)
+		Expect(deployments).To(HaveLen(2))
+		Expect(ptr.ToString(deployments[0].Status)).To(Equal(servicedeployments.StatusPrimary))
+		Expect(ptr.ToString(deployments[0].TaskDefinition)).To(Equal(taskDefV2))
+		Expect(ptr.ToInt32(deployments[0].RunningCount)).To(Equal(int32(1)))
+		Expect(ptr.ToString(deployments[1].Status)).To(Equal(servicedeployments.StatusActive))
+		Expect(ptr.ToInt32(deployments[1].RunningCount)).To(Equal(int32(2)))
+
+		By("ignoring counts observed for the old task definition")
+		Expect(servicedeployments.Progress(service, taskDefV1, 2, 0, now)).To(BeEmpty())
+
+		Expect(servicedeployments.Progress(service, taskDefV2, 2, 0, now.Add(2*time.Minute))).To(Equal(id))
+		Expect(servicedeployments.List(service)).To(HaveLen(1))
+	})
+
+	It("should mark the primary deployment FAILED", func() {
+		id := servicedeployments.Start(service, now)
+		Expect(servicedeployments.Fail(service, "ProgressDeadlineExceeded", now)).To(Equal(id))
+
+		deployment := servicedeployments.List(service)[0]
+		Expect(rolloutState(deployment)).To(Equal(generated.DeploymentRolloutStateFAILED))
+		Expect(ptr.ToString(deployment.RolloutStateReason)).To(ContainSubstring("ProgressDeadlineExceeded"))
+
+		By("not failing a deployment twice")
+		Expect(servicedeployments.Fail(service, "again", now)).To(BeEmpty())
+	})
+	It("should roll back to the task definition of the replaced deployment", func() {
+		servicedeployments.Start(service, now)
+		servicedeployments.Progress(service, taskDefV1, 2, 0, now)
+		service.TaskDefinitionARN = taskDefV2
+		id := servicedeployments.Start(service, now.Add(time.Minute))
+		Expect(servicedeployments.InProgress(service)).To(BeTrue())
+
+		failed, rollback := servicedeployments.Rollback(service, "alarm detected", now.Add(2*time.Minute))
+		Expect(failed).To(Equal(id))
+		Expect(rollback).NotTo(BeEmpty())
+		Expect(service.TaskDefinitionARN).To(Equal(taskDefV1))
+
+		deployments := servicedeployments.List(service)
+		Expect(deployments).To(HaveLen(3))
+		Expect(ptr.ToString(deployments[0].TaskDefinition)).To(Equal(taskDefV1))
+		Expect(ptr.ToString(deployments[1].Id)).To(Equal(id))
+		Expect(rolloutState(deployments[1])).To(Equal(generated.DeploymentRolloutStateFAILED))
+
+		Expect(servicedeployments.Progress(service, taskDefV1, 2, 0, now.Add(3*time.Minute))).To(Equal(rollback))
+		Expect(servicedeployments.InProgress(service)).To(BeFalse())
+		Expect(servicedeployments.List(service)).To(HaveLen(1))
+	})
+
+	It("should report a deployment running no task as starting", func() {
+		id := servicedeployments.Start(service, now)
+		startingID, createdAt, starting := servicedeployments.Starting(service)
+		Expect(starting).To(BeTrue())
+		Expect(startingID).To(Equal(id))
+		Expect(createdAt).To(BeTemporally("==", now))
+
+		By("not starting once a task runs")
+		servicedeployments.Progress(service, taskDefV1, 1, 1, now.Add(time.Minute))
+		_, _, starting = servicedeployments.Starting(service)
+		Expect(starting).To(BeFalse())
+
+		By("not starting a failed deployment")
+		servicedeployments.Progress(service, taskDefV1, 0, 2, now.Add(time.Minute))
+		servicedeployments.Fail(service, "tasks failed to start", now.Add(time.Minute))
+		Expect(servicedeployments.Failed(service)).To(BeTrue())
+		_, _, starting = servicedeployments.Starting(service)
+		Expect(starting).To(BeFalse())
+	})
+
+	It("should only fail a deployment without an earlier one", func() {
+		id := servicedeployments.Start(service, now)
+		failed, rollback := servicedeployments.Rollback(service, "alarm detected", now)
+		Expect(failed).To(Equal(id))
+		Expect(rollback).To(BeEmpty())
+		Expect(service.TaskDefinitionARN).To(Equal(taskDefV1))
+	})
+})
@@ -0,0 +1,13 @@
+package servicedeployments_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestServiceDeployments(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Service Deployments Suite")
+}
@@ -0,0 +1,501 @@
+// Package servicediff compares the live state of an ECS service with the
+// state an update to another task definition or spec would produce, both at
+// the ECS level and for the Kubernetes objects KECS would create.
+package servicediff
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// ErrInvalidTarget is returned when the target of a diff can not be used
+var ErrInvalidTarget = errors.New("invalid diff target")
+
+// Change is a field whose value differs between the current and the target
+// state. An empty value means the field is not set.
+type Change struct {
+	Section string `json:"section"`
+	Field   string `json:"field"`
+	Current string `json:"current,omitempty"`
+	Target  string `json:"target,omitempty"`
+}
+
+// Report lists the changes an update of a service would make
+type Report struct {
+	Cluster               string   `json:"cluster"`
+	Service               string   `json:"service"`
+	CurrentTaskDefinition string   `json:"currentTaskDefinition"`
+	TargetTaskDefinition  string   `json:"targetTaskDefinition"`
+	Changes               []Change `json:"changes"`
+	// KubernetesChanges are the changes of the Deployment and Service the
+	// converter creates for the service
+	KubernetesChanges []Change `json:"kubernetesChanges"`
+}
+
+// Target is the state a service is compared with. TaskDefinition is a
+// family:revision, a family for its latest revision, or an ARN. Spec is
+// either a RegisterTaskDefinition request or a service spec with the fields
+// of UpdateService; the task definition of a service spec is overridden by
+// TaskDefinition.
+type Target struct {
+	TaskDefinition string          `json:"taskDefinition,omitempty"`
+	Spec           json.RawMessage `json:"spec,omitempty"`
+}
+
+// ServiceSpec is the part of an UpdateService request a service spec may set
+type ServiceSpec struct {
+	TaskDefinition       string                          `json:"taskDefinition,omitempty"`
+	DesiredCount         *int                            `json:"desiredCount,omitempty"`
+	LaunchType           string                          `json:"launchType,omitempty"`
+	PlatformVersion      string                          `json:"platformVersion,omitempty"`
+	LoadBalancers        []generated.LoadBalancer        `json:"loadBalancers,omitempty"`
+	NetworkConfiguration *generated.NetworkConfiguration `json:"networkConfiguration,omitempty"`
+}
+
+// Differ compares services with their targets
+type Differ struct {
+	storage   storage.Storage
+	converter converters.ServiceConverterInterface
+}
+
+// NewDiffer creates a differ that converts services with the same converter
+// KECS uses when it creates them
+func NewDiffer(storage storage.Storage, converter converters.ServiceConverterInterface) *Differ {
+	return &Differ{storage: storage, converter: converter}
+}
+
+// Diff compares a service of a cluster with a target
+func (d *Differ) Diff(ctx context.Context, clusterName, serviceName string, target Target) (*Report, error) {
+	if target.TaskDefinition == "" && len(target.Spec) == 0 {
+		return nil, fmt.Errorf("%w: a task definition or a spec is required", ErrInvalidTarget)
+	}
+
+	cluster, err := d.storage.ClusterStore().Get(ctx, clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster %s: %w", clusterName, err)
+	}
+	if cluster == nil {
+		return nil, fmt.Errorf("cluster %s: %w", clusterName, storage.ErrResourceNotFound)
+	}
+	service, err := d.storage.ServiceStore().Get(ctx, cluster.ARN, serviceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service %s: %w", serviceName, err)
+	}
+	if service == nil {
+		return nil, fmt.Errorf("service %s: %w", serviceName, storage.ErrResourceNotFound)
+	}
+	currentTaskDef, err := d.storage.TaskDefinitionStore().GetByARN(ctx, service.TaskDefinitionARN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task definition of service %s: %w", serviceName, err)
+	}
+
+	targetService, targetTaskDef, err := d.applyTarget(ctx, service, currentTaskDef, target)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		Cluster:               cluster.Name,
+		Service:               service.ServiceName,
+		CurrentTaskDefinition: taskDefinitionName(currentTaskDef),
+		TargetTaskDefinition:  taskDefinitionName(targetTaskDef),
+		Changes:               []Change{},
+	}
+	report.Changes = append(report.Changes, diffService(service, targetService)...)
+	report.Changes = append(report.Changes, diffTaskDefinition(currentTaskDef, targetTaskDef)...)
+	containerChanges, err := diffContainers(currentTaskDef, targetTaskDef)
+	if err != nil {
+		return nil, err
+	}
+	report.Changes = append(report.Changes, containerChanges...)
+
+	report.KubernetesChanges, err = d.diffKubernetes(cluster, service, currentTaskDef, targetService, targetTaskDef)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// applyTarget returns copies of the service and task definition with the
+// target applied
+func (d *Differ) applyTarget(ctx context.Context, service *storage.Service, taskDef *storage.TaskDefinition, target Target) (*storage.Service, *storage.TaskDefinition, error) {
+	targetService := *service
+	targetTaskDef := taskDef
+
+	var spec ServiceSpec
+	if len(target.Spec) > 0 {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(target.Spec, &fields); err != nil {
+			return nil, nil, fmt.Errorf("%w: spec is not a JSON object: %v", ErrInvalidTarget, err)
+		}
+		if _, ok := fields["containerDefinitions"]; ok {
+			if target.TaskDefinition != "" {
+				return nil, nil, fmt.Errorf("%w: a task definition spec can not be combined with a task definition", ErrInvalidTarget)
+			}
+			specTaskDef, err := taskDefinitionFromSpec(target.Spec)
+			if err != nil {
+				return nil, nil, err
+			}
+			targetService.TaskDefinitionARN = ""
+			return &targetService, specTaskDef, nil
+		}
+		if err := json.Unmarshal(target.Spec, &spec); err != nil {
+			return nil, nil, fmt.Errorf("%w: invalid service spec: %v", ErrInvalidTarget, err)
+		}
+	}
+
+	if target.TaskDefinition != "" {
+		spec.TaskDefinition = target.TaskDefinition
+	}
+	if spec.TaskDefinition != "" {
+		resolved, err := d.resolveTaskDefinition(ctx, spec.TaskDefinition)
+		if err != nil {
+			return nil, nil, err
+		}
+		targetTaskDef = resolved
+		targetService.TaskDefinitionARN = resolved.ARN
+	}
+	if spec.DesiredCount != nil {
+		targetService.DesiredCount = *spec.DesiredCount
+	}
+	if spec.LaunchType != "" {
+		targetService.LaunchType = spec.LaunchType
+	}
+	if spec.PlatformVersion != "" {
+		targetService.PlatformVersion = spec.PlatformVersion
+	}
+	if spec.LoadBalancers != nil {
+		data, err := json.Marshal(spec.LoadBalancers)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode load balancers: %w", err)
+		}
+		targetService.LoadBalancers = string(data)
+	}
+	if spec.NetworkConfiguration != nil {
+		data, err := json.Marshal(spec.NetworkConfiguration)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode network configuration: %w", err)
+		}
+		targetService.NetworkConfiguration = string(data)
+	}
+	return &targetService, targetTaskDef, nil
+}
+
+// resolveTaskDefinition gets a task definition by family:revision, family or ARN
+func (d *Differ) resolveTaskDefinition(ctx context.Context, identifier string) (*storage.TaskDefinition, error) {
+	store := d.storage.TaskDefinitionStore()
+	var (
+		taskDef *storage.TaskDefinition
+		err     error
+	)
+	if strings.HasPrefix(identifier, "arn:") {
+		taskDef, err = store.GetByARN(ctx, identifier)
+	} else if family, revisionStr, ok := strings.Cut(identifier, ":"); ok {
+		revision, convErr := strconv.Atoi(revisionStr)
+		if convErr != nil || revision < 1 || family == "" {
+			return nil, fmt.Errorf("%w: task definition must be family, family:revision or an ARN", ErrInvalidTarget)
+		}
+		taskDef, err = store.Get(ctx, family, revision)
+	} else {
+		taskDef, err = store.GetLatest(ctx, identifier)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task definition %s: %w", identifier, err)
+	}
+	if taskDef == nil {
+		return nil, fmt.Errorf("task definition %s: %w", identifier, storage.ErrResourceNotFound)
+	}
+	return taskDef, nil
+}
+
+// taskDefinitionFromSpec converts a RegisterTaskDefinition request into the
+// task definition it would register
+func taskDefinitionFromSpec(spec json.RawMessage) (*storage.TaskDefinition, error) {
+	var req generated.RegisterTaskDefinitionRequest
+	if err := json.Unmarshal(spec, &req); err != nil {
+		return nil, fmt.Errorf("%w: invalid task definition spec: %v", ErrInvalidTarget, err)
+	}
+	if len(req.ContainerDefinitions) == 0 {
+		return nil, fmt.Errorf("%w: task definition spec has no container definitions", ErrInvalidTarget)
+	}
+
+	taskDef := &storage.TaskDefinition{Family: req.Family, NetworkMode: "bridge"}
+	if req.NetworkMode != nil {
+		taskDef.NetworkMode = string(*req.NetworkMode)
+	}
+	if req.Cpu != nil {
+		taskDef.CPU = *req.Cpu
+	}
+	if req.Memory != nil {
+		taskDef.Memory = *req.Memory
+	}
+	if req.TaskRoleArn != nil {
+		taskDef.TaskRoleARN = *req.TaskRoleArn
+	}
+	if req.ExecutionRoleArn != nil {
+		taskDef.ExecutionRoleARN = *req.ExecutionRoleArn
+	}
+
+	fields := []struct {
+		value  interface{}
+		set    bool
+		target *string
+	}{
+		{req.ContainerDefinitions, true, &taskDef.ContainerDefinitions},
+		{req.Volumes, len(req.Volumes) > 0, &taskDef.Volumes},
+		{req.PlacementConstraints, len(req.PlacementConstraints) > 0, &taskDef.PlacementConstraints},
+		{req.RequiresCompatibilities, len(req.RequiresCompatibilities) > 0, &taskDef.RequiresCompatibilities},
+		{req.RuntimePlatform, req.RuntimePlatform != nil, &taskDef.RuntimePlatform},
+		{req.EphemeralStorage, req.EphemeralStorage != nil, &taskDef.EphemeralStorage},
+	}
+	for _, field := range fields {
+		if !field.set {
+			continue
+		}
+		data, err := json.Marshal(field.value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode task definition spec: %w", err)
+		}
+		*field.target = string(data)
+	}
+	return taskDef, nil
+}
+
+// taskDefinitionName returns family:revision, or the family of an
+// unregistered task definition
+func taskDefinitionName(taskDef *storage.TaskDefinition) string {
+	if taskDef.Revision == 0 {
+		return taskDef.Family + " (spec)"
+	}
+	return fmt.Sprintf("%s:%d", taskDef.Family, taskDef.Revision)
+}
+
+// diffService compares the service level fields
+func diffService(current, target *storage.Service) []Change {
+	var changes []Change
+	add := func(field, currentValue, targetValue string) {
+		if currentValue != targetValue {
+			changes = append(changes, Change{Section: "service", Field: field, Current: currentValue, Target: targetValue})
+		}
+	}
+	add("desiredCount", strconv.Itoa(current.DesiredCount), strconv.Itoa(target.DesiredCount))
+	add("launchType", current.LaunchType, target.LaunchType)
+	add("platformVersion", current.PlatformVersion, target.PlatformVersion)
+	add("loadBalancers", formatLoadBalancers(current.LoadBalancers), formatLoadBalancers(target.LoadBalancers))
+	add("networkConfiguration", normalizeJSON(current.NetworkConfiguration), normalizeJSON(target.NetworkConfiguration))
+	return changes
+}
+
+// diffTaskDefinition compares the task level fields of two task definitions
+func diffTaskDefinition(current, target *storage.TaskDefinition) []Change {
+	var changes []Change
+	add := func(field, currentValue, targetValue string) {
+		if currentValue != targetValue {
+			changes = append(changes, Change{Section: "task", Field: field, Current: currentValue, Target: targetValue})
+		}
+	}
+	add("taskDefinition", taskDefinitionName(current), taskDefinitionName(target))
+	add("cpu", current.CPU, target.CPU)
+	add("memory", current.Memory, target.Memory)
+	add("networkMode", current.NetworkMode, target.NetworkMode)
+	add("taskRoleArn", current.TaskRoleARN, target.TaskRoleARN)
+	add("executionRoleArn", current.ExecutionRoleARN, target.ExecutionRoleARN)
+	add("volumes", normalizeJSON(current.Volumes), normalizeJSON(target.Volumes))
+	add("runtimePlatform", normalizeJSON(current.RuntimePlatform), normalizeJSON(target.RuntimePlatform))
+	return changes
+}
+
+// diffContainers compares the containers of two task definitions by name
+func diffContainers(current, target *storage.TaskDefinition) ([]Change, error) {
+	currentContainers, err := parseContainers(current)
+	if err != nil {
+		return nil, err
+	}
+	targetContainers, err := parseContainers(target)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool)
+	for name := range currentContainers {
+		names[name] = true
+	}
+	for name := range targetContainers {
+		names[name] = true
+	}
+
+	var changes []Change
+	for _, name := range sortedKeys(names) {
+		currentContainer, inCurrent := currentContainers[name]
+		targetContainer, inTarget := targetContainers[name]
+		switch {
+		case !inCurrent:
+			changes = append(changes, Change{Section: "containers", Field: name, Target: "added (" + stringValue(targetContainer.Image) + ")"})
+		case !inTarget:
+			changes = append(changes, Change{Section: "containers", Field: name, Current: "removed (" + stringValue(currentContainer.Image) + ")"})
+		default:
+			changes = append(changes, diffContainer(name, currentContainer, targetContainer)...)
+		}
+	}
+	return changes, nil
+}
+
+// diffContainer compares two definitions of the same container
+func diffContainer(name string, current, target generated.ContainerDefinition) []Change {
+	section := "container " + name
+	var changes []Change
+	add := func(field, currentValue, targetValue string) {
+		if currentValue != targetValue {
+			changes = append(changes, Change{Section: section, Field: field, Current: currentValue, Target: targetValue})
+		}
+	}
+	add("image", stringValue(current.Image), stringValue(target.Image))
+	add("cpu", int32Value(current.Cpu), int32Value(target.Cpu))
+	add("memory", int32Value(current.Memory), int32Value(target.Memory))
+	add("memoryReservation", int32Value(current.MemoryReservation), int32Value(target.MemoryReservation))
+	add("essential", essentialValue(current.Essential), essentialValue(target.Essential))
+	add("command", formatJSON(current.Command), formatJSON(target.Command))
+	add("entryPoint", formatJSON(current.EntryPoint), formatJSON(target.EntryPoint))
+	add("portMappings", formatPortMappings(current.PortMappings), formatPortMappings(target.PortMappings))
+
+	currentEnv, targetEnv := environmentMap(current.Environment), environmentMap(target.Environment)
+	for _, key := range sortedKeys(currentEnv, targetEnv) {
+		add("environment."+key, currentEnv[key], targetEnv[key])
+	}
+	currentSecrets, targetSecrets := secretMap(current.Secrets), secretMap(target.Secrets)
+	for _, key := range sortedKeys(currentSecrets, targetSecrets) {
+		add("secrets."+key, currentSecrets[key], targetSecrets[key])
+	}
+	return changes
+}
+
+// parseContainers returns the container definitions of a task definition by name
+func parseContainers(taskDef *storage.TaskDefinition) (map[string]generated.ContainerDefinition, error) {
+	var definitions []generated.ContainerDefinition
+	if err := json.Unmarshal([]byte(taskDef.ContainerDefinitions), &definitions); err != nil {
+		return nil, fmt.Errorf("failed to parse container definitions of %s: %w", taskDefinitionName(taskDef), err)
+	}
+	containers := make(map[string]generated.ContainerDefinition, len(definitions))
+	for _, definition := range definitions {
+		containers[stringValue(definition.Name)] = definition
+	}
+	return containers, nil
+}
+
+func environmentMap(environment []generated.KeyValuePair) map[string]string {
+	values := make(map[string]string, len(environment))
+	for _, pair := range environment {
+		values[stringValue(pair.Name)] = stringValue(pair.Value)
+	}
+	return values
+}
+
+func secretMap(secrets []generated.Secret) map[string]string {
+	values := make(map[string]string, len(secrets))
+	for _, secret := range secrets {
+		values[secret.Name] = secret.ValueFrom
+	}
+	return values
+}
+
+// formatPortMappings lists port mappings as containerPort[:hostPort]/protocol
+func formatPortMappings(mappings []generated.PortMapping) string {
+	formatted := make([]string, 0, len(mappings))
+	for _, mapping := range mappings {
+		port := int32Value(mapping.ContainerPort)
+		if hostPort := int32Value(mapping.HostPort); hostPort != "" && hostPort != "0" && hostPort != port {
+			port += ":" + hostPort
+		}
+		protocol := "tcp"
+		if mapping.Protocol != nil {
+			protocol = string(*mapping.Protocol)
+		}
+		formatted = append(formatted, port+"/"+protocol)
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// formatLoadBalancers lists the load balancers of a service as
+// target:container:port
+func formatLoadBalancers(data string) string {
+	var loadBalancers []generated.LoadBalancer
+	if data == "" || json.Unmarshal([]byte(data), &loadBalancers) != nil {
+		return normalizeJSON(data)
+	}
+	formatted := make([]string, 0, len(loadBalancers))
+	for _, lb := range loadBalancers {
+		target := stringValue(lb.TargetGroupArn)
+		if target == "" {
+			target = stringValue(lb.LoadBalancerName)
+		}
+		formatted = append(formatted, fmt.Sprintf("%s -> %s:%s", target, stringValue(lb.ContainerName), int32Value(lb.ContainerPort)))
+	}
+	sort.Strings(formatted)
+	return strings.Join(formatted, ", ")
+}
+
+// normalizeJSON re-encodes a JSON document so that formatting differences
+// are not reported as changes. Empty documents are returned as "".
+func normalizeJSON(data string) string {
+	if data == "" || data == "null" || data == "[]" || data == "{}" {
+		return ""
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		return data
+	}
+	return formatJSON(value)
+}
+
+func formatJSON(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil || string(data) == "null" {
+		return ""
+	}
+	return string(data)
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func int32Value(i *int32) string {
+	if i == nil {
+		return ""
+	}
+	return strconv.Itoa(int(*i))
+}
+
+// essentialValue returns the essential flag, containers are essential
+// unless they opt out
+func essentialValue(essential *bool) string {
+	return strconv.FormatBool(essential == nil || *essential)
+}
+
+// sortedKeys returns the sorted union of the keys of maps
+func sortedKeys[V any](maps ...map[string]V) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range maps {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,175 @@
+package servicediff_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/mocks"
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/servicediff"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+var _ = Describe("Differ", func() {
+	const clusterARN = "arn:aws:ecs:us-east-1:123456789012:cluster/default"
+
+	var (
+		ctx    context.Context
+		store  *mocks.MockStorage
+		differ *servicediff.Differ
+	)
+
+	register := func(containerDefinitions string) *storage.TaskDefinition {
+		taskDef, err := store.TaskDefinitionStore().Register(ctx, &storage.TaskDefinition{
+			Family:               "app",
+			NetworkMode:          "awsvpc",
+			CPU:                  "256",
+			Memory:               "512",
+			ContainerDefinitions: containerDefinitions,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		return taskDef
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		store = mocks.NewMockStorage()
+		store.SetClusterStore(mocks.NewMockClusterStore())
+		store.SetTaskDefinitionStore(mocks.NewMockTaskDefinitionStore())
+		store.SetServiceStore(mocks.NewMockServiceStore())
+		differ = servicediff.NewDiffer(store, converters.NewServiceConverter("us-east-1", "123456789012"))
+
+		Expect(store.ClusterStore().Create(ctx, &storage.Cluster{
+			ARN:       clusterARN,
+			Name:      "default",
+			Status:    "ACTIVE",
+			Region:    "us-east-1",
+			AccountID: "123456789012",
+		})).To(Succeed())
+
+		current := register(`[{
+			"name": "app",
+			"image": "nginx:1.25",
+			"portMappings": [{"containerPort": 80, "protocol": "tcp"}],
+			"environment": [{"name": "LOG_LEVEL", "value": "info"}, {"name": "REGION", "value": "us-east-1"}],
+			"secrets": [{"name": "DB_PASSWORD", "valueFrom": "arn:aws:ssm:us-east-1:123456789012:parameter/db-password"}]
+		}]`)
+		register(`[{
+			"name": "app",
+			"image": "nginx:1.27",
+			"portMappings": [{"containerPort": 80, "protocol": "tcp"}],
+			"environment": [{"name": "REGION", "value": "us-east-1"}, {"name": "LOG_LEVEL", "value": "debug"}, {"name": "FEATURE", "value": "on"}]
+		}]`)
+
+		Expect(store.ServiceStore().Create(ctx, &storage.Service{
+			ARN:                "arn:aws:ecs:us-east-1:123456789012:service/default/web",
+			ServiceName:        "web",
+			ClusterARN:         clusterARN,
+			TaskDefinitionARN:  current.ARN,
+			DesiredCount:       2,
+			LaunchType:         "FARGATE",
+			SchedulingStrategy: "REPLICA",
+			Status:             "ACTIVE",
+			LoadBalancers:      `[{"targetGroupArn": "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/web/1", "containerName": "app", "containerPort": 80}]`,
+			Region:             "us-east-1",
+			AccountID:          "123456789012",
+		})).To(Succeed())
+	})
+
+	It("should report the changes of another revision", func() {
+		report, err := differ.Diff(ctx, "default", "web", servicediff.Target{TaskDefinition: "app:2"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(report.CurrentTaskDefinition).To(Equal("app:1"))
+		Expect(report.TargetTaskDefinition).To(Equal("app:2"))
+		Expect(report.Changes).To(ConsistOf(
+			servicediff.Change{Section: "task", Field: "taskDefinition", Current: "app:1", Target: "app:2"},
+			servicediff.Change{Section: "container app", Field: "image", Current: "nginx:1.25", Target: "nginx:1.27"},
+			servicediff.Change{Section: "container app", Field: "environment.FEATURE", Target: "on"},
+			servicediff.Change{Section: "container app", Field: "environment.LOG_LEVEL", Current: "info", Target: "debug"},
+			servicediff.Change{Section: "container app", Field: "secrets.DB_PASSWORD", Current: "arn:aws:ssm:us-east-1:123456789012:parameter/db-password"},
+		))
+
+		Expect(report.KubernetesChanges).To(ContainElement(servicediff.Change{
+			Section: "Deployment web",
+			Field:   "spec.template.spec.containers[app].image",
+			Current: "nginx:1.25",
+			Target:  "nginx:1.27",
+		}))
+		Expect(report.KubernetesChanges).To(ContainElement(SatisfyAll(
+			HaveField("Field", "spec.template.spec.containers[app].env[LOG_LEVEL].value"),
+			HaveField("Current", "info"),
+			HaveField("Target", "debug"),
+		)))
+		for _, change := range report.KubernetesChanges {
+			Expect(change.Field).NotTo(ContainSubstring("env[REGION]"), "reordered env vars should not be reported")
+		}
+	})
+
+	It("should resolve the latest revision of a family", func() {
+		report, err := differ.Diff(ctx, "default", "web", servicediff.Target{TaskDefinition: "app"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.TargetTaskDefinition).To(Equal("app:2"))
+	})
+
+	It("should report no changes for the current revision", func() {
+		report, err := differ.Diff(ctx, "default", "web", servicediff.Target{TaskDefinition: "app:1"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Changes).To(BeEmpty())
+		Expect(report.KubernetesChanges).To(BeEmpty())
+	})
+
+	It("should apply a service spec", func() {
+		report, err := differ.Diff(ctx, "default", "web", servicediff.Target{Spec: json.RawMessage(`{
+			"desiredCount": 3,
+			"loadBalancers": [{"targetGroupArn": "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/web/2", "containerName": "app", "containerPort": 80}]
+		}`)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Changes).To(ConsistOf(
+			servicediff.Change{Section: "service", Field: "desiredCount", Current: "2", Target: "3"},
+			servicediff.Change{
+				Section: "service",
+				Field:   "loadBalancers",
+				Current: "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/web/1 -> app:80",
+				Target:  "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/web/2 -> app:80",
+			},
+		))
+		Expect(report.KubernetesChanges).To(ContainElement(servicediff.Change{Section: "Deployment web", Field: "spec.replicas", Current: "2", Target: "3"}))
+	})
+
+	It("should compare with a task definition spec", func() {
+		report, err := differ.Diff(ctx, "default", "web", servicediff.Target{Spec: json.RawMessage(`{
+			"family": "app",
+			"networkMode": "awsvpc",
+			"cpu": "512",
+			"memory": "512",
+			"containerDefinitions": [
+				{"name": "app", "image": "nginx:1.25", "portMappings": [{"containerPort": 80, "protocol": "tcp"}],
+				 "environment": [{"name": "LOG_LEVEL", "value": "info"}, {"name": "REGION", "value": "us-east-1"}],
+				 "secrets": [{"name": "DB_PASSWORD", "valueFrom": "arn:aws:ssm:us-east-1:123456789012:parameter/db-password"}]},
+				{"name": "sidecar", "image": "envoyproxy/envoy:v1.30", "essential": false}
+			]
+		}`)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.TargetTaskDefinition).To(Equal("app (spec)"))
+		Expect(report.Changes).To(ConsistOf(
+			servicediff.Change{Section: "task", Field: "taskDefinition", Current: "app:1", Target: "app (spec)"},
+			servicediff.Change{Section: "task", Field: "cpu", Current: "256", Target: "512"},
+			servicediff.Change{Section: "containers", Field: "sidecar", Target: "added (envoyproxy/envoy:v1.30)"},
+		))
+	})
+
+	It("should reject a target without task definition or spec", func() {
+		_, err := differ.Diff(ctx, "default", "web", servicediff.Target{})
+		Expect(errors.Is(err, servicediff.ErrInvalidTarget)).To(BeTrue())
+	})
+
+	It("should fail for an unknown service", func() {
+		_, err := differ.Diff(ctx, "default", "missing", servicediff.Target{TaskDefinition: "app:2"})
+		Expect(err).To(HaveOccurred())
+	})
+})
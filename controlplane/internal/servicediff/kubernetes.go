@@ -0,0 +1,132 @@
+package servicediff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// diffKubernetes converts the current and the target state and compares the
+// resulting Deployments and Services field by field
+func (d *Differ) diffKubernetes(cluster *storage.Cluster, current *storage.Service, currentTaskDef *storage.TaskDefinition, target *storage.Service, targetTaskDef *storage.TaskDefinition) ([]Change, error) {
+	currentObjects, err := d.convert(cluster, current, currentTaskDef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert the current state: %w", err)
+	}
+	targetObjects, err := d.convert(cluster, target, targetTaskDef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert the target state: %w", err)
+	}
+
+	changes := []Change{}
+	for _, section := range sortedKeys(currentObjects, targetObjects) {
+		currentFields, targetFields := currentObjects[section], targetObjects[section]
+		for _, field := range sortedKeys(currentFields, targetFields) {
+			if currentFields[field] != targetFields[field] {
+				changes = append(changes, Change{Section: section, Field: field, Current: currentFields[field], Target: targetFields[field]})
+			}
+		}
+	}
+	return changes, nil
+}
+
+// convert returns the flattened fields of the Kubernetes objects of a service
+// by "Kind name"
+func (d *Differ) convert(cluster *storage.Cluster, service *storage.Service, taskDef *storage.TaskDefinition) (map[string]map[string]string, error) {
+	var networkConfig *generated.NetworkConfiguration
+	if service.NetworkConfiguration != "" && service.NetworkConfiguration != "null" {
+		networkConfig = &generated.NetworkConfiguration{}
+		if err := json.Unmarshal([]byte(service.NetworkConfiguration), networkConfig); err != nil {
+			return nil, fmt.Errorf("failed to parse network configuration: %w", err)
+		}
+	}
+
+	deployment, kubeService, err := d.converter.ConvertServiceToDeploymentWithNetworkConfig(service, taskDef, cluster, networkConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make(map[string]map[string]string)
+	if deployment != nil {
+		fields, err := flattenObject(deployment)
+		if err != nil {
+			return nil, err
+		}
+		objects["Deployment "+deployment.Name] = fields
+	}
+	if kubeService != nil {
+		fields, err := flattenObject(kubeService)
+		if err != nil {
+			return nil, err
+		}
+		objects["Service "+kubeService.Name] = fields
+	}
+	return objects, nil
+}
+
+// flattenObject returns the leaf values of an object by path, without its
+// status and creation timestamps
+func flattenObject(object interface{}) (map[string]string, error) {
+	data, err := json.Marshal(object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal object: %w", err)
+	}
+	var value map[string]interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("failed to marshal object: %w", err)
+	}
+	delete(value, "status")
+
+	fields := make(map[string]string)
+	flatten("", value, fields)
+	for path := range fields {
+		if path == "metadata.creationTimestamp" || path == "spec.template.metadata.creationTimestamp" {
+			delete(fields, path)
+		}
+	}
+	return fields, nil
+}
+
+// flatten records the leaves of a decoded JSON value. Elements of lists of
+// named objects, such as containers, env vars and ports, are keyed by name so
+// that reordering or inserting one does not change the path of the others.
+func flatten(path string, value interface{}, fields map[string]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			flatten(joinPath(path, key), v[key], fields)
+		}
+	case []interface{}:
+		for i, element := range v {
+			key := "[" + strconv.Itoa(i) + "]"
+			if m, ok := element.(map[string]interface{}); ok {
+				if name, ok := m["name"].(string); ok && name != "" {
+					key = "[" + name + "]"
+				}
+			}
+			flatten(path+key, element, fields)
+		}
+	case nil:
+	default:
+		fields[path] = formatJSON(v)
+		if s, ok := v.(string); ok {
+			fields[path] = s
+		}
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,13 @@
+package servicediff_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestServiceDiff(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ServiceDiff Suite")
+}
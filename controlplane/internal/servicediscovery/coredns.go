@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"path"
 	"strings"
 	"text/template"
 
@@ -20,33 +21,16 @@ const (
 	customCoreDNSConfigMap = "coredns-custom"
 )
 
-// coreDNSConfigTemplate is the template for CoreDNS configuration
+// coreDNSConfigTemplate is the template for CoreDNS configuration. The
+// namespace is served authoritatively from the zone file KECS generates from
+// the registered instances, so answers carry the queried name and no CNAME
+// chain into cluster.local, which Go's resolver rejects.
 const coreDNSConfigTemplate = `{{.NamespaceName}}:53 {
     errors
-    health {
-        lameduck 5s
+    file {{.ZoneFile}} {{.NamespaceName}} {
+        reload 5s
     }
-    ready
-
-    # Rewrite Service Discovery queries to default namespace
-    # Note: Only rewrite the query name, not the answer
-    # This prevents CNAME chain issues with Go's DNS resolver
-    rewrite stop {
-        name regex (.*)\.{{.NamespaceNameEscaped}} {1}.{{.K8sNamespace}}.svc.cluster.local
-    }
-
-    kubernetes cluster.local in-addr.arpa ip6.arpa {
-        pods insecure
-        fallthrough in-addr.arpa ip6.arpa
-    }
-{{if .LocalStackEndpoint}}
-    forward . {{.LocalStackEndpoint}} {
-        except kubernetes.default.svc.cluster.local
-    }
-{{end}}
-    cache 30
-    loop
-    reload
+    cache 5
     loadbalance
 }
 `
@@ -108,12 +92,13 @@ func (m *manager) updateCoreDNSConfig(ctx context.Context, namespace *Namespace)
 			logging.Warn("Removing duplicate CoreDNS configuration for domain",
 				"domain", namespace.Name,
 				"existingKey", key,
-				"newKey", fmt.Sprintf("kecs-%s.server", namespace.ID))
+				"newKey", serverBlockKey(namespace.ID))
 			delete(customCM.Data, key)
 		}
 	}
 
-	customCM.Data[fmt.Sprintf("kecs-%s.server", namespace.ID)] = corefile
+	customCM.Data[serverBlockKey(namespace.ID)] = corefile
+	customCM.Data[zoneFileKey(namespace.ID)] = m.buildNamespaceZone(namespace)
 
 	// Update ConfigMap
 	_, err = m.kubeClient.CoreV1().ConfigMaps(coreDNSNamespace).Update(ctx, customCM, metav1.UpdateOptions{})
@@ -144,8 +129,9 @@ func (m *manager) removeCoreDNSConfig(ctx context.Context, namespaceID string) e
 		return fmt.Errorf("failed to get custom CoreDNS ConfigMap: %w", err)
 	}
 
-	// Remove configuration for this namespace
-	delete(customCM.Data, fmt.Sprintf("kecs-%s.server", namespaceID))
+	// Remove configuration and zone of this namespace
+	delete(customCM.Data, serverBlockKey(namespaceID))
+	delete(customCM.Data, zoneFileKey(namespaceID))
 
 	// Update ConfigMap
 	_, err = m.kubeClient.CoreV1().ConfigMaps(coreDNSNamespace).Update(ctx, customCM, metav1.UpdateOptions{})
@@ -164,34 +150,15 @@ func (m *manager) removeCoreDNSConfig(ctx context.Context, namespaceID string) e
 
 // coreDNSConfigData holds the data for CoreDNS configuration template
 type coreDNSConfigData struct {
-	NamespaceName        string
-	NamespaceNameEscaped string
-	K8sNamespace         string
-	K8sNamespaceEscaped  string
-	LocalStackEndpoint   string
+	NamespaceName string
+	ZoneFile      string
 }
 
 // buildCoreDNSConfig builds CoreDNS configuration for a namespace
 func (m *manager) buildCoreDNSConfig(namespace *Namespace) string {
-	// Get Kubernetes namespace for this DNS namespace
-	k8sNamespace := m.dnsToK8sNamespace[namespace.Name]
-	if k8sNamespace == "" {
-		k8sNamespace = "default"
-	}
-
-	// Get LocalStack endpoint if Route53 integration is available
-	localstackEndpoint := ""
-	if m.route53Manager != nil && namespace.HostedZoneID != "" {
-		localstackEndpoint = m.getLocalStackDNSEndpoint()
-	}
-
-	// Prepare template data
 	data := coreDNSConfigData{
-		NamespaceName:        namespace.Name,
-		NamespaceNameEscaped: escapeRegex(namespace.Name),
-		K8sNamespace:         k8sNamespace,
-		K8sNamespaceEscaped:  escapeRegex(k8sNamespace),
-		LocalStackEndpoint:   localstackEndpoint,
+		NamespaceName: namespace.Name,
+		ZoneFile:      path.Join(coreDNSCustomDir, zoneFileKey(namespace.ID)),
 	}
 
 	// Execute template
@@ -226,14 +193,6 @@ func (m *manager) restartCoreDNS(ctx context.Context) error {
 	return nil
 }
 
-// getLocalStackDNSEndpoint gets the LocalStack DNS endpoint
-func (m *manager) getLocalStackDNSEndpoint() string {
-	// Check if LocalStack is running and get its DNS endpoint
-	// For now, return a default value
-	// TODO: Get actual LocalStack service endpoint
-	return "" // Disabled for now until LocalStack DNS is properly configured
-}
-
 // createServiceDNSAlias creates a DNS alias for the service in Kubernetes
 func (m *manager) createServiceDNSAlias(ctx context.Context, namespace *Namespace, service *Service) error {
 	if m.kubeClient == nil {
@@ -345,26 +304,3 @@ func (m *manager) removeServiceDNSAlias(ctx context.Context, namespace *Namespac
 	logging.Info("Removed DNS alias service", "name", service.Name, "namespace", k8sNamespace)
 	return nil
 }
-
-// escapeRegex escapes special regex characters in a string for use in CoreDNS rewrite rules
-func escapeRegex(s string) string {
-	// Escape all regex special characters to prevent regex injection
-	// Note: backslash must be escaped first to avoid double-escaping
-	replacer := strings.NewReplacer(
-		"\\", "\\\\",
-		".", "\\.",
-		"+", "\\+",
-		"*", "\\*",
-		"?", "\\?",
-		"[", "\\[",
-		"]", "\\]",
-		"(", "\\(",
-		")", "\\)",
-		"{", "\\{",
-		"}", "\\}",
-		"^", "\\^",
-		"$", "\\$",
-		"|", "\\|",
-	)
-	return replacer.Replace(s)
-}
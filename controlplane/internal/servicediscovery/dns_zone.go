@@ -0,0 +1,215 @@
+package servicediscovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+const (
+	// coreDNSCustomDir is where CoreDNS mounts the coredns-custom ConfigMap
+	coreDNSCustomDir = "/etc/coredns/custom"
+
+	// defaultRecordTTL is the TTL of records of services without a DNS config
+	defaultRecordTTL = 10
+)
+
+// serverBlockKey returns the coredns-custom key of the server block of a namespace
+func serverBlockKey(namespaceID string) string {
+	return fmt.Sprintf("kecs-%s.server", namespaceID)
+}
+
+// zoneFileKey returns the coredns-custom key of the zone file of a namespace
+func zoneFileKey(namespaceID string) string {
+	return fmt.Sprintf("kecs-%s.db", namespaceID)
+}
+
+// zoneService is a service of a zone with the instances it answers with
+type zoneService struct {
+	service   *Service
+	instances []*Instance
+}
+
+// buildZoneFile renders the zone of a DNS namespace with the records of its
+// services, the way Cloud Map fills the Route 53 hosted zone: every record
+// type of the service DNS config is answered directly from the registered
+// instances, so clients never follow CNAME chains into cluster.local.
+// Instances reported UNHEALTHY are left out.
+func buildZoneFile(namespace *Namespace, services []zoneService, serial uint32) string {
+	var b strings.Builder
+	origin := namespace.Name + "."
+
+	fmt.Fprintf(&b, "$ORIGIN %s\n", origin)
+	fmt.Fprintf(&b, "$TTL %d\n", defaultRecordTTL)
+	fmt.Fprintf(&b, "@ IN SOA ns.%s hostmaster.%s %d 60 30 86400 %d\n", origin, origin, serial, defaultRecordTTL)
+	fmt.Fprintf(&b, "@ IN NS ns.%s\n", origin)
+
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].service.Name < services[j].service.Name
+	})
+	for _, zs := range services {
+		name := strings.ToLower(zs.service.Name)
+		instances := make([]*Instance, 0, len(zs.instances))
+		for _, instance := range zs.instances {
+			if instance.HealthStatus != "UNHEALTHY" {
+				instances = append(instances, instance)
+			}
+		}
+		sort.Slice(instances, func(i, j int) bool {
+			return instances[i].ID < instances[j].ID
+		})
+
+		for _, record := range serviceDNSRecords(zs.service) {
+			ttl := record.TTL
+			if ttl <= 0 {
+				ttl = defaultRecordTTL
+			}
+
+			switch record.Type {
+			case "A":
+				for _, instance := range instances {
+					if ip := instanceIPv4(instance); ip != "" {
+						fmt.Fprintf(&b, "%s %d IN A %s\n", name, ttl, ip)
+					}
+				}
+			case "AAAA":
+				for _, instance := range instances {
+					if ip := instanceIPv6(instance); ip != "" {
+						fmt.Fprintf(&b, "%s %d IN AAAA %s\n", name, ttl, ip)
+					}
+				}
+			case "SRV":
+				// SRV targets are per-instance hosts, as in Cloud Map
+				for _, instance := range instances {
+					ip := instanceIPv4(instance)
+					port := instancePort(instance)
+					if ip == "" || port == 0 {
+						continue
+					}
+					target := fmt.Sprintf("%s.%s", dnsLabel(instance.ID), name)
+					fmt.Fprintf(&b, "%s %d IN SRV 1 1 %d %s\n", name, ttl, port, target)
+					fmt.Fprintf(&b, "%s %d IN A %s\n", target, ttl, ip)
+				}
+			case "CNAME":
+				// A CNAME service has a single instance
+				for _, instance := range instances {
+					if cname := instance.Attributes["AWS_INSTANCE_CNAME"]; cname != "" {
+						fmt.Fprintf(&b, "%s %d IN CNAME %s\n", name, ttl, strings.TrimSuffix(cname, ".")+".")
+						break
+					}
+				}
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// serviceDNSRecords returns the DNS records of a service, A records for
+// services registered without a DNS config
+func serviceDNSRecords(service *Service) []DNSRecord {
+	if service.DNSConfig == nil || len(service.DNSConfig.DNSRecords) == 0 {
+		return []DNSRecord{{Type: "A", TTL: defaultRecordTTL}}
+	}
+	return service.DNSConfig.DNSRecords
+}
+
+// instanceIPv4 returns the IPv4 address of an instance, if valid
+func instanceIPv4(instance *Instance) string {
+	ip := net.ParseIP(instance.Attributes["AWS_INSTANCE_IPV4"])
+	if ip == nil || ip.To4() == nil {
+		return ""
+	}
+	return ip.String()
+}
+
+// instanceIPv6 returns the IPv6 address of an instance, if valid
+func instanceIPv6(instance *Instance) string {
+	ip := net.ParseIP(instance.Attributes["AWS_INSTANCE_IPV6"])
+	if ip == nil || ip.To4() != nil {
+		return ""
+	}
+	return ip.String()
+}
+
+// dnsLabel turns an instance ID into a DNS label
+func dnsLabel(id string) string {
+	label := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		default:
+			return '-'
+		}
+	}, id)
+	if len(label) > 63 {
+		label = label[:63]
+	}
+	return strings.Trim(label, "-")
+}
+
+// nextZoneSerial returns a SOA serial greater than the last one, which makes
+// CoreDNS reload the zone
+func (m *manager) nextZoneSerial() uint32 {
+	serial := uint32(time.Now().Unix())
+	if serial <= m.zoneSerial {
+		serial = m.zoneSerial + 1
+	}
+	m.zoneSerial = serial
+	return serial
+}
+
+// buildNamespaceZone renders the zone file of a namespace. The caller must
+// hold m.mu.
+func (m *manager) buildNamespaceZone(namespace *Namespace) string {
+	var services []zoneService
+	for _, service := range m.services {
+		if service.NamespaceID != namespace.ID {
+			continue
+		}
+		zs := zoneService{service: service}
+		for _, instance := range m.instances[service.ID] {
+			zs.instances = append(zs.instances, instance)
+		}
+		services = append(services, zs)
+	}
+	return buildZoneFile(namespace, services, m.nextZoneSerial())
+}
+
+// refreshZone rewrites the zone file of a namespace after its services or
+// instances changed. CoreDNS reloads the zone on its own, no restart is
+// needed. The caller must hold m.mu.
+func (m *manager) refreshZone(ctx context.Context, namespaceID string) {
+	if m.kubeClient == nil {
+		return
+	}
+	namespace, exists := m.namespaces[namespaceID]
+	if !exists || namespace.Type == NamespaceTypeHTTP {
+		return
+	}
+
+	customCM, err := m.kubeClient.CoreV1().ConfigMaps(coreDNSNamespace).Get(ctx, customCoreDNSConfigMap, metav1.GetOptions{})
+	if err != nil {
+		logging.Warn("Failed to get custom CoreDNS ConfigMap", "namespace", namespace.Name, "error", err)
+		return
+	}
+	if customCM.Data == nil {
+		customCM.Data = make(map[string]string)
+	}
+	customCM.Data[zoneFileKey(namespaceID)] = m.buildNamespaceZone(namespace)
+
+	if _, err := m.kubeClient.CoreV1().ConfigMaps(coreDNSNamespace).Update(ctx, customCM, metav1.UpdateOptions{}); err != nil {
+		logging.Warn("Failed to update DNS zone", "namespace", namespace.Name, "error", err)
+		return
+	}
+	logging.Debug("Updated DNS zone", "namespace", namespace.Name)
+}
@@ -0,0 +1,151 @@
+package servicediscovery
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("DNS zone", func() {
+	namespace := &Namespace{ID: "ns-123", Name: "demo.local", Type: NamespaceTypeDNSPrivate}
+
+	instance := func(id, ip, port, status string) *Instance {
+		return &Instance{
+			ID:           id,
+			HealthStatus: status,
+			Attributes: map[string]string{
+				"AWS_INSTANCE_IPV4": ip,
+				"AWS_INSTANCE_PORT": port,
+			},
+		}
+	}
+
+	Describe("buildZoneFile", func() {
+		It("should answer A and SRV records from the registered instances", func() {
+			services := []zoneService{{
+				service: &Service{
+					Name: "backend",
+					DNSConfig: &DNSConfig{DNSRecords: []DNSRecord{
+						{Type: "A", TTL: 60},
+						{Type: "SRV", TTL: 60},
+					}},
+				},
+				instances: []*Instance{
+					instance("task-b", "10.42.0.11", "8080", "HEALTHY"),
+					instance("task-a", "10.42.0.10", "8080", "UNKNOWN"),
+					instance("task-c", "10.42.0.12", "8080", "UNHEALTHY"),
+				},
+			}}
+
+			zone := buildZoneFile(namespace, services, 42)
+			Expect(zone).To(ContainSubstring("$ORIGIN demo.local.\n"))
+			Expect(zone).To(ContainSubstring("@ IN SOA ns.demo.local. hostmaster.demo.local. 42 "))
+			Expect(zone).To(ContainSubstring("backend 60 IN A 10.42.0.10\nbackend 60 IN A 10.42.0.11\n"))
+			Expect(zone).To(ContainSubstring("backend 60 IN SRV 1 1 8080 task-a.backend\n"))
+			Expect(zone).To(ContainSubstring("task-a.backend 60 IN A 10.42.0.10\n"))
+			Expect(zone).NotTo(ContainSubstring("10.42.0.12"))
+			Expect(zone).NotTo(ContainSubstring("cluster.local"))
+		})
+
+		It("should default to A records without a DNS config", func() {
+			services := []zoneService{{
+				service:   &Service{Name: "Web"},
+				instances: []*Instance{instance("task-a", "not-an-ip", "", ""), instance("task-b", "10.42.0.20", "", "")},
+			}}
+
+			zone := buildZoneFile(namespace, services, 1)
+			Expect(zone).To(ContainSubstring("web 10 IN A 10.42.0.20\n"))
+			Expect(zone).NotTo(ContainSubstring("not-an-ip"))
+		})
+	})
+
+	Describe("manager", func() {
+		var (
+			ctx        context.Context
+			fakeClient *fake.Clientset
+			mgr        Manager
+		)
+
+		customConfigMapData := func() map[string]string {
+			cm, err := fakeClient.CoreV1().ConfigMaps(coreDNSNamespace).Get(ctx, customCoreDNSConfigMap, metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			return cm.Data
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			fakeClient = fake.NewSimpleClientset()
+			mgr = NewManager(fakeClient, "us-east-1", "123456789012", "")
+		})
+
+		It("should keep the zone of a namespace in sync with its instances", func() {
+			ns, err := mgr.CreatePrivateDnsNamespace(ctx, "demo.local", "vpc-123", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			data := customConfigMapData()
+			Expect(data).To(HaveKey(serverBlockKey(ns.ID)))
+			Expect(data[serverBlockKey(ns.ID)]).To(ContainSubstring("file /etc/coredns/custom/" + zoneFileKey(ns.ID) + " demo.local"))
+			Expect(data[serverBlockKey(ns.ID)]).NotTo(ContainSubstring("rewrite"))
+			Expect(data).To(HaveKey(zoneFileKey(ns.ID)))
+
+			Expect(mgr.CreateService(ctx, &Service{ID: "srv-1", Name: "backend", NamespaceID: ns.ID})).To(Succeed())
+			Expect(mgr.RegisterInstance(ctx, &Instance{
+				ID:           "task-a",
+				ServiceID:    "srv-1",
+				HealthStatus: "HEALTHY",
+				Attributes:   map[string]string{"AWS_INSTANCE_IPV4": "10.42.0.10"},
+			})).To(Succeed())
+			Expect(customConfigMapData()[zoneFileKey(ns.ID)]).To(ContainSubstring("backend 10 IN A 10.42.0.10"))
+
+			Expect(mgr.UpdateInstanceHealthStatus(ctx, "srv-1", "task-a", "UNHEALTHY")).To(Succeed())
+			Expect(customConfigMapData()[zoneFileKey(ns.ID)]).NotTo(ContainSubstring("10.42.0.10"))
+
+			Expect(mgr.DeregisterInstance(ctx, "srv-1", "task-a")).To(Succeed())
+			Expect(mgr.DeleteService(ctx, "srv-1")).To(Succeed())
+			Expect(mgr.DeleteNamespace(ctx, ns.ID)).To(Succeed())
+
+			data = customConfigMapData()
+			Expect(data).NotTo(HaveKey(serverBlockKey(ns.ID)))
+			Expect(data).NotTo(HaveKey(zoneFileKey(ns.ID)))
+		})
+
+		It("should publish the SRV port of the instances", func() {
+			ns, err := mgr.CreatePrivateDnsNamespace(ctx, "demo.local", "vpc-123", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mgr.CreateService(ctx, &Service{
+				ID:          "srv-1",
+				Name:        "backend",
+				NamespaceID: ns.ID,
+				DNSConfig:   &DNSConfig{DNSRecords: []DNSRecord{{Type: "SRV", TTL: 60}}},
+			})).To(Succeed())
+			Expect(mgr.RegisterInstance(ctx, &Instance{
+				ID:           "task-a",
+				ServiceID:    "srv-1",
+				HealthStatus: "HEALTHY",
+				Attributes:   map[string]string{"AWS_INSTANCE_IPV4": "10.42.0.10", "AWS_INSTANCE_PORT": "8080"},
+			})).To(Succeed())
+
+			Expect(customConfigMapData()[zoneFileKey(ns.ID)]).To(ContainSubstring("backend 60 IN SRV 1 1 8080 task-a.backend\n"))
+
+			k8sService, err := fakeClient.CoreV1().Services("demolocal").Get(ctx, "backend", metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sService.Spec.Ports).To(HaveLen(1))
+			Expect(k8sService.Spec.Ports[0].Name).To(Equal("srv"))
+			Expect(k8sService.Spec.Ports[0].Port).To(Equal(int32(8080)))
+
+			endpoints, err := fakeClient.CoreV1().Endpoints("demolocal").Get(ctx, "backend", metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(endpoints.Subsets[0].Ports[0].Name).To(Equal("srv"))
+			Expect(endpoints.Subsets[0].Ports[0].Port).To(Equal(int32(8080)))
+		})
+
+		It("should increase the zone serial on every change", func() {
+			m := mgr.(*manager)
+			first := m.nextZoneSerial()
+			Expect(m.nextZoneSerial()).To(BeNumerically(">", first))
+		})
+	})
+})
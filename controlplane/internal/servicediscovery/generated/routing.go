@@ -158,6 +158,10 @@ func (r *Router) handleCreateHttpNamespace(w http.ResponseWriter, req *http.Requ
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.CreateHttpNamespace(req.Context(), &input)
@@ -180,6 +184,10 @@ func (r *Router) handleCreatePrivateDnsNamespace(w http.ResponseWriter, req *htt
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.CreatePrivateDnsNamespace(req.Context(), &input)
@@ -202,6 +210,10 @@ func (r *Router) handleCreatePublicDnsNamespace(w http.ResponseWriter, req *http
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.CreatePublicDnsNamespace(req.Context(), &input)
@@ -224,6 +236,10 @@ func (r *Router) handleCreateService(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.CreateService(req.Context(), &input)
@@ -246,6 +262,10 @@ func (r *Router) handleDeleteNamespace(w http.ResponseWriter, req *http.Request)
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.DeleteNamespace(req.Context(), &input)
@@ -268,6 +288,10 @@ func (r *Router) handleDeleteService(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.DeleteService(req.Context(), &input)
@@ -290,6 +314,10 @@ func (r *Router) handleDeleteServiceAttributes(w http.ResponseWriter, req *http.
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.DeleteServiceAttributes(req.Context(), &input)
@@ -312,6 +340,10 @@ func (r *Router) handleDeregisterInstance(w http.ResponseWriter, req *http.Reque
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.DeregisterInstance(req.Context(), &input)
@@ -334,6 +366,10 @@ func (r *Router) handleDiscoverInstances(w http.ResponseWriter, req *http.Reques
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.DiscoverInstances(req.Context(), &input)
@@ -356,6 +392,10 @@ func (r *Router) handleDiscoverInstancesRevision(w http.ResponseWriter, req *htt
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.DiscoverInstancesRevision(req.Context(), &input)
@@ -378,6 +418,10 @@ func (r *Router) handleGetInstance(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.GetInstance(req.Context(), &input)
@@ -400,6 +444,10 @@ func (r *Router) handleGetInstancesHealthStatus(w http.ResponseWriter, req *http
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.GetInstancesHealthStatus(req.Context(), &input)
@@ -422,6 +470,10 @@ func (r *Router) handleGetNamespace(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.GetNamespace(req.Context(), &input)
@@ -444,6 +496,10 @@ func (r *Router) handleGetOperation(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.GetOperation(req.Context(), &input)
@@ -466,6 +522,10 @@ func (r *Router) handleGetService(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.GetService(req.Context(), &input)
@@ -488,6 +548,10 @@ func (r *Router) handleGetServiceAttributes(w http.ResponseWriter, req *http.Req
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.GetServiceAttributes(req.Context(), &input)
@@ -510,6 +574,10 @@ func (r *Router) handleListInstances(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.ListInstances(req.Context(), &input)
@@ -532,6 +600,10 @@ func (r *Router) handleListNamespaces(w http.ResponseWriter, req *http.Request)
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.ListNamespaces(req.Context(), &input)
@@ -554,6 +626,10 @@ func (r *Router) handleListOperations(w http.ResponseWriter, req *http.Request)
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.ListOperations(req.Context(), &input)
@@ -576,6 +652,10 @@ func (r *Router) handleListServices(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.ListServices(req.Context(), &input)
@@ -598,6 +678,10 @@ func (r *Router) handleListTagsForResource(w http.ResponseWriter, req *http.Requ
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.ListTagsForResource(req.Context(), &input)
@@ -620,6 +704,10 @@ func (r *Router) handleRegisterInstance(w http.ResponseWriter, req *http.Request
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.RegisterInstance(req.Context(), &input)
@@ -642,6 +730,10 @@ func (r *Router) handleTagResource(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.TagResource(req.Context(), &input)
@@ -664,6 +756,10 @@ func (r *Router) handleUntagResource(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.UntagResource(req.Context(), &input)
@@ -686,6 +782,10 @@ func (r *Router) handleUpdateHttpNamespace(w http.ResponseWriter, req *http.Requ
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.UpdateHttpNamespace(req.Context(), &input)
@@ -708,6 +808,10 @@ func (r *Router) handleUpdateInstanceCustomHealthStatus(w http.ResponseWriter, r
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.UpdateInstanceCustomHealthStatus(req.Context(), &input)
@@ -730,6 +834,10 @@ func (r *Router) handleUpdatePrivateDnsNamespace(w http.ResponseWriter, req *htt
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.UpdatePrivateDnsNamespace(req.Context(), &input)
@@ -752,6 +860,10 @@ func (r *Router) handleUpdatePublicDnsNamespace(w http.ResponseWriter, req *http
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.UpdatePublicDnsNamespace(req.Context(), &input)
@@ -774,6 +886,10 @@ func (r *Router) handleUpdateService(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.UpdateService(req.Context(), &input)
@@ -796,6 +912,10 @@ func (r *Router) handleUpdateServiceAttributes(w http.ResponseWriter, req *http.
 			return
 		}
 	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "ValidationException", err.Error())
+		return
+	}
 
 	// Call API
 	output, err := r.api.UpdateServiceAttributes(req.Context(), &input)
@@ -2,41 +2,52 @@ package servicediscovery
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
 	"github.com/nandemo-ya/kecs/controlplane/internal/servicediscovery/generated"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
 )
 
 // Handler implements the Service Discovery API
 type Handler struct {
-	logger  *logrus.Logger
-	store   storage.Storage
-	manager Manager
+	logger    *logrus.Logger
+	store     storage.Storage
+	manager   Manager
+	region    string
+	accountID string
 }
 
-// NewHandler creates a new Service Discovery API handler
-func NewHandler(logger *logrus.Logger, store storage.Storage, manager Manager) *Handler {
+// NewHandler creates a new Service Discovery API handler. ARNs are built in
+// the region and account of the instance.
+func NewHandler(logger *logrus.Logger, store storage.Storage, manager Manager, region, accountID string) *Handler {
 	return &Handler{
-		logger:  logger,
-		store:   store,
-		manager: manager,
+		logger:    logger,
+		store:     store,
+		manager:   manager,
+		region:    region,
+		accountID: accountID,
 	}
 }
 
-// CreatePrivateDnsNamespace creates a private DNS namespace
+// CreatePrivateDnsNamespace creates a private DNS namespace. The namespace is
+// created asynchronously, its CREATE_NAMESPACE operation tells when it is.
 func (h *Handler) CreatePrivateDnsNamespace(ctx context.Context, input *generated.CreatePrivateDnsNamespaceRequest) (*generated.CreatePrivateDnsNamespaceResponse, error) {
 	h.logger.WithField("name", input.Name).Info("Creating private DNS namespace")
 
+	if err := h.validateNamespaceName(ctx, input.Name, input.CreatorRequestId); err != nil {
+		return nil, err
+	}
+
 	// Generate namespace ID
 	namespaceID := fmt.Sprintf("ns-%s", uuid.New().String())
-	operationID := fmt.Sprintf("op-%s", uuid.New().String())
 
-	// Store namespace in database
 	namespace := &Namespace{
 		ID:          namespaceID,
 		Name:        input.Name,
@@ -49,41 +60,26 @@ func (h *Handler) CreatePrivateDnsNamespace(ctx context.Context, input *generate
 	// Note: PrivateDnsPropertiesMutable doesn't have HostedZoneId field
 	// The hosted zone will be created by the manager
 
-	if err := h.manager.CreateNamespace(ctx, namespace); err != nil {
-		h.logger.WithError(err).Error("Failed to create namespace")
-		return nil, err
-	}
-
-	// Store operation
-	operation := &Operation{
-		ID:        operationID,
-		Type:      "CREATE_NAMESPACE",
-		Status:    "SUCCESS",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		Targets: map[string]string{
-			"NAMESPACE": namespaceID,
-		},
-	}
-
-	if err := h.storeOperation(ctx, operation); err != nil {
-		h.logger.WithError(err).Error("Failed to store operation")
-	}
+	operationID := h.startNamespaceOperation(generated.OperationTypeCREATE_NAMESPACE, namespaceID, func(ctx context.Context) error {
+		return h.manager.CreateNamespace(ctx, namespace)
+	})
 
 	return &generated.CreatePrivateDnsNamespaceResponse{
 		OperationId: &operationID,
 	}, nil
 }
 
-// CreatePublicDnsNamespace creates a public DNS namespace
+// CreatePublicDnsNamespace creates a public DNS namespace asynchronously
 func (h *Handler) CreatePublicDnsNamespace(ctx context.Context, input *generated.CreatePublicDnsNamespaceRequest) (*generated.CreatePublicDnsNamespaceResponse, error) {
 	h.logger.WithField("name", input.Name).Info("Creating public DNS namespace")
 
+	if err := h.validateNamespaceName(ctx, input.Name, input.CreatorRequestId); err != nil {
+		return nil, err
+	}
+
 	// Generate namespace ID
 	namespaceID := fmt.Sprintf("ns-%s", uuid.New().String())
-	operationID := fmt.Sprintf("op-%s", uuid.New().String())
 
-	// Store namespace in database
 	namespace := &Namespace{
 		ID:          namespaceID,
 		Name:        input.Name,
@@ -95,41 +91,26 @@ func (h *Handler) CreatePublicDnsNamespace(ctx context.Context, input *generated
 	// Note: PublicDnsPropertiesMutable doesn't have HostedZoneId field
 	// The hosted zone will be created by the manager
 
-	if err := h.manager.CreateNamespace(ctx, namespace); err != nil {
-		h.logger.WithError(err).Error("Failed to create namespace")
-		return nil, err
-	}
-
-	// Store operation
-	operation := &Operation{
-		ID:        operationID,
-		Type:      "CREATE_NAMESPACE",
-		Status:    "SUCCESS",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		Targets: map[string]string{
-			"NAMESPACE": namespaceID,
-		},
-	}
-
-	if err := h.storeOperation(ctx, operation); err != nil {
-		h.logger.WithError(err).Error("Failed to store operation")
-	}
+	operationID := h.startNamespaceOperation(generated.OperationTypeCREATE_NAMESPACE, namespaceID, func(ctx context.Context) error {
+		return h.manager.CreateNamespace(ctx, namespace)
+	})
 
 	return &generated.CreatePublicDnsNamespaceResponse{
 		OperationId: &operationID,
 	}, nil
 }
 
-// CreateHttpNamespace creates an HTTP namespace
+// CreateHttpNamespace creates an HTTP namespace asynchronously
 func (h *Handler) CreateHttpNamespace(ctx context.Context, input *generated.CreateHttpNamespaceRequest) (*generated.CreateHttpNamespaceResponse, error) {
 	h.logger.WithField("name", input.Name).Info("Creating HTTP namespace")
 
+	if err := h.validateNamespaceName(ctx, input.Name, input.CreatorRequestId); err != nil {
+		return nil, err
+	}
+
 	// Generate namespace ID
 	namespaceID := fmt.Sprintf("ns-%s", uuid.New().String())
-	operationID := fmt.Sprintf("op-%s", uuid.New().String())
 
-	// Store namespace in database
 	namespace := &Namespace{
 		ID:          namespaceID,
 		Name:        input.Name,
@@ -138,26 +119,9 @@ func (h *Handler) CreateHttpNamespace(ctx context.Context, input *generated.Crea
 		Description: stringValue(input.Description),
 	}
 
-	if err := h.manager.CreateNamespace(ctx, namespace); err != nil {
-		h.logger.WithError(err).Error("Failed to create namespace")
-		return nil, err
-	}
-
-	// Store operation
-	operation := &Operation{
-		ID:        operationID,
-		Type:      "CREATE_NAMESPACE",
-		Status:    "SUCCESS",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		Targets: map[string]string{
-			"NAMESPACE": namespaceID,
-		},
-	}
-
-	if err := h.storeOperation(ctx, operation); err != nil {
-		h.logger.WithError(err).Error("Failed to store operation")
-	}
+	operationID := h.startNamespaceOperation(generated.OperationTypeCREATE_NAMESPACE, namespaceID, func(ctx context.Context) error {
+		return h.manager.CreateNamespace(ctx, namespace)
+	})
 
 	return &generated.CreateHttpNamespaceResponse{
 		OperationId: &operationID,
@@ -311,7 +275,8 @@ func (h *Handler) DiscoverInstances(ctx context.Context, input *generated.Discov
 		"service":   input.ServiceName,
 	}).Info("Discovering instances")
 
-	instances, err := h.manager.DiscoverInstances(ctx, input.NamespaceName, input.ServiceName)
+	healthStatus := string(ptrValue(input.HealthStatus, generated.HealthStatusFilterHEALTHY))
+	instances, err := h.manager.DiscoverInstancesByHealth(ctx, input.NamespaceName, input.ServiceName, healthStatus)
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to discover instances")
 		return nil, err
@@ -322,7 +287,7 @@ func (h *Handler) DiscoverInstances(ctx context.Context, input *generated.Discov
 	for _, inst := range instances {
 		httpInstances = append(httpInstances, generated.HttpInstanceSummary{
 			Attributes:    inst.Attributes,
-			HealthStatus:  (*generated.HealthStatus)(stringPtr("HEALTHY")),
+			HealthStatus:  (*generated.HealthStatus)(stringPtr(instanceHealthStatus(inst))),
 			InstanceId:    &inst.ID,
 			NamespaceName: &input.NamespaceName,
 			ServiceName:   &input.ServiceName,
@@ -351,7 +316,7 @@ func (h *Handler) ListNamespaces(ctx context.Context, input *generated.ListNames
 	for _, ns := range namespaces {
 		nsType := h.convertNamespaceType(ns.Type)
 		genNamespaces = append(genNamespaces, generated.NamespaceSummary{
-			Arn:         stringPtr(fmt.Sprintf("arn:aws:servicediscovery:us-east-1:123456789012:namespace/%s", ns.ID)),
+			Arn:         stringPtr(h.namespaceARN(ns.ID)),
 			CreateDate:  timePtr(ns.CreatedAt),
 			Description: stringPtr(ns.Description),
 			Id:          &ns.ID,
@@ -390,7 +355,7 @@ func (h *Handler) ListServices(ctx context.Context, input *generated.ListService
 	var genServices []generated.ServiceSummary
 	for _, svc := range services {
 		genServices = append(genServices, generated.ServiceSummary{
-			Arn:         stringPtr(fmt.Sprintf("arn:aws:servicediscovery:us-east-1:123456789012:service/%s", svc.ID)),
+			Arn:         stringPtr(h.serviceARN(svc.ID)),
 			CreateDate:  timePtr(svc.CreatedAt),
 			Description: stringPtr(svc.Description),
 			Id:          &svc.ID,
@@ -439,13 +404,30 @@ func (h *Handler) GetService(ctx context.Context, input *generated.GetServiceReq
 	}, nil
 }
 
-// Implement remaining required methods with basic stubs for now
-
+// DeleteNamespace deletes a namespace without services asynchronously, its
+// DELETE_NAMESPACE operation tells when it is deleted
 func (h *Handler) DeleteNamespace(ctx context.Context, input *generated.DeleteNamespaceRequest) (*generated.DeleteNamespaceResponse, error) {
-	operationID := fmt.Sprintf("op-%s", uuid.New().String())
+	h.logger.WithField("id", input.Id).Info("Deleting namespace")
+
+	namespace, err := h.manager.GetNamespace(ctx, input.Id)
+	if err != nil {
+		return nil, &generated.NamespaceNotFound{Message: stringPtr(err.Error())}
+	}
+	if namespace.ServiceCount > 0 {
+		return nil, &generated.ResourceInUse{
+			Message: stringPtr(fmt.Sprintf("namespace %s has %d services, delete them first", input.Id, namespace.ServiceCount)),
+		}
+	}
+
+	operationID := h.startNamespaceOperation(generated.OperationTypeDELETE_NAMESPACE, input.Id, func(ctx context.Context) error {
+		return h.manager.DeleteNamespace(ctx, input.Id)
+	})
+
 	return &generated.DeleteNamespaceResponse{OperationId: &operationID}, nil
 }
 
+// Implement remaining required methods with basic stubs for now
+
 func (h *Handler) DeleteService(ctx context.Context, input *generated.DeleteServiceRequest) (*generated.DeleteServiceResponse, error) {
 	return &generated.DeleteServiceResponse{}, nil
 }
@@ -464,11 +446,34 @@ func (h *Handler) GetInstance(ctx context.Context, input *generated.GetInstanceR
 }
 
 func (h *Handler) GetInstancesHealthStatus(ctx context.Context, input *generated.GetInstancesHealthStatusRequest) (*generated.GetInstancesHealthStatusResponse, error) {
-	return &generated.GetInstancesHealthStatusResponse{}, nil
+	instances, err := h.manager.ListInstances(ctx, input.ServiceId)
+	if err != nil {
+		return nil, &generated.ServiceNotFound{Message: stringPtr(err.Error())}
+	}
+
+	requested := make(map[string]bool, len(input.Instances))
+	for _, id := range input.Instances {
+		requested[id] = true
+	}
+	status := make(map[string]generated.HealthStatus)
+	for _, instance := range instances {
+		if len(requested) == 0 || requested[instance.ID] {
+			status[instance.ID] = generated.HealthStatus(instanceHealthStatus(instance))
+		}
+	}
+	return &generated.GetInstancesHealthStatusResponse{Status: status}, nil
 }
 
+// GetOperation returns the status of an operation
 func (h *Handler) GetOperation(ctx context.Context, input *generated.GetOperationRequest) (*generated.GetOperationResponse, error) {
-	return &generated.GetOperationResponse{}, nil
+	operation, err := h.manager.GetOperation(ctx, input.OperationId)
+	if err != nil {
+		return nil, &generated.OperationNotFound{Message: stringPtr(err.Error())}
+	}
+
+	return &generated.GetOperationResponse{
+		Operation: h.convertOperationToGenerated(operation),
+	}, nil
 }
 
 func (h *Handler) GetServiceAttributes(ctx context.Context, input *generated.GetServiceAttributesRequest) (*generated.GetServiceAttributesResponse, error) {
@@ -500,8 +505,36 @@ func (h *Handler) ListInstances(ctx context.Context, input *generated.ListInstan
 	}, nil
 }
 
+// ListOperations lists the operations matching all filters
 func (h *Handler) ListOperations(ctx context.Context, input *generated.ListOperationsRequest) (*generated.ListOperationsResponse, error) {
-	return &generated.ListOperationsResponse{}, nil
+	operations, err := h.manager.ListOperations(ctx)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list operations")
+		return nil, err
+	}
+
+	summaries := []generated.OperationSummary{}
+	for _, operation := range operations {
+		matches, err := matchOperationFilters(operation, input.Filters)
+		if err != nil {
+			return nil, err
+		}
+		if !matches {
+			continue
+		}
+		status := generated.OperationStatus(operation.Status)
+		summaries = append(summaries, generated.OperationSummary{
+			Id:     stringPtr(operation.ID),
+			Status: &status,
+		})
+	}
+	if input.MaxResults != nil && *input.MaxResults > 0 && len(summaries) > int(*input.MaxResults) {
+		summaries = summaries[:*input.MaxResults]
+	}
+
+	return &generated.ListOperationsResponse{
+		Operations: summaries,
+	}, nil
 }
 
 func (h *Handler) ListTagsForResource(ctx context.Context, input *generated.ListTagsForResourceRequest) (*generated.ListTagsForResourceResponse, error) {
@@ -521,8 +554,34 @@ func (h *Handler) UpdateHttpNamespace(ctx context.Context, input *generated.Upda
 	return &generated.UpdateHttpNamespaceResponse{OperationId: &operationID}, nil
 }
 
+// UpdateInstanceCustomHealthStatus records the status a custom health check
+// reported for an instance
 func (h *Handler) UpdateInstanceCustomHealthStatus(ctx context.Context, input *generated.UpdateInstanceCustomHealthStatusRequest) (*generated.Unit, error) {
-	return &generated.Unit{}, nil
+	h.logger.WithFields(logrus.Fields{
+		"serviceId":  input.ServiceId,
+		"instanceId": input.InstanceId,
+		"status":     input.Status,
+	}).Info("Updating instance custom health status")
+
+	if input.Status != generated.CustomHealthStatusHEALTHY && input.Status != generated.CustomHealthStatusUNHEALTHY {
+		return nil, &generated.InvalidInput{
+			Message: stringPtr(fmt.Sprintf("invalid status %q, valid values are HEALTHY and UNHEALTHY", input.Status)),
+		}
+	}
+
+	err := h.manager.UpdateInstanceCustomHealthStatus(ctx, input.ServiceId, input.InstanceId, string(input.Status))
+	switch {
+	case err == nil:
+		return &generated.Unit{}, nil
+	case errors.Is(err, ErrServiceNotFound):
+		return nil, &generated.ServiceNotFound{Message: stringPtr(err.Error())}
+	case errors.Is(err, ErrInstanceNotFound):
+		return nil, &generated.InstanceNotFound{Message: stringPtr(err.Error())}
+	case errors.Is(err, ErrCustomHealthNotFound):
+		return nil, &generated.CustomHealthNotFound{Message: stringPtr(err.Error())}
+	default:
+		return nil, err
+	}
 }
 
 func (h *Handler) UpdatePrivateDnsNamespace(ctx context.Context, input *generated.UpdatePrivateDnsNamespaceRequest) (*generated.UpdatePrivateDnsNamespaceResponse, error) {
@@ -546,16 +605,156 @@ func (h *Handler) UpdateServiceAttributes(ctx context.Context, input *generated.
 
 // Helper functions
 
+// instanceHealthStatus returns the Cloud Map health status of an instance;
+// instances registered without a health check report UNKNOWN
+func instanceHealthStatus(instance *Instance) string {
+	if instance.HealthStatus == "" {
+		return "UNKNOWN"
+	}
+	return instance.HealthStatus
+}
+
 func (h *Handler) storeOperation(ctx context.Context, operation *Operation) error {
-	// TODO: Implement operation storage when ServiceDiscoveryStore is available
-	// For now, operations are not persisted
-	h.logger.WithField("operationID", operation.ID).Debug("Operation storage not yet implemented")
+	h.manager.RecordOperation(operation)
+	return nil
+}
+
+// validateNamespaceName rejects empty names and names of existing namespaces
+func (h *Handler) validateNamespaceName(ctx context.Context, name string, creatorRequestID *string) error {
+	if name == "" {
+		return &generated.InvalidInput{Message: stringPtr("namespace name is required")}
+	}
+
+	namespaces, err := h.manager.ListNamespaces(ctx)
+	if err != nil {
+		return err
+	}
+	for _, ns := range namespaces {
+		if ns.Name == name {
+			return &generated.NamespaceAlreadyExists{
+				Message:          stringPtr(fmt.Sprintf("namespace %s already exists", name)),
+				NamespaceId:      stringPtr(ns.ID),
+				CreatorRequestId: creatorRequestID,
+			}
+		}
+	}
 	return nil
 }
 
+// startNamespaceOperation starts an operation on a namespace and returns
+// its ID
+func (h *Handler) startNamespaceOperation(operationType generated.OperationType, namespaceID string, run func(ctx context.Context) error) string {
+	operation := &Operation{
+		ID:        fmt.Sprintf("op-%s", uuid.New().String()),
+		Type:      string(operationType),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Targets: map[string]string{
+			string(generated.OperationTargetTypeNAMESPACE): namespaceID,
+		},
+	}
+	h.manager.StartOperation(operation, run)
+	return operation.ID
+}
+
+// matchOperationFilters reports whether an operation matches all filters
+// of ListOperations
+func matchOperationFilters(operation *Operation, filters []generated.OperationFilter) (bool, error) {
+	for _, filter := range filters {
+		condition := ptrValue(filter.Condition, generated.FilterConditionEQ)
+		if filter.Name == generated.OperationFilterNameUPDATE_DATE {
+			if len(filter.Values) != 2 {
+				return false, &generated.InvalidInput{Message: stringPtr("UPDATE_DATE filters take a start and an end date")}
+			}
+			var bounds [2]time.Time
+			for i, value := range filter.Values {
+				t, err := parseFilterDate(value)
+				if err != nil {
+					return false, &generated.InvalidInput{Message: stringPtr(fmt.Sprintf("invalid UPDATE_DATE value %q", value))}
+				}
+				bounds[i] = t
+			}
+			if operation.UpdatedAt.Before(bounds[0]) || operation.UpdatedAt.After(bounds[1]) {
+				return false, nil
+			}
+			continue
+		}
+
+		var value string
+		switch filter.Name {
+		case generated.OperationFilterNameNAMESPACE_ID:
+			value = operation.Targets[string(generated.OperationTargetTypeNAMESPACE)]
+		case generated.OperationFilterNameSERVICE_ID:
+			value = operation.Targets[string(generated.OperationTargetTypeSERVICE)]
+		case generated.OperationFilterNameSTATUS:
+			value = operation.Status
+		case generated.OperationFilterNameTYPE:
+			value = operation.Type
+		default:
+			return false, &generated.InvalidInput{Message: stringPtr(fmt.Sprintf("invalid filter name %q", filter.Name))}
+		}
+		if condition == generated.FilterConditionEQ && len(filter.Values) != 1 {
+			return false, &generated.InvalidInput{Message: stringPtr(fmt.Sprintf("EQ filters on %s take a single value", filter.Name))}
+		}
+		matches := false
+		for _, v := range filter.Values {
+			if v == value {
+				matches = true
+				break
+			}
+		}
+		if !matches {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// parseFilterDate parses a date of an UPDATE_DATE filter, in RFC 3339 or
+// seconds since the epoch as the AWS CLI sends them
+func parseFilterDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second))), nil
+}
+
+func (h *Handler) convertOperationToGenerated(operation *Operation) *generated.Operation {
+	status := generated.OperationStatus(operation.Status)
+	operationType := generated.OperationType(operation.Type)
+	targets := make(map[generated.OperationTargetType]string, len(operation.Targets))
+	for target, id := range operation.Targets {
+		targets[generated.OperationTargetType(target)] = id
+	}
+	return &generated.Operation{
+		CreateDate:   timePtr(operation.CreatedAt),
+		ErrorCode:    stringPtr(operation.ErrorCode),
+		ErrorMessage: stringPtr(operation.ErrorMessage),
+		Id:           stringPtr(operation.ID),
+		Status:       &status,
+		Targets:      targets,
+		Type:         &operationType,
+		UpdateDate:   timePtr(operation.UpdatedAt),
+	}
+}
+
+// namespaceARN returns the ARN of the namespace with the given ID
+func (h *Handler) namespaceARN(id string) string {
+	return awsarn.Build("servicediscovery", h.region, h.accountID, fmt.Sprintf("namespace/%s", id))
+}
+
+// serviceARN returns the ARN of the service with the given ID
+func (h *Handler) serviceARN(id string) string {
+	return awsarn.Build("servicediscovery", h.region, h.accountID, fmt.Sprintf("service/%s", id))
+}
+
 func (h *Handler) convertServiceToGenerated(service *Service) *generated.Service {
 	genService := &generated.Service{
-		Arn:         stringPtr(fmt.Sprintf("arn:aws:servicediscovery:us-east-1:123456789012:service/%s", service.ID)),
+		Arn:         stringPtr(h.serviceARN(service.ID)),
 		CreateDate:  timePtr(service.CreatedAt),
 		Description: stringPtr(service.Description),
 		Id:          &service.ID,
@@ -586,7 +785,7 @@ func (h *Handler) convertServiceToGenerated(service *Service) *generated.Service
 func (h *Handler) convertNamespaceToGenerated(namespace *Namespace) *generated.Namespace {
 	nsType := h.convertNamespaceType(namespace.Type)
 	return &generated.Namespace{
-		Arn:         stringPtr(fmt.Sprintf("arn:aws:servicediscovery:us-east-1:123456789012:namespace/%s", namespace.ID)),
+		Arn:         stringPtr(h.namespaceARN(namespace.ID)),
 		CreateDate:  timePtr(namespace.CreatedAt),
 		Description: stringPtr(namespace.Description),
 		Id:          &namespace.ID,
@@ -0,0 +1,127 @@
+package servicediscovery
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/servicediscovery/generated"
+)
+
+var _ = Describe("Handler custom health checks", func() {
+	var (
+		ctx     context.Context
+		mgr     Manager
+		handler *Handler
+	)
+
+	discover := func(filter generated.HealthStatusFilter) []string {
+		resp, err := handler.DiscoverInstances(ctx, &generated.DiscoverInstancesRequest{
+			NamespaceName: "demo.local",
+			ServiceName:   "backend",
+			HealthStatus:  &filter,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		var ids []string
+		for _, instance := range resp.Instances {
+			ids = append(ids, *instance.InstanceId)
+		}
+		return ids
+	}
+
+	updateStatus := func(instanceID string, status generated.CustomHealthStatus) error {
+		_, err := handler.UpdateInstanceCustomHealthStatus(ctx, &generated.UpdateInstanceCustomHealthStatusRequest{
+			ServiceId:  "srv-backend",
+			InstanceId: instanceID,
+			Status:     status,
+		})
+		return err
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mgr = NewManager(nil, "ap-northeast-1", "111122223333", "")
+		handler = NewHandler(logrus.New(), nil, mgr, "ap-northeast-1", "111122223333")
+
+		namespace, err := mgr.CreatePrivateDnsNamespace(ctx, "demo.local", "vpc-123", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mgr.CreateService(ctx, &Service{
+			ID:                      "srv-backend",
+			Name:                    "backend",
+			NamespaceID:             namespace.ID,
+			HealthCheckCustomConfig: &HealthCheckCustomConfig{FailureThreshold: 2},
+		})).To(Succeed())
+
+		for _, id := range []string{"task-a", "task-b"} {
+			Expect(mgr.RegisterInstance(ctx, &Instance{
+				ID:         id,
+				ServiceID:  "srv-backend",
+				Attributes: map[string]string{"AWS_INSTANCE_IPV4": "10.42.0.10"},
+			})).To(Succeed())
+		}
+	})
+
+	It("should mark instances unhealthy once the failure threshold is reached", func() {
+		Expect(discover(generated.HealthStatusFilterHEALTHY)).To(ConsistOf("task-a", "task-b"))
+
+		Expect(updateStatus("task-a", generated.CustomHealthStatusUNHEALTHY)).To(Succeed())
+		Expect(discover(generated.HealthStatusFilterHEALTHY)).To(ConsistOf("task-a", "task-b"))
+
+		Expect(updateStatus("task-a", generated.CustomHealthStatusUNHEALTHY)).To(Succeed())
+		Expect(discover(generated.HealthStatusFilterHEALTHY)).To(ConsistOf("task-b"))
+		Expect(discover(generated.HealthStatusFilterUNHEALTHY)).To(ConsistOf("task-a"))
+		Expect(discover(generated.HealthStatusFilterALL)).To(ConsistOf("task-a", "task-b"))
+
+		resp, err := handler.GetInstancesHealthStatus(ctx, &generated.GetInstancesHealthStatusRequest{ServiceId: "srv-backend"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Status).To(HaveKeyWithValue("task-a", generated.HealthStatus("UNHEALTHY")))
+		Expect(resp.Status).To(HaveKeyWithValue("task-b", generated.HealthStatus("HEALTHY")))
+
+		Expect(updateStatus("task-a", generated.CustomHealthStatusHEALTHY)).To(Succeed())
+		Expect(discover(generated.HealthStatusFilterHEALTHY)).To(ConsistOf("task-a", "task-b"))
+	})
+
+	It("should return all instances when none is healthy for HEALTHY_OR_ELSE_ALL", func() {
+		for _, id := range []string{"task-a", "task-b"} {
+			Expect(updateStatus(id, generated.CustomHealthStatusUNHEALTHY)).To(Succeed())
+			Expect(updateStatus(id, generated.CustomHealthStatusUNHEALTHY)).To(Succeed())
+		}
+		Expect(discover(generated.HealthStatusFilterHEALTHY)).To(BeEmpty())
+		Expect(discover(generated.HealthStatusFilterHEALTHY_OR_ELSE_ALL)).To(ConsistOf("task-a", "task-b"))
+	})
+
+	It("should reject updates for unknown instances and services without custom health checks", func() {
+		err := updateStatus("task-missing", generated.CustomHealthStatusUNHEALTHY)
+		Expect(err).To(BeAssignableToTypeOf(&generated.InstanceNotFound{}))
+
+		err = updateStatus("task-a", generated.CustomHealthStatus("DEGRADED"))
+		Expect(err).To(BeAssignableToTypeOf(&generated.InvalidInput{}))
+
+		namespaces, err := mgr.ListNamespaces(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mgr.CreateService(ctx, &Service{ID: "srv-plain", Name: "plain", NamespaceID: namespaces[0].ID})).To(Succeed())
+		_, err = handler.UpdateInstanceCustomHealthStatus(ctx, &generated.UpdateInstanceCustomHealthStatusRequest{
+			ServiceId:  "srv-plain",
+			InstanceId: "task-a",
+			Status:     generated.CustomHealthStatusHEALTHY,
+		})
+		Expect(err).To(BeAssignableToTypeOf(&generated.CustomHealthNotFound{}))
+	})
+
+	It("should start instances with the initial health status", func() {
+		Expect(mgr.RegisterInstance(ctx, &Instance{
+			ID:         "task-c",
+			ServiceID:  "srv-backend",
+			Attributes: map[string]string{"AWS_INIT_HEALTH_STATUS": "UNHEALTHY"},
+		})).To(Succeed())
+		Expect(discover(generated.HealthStatusFilterUNHEALTHY)).To(ConsistOf("task-c"))
+	})
+	It("should build ARNs in the region and account of the instance", func() {
+		resp, err := handler.ListServices(ctx, &generated.ListServicesRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Services).To(HaveLen(1))
+		Expect(*resp.Services[0].Arn).To(Equal("arn:aws:servicediscovery:ap-northeast-1:111122223333:service/srv-backend"))
+	})
+})
@@ -3,8 +3,10 @@ package servicediscovery
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -12,8 +14,75 @@ import (
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
 )
 
+// srvPortName is the name of the Service port of SRV records
+const srvPortName = "srv"
+
+// endpointsNamespace returns the Kubernetes namespace holding the Services and
+// Endpoints of the instances of a namespace
+func endpointsNamespace(namespace *Namespace) string {
+	return sanitizeDNSLabel(namespace.Name)
+}
+
+// ensureEndpointsNamespace creates the Kubernetes namespace holding the
+// Services and Endpoints of a namespace if it does not exist
+func (m *manager) ensureEndpointsNamespace(ctx context.Context, namespace *Namespace) error {
+	k8sNamespace := endpointsNamespace(namespace)
+	_, err := m.kubeClient.CoreV1().Namespaces().Get(ctx, k8sNamespace, metav1.GetOptions{})
+	if err == nil {
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check namespace: %w", err)
+	}
+
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: k8sNamespace,
+			Labels: map[string]string{
+				"kecs.io/managed":           "true",
+				"kecs.io/service-discovery": "true",
+			},
+		},
+	}
+	if _, err := m.kubeClient.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{}); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create namespace: %w", err)
+		}
+	}
+
+	logging.Info("Created Kubernetes namespace for Service Discovery", "namespace", k8sNamespace)
+	return nil
+}
+
+// deleteEndpointsNamespace deletes the Kubernetes namespace of a namespace
+// if Service Discovery created it
+func (m *manager) deleteEndpointsNamespace(ctx context.Context, namespace *Namespace) error {
+	k8sNamespace := endpointsNamespace(namespace)
+	ns, err := m.kubeClient.CoreV1().Namespaces().Get(ctx, k8sNamespace, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to check namespace: %w", err)
+	}
+	if ns.Labels["kecs.io/service-discovery"] != "true" {
+		return nil
+	}
+
+	if err := m.kubeClient.CoreV1().Namespaces().Delete(ctx, k8sNamespace, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete namespace: %w", err)
+	}
+
+	logging.Info("Deleted Kubernetes namespace of Service Discovery", "namespace", k8sNamespace)
+	return nil
+}
+
 // updateKubernetesEndpoints updates Kubernetes endpoints for service discovery
 func (m *manager) updateKubernetesEndpoints(ctx context.Context, service *Service, instances map[string]*Instance) error {
+	if m.kubeClient == nil {
+		return nil
+	}
+
 	namespace, exists := m.namespaces[service.NamespaceID]
 	if !exists {
 		return fmt.Errorf("namespace not found: %s", service.NamespaceID)
@@ -21,44 +90,25 @@ func (m *manager) updateKubernetesEndpoints(ctx context.Context, service *Servic
 
 	// Get Kubernetes namespace - use namespace name directly for better DNS resolution
 	// This allows service.demo.local to work directly
-	k8sNamespace := sanitizeDNSLabel(namespace.Name)
-
-	// Ensure namespace exists (simple inline implementation)
-	if m.kubeClient != nil {
-		_, err := m.kubeClient.CoreV1().Namespaces().Get(ctx, k8sNamespace, metav1.GetOptions{})
-		if err != nil {
-			if errors.IsNotFound(err) {
-				// Create namespace
-				ns := &corev1.Namespace{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: k8sNamespace,
-						Labels: map[string]string{
-							"kecs.io/managed":           "true",
-							"kecs.io/service-discovery": "true",
-						},
-					},
-				}
-
-				if _, err := m.kubeClient.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{}); err != nil {
-					if !errors.IsAlreadyExists(err) {
-						return fmt.Errorf("failed to create namespace: %w", err)
-					}
-				}
-
-				logging.Info("Created Kubernetes namespace for Service Discovery", "namespace", k8sNamespace)
-			} else {
-				return fmt.Errorf("failed to check namespace: %w", err)
-			}
-		}
+	k8sNamespace := endpointsNamespace(namespace)
+	if err := m.ensureEndpointsNamespace(ctx, namespace); err != nil {
+		return err
 	}
 
 	// Use service name directly (not prefixed with sd-)
 	// This allows backend-api.demo.local to resolve correctly
 	k8sServiceName := service.Name
+	servicePorts := m.getServicePorts(service, instances)
 
 	// Check if Kubernetes Service exists, create if not
 	k8sService, err := m.kubeClient.CoreV1().Services(k8sNamespace).Get(ctx, k8sServiceName, metav1.GetOptions{})
-	if err != nil {
+	if err == nil && !equality.Semantic.DeepEqual(k8sService.Spec.Ports, servicePorts) {
+		// The SRV port is known once the first instance registers
+		k8sService.Spec.Ports = servicePorts
+		if _, err := m.kubeClient.CoreV1().Services(k8sNamespace).Update(ctx, k8sService, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update Kubernetes service ports: %w", err)
+		}
+	} else if err != nil {
 		// Create headless service for service discovery
 		k8sService = &corev1.Service{
 			ObjectMeta: metav1.ObjectMeta{
@@ -78,7 +128,7 @@ func (m *manager) updateKubernetesEndpoints(ctx context.Context, service *Servic
 			Spec: corev1.ServiceSpec{
 				ClusterIP: corev1.ClusterIPNone, // Headless service
 				Selector:  nil,                  // We'll manage endpoints manually
-				Ports:     m.getServicePorts(service),
+				Ports:     servicePorts,
 			},
 		}
 
@@ -114,32 +164,45 @@ func (m *manager) updateKubernetesEndpoints(ctx context.Context, service *Servic
 	return nil
 }
 
-// getServicePorts extracts ports from service DNS configuration
-func (m *manager) getServicePorts(service *Service) []corev1.ServicePort {
-	ports := []corev1.ServicePort{}
+// getServicePorts returns the ports of the headless Service. Services with
+// an SRV record publish the port the instances registered under the "srv"
+// name, so _srv._tcp.<service> resolves in the cluster as well.
+func (m *manager) getServicePorts(service *Service, instances map[string]*Instance) []corev1.ServicePort {
+	if port := srvPort(service, instances); port > 0 {
+		return []corev1.ServicePort{{
+			Name:       srvPortName,
+			Port:       int32(port),
+			TargetPort: intstr.FromInt(port),
+			Protocol:   corev1.ProtocolTCP,
+		}}
+	}
 
 	// Default port for A/AAAA records
-	defaultPort := corev1.ServicePort{
+	return []corev1.ServicePort{{
 		Name:       "http",
 		Port:       80,
 		TargetPort: intstr.FromInt(80),
 		Protocol:   corev1.ProtocolTCP,
-	}
+	}}
+}
 
-	if service.DNSConfig != nil && len(service.DNSConfig.DNSRecords) > 0 {
-		for _, record := range service.DNSConfig.DNSRecords {
-			if record.Type == "SRV" {
-				// For SRV records, we need to extract port from instance attributes
-				// This is handled in buildEndpointSubsets
-				continue
-			}
+// srvPort returns the port of the SRV record of a service, taken from its
+// instances, or 0 when the service has no SRV record
+func srvPort(service *Service, instances map[string]*Instance) int {
+	if !hasDNSRecord(service, "SRV") {
+		return 0
+	}
+	ids := make([]string, 0, len(instances))
+	for id := range instances {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if port := instancePort(instances[id]); port > 0 {
+			return port
 		}
 	}
-
-	// Always add a default port for DNS resolution
-	ports = append(ports, defaultPort)
-
-	return ports
+	return 0
 }
 
 // buildEndpointSubsets builds endpoint subsets from instances
@@ -190,35 +253,13 @@ func (m *manager) buildEndpointSubsets(instances map[string]*Instance, service *
 		}
 	}
 
-	// Build ports
+	// Endpoint ports must carry the names of the Service ports
 	ports := []corev1.EndpointPort{}
-
-	// Check if we have SRV records that specify ports
-	if service.DNSConfig != nil {
-		for _, record := range service.DNSConfig.DNSRecords {
-			if record.Type == "SRV" {
-				// For SRV records, check instance attributes for port
-				for _, instance := range instances {
-					if portStr, ok := instance.Attributes["PORT"]; ok {
-						port := parsePort(portStr)
-						if port > 0 {
-							ports = append(ports, corev1.EndpointPort{
-								Port:     int32(port),
-								Protocol: corev1.ProtocolTCP,
-							})
-							break
-						}
-					}
-				}
-			}
-		}
-	}
-
-	// Default port if none specified
-	if len(ports) == 0 {
+	for _, servicePort := range m.getServicePorts(service, instances) {
 		ports = append(ports, corev1.EndpointPort{
-			Port:     80,
-			Protocol: corev1.ProtocolTCP,
+			Name:     servicePort.Name,
+			Port:     servicePort.TargetPort.IntVal,
+			Protocol: servicePort.Protocol,
 		})
 	}
 
@@ -246,10 +287,3 @@ func (m *manager) getK8sNamespaceForInstance(instance *Instance) string {
 
 	return "default"
 }
-
-// parsePort parses a port string to int
-func parsePort(portStr string) int {
-	var port int
-	fmt.Sscanf(portStr, "%d", &port)
-	return port
-}
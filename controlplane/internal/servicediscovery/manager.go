@@ -2,12 +2,14 @@ package servicediscovery
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"k8s.io/client-go/kubernetes"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
 	"github.com/nandemo-ya/kecs/controlplane/internal/route53"
 )
@@ -20,6 +22,13 @@ type Manager interface {
 	GetNamespace(ctx context.Context, namespaceID string) (*Namespace, error)
 	ListNamespaces(ctx context.Context) ([]*Namespace, error)
 	DeleteNamespace(ctx context.Context, namespaceID string) error
+	MapK8sNamespace(dnsNamespace, k8sNamespace string)
+
+	// Operation tracking
+	StartOperation(operation *Operation, run func(ctx context.Context) error)
+	RecordOperation(operation *Operation)
+	GetOperation(ctx context.Context, operationID string) (*Operation, error)
+	ListOperations(ctx context.Context) ([]*Operation, error)
 
 	// Service operations
 	CreateService(ctx context.Context, service *Service) error
@@ -33,9 +42,26 @@ type Manager interface {
 	DeregisterInstance(ctx context.Context, serviceID string, instanceID string) error
 	ListInstances(ctx context.Context, serviceID string) ([]*Instance, error)
 	DiscoverInstances(ctx context.Context, namespaceName, serviceName string) ([]*Instance, error)
+	DiscoverInstancesByHealth(ctx context.Context, namespaceName, serviceName, healthStatus string) ([]*Instance, error)
 	UpdateInstanceHealthStatus(ctx context.Context, serviceID, instanceID string, status string) error
+	UpdateInstanceCustomHealthStatus(ctx context.Context, serviceID, instanceID string, status string) error
 }
 
+var (
+	// ErrNamespaceNotFound is returned for unknown namespaces
+	ErrNamespaceNotFound = errors.New("namespace not found")
+	// ErrNamespaceNotEmpty is returned when deleting a namespace with services
+	ErrNamespaceNotEmpty = errors.New("namespace has services")
+	// ErrOperationNotFound is returned for unknown operations
+	ErrOperationNotFound = errors.New("operation not found")
+	// ErrServiceNotFound is returned for unknown services
+	ErrServiceNotFound = errors.New("service not found")
+	// ErrInstanceNotFound is returned for unknown instances
+	ErrInstanceNotFound = errors.New("instance not found")
+	// ErrCustomHealthNotFound is returned when a service has no custom health check
+	ErrCustomHealthNotFound = errors.New("service has no custom health check")
+)
+
 // manager implements the Manager interface
 type manager struct {
 	kubeClient kubernetes.Interface
@@ -51,6 +77,13 @@ type manager struct {
 	// DNS namespace to Kubernetes namespace mapping
 	dnsToK8sNamespace map[string]string
 
+	// SOA serial of the last generated DNS zone
+	zoneSerial uint32
+
+	// Operations of the asynchronous API calls, guarded by opMu
+	opMu       sync.RWMutex
+	operations map[string]*Operation
+
 	// Route53 integration (optional)
 	route53Manager *route53.Manager
 }
@@ -65,6 +98,7 @@ func NewManager(kubeClient kubernetes.Interface, region, accountID, endpoint str
 		services:          make(map[string]*Service),
 		instances:         make(map[string]map[string]*Instance),
 		dnsToK8sNamespace: make(map[string]string),
+		operations:        make(map[string]*Operation),
 	}
 
 	// Initialize Route53 integration if endpoint is provided
@@ -108,7 +142,7 @@ func (m *manager) CreateNamespace(ctx context.Context, namespace *Namespace) err
 
 	// Generate ARN if not set
 	if namespace.ARN == "" {
-		namespace.ARN = fmt.Sprintf("arn:aws:servicediscovery:%s:%s:namespace/%s", m.region, m.accountID, namespace.ID)
+		namespace.ARN = awsarn.Build("servicediscovery", m.region, m.accountID, fmt.Sprintf("namespace/%s", namespace.ID))
 	}
 
 	// Set creation time if not set
@@ -138,6 +172,19 @@ func (m *manager) CreateNamespace(ctx context.Context, namespace *Namespace) err
 	// Store namespace
 	m.namespaces[namespace.ID] = namespace
 
+	// Route the ExternalName aliases of its services unless the namespace
+	// was already mapped to the namespace of an ECS cluster
+	if _, mapped := m.dnsToK8sNamespace[namespace.Name]; !mapped {
+		m.dnsToK8sNamespace[namespace.Name] = defaultK8sNamespace(namespace.Name)
+	}
+
+	// Create the Kubernetes namespace the endpoints of its instances go to
+	if m.kubeClient != nil {
+		if err := m.ensureEndpointsNamespace(ctx, namespace); err != nil {
+			logging.Warn("Failed to create Kubernetes namespace", "namespace", namespace.Name, "error", err)
+		}
+	}
+
 	// Update CoreDNS configuration for DNS namespaces
 	if namespace.Type == NamespaceTypeDNSPrivate || namespace.Type == NamespaceTypeDNSPublic {
 		if err := m.updateCoreDNSConfig(ctx, namespace); err != nil {
@@ -168,7 +215,7 @@ func (m *manager) CreatePrivateDnsNamespace(ctx context.Context, name, vpc strin
 
 	// Generate IDs
 	namespaceID := fmt.Sprintf("ns-%s", generateID())
-	arn := fmt.Sprintf("arn:aws:servicediscovery:%s:%s:namespace/%s", m.region, m.accountID, namespaceID)
+	arn := awsarn.Build("servicediscovery", m.region, m.accountID, fmt.Sprintf("namespace/%s", namespaceID))
 
 	// Create namespace
 	namespace := &Namespace{
@@ -193,13 +240,7 @@ func (m *manager) CreatePrivateDnsNamespace(ctx context.Context, name, vpc strin
 	m.namespaces[namespaceID] = namespace
 
 	// Map DNS namespace to Kubernetes namespace
-	// Use the cluster name as Kubernetes namespace (e.g., "local.ecs" -> "default")
-	k8sNamespace := "default"
-	if name != "local.ecs" {
-		// Extract first part as namespace name
-		k8sNamespace = extractK8sNamespace(name)
-	}
-	m.dnsToK8sNamespace[name] = k8sNamespace
+	m.dnsToK8sNamespace[name] = defaultK8sNamespace(name)
 
 	// Create Route53 hosted zone if integration is enabled
 	if m.route53Manager != nil {
@@ -210,6 +251,11 @@ func (m *manager) CreatePrivateDnsNamespace(ctx context.Context, name, vpc strin
 		}
 	}
 
+	// Serve the namespace from CoreDNS
+	if err := m.updateCoreDNSConfig(ctx, namespace); err != nil {
+		logging.Warn("Failed to update CoreDNS configuration", "namespace", name, "error", err)
+	}
+
 	logging.Info("Created private DNS namespace", "name", name, "id", namespaceID)
 
 	return namespace, nil
@@ -222,7 +268,7 @@ func (m *manager) GetNamespace(ctx context.Context, namespaceID string) (*Namesp
 
 	namespace, exists := m.namespaces[namespaceID]
 	if !exists {
-		return nil, fmt.Errorf("namespace not found: %s", namespaceID)
+		return nil, fmt.Errorf("%w: %s", ErrNamespaceNotFound, namespaceID)
 	}
 
 	return namespace, nil
@@ -241,6 +287,15 @@ func (m *manager) ListNamespaces(ctx context.Context) ([]*Namespace, error) {
 	return namespaces, nil
 }
 
+// MapK8sNamespace routes a DNS namespace to the Kubernetes namespace its
+// services run in, replacing the mapping derived from the namespace name
+func (m *manager) MapK8sNamespace(dnsNamespace, k8sNamespace string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.dnsToK8sNamespace[dnsNamespace] = k8sNamespace
+}
+
 // DeleteNamespace deletes a namespace
 func (m *manager) DeleteNamespace(ctx context.Context, namespaceID string) error {
 	m.mu.Lock()
@@ -248,12 +303,12 @@ func (m *manager) DeleteNamespace(ctx context.Context, namespaceID string) error
 
 	namespace, exists := m.namespaces[namespaceID]
 	if !exists {
-		return fmt.Errorf("namespace not found: %s", namespaceID)
+		return fmt.Errorf("%w: %s", ErrNamespaceNotFound, namespaceID)
 	}
 
 	// Check if namespace has services
 	if namespace.ServiceCount > 0 {
-		return fmt.Errorf("namespace has %d services, cannot delete", namespace.ServiceCount)
+		return fmt.Errorf("%w: %d services, cannot delete", ErrNamespaceNotEmpty, namespace.ServiceCount)
 	}
 
 	delete(m.namespaces, namespaceID)
@@ -266,6 +321,13 @@ func (m *manager) DeleteNamespace(ctx context.Context, namespaceID string) error
 		}
 	}
 
+	// Delete the Kubernetes namespace of its endpoints
+	if m.kubeClient != nil {
+		if err := m.deleteEndpointsNamespace(ctx, namespace); err != nil {
+			logging.Warn("Failed to delete Kubernetes namespace", "namespace", namespace.Name, "error", err)
+		}
+	}
+
 	// Delete Route53 hosted zone if integration is enabled
 	if m.route53Manager != nil {
 		err := m.route53Manager.DeleteNamespaceZone(ctx, namespace.Name)
@@ -299,7 +361,7 @@ func (m *manager) CreateService(ctx context.Context, service *Service) error {
 
 	// Generate ARN if not set
 	if service.ARN == "" {
-		service.ARN = fmt.Sprintf("arn:aws:servicediscovery:%s:%s:service/%s", m.region, m.accountID, service.ID)
+		service.ARN = awsarn.Build("servicediscovery", m.region, m.accountID, fmt.Sprintf("service/%s", service.ID))
 	}
 
 	// Set creation time if not set
@@ -319,6 +381,7 @@ func (m *manager) CreateService(ctx context.Context, service *Service) error {
 		logging.Warn("Failed to create DNS alias for service", "service", service.Name, "error", err)
 		// Don't fail service creation for DNS alias issues
 	}
+	m.refreshZone(ctx, service.NamespaceID)
 
 	logging.Info("Created service in namespace", "name", service.Name, "namespaceID", service.NamespaceID, "serviceID", service.ID)
 
@@ -381,6 +444,7 @@ func (m *manager) DeleteService(ctx context.Context, serviceID string) error {
 
 	delete(m.services, serviceID)
 	delete(m.instances, serviceID)
+	m.refreshZone(ctx, service.NamespaceID)
 
 	logging.Info("Deleted service", "serviceID", serviceID)
 
@@ -420,6 +484,7 @@ func (m *manager) RegisterInstance(ctx context.Context, instance *Instance) erro
 				existingInstance.Attributes[k] = v
 			}
 		}
+		m.refreshZone(ctx, service.NamespaceID)
 		return nil
 	}
 
@@ -437,9 +502,18 @@ func (m *manager) RegisterInstance(ctx context.Context, instance *Instance) erro
 	// AWS SDK compatibility attributes
 	instance.Attributes["AWS_INSTANCE_ID"] = instance.ID
 
-	// Set default health status if not set
+	// Set default health status if not set. Instances of services with a
+	// custom health check start HEALTHY unless AWS_INIT_HEALTH_STATUS says
+	// otherwise, as in Cloud Map.
 	if instance.HealthStatus == "" {
-		instance.HealthStatus = "UNKNOWN"
+		switch {
+		case service.HealthCheckCustomConfig == nil:
+			instance.HealthStatus = "UNKNOWN"
+		case instance.Attributes["AWS_INIT_HEALTH_STATUS"] == "UNHEALTHY":
+			instance.HealthStatus = "UNHEALTHY"
+		default:
+			instance.HealthStatus = "HEALTHY"
+		}
 	}
 
 	m.instances[instance.ServiceID][instance.ID] = instance
@@ -456,21 +530,10 @@ func (m *manager) RegisterInstance(ctx context.Context, instance *Instance) erro
 		logging.Error("Failed to update Kubernetes endpoints", "error", err)
 		// Don't fail the registration, just log the error
 	}
+	m.refreshZone(ctx, service.NamespaceID)
 
 	// Update Route53 records if integration is enabled
-	if m.route53Manager != nil {
-		namespace := m.namespaces[service.NamespaceID]
-		if namespace != nil {
-			// Collect all IPs from instances
-			ips := m.collectInstanceIPs(m.instances[instance.ServiceID])
-			if len(ips) > 0 {
-				err := m.route53Manager.RegisterService(ctx, namespace.Name, service.Name, ips)
-				if err != nil {
-					logging.Warn("Failed to update Route53 records", "service", service.Name, "error", err)
-				}
-			}
-		}
-	}
+	m.updateRoute53Records(ctx, service)
 
 	return nil
 }
@@ -498,25 +561,10 @@ func (m *manager) DeregisterInstance(ctx context.Context, serviceID string, inst
 	if err := m.updateKubernetesEndpoints(ctx, service, m.instances[serviceID]); err != nil {
 		logging.Error("Failed to update Kubernetes endpoints", "error", err)
 	}
+	m.refreshZone(ctx, service.NamespaceID)
 
 	// Update Route53 records if integration is enabled
-	if m.route53Manager != nil {
-		namespace := m.namespaces[service.NamespaceID]
-		if namespace != nil {
-			// Collect remaining IPs from instances
-			ips := m.collectInstanceIPs(m.instances[serviceID])
-			var err error
-			if len(ips) > 0 {
-				err = m.route53Manager.RegisterService(ctx, namespace.Name, service.Name, ips)
-			} else {
-				// No more instances, remove the service from Route53
-				err = m.route53Manager.DeregisterService(ctx, namespace.Name, service.Name)
-			}
-			if err != nil {
-				logging.Warn("Failed to update Route53 records", "service", service.Name, "error", err)
-			}
-		}
-	}
+	m.updateRoute53Records(ctx, service)
 
 	return nil
 }
@@ -542,8 +590,15 @@ func (m *manager) ListInstances(ctx context.Context, serviceID string) ([]*Insta
 	return instances, nil
 }
 
-// DiscoverInstances discovers instances for a service
+// DiscoverInstances discovers the healthy instances of a service
 func (m *manager) DiscoverInstances(ctx context.Context, namespaceName, serviceName string) ([]*Instance, error) {
+	return m.DiscoverInstancesByHealth(ctx, namespaceName, serviceName, "HEALTHY")
+}
+
+// DiscoverInstancesByHealth discovers the instances of a service matching a
+// Cloud Map health status filter: HEALTHY, UNHEALTHY, ALL or
+// HEALTHY_OR_ELSE_ALL
+func (m *manager) DiscoverInstancesByHealth(ctx context.Context, namespaceName, serviceName, healthStatus string) ([]*Instance, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
@@ -573,17 +628,29 @@ func (m *manager) DiscoverInstances(ctx context.Context, namespaceName, serviceN
 		return nil, fmt.Errorf("service not found: %s in namespace %s", serviceName, namespaceName)
 	}
 
-	// Get only healthy instances for DNS resolution
-	var instances []*Instance
+	var healthy, unhealthy, all []*Instance
 	for _, instance := range m.instances[serviceID] {
-		// Only include healthy instances in DNS responses
+		all = append(all, instance)
+		// Only healthy instances are returned by default
 		// This implements ECS behavior where unhealthy containers are excluded from Service Discovery
 		if instance.HealthStatus == "HEALTHY" || instance.HealthStatus == "" {
-			instances = append(instances, instance)
+			healthy = append(healthy, instance)
+		} else if instance.HealthStatus == "UNHEALTHY" {
+			unhealthy = append(unhealthy, instance)
 		}
 	}
 
-	return instances, nil
+	switch healthStatus {
+	case "ALL":
+		return all, nil
+	case "UNHEALTHY":
+		return unhealthy, nil
+	case "HEALTHY_OR_ELSE_ALL":
+		if len(healthy) == 0 {
+			return all, nil
+		}
+	}
+	return healthy, nil
 }
 
 // UpdateInstanceHealthStatus updates the health status of an instance
@@ -601,9 +668,62 @@ func (m *manager) UpdateInstanceHealthStatus(ctx context.Context, serviceID, ins
 		return fmt.Errorf("instance %s not found", instanceID)
 	}
 
+	m.setInstanceHealthStatus(ctx, service, instance, status)
+	return nil
+}
+
+// UpdateInstanceCustomHealthStatus records a status reported for an
+// instance of a service with a custom health check. An instance turns
+// UNHEALTHY once the failure threshold of the service is reached by
+// consecutive UNHEALTHY reports and HEALTHY with the first HEALTHY one.
+func (m *manager) UpdateInstanceCustomHealthStatus(ctx context.Context, serviceID, instanceID string, status string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	service, exists := m.services[serviceID]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrServiceNotFound, serviceID)
+	}
+	if service.HealthCheckCustomConfig == nil {
+		return fmt.Errorf("%w: %s", ErrCustomHealthNotFound, serviceID)
+	}
+	instance, exists := m.instances[serviceID][instanceID]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrInstanceNotFound, instanceID)
+	}
+
+	switch status {
+	case "HEALTHY":
+		instance.customHealthFailures = 0
+	case "UNHEALTHY":
+		instance.customHealthFailures++
+		threshold := service.HealthCheckCustomConfig.FailureThreshold
+		if threshold < 1 {
+			threshold = 1
+		}
+		if instance.customHealthFailures < threshold {
+			logging.Debug("Instance below custom health failure threshold",
+				"instanceID", instanceID,
+				"failures", instance.customHealthFailures,
+				"threshold", threshold)
+			return nil
+		}
+	default:
+		return fmt.Errorf("invalid custom health status: %s", status)
+	}
+
+	m.setInstanceHealthStatus(ctx, service, instance, status)
+	return nil
+}
+
+// setInstanceHealthStatus changes the health status of an instance and
+// updates its DNS records. The caller must hold m.mu.
+func (m *manager) setInstanceHealthStatus(ctx context.Context, service *Service, instance *Instance, status string) {
+	serviceID, instanceID := service.ID, instance.ID
+
 	// Only update if status actually changed
 	if instance.HealthStatus == status {
-		return nil
+		return
 	}
 
 	previousStatus := instance.HealthStatus
@@ -624,30 +744,10 @@ func (m *manager) UpdateInstanceHealthStatus(ctx context.Context, serviceID, ins
 			"error", err)
 		// Don't fail the health status update, just log the error
 	}
+	m.refreshZone(ctx, service.NamespaceID)
 
 	// Update Route53 records if integration is enabled
-	if m.route53Manager != nil {
-		namespace := m.namespaces[service.NamespaceID]
-		if namespace != nil {
-			// Collect only healthy instance IPs
-			var ips []string
-			for _, inst := range m.instances[serviceID] {
-				if inst.HealthStatus == "HEALTHY" || inst.HealthStatus == "" {
-					if ip := inst.Attributes["AWS_INSTANCE_IPV4"]; ip != "" {
-						ips = append(ips, ip)
-					}
-				}
-			}
-
-			if err := m.route53Manager.RegisterService(ctx, namespace.Name, service.Name, ips); err != nil {
-				logging.Warn("Failed to update Route53 records after health status change",
-					"service", service.Name,
-					"error", err)
-			}
-		}
-	}
-
-	return nil
+	m.updateRoute53Records(ctx, service)
 }
 
 // UpdateServiceEndpoint updates the ExternalName Service to point to the actual ECS service
@@ -677,3 +777,38 @@ func (m *manager) UpdateServiceEndpoint(ctx context.Context, serviceID string, e
 
 	return nil
 }
+
+// updateRoute53Records publishes the healthy instances of a service to its
+// Route53 hosted zone: SRV records with per-instance host records when the
+// service DNS config has an SRV record, A records otherwise. The caller must
+// hold m.mu.
+func (m *manager) updateRoute53Records(ctx context.Context, service *Service) {
+	if m.route53Manager == nil {
+		return
+	}
+	namespace := m.namespaces[service.NamespaceID]
+	if namespace == nil {
+		return
+	}
+
+	var err error
+	if hasDNSRecord(service, "SRV") {
+		targets := m.collectSRVTargets(namespace, service, m.instances[service.ID])
+		if len(targets) > 0 {
+			err = m.route53Manager.RegisterServiceWithPorts(ctx, namespace.Name, service.Name, targets)
+		} else {
+			err = m.route53Manager.DeregisterService(ctx, namespace.Name, service.Name)
+		}
+	} else {
+		ips := m.collectInstanceIPs(m.instances[service.ID])
+		if len(ips) > 0 {
+			err = m.route53Manager.RegisterService(ctx, namespace.Name, service.Name, ips)
+		} else {
+			// No more instances, remove the service from Route53
+			err = m.route53Manager.DeregisterService(ctx, namespace.Name, service.Name)
+		}
+	}
+	if err != nil {
+		logging.Warn("Failed to update Route53 records", "service", service.Name, "error", err)
+	}
+}
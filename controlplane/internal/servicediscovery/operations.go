@@ -0,0 +1,119 @@
+package servicediscovery
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// Operation statuses, as in Cloud Map
+const (
+	OperationStatusSubmitted = "SUBMITTED"
+	OperationStatusPending   = "PENDING"
+	OperationStatusSuccess   = "SUCCESS"
+	OperationStatusFail      = "FAIL"
+)
+
+// operationErrorCode is the error code of failed operations whose error has
+// no AWS error code
+const operationErrorCode = "INTERNAL_FAILURE"
+
+// StartOperation records an operation as SUBMITTED and runs it in the
+// background, moving it to PENDING and then to SUCCESS or FAIL with the
+// error it returned. Callers poll the outcome with GetOperation.
+func (m *manager) StartOperation(operation *Operation, run func(ctx context.Context) error) {
+	operation.Status = OperationStatusSubmitted
+	m.RecordOperation(operation)
+
+	go func() {
+		m.setOperationStatus(operation.ID, OperationStatusPending, nil)
+		err := run(context.Background())
+		if err != nil {
+			logging.Warn("Service discovery operation failed",
+				"operationID", operation.ID,
+				"type", operation.Type,
+				"error", err)
+			m.setOperationStatus(operation.ID, OperationStatusFail, err)
+			return
+		}
+		m.setOperationStatus(operation.ID, OperationStatusSuccess, nil)
+	}()
+}
+
+// RecordOperation stores an operation, completed ones included
+func (m *manager) RecordOperation(operation *Operation) {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
+	now := time.Now()
+	if operation.CreatedAt.IsZero() {
+		operation.CreatedAt = now
+	}
+	if operation.UpdatedAt.IsZero() {
+		operation.UpdatedAt = now
+	}
+	m.operations[operation.ID] = operation
+}
+
+// setOperationStatus moves an operation to a status, recording the error of
+// failed operations
+func (m *manager) setOperationStatus(operationID, status string, err error) {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
+	operation, exists := m.operations[operationID]
+	if !exists {
+		return
+	}
+	operation.Status = status
+	operation.UpdatedAt = time.Now()
+	if err != nil {
+		operation.ErrorCode = operationErrorCode
+		if coded, ok := err.(interface{ ErrorCode() string }); ok {
+			operation.ErrorCode = coded.ErrorCode()
+		}
+		operation.ErrorMessage = err.Error()
+	}
+}
+
+// GetOperation returns a copy of an operation
+func (m *manager) GetOperation(ctx context.Context, operationID string) (*Operation, error) {
+	m.opMu.RLock()
+	defer m.opMu.RUnlock()
+
+	operation, exists := m.operations[operationID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrOperationNotFound, operationID)
+	}
+	return copyOperation(operation), nil
+}
+
+// ListOperations returns copies of all operations, oldest first
+func (m *manager) ListOperations(ctx context.Context) ([]*Operation, error) {
+	m.opMu.RLock()
+	defer m.opMu.RUnlock()
+
+	operations := make([]*Operation, 0, len(m.operations))
+	for _, operation := range m.operations {
+		operations = append(operations, copyOperation(operation))
+	}
+	sort.Slice(operations, func(i, j int) bool {
+		if operations[i].CreatedAt.Equal(operations[j].CreatedAt) {
+			return operations[i].ID < operations[j].ID
+		}
+		return operations[i].CreatedAt.Before(operations[j].CreatedAt)
+	})
+	return operations, nil
+}
+
+func copyOperation(operation *Operation) *Operation {
+	c := *operation
+	c.Targets = make(map[string]string, len(operation.Targets))
+	for k, v := range operation.Targets {
+		c.Targets[k] = v
+	}
+	return &c
+}
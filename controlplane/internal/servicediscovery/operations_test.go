@@ -0,0 +1,127 @@
+package servicediscovery
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/servicediscovery/generated"
+)
+
+var _ = Describe("Handler namespace operations", func() {
+	var (
+		ctx        context.Context
+		fakeClient *fake.Clientset
+		mgr        Manager
+		handler    *Handler
+	)
+
+	operationStatus := func(operationID string) func() generated.OperationStatus {
+		return func() generated.OperationStatus {
+			resp, err := handler.GetOperation(ctx, &generated.GetOperationRequest{OperationId: operationID})
+			Expect(err).NotTo(HaveOccurred())
+			return *resp.Operation.Status
+		}
+	}
+
+	createNamespace := func(name string) (string, string) {
+		resp, err := handler.CreatePrivateDnsNamespace(ctx, &generated.CreatePrivateDnsNamespaceRequest{Name: name, Vpc: "vpc-123"})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(operationStatus(*resp.OperationId)).Should(Equal(generated.OperationStatusSUCCESS))
+
+		op, err := handler.GetOperation(ctx, &generated.GetOperationRequest{OperationId: *resp.OperationId})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*op.Operation.Type).To(Equal(generated.OperationTypeCREATE_NAMESPACE))
+		return *resp.OperationId, op.Operation.Targets[generated.OperationTargetTypeNAMESPACE]
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		fakeClient = fake.NewSimpleClientset()
+		mgr = NewManager(fakeClient, "us-east-1", "123456789012", "")
+		handler = NewHandler(logrus.New(), nil, mgr, "us-east-1", "123456789012")
+	})
+
+	It("should create a namespace with its CoreDNS config and Kubernetes namespace", func() {
+		_, namespaceID := createNamespace("orders.local")
+
+		resp, err := handler.GetNamespace(ctx, &generated.GetNamespaceRequest{Id: namespaceID})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*resp.Namespace.Name).To(Equal("orders.local"))
+
+		cm, err := fakeClient.CoreV1().ConfigMaps(coreDNSNamespace).Get(ctx, customCoreDNSConfigMap, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cm.Data).To(HaveKey(serverBlockKey(namespaceID)))
+
+		ns, err := fakeClient.CoreV1().Namespaces().Get(ctx, "orderslocal", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ns.Labels).To(HaveKeyWithValue("kecs.io/service-discovery", "true"))
+	})
+
+	It("should reject namespaces that already exist", func() {
+		_, namespaceID := createNamespace("orders.local")
+
+		_, err := handler.CreatePrivateDnsNamespace(ctx, &generated.CreatePrivateDnsNamespaceRequest{Name: "orders.local", Vpc: "vpc-123"})
+		Expect(err).To(BeAssignableToTypeOf(&generated.NamespaceAlreadyExists{}))
+		Expect(*err.(*generated.NamespaceAlreadyExists).NamespaceId).To(Equal(namespaceID))
+	})
+
+	It("should delete namespaces without services", func() {
+		_, namespaceID := createNamespace("orders.local")
+
+		Expect(mgr.CreateService(ctx, &Service{ID: "srv-api", Name: "api", NamespaceID: namespaceID})).To(Succeed())
+		_, err := handler.DeleteNamespace(ctx, &generated.DeleteNamespaceRequest{Id: namespaceID})
+		Expect(err).To(BeAssignableToTypeOf(&generated.ResourceInUse{}))
+
+		Expect(mgr.DeleteService(ctx, "srv-api")).To(Succeed())
+		resp, err := handler.DeleteNamespace(ctx, &generated.DeleteNamespaceRequest{Id: namespaceID})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(operationStatus(*resp.OperationId)).Should(Equal(generated.OperationStatusSUCCESS))
+
+		_, err = handler.GetNamespace(ctx, &generated.GetNamespaceRequest{Id: namespaceID})
+		Expect(err).To(HaveOccurred())
+		cm, err := fakeClient.CoreV1().ConfigMaps(coreDNSNamespace).Get(ctx, customCoreDNSConfigMap, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cm.Data).NotTo(HaveKey(serverBlockKey(namespaceID)))
+		_, err = fakeClient.CoreV1().Namespaces().Get(ctx, "orderslocal", metav1.GetOptions{})
+		Expect(err).To(HaveOccurred())
+
+		_, err = handler.DeleteNamespace(ctx, &generated.DeleteNamespaceRequest{Id: namespaceID})
+		Expect(err).To(BeAssignableToTypeOf(&generated.NamespaceNotFound{}))
+	})
+
+	It("should list operations matching filters", func() {
+		createOp, namespaceID := createNamespace("orders.local")
+		resp, err := handler.DeleteNamespace(ctx, &generated.DeleteNamespaceRequest{Id: namespaceID})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(operationStatus(*resp.OperationId)).Should(Equal(generated.OperationStatusSUCCESS))
+
+		list, err := handler.ListOperations(ctx, &generated.ListOperationsRequest{
+			Filters: []generated.OperationFilter{
+				{Name: generated.OperationFilterNameNAMESPACE_ID, Values: []string{namespaceID}},
+				{Name: generated.OperationFilterNameTYPE, Values: []string{"CREATE_NAMESPACE"}},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list.Operations).To(HaveLen(1))
+		Expect(*list.Operations[0].Id).To(Equal(createOp))
+
+		in := generated.FilterConditionIN
+		list, err = handler.ListOperations(ctx, &generated.ListOperationsRequest{
+			Filters: []generated.OperationFilter{
+				{Name: generated.OperationFilterNameTYPE, Condition: &in, Values: []string{"CREATE_NAMESPACE", "DELETE_NAMESPACE"}},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list.Operations).To(HaveLen(2))
+	})
+
+	It("should report unknown operations", func() {
+		_, err := handler.GetOperation(ctx, &generated.GetOperationRequest{OperationId: "op-missing"})
+		Expect(err).To(BeAssignableToTypeOf(&generated.OperationNotFound{}))
+	})
+})
@@ -89,6 +89,9 @@ type Instance struct {
 	HealthStatus string // HEALTHY, UNHEALTHY, UNKNOWN
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
+
+	// customHealthFailures counts consecutive UNHEALTHY custom health reports
+	customHealthFailures int
 }
 
 // DiscoverInstancesRequest represents a request to discover instances
@@ -116,10 +119,12 @@ type InstanceSummary struct {
 
 // Operation represents an async operation
 type Operation struct {
-	ID        string            `json:"id"`
-	Type      string            `json:"type"`
-	Status    string            `json:"status"`
-	CreatedAt time.Time         `json:"created_at"`
-	UpdatedAt time.Time         `json:"updated_at"`
-	Targets   map[string]string `json:"targets"`
+	ID           string            `json:"id"`
+	Type         string            `json:"type"`
+	Status       string            `json:"status"`
+	ErrorCode    string            `json:"error_code,omitempty"`
+	ErrorMessage string            `json:"error_message,omitempty"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+	Targets      map[string]string `json:"targets"`
 }
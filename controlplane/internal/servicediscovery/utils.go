@@ -3,7 +3,11 @@ package servicediscovery
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"fmt"
+	"strconv"
 	"strings"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/route53"
 )
 
 // generateID generates a random ID for resources
@@ -13,6 +17,16 @@ func generateID() string {
 	return hex.EncodeToString(bytes)[:12]
 }
 
+// defaultK8sNamespace returns the Kubernetes namespace the services of a DNS
+// namespace are aliased in until it is mapped to the namespace of an ECS
+// cluster, "default" for local.ecs
+func defaultK8sNamespace(dnsNamespace string) string {
+	if dnsNamespace == "local.ecs" {
+		return "default"
+	}
+	return extractK8sNamespace(dnsNamespace)
+}
+
 // extractK8sNamespace extracts a Kubernetes namespace from DNS namespace name
 func extractK8sNamespace(dnsNamespace string) string {
 	// Remove common suffixes
@@ -59,3 +73,52 @@ func (m *manager) collectInstanceIPs(instances map[string]*Instance) []string {
 	}
 	return ips
 }
+
+// collectSRVTargets collects the SRV targets of the healthy instances of a
+// service. Each target is a host record of the instance under the service
+// name, as Cloud Map names them.
+func (m *manager) collectSRVTargets(namespace *Namespace, service *Service, instances map[string]*Instance) []route53.ServiceTarget {
+	targets := make([]route53.ServiceTarget, 0)
+	for _, instance := range instances {
+		if instance.HealthStatus != "HEALTHY" && instance.HealthStatus != "" {
+			continue
+		}
+		ip := instanceIPv4(instance)
+		port := instancePort(instance)
+		if ip == "" || port == 0 {
+			continue
+		}
+		targets = append(targets, route53.ServiceTarget{
+			Host: fmt.Sprintf("%s.%s.%s", dnsLabel(instance.ID), strings.ToLower(service.Name), namespace.Name),
+			IP:   ip,
+			Port: uint16(port),
+		})
+	}
+	return targets
+}
+
+// hasDNSRecord reports whether the DNS config of a service has a record type
+func hasDNSRecord(service *Service, recordType string) bool {
+	if service.DNSConfig == nil {
+		return false
+	}
+	for _, record := range service.DNSConfig.DNSRecords {
+		if record.Type == recordType {
+			return true
+		}
+	}
+	return false
+}
+
+// instancePort returns the port an instance registered, 0 if none
+func instancePort(instance *Instance) int {
+	value, ok := instance.Attributes["AWS_INSTANCE_PORT"]
+	if !ok {
+		value = instance.Attributes["PORT"]
+	}
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return 0
+	}
+	return port
+}
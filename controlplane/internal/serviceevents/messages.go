@@ -0,0 +1,74 @@
+package serviceevents
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The helpers below produce messages in the same format as AWS ECS so that
+// tooling which greps service events keeps working against KECS.
+
+// SteadyState returns the message recorded when a service reaches its desired count
+func SteadyState(serviceName string) string {
+	return fmt.Sprintf("(service %s) has reached a steady state.", serviceName)
+}
+
+// DeploymentStarted returns the message recorded when a new deployment begins
+func DeploymentStarted(serviceName, deploymentID string) string {
+	return fmt.Sprintf("(service %s) (deployment %s) deployment started.", serviceName, deploymentID)
+}
+
+// DeploymentCompleted returns the message recorded when a deployment finishes
+func DeploymentCompleted(serviceName, deploymentID string) string {
+	return fmt.Sprintf("(service %s) (deployment %s) deployment completed.", serviceName, deploymentID)
+}
+
+// DesiredCountChanged returns the message recorded when a service is scaled
+func DesiredCountChanged(serviceName string, from, to int) string {
+	return fmt.Sprintf("(service %s) desired count changed from %d to %d.", serviceName, from, to)
+}
+
+// TasksStarted returns the message recorded when tasks are started for a service
+func TasksStarted(serviceName string, taskIDs []string) string {
+	parts := make([]string, 0, len(taskIDs))
+	for _, id := range taskIDs {
+		parts = append(parts, fmt.Sprintf("(task %s)", id))
+	}
+	return fmt.Sprintf("(service %s) has started %d tasks: %s.", serviceName, len(taskIDs), strings.Join(parts, " "))
+}
+
+// UnableToPlaceTask returns the message recorded when a task cannot be scheduled
+func UnableToPlaceTask(serviceName, reason string) string {
+	msg := fmt.Sprintf("(service %s) was unable to place a task because no container instance met all of its requirements.", serviceName)
+	if reason != "" {
+		msg += " Reason: " + reason
+	}
+	return msg
+}
+
+// TargetsRegistered returns the message recorded when targets are registered with a target group
+func TargetsRegistered(serviceName string, count int, targetGroupARN string) string {
+	return fmt.Sprintf("(service %s) registered %d targets in (target-group %s)", serviceName, count, targetGroupARN)
+}
+
+// DeploymentFailed returns the message recorded when a deployment cannot be applied
+func DeploymentFailed(serviceName, deploymentID, reason string) string {
+	return fmt.Sprintf("(service %s) (deployment %s) deployment failed: %s.", serviceName, deploymentID, reason)
+}
+
+// RollingBack returns the message recorded when a failed deployment is rolled back
+func RollingBack(serviceName, deploymentID string) string {
+	return fmt.Sprintf("(service %s) rolling back to deployment %s.", serviceName, deploymentID)
+}
+
+// DriftDetected returns the message recorded when the Kubernetes resources of
+// a service no longer match its desired state
+func DriftDetected(serviceName, drift string) string {
+	return fmt.Sprintf("(service %s) drifted from its desired state: %s.", serviceName, drift)
+}
+
+// RestoringResources returns the message recorded when the Kubernetes
+// resources of a drifted service are re-applied
+func RestoringResources(serviceName string) string {
+	return fmt.Sprintf("(service %s) is restoring its resources to the desired state.", serviceName)
+}
@@ -0,0 +1,122 @@
+package serviceevents
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultCapacity is the number of events kept per service, matching the
+// maximum number of events returned by DescribeServices in AWS ECS
+const DefaultCapacity = 100
+
+// Event is a single service event
+type Event struct {
+	ID        string
+	CreatedAt time.Time
+	Message   string
+}
+
+// ring is a fixed size buffer of events for one service
+type ring struct {
+	events []Event
+	next   int
+	full   bool
+}
+
+// Recorder keeps a capped ring buffer of events per service ARN
+type Recorder struct {
+	mu       sync.RWMutex
+	capacity int
+	services map[string]*ring
+	now      func() time.Time
+}
+
+// NewRecorder creates a new recorder keeping at most capacity events per service
+func NewRecorder(capacity int) *Recorder {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	return &Recorder{
+		capacity: capacity,
+		services: make(map[string]*ring),
+		now:      time.Now,
+	}
+}
+
+// Record appends an event for the service. Consecutive identical messages are
+// collapsed so that periodic syncs do not flood the buffer.
+func (r *Recorder) Record(serviceARN, message string) {
+	if r == nil || serviceARN == "" || message == "" {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	buf, ok := r.services[serviceARN]
+	if !ok {
+		buf = &ring{events: make([]Event, r.capacity)}
+		r.services[serviceARN] = buf
+	}
+
+	if last, ok := buf.latest(r.capacity); ok && last.Message == message {
+		return
+	}
+
+	buf.events[buf.next] = Event{
+		ID:        uuid.New().String(),
+		CreatedAt: r.now(),
+		Message:   message,
+	}
+	buf.next = (buf.next + 1) % r.capacity
+	if buf.next == 0 {
+		buf.full = true
+	}
+}
+
+// Events returns the events of a service, newest first
+func (r *Recorder) Events(serviceARN string) []Event {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	buf, ok := r.services[serviceARN]
+	if !ok {
+		return nil
+	}
+
+	count := buf.next
+	if buf.full {
+		count = r.capacity
+	}
+
+	result := make([]Event, 0, count)
+	for i := 1; i <= count; i++ {
+		idx := (buf.next - i + r.capacity) % r.capacity
+		result = append(result, buf.events[idx])
+	}
+	return result
+}
+
+// Delete drops all events of a service
+func (r *Recorder) Delete(serviceARN string) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.services, serviceARN)
+}
+
+func (b *ring) latest(capacity int) (Event, bool) {
+	if b.next == 0 && !b.full {
+		return Event{}, false
+	}
+	return b.events[(b.next-1+capacity)%capacity], true
+}
@@ -0,0 +1,54 @@
+package serviceevents_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/serviceevents"
+)
+
+var _ = Describe("Recorder", func() {
+	const serviceARN = "arn:aws:ecs:us-east-1:000000000000:service/default/web"
+
+	It("should return events newest first", func() {
+		recorder := serviceevents.NewRecorder(10)
+		recorder.Record(serviceARN, "first")
+		recorder.Record(serviceARN, "second")
+
+		events := recorder.Events(serviceARN)
+		Expect(events).To(HaveLen(2))
+		Expect(events[0].Message).To(Equal("second"))
+		Expect(events[1].Message).To(Equal("first"))
+		Expect(events[0].ID).NotTo(BeEmpty())
+	})
+
+	It("should cap the number of events per service", func() {
+		recorder := serviceevents.NewRecorder(3)
+		for i := 0; i < 5; i++ {
+			recorder.Record(serviceARN, fmt.Sprintf("event-%d", i))
+		}
+
+		events := recorder.Events(serviceARN)
+		Expect(events).To(HaveLen(3))
+		Expect(events[0].Message).To(Equal("event-4"))
+		Expect(events[2].Message).To(Equal("event-2"))
+	})
+
+	It("should collapse consecutive duplicate messages", func() {
+		recorder := serviceevents.NewRecorder(10)
+		recorder.Record(serviceARN, serviceevents.SteadyState("web"))
+		recorder.Record(serviceARN, serviceevents.SteadyState("web"))
+
+		Expect(recorder.Events(serviceARN)).To(HaveLen(1))
+	})
+
+	It("should drop events when a service is deleted", func() {
+		recorder := serviceevents.NewRecorder(10)
+		recorder.Record(serviceARN, "first")
+		recorder.Delete(serviceARN)
+
+		Expect(recorder.Events(serviceARN)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,13 @@
+package serviceevents_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestServiceEvents(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Service Events Suite")
+}
@@ -0,0 +1,93 @@
+// Package shutdown stops an instance from accepting AWS API calls that change
+// state once it is shutting down, and waits for the calls already in flight,
+// so that no call is cut off halfway through creating its resources.
+package shutdown
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsrequest"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// Gate admits mutating AWS API calls until it is closed and tracks the ones
+// in flight
+type Gate struct {
+	mu       sync.Mutex
+	closed   bool
+	inFlight sync.WaitGroup
+}
+
+// NewGate creates an open gate
+func NewGate() *Gate {
+	return &Gate{}
+}
+
+// Middleware returns an HTTP middleware rejecting mutating AWS API calls with
+// a service unavailable error, which AWS SDKs retry, once the gate is closed.
+// Read-only operations and requests that are not AWS API calls pass through.
+func (g *Gate) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		op, ok := awsrequest.Identify(r)
+		if !ok || !op.Mutating() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !g.enter() {
+			logging.Debug("Rejecting mutating API call during shutdown", "service", op.Service, "operation", op.Name)
+			message := fmt.Sprintf("%s:%s is not allowed, this KECS instance is shutting down", op.Service, op.Name)
+			if op.QueryProtocol {
+				awsrequest.WriteError(w, true, http.StatusServiceUnavailable, "ServiceUnavailable", message)
+			} else {
+				awsrequest.WriteError(w, false, http.StatusServiceUnavailable, "ServiceUnavailableException", message)
+			}
+			return
+		}
+		defer g.inFlight.Done()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// enter admits a mutating call unless the gate is closed
+func (g *Gate) enter() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return false
+	}
+	g.inFlight.Add(1)
+	return true
+}
+
+// Close stops admitting mutating calls and waits for the ones in flight. It
+// returns the error of ctx if it is done first. Closing a closed gate waits
+// again.
+func (g *Gate) Close(ctx context.Context) error {
+	g.mu.Lock()
+	g.closed = true
+	g.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		g.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Closed reports whether the gate is closed
+func (g *Gate) Closed() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.closed
+}
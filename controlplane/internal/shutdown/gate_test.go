@@ -0,0 +1,108 @@
+package shutdown_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/shutdown"
+)
+
+var _ = Describe("Gate", func() {
+	var (
+		gate    *shutdown.Gate
+		handler http.Handler
+		release chan struct{}
+		started chan string
+	)
+
+	BeforeEach(func() {
+		gate = shutdown.NewGate()
+		release = make(chan struct{})
+		started = make(chan string, 4)
+		handler = gate.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			started <- r.Header.Get("X-Amz-Target")
+			<-release
+			w.WriteHeader(http.StatusOK)
+		}))
+	})
+
+	ecsRequest := func(operation string) *http.Request {
+		req := httptest.NewRequest("POST", "/", strings.NewReader("{}"))
+		req.Header.Set("X-Amz-Target", "AmazonEC2ContainerServiceV20141113."+operation)
+		req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+		return req
+	}
+
+	It("waits for mutating calls in flight", func() {
+		served := make(chan int)
+		go func() {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, ecsRequest("CreateService"))
+			served <- rec.Code
+		}()
+		Eventually(started).Should(Receive())
+
+		closed := make(chan error)
+		go func() { closed <- gate.Close(context.Background()) }()
+		Eventually(gate.Closed).Should(BeTrue())
+		Consistently(closed, 50*time.Millisecond).ShouldNot(Receive())
+
+		close(release)
+		Eventually(served).Should(Receive(Equal(http.StatusOK)))
+		Eventually(closed).Should(Receive(BeNil()))
+	})
+
+	It("gives up waiting once the context is done", func() {
+		go handler.ServeHTTP(httptest.NewRecorder(), ecsRequest("RunTask"))
+		Eventually(started).Should(Receive())
+		defer close(release)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		Expect(gate.Close(ctx)).To(MatchError(context.DeadlineExceeded))
+	})
+
+	Context("when closed", func() {
+		BeforeEach(func() {
+			close(release)
+			Expect(gate.Close(context.Background())).To(Succeed())
+		})
+
+		It("rejects mutating ECS calls with ServiceUnavailableException", func() {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, ecsRequest("CreateService"))
+
+			Expect(rec.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(rec.Body.String()).To(ContainSubstring(`"__type":"ServiceUnavailableException"`))
+			Expect(rec.Body.String()).To(ContainSubstring("ecs:CreateService is not allowed"))
+			Expect(started).NotTo(Receive())
+		})
+
+		It("rejects mutating ELBv2 calls with ServiceUnavailable", func() {
+			req := httptest.NewRequest("POST", "/", strings.NewReader("Action=CreateLoadBalancer&Version=2015-12-01"))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			Expect(rec.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(rec.Body.String()).To(ContainSubstring("<Code>ServiceUnavailable</Code>"))
+		})
+
+		It("serves reads and requests that are not AWS API calls", func() {
+			for _, req := range []*http.Request{
+				ecsRequest("DescribeServices"),
+				httptest.NewRequest("GET", "/health", nil),
+			} {
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				Expect(rec.Code).To(Equal(http.StatusOK))
+			}
+		})
+	})
+})
@@ -0,0 +1,314 @@
+// Package sigv4 verifies AWS Signature Version 4 signed requests against a
+// static set of credentials, so that KECS can tell callers apart and reject
+// requests with bad signatures the way AWS does.
+package sigv4
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
+	"github.com/nandemo-ya/kecs/controlplane/internal/iampolicy"
+)
+
+// User is a principal and its access keys
+type User struct {
+	Principal string      `yaml:"principal"`
+	Keys      []AccessKey `yaml:"accessKeys"`
+	// AccountID is the account the resources created by the user belong to,
+	// the account of the instance when empty
+	AccountID string `yaml:"accountId,omitempty"`
+	// Policy is the identity policy of the user, only evaluated when policies are enforced
+	Policy *iampolicy.Policy `yaml:"policy,omitempty"`
+}
+
+// AccessKey is a static access key pair
+type AccessKey struct {
+	AccessKeyID     string `yaml:"accessKeyId"`
+	SecretAccessKey string `yaml:"secretAccessKey"`
+}
+
+// Role is a role users assume with STS AssumeRole to obtain temporary
+// credentials
+type Role struct {
+	Name string `yaml:"name"`
+	// AccountID is the account of the role, the account of the instance when empty
+	AccountID string `yaml:"accountId,omitempty"`
+	// TrustedPrincipals are the users that may assume the role, "*" trusts every user
+	TrustedPrincipals []string `yaml:"trustedPrincipals"`
+	// Policy is the identity policy of the sessions of the role
+	Policy *iampolicy.Policy `yaml:"policy,omitempty"`
+	// MaxSessionDuration is the longest session in seconds, one hour when unset
+	MaxSessionDuration int32 `yaml:"maxSessionDuration,omitempty"`
+}
+
+// Trusts reports whether the principal may assume the role
+func (r Role) Trusts(principal string) bool {
+	return principal != "" && (slices.Contains(r.TrustedPrincipals, "*") || slices.Contains(r.TrustedPrincipals, principal))
+}
+
+// Session duration bounds of STS, in seconds
+const (
+	MinSessionDuration     = 900
+	DefaultSessionDuration = 3600
+	maxSessionDuration     = 43200
+)
+
+// Session is a set of temporary credentials issued by STS AssumeRole
+type Session struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Expiration      time.Time
+	RoleName        string
+	// Name is the session name the caller chose
+	Name      string
+	AccountID string
+	// Policies are the role policy and the session policy, calls have to be
+	// allowed by all of them
+	Policies []*iampolicy.Policy
+}
+
+// Principal returns the principal of the session
+func (s *Session) Principal() string {
+	return iampolicy.AssumedRolePrincipal(s.RoleName, s.Name)
+}
+
+// CredentialsFile is the format of the credentials file:
+//
+//	users:
+//	  - principal: alice
+//	    accountId: "111111111111"
+//	    accessKeys:
+//	      - accessKeyId: AKIAALICE
+//	        secretAccessKey: alice-secret
+//	    policy:
+//	      Statement:
+//	        - Effect: Allow
+//	          Action: ecs:*
+//	          Resource: "*"
+//	roles:
+//	  - name: deployer
+//	    trustedPrincipals: [alice]
+//	    policy:
+//	      Statement:
+//	        - Effect: Allow
+//	          Action: ecs:UpdateService
+//	          Resource: "*"
+type CredentialsFile struct {
+	Users []User `yaml:"users"`
+	Roles []Role `yaml:"roles,omitempty"`
+}
+
+// credential is the secret and principal of one access key
+type credential struct {
+	principal string
+	secret    string
+	accountID string
+	// session is set for temporary credentials
+	session *Session
+}
+
+// Credentials maps access keys to their secrets and principals. Besides the
+// static keys of the users, it holds the temporary keys of STS sessions.
+type Credentials struct {
+	keys     map[string]credential
+	policies map[string]*iampolicy.Policy
+	roles    map[string]Role
+
+	mu       sync.RWMutex
+	sessions map[string]*Session
+}
+
+// NewCredentials validates the users and roles and indexes the access keys
+// of the users
+func NewCredentials(users []User, roles ...Role) (*Credentials, error) {
+	c := &Credentials{
+		keys:     make(map[string]credential),
+		policies: make(map[string]*iampolicy.Policy),
+		roles:    make(map[string]Role),
+		sessions: make(map[string]*Session),
+	}
+	for i, user := range users {
+		if user.Principal == "" {
+			return nil, fmt.Errorf("user %d has no principal", i)
+		}
+		if len(user.Keys) == 0 {
+			return nil, fmt.Errorf("user %s has no access keys", user.Principal)
+		}
+		if user.AccountID != "" {
+			if err := awsarn.ValidateAccountID(user.AccountID); err != nil {
+				return nil, fmt.Errorf("user %s has an invalid account: %w", user.Principal, err)
+			}
+		}
+		if user.Policy != nil {
+			if err := user.Policy.Validate(); err != nil {
+				return nil, fmt.Errorf("user %s has an invalid policy: %w", user.Principal, err)
+			}
+			c.policies[user.Principal] = user.Policy
+		}
+		for _, key := range user.Keys {
+			if key.AccessKeyID == "" || key.SecretAccessKey == "" {
+				return nil, fmt.Errorf("user %s has an access key without id or secret", user.Principal)
+			}
+			if existing, found := c.keys[key.AccessKeyID]; found {
+				return nil, fmt.Errorf("access key %s is assigned to both %s and %s", key.AccessKeyID, existing.principal, user.Principal)
+			}
+			c.keys[key.AccessKeyID] = credential{principal: user.Principal, secret: key.SecretAccessKey, accountID: user.AccountID}
+		}
+	}
+
+	principals := make(map[string]bool, len(users))
+	for _, user := range users {
+		principals[user.Principal] = true
+	}
+	for i, role := range roles {
+		if role.Name == "" {
+			return nil, fmt.Errorf("role %d has no name", i)
+		}
+		if err := validateRole(role, principals); err != nil {
+			return nil, fmt.Errorf("role %s %w", role.Name, err)
+		}
+		if _, found := c.roles[role.Name]; found {
+			return nil, fmt.Errorf("role %s is defined twice", role.Name)
+		}
+		c.roles[role.Name] = role
+	}
+	return c, nil
+}
+
+// validateRole checks a role against the users of the credentials file
+func validateRole(role Role, principals map[string]bool) error {
+	if role.AccountID != "" {
+		if err := awsarn.ValidateAccountID(role.AccountID); err != nil {
+			return fmt.Errorf("has an invalid account: %w", err)
+		}
+	}
+	for _, principal := range role.TrustedPrincipals {
+		if principal != "*" && !principals[principal] {
+			return fmt.Errorf("trusts unknown user %s", principal)
+		}
+	}
+	if role.Policy != nil {
+		if err := role.Policy.Validate(); err != nil {
+			return fmt.Errorf("has an invalid policy: %w", err)
+		}
+	}
+	if d := role.MaxSessionDuration; d != 0 && (d < DefaultSessionDuration || d > maxSessionDuration) {
+		return fmt.Errorf("has a maxSessionDuration outside of %d to %d seconds", DefaultSessionDuration, maxSessionDuration)
+	}
+	return nil
+}
+
+// LoadCredentials reads a credentials file
+func LoadCredentials(path string) (*Credentials, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	var file CredentialsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials file %s: %w", path, err)
+	}
+	if len(file.Users) == 0 {
+		return nil, fmt.Errorf("credentials file %s defines no users", path)
+	}
+	return NewCredentials(file.Users, file.Roles...)
+}
+
+// Principal returns the principal of an access key, or an empty string for unknown keys
+func (c *Credentials) Principal(accessKeyID string) string {
+	if c == nil {
+		return ""
+	}
+	cred, _ := c.lookup(accessKeyID)
+	return cred.principal
+}
+
+// AccountID returns the account of an access key, or an empty string for
+// unknown keys and users without an account
+func (c *Credentials) AccountID(accessKeyID string) string {
+	if c == nil {
+		return ""
+	}
+	cred, _ := c.lookup(accessKeyID)
+	return cred.accountID
+}
+
+// Policies returns the policies of the users that have one, by principal
+func (c *Credentials) Policies() map[string]*iampolicy.Policy {
+	return c.policies
+}
+
+// PoliciesFor returns the policies that have to allow the calls signed with
+// an access key: the policy of the user, or those of the session for
+// temporary credentials. It returns nil when no policy applies.
+func (c *Credentials) PoliciesFor(accessKeyID string) []*iampolicy.Policy {
+	if c == nil {
+		return nil
+	}
+	cred, found := c.lookup(accessKeyID)
+	switch {
+	case !found:
+		return nil
+	case cred.session != nil:
+		return cred.session.Policies
+	case c.policies[cred.principal] != nil:
+		return []*iampolicy.Policy{c.policies[cred.principal]}
+	}
+	return nil
+}
+
+// Role returns the role named name
+func (c *Credentials) Role(name string) (Role, bool) {
+	if c == nil {
+		return Role{}, false
+	}
+	role, found := c.roles[name]
+	return role, found
+}
+
+// AddSession makes the temporary credentials of a session valid until they
+// expire. Sessions that expired are forgotten.
+func (c *Credentials) AddSession(session *Session) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for accessKeyID, existing := range c.sessions {
+		if existing.Expiration.Before(now) {
+			delete(c.sessions, accessKeyID)
+		}
+	}
+	c.sessions[session.AccessKeyID] = session
+}
+
+// Session returns the session of a temporary access key
+func (c *Credentials) Session(accessKeyID string) (*Session, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	session, found := c.sessions[accessKeyID]
+	return session, found
+}
+
+func (c *Credentials) lookup(accessKeyID string) (credential, bool) {
+	if cred, found := c.keys[accessKeyID]; found {
+		return cred, true
+	}
+	if session, found := c.Session(accessKeyID); found {
+		return credential{
+			principal: session.Principal(),
+			secret:    session.SecretAccessKey,
+			accountID: session.AccountID,
+			session:   session,
+		}, true
+	}
+	return credential{}, false
+}
@@ -0,0 +1,13 @@
+package sigv4_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSigV4(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "SigV4 Suite")
+}
@@ -0,0 +1,338 @@
+package sigv4_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
+	"github.com/nandemo-ya/kecs/controlplane/internal/iampolicy"
+	"github.com/nandemo-ya/kecs/controlplane/internal/sigv4"
+)
+
+var signedAt = time.Date(2025, 10, 15, 12, 0, 0, 0, time.UTC)
+
+// newRequest builds an ECS JSON protocol request
+func newRequest(body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "http://localhost:8080/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "AmazonEC2ContainerServiceV20141113.CreateCluster")
+	return req
+}
+
+// newQueryRequest builds an ELBv2 query protocol request
+func newQueryRequest(body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "http://localhost:8080/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	return req
+}
+
+// sign signs a request the way the AWS SDK does
+func sign(req *http.Request, body, accessKeyID, secret, service string, at time.Time) {
+	signWithToken(req, body, aws.Credentials{AccessKeyID: accessKeyID, SecretAccessKey: secret}, service, at)
+}
+
+// signWithToken signs a request with temporary credentials
+func signWithToken(req *http.Request, body string, creds aws.Credentials, service string, at time.Time) {
+	sum := sha256.Sum256([]byte(body))
+	err := v4.NewSigner().SignHTTP(context.Background(), creds, req, hex.EncodeToString(sum[:]), service, "us-east-1", at)
+	Expect(err).NotTo(HaveOccurred())
+}
+
+var _ = Describe("Credentials", func() {
+	It("loads users from a file", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "credentials.yaml")
+		Expect(os.WriteFile(path, []byte(`users:
+  - principal: alice
+    accessKeys:
+      - accessKeyId: AKIAALICE
+        secretAccessKey: alice-secret
+      - accessKeyId: AKIAALICE2
+        secretAccessKey: alice-secret-2
+  - principal: bob
+    accessKeys:
+      - accessKeyId: AKIABOB
+        secretAccessKey: bob-secret
+    policy:
+      Statement:
+        - Effect: Allow
+          Action: ecs:Describe*
+          Resource: "*"
+`), 0600)).To(Succeed())
+
+		creds, err := sigv4.LoadCredentials(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(creds.Principal("AKIAALICE2")).To(Equal("alice"))
+		Expect(creds.Principal("AKIABOB")).To(Equal("bob"))
+		Expect(creds.Principal("AKIAUNKNOWN")).To(BeEmpty())
+		Expect(creds.Policies()).To(HaveKey("bob"))
+		Expect(creds.Policies()).NotTo(HaveKey("alice"))
+		Expect(creds.Policies()["bob"].Evaluate("ecs:DescribeClusters", "*")).To(Equal(iampolicy.Allowed))
+	})
+
+	It("rejects an access key assigned twice", func() {
+		_, err := sigv4.NewCredentials([]sigv4.User{
+			{Principal: "alice", Keys: []sigv4.AccessKey{{AccessKeyID: "AKIA1", SecretAccessKey: "a"}}},
+			{Principal: "bob", Keys: []sigv4.AccessKey{{AccessKeyID: "AKIA1", SecretAccessKey: "b"}}},
+		})
+		Expect(err).To(MatchError(ContainSubstring("assigned to both alice and bob")))
+	})
+
+	It("rejects an invalid account", func() {
+		_, err := sigv4.NewCredentials([]sigv4.User{
+			{Principal: "alice", AccountID: "1234", Keys: []sigv4.AccessKey{{AccessKeyID: "AKIA1", SecretAccessKey: "a"}}},
+		})
+		Expect(err).To(MatchError(ContainSubstring("invalid account")))
+	})
+
+	It("loads roles from a file", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "credentials.yaml")
+		Expect(os.WriteFile(path, []byte(`users:
+  - principal: alice
+    accessKeys:
+      - accessKeyId: AKIAALICE
+        secretAccessKey: alice-secret
+roles:
+  - name: deployer
+    trustedPrincipals: [alice]
+    maxSessionDuration: 7200
+    policy:
+      Statement:
+        - Effect: Allow
+          Action: ecs:UpdateService
+          Resource: "*"
+`), 0600)).To(Succeed())
+
+		creds, err := sigv4.LoadCredentials(path)
+		Expect(err).NotTo(HaveOccurred())
+		role, found := creds.Role("deployer")
+		Expect(found).To(BeTrue())
+		Expect(role.Trusts("alice")).To(BeTrue())
+		Expect(role.Trusts("bob")).To(BeFalse())
+		Expect(role.MaxSessionDuration).To(Equal(int32(7200)))
+		Expect(role.Policy.Evaluate("ecs:UpdateService", "*")).To(Equal(iampolicy.Allowed))
+	})
+
+	It("rejects a role trusting an unknown user", func() {
+		_, err := sigv4.NewCredentials([]sigv4.User{
+			{Principal: "alice", Keys: []sigv4.AccessKey{{AccessKeyID: "AKIA1", SecretAccessKey: "a"}}},
+		}, sigv4.Role{Name: "deployer", TrustedPrincipals: []string{"bob"}})
+		Expect(err).To(MatchError(ContainSubstring("role deployer trusts unknown user bob")))
+	})
+
+	It("resolves the keys of sessions", func() {
+		creds, err := sigv4.NewCredentials(nil)
+		Expect(err).NotTo(HaveOccurred())
+		policy := &iampolicy.Policy{}
+		creds.AddSession(&sigv4.Session{
+			AccessKeyID: "ASIA1", SecretAccessKey: "s", SessionToken: "t",
+			Expiration: time.Now().Add(time.Hour), RoleName: "deployer", Name: "ci",
+			AccountID: "111111111111", Policies: []*iampolicy.Policy{policy},
+		})
+		Expect(creds.Principal("ASIA1")).To(Equal("assumed-role/deployer/ci"))
+		Expect(creds.AccountID("ASIA1")).To(Equal("111111111111"))
+		Expect(creds.PoliciesFor("ASIA1")).To(ConsistOf(policy))
+		Expect(creds.PoliciesFor("ASIA2")).To(BeNil())
+	})
+})
+
+var _ = Describe("Verifier", func() {
+	var (
+		verifier  *sigv4.Verifier
+		creds     *sigv4.Credentials
+		handler   http.Handler
+		reached   bool
+		accountID string
+	)
+
+	newVerifier := func(allowUnsigned bool) {
+		var err error
+		creds, err = sigv4.NewCredentials([]sigv4.User{
+			{Principal: "alice", Keys: []sigv4.AccessKey{{AccessKeyID: "AKIAALICE", SecretAccessKey: "alice-secret"}}},
+			{Principal: "carol", AccountID: "111111111111", Keys: []sigv4.AccessKey{{AccessKeyID: "AKIACAROL", SecretAccessKey: "carol-secret"}}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		verifier = sigv4.NewVerifier(creds, allowUnsigned)
+		verifier.SetClock(func() time.Time { return signedAt.Add(time.Minute) })
+		handler = verifier.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reached = true
+			accountID = awsarn.AccountIDFrom(r.Context(), "000000000000")
+			w.WriteHeader(http.StatusOK)
+		}))
+	}
+
+	BeforeEach(func() {
+		reached = false
+		newVerifier(false)
+	})
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	It("accepts a request signed with a known key", func() {
+		body := `{"clusterName":"default"}`
+		req := newRequest(body)
+		sign(req, body, "AKIAALICE", "alice-secret", "ecs", signedAt)
+
+		principal, verr := verifier.Verify(req, []byte(body))
+		Expect(verr).To(BeNil())
+		Expect(principal).To(Equal("alice"))
+
+		Expect(serve(req).Code).To(Equal(http.StatusOK))
+		Expect(reached).To(BeTrue())
+	})
+
+	It("selects the account of the caller", func() {
+		body := `{"clusterName":"default"}`
+		req := newRequest(body)
+		sign(req, body, "AKIACAROL", "carol-secret", "ecs", signedAt)
+		Expect(serve(req).Code).To(Equal(http.StatusOK))
+		Expect(accountID).To(Equal("111111111111"))
+
+		req = newRequest(body)
+		sign(req, body, "AKIAALICE", "alice-secret", "ecs", signedAt)
+		Expect(serve(req).Code).To(Equal(http.StatusOK))
+		Expect(accountID).To(Equal("000000000000"), "users without an account use the instance account")
+	})
+
+	It("rejects a request signed with the wrong secret", func() {
+		body := `{"clusterName":"default"}`
+		req := newRequest(body)
+		sign(req, body, "AKIAALICE", "wrong-secret", "ecs", signedAt)
+
+		rec := serve(req)
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+		Expect(rec.Body.String()).To(ContainSubstring("InvalidSignatureException"))
+		Expect(reached).To(BeFalse())
+	})
+
+	It("rejects a request whose body was changed after signing", func() {
+		req := newRequest(`{"clusterName":"other"}`)
+		sign(req, `{"clusterName":"default"}`, "AKIAALICE", "alice-secret", "ecs", signedAt)
+
+		Expect(serve(req).Body.String()).To(ContainSubstring("InvalidSignatureException"))
+	})
+
+	It("rejects an unknown access key", func() {
+		body := `{}`
+		req := newRequest(body)
+		sign(req, body, "AKIAMALLORY", "alice-secret", "ecs", signedAt)
+
+		rec := serve(req)
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+		Expect(rec.Body.String()).To(ContainSubstring("UnrecognizedClientException"))
+	})
+
+	It("rejects an expired signature", func() {
+		body := `{}`
+		req := newRequest(body)
+		sign(req, body, "AKIAALICE", "alice-secret", "ecs", signedAt.Add(-time.Hour))
+
+		rec := serve(req)
+		Expect(rec.Body.String()).To(ContainSubstring("Signature expired"))
+	})
+
+	It("rejects unsigned requests unless allowed", func() {
+		rec := serve(newRequest(`{}`))
+		Expect(rec.Body.String()).To(ContainSubstring("MissingAuthenticationTokenException"))
+		Expect(reached).To(BeFalse())
+
+		newVerifier(true)
+		Expect(serve(newRequest(`{}`)).Code).To(Equal(http.StatusOK))
+		Expect(reached).To(BeTrue())
+	})
+
+	It("still checks signed requests when unsigned requests are allowed", func() {
+		newVerifier(true)
+		body := `{}`
+		req := newRequest(body)
+		sign(req, body, "AKIAALICE", "wrong-secret", "ecs", signedAt)
+
+		Expect(serve(req).Body.String()).To(ContainSubstring("InvalidSignatureException"))
+	})
+
+	It("reports query protocol errors as XML", func() {
+		body := "Action=CreateLoadBalancer&Name=web&Version=2015-12-01"
+		req := newQueryRequest(body)
+		sign(req, body, "AKIAALICE", "alice-secret", "elasticloadbalancing", signedAt)
+		Expect(serve(req).Code).To(Equal(http.StatusOK))
+
+		req = newQueryRequest(body)
+		sign(req, body, "AKIAALICE", "wrong-secret", "elasticloadbalancing", signedAt)
+		rec := serve(req)
+		Expect(rec.Code).To(Equal(http.StatusForbidden))
+		Expect(rec.Body.String()).To(ContainSubstring("<Code>SignatureDoesNotMatch</Code>"))
+	})
+
+	Context("with temporary credentials", func() {
+		session := aws.Credentials{AccessKeyID: "ASIASESSION", SecretAccessKey: "session-secret", SessionToken: "session-token"}
+
+		addSession := func(expiration time.Time) {
+			creds.AddSession(&sigv4.Session{
+				AccessKeyID:     session.AccessKeyID,
+				SecretAccessKey: session.SecretAccessKey,
+				SessionToken:    session.SessionToken,
+				Expiration:      expiration,
+				RoleName:        "deployer",
+				Name:            "ci",
+				AccountID:       "111111111111",
+			})
+		}
+
+		It("accepts requests carrying the session token", func() {
+			addSession(signedAt.Add(time.Hour))
+			body := `{}`
+			req := newRequest(body)
+			signWithToken(req, body, session, "ecs", signedAt)
+
+			principal, verr := verifier.Verify(req, []byte(body))
+			Expect(verr).To(BeNil())
+			Expect(principal).To(Equal("assumed-role/deployer/ci"))
+			Expect(serve(req).Code).To(Equal(http.StatusOK))
+			Expect(accountID).To(Equal("111111111111"))
+		})
+
+		It("rejects requests with another session token", func() {
+			addSession(signedAt.Add(time.Hour))
+			body := `{}`
+			req := newRequest(body)
+			forged := session
+			forged.SessionToken = "forged-token"
+			signWithToken(req, body, forged, "ecs", signedAt)
+
+			Expect(serve(req).Body.String()).To(ContainSubstring("UnrecognizedClientException"))
+			Expect(reached).To(BeFalse())
+		})
+
+		It("rejects expired sessions", func() {
+			addSession(signedAt)
+			body := `{}`
+			req := newRequest(body)
+			signWithToken(req, body, session, "ecs", signedAt)
+
+			rec := serve(req)
+			Expect(rec.Code).To(Equal(http.StatusBadRequest))
+			Expect(rec.Body.String()).To(ContainSubstring("ExpiredTokenException"))
+		})
+	})
+
+	It("passes requests that are not AWS API calls", func() {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost:8080/health", nil)
+		Expect(serve(req).Code).To(Equal(http.StatusOK))
+		Expect(reached).To(BeTrue())
+	})
+})
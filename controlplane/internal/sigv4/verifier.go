@@ -0,0 +1,361 @@
+package sigv4
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsrequest"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+const (
+	algorithm       = "AWS4-HMAC-SHA256"
+	amzDateFormat   = "20060102T150405Z"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+
+	// DefaultMaxSkew is how far the request time may differ from the server time, as in AWS
+	DefaultMaxSkew = 15 * time.Minute
+)
+
+// Error is a verification failure. JSON and query protocol services report
+// the same failure with different codes and statuses.
+type Error struct {
+	Status      int
+	Code        string
+	QueryStatus int
+	QueryCode   string
+	Message     string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// write sends the error in the wire format of the calling service
+func (e *Error) write(w http.ResponseWriter, queryProtocol bool) {
+	if queryProtocol {
+		awsrequest.WriteError(w, true, e.QueryStatus, e.QueryCode, e.Message)
+		return
+	}
+	awsrequest.WriteError(w, false, e.Status, e.Code, e.Message)
+}
+
+func missingTokenError() *Error {
+	return &Error{http.StatusBadRequest, "MissingAuthenticationTokenException", http.StatusForbidden, "MissingAuthenticationToken",
+		"Missing Authentication Token"}
+}
+
+func incompleteSignatureError(message string) *Error {
+	return &Error{http.StatusBadRequest, "IncompleteSignatureException", http.StatusBadRequest, "IncompleteSignature", message}
+}
+
+func unknownKeyError() *Error {
+	return &Error{http.StatusBadRequest, "UnrecognizedClientException", http.StatusForbidden, "InvalidClientTokenId",
+		"The security token included in the request is invalid."}
+}
+
+func signatureMismatchError() *Error {
+	return &Error{http.StatusBadRequest, "InvalidSignatureException", http.StatusForbidden, "SignatureDoesNotMatch",
+		"The request signature we calculated does not match the signature you provided. Check your AWS Secret Access Key and signing method. Consult the service documentation for details."}
+}
+
+func expiredTokenError() *Error {
+	return &Error{http.StatusBadRequest, "ExpiredTokenException", http.StatusBadRequest, "ExpiredToken",
+		"The security token included in the request is expired"}
+}
+
+func expiredError(signedAt, now time.Time, maxSkew time.Duration) *Error {
+	message := fmt.Sprintf("Signature expired: %s is now earlier than %s (%s - %s.)",
+		signedAt.Format(amzDateFormat), now.Add(-maxSkew).Format(amzDateFormat), now.Format(amzDateFormat), maxSkew)
+	if signedAt.After(now) {
+		message = fmt.Sprintf("Signature not yet current: %s is still later than %s (%s + %s.)",
+			signedAt.Format(amzDateFormat), now.Add(maxSkew).Format(amzDateFormat), now.Format(amzDateFormat), maxSkew)
+	}
+	return &Error{http.StatusBadRequest, "InvalidSignatureException", http.StatusBadRequest, "RequestExpired", message}
+}
+
+// Verifier checks the SigV4 signature of AWS API requests
+type Verifier struct {
+	credentials *Credentials
+	// allowUnsigned lets requests without Authorization header through, for
+	// tools such as the TUI that call the API without signing
+	allowUnsigned bool
+	maxSkew       time.Duration
+	now           func() time.Time
+}
+
+// NewVerifier creates a verifier for the given credentials
+func NewVerifier(credentials *Credentials, allowUnsigned bool) *Verifier {
+	return &Verifier{
+		credentials:   credentials,
+		allowUnsigned: allowUnsigned,
+		maxSkew:       DefaultMaxSkew,
+		now:           time.Now,
+	}
+}
+
+// SetClock replaces the clock used to check the request time
+func (v *Verifier) SetClock(now func() time.Time) {
+	v.now = now
+}
+
+// Principal returns the principal of an access key, or an empty string for unknown keys
+func (v *Verifier) Principal(accessKeyID string) string {
+	return v.credentials.Principal(accessKeyID)
+}
+
+// Middleware returns an HTTP middleware rejecting AWS API requests that are
+// not signed with a known access key. Requests that are not AWS API calls,
+// such as health checks, pass through unchecked.
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		op, ok := awsrequest.Identify(r)
+		if !ok || (v.allowUnsigned && r.Header.Get("Authorization") == "") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := awsrequest.ReadBody(r)
+		if err != nil {
+			awsrequest.WriteError(w, op.QueryProtocol, http.StatusBadRequest, "SerializationException", "Failed to read request body")
+			return
+		}
+		if _, verr := v.Verify(r, body); verr != nil {
+			logging.Debug("Rejecting request with invalid signature", "operation", op.Name, "code", verr.Code)
+			verr.write(w, op.QueryProtocol)
+			return
+		}
+		// Resources created by the request belong to the account of the caller
+		accessKeyID := awsrequest.ParseCredential(r.Header.Get("Authorization")).AccessKeyID
+		if accountID := v.credentials.AccountID(accessKeyID); accountID != "" {
+			r = r.WithContext(awsarn.WithAccountID(r.Context(), accountID))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorization is a parsed SigV4 Authorization header
+type authorization struct {
+	credential    awsrequest.Credential
+	signedHeaders []string
+	signature     string
+}
+
+// parseAuthorization parses
+// AWS4-HMAC-SHA256 Credential=AKID/20240101/us-east-1/ecs/aws4_request, SignedHeaders=host;x-amz-date, Signature=hex
+func parseAuthorization(header string) (*authorization, *Error) {
+	if header == "" {
+		return nil, missingTokenError()
+	}
+	scheme, params, found := strings.Cut(header, " ")
+	if !found || scheme != algorithm {
+		return nil, incompleteSignatureError(fmt.Sprintf("Unsupported authorization type, only %s is accepted", algorithm))
+	}
+
+	auth := &authorization{credential: awsrequest.ParseCredential(header)}
+	for _, param := range strings.Split(params, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		switch key {
+		case "SignedHeaders":
+			auth.signedHeaders = strings.Split(value, ";")
+		case "Signature":
+			auth.signature = value
+		}
+	}
+
+	switch {
+	case auth.credential.AccessKeyID == "":
+		return nil, incompleteSignatureError("Authorization header requires a valid 'Credential' parameter")
+	case len(auth.signedHeaders) == 0 || auth.signedHeaders[0] == "":
+		return nil, incompleteSignatureError("Authorization header requires 'SignedHeaders' parameter")
+	case auth.signature == "":
+		return nil, incompleteSignatureError("Authorization header requires 'Signature' parameter")
+	}
+	return auth, nil
+}
+
+// Verify checks the signature of a request and returns the principal that signed it
+func (v *Verifier) Verify(r *http.Request, body []byte) (string, *Error) {
+	auth, verr := parseAuthorization(r.Header.Get("Authorization"))
+	if verr != nil {
+		return "", verr
+	}
+	cred, found := v.credentials.lookup(auth.credential.AccessKeyID)
+	if !found {
+		return "", unknownKeyError()
+	}
+
+	signedAt, verr := requestTime(r)
+	if verr != nil {
+		return "", verr
+	}
+	if signedAt.Format("20060102") != auth.credential.Date {
+		return "", signatureMismatchError()
+	}
+	now := v.now()
+	// Temporary credentials are only valid with their session token, until they expire
+	if cred.session != nil {
+		if r.Header.Get("X-Amz-Security-Token") != cred.session.SessionToken {
+			return "", unknownKeyError()
+		}
+		if !now.Before(cred.session.Expiration) {
+			return "", expiredTokenError()
+		}
+	}
+	if skew := now.Sub(signedAt); skew > v.maxSkew || skew < -v.maxSkew {
+		return "", expiredError(signedAt, now, v.maxSkew)
+	}
+
+	scope := strings.Join([]string{auth.credential.Date, auth.credential.Region, auth.credential.Service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		algorithm,
+		signedAt.Format(amzDateFormat),
+		scope,
+		hashHex([]byte(canonicalRequest(r, auth.signedHeaders, body))),
+	}, "\n")
+
+	key := signingKey(cred.secret, auth.credential.Date, auth.credential.Region, auth.credential.Service)
+	expected := hex.EncodeToString(hmacSHA256(key, []byte(stringToSign)))
+	if !hmac.Equal([]byte(expected), []byte(auth.signature)) {
+		return "", signatureMismatchError()
+	}
+	return cred.principal, nil
+}
+
+// requestTime returns the signing time from the X-Amz-Date or Date header
+func requestTime(r *http.Request) (time.Time, *Error) {
+	if value := r.Header.Get("X-Amz-Date"); value != "" {
+		t, err := time.Parse(amzDateFormat, value)
+		if err != nil {
+			return time.Time{}, incompleteSignatureError(fmt.Sprintf("Invalid X-Amz-Date header: %s", value))
+		}
+		return t, nil
+	}
+	if value := r.Header.Get("Date"); value != "" {
+		t, err := http.ParseTime(value)
+		if err != nil {
+			return time.Time{}, incompleteSignatureError(fmt.Sprintf("Invalid Date header: %s", value))
+		}
+		return t.UTC(), nil
+	}
+	return time.Time{}, incompleteSignatureError("Authorization header requires existence of either a 'X-Amz-Date' or a 'Date' header")
+}
+
+// canonicalRequest builds the SigV4 canonical request
+func canonicalRequest(r *http.Request, signedHeaders []string, body []byte) string {
+	payloadHash := hashHex(body)
+	if r.Header.Get("X-Amz-Content-Sha256") == unsignedPayload {
+		payloadHash = unsignedPayload
+	}
+
+	var headers strings.Builder
+	for _, name := range signedHeaders {
+		headers.WriteString(name)
+		headers.WriteByte(':')
+		headers.WriteString(headerValue(r, name))
+		headers.WriteByte('\n')
+	}
+
+	return strings.Join([]string{
+		r.Method,
+		canonicalURI(r.URL),
+		canonicalQuery(r.URL),
+		headers.String(),
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+}
+
+// canonicalURI encodes the already escaped path a second time, as AWS SDKs do
+// for all services but S3
+func canonicalURI(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	return uriEncode(path, false)
+}
+
+// canonicalQuery sorts and encodes the query parameters
+func canonicalQuery(u *url.URL) string {
+	query := u.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// headerValue returns the canonical value of a signed header
+func headerValue(r *http.Request, name string) string {
+	switch name {
+	case "host":
+		return r.Host
+	case "content-length":
+		if values := r.Header.Values("Content-Length"); len(values) == 0 && r.ContentLength >= 0 {
+			return strconv.FormatInt(r.ContentLength, 10)
+		}
+	}
+
+	values := r.Header.Values(name)
+	trimmed := make([]string, len(values))
+	for i, value := range values {
+		trimmed[i] = strings.Join(strings.Fields(value), " ")
+	}
+	return strings.Join(trimmed, ",")
+}
+
+// uriEncode percent-encodes everything but the unreserved characters
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func signingKey(secret, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), []byte(date))
+	key = hmacSHA256(key, []byte(region))
+	key = hmacSHA256(key, []byte(service))
+	return hmacSHA256(key, []byte("aws4_request"))
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
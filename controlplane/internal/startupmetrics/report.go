@@ -0,0 +1,157 @@
+package startupmetrics
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/types"
+)
+
+// Stats summarizes durations in milliseconds
+type Stats struct {
+	Count int   `json:"count"`
+	P50Ms int64 `json:"p50Ms"`
+	P95Ms int64 `json:"p95Ms"`
+	MaxMs int64 `json:"maxMs"`
+}
+
+// Group is the startup and pull time of the tasks of a service or an image
+type Group struct {
+	Name    string `json:"name"`
+	Startup Stats  `json:"startup"`
+	Pull    Stats  `json:"pull"`
+}
+
+// Report summarizes the startup time of tasks, from creation until all
+// containers run, and the time spent pulling images
+type Report struct {
+	// Tasks is the number of tasks that finished starting
+	Tasks    int     `json:"tasks"`
+	Services []Group `json:"services"`
+	Images   []Group `json:"images"`
+}
+
+// samples collects the durations of one group
+type samples struct {
+	startup []time.Duration
+	pull    []time.Duration
+}
+
+// BuildReport summarizes the tasks that have started. Standalone tasks only
+// count towards their images.
+func BuildReport(tasks []*storage.Task) Report {
+	services := make(map[string]*samples)
+	images := make(map[string]*samples)
+	add := func(groups map[string]*samples, name string, startup time.Duration, pull *time.Duration) {
+		group, found := groups[name]
+		if !found {
+			group = &samples{}
+			groups[name] = group
+		}
+		group.startup = append(group.startup, startup)
+		if pull != nil {
+			group.pull = append(group.pull, *pull)
+		}
+	}
+
+	report := Report{}
+	for _, task := range tasks {
+		if task.StartedAt == nil || task.CreatedAt.IsZero() || task.StartedAt.Before(task.CreatedAt) {
+			continue
+		}
+		report.Tasks++
+		startup := task.StartedAt.Sub(task.CreatedAt)
+		var pull *time.Duration
+		if task.PullStartedAt != nil && task.PullStoppedAt != nil && !task.PullStoppedAt.Before(*task.PullStartedAt) {
+			d := task.PullStoppedAt.Sub(*task.PullStartedAt)
+			pull = &d
+		}
+
+		if service := ServiceName(task); service != "" {
+			add(services, service, startup, pull)
+		}
+		for _, image := range taskImages(task) {
+			add(images, image, startup, pull)
+		}
+	}
+
+	report.Services = summarize(services)
+	report.Images = summarize(images)
+	return report
+}
+
+// ServiceName returns the service that started a task, or an empty string for standalone tasks
+func ServiceName(task *storage.Task) string {
+	if name, found := strings.CutPrefix(task.Group, "service:"); found {
+		return name
+	}
+	if name, found := strings.CutPrefix(task.StartedBy, "ecs-svc/"); found {
+		return name
+	}
+	return ""
+}
+
+// taskImages returns the distinct images of the containers of a task
+func taskImages(task *storage.Task) []string {
+	if task.Containers == "" {
+		return nil
+	}
+	var containers []types.Container
+	if err := json.Unmarshal([]byte(task.Containers), &containers); err != nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var images []string
+	for _, container := range containers {
+		if container.Image != "" && !seen[container.Image] {
+			seen[container.Image] = true
+			images = append(images, container.Image)
+		}
+	}
+	return images
+}
+
+// summarize computes the stats of every group, slowest P95 first
+func summarize(groups map[string]*samples) []Group {
+	result := make([]Group, 0, len(groups))
+	for name, group := range groups {
+		result = append(result, Group{
+			Name:    name,
+			Startup: computeStats(group.startup),
+			Pull:    computeStats(group.pull),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Startup.P95Ms != result[j].Startup.P95Ms {
+			return result[i].Startup.P95Ms > result[j].Startup.P95Ms
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+func computeStats(durations []time.Duration) Stats {
+	if len(durations) == 0 {
+		return Stats{}
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return Stats{
+		Count: len(sorted),
+		P50Ms: percentile(sorted, 50).Milliseconds(),
+		P95Ms: percentile(sorted, 95).Milliseconds(),
+		MaxMs: sorted[len(sorted)-1].Milliseconds(),
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted durations
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
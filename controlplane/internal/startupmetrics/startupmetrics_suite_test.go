@@ -0,0 +1,13 @@
+package startupmetrics_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestStartupMetrics(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "StartupMetrics Suite")
+}
@@ -0,0 +1,138 @@
+package startupmetrics_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/startupmetrics"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+var _ = Describe("PodTimeline", func() {
+	base := time.Date(2025, 10, 15, 6, 0, 0, 0, time.UTC)
+	at := func(seconds int) metav1.Time { return metav1.NewTime(base.Add(time.Duration(seconds) * time.Second)) }
+
+	scheduledPod := func() *corev1.Pod {
+		return &corev1.Pod{
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}, {Name: "sidecar"}}},
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{
+					{Type: corev1.PodScheduled, Status: corev1.ConditionTrue, LastTransitionTime: at(1)},
+				},
+			},
+		}
+	}
+
+	It("starts the pull when the pod is scheduled", func() {
+		timeline := startupmetrics.PodTimeline(scheduledPod())
+		Expect(*timeline.PullStartedAt).To(Equal(base.Add(time.Second)))
+		Expect(timeline.PullStoppedAt).To(BeNil())
+		Expect(timeline.StartedAt).To(BeNil())
+	})
+
+	It("starts the pull once init containers completed", func() {
+		pod := scheduledPod()
+		pod.Spec.InitContainers = []corev1.Container{{Name: "migrate"}}
+		pod.Status.Conditions = append(pod.Status.Conditions,
+			corev1.PodCondition{Type: corev1.PodInitialized, Status: corev1.ConditionTrue, LastTransitionTime: at(5)})
+
+		timeline := startupmetrics.PodTimeline(pod)
+		Expect(*timeline.PullStartedAt).To(Equal(base.Add(5 * time.Second)))
+	})
+
+	It("is started only once every container started", func() {
+		pod := scheduledPod()
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+			{Name: "app", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: at(4)}}},
+			{Name: "sidecar", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}}},
+		}
+		timeline := startupmetrics.PodTimeline(pod)
+		Expect(*timeline.PullStoppedAt).To(Equal(base.Add(4 * time.Second)))
+		Expect(timeline.StartedAt).To(BeNil())
+
+		pod.Status.ContainerStatuses[1].State = corev1.ContainerState{
+			Terminated: &corev1.ContainerStateTerminated{StartedAt: at(7), ExitCode: 0},
+		}
+		timeline = startupmetrics.PodTimeline(pod)
+		Expect(*timeline.PullStoppedAt).To(Equal(base.Add(4 * time.Second)))
+		Expect(*timeline.StartedAt).To(Equal(base.Add(7 * time.Second)))
+	})
+
+	It("uses the kubelet pull events when they are available", func() {
+		timeline := startupmetrics.PodTimeline(scheduledPod())
+		timeline.ApplyEvents([]corev1.Event{
+			{Reason: "Scheduled", FirstTimestamp: at(0)},
+			{Reason: "Pulling", FirstTimestamp: at(3)},
+			{Reason: "Pulling", FirstTimestamp: at(2)},
+			{Reason: "Pulled", FirstTimestamp: at(6)},
+			{Reason: "Pulled", EventTime: metav1.NewMicroTime(base.Add(8 * time.Second))},
+		})
+		Expect(*timeline.PullStartedAt).To(Equal(base.Add(2 * time.Second)))
+		Expect(*timeline.PullStoppedAt).To(Equal(base.Add(8 * time.Second)))
+	})
+})
+
+var _ = Describe("BuildReport", func() {
+	base := time.Date(2025, 10, 15, 6, 0, 0, 0, time.UTC)
+	task := func(group, images string, startupSeconds, pullSeconds int) *storage.Task {
+		started := base.Add(time.Duration(startupSeconds) * time.Second)
+		pullStarted := base.Add(time.Second)
+		pullStopped := pullStarted.Add(time.Duration(pullSeconds) * time.Second)
+		return &storage.Task{
+			Group:         group,
+			Containers:    images,
+			CreatedAt:     base,
+			StartedAt:     &started,
+			PullStartedAt: &pullStarted,
+			PullStoppedAt: &pullStopped,
+		}
+	}
+	const nginx = `[{"name":"web","image":"nginx:latest"}]`
+	const api = `[{"name":"api","image":"api:v1"},{"name":"proxy","image":"nginx:latest"}]`
+
+	It("summarizes startup and pull time per service and per image", func() {
+		var tasks []*storage.Task
+		for i := 1; i <= 10; i++ {
+			tasks = append(tasks, task("service:web", nginx, i, i-1))
+		}
+		tasks = append(tasks, task("service:api", api, 30, 20))
+		tasks = append(tasks, task("family:batch", nginx, 2, 1))
+
+		report := startupmetrics.BuildReport(tasks)
+		Expect(report.Tasks).To(Equal(12))
+
+		Expect(report.Services).To(HaveLen(2))
+		Expect(report.Services[0].Name).To(Equal("api"))
+		web := report.Services[1]
+		Expect(web.Name).To(Equal("web"))
+		Expect(web.Startup).To(Equal(startupmetrics.Stats{Count: 10, P50Ms: 5000, P95Ms: 10000, MaxMs: 10000}))
+		Expect(web.Pull).To(Equal(startupmetrics.Stats{Count: 10, P50Ms: 4000, P95Ms: 9000, MaxMs: 9000}))
+
+		Expect(report.Images).To(HaveLen(2))
+		// Both have the api task as P95, ties are sorted by name
+		Expect(report.Images[0].Name).To(Equal("api:v1"))
+		Expect(report.Images[0].Startup.Count).To(Equal(1))
+		Expect(report.Images[1].Name).To(Equal("nginx:latest"))
+		Expect(report.Images[1].Startup.Count).To(Equal(12))
+		Expect(report.Images[1].Startup.P50Ms).To(Equal(int64(5000)))
+	})
+
+	It("skips tasks that have not started", func() {
+		pending := task("service:web", nginx, 1, 1)
+		pending.StartedAt = nil
+
+		report := startupmetrics.BuildReport([]*storage.Task{pending})
+		Expect(report.Tasks).To(BeZero())
+		Expect(report.Services).To(BeEmpty())
+	})
+
+	It("attributes tasks started by a service deployment", func() {
+		t := task("", nginx, 1, 1)
+		t.StartedBy = "ecs-svc/worker"
+		Expect(startupmetrics.ServiceName(t)).To(Equal("worker"))
+	})
+})
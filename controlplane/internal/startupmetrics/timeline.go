@@ -0,0 +1,109 @@
+// Package startupmetrics derives the startup timeline of tasks from their pods
+// and summarizes cold-start latency per service and per image.
+package startupmetrics
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Kubelet event reasons bracketing image pulls
+const (
+	reasonPulling = "Pulling"
+	reasonPulled  = "Pulled"
+)
+
+// Timeline is when a task started and finished pulling its images and when
+// all its containers were running
+type Timeline struct {
+	PullStartedAt *time.Time
+	PullStoppedAt *time.Time
+	StartedAt     *time.Time
+}
+
+// PodTimeline derives a timeline from the pod status. Kubelet starts pulling
+// once the pod is scheduled, or once init containers completed, and the first
+// container starts right after its image is available. Timestamps that are
+// not known yet are nil.
+func PodTimeline(pod *corev1.Pod) Timeline {
+	var timeline Timeline
+
+	conditionType := corev1.PodScheduled
+	if len(pod.Spec.InitContainers) > 0 {
+		conditionType = corev1.PodInitialized
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == conditionType && condition.Status == corev1.ConditionTrue {
+			t := condition.LastTransitionTime.Time
+			timeline.PullStartedAt = &t
+		}
+	}
+
+	var first, last time.Time
+	allStarted := len(pod.Status.ContainerStatuses) > 0
+	for _, status := range pod.Status.ContainerStatuses {
+		var startedAt time.Time
+		switch {
+		case status.State.Running != nil:
+			startedAt = status.State.Running.StartedAt.Time
+		case status.State.Terminated != nil:
+			startedAt = status.State.Terminated.StartedAt.Time
+		}
+		if startedAt.IsZero() {
+			allStarted = false
+			continue
+		}
+		if first.IsZero() || startedAt.Before(first) {
+			first = startedAt
+		}
+		if startedAt.After(last) {
+			last = startedAt
+		}
+	}
+	if !first.IsZero() {
+		timeline.PullStoppedAt = &first
+	}
+	if allStarted {
+		timeline.StartedAt = &last
+	}
+	return timeline
+}
+
+// ApplyEvents refines the pull timestamps with the Pulling and Pulled events
+// kubelet records for the pod, which bracket the pulls exactly
+func (t *Timeline) ApplyEvents(events []corev1.Event) {
+	var pullStarted, pullStopped time.Time
+	for _, event := range events {
+		at := eventTime(event)
+		if at.IsZero() {
+			continue
+		}
+		switch event.Reason {
+		case reasonPulling:
+			if pullStarted.IsZero() || at.Before(pullStarted) {
+				pullStarted = at
+			}
+		case reasonPulled:
+			if at.After(pullStopped) {
+				pullStopped = at
+			}
+		}
+	}
+	if !pullStarted.IsZero() {
+		t.PullStartedAt = &pullStarted
+	}
+	if !pullStopped.IsZero() {
+		t.PullStoppedAt = &pullStopped
+	}
+}
+
+func eventTime(event corev1.Event) time.Time {
+	switch {
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	case !event.FirstTimestamp.IsZero():
+		return event.FirstTimestamp.Time
+	}
+	return event.LastTimestamp.Time
+}
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -276,6 +277,36 @@ func (s *cachedTaskDefinitionStore) Deregister(ctx context.Context, family strin
 	return nil
 }
 
+func (s *cachedTaskDefinitionStore) Purge(ctx context.Context, family string, revision int) error {
+	if err := s.backend.Purge(ctx, family, revision); err != nil {
+		return err
+	}
+
+	// Remove from cache, including the entry by ARN
+	key := taskDefKey(family, revision)
+	if cached, found := s.cache.Get(ctx, key); found {
+		s.cache.Delete(ctx, taskDefKeyByArn(cached.(*storage.TaskDefinition).ARN))
+	}
+	s.cache.Delete(ctx, key)
+
+	// Invalidate family cache
+	s.cache.Delete(ctx, taskDefFamilyKey(family))
+
+	return nil
+}
+
+func (s *cachedTaskDefinitionStore) Update(ctx context.Context, taskDef *storage.TaskDefinition) error {
+	if err := s.backend.Update(ctx, taskDef); err != nil {
+		return err
+	}
+
+	// Remove from cache so the next read returns the stored status and tags
+	s.cache.Delete(ctx, taskDefKey(taskDef.Family, taskDef.Revision))
+	s.cache.Delete(ctx, taskDefKeyByArn(taskDef.ARN))
+
+	return nil
+}
+
 func (s *cachedTaskDefinitionStore) Get(ctx context.Context, family string, revision int) (*storage.TaskDefinition, error) {
 	key := taskDefKey(family, revision)
 
@@ -328,6 +359,11 @@ func (s *cachedTaskDefinitionStore) ListFamilies(ctx context.Context, familyPref
 	return s.backend.ListFamilies(ctx, familyPrefix, status, limit, nextToken)
 }
 
+func (s *cachedTaskDefinitionStore) List(ctx context.Context, filters storage.TaskDefinitionFilters, limit int, nextToken string) ([]*storage.TaskDefinitionRevision, string, error) {
+	// Don't cache list operations
+	return s.backend.List(ctx, filters, limit, nextToken)
+}
+
 func (s *cachedTaskDefinitionStore) ListRevisions(ctx context.Context, family string, status string, limit int, nextToken string) ([]*storage.TaskDefinitionRevision, string, error) {
 	// Don't cache list operations as they change frequently
 	return s.backend.ListRevisions(ctx, family, status, limit, nextToken)
@@ -344,8 +380,9 @@ func (s *cachedServiceStore) Create(ctx context.Context, service *storage.Servic
 		return err
 	}
 
-	// Cache the service
-	s.cache.Set(ctx, serviceKey(service.ClusterARN, service.ServiceName), service)
+	// Cache a copy of the service, so that callers changing theirs do not
+	// change the cached one
+	s.cache.Set(ctx, serviceKey(service.ClusterARN, service.ServiceName), service.Copy())
 
 	// Invalidate list cache
 	s.cache.Delete(ctx, fmt.Sprintf("services:list:%s", service.ClusterARN))
@@ -358,7 +395,9 @@ func (s *cachedServiceStore) Get(ctx context.Context, cluster, serviceName strin
 
 	// Check cache
 	if cached, found := s.cache.Get(ctx, key); found {
-		return cached.(*storage.Service), nil
+		// Every caller gets its own copy, as callers modify the service and
+		// Update relies on the version of the copy they read
+		return cached.(*storage.Service).Copy(), nil
 	}
 
 	// Fetch from backend
@@ -368,23 +407,27 @@ func (s *cachedServiceStore) Get(ctx context.Context, cluster, serviceName strin
 	}
 
 	// Cache the result
-	s.cache.Set(ctx, key, service)
+	s.cache.Set(ctx, key, service.Copy())
 
 	return service, nil
 }
 
-func (s *cachedServiceStore) List(ctx context.Context, cluster string, serviceName string, launchType string, limit int, nextToken string) ([]*storage.Service, string, error) {
+func (s *cachedServiceStore) List(ctx context.Context, cluster string, filters storage.ServiceFilters, limit int, nextToken string) ([]*storage.Service, string, error) {
 	// Don't cache list operations as they change frequently and have complex filtering
-	return s.backend.List(ctx, cluster, serviceName, launchType, limit, nextToken)
+	return s.backend.List(ctx, cluster, filters, limit, nextToken)
 }
 
 func (s *cachedServiceStore) Update(ctx context.Context, service *storage.Service) error {
 	if err := s.backend.Update(ctx, service); err != nil {
+		if errors.Is(err, storage.ErrConflict) {
+			// The cached service is stale, read the current one next time
+			s.cache.Delete(ctx, serviceKey(service.ClusterARN, service.ServiceName))
+		}
 		return err
 	}
 
 	// Update cache
-	s.cache.Set(ctx, serviceKey(service.ClusterARN, service.ServiceName), service)
+	s.cache.Set(ctx, serviceKey(service.ClusterARN, service.ServiceName), service.Copy())
 
 	// Invalidate list cache
 	s.cache.Delete(ctx, fmt.Sprintf("services:list:%s", service.ClusterARN))
@@ -412,6 +455,11 @@ func (s *cachedServiceStore) GetByARN(ctx context.Context, arn string) (*storage
 	return s.backend.GetByARN(ctx, arn)
 }
 
+func (s *cachedServiceStore) GetMany(ctx context.Context, cluster string, serviceNamesOrARNs []string) ([]*storage.Service, error) {
+	// For batch operations, fetch from backend
+	return s.backend.GetMany(ctx, cluster, serviceNamesOrARNs)
+}
+
 // cachedTaskStore implements storage.TaskStore with caching
 type cachedTaskStore struct {
 	backend storage.TaskStore
@@ -457,6 +505,11 @@ func (s *cachedTaskStore) GetByARNs(ctx context.Context, arns []string) ([]*stor
 	return s.backend.GetByARNs(ctx, arns)
 }
 
+func (s *cachedTaskStore) GetMany(ctx context.Context, cluster string, taskIDsOrARNs []string) ([]*storage.Task, error) {
+	// For batch operations, fetch from backend
+	return s.backend.GetMany(ctx, cluster, taskIDsOrARNs)
+}
+
 func (s *cachedTaskStore) List(ctx context.Context, cluster string, filters storage.TaskFilters) ([]*storage.Task, error) {
 	// Don't cache list operations as they change frequently and have complex filtering
 	return s.backend.List(ctx, cluster, filters)
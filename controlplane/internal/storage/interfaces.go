@@ -10,6 +10,7 @@ import (
 var (
 	ErrResourceNotFound      = errors.New("resource not found")
 	ErrResourceAlreadyExists = errors.New("resource already exists")
+	ErrConflict              = errors.New("resource was modified concurrently")
 )
 
 // Storage defines the interface for all storage operations
@@ -128,6 +129,15 @@ type Cluster struct {
 	// LocalStack deployment state
 	LocalStackState string `json:"localStackState,omitempty"`
 
+	// Service Connect defaults as JSON
+	ServiceConnectDefaults string `json:"serviceConnectDefaults,omitempty"`
+
+	// Attachments (capacity provider scaling policies, Service Connect) as JSON
+	Attachments string `json:"attachments,omitempty"`
+
+	// Status of the last attachments update (UPDATE_IN_PROGRESS, UPDATE_COMPLETE)
+	AttachmentsStatus string `json:"attachmentsStatus,omitempty"`
+
 	// Timestamps
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
@@ -144,17 +154,28 @@ type TaskDefinitionStore interface {
 	// Get the latest revision of a task definition family
 	GetLatest(ctx context.Context, family string) (*TaskDefinition, error)
 
-	// List task definition families with pagination
+	// List task definition families with pagination. An ACTIVE status lists the
+	// families with an active revision, INACTIVE those without one.
 	ListFamilies(ctx context.Context, familyPrefix string, status string, limit int, nextToken string) ([]*TaskDefinitionFamily, string, error)
 
+	// List task definition revisions across families, ordered by family and
+	// revision
+	List(ctx context.Context, filters TaskDefinitionFilters, limit int, nextToken string) ([]*TaskDefinitionRevision, string, error)
+
 	// List revisions of a specific task definition family
 	ListRevisions(ctx context.Context, family string, status string, limit int, nextToken string) ([]*TaskDefinitionRevision, string, error)
 
 	// Deregister a task definition revision
 	Deregister(ctx context.Context, family string, revision int) error
 
+	// Purge permanently deletes an INACTIVE task definition revision
+	Purge(ctx context.Context, family string, revision int) error
+
 	// Get task definition by ARN
 	GetByARN(ctx context.Context, arn string) (*TaskDefinition, error)
+
+	// Update the status and tags of a task definition revision
+	Update(ctx context.Context, taskDef *TaskDefinition) error
 }
 
 // TaskDefinition represents a task definition with its full configuration
@@ -216,6 +237,9 @@ type TaskDefinition struct {
 	// Runtime platform as JSON
 	RuntimePlatform string `json:"runtimePlatform,omitempty"`
 
+	// Ephemeral storage as JSON
+	EphemeralStorage string `json:"ephemeralStorage,omitempty"`
+
 	// Status (ACTIVE, INACTIVE)
 	Status string `json:"status"`
 
@@ -237,6 +261,18 @@ type TaskDefinitionFamily struct {
 	ActiveRevisions int    `json:"activeRevisions"`
 }
 
+// TaskDefinitionFilters selects the revisions listed by TaskDefinitionStore.List
+type TaskDefinitionFilters struct {
+	// Filter by family name
+	Family string
+
+	// Filter by status
+	Status string
+
+	// List families and revisions in descending order
+	Descending bool
+}
+
 // TaskDefinitionRevision represents a task definition revision summary
 type TaskDefinitionRevision struct {
 	ARN          string    `json:"arn"`
@@ -244,6 +280,8 @@ type TaskDefinitionRevision struct {
 	Revision     int       `json:"revision"`
 	Status       string    `json:"status"`
 	RegisteredAt time.Time `json:"registeredAt"`
+	// DeregisteredAt is only set by ListRevisions
+	DeregisteredAt *time.Time `json:"deregisteredAt,omitempty"`
 }
 
 // ServiceStore defines service-specific storage operations
@@ -254,10 +292,11 @@ type ServiceStore interface {
 	// Get a service by cluster and service name
 	Get(ctx context.Context, cluster, serviceName string) (*Service, error)
 
-	// List services with filtering
-	List(ctx context.Context, cluster string, serviceName string, launchType string, limit int, nextToken string) ([]*Service, string, error)
+	// List the services of a cluster matching filters, newest first
+	List(ctx context.Context, cluster string, filters ServiceFilters, limit int, nextToken string) ([]*Service, string, error)
 
-	// Update a service
+	// Update a service if its version is the stored one, incrementing the
+	// version. A service modified meanwhile fails with ErrConflict.
 	Update(ctx context.Context, service *Service) error
 
 	// Delete a service
@@ -266,10 +305,30 @@ type ServiceStore interface {
 	// Get service by ARN
 	GetByARN(ctx context.Context, arn string) (*Service, error)
 
+	// Get the services of a cluster by names or ARNs in a single query,
+	// omitting those not found
+	GetMany(ctx context.Context, cluster string, serviceNamesOrARNs []string) ([]*Service, error)
+
 	// DeleteMarkedForDeletion deletes services marked for deletion before the specified time
 	DeleteMarkedForDeletion(ctx context.Context, clusterARN string, before time.Time) (int, error)
 }
 
+// ServiceFilters selects the services listed by ServiceStore.List
+type ServiceFilters struct {
+	// Filter by service name
+	ServiceName string
+
+	// Filter by launch type
+	LaunchType string
+
+	// Filter by status
+	Status string
+
+	// Filter by tags, all of which a service must have. An empty value
+	// matches any value of the key.
+	Tags map[string]string
+}
+
 // Service represents an ECS service in storage
 type Service struct {
 	// Unique identifier
@@ -320,6 +379,9 @@ type Service struct {
 	// Deployment configuration as JSON
 	DeploymentConfiguration string `json:"deploymentConfiguration,omitempty"`
 
+	// Deployments as JSON, the primary deployment first
+	Deployments string `json:"deployments,omitempty"`
+
 	// Deployment controller as JSON (type: ECS|CODE_DEPLOY|EXTERNAL)
 	DeploymentController string `json:"deploymentController,omitempty"`
 
@@ -341,6 +403,9 @@ type Service struct {
 	// Service connect configuration as JSON
 	ServiceConnectConfiguration string `json:"serviceConnectConfiguration,omitempty"`
 
+	// Volume configurations of the volumes configured at launch as JSON
+	VolumeConfigurations string `json:"volumeConfigurations,omitempty"`
+
 	// Enable ECS managed tags
 	EnableECSManagedTags bool `json:"enableECSManagedTags"`
 
@@ -371,6 +436,9 @@ type Service struct {
 	// Service registry metadata for service discovery
 	ServiceRegistryMetadata map[string]string `json:"serviceRegistryMetadata,omitempty"`
 
+	// Version of the record for optimistic locking, incremented by every update
+	Version int64 `json:"version"`
+
 	// Timestamps
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
@@ -396,6 +464,10 @@ type TaskStore interface {
 	// Get tasks by ARNs
 	GetByARNs(ctx context.Context, arns []string) ([]*Task, error)
 
+	// Get tasks by full ARNs, in any cluster, or by IDs within a cluster in a
+	// single query, omitting those not found
+	GetMany(ctx context.Context, cluster string, taskIDsOrARNs []string) ([]*Task, error)
+
 	// CreateOrUpdate creates a new task or updates if it already exists
 	CreateOrUpdate(ctx context.Context, task *Task) error
 
@@ -932,6 +1004,7 @@ type ELBv2LoadBalancer struct {
 	AvailabilityZones     []string          `json:"availabilityZones"`
 	SecurityGroups        []string          `json:"securityGroups"`
 	IpAddressType         string            `json:"ipAddressType"`
+	Attributes            map[string]string `json:"attributes"`
 	Tags                  map[string]string `json:"tags"`
 	Region                string            `json:"region"`
 	AccountID             string            `json:"accountId"`
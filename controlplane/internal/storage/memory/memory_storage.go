@@ -134,7 +134,12 @@ func (s *memoryClusterStore) List(ctx context.Context) ([]*storage.Cluster, erro
 // ListWithPagination lists clusters with pagination
 func (s *memoryClusterStore) ListWithPagination(ctx context.Context, limit int, nextToken string) ([]*storage.Cluster, string, error) {
 	clusters, err := s.List(ctx)
-	return clusters, "", err
+	if err != nil {
+		return nil, "", err
+	}
+	return storage.Paginate(clusters, func(c *storage.Cluster) storage.PageCursor {
+		return storage.NewestFirstCursor(c.CreatedAt, c.ARN)
+	}, storage.NewestFirst, limit, nextToken)
 }
 
 // Update updates a cluster
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrInvalidNextToken is returned when a pagination token was not issued by a
+// List operation
+var ErrInvalidNextToken = errors.New("invalid pagination token")
+
+// PageCursor is the position of the last item of a page. Lists are sorted on
+// keys that never tie, so a page that resumes after the cursor neither skips
+// nor repeats items, even when items are created or deleted in between.
+//
+// Clusters, services, tasks and ELBv2 resources are listed newest first and
+// positioned by CreatedAt and ARN. Task definition families and revisions are
+// positioned by Family and Revision.
+type PageCursor struct {
+	CreatedAt time.Time `json:"c"`
+	ARN       string    `json:"a,omitempty"`
+	Family    string    `json:"f,omitempty"`
+	Revision  int       `json:"r,omitempty"`
+}
+
+// EncodeNextToken encodes a cursor as an opaque nextToken
+func EncodeNextToken(cursor PageCursor) string {
+	cursor.CreatedAt = cursor.CreatedAt.UTC()
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeNextToken decodes a nextToken issued by EncodeNextToken
+func DecodeNextToken(token string) (PageCursor, error) {
+	var cursor PageCursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, ErrInvalidNextToken
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, ErrInvalidNextToken
+	}
+	return cursor, nil
+}
+
+// NewestFirstCursor returns the cursor of an item listed newest first
+func NewestFirstCursor(createdAt time.Time, arn string) PageCursor {
+	return PageCursor{CreatedAt: createdAt, ARN: arn}
+}
+
+// CursorOrder reports whether the item at cursor a is listed before the item
+// at cursor b
+type CursorOrder func(a, b PageCursor) bool
+
+// NewestFirst orders by creation time, newest first, then by ARN, as
+// ORDER BY created_at DESC, arn DESC
+func NewestFirst(a, b PageCursor) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return a.ARN > b.ARN
+}
+
+// ByFamilyRevision orders by family, then by revision, as
+// ORDER BY family, revision
+func ByFamilyRevision(a, b PageCursor) bool {
+	if a.Family != b.Family {
+		return a.Family < b.Family
+	}
+	return a.Revision < b.Revision
+}
+
+// ByFamilyRevisionDesc orders by family, then by revision, both descending,
+// as ORDER BY family DESC, revision DESC
+func ByFamilyRevisionDesc(a, b PageCursor) bool {
+	return ByFamilyRevision(b, a)
+}
+
+// LatestRevisionFirst orders the revisions of a family latest first, as
+// ORDER BY revision DESC
+func LatestRevisionFirst(a, b PageCursor) bool {
+	return a.Revision > b.Revision
+}
+
+// Paginate sorts items in order and returns the page of at most limit items
+// after nextToken, with the token of the following page. A limit of 0 returns
+// all items after nextToken. It gives the stores that keep their items in
+// memory the same ordering and tokens as the database stores.
+func Paginate[T any](items []T, cursorOf func(T) PageCursor, order CursorOrder, limit int, nextToken string) ([]T, string, error) {
+	sorted := make([]T, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return order(cursorOf(sorted[i]), cursorOf(sorted[j]))
+	})
+
+	if nextToken != "" {
+		after, err := DecodeNextToken(nextToken)
+		if err != nil {
+			return nil, "", err
+		}
+		start := sort.Search(len(sorted), func(i int) bool {
+			return order(after, cursorOf(sorted[i]))
+		})
+		sorted = sorted[start:]
+	}
+
+	if limit <= 0 || len(sorted) <= limit {
+		return sorted, "", nil
+	}
+	page := sorted[:limit]
+	return page, EncodeNextToken(cursorOf(page[len(page)-1])), nil
+}
@@ -0,0 +1,137 @@
+package storage_test
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+var _ = Describe("Pagination", func() {
+	clusterCursor := func(c *storage.Cluster) storage.PageCursor {
+		return storage.NewestFirstCursor(c.CreatedAt, c.ARN)
+	}
+
+	// collect pages through all items, page by page
+	collect := func(clusters []*storage.Cluster, limit int) []string {
+		var arns []string
+		token := ""
+		for {
+			page, next, err := storage.Paginate(clusters, clusterCursor, storage.NewestFirst, limit, token)
+			Expect(err).NotTo(HaveOccurred())
+			for _, c := range page {
+				arns = append(arns, c.ARN)
+			}
+			if next == "" {
+				return arns
+			}
+			token = next
+		}
+	}
+
+	Describe("next tokens", func() {
+		It("round trips a cursor", func() {
+			cursor := storage.PageCursor{
+				CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC),
+				ARN:       "arn:aws:ecs:us-east-1:000000000000:cluster/a",
+			}
+			decoded, err := storage.DecodeNextToken(storage.EncodeNextToken(cursor))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decoded.CreatedAt.Equal(cursor.CreatedAt)).To(BeTrue())
+			Expect(decoded.ARN).To(Equal(cursor.ARN))
+		})
+
+		It("rejects tokens it did not issue", func() {
+			for _, token := range []string{"42", "not a token", "bm90LWpzb24"} {
+				_, err := storage.DecodeNextToken(token)
+				Expect(err).To(MatchError(storage.ErrInvalidNextToken), token)
+			}
+		})
+	})
+
+	Describe("Paginate", func() {
+		var clusters []*storage.Cluster
+
+		BeforeEach(func() {
+			// Clusters created in the same instant must still page stably
+			now := time.Now()
+			clusters = nil
+			for i := 0; i < 25; i++ {
+				clusters = append(clusters, &storage.Cluster{
+					ARN:       fmt.Sprintf("arn:aws:ecs:us-east-1:000000000000:cluster/c-%02d", i),
+					CreatedAt: now.Add(-time.Duration(i/5) * time.Minute),
+				})
+			}
+		})
+
+		It("lists newest first with the ARN breaking ties", func() {
+			page, next, err := storage.Paginate(clusters, clusterCursor, storage.NewestFirst, 3, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(next).NotTo(BeEmpty())
+			Expect(page[0].ARN).To(HaveSuffix("c-04"))
+			Expect(page[1].ARN).To(HaveSuffix("c-03"))
+			Expect(page[2].ARN).To(HaveSuffix("c-02"))
+		})
+
+		It("returns every item exactly once across pages", func() {
+			for _, limit := range []int{1, 4, 5, 7, 25, 100} {
+				arns := collect(clusters, limit)
+				Expect(arns).To(HaveLen(25), "limit %d", limit)
+				Expect(arns).To(ConsistOf(collect(clusters, 0)), "limit %d", limit)
+			}
+		})
+
+		It("neither skips nor repeats items when items change between pages", func() {
+			first, next, err := storage.Paginate(clusters, clusterCursor, storage.NewestFirst, 10, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			// A new cluster sorts before the first page, a listed one is deleted
+			changed := append([]*storage.Cluster{{
+				ARN:       "arn:aws:ecs:us-east-1:000000000000:cluster/new",
+				CreatedAt: time.Now().Add(time.Hour),
+			}}, clusters[1:]...)
+			rest, _, err := storage.Paginate(changed, clusterCursor, storage.NewestFirst, 0, next)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(len(first) + len(rest)).To(Equal(25))
+			seen := map[string]bool{}
+			for _, c := range append(first, rest...) {
+				Expect(seen[c.ARN]).To(BeFalse(), c.ARN)
+				seen[c.ARN] = true
+			}
+		})
+
+		It("orders revisions numerically", func() {
+			var revisions []*storage.TaskDefinitionRevision
+			for _, family := range []string{"web", "api"} {
+				for rev := 12; rev >= 1; rev-- {
+					revisions = append(revisions, &storage.TaskDefinitionRevision{Family: family, Revision: rev})
+				}
+			}
+			cursorOf := func(r *storage.TaskDefinitionRevision) storage.PageCursor {
+				return storage.PageCursor{Family: r.Family, Revision: r.Revision}
+			}
+
+			page, next, err := storage.Paginate(revisions, cursorOf, storage.ByFamilyRevision, 10, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(page[0]).To(Equal(&storage.TaskDefinitionRevision{Family: "api", Revision: 1}))
+			Expect(page[9]).To(Equal(&storage.TaskDefinitionRevision{Family: "api", Revision: 10}))
+
+			page, _, err = storage.Paginate(revisions, cursorOf, storage.ByFamilyRevision, 3, next)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(page).To(Equal([]*storage.TaskDefinitionRevision{
+				{Family: "api", Revision: 11},
+				{Family: "api", Revision: 12},
+				{Family: "web", Revision: 1},
+			}))
+		})
+
+		It("fails on an invalid token", func() {
+			_, _, err := storage.Paginate(clusters, clusterCursor, storage.NewestFirst, 10, "bogus")
+			Expect(err).To(MatchError(storage.ErrInvalidNextToken))
+		})
+	})
+})
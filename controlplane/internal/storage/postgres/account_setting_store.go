@@ -4,10 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"time"
 
-	"github.com/google/uuid"
 	"github.com/lib/pq"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
 )
 
@@ -18,10 +17,10 @@ type accountSettingStore struct {
 // Upsert creates or updates an account setting
 func (s *accountSettingStore) Upsert(ctx context.Context, setting *storage.AccountSetting) error {
 	if setting.ID == "" {
-		setting.ID = uuid.New().String()
+		setting.ID = deterministic.NewUUID()
 	}
 
-	now := time.Now()
+	now := deterministic.Now()
 	if setting.CreatedAt.IsZero() {
 		setting.CreatedAt = now
 	}
@@ -229,13 +228,13 @@ func (s *accountSettingStore) Delete(ctx context.Context, principalARN, name str
 // SetDefault sets a default account setting
 func (s *accountSettingStore) SetDefault(ctx context.Context, name, value string) error {
 	setting := &storage.AccountSetting{
-		ID:           uuid.New().String(),
+		ID:           deterministic.NewUUID(),
 		Name:         name,
 		Value:        value,
 		PrincipalARN: "default",
 		IsDefault:    true,
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+		CreatedAt:    deterministic.Now(),
+		UpdatedAt:    deterministic.Now(),
 	}
 
 	query := `
@@ -4,10 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"time"
 
-	"github.com/google/uuid"
 	"github.com/lib/pq"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
 )
 
@@ -44,10 +43,10 @@ func (s *attributeStore) Put(ctx context.Context, attributes []*storage.Attribut
 	}
 	defer stmt.Close()
 
-	now := time.Now()
+	now := deterministic.Now()
 	for _, attr := range attributes {
 		if attr.ID == "" {
-			attr.ID = uuid.New().String()
+			attr.ID = deterministic.NewUUID()
 		}
 		if attr.CreatedAt.IsZero() {
 			attr.CreatedAt = now
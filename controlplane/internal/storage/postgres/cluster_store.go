@@ -5,10 +5,9 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"time"
 
-	"github.com/google/uuid"
 	"github.com/lib/pq"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
 )
 
@@ -20,11 +19,11 @@ type clusterStore struct {
 func (s *clusterStore) Create(ctx context.Context, cluster *storage.Cluster) error {
 	// Generate ID if not provided
 	if cluster.ID == "" {
-		cluster.ID = uuid.New().String()
+		cluster.ID = deterministic.NewUUID()
 	}
 
 	// Set timestamps
-	now := time.Now()
+	now := deterministic.Now()
 	cluster.CreatedAt = now
 	cluster.UpdatedAt = now
 
@@ -35,9 +34,9 @@ func (s *clusterStore) Create(ctx context.Context, cluster *storage.Cluster) err
 			registered_container_instances_count, running_tasks_count,
 			pending_tasks_count, active_services_count,
 			capacity_providers, default_capacity_provider_strategy,
-			localstack_state,
+			localstack_state, service_connect_defaults, attachments, attachments_status,
 			created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)`
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)`
 
 	_, err := s.db.ExecContext(ctx, query,
 		cluster.ID,
@@ -57,6 +56,9 @@ func (s *clusterStore) Create(ctx context.Context, cluster *storage.Cluster) err
 		toNullString(cluster.CapacityProviders),
 		toNullString(cluster.DefaultCapacityProviderStrategy),
 		toNullString(cluster.LocalStackState),
+		toNullString(cluster.ServiceConnectDefaults),
+		toNullString(cluster.Attachments),
+		toNullString(cluster.AttachmentsStatus),
 		cluster.CreatedAt,
 		cluster.UpdatedAt,
 	)
@@ -80,14 +82,15 @@ func (s *clusterStore) Get(ctx context.Context, identifier string) (*storage.Clu
 			registered_container_instances_count, running_tasks_count,
 			pending_tasks_count, active_services_count,
 			capacity_providers, default_capacity_provider_strategy,
-			localstack_state,
+			localstack_state, service_connect_defaults, attachments, attachments_status,
 			created_at, updated_at
 		FROM clusters
 		WHERE arn = $1 OR name = $2`
 
 	var cluster storage.Cluster
 	var configuration, settings, tags, k8sClusterName sql.NullString
-	var capacityProviders, defaultCapacityProviderStrategy, localStackState sql.NullString
+	var capacityProviders, defaultCapacityProviderStrategy, localStackState, serviceConnectDefaults sql.NullString
+	var attachments, attachmentsStatus sql.NullString
 
 	err := s.db.QueryRowContext(ctx, query, identifier, identifier).Scan(
 		&cluster.ID,
@@ -107,6 +110,9 @@ func (s *clusterStore) Get(ctx context.Context, identifier string) (*storage.Clu
 		&capacityProviders,
 		&defaultCapacityProviderStrategy,
 		&localStackState,
+		&serviceConnectDefaults,
+		&attachments,
+		&attachmentsStatus,
 		&cluster.CreatedAt,
 		&cluster.UpdatedAt,
 	)
@@ -125,6 +131,9 @@ func (s *clusterStore) Get(ctx context.Context, identifier string) (*storage.Clu
 	cluster.CapacityProviders = fromNullString(capacityProviders)
 	cluster.DefaultCapacityProviderStrategy = fromNullString(defaultCapacityProviderStrategy)
 	cluster.LocalStackState = fromNullString(localStackState)
+	cluster.ServiceConnectDefaults = fromNullString(serviceConnectDefaults)
+	cluster.Attachments = fromNullString(attachments)
+	cluster.AttachmentsStatus = fromNullString(attachmentsStatus)
 
 	return &cluster, nil
 }
@@ -138,10 +147,10 @@ func (s *clusterStore) List(ctx context.Context) ([]*storage.Cluster, error) {
 			registered_container_instances_count, running_tasks_count,
 			pending_tasks_count, active_services_count,
 			capacity_providers, default_capacity_provider_strategy,
-			localstack_state,
+			localstack_state, service_connect_defaults, attachments, attachments_status,
 			created_at, updated_at
 		FROM clusters
-		ORDER BY created_at DESC`
+		ORDER BY created_at DESC, arn DESC`
 
 	rows, err := s.db.QueryContext(ctx, query)
 	if err != nil {
@@ -153,7 +162,8 @@ func (s *clusterStore) List(ctx context.Context) ([]*storage.Cluster, error) {
 	for rows.Next() {
 		var cluster storage.Cluster
 		var configuration, settings, tags, k8sClusterName sql.NullString
-		var capacityProviders, defaultCapacityProviderStrategy, localStackState sql.NullString
+		var capacityProviders, defaultCapacityProviderStrategy, localStackState, serviceConnectDefaults sql.NullString
+		var attachments, attachmentsStatus sql.NullString
 
 		err := rows.Scan(
 			&cluster.ID,
@@ -173,6 +183,9 @@ func (s *clusterStore) List(ctx context.Context) ([]*storage.Cluster, error) {
 			&capacityProviders,
 			&defaultCapacityProviderStrategy,
 			&localStackState,
+			&serviceConnectDefaults,
+			&attachments,
+			&attachmentsStatus,
 			&cluster.CreatedAt,
 			&cluster.UpdatedAt,
 		)
@@ -187,6 +200,9 @@ func (s *clusterStore) List(ctx context.Context) ([]*storage.Cluster, error) {
 		cluster.CapacityProviders = fromNullString(capacityProviders)
 		cluster.DefaultCapacityProviderStrategy = fromNullString(defaultCapacityProviderStrategy)
 		cluster.LocalStackState = fromNullString(localStackState)
+		cluster.ServiceConnectDefaults = fromNullString(serviceConnectDefaults)
+		cluster.Attachments = fromNullString(attachments)
+		cluster.AttachmentsStatus = fromNullString(attachmentsStatus)
 
 		clusters = append(clusters, &cluster)
 	}
@@ -196,14 +212,6 @@ func (s *clusterStore) List(ctx context.Context) ([]*storage.Cluster, error) {
 
 // ListWithPagination retrieves clusters with pagination
 func (s *clusterStore) ListWithPagination(ctx context.Context, maxResults int, nextToken string) ([]*storage.Cluster, string, error) {
-	// Parse the next token to get the offset
-	offset := 0
-	if nextToken != "" {
-		if _, err := fmt.Sscanf(nextToken, "%d", &offset); err != nil {
-			return nil, "", fmt.Errorf("invalid next token: %w", err)
-		}
-	}
-
 	query := `
 		SELECT
 			id, arn, name, status, region, account_id,
@@ -211,13 +219,16 @@ func (s *clusterStore) ListWithPagination(ctx context.Context, maxResults int, n
 			registered_container_instances_count, running_tasks_count,
 			pending_tasks_count, active_services_count,
 			capacity_providers, default_capacity_provider_strategy,
-			localstack_state,
+			localstack_state, service_connect_defaults, attachments, attachments_status,
 			created_at, updated_at
-		FROM clusters
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2`
+		FROM clusters`
 
-	rows, err := s.db.QueryContext(ctx, query, maxResults, offset)
+	query, args, err := appendNewestFirstPage(query, nil, false, nextToken, pageFetch(maxResults))
+	if err != nil {
+		return nil, "", err
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to list clusters with pagination: %w", err)
 	}
@@ -227,7 +238,8 @@ func (s *clusterStore) ListWithPagination(ctx context.Context, maxResults int, n
 	for rows.Next() {
 		var cluster storage.Cluster
 		var configuration, settings, tags, k8sClusterName sql.NullString
-		var capacityProviders, defaultCapacityProviderStrategy, localStackState sql.NullString
+		var capacityProviders, defaultCapacityProviderStrategy, localStackState, serviceConnectDefaults sql.NullString
+		var attachments, attachmentsStatus sql.NullString
 
 		err := rows.Scan(
 			&cluster.ID,
@@ -247,6 +259,9 @@ func (s *clusterStore) ListWithPagination(ctx context.Context, maxResults int, n
 			&capacityProviders,
 			&defaultCapacityProviderStrategy,
 			&localStackState,
+			&serviceConnectDefaults,
+			&attachments,
+			&attachmentsStatus,
 			&cluster.CreatedAt,
 			&cluster.UpdatedAt,
 		)
@@ -261,22 +276,22 @@ func (s *clusterStore) ListWithPagination(ctx context.Context, maxResults int, n
 		cluster.CapacityProviders = fromNullString(capacityProviders)
 		cluster.DefaultCapacityProviderStrategy = fromNullString(defaultCapacityProviderStrategy)
 		cluster.LocalStackState = fromNullString(localStackState)
+		cluster.ServiceConnectDefaults = fromNullString(serviceConnectDefaults)
+		cluster.Attachments = fromNullString(attachments)
+		cluster.AttachmentsStatus = fromNullString(attachmentsStatus)
 
 		clusters = append(clusters, &cluster)
 	}
 
-	// Generate next token if there are more results
-	newNextToken := ""
-	if len(clusters) == int(maxResults) {
-		newNextToken = fmt.Sprintf("%d", offset+int(maxResults))
-	}
-
+	clusters, newNextToken := nextPageToken(clusters, maxResults, func(c *storage.Cluster) storage.PageCursor {
+		return storage.NewestFirstCursor(c.CreatedAt, c.ARN)
+	})
 	return clusters, newNextToken, nil
 }
 
 // Update updates an existing cluster
 func (s *clusterStore) Update(ctx context.Context, cluster *storage.Cluster) error {
-	cluster.UpdatedAt = time.Now()
+	cluster.UpdatedAt = deterministic.Now()
 
 	query := `
 		UPDATE clusters SET
@@ -290,8 +305,11 @@ func (s *clusterStore) Update(ctx context.Context, cluster *storage.Cluster) err
 			capacity_providers = $12,
 			default_capacity_provider_strategy = $13,
 			localstack_state = $14,
-			updated_at = $15
-		WHERE arn = $16`
+			service_connect_defaults = $15,
+			attachments = $16,
+			attachments_status = $17,
+			updated_at = $18
+		WHERE arn = $19`
 
 	result, err := s.db.ExecContext(ctx, query,
 		cluster.Status,
@@ -308,6 +326,9 @@ func (s *clusterStore) Update(ctx context.Context, cluster *storage.Cluster) err
 		toNullString(cluster.CapacityProviders),
 		toNullString(cluster.DefaultCapacityProviderStrategy),
 		toNullString(cluster.LocalStackState),
+		toNullString(cluster.ServiceConnectDefaults),
+		toNullString(cluster.Attachments),
+		toNullString(cluster.AttachmentsStatus),
 		cluster.UpdatedAt,
 		cluster.ARN,
 	)
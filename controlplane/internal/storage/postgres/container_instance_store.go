@@ -7,8 +7,8 @@ import (
 	"strings"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/lib/pq"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
 )
 
@@ -19,10 +19,10 @@ type containerInstanceStore struct {
 // Register registers a new container instance
 func (s *containerInstanceStore) Register(ctx context.Context, instance *storage.ContainerInstance) error {
 	if instance.ID == "" {
-		instance.ID = uuid.New().String()
+		instance.ID = deterministic.NewUUID()
 	}
 
-	now := time.Now()
+	now := deterministic.Now()
 	if instance.RegisteredAt.IsZero() {
 		instance.RegisteredAt = now
 	}
@@ -219,7 +219,7 @@ func (s *containerInstanceStore) ListWithPagination(ctx context.Context, cluster
 
 // Update updates a container instance
 func (s *containerInstanceStore) Update(ctx context.Context, instance *storage.ContainerInstance) error {
-	instance.UpdatedAt = time.Now()
+	instance.UpdatedAt = deterministic.Now()
 
 	query := `
 	UPDATE container_instances SET
@@ -261,7 +261,7 @@ func (s *containerInstanceStore) Update(ctx context.Context, instance *storage.C
 
 // Deregister deregisters a container instance
 func (s *containerInstanceStore) Deregister(ctx context.Context, arn string) error {
-	now := time.Now()
+	now := deterministic.Now()
 
 	query := `
 	UPDATE container_instances SET
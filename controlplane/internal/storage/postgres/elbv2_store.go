@@ -5,9 +5,9 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"time"
 
 	"github.com/lib/pq"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
 )
 
@@ -20,25 +20,26 @@ type elbv2Store struct {
 // CreateLoadBalancer creates a new load balancer
 func (s *elbv2Store) CreateLoadBalancer(ctx context.Context, lb *storage.ELBv2LoadBalancer) error {
 	if lb.CreatedAt.IsZero() {
-		lb.CreatedAt = time.Now()
+		lb.CreatedAt = deterministic.Now()
 	}
-	lb.UpdatedAt = time.Now()
+	lb.UpdatedAt = deterministic.Now()
 
 	// Convert arrays and maps to JSON
 	subnetsJSON, _ := json.Marshal(lb.Subnets)
 	azsJSON, _ := json.Marshal(lb.AvailabilityZones)
 	sgJSON, _ := json.Marshal(lb.SecurityGroups)
 	tagsJSON, _ := json.Marshal(lb.Tags)
+	attributesJSON, _ := json.Marshal(lb.Attributes)
 
 	query := `
 	INSERT INTO elbv2_load_balancers (
 		arn, name, dns_name, canonical_hosted_zone_id,
 		state, type, scheme, vpc_id, subnets,
 		availability_zones, security_groups, ip_address_type,
-		tags, region, account_id, created_at, updated_at
+		tags, region, account_id, created_at, updated_at, attributes
 	) VALUES (
 		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
-		$11, $12, $13, $14, $15, $16, $17
+		$11, $12, $13, $14, $15, $16, $17, $18
 	)`
 
 	_, err := s.db.ExecContext(ctx, query,
@@ -47,6 +48,7 @@ func (s *elbv2Store) CreateLoadBalancer(ctx context.Context, lb *storage.ELBv2Lo
 		string(subnetsJSON), string(azsJSON), string(sgJSON),
 		lb.IpAddressType, string(tagsJSON),
 		lb.Region, lb.AccountID, lb.CreatedAt, lb.UpdatedAt,
+		string(attributesJSON),
 	)
 
 	if err != nil {
@@ -65,19 +67,20 @@ func (s *elbv2Store) GetLoadBalancer(ctx context.Context, arn string) (*storage.
 	SELECT arn, name, dns_name, canonical_hosted_zone_id,
 		state, type, scheme, vpc_id, subnets,
 		availability_zones, security_groups, ip_address_type,
-		tags, region, account_id, created_at, updated_at
+		tags, region, account_id, created_at, updated_at, attributes
 	FROM elbv2_load_balancers
 	WHERE arn = $1`
 
 	var lb storage.ELBv2LoadBalancer
 	var subnetsJSON, azsJSON, sgJSON, tagsJSON string
+	var attributesJSON sql.NullString
 
 	err := s.db.QueryRowContext(ctx, query, arn).Scan(
 		&lb.ARN, &lb.Name, &lb.DNSName, &lb.CanonicalHostedZoneID,
 		&lb.State, &lb.Type, &lb.Scheme, &lb.VpcID,
 		&subnetsJSON, &azsJSON, &sgJSON, &lb.IpAddressType,
 		&tagsJSON, &lb.Region, &lb.AccountID,
-		&lb.CreatedAt, &lb.UpdatedAt,
+		&lb.CreatedAt, &lb.UpdatedAt, &attributesJSON,
 	)
 
 	if err != nil {
@@ -100,6 +103,11 @@ func (s *elbv2Store) GetLoadBalancer(ctx context.Context, arn string) (*storage.
 	if err := json.Unmarshal([]byte(tagsJSON), &lb.Tags); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal tags: %w", err)
 	}
+	if attributesJSON.Valid && attributesJSON.String != "" {
+		if err := json.Unmarshal([]byte(attributesJSON.String), &lb.Attributes); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal attributes: %w", err)
+		}
+	}
 
 	return &lb, nil
 }
@@ -110,19 +118,20 @@ func (s *elbv2Store) GetLoadBalancerByName(ctx context.Context, name string) (*s
 	SELECT arn, name, dns_name, canonical_hosted_zone_id,
 		state, type, scheme, vpc_id, subnets,
 		availability_zones, security_groups, ip_address_type,
-		tags, region, account_id, created_at, updated_at
+		tags, region, account_id, created_at, updated_at, attributes
 	FROM elbv2_load_balancers
 	WHERE name = $1`
 
 	var lb storage.ELBv2LoadBalancer
 	var subnetsJSON, azsJSON, sgJSON, tagsJSON string
+	var attributesJSON sql.NullString
 
 	err := s.db.QueryRowContext(ctx, query, name).Scan(
 		&lb.ARN, &lb.Name, &lb.DNSName, &lb.CanonicalHostedZoneID,
 		&lb.State, &lb.Type, &lb.Scheme, &lb.VpcID,
 		&subnetsJSON, &azsJSON, &sgJSON, &lb.IpAddressType,
 		&tagsJSON, &lb.Region, &lb.AccountID,
-		&lb.CreatedAt, &lb.UpdatedAt,
+		&lb.CreatedAt, &lb.UpdatedAt, &attributesJSON,
 	)
 
 	if err != nil {
@@ -145,6 +154,11 @@ func (s *elbv2Store) GetLoadBalancerByName(ctx context.Context, name string) (*s
 	if err := json.Unmarshal([]byte(tagsJSON), &lb.Tags); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal tags: %w", err)
 	}
+	if attributesJSON.Valid && attributesJSON.String != "" {
+		if err := json.Unmarshal([]byte(attributesJSON.String), &lb.Attributes); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal attributes: %w", err)
+		}
+	}
 
 	return &lb, nil
 }
@@ -155,7 +169,7 @@ func (s *elbv2Store) ListLoadBalancers(ctx context.Context, region string) ([]*s
 	SELECT arn, name, dns_name, canonical_hosted_zone_id,
 		state, type, scheme, vpc_id, subnets,
 		availability_zones, security_groups, ip_address_type,
-		tags, region, account_id, created_at, updated_at
+		tags, region, account_id, created_at, updated_at, attributes
 	FROM elbv2_load_balancers
 	WHERE region = $1
 	ORDER BY created_at DESC`
@@ -170,13 +184,14 @@ func (s *elbv2Store) ListLoadBalancers(ctx context.Context, region string) ([]*s
 	for rows.Next() {
 		var lb storage.ELBv2LoadBalancer
 		var subnetsJSON, azsJSON, sgJSON, tagsJSON string
+		var attributesJSON sql.NullString
 
 		err := rows.Scan(
 			&lb.ARN, &lb.Name, &lb.DNSName, &lb.CanonicalHostedZoneID,
 			&lb.State, &lb.Type, &lb.Scheme, &lb.VpcID,
 			&subnetsJSON, &azsJSON, &sgJSON, &lb.IpAddressType,
 			&tagsJSON, &lb.Region, &lb.AccountID,
-			&lb.CreatedAt, &lb.UpdatedAt,
+			&lb.CreatedAt, &lb.UpdatedAt, &attributesJSON,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan load balancer: %w", err)
@@ -195,6 +210,11 @@ func (s *elbv2Store) ListLoadBalancers(ctx context.Context, region string) ([]*s
 		if err := json.Unmarshal([]byte(tagsJSON), &lb.Tags); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal tags: %w", err)
 		}
+		if attributesJSON.Valid && attributesJSON.String != "" {
+			if err := json.Unmarshal([]byte(attributesJSON.String), &lb.Attributes); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal attributes: %w", err)
+			}
+		}
 
 		lbs = append(lbs, &lb)
 	}
@@ -204,23 +224,26 @@ func (s *elbv2Store) ListLoadBalancers(ctx context.Context, region string) ([]*s
 
 // UpdateLoadBalancer updates a load balancer
 func (s *elbv2Store) UpdateLoadBalancer(ctx context.Context, lb *storage.ELBv2LoadBalancer) error {
-	lb.UpdatedAt = time.Now()
+	lb.UpdatedAt = deterministic.Now()
 
 	// Convert arrays and maps to JSON
 	subnetsJSON, _ := json.Marshal(lb.Subnets)
 	azsJSON, _ := json.Marshal(lb.AvailabilityZones)
 	sgJSON, _ := json.Marshal(lb.SecurityGroups)
 	tagsJSON, _ := json.Marshal(lb.Tags)
+	attributesJSON, _ := json.Marshal(lb.Attributes)
 
 	query := `
 	UPDATE elbv2_load_balancers SET
 		state = $1, subnets = $2, availability_zones = $3,
-		security_groups = $4, tags = $5, updated_at = $6
-	WHERE arn = $7`
+		security_groups = $4, tags = $5, updated_at = $6,
+		attributes = $7
+	WHERE arn = $8`
 
 	result, err := s.db.ExecContext(ctx, query,
 		lb.State, string(subnetsJSON), string(azsJSON),
-		string(sgJSON), string(tagsJSON), lb.UpdatedAt, lb.ARN,
+		string(sgJSON), string(tagsJSON), lb.UpdatedAt,
+		string(attributesJSON), lb.ARN,
 	)
 
 	if err != nil {
@@ -265,9 +288,9 @@ func (s *elbv2Store) DeleteLoadBalancer(ctx context.Context, arn string) error {
 // CreateTargetGroup creates a new target group
 func (s *elbv2Store) CreateTargetGroup(ctx context.Context, tg *storage.ELBv2TargetGroup) error {
 	if tg.CreatedAt.IsZero() {
-		tg.CreatedAt = time.Now()
+		tg.CreatedAt = deterministic.Now()
 	}
-	tg.UpdatedAt = time.Now()
+	tg.UpdatedAt = deterministic.Now()
 
 	// Convert arrays and maps to JSON
 	lbArnsJSON, _ := json.Marshal(tg.LoadBalancerArns)
@@ -433,7 +456,7 @@ func (s *elbv2Store) ListTargetGroups(ctx context.Context, region string) ([]*st
 
 // UpdateTargetGroup updates a target group
 func (s *elbv2Store) UpdateTargetGroup(ctx context.Context, tg *storage.ELBv2TargetGroup) error {
-	tg.UpdatedAt = time.Now()
+	tg.UpdatedAt = deterministic.Now()
 
 	// Convert arrays and maps to JSON
 	lbArnsJSON, _ := json.Marshal(tg.LoadBalancerArns)
@@ -2,7 +2,12 @@ package postgres
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
 )
 
 // Helper functions for handling SQL NULL values
@@ -35,3 +40,70 @@ func fromNullTime(nt sql.NullTime) *time.Time {
 	}
 	return &nt.Time
 }
+
+// tagContainment returns a JSON document of tags that the tags column of a
+// resource contains, with jsonb @>, when it has all of them. Tags without a
+// value match any value.
+func tagContainment(tags map[string]string) string {
+	type tag struct {
+		Key   string `json:"key"`
+		Value string `json:"value,omitempty"`
+	}
+	contained := make([]tag, 0, len(tags))
+	for key, value := range tags {
+		contained = append(contained, tag{Key: key, Value: value})
+	}
+	data, _ := json.Marshal(contained)
+	return string(data)
+}
+
+// likePrefix returns a LIKE pattern matching the values that start with
+// prefix, which is matched literally
+func likePrefix(prefix string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix) + "%"
+}
+
+// Helper functions for keyset pagination
+
+// appendNewestFirstPage appends to query the condition, ordering and limit of
+// a page listed newest first after nextToken. where tells whether query
+// already has a WHERE clause. A fetch of 0 returns all remaining rows.
+func appendNewestFirstPage(query string, args []interface{}, where bool, nextToken string, fetch int) (string, []interface{}, error) {
+	if nextToken != "" {
+		cursor, err := storage.DecodeNextToken(nextToken)
+		if err != nil {
+			return "", nil, err
+		}
+		keyword := " WHERE"
+		if where {
+			keyword = " AND"
+		}
+		query += fmt.Sprintf("%s (created_at, arn) < ($%d, $%d)", keyword, len(args)+1, len(args)+2)
+		args = append(args, cursor.CreatedAt, cursor.ARN)
+	}
+	query += " ORDER BY created_at DESC, arn DESC"
+	if fetch > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", len(args)+1)
+		args = append(args, fetch)
+	}
+	return query, args, nil
+}
+
+// pageFetch returns the number of rows to fetch for a page of limit items.
+// One extra row tells whether another page follows.
+func pageFetch(limit int) int {
+	if limit <= 0 {
+		return 0
+	}
+	return limit + 1
+}
+
+// nextPageToken drops the extra row fetched by pageFetch and returns the token
+// of the next page, empty on the last page
+func nextPageToken[T any](items []T, limit int, cursorOf func(T) storage.PageCursor) ([]T, string) {
+	if limit <= 0 || len(items) <= limit {
+		return items, ""
+	}
+	items = items[:limit]
+	return items, storage.EncodeNextToken(cursorOf(items[limit-1]))
+}
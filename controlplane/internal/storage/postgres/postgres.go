@@ -225,6 +225,17 @@ func (s *PostgresStorage) createClustersTable(ctx context.Context) error {
 		return fmt.Errorf("failed to create clusters table: %w", err)
 	}
 
+	// Service Connect defaults were added after the initial schema
+	if _, err := s.db.ExecContext(ctx, `ALTER TABLE clusters ADD COLUMN IF NOT EXISTS service_connect_defaults TEXT`); err != nil {
+		return fmt.Errorf("failed to add service_connect_defaults column to clusters: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `ALTER TABLE clusters ADD COLUMN IF NOT EXISTS attachments TEXT`); err != nil {
+		return fmt.Errorf("failed to add attachments column to clusters: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `ALTER TABLE clusters ADD COLUMN IF NOT EXISTS attachments_status TEXT`); err != nil {
+		return fmt.Errorf("failed to add attachments_status column to clusters: %w", err)
+	}
+
 	// Create indexes
 	indexes := []string{
 		"CREATE INDEX IF NOT EXISTS idx_clusters_arn ON clusters(arn)",
@@ -264,6 +275,7 @@ func (s *PostgresStorage) createTaskDefinitionsTable(ctx context.Context) error
 		proxy_configuration TEXT,
 		inference_accelerators TEXT,
 		runtime_platform TEXT,
+		ephemeral_storage TEXT,
 		status TEXT NOT NULL DEFAULT 'ACTIVE',
 		region TEXT,
 		account_id TEXT,
@@ -277,6 +289,11 @@ func (s *PostgresStorage) createTaskDefinitionsTable(ctx context.Context) error
 		return fmt.Errorf("failed to create task_definitions table: %w", err)
 	}
 
+	// Ephemeral storage was added after the initial schema
+	if _, err := s.db.ExecContext(ctx, `ALTER TABLE task_definitions ADD COLUMN IF NOT EXISTS ephemeral_storage TEXT`); err != nil {
+		return fmt.Errorf("failed to add ephemeral_storage column to task_definitions: %w", err)
+	}
+
 	// Create indexes
 	indexes := []string{
 		"CREATE INDEX IF NOT EXISTS idx_task_definitions_family ON task_definitions(family)",
@@ -290,6 +307,39 @@ func (s *PostgresStorage) createTaskDefinitionsTable(ctx context.Context) error
 		}
 	}
 
+	// Registered revisions are immutable: only the status, tags and
+	// deregistration time change over their lifetime
+	immutability := []string{
+		`CREATE OR REPLACE FUNCTION task_definitions_immutable() RETURNS trigger AS $$
+		BEGIN
+			IF (NEW.id, NEW.arn, NEW.family, NEW.revision, NEW.task_role_arn, NEW.execution_role_arn,
+				NEW.network_mode, NEW.container_definitions, NEW.volumes, NEW.placement_constraints,
+				NEW.requires_compatibilities, NEW.cpu, NEW.memory, NEW.pid_mode, NEW.ipc_mode,
+				NEW.proxy_configuration, NEW.inference_accelerators, NEW.runtime_platform,
+				NEW.ephemeral_storage, NEW.region, NEW.account_id, NEW.registered_at)
+			IS DISTINCT FROM
+				(OLD.id, OLD.arn, OLD.family, OLD.revision, OLD.task_role_arn, OLD.execution_role_arn,
+				OLD.network_mode, OLD.container_definitions, OLD.volumes, OLD.placement_constraints,
+				OLD.requires_compatibilities, OLD.cpu, OLD.memory, OLD.pid_mode, OLD.ipc_mode,
+				OLD.proxy_configuration, OLD.inference_accelerators, OLD.runtime_platform,
+				OLD.ephemeral_storage, OLD.region, OLD.account_id, OLD.registered_at)
+			THEN
+				RAISE EXCEPTION 'task definition % is immutable', OLD.arn;
+			END IF;
+			RETURN NEW;
+		END;
+		$$ LANGUAGE plpgsql`,
+		"DROP TRIGGER IF EXISTS task_definitions_immutable ON task_definitions",
+		`CREATE TRIGGER task_definitions_immutable BEFORE UPDATE ON task_definitions
+		FOR EACH ROW EXECUTE FUNCTION task_definitions_immutable()`,
+	}
+
+	for _, stmt := range immutability {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to create task definition immutability trigger: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -328,6 +378,7 @@ func (s *PostgresStorage) createServicesTable(ctx context.Context) error {
 		account_id TEXT,
 		deployment_name TEXT,
 		namespace TEXT,
+		deployments TEXT,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		UNIQUE(cluster_arn, service_name)
@@ -338,6 +389,17 @@ func (s *PostgresStorage) createServicesTable(ctx context.Context) error {
 		return fmt.Errorf("failed to create services table: %w", err)
 	}
 
+	// Deployment tracking was added after the initial schema
+	if _, err := s.db.ExecContext(ctx, `ALTER TABLE services ADD COLUMN IF NOT EXISTS deployments TEXT`); err != nil {
+		return fmt.Errorf("failed to add deployments column to services: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `ALTER TABLE services ADD COLUMN IF NOT EXISTS volume_configurations TEXT`); err != nil {
+		return fmt.Errorf("failed to add volume_configurations column to services: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `ALTER TABLE services ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1`); err != nil {
+		return fmt.Errorf("failed to add version column to services: %w", err)
+	}
+
 	// Create indexes
 	indexes := []string{
 		"CREATE INDEX IF NOT EXISTS idx_services_arn ON services(arn)",
@@ -345,6 +407,7 @@ func (s *PostgresStorage) createServicesTable(ctx context.Context) error {
 		"CREATE INDEX IF NOT EXISTS idx_services_service_name ON services(service_name)",
 		"CREATE INDEX IF NOT EXISTS idx_services_status ON services(status)",
 		"CREATE INDEX IF NOT EXISTS idx_services_created_at ON services(created_at)",
+		"CREATE INDEX IF NOT EXISTS idx_services_tags ON services USING GIN (" + serviceTagsExpr + " jsonb_path_ops)",
 	}
 
 	for _, idx := range indexes {
@@ -638,6 +701,11 @@ func (s *PostgresStorage) createELBv2Tables(ctx context.Context) error {
 		return fmt.Errorf("failed to create elbv2_load_balancers table: %w", err)
 	}
 
+	// Attributes were added after the initial schema
+	if _, err := s.db.ExecContext(ctx, `ALTER TABLE elbv2_load_balancers ADD COLUMN IF NOT EXISTS attributes TEXT`); err != nil {
+		return fmt.Errorf("failed to add attributes column to elbv2_load_balancers: %w", err)
+	}
+
 	// Create target groups table
 	tgQuery := `
 	CREATE TABLE IF NOT EXISTS elbv2_target_groups (
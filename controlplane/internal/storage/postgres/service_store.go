@@ -4,10 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/lib/pq"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
 )
 
@@ -18,14 +19,15 @@ type serviceStore struct {
 // Create creates a new service
 func (s *serviceStore) Create(ctx context.Context, service *storage.Service) error {
 	if service.ID == "" {
-		service.ID = uuid.New().String()
+		service.ID = deterministic.NewUUID()
 	}
 
-	now := time.Now()
+	now := deterministic.Now()
 	if service.CreatedAt.IsZero() {
 		service.CreatedAt = now
 	}
 	service.UpdatedAt = now
+	service.Version = 1
 
 	query := `
 	INSERT INTO services (
@@ -35,7 +37,8 @@ func (s *serviceStore) Create(ctx context.Context, service *storage.Service) err
 		deployment_configuration, deployment_controller, placement_constraints, placement_strategy,
 		capacity_provider_strategy, tags, scheduling_strategy, service_connect_configuration,
 		enable_ecs_managed_tags, propagate_tags, enable_execute_command,
-		health_check_grace_period_seconds, region, account_id, deployment_name, namespace,
+		health_check_grace_period_seconds, region, account_id, deployment_name, namespace, deployments,
+		volume_configurations, version,
 		created_at, updated_at
 	) VALUES (
 		$1, $2, $3, $4, $5,
@@ -44,7 +47,8 @@ func (s *serviceStore) Create(ctx context.Context, service *storage.Service) err
 		$16, $17, $18, $19, $20,
 		$21, $22, $23, $24, $25,
 		$26, $27, $28, $29, $30,
-		$31, $32, $33
+		$31, $32, $33, $34, $35,
+		$36
 	)`
 
 	_, err := s.db.ExecContext(ctx, query,
@@ -60,7 +64,8 @@ func (s *serviceStore) Create(ctx context.Context, service *storage.Service) err
 		toNullString(service.SchedulingStrategy), toNullString(service.ServiceConnectConfiguration),
 		service.EnableECSManagedTags, toNullString(service.PropagateTags), service.EnableExecuteCommand,
 		service.HealthCheckGracePeriodSeconds, service.Region, service.AccountID,
-		toNullString(service.DeploymentName), toNullString(service.Namespace),
+		toNullString(service.DeploymentName), toNullString(service.Namespace), toNullString(service.Deployments),
+		toNullString(service.VolumeConfigurations), service.Version,
 		service.CreatedAt, service.UpdatedAt,
 	)
 
@@ -84,7 +89,8 @@ func (s *serviceStore) Get(ctx context.Context, clusterARN, serviceNameOrARN str
 		deployment_configuration, deployment_controller, placement_constraints, placement_strategy,
 		capacity_provider_strategy, tags, scheduling_strategy, service_connect_configuration,
 		enable_ecs_managed_tags, propagate_tags, enable_execute_command,
-		health_check_grace_period_seconds, region, account_id, deployment_name, namespace,
+		health_check_grace_period_seconds, region, account_id, deployment_name, namespace, deployments,
+		volume_configurations, version,
 		created_at, updated_at
 	FROM services
 	WHERE cluster_arn = $1 AND (arn = $2 OR service_name = $2)`
@@ -94,7 +100,7 @@ func (s *serviceStore) Get(ctx context.Context, clusterARN, serviceNameOrARN str
 	var networkConfiguration, deploymentConfiguration, deploymentController sql.NullString
 	var placementConstraints, placementStrategy, capacityProviderStrategy sql.NullString
 	var tags, schedulingStrategy, serviceConnectConfiguration, propagateTags sql.NullString
-	var deploymentName, namespace sql.NullString
+	var deploymentName, namespace, deployments, volumeConfigurations sql.NullString
 
 	err := s.db.QueryRowContext(ctx, query, clusterARN, serviceNameOrARN).Scan(
 		&service.ID, &service.ARN, &service.ServiceName, &service.ClusterARN, &service.TaskDefinitionARN,
@@ -103,7 +109,8 @@ func (s *serviceStore) Get(ctx context.Context, clusterARN, serviceNameOrARN str
 		&deploymentConfiguration, &deploymentController, &placementConstraints, &placementStrategy,
 		&capacityProviderStrategy, &tags, &schedulingStrategy, &serviceConnectConfiguration,
 		&service.EnableECSManagedTags, &propagateTags, &service.EnableExecuteCommand,
-		&service.HealthCheckGracePeriodSeconds, &service.Region, &service.AccountID, &deploymentName, &namespace,
+		&service.HealthCheckGracePeriodSeconds, &service.Region, &service.AccountID, &deploymentName, &namespace, &deployments,
+		&volumeConfigurations, &service.Version,
 		&service.CreatedAt, &service.UpdatedAt,
 	)
 
@@ -132,6 +139,8 @@ func (s *serviceStore) Get(ctx context.Context, clusterARN, serviceNameOrARN str
 	service.PropagateTags = fromNullString(propagateTags)
 	service.DeploymentName = fromNullString(deploymentName)
 	service.Namespace = fromNullString(namespace)
+	service.Deployments = fromNullString(deployments)
+	service.VolumeConfigurations = fromNullString(volumeConfigurations)
 
 	return &service, nil
 }
@@ -146,7 +155,8 @@ func (s *serviceStore) GetByARN(ctx context.Context, serviceARN string) (*storag
 		deployment_configuration, deployment_controller, placement_constraints, placement_strategy,
 		capacity_provider_strategy, tags, scheduling_strategy, service_connect_configuration,
 		enable_ecs_managed_tags, propagate_tags, enable_execute_command,
-		health_check_grace_period_seconds, region, account_id, deployment_name, namespace,
+		health_check_grace_period_seconds, region, account_id, deployment_name, namespace, deployments,
+		volume_configurations, version,
 		created_at, updated_at
 	FROM services
 	WHERE arn = $1`
@@ -156,7 +166,7 @@ func (s *serviceStore) GetByARN(ctx context.Context, serviceARN string) (*storag
 	var networkConfiguration, deploymentConfiguration, deploymentController sql.NullString
 	var placementConstraints, placementStrategy, capacityProviderStrategy sql.NullString
 	var tags, schedulingStrategy, serviceConnectConfiguration, propagateTags sql.NullString
-	var deploymentName, namespace sql.NullString
+	var deploymentName, namespace, deployments, volumeConfigurations sql.NullString
 
 	err := s.db.QueryRowContext(ctx, query, serviceARN).Scan(
 		&service.ID, &service.ARN, &service.ServiceName, &service.ClusterARN, &service.TaskDefinitionARN,
@@ -165,7 +175,8 @@ func (s *serviceStore) GetByARN(ctx context.Context, serviceARN string) (*storag
 		&deploymentConfiguration, &deploymentController, &placementConstraints, &placementStrategy,
 		&capacityProviderStrategy, &tags, &schedulingStrategy, &serviceConnectConfiguration,
 		&service.EnableECSManagedTags, &propagateTags, &service.EnableExecuteCommand,
-		&service.HealthCheckGracePeriodSeconds, &service.Region, &service.AccountID, &deploymentName, &namespace,
+		&service.HealthCheckGracePeriodSeconds, &service.Region, &service.AccountID, &deploymentName, &namespace, &deployments,
+		&volumeConfigurations, &service.Version,
 		&service.CreatedAt, &service.UpdatedAt,
 	)
 
@@ -194,20 +205,14 @@ func (s *serviceStore) GetByARN(ctx context.Context, serviceARN string) (*storag
 	service.PropagateTags = fromNullString(propagateTags)
 	service.DeploymentName = fromNullString(deploymentName)
 	service.Namespace = fromNullString(namespace)
+	service.Deployments = fromNullString(deployments)
+	service.VolumeConfigurations = fromNullString(volumeConfigurations)
 
 	return &service, nil
 }
 
 // List retrieves services with filtering
-func (s *serviceStore) List(ctx context.Context, clusterARN string, serviceName string, launchType string, limit int, nextToken string) ([]*storage.Service, string, error) {
-	// Parse the next token to get offset
-	offset := 0
-	if nextToken != "" {
-		if _, err := fmt.Sscanf(nextToken, "%d", &offset); err != nil {
-			return nil, "", fmt.Errorf("invalid next token: %w", err)
-		}
-	}
-
+func (s *serviceStore) List(ctx context.Context, clusterARN string, filters storage.ServiceFilters, limit int, nextToken string) ([]*storage.Service, string, error) {
 	// Build query with filters
 	query := `
 	SELECT
@@ -217,7 +222,8 @@ func (s *serviceStore) List(ctx context.Context, clusterARN string, serviceName
 		deployment_configuration, deployment_controller, placement_constraints, placement_strategy,
 		capacity_provider_strategy, tags, scheduling_strategy, service_connect_configuration,
 		enable_ecs_managed_tags, propagate_tags, enable_execute_command,
-		health_check_grace_period_seconds, region, account_id, deployment_name, namespace,
+		health_check_grace_period_seconds, region, account_id, deployment_name, namespace, deployments,
+		volume_configurations, version,
 		created_at, updated_at
 	FROM services
 	WHERE cluster_arn = $1`
@@ -225,23 +231,34 @@ func (s *serviceStore) List(ctx context.Context, clusterARN string, serviceName
 	args := []interface{}{clusterARN}
 	argNum := 2
 
-	if serviceName != "" {
+	if filters.ServiceName != "" {
 		query += fmt.Sprintf(" AND service_name = $%d", argNum)
-		args = append(args, serviceName)
+		args = append(args, filters.ServiceName)
 		argNum++
 	}
 
-	if launchType != "" {
+	if filters.LaunchType != "" {
 		query += fmt.Sprintf(" AND launch_type = $%d", argNum)
-		args = append(args, launchType)
+		args = append(args, filters.LaunchType)
+		argNum++
+	}
+
+	if filters.Status != "" {
+		query += fmt.Sprintf(" AND status = $%d", argNum)
+		args = append(args, filters.Status)
 		argNum++
 	}
 
-	query += " ORDER BY created_at DESC"
+	if len(filters.Tags) > 0 {
+		// Served by the idx_services_tags index, whose expression this is
+		query += fmt.Sprintf(" AND %s @> $%d::jsonb", serviceTagsExpr, argNum)
+		args = append(args, tagContainment(filters.Tags))
+		argNum++
+	}
 
-	if limit > 0 {
-		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argNum, argNum+1)
-		args = append(args, limit, offset)
+	query, args, err := appendNewestFirstPage(query, args, true, nextToken, pageFetch(limit))
+	if err != nil {
+		return nil, "", err
 	}
 
 	rows, err := s.db.QueryContext(ctx, query, args...)
@@ -252,61 +269,122 @@ func (s *serviceStore) List(ctx context.Context, clusterARN string, serviceName
 
 	var services []*storage.Service
 	for rows.Next() {
-		var service storage.Service
-		var launchType, platformVersion, roleARN, loadBalancers, serviceRegistries sql.NullString
-		var networkConfiguration, deploymentConfiguration, deploymentController sql.NullString
-		var placementConstraints, placementStrategy, capacityProviderStrategy sql.NullString
-		var tags, schedulingStrategy, serviceConnectConfiguration, propagateTags sql.NullString
-		var deploymentName, namespace sql.NullString
-
-		err := rows.Scan(
-			&service.ID, &service.ARN, &service.ServiceName, &service.ClusterARN, &service.TaskDefinitionARN,
-			&service.DesiredCount, &service.RunningCount, &service.PendingCount, &launchType, &platformVersion,
-			&service.Status, &roleARN, &loadBalancers, &serviceRegistries, &networkConfiguration,
-			&deploymentConfiguration, &deploymentController, &placementConstraints, &placementStrategy,
-			&capacityProviderStrategy, &tags, &schedulingStrategy, &serviceConnectConfiguration,
-			&service.EnableECSManagedTags, &propagateTags, &service.EnableExecuteCommand,
-			&service.HealthCheckGracePeriodSeconds, &service.Region, &service.AccountID, &deploymentName, &namespace,
-			&service.CreatedAt, &service.UpdatedAt,
-		)
+		service, err := scanServiceFromRows(rows)
 		if err != nil {
-			return nil, "", fmt.Errorf("failed to scan service row: %w", err)
+			return nil, "", err
 		}
+		services = append(services, service)
+	}
 
-		// Convert null strings back to regular strings
-		service.LaunchType = fromNullString(launchType)
-		service.PlatformVersion = fromNullString(platformVersion)
-		service.RoleARN = fromNullString(roleARN)
-		service.LoadBalancers = fromNullString(loadBalancers)
-		service.ServiceRegistries = fromNullString(serviceRegistries)
-		service.NetworkConfiguration = fromNullString(networkConfiguration)
-		service.DeploymentConfiguration = fromNullString(deploymentConfiguration)
-		service.DeploymentController = fromNullString(deploymentController)
-		service.PlacementConstraints = fromNullString(placementConstraints)
-		service.PlacementStrategy = fromNullString(placementStrategy)
-		service.CapacityProviderStrategy = fromNullString(capacityProviderStrategy)
-		service.Tags = fromNullString(tags)
-		service.SchedulingStrategy = fromNullString(schedulingStrategy)
-		service.ServiceConnectConfiguration = fromNullString(serviceConnectConfiguration)
-		service.PropagateTags = fromNullString(propagateTags)
-		service.DeploymentName = fromNullString(deploymentName)
-		service.Namespace = fromNullString(namespace)
-
-		services = append(services, &service)
+	services, newNextToken := nextPageToken(services, limit, func(svc *storage.Service) storage.PageCursor {
+		return storage.NewestFirstCursor(svc.CreatedAt, svc.ARN)
+	})
+	return services, newNextToken, nil
+}
+
+// serviceTagsExpr is the tags column of services as jsonb, with services
+// without tags as an empty list
+const serviceTagsExpr = "(COALESCE(NULLIF(tags, ''), '[]')::jsonb)"
+
+// GetMany retrieves the services of a cluster by names or ARNs in a single query
+func (s *serviceStore) GetMany(ctx context.Context, clusterARN string, serviceNamesOrARNs []string) ([]*storage.Service, error) {
+	if len(serviceNamesOrARNs) == 0 {
+		return []*storage.Service{}, nil
 	}
 
-	// Generate next token if there are more results
-	newNextToken := ""
-	if limit > 0 && len(services) == limit {
-		newNextToken = fmt.Sprintf("%d", offset+limit)
+	// Build placeholders for IN clause, after the cluster ARN
+	placeholders := make([]string, len(serviceNamesOrARNs))
+	args := make([]interface{}, 0, len(serviceNamesOrARNs)+1)
+	args = append(args, clusterARN)
+	for i, identifier := range serviceNamesOrARNs {
+		placeholders[i] = fmt.Sprintf("$%d", i+2)
+		args = append(args, identifier)
 	}
+	in := strings.Join(placeholders, ",")
 
-	return services, newNextToken, nil
+	query := fmt.Sprintf(`
+	SELECT
+		id, arn, service_name, cluster_arn, task_definition_arn,
+		desired_count, running_count, pending_count, launch_type, platform_version,
+		status, role_arn, load_balancers, service_registries, network_configuration,
+		deployment_configuration, deployment_controller, placement_constraints, placement_strategy,
+		capacity_provider_strategy, tags, scheduling_strategy, service_connect_configuration,
+		enable_ecs_managed_tags, propagate_tags, enable_execute_command,
+		health_check_grace_period_seconds, region, account_id, deployment_name, namespace, deployments,
+		volume_configurations, version,
+		created_at, updated_at
+	FROM services
+	WHERE cluster_arn = $1 AND (arn IN (%s) OR service_name IN (%s))`, in, in)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get services: %w", err)
+	}
+	defer rows.Close()
+
+	var services []*storage.Service
+	for rows.Next() {
+		service, err := scanServiceFromRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, service)
+	}
+
+	return services, nil
 }
 
-// Update updates an existing service
+// scanServiceFromRows scans a service from the current row of rows
+func scanServiceFromRows(rows *sql.Rows) (*storage.Service, error) {
+	var service storage.Service
+	var launchType, platformVersion, roleARN, loadBalancers, serviceRegistries sql.NullString
+	var networkConfiguration, deploymentConfiguration, deploymentController sql.NullString
+	var placementConstraints, placementStrategy, capacityProviderStrategy sql.NullString
+	var tags, schedulingStrategy, serviceConnectConfiguration, propagateTags sql.NullString
+	var deploymentName, namespace, deployments, volumeConfigurations sql.NullString
+
+	err := rows.Scan(
+		&service.ID, &service.ARN, &service.ServiceName, &service.ClusterARN, &service.TaskDefinitionARN,
+		&service.DesiredCount, &service.RunningCount, &service.PendingCount, &launchType, &platformVersion,
+		&service.Status, &roleARN, &loadBalancers, &serviceRegistries, &networkConfiguration,
+		&deploymentConfiguration, &deploymentController, &placementConstraints, &placementStrategy,
+		&capacityProviderStrategy, &tags, &schedulingStrategy, &serviceConnectConfiguration,
+		&service.EnableECSManagedTags, &propagateTags, &service.EnableExecuteCommand,
+		&service.HealthCheckGracePeriodSeconds, &service.Region, &service.AccountID, &deploymentName, &namespace, &deployments,
+		&volumeConfigurations, &service.Version,
+		&service.CreatedAt, &service.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan service row: %w", err)
+	}
+
+	// Convert null strings back to regular strings
+	service.LaunchType = fromNullString(launchType)
+	service.PlatformVersion = fromNullString(platformVersion)
+	service.RoleARN = fromNullString(roleARN)
+	service.LoadBalancers = fromNullString(loadBalancers)
+	service.ServiceRegistries = fromNullString(serviceRegistries)
+	service.NetworkConfiguration = fromNullString(networkConfiguration)
+	service.DeploymentConfiguration = fromNullString(deploymentConfiguration)
+	service.DeploymentController = fromNullString(deploymentController)
+	service.PlacementConstraints = fromNullString(placementConstraints)
+	service.PlacementStrategy = fromNullString(placementStrategy)
+	service.CapacityProviderStrategy = fromNullString(capacityProviderStrategy)
+	service.Tags = fromNullString(tags)
+	service.SchedulingStrategy = fromNullString(schedulingStrategy)
+	service.ServiceConnectConfiguration = fromNullString(serviceConnectConfiguration)
+	service.PropagateTags = fromNullString(propagateTags)
+	service.DeploymentName = fromNullString(deploymentName)
+	service.Namespace = fromNullString(namespace)
+	service.Deployments = fromNullString(deployments)
+	service.VolumeConfigurations = fromNullString(volumeConfigurations)
+
+	return &service, nil
+}
+
+// Update updates an existing service if its version is the stored one
 func (s *serviceStore) Update(ctx context.Context, service *storage.Service) error {
-	service.UpdatedAt = time.Now()
+	updatedAt := deterministic.Now()
 
 	query := `
 	UPDATE services SET
@@ -318,8 +396,8 @@ func (s *serviceStore) Update(ctx context.Context, service *storage.Service) err
 		tags = $17, scheduling_strategy = $18, service_connect_configuration = $19,
 		enable_ecs_managed_tags = $20, propagate_tags = $21, enable_execute_command = $22,
 		health_check_grace_period_seconds = $23, deployment_name = $24, namespace = $25,
-		updated_at = $26
-	WHERE arn = $27`
+		deployments = $26, volume_configurations = $27, updated_at = $28, version = version + 1
+	WHERE arn = $29 AND version = $30`
 
 	result, err := s.db.ExecContext(ctx, query,
 		service.TaskDefinitionARN, service.DesiredCount, service.RunningCount,
@@ -333,7 +411,8 @@ func (s *serviceStore) Update(ctx context.Context, service *storage.Service) err
 		toNullString(service.ServiceConnectConfiguration),
 		service.EnableECSManagedTags, toNullString(service.PropagateTags), service.EnableExecuteCommand,
 		service.HealthCheckGracePeriodSeconds, toNullString(service.DeploymentName),
-		toNullString(service.Namespace), service.UpdatedAt, service.ARN,
+		toNullString(service.Namespace), toNullString(service.Deployments), toNullString(service.VolumeConfigurations),
+		updatedAt, service.ARN, service.Version,
 	)
 
 	if err != nil {
@@ -346,9 +425,19 @@ func (s *serviceStore) Update(ctx context.Context, service *storage.Service) err
 	}
 
 	if rowsAffected == 0 {
+		// Either the service is gone or another update won the race
+		var exists bool
+		if err := s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM services WHERE arn = $1)`, service.ARN).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to check service existence: %w", err)
+		}
+		if exists {
+			return storage.ErrConflict
+		}
 		return storage.ErrResourceNotFound
 	}
 
+	service.UpdatedAt = updatedAt
+	service.Version++
 	return nil
 }
 
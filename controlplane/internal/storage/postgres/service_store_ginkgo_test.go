@@ -132,6 +132,29 @@ var _ = Describe("ServiceStore", func() {
 				Expect(int(retrieved.DesiredCount)).To(Equal(5))
 				Expect(int(retrieved.RunningCount)).To(Equal(4))
 				Expect(retrieved.Status).To(Equal("UPDATING"))
+				Expect(retrieved.Version).To(Equal(int64(2)))
+			})
+		})
+
+		Context("when the service was modified concurrently", func() {
+			It("should return ErrConflict and keep the other update", func() {
+				createTestService(store, cluster.ARN, "test-conflict")
+
+				first, err := store.ServiceStore().Get(ctx, cluster.ARN, "test-conflict")
+				Expect(err).NotTo(HaveOccurred())
+				second, err := store.ServiceStore().Get(ctx, cluster.ARN, "test-conflict")
+				Expect(err).NotTo(HaveOccurred())
+
+				first.DesiredCount = 3
+				Expect(store.ServiceStore().Update(ctx, first)).To(Succeed())
+
+				second.DesiredCount = 7
+				Expect(store.ServiceStore().Update(ctx, second)).To(MatchError(storage.ErrConflict))
+
+				retrieved, err := store.ServiceStore().Get(ctx, cluster.ARN, "test-conflict")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(retrieved.DesiredCount).To(Equal(3))
+				Expect(retrieved.Version).To(Equal(first.Version))
 			})
 		})
 
@@ -182,7 +205,7 @@ var _ = Describe("ServiceStore", func() {
 
 		Context("when listing all services", func() {
 			It("should return all services", func() {
-				services, nextToken, err := store.ServiceStore().List(ctx, cluster.ARN, "", "", 10, "")
+				services, nextToken, err := store.ServiceStore().List(ctx, cluster.ARN, storage.ServiceFilters{}, 10, "")
 				Expect(err).NotTo(HaveOccurred())
 				Expect(services).To(HaveLen(5))
 				Expect(nextToken).To(BeEmpty()) // All services fit in one page
@@ -192,13 +215,13 @@ var _ = Describe("ServiceStore", func() {
 		Context("when listing with pagination", func() {
 			It("should return paginated results", func() {
 				// First page
-				services1, nextToken1, err := store.ServiceStore().List(ctx, cluster.ARN, "", "", 2, "")
+				services1, nextToken1, err := store.ServiceStore().List(ctx, cluster.ARN, storage.ServiceFilters{}, 2, "")
 				Expect(err).NotTo(HaveOccurred())
 				Expect(services1).To(HaveLen(2))
 				Expect(nextToken1).NotTo(BeEmpty())
 
 				// Second page
-				services2, nextToken2, err := store.ServiceStore().List(ctx, cluster.ARN, "", "", 2, nextToken1)
+				services2, nextToken2, err := store.ServiceStore().List(ctx, cluster.ARN, storage.ServiceFilters{}, 2, nextToken1)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(services2).To(HaveLen(2))
 				Expect(nextToken2).NotTo(BeEmpty())
@@ -232,11 +255,43 @@ var _ = Describe("ServiceStore", func() {
 				Expect(err).NotTo(HaveOccurred())
 
 				// List only FARGATE services
-				services, _, err := store.ServiceStore().List(ctx, cluster.ARN, "", "FARGATE", 10, "")
+				services, _, err := store.ServiceStore().List(ctx, cluster.ARN, storage.ServiceFilters{LaunchType: "FARGATE"}, 10, "")
 				Expect(err).NotTo(HaveOccurred())
 				Expect(services).To(HaveLen(1))
 				Expect(services[0].ServiceName).To(Equal("fargate-service"))
 			})
 		})
+
+		Context("when listing with tag and status filters", func() {
+			It("should return only services with all of the tags", func() {
+				for _, svc := range []*storage.Service{
+					{ServiceName: "tagged-prod", Status: "ACTIVE", Tags: `[{"key":"team","value":"payments"},{"key":"env","value":"prod"}]`},
+					{ServiceName: "tagged-dev", Status: "ACTIVE", Tags: `[{"key":"team","value":"payments"},{"key":"env","value":"dev"}]`},
+					{ServiceName: "tagged-draining", Status: "DRAINING", Tags: `[{"key":"team","value":"payments"}]`},
+					{ServiceName: "untagged", Status: "ACTIVE"},
+				} {
+					svc.ID = uuid.New().String()
+					svc.ARN = fmt.Sprintf("arn:aws:ecs:us-east-1:000000000000:service/%s/%s", cluster.ARN, svc.ServiceName)
+					svc.ClusterARN = cluster.ARN
+					svc.Region = "us-east-1"
+					svc.AccountID = "000000000000"
+					Expect(store.ServiceStore().Create(ctx, svc)).To(Succeed())
+				}
+
+				services, _, err := store.ServiceStore().List(ctx, cluster.ARN, storage.ServiceFilters{
+					Tags: map[string]string{"team": "payments", "env": ""},
+				}, 10, "")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(services).To(HaveLen(2))
+
+				services, _, err = store.ServiceStore().List(ctx, cluster.ARN, storage.ServiceFilters{
+					Status: "ACTIVE",
+					Tags:   map[string]string{"team": "payments", "env": "prod"},
+				}, 10, "")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(services).To(HaveLen(1))
+				Expect(services[0].ServiceName).To(Equal("tagged-prod"))
+			})
+		})
 	})
 })
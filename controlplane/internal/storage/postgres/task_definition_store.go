@@ -4,10 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"time"
 
-	"github.com/google/uuid"
 	"github.com/lib/pq"
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
 )
 
@@ -18,11 +18,11 @@ type taskDefinitionStore struct {
 // Register registers a new task definition (creates a new revision)
 func (s *taskDefinitionStore) Register(ctx context.Context, td *storage.TaskDefinition) (*storage.TaskDefinition, error) {
 	if td.ID == "" {
-		td.ID = uuid.New().String()
+		td.ID = deterministic.NewUUID()
 	}
 
 	if td.RegisteredAt.IsZero() {
-		td.RegisteredAt = time.Now()
+		td.RegisteredAt = deterministic.Now()
 	}
 
 	// Get the next revision number for this family
@@ -41,8 +41,7 @@ func (s *taskDefinitionStore) Register(ctx context.Context, td *storage.TaskDefi
 	if td.AccountID == "" {
 		td.AccountID = "000000000000"
 	}
-	td.ARN = fmt.Sprintf("arn:aws:ecs:%s:%s:task-definition/%s:%d",
-		td.Region, td.AccountID, td.Family, td.Revision)
+	td.ARN = awsarn.Build("ecs", td.Region, td.AccountID, fmt.Sprintf("task-definition/%s:%d", td.Family, td.Revision))
 
 	if td.Status == "" {
 		td.Status = "ACTIVE"
@@ -54,11 +53,11 @@ func (s *taskDefinitionStore) Register(ctx context.Context, td *storage.TaskDefi
 		network_mode, container_definitions, volumes, placement_constraints,
 		requires_compatibilities, cpu, memory, tags, pid_mode, ipc_mode,
 		proxy_configuration, inference_accelerators, runtime_platform,
-		status, region, account_id, registered_at
+		ephemeral_storage, status, region, account_id, registered_at
 	) VALUES (
 		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
 		$11, $12, $13, $14, $15, $16, $17, $18, $19,
-		$20, $21, $22, $23
+		$20, $21, $22, $23, $24
 	)`
 
 	_, err = s.db.ExecContext(ctx, insertQuery,
@@ -70,7 +69,7 @@ func (s *taskDefinitionStore) Register(ctx context.Context, td *storage.TaskDefi
 		toNullString(td.Memory), toNullString(td.Tags),
 		toNullString(td.PidMode), toNullString(td.IpcMode),
 		toNullString(td.ProxyConfiguration), toNullString(td.InferenceAccelerators),
-		toNullString(td.RuntimePlatform),
+		toNullString(td.RuntimePlatform), toNullString(td.EphemeralStorage),
 		td.Status, td.Region, td.AccountID, td.RegisteredAt,
 	)
 
@@ -92,7 +91,7 @@ func (s *taskDefinitionStore) GetByARN(ctx context.Context, taskDefArn string) (
 		network_mode, container_definitions, volumes, placement_constraints,
 		requires_compatibilities, cpu, memory, tags, pid_mode, ipc_mode,
 		proxy_configuration, inference_accelerators, runtime_platform,
-		status, region, account_id, registered_at, deregistered_at
+		ephemeral_storage, status, region, account_id, registered_at, deregistered_at
 	FROM task_definitions
 	WHERE arn = $1`
 
@@ -100,7 +99,7 @@ func (s *taskDefinitionStore) GetByARN(ctx context.Context, taskDefArn string) (
 	var taskRoleARN, executionRoleARN, volumes, placementConstraints sql.NullString
 	var requiresCompatibilities, cpu, memory, tags sql.NullString
 	var pidMode, ipcMode, proxyConfiguration, inferenceAccelerators sql.NullString
-	var runtimePlatform sql.NullString
+	var runtimePlatform, ephemeralStorage sql.NullString
 	var deregisteredAt sql.NullTime
 
 	err := s.db.QueryRowContext(ctx, query, taskDefArn).Scan(
@@ -110,7 +109,7 @@ func (s *taskDefinitionStore) GetByARN(ctx context.Context, taskDefArn string) (
 		&volumes, &placementConstraints,
 		&requiresCompatibilities, &cpu, &memory, &tags,
 		&pidMode, &ipcMode, &proxyConfiguration,
-		&inferenceAccelerators, &runtimePlatform,
+		&inferenceAccelerators, &runtimePlatform, &ephemeralStorage,
 		&td.Status, &td.Region, &td.AccountID,
 		&td.RegisteredAt, &deregisteredAt,
 	)
@@ -136,6 +135,7 @@ func (s *taskDefinitionStore) GetByARN(ctx context.Context, taskDefArn string) (
 	td.ProxyConfiguration = fromNullString(proxyConfiguration)
 	td.InferenceAccelerators = fromNullString(inferenceAccelerators)
 	td.RuntimePlatform = fromNullString(runtimePlatform)
+	td.EphemeralStorage = fromNullString(ephemeralStorage)
 	td.DeregisteredAt = fromNullTime(deregisteredAt)
 
 	return &td, nil
@@ -149,7 +149,7 @@ func (s *taskDefinitionStore) GetLatest(ctx context.Context, family string) (*st
 		network_mode, container_definitions, volumes, placement_constraints,
 		requires_compatibilities, cpu, memory, tags, pid_mode, ipc_mode,
 		proxy_configuration, inference_accelerators, runtime_platform,
-		status, region, account_id, registered_at, deregistered_at
+		ephemeral_storage, status, region, account_id, registered_at, deregistered_at
 	FROM task_definitions
 	WHERE family = $1 AND status = 'ACTIVE'
 	ORDER BY revision DESC
@@ -159,7 +159,7 @@ func (s *taskDefinitionStore) GetLatest(ctx context.Context, family string) (*st
 	var taskRoleARN, executionRoleARN, volumes, placementConstraints sql.NullString
 	var requiresCompatibilities, cpu, memory, tags sql.NullString
 	var pidMode, ipcMode, proxyConfiguration, inferenceAccelerators sql.NullString
-	var runtimePlatform sql.NullString
+	var runtimePlatform, ephemeralStorage sql.NullString
 	var deregisteredAt sql.NullTime
 
 	err := s.db.QueryRowContext(ctx, query, family).Scan(
@@ -169,7 +169,7 @@ func (s *taskDefinitionStore) GetLatest(ctx context.Context, family string) (*st
 		&volumes, &placementConstraints,
 		&requiresCompatibilities, &cpu, &memory, &tags,
 		&pidMode, &ipcMode, &proxyConfiguration,
-		&inferenceAccelerators, &runtimePlatform,
+		&inferenceAccelerators, &runtimePlatform, &ephemeralStorage,
 		&td.Status, &td.Region, &td.AccountID,
 		&td.RegisteredAt, &deregisteredAt,
 	)
@@ -195,6 +195,7 @@ func (s *taskDefinitionStore) GetLatest(ctx context.Context, family string) (*st
 	td.ProxyConfiguration = fromNullString(proxyConfiguration)
 	td.InferenceAccelerators = fromNullString(inferenceAccelerators)
 	td.RuntimePlatform = fromNullString(runtimePlatform)
+	td.EphemeralStorage = fromNullString(ephemeralStorage)
 	td.DeregisteredAt = fromNullTime(deregisteredAt)
 
 	return &td, nil
@@ -208,7 +209,7 @@ func (s *taskDefinitionStore) Get(ctx context.Context, family string, revision i
 		network_mode, container_definitions, volumes, placement_constraints,
 		requires_compatibilities, cpu, memory, tags, pid_mode, ipc_mode,
 		proxy_configuration, inference_accelerators, runtime_platform,
-		status, region, account_id, registered_at, deregistered_at
+		ephemeral_storage, status, region, account_id, registered_at, deregistered_at
 	FROM task_definitions
 	WHERE family = $1 AND revision = $2`
 
@@ -216,7 +217,7 @@ func (s *taskDefinitionStore) Get(ctx context.Context, family string, revision i
 	var taskRoleARN, executionRoleARN, volumes, placementConstraints sql.NullString
 	var requiresCompatibilities, cpu, memory, tags sql.NullString
 	var pidMode, ipcMode, proxyConfiguration, inferenceAccelerators sql.NullString
-	var runtimePlatform sql.NullString
+	var runtimePlatform, ephemeralStorage sql.NullString
 	var deregisteredAt sql.NullTime
 
 	err := s.db.QueryRowContext(ctx, query, family, revision).Scan(
@@ -226,7 +227,7 @@ func (s *taskDefinitionStore) Get(ctx context.Context, family string, revision i
 		&volumes, &placementConstraints,
 		&requiresCompatibilities, &cpu, &memory, &tags,
 		&pidMode, &ipcMode, &proxyConfiguration,
-		&inferenceAccelerators, &runtimePlatform,
+		&inferenceAccelerators, &runtimePlatform, &ephemeralStorage,
 		&td.Status, &td.Region, &td.AccountID,
 		&td.RegisteredAt, &deregisteredAt,
 	)
@@ -252,21 +253,78 @@ func (s *taskDefinitionStore) Get(ctx context.Context, family string, revision i
 	td.ProxyConfiguration = fromNullString(proxyConfiguration)
 	td.InferenceAccelerators = fromNullString(inferenceAccelerators)
 	td.RuntimePlatform = fromNullString(runtimePlatform)
+	td.EphemeralStorage = fromNullString(ephemeralStorage)
 	td.DeregisteredAt = fromNullTime(deregisteredAt)
 
 	return &td, nil
 }
 
-// ListRevisions lists revisions of a specific task definition family
-func (s *taskDefinitionStore) ListRevisions(ctx context.Context, family string, status string, limit int, nextToken string) ([]*storage.TaskDefinitionRevision, string, error) {
-	// Parse the next token to get offset
-	offset := 0
+// List lists task definition revisions across families, ordered by family
+// and revision
+func (s *taskDefinitionStore) List(ctx context.Context, filters storage.TaskDefinitionFilters, limit int, nextToken string) ([]*storage.TaskDefinitionRevision, string, error) {
+	query := `
+	SELECT family, revision, arn, status, registered_at
+	FROM task_definitions
+	WHERE 1=1`
+
+	args := []interface{}{}
+	argNum := 1
+
+	if filters.Family != "" {
+		query += fmt.Sprintf(" AND family = $%d", argNum)
+		args = append(args, filters.Family)
+		argNum++
+	}
+
+	if filters.Status != "" {
+		query += fmt.Sprintf(" AND status = $%d", argNum)
+		args = append(args, filters.Status)
+		argNum++
+	}
+
+	comparison, direction := ">", ""
+	if filters.Descending {
+		comparison, direction = "<", " DESC"
+	}
+
 	if nextToken != "" {
-		if _, err := fmt.Sscanf(nextToken, "%d", &offset); err != nil {
-			return nil, "", fmt.Errorf("invalid next token: %w", err)
+		cursor, err := storage.DecodeNextToken(nextToken)
+		if err != nil {
+			return nil, "", err
 		}
+		query += fmt.Sprintf(" AND (family, revision) %s ($%d, $%d)", comparison, argNum, argNum+1)
+		args = append(args, cursor.Family, cursor.Revision)
+		argNum += 2
 	}
 
+	query += fmt.Sprintf(" ORDER BY family%s, revision%s", direction, direction)
+
+	if fetch := pageFetch(limit); fetch > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argNum)
+		args = append(args, fetch)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list task definitions: %w", err)
+	}
+	defer rows.Close()
+
+	var revisions []*storage.TaskDefinitionRevision
+	for rows.Next() {
+		var rev storage.TaskDefinitionRevision
+		if err := rows.Scan(&rev.Family, &rev.Revision, &rev.ARN, &rev.Status, &rev.RegisteredAt); err != nil {
+			return nil, "", fmt.Errorf("failed to scan revision row: %w", err)
+		}
+		revisions = append(revisions, &rev)
+	}
+
+	revisions, newNextToken := nextPageToken(revisions, limit, revisionCursor)
+	return revisions, newNextToken, nil
+}
+
+// ListRevisions lists revisions of a specific task definition family
+func (s *taskDefinitionStore) ListRevisions(ctx context.Context, family string, status string, limit int, nextToken string) ([]*storage.TaskDefinitionRevision, string, error) {
 	query := `
 	SELECT family, revision, arn, status, registered_at, deregistered_at
 	FROM task_definitions
@@ -281,11 +339,21 @@ func (s *taskDefinitionStore) ListRevisions(ctx context.Context, family string,
 		argNum++
 	}
 
+	if nextToken != "" {
+		cursor, err := storage.DecodeNextToken(nextToken)
+		if err != nil {
+			return nil, "", err
+		}
+		query += fmt.Sprintf(" AND revision < $%d", argNum)
+		args = append(args, cursor.Revision)
+		argNum++
+	}
+
 	query += " ORDER BY revision DESC"
 
-	if limit > 0 {
-		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argNum, argNum+1)
-		args = append(args, limit, offset)
+	if fetch := pageFetch(limit); fetch > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argNum)
+		args = append(args, fetch)
 	}
 
 	rows, err := s.db.QueryContext(ctx, query, args...)
@@ -307,32 +375,20 @@ func (s *taskDefinitionStore) ListRevisions(ctx context.Context, family string,
 			return nil, "", fmt.Errorf("failed to scan revision row: %w", err)
 		}
 
-		// Note: deregisteredAt is not part of TaskDefinitionRevision struct
-		// but we query it for completeness
+		if deregisteredAt.Valid {
+			rev.DeregisteredAt = &deregisteredAt.Time
+		}
 		revisions = append(revisions, &rev)
 	}
 
-	// Generate next token if there are more results
-	newNextToken := ""
-	if limit > 0 && len(revisions) == limit {
-		newNextToken = fmt.Sprintf("%d", offset+limit)
-	}
-
+	revisions, newNextToken := nextPageToken(revisions, limit, revisionCursor)
 	return revisions, newNextToken, nil
 }
 
 // ListFamilies lists task definition families with pagination
 func (s *taskDefinitionStore) ListFamilies(ctx context.Context, familyPrefix string, status string, limit int, nextToken string) ([]*storage.TaskDefinitionFamily, string, error) {
-	// Parse the next token to get offset
-	offset := 0
-	if nextToken != "" {
-		if _, err := fmt.Sscanf(nextToken, "%d", &offset); err != nil {
-			return nil, "", fmt.Errorf("invalid next token: %w", err)
-		}
-	}
-
 	query := `
-	SELECT DISTINCT family
+	SELECT family, MAX(revision), COUNT(*) FILTER (WHERE status = 'ACTIVE')
 	FROM task_definitions
 	WHERE 1=1`
 
@@ -341,21 +397,35 @@ func (s *taskDefinitionStore) ListFamilies(ctx context.Context, familyPrefix str
 
 	if familyPrefix != "" {
 		query += fmt.Sprintf(" AND family LIKE $%d", argNum)
-		args = append(args, familyPrefix+"%")
+		args = append(args, likePrefix(familyPrefix))
 		argNum++
 	}
 
-	if status != "" {
-		query += fmt.Sprintf(" AND status = $%d", argNum)
-		args = append(args, status)
+	if nextToken != "" {
+		cursor, err := storage.DecodeNextToken(nextToken)
+		if err != nil {
+			return nil, "", err
+		}
+		query += fmt.Sprintf(" AND family > $%d", argNum)
+		args = append(args, cursor.Family)
 		argNum++
 	}
 
+	query += " GROUP BY family"
+
+	// A family is active while any of its revisions is
+	switch status {
+	case "ACTIVE":
+		query += " HAVING COUNT(*) FILTER (WHERE status = 'ACTIVE') > 0"
+	case "INACTIVE":
+		query += " HAVING COUNT(*) FILTER (WHERE status = 'ACTIVE') = 0"
+	}
+
 	query += " ORDER BY family"
 
-	if limit > 0 {
-		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argNum, argNum+1)
-		args = append(args, limit, offset)
+	if fetch := pageFetch(limit); fetch > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argNum)
+		args = append(args, fetch)
 	}
 
 	rows, err := s.db.QueryContext(ctx, query, args...)
@@ -367,23 +437,25 @@ func (s *taskDefinitionStore) ListFamilies(ctx context.Context, familyPrefix str
 	var families []*storage.TaskDefinitionFamily
 	for rows.Next() {
 		var family storage.TaskDefinitionFamily
-		err := rows.Scan(&family.Family)
+		err := rows.Scan(&family.Family, &family.LatestRevision, &family.ActiveRevisions)
 		if err != nil {
 			return nil, "", fmt.Errorf("failed to scan family row: %w", err)
 		}
 		families = append(families, &family)
 	}
 
-	// Generate next token if there are more results
-	newNextToken := ""
-	if limit > 0 && len(families) == limit {
-		newNextToken = fmt.Sprintf("%d", offset+limit)
-	}
-
+	families, newNextToken := nextPageToken(families, limit, func(f *storage.TaskDefinitionFamily) storage.PageCursor {
+		return storage.PageCursor{Family: f.Family}
+	})
 	return families, newNextToken, nil
 }
 
-// Update updates a task definition
+// revisionCursor returns the cursor positioned at a task definition revision
+func revisionCursor(rev *storage.TaskDefinitionRevision) storage.PageCursor {
+	return storage.PageCursor{Family: rev.Family, Revision: rev.Revision}
+}
+
+// Update updates the status and tags of a task definition
 func (s *taskDefinitionStore) Update(ctx context.Context, td *storage.TaskDefinition) error {
 	query := `
 	UPDATE task_definitions SET
@@ -418,7 +490,7 @@ func (s *taskDefinitionStore) Delete(ctx context.Context, taskDefArn string) err
 		deregistered_at = $1
 	WHERE arn = $2`
 
-	result, err := s.db.ExecContext(ctx, query, time.Now(), taskDefArn)
+	result, err := s.db.ExecContext(ctx, query, deterministic.Now(), taskDefArn)
 	if err != nil {
 		return fmt.Errorf("failed to delete task definition: %w", err)
 	}
@@ -443,7 +515,7 @@ func (s *taskDefinitionStore) Deregister(ctx context.Context, family string, rev
 		deregistered_at = $1
 	WHERE family = $2 AND revision = $3`
 
-	result, err := s.db.ExecContext(ctx, query, time.Now(), family, revision)
+	result, err := s.db.ExecContext(ctx, query, deterministic.Now(), family, revision)
 	if err != nil {
 		return fmt.Errorf("failed to deregister task definition: %w", err)
 	}
@@ -459,3 +531,24 @@ func (s *taskDefinitionStore) Deregister(ctx context.Context, family string, rev
 
 	return nil
 }
+
+// Purge permanently deletes an INACTIVE task definition revision
+func (s *taskDefinitionStore) Purge(ctx context.Context, family string, revision int) error {
+	query := `DELETE FROM task_definitions WHERE family = $1 AND revision = $2 AND status = 'INACTIVE'`
+
+	result, err := s.db.ExecContext(ctx, query, family, revision)
+	if err != nil {
+		return fmt.Errorf("failed to purge task definition: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return storage.ErrResourceNotFound
+	}
+
+	return nil
+}
@@ -294,4 +294,38 @@ var _ = Describe("TaskDefinitionStore", func() {
 			})
 		})
 	})
+
+	Describe("Immutability", func() {
+		It("should reject changing the content of a registered revision", func() {
+			td := createTestTaskDefinition(store, "test-immutable")
+
+			db := openDirectConnection()
+			defer db.Close()
+
+			_, err := db.ExecContext(ctx,
+				`UPDATE task_definitions SET container_definitions = '[]' WHERE arn = $1`, td.ARN)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("is immutable"))
+
+			_, err = db.ExecContext(ctx,
+				`UPDATE task_definitions SET revision = 2 WHERE arn = $1`, td.ARN)
+			Expect(err).To(HaveOccurred())
+
+			retrieved, err := store.TaskDefinitionStore().GetByARN(ctx, td.ARN)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(retrieved.ContainerDefinitions).To(Equal(td.ContainerDefinitions))
+		})
+
+		It("should allow changing the status and tags", func() {
+			td := createTestTaskDefinition(store, "test-mutable-status")
+
+			db := openDirectConnection()
+			defer db.Close()
+
+			_, err := db.ExecContext(ctx,
+				`UPDATE task_definitions SET tags = '[{"key":"team","value":"platform"}]' WHERE arn = $1`, td.ARN)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(store.TaskDefinitionStore().Deregister(ctx, td.Family, td.Revision)).To(Succeed())
+		})
+	})
 })
@@ -6,7 +6,7 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/google/uuid"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
 )
 
@@ -41,10 +41,10 @@ func (s *taskLogStore) SaveLogs(ctx context.Context, logs []storage.TaskLog) err
 	}
 	defer stmt.Close()
 
-	now := time.Now()
+	now := deterministic.Now()
 	for _, log := range logs {
 		if log.ID == "" {
-			log.ID = uuid.New().String()
+			log.ID = deterministic.NewUUID()
 		}
 		if log.CreatedAt.IsZero() {
 			log.CreatedAt = now
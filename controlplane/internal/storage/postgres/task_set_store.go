@@ -4,10 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"time"
 
-	"github.com/google/uuid"
 	"github.com/lib/pq"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
 )
 
@@ -18,10 +17,10 @@ type taskSetStore struct {
 // Create creates a new task set
 func (s *taskSetStore) Create(ctx context.Context, taskSet *storage.TaskSet) error {
 	if taskSet.ID == "" {
-		taskSet.ID = uuid.New().String()
+		taskSet.ID = deterministic.NewUUID()
 	}
 
-	now := time.Now()
+	now := deterministic.Now()
 	if taskSet.CreatedAt.IsZero() {
 		taskSet.CreatedAt = now
 	}
@@ -135,7 +134,7 @@ func (s *taskSetStore) List(ctx context.Context, serviceARN string, taskSetIDs [
 
 // Update updates a task set
 func (s *taskSetStore) Update(ctx context.Context, taskSet *storage.TaskSet) error {
-	taskSet.UpdatedAt = time.Now()
+	taskSet.UpdatedAt = deterministic.Now()
 
 	query := `
 	UPDATE task_sets SET
@@ -232,7 +231,7 @@ func (s *taskSetStore) UpdatePrimary(ctx context.Context, serviceARN, taskSetID
 	SET status = 'PRIMARY', updated_at = $1
 	WHERE service_arn = $2 AND id = $3`
 
-	result, err := tx.ExecContext(ctx, setPrimaryQuery, time.Now(), serviceARN, taskSetID)
+	result, err := tx.ExecContext(ctx, setPrimaryQuery, deterministic.Now(), serviceARN, taskSetID)
 	if err != nil {
 		return fmt.Errorf("failed to set primary task set: %w", err)
 	}
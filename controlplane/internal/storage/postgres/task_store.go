@@ -7,8 +7,9 @@ import (
 	"strings"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/lib/pq"
+	"github.com/nandemo-ya/kecs/controlplane/internal/awsarn"
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
 )
 
@@ -19,11 +20,11 @@ type taskStore struct {
 // Create creates a new task
 func (s *taskStore) Create(ctx context.Context, task *storage.Task) error {
 	if task.ID == "" {
-		task.ID = uuid.New().String()
+		task.ID = deterministic.NewUUID()
 	}
 
 	if task.CreatedAt.IsZero() {
-		task.CreatedAt = time.Now()
+		task.CreatedAt = deterministic.Now()
 	}
 
 	query := `
@@ -84,7 +85,7 @@ func (s *taskStore) Get(ctx context.Context, clusterARN, taskID string) (*storag
 	var args []interface{}
 
 	// If clusterARN is empty and taskID looks like a full ARN, search by ARN only
-	if clusterARN == "" && strings.Contains(taskID, "arn:aws:ecs:") {
+	if clusterARN == "" && awsarn.IsService(taskID, "ecs") {
 		query = `
 		SELECT
 			id, arn, cluster_arn, task_definition_arn, container_instance_arn,
@@ -174,7 +175,10 @@ func (s *taskStore) List(ctx context.Context, clusterARN string, filters storage
 		argNum++
 	}
 
-	query += " ORDER BY created_at DESC"
+	query, args, err := appendNewestFirstPage(query, args, true, filters.NextToken, filters.MaxResults)
+	if err != nil {
+		return nil, err
+	}
 
 	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -302,6 +306,56 @@ func (s *taskStore) GetByARNs(ctx context.Context, arns []string) ([]*storage.Ta
 	return tasks, nil
 }
 
+// GetMany retrieves tasks by full ARNs, in any cluster, or by IDs within a
+// cluster in a single query
+func (s *taskStore) GetMany(ctx context.Context, clusterARN string, taskIDsOrARNs []string) ([]*storage.Task, error) {
+	if len(taskIDsOrARNs) == 0 {
+		return []*storage.Task{}, nil
+	}
+
+	// Build placeholders for IN clause, after the cluster ARN
+	placeholders := make([]string, len(taskIDsOrARNs))
+	args := make([]interface{}, 0, len(taskIDsOrARNs)+1)
+	args = append(args, clusterARN)
+	for i, identifier := range taskIDsOrARNs {
+		placeholders[i] = fmt.Sprintf("$%d", i+2)
+		args = append(args, identifier)
+	}
+	in := strings.Join(placeholders, ",")
+
+	query := fmt.Sprintf(`
+	SELECT
+		id, arn, cluster_arn, task_definition_arn, container_instance_arn,
+		overrides, last_status, desired_status, cpu, memory,
+		containers, started_by, version, stop_code, stopped_reason,
+		stopping_at, stopped_at, connectivity, connectivity_at,
+		pull_started_at, pull_stopped_at, execution_stopped_at,
+		created_at, started_at, launch_type, platform_version,
+		platform_family, task_group, attachments, health_status,
+		tags, attributes, enable_execute_command, capacity_provider_name,
+		ephemeral_storage, region, account_id, pod_name, namespace,
+		service_registries
+	FROM tasks
+	WHERE arn IN (%s) OR (cluster_arn = $1 AND id IN (%s))`, in, in)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []*storage.Task
+	for rows.Next() {
+		task, err := scanTaskFromRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, nil
+}
+
 // CreateOrUpdate creates a new task or updates if it already exists
 func (s *taskStore) CreateOrUpdate(ctx context.Context, task *storage.Task) error {
 	// Try to create first
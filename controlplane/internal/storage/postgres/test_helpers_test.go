@@ -88,8 +88,9 @@ func setupTestDB() storage.Storage {
 	return testDB
 }
 
-// cleanupDatabase removes all test data
-func cleanupDatabase() {
+// openDirectConnection opens a connection to the test database that
+// bypasses the storage layer
+func openDirectConnection() *sql.DB {
 	ctx := context.Background()
 
 	// Get host and port from container
@@ -99,12 +100,20 @@ func cleanupDatabase() {
 	mappedPort, err := postgresContainer.MappedPort(ctx, nat.Port("5432/tcp"))
 	Expect(err).NotTo(HaveOccurred())
 
-	// Create direct connection for cleanup
 	connStr := fmt.Sprintf("postgres://kecs_test:kecs_test@%s:%s/kecs_test?sslmode=disable",
 		host, mappedPort.Port())
 
 	db, err := sql.Open("postgres", connStr)
 	Expect(err).NotTo(HaveOccurred())
+	return db
+}
+
+// cleanupDatabase removes all test data
+func cleanupDatabase() {
+	ctx := context.Background()
+
+	// Create direct connection for cleanup
+	db := openDirectConnection()
 	defer db.Close()
 
 	// Tables to clean in order (respecting foreign key constraints)
@@ -0,0 +1,43 @@
+package storage
+
+import "encoding/json"
+
+// Matches reports whether a service passes the filters, for stores that
+// cannot filter in their queries
+func (f ServiceFilters) Matches(service *Service) bool {
+	if f.ServiceName != "" && service.ServiceName != f.ServiceName {
+		return false
+	}
+	if f.LaunchType != "" && service.LaunchType != f.LaunchType {
+		return false
+	}
+	if f.Status != "" && service.Status != f.Status {
+		return false
+	}
+	if len(f.Tags) == 0 {
+		return true
+	}
+
+	var tags []struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	}
+	if service.Tags != "" {
+		if err := json.Unmarshal([]byte(service.Tags), &tags); err != nil {
+			return false
+		}
+	}
+	for key, value := range f.Tags {
+		found := false
+		for _, tag := range tags {
+			if tag.Key == key && (value == "" || tag.Value == value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,34 @@
+package storage_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+var _ = Describe("ServiceFilters", func() {
+	service := &storage.Service{
+		ServiceName: "web",
+		LaunchType:  "FARGATE",
+		Status:      "ACTIVE",
+		Tags:        `[{"key":"team","value":"payments"},{"key":"env","value":"prod"}]`,
+	}
+
+	DescribeTable("Matches",
+		func(filters storage.ServiceFilters, expected bool) {
+			Expect(filters.Matches(service)).To(Equal(expected))
+		},
+		Entry("no filters", storage.ServiceFilters{}, true),
+		Entry("launch type and status", storage.ServiceFilters{LaunchType: "FARGATE", Status: "ACTIVE"}, true),
+		Entry("other status", storage.ServiceFilters{Status: "DRAINING"}, false),
+		Entry("tag values", storage.ServiceFilters{Tags: map[string]string{"team": "payments", "env": "prod"}}, true),
+		Entry("tag key with any value", storage.ServiceFilters{Tags: map[string]string{"env": ""}}, true),
+		Entry("other tag value", storage.ServiceFilters{Tags: map[string]string{"env": "dev"}}, false),
+		Entry("missing tag", storage.ServiceFilters{Tags: map[string]string{"owner": ""}}, false),
+	)
+
+	It("should not match untagged services on tags", func() {
+		Expect(storage.ServiceFilters{Tags: map[string]string{"env": ""}}.Matches(&storage.Service{})).To(BeFalse())
+	})
+})
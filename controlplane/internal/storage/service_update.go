@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"errors"
+)
+
+// maxServiceUpdateAttempts bounds how often UpdateService re-applies a change
+// to a service that keeps being modified concurrently
+const maxServiceUpdateAttempts = 5
+
+// UpdateService applies mutate to a service read from store and stores it.
+// When the service was modified since it was read, the stored service is read
+// again and mutate applied to it, so concurrent changes to other fields are
+// kept. Errors of mutate abort the update and are returned as is; ErrConflict
+// is returned when the service is still modified concurrently after
+// maxServiceUpdateAttempts attempts.
+func UpdateService(ctx context.Context, store ServiceStore, service *Service, mutate func(*Service) error) (*Service, error) {
+	for attempt := 1; ; attempt++ {
+		if err := mutate(service); err != nil {
+			return nil, err
+		}
+		err := store.Update(ctx, service)
+		if err == nil {
+			return service, nil
+		}
+		if !errors.Is(err, ErrConflict) || attempt == maxServiceUpdateAttempts {
+			return nil, err
+		}
+
+		service, err = store.GetByARN(ctx, service.ARN)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Copy returns a copy of the service that can be modified without changing s.
+// Stores that keep services in memory hand out copies, so that a caller only
+// changes the stored service through Update.
+func (s *Service) Copy() *Service {
+	c := *s
+	if s.ServiceRegistryMetadata != nil {
+		c.ServiceRegistryMetadata = make(map[string]string, len(s.ServiceRegistryMetadata))
+		for k, v := range s.ServiceRegistryMetadata {
+			c.ServiceRegistryMetadata[k] = v
+		}
+	}
+	return &c
+}
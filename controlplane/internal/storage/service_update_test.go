@@ -0,0 +1,87 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// versionedServiceStore keeps a single service and fails updates of stale
+// copies of it, bumping its version before the next conflicts updates
+type versionedServiceStore struct {
+	storage.ServiceStore
+	service   storage.Service
+	conflicts int
+	updates   int
+}
+
+func (s *versionedServiceStore) GetByARN(ctx context.Context, arn string) (*storage.Service, error) {
+	c := s.service
+	return &c, nil
+}
+
+func (s *versionedServiceStore) Update(ctx context.Context, service *storage.Service) error {
+	s.updates++
+	if s.conflicts > 0 {
+		s.conflicts--
+		s.service.RunningCount++
+		s.service.Version++
+	}
+	if service.Version != s.service.Version {
+		return storage.ErrConflict
+	}
+	service.Version++
+	s.service = *service
+	return nil
+}
+
+var _ = Describe("UpdateService", func() {
+	var (
+		ctx   context.Context
+		store *versionedServiceStore
+	)
+
+	setDesiredCount := func(service *storage.Service) error {
+		service.DesiredCount = 3
+		return nil
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		store = &versionedServiceStore{service: storage.Service{ARN: "arn:aws:ecs:us-east-1:000000000000:service/default/web", Version: 1}}
+	})
+
+	It("should re-apply the change to a service modified concurrently", func() {
+		store.conflicts = 2
+		service, _ := store.GetByARN(ctx, store.service.ARN)
+
+		updated, err := storage.UpdateService(ctx, store, service, setDesiredCount)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updated.DesiredCount).To(Equal(3))
+		Expect(updated.RunningCount).To(Equal(2))
+		Expect(store.service.Version).To(Equal(int64(4)))
+		Expect(store.updates).To(Equal(3))
+	})
+
+	It("should give up with ErrConflict", func() {
+		store.conflicts = 100
+		service, _ := store.GetByARN(ctx, store.service.ARN)
+
+		_, err := storage.UpdateService(ctx, store, service, setDesiredCount)
+		Expect(err).To(MatchError(storage.ErrConflict))
+		Expect(store.updates).To(Equal(5))
+	})
+
+	It("should not store the service when the change fails", func() {
+		service, _ := store.GetByARN(ctx, store.service.ARN)
+		failed := errors.New("invalid change")
+
+		_, err := storage.UpdateService(ctx, store, service, func(*storage.Service) error { return failed })
+		Expect(err).To(MatchError(failed))
+		Expect(store.updates).To(BeZero())
+	})
+})
@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// TaskDefinitionContentHash returns a digest of the content of a task
+// definition revision, i.e. everything that is fixed once it is registered.
+// The identity (ID, ARN, revision), status, tags and timestamps are left out,
+// so re-registering the same content yields the same hash.
+func TaskDefinitionContentHash(td *TaskDefinition) string {
+	h := sha256.New()
+	for _, field := range []string{
+		td.Family,
+		td.TaskRoleARN,
+		td.ExecutionRoleARN,
+		td.NetworkMode,
+		td.ContainerDefinitions,
+		td.Volumes,
+		td.PlacementConstraints,
+		td.RequiresCompatibilities,
+		td.CPU,
+		td.Memory,
+		td.PidMode,
+		td.IpcMode,
+		td.ProxyConfiguration,
+		td.InferenceAccelerators,
+		td.RuntimePlatform,
+		td.EphemeralStorage,
+	} {
+		// Length prefixes keep adjacent fields from running into each other
+		fmt.Fprintf(h, "%d:%s;", len(field), field)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// TaskDefinitionReferences lists what uses a task definition revision.
+// Revisions are shared by all clusters of an account, so every cluster is
+// searched.
+type TaskDefinitionReferences struct {
+	TaskDefinitionARN string             `json:"taskDefinitionArn"`
+	Services          []ServiceReference `json:"services"`
+	TaskSets          []TaskSetReference `json:"taskSets"`
+	Tasks             []TaskReference    `json:"tasks"`
+}
+
+// ServiceReference is a service whose primary task definition is the revision
+type ServiceReference struct {
+	ServiceARN  string `json:"serviceArn"`
+	ServiceName string `json:"serviceName"`
+	ClusterARN  string `json:"clusterArn"`
+	Status      string `json:"status"`
+}
+
+// TaskSetReference is a task set that runs the revision
+type TaskSetReference struct {
+	TaskSetARN string `json:"taskSetArn"`
+	ServiceARN string `json:"serviceArn"`
+	ClusterARN string `json:"clusterArn"`
+	Status     string `json:"status"`
+}
+
+// TaskReference is a task of the revision that has not stopped
+type TaskReference struct {
+	TaskARN    string `json:"taskArn"`
+	ClusterARN string `json:"clusterArn"`
+	LastStatus string `json:"lastStatus"`
+}
+
+// InUse reports whether anything references the revision
+func (r *TaskDefinitionReferences) InUse() bool {
+	return len(r.Services) > 0 || len(r.TaskSets) > 0 || len(r.Tasks) > 0
+}
+
+// FindTaskDefinitionReferences finds the services, task sets and tasks of all
+// clusters that use a task definition revision. Deleted services and stopped
+// tasks do not count.
+func FindTaskDefinitionReferences(ctx context.Context, s Storage, taskDef *TaskDefinition) (*TaskDefinitionReferences, error) {
+	refs := &TaskDefinitionReferences{
+		TaskDefinitionARN: taskDef.ARN,
+		Services:          []ServiceReference{},
+		TaskSets:          []TaskSetReference{},
+		Tasks:             []TaskReference{},
+	}
+
+	clusters, err := s.ClusterStore().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+	for _, cluster := range clusters {
+		services, _, err := s.ServiceStore().List(ctx, cluster.ARN, ServiceFilters{}, 0, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list services of cluster %s: %w", cluster.Name, err)
+		}
+		for _, service := range services {
+			if service.Status == "INACTIVE" {
+				continue
+			}
+			if service.TaskDefinitionARN == taskDef.ARN {
+				refs.Services = append(refs.Services, ServiceReference{
+					ServiceARN:  service.ARN,
+					ServiceName: service.ServiceName,
+					ClusterARN:  cluster.ARN,
+					Status:      service.Status,
+				})
+			}
+			if s.TaskSetStore() == nil {
+				continue
+			}
+			taskSets, err := s.TaskSetStore().List(ctx, service.ARN, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list task sets of service %s: %w", service.ServiceName, err)
+			}
+			for _, taskSet := range taskSets {
+				if taskSet.TaskDefinition == taskDef.ARN {
+					refs.TaskSets = append(refs.TaskSets, TaskSetReference{
+						TaskSetARN: taskSet.ARN,
+						ServiceARN: service.ARN,
+						ClusterARN: cluster.ARN,
+						Status:     taskSet.Status,
+					})
+				}
+			}
+		}
+
+		tasks, err := s.TaskStore().List(ctx, cluster.ARN, TaskFilters{Family: taskDef.Family})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tasks of cluster %s: %w", cluster.Name, err)
+		}
+		for _, task := range tasks {
+			if task.TaskDefinitionARN == taskDef.ARN && task.LastStatus != "STOPPED" {
+				refs.Tasks = append(refs.Tasks, TaskReference{
+					TaskARN:    task.ARN,
+					ClusterARN: cluster.ARN,
+					LastStatus: task.LastStatus,
+				})
+			}
+		}
+	}
+	return refs, nil
+}
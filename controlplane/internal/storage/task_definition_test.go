@@ -0,0 +1,131 @@
+package storage_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/mocks"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+var _ = Describe("TaskDefinitionContentHash", func() {
+	newTaskDefinition := func() *storage.TaskDefinition {
+		return &storage.TaskDefinition{
+			ID:                   "id-1",
+			ARN:                  "arn:aws:ecs:us-east-1:000000000000:task-definition/web:1",
+			Family:               "web",
+			Revision:             1,
+			NetworkMode:          "awsvpc",
+			ContainerDefinitions: `[{"name":"app","image":"app:v1"}]`,
+			CPU:                  "256",
+			Memory:               "512",
+			Status:               "ACTIVE",
+		}
+	}
+
+	It("should ignore the identity, status and tags", func() {
+		a := newTaskDefinition()
+		b := newTaskDefinition()
+		b.ID = "id-2"
+		b.ARN = "arn:aws:ecs:us-east-1:000000000000:task-definition/web:2"
+		b.Revision = 2
+		b.Status = "INACTIVE"
+		b.Tags = `[{"key":"team","value":"platform"}]`
+
+		Expect(storage.TaskDefinitionContentHash(a)).To(Equal(storage.TaskDefinitionContentHash(b)))
+	})
+
+	It("should change with the content", func() {
+		a := newTaskDefinition()
+		b := newTaskDefinition()
+		b.ContainerDefinitions = `[{"name":"app","image":"app:v2"}]`
+
+		Expect(storage.TaskDefinitionContentHash(a)).NotTo(Equal(storage.TaskDefinitionContentHash(b)))
+	})
+
+	It("should not confuse adjacent fields", func() {
+		a := newTaskDefinition()
+		a.CPU, a.Memory = "2565", "12"
+		b := newTaskDefinition()
+		b.CPU, b.Memory = "256", "512"
+
+		Expect(storage.TaskDefinitionContentHash(a)).NotTo(Equal(storage.TaskDefinitionContentHash(b)))
+	})
+})
+
+var _ = Describe("FindTaskDefinitionReferences", func() {
+	const (
+		taskDefARN   = "arn:aws:ecs:us-east-1:000000000000:task-definition/web:1"
+		otherDefARN  = "arn:aws:ecs:us-east-1:000000000000:task-definition/web:2"
+		prodCluster  = "arn:aws:ecs:us-east-1:000000000000:cluster/prod"
+		stageCluster = "arn:aws:ecs:us-east-1:000000000000:cluster/stage"
+	)
+
+	var (
+		ctx         context.Context
+		mockStorage *mocks.MockStorage
+		taskDef     *storage.TaskDefinition
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockStorage = mocks.NewMockStorage()
+		clusters := mocks.NewMockClusterStore()
+		services := mocks.NewMockServiceStore()
+		tasks := mocks.NewMockTaskStore()
+		taskSets := mocks.NewMockTaskSetStore()
+		mockStorage.SetClusterStore(clusters)
+		mockStorage.SetServiceStore(services)
+		mockStorage.SetTaskStore(tasks)
+		mockStorage.SetTaskSetStore(taskSets)
+		taskDef = &storage.TaskDefinition{ARN: taskDefARN, Family: "web", Revision: 1}
+
+		Expect(clusters.Create(ctx, &storage.Cluster{Name: "prod", ARN: prodCluster})).To(Succeed())
+		Expect(clusters.Create(ctx, &storage.Cluster{Name: "stage", ARN: stageCluster})).To(Succeed())
+
+		for _, service := range []*storage.Service{
+			{ServiceName: "web", ARN: prodCluster + "/web", ClusterARN: prodCluster, TaskDefinitionARN: taskDefARN, Status: "ACTIVE"},
+			{ServiceName: "old", ARN: prodCluster + "/old", ClusterARN: prodCluster, TaskDefinitionARN: taskDefARN, Status: "INACTIVE"},
+			{ServiceName: "web", ARN: stageCluster + "/web", ClusterARN: stageCluster, TaskDefinitionARN: otherDefARN, Status: "ACTIVE"},
+		} {
+			Expect(services.Create(ctx, service)).To(Succeed())
+		}
+		Expect(taskSets.Create(ctx, &storage.TaskSet{
+			ID: "ts-1", ARN: "arn:task-set/ts-1", ServiceARN: stageCluster + "/web", ClusterARN: stageCluster,
+			TaskDefinition: taskDefARN, Status: "ACTIVE",
+		})).To(Succeed())
+		for _, task := range []*storage.Task{
+			{ID: "t1", ARN: "arn:task/t1", ClusterARN: stageCluster, TaskDefinitionARN: taskDefARN, LastStatus: "RUNNING"},
+			{ID: "t2", ARN: "arn:task/t2", ClusterARN: stageCluster, TaskDefinitionARN: taskDefARN, LastStatus: "STOPPED"},
+			{ID: "t3", ARN: "arn:task/t3", ClusterARN: prodCluster, TaskDefinitionARN: otherDefARN, LastStatus: "RUNNING"},
+		} {
+			Expect(tasks.Create(ctx, task)).To(Succeed())
+		}
+	})
+
+	It("should find the references of all clusters", func() {
+		refs, err := storage.FindTaskDefinitionReferences(ctx, mockStorage, taskDef)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(refs.InUse()).To(BeTrue())
+
+		Expect(refs.Services).To(HaveLen(1))
+		Expect(refs.Services[0].ServiceARN).To(Equal(prodCluster + "/web"))
+		Expect(refs.TaskSets).To(HaveLen(1))
+		Expect(refs.TaskSets[0].ClusterARN).To(Equal(stageCluster))
+		Expect(refs.Tasks).To(HaveLen(1))
+		Expect(refs.Tasks[0].TaskARN).To(Equal("arn:task/t1"))
+	})
+
+	It("should report an unused revision", func() {
+		taskDef = &storage.TaskDefinition{
+			ARN:      "arn:aws:ecs:us-east-1:000000000000:task-definition/web:3",
+			Family:   "web",
+			Revision: 3,
+		}
+		refs, err := storage.FindTaskDefinitionReferences(ctx, mockStorage, taskDef)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(refs.InUse()).To(BeFalse())
+	})
+})
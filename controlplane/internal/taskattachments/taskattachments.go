@@ -0,0 +1,232 @@
+// Package taskattachments models the attachments of tasks: the elastic
+// network interface of awsvpc tasks, the Service Connect attachment of the
+// tasks of Service Connect services and the managed EBS volumes. As in ECS,
+// attachments are PRECREATED with the task, ATTACHED once it runs and
+// DETACHED once it stops, following the pod of the task.
+package taskattachments
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/types"
+)
+
+// Types of task attachments
+const (
+	TypeElasticNetworkInterface = "ElasticNetworkInterface"
+	TypeServiceConnect          = "Service Connect"
+	TypeEBSVolume               = "AmazonElasticBlockStorage"
+)
+
+// Statuses of task attachments, in the order they are reached
+const (
+	StatusPrecreated = "PRECREATED"
+	StatusCreated    = "CREATED"
+	StatusAttaching  = "ATTACHING"
+	StatusAttached   = "ATTACHED"
+	StatusDetaching  = "DETACHING"
+	StatusDetached   = "DETACHED"
+	StatusDeleted    = "DELETED"
+	StatusFailed     = "FAILED"
+)
+
+// statusOrder ranks the statuses, attachments never move back
+var statusOrder = map[string]int{
+	StatusPrecreated: 0,
+	StatusCreated:    1,
+	StatusAttaching:  2,
+	StatusAttached:   3,
+	StatusDetaching:  4,
+	StatusDetached:   5,
+	StatusDeleted:    6,
+	StatusFailed:     6,
+}
+
+// lifecycleTypes are the attachment types that follow the task. Other
+// attachments, such as the public IP of tasks, keep their status.
+var lifecycleTypes = map[string]bool{
+	TypeElasticNetworkInterface: true,
+	TypeServiceConnect:          true,
+	TypeEBSVolume:               true,
+}
+
+// ENIAttachmentID returns the ID of the elastic network interface attachment
+// of the task of a pod, which the network interfaces of its containers refer to
+func ENIAttachmentID(pod *corev1.Pod) string {
+	return "eni-attach-" + podKey(pod)
+}
+
+// podKey identifies a pod in attachment IDs, by UID once it has one
+func podKey(pod *corev1.Pod) string {
+	if pod.UID != "" {
+		return string(pod.UID)
+	}
+	return pod.Name
+}
+
+// ForPod returns the attachments the task of a pod has, PRECREATED. Details
+// not known yet, such as the private IP of the network interface, are added
+// by Sync once the pod has them.
+func ForPod(pod *corev1.Pod) []types.Attachment {
+	var attachments []types.Attachment
+
+	if pod.Annotations["ecs.amazonaws.com/network-mode"] == "awsvpc" {
+		details := []types.KeyValuePair{
+			detail("networkInterfaceId", "eni-"+podKey(pod)),
+			detail("macAddress", "02:00:00:00:00:01"),
+		}
+		if subnets := pod.Annotations["ecs.amazonaws.com/subnets"]; subnets != "" {
+			details = append(details, detail("subnetId", strings.Split(subnets, ",")[0]))
+		}
+		if ip := pod.Status.PodIP; ip != "" {
+			details = append(details,
+				detail("privateDnsName", "ip-"+strings.ReplaceAll(ip, ".", "-")+".ec2.internal"),
+				detail("privateIPv4Address", ip),
+			)
+		}
+		attachments = append(attachments, attachment(ENIAttachmentID(pod), TypeElasticNetworkInterface, details))
+	}
+
+	if namespace := pod.Annotations[converters.ServiceConnectNamespaceAnnotation]; namespace != "" {
+		attachments = append(attachments, attachment("sc-attach-"+podKey(pod), TypeServiceConnect, []types.KeyValuePair{
+			detail("namespace", namespace),
+		}))
+	}
+
+	for _, volume := range pod.Spec.Volumes {
+		if volume.Ephemeral == nil || volume.Ephemeral.VolumeClaimTemplate == nil {
+			continue
+		}
+		annotations := volume.Ephemeral.VolumeClaimTemplate.Annotations
+		size, ok := annotations[converters.EBSSizeAnnotation]
+		if !ok {
+			continue
+		}
+		details := []types.KeyValuePair{
+			detail("volumeName", volume.Name),
+			detail("sizeInGiB", size),
+		}
+		if volumeType := annotations[converters.EBSVolumeTypeAnnotation]; volumeType != "" {
+			details = append(details, detail("volumeType", volumeType))
+		}
+		attachments = append(attachments, attachment("ebs-attach-"+podKey(pod)+"-"+volume.Name, TypeEBSVolume, details))
+	}
+
+	return attachments
+}
+
+// Sync returns the attachments of a task, as JSON, after its pod changed:
+// attachments the pod needs are added, their details refreshed, and the
+// attachments following the task move to the status of its last status.
+// A nil pod only moves the statuses, e.g. once the pod is deleted.
+// Attachments that cannot be parsed are returned unchanged.
+func Sync(attachmentsJSON string, pod *corev1.Pod, lastStatus string) string {
+	var attachments []types.Attachment
+	if attachmentsJSON != "" {
+		if err := json.Unmarshal([]byte(attachmentsJSON), &attachments); err != nil {
+			return attachmentsJSON
+		}
+	}
+
+	if pod != nil {
+		for _, desired := range ForPod(pod) {
+			if existing := find(attachments, *desired.Id); existing != nil {
+				existing.Details = mergeDetails(existing.Details, desired.Details)
+			} else {
+				attachments = append(attachments, desired)
+			}
+		}
+	}
+
+	if status := statusForTask(lastStatus); status != "" {
+		for i := range attachments {
+			advance(&attachments[i], status)
+		}
+	}
+
+	if len(attachments) == 0 {
+		return attachmentsJSON
+	}
+	data, err := json.Marshal(attachments)
+	if err != nil {
+		return attachmentsJSON
+	}
+	return string(data)
+}
+
+// statusForTask returns the status the attachments of a task reach with its
+// last status, empty when the task status does not move them
+func statusForTask(lastStatus string) string {
+	switch lastStatus {
+	case "PROVISIONING", "PENDING", "ACTIVATING":
+		return StatusPrecreated
+	case "RUNNING":
+		return StatusAttached
+	case "DEACTIVATING", "STOPPING", "DEPROVISIONING", "STOPPED":
+		return StatusDetached
+	}
+	return ""
+}
+
+// advance moves an attachment following the task to a later status.
+// Attachments without a status, or with one reported through
+// SubmitAttachmentStateChanges that is already later, are kept.
+func advance(a *types.Attachment, status string) {
+	if a.Type == nil || !lifecycleTypes[*a.Type] {
+		return
+	}
+	if a.Status == nil {
+		a.Status = &status
+		return
+	}
+	current, known := statusOrder[*a.Status]
+	if !known || current < statusOrder[status] {
+		a.Status = &status
+	}
+}
+
+// find returns the attachment with an ID
+func find(attachments []types.Attachment, id string) *types.Attachment {
+	for i := range attachments {
+		if attachments[i].Id != nil && *attachments[i].Id == id {
+			return &attachments[i]
+		}
+	}
+	return nil
+}
+
+// mergeDetails sets the details of updates on existing ones, keeping the
+// details updates do not have, sorted by name
+func mergeDetails(existing, updates []types.KeyValuePair) []types.KeyValuePair {
+	byName := make(map[string]types.KeyValuePair, len(existing)+len(updates))
+	for _, d := range existing {
+		if d.Name != nil {
+			byName[*d.Name] = d
+		}
+	}
+	for _, d := range updates {
+		if d.Name != nil {
+			byName[*d.Name] = d
+		}
+	}
+	merged := make([]types.KeyValuePair, 0, len(byName))
+	for _, d := range byName {
+		merged = append(merged, d)
+	}
+	sort.Slice(merged, func(i, j int) bool { return *merged[i].Name < *merged[j].Name })
+	return merged
+}
+
+func attachment(id, attachmentType string, details []types.KeyValuePair) types.Attachment {
+	status := StatusPrecreated
+	return types.Attachment{Id: &id, Type: &attachmentType, Status: &status, Details: details}
+}
+
+func detail(name, value string) types.KeyValuePair {
+	return types.KeyValuePair{Name: &name, Value: &value}
+}
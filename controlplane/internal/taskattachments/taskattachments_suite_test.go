@@ -0,0 +1,13 @@
+package taskattachments_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestTaskAttachments(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "TaskAttachments Suite")
+}
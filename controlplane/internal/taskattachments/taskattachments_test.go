@@ -0,0 +1,120 @@
+package taskattachments_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/converters"
+	"github.com/nandemo-ya/kecs/controlplane/internal/taskattachments"
+	"github.com/nandemo-ya/kecs/controlplane/internal/types"
+)
+
+var _ = Describe("Task attachments", func() {
+	var pod *corev1.Pod
+
+	BeforeEach(func() {
+		pod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "task-1",
+				UID:  "uid-1",
+				Annotations: map[string]string{
+					"ecs.amazonaws.com/network-mode":             "awsvpc",
+					"ecs.amazonaws.com/subnets":                  "subnet-a,subnet-b",
+					converters.ServiceConnectNamespaceAnnotation: "arn:aws:servicediscovery:us-east-1:000000000000:namespace/ns-1",
+				},
+			},
+			Spec: corev1.PodSpec{
+				Volumes: []corev1.Volume{
+					{Name: "scratch", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+					{Name: "data", VolumeSource: corev1.VolumeSource{Ephemeral: &corev1.EphemeralVolumeSource{
+						VolumeClaimTemplate: &corev1.PersistentVolumeClaimTemplate{
+							ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+								converters.EBSSizeAnnotation:       "20",
+								converters.EBSVolumeTypeAnnotation: "gp3",
+							}},
+						},
+					}}},
+				},
+			},
+		}
+	})
+
+	parse := func(attachmentsJSON string) map[string]types.Attachment {
+		var attachments []types.Attachment
+		Expect(json.Unmarshal([]byte(attachmentsJSON), &attachments)).To(Succeed())
+		byType := make(map[string]types.Attachment)
+		for _, a := range attachments {
+			byType[*a.Type] = a
+		}
+		return byType
+	}
+
+	detail := func(a types.Attachment, name string) string {
+		for _, d := range a.Details {
+			if *d.Name == name {
+				return *d.Value
+			}
+		}
+		return ""
+	}
+
+	It("precreates the attachments the pod needs", func() {
+		attachments := taskattachments.ForPod(pod)
+		Expect(attachments).To(HaveLen(3))
+		for _, a := range attachments {
+			Expect(*a.Status).To(Equal(taskattachments.StatusPrecreated))
+		}
+
+		Expect(*attachments[0].Id).To(Equal("eni-attach-uid-1"))
+		Expect(*attachments[0].Type).To(Equal(taskattachments.TypeElasticNetworkInterface))
+		Expect(detail(attachments[0], "subnetId")).To(Equal("subnet-a"))
+		Expect(detail(attachments[0], "privateIPv4Address")).To(BeEmpty())
+
+		Expect(*attachments[1].Type).To(Equal(taskattachments.TypeServiceConnect))
+		Expect(detail(attachments[1], "namespace")).To(HaveSuffix("namespace/ns-1"))
+
+		Expect(*attachments[2].Id).To(Equal("ebs-attach-uid-1-data"))
+		Expect(*attachments[2].Type).To(Equal(taskattachments.TypeEBSVolume))
+		Expect(detail(attachments[2], "sizeInGiB")).To(Equal("20"))
+		Expect(detail(attachments[2], "volumeType")).To(Equal("gp3"))
+	})
+
+	It("has no attachments for bridge mode pods", func() {
+		Expect(taskattachments.ForPod(&corev1.Pod{})).To(BeEmpty())
+		Expect(taskattachments.Sync("", &corev1.Pod{}, "RUNNING")).To(BeEmpty())
+	})
+
+	It("moves the attachments with the task", func() {
+		attachments := taskattachments.Sync(`[{"id":"public-ip-1","type":"PublicIp","status":"ATTACHED"}]`, pod, "PENDING")
+		Expect(*parse(attachments)["ElasticNetworkInterface"].Status).To(Equal("PRECREATED"))
+
+		pod.Status.PodIP = "10.42.0.7"
+		attachments = taskattachments.Sync(attachments, pod, "RUNNING")
+		byType := parse(attachments)
+		Expect(byType).To(HaveLen(4))
+		Expect(*byType["ElasticNetworkInterface"].Status).To(Equal("ATTACHED"))
+		Expect(detail(byType["ElasticNetworkInterface"], "privateIPv4Address")).To(Equal("10.42.0.7"))
+		Expect(detail(byType["ElasticNetworkInterface"], "subnetId")).To(Equal("subnet-a"))
+		Expect(*byType["Service Connect"].Status).To(Equal("ATTACHED"))
+		Expect(*byType["AmazonElasticBlockStorage"].Status).To(Equal("ATTACHED"))
+
+		attachments = taskattachments.Sync(attachments, nil, "STOPPED")
+		byType = parse(attachments)
+		Expect(*byType["ElasticNetworkInterface"].Status).To(Equal("DETACHED"))
+		Expect(*byType["AmazonElasticBlockStorage"].Status).To(Equal("DETACHED"))
+		Expect(*byType["PublicIp"].Status).To(Equal("ATTACHED"))
+	})
+
+	It("never moves attachments back", func() {
+		attachments := taskattachments.Sync(`[{"id":"eni-attach-uid-1","type":"ElasticNetworkInterface","status":"DELETED"}]`, pod, "RUNNING")
+		Expect(*parse(attachments)["ElasticNetworkInterface"].Status).To(Equal("DELETED"))
+	})
+
+	It("keeps attachments it cannot parse", func() {
+		Expect(taskattachments.Sync("not json", pod, "RUNNING")).To(Equal("not json"))
+	})
+})
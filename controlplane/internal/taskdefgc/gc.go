@@ -0,0 +1,282 @@
+// Package taskdefgc garbage collects task definition revisions, so that
+// long-lived instances do not accumulate thousands of INACTIVE revisions.
+package taskdefgc
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/config"
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/generated/ptr"
+	"github.com/nandemo-ya/kecs/controlplane/internal/servicedeployments"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+// Default policy values
+const (
+	DefaultInactiveRetention = 30 * 24 * time.Hour
+	DefaultTaskWindow        = 24 * time.Hour
+)
+
+// Policy decides which revisions are garbage collected. The latest revision
+// of a family is always kept, so revision numbers are never reused.
+type Policy struct {
+	// KeepActiveRevisions is how many of the newest ACTIVE revisions of each
+	// family are kept; older ACTIVE revisions are deregistered. Zero keeps all.
+	KeepActiveRevisions int `json:"keepActiveRevisions"`
+
+	// InactiveRetention is how long INACTIVE revisions are kept after they
+	// were deregistered before they are purged. Zero keeps them forever.
+	InactiveRetention time.Duration `json:"inactiveRetention"`
+
+	// TaskWindow protects the revisions of tasks started within the window,
+	// even when the tasks have stopped since
+	TaskWindow time.Duration `json:"taskWindow"`
+
+	// DryRun only reports what would be collected
+	DryRun bool `json:"dryRun"`
+}
+
+// PolicyFromConfig returns the policy configured under cleanup.taskDefinition
+func PolicyFromConfig() Policy {
+	return Policy{
+		KeepActiveRevisions: config.GetInt("cleanup.taskDefinition.keepActiveRevisions"),
+		InactiveRetention:   config.GetDuration("cleanup.taskDefinition.inactiveRetention", DefaultInactiveRetention),
+		TaskWindow:          config.GetDuration("cleanup.taskDefinition.taskWindow", DefaultTaskWindow),
+		DryRun:              config.GetBool("cleanup.taskDefinition.dryRun"),
+	}
+}
+
+// Actions taken on revisions
+const (
+	ActionDeregister = "DEREGISTER"
+	ActionPurge      = "PURGE"
+)
+
+// Action is a revision the policy collects
+type Action struct {
+	TaskDefinitionARN string `json:"taskDefinitionArn"`
+	Family            string `json:"family"`
+	Revision          int    `json:"revision"`
+	Action            string `json:"action"`
+	Applied           bool   `json:"applied"`
+	Error             string `json:"error,omitempty"`
+}
+
+// Protection is a revision the policy would collect but that is still in use
+type Protection struct {
+	TaskDefinitionARN string `json:"taskDefinitionArn"`
+	Action            string `json:"action"`
+	Reason            string `json:"reason"`
+}
+
+// Report describes a garbage collection run
+type Report struct {
+	Policy    Policy       `json:"policy"`
+	Families  int          `json:"families"`
+	Revisions int          `json:"revisions"`
+	Actions   []Action     `json:"actions"`
+	Protected []Protection `json:"protected"`
+}
+
+// Applied returns how many actions were applied
+func (r *Report) Applied() int {
+	applied := 0
+	for _, action := range r.Actions {
+		if action.Applied {
+			applied++
+		}
+	}
+	return applied
+}
+
+// Run plans the garbage collection and applies it unless the policy is a dry run
+func Run(ctx context.Context, s storage.Storage, policy Policy, now time.Time) (*Report, error) {
+	report, err := Plan(ctx, s, policy, now)
+	if err != nil {
+		return nil, err
+	}
+	if policy.DryRun {
+		return report, nil
+	}
+
+	store := s.TaskDefinitionStore()
+	for i := range report.Actions {
+		action := &report.Actions[i]
+		switch action.Action {
+		case ActionDeregister:
+			err = store.Deregister(ctx, action.Family, action.Revision)
+		case ActionPurge:
+			err = store.Purge(ctx, action.Family, action.Revision)
+		}
+		if err != nil {
+			action.Error = err.Error()
+			continue
+		}
+		action.Applied = true
+	}
+	return report, nil
+}
+
+// Plan finds the revisions the policy collects without changing anything
+func Plan(ctx context.Context, s storage.Storage, policy Policy, now time.Time) (*Report, error) {
+	report := &Report{
+		Policy:    policy,
+		Actions:   []Action{},
+		Protected: []Protection{},
+	}
+	if policy.KeepActiveRevisions <= 0 && policy.InactiveRetention <= 0 {
+		return report, nil
+	}
+
+	references, err := findReferences(ctx, s, now.Add(-policy.TaskWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	families, err := listFamilies(ctx, s.TaskDefinitionStore())
+	if err != nil {
+		return nil, err
+	}
+	for _, family := range families {
+		revisions, err := listRevisions(ctx, s.TaskDefinitionStore(), family)
+		if err != nil {
+			return nil, err
+		}
+		report.Families++
+		report.Revisions += len(revisions)
+
+		active := 0
+		// Newest first; the latest revision is never collected
+		for i, rev := range revisions {
+			action := ""
+			switch rev.Status {
+			case "ACTIVE":
+				active++
+				if policy.KeepActiveRevisions > 0 && active > policy.KeepActiveRevisions && i > 0 {
+					action = ActionDeregister
+				}
+			case "INACTIVE":
+				deregisteredAt := rev.RegisteredAt
+				if rev.DeregisteredAt != nil {
+					deregisteredAt = *rev.DeregisteredAt
+				}
+				if policy.InactiveRetention > 0 && now.Sub(deregisteredAt) > policy.InactiveRetention && i > 0 {
+					action = ActionPurge
+				}
+			}
+			if action == "" {
+				continue
+			}
+			if reason, ok := references[rev.ARN]; ok {
+				report.Protected = append(report.Protected, Protection{
+					TaskDefinitionARN: rev.ARN,
+					Action:            action,
+					Reason:            reason,
+				})
+				continue
+			}
+			report.Actions = append(report.Actions, Action{
+				TaskDefinitionARN: rev.ARN,
+				Family:            rev.Family,
+				Revision:          rev.Revision,
+				Action:            action,
+			})
+		}
+	}
+	return report, nil
+}
+
+// findReferences returns the revisions used by services, task sets and tasks
+// of all clusters, with the reason they are protected. Tasks count while they
+// run and when they were started after since.
+func findReferences(ctx context.Context, s storage.Storage, since time.Time) (map[string]string, error) {
+	references := make(map[string]string)
+	protect := func(arn, reason string) {
+		if _, ok := references[arn]; !ok && arn != "" {
+			references[arn] = reason
+		}
+	}
+
+	clusters, err := s.ClusterStore().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+	for _, cluster := range clusters {
+		services, _, err := s.ServiceStore().List(ctx, cluster.ARN, storage.ServiceFilters{}, 0, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list services of cluster %s: %w", cluster.Name, err)
+		}
+		for _, service := range services {
+			if service.Status == "INACTIVE" {
+				continue
+			}
+			protect(service.TaskDefinitionARN, fmt.Sprintf("used by service %s", service.ARN))
+			for _, deployment := range servicedeployments.List(service) {
+				protect(ptr.ToString(deployment.TaskDefinition), fmt.Sprintf("used by a deployment of service %s", service.ARN))
+			}
+			if s.TaskSetStore() == nil {
+				continue
+			}
+			taskSets, err := s.TaskSetStore().List(ctx, service.ARN, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list task sets of service %s: %w", service.ServiceName, err)
+			}
+			for _, taskSet := range taskSets {
+				protect(taskSet.TaskDefinition, fmt.Sprintf("used by task set %s", taskSet.ARN))
+			}
+		}
+
+		tasks, err := s.TaskStore().List(ctx, cluster.ARN, storage.TaskFilters{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tasks of cluster %s: %w", cluster.Name, err)
+		}
+		for _, task := range tasks {
+			if task.LastStatus != "STOPPED" || task.CreatedAt.After(since) {
+				protect(task.TaskDefinitionARN, fmt.Sprintf("used by task %s", task.ARN))
+			}
+		}
+	}
+	return references, nil
+}
+
+// listFamilies returns the names of all task definition families
+func listFamilies(ctx context.Context, store storage.TaskDefinitionStore) ([]string, error) {
+	var names []string
+	nextToken := ""
+	for {
+		families, token, err := store.ListFamilies(ctx, "", "", 100, nextToken)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list task definition families: %w", err)
+		}
+		for _, family := range families {
+			names = append(names, family.Family)
+		}
+		if token == "" {
+			break
+		}
+		nextToken = token
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// listRevisions returns all revisions of a family, newest first
+func listRevisions(ctx context.Context, store storage.TaskDefinitionStore, family string) ([]*storage.TaskDefinitionRevision, error) {
+	var revisions []*storage.TaskDefinitionRevision
+	nextToken := ""
+	for {
+		page, token, err := store.ListRevisions(ctx, family, "", 100, nextToken)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list revisions of family %s: %w", family, err)
+		}
+		revisions = append(revisions, page...)
+		if token == "" {
+			break
+		}
+		nextToken = token
+	}
+	return revisions, nil
+}
@@ -0,0 +1,152 @@
+package taskdefgc_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/controlplane/api/mocks"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/taskdefgc"
+)
+
+var _ = Describe("Task definition GC", func() {
+	const cluster = "arn:aws:ecs:us-east-1:000000000000:cluster/default"
+
+	var (
+		ctx         context.Context
+		now         time.Time
+		mockStorage *mocks.MockStorage
+		taskDefs    *mocks.MockTaskDefinitionStore
+		services    *mocks.MockServiceStore
+		tasks       *mocks.MockTaskStore
+	)
+
+	arn := func(family string, revision int) string {
+		return fmt.Sprintf("arn:aws:ecs:us-east-1:000000000000:task-definition/%s:%d", family, revision)
+	}
+
+	// register registers a revision, deregistered the given time ago when inactiveFor is set
+	register := func(family string, inactiveFor time.Duration) {
+		td, err := taskDefs.Register(ctx, &storage.TaskDefinition{Family: family})
+		Expect(err).NotTo(HaveOccurred())
+		td.ARN = arn(family, td.Revision)
+		if inactiveFor > 0 {
+			deregisteredAt := now.Add(-inactiveFor)
+			td.Status = "INACTIVE"
+			td.DeregisteredAt = &deregisteredAt
+		}
+	}
+
+	actions := func(report *taskdefgc.Report) []string {
+		result := []string{}
+		for _, action := range report.Actions {
+			result = append(result, fmt.Sprintf("%s %s:%d", action.Action, action.Family, action.Revision))
+		}
+		return result
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		now = time.Now()
+		mockStorage = mocks.NewMockStorage()
+		clusters := mocks.NewMockClusterStore()
+		taskDefs = mocks.NewMockTaskDefinitionStore()
+		services = mocks.NewMockServiceStore()
+		tasks = mocks.NewMockTaskStore()
+		mockStorage.SetClusterStore(clusters)
+		mockStorage.SetTaskDefinitionStore(taskDefs)
+		mockStorage.SetServiceStore(services)
+		mockStorage.SetTaskStore(tasks)
+		mockStorage.SetTaskSetStore(mocks.NewMockTaskSetStore())
+		Expect(clusters.Create(ctx, &storage.Cluster{Name: "default", ARN: cluster})).To(Succeed())
+
+		// web:1-3 INACTIVE for 60, 40 and 5 days, web:4-6 ACTIVE
+		register("web", 60*24*time.Hour)
+		register("web", 40*24*time.Hour)
+		register("web", 5*24*time.Hour)
+		register("web", 0)
+		register("web", 0)
+		register("web", 0)
+	})
+
+	It("should purge old INACTIVE revisions", func() {
+		policy := taskdefgc.Policy{InactiveRetention: 30 * 24 * time.Hour}
+		report, err := taskdefgc.Run(ctx, mockStorage, policy, now)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actions(report)).To(ConsistOf("PURGE web:1", "PURGE web:2"))
+		Expect(report.Applied()).To(Equal(2))
+		Expect(report.Revisions).To(Equal(6))
+
+		revisions, _, err := taskDefs.ListRevisions(ctx, "web", "", 0, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(revisions).To(HaveLen(4))
+	})
+
+	It("should deregister ACTIVE revisions beyond the newest ones kept", func() {
+		policy := taskdefgc.Policy{KeepActiveRevisions: 2}
+		report, err := taskdefgc.Run(ctx, mockStorage, policy, now)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actions(report)).To(ConsistOf("DEREGISTER web:4"))
+
+		td, err := taskDefs.Get(ctx, "web", 4)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(td.Status).To(Equal("INACTIVE"))
+	})
+
+	It("should only report in a dry run", func() {
+		policy := taskdefgc.Policy{KeepActiveRevisions: 1, InactiveRetention: 30 * 24 * time.Hour, DryRun: true}
+		report, err := taskdefgc.Run(ctx, mockStorage, policy, now)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actions(report)).To(ConsistOf("PURGE web:1", "PURGE web:2", "DEREGISTER web:4", "DEREGISTER web:5"))
+		Expect(report.Applied()).To(BeZero())
+
+		revisions, _, err := taskDefs.ListRevisions(ctx, "web", "ACTIVE", 0, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(revisions).To(HaveLen(3))
+	})
+
+	It("should protect revisions referenced by services and recent tasks", func() {
+		Expect(services.Create(ctx, &storage.Service{
+			ServiceName: "web", ARN: cluster + "/web", ClusterARN: cluster,
+			TaskDefinitionARN: arn("web", 4), Status: "ACTIVE",
+		})).To(Succeed())
+		Expect(tasks.Create(ctx, &storage.Task{
+			ID: "recent", ARN: "arn:task/recent", ClusterARN: cluster,
+			TaskDefinitionARN: arn("web", 1), LastStatus: "STOPPED", CreatedAt: now.Add(-time.Hour),
+		})).To(Succeed())
+		Expect(tasks.Create(ctx, &storage.Task{
+			ID: "old", ARN: "arn:task/old", ClusterARN: cluster,
+			TaskDefinitionARN: arn("web", 2), LastStatus: "STOPPED", CreatedAt: now.Add(-72 * time.Hour),
+		})).To(Succeed())
+
+		policy := taskdefgc.Policy{KeepActiveRevisions: 1, InactiveRetention: 30 * 24 * time.Hour, TaskWindow: 24 * time.Hour}
+		report, err := taskdefgc.Plan(ctx, mockStorage, policy, now)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actions(report)).To(ConsistOf("PURGE web:2", "DEREGISTER web:5"))
+
+		Expect(report.Protected).To(HaveLen(2))
+		// Newest revisions first
+		Expect(report.Protected[0].TaskDefinitionARN).To(Equal(arn("web", 4)))
+		Expect(report.Protected[0].Reason).To(ContainSubstring(cluster + "/web"))
+		Expect(report.Protected[1].TaskDefinitionARN).To(Equal(arn("web", 1)))
+		Expect(report.Protected[1].Reason).To(ContainSubstring("arn:task/recent"))
+	})
+
+	It("should keep the latest revision of a family", func() {
+		register("batch", 90*24*time.Hour)
+		register("batch", 90*24*time.Hour)
+
+		policy := taskdefgc.Policy{InactiveRetention: 30 * 24 * time.Hour}
+		report, err := taskdefgc.Run(ctx, mockStorage, policy, now)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actions(report)).To(ConsistOf("PURGE batch:1", "PURGE web:1", "PURGE web:2"))
+
+		td, err := taskDefs.Register(ctx, &storage.TaskDefinition{Family: "batch"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(td.Revision).To(Equal(3))
+	})
+})
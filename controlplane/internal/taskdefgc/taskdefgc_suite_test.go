@@ -0,0 +1,13 @@
+package taskdefgc_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestTaskDefGC(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Task Definition GC Suite")
+}
@@ -0,0 +1,13 @@
+package taskdeftemplate
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestTaskDefTemplate(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "TaskDefTemplate Suite")
+}
@@ -0,0 +1,118 @@
+// Package taskdeftemplate renders task definition templates, task definition
+// JSON with ${NAME} placeholders, so that teams can keep one task definition
+// for all their environments and register it with per-environment variables.
+package taskdeftemplate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// NameTag is the tag recording the name of the template a revision was
+	// rendered from
+	NameTag = "kecs.dev/template"
+	// DigestTag is the tag recording the digest of the template a revision
+	// was rendered from
+	DigestTag = "kecs.dev/template-digest"
+)
+
+// MissingVariablesError is returned when a template uses variables that are
+// not defined
+type MissingVariablesError struct {
+	Names []string
+}
+
+func (e *MissingVariablesError) Error() string {
+	return "template variables are not defined: " + strings.Join(e.Names, ", ")
+}
+
+// Render replaces the ${NAME} placeholders of a template with the values of
+// variables. Values are escaped for JSON strings, so a placeholder can be
+// used within a string or, for numbers and booleans, as a value of its own.
+// $${ renders as a literal ${. Rendering fails when a variable is not
+// defined, no placeholder is left empty.
+func Render(template string, variables map[string]string) (string, error) {
+	var b strings.Builder
+	missing := make(map[string]bool)
+	rest := template
+	for {
+		i := strings.Index(rest, "${")
+		if i < 0 {
+			b.WriteString(rest)
+			break
+		}
+		if i > 0 && rest[i-1] == '$' {
+			b.WriteString(rest[:i-1])
+			b.WriteString("${")
+			rest = rest[i+2:]
+			continue
+		}
+		b.WriteString(rest[:i])
+
+		end := strings.IndexByte(rest[i:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated placeholder at %q", abbreviate(rest[i:]))
+		}
+		name := rest[i+2 : i+end]
+		if !isVariableName(name) {
+			return "", fmt.Errorf("invalid placeholder ${%s}: names are letters, digits and underscores", name)
+		}
+		value, ok := variables[name]
+		if !ok {
+			missing[name] = true
+		}
+		b.WriteString(escapeJSON(value))
+		rest = rest[i+end+1:]
+	}
+
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return "", &MissingVariablesError{Names: names}
+	}
+	return b.String(), nil
+}
+
+// Digest returns the sha256 digest of a template
+func Digest(template string) string {
+	sum := sha256.Sum256([]byte(template))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// isVariableName reports whether name is a letter or underscore followed by
+// letters, digits and underscores
+func isVariableName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c == '_', c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// escapeJSON escapes a value for a JSON string, without the quotes
+func escapeJSON(value string) string {
+	data, _ := json.Marshal(value)
+	return string(data[1 : len(data)-1])
+}
+
+func abbreviate(s string) string {
+	if len(s) > 20 {
+		return s[:20] + "..."
+	}
+	return s
+}
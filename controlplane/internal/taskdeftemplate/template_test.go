@@ -0,0 +1,49 @@
+package taskdeftemplate
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Render", func() {
+	It("should substitute variables", func() {
+		rendered, err := Render(`{"image": "app:${VERSION}", "memory": ${MEMORY}, "name": "${NAME}-${VERSION}"}`,
+			map[string]string{"VERSION": "1.2", "MEMORY": "512", "NAME": "web"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rendered).To(Equal(`{"image": "app:1.2", "memory": 512, "name": "web-1.2"}`))
+	})
+
+	It("should escape values for JSON strings", func() {
+		rendered, err := Render(`{"value": "${VALUE}"}`, map[string]string{"VALUE": "a \"quoted\"\nline\\"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rendered).To(Equal(`{"value": "a \"quoted\"\nline\\"}`))
+	})
+
+	It("should render $${ as a literal ${", func() {
+		rendered, err := Render(`{"command": "echo $${HOME} ${USER}"}`, map[string]string{"USER": "app"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rendered).To(Equal(`{"command": "echo ${HOME} app"}`))
+	})
+
+	It("should list all undefined variables", func() {
+		_, err := Render(`${B} ${A} ${B} ${C}`, map[string]string{"C": ""})
+		Expect(err).To(BeAssignableToTypeOf(&MissingVariablesError{}))
+		Expect(err.(*MissingVariablesError).Names).To(Equal([]string{"A", "B"}))
+		Expect(err.Error()).To(Equal("template variables are not defined: A, B"))
+	})
+
+	DescribeTable("invalid placeholders",
+		func(template, message string) {
+			_, err := Render(template, map[string]string{})
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("unterminated", `{"image": "app:${VERSION`, "unterminated placeholder"),
+		Entry("empty name", `${}`, "invalid placeholder ${}"),
+		Entry("invalid name", `${1A}`, "invalid placeholder ${1A}"),
+		Entry("default value", `${A:-x}`, "invalid placeholder ${A:-x}"),
+	)
+
+	It("should digest templates", func() {
+		Expect(Digest("{}")).To(Equal("sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a"))
+	})
+})
@@ -55,6 +55,8 @@ func (m Model) executeAction(action KeyAction) (Model, tea.Cmd) {
 		return m.executeTaskDefFamiliesAction(action)
 	case ViewTaskDefinitionRevisions:
 		return m.executeTaskDefRevisionsAction(action)
+	case ViewCapacity:
+		return m.executeCapacityAction(action)
 	}
 
 	return m, nil
@@ -121,6 +123,9 @@ func (m Model) executeGlobalAction(action KeyAction) (Model, tea.Cmd) {
 		m.moveCursorDown()
 
 	case ActionRefresh:
+		if m.currentView == ViewCapacity {
+			return m, tea.Batch(m.loadDataFromAPI(), m.loadCapacityCmd())
+		}
 		return m, m.loadDataFromAPI()
 
 	case ActionGoHome:
@@ -345,6 +350,23 @@ func (m Model) executeClusterAction(action KeyAction) (Model, tea.Cmd) {
 			m.tgCursor = 0
 			return m, m.loadELBv2DataCmd()
 		}
+
+	case ActionNavigateCapacity:
+		if m.selectedInstance != "" {
+			m.currentView = ViewCapacity
+			return m, m.loadCapacityCmd()
+		}
+	}
+
+	return m, nil
+}
+
+// executeCapacityAction handles actions specific to the Capacity view
+func (m Model) executeCapacityAction(action KeyAction) (Model, tea.Cmd) {
+	switch action {
+	case ActionNavigateClusters:
+		m.currentView = ViewClusters
+		return m, m.loadDataFromAPI()
 	}
 
 	return m, nil
@@ -373,6 +395,13 @@ func (m Model) executeServiceAction(action KeyAction) (Model, tea.Cmd) {
 			return m, m.fetchTaskDefinitionsForUpdate(service.Name, service.TaskDef)
 		}
 
+	case ActionRestartService:
+		return m.confirmServiceRestart()
+
+	case ActionFilterServices:
+		m.serviceFilterMode = true
+		m.serviceFilterInput = m.serviceFilter
+
 	case ActionViewLogs:
 		if len(m.services) > 0 {
 			m.previousView = m.currentView
@@ -414,14 +443,7 @@ func (m Model) executeTaskAction(action KeyAction) (Model, tea.Cmd) {
 	case ActionStopTask:
 		if len(m.tasks) > 0 && m.taskCursor < len(m.tasks) {
 			task := m.tasks[m.taskCursor]
-			m.confirmDialog = StopTaskDialog(
-				task.ID,
-				func() error { return nil },
-				func() {},
-			)
-			m.pendingCommand = m.stopTaskCmd(task.ARN)
-			m.previousView = m.currentView
-			m.currentView = ViewConfirmDialog
+			m.taskStopDialog = NewTaskStopDialog(task.ID, task.ARN)
 		}
 
 	case ActionViewLogs:
@@ -438,6 +460,12 @@ func (m Model) executeTaskAction(action KeyAction) (Model, tea.Cmd) {
 			return m, m.viewTaskLogsCmd(task.ARN, containerName)
 		}
 
+	case ActionExecTask:
+		if len(m.tasks) > 0 && m.taskCursor < len(m.tasks) {
+			task := m.tasks[m.taskCursor]
+			return m.openExecSession(task.ID, task.ARN, "")
+		}
+
 	case ActionNavigateClusters:
 		m.currentView = ViewClusters
 		m.selectedCluster = ""
@@ -521,7 +549,18 @@ func (m Model) executeTaskDescribeAction(action KeyAction) (Model, tea.Cmd) {
 		// TODO: Implement restart task
 
 	case ActionStopTask:
-		// TODO: Implement stop task from describe view
+		if m.selectedTaskDetail != nil {
+			m.taskStopDialog = NewTaskStopDialog(m.selectedTask, m.selectedTaskDetail.TaskARN)
+		}
+
+	case ActionExecTask:
+		if m.selectedTaskDetail != nil {
+			containerName := ""
+			if m.selectedContainer < len(m.selectedTaskDetail.Containers) {
+				containerName = m.selectedTaskDetail.Containers[m.selectedContainer].Name
+			}
+			return m.openExecSession(m.selectedTask, m.selectedTaskDetail.TaskARN, containerName)
+		}
 
 	case ActionBack:
 		m.currentView = m.previousView
@@ -611,8 +650,7 @@ func (m Model) executeTaskDefRevisionsAction(action KeyAction) (Model, tea.Cmd)
 				}
 			} else {
 				// Load from API
-				taskDefArn := fmt.Sprintf("arn:aws:ecs:us-east-1:123456789012:task-definition/%s:%d",
-					rev.Family, rev.Revision)
+				taskDefArn := fmt.Sprintf("%s:%d", rev.Family, rev.Revision)
 				return m, m.loadTaskDefinitionJSONCmd(taskDefArn)
 			}
 		}
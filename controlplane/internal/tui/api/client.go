@@ -30,7 +30,10 @@ import (
 	"time"
 
 	"gopkg.in/yaml.v3"
+	"k8s.io/client-go/rest"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/capacity"
+	"github.com/nandemo-ya/kecs/controlplane/internal/host/instance"
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
 )
 
@@ -338,7 +341,7 @@ func (c *HTTPClient) DeleteCluster(ctx context.Context, instanceName, clusterNam
 
 // ECS Service operations
 
-func (c *HTTPClient) ListServices(ctx context.Context, instanceName, clusterName string) ([]string, error) {
+func (c *HTTPClient) ListServices(ctx context.Context, instanceName, clusterName, filter string) ([]string, error) {
 	// Get instance info to find API port
 	if c.k3dProvider != nil {
 		inst, err := c.k3dProvider.GetInstance(ctx, instanceName)
@@ -350,7 +353,7 @@ func (c *HTTPClient) ListServices(ctx context.Context, instanceName, clusterName
 		url := fmt.Sprintf("http://localhost:%d/v1/ListServices", inst.APIPort)
 		client := &http.Client{Timeout: 5 * time.Second}
 
-		reqBody, _ := json.Marshal(ListServicesRequest{Cluster: clusterName})
+		reqBody, _ := json.Marshal(ListServicesRequest{Cluster: clusterName, Filter: filter})
 		resp, err := client.Post(url, "application/json", bytes.NewReader(reqBody))
 		if err != nil {
 			return nil, fmt.Errorf("failed to call ListServices: %w", err)
@@ -358,6 +361,13 @@ func (c *HTTPClient) ListServices(ctx context.Context, instanceName, clusterName
 		defer resp.Body.Close()
 
 		if resp.StatusCode != http.StatusOK {
+			// Invalid filter expressions are reported in the error message
+			var errResp struct {
+				Message string `json:"message"`
+			}
+			if json.NewDecoder(resp.Body).Decode(&errResp) == nil && errResp.Message != "" {
+				return nil, fmt.Errorf("ListServices failed: %s", errResp.Message)
+			}
 			return nil, fmt.Errorf("ListServices returned status %d", resp.StatusCode)
 		}
 
@@ -373,6 +383,7 @@ func (c *HTTPClient) ListServices(ctx context.Context, instanceName, clusterName
 	path := fmt.Sprintf("/api/instances/%s/services", url.PathEscape(instanceName))
 	req := ListServicesRequest{
 		Cluster: clusterName,
+		Filter:  filter,
 	}
 	var resp ListServicesResponse
 	err := c.doRequest(ctx, "POST", path, req, &resp)
@@ -502,6 +513,54 @@ func (c *HTTPClient) UpdateServiceDesiredCount(instanceName, clusterName, servic
 	return fmt.Errorf("UpdateServiceDesiredCount not implemented for this client type")
 }
 
+// RestartService starts a new deployment of the service with its current
+// task definition, replacing all of its tasks
+func (c *HTTPClient) RestartService(instanceName, clusterName, serviceName string) error {
+	// Get instance info to find API port
+	if c.k3dProvider != nil {
+		inst, err := c.k3dProvider.GetInstance(context.Background(), instanceName)
+		if err != nil {
+			return fmt.Errorf("failed to get instance: %w", err)
+		}
+
+		url := fmt.Sprintf("http://localhost:%d/v1/UpdateService", inst.APIPort)
+		client := &http.Client{Timeout: 10 * time.Second}
+
+		reqBody := map[string]interface{}{
+			"service":            serviceName,
+			"cluster":            clusterName,
+			"forceNewDeployment": true,
+		}
+
+		jsonData, err := json.Marshal(reqBody)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+
+		req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+		req.Header.Set("X-Amz-Target", "AmazonEC2ContainerServiceV20141113.UpdateService")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to call UpdateService: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			return fmt.Errorf("UpdateService failed: %s", string(body))
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("RestartService not implemented for this client type")
+}
+
 func (c *HTTPClient) UpdateServiceTaskDefinition(instanceName, clusterName, serviceName, taskDefinition string) error {
 	// Get instance info to find API port
 	if c.k3dProvider != nil {
@@ -749,6 +808,53 @@ func (c *HTTPClient) ListTaskDefinitions(ctx context.Context, instanceName strin
 }
 
 func (c *HTTPClient) RegisterTaskDefinition(ctx context.Context, instanceName string, taskDef interface{}) (string, error) {
+	// Get instance info to find API port
+	if c.k3dProvider != nil {
+		inst, err := c.k3dProvider.GetInstance(ctx, instanceName)
+		if err != nil {
+			return "", fmt.Errorf("failed to get instance: %w", err)
+		}
+
+		// Call the instance's API directly
+		url := fmt.Sprintf("http://localhost:%d/v1/RegisterTaskDefinition", inst.APIPort)
+		client := &http.Client{Timeout: 10 * time.Second}
+
+		jsonData, err := json.Marshal(taskDef)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal request: %w", err)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+		if err != nil {
+			return "", fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+		req.Header.Set("X-Amz-Target", "AmazonEC2ContainerServiceV20141113.RegisterTaskDefinition")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("failed to call RegisterTaskDefinition: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			return "", fmt.Errorf("RegisterTaskDefinition failed: %s", string(body))
+		}
+
+		var result struct {
+			TaskDefinition struct {
+				TaskDefinitionArn string `json:"taskDefinitionArn"`
+			} `json:"taskDefinition"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return "", fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		return result.TaskDefinition.TaskDefinitionArn, nil
+	}
+
+	// Fallback to admin API path
 	path := fmt.Sprintf("/api/instances/%s/task-definitions", url.PathEscape(instanceName))
 	var result map[string]string
 	err := c.doRequest(ctx, "POST", path, taskDef, &result)
@@ -923,8 +1029,13 @@ func (c *HTTPClient) HealthCheck(ctx context.Context, instanceName string) error
 			return fmt.Errorf("failed to get instance: %w", err)
 		}
 
-		// Call the instance's health endpoint directly
-		healthURL := fmt.Sprintf("http://localhost:%d/health", inst.AdminPort)
+		// Call the instance's health endpoint directly. Instances found on a
+		// known port only expose the API server, which serves /health as well.
+		healthPort := inst.AdminPort
+		if healthPort == 0 {
+			healthPort = inst.APIPort
+		}
+		healthURL := fmt.Sprintf("http://localhost:%d/health", healthPort)
 		client := &http.Client{Timeout: 2 * time.Second}
 
 		resp, err := client.Get(healthURL)
@@ -1264,6 +1375,10 @@ type instanceConfig struct {
 
 // getPortForInstance returns the API port for the given instance
 func (c *HTTPClient) getPortForInstance(instanceName string) int {
+	if port, ok := instance.PortFromInstanceName(instanceName); ok {
+		return port
+	}
+
 	// Get home directory
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -1306,52 +1421,210 @@ type describeTargetHealthResult struct {
 }
 
 type xmlTargetHealthDescription struct {
-	Target       xmlTarget       `xml:"Target"`
-	TargetHealth xmlTargetHealth `xml:"TargetHealth"`
+	Target          xmlTarget       `xml:"Target"`
+	HealthCheckPort string          `xml:"HealthCheckPort"`
+	TargetHealth    xmlTargetHealth `xml:"TargetHealth"`
 }
 
 type xmlTarget struct {
-	Id   string `xml:"Id"`
-	Port int32  `xml:"Port"`
+	Id               string `xml:"Id"`
+	Port             int32  `xml:"Port"`
+	AvailabilityZone string `xml:"AvailabilityZone"`
 }
 
 type xmlTargetHealth struct {
-	State string `xml:"State"`
+	State       string `xml:"State"`
+	Reason      string `xml:"Reason"`
+	Description string `xml:"Description"`
 }
 
-// getTargetHealth retrieves target health counts for a target group
-func (c *HTTPClient) getTargetHealth(ctx context.Context, instanceName, targetGroupArn string) (*struct {
-	Healthy   int
-	Unhealthy int
-	Total     int
-}, error) {
-	apiURL := fmt.Sprintf("http://localhost:%d/", c.getPortForInstance(instanceName))
+// XML response structures for Rules
+type describeRulesResponse struct {
+	XMLName xml.Name            `xml:"DescribeRulesResponse"`
+	Result  describeRulesResult `xml:"DescribeRulesResult"`
+}
 
-	// Prepare form data
-	formData := url.Values{}
-	formData.Set("Action", "DescribeTargetHealth")
-	formData.Set("TargetGroupArn", targetGroupArn)
+type describeRulesResult struct {
+	Rules []xmlRule `xml:"Rules>member"`
+}
+
+type xmlRule struct {
+	RuleArn    string              `xml:"RuleArn"`
+	Priority   string              `xml:"Priority"`
+	IsDefault  bool                `xml:"IsDefault"`
+	Conditions []xmlRuleCondition  `xml:"Conditions>member"`
+	Actions    []xmlListenerAction `xml:"Actions>member"`
+}
+
+type xmlRuleCondition struct {
+	Field                   string          `xml:"Field"`
+	Values                  []string        `xml:"Values>member"`
+	HostHeaderConfig        *xmlValueConfig `xml:"HostHeaderConfig"`
+	PathPatternConfig       *xmlValueConfig `xml:"PathPatternConfig"`
+	HttpHeaderConfig        *xmlValueConfig `xml:"HttpHeaderConfig"`
+	HttpRequestMethodConfig *xmlValueConfig `xml:"HttpRequestMethodConfig"`
+	SourceIpConfig          *xmlValueConfig `xml:"SourceIpConfig"`
+}
+
+type xmlValueConfig struct {
+	HttpHeaderName string   `xml:"HttpHeaderName"`
+	Values         []string `xml:"Values>member"`
+}
+
+// values returns the values of a rule condition, which are either set
+// directly or through the field specific config
+func (c xmlRuleCondition) values() []string {
+	if len(c.Values) > 0 {
+		return c.Values
+	}
+	for _, config := range []*xmlValueConfig{c.HostHeaderConfig, c.PathPatternConfig, c.HttpRequestMethodConfig, c.SourceIpConfig} {
+		if config != nil {
+			return config.Values
+		}
+	}
+	if c.HttpHeaderConfig != nil {
+		values := make([]string, 0, len(c.HttpHeaderConfig.Values))
+		for _, v := range c.HttpHeaderConfig.Values {
+			values = append(values, c.HttpHeaderConfig.HttpHeaderName+"="+v)
+		}
+		return values
+	}
+	return nil
+}
+
+// doELBv2Request posts an ELBv2 query API action and decodes the XML response
+// into out. out may be nil for actions whose response carries no data.
+func (c *HTTPClient) doELBv2Request(ctx context.Context, instanceName string, formData url.Values, out interface{}) error {
+	apiURL := fmt.Sprintf("http://localhost:%d/", c.getPortForInstance(instanceName))
 	formData.Set("Version", "2015-12-01")
 
 	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, strings.NewReader(formData.Encode()))
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
 	}
 
-	// Parse XML response
+	if out == nil {
+		return nil
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode XML response: %w", err)
+	}
+	return nil
+}
+
+// DescribeTargetHealth retrieves the health of every target registered with a target group
+func (c *HTTPClient) DescribeTargetHealth(ctx context.Context, instanceName, targetGroupArn string) ([]ELBv2TargetHealth, error) {
+	formData := url.Values{}
+	formData.Set("Action", "DescribeTargetHealth")
+	formData.Set("TargetGroupArn", targetGroupArn)
+
 	var xmlResp describeTargetHealthResponse
-	if err := xml.NewDecoder(resp.Body).Decode(&xmlResp); err != nil {
+	if err := c.doELBv2Request(ctx, instanceName, formData, &xmlResp); err != nil {
+		return nil, err
+	}
+
+	var targets []ELBv2TargetHealth
+	for _, thd := range xmlResp.Result.TargetHealthDescriptions {
+		targets = append(targets, ELBv2TargetHealth{
+			Target: ELBv2Target{
+				Id:               thd.Target.Id,
+				Port:             thd.Target.Port,
+				AvailabilityZone: thd.Target.AvailabilityZone,
+			},
+			HealthCheckPort: thd.HealthCheckPort,
+			State:           thd.TargetHealth.State,
+			Reason:          thd.TargetHealth.Reason,
+			Description:     thd.TargetHealth.Description,
+		})
+	}
+
+	return targets, nil
+}
+
+// DeregisterTargets removes targets from a target group
+func (c *HTTPClient) DeregisterTargets(ctx context.Context, instanceName, targetGroupArn string, targets []ELBv2Target) error {
+	formData := url.Values{}
+	formData.Set("Action", "DeregisterTargets")
+	formData.Set("TargetGroupArn", targetGroupArn)
+	for i, target := range targets {
+		prefix := fmt.Sprintf("Targets.member.%d.", i+1)
+		formData.Set(prefix+"Id", target.Id)
+		if target.Port > 0 {
+			formData.Set(prefix+"Port", fmt.Sprintf("%d", target.Port))
+		}
+		if target.AvailabilityZone != "" {
+			formData.Set(prefix+"AvailabilityZone", target.AvailabilityZone)
+		}
+	}
+
+	return c.doELBv2Request(ctx, instanceName, formData, nil)
+}
+
+// ListRules retrieves the rules of a listener, including its default rule
+func (c *HTTPClient) ListRules(ctx context.Context, instanceName, listenerArn string) ([]ELBv2Rule, error) {
+	formData := url.Values{}
+	formData.Set("Action", "DescribeRules")
+	formData.Set("ListenerArn", listenerArn)
+
+	var xmlResp describeRulesResponse
+	if err := c.doELBv2Request(ctx, instanceName, formData, &xmlResp); err != nil {
+		return nil, err
+	}
+
+	var rules []ELBv2Rule
+	for _, r := range xmlResp.Result.Rules {
+		rule := ELBv2Rule{
+			RuleArn:   r.RuleArn,
+			Priority:  r.Priority,
+			IsDefault: r.IsDefault,
+		}
+		for _, cond := range r.Conditions {
+			rule.Conditions = append(rule.Conditions, ELBv2RuleCondition{
+				Field:  cond.Field,
+				Values: cond.values(),
+			})
+		}
+		for _, act := range r.Actions {
+			rule.Actions = append(rule.Actions, ELBv2ListenerAction{
+				Type:           act.Type,
+				TargetGroupArn: act.TargetGroupArn,
+			})
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// DeleteRule deletes a listener rule
+func (c *HTTPClient) DeleteRule(ctx context.Context, instanceName, ruleArn string) error {
+	formData := url.Values{}
+	formData.Set("Action", "DeleteRule")
+	formData.Set("RuleArn", ruleArn)
+
+	return c.doELBv2Request(ctx, instanceName, formData, nil)
+}
+
+// getTargetHealth retrieves target health counts for a target group
+func (c *HTTPClient) getTargetHealth(ctx context.Context, instanceName, targetGroupArn string) (*struct {
+	Healthy   int
+	Unhealthy int
+	Total     int
+}, error) {
+	targets, err := c.DescribeTargetHealth(ctx, instanceName, targetGroupArn)
+	if err != nil {
 		return nil, err
 	}
 
@@ -1360,11 +1633,11 @@ func (c *HTTPClient) getTargetHealth(ctx context.Context, instanceName, targetGr
 		Unhealthy int
 		Total     int
 	}{
-		Total: len(xmlResp.Result.TargetHealthDescriptions),
+		Total: len(targets),
 	}
 
-	for _, thd := range xmlResp.Result.TargetHealthDescriptions {
-		switch thd.TargetHealth.State {
+	for _, target := range targets {
+		switch target.State {
 		case "healthy":
 			health.Healthy++
 		case "unhealthy", "unavailable", "draining":
@@ -1375,6 +1648,54 @@ func (c *HTTPClient) getTargetHealth(ctx context.Context, instanceName, targetGr
 	return health, nil
 }
 
+// GetCapacity returns the capacity report of the admin API of an instance
+func (c *HTTPClient) GetCapacity(ctx context.Context, instanceName string) (*capacity.Report, error) {
+	if c.k3dProvider == nil {
+		return nil, fmt.Errorf("k3d is not available")
+	}
+	inst, err := c.k3dProvider.GetInstance(ctx, instanceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance: %w", err)
+	}
+	if inst.AdminPort == 0 {
+		return nil, fmt.Errorf("instance %s has no admin API", instanceName)
+	}
+
+	capacityURL := fmt.Sprintf("http://localhost:%d/api/capacity", inst.AdminPort)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, capacityURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get capacity: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("capacity request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var report capacity.Report
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return nil, fmt.Errorf("failed to decode capacity: %w", err)
+	}
+	return &report, nil
+}
+
+// GetKubeConfig returns the REST config of the k3d cluster backing an instance.
+// Instances discovered on a known port have no cluster the TUI can reach.
+func (c *HTTPClient) GetKubeConfig(ctx context.Context, instanceName string) (*rest.Config, error) {
+	if _, ok := instance.PortFromInstanceName(instanceName); ok {
+		return nil, fmt.Errorf("instance %s is not backed by a k3d cluster", instanceName)
+	}
+	if c.k3dProvider == nil {
+		return nil, fmt.Errorf("k3d is not available")
+	}
+	return c.k3dProvider.GetKubeConfig(ctx, instanceName)
+}
+
 // Close cleans up resources
 func (c *HTTPClient) Close() error {
 	if c.k3dProvider != nil {
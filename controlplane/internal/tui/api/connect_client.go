@@ -0,0 +1,377 @@
+// Copyright 2025 The KECS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/connect"
+	"github.com/nandemo-ya/kecs/controlplane/internal/kecsapi"
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// ConnectClient is an HTTPClient that watches instances through the
+// kecs.v1.KecsService Connect API. While an instance is subscribed, its
+// clusters, services and tasks are served from the snapshots the API pushes;
+// otherwise, and for instances whose API does not serve kecs.v1.KecsService,
+// they are read from the ECS API like HTTPClient does.
+type ConnectClient struct {
+	*HTTPClient
+
+	// streamClient has no timeout, streams last until they are unsubscribed
+	streamClient *http.Client
+
+	mu      sync.RWMutex
+	watches map[string]*instanceWatch
+}
+
+// instanceWatch holds the latest snapshots of a subscribed instance. A nil
+// snapshot has not been received yet or its stream ended.
+type instanceWatch struct {
+	cancel   context.CancelFunc
+	clusters *kecsapi.ClusterList
+	services *kecsapi.ServiceList
+	tasks    *kecsapi.TaskList
+}
+
+// NewConnectClient creates a new Connect-based API client
+func NewConnectClient(baseURL string) *ConnectClient {
+	return &ConnectClient{
+		HTTPClient:   NewHTTPClient(baseURL),
+		streamClient: &http.Client{},
+		watches:      make(map[string]*instanceWatch),
+	}
+}
+
+// Subscribe watches the clusters, services and tasks of an instance. An
+// event is sent whenever one of them changes. The channel is closed when
+// the subscription ends, by Unsubscribe, by ctx or by the instance going
+// away.
+func (c *ConnectClient) Subscribe(ctx context.Context, instanceName string) (<-chan Event, error) {
+	port, err := c.apiPort(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+	client := kecsapi.NewClient(fmt.Sprintf("http://localhost:%d", port), c.streamClient)
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	clusters, err := client.WatchClusters(watchCtx, &kecsapi.WatchClustersRequest{})
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to watch clusters: %w", err)
+	}
+	services, err := client.WatchServices(watchCtx, &kecsapi.WatchServicesRequest{})
+	if err != nil {
+		clusters.Close()
+		cancel()
+		return nil, fmt.Errorf("failed to watch services: %w", err)
+	}
+	tasks, err := client.WatchTasks(watchCtx, &kecsapi.WatchTasksRequest{})
+	if err != nil {
+		clusters.Close()
+		services.Close()
+		cancel()
+		return nil, fmt.Errorf("failed to watch tasks: %w", err)
+	}
+
+	watch := &instanceWatch{cancel: cancel}
+	c.mu.Lock()
+	if previous, ok := c.watches[instanceName]; ok {
+		previous.cancel()
+	}
+	c.watches[instanceName] = watch
+	c.mu.Unlock()
+
+	// One pending event is enough for the subscriber to reload
+	events := make(chan Event, 1)
+	notify := func(eventType EventType, data interface{}) {
+		select {
+		case events <- Event{Type: eventType, Resource: instanceName, Action: "snapshot", Data: data, Timestamp: time.Now().Unix()}:
+		default:
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		receive(c, watch, clusters, func(list *kecsapi.ClusterList) {
+			watch.clusters = list
+		}, func(list *kecsapi.ClusterList) { notify(EventTypeCluster, list) })
+	}()
+	go func() {
+		defer wg.Done()
+		receive(c, watch, services, func(list *kecsapi.ServiceList) {
+			watch.services = list
+		}, func(list *kecsapi.ServiceList) { notify(EventTypeService, list) })
+	}()
+	go func() {
+		defer wg.Done()
+		receive(c, watch, tasks, func(list *kecsapi.TaskList) {
+			watch.tasks = list
+		}, func(list *kecsapi.TaskList) { notify(EventTypeTask, list) })
+	}()
+	go func() {
+		wg.Wait()
+		cancel()
+		c.mu.Lock()
+		if c.watches[instanceName] == watch {
+			delete(c.watches, instanceName)
+		}
+		c.mu.Unlock()
+		close(events)
+	}()
+
+	return events, nil
+}
+
+// receive stores the snapshots of a stream until it ends. The snapshot is
+// cleared when the stream ends, so that reads fall back to the ECS API.
+func receive[T any](c *ConnectClient, watch *instanceWatch, stream *connect.ServerStream[T], store func(*T), notify func(*T)) {
+	defer stream.Close()
+	for {
+		snapshot, err := stream.Receive()
+		if err != nil {
+			if connect.CodeOf(err) != connect.CodeCanceled {
+				logging.Debug("Watch stream ended", "error", err)
+			}
+			c.mu.Lock()
+			store(nil)
+			c.mu.Unlock()
+			return
+		}
+		c.mu.Lock()
+		store(snapshot)
+		c.mu.Unlock()
+		notify(snapshot)
+	}
+}
+
+// Unsubscribe stops watching an instance
+func (c *ConnectClient) Unsubscribe(ctx context.Context, instanceName string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if watch, ok := c.watches[instanceName]; ok {
+		watch.cancel()
+		delete(c.watches, instanceName)
+	}
+	return nil
+}
+
+// Close stops all subscriptions
+func (c *ConnectClient) Close() error {
+	c.mu.Lock()
+	for name, watch := range c.watches {
+		watch.cancel()
+		delete(c.watches, name)
+	}
+	c.mu.Unlock()
+	return c.HTTPClient.Close()
+}
+
+// apiPort returns the API port of an instance
+func (c *ConnectClient) apiPort(ctx context.Context, instanceName string) (int, error) {
+	if c.k3dProvider != nil {
+		inst, err := c.k3dProvider.GetInstance(ctx, instanceName)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get instance: %w", err)
+		}
+		return inst.APIPort, nil
+	}
+	return c.getPortForInstance(instanceName), nil
+}
+
+// watch returns the watch of a subscribed instance. The caller must hold
+// the read lock.
+func (c *ConnectClient) watch(instanceName string) *instanceWatch {
+	return c.watches[instanceName]
+}
+
+func (c *ConnectClient) ListClusters(ctx context.Context, instanceName string) ([]string, error) {
+	c.mu.RLock()
+	if watch := c.watch(instanceName); watch != nil && watch.clusters != nil {
+		arns := []string{}
+		for _, cluster := range watch.clusters.Clusters {
+			arns = append(arns, cluster.ClusterArn)
+		}
+		c.mu.RUnlock()
+		return arns, nil
+	}
+	c.mu.RUnlock()
+	return c.HTTPClient.ListClusters(ctx, instanceName)
+}
+
+func (c *ConnectClient) DescribeClusters(ctx context.Context, instanceName string, clusterNames []string) ([]Cluster, error) {
+	c.mu.RLock()
+	if watch := c.watch(instanceName); watch != nil && watch.clusters != nil {
+		clusters := []Cluster{}
+		for _, cluster := range watch.clusters.Clusters {
+			if !containsClusterRef(clusterNames, cluster.ClusterArn) {
+				continue
+			}
+			clusters = append(clusters, Cluster{
+				ClusterArn:                        cluster.ClusterArn,
+				ClusterName:                       cluster.ClusterName,
+				Status:                            cluster.Status,
+				RegisteredContainerInstancesCount: int(cluster.RegisteredContainerInstancesCount),
+				RunningTasksCount:                 int(cluster.RunningTasksCount),
+				PendingTasksCount:                 int(cluster.PendingTasksCount),
+				ActiveServicesCount:               int(cluster.ActiveServicesCount),
+			})
+		}
+		c.mu.RUnlock()
+		return clusters, nil
+	}
+	c.mu.RUnlock()
+	return c.HTTPClient.DescribeClusters(ctx, instanceName, clusterNames)
+}
+
+func (c *ConnectClient) ListServices(ctx context.Context, instanceName, clusterName, filter string) ([]string, error) {
+	// Filters are evaluated by the instance, the watched services lack tags
+	if filter != "" {
+		return c.HTTPClient.ListServices(ctx, instanceName, clusterName, filter)
+	}
+	c.mu.RLock()
+	if watch := c.watch(instanceName); watch != nil && watch.services != nil {
+		arns := []string{}
+		for _, service := range watch.services.Services {
+			if matchesRef(clusterName, service.ClusterArn) {
+				arns = append(arns, service.ServiceArn)
+			}
+		}
+		c.mu.RUnlock()
+		return arns, nil
+	}
+	c.mu.RUnlock()
+	return c.HTTPClient.ListServices(ctx, instanceName, clusterName, filter)
+}
+
+func (c *ConnectClient) DescribeServices(ctx context.Context, instanceName, clusterName string, serviceNames []string) ([]Service, error) {
+	c.mu.RLock()
+	if watch := c.watch(instanceName); watch != nil && watch.services != nil {
+		services := []Service{}
+		for _, service := range watch.services.Services {
+			if !matchesRef(clusterName, service.ClusterArn) || !containsRef(serviceNames, service.ServiceArn) {
+				continue
+			}
+			services = append(services, Service{
+				ServiceArn:     service.ServiceArn,
+				ServiceName:    service.ServiceName,
+				ClusterArn:     service.ClusterArn,
+				Status:         service.Status,
+				DesiredCount:   int(service.DesiredCount),
+				RunningCount:   int(service.RunningCount),
+				PendingCount:   int(service.PendingCount),
+				TaskDefinition: service.TaskDefinition,
+				CreatedAt:      service.CreatedAt,
+			})
+		}
+		c.mu.RUnlock()
+		return services, nil
+	}
+	c.mu.RUnlock()
+	return c.HTTPClient.DescribeServices(ctx, instanceName, clusterName, serviceNames)
+}
+
+func (c *ConnectClient) ListTasks(ctx context.Context, instanceName, clusterName string, serviceName string) ([]string, error) {
+	c.mu.RLock()
+	if watch := c.watch(instanceName); watch != nil && watch.tasks != nil {
+		arns := []string{}
+		for _, task := range watch.tasks.Tasks {
+			if matchesRef(clusterName, task.ClusterArn) && (serviceName == "" || task.ServiceName == serviceName) {
+				arns = append(arns, task.TaskArn)
+			}
+		}
+		c.mu.RUnlock()
+		return arns, nil
+	}
+	c.mu.RUnlock()
+	return c.HTTPClient.ListTasks(ctx, instanceName, clusterName, serviceName)
+}
+
+func (c *ConnectClient) DescribeTasks(ctx context.Context, instanceName, clusterName string, taskArns []string) ([]Task, error) {
+	c.mu.RLock()
+	if watch := c.watch(instanceName); watch != nil && watch.tasks != nil {
+		tasks := []Task{}
+		for _, task := range watch.tasks.Tasks {
+			if !matchesRef(clusterName, task.ClusterArn) || !containsRef(taskArns, task.TaskArn) {
+				continue
+			}
+			tasks = append(tasks, convertConnectTask(task))
+		}
+		c.mu.RUnlock()
+		return tasks, nil
+	}
+	c.mu.RUnlock()
+	return c.HTTPClient.DescribeTasks(ctx, instanceName, clusterName, taskArns)
+}
+
+func convertConnectTask(task kecsapi.Task) Task {
+	result := Task{
+		TaskArn:           task.TaskArn,
+		ClusterArn:        task.ClusterArn,
+		TaskDefinitionArn: task.TaskDefinitionArn,
+		ServiceName:       task.ServiceName,
+		LastStatus:        task.LastStatus,
+		DesiredStatus:     task.DesiredStatus,
+		HealthStatus:      task.HealthStatus,
+		Cpu:               task.Cpu,
+		Memory:            task.Memory,
+		CreatedAt:         task.CreatedAt,
+		StartedAt:         task.StartedAt,
+		StoppedAt:         task.StoppedAt,
+	}
+	for _, container := range task.Containers {
+		converted := Container{
+			ContainerArn: container.ContainerArn,
+			Name:         container.Name,
+			LastStatus:   container.LastStatus,
+			Reason:       container.Reason,
+		}
+		if container.ExitCode != nil {
+			exitCode := int(*container.ExitCode)
+			converted.ExitCode = &exitCode
+		}
+		result.Containers = append(result.Containers, converted)
+	}
+	return result
+}
+
+// matchesRef reports whether a name or ARN refers to the resource with arn.
+// An empty reference matches every resource.
+func matchesRef(ref, arn string) bool {
+	return ref == "" || ref == arn || strings.HasSuffix(arn, "/"+ref)
+}
+
+// containsRef reports whether one of refs refers to the resource with arn
+func containsRef(refs []string, arn string) bool {
+	for _, ref := range refs {
+		if ref != "" && matchesRef(ref, arn) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsClusterRef is containsRef for clusters, which DescribeClusters
+// describes all of when no cluster is given
+func containsClusterRef(refs []string, arn string) bool {
+	return len(refs) == 0 || containsRef(refs, arn)
+}
@@ -16,6 +16,10 @@ package api
 
 import (
 	"context"
+
+	"k8s.io/client-go/rest"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/capacity"
 )
 
 // Client defines the interface for KECS API operations
@@ -37,12 +41,13 @@ type Client interface {
 	DeleteCluster(ctx context.Context, instanceName, clusterName string) error
 
 	// ECS Service operations
-	ListServices(ctx context.Context, instanceName, clusterName string) ([]string, error)
+	ListServices(ctx context.Context, instanceName, clusterName, filter string) ([]string, error)
 	DescribeServices(ctx context.Context, instanceName, clusterName string, serviceNames []string) ([]Service, error)
 	CreateService(ctx context.Context, instanceName, clusterName string, service Service) (*Service, error)
 	UpdateService(ctx context.Context, instanceName, clusterName string, service Service) (*Service, error)
 	UpdateServiceDesiredCount(instanceName, clusterName, serviceArn string, desiredCount int) error
 	UpdateServiceTaskDefinition(instanceName, clusterName, serviceName, taskDefinition string) error
+	RestartService(instanceName, clusterName, serviceName string) error
 	DeleteService(ctx context.Context, instanceName, clusterName, serviceName string) error
 
 	// ECS Task operations
@@ -64,6 +69,16 @@ type Client interface {
 	ListLoadBalancers(ctx context.Context, instanceName string) ([]ELBv2LoadBalancer, error)
 	ListTargetGroups(ctx context.Context, instanceName string) ([]ELBv2TargetGroup, error)
 	ListListeners(ctx context.Context, instanceName, loadBalancerArn string) ([]ELBv2Listener, error)
+	DescribeTargetHealth(ctx context.Context, instanceName, targetGroupArn string) ([]ELBv2TargetHealth, error)
+	DeregisterTargets(ctx context.Context, instanceName, targetGroupArn string, targets []ELBv2Target) error
+	ListRules(ctx context.Context, instanceName, listenerArn string) ([]ELBv2Rule, error)
+	DeleteRule(ctx context.Context, instanceName, ruleArn string) error
+
+	// Node capacity of the instance, from its admin API
+	GetCapacity(ctx context.Context, instanceName string) (*capacity.Report, error)
+
+	// Kubernetes access, used to exec into task containers
+	GetKubeConfig(ctx context.Context, instanceName string) (*rest.Config, error)
 
 	// Health check
 	HealthCheck(ctx context.Context, instanceName string) error
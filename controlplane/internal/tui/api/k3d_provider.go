@@ -25,6 +25,7 @@ import (
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
+	"k8s.io/client-go/rest"
 
 	"github.com/nandemo-ya/kecs/controlplane/internal/host/instance"
 	"github.com/nandemo-ya/kecs/controlplane/internal/host/k3d"
@@ -73,6 +74,7 @@ func (p *K3dInstanceProvider) ListInstances(ctx context.Context) ([]Instance, er
 	}
 
 	var instances []Instance
+	k3dPorts := make(map[int]bool)
 	for _, cluster := range clusters {
 		instance, err := p.getInstanceInfo(ctx, cluster.Name)
 		if err != nil {
@@ -80,11 +82,31 @@ func (p *K3dInstanceProvider) ListInstances(ctx context.Context) ([]Instance, er
 			continue
 		}
 		instances = append(instances, instance)
+		k3dPorts[instance.APIPort] = true
+	}
+
+	// Add instances running outside of k3d on the known ports
+	for _, found := range instance.DiscoverPortInstances(ctx, instance.KnownPorts(), k3dPorts) {
+		instances = append(instances, p.getPortInstanceInfo(found.APIPort))
 	}
 
 	return instances, nil
 }
 
+// getPortInstanceInfo builds the information of an instance discovered on a known port
+func (p *K3dInstanceProvider) getPortInstanceInfo(apiPort int) Instance {
+	inst := Instance{
+		Name:      instance.PortInstanceName(apiPort),
+		Status:    "Running",
+		APIPort:   apiPort,
+		CreatedAt: time.Now(),
+		Source:    instance.SourcePort,
+		Endpoint:  fmt.Sprintf("http://localhost:%d", apiPort),
+	}
+	inst.Clusters, inst.Services, inst.Tasks = p.getInstanceCounts(apiPort)
+	return inst
+}
+
 // getInstanceInfo retrieves detailed information about a KECS instance
 func (p *K3dInstanceProvider) getInstanceInfo(ctx context.Context, name string) (Instance, error) {
 	inst := Instance{
@@ -95,6 +117,7 @@ func (p *K3dInstanceProvider) getInstanceInfo(ctx context.Context, name string)
 		Tasks:     0,
 		APIPort:   5373,
 		CreatedAt: time.Now(), // Default value
+		Source:    instance.SourceK3d,
 	}
 
 	// Try to load saved configuration
@@ -148,6 +171,8 @@ func (p *K3dInstanceProvider) getInstanceInfo(ctx context.Context, name string)
 		}
 	}
 
+	inst.Endpoint = fmt.Sprintf("http://localhost:%d", inst.APIPort)
+
 	// If instance is running, try to get resource counts from API
 	if inst.Status == "Running" && inst.APIPort > 0 {
 		// Get cluster, service, and task counts from the instance's API
@@ -203,6 +228,15 @@ func (p *K3dInstanceProvider) CreateInstance(ctx context.Context, opts CreateIns
 
 // GetInstance retrieves a specific KECS instance information
 func (p *K3dInstanceProvider) GetInstance(ctx context.Context, name string) (*Instance, error) {
+	// Instances discovered on a known port are addressed by their port
+	if apiPort, ok := instance.PortFromInstanceName(name); ok {
+		if !instance.ProbePort(ctx, apiPort) {
+			return nil, fmt.Errorf("instance not reachable: %s", name)
+		}
+		inst := p.getPortInstanceInfo(apiPort)
+		return &inst, nil
+	}
+
 	// Check if the instance exists
 	exists, err := p.k3dManager.ClusterExists(ctx, name)
 	if err != nil {
@@ -461,3 +495,8 @@ func (p *K3dInstanceProvider) Close() error {
 	}
 	return nil
 }
+
+// GetKubeConfig returns the REST config of the k3d cluster backing an instance
+func (p *K3dInstanceProvider) GetKubeConfig(ctx context.Context, name string) (*rest.Config, error) {
+	return p.k3dManager.GetKubeConfig(ctx, name)
+}
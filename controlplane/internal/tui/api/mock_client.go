@@ -19,6 +19,10 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"k8s.io/client-go/rest"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/capacity"
 )
 
 // MockClient implements the Client interface with mock data
@@ -366,7 +370,7 @@ func (c *MockClient) DeleteCluster(ctx context.Context, instanceName, clusterNam
 
 // ECS Service operations
 
-func (c *MockClient) ListServices(ctx context.Context, instanceName, clusterName string) ([]string, error) {
+func (c *MockClient) ListServices(ctx context.Context, instanceName, clusterName, filter string) ([]string, error) {
 	key := fmt.Sprintf("%s/%s", instanceName, clusterName)
 	services := c.services[key]
 	arns := make([]string, len(services))
@@ -415,6 +419,16 @@ func (c *MockClient) UpdateServiceTaskDefinition(instanceName, clusterName, serv
 	return fmt.Errorf("service not found: %s", serviceName)
 }
 
+func (c *MockClient) RestartService(instanceName, clusterName, serviceName string) error {
+	key := fmt.Sprintf("%s/%s", instanceName, clusterName)
+	for _, s := range c.services[key] {
+		if s.ServiceName == serviceName {
+			return nil
+		}
+	}
+	return fmt.Errorf("service not found: %s", serviceName)
+}
+
 func (c *MockClient) UpdateServiceDesiredCount(instanceName, clusterName, serviceNameOrArn string, desiredCount int) error {
 	// Extract service name from ARN if it's an ARN, otherwise use as-is
 	serviceName := serviceNameOrArn
@@ -738,6 +752,111 @@ func (c *MockClient) ListListeners(ctx context.Context, instanceName, loadBalanc
 	}, nil
 }
 
+func (c *MockClient) DescribeTargetHealth(ctx context.Context, instanceName, targetGroupArn string) ([]ELBv2TargetHealth, error) {
+	return []ELBv2TargetHealth{
+		{
+			Target:          ELBv2Target{Id: "10.42.0.12", Port: 8080, AvailabilityZone: "us-east-1a"},
+			HealthCheckPort: "8080",
+			State:           "healthy",
+		},
+		{
+			Target:          ELBv2Target{Id: "10.42.0.13", Port: 8080, AvailabilityZone: "us-east-1b"},
+			HealthCheckPort: "8080",
+			State:           "unhealthy",
+			Reason:          "Target.ResponseCodeMismatch",
+			Description:     "Health checks failed with these codes: [503]",
+		},
+	}, nil
+}
+
+func (c *MockClient) DeregisterTargets(ctx context.Context, instanceName, targetGroupArn string, targets []ELBv2Target) error {
+	return nil
+}
+
+func (c *MockClient) ListRules(ctx context.Context, instanceName, listenerArn string) ([]ELBv2Rule, error) {
+	return []ELBv2Rule{
+		{
+			RuleArn:  listenerArn + "/rule/9683b2d02a6cabee",
+			Priority: "10",
+			Conditions: []ELBv2RuleCondition{
+				{Field: "path-pattern", Values: []string{"/api/*"}},
+			},
+			Actions: []ELBv2ListenerAction{
+				{
+					Type:           "forward",
+					TargetGroupArn: "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/api-targets/83e2d6bc24d8a099",
+				},
+			},
+		},
+		{
+			RuleArn:   listenerArn + "/rule/default",
+			Priority:  "default",
+			IsDefault: true,
+			Actions: []ELBv2ListenerAction{
+				{
+					Type:           "forward",
+					TargetGroupArn: "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/my-targets/73e2d6bc24d8a067",
+				},
+			},
+		},
+	}, nil
+}
+
+func (c *MockClient) DeleteRule(ctx context.Context, instanceName, ruleArn string) error {
+	return nil
+}
+
+func (c *MockClient) GetCapacity(ctx context.Context, instanceName string) (*capacity.Report, error) {
+	nodes := []capacity.NodeCapacity{
+		{
+			Name: "k3d-kecs-" + instanceName + "-server-0", Architecture: "amd64", Ready: true, Schedulable: true,
+			Allocatable: capacity.Resources{CPU: 4096, Memory: 8192},
+			Requested:   capacity.Resources{CPU: 3584, Memory: 4096},
+			Available:   capacity.Resources{CPU: 512, Memory: 4096},
+			Utilization: capacity.Utilization{CPU: 87.5, Memory: 50},
+			Tasks:       9, Pods: 14,
+		},
+		{
+			Name: "k3d-kecs-" + instanceName + "-agent-0", Architecture: "arm64", Ready: true, Schedulable: true,
+			Allocatable: capacity.Resources{CPU: 2048, Memory: 4096},
+			Requested:   capacity.Resources{CPU: 512, Memory: 1024},
+			Available:   capacity.Resources{CPU: 1536, Memory: 3072},
+			Utilization: capacity.Utilization{CPU: 25, Memory: 25},
+			Tasks:       3, Pods: 4,
+		},
+	}
+	return &capacity.Report{
+		GeneratedAt: time.Now().UTC(),
+		Thresholds:  capacity.DefaultThresholds(),
+		Allocatable: capacity.Resources{CPU: 6144, Memory: 12288},
+		Requested:   capacity.Resources{CPU: 4096, Memory: 5120},
+		Available:   capacity.Resources{CPU: 2048, Memory: 7168},
+		Utilization: capacity.Utilization{CPU: 66.6, Memory: 41.6},
+		Tasks:       12,
+		TaskDensity: 6,
+		Nodes:       nodes,
+		Clusters: []capacity.ClusterCapacity{
+			{Cluster: "default", Requested: capacity.Resources{CPU: 3072, Memory: 3584}, Tasks: 9},
+			{Cluster: "staging", Requested: capacity.Resources{CPU: 512, Memory: 1024}, Tasks: 3, PendingTasks: 1},
+		},
+		PendingPods: []capacity.PendingPod{
+			{
+				Name: "worker-7f9c", Namespace: "staging-us-east-1", Cluster: "staging",
+				Reason: "Unschedulable", Message: "0/2 nodes are available: 2 Insufficient cpu.",
+				Requested: capacity.Resources{CPU: 2048, Memory: 1024}, Since: time.Now().Add(-3 * time.Minute).UTC(),
+			},
+		},
+		Warnings: []string{
+			"node " + nodes[0].Name + " CPU utilization 88% exceeds 80%",
+			"1 pods cannot be scheduled",
+		},
+	}, nil
+}
+
+func (c *MockClient) GetKubeConfig(ctx context.Context, instanceName string) (*rest.Config, error) {
+	return nil, fmt.Errorf("exec is not supported by the mock client")
+}
+
 func (c *MockClient) Close() error {
 	return nil
 }
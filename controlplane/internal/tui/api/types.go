@@ -30,6 +30,8 @@ type Instance struct {
 	LocalStack bool      `json:"localStack"`
 	Traefik    bool      `json:"traefik"`
 	CreatedAt  time.Time `json:"createdAt"`
+	Source     string    `json:"source,omitempty"`   // k3d or port, see instance.SourceK3d
+	Endpoint   string    `json:"endpoint,omitempty"` // Base URL of the instance's AWS API
 }
 
 // CreateInstanceOptions contains options for creating a new instance
@@ -155,6 +157,8 @@ type ListServicesRequest struct {
 	MaxResults         *int    `json:"maxResults,omitempty"`
 	LaunchType         string  `json:"launchType,omitempty"`
 	SchedulingStrategy string  `json:"schedulingStrategy,omitempty"`
+	// Filter is the KECS filter expression, e.g. "tag:team=payments status=ACTIVE"
+	Filter string `json:"filter,omitempty"`
 }
 
 // ListServicesResponse represents the response from ListServices
@@ -372,3 +376,34 @@ type ELBv2ListenerAction struct {
 	Type           string `json:"Type"`
 	TargetGroupArn string `json:"TargetGroupArn,omitempty"`
 }
+
+// ELBv2Target identifies a target registered with a target group
+type ELBv2Target struct {
+	Id               string `json:"Id"`
+	Port             int32  `json:"Port,omitempty"`
+	AvailabilityZone string `json:"AvailabilityZone,omitempty"`
+}
+
+// ELBv2TargetHealth represents the health of a registered target
+type ELBv2TargetHealth struct {
+	Target          ELBv2Target `json:"Target"`
+	HealthCheckPort string      `json:"HealthCheckPort,omitempty"`
+	State           string      `json:"State"`
+	Reason          string      `json:"Reason,omitempty"`
+	Description     string      `json:"Description,omitempty"`
+}
+
+// ELBv2RuleCondition represents a condition of a listener rule
+type ELBv2RuleCondition struct {
+	Field  string   `json:"Field"`
+	Values []string `json:"Values,omitempty"`
+}
+
+// ELBv2Rule represents a listener rule from ELBv2 API
+type ELBv2Rule struct {
+	RuleArn    string                `json:"RuleArn"`
+	Priority   string                `json:"Priority"`
+	IsDefault  bool                  `json:"IsDefault"`
+	Conditions []ELBv2RuleCondition  `json:"Conditions,omitempty"`
+	Actions    []ELBv2ListenerAction `json:"Actions,omitempty"`
+}
@@ -1,11 +1,8 @@
 package tui
 
 import (
-	"context"
-	"encoding/json"
 	"fmt"
 	"io"
-	"strconv"
 	"strings"
 	"time"
 
@@ -78,6 +75,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.searchMode {
 			return m.handleSearchInput(msg)
 		}
+		if m.serviceFilterMode {
+			return m.handleServiceFilterInput(msg)
+		}
 		if m.commandMode {
 			return m.handleCommandInput(msg)
 		}
@@ -101,8 +101,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.handleServiceUpdateDialogKeys(msg)
 		}
 
+		// Handle task stop dialog
+		if m.taskStopDialog != nil {
+			return m.handleTaskStopDialogKeys(msg)
+		}
+
 		// Handle ELBv2 views first - they have custom key handling
-		if m.currentView == ViewLoadBalancers || m.currentView == ViewTargetGroups || m.currentView == ViewListeners {
+		if isELBv2View(m.currentView) {
 			if debugLogger := GetDebugLogger(); debugLogger != nil {
 				debugLogger.LogWithCaller("Update", "ELBv2 view detected (%s) - routing key '%s' directly to handleELBv2Keys", m.currentView.String(), keyStr)
 			}
@@ -114,6 +119,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		dialogActive := m.confirmDialog != nil ||
 			m.serviceScaleDialog != nil ||
 			m.serviceUpdateDialog != nil ||
+			m.taskStopDialog != nil ||
 			m.instanceForm != nil ||
 			m.clusterForm != nil ||
 			m.currentView == ViewConfirmDialog ||
@@ -202,8 +208,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tickMsg:
 		// Update data periodically
 		cmds = append(cmds, tickCmd())
+		if cmd := m.syncSubscription(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
 		if m.lastUpdate.Add(m.refreshInterval).Before(time.Time(msg)) {
 			cmds = append(cmds, m.loadMockDataCmd())
+			if m.currentView == ViewCapacity {
+				cmds = append(cmds, m.loadCapacityCmd())
+			}
 			m.lastUpdate = time.Time(msg)
 		}
 		// Check if command result should be cleared
@@ -211,6 +223,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.commandPalette.ShouldShowResult() // This will clear expired results
 		}
 
+	case subscribedMsg, subscribeFailedMsg, resourceEventMsg, subscriptionEndedMsg:
+		if cmd := m.handleSubscriptionMsg(msg); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+
 	case TaskDefinitionsFetchedMsg:
 		// Handle fetched task definitions and open dialog
 		// Show dialog even if there was an error (with fallback data)
@@ -224,8 +241,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Refresh services to show updated task definition
 			cmds = append(cmds, m.loadDataFromAPI())
 		} else {
-			// Show error (could add error dialog here)
 			m.err = msg.Error
+			m.showToast(fmt.Sprintf("Failed to update service: %v", msg.Error), true)
 		}
 
 	case ServiceScaledMsg:
@@ -233,13 +250,46 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.scalingInProgress = false
 
 		if msg.Success {
+			m.showToast(fmt.Sprintf("Scaled service %s to %d task(s)", msg.ServiceName, msg.DesiredCount), false)
 			// Refresh services to show updated count
 			cmds = append(cmds, m.loadDataFromAPI())
 		} else {
-			// Show error (could add error dialog here)
+			// Roll back the optimistic desired count
+			for i := range m.services {
+				if m.services[i].Name == msg.ServiceName {
+					m.services[i].Desired = msg.PreviousCount
+				}
+			}
 			m.err = msg.Error
+			m.showToast(fmt.Sprintf("Failed to scale service %s: %v", msg.ServiceName, msg.Error), true)
 		}
 
+	case serviceRestartingMsg:
+		m.setServiceStatus(msg.serviceName, "RESTARTING")
+
+	case ServiceRestartedMsg:
+		m, cmd = m.handleServiceRestarted(msg)
+		cmds = append(cmds, cmd)
+
+	case execSessionReadyMsg:
+		cmds = append(cmds, tea.Exec(msg.session, msg.session.finished))
+
+	case ExecFinishedMsg:
+		m, cmd = m.handleExecFinished(msg)
+		cmds = append(cmds, cmd)
+
+	case TargetDeregisteredMsg:
+		m, cmd = m.handleTargetDeregistered(msg)
+		cmds = append(cmds, cmd)
+
+	case RuleDeletedMsg:
+		m, cmd = m.handleRuleDeleted(msg)
+		cmds = append(cmds, cmd)
+
+	case TaskStoppedMsg:
+		m, cmd = m.handleTaskStopped(msg)
+		cmds = append(cmds, cmd)
+
 	case statusTickMsg:
 		// Update instance statuses periodically
 		cmds = append(cmds, statusTickCmd())
@@ -287,6 +337,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.services = msg.services
 		m.tasks = msg.tasks
 
+		if msg.serviceFilterErr != nil {
+			m.showToast(fmt.Sprintf("Invalid service filter: %v", msg.serviceFilterErr), true)
+		}
+
 		// Set ready flag when data is loaded (in addition to window size)
 		if !m.ready && m.width > 0 && m.height > 0 {
 			m.ready = true
@@ -416,51 +470,22 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case editorSaveRequestMsg:
-		// Handle editor save request - actually register the task definition
-		if m.apiClient != nil && m.selectedInstance != "" {
-			// Parse the JSON to get a proper task definition object
-			var taskDef map[string]interface{}
-			if err := json.Unmarshal([]byte(msg.jsonContent), &taskDef); err == nil {
-				// Call the API to register the task definition
-				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-				defer cancel()
-
-				taskDefArn, err := m.apiClient.RegisterTaskDefinition(ctx, m.selectedInstance, taskDef)
-				if err == nil {
-					// Extract revision number from ARN if possible
-					revision := 1
-					parts := strings.Split(taskDefArn, ":")
-					if len(parts) > 0 {
-						if r, err := strconv.Atoi(parts[len(parts)-1]); err == nil {
-							revision = r
-						}
-					}
-
-					// Send success message
-					return m, func() tea.Msg {
-						return editorSaveMsg{
-							family:   msg.family,
-							revision: revision,
-						}
-					}
-				} else {
-					// Handle error
-					m.err = fmt.Errorf("Failed to save task definition: %v", err)
-				}
-			} else {
-				m.err = fmt.Errorf("Failed to parse task definition JSON: %v", err)
-			}
+		// Register the edited task definition as a new revision
+		if m.selectedInstance != "" {
+			return m, m.registerTaskDefinitionCmd(msg.family, msg.jsonContent)
 		}
 
 	case editorSaveMsg:
-		// Handle successful save
-		m.commandPalette.lastResult = fmt.Sprintf("Task definition %s saved as revision %d", msg.family, msg.revision)
-		m.commandPalette.showResult = true
-		// Go back to revisions view
-		m.currentView = ViewTaskDefinitionRevisions
-		m.taskDefEditor = nil
-		// Reload revisions
-		cmds = append(cmds, m.loadTaskDefinitionRevisionsCmd())
+		return m.handleTaskDefinitionSaved(msg)
+
+	case externalEditorMsg:
+		// Pass the content edited in $EDITOR back to the editor
+		if m.taskDefEditor != nil {
+			m.taskDefEditor, _ = m.taskDefEditor.Update(msg)
+		}
+
+	case taskDefServicesUpdatedMsg:
+		return m.handleTaskDefServicesUpdated(msg)
 
 	case editorQuitMsg:
 		// Handle editor quit without saving
@@ -655,6 +680,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Reload clusters
 		cmds = append(cmds, m.loadDataFromAPI())
 
+	case capacityLoadedMsg:
+		m.handleCapacityLoaded(msg)
+
 	case elbv2DataLoadedMsg:
 		// Update ELBv2 data - always update even if empty (could be error or no resources)
 		m.loadBalancers = msg.loadBalancers
@@ -666,6 +694,37 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				len(m.loadBalancers), len(m.targetGroups), len(m.listeners))
 		}
 
+	case elbv2ListenersLoadedMsg:
+		if msg.loadBalancerARN == m.selectedLB {
+			m.listeners = msg.listeners
+			if m.listenerCursor >= len(m.listeners) {
+				m.listenerCursor = max(len(m.listeners)-1, 0)
+			}
+		}
+
+	case elbv2TargetsLoadedMsg:
+		// Ignore responses for a target group that is no longer selected
+		if msg.targetGroupARN == m.selectedTG {
+			if msg.err != nil {
+				m.err = msg.err
+			}
+			m.targets = msg.targets
+			if m.targetCursor >= len(m.targets) {
+				m.targetCursor = max(len(m.targets)-1, 0)
+			}
+		}
+
+	case elbv2RulesLoadedMsg:
+		if msg.listenerARN == m.selectedListener {
+			if msg.err != nil {
+				m.err = msg.err
+			}
+			m.listenerRules = msg.rules
+			if m.ruleCursor >= len(m.listenerRules) {
+				m.ruleCursor = max(len(m.listenerRules)-1, 0)
+			}
+		}
+
 	case errMsg:
 		// Handle API errors
 		m.err = msg.err
@@ -730,7 +789,7 @@ func (m Model) View() string {
 	}
 
 	// For ELBv2 views, use the special ELBv2 rendering
-	if m.currentView == ViewLoadBalancers || m.currentView == ViewTargetGroups || m.currentView == ViewListeners {
+	if isELBv2View(m.currentView) {
 		return m.renderELBv2View()
 	}
 
@@ -754,9 +813,9 @@ func (m Model) View() string {
 		return m.renderServiceUpdateDialog()
 	}
 
-	// Scaling progress overlay
-	if m.scalingInProgress {
-		return m.renderScalingProgress()
+	// Task stop dialog
+	if m.taskStopDialog != nil {
+		return m.renderTaskStopDialog()
 	}
 
 	// Updating progress overlay
@@ -1219,21 +1278,7 @@ func (m Model) handleTasksKeys(msg tea.KeyMsg) (Model, tea.Cmd) {
 		// Stop selected task
 		if len(m.tasks) > 0 && m.taskCursor < len(m.tasks) {
 			task := m.tasks[m.taskCursor]
-			// Create stop task confirmation dialog
-			m.confirmDialog = StopTaskDialog(
-				task.ID,
-				func() error {
-					// This will be executed when user confirms
-					return nil
-				},
-				func() {
-					// This will be executed when user cancels
-				},
-			)
-			// Store the command to execute after confirmation
-			m.pendingCommand = m.stopTaskCmd(task.ARN)
-			m.previousView = m.currentView
-			m.currentView = ViewConfirmDialog
+			m.taskStopDialog = NewTaskStopDialog(task.ID, task.ARN)
 		}
 	case "/":
 		m.searchMode = true
@@ -1342,6 +1387,31 @@ func (m Model) handleSearchInput(msg tea.KeyMsg) (Model, tea.Cmd) {
 	return m, nil
 }
 
+// handleServiceFilterInput handles input while editing the filter of the
+// services view. Unlike search, the filter is applied by the instance when
+// services are reloaded.
+func (m Model) handleServiceFilterInput(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		m.serviceFilterMode = false
+		m.serviceFilter = strings.TrimSpace(m.serviceFilterInput)
+		m.serviceCursor = 0
+		return m, m.loadDataFromAPI()
+	case "esc":
+		m.serviceFilterMode = false
+		m.serviceFilterInput = ""
+	case "backspace":
+		if len(m.serviceFilterInput) > 0 {
+			m.serviceFilterInput = m.serviceFilterInput[:len(m.serviceFilterInput)-1]
+		}
+	default:
+		if len(msg.String()) == 1 || msg.String() == " " {
+			m.serviceFilterInput += msg.String()
+		}
+	}
+	return m, nil
+}
+
 func (m Model) handleCommandInput(msg tea.KeyMsg) (Model, tea.Cmd) {
 	switch msg.String() {
 	case "enter":
@@ -1843,8 +1913,7 @@ func (m Model) handleTaskDefinitionRevisionsKeys(msg tea.KeyMsg) (Model, tea.Cmd
 				}
 			} else {
 				// Load from API
-				taskDefArn := fmt.Sprintf("arn:aws:ecs:us-east-1:123456789012:task-definition/%s:%d",
-					selectedRev.Family, selectedRev.Revision)
+				taskDefArn := fmt.Sprintf("%s:%d", selectedRev.Family, selectedRev.Revision)
 				return m, m.loadTaskDefinitionJSONCmd(taskDefArn)
 			}
 		}
@@ -0,0 +1,161 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/capacity"
+)
+
+// capacityBarWidth is the width of the utilization bars of the capacity view
+const capacityBarWidth = 20
+
+// capacityLoadedMsg carries the capacity report of an instance
+type capacityLoadedMsg struct {
+	instance string
+	report   *capacity.Report
+	err      error
+}
+
+// loadCapacityCmd loads the capacity report of the selected instance
+func (m Model) loadCapacityCmd() tea.Cmd {
+	instanceName := m.selectedInstance
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		report, err := m.apiClient.GetCapacity(ctx, instanceName)
+		return capacityLoadedMsg{instance: instanceName, report: report, err: err}
+	}
+}
+
+// handleCapacityLoaded keeps the report of the selected instance
+func (m *Model) handleCapacityLoaded(msg capacityLoadedMsg) {
+	if msg.instance != m.selectedInstance {
+		return
+	}
+	m.capacity = msg.report
+	m.capacityErr = msg.err
+}
+
+// utilizationColor returns the color of a utilization: red above the
+// threshold, yellow within 10 points of it, green otherwise
+func utilizationColor(utilization, threshold float64) lipgloss.Color {
+	switch {
+	case threshold > 0 && utilization > threshold:
+		return lipgloss.Color("#ff5555")
+	case threshold > 10 && utilization > threshold-10:
+		return lipgloss.Color("#f1fa8c")
+	default:
+		return lipgloss.Color("#50fa7b")
+	}
+}
+
+// renderUtilizationBar renders a utilization in percent as a bar
+func renderUtilizationBar(utilization, threshold float64) string {
+	filled := int(utilization / 100 * capacityBarWidth)
+	filled = min(max(filled, 0), capacityBarWidth)
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", capacityBarWidth-filled)
+	return lipgloss.NewStyle().
+		Foreground(utilizationColor(utilization, threshold)).
+		Render(fmt.Sprintf("%s %5.1f%%", bar, utilization))
+}
+
+// formatCapacityResources formats CPU units as vCPU and MiB as GiB
+func formatCapacityResources(r capacity.Resources) string {
+	return fmt.Sprintf("%.2f vCPU / %.1f GiB", float64(r.CPU)/1024, float64(r.Memory)/1024)
+}
+
+// renderCapacityView renders the capacity of the selected instance: its
+// warnings, the utilization of the instance and each node, what each
+// cluster requests and the pods waiting for capacity
+func (m Model) renderCapacityView(height int) string {
+	emptyStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#718096")).
+		Align(lipgloss.Center, lipgloss.Center).
+		Width(m.width-8).
+		Height(height).
+		Padding(1, 2)
+	if m.capacityErr != nil {
+		return emptyStyle.Render(fmt.Sprintf("Failed to load capacity: %v", m.capacityErr))
+	}
+	if m.capacity == nil {
+		return emptyStyle.Render("Loading capacity...")
+	}
+
+	report := m.capacity
+	headerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#a0aec0")).Bold(true)
+	warningStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#ff5555"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#718096"))
+
+	var lines []string
+	for _, warning := range report.Warnings {
+		lines = append(lines, warningStyle.Render("⚠ "+warning))
+	}
+	if len(report.Warnings) > 0 {
+		lines = append(lines, "")
+	}
+
+	lines = append(lines,
+		headerStyle.Render("INSTANCE"),
+		fmt.Sprintf("  CPU     %s  %s requested of %s", renderUtilizationBar(report.Utilization.CPU, report.Thresholds.CPU),
+			fmt.Sprintf("%.2f", float64(report.Requested.CPU)/1024), fmt.Sprintf("%.2f vCPU", float64(report.Allocatable.CPU)/1024)),
+		fmt.Sprintf("  Memory  %s  %s requested of %s", renderUtilizationBar(report.Utilization.Memory, report.Thresholds.Memory),
+			fmt.Sprintf("%.1f", float64(report.Requested.Memory)/1024), fmt.Sprintf("%.1f GiB", float64(report.Allocatable.Memory)/1024)),
+		fmt.Sprintf("  Tasks   %d running, %.1f per node, %d pending", report.Tasks, report.TaskDensity, len(report.PendingPods)),
+		"",
+	)
+
+	lines = append(lines, headerStyle.Render(fmt.Sprintf("  %-32s %-6s %-28s %-28s %-6s %s", "NODE", "ARCH", "CPU", "MEMORY", "TASKS", "AVAILABLE")))
+	for _, node := range report.Nodes {
+		name := node.Name
+		if !node.Ready {
+			name += " (NotReady)"
+		} else if !node.Schedulable {
+			name += " (Cordoned)"
+		}
+		lines = append(lines, fmt.Sprintf("  %-32s %-6s %s  %s  %-6d %s",
+			truncate(name, 32), node.Architecture,
+			renderUtilizationBar(node.Utilization.CPU, report.Thresholds.CPU),
+			renderUtilizationBar(node.Utilization.Memory, report.Thresholds.Memory),
+			node.Tasks, formatCapacityResources(node.Available)))
+	}
+	lines = append(lines, "")
+
+	lines = append(lines, headerStyle.Render(fmt.Sprintf("  %-32s %-28s %-8s %s", "CLUSTER", "REQUESTED", "TASKS", "PENDING")))
+	if len(report.Clusters) == 0 {
+		lines = append(lines, dimStyle.Render("  No tasks running"))
+	}
+	for _, cluster := range report.Clusters {
+		lines = append(lines, fmt.Sprintf("  %-32s %-28s %-8d %d",
+			truncate(cluster.Cluster, 32), formatCapacityResources(cluster.Requested), cluster.Tasks, cluster.PendingTasks))
+	}
+
+	if len(report.PendingPods) > 0 {
+		lines = append(lines, "", headerStyle.Render(fmt.Sprintf("  %-32s %-16s %-16s %s", "PENDING POD", "CLUSTER", "REASON", "MESSAGE")))
+		for _, pod := range report.PendingPods {
+			lines = append(lines, fmt.Sprintf("  %-32s %-16s %-16s %s",
+				truncate(pod.Name, 32), truncate(pod.Cluster, 16), truncate(pod.Reason, 16), pod.Message))
+		}
+	}
+
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// capacitySummary returns the summary line of the capacity view
+func (m Model) capacitySummary() string {
+	if m.capacity == nil {
+		return fmt.Sprintf("Instance: %s | Capacity", m.selectedInstance)
+	}
+	return fmt.Sprintf("Instance: %s | Nodes: %d | CPU: %.0f%% | Memory: %.0f%% | Pending: %d | Warnings: %d",
+		m.selectedInstance, len(m.capacity.Nodes), m.capacity.Utilization.CPU, m.capacity.Utilization.Memory,
+		len(m.capacity.PendingPods), len(m.capacity.Warnings))
+}
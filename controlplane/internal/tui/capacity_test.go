@@ -0,0 +1,76 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/tui/api"
+)
+
+func TestCapacityView(t *testing.T) {
+	t.Run("loads the capacity of the selected instance", func(t *testing.T) {
+		m := NewModelWithClient(api.NewMockClient())
+		m.selectedInstance = "dev"
+		m.currentView = ViewClusters
+
+		m, cmd := m.executeAction(ActionNavigateCapacity)
+		if m.currentView != ViewCapacity || cmd == nil {
+			t.Fatalf("expected capacity view with a load command, got %s", m.currentView)
+		}
+
+		updated, _ := m.Update(cmd())
+		m = updated.(Model)
+		if m.capacity == nil || len(m.capacity.Nodes) != 2 {
+			t.Fatalf("expected capacity of 2 nodes, got %+v", m.capacity)
+		}
+
+		m, _ = m.executeAction(ActionNavigateClusters)
+		if m.currentView != ViewClusters {
+			t.Errorf("expected clusters view, got %s", m.currentView)
+		}
+	})
+
+	t.Run("ignores the capacity of another instance", func(t *testing.T) {
+		m := NewModelWithClient(api.NewMockClient())
+		m.selectedInstance = "dev"
+		cmd := m.loadCapacityCmd()
+
+		m.selectedInstance = "staging"
+		updated, _ := m.Update(cmd())
+		if updated.(Model).capacity != nil {
+			t.Error("expected the capacity of dev to be ignored")
+		}
+	})
+
+	t.Run("renders warnings and pending pods", func(t *testing.T) {
+		m := NewModelWithClient(api.NewMockClient())
+		m.selectedInstance = "dev"
+		m.width = 160
+		msg := m.loadCapacityCmd()().(capacityLoadedMsg)
+		m.handleCapacityLoaded(msg)
+
+		view := m.renderCapacityView(40)
+		for _, want := range append(msg.report.Warnings, "k3d-kecs-dev-agent-0", "staging", "worker-7f9c") {
+			if !strings.Contains(view, want) {
+				t.Errorf("expected capacity view to contain %q", want)
+			}
+		}
+	})
+}
+
+func TestUtilizationColor(t *testing.T) {
+	tests := []struct {
+		utilization float64
+		want        string
+	}{
+		{utilization: 50, want: "#50fa7b"},
+		{utilization: 75, want: "#f1fa8c"},
+		{utilization: 90, want: "#ff5555"},
+	}
+
+	for _, tt := range tests {
+		if got := utilizationColor(tt.utilization, 80); string(got) != tt.want {
+			t.Errorf("utilizationColor(%v, 80) = %s, want %s", tt.utilization, got, tt.want)
+		}
+	}
+}
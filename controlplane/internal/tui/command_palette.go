@@ -229,7 +229,7 @@ func (cp *CommandPalette) initCommands() {
 				return "Navigated to load balancers", nil
 			},
 			Available: func(m *Model) bool {
-				return m.selectedInstance != "" && (m.currentView == ViewClusters || m.currentView == ViewTargetGroups || m.currentView == ViewListeners)
+				return m.selectedInstance != "" && (m.currentView == ViewClusters || m.currentView == ViewTargetGroups || m.currentView == ViewListeners || m.currentView == ViewTargets || m.currentView == ViewListenerRules)
 			},
 		},
 		{
@@ -246,7 +246,24 @@ func (cp *CommandPalette) initCommands() {
 				return "Navigated to target groups", nil
 			},
 			Available: func(m *Model) bool {
-				return m.selectedInstance != "" && (m.currentView == ViewClusters || m.currentView == ViewLoadBalancers || m.currentView == ViewListeners)
+				return m.selectedInstance != "" && (m.currentView == ViewClusters || m.currentView == ViewLoadBalancers || m.currentView == ViewListeners || m.currentView == ViewTargets || m.currentView == ViewListenerRules)
+			},
+		},
+		{
+			Name:        "goto capacity",
+			Description: "Navigate to node capacity view",
+			Category:    CommandCategoryNavigation,
+			Shortcut:    "p",
+			Aliases:     []string{"capacity", "cap", "nodes"},
+			Handler: func(m *Model) (string, error) {
+				if m.selectedInstance == "" {
+					return "", fmt.Errorf("no instance selected")
+				}
+				m.currentView = ViewCapacity
+				return "Navigated to capacity", nil
+			},
+			Available: func(m *Model) bool {
+				return m.selectedInstance != "" && m.currentView == ViewClusters
 			},
 		},
 		{
@@ -539,7 +556,7 @@ func (cp *CommandPalette) initCommands() {
 					return "", fmt.Errorf("not in tasks view")
 				}
 				if len(m.tasks) > 0 && m.taskCursor < len(m.tasks) {
-					return fmt.Sprintf("Opening shell in task %s", m.tasks[m.taskCursor].ID), nil
+					return fmt.Sprintf("Press x to open a shell in task %s", m.tasks[m.taskCursor].ID), nil
 				}
 				return "", fmt.Errorf("no task selected")
 			},
@@ -58,6 +58,8 @@ func (m Model) loadDataFromAPI() tea.Cmd {
 				LocalStack: inst.LocalStack,
 				Traefik:    inst.Traefik,
 				Age:        time.Since(inst.CreatedAt),
+				Source:     inst.Source,
+				Endpoint:   inst.Endpoint,
 			}
 		}
 
@@ -87,8 +89,12 @@ func (m Model) loadDataFromAPI() tea.Cmd {
 
 		// Load services if cluster is selected
 		var tuiServices []Service
+		var serviceFilterErr error
 		if m.selectedInstance != "" && m.selectedCluster != "" {
-			serviceArns, err := m.apiClient.ListServices(ctx, m.selectedInstance, m.selectedCluster)
+			serviceArns, err := m.apiClient.ListServices(ctx, m.selectedInstance, m.selectedCluster, m.serviceFilter)
+			if err != nil && m.serviceFilter != "" {
+				serviceFilterErr = err
+			}
 			if err == nil && len(serviceArns) > 0 {
 				services, err := m.apiClient.DescribeServices(ctx, m.selectedInstance, m.selectedCluster, serviceArns)
 				if err == nil {
@@ -177,6 +183,8 @@ func (m Model) loadDataFromAPI() tea.Cmd {
 			clusters:  tuiClusters,
 			services:  tuiServices,
 			tasks:     tuiTasks,
+
+			serviceFilterErr: serviceFilterErr,
 		}
 	}
 }
@@ -376,6 +384,9 @@ type dataLoadedMsg struct {
 	clusters  []Cluster
 	services  []Service
 	tasks     []Task
+	// serviceFilterErr is the error of listing services with the filter of
+	// the services view, e.g. an invalid expression
+	serviceFilterErr error
 }
 
 type logsLoadedMsg struct {
@@ -513,6 +524,8 @@ func (m Model) updateInstanceStatusCmd() tea.Cmd {
 				LocalStack: inst.LocalStack,
 				Traefik:    inst.Traefik,
 				Age:        time.Since(inst.CreatedAt),
+				Source:     inst.Source,
+				Endpoint:   inst.Endpoint,
 			}
 		}
 
@@ -714,23 +727,6 @@ type logViewerCreatedMsg struct {
 	container string
 }
 
-// stopTaskCmd stops a running task
-func (m Model) stopTaskCmd(taskArn string) tea.Cmd {
-	return func() tea.Msg {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-
-		// Call StopTask API with a reason
-		err := m.apiClient.StopTask(ctx, m.selectedInstance, m.selectedCluster, taskArn, "User requested stop via TUI")
-		if err != nil {
-			return errMsg{err: fmt.Errorf("failed to stop task: %w", err)}
-		}
-
-		// Return a message to trigger data refresh
-		return refreshInstancesMsg{}
-	}
-}
-
 // deleteInstanceCmd initiates instance deletion
 func (m Model) deleteInstanceCmd(instanceName string) tea.Cmd {
 	return func() tea.Msg {
@@ -39,7 +39,9 @@ func LoadConfig() Config {
 	return cfg
 }
 
-// CreateAPIClient creates an API client based on configuration
+// CreateAPIClient creates an API client based on configuration. The client
+// watches the selected instance through its Connect API and falls back to
+// polling the ECS API for instances that do not serve it.
 func CreateAPIClient(cfg Config) api.Client {
-	return api.NewHTTPClient(cfg.APIEndpoint)
+	return api.NewConnectClient(cfg.APIEndpoint)
 }
@@ -0,0 +1,172 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/tui/api"
+)
+
+// TargetDeregisteredMsg is sent when a target deregistration request completes
+type TargetDeregisteredMsg struct {
+	TargetGroupARN string
+	TargetID       string
+	Error          error
+}
+
+// RuleDeletedMsg is sent when a rule deletion request completes
+type RuleDeletedMsg struct {
+	ListenerARN string
+	RuleARN     string
+	Error       error
+}
+
+// DeregisterTargetDialog creates a confirmation dialog for deregistering a target
+func DeregisterTargetDialog(targetID, targetGroupName string, onDeregister func() error, onCancel func()) *ConfirmDialog {
+	title := "Deregister Target"
+	message := fmt.Sprintf("Deregister target '%s' from target group '%s'?\nThe target stops receiving traffic.", targetID, targetGroupName)
+	return NewConfirmDialog(title, message, onDeregister, onCancel)
+}
+
+// DeleteRuleDialog creates a confirmation dialog for deleting a listener rule
+func DeleteRuleDialog(priority string, onDelete func() error, onCancel func()) *ConfirmDialog {
+	title := "Delete Rule"
+	message := fmt.Sprintf("Delete the listener rule with priority %s?\nThis action cannot be undone.", priority)
+	return NewConfirmDialog(title, message, onDelete, onCancel)
+}
+
+// openTargetHealth drills down into the targets of the selected target group
+func (m Model) openTargetHealth() (Model, tea.Cmd) {
+	if len(m.targetGroups) == 0 || m.tgCursor >= len(m.targetGroups) {
+		return m, nil
+	}
+	m.selectedTG = m.targetGroups[m.tgCursor].ARN
+	m.targets = nil
+	m.targetCursor = 0
+	m.currentView = ViewTargets
+	return m, m.loadTargetHealthCmd(m.selectedTG)
+}
+
+// openListenerRules drills down into the rules of the selected listener
+func (m Model) openListenerRules() (Model, tea.Cmd) {
+	if len(m.listeners) == 0 || m.listenerCursor >= len(m.listeners) {
+		return m, nil
+	}
+	m.selectedListener = m.listeners[m.listenerCursor].ARN
+	m.listenerRules = nil
+	m.ruleCursor = 0
+	m.currentView = ViewListenerRules
+	return m, m.loadListenerRulesCmd(m.selectedListener)
+}
+
+// confirmDeregisterTarget opens the confirmation dialog for deregistering the selected target
+func (m Model) confirmDeregisterTarget() (Model, tea.Cmd) {
+	if len(m.targets) == 0 || m.targetCursor >= len(m.targets) {
+		return m, nil
+	}
+	target := m.targets[m.targetCursor]
+
+	m.confirmDialog = DeregisterTargetDialog(target.ID, m.targetGroupName(m.selectedTG), func() error { return nil }, func() {})
+	m.pendingCommand = m.deregisterTargetCmd(m.selectedTG, target)
+	m.previousView = m.currentView
+	m.currentView = ViewConfirmDialog
+	return m, nil
+}
+
+// confirmDeleteRule opens the confirmation dialog for deleting the selected rule.
+// The default rule belongs to the listener and cannot be deleted.
+func (m Model) confirmDeleteRule() (Model, tea.Cmd) {
+	if len(m.listenerRules) == 0 || m.ruleCursor >= len(m.listenerRules) {
+		return m, nil
+	}
+	rule := m.listenerRules[m.ruleCursor]
+	if rule.IsDefault {
+		m.showToast("The default rule cannot be deleted", true)
+		return m, nil
+	}
+
+	m.confirmDialog = DeleteRuleDialog(rule.Priority, func() error { return nil }, func() {})
+	m.pendingCommand = m.deleteRuleCmd(m.selectedListener, rule.ARN)
+	m.previousView = m.currentView
+	m.currentView = ViewConfirmDialog
+	return m, nil
+}
+
+// deregisterTargetCmd deregisters a target from a target group
+func (m Model) deregisterTargetCmd(targetGroupARN string, target Target) tea.Cmd {
+	instanceName := m.selectedInstance
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		err := m.apiClient.DeregisterTargets(ctx, instanceName, targetGroupARN, []api.ELBv2Target{{
+			Id:               target.ID,
+			Port:             int32(target.Port),
+			AvailabilityZone: target.AvailabilityZone,
+		}})
+		if err != nil {
+			err = fmt.Errorf("failed to deregister target: %w", err)
+		}
+		return TargetDeregisteredMsg{
+			TargetGroupARN: targetGroupARN,
+			TargetID:       target.ID,
+			Error:          err,
+		}
+	}
+}
+
+// deleteRuleCmd deletes a listener rule
+func (m Model) deleteRuleCmd(listenerARN, ruleARN string) tea.Cmd {
+	instanceName := m.selectedInstance
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		err := m.apiClient.DeleteRule(ctx, instanceName, ruleARN)
+		if err != nil {
+			err = fmt.Errorf("failed to delete rule: %w", err)
+		}
+		return RuleDeletedMsg{
+			ListenerARN: listenerARN,
+			RuleARN:     ruleARN,
+			Error:       err,
+		}
+	}
+}
+
+// handleTargetDeregistered applies the outcome of a target deregistration
+func (m Model) handleTargetDeregistered(msg TargetDeregisteredMsg) (Model, tea.Cmd) {
+	if msg.Error != nil {
+		m.err = msg.Error
+		m.showToast(msg.Error.Error(), true)
+		return m, nil
+	}
+
+	m.showToast(fmt.Sprintf("Deregistered target %s", msg.TargetID), false)
+	return m, m.loadTargetHealthCmd(msg.TargetGroupARN)
+}
+
+// handleRuleDeleted applies the outcome of a rule deletion
+func (m Model) handleRuleDeleted(msg RuleDeletedMsg) (Model, tea.Cmd) {
+	if msg.Error != nil {
+		m.err = msg.Error
+		m.showToast(msg.Error.Error(), true)
+		return m, nil
+	}
+
+	m.showToast("Deleted listener rule", false)
+	return m, m.loadListenerRulesCmd(msg.ListenerARN)
+}
+
+// targetGroupName returns the name of a loaded target group, or its ARN if unknown
+func (m Model) targetGroupName(targetGroupARN string) string {
+	for _, tg := range m.targetGroups {
+		if tg.ARN == targetGroupARN {
+			return tg.Name
+		}
+	}
+	return targetGroupARN
+}
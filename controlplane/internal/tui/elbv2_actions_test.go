@@ -0,0 +1,170 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/tui/api"
+)
+
+const (
+	testTargetGroupARN = "arn:aws:elasticloadbalancing:us-east-1:000000000000:targetgroup/web/73e2d6bc24d8a067"
+	testListenerARN    = "arn:aws:elasticloadbalancing:us-east-1:000000000000:listener/app/web/50dc6c495c0c9188/f2f7dc8efc522ab2"
+)
+
+// elbv2Client records the calls made by ELBv2 actions
+type elbv2Client struct {
+	api.Client
+	err          error
+	deregistered []api.ELBv2Target
+	deletedRule  string
+}
+
+func (c *elbv2Client) DescribeTargetHealth(ctx context.Context, instanceName, targetGroupArn string) ([]api.ELBv2TargetHealth, error) {
+	return []api.ELBv2TargetHealth{
+		{Target: api.ELBv2Target{Id: "10.42.0.12", Port: 8080}, State: "healthy"},
+		{Target: api.ELBv2Target{Id: "10.42.0.13", Port: 8080}, State: "unhealthy", Reason: "Target.Timeout"},
+	}, nil
+}
+
+func (c *elbv2Client) DeregisterTargets(ctx context.Context, instanceName, targetGroupArn string, targets []api.ELBv2Target) error {
+	c.deregistered = targets
+	return c.err
+}
+
+func (c *elbv2Client) ListRules(ctx context.Context, instanceName, listenerArn string) ([]api.ELBv2Rule, error) {
+	return []api.ELBv2Rule{
+		{
+			RuleArn:    listenerArn + "/rule/1",
+			Priority:   "10",
+			Conditions: []api.ELBv2RuleCondition{{Field: "path-pattern", Values: []string{"/api/*", "/v2/*"}}},
+			Actions:    []api.ELBv2ListenerAction{{Type: "forward", TargetGroupArn: testTargetGroupARN}},
+		},
+		{RuleArn: listenerArn + "/rule/default", Priority: "default", IsDefault: true},
+	}, nil
+}
+
+func (c *elbv2Client) DeleteRule(ctx context.Context, instanceName, ruleArn string) error {
+	c.deletedRule = ruleArn
+	return c.err
+}
+
+func newELBv2Model(client api.Client) Model {
+	m := NewModelWithClient(client)
+	m.selectedInstance = "dev"
+	m.targetGroups = []TargetGroup{{ARN: testTargetGroupARN, Name: "web"}}
+	m.listeners = []Listener{{ARN: testListenerARN, Port: 80, Protocol: "HTTP"}}
+	return m
+}
+
+func TestTargetHealthDrillDown(t *testing.T) {
+	m := newELBv2Model(&elbv2Client{})
+	m.currentView = ViewTargetGroups
+
+	m, cmd := m.handleELBv2Keys(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.currentView != ViewTargets || m.selectedTG != testTargetGroupARN {
+		t.Fatalf("expected targets view of %s, got %s", testTargetGroupARN, m.currentView)
+	}
+
+	updated, _ := m.Update(cmd())
+	m = updated.(Model)
+	if len(m.targets) != 2 {
+		t.Fatalf("expected 2 targets, got %d", len(m.targets))
+	}
+	if m.targets[1].State != "unhealthy" || m.targets[1].Reason != "Target.Timeout" {
+		t.Errorf("unexpected target health %+v", m.targets[1])
+	}
+
+	m, _ = m.handleELBv2Keys(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.currentView != ViewTargetGroups {
+		t.Errorf("expected esc to go back to target groups, got %s", m.currentView)
+	}
+}
+
+func TestDeregisterTarget(t *testing.T) {
+	t.Run("deregisters the selected target after confirmation", func(t *testing.T) {
+		client := &elbv2Client{}
+		m := newELBv2Model(client)
+		m.currentView = ViewTargets
+		m.selectedTG = testTargetGroupARN
+		m.targets = []Target{{ID: "10.42.0.12", Port: 8080}, {ID: "10.42.0.13", Port: 8080}}
+		m.targetCursor = 1
+
+		m, _ = m.handleELBv2Keys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+		if m.currentView != ViewConfirmDialog || m.pendingCommand == nil {
+			t.Fatal("expected deregister confirmation dialog")
+		}
+
+		msg := m.pendingCommand()
+		if len(client.deregistered) != 1 || client.deregistered[0].Id != "10.42.0.13" || client.deregistered[0].Port != 8080 {
+			t.Errorf("unexpected deregistered targets %+v", client.deregistered)
+		}
+		m, cmd := m.handleTargetDeregistered(msg.(TargetDeregisteredMsg))
+		if m.toastIsError || cmd == nil {
+			t.Errorf("expected success toast and target reload, got %q", m.toastMessage)
+		}
+	})
+
+	t.Run("shows an error toast when deregistration fails", func(t *testing.T) {
+		m := newELBv2Model(&elbv2Client{err: errors.New("TargetGroupNotFound")})
+		msg := m.deregisterTargetCmd(testTargetGroupARN, Target{ID: "10.42.0.12"})()
+
+		m, _ = m.handleTargetDeregistered(msg.(TargetDeregisteredMsg))
+		if !m.toastIsError {
+			t.Errorf("expected error toast, got %q", m.toastMessage)
+		}
+	})
+}
+
+func TestListenerRules(t *testing.T) {
+	t.Run("loads the rules of the selected listener", func(t *testing.T) {
+		m := newELBv2Model(&elbv2Client{})
+		m.currentView = ViewListeners
+
+		m, cmd := m.handleELBv2Keys(tea.KeyMsg{Type: tea.KeyEnter})
+		if m.currentView != ViewListenerRules || m.selectedListener != testListenerARN {
+			t.Fatalf("expected rules view of %s, got %s", testListenerARN, m.currentView)
+		}
+
+		updated, _ := m.Update(cmd())
+		m = updated.(Model)
+		if len(m.listenerRules) != 2 {
+			t.Fatalf("expected 2 rules, got %d", len(m.listenerRules))
+		}
+		if got := m.listenerRules[0].Conditions[0]; got != "path-pattern=/api/*,/v2/*" {
+			t.Errorf("unexpected condition %q", got)
+		}
+	})
+
+	t.Run("deletes the selected rule after confirmation", func(t *testing.T) {
+		client := &elbv2Client{}
+		m := newELBv2Model(client)
+		m.currentView = ViewListenerRules
+		m.selectedListener = testListenerARN
+		m.listenerRules = []ListenerRule{{ARN: testListenerARN + "/rule/1", Priority: "10"}}
+
+		m, _ = m.handleELBv2Keys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+		if m.currentView != ViewConfirmDialog || m.pendingCommand == nil {
+			t.Fatal("expected delete confirmation dialog")
+		}
+		m.pendingCommand()
+		if client.deletedRule != testListenerARN+"/rule/1" {
+			t.Errorf("expected rule to be deleted, got %q", client.deletedRule)
+		}
+	})
+
+	t.Run("refuses to delete the default rule", func(t *testing.T) {
+		m := newELBv2Model(&elbv2Client{})
+		m.currentView = ViewListenerRules
+		m.selectedListener = testListenerARN
+		m.listenerRules = []ListenerRule{{ARN: testListenerARN + "/rule/default", Priority: "default", IsDefault: true}}
+
+		m, _ = m.handleELBv2Keys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+		if m.confirmDialog != nil || !m.toastIsError {
+			t.Error("expected the default rule to be protected")
+		}
+	})
+}
@@ -3,9 +3,12 @@ package tui
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/tui/api"
 )
 
 // ELBv2 data loaded messages
@@ -15,6 +18,35 @@ type elbv2DataLoadedMsg struct {
 	listeners     []Listener
 }
 
+// elbv2ListenersLoadedMsg carries the listeners of a load balancer
+type elbv2ListenersLoadedMsg struct {
+	loadBalancerARN string
+	listeners       []Listener
+}
+
+// elbv2TargetsLoadedMsg carries the targets of a target group
+type elbv2TargetsLoadedMsg struct {
+	targetGroupARN string
+	targets        []Target
+	err            error
+}
+
+// elbv2RulesLoadedMsg carries the rules of a listener
+type elbv2RulesLoadedMsg struct {
+	listenerARN string
+	rules       []ListenerRule
+	err         error
+}
+
+// isELBv2View reports whether the view is one of the ELBv2 resource views
+func isELBv2View(view ViewType) bool {
+	switch view {
+	case ViewLoadBalancers, ViewTargetGroups, ViewListeners, ViewTargets, ViewListenerRules:
+		return true
+	}
+	return false
+}
+
 // loadELBv2DataCmd loads ELBv2 resources from the API
 func (m Model) loadELBv2DataCmd() tea.Cmd {
 	return func() tea.Msg {
@@ -78,27 +110,15 @@ func (m Model) loadELBv2DataCmd() tea.Cmd {
 			}
 		}
 
-		// Load listeners for the first load balancer (if any)
-		if len(loadBalancers) > 0 {
-			lsts, err := m.apiClient.ListListeners(ctx, m.selectedInstance, loadBalancers[0].ARN)
+		// Load listeners for the selected load balancer, or the first one
+		lbARN := m.selectedLB
+		if lbARN == "" && len(loadBalancers) > 0 {
+			lbARN = loadBalancers[0].ARN
+		}
+		if lbARN != "" {
+			lsts, err := m.apiClient.ListListeners(ctx, m.selectedInstance, lbARN)
 			if err == nil {
-				for _, lst := range lsts {
-					var actions []ListenerAction
-					for _, act := range lst.DefaultActions {
-						actions = append(actions, ListenerAction{
-							Type:           act.Type,
-							TargetGroupArn: act.TargetGroupArn,
-						})
-					}
-					listeners = append(listeners, Listener{
-						ARN:             lst.ListenerArn,
-						LoadBalancerARN: lst.LoadBalancerArn,
-						Port:            int(lst.Port),
-						Protocol:        lst.Protocol,
-						DefaultActions:  actions,
-						RuleCount:       0, // TODO: fetch rule count
-					})
-				}
+				listeners = m.toListeners(ctx, lsts)
 			}
 		}
 
@@ -119,31 +139,132 @@ func (m Model) loadListenersForLBCmd(loadBalancerARN string) tea.Cmd {
 		var listeners []Listener
 		lsts, err := m.apiClient.ListListeners(ctx, m.selectedInstance, loadBalancerARN)
 		if err == nil {
-			for _, lst := range lsts {
-				var actions []ListenerAction
-				for _, act := range lst.DefaultActions {
-					actions = append(actions, ListenerAction{
-						Type:           act.Type,
-						TargetGroupArn: act.TargetGroupArn,
-					})
+			listeners = m.toListeners(ctx, lsts)
+		}
+
+		return elbv2ListenersLoadedMsg{
+			loadBalancerARN: loadBalancerARN,
+			listeners:       listeners,
+		}
+	}
+}
+
+// toListeners converts API listeners, counting the non-default rules of each
+func (m Model) toListeners(ctx context.Context, lsts []api.ELBv2Listener) []Listener {
+	var listeners []Listener
+	for _, lst := range lsts {
+		var actions []ListenerAction
+		for _, act := range lst.DefaultActions {
+			actions = append(actions, ListenerAction{
+				Type:           act.Type,
+				TargetGroupArn: act.TargetGroupArn,
+			})
+		}
+
+		ruleCount := 0
+		if rules, err := m.apiClient.ListRules(ctx, m.selectedInstance, lst.ListenerArn); err == nil {
+			for _, rule := range rules {
+				if !rule.IsDefault {
+					ruleCount++
 				}
-				listeners = append(listeners, Listener{
-					ARN:             lst.ListenerArn,
-					LoadBalancerARN: lst.LoadBalancerArn,
-					Port:            int(lst.Port),
-					Protocol:        lst.Protocol,
-					DefaultActions:  actions,
-					RuleCount:       0, // TODO: fetch rule count
-				})
 			}
 		}
 
-		return elbv2DataLoadedMsg{
-			listeners: listeners,
+		listeners = append(listeners, Listener{
+			ARN:             lst.ListenerArn,
+			LoadBalancerARN: lst.LoadBalancerArn,
+			Port:            int(lst.Port),
+			Protocol:        lst.Protocol,
+			DefaultActions:  actions,
+			RuleCount:       ruleCount,
+		})
+	}
+	return listeners
+}
+
+// loadTargetHealthCmd loads the targets of a target group with their health
+func (m Model) loadTargetHealthCmd(targetGroupARN string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		descriptions, err := m.apiClient.DescribeTargetHealth(ctx, m.selectedInstance, targetGroupARN)
+		if err != nil {
+			return elbv2TargetsLoadedMsg{
+				targetGroupARN: targetGroupARN,
+				err:            fmt.Errorf("failed to describe target health: %w", err),
+			}
+		}
+
+		var targets []Target
+		for _, d := range descriptions {
+			targets = append(targets, Target{
+				ID:               d.Target.Id,
+				Port:             int(d.Target.Port),
+				AvailabilityZone: d.Target.AvailabilityZone,
+				HealthCheckPort:  d.HealthCheckPort,
+				State:            d.State,
+				Reason:           d.Reason,
+				Description:      d.Description,
+			})
+		}
+		return elbv2TargetsLoadedMsg{
+			targetGroupARN: targetGroupARN,
+			targets:        targets,
+		}
+	}
+}
+
+// loadListenerRulesCmd loads the rules of a listener
+func (m Model) loadListenerRulesCmd(listenerARN string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		apiRules, err := m.apiClient.ListRules(ctx, m.selectedInstance, listenerARN)
+		if err != nil {
+			return elbv2RulesLoadedMsg{
+				listenerARN: listenerARN,
+				err:         fmt.Errorf("failed to describe rules: %w", err),
+			}
+		}
+
+		var rules []ListenerRule
+		for _, r := range apiRules {
+			rule := ListenerRule{
+				ARN:       r.RuleArn,
+				Priority:  r.Priority,
+				IsDefault: r.IsDefault,
+			}
+			for _, cond := range r.Conditions {
+				rule.Conditions = append(rule.Conditions, fmt.Sprintf("%s=%s", cond.Field, strings.Join(cond.Values, ",")))
+			}
+			for _, act := range r.Actions {
+				rule.Actions = append(rule.Actions, ListenerAction{
+					Type:           act.Type,
+					TargetGroupArn: act.TargetGroupArn,
+				})
+			}
+			rules = append(rules, rule)
+		}
+		return elbv2RulesLoadedMsg{
+			listenerARN: listenerARN,
+			rules:       rules,
 		}
 	}
 }
 
+// refreshELBv2Cmd reloads the resources shown in the current ELBv2 view
+func (m Model) refreshELBv2Cmd() tea.Cmd {
+	switch m.currentView {
+	case ViewTargets:
+		return m.loadTargetHealthCmd(m.selectedTG)
+	case ViewListenerRules:
+		return m.loadListenerRulesCmd(m.selectedListener)
+	}
+	return m.loadELBv2DataCmd()
+}
+
 // handleELBv2Keys handles key events for ELBv2 views
 func (m Model) handleELBv2Keys(msg tea.KeyMsg) (Model, tea.Cmd) {
 	key := msg.String()
@@ -189,6 +310,14 @@ func (m Model) handleELBv2Keys(msg tea.KeyMsg) (Model, tea.Cmd) {
 			if debugLogger := GetDebugLogger(); debugLogger != nil {
 				debugLogger.LogWithCaller("handleELBv2Keys", "Listener cursor moved from %d to %d", oldCursor, m.listenerCursor)
 			}
+		case ViewTargets:
+			if m.targetCursor > 0 {
+				m.targetCursor--
+			}
+		case ViewListenerRules:
+			if m.ruleCursor > 0 {
+				m.ruleCursor--
+			}
 		}
 		return m, nil
 
@@ -221,6 +350,14 @@ func (m Model) handleELBv2Keys(msg tea.KeyMsg) (Model, tea.Cmd) {
 			if debugLogger := GetDebugLogger(); debugLogger != nil {
 				debugLogger.LogWithCaller("handleELBv2Keys", "Listener cursor moved from %d to %d", oldCursor, m.listenerCursor)
 			}
+		case ViewTargets:
+			if m.targetCursor < len(m.targets)-1 {
+				m.targetCursor++
+			}
+		case ViewListenerRules:
+			if m.ruleCursor < len(m.listenerRules)-1 {
+				m.ruleCursor++
+			}
 		}
 		return m, nil
 	}
@@ -232,6 +369,15 @@ func (m Model) handleELBv2Keys(msg tea.KeyMsg) (Model, tea.Cmd) {
 		}
 		switch action {
 		case ActionBack:
+			// Targets and rules go back to the list they were opened from
+			switch m.currentView {
+			case ViewTargets:
+				m.currentView = ViewTargetGroups
+				return m, m.loadELBv2DataCmd()
+			case ViewListenerRules:
+				m.currentView = ViewListeners
+				return m, m.loadListenersForLBCmd(m.selectedLB)
+			}
 			if debugLogger := GetDebugLogger(); debugLogger != nil {
 				debugLogger.LogWithCaller("handleELBv2Keys", "Executing ActionBack - going to Clusters view")
 			}
@@ -252,7 +398,7 @@ func (m Model) handleELBv2Keys(msg tea.KeyMsg) (Model, tea.Cmd) {
 				debugLogger.LogWithCaller("handleELBv2Keys", "Executing ActionRefresh - reloading ELBv2 data")
 			}
 			// Refresh data
-			return m, m.loadELBv2DataCmd()
+			return m, m.refreshELBv2Cmd()
 		case ActionSearch:
 			if debugLogger := GetDebugLogger(); debugLogger != nil {
 				debugLogger.LogWithCaller("handleELBv2Keys", "Executing ActionSearch - entering search mode")
@@ -336,17 +482,9 @@ func (m Model) handleELBv2Keys(msg tea.KeyMsg) (Model, tea.Cmd) {
 				}
 			}
 		case ViewTargetGroups:
-			if debugLogger := GetDebugLogger(); debugLogger != nil {
-				debugLogger.LogWithCaller("handleELBv2Keys", "TODO: View targets for selected target group")
-			}
-			// TODO: View targets for selected target group
-			return m, nil
+			return m.openTargetHealth()
 		case ViewListeners:
-			if debugLogger := GetDebugLogger(); debugLogger != nil {
-				debugLogger.LogWithCaller("handleELBv2Keys", "TODO: View rules for selected listener")
-			}
-			// TODO: View rules for selected listener
-			return m, nil
+			return m.openListenerRules()
 		}
 		return m, nil
 
@@ -369,6 +507,14 @@ func (m Model) handleELBv2Keys(msg tea.KeyMsg) (Model, tea.Cmd) {
 			if len(m.listeners) > m.listenerCursor {
 				arn = m.listeners[m.listenerCursor].ARN
 			}
+		case ViewTargets:
+			if len(m.targets) > m.targetCursor {
+				arn = m.targets[m.targetCursor].ID
+			}
+		case ViewListenerRules:
+			if len(m.listenerRules) > m.ruleCursor {
+				arn = m.listenerRules[m.ruleCursor].ARN
+			}
 		}
 		if arn != "" {
 			if debugLogger := GetDebugLogger(); debugLogger != nil {
@@ -390,7 +536,7 @@ func (m Model) handleELBv2Keys(msg tea.KeyMsg) (Model, tea.Cmd) {
 			debugLogger.LogWithCaller("handleELBv2Keys", "R key pressed - Refreshing ELBv2 data")
 		}
 		// Refresh ELBv2 data
-		return m, m.loadELBv2DataCmd()
+		return m, m.refreshELBv2Cmd()
 	}
 
 	// Check for view-specific actions
@@ -448,20 +594,18 @@ func (m Model) handleELBv2Keys(msg tea.KeyMsg) (Model, tea.Cmd) {
 			// View details or navigate deeper
 			switch m.currentView {
 			case ViewTargetGroups:
-				// TODO: Show target group targets
-				if debugLogger := GetDebugLogger(); debugLogger != nil {
-					debugLogger.LogWithCaller("handleELBv2Keys", "TODO: Show target group targets")
-				}
-				return m, nil
+				return m.openTargetHealth()
 			case ViewListeners:
-				// TODO: Show listener rules
-				if debugLogger := GetDebugLogger(); debugLogger != nil {
-					debugLogger.LogWithCaller("handleELBv2Keys", "TODO: Show listener rules")
-				}
-				return m, nil
+				return m.openListenerRules()
 			}
 			return m, nil
 
+		case ActionDeregisterTarget:
+			return m.confirmDeregisterTarget()
+
+		case ActionDeleteRule:
+			return m.confirmDeleteRule()
+
 		case ActionYank:
 			if debugLogger := GetDebugLogger(); debugLogger != nil {
 				debugLogger.LogWithCaller("handleELBv2Keys", "Executing ActionYank (TODO)")
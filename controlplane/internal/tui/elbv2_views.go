@@ -42,6 +42,12 @@ func (m Model) renderELBv2View() string {
 	var content string
 	contentHeight := resourcePanelHeight - 4 // Account for borders
 
+	// The ELBv2 view has no footer, so toasts take the last line of the panel
+	toast := m.renderToast()
+	if toast != "" {
+		contentHeight--
+	}
+
 	switch m.currentView {
 	case ViewLoadBalancers:
 		content = m.renderLoadBalancersList(contentHeight)
@@ -49,10 +55,20 @@ func (m Model) renderELBv2View() string {
 		content = m.renderTargetGroupsList(contentHeight)
 	case ViewListeners:
 		content = m.renderListenersList(contentHeight)
+	case ViewTargets:
+		content = m.renderTargetsList(contentHeight)
+	case ViewListenerRules:
+		content = m.renderListenerRulesList(contentHeight)
 	default:
 		content = m.renderLoadBalancersList(contentHeight)
 	}
 
+	if toast != "" {
+		content = lipgloss.JoinVertical(lipgloss.Left,
+			lipgloss.NewStyle().Height(contentHeight).Render(content),
+			"  "+toast)
+	}
+
 	// Wrap content in a bordered panel
 	resourcePanel := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
@@ -336,6 +352,189 @@ func (m Model) renderListenersList(height int) string {
 	return strings.Join(lines, "\n")
 }
 
+// renderTargetsList renders the targets of the selected target group with their health
+func (m Model) renderTargetsList(height int) string {
+	if len(m.targets) == 0 {
+		emptyStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#718096")).
+			Align(lipgloss.Center, lipgloss.Center).
+			Width(m.width-8).
+			Height(height).
+			Padding(1, 2)
+		return emptyStyle.Render("No targets registered with selected target group")
+	}
+
+	// Calculate column widths based on available width
+	availableWidth := m.width - 8
+	targetWidth := int(float64(availableWidth) * 0.22)
+	portWidth := int(float64(availableWidth) * 0.08)
+	zoneWidth := int(float64(availableWidth) * 0.12)
+	stateWidth := int(float64(availableWidth) * 0.12)
+	reasonWidth := int(float64(availableWidth) * 0.20)
+	descriptionWidth := int(float64(availableWidth) * 0.22)
+
+	// Header
+	headerStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#a0aec0")).
+		Bold(true)
+
+	header := fmt.Sprintf("%-*s %-*s %-*s %-*s %-*s %-*s",
+		targetWidth, "TARGET",
+		portWidth, "PORT",
+		zoneWidth, "ZONE",
+		stateWidth, "HEALTH",
+		reasonWidth, "REASON",
+		descriptionWidth, "DESCRIPTION")
+
+	// Rows
+	var rows []string
+	rows = append(rows, "  "+headerStyle.Render(header))
+	rows = append(rows, "  "+strings.Repeat("─", availableWidth-2))
+
+	selectedStyle := lipgloss.NewStyle().
+		Background(lipgloss.Color("#2d3748")).
+		Foreground(lipgloss.Color("#ffffff"))
+
+	normalStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#cbd5e0"))
+
+	// Health state colors
+	stateColors := map[string]lipgloss.Color{
+		"healthy":     lipgloss.Color("#48bb78"), // green
+		"initial":     lipgloss.Color("#f6e05e"), // yellow
+		"draining":    lipgloss.Color("#f6e05e"), // yellow
+		"unhealthy":   lipgloss.Color("#f56565"), // red
+		"unavailable": lipgloss.Color("#f56565"), // red
+		"unused":      lipgloss.Color("#718096"), // gray
+	}
+
+	for i, target := range m.targets {
+		stateStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#cbd5e0"))
+		if color, ok := stateColors[target.State]; ok {
+			stateStyle = stateStyle.Foreground(color)
+		}
+		state := stateStyle.Render(fmt.Sprintf("%-*s", stateWidth, target.State))
+
+		port := "-"
+		if target.Port > 0 {
+			port = fmt.Sprintf("%d", target.Port)
+		}
+		zone := target.AvailabilityZone
+		if zone == "" {
+			zone = "-"
+		}
+
+		row := fmt.Sprintf("%-*s %-*s %-*s %s %-*s %-*s",
+			targetWidth, truncate(target.ID, targetWidth),
+			portWidth, port,
+			zoneWidth, truncate(zone, zoneWidth),
+			state,
+			reasonWidth, truncate(target.Reason, reasonWidth),
+			descriptionWidth, truncate(target.Description, descriptionWidth))
+
+		if i == m.targetCursor {
+			rows = append(rows, selectedStyle.Width(availableWidth).Render("▸ "+row))
+		} else {
+			rows = append(rows, "  "+normalStyle.Render(row))
+		}
+	}
+
+	// Join rows and limit to available height
+	content := strings.Join(rows, "\n")
+	lines := strings.Split(content, "\n")
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// renderListenerRulesList renders the rules of the selected listener
+func (m Model) renderListenerRulesList(height int) string {
+	if len(m.listenerRules) == 0 {
+		emptyStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#718096")).
+			Align(lipgloss.Center, lipgloss.Center).
+			Width(m.width-8).
+			Height(height).
+			Padding(1, 2)
+		return emptyStyle.Render("No rules found for selected listener")
+	}
+
+	// Calculate column widths based on available width
+	availableWidth := m.width - 8
+	priorityWidth := int(float64(availableWidth) * 0.10)
+	conditionsWidth := int(float64(availableWidth) * 0.45)
+	actionWidth := int(float64(availableWidth) * 0.15)
+	targetGroupWidth := int(float64(availableWidth) * 0.28)
+
+	// Header
+	headerStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#a0aec0")).
+		Bold(true)
+
+	header := fmt.Sprintf("%-*s %-*s %-*s %-*s",
+		priorityWidth, "PRIORITY",
+		conditionsWidth, "CONDITIONS",
+		actionWidth, "ACTION",
+		targetGroupWidth, "TARGET GROUP")
+
+	// Rows
+	var rows []string
+	rows = append(rows, "  "+headerStyle.Render(header))
+	rows = append(rows, "  "+strings.Repeat("─", availableWidth-2))
+
+	selectedStyle := lipgloss.NewStyle().
+		Background(lipgloss.Color("#2d3748")).
+		Foreground(lipgloss.Color("#ffffff"))
+
+	normalStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#cbd5e0"))
+
+	for i, rule := range m.listenerRules {
+		conditions := strings.Join(rule.Conditions, " AND ")
+		if rule.IsDefault {
+			conditions = "(default)"
+		} else if conditions == "" {
+			conditions = "-"
+		}
+
+		action := "unknown"
+		targetGroup := "-"
+		if len(rule.Actions) > 0 {
+			action = rule.Actions[0].Type
+			if rule.Actions[0].TargetGroupArn != "" {
+				// Extract target group name from ARN
+				parts := strings.Split(rule.Actions[0].TargetGroupArn, "/")
+				if len(parts) >= 2 {
+					targetGroup = parts[1]
+				}
+			}
+		}
+
+		row := fmt.Sprintf("%-*s %-*s %-*s %-*s",
+			priorityWidth, truncate(rule.Priority, priorityWidth),
+			conditionsWidth, truncate(conditions, conditionsWidth),
+			actionWidth, truncate(action, actionWidth),
+			targetGroupWidth, truncate(targetGroup, targetGroupWidth))
+
+		if i == m.ruleCursor {
+			rows = append(rows, selectedStyle.Width(availableWidth).Render("▸ "+row))
+		} else {
+			rows = append(rows, "  "+normalStyle.Render(row))
+		}
+	}
+
+	// Join rows and limit to available height
+	content := strings.Join(rows, "\n")
+	lines := strings.Split(content, "\n")
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+
+	return strings.Join(lines, "\n")
+}
+
 // Helper function to format age
 func formatAge(d time.Duration) string {
 	if d < time.Minute {
@@ -0,0 +1,107 @@
+// Copyright 2025 The KECS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// asciicastHeader is the first line of an asciicast v2 recording
+type asciicastHeader struct {
+	Version   int    `json:"version"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Timestamp int64  `json:"timestamp"`
+	Title     string `json:"title,omitempty"`
+}
+
+// sessionRecorder records terminal output in the asciicast v2 format: a JSON
+// header followed by one [elapsed, code, data] event per line
+type sessionRecorder struct {
+	mu     sync.Mutex
+	file   *os.File
+	writer *bufio.Writer
+	start  time.Time
+}
+
+// newSessionRecorder creates the recording file and writes its header
+func newSessionRecorder(path string, width, height int, title string) (*sessionRecorder, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording file: %w", err)
+	}
+
+	r := &sessionRecorder{
+		file:   file,
+		writer: bufio.NewWriter(file),
+		start:  time.Now(),
+	}
+	header, err := json.Marshal(asciicastHeader{
+		Version:   2,
+		Width:     width,
+		Height:    height,
+		Timestamp: r.start.Unix(),
+		Title:     title,
+	})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	r.writer.Write(append(header, '\n'))
+	return r, nil
+}
+
+// Write records terminal output. Recording errors never interrupt the session.
+func (r *sessionRecorder) Write(p []byte) (int, error) {
+	r.writeEvent("o", string(p))
+	return len(p), nil
+}
+
+// Resize records a change of the terminal size
+func (r *sessionRecorder) Resize(width, height int) {
+	r.writeEvent("r", fmt.Sprintf("%dx%d", width, height))
+}
+
+func (r *sessionRecorder) writeEvent(code, data string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	event, err := json.Marshal([]interface{}{time.Since(r.start).Seconds(), code, data})
+	if err != nil {
+		return
+	}
+	r.writer.Write(append(event, '\n'))
+}
+
+// Close flushes and closes the recording file
+func (r *sessionRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.writer.Flush(); err != nil {
+		r.file.Close()
+		return err
+	}
+	return r.file.Close()
+}
@@ -0,0 +1,73 @@
+// Copyright 2025 The KECS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	"os"
+	"os/signal"
+
+	"golang.org/x/term"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// terminalSizeQueue reports the size of the local terminal to an exec session,
+// first on start and then whenever the terminal is resized
+type terminalSizeQueue struct {
+	fd       int
+	resized  chan os.Signal
+	done     chan struct{}
+	started  bool
+	onResize func(width, height int)
+}
+
+// newTerminalSizeQueue starts watching the terminal for size changes
+func newTerminalSizeQueue(fd int) *terminalSizeQueue {
+	q := &terminalSizeQueue{
+		fd:      fd,
+		resized: make(chan os.Signal, 1),
+		done:    make(chan struct{}),
+	}
+	notifyResize(q.resized)
+	return q
+}
+
+// Next implements remotecommand.TerminalSizeQueue. It returns nil once the
+// queue is stopped, which ends the resize handling of the session.
+func (q *terminalSizeQueue) Next() *remotecommand.TerminalSize {
+	resized := q.started
+	if resized {
+		select {
+		case <-q.resized:
+		case <-q.done:
+			return nil
+		}
+	}
+	q.started = true
+
+	width, height, err := term.GetSize(q.fd)
+	if err != nil {
+		return nil
+	}
+	if resized && q.onResize != nil {
+		q.onResize(width, height)
+	}
+	return &remotecommand.TerminalSize{Width: uint16(width), Height: uint16(height)}
+}
+
+// stop stops watching the terminal
+func (q *terminalSizeQueue) stop() {
+	signal.Stop(q.resized)
+	close(q.done)
+}
@@ -0,0 +1,275 @@
+// Copyright 2025 The KECS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/term"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// execShellCommand starts bash when the image has it and falls back to sh
+var execShellCommand = []string{"/bin/sh", "-c", "command -v bash >/dev/null 2>&1 && exec bash || exec sh"}
+
+// execSessionReadyMsg is sent once the pod and container of an exec session are resolved
+type execSessionReadyMsg struct {
+	session *ExecSession
+}
+
+// ExecFinishedMsg is sent when an exec session ends or cannot be started
+type ExecFinishedMsg struct {
+	TaskID        string
+	RecordingPath string
+	Error         error
+}
+
+// ExecSession is an interactive shell in a task container. It implements
+// tea.ExecCommand, so the TUI hands the whole terminal over to the shell while
+// it runs and comes back when the shell exits. The session is recorded to an
+// asciicast file that can be replayed with `asciinema play`.
+type ExecSession struct {
+	TaskID        string
+	Namespace     string
+	PodName       string
+	Container     string
+	RecordingPath string
+
+	restConfig *rest.Config
+	kubeClient kubernetes.Interface
+	stdin      io.Reader
+	stdout     io.Writer
+}
+
+// SetStdin implements tea.ExecCommand
+func (s *ExecSession) SetStdin(r io.Reader) {
+	s.stdin = r
+}
+
+// SetStdout implements tea.ExecCommand
+func (s *ExecSession) SetStdout(w io.Writer) {
+	s.stdout = w
+}
+
+// SetStderr implements tea.ExecCommand. The shell runs with a TTY, which
+// merges its stderr into stdout.
+func (s *ExecSession) SetStderr(w io.Writer) {}
+
+// Run implements tea.ExecCommand
+func (s *ExecSession) Run() error {
+	req := s.kubeClient.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(s.Namespace).
+		Name(s.PodName).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: s.Container,
+			Command:   execShellCommand,
+			Stdin:     true,
+			Stdout:    true,
+			TTY:       true,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(s.restConfig, "POST", req.URL())
+	if err != nil {
+		return fmt.Errorf("failed to create exec session: %w", err)
+	}
+
+	width, height := 80, 24
+	var sizeQueue *terminalSizeQueue
+	if f, ok := s.stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		fd := int(f.Fd())
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return fmt.Errorf("failed to switch terminal to raw mode: %w", err)
+		}
+		defer term.Restore(fd, state)
+
+		if w, h, err := term.GetSize(fd); err == nil {
+			width, height = w, h
+		}
+		sizeQueue = newTerminalSizeQueue(fd)
+		defer sizeQueue.stop()
+	}
+
+	stdout := s.stdout
+	if s.RecordingPath != "" {
+		title := fmt.Sprintf("%s/%s", s.TaskID, s.Container)
+		recorder, err := newSessionRecorder(s.RecordingPath, width, height, title)
+		if err != nil {
+			return err
+		}
+		defer recorder.Close()
+		stdout = io.MultiWriter(s.stdout, recorder)
+		if sizeQueue != nil {
+			sizeQueue.onResize = recorder.Resize
+		}
+	}
+
+	fmt.Fprintf(s.stdout, "Connected to container %s of task %s. Exit the shell to return to KECS.\r\n", s.Container, s.TaskID)
+
+	opts := remotecommand.StreamOptions{
+		Stdin:  s.stdin,
+		Stdout: stdout,
+		Tty:    true,
+	}
+	if sizeQueue != nil {
+		opts.TerminalSizeQueue = sizeQueue
+	}
+	return executor.StreamWithContext(context.Background(), opts)
+}
+
+// finished reports the outcome of the session back to the TUI
+func (s *ExecSession) finished(err error) tea.Msg {
+	if err != nil {
+		err = fmt.Errorf("exec session failed: %w", err)
+	}
+	return ExecFinishedMsg{
+		TaskID:        s.TaskID,
+		RecordingPath: s.RecordingPath,
+		Error:         err,
+	}
+}
+
+// openExecSession opens a shell in a container of the given task. An empty
+// container name selects the first container of the task.
+func (m Model) openExecSession(taskID, taskARN, containerName string) (Model, tea.Cmd) {
+	if m.selectedInstance == "" {
+		return m, nil
+	}
+	m.showToast(fmt.Sprintf("Connecting to task %s...", taskID), false)
+	return m, m.prepareExecCmd(taskID, taskARN, containerName)
+}
+
+// prepareExecCmd resolves the pod and container of a task before the TUI
+// hands the terminal over, so that lookup errors show up as toasts
+func (m Model) prepareExecCmd(taskID, taskARN, containerName string) tea.Cmd {
+	instanceName := m.selectedInstance
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		restConfig, err := m.apiClient.GetKubeConfig(ctx, instanceName)
+		if err != nil {
+			return ExecFinishedMsg{TaskID: taskID, Error: fmt.Errorf("failed to connect to instance %s: %w", instanceName, err)}
+		}
+		kubeClient, err := kubernetes.NewForConfig(restConfig)
+		if err != nil {
+			return ExecFinishedMsg{TaskID: taskID, Error: fmt.Errorf("failed to create Kubernetes client: %w", err)}
+		}
+
+		session, err := findExecTarget(ctx, kubeClient, taskID, taskARN, containerName)
+		if err != nil {
+			return ExecFinishedMsg{TaskID: taskID, Error: err}
+		}
+		session.restConfig = restConfig
+		session.RecordingPath = execRecordingPath(instanceName, taskID, session.Container, time.Now())
+		return execSessionReadyMsg{session: session}
+	}
+}
+
+// findExecTarget looks up the pod running a task and checks the container
+func findExecTarget(ctx context.Context, kubeClient kubernetes.Interface, taskID, taskARN, containerName string) (*ExecSession, error) {
+	namespace, err := taskNamespace(taskARN)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := kubeClient.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("kecs.dev/task-id=%s", taskID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find pod for task %s: %w", taskID, err)
+	}
+	if len(pods.Items) == 0 {
+		return nil, fmt.Errorf("no pod found for task %s", taskID)
+	}
+	pod := pods.Items[0]
+	if pod.Status.Phase != corev1.PodRunning {
+		return nil, fmt.Errorf("task %s is not running (pod phase %s)", taskID, pod.Status.Phase)
+	}
+
+	container := ""
+	for _, c := range pod.Spec.Containers {
+		if containerName == "" || c.Name == containerName {
+			container = c.Name
+			break
+		}
+	}
+	if container == "" {
+		return nil, fmt.Errorf("container %s not found in task %s", containerName, taskID)
+	}
+
+	return &ExecSession{
+		TaskID:     taskID,
+		Namespace:  pod.Namespace,
+		PodName:    pod.Name,
+		Container:  container,
+		kubeClient: kubeClient,
+	}, nil
+}
+
+// taskNamespace returns the namespace of a task's pod from its ARN, e.g.
+// arn:aws:ecs:us-east-1:000000000000:task/default/abc123 runs in default-us-east-1
+func taskNamespace(taskARN string) (string, error) {
+	parts := strings.SplitN(taskARN, ":", 6)
+	if len(parts) != 6 || parts[3] == "" {
+		return "", fmt.Errorf("invalid task ARN: %s", taskARN)
+	}
+	resource := strings.Split(parts[5], "/")
+	if len(resource) != 3 || resource[0] != "task" {
+		return "", fmt.Errorf("task ARN does not include a cluster: %s", taskARN)
+	}
+	return fmt.Sprintf("%s-%s", resource[1], parts[3]), nil
+}
+
+// execRecordingPath returns where an exec session is recorded, e.g.
+// ~/.kecs/sessions/dev/abc123-web-20251015-150405.cast
+func execRecordingPath(instanceName, taskID, container string, startedAt time.Time) string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	name := fmt.Sprintf("%s-%s-%s.cast", taskID, container, startedAt.Format("20060102-150405"))
+	return filepath.Join(homeDir, ".kecs", "sessions", instanceName, name)
+}
+
+// handleExecFinished reports the outcome of an exec session
+func (m Model) handleExecFinished(msg ExecFinishedMsg) (Model, tea.Cmd) {
+	if msg.Error != nil {
+		m.err = msg.Error
+		m.showToast(msg.Error.Error(), true)
+		return m, nil
+	}
+
+	message := fmt.Sprintf("Exec session in task %s ended", msg.TaskID)
+	if msg.RecordingPath != "" {
+		message += fmt.Sprintf(", recorded to %s", msg.RecordingPath)
+	}
+	m.showToast(message, false)
+	return m, m.loadDataFromAPI()
+}
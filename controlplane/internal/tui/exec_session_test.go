@@ -0,0 +1,156 @@
+package tui
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/tui/api"
+)
+
+const testTaskARN = "arn:aws:ecs:us-east-1:000000000000:task/default/abc123"
+
+func taskPod(phase corev1.PodPhase, containers ...string) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "abc123",
+			Namespace: "default-us-east-1",
+			Labels:    map[string]string{"kecs.dev/task-id": "abc123"},
+		},
+		Status: corev1.PodStatus{Phase: phase},
+	}
+	for _, name := range containers {
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: name})
+	}
+	return pod
+}
+
+func TestTaskNamespace(t *testing.T) {
+	namespace, err := taskNamespace(testTaskARN)
+	if err != nil || namespace != "default-us-east-1" {
+		t.Errorf("expected default-us-east-1, got %q (%v)", namespace, err)
+	}
+
+	for _, arn := range []string{"abc123", "arn:aws:ecs:us-east-1:000000000000:task/abc123"} {
+		if _, err := taskNamespace(arn); err == nil {
+			t.Errorf("expected an error for %s", arn)
+		}
+	}
+}
+
+func TestFindExecTarget(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("selects the first container by default", func(t *testing.T) {
+		client := fake.NewSimpleClientset(taskPod(corev1.PodRunning, "web", "sidecar"))
+		session, err := findExecTarget(ctx, client, "abc123", testTaskARN, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if session.PodName != "abc123" || session.Namespace != "default-us-east-1" || session.Container != "web" {
+			t.Errorf("unexpected session %+v", session)
+		}
+	})
+
+	t.Run("selects the requested container", func(t *testing.T) {
+		client := fake.NewSimpleClientset(taskPod(corev1.PodRunning, "web", "sidecar"))
+		session, err := findExecTarget(ctx, client, "abc123", testTaskARN, "sidecar")
+		if err != nil || session.Container != "sidecar" {
+			t.Errorf("expected sidecar container, got %+v (%v)", session, err)
+		}
+	})
+
+	t.Run("rejects unknown containers and stopped tasks", func(t *testing.T) {
+		client := fake.NewSimpleClientset(taskPod(corev1.PodRunning, "web"))
+		if _, err := findExecTarget(ctx, client, "abc123", testTaskARN, "db"); err == nil {
+			t.Error("expected an error for an unknown container")
+		}
+
+		client = fake.NewSimpleClientset(taskPod(corev1.PodSucceeded, "web"))
+		if _, err := findExecTarget(ctx, client, "abc123", testTaskARN, ""); err == nil {
+			t.Error("expected an error for a stopped task")
+		}
+
+		client = fake.NewSimpleClientset()
+		if _, err := findExecTarget(ctx, client, "abc123", testTaskARN, ""); err == nil {
+			t.Error("expected an error for a task without pod")
+		}
+	})
+}
+
+func TestSessionRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions", "abc123.cast")
+	recorder, err := newSessionRecorder(path, 120, 40, "abc123/web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder.Write([]byte("$ ls\r\n"))
+	recorder.Resize(100, 30)
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if len(lines) != 3 {
+		t.Fatalf("expected header and 2 events, got %d lines", len(lines))
+	}
+
+	var header asciicastHeader
+	if err := json.Unmarshal([]byte(lines[0]), &header); err != nil {
+		t.Fatal(err)
+	}
+	if header.Version != 2 || header.Width != 120 || header.Height != 40 || header.Title != "abc123/web" {
+		t.Errorf("unexpected header %+v", header)
+	}
+
+	var event []interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &event); err != nil {
+		t.Fatal(err)
+	}
+	if len(event) != 3 || event[1] != "o" || event[2] != "$ ls\r\n" {
+		t.Errorf("unexpected output event %v", event)
+	}
+	if !strings.Contains(lines[2], `"r","100x30"`) {
+		t.Errorf("unexpected resize event %s", lines[2])
+	}
+}
+
+func TestExecTaskAction(t *testing.T) {
+	m := NewModelWithClient(api.NewMockClient())
+	m.selectedInstance = "dev"
+	m.selectedCluster = "default"
+	m.tasks = []Task{{ID: "abc123", ARN: testTaskARN, Status: "RUNNING"}}
+
+	m, cmd := m.executeTaskAction(ActionExecTask)
+	if cmd == nil {
+		t.Fatal("expected exec session to be prepared")
+	}
+
+	// The mock client has no cluster to exec into
+	msg, ok := cmd().(ExecFinishedMsg)
+	if !ok || msg.Error == nil {
+		t.Fatalf("expected exec to fail with the mock client, got %#v", msg)
+	}
+	m, _ = m.handleExecFinished(msg)
+	if !m.toastIsError {
+		t.Errorf("expected error toast, got %q", m.toastMessage)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2025 The KECS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// FederationSummary aggregates the resources of all known instances
+type FederationSummary struct {
+	Instances int
+	Running   int
+	Clusters  int
+	Services  int
+	Tasks     int
+}
+
+// SummarizeInstances sums up the resource counts of the given instances
+func SummarizeInstances(instances []Instance) FederationSummary {
+	summary := FederationSummary{Instances: len(instances)}
+	for _, inst := range instances {
+		if strings.EqualFold(inst.Status, "running") {
+			summary.Running++
+		}
+		summary.Clusters += inst.Clusters
+		summary.Services += inst.Services
+		summary.Tasks += inst.Tasks
+	}
+	return summary
+}
+
+// String returns a one-line representation of the summary
+func (s FederationSummary) String() string {
+	return fmt.Sprintf("%d instances (%d running) │ %d clusters │ %d services │ %d tasks",
+		s.Instances, s.Running, s.Clusters, s.Services, s.Tasks)
+}
+
+// formatInstanceCounts returns the compact resource counts of an instance
+func formatInstanceCounts(inst Instance) string {
+	return fmt.Sprintf("%dc %ds %dt", inst.Clusters, inst.Services, inst.Tasks)
+}
+
+// renderFederationSummary renders the aggregated counts shown above the instances list
+func (m Model) renderFederationSummary() string {
+	return lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#00aaff")).
+		Render("Σ " + SummarizeInstances(m.instances).String())
+}
@@ -0,0 +1,44 @@
+package tui_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/tui"
+)
+
+var _ = Describe("Federation", func() {
+	instances := []tui.Instance{
+		{Name: "dev", Status: "running", Clusters: 2, Services: 3, Tasks: 5, Source: "k3d"},
+		{Name: "ci-1234", Status: "Stopped", Source: "k3d"},
+		{Name: "localhost:8080", Status: "Running", Clusters: 1, Services: 2, Tasks: 4, Source: "port"},
+	}
+
+	Describe("SummarizeInstances", func() {
+		It("should aggregate counts across instances", func() {
+			summary := tui.SummarizeInstances(instances)
+			Expect(summary).To(Equal(tui.FederationSummary{
+				Instances: 3,
+				Running:   2,
+				Clusters:  3,
+				Services:  5,
+				Tasks:     9,
+			}))
+			Expect(summary.String()).To(Equal("3 instances (2 running) │ 3 clusters │ 5 services │ 9 tasks"))
+		})
+
+		It("should handle no instances", func() {
+			Expect(tui.SummarizeInstances(nil)).To(Equal(tui.FederationSummary{}))
+		})
+	})
+
+	Describe("InstanceSwitcher", func() {
+		It("should show per-instance and aggregated counts", func() {
+			switcher := tui.NewInstanceSwitcher(instances)
+			view := switcher.Render(120, 40)
+			Expect(view).To(ContainSubstring("3 instances │ 3c 5s 9t in total"))
+			Expect(view).To(ContainSubstring("2c 3s 5t"))
+			Expect(view).To(ContainSubstring("localhost:8080"))
+		})
+	})
+})
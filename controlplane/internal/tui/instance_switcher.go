@@ -124,6 +124,10 @@ func (s *InstanceSwitcher) Render(width, height int) string {
 
 	// Title
 	content = append(content, titleStyle.Render("Switch Instance"))
+	summary := SummarizeInstances(s.instances)
+	content = append(content, lipgloss.NewStyle().Foreground(lipgloss.Color("#666666")).Render(
+		fmt.Sprintf("%d instances │ %dc %ds %dt in total",
+			summary.Instances, summary.Clusters, summary.Services, summary.Tasks)))
 	content = append(content, "")
 
 	// Input
@@ -141,7 +145,11 @@ func (s *InstanceSwitcher) Render(width, height int) string {
 
 		inst := s.instances[idx]
 		status := formatInstanceStatus(inst.Status)
-		line := fmt.Sprintf("%-20s %s", inst.Name, status)
+		name := inst.Name
+		if len(name) > 18 {
+			name = name[:15] + "..."
+		}
+		line := fmt.Sprintf("%-18s %-12s %s", name, status, formatInstanceCounts(inst))
 
 		if i == s.selectedIndex {
 			line = "▸ " + line
@@ -39,6 +39,7 @@ const (
 	ActionNavigateLoadBalancers KeyAction = "nav_load_balancers"
 	ActionNavigateTargetGroups  KeyAction = "nav_target_groups"
 	ActionNavigateListeners     KeyAction = "nav_listeners"
+	ActionNavigateCapacity      KeyAction = "nav_capacity"
 
 	// Instance actions
 	ActionNewInstance    KeyAction = "new_instance"
@@ -51,13 +52,16 @@ const (
 	ActionDeleteCluster KeyAction = "delete_cluster"
 
 	// Service actions
-	ActionScaleService  KeyAction = "scale_service"
-	ActionUpdateService KeyAction = "update_service"
+	ActionScaleService   KeyAction = "scale_service"
+	ActionUpdateService  KeyAction = "update_service"
+	ActionRestartService KeyAction = "restart_service"
+	ActionFilterServices KeyAction = "filter_services"
 
 	// Task actions
 	ActionDescribeTask KeyAction = "describe_task"
 	ActionStopTask     KeyAction = "stop_task"
 	ActionRestartTask  KeyAction = "restart_task"
+	ActionExecTask     KeyAction = "exec_task"
 
 	// Task definition actions
 	ActionNewTaskDef        KeyAction = "new_task_def"
@@ -66,6 +70,10 @@ const (
 	ActionDeregisterTaskDef KeyAction = "deregister_task_def"
 	ActionToggleJSON        KeyAction = "toggle_json"
 
+	// ELBv2 actions
+	ActionDeregisterTarget KeyAction = "deregister_target"
+	ActionDeleteRule       KeyAction = "delete_rule"
+
 	// Log actions
 	ActionViewLogs KeyAction = "view_logs"
 	ActionSaveLogs KeyAction = "save_logs"
@@ -157,6 +165,7 @@ func (r *KeyBindingsRegistry) registerViewBindings() {
 		{Keys: []string{"T"}, Description: "All tasks", Action: ActionNavigateAllTasks},
 		{Keys: []string{"b"}, Description: "Load Balancers", Action: ActionNavigateLoadBalancers},
 		{Keys: []string{"g"}, Description: "Target Groups", Action: ActionNavigateTargetGroups},
+		{Keys: []string{"p"}, Description: "Capacity", Action: ActionNavigateCapacity},
 	})
 
 	// Services view
@@ -164,6 +173,8 @@ func (r *KeyBindingsRegistry) registerViewBindings() {
 		{Keys: []string{"enter"}, Description: "Select", Action: ActionSelect},
 		{Keys: []string{"s"}, Description: "Scale", Action: ActionScaleService},
 		{Keys: []string{"u"}, Description: "Update", Action: ActionUpdateService},
+		{Keys: []string{"R"}, Description: "Restart", Action: ActionRestartService},
+		{Keys: []string{"f"}, Description: "Filter", Action: ActionFilterServices},
 		{Keys: []string{"l"}, Description: "Logs", Action: ActionViewLogs},
 		{Keys: []string{"t"}, Description: "Task defs", Action: ActionNavigateTaskDefs},
 		{Keys: []string{"c"}, Description: "Clusters", Action: ActionNavigateClusters},
@@ -174,6 +185,7 @@ func (r *KeyBindingsRegistry) registerViewBindings() {
 		{Keys: []string{"enter"}, Description: "Describe", Action: ActionDescribeTask},
 		{Keys: []string{"s"}, Description: "Stop task", Action: ActionStopTask},
 		{Keys: []string{"l"}, Description: "Logs", Action: ActionViewLogs},
+		{Keys: []string{"x"}, Description: "Shell", Action: ActionExecTask},
 		{Keys: []string{"t"}, Description: "Task defs", Action: ActionNavigateTaskDefs},
 		{Keys: []string{"c"}, Description: "Clusters", Action: ActionNavigateClusters},
 	})
@@ -183,6 +195,7 @@ func (r *KeyBindingsRegistry) registerViewBindings() {
 		{Keys: []string{"l"}, Description: "View Logs", Action: ActionViewLogs},
 		{Keys: []string{"r"}, Description: "Restart", Action: ActionRestartTask},
 		{Keys: []string{"s"}, Description: "Stop", Action: ActionStopTask},
+		{Keys: []string{"x"}, Description: "Shell", Action: ActionExecTask},
 		{Keys: []string{"g"}, Description: "Go to top", Action: ActionHome},
 		{Keys: []string{"G"}, Description: "Go to bottom", Action: ActionEnd},
 		{Keys: []string{"ctrl+u", "pgup"}, Description: "Page up", Action: ActionPageUp},
@@ -234,6 +247,27 @@ func (r *KeyBindingsRegistry) registerViewBindings() {
 		{Keys: []string{"c"}, Description: "Clusters", Action: ActionNavigateClusters},
 		{Keys: []string{"y"}, Description: "Yank ARN", Action: ActionYank},
 	})
+
+	// Targets view
+	r.registerViewKeys(ViewTargets, []KeyBinding{
+		{Keys: []string{"d"}, Description: "Deregister", Action: ActionDeregisterTarget},
+		{Keys: []string{"g"}, Description: "Target Groups", Action: ActionNavigateTargetGroups},
+		{Keys: []string{"b"}, Description: "Load Balancers", Action: ActionNavigateLoadBalancers},
+		{Keys: []string{"y"}, Description: "Yank ID", Action: ActionYank},
+	})
+
+	// Listener Rules view
+	r.registerViewKeys(ViewListenerRules, []KeyBinding{
+		{Keys: []string{"d"}, Description: "Delete rule", Action: ActionDeleteRule},
+		{Keys: []string{"b"}, Description: "Load Balancers", Action: ActionNavigateLoadBalancers},
+		{Keys: []string{"g"}, Description: "Target Groups", Action: ActionNavigateTargetGroups},
+		{Keys: []string{"y"}, Description: "Yank ARN", Action: ActionYank},
+	})
+
+	// Capacity view
+	r.registerViewKeys(ViewCapacity, []KeyBinding{
+		{Keys: []string{"c"}, Description: "Clusters", Action: ActionNavigateClusters},
+	})
 }
 
 // registerViewKeys registers key bindings for a specific view
@@ -369,7 +403,10 @@ func (r *KeyBindingsRegistry) GetViewBindings(view ViewType) []KeyBinding {
 		ActionStopTask,
 		ActionScaleService,
 		ActionUpdateService,
+		ActionRestartService,
+		ActionFilterServices,
 		ActionViewLogs,
+		ActionExecTask,
 		ActionToggleJSON,
 		ActionYank,
 		ActionCopyJSON,
@@ -377,6 +414,8 @@ func (r *KeyBindingsRegistry) GetViewBindings(view ViewType) []KeyBinding {
 		ActionEditTaskDef,
 		ActionDeleteInstance,
 		ActionDeregisterTaskDef,
+		ActionDeregisterTarget,
+		ActionDeleteRule,
 		ActionNavigateClusters,
 		ActionNavigateServices,
 		ActionNavigateTasks,
@@ -385,6 +424,7 @@ func (r *KeyBindingsRegistry) GetViewBindings(view ViewType) []KeyBinding {
 		ActionNavigateLoadBalancers,
 		ActionNavigateTargetGroups,
 		ActionNavigateListeners,
+		ActionNavigateCapacity,
 		ActionRestartTask,
 		ActionSaveLogs,
 		ActionHome,
@@ -7,6 +7,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/capacity"
 	"github.com/nandemo-ya/kecs/controlplane/internal/tui/api"
 )
 
@@ -33,6 +34,9 @@ const (
 	ViewLoadBalancers
 	ViewTargetGroups
 	ViewListeners
+	ViewTargets
+	ViewListenerRules
+	ViewCapacity
 )
 
 // String returns the string representation of ViewType
@@ -76,6 +80,12 @@ func (v ViewType) String() string {
 		return "Target Groups"
 	case ViewListeners:
 		return "Listeners"
+	case ViewTargets:
+		return "Targets"
+	case ViewListenerRules:
+		return "Listener Rules"
+	case ViewCapacity:
+		return "Capacity"
 	default:
 		return "Unknown"
 	}
@@ -94,6 +104,8 @@ type Instance struct {
 	Traefik    bool
 	Age        time.Duration
 	Selected   bool
+	Source     string // Where the instance was discovered: k3d or port
+	Endpoint   string
 }
 
 // Cluster represents an ECS cluster
@@ -203,6 +215,26 @@ type ListenerAction struct {
 	TargetGroupArn string // If action is forward
 }
 
+// Target represents a target registered with a target group and its health
+type Target struct {
+	ID               string
+	Port             int
+	AvailabilityZone string
+	HealthCheckPort  string
+	State            string // initial, healthy, unhealthy, unused, draining, unavailable
+	Reason           string
+	Description      string
+}
+
+// ListenerRule represents a rule of a listener
+type ListenerRule struct {
+	ARN        string
+	Priority   string
+	IsDefault  bool
+	Conditions []string // Formatted as field=value,value
+	Actions    []ListenerAction
+}
+
 // TaskDefinitionEditor manages task definition JSON editing
 type TaskDefinitionEditor struct {
 	family        string
@@ -266,6 +298,12 @@ type Model struct {
 	showHelp     bool
 	err          error
 
+	// Services filter, the ListServices filter expression evaluated by the
+	// instance
+	serviceFilter      string
+	serviceFilterMode  bool
+	serviceFilterInput string
+
 	// Command palette
 	commandPalette *CommandPalette
 
@@ -304,6 +342,11 @@ type Model struct {
 	// API client
 	apiClient api.Client
 
+	// Subscription to the selected instance when the API client streams updates
+	subscribedInstance string
+	subscribeFailed    string
+	subscribeRetryAt   time.Time
+
 	// Clipboard notification
 	clipboardMsg     string
 	clipboardMsgTime time.Time
@@ -327,6 +370,14 @@ type Model struct {
 	scalingServiceName string
 	scalingTargetCount int
 
+	// Task stopping
+	taskStopDialog *TaskStopDialog
+
+	// Toast notification for the outcome of actions
+	toastMessage string
+	toastIsError bool
+	toastTime    time.Time
+
 	// Service updating
 	serviceUpdateDialog *ServiceUpdateDialog
 	updatingInProgress  bool
@@ -337,15 +388,24 @@ type Model struct {
 	keyBindings *KeyBindingsRegistry
 
 	// ELBv2 state
-	loadBalancers  []LoadBalancer
-	targetGroups   []TargetGroup
-	listeners      []Listener
-	selectedLB     string // Selected load balancer ARN
-	selectedTG     string // Selected target group ARN
-	lbCursor       int
-	tgCursor       int
-	listenerCursor int
-	elbv2SubView   int // 0=LoadBalancers, 1=TargetGroups, 2=Listeners
+	loadBalancers    []LoadBalancer
+	targetGroups     []TargetGroup
+	listeners        []Listener
+	targets          []Target
+	listenerRules    []ListenerRule
+	selectedLB       string // Selected load balancer ARN
+	selectedTG       string // Selected target group ARN
+	selectedListener string // Selected listener ARN
+	lbCursor         int
+	tgCursor         int
+	listenerCursor   int
+	targetCursor     int
+	ruleCursor       int
+	elbv2SubView     int // 0=LoadBalancers, 1=TargetGroups, 2=Listeners
+
+	// Capacity state
+	capacity    *capacity.Report
+	capacityErr error
 }
 
 // NewModel creates a new application model
@@ -458,6 +518,8 @@ func (m *Model) goBack() {
 	case ViewTaskDefinitionFamilies:
 		m.currentView = ViewClusters
 		m.selectedFamily = ""
+	case ViewCapacity:
+		m.currentView = ViewClusters
 	case ViewTaskDefinitionRevisions:
 		// Special handling for JSON view
 		if m.showTaskDefJSON {
@@ -362,6 +362,11 @@ func (m Model) renderBreadcrumb() string {
 }
 
 func (m Model) renderFooter() string {
+	// Outcome of the last action takes precedence
+	if toast := m.renderToast(); toast != "" {
+		return footerStyle.Width(m.width).Render(toast)
+	}
+
 	// Check if we should show clipboard notification
 	if m.clipboardMsg != "" && time.Since(m.clipboardMsgTime) < 3*time.Second {
 		notification := successStyle.Render("📋 " + m.clipboardMsg)
@@ -381,6 +386,11 @@ func (m Model) renderFooter() string {
 		help := dimmedStyle.Render("[Enter] Apply  [Esc] Cancel")
 		content := fmt.Sprintf("%s  %s", input, help)
 		return footerStyle.Width(m.width).Render(content)
+	} else if m.serviceFilterMode {
+		input := fmt.Sprintf("Filter: %s_", m.serviceFilterInput)
+		help := dimmedStyle.Render("tag:KEY[=VALUE] launchType=FARGATE status=ACTIVE  [Enter] Apply  [Esc] Cancel")
+		content := fmt.Sprintf("%s  %s", input, help)
+		return footerStyle.Width(m.width).Render(content)
 	} else if m.commandMode {
 		input := fmt.Sprintf("Command: %s_", m.commandInput)
 		help := dimmedStyle.Render("[Enter] Execute  [Tab] Palette  [Esc] Cancel")
@@ -498,6 +508,8 @@ func (m Model) renderResourcePanel() string {
 		} else {
 			content = m.renderTaskDefRevisionsList(resourceHeight-4, m.width-8)
 		}
+	case ViewCapacity:
+		content = m.renderCapacityView(resourceHeight - 4)
 	}
 
 	// Apply resource panel style with fixed height
@@ -589,6 +601,11 @@ func (m Model) renderSummary() string {
 			summary = fmt.Sprintf("Log entries: %d", len(m.logs))
 		}
 
+	case ViewCapacity:
+		if m.selectedInstance != "" {
+			summary = m.capacitySummary()
+		}
+
 	case ViewTaskDefinitionFamilies:
 		if m.selectedInstance != "" {
 			active := 0
@@ -661,6 +678,35 @@ func (m Model) renderSummary() string {
 			summary = fmt.Sprintf("Listeners: %d | Load Balancer: %s",
 				len(m.listeners), lbName)
 		}
+
+	case ViewTargets:
+		if m.selectedTG != "" {
+			healthy := 0
+			unhealthy := 0
+			for _, target := range m.targets {
+				switch target.State {
+				case "healthy":
+					healthy++
+				case "unhealthy", "unavailable":
+					unhealthy++
+				}
+			}
+			summary = fmt.Sprintf("Targets: %d | Healthy: %d | Unhealthy: %d | Target Group: %s",
+				len(m.targets), healthy, unhealthy, m.targetGroupName(m.selectedTG))
+		}
+
+	case ViewListenerRules:
+		if m.selectedListener != "" {
+			listenerName := m.selectedListener
+			for _, listener := range m.listeners {
+				if listener.ARN == m.selectedListener {
+					listenerName = fmt.Sprintf("%s:%d", listener.Protocol, listener.Port)
+					break
+				}
+			}
+			summary = fmt.Sprintf("Rules: %d | Listener: %s",
+				len(m.listenerRules), listenerName)
+		}
 	}
 
 	if summary == "" {
@@ -782,14 +828,14 @@ func (m Model) renderInstancesList(maxHeight int) string {
 	)
 	header = instHeaderStyle.Render(header)
 
-	// Rows
-	rows := []string{header}
+	// Rows, starting with the counts aggregated over all instances
+	rows := []string{m.renderFederationSummary(), header}
 
 	// Get filtered instances
 	filteredInstances := m.filterInstances(m.instances)
 
 	// Calculate visible range with scrolling
-	visibleRows := maxHeight - 2 // Account for header and potential scroll indicator
+	visibleRows := maxHeight - 3 // Account for summary, header and potential scroll indicator
 	startIdx := 0
 	endIdx := len(filteredInstances)
 
@@ -1188,6 +1234,12 @@ func (m Model) renderServicesList(maxHeight int) string {
 		rows = append(rows, lipgloss.NewStyle().Foreground(lipgloss.Color("#666666")).Render(scrollInfo))
 	}
 
+	// Add filter indicator if filtering
+	if m.serviceFilter != "" {
+		filterInfo := fmt.Sprintf("\n[Filter: %s]", m.serviceFilter)
+		rows = append(rows, lipgloss.NewStyle().Foreground(lipgloss.Color("#ffff00")).Render(filterInfo))
+	}
+
 	// Add search indicator if searching
 	if m.searchMode || m.searchQuery != "" {
 		searchInfo := fmt.Sprintf("\n[Search: %s]", m.searchQuery)
@@ -0,0 +1,181 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/tui/api"
+)
+
+// actionsClient records the calls made by service and task actions
+type actionsClient struct {
+	api.Client
+	err          error
+	stopReason   string
+	scaledTo     int
+	restartedSvc string
+}
+
+func (c *actionsClient) StopTask(ctx context.Context, instanceName, clusterName, taskArn string, reason string) error {
+	c.stopReason = reason
+	return c.err
+}
+
+func (c *actionsClient) UpdateServiceDesiredCount(instanceName, clusterName, serviceNameOrArn string, desiredCount int) error {
+	c.scaledTo = desiredCount
+	return c.err
+}
+
+func (c *actionsClient) RestartService(instanceName, clusterName, serviceName string) error {
+	c.restartedSvc = serviceName
+	return c.err
+}
+
+func newActionsModel(client api.Client) Model {
+	m := NewModelWithClient(client)
+	m.selectedInstance = "dev"
+	m.selectedCluster = "default"
+	m.services = []Service{{Name: "web", Desired: 2, Status: "ACTIVE"}}
+	m.tasks = []Task{{ID: "abc123", ARN: "arn:aws:ecs:us-east-1:000000000000:task/default/abc123", Status: "RUNNING"}}
+	return m
+}
+
+func typeText(m Model, text string) Model {
+	for _, r := range text {
+		m, _ = m.handleTaskStopDialogKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return m
+}
+
+func TestServiceScaleOptimisticUpdate(t *testing.T) {
+	t.Run("rolls back the desired count when scaling fails", func(t *testing.T) {
+		client := &actionsClient{err: errors.New("boom")}
+		m := newActionsModel(client)
+		m.serviceScaleDialog = NewServiceScaleDialog("web", 2)
+		m.serviceScaleDialog.UpdateInput("5")
+
+		m, cmd := m.executeServiceScale()
+		if m.services[0].Desired != 5 {
+			t.Fatalf("expected optimistic desired count 5, got %d", m.services[0].Desired)
+		}
+
+		updated, _ := m.Update(cmd())
+		m = updated.(Model)
+		if client.scaledTo != 5 {
+			t.Errorf("expected UpdateService with desired count 5, got %d", client.scaledTo)
+		}
+		if m.services[0].Desired != 2 {
+			t.Errorf("expected desired count to be rolled back to 2, got %d", m.services[0].Desired)
+		}
+		if !m.toastIsError || !strings.Contains(m.toastMessage, "boom") {
+			t.Errorf("expected error toast, got %q", m.toastMessage)
+		}
+	})
+
+	t.Run("shows a toast when scaling succeeds", func(t *testing.T) {
+		m := newActionsModel(&actionsClient{})
+		m.serviceScaleDialog = NewServiceScaleDialog("web", 2)
+		m.serviceScaleDialog.UpdateInput("3")
+
+		m, cmd := m.executeServiceScale()
+		updated, _ := m.Update(cmd())
+		m = updated.(Model)
+		if m.services[0].Desired != 3 {
+			t.Errorf("expected desired count 3, got %d", m.services[0].Desired)
+		}
+		if m.toastIsError || !strings.Contains(m.toastMessage, "Scaled service web to 3") {
+			t.Errorf("unexpected toast %q", m.toastMessage)
+		}
+	})
+}
+
+func TestTaskStopDialog(t *testing.T) {
+	t.Run("stops the task with the entered reason", func(t *testing.T) {
+		client := &actionsClient{}
+		m := newActionsModel(client)
+		m, _ = m.executeTaskAction(ActionStopTask)
+		if m.taskStopDialog == nil {
+			t.Fatal("expected task stop dialog to open")
+		}
+
+		m = typeText(m, "rolling back")
+		m, _ = m.handleTaskStopDialogKeys(tea.KeyMsg{Type: tea.KeyEnter}) // focus Stop
+		m, cmd := m.handleTaskStopDialogKeys(tea.KeyMsg{Type: tea.KeyEnter})
+		if m.taskStopDialog != nil {
+			t.Error("expected dialog to close")
+		}
+		if m.tasks[0].Status != "STOPPING" {
+			t.Errorf("expected optimistic STOPPING status, got %s", m.tasks[0].Status)
+		}
+
+		msg := cmd()
+		if client.stopReason != "rolling back" {
+			t.Errorf("expected reason 'rolling back', got %q", client.stopReason)
+		}
+		updated, _ := m.Update(msg)
+		m = updated.(Model)
+		if m.toastIsError {
+			t.Errorf("unexpected error toast %q", m.toastMessage)
+		}
+	})
+
+	t.Run("uses the default reason when none is entered", func(t *testing.T) {
+		d := NewTaskStopDialog("abc123", "arn")
+		if d.GetReason() != defaultTaskStopReason {
+			t.Errorf("expected default reason, got %q", d.GetReason())
+		}
+	})
+
+	t.Run("restores the task status when stopping fails", func(t *testing.T) {
+		m := newActionsModel(&actionsClient{err: errors.New("denied")})
+		m.taskStopDialog = NewTaskStopDialog("abc123", m.tasks[0].ARN)
+
+		m, cmd := m.executeTaskStop()
+		updated, _ := m.Update(cmd())
+		m = updated.(Model)
+		if m.tasks[0].Status != "RUNNING" {
+			t.Errorf("expected status RUNNING after rollback, got %s", m.tasks[0].Status)
+		}
+		if !m.toastIsError || !strings.Contains(m.toastMessage, "denied") {
+			t.Errorf("expected error toast, got %q", m.toastMessage)
+		}
+	})
+}
+
+func TestServiceRestart(t *testing.T) {
+	t.Run("asks for confirmation before restarting", func(t *testing.T) {
+		m := newActionsModel(&actionsClient{})
+		m.currentView = ViewServices
+		m, _ = m.executeServiceAction(ActionRestartService)
+		if m.currentView != ViewConfirmDialog || m.confirmDialog == nil || m.pendingCommand == nil {
+			t.Fatal("expected restart confirmation dialog")
+		}
+	})
+
+	t.Run("forces a new deployment and rolls back the status on failure", func(t *testing.T) {
+		client := &actionsClient{err: errors.New("unavailable")}
+		m := newActionsModel(client)
+
+		updated, _ := m.Update(serviceRestartingMsg{serviceName: "web"})
+		m = updated.(Model)
+		if m.services[0].Status != "RESTARTING" {
+			t.Fatalf("expected RESTARTING status, got %s", m.services[0].Status)
+		}
+
+		updated, _ = m.Update(m.restartServiceCmd("web", "ACTIVE")())
+		m = updated.(Model)
+		if client.restartedSvc != "web" {
+			t.Errorf("expected restart of service web, got %q", client.restartedSvc)
+		}
+		if m.services[0].Status != "ACTIVE" {
+			t.Errorf("expected status ACTIVE after rollback, got %s", m.services[0].Status)
+		}
+		if !m.toastIsError {
+			t.Error("expected error toast")
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/tui/api"
+)
+
+// serviceFilterClient records the filter services are listed with
+type serviceFilterClient struct {
+	api.Client
+	filter string
+}
+
+func (c *serviceFilterClient) ListInstances(ctx context.Context) ([]api.Instance, error) {
+	return []api.Instance{{Name: "dev", Status: "running"}}, nil
+}
+
+func (c *serviceFilterClient) ListClusters(ctx context.Context, instanceName string) ([]string, error) {
+	return nil, nil
+}
+
+func (c *serviceFilterClient) ListServices(ctx context.Context, instanceName, clusterName, filter string) ([]string, error) {
+	c.filter = filter
+	if strings.Contains(filter, "bogus") {
+		return nil, errors.New(`invalid filter term "bogus": expected tag:KEY[=VALUE], launchType=VALUE or status=VALUE`)
+	}
+	return nil, nil
+}
+
+func (c *serviceFilterClient) ListTasks(ctx context.Context, instanceName, clusterName, serviceName string) ([]string, error) {
+	return nil, nil
+}
+
+func enterServiceFilter(m Model, filter string) (Model, tea.Cmd) {
+	m, _ = m.executeServiceAction(ActionFilterServices)
+	for _, r := range filter {
+		m, _ = m.handleServiceFilterInput(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return m.handleServiceFilterInput(tea.KeyMsg{Type: tea.KeyEnter})
+}
+
+func TestServiceFilter(t *testing.T) {
+	t.Run("lists services with the entered filter", func(t *testing.T) {
+		client := &serviceFilterClient{}
+		m := newActionsModel(client)
+
+		m, cmd := enterServiceFilter(m, "tag:team=payments status=ACTIVE")
+		if m.serviceFilterMode {
+			t.Error("expected filter input to close")
+		}
+		if m.serviceFilter != "tag:team=payments status=ACTIVE" {
+			t.Errorf("unexpected filter %q", m.serviceFilter)
+		}
+
+		updated, _ := m.Update(cmd())
+		m = updated.(Model)
+		if client.filter != "tag:team=payments status=ACTIVE" {
+			t.Errorf("expected ListServices with the filter, got %q", client.filter)
+		}
+		if m.toastMessage != "" {
+			t.Errorf("unexpected toast %q", m.toastMessage)
+		}
+	})
+
+	t.Run("shows an error toast for an invalid filter", func(t *testing.T) {
+		m := newActionsModel(&serviceFilterClient{})
+
+		m, cmd := enterServiceFilter(m, "bogus")
+		updated, _ := m.Update(cmd())
+		m = updated.(Model)
+		if !m.toastIsError || !strings.Contains(m.toastMessage, `invalid filter term "bogus"`) {
+			t.Errorf("expected error toast, got %q", m.toastMessage)
+		}
+	})
+
+	t.Run("keeps the filter when editing is cancelled", func(t *testing.T) {
+		m := newActionsModel(&serviceFilterClient{})
+		m.serviceFilter = "launchType=FARGATE"
+
+		m, _ = m.executeServiceAction(ActionFilterServices)
+		m, _ = m.handleServiceFilterInput(tea.KeyMsg{Type: tea.KeyBackspace})
+		m, cmd := m.handleServiceFilterInput(tea.KeyMsg{Type: tea.KeyEsc})
+		if cmd != nil || m.serviceFilterMode {
+			t.Error("expected filter input to close without reloading")
+		}
+		if m.serviceFilter != "launchType=FARGATE" {
+			t.Errorf("expected filter to be kept, got %q", m.serviceFilter)
+		}
+	})
+
+	t.Run("clears the filter when an empty filter is entered", func(t *testing.T) {
+		m := newActionsModel(&serviceFilterClient{})
+		m.serviceFilter = "launchType=FARGATE"
+
+		m, _ = m.executeServiceAction(ActionFilterServices)
+		for range "launchType=FARGATE" {
+			m, _ = m.handleServiceFilterInput(tea.KeyMsg{Type: tea.KeyBackspace})
+		}
+		m, _ = m.handleServiceFilterInput(tea.KeyMsg{Type: tea.KeyEnter})
+		if m.serviceFilter != "" {
+			t.Errorf("expected filter to be cleared, got %q", m.serviceFilter)
+		}
+	})
+}
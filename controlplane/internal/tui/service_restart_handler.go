@@ -0,0 +1,96 @@
+// Copyright 2025 The KECS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// serviceRestartingMsg marks a service as restarting once the restart is confirmed
+type serviceRestartingMsg struct {
+	serviceName string
+}
+
+// ServiceRestartedMsg is sent when a service restart request completes
+type ServiceRestartedMsg struct {
+	ServiceName    string
+	PreviousStatus string
+	Error          error
+}
+
+// RestartServiceDialog creates a confirmation dialog for restarting a service
+func RestartServiceDialog(serviceName string, onRestart func() error, onCancel func()) *ConfirmDialog {
+	title := "Restart Service"
+	message := fmt.Sprintf("Force a new deployment of service '%s'?\nAll of its tasks will be replaced.", serviceName)
+	return NewConfirmDialog(title, message, onRestart, onCancel)
+}
+
+// confirmServiceRestart opens the confirmation dialog for restarting the selected service
+func (m Model) confirmServiceRestart() (Model, tea.Cmd) {
+	if len(m.services) == 0 || m.serviceCursor >= len(m.services) {
+		return m, nil
+	}
+	service := m.services[m.serviceCursor]
+
+	m.confirmDialog = RestartServiceDialog(service.Name, func() error { return nil }, func() {})
+	m.pendingCommand = tea.Sequence(
+		func() tea.Msg { return serviceRestartingMsg{serviceName: service.Name} },
+		m.restartServiceCmd(service.Name, service.Status),
+	)
+	m.previousView = m.currentView
+	m.currentView = ViewConfirmDialog
+	return m, nil
+}
+
+// restartServiceCmd forces a new deployment of the service
+func (m Model) restartServiceCmd(serviceName, previousStatus string) tea.Cmd {
+	instanceName := m.selectedInstance
+	clusterName := m.selectedCluster
+	return func() tea.Msg {
+		err := m.apiClient.RestartService(instanceName, clusterName, serviceName)
+		if err != nil {
+			err = fmt.Errorf("failed to restart service: %w", err)
+		}
+		return ServiceRestartedMsg{
+			ServiceName:    serviceName,
+			PreviousStatus: previousStatus,
+			Error:          err,
+		}
+	}
+}
+
+// setServiceStatus updates the status of a service in the list
+func (m *Model) setServiceStatus(serviceName, status string) {
+	for i := range m.services {
+		if m.services[i].Name == serviceName {
+			m.services[i].Status = status
+		}
+	}
+}
+
+// handleServiceRestarted applies the outcome of a service restart request
+func (m Model) handleServiceRestarted(msg ServiceRestartedMsg) (Model, tea.Cmd) {
+	if msg.Error != nil {
+		m.setServiceStatus(msg.ServiceName, msg.PreviousStatus)
+		m.err = msg.Error
+		m.showToast(msg.Error.Error(), true)
+		return m, nil
+	}
+
+	m.showToast(fmt.Sprintf("Started a new deployment of service %s", msg.ServiceName), false)
+	return m, m.loadDataFromAPI()
+}
@@ -1,8 +1,6 @@
 package tui
 
 import (
-	"time"
-
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -71,63 +69,60 @@ func (m Model) executeServiceScale() (Model, tea.Cmd) {
 	desiredCount := d.GetDesiredCount()
 	serviceName := d.serviceName
 
-	// Close dialog and show progress
+	// Close dialog
 	m.serviceScaleDialog = nil
-	m.scalingInProgress = true
-	m.scalingServiceName = serviceName
-	m.scalingTargetCount = desiredCount
 
-	// Verify service exists
-	serviceFound := false
-	for _, svc := range m.services {
+	// Show the new desired count right away, it is rolled back if the request fails
+	serviceIndex := -1
+	for i, svc := range m.services {
 		if svc.Name == serviceName {
-			serviceFound = true
+			serviceIndex = i
 			break
 		}
 	}
 
-	if !serviceFound {
-		m.scalingInProgress = false
+	if serviceIndex < 0 {
 		return m, nil
 	}
 
+	previousCount := m.services[serviceIndex].Desired
+	m.services[serviceIndex].Desired = desiredCount
+	m.scalingInProgress = true
+	m.scalingServiceName = serviceName
+	m.scalingTargetCount = desiredCount
+
 	// Create the scale command (pass service name, not ARN)
-	return m, m.scaleService(serviceName, serviceName, desiredCount)
+	return m, m.scaleService(serviceName, serviceName, desiredCount, previousCount)
 }
 
 // scaleService creates a command to scale the service
-func (m Model) scaleService(serviceNameOrArn string, serviceName string, desiredCount int) tea.Cmd {
+func (m Model) scaleService(serviceNameOrArn string, serviceName string, desiredCount, previousCount int) tea.Cmd {
+	instanceName := m.selectedInstance
+	clusterName := m.selectedCluster
 	return func() tea.Msg {
 		// Call UpdateService API
 		err := m.apiClient.UpdateServiceDesiredCount(
-			m.selectedInstance,
-			m.selectedCluster,
+			instanceName,
+			clusterName,
 			serviceNameOrArn, // Pass service name, not full ARN
 			desiredCount,
 		)
 
-		if err != nil {
-			return ServiceScaledMsg{
-				Success: false,
-				Error:   err,
-			}
-		}
-
-		// Wait a bit to show progress
-		time.Sleep(1 * time.Second)
-
 		return ServiceScaledMsg{
-			Success:      true,
-			ServiceName:  serviceName,
-			DesiredCount: desiredCount,
+			Success:       err == nil,
+			ServiceName:   serviceName,
+			DesiredCount:  desiredCount,
+			PreviousCount: previousCount,
+			Error:         err,
 		}
 	}
 }
 
 // ServiceScaledMsg is sent when a service scaling operation completes
 type ServiceScaledMsg struct {
-	Success      bool
-	ServiceName  string
-	DesiredCount int
-	Error        error
+	Success       bool
+	ServiceName   string
+	DesiredCount  int
+	PreviousCount int
+	Error         error
 }
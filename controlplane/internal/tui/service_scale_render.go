@@ -1,7 +1,6 @@
 package tui
 
 import (
-	"fmt"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
@@ -106,43 +105,3 @@ func (m Model) renderServiceScaleDialog() string {
 		lipgloss.WithWhitespaceBackground(lipgloss.Color("#000000")),
 	)
 }
-
-// renderScalingProgress renders the scaling progress overlay
-func (m Model) renderScalingProgress() string {
-	var content []string
-
-	content = append(content, "")
-	content = append(content, formSuccessStyle.Render("⏳ Scaling Service..."))
-	content = append(content, "")
-	content = append(content, formLabelStyle.Render(fmt.Sprintf("Service: %s", m.scalingServiceName)))
-
-	if m.scalingTargetCount >= 0 {
-		content = append(content, formLabelStyle.Render(fmt.Sprintf("Target: %d task(s)", m.scalingTargetCount)))
-	}
-
-	content = append(content, "")
-	content = append(content, formHelpStyle.Render("Please wait..."))
-
-	// Join all content
-	progressContent := strings.Join(content, "\n")
-
-	// Apply dialog style
-	dialog := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("#666666")).
-		Background(lipgloss.Color("#1a1a1a")).
-		Foreground(lipgloss.Color("#ffffff")).
-		Padding(1, 2).
-		Width(50).
-		Render(progressContent)
-
-	// Center the dialog
-	return lipgloss.Place(
-		m.width,
-		m.height,
-		lipgloss.Center,
-		lipgloss.Center,
-		dialog,
-		lipgloss.WithWhitespaceBackground(lipgloss.Color("#000000")),
-	)
-}
@@ -0,0 +1,153 @@
+// Copyright 2025 The KECS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	"context"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+	"github.com/nandemo-ya/kecs/controlplane/internal/tui/api"
+)
+
+// subscribeRetryInterval is how long the TUI polls an instance whose
+// subscription failed before subscribing again
+const subscribeRetryInterval = 30 * time.Second
+
+// subscribedMsg is sent when the selected instance was subscribed to
+type subscribedMsg struct {
+	instance string
+	events   <-chan api.Event
+}
+
+// subscribeFailedMsg is sent when the selected instance could not be
+// subscribed to, e.g. because it runs a version without streaming API
+type subscribeFailedMsg struct {
+	instance string
+	err      error
+}
+
+// resourceEventMsg is sent when a resource of a subscribed instance changed
+type resourceEventMsg struct {
+	instance string
+	events   <-chan api.Event
+}
+
+// subscriptionEndedMsg is sent when the subscription of an instance ended
+type subscriptionEndedMsg struct {
+	instance string
+}
+
+// syncSubscription subscribes to the selected instance when the API client
+// streams updates, and unsubscribes from the instance selected before.
+// Polling keeps the data fresh until the subscription is established.
+func (m *Model) syncSubscription() tea.Cmd {
+	client, ok := m.apiClient.(api.StreamingClient)
+	if !ok || m.subscribedInstance == m.selectedInstance {
+		return nil
+	}
+
+	var cmds []tea.Cmd
+	if previous := m.subscribedInstance; previous != "" {
+		cmds = append(cmds, func() tea.Msg {
+			client.Unsubscribe(context.Background(), previous)
+			return nil
+		})
+	}
+	m.subscribedInstance = ""
+
+	instance := m.selectedInstance
+	if instance != "" && (instance != m.subscribeFailed || time.Now().After(m.subscribeRetryAt)) {
+		m.subscribedInstance = instance
+		cmds = append(cmds, func() tea.Msg {
+			events, err := client.Subscribe(context.Background(), instance)
+			if err != nil {
+				return subscribeFailedMsg{instance: instance, err: err}
+			}
+			return subscribedMsg{instance: instance, events: events}
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+// waitForEventCmd waits for the next event of a subscription
+func waitForEventCmd(instance string, events <-chan api.Event) tea.Cmd {
+	return func() tea.Msg {
+		if _, ok := <-events; !ok {
+			return subscriptionEndedMsg{instance: instance}
+		}
+		// Events arriving meanwhile are covered by the reload of this one
+		for {
+			select {
+			case _, ok := <-events:
+				if !ok {
+					return resourceEventMsg{instance: instance}
+				}
+			default:
+				return resourceEventMsg{instance: instance, events: events}
+			}
+		}
+	}
+}
+
+// handleSubscriptionMsg handles the messages of subscriptions
+func (m *Model) handleSubscriptionMsg(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case subscribedMsg:
+		if msg.instance != m.subscribedInstance {
+			// Another instance was selected while subscribing
+			if client, ok := m.apiClient.(api.StreamingClient); ok && msg.instance != m.selectedInstance {
+				return func() tea.Msg {
+					client.Unsubscribe(context.Background(), msg.instance)
+					return nil
+				}
+			}
+			return nil
+		}
+		m.subscribeFailed = ""
+		return waitForEventCmd(msg.instance, msg.events)
+
+	case subscribeFailedMsg:
+		logging.Debug("Failed to subscribe to instance, polling instead", "instance", msg.instance, "error", msg.err)
+		if msg.instance == m.subscribedInstance {
+			m.subscribedInstance = ""
+			m.subscribeFailed = msg.instance
+			m.subscribeRetryAt = time.Now().Add(subscribeRetryInterval)
+		}
+		return nil
+
+	case resourceEventMsg:
+		if msg.instance != m.selectedInstance {
+			return nil
+		}
+		m.lastUpdate = time.Now()
+		cmds := []tea.Cmd{m.loadDataFromAPI()}
+		if msg.events != nil {
+			cmds = append(cmds, waitForEventCmd(msg.instance, msg.events))
+		} else if msg.instance == m.subscribedInstance {
+			m.subscribedInstance = ""
+		}
+		return tea.Batch(cmds...)
+
+	case subscriptionEndedMsg:
+		if msg.instance == m.subscribedInstance {
+			m.subscribedInstance = ""
+		}
+		return nil
+	}
+	return nil
+}
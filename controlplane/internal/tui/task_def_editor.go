@@ -3,6 +3,8 @@ package tui
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"os/exec"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -58,6 +60,17 @@ func (e *TaskDefinitionEditor) Update(msg tea.Msg) (*TaskDefinitionEditor, tea.C
 		e.cursorCol = 0
 		e.validateJSON()
 		return e, nil
+	case externalEditorMsg:
+		// Load the content edited in $EDITOR
+		if msg.err != nil {
+			e.errors = []ValidationError{{Message: msg.err.Error()}}
+			return e, nil
+		}
+		e.content = msg.content
+		e.cursorLine = 0
+		e.cursorCol = 0
+		e.validateJSON()
+		return e, nil
 	}
 
 	return e, nil
@@ -180,6 +193,10 @@ func (e *TaskDefinitionEditor) handleNormalMode(msg tea.KeyMsg) (*TaskDefinition
 		// Format JSON
 		return e, e.formatJSON()
 
+	case "ctrl+e":
+		// Edit in $EDITOR
+		return e, e.openExternalEditor()
+
 	case "ctrl+q":
 		// Quick quit without saving
 		return e, func() tea.Msg {
@@ -464,13 +481,13 @@ func (e *TaskDefinitionEditor) executeCommand(command string) tea.Cmd {
 		}
 
 	case "wq":
-		// Save and quit
-		return tea.Sequence(
-			e.saveTaskDefinition(),
-			func() tea.Msg {
-				return editorQuitMsg{}
-			},
-		)
+		// Save and quit. A successful save closes the editor, a failed one
+		// keeps it open so the changes are not lost.
+		return e.saveTaskDefinition()
+
+	case "e", "edit":
+		// Edit in $EDITOR
+		return e.openExternalEditor()
 
 	case "format", "fmt":
 		// Format JSON
@@ -507,6 +524,48 @@ func (e *TaskDefinitionEditor) saveTaskDefinition() tea.Cmd {
 	}
 }
 
+// openExternalEditor hands the terminal over to $VISUAL or $EDITOR (vi when
+// neither is set) to edit the content in a temporary file
+func (e *TaskDefinitionEditor) openExternalEditor() tea.Cmd {
+	file, err := os.CreateTemp("", e.family+"-*.json")
+	if err != nil {
+		return func() tea.Msg {
+			return externalEditorMsg{err: fmt.Errorf("failed to create temporary file: %w", err)}
+		}
+	}
+	path := file.Name()
+	_, err = file.WriteString(e.content)
+	file.Close()
+	if err != nil {
+		os.Remove(path)
+		return func() tea.Msg {
+			return externalEditorMsg{err: fmt.Errorf("failed to write temporary file: %w", err)}
+		}
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	args := strings.Fields(editor)
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		defer os.Remove(path)
+		if err != nil {
+			return externalEditorMsg{err: fmt.Errorf("%s exited with error: %w", args[0], err)}
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return externalEditorMsg{err: fmt.Errorf("failed to read temporary file: %w", err)}
+		}
+		return externalEditorMsg{content: string(content)}
+	})
+}
+
 // Render renders the editor view
 func (e *TaskDefinitionEditor) Render(width, height int) string {
 	// Styles
@@ -641,7 +700,7 @@ func (e *TaskDefinitionEditor) Render(width, height int) string {
 	} else {
 		// Show help
 		helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#6c7086"))
-		helpText := "i: insert | :w: save | :q: quit | ^Q: quick quit | v: validate | ^F: format | ESC: exit"
+		helpText := "i: insert | :w: save | :q: quit | ^Q: quick quit | ^E: $EDITOR | v: validate | ^F: format | ESC: exit"
 		bottomLine = helpStyle.Render(helpText)
 	}
 
@@ -785,8 +844,11 @@ func (e *TaskDefinitionEditor) moveToPrevWord(lines []string) {
 
 // Message types for editor
 type editorSaveMsg struct {
-	family   string
-	revision int
+	family     string
+	revision   int
+	taskDefArn string
+	services   []string // services of the current cluster running another revision of the family
+	err        error
 }
 
 type editorSaveRequestMsg struct {
@@ -795,3 +857,8 @@ type editorSaveRequestMsg struct {
 }
 
 type editorQuitMsg struct{}
+
+type externalEditorMsg struct {
+	content string
+	err     error
+}
@@ -0,0 +1,171 @@
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// readOnlyTaskDefFields are returned by DescribeTaskDefinition but rejected by
+// RegisterTaskDefinition, so they are dropped from the edited JSON
+var readOnlyTaskDefFields = []string{
+	"taskDefinitionArn",
+	"revision",
+	"status",
+	"registeredAt",
+	"registeredBy",
+	"deregisteredAt",
+	"requiresAttributes",
+	"compatibilities",
+}
+
+// taskDefServicesUpdatedMsg is sent when services have been moved to a new revision
+type taskDefServicesUpdatedMsg struct {
+	taskDef  string
+	services []string
+	err      error
+}
+
+// registerTaskDefinitionInput converts editor content into a RegisterTaskDefinition request
+func registerTaskDefinitionInput(content string) (map[string]interface{}, error) {
+	var taskDef map[string]interface{}
+	if err := json.Unmarshal([]byte(content), &taskDef); err != nil {
+		return nil, fmt.Errorf("failed to parse task definition JSON: %w", err)
+	}
+	for _, field := range readOnlyTaskDefFields {
+		delete(taskDef, field)
+	}
+	return taskDef, nil
+}
+
+// revisionFromArn returns the revision of a task definition ARN or family:revision
+func revisionFromArn(taskDefArn string) int {
+	parts := strings.Split(taskDefArn, ":")
+	revision, err := strconv.Atoi(parts[len(parts)-1])
+	if err != nil {
+		return 0
+	}
+	return revision
+}
+
+// registerTaskDefinitionCmd registers the edited task definition as a new
+// revision and looks up the services of the current cluster that run the family
+func (m Model) registerTaskDefinitionCmd(family, content string) tea.Cmd {
+	instanceName := m.selectedInstance
+	clusterName := m.selectedCluster
+	return func() tea.Msg {
+		taskDef, err := registerTaskDefinitionInput(content)
+		if err != nil {
+			return editorSaveMsg{family: family, err: err}
+		}
+		if f, ok := taskDef["family"].(string); ok && f != "" {
+			family = f
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		taskDefArn, err := m.apiClient.RegisterTaskDefinition(ctx, instanceName, taskDef)
+		if err != nil {
+			return editorSaveMsg{family: family, err: fmt.Errorf("failed to register task definition: %w", err)}
+		}
+
+		msg := editorSaveMsg{
+			family:     family,
+			revision:   revisionFromArn(taskDefArn),
+			taskDefArn: taskDefArn,
+		}
+		if clusterName != "" {
+			// Failing to find services only skips the update offer
+			msg.services, _ = m.servicesUsingFamily(ctx, instanceName, clusterName, family, msg.revision)
+		}
+		return msg
+	}
+}
+
+// servicesUsingFamily returns the services of a cluster that run another
+// revision of the given family
+func (m Model) servicesUsingFamily(ctx context.Context, instanceName, clusterName, family string, revision int) ([]string, error) {
+	serviceArns, err := m.apiClient.ListServices(ctx, instanceName, clusterName, "")
+	if err != nil || len(serviceArns) == 0 {
+		return nil, err
+	}
+	services, err := m.apiClient.DescribeServices(ctx, instanceName, clusterName, serviceArns)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, svc := range services {
+		taskDef := formatTaskDefinition(svc.TaskDefinition)
+		if strings.HasPrefix(taskDef, family+":") && revisionFromArn(taskDef) != revision {
+			names = append(names, svc.ServiceName)
+		}
+	}
+	return names, nil
+}
+
+// handleTaskDefinitionSaved reports a save and offers to move the services of
+// the family to the new revision
+func (m Model) handleTaskDefinitionSaved(msg editorSaveMsg) (Model, tea.Cmd) {
+	if msg.err != nil {
+		// Keep the editor open so the changes are not lost
+		m.showToast(msg.err.Error(), true)
+		return m, nil
+	}
+
+	taskDef := fmt.Sprintf("%s:%d", msg.family, msg.revision)
+	m.showToast(fmt.Sprintf("Registered task definition %s", taskDef), false)
+	m.currentView = ViewTaskDefinitionRevisions
+	m.taskDefEditor = nil
+	if msg.family != m.selectedFamily {
+		m.selectedFamily = msg.family
+		m.taskDefRevisionCursor = 0
+	}
+
+	if len(msg.services) > 0 {
+		title := "Update Services"
+		message := fmt.Sprintf("Update %d service(s) using %s to %s?\n%s",
+			len(msg.services), msg.family, taskDef, strings.Join(msg.services, ", "))
+		m.confirmDialog = NewConfirmDialog(title, message, func() error { return nil }, func() {})
+		m.pendingCommand = m.updateFamilyServicesCmd(msg.services, taskDef)
+		m.previousView = ViewTaskDefinitionRevisions
+		m.currentView = ViewConfirmDialog
+	}
+	return m, m.loadTaskDefinitionRevisionsCmd()
+}
+
+// updateFamilyServicesCmd moves services to a new task definition revision
+func (m Model) updateFamilyServicesCmd(services []string, taskDef string) tea.Cmd {
+	instanceName := m.selectedInstance
+	clusterName := m.selectedCluster
+	return func() tea.Msg {
+		var updated []string
+		for _, service := range services {
+			if err := m.apiClient.UpdateServiceTaskDefinition(instanceName, clusterName, service, taskDef); err != nil {
+				return taskDefServicesUpdatedMsg{
+					taskDef:  taskDef,
+					services: updated,
+					err:      fmt.Errorf("failed to update service %s: %w", service, err),
+				}
+			}
+			updated = append(updated, service)
+		}
+		return taskDefServicesUpdatedMsg{taskDef: taskDef, services: updated}
+	}
+}
+
+// handleTaskDefServicesUpdated reports the outcome of a service update
+func (m Model) handleTaskDefServicesUpdated(msg taskDefServicesUpdatedMsg) (Model, tea.Cmd) {
+	if msg.err != nil {
+		m.showToast(msg.err.Error(), true)
+		return m, nil
+	}
+	m.showToast(fmt.Sprintf("Updated %d service(s) to %s", len(msg.services), msg.taskDef), false)
+	return m, nil
+}
@@ -0,0 +1,115 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/tui/api"
+)
+
+const editedTaskDef = `{
+  "taskDefinitionArn": "arn:aws:ecs:us-east-1:000000000000:task-definition/web:3",
+  "family": "web",
+  "revision": 3,
+  "status": "ACTIVE",
+  "registeredAt": "2025-10-01T00:00:00Z",
+  "containerDefinitions": [{"name": "web", "image": "nginx:1.29"}]
+}`
+
+// taskDefClient records the calls made when saving a task definition
+type taskDefClient struct {
+	api.Client
+	err        error
+	registered map[string]interface{}
+	updated    []string
+}
+
+func (c *taskDefClient) RegisterTaskDefinition(ctx context.Context, instanceName string, taskDef interface{}) (string, error) {
+	if c.err != nil {
+		return "", c.err
+	}
+	c.registered = taskDef.(map[string]interface{})
+	return "arn:aws:ecs:us-east-1:000000000000:task-definition/web:4", nil
+}
+
+func (c *taskDefClient) ListServices(ctx context.Context, instanceName, clusterName, filter string) ([]string, error) {
+	return []string{"web", "worker", "api"}, nil
+}
+
+func (c *taskDefClient) DescribeServices(ctx context.Context, instanceName, clusterName string, serviceNames []string) ([]api.Service, error) {
+	return []api.Service{
+		{ServiceName: "web", TaskDefinition: "arn:aws:ecs:us-east-1:000000000000:task-definition/web:3"},
+		{ServiceName: "worker", TaskDefinition: "arn:aws:ecs:us-east-1:000000000000:task-definition/worker:1"},
+		{ServiceName: "api", TaskDefinition: "arn:aws:ecs:us-east-1:000000000000:task-definition/web:4"},
+	}, nil
+}
+
+func (c *taskDefClient) UpdateServiceTaskDefinition(instanceName, clusterName, serviceName, taskDefinition string) error {
+	c.updated = append(c.updated, serviceName+"="+taskDefinition)
+	return nil
+}
+
+func newTaskDefModel(client api.Client) Model {
+	m := NewModelWithClient(client)
+	m.selectedInstance = "dev"
+	m.selectedCluster = "default"
+	m.selectedFamily = "web"
+	m.taskDefEditor = NewTaskDefinitionEditor("web", nil)
+	m.currentView = ViewTaskDefinitionEditor
+	return m
+}
+
+func TestRegisterEditedTaskDefinition(t *testing.T) {
+	t.Run("registers a new revision and offers to update services", func(t *testing.T) {
+		client := &taskDefClient{}
+		m := newTaskDefModel(client)
+
+		msg := m.registerTaskDefinitionCmd("web", editedTaskDef)().(editorSaveMsg)
+		if msg.err != nil || msg.revision != 4 {
+			t.Fatalf("expected revision 4, got %+v", msg)
+		}
+		for _, field := range readOnlyTaskDefFields {
+			if _, found := client.registered[field]; found {
+				t.Errorf("read-only field %s was sent to RegisterTaskDefinition", field)
+			}
+		}
+		if len(msg.services) != 1 || msg.services[0] != "web" {
+			t.Fatalf("expected only service web to be offered, got %v", msg.services)
+		}
+
+		m, _ = m.handleTaskDefinitionSaved(msg)
+		if m.taskDefEditor != nil || m.currentView != ViewConfirmDialog || m.pendingCommand == nil {
+			t.Fatal("expected the editor to close and the service update to be offered")
+		}
+
+		updated := m.pendingCommand().(taskDefServicesUpdatedMsg)
+		if updated.err != nil || len(client.updated) != 1 || client.updated[0] != "web=web:4" {
+			t.Errorf("unexpected service updates %v (%v)", client.updated, updated.err)
+		}
+	})
+
+	t.Run("keeps the editor open when registration fails", func(t *testing.T) {
+		m := newTaskDefModel(&taskDefClient{err: errors.New("ClientException")})
+
+		msg := m.registerTaskDefinitionCmd("web", editedTaskDef)().(editorSaveMsg)
+		m, _ = m.handleTaskDefinitionSaved(msg)
+		if m.taskDefEditor == nil || m.currentView != ViewTaskDefinitionEditor || !m.toastIsError {
+			t.Errorf("expected error toast in the editor, got view %s", m.currentView)
+		}
+	})
+}
+
+func TestExternalEditorContent(t *testing.T) {
+	editor := NewTaskDefinitionEditor("web", nil)
+
+	editor, _ = editor.Update(externalEditorMsg{content: editedTaskDef})
+	if editor.content != editedTaskDef || len(editor.errors) != 0 {
+		t.Errorf("expected edited content without errors, got %v", editor.errors)
+	}
+
+	editor, _ = editor.Update(externalEditorMsg{err: errors.New("vi exited with error")})
+	if editor.content != editedTaskDef || len(editor.errors) != 1 {
+		t.Errorf("expected content to be kept and the error shown, got %v", editor.errors)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2025 The KECS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultTaskStopReason is used when the stop reason is left empty
+const defaultTaskStopReason = "User requested stop via TUI"
+
+// maxTaskStopReasonLength is the maximum length ECS accepts for a stop reason
+const maxTaskStopReasonLength = 255
+
+// TaskStopDialog asks for the reason before stopping a task
+type TaskStopDialog struct {
+	taskID        string
+	taskArn       string
+	reason        string
+	errorMsg      string
+	focusedButton int // -1: Input field, 0: Stop, 1: Cancel
+}
+
+// NewTaskStopDialog creates a new task stop dialog
+func NewTaskStopDialog(taskID, taskArn string) *TaskStopDialog {
+	return &TaskStopDialog{
+		taskID:        taskID,
+		taskArn:       taskArn,
+		focusedButton: -1, // Start with input field focused
+	}
+}
+
+// AppendInput appends typed text to the reason
+func (d *TaskStopDialog) AppendInput(input string) {
+	if len(d.reason)+len(input) > maxTaskStopReasonLength {
+		d.errorMsg = fmt.Sprintf("Reason cannot exceed %d characters", maxTaskStopReasonLength)
+		return
+	}
+	d.reason += input
+	d.errorMsg = ""
+}
+
+// RemoveLastChar removes the last character from the reason
+func (d *TaskStopDialog) RemoveLastChar() {
+	if len(d.reason) > 0 {
+		d.reason = d.reason[:len(d.reason)-1]
+		d.errorMsg = ""
+	}
+}
+
+// MoveFocus cycles focus through the input field and the buttons
+func (d *TaskStopDialog) MoveFocus() {
+	d.focusedButton = (d.focusedButton+2)%3 - 1 // -1, 0, 1
+}
+
+// GetReason returns the stop reason, falling back to the default reason
+func (d *TaskStopDialog) GetReason() string {
+	if reason := strings.TrimSpace(d.reason); reason != "" {
+		return reason
+	}
+	return defaultTaskStopReason
+}
+
+// IsInputFocused returns true if the input field is focused
+func (d *TaskStopDialog) IsInputFocused() bool {
+	return d.focusedButton == -1
+}
+
+// IsStopFocused returns true if the Stop button is focused
+func (d *TaskStopDialog) IsStopFocused() bool {
+	return d.focusedButton == 0
+}
+
+// IsCancelFocused returns true if the Cancel button is focused
+func (d *TaskStopDialog) IsCancelFocused() bool {
+	return d.focusedButton == 1
+}
+
+// GetMessage returns the dialog message
+func (d *TaskStopDialog) GetMessage() string {
+	return fmt.Sprintf("Stop task '%s'?", d.taskID)
+}
@@ -0,0 +1,127 @@
+// Copyright 2025 The KECS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TaskStoppedMsg is sent when a task stop request completes
+type TaskStoppedMsg struct {
+	TaskID         string
+	TaskArn        string
+	PreviousStatus string
+	Error          error
+}
+
+// handleTaskStopDialogKeys handles keyboard input for the task stop dialog
+func (m Model) handleTaskStopDialogKeys(msg tea.KeyMsg) (Model, tea.Cmd) {
+	d := m.taskStopDialog
+
+	switch msg.String() {
+	case "esc":
+		m.taskStopDialog = nil
+		return m, nil
+
+	case "tab":
+		d.MoveFocus()
+
+	case "enter":
+		switch {
+		case d.IsInputFocused():
+			// Move to the Stop button
+			d.focusedButton = 0
+		case d.IsStopFocused():
+			return m.executeTaskStop()
+		case d.IsCancelFocused():
+			m.taskStopDialog = nil
+		}
+
+	case "backspace":
+		if d.IsInputFocused() {
+			d.RemoveLastChar()
+		}
+
+	default:
+		if d.IsInputFocused() && len(msg.Runes) > 0 {
+			d.AppendInput(string(msg.Runes))
+		}
+	}
+
+	return m, nil
+}
+
+// executeTaskStop marks the task as stopping right away and sends the StopTask request
+func (m Model) executeTaskStop() (Model, tea.Cmd) {
+	d := m.taskStopDialog
+	m.taskStopDialog = nil
+	if d == nil {
+		return m, nil
+	}
+
+	previousStatus := ""
+	for i := range m.tasks {
+		if m.tasks[i].ARN == d.taskArn {
+			previousStatus = m.tasks[i].Status
+			m.tasks[i].Status = "STOPPING"
+			break
+		}
+	}
+
+	return m, m.stopTaskCmd(d.taskID, d.taskArn, d.GetReason(), previousStatus)
+}
+
+// stopTaskCmd stops a running task with the given reason
+func (m Model) stopTaskCmd(taskID, taskArn, reason, previousStatus string) tea.Cmd {
+	instanceName := m.selectedInstance
+	clusterName := m.selectedCluster
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		err := m.apiClient.StopTask(ctx, instanceName, clusterName, taskArn, reason)
+		if err != nil {
+			err = fmt.Errorf("failed to stop task: %w", err)
+		}
+		return TaskStoppedMsg{
+			TaskID:         taskID,
+			TaskArn:        taskArn,
+			PreviousStatus: previousStatus,
+			Error:          err,
+		}
+	}
+}
+
+// handleTaskStopped applies the outcome of a task stop request
+func (m Model) handleTaskStopped(msg TaskStoppedMsg) (Model, tea.Cmd) {
+	if msg.Error != nil {
+		// Roll back the optimistic status change
+		for i := range m.tasks {
+			if m.tasks[i].ARN == msg.TaskArn && msg.PreviousStatus != "" {
+				m.tasks[i].Status = msg.PreviousStatus
+			}
+		}
+		m.err = msg.Error
+		m.showToast(msg.Error.Error(), true)
+		return m, nil
+	}
+
+	m.showToast(fmt.Sprintf("Stopping task %s", msg.TaskID), false)
+	return m, m.loadDataFromAPI()
+}
@@ -0,0 +1,90 @@
+// Copyright 2025 The KECS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// renderTaskStopDialog renders the task stop dialog
+func (m Model) renderTaskStopDialog() string {
+	if m.taskStopDialog == nil {
+		return ""
+	}
+
+	d := m.taskStopDialog
+	var content []string
+
+	content = append(content, renderDialogTitle("Stop Task", false, false, 66))
+	content = append(content, "")
+	content = append(content, d.GetMessage())
+	content = append(content, "")
+
+	// Reason input
+	content = append(content, "Reason (optional):")
+	inputStyle := formInputStyle
+	if d.IsInputFocused() {
+		inputStyle = formInputFocusedStyle
+	}
+	inputValue := d.reason
+	if d.IsInputFocused() {
+		inputValue += "_"
+	} else if inputValue == "" {
+		inputValue = defaultTaskStopReason
+	}
+	content = append(content, inputStyle.Width(60).Render(inputValue))
+
+	if d.errorMsg != "" {
+		content = append(content, formErrorStyle.Render(d.errorMsg))
+	}
+	content = append(content, "")
+
+	// Buttons
+	stopBtn := m.renderFormButton("Stop", d.IsStopFocused())
+	cancelBtn := m.renderFormButton("Cancel", d.IsCancelFocused())
+	buttons := lipgloss.JoinHorizontal(lipgloss.Top, stopBtn, cancelBtn)
+
+	// Center the buttons
+	buttonsWidth := lipgloss.Width(buttons)
+	formWidth := 66
+	if buttonsWidth < formWidth {
+		padding := (formWidth - buttonsWidth) / 2
+		buttons = strings.Repeat(" ", padding) + buttons
+	}
+	content = append(content, buttons)
+
+	content = append(content, "")
+	content = append(content, formHelpStyle.Render("[Tab] Navigate  [Enter] Select  [Esc] Cancel"))
+
+	dialog := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#666666")).
+		Background(lipgloss.Color("#1a1a1a")).
+		Foreground(lipgloss.Color("#ffffff")).
+		Padding(1, 2).
+		Width(70).
+		Render(strings.Join(content, "\n"))
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		dialog,
+		lipgloss.WithWhitespaceBackground(lipgloss.Color("#000000")),
+	)
+}
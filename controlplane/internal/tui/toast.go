@@ -0,0 +1,40 @@
+// Copyright 2025 The KECS Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	"time"
+)
+
+// toastDuration is how long a toast stays in the footer
+const toastDuration = 5 * time.Second
+
+// showToast shows a short notification about the outcome of an action in the footer
+func (m *Model) showToast(message string, isError bool) {
+	m.toastMessage = message
+	m.toastIsError = isError
+	m.toastTime = time.Now()
+}
+
+// renderToast renders the active toast, or an empty string if there is none
+func (m Model) renderToast() string {
+	if m.toastMessage == "" || time.Since(m.toastTime) >= toastDuration {
+		return ""
+	}
+	if m.toastIsError {
+		return errorStyle.Render("✗ " + m.toastMessage)
+	}
+	return successStyle.Render("✓ " + m.toastMessage)
+}
@@ -60,10 +60,12 @@ type Container struct {
 
 // NetworkBinding represents a network binding for a container
 type NetworkBinding struct {
-	BindIP        string `json:"bindIP,omitempty"`
-	ContainerPort int    `json:"containerPort,omitempty"`
-	HostPort      int    `json:"hostPort,omitempty"`
-	Protocol      string `json:"protocol,omitempty"`
+	BindIP             string `json:"bindIP,omitempty"`
+	ContainerPort      int    `json:"containerPort,omitempty"`
+	ContainerPortRange string `json:"containerPortRange,omitempty"`
+	HostPort           int    `json:"hostPort,omitempty"`
+	HostPortRange      string `json:"hostPortRange,omitempty"`
+	Protocol           string `json:"protocol,omitempty"`
 }
 
 // NetworkInterface represents a network interface for a container
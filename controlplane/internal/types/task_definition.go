@@ -30,53 +30,62 @@ type TaskDefinition struct {
 
 // ContainerDefinition represents a container definition in a task definition
 type ContainerDefinition struct {
-	Name                   *string                `json:"name"`
-	Image                  *string                `json:"image"`
-	Cpu                    *int                   `json:"cpu,omitempty"`
-	Memory                 *int                   `json:"memory,omitempty"`
-	MemoryReservation      *int                   `json:"memoryReservation,omitempty"`
-	Links                  []string               `json:"links,omitempty"`
-	PortMappings           []PortMapping          `json:"portMappings,omitempty"`
-	Essential              *bool                  `json:"essential,omitempty"`
-	EntryPoint             []string               `json:"entryPoint,omitempty"`
-	Command                []string               `json:"command,omitempty"`
-	Environment            []KeyValuePair         `json:"environment,omitempty"`
-	EnvironmentFiles       []EnvironmentFile      `json:"environmentFiles,omitempty"`
-	MountPoints            []MountPoint           `json:"mountPoints,omitempty"`
-	VolumesFrom            []VolumeFrom           `json:"volumesFrom,omitempty"`
-	LinuxParameters        *LinuxParameters       `json:"linuxParameters,omitempty"`
-	Secrets                []Secret               `json:"secrets,omitempty"`
-	DependsOn              []ContainerDependency  `json:"dependsOn,omitempty"`
-	StartTimeout           *int                   `json:"startTimeout,omitempty"`
-	StopTimeout            *int                   `json:"stopTimeout,omitempty"`
-	Hostname               *string                `json:"hostname,omitempty"`
-	User                   *string                `json:"user,omitempty"`
-	WorkingDirectory       *string                `json:"workingDirectory,omitempty"`
-	DisableNetworking      *bool                  `json:"disableNetworking,omitempty"`
-	Privileged             *bool                  `json:"privileged,omitempty"`
-	ReadonlyRootFilesystem *bool                  `json:"readonlyRootFilesystem,omitempty"`
-	DnsServers             []string               `json:"dnsServers,omitempty"`
-	DnsSearchDomains       []string               `json:"dnsSearchDomains,omitempty"`
-	ExtraHosts             []HostEntry            `json:"extraHosts,omitempty"`
-	DockerSecurityOptions  []string               `json:"dockerSecurityOptions,omitempty"`
-	Interactive            *bool                  `json:"interactive,omitempty"`
-	PseudoTerminal         *bool                  `json:"pseudoTerminal,omitempty"`
-	DockerLabels           map[string]string      `json:"dockerLabels,omitempty"`
-	Ulimits                []Ulimit               `json:"ulimits,omitempty"`
-	LogConfiguration       *LogConfiguration      `json:"logConfiguration,omitempty"`
-	HealthCheck            *HealthCheck           `json:"healthCheck,omitempty"`
-	SystemControls         []SystemControl        `json:"systemControls,omitempty"`
-	ResourceRequirements   []ResourceRequirement  `json:"resourceRequirements,omitempty"`
-	FirelensConfiguration  *FirelensConfiguration `json:"firelensConfiguration,omitempty"`
-	Artifacts              []Artifact             `json:"artifacts,omitempty"`
+	Name                   *string                 `json:"name"`
+	Image                  *string                 `json:"image"`
+	Cpu                    *int                    `json:"cpu,omitempty"`
+	Memory                 *int                    `json:"memory,omitempty"`
+	MemoryReservation      *int                    `json:"memoryReservation,omitempty"`
+	Links                  []string                `json:"links,omitempty"`
+	PortMappings           []PortMapping           `json:"portMappings,omitempty"`
+	Essential              *bool                   `json:"essential,omitempty"`
+	EntryPoint             []string                `json:"entryPoint,omitempty"`
+	Command                []string                `json:"command,omitempty"`
+	Environment            []KeyValuePair          `json:"environment,omitempty"`
+	EnvironmentFiles       []EnvironmentFile       `json:"environmentFiles,omitempty"`
+	MountPoints            []MountPoint            `json:"mountPoints,omitempty"`
+	VolumesFrom            []VolumeFrom            `json:"volumesFrom,omitempty"`
+	LinuxParameters        *LinuxParameters        `json:"linuxParameters,omitempty"`
+	Secrets                []Secret                `json:"secrets,omitempty"`
+	DependsOn              []ContainerDependency   `json:"dependsOn,omitempty"`
+	StartTimeout           *int                    `json:"startTimeout,omitempty"`
+	StopTimeout            *int                    `json:"stopTimeout,omitempty"`
+	Hostname               *string                 `json:"hostname,omitempty"`
+	User                   *string                 `json:"user,omitempty"`
+	WorkingDirectory       *string                 `json:"workingDirectory,omitempty"`
+	DisableNetworking      *bool                   `json:"disableNetworking,omitempty"`
+	Privileged             *bool                   `json:"privileged,omitempty"`
+	ReadonlyRootFilesystem *bool                   `json:"readonlyRootFilesystem,omitempty"`
+	DnsServers             []string                `json:"dnsServers,omitempty"`
+	DnsSearchDomains       []string                `json:"dnsSearchDomains,omitempty"`
+	ExtraHosts             []HostEntry             `json:"extraHosts,omitempty"`
+	DockerSecurityOptions  []string                `json:"dockerSecurityOptions,omitempty"`
+	Interactive            *bool                   `json:"interactive,omitempty"`
+	PseudoTerminal         *bool                   `json:"pseudoTerminal,omitempty"`
+	DockerLabels           map[string]string       `json:"dockerLabels,omitempty"`
+	Ulimits                []Ulimit                `json:"ulimits,omitempty"`
+	LogConfiguration       *LogConfiguration       `json:"logConfiguration,omitempty"`
+	HealthCheck            *HealthCheck            `json:"healthCheck,omitempty"`
+	SystemControls         []SystemControl         `json:"systemControls,omitempty"`
+	ResourceRequirements   []ResourceRequirement   `json:"resourceRequirements,omitempty"`
+	FirelensConfiguration  *FirelensConfiguration  `json:"firelensConfiguration,omitempty"`
+	Artifacts              []Artifact              `json:"artifacts,omitempty"`
+	RestartPolicy          *ContainerRestartPolicy `json:"restartPolicy,omitempty"`
+}
+
+// ContainerRestartPolicy represents the restart policy of a container
+type ContainerRestartPolicy struct {
+	Enabled              bool  `json:"enabled"`
+	IgnoredExitCodes     []int `json:"ignoredExitCodes,omitempty"`
+	RestartAttemptPeriod *int  `json:"restartAttemptPeriod,omitempty"`
 }
 
 // PortMapping represents a port mapping for a container
 type PortMapping struct {
-	ContainerPort *int    `json:"containerPort"`
-	HostPort      *int    `json:"hostPort,omitempty"`
-	Protocol      *string `json:"protocol,omitempty"`
-	Name          *string `json:"name,omitempty"`
+	ContainerPort      *int    `json:"containerPort"`
+	ContainerPortRange *string `json:"containerPortRange,omitempty"`
+	HostPort           *int    `json:"hostPort,omitempty"`
+	Protocol           *string `json:"protocol,omitempty"`
+	Name               *string `json:"name,omitempty"`
 }
 
 // KeyValuePair represents a key-value pair for environment variables
@@ -105,6 +114,35 @@ type Volume struct {
 	DockerVolumeConfiguration               *DockerVolumeConfiguration               `json:"dockerVolumeConfiguration,omitempty"`
 	EfsVolumeConfiguration                  *EFSVolumeConfiguration                  `json:"efsVolumeConfiguration,omitempty"`
 	FsxWindowsFileServerVolumeConfiguration *FSxWindowsFileServerVolumeConfiguration `json:"fsxWindowsFileServerVolumeConfiguration,omitempty"`
+	ConfiguredAtLaunch                      *bool                                    `json:"configuredAtLaunch,omitempty"`
+}
+
+// VolumeConfiguration configures a volume of the task definition that is
+// configured at launch, given to RunTask, CreateService or UpdateService
+type VolumeConfiguration struct {
+	Name             string                         `json:"name"`
+	ManagedEBSVolume *ManagedEBSVolumeConfiguration `json:"managedEBSVolume,omitempty"`
+}
+
+// ManagedEBSVolumeConfiguration represents an Amazon EBS volume that is
+// created for each task
+type ManagedEBSVolumeConfiguration struct {
+	Encrypted         *bool                              `json:"encrypted,omitempty"`
+	FilesystemType    *string                            `json:"filesystemType,omitempty"`
+	Iops              *int32                             `json:"iops,omitempty"`
+	KmsKeyId          *string                            `json:"kmsKeyId,omitempty"`
+	RoleArn           string                             `json:"roleArn"`
+	SizeInGiB         *int32                             `json:"sizeInGiB,omitempty"`
+	SnapshotId        *string                            `json:"snapshotId,omitempty"`
+	Throughput        *int32                             `json:"throughput,omitempty"`
+	VolumeType        *string                            `json:"volumeType,omitempty"`
+	TerminationPolicy *ManagedEBSVolumeTerminationPolicy `json:"terminationPolicy,omitempty"`
+}
+
+// ManagedEBSVolumeTerminationPolicy decides whether the volume of a task is
+// deleted when the task stops. Volumes of services are always deleted.
+type ManagedEBSVolumeTerminationPolicy struct {
+	DeleteOnTermination bool `json:"deleteOnTermination"`
 }
 
 // HostVolumeProperties represents host volume properties
@@ -284,4 +322,21 @@ type Artifact struct {
 	Permissions  *string `json:"permissions,omitempty"`  // File permissions (e.g., "0644")
 	Checksum     *string `json:"checksum,omitempty"`     // Optional checksum for validation
 	ChecksumType *string `json:"checksumType,omitempty"` // "sha256", "md5"
+
+	// Headers are sent with HTTP(S) downloads, e.g. an Authorization header
+	Headers []ArtifactHeader `json:"headers,omitempty"`
+	// CredentialsFrom is the ARN of a Secrets Manager secret holding the
+	// accessKeyId, secretAccessKey and optional sessionToken for S3 downloads
+	CredentialsFrom *string `json:"credentialsFrom,omitempty"`
+	// Extract unpacks a zip or tar.gz archive into TargetPath
+	Extract *bool `json:"extract,omitempty"`
+}
+
+// ArtifactHeader is an HTTP header sent when downloading an artifact. The
+// value is given inline or read from a Secrets Manager secret or an SSM
+// parameter.
+type ArtifactHeader struct {
+	Name      *string `json:"name"`
+	Value     *string `json:"value,omitempty"`
+	ValueFrom *string `json:"valueFrom,omitempty"`
 }
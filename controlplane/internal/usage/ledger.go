@@ -0,0 +1,194 @@
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
+)
+
+// DefaultRetention is how long records are kept when no retention is configured
+const DefaultRetention = 7 * 24 * time.Hour
+
+// Ledger keeps the usage records of stopped tasks after they are deleted
+// from storage, so that reports cover longer periods than the task
+// retention. Records are kept in memory and appended to a JSON lines file;
+// the file is rewritten when expired records are pruned.
+type Ledger struct {
+	mu        sync.RWMutex
+	records   []Record
+	retention time.Duration
+
+	path string
+	file *os.File
+}
+
+// NewLedger creates an in-memory ledger keeping records for retention
+func NewLedger(retention time.Duration) *Ledger {
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+	return &Ledger{retention: retention}
+}
+
+// OpenLedger creates a ledger persisted to path, loading the records already in the file
+func OpenLedger(path string, retention time.Duration) (*Ledger, error) {
+	l := NewLedger(retention)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create usage ledger directory: %w", err)
+	}
+	if err := l.load(path); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open usage ledger: %w", err)
+	}
+	l.path = path
+	l.file = file
+	return l, nil
+}
+
+// load reads the records of an existing ledger file
+func (l *Ledger) load(path string) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read usage ledger: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			// Skip a line torn by a crash
+			continue
+		}
+		l.records = append(l.records, record)
+	}
+	return scanner.Err()
+}
+
+// Add archives the records of stopped tasks
+func (l *Ledger) Add(records ...Record) {
+	if l == nil || len(records) == 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, records...)
+	if l.file == nil {
+		return
+	}
+	writer := bufio.NewWriter(l.file)
+	encoder := json.NewEncoder(writer)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			logging.Warn("Failed to encode usage record", "task", record.TaskARN, "error", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		logging.Warn("Failed to write usage ledger", "path", l.path, "error", err)
+	}
+}
+
+// Records returns the records of tasks that stopped at or after since
+func (l *Ledger) Records(since time.Time) []Record {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	result := []Record{}
+	for _, record := range l.records {
+		if record.Stop == nil || !record.Stop.Before(since) {
+			result = append(result, record)
+		}
+	}
+	return result
+}
+
+// Prune drops the records of tasks that stopped before the retention period
+// and returns how many were dropped
+func (l *Ledger) Prune(now time.Time) int {
+	if l == nil {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	cutoff := now.Add(-l.retention)
+	kept := make([]Record, 0, len(l.records))
+	for _, record := range l.records {
+		if record.Stop == nil || !record.Stop.Before(cutoff) {
+			kept = append(kept, record)
+		}
+	}
+	pruned := len(l.records) - len(kept)
+	if pruned == 0 {
+		return 0
+	}
+	l.records = kept
+	if l.file != nil {
+		if err := l.compact(); err != nil {
+			logging.Warn("Failed to compact usage ledger", "path", l.path, "error", err)
+		}
+	}
+	return pruned
+}
+
+// compact replaces the ledger file with the records kept in memory
+func (l *Ledger) compact() error {
+	tmpPath := l.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for _, record := range l.records {
+		if err := encoder.Encode(record); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	tmp.Close()
+
+	if err := os.Rename(tmpPath, l.path); err != nil {
+		return err
+	}
+	l.file.Close()
+	l.file, err = os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND, 0600)
+	return err
+}
+
+// Close closes the ledger file
+func (l *Ledger) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
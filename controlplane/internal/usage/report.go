@@ -0,0 +1,248 @@
+// Package usage estimates what the tasks run by a KECS instance would cost on
+// Fargate, so that FinOps tooling can be tested against realistic numbers.
+package usage
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/startupmetrics"
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+)
+
+const (
+	// DefaultVCPUHourPrice is the Fargate Linux/x86 price per vCPU-hour in us-east-1
+	DefaultVCPUHourPrice = 0.04048
+	// DefaultGBHourPrice is the Fargate Linux/x86 price per GB-hour in us-east-1
+	DefaultGBHourPrice = 0.004445
+
+	// minimumBilledDuration is the shortest duration Fargate bills a task for
+	minimumBilledDuration = time.Minute
+
+	// Fargate's smallest task size, used for tasks without cpu or memory
+	defaultVCPU     = 0.25
+	defaultMemoryGB = 0.5
+)
+
+// Pricing is the price of the resources reserved by a task
+type Pricing struct {
+	VCPUHour float64 `json:"vcpuHour"`
+	GBHour   float64 `json:"gbHour"`
+}
+
+// DefaultPricing returns the Fargate on-demand pricing of us-east-1
+func DefaultPricing() Pricing {
+	return Pricing{VCPUHour: DefaultVCPUHourPrice, GBHour: DefaultGBHourPrice}
+}
+
+// Record is the resource reservation of a task over its billed lifetime
+type Record struct {
+	TaskARN    string  `json:"taskArn"`
+	ClusterARN string  `json:"clusterArn"`
+	Service    string  `json:"service,omitempty"`
+	VCPU       float64 `json:"vcpu"`
+	MemoryGB   float64 `json:"memoryGb"`
+	// Start is when Fargate starts billing, the start of the image pull
+	Start time.Time `json:"start"`
+	// Stop is when the task stopped, nil while it runs
+	Stop *time.Time `json:"stop,omitempty"`
+}
+
+// RecordFromTask returns the usage record of a task. It returns false for
+// tasks that never started and for stopped tasks without a stop time.
+func RecordFromTask(task *storage.Task) (Record, bool) {
+	start := task.PullStartedAt
+	if start == nil {
+		start = task.StartedAt
+	}
+	if start == nil {
+		return Record{}, false
+	}
+
+	var stop *time.Time
+	for _, t := range []*time.Time{task.ExecutionStoppedAt, task.StoppedAt, task.StoppingAt} {
+		if t != nil {
+			stop = t
+			break
+		}
+	}
+	if stop == nil && task.LastStatus == "STOPPED" {
+		return Record{}, false
+	}
+
+	return Record{
+		TaskARN:    task.ARN,
+		ClusterARN: task.ClusterARN,
+		Service:    startupmetrics.ServiceName(task),
+		VCPU:       parseVCPU(task.CPU),
+		MemoryGB:   parseMemoryGB(task.Memory),
+		Start:      *start,
+		Stop:       stop,
+	}, true
+}
+
+// parseVCPU converts task cpu, CPU units or a value like "0.5 vCPU", to vCPUs
+func parseVCPU(cpu string) float64 {
+	value, unit := splitQuantity(cpu)
+	if value <= 0 {
+		return defaultVCPU
+	}
+	if strings.EqualFold(unit, "vcpu") {
+		return value
+	}
+	return value / 1024
+}
+
+// parseMemoryGB converts task memory, MiB or a value like "2 GB", to GB
+func parseMemoryGB(memory string) float64 {
+	value, unit := splitQuantity(memory)
+	if value <= 0 {
+		return defaultMemoryGB
+	}
+	if strings.EqualFold(unit, "gb") {
+		return value
+	}
+	return value / 1024
+}
+
+// splitQuantity splits "0.5 vCPU" or "2GB" into its value and unit
+func splitQuantity(s string) (float64, string) {
+	s = strings.TrimSpace(s)
+	end := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if end < 0 {
+		end = len(s)
+	}
+	value, err := strconv.ParseFloat(s[:end], 64)
+	if err != nil {
+		return 0, ""
+	}
+	return value, strings.TrimSpace(s[end:])
+}
+
+// Usage is the reserved resources and estimated cost of a set of tasks
+type Usage struct {
+	Tasks         int     `json:"tasks"`
+	VCPUSeconds   float64 `json:"vcpuSeconds"`
+	GBSeconds     float64 `json:"gbSeconds"`
+	EstimatedCost float64 `json:"estimatedCost"`
+}
+
+func (u *Usage) add(other Usage) {
+	u.Tasks += other.Tasks
+	u.VCPUSeconds += other.VCPUSeconds
+	u.GBSeconds += other.GBSeconds
+	u.EstimatedCost += other.EstimatedCost
+}
+
+// ServiceUsage is the usage of the tasks of a service. Standalone tasks are
+// reported with an empty service name.
+type ServiceUsage struct {
+	Service string `json:"service"`
+	Usage
+}
+
+// ClusterUsage is the usage of the tasks of a cluster
+type ClusterUsage struct {
+	ClusterARN string `json:"clusterArn"`
+	Usage
+	Services []ServiceUsage `json:"services"`
+}
+
+// Report is the usage of all tasks that ran during a period
+type Report struct {
+	Since    time.Time      `json:"since"`
+	Until    time.Time      `json:"until"`
+	Pricing  Pricing        `json:"pricing"`
+	Total    Usage          `json:"total"`
+	Clusters []ClusterUsage `json:"clusters"`
+}
+
+// BuildReport estimates the cost of the part of each record that falls
+// between since and until. Fargate bills per second with a one minute
+// minimum, the minimum is charged to the period the task started in.
+// Records of the same task are counted once, the last one wins.
+func BuildReport(records []Record, since, until time.Time, pricing Pricing) Report {
+	byTask := make(map[string]Record, len(records))
+	for _, record := range records {
+		byTask[record.TaskARN] = record
+	}
+
+	clusters := make(map[string]map[string]*Usage)
+	for _, record := range byTask {
+		usage, ok := recordUsage(record, since, until, pricing)
+		if !ok {
+			continue
+		}
+		services, found := clusters[record.ClusterARN]
+		if !found {
+			services = make(map[string]*Usage)
+			clusters[record.ClusterARN] = services
+		}
+		if services[record.Service] == nil {
+			services[record.Service] = &Usage{}
+		}
+		services[record.Service].add(usage)
+	}
+
+	report := Report{Since: since, Until: until, Pricing: pricing, Clusters: []ClusterUsage{}}
+	for clusterARN, services := range clusters {
+		cluster := ClusterUsage{ClusterARN: clusterARN}
+		for name, usage := range services {
+			cluster.Usage.add(*usage)
+			cluster.Services = append(cluster.Services, ServiceUsage{Service: name, Usage: *usage})
+		}
+		sortByCost(cluster.Services, func(s ServiceUsage) (float64, string) { return s.EstimatedCost, s.Service })
+		report.Total.add(cluster.Usage)
+		report.Clusters = append(report.Clusters, cluster)
+	}
+	sortByCost(report.Clusters, func(c ClusterUsage) (float64, string) { return c.EstimatedCost, c.ClusterARN })
+	return report
+}
+
+// recordUsage returns the usage of a record between since and until
+func recordUsage(record Record, since, until time.Time, pricing Pricing) (Usage, bool) {
+	if !record.Start.Before(until) {
+		return Usage{}, false
+	}
+	startedInPeriod := !record.Start.Before(since)
+	start := record.Start
+	if !startedInPeriod {
+		start = since
+	}
+	stop := until
+	if record.Stop != nil && record.Stop.Before(until) {
+		stop = *record.Stop
+	}
+	if stop.Before(start) || (stop.Equal(start) && !startedInPeriod) {
+		return Usage{}, false
+	}
+
+	billed := stop.Sub(start)
+	if startedInPeriod && record.Stop != nil && record.Stop.Sub(record.Start) < minimumBilledDuration {
+		billed = minimumBilledDuration
+	}
+	seconds := billed.Seconds()
+	usage := Usage{
+		Tasks:       1,
+		VCPUSeconds: record.VCPU * seconds,
+		GBSeconds:   record.MemoryGB * seconds,
+	}
+	usage.EstimatedCost = usage.VCPUSeconds/3600*pricing.VCPUHour + usage.GBSeconds/3600*pricing.GBHour
+	return usage, true
+}
+
+// sortByCost sorts the most expensive entries first, then by name
+func sortByCost[T any](entries []T, key func(T) (float64, string)) {
+	sort.Slice(entries, func(i, j int) bool {
+		ci, ni := key(entries[i])
+		cj, nj := key(entries[j])
+		if ci != cj {
+			return ci > cj
+		}
+		return ni < nj
+	})
+}
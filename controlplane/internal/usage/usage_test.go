@@ -0,0 +1,139 @@
+package usage_test
+
+import (
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
+	"github.com/nandemo-ya/kecs/controlplane/internal/usage"
+)
+
+const clusterARN = "arn:aws:ecs:us-east-1:000000000000:cluster/default"
+
+var base = time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+
+func at(d time.Duration) *time.Time {
+	t := base.Add(d)
+	return &t
+}
+
+var _ = Describe("RecordFromTask", func() {
+	It("converts CPU units and MiB and bills from the image pull", func() {
+		record, ok := usage.RecordFromTask(&storage.Task{
+			ARN:           "arn:aws:ecs:us-east-1:000000000000:task/default/1",
+			ClusterARN:    clusterARN,
+			StartedBy:     "ecs-svc/web",
+			CPU:           "512",
+			Memory:        "2048",
+			PullStartedAt: at(0),
+			StartedAt:     at(time.Minute),
+			LastStatus:    "STOPPED",
+			StoppedAt:     at(time.Hour),
+		})
+		Expect(ok).To(BeTrue())
+		Expect(record.Service).To(Equal("web"))
+		Expect(record.VCPU).To(Equal(0.5))
+		Expect(record.MemoryGB).To(Equal(2.0))
+		Expect(record.Start).To(Equal(base))
+		Expect(*record.Stop).To(Equal(base.Add(time.Hour)))
+	})
+
+	It("accepts vCPU and GB values and defaults to the smallest Fargate size", func() {
+		record, ok := usage.RecordFromTask(&storage.Task{CPU: "1 vCPU", Memory: "4GB", StartedAt: at(0), LastStatus: "RUNNING"})
+		Expect(ok).To(BeTrue())
+		Expect(record.VCPU).To(Equal(1.0))
+		Expect(record.MemoryGB).To(Equal(4.0))
+		Expect(record.Stop).To(BeNil())
+
+		record, ok = usage.RecordFromTask(&storage.Task{StartedAt: at(0), LastStatus: "RUNNING"})
+		Expect(ok).To(BeTrue())
+		Expect(record.VCPU).To(Equal(0.25))
+		Expect(record.MemoryGB).To(Equal(0.5))
+	})
+
+	It("skips tasks that never started", func() {
+		_, ok := usage.RecordFromTask(&storage.Task{LastStatus: "STOPPED", StoppedAt: at(0)})
+		Expect(ok).To(BeFalse())
+	})
+})
+
+var _ = Describe("BuildReport", func() {
+	pricing := usage.Pricing{VCPUHour: 0.04, GBHour: 0.004}
+
+	It("aggregates the usage within the period per cluster and service", func() {
+		records := []usage.Record{
+			{TaskARN: "t1", ClusterARN: clusterARN, Service: "web", VCPU: 1, MemoryGB: 2, Start: base, Stop: at(2 * time.Hour)},
+			{TaskARN: "t2", ClusterARN: clusterARN, Service: "web", VCPU: 1, MemoryGB: 2, Start: base.Add(-time.Hour), Stop: at(time.Hour)},
+			{TaskARN: "t3", ClusterARN: clusterARN, VCPU: 0.25, MemoryGB: 0.5, Start: base},
+			// Stopped before the period
+			{TaskARN: "t4", ClusterARN: clusterARN, Service: "web", VCPU: 1, MemoryGB: 2, Start: base.Add(-2 * time.Hour), Stop: at(-time.Hour)},
+		}
+
+		report := usage.BuildReport(records, base, base.Add(4*time.Hour), pricing)
+		Expect(report.Clusters).To(HaveLen(1))
+		cluster := report.Clusters[0]
+		Expect(cluster.Tasks).To(Equal(3))
+		Expect(cluster.Services).To(HaveLen(2))
+
+		web := cluster.Services[0]
+		Expect(web.Service).To(Equal("web"))
+		Expect(web.Tasks).To(Equal(2))
+		Expect(web.VCPUSeconds).To(BeNumerically("~", 3*3600))
+		Expect(web.GBSeconds).To(BeNumerically("~", 6*3600))
+		Expect(web.EstimatedCost).To(BeNumerically("~", 3*0.04+6*0.004))
+
+		standalone := cluster.Services[1]
+		Expect(standalone.Service).To(BeEmpty())
+		Expect(standalone.VCPUSeconds).To(BeNumerically("~", 0.25*4*3600), "running tasks count until the end of the period")
+
+		Expect(report.Total.EstimatedCost).To(BeNumerically("~", cluster.EstimatedCost))
+	})
+
+	It("bills short tasks for one minute", func() {
+		records := []usage.Record{
+			{TaskARN: "t1", ClusterARN: clusterARN, VCPU: 1, MemoryGB: 1, Start: base, Stop: at(10 * time.Second)},
+		}
+		report := usage.BuildReport(records, base, base.Add(time.Hour), pricing)
+		Expect(report.Total.VCPUSeconds).To(BeNumerically("~", 60))
+	})
+
+	It("counts a task once when it is both archived and in storage", func() {
+		records := []usage.Record{
+			{TaskARN: "t1", ClusterARN: clusterARN, VCPU: 1, MemoryGB: 1, Start: base, Stop: at(time.Hour)},
+			{TaskARN: "t1", ClusterARN: clusterARN, VCPU: 1, MemoryGB: 1, Start: base, Stop: at(2 * time.Hour)},
+		}
+		report := usage.BuildReport(records, base, base.Add(4*time.Hour), pricing)
+		Expect(report.Total.Tasks).To(Equal(1))
+		Expect(report.Total.VCPUSeconds).To(BeNumerically("~", 2*3600))
+	})
+})
+
+var _ = Describe("Ledger", func() {
+	It("keeps records across restarts and prunes expired ones", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "usage", "tasks.log")
+		ledger, err := usage.OpenLedger(path, 24*time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+		ledger.Add(
+			usage.Record{TaskARN: "old", Start: base, Stop: at(time.Hour)},
+			usage.Record{TaskARN: "new", Start: base, Stop: at(30 * time.Hour)},
+		)
+		Expect(ledger.Records(base.Add(2 * time.Hour))).To(HaveLen(1))
+		Expect(ledger.Close()).To(Succeed())
+
+		ledger, err = usage.OpenLedger(path, 24*time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ledger.Records(time.Time{})).To(HaveLen(2))
+		Expect(ledger.Prune(base.Add(26 * time.Hour))).To(Equal(1))
+		Expect(ledger.Close()).To(Succeed())
+
+		ledger, err = usage.OpenLedger(path, 24*time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+		records := ledger.Records(time.Time{})
+		Expect(records).To(HaveLen(1))
+		Expect(records[0].TaskARN).To(Equal("new"))
+		Expect(ledger.Close()).To(Succeed())
+	})
+})
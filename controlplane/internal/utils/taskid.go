@@ -5,11 +5,18 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/deterministic"
 )
 
 // GenerateTaskID generates a task ID in the format used by AWS ECS
 // Returns a 32-character hexadecimal string (e.g., "36374d1d33ad4ec0b5a9980f30402ead")
 func GenerateTaskID() (string, error) {
+	// Use sequential IDs when deterministic mode is enabled
+	if deterministic.Enabled() {
+		return deterministic.Suffix(32), nil
+	}
+
 	// Generate 16 random bytes
 	bytes := make([]byte, 16)
 	if _, err := rand.Read(bytes); err != nil {
@@ -0,0 +1,252 @@
+package vpc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+)
+
+// DefaultZoneSuffixes are the availability zone suffixes used for the default VPC
+var DefaultZoneSuffixes = []string{"a", "b", "c"}
+
+// Vpc represents a simulated VPC
+type Vpc struct {
+	VpcID     string
+	CidrBlock string
+	IsDefault bool
+	State     string
+}
+
+// Subnet represents a simulated subnet bound to an availability zone
+type Subnet struct {
+	SubnetID         string
+	VpcID            string
+	CidrBlock        string
+	AvailabilityZone string
+	DefaultForAz     bool
+	State            string
+}
+
+// NotFoundError is returned when a VPC or subnet does not exist
+type NotFoundError struct {
+	Code string
+	ID   string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s: The %s '%s' does not exist", e.Code, e.kind(), e.ID)
+}
+
+func (e *NotFoundError) kind() string {
+	if e.Code == "InvalidVpcID.NotFound" {
+		return "vpc ID"
+	}
+	return "subnet ID"
+}
+
+// Registry keeps track of simulated VPCs and subnets and maps subnets to zones.
+// Zones correspond to the topology.kubernetes.io/zone label on cluster nodes.
+type Registry struct {
+	mu      sync.RWMutex
+	region  string
+	vpcs    map[string]*Vpc
+	subnets map[string]*Subnet
+}
+
+// NewRegistry creates a registry seeded with a default VPC and one default subnet per zone.
+// The IDs of the default VPC and subnets are derived from the region, so subnets stored
+// with services and task sets still resolve after a restart.
+func NewRegistry(region string) *Registry {
+	r := &Registry{
+		region:  region,
+		vpcs:    make(map[string]*Vpc),
+		subnets: make(map[string]*Subnet),
+	}
+
+	defaultVpc := &Vpc{
+		VpcID:     "vpc-" + defaultHex(region, 8),
+		CidrBlock: "172.31.0.0/16",
+		IsDefault: true,
+		State:     "available",
+	}
+	r.vpcs[defaultVpc.VpcID] = defaultVpc
+
+	for i, suffix := range DefaultZoneSuffixes {
+		subnet := &Subnet{
+			SubnetID:         "subnet-" + defaultHex(region+suffix, 8),
+			VpcID:            defaultVpc.VpcID,
+			CidrBlock:        fmt.Sprintf("172.31.%d.0/20", i*16),
+			AvailabilityZone: region + suffix,
+			DefaultForAz:     true,
+			State:            "available",
+		}
+		r.subnets[subnet.SubnetID] = subnet
+	}
+
+	return r
+}
+
+// Region returns the region the registry simulates
+func (r *Registry) Region() string {
+	return r.region
+}
+
+// CreateVpc creates a new VPC with the given CIDR block
+func (r *Registry) CreateVpc(cidrBlock string) (*Vpc, error) {
+	if _, _, err := net.ParseCIDR(cidrBlock); err != nil {
+		return nil, fmt.Errorf("InvalidParameterValue: Value (%s) for parameter cidrBlock is invalid", cidrBlock)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	v := &Vpc{
+		VpcID:     "vpc-" + randomHex(8),
+		CidrBlock: cidrBlock,
+		State:     "available",
+	}
+	r.vpcs[v.VpcID] = v
+
+	copied := *v
+	return &copied, nil
+}
+
+// CreateSubnet creates a subnet in the given VPC. When zone is empty the first
+// default zone of the region is used.
+func (r *Registry) CreateSubnet(vpcID, cidrBlock, zone string) (*Subnet, error) {
+	if _, _, err := net.ParseCIDR(cidrBlock); err != nil {
+		return nil, fmt.Errorf("InvalidParameterValue: Value (%s) for parameter cidrBlock is invalid", cidrBlock)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.vpcs[vpcID]; !ok {
+		return nil, &NotFoundError{Code: "InvalidVpcID.NotFound", ID: vpcID}
+	}
+
+	if zone == "" {
+		zone = r.region + DefaultZoneSuffixes[0]
+	}
+
+	s := &Subnet{
+		SubnetID:         "subnet-" + randomHex(8),
+		VpcID:            vpcID,
+		CidrBlock:        cidrBlock,
+		AvailabilityZone: zone,
+		State:            "available",
+	}
+	r.subnets[s.SubnetID] = s
+
+	copied := *s
+	return &copied, nil
+}
+
+// DescribeVpcs returns the requested VPCs, or all VPCs when ids is empty
+func (r *Registry) DescribeVpcs(ids []string) ([]Vpc, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []Vpc
+	if len(ids) == 0 {
+		for _, v := range r.vpcs {
+			result = append(result, *v)
+		}
+		sort.Slice(result, func(i, j int) bool { return result[i].VpcID < result[j].VpcID })
+		return result, nil
+	}
+
+	for _, id := range ids {
+		v, ok := r.vpcs[id]
+		if !ok {
+			return nil, &NotFoundError{Code: "InvalidVpcID.NotFound", ID: id}
+		}
+		result = append(result, *v)
+	}
+	return result, nil
+}
+
+// DescribeSubnets returns the requested subnets, or all subnets when ids is empty.
+// When vpcID is set only subnets in that VPC are returned.
+func (r *Registry) DescribeSubnets(ids []string, vpcID string) ([]Subnet, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []Subnet
+	if len(ids) == 0 {
+		for _, s := range r.subnets {
+			if vpcID != "" && s.VpcID != vpcID {
+				continue
+			}
+			result = append(result, *s)
+		}
+		sort.Slice(result, func(i, j int) bool {
+			if result[i].AvailabilityZone != result[j].AvailabilityZone {
+				return result[i].AvailabilityZone < result[j].AvailabilityZone
+			}
+			return result[i].SubnetID < result[j].SubnetID
+		})
+		return result, nil
+	}
+
+	for _, id := range ids {
+		s, ok := r.subnets[id]
+		if !ok {
+			return nil, &NotFoundError{Code: "InvalidSubnetID.NotFound", ID: id}
+		}
+		if vpcID != "" && s.VpcID != vpcID {
+			continue
+		}
+		result = append(result, *s)
+	}
+	return result, nil
+}
+
+// ZoneForSubnet returns the availability zone a subnet belongs to
+func (r *Registry) ZoneForSubnet(subnetID string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s, ok := r.subnets[subnetID]
+	if !ok {
+		return "", &NotFoundError{Code: "InvalidSubnetID.NotFound", ID: subnetID}
+	}
+	return s.AvailabilityZone, nil
+}
+
+// ValidateSubnets checks that every subnet exists and that all subnets belong to the same VPC
+func (r *Registry) ValidateSubnets(subnetIDs []string) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	vpcID := ""
+	for _, id := range subnetIDs {
+		s, ok := r.subnets[id]
+		if !ok {
+			return &NotFoundError{Code: "InvalidSubnetID.NotFound", ID: id}
+		}
+		if vpcID != "" && s.VpcID != vpcID {
+			return fmt.Errorf("subnets %v must all belong to the same VPC", subnetIDs)
+		}
+		vpcID = s.VpcID
+	}
+	return nil
+}
+
+// defaultHex returns n hex digits derived from name, for the IDs of default resources
+func defaultHex(name string, n int) string {
+	sum := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(sum[:])[:n]
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n/2+1)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("failed to generate random id: %v", err))
+	}
+	return hex.EncodeToString(b)[:n]
+}
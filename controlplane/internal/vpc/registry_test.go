@@ -0,0 +1,95 @@
+package vpc_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/nandemo-ya/kecs/controlplane/internal/vpc"
+)
+
+var _ = Describe("Registry", func() {
+	var registry *vpc.Registry
+
+	BeforeEach(func() {
+		registry = vpc.NewRegistry("us-east-1")
+	})
+
+	Context("default VPC", func() {
+		It("should seed one default subnet per zone", func() {
+			vpcs, err := registry.DescribeVpcs(nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vpcs).To(HaveLen(1))
+			Expect(vpcs[0].IsDefault).To(BeTrue())
+
+			subnets, err := registry.DescribeSubnets(nil, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(subnets).To(HaveLen(3))
+			Expect(subnets[0].AvailabilityZone).To(Equal("us-east-1a"))
+			Expect(subnets[1].AvailabilityZone).To(Equal("us-east-1b"))
+			Expect(subnets[2].AvailabilityZone).To(Equal("us-east-1c"))
+		})
+
+		It("should keep the default IDs across registries", func() {
+			vpcs, err := registry.DescribeVpcs(nil)
+			Expect(err).NotTo(HaveOccurred())
+			subnets, err := registry.DescribeSubnets(nil, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			restarted := vpc.NewRegistry("us-east-1")
+			Expect(restarted.DescribeVpcs(nil)).To(Equal(vpcs))
+			Expect(restarted.DescribeSubnets(nil, "")).To(Equal(subnets))
+			Expect(restarted.ValidateSubnets([]string{subnets[0].SubnetID})).To(Succeed())
+
+			other := vpc.NewRegistry("eu-west-1")
+			otherVpcs, err := other.DescribeVpcs(nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(otherVpcs[0].VpcID).NotTo(Equal(vpcs[0].VpcID))
+		})
+	})
+
+	Context("CreateSubnet", func() {
+		It("should map the subnet to the requested zone", func() {
+			v, err := registry.CreateVpc("10.0.0.0/16")
+			Expect(err).NotTo(HaveOccurred())
+
+			s, err := registry.CreateSubnet(v.VpcID, "10.0.1.0/24", "us-east-1b")
+			Expect(err).NotTo(HaveOccurred())
+
+			zone, err := registry.ZoneForSubnet(s.SubnetID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(zone).To(Equal("us-east-1b"))
+		})
+
+		It("should reject an unknown VPC", func() {
+			_, err := registry.CreateSubnet("vpc-missing", "10.0.1.0/24", "")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("InvalidVpcID.NotFound"))
+		})
+
+		It("should reject an invalid CIDR block", func() {
+			_, err := registry.CreateVpc("not-a-cidr")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("ValidateSubnets", func() {
+		It("should return InvalidSubnetID.NotFound for unknown subnets", func() {
+			err := registry.ValidateSubnets([]string{"subnet-12345678"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("InvalidSubnetID.NotFound"))
+		})
+
+		It("should reject subnets from different VPCs", func() {
+			defaults, err := registry.DescribeSubnets(nil, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			v, err := registry.CreateVpc("10.0.0.0/16")
+			Expect(err).NotTo(HaveOccurred())
+			s, err := registry.CreateSubnet(v.VpcID, "10.0.1.0/24", "us-east-1a")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = registry.ValidateSubnets([]string{defaults[0].SubnetID, s.SubnetID})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,13 @@
+package vpc_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestVpc(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "VPC Suite")
+}
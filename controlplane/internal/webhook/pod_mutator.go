@@ -2,6 +2,7 @@ package webhook
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"strconv"
@@ -14,6 +15,8 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 
+	"github.com/nandemo-ya/kecs/controlplane/internal/appconfig"
+	"github.com/nandemo-ya/kecs/controlplane/internal/kubernetes"
 	"github.com/nandemo-ya/kecs/controlplane/internal/logging"
 	"github.com/nandemo-ya/kecs/controlplane/internal/storage"
 )
@@ -169,6 +172,10 @@ func (m *PodMutator) mutate(req *admissionv1.AdmissionRequest) *admissionv1.Admi
 		// AWS environment variables to inject
 		awsEnvVars := []corev1.EnvVar{
 			{Name: "AWS_ENDPOINT_URL", Value: "http://localstack.kecs-system.svc.cluster.local:4566"},
+			// AppConfig and STS are served by KECS itself
+			{Name: "AWS_ENDPOINT_URL_APPCONFIG", Value: appconfig.InClusterEndpoint},
+			{Name: "AWS_ENDPOINT_URL_APPCONFIGDATA", Value: appconfig.InClusterEndpoint},
+			{Name: "AWS_ENDPOINT_URL_STS", Value: appconfig.InClusterEndpoint},
 			{Name: "AWS_ACCESS_KEY_ID", Value: "test"},
 			{Name: "AWS_SECRET_ACCESS_KEY", Value: "test"},
 			{Name: "AWS_DEFAULT_REGION", Value: "us-east-1"},
@@ -252,6 +259,22 @@ func (m *PodMutator) mutate(req *admissionv1.AdmissionRequest) *admissionv1.Admi
 			Value: taskID,
 		})
 
+		// Assign the dynamic host ports of bridge mode services, which differ
+		// between the replicas
+		if taskARN := kubernetes.ServicePodTaskARN(&pod, taskID); taskARN != "" {
+			hostPortPatches, err := hostPortPatches(&pod, taskARN)
+			if err != nil {
+				logging.Warn("Failed to assign host ports to service pod", "service", serviceName, "error", err)
+				return &admissionv1.AdmissionResponse{
+					Allowed: false,
+					Result: &metav1.Status{
+						Message: err.Error(),
+					},
+				}
+			}
+			patches = append(patches, hostPortPatches...)
+		}
+
 		// Don't create task here - let ServiceManager handle it to avoid duplicates
 		// The webhook only adds the task ID label, and ServiceManager will create
 		// the actual task record when it processes the pod
@@ -288,6 +311,30 @@ applyPatches:
 	}
 }
 
+// hostPortPatches returns the patches setting the host ports assigned to the
+// ports of a pod
+func hostPortPatches(pod *corev1.Pod, taskARN string) ([]patchOperation, error) {
+	assigned := pod.DeepCopy()
+	if err := kubernetes.AllocateServicePodHostPorts(assigned, taskARN); err != nil {
+		return nil, err
+	}
+
+	var patches []patchOperation
+	for i, container := range assigned.Spec.Containers {
+		for j, port := range container.Ports {
+			if port.HostPort == pod.Spec.Containers[i].Ports[j].HostPort {
+				continue
+			}
+			patches = append(patches, patchOperation{
+				Op:    "add",
+				Path:  fmt.Sprintf("/spec/containers/%d/ports/%d/hostPort", i, j),
+				Value: port.HostPort,
+			})
+		}
+	}
+	return patches, nil
+}
+
 // patchOperation represents a JSON patch operation
 type patchOperation struct {
 	Op    string      `json:"op"`
@@ -5,15 +5,16 @@
 set -e
 
 SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
-CODEGEN_DIR="$SCRIPT_DIR/../cmd/codegen"
+MODELS_DIR="$SCRIPT_DIR/../internal/awsmodels"
 
 echo "Downloading AWS API definitions..."
 
 # Create directory if it doesn't exist
-mkdir -p "$CODEGEN_DIR"
+mkdir -p "$MODELS_DIR"
 
 # Download API definitions from AWS SDK Go v2 repository
 # These are Smithy JSON files from the SDK's codegen/sdk-codegen/aws-models directory
+# The models are replaced as they are; KECS extensions live in $MODELS_DIR/overlays
 
 SERVICES=(
     "ecs"
@@ -31,7 +32,7 @@ BASE_URL="https://raw.githubusercontent.com/aws/aws-sdk-go-v2/main/codegen/sdk-c
 
 for SERVICE in "${SERVICES[@]}"; do
     echo "Downloading $SERVICE.json..."
-    OUTPUT_FILE="$CODEGEN_DIR/${SERVICE//-/}.json"
+    OUTPUT_FILE="$MODELS_DIR/${SERVICE//-/}.json"
     
     # Try to download the service definition
     if curl -f -L -o "$OUTPUT_FILE" "$BASE_URL/$SERVICE.json" 2>/dev/null; then
@@ -45,7 +46,7 @@ done
 # Check if we have the files
 echo ""
 echo "Downloaded API definitions:"
-ls -la "$CODEGEN_DIR"/*.json 2>/dev/null || echo "No JSON files found"
+ls -la "$MODELS_DIR"/*.json 2>/dev/null || echo "No JSON files found"
 
 echo ""
 echo "Done!"
\ No newline at end of file
@@ -0,0 +1,42 @@
+// Conformance scenario of CreateCapacityProvider, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:CreateCapacityProvider) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("CreateCapacityProvider", Label("conformance", "CreateCapacityProvider"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:CreateCapacityProvider): call CreateCapacityProvider with autoScalingGroupProvider, name
+		// and compare the response with AWS
+		Skip("TODO(conformance:CreateCapacityProvider): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:CreateCapacityProvider): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:CreateCapacityProvider): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:CreateCapacityProvider): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:CreateCapacityProvider): scenario not written")
+	})
+
+	It("returns LimitExceededException", func() {
+		// TODO(conformance:CreateCapacityProvider): trigger LimitExceededException and compare the error with AWS
+		Skip("TODO(conformance:CreateCapacityProvider): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:CreateCapacityProvider): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:CreateCapacityProvider): scenario not written")
+	})
+
+	It("returns UpdateInProgressException", func() {
+		// TODO(conformance:CreateCapacityProvider): trigger UpdateInProgressException and compare the error with AWS
+		Skip("TODO(conformance:CreateCapacityProvider): scenario not written")
+	})
+})
@@ -0,0 +1,37 @@
+// Conformance scenario of CreateCluster, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:CreateCluster) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("CreateCluster", Label("conformance", "CreateCluster"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:CreateCluster): call CreateCluster without members
+		// and compare the response with AWS
+		Skip("TODO(conformance:CreateCluster): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:CreateCluster): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:CreateCluster): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:CreateCluster): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:CreateCluster): scenario not written")
+	})
+
+	It("returns NamespaceNotFoundException", func() {
+		// TODO(conformance:CreateCluster): trigger NamespaceNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:CreateCluster): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:CreateCluster): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:CreateCluster): scenario not written")
+	})
+})
@@ -0,0 +1,62 @@
+// Conformance scenario of CreateService, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:CreateService) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("CreateService", Label("conformance", "CreateService"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:CreateService): call CreateService with serviceName
+		// and compare the response with AWS
+		Skip("TODO(conformance:CreateService): scenario not written")
+	})
+
+	It("returns AccessDeniedException", func() {
+		// TODO(conformance:CreateService): trigger AccessDeniedException and compare the error with AWS
+		Skip("TODO(conformance:CreateService): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:CreateService): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:CreateService): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:CreateService): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:CreateService): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:CreateService): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:CreateService): scenario not written")
+	})
+
+	It("returns NamespaceNotFoundException", func() {
+		// TODO(conformance:CreateService): trigger NamespaceNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:CreateService): scenario not written")
+	})
+
+	It("returns PlatformTaskDefinitionIncompatibilityException", func() {
+		// TODO(conformance:CreateService): trigger PlatformTaskDefinitionIncompatibilityException and compare the error with AWS
+		Skip("TODO(conformance:CreateService): scenario not written")
+	})
+
+	It("returns PlatformUnknownException", func() {
+		// TODO(conformance:CreateService): trigger PlatformUnknownException and compare the error with AWS
+		Skip("TODO(conformance:CreateService): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:CreateService): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:CreateService): scenario not written")
+	})
+
+	It("returns UnsupportedFeatureException", func() {
+		// TODO(conformance:CreateService): trigger UnsupportedFeatureException and compare the error with AWS
+		Skip("TODO(conformance:CreateService): scenario not written")
+	})
+})
@@ -0,0 +1,72 @@
+// Conformance scenario of CreateTaskSet, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:CreateTaskSet) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("CreateTaskSet", Label("conformance", "CreateTaskSet"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:CreateTaskSet): call CreateTaskSet with cluster, service, taskDefinition
+		// and compare the response with AWS
+		Skip("TODO(conformance:CreateTaskSet): scenario not written")
+	})
+
+	It("returns AccessDeniedException", func() {
+		// TODO(conformance:CreateTaskSet): trigger AccessDeniedException and compare the error with AWS
+		Skip("TODO(conformance:CreateTaskSet): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:CreateTaskSet): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:CreateTaskSet): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:CreateTaskSet): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:CreateTaskSet): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:CreateTaskSet): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:CreateTaskSet): scenario not written")
+	})
+
+	It("returns NamespaceNotFoundException", func() {
+		// TODO(conformance:CreateTaskSet): trigger NamespaceNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:CreateTaskSet): scenario not written")
+	})
+
+	It("returns PlatformTaskDefinitionIncompatibilityException", func() {
+		// TODO(conformance:CreateTaskSet): trigger PlatformTaskDefinitionIncompatibilityException and compare the error with AWS
+		Skip("TODO(conformance:CreateTaskSet): scenario not written")
+	})
+
+	It("returns PlatformUnknownException", func() {
+		// TODO(conformance:CreateTaskSet): trigger PlatformUnknownException and compare the error with AWS
+		Skip("TODO(conformance:CreateTaskSet): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:CreateTaskSet): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:CreateTaskSet): scenario not written")
+	})
+
+	It("returns ServiceNotActiveException", func() {
+		// TODO(conformance:CreateTaskSet): trigger ServiceNotActiveException and compare the error with AWS
+		Skip("TODO(conformance:CreateTaskSet): scenario not written")
+	})
+
+	It("returns ServiceNotFoundException", func() {
+		// TODO(conformance:CreateTaskSet): trigger ServiceNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:CreateTaskSet): scenario not written")
+	})
+
+	It("returns UnsupportedFeatureException", func() {
+		// TODO(conformance:CreateTaskSet): trigger UnsupportedFeatureException and compare the error with AWS
+		Skip("TODO(conformance:CreateTaskSet): scenario not written")
+	})
+})
@@ -0,0 +1,32 @@
+// Conformance scenario of DeleteAccountSetting, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:DeleteAccountSetting) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("DeleteAccountSetting", Label("conformance", "DeleteAccountSetting"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:DeleteAccountSetting): call DeleteAccountSetting with name
+		// and compare the response with AWS
+		Skip("TODO(conformance:DeleteAccountSetting): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:DeleteAccountSetting): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:DeleteAccountSetting): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:DeleteAccountSetting): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:DeleteAccountSetting): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:DeleteAccountSetting): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:DeleteAccountSetting): scenario not written")
+	})
+})
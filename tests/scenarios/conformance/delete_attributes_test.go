@@ -0,0 +1,32 @@
+// Conformance scenario of DeleteAttributes, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:DeleteAttributes) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("DeleteAttributes", Label("conformance", "DeleteAttributes"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:DeleteAttributes): call DeleteAttributes with attributes
+		// and compare the response with AWS
+		Skip("TODO(conformance:DeleteAttributes): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:DeleteAttributes): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:DeleteAttributes): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:DeleteAttributes): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:DeleteAttributes): scenario not written")
+	})
+
+	It("returns TargetNotFoundException", func() {
+		// TODO(conformance:DeleteAttributes): trigger TargetNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:DeleteAttributes): scenario not written")
+	})
+})
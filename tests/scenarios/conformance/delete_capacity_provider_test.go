@@ -0,0 +1,32 @@
+// Conformance scenario of DeleteCapacityProvider, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:DeleteCapacityProvider) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("DeleteCapacityProvider", Label("conformance", "DeleteCapacityProvider"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:DeleteCapacityProvider): call DeleteCapacityProvider with capacityProvider
+		// and compare the response with AWS
+		Skip("TODO(conformance:DeleteCapacityProvider): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:DeleteCapacityProvider): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:DeleteCapacityProvider): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:DeleteCapacityProvider): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:DeleteCapacityProvider): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:DeleteCapacityProvider): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:DeleteCapacityProvider): scenario not written")
+	})
+})
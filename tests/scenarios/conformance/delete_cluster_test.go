@@ -0,0 +1,57 @@
+// Conformance scenario of DeleteCluster, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:DeleteCluster) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("DeleteCluster", Label("conformance", "DeleteCluster"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:DeleteCluster): call DeleteCluster with cluster
+		// and compare the response with AWS
+		Skip("TODO(conformance:DeleteCluster): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:DeleteCluster): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:DeleteCluster): scenario not written")
+	})
+
+	It("returns ClusterContainsContainerInstancesException", func() {
+		// TODO(conformance:DeleteCluster): trigger ClusterContainsContainerInstancesException and compare the error with AWS
+		Skip("TODO(conformance:DeleteCluster): scenario not written")
+	})
+
+	It("returns ClusterContainsServicesException", func() {
+		// TODO(conformance:DeleteCluster): trigger ClusterContainsServicesException and compare the error with AWS
+		Skip("TODO(conformance:DeleteCluster): scenario not written")
+	})
+
+	It("returns ClusterContainsTasksException", func() {
+		// TODO(conformance:DeleteCluster): trigger ClusterContainsTasksException and compare the error with AWS
+		Skip("TODO(conformance:DeleteCluster): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:DeleteCluster): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:DeleteCluster): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:DeleteCluster): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:DeleteCluster): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:DeleteCluster): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:DeleteCluster): scenario not written")
+	})
+
+	It("returns UpdateInProgressException", func() {
+		// TODO(conformance:DeleteCluster): trigger UpdateInProgressException and compare the error with AWS
+		Skip("TODO(conformance:DeleteCluster): scenario not written")
+	})
+})
@@ -0,0 +1,42 @@
+// Conformance scenario of DeleteService, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:DeleteService) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("DeleteService", Label("conformance", "DeleteService"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:DeleteService): call DeleteService with service
+		// and compare the response with AWS
+		Skip("TODO(conformance:DeleteService): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:DeleteService): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:DeleteService): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:DeleteService): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:DeleteService): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:DeleteService): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:DeleteService): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:DeleteService): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:DeleteService): scenario not written")
+	})
+
+	It("returns ServiceNotFoundException", func() {
+		// TODO(conformance:DeleteService): trigger ServiceNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:DeleteService): scenario not written")
+	})
+})
@@ -0,0 +1,37 @@
+// Conformance scenario of DeleteTaskDefinitions, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:DeleteTaskDefinitions) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("DeleteTaskDefinitions", Label("conformance", "DeleteTaskDefinitions"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:DeleteTaskDefinitions): call DeleteTaskDefinitions with taskDefinitions
+		// and compare the response with AWS
+		Skip("TODO(conformance:DeleteTaskDefinitions): scenario not written")
+	})
+
+	It("returns AccessDeniedException", func() {
+		// TODO(conformance:DeleteTaskDefinitions): trigger AccessDeniedException and compare the error with AWS
+		Skip("TODO(conformance:DeleteTaskDefinitions): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:DeleteTaskDefinitions): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:DeleteTaskDefinitions): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:DeleteTaskDefinitions): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:DeleteTaskDefinitions): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:DeleteTaskDefinitions): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:DeleteTaskDefinitions): scenario not written")
+	})
+})
@@ -0,0 +1,62 @@
+// Conformance scenario of DeleteTaskSet, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:DeleteTaskSet) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("DeleteTaskSet", Label("conformance", "DeleteTaskSet"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:DeleteTaskSet): call DeleteTaskSet with cluster, service, taskSet
+		// and compare the response with AWS
+		Skip("TODO(conformance:DeleteTaskSet): scenario not written")
+	})
+
+	It("returns AccessDeniedException", func() {
+		// TODO(conformance:DeleteTaskSet): trigger AccessDeniedException and compare the error with AWS
+		Skip("TODO(conformance:DeleteTaskSet): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:DeleteTaskSet): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:DeleteTaskSet): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:DeleteTaskSet): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:DeleteTaskSet): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:DeleteTaskSet): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:DeleteTaskSet): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:DeleteTaskSet): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:DeleteTaskSet): scenario not written")
+	})
+
+	It("returns ServiceNotActiveException", func() {
+		// TODO(conformance:DeleteTaskSet): trigger ServiceNotActiveException and compare the error with AWS
+		Skip("TODO(conformance:DeleteTaskSet): scenario not written")
+	})
+
+	It("returns ServiceNotFoundException", func() {
+		// TODO(conformance:DeleteTaskSet): trigger ServiceNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:DeleteTaskSet): scenario not written")
+	})
+
+	It("returns TaskSetNotFoundException", func() {
+		// TODO(conformance:DeleteTaskSet): trigger TaskSetNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:DeleteTaskSet): scenario not written")
+	})
+
+	It("returns UnsupportedFeatureException", func() {
+		// TODO(conformance:DeleteTaskSet): trigger UnsupportedFeatureException and compare the error with AWS
+		Skip("TODO(conformance:DeleteTaskSet): scenario not written")
+	})
+})
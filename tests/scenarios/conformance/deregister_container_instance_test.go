@@ -0,0 +1,37 @@
+// Conformance scenario of DeregisterContainerInstance, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:DeregisterContainerInstance) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("DeregisterContainerInstance", Label("conformance", "DeregisterContainerInstance"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:DeregisterContainerInstance): call DeregisterContainerInstance with containerInstance
+		// and compare the response with AWS
+		Skip("TODO(conformance:DeregisterContainerInstance): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:DeregisterContainerInstance): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:DeregisterContainerInstance): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:DeregisterContainerInstance): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:DeregisterContainerInstance): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:DeregisterContainerInstance): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:DeregisterContainerInstance): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:DeregisterContainerInstance): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:DeregisterContainerInstance): scenario not written")
+	})
+})
@@ -0,0 +1,32 @@
+// Conformance scenario of DeregisterTaskDefinition, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:DeregisterTaskDefinition) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("DeregisterTaskDefinition", Label("conformance", "DeregisterTaskDefinition"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:DeregisterTaskDefinition): call DeregisterTaskDefinition with taskDefinition
+		// and compare the response with AWS
+		Skip("TODO(conformance:DeregisterTaskDefinition): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:DeregisterTaskDefinition): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:DeregisterTaskDefinition): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:DeregisterTaskDefinition): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:DeregisterTaskDefinition): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:DeregisterTaskDefinition): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:DeregisterTaskDefinition): scenario not written")
+	})
+})
@@ -0,0 +1,32 @@
+// Conformance scenario of DescribeCapacityProviders, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:DescribeCapacityProviders) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("DescribeCapacityProviders", Label("conformance", "DescribeCapacityProviders"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:DescribeCapacityProviders): call DescribeCapacityProviders without members
+		// and compare the response with AWS
+		Skip("TODO(conformance:DescribeCapacityProviders): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:DescribeCapacityProviders): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:DescribeCapacityProviders): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:DescribeCapacityProviders): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:DescribeCapacityProviders): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:DescribeCapacityProviders): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:DescribeCapacityProviders): scenario not written")
+	})
+})
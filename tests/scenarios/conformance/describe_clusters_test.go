@@ -0,0 +1,32 @@
+// Conformance scenario of DescribeClusters, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:DescribeClusters) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("DescribeClusters", Label("conformance", "DescribeClusters"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:DescribeClusters): call DescribeClusters without members
+		// and compare the response with AWS
+		Skip("TODO(conformance:DescribeClusters): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:DescribeClusters): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:DescribeClusters): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:DescribeClusters): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:DescribeClusters): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:DescribeClusters): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:DescribeClusters): scenario not written")
+	})
+})
@@ -0,0 +1,37 @@
+// Conformance scenario of DescribeContainerInstances, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:DescribeContainerInstances) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("DescribeContainerInstances", Label("conformance", "DescribeContainerInstances"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:DescribeContainerInstances): call DescribeContainerInstances with containerInstances
+		// and compare the response with AWS
+		Skip("TODO(conformance:DescribeContainerInstances): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:DescribeContainerInstances): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:DescribeContainerInstances): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:DescribeContainerInstances): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:DescribeContainerInstances): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:DescribeContainerInstances): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:DescribeContainerInstances): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:DescribeContainerInstances): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:DescribeContainerInstances): scenario not written")
+	})
+})
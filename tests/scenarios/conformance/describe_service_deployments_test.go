@@ -0,0 +1,52 @@
+// Conformance scenario of DescribeServiceDeployments, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:DescribeServiceDeployments) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("DescribeServiceDeployments", Label("conformance", "DescribeServiceDeployments"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:DescribeServiceDeployments): call DescribeServiceDeployments with serviceDeploymentArns
+		// and compare the response with AWS
+		Skip("TODO(conformance:DescribeServiceDeployments): scenario not written")
+	})
+
+	It("returns AccessDeniedException", func() {
+		// TODO(conformance:DescribeServiceDeployments): trigger AccessDeniedException and compare the error with AWS
+		Skip("TODO(conformance:DescribeServiceDeployments): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:DescribeServiceDeployments): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:DescribeServiceDeployments): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:DescribeServiceDeployments): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:DescribeServiceDeployments): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:DescribeServiceDeployments): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:DescribeServiceDeployments): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:DescribeServiceDeployments): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:DescribeServiceDeployments): scenario not written")
+	})
+
+	It("returns ServiceNotFoundException", func() {
+		// TODO(conformance:DescribeServiceDeployments): trigger ServiceNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:DescribeServiceDeployments): scenario not written")
+	})
+
+	It("returns UnsupportedFeatureException", func() {
+		// TODO(conformance:DescribeServiceDeployments): trigger UnsupportedFeatureException and compare the error with AWS
+		Skip("TODO(conformance:DescribeServiceDeployments): scenario not written")
+	})
+})
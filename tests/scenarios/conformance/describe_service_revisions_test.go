@@ -0,0 +1,52 @@
+// Conformance scenario of DescribeServiceRevisions, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:DescribeServiceRevisions) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("DescribeServiceRevisions", Label("conformance", "DescribeServiceRevisions"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:DescribeServiceRevisions): call DescribeServiceRevisions with serviceRevisionArns
+		// and compare the response with AWS
+		Skip("TODO(conformance:DescribeServiceRevisions): scenario not written")
+	})
+
+	It("returns AccessDeniedException", func() {
+		// TODO(conformance:DescribeServiceRevisions): trigger AccessDeniedException and compare the error with AWS
+		Skip("TODO(conformance:DescribeServiceRevisions): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:DescribeServiceRevisions): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:DescribeServiceRevisions): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:DescribeServiceRevisions): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:DescribeServiceRevisions): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:DescribeServiceRevisions): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:DescribeServiceRevisions): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:DescribeServiceRevisions): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:DescribeServiceRevisions): scenario not written")
+	})
+
+	It("returns ServiceNotFoundException", func() {
+		// TODO(conformance:DescribeServiceRevisions): trigger ServiceNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:DescribeServiceRevisions): scenario not written")
+	})
+
+	It("returns UnsupportedFeatureException", func() {
+		// TODO(conformance:DescribeServiceRevisions): trigger UnsupportedFeatureException and compare the error with AWS
+		Skip("TODO(conformance:DescribeServiceRevisions): scenario not written")
+	})
+})
@@ -0,0 +1,37 @@
+// Conformance scenario of DescribeServices, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:DescribeServices) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("DescribeServices", Label("conformance", "DescribeServices"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:DescribeServices): call DescribeServices with services
+		// and compare the response with AWS
+		Skip("TODO(conformance:DescribeServices): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:DescribeServices): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:DescribeServices): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:DescribeServices): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:DescribeServices): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:DescribeServices): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:DescribeServices): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:DescribeServices): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:DescribeServices): scenario not written")
+	})
+})
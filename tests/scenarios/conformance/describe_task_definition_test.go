@@ -0,0 +1,32 @@
+// Conformance scenario of DescribeTaskDefinition, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:DescribeTaskDefinition) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("DescribeTaskDefinition", Label("conformance", "DescribeTaskDefinition"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:DescribeTaskDefinition): call DescribeTaskDefinition with taskDefinition
+		// and compare the response with AWS
+		Skip("TODO(conformance:DescribeTaskDefinition): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:DescribeTaskDefinition): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:DescribeTaskDefinition): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:DescribeTaskDefinition): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:DescribeTaskDefinition): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:DescribeTaskDefinition): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:DescribeTaskDefinition): scenario not written")
+	})
+})
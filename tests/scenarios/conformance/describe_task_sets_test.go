@@ -0,0 +1,57 @@
+// Conformance scenario of DescribeTaskSets, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:DescribeTaskSets) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("DescribeTaskSets", Label("conformance", "DescribeTaskSets"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:DescribeTaskSets): call DescribeTaskSets with cluster, service
+		// and compare the response with AWS
+		Skip("TODO(conformance:DescribeTaskSets): scenario not written")
+	})
+
+	It("returns AccessDeniedException", func() {
+		// TODO(conformance:DescribeTaskSets): trigger AccessDeniedException and compare the error with AWS
+		Skip("TODO(conformance:DescribeTaskSets): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:DescribeTaskSets): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:DescribeTaskSets): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:DescribeTaskSets): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:DescribeTaskSets): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:DescribeTaskSets): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:DescribeTaskSets): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:DescribeTaskSets): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:DescribeTaskSets): scenario not written")
+	})
+
+	It("returns ServiceNotActiveException", func() {
+		// TODO(conformance:DescribeTaskSets): trigger ServiceNotActiveException and compare the error with AWS
+		Skip("TODO(conformance:DescribeTaskSets): scenario not written")
+	})
+
+	It("returns ServiceNotFoundException", func() {
+		// TODO(conformance:DescribeTaskSets): trigger ServiceNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:DescribeTaskSets): scenario not written")
+	})
+
+	It("returns UnsupportedFeatureException", func() {
+		// TODO(conformance:DescribeTaskSets): trigger UnsupportedFeatureException and compare the error with AWS
+		Skip("TODO(conformance:DescribeTaskSets): scenario not written")
+	})
+})
@@ -0,0 +1,37 @@
+// Conformance scenario of DescribeTasks, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:DescribeTasks) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("DescribeTasks", Label("conformance", "DescribeTasks"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:DescribeTasks): call DescribeTasks with tasks
+		// and compare the response with AWS
+		Skip("TODO(conformance:DescribeTasks): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:DescribeTasks): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:DescribeTasks): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:DescribeTasks): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:DescribeTasks): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:DescribeTasks): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:DescribeTasks): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:DescribeTasks): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:DescribeTasks): scenario not written")
+	})
+})
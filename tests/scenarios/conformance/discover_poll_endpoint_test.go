@@ -0,0 +1,27 @@
+// Conformance scenario of DiscoverPollEndpoint, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:DiscoverPollEndpoint) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("DiscoverPollEndpoint", Label("conformance", "DiscoverPollEndpoint"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:DiscoverPollEndpoint): call DiscoverPollEndpoint without members
+		// and compare the response with AWS
+		Skip("TODO(conformance:DiscoverPollEndpoint): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:DiscoverPollEndpoint): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:DiscoverPollEndpoint): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:DiscoverPollEndpoint): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:DiscoverPollEndpoint): scenario not written")
+	})
+})
@@ -0,0 +1,47 @@
+// Conformance scenario of ExecuteCommand, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:ExecuteCommand) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("ExecuteCommand", Label("conformance", "ExecuteCommand"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:ExecuteCommand): call ExecuteCommand with command, interactive, task
+		// and compare the response with AWS
+		Skip("TODO(conformance:ExecuteCommand): scenario not written")
+	})
+
+	It("returns AccessDeniedException", func() {
+		// TODO(conformance:ExecuteCommand): trigger AccessDeniedException and compare the error with AWS
+		Skip("TODO(conformance:ExecuteCommand): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:ExecuteCommand): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:ExecuteCommand): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:ExecuteCommand): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:ExecuteCommand): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:ExecuteCommand): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:ExecuteCommand): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:ExecuteCommand): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:ExecuteCommand): scenario not written")
+	})
+
+	It("returns TargetNotConnectedException", func() {
+		// TODO(conformance:ExecuteCommand): trigger TargetNotConnectedException and compare the error with AWS
+		Skip("TODO(conformance:ExecuteCommand): scenario not written")
+	})
+})
@@ -0,0 +1,52 @@
+// Conformance scenario of GetTaskProtection, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:GetTaskProtection) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("GetTaskProtection", Label("conformance", "GetTaskProtection"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:GetTaskProtection): call GetTaskProtection with cluster
+		// and compare the response with AWS
+		Skip("TODO(conformance:GetTaskProtection): scenario not written")
+	})
+
+	It("returns AccessDeniedException", func() {
+		// TODO(conformance:GetTaskProtection): trigger AccessDeniedException and compare the error with AWS
+		Skip("TODO(conformance:GetTaskProtection): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:GetTaskProtection): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:GetTaskProtection): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:GetTaskProtection): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:GetTaskProtection): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:GetTaskProtection): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:GetTaskProtection): scenario not written")
+	})
+
+	It("returns ResourceNotFoundException", func() {
+		// TODO(conformance:GetTaskProtection): trigger ResourceNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:GetTaskProtection): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:GetTaskProtection): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:GetTaskProtection): scenario not written")
+	})
+
+	It("returns UnsupportedFeatureException", func() {
+		// TODO(conformance:GetTaskProtection): trigger UnsupportedFeatureException and compare the error with AWS
+		Skip("TODO(conformance:GetTaskProtection): scenario not written")
+	})
+})
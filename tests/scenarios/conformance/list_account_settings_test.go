@@ -0,0 +1,32 @@
+// Conformance scenario of ListAccountSettings, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:ListAccountSettings) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("ListAccountSettings", Label("conformance", "ListAccountSettings"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:ListAccountSettings): call ListAccountSettings without members
+		// and compare the response with AWS
+		Skip("TODO(conformance:ListAccountSettings): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:ListAccountSettings): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:ListAccountSettings): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:ListAccountSettings): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:ListAccountSettings): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:ListAccountSettings): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:ListAccountSettings): scenario not written")
+	})
+})
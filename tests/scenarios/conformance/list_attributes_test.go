@@ -0,0 +1,27 @@
+// Conformance scenario of ListAttributes, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:ListAttributes) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("ListAttributes", Label("conformance", "ListAttributes"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:ListAttributes): call ListAttributes with targetType
+		// and compare the response with AWS
+		Skip("TODO(conformance:ListAttributes): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:ListAttributes): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:ListAttributes): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:ListAttributes): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:ListAttributes): scenario not written")
+	})
+})
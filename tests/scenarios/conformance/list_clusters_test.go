@@ -0,0 +1,32 @@
+// Conformance scenario of ListClusters, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:ListClusters) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("ListClusters", Label("conformance", "ListClusters"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:ListClusters): call ListClusters without members
+		// and compare the response with AWS
+		Skip("TODO(conformance:ListClusters): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:ListClusters): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:ListClusters): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:ListClusters): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:ListClusters): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:ListClusters): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:ListClusters): scenario not written")
+	})
+})
@@ -0,0 +1,47 @@
+// Conformance scenario of ListServiceDeployments, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:ListServiceDeployments) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("ListServiceDeployments", Label("conformance", "ListServiceDeployments"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:ListServiceDeployments): call ListServiceDeployments with service
+		// and compare the response with AWS
+		Skip("TODO(conformance:ListServiceDeployments): scenario not written")
+	})
+
+	It("returns AccessDeniedException", func() {
+		// TODO(conformance:ListServiceDeployments): trigger AccessDeniedException and compare the error with AWS
+		Skip("TODO(conformance:ListServiceDeployments): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:ListServiceDeployments): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:ListServiceDeployments): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:ListServiceDeployments): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:ListServiceDeployments): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:ListServiceDeployments): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:ListServiceDeployments): scenario not written")
+	})
+
+	It("returns ServiceNotFoundException", func() {
+		// TODO(conformance:ListServiceDeployments): trigger ServiceNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:ListServiceDeployments): scenario not written")
+	})
+
+	It("returns UnsupportedFeatureException", func() {
+		// TODO(conformance:ListServiceDeployments): trigger UnsupportedFeatureException and compare the error with AWS
+		Skip("TODO(conformance:ListServiceDeployments): scenario not written")
+	})
+})
@@ -0,0 +1,37 @@
+// Conformance scenario of ListServicesByNamespace, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:ListServicesByNamespace) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("ListServicesByNamespace", Label("conformance", "ListServicesByNamespace"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:ListServicesByNamespace): call ListServicesByNamespace with namespace
+		// and compare the response with AWS
+		Skip("TODO(conformance:ListServicesByNamespace): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:ListServicesByNamespace): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:ListServicesByNamespace): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:ListServicesByNamespace): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:ListServicesByNamespace): scenario not written")
+	})
+
+	It("returns NamespaceNotFoundException", func() {
+		// TODO(conformance:ListServicesByNamespace): trigger NamespaceNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:ListServicesByNamespace): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:ListServicesByNamespace): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:ListServicesByNamespace): scenario not written")
+	})
+})
@@ -0,0 +1,37 @@
+// Conformance scenario of ListServices, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:ListServices) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("ListServices", Label("conformance", "ListServices"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:ListServices): call ListServices without members
+		// and compare the response with AWS
+		Skip("TODO(conformance:ListServices): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:ListServices): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:ListServices): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:ListServices): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:ListServices): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:ListServices): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:ListServices): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:ListServices): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:ListServices): scenario not written")
+	})
+})
@@ -0,0 +1,37 @@
+// Conformance scenario of ListTagsForResource, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:ListTagsForResource) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("ListTagsForResource", Label("conformance", "ListTagsForResource"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:ListTagsForResource): call ListTagsForResource with resourceArn
+		// and compare the response with AWS
+		Skip("TODO(conformance:ListTagsForResource): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:ListTagsForResource): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:ListTagsForResource): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:ListTagsForResource): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:ListTagsForResource): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:ListTagsForResource): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:ListTagsForResource): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:ListTagsForResource): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:ListTagsForResource): scenario not written")
+	})
+})
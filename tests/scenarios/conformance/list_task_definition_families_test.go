@@ -0,0 +1,32 @@
+// Conformance scenario of ListTaskDefinitionFamilies, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:ListTaskDefinitionFamilies) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("ListTaskDefinitionFamilies", Label("conformance", "ListTaskDefinitionFamilies"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:ListTaskDefinitionFamilies): call ListTaskDefinitionFamilies without members
+		// and compare the response with AWS
+		Skip("TODO(conformance:ListTaskDefinitionFamilies): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:ListTaskDefinitionFamilies): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:ListTaskDefinitionFamilies): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:ListTaskDefinitionFamilies): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:ListTaskDefinitionFamilies): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:ListTaskDefinitionFamilies): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:ListTaskDefinitionFamilies): scenario not written")
+	})
+})
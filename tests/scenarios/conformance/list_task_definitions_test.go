@@ -0,0 +1,32 @@
+// Conformance scenario of ListTaskDefinitions, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:ListTaskDefinitions) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("ListTaskDefinitions", Label("conformance", "ListTaskDefinitions"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:ListTaskDefinitions): call ListTaskDefinitions without members
+		// and compare the response with AWS
+		Skip("TODO(conformance:ListTaskDefinitions): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:ListTaskDefinitions): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:ListTaskDefinitions): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:ListTaskDefinitions): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:ListTaskDefinitions): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:ListTaskDefinitions): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:ListTaskDefinitions): scenario not written")
+	})
+})
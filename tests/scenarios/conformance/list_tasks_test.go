@@ -0,0 +1,42 @@
+// Conformance scenario of ListTasks, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:ListTasks) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("ListTasks", Label("conformance", "ListTasks"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:ListTasks): call ListTasks without members
+		// and compare the response with AWS
+		Skip("TODO(conformance:ListTasks): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:ListTasks): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:ListTasks): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:ListTasks): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:ListTasks): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:ListTasks): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:ListTasks): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:ListTasks): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:ListTasks): scenario not written")
+	})
+
+	It("returns ServiceNotFoundException", func() {
+		// TODO(conformance:ListTasks): trigger ServiceNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:ListTasks): scenario not written")
+	})
+})
@@ -0,0 +1,32 @@
+// Conformance scenario of PutAccountSettingDefault, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:PutAccountSettingDefault) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("PutAccountSettingDefault", Label("conformance", "PutAccountSettingDefault"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:PutAccountSettingDefault): call PutAccountSettingDefault with name, value
+		// and compare the response with AWS
+		Skip("TODO(conformance:PutAccountSettingDefault): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:PutAccountSettingDefault): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:PutAccountSettingDefault): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:PutAccountSettingDefault): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:PutAccountSettingDefault): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:PutAccountSettingDefault): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:PutAccountSettingDefault): scenario not written")
+	})
+})
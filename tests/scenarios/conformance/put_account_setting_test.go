@@ -0,0 +1,32 @@
+// Conformance scenario of PutAccountSetting, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:PutAccountSetting) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("PutAccountSetting", Label("conformance", "PutAccountSetting"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:PutAccountSetting): call PutAccountSetting with name, value
+		// and compare the response with AWS
+		Skip("TODO(conformance:PutAccountSetting): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:PutAccountSetting): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:PutAccountSetting): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:PutAccountSetting): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:PutAccountSetting): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:PutAccountSetting): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:PutAccountSetting): scenario not written")
+	})
+})
@@ -0,0 +1,37 @@
+// Conformance scenario of PutAttributes, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:PutAttributes) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("PutAttributes", Label("conformance", "PutAttributes"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:PutAttributes): call PutAttributes with attributes
+		// and compare the response with AWS
+		Skip("TODO(conformance:PutAttributes): scenario not written")
+	})
+
+	It("returns AttributeLimitExceededException", func() {
+		// TODO(conformance:PutAttributes): trigger AttributeLimitExceededException and compare the error with AWS
+		Skip("TODO(conformance:PutAttributes): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:PutAttributes): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:PutAttributes): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:PutAttributes): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:PutAttributes): scenario not written")
+	})
+
+	It("returns TargetNotFoundException", func() {
+		// TODO(conformance:PutAttributes): trigger TargetNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:PutAttributes): scenario not written")
+	})
+})
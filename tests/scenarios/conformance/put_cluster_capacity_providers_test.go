@@ -0,0 +1,47 @@
+// Conformance scenario of PutClusterCapacityProviders, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:PutClusterCapacityProviders) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("PutClusterCapacityProviders", Label("conformance", "PutClusterCapacityProviders"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:PutClusterCapacityProviders): call PutClusterCapacityProviders with capacityProviders, cluster, defaultCapacityProviderStrategy
+		// and compare the response with AWS
+		Skip("TODO(conformance:PutClusterCapacityProviders): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:PutClusterCapacityProviders): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:PutClusterCapacityProviders): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:PutClusterCapacityProviders): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:PutClusterCapacityProviders): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:PutClusterCapacityProviders): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:PutClusterCapacityProviders): scenario not written")
+	})
+
+	It("returns ResourceInUseException", func() {
+		// TODO(conformance:PutClusterCapacityProviders): trigger ResourceInUseException and compare the error with AWS
+		Skip("TODO(conformance:PutClusterCapacityProviders): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:PutClusterCapacityProviders): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:PutClusterCapacityProviders): scenario not written")
+	})
+
+	It("returns UpdateInProgressException", func() {
+		// TODO(conformance:PutClusterCapacityProviders): trigger UpdateInProgressException and compare the error with AWS
+		Skip("TODO(conformance:PutClusterCapacityProviders): scenario not written")
+	})
+})
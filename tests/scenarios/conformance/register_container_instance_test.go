@@ -0,0 +1,32 @@
+// Conformance scenario of RegisterContainerInstance, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:RegisterContainerInstance) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("RegisterContainerInstance", Label("conformance", "RegisterContainerInstance"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:RegisterContainerInstance): call RegisterContainerInstance without members
+		// and compare the response with AWS
+		Skip("TODO(conformance:RegisterContainerInstance): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:RegisterContainerInstance): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:RegisterContainerInstance): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:RegisterContainerInstance): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:RegisterContainerInstance): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:RegisterContainerInstance): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:RegisterContainerInstance): scenario not written")
+	})
+})
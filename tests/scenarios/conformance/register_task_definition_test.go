@@ -0,0 +1,32 @@
+// Conformance scenario of RegisterTaskDefinition, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:RegisterTaskDefinition) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("RegisterTaskDefinition", Label("conformance", "RegisterTaskDefinition"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:RegisterTaskDefinition): call RegisterTaskDefinition with containerDefinitions, family
+		// and compare the response with AWS
+		Skip("TODO(conformance:RegisterTaskDefinition): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:RegisterTaskDefinition): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:RegisterTaskDefinition): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:RegisterTaskDefinition): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:RegisterTaskDefinition): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:RegisterTaskDefinition): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:RegisterTaskDefinition): scenario not written")
+	})
+})
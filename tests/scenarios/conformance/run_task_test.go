@@ -0,0 +1,67 @@
+// Conformance scenario of RunTask, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:RunTask) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("RunTask", Label("conformance", "RunTask"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:RunTask): call RunTask with taskDefinition
+		// and compare the response with AWS
+		Skip("TODO(conformance:RunTask): scenario not written")
+	})
+
+	It("returns AccessDeniedException", func() {
+		// TODO(conformance:RunTask): trigger AccessDeniedException and compare the error with AWS
+		Skip("TODO(conformance:RunTask): scenario not written")
+	})
+
+	It("returns BlockedException", func() {
+		// TODO(conformance:RunTask): trigger BlockedException and compare the error with AWS
+		Skip("TODO(conformance:RunTask): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:RunTask): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:RunTask): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:RunTask): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:RunTask): scenario not written")
+	})
+
+	It("returns ConflictException", func() {
+		// TODO(conformance:RunTask): trigger ConflictException and compare the error with AWS
+		Skip("TODO(conformance:RunTask): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:RunTask): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:RunTask): scenario not written")
+	})
+
+	It("returns PlatformTaskDefinitionIncompatibilityException", func() {
+		// TODO(conformance:RunTask): trigger PlatformTaskDefinitionIncompatibilityException and compare the error with AWS
+		Skip("TODO(conformance:RunTask): scenario not written")
+	})
+
+	It("returns PlatformUnknownException", func() {
+		// TODO(conformance:RunTask): trigger PlatformUnknownException and compare the error with AWS
+		Skip("TODO(conformance:RunTask): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:RunTask): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:RunTask): scenario not written")
+	})
+
+	It("returns UnsupportedFeatureException", func() {
+		// TODO(conformance:RunTask): trigger UnsupportedFeatureException and compare the error with AWS
+		Skip("TODO(conformance:RunTask): scenario not written")
+	})
+})
@@ -0,0 +1,42 @@
+// Conformance scenario of StartTask, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:StartTask) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("StartTask", Label("conformance", "StartTask"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:StartTask): call StartTask with containerInstances, taskDefinition
+		// and compare the response with AWS
+		Skip("TODO(conformance:StartTask): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:StartTask): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:StartTask): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:StartTask): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:StartTask): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:StartTask): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:StartTask): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:StartTask): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:StartTask): scenario not written")
+	})
+
+	It("returns UnsupportedFeatureException", func() {
+		// TODO(conformance:StartTask): trigger UnsupportedFeatureException and compare the error with AWS
+		Skip("TODO(conformance:StartTask): scenario not written")
+	})
+})
@@ -0,0 +1,52 @@
+// Conformance scenario of StopServiceDeployment, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:StopServiceDeployment) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("StopServiceDeployment", Label("conformance", "StopServiceDeployment"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:StopServiceDeployment): call StopServiceDeployment with serviceDeploymentArn
+		// and compare the response with AWS
+		Skip("TODO(conformance:StopServiceDeployment): scenario not written")
+	})
+
+	It("returns AccessDeniedException", func() {
+		// TODO(conformance:StopServiceDeployment): trigger AccessDeniedException and compare the error with AWS
+		Skip("TODO(conformance:StopServiceDeployment): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:StopServiceDeployment): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:StopServiceDeployment): scenario not written")
+	})
+
+	It("returns ConflictException", func() {
+		// TODO(conformance:StopServiceDeployment): trigger ConflictException and compare the error with AWS
+		Skip("TODO(conformance:StopServiceDeployment): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:StopServiceDeployment): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:StopServiceDeployment): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:StopServiceDeployment): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:StopServiceDeployment): scenario not written")
+	})
+
+	It("returns ServiceDeploymentNotFoundException", func() {
+		// TODO(conformance:StopServiceDeployment): trigger ServiceDeploymentNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:StopServiceDeployment): scenario not written")
+	})
+
+	It("returns UnsupportedFeatureException", func() {
+		// TODO(conformance:StopServiceDeployment): trigger UnsupportedFeatureException and compare the error with AWS
+		Skip("TODO(conformance:StopServiceDeployment): scenario not written")
+	})
+})
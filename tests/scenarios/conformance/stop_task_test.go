@@ -0,0 +1,37 @@
+// Conformance scenario of StopTask, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:StopTask) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("StopTask", Label("conformance", "StopTask"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:StopTask): call StopTask with task
+		// and compare the response with AWS
+		Skip("TODO(conformance:StopTask): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:StopTask): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:StopTask): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:StopTask): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:StopTask): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:StopTask): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:StopTask): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:StopTask): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:StopTask): scenario not written")
+	})
+})
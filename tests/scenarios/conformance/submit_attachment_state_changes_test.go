@@ -0,0 +1,37 @@
+// Conformance scenario of SubmitAttachmentStateChanges, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:SubmitAttachmentStateChanges) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("SubmitAttachmentStateChanges", Label("conformance", "SubmitAttachmentStateChanges"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:SubmitAttachmentStateChanges): call SubmitAttachmentStateChanges with attachments
+		// and compare the response with AWS
+		Skip("TODO(conformance:SubmitAttachmentStateChanges): scenario not written")
+	})
+
+	It("returns AccessDeniedException", func() {
+		// TODO(conformance:SubmitAttachmentStateChanges): trigger AccessDeniedException and compare the error with AWS
+		Skip("TODO(conformance:SubmitAttachmentStateChanges): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:SubmitAttachmentStateChanges): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:SubmitAttachmentStateChanges): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:SubmitAttachmentStateChanges): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:SubmitAttachmentStateChanges): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:SubmitAttachmentStateChanges): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:SubmitAttachmentStateChanges): scenario not written")
+	})
+})
@@ -0,0 +1,32 @@
+// Conformance scenario of SubmitContainerStateChange, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:SubmitContainerStateChange) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("SubmitContainerStateChange", Label("conformance", "SubmitContainerStateChange"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:SubmitContainerStateChange): call SubmitContainerStateChange without members
+		// and compare the response with AWS
+		Skip("TODO(conformance:SubmitContainerStateChange): scenario not written")
+	})
+
+	It("returns AccessDeniedException", func() {
+		// TODO(conformance:SubmitContainerStateChange): trigger AccessDeniedException and compare the error with AWS
+		Skip("TODO(conformance:SubmitContainerStateChange): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:SubmitContainerStateChange): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:SubmitContainerStateChange): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:SubmitContainerStateChange): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:SubmitContainerStateChange): scenario not written")
+	})
+})
@@ -0,0 +1,37 @@
+// Conformance scenario of SubmitTaskStateChange, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:SubmitTaskStateChange) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("SubmitTaskStateChange", Label("conformance", "SubmitTaskStateChange"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:SubmitTaskStateChange): call SubmitTaskStateChange without members
+		// and compare the response with AWS
+		Skip("TODO(conformance:SubmitTaskStateChange): scenario not written")
+	})
+
+	It("returns AccessDeniedException", func() {
+		// TODO(conformance:SubmitTaskStateChange): trigger AccessDeniedException and compare the error with AWS
+		Skip("TODO(conformance:SubmitTaskStateChange): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:SubmitTaskStateChange): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:SubmitTaskStateChange): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:SubmitTaskStateChange): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:SubmitTaskStateChange): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:SubmitTaskStateChange): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:SubmitTaskStateChange): scenario not written")
+	})
+})
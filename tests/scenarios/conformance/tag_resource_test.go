@@ -0,0 +1,42 @@
+// Conformance scenario of TagResource, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:TagResource) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("TagResource", Label("conformance", "TagResource"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:TagResource): call TagResource with resourceArn, tags
+		// and compare the response with AWS
+		Skip("TODO(conformance:TagResource): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:TagResource): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:TagResource): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:TagResource): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:TagResource): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:TagResource): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:TagResource): scenario not written")
+	})
+
+	It("returns ResourceNotFoundException", func() {
+		// TODO(conformance:TagResource): trigger ResourceNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:TagResource): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:TagResource): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:TagResource): scenario not written")
+	})
+})
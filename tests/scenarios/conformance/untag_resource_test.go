@@ -0,0 +1,42 @@
+// Conformance scenario of UntagResource, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:UntagResource) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("UntagResource", Label("conformance", "UntagResource"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:UntagResource): call UntagResource with resourceArn, tagKeys
+		// and compare the response with AWS
+		Skip("TODO(conformance:UntagResource): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:UntagResource): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:UntagResource): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:UntagResource): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:UntagResource): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:UntagResource): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:UntagResource): scenario not written")
+	})
+
+	It("returns ResourceNotFoundException", func() {
+		// TODO(conformance:UntagResource): trigger ResourceNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:UntagResource): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:UntagResource): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:UntagResource): scenario not written")
+	})
+})
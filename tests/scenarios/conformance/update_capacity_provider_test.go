@@ -0,0 +1,32 @@
+// Conformance scenario of UpdateCapacityProvider, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:UpdateCapacityProvider) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("UpdateCapacityProvider", Label("conformance", "UpdateCapacityProvider"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:UpdateCapacityProvider): call UpdateCapacityProvider with autoScalingGroupProvider, name
+		// and compare the response with AWS
+		Skip("TODO(conformance:UpdateCapacityProvider): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:UpdateCapacityProvider): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:UpdateCapacityProvider): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:UpdateCapacityProvider): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:UpdateCapacityProvider): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:UpdateCapacityProvider): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:UpdateCapacityProvider): scenario not written")
+	})
+})
@@ -0,0 +1,37 @@
+// Conformance scenario of UpdateClusterSettings, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:UpdateClusterSettings) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("UpdateClusterSettings", Label("conformance", "UpdateClusterSettings"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:UpdateClusterSettings): call UpdateClusterSettings with cluster, settings
+		// and compare the response with AWS
+		Skip("TODO(conformance:UpdateClusterSettings): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:UpdateClusterSettings): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:UpdateClusterSettings): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:UpdateClusterSettings): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:UpdateClusterSettings): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:UpdateClusterSettings): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:UpdateClusterSettings): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:UpdateClusterSettings): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:UpdateClusterSettings): scenario not written")
+	})
+})
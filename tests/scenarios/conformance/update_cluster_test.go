@@ -0,0 +1,42 @@
+// Conformance scenario of UpdateCluster, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:UpdateCluster) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("UpdateCluster", Label("conformance", "UpdateCluster"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:UpdateCluster): call UpdateCluster with cluster
+		// and compare the response with AWS
+		Skip("TODO(conformance:UpdateCluster): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:UpdateCluster): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:UpdateCluster): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:UpdateCluster): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:UpdateCluster): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:UpdateCluster): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:UpdateCluster): scenario not written")
+	})
+
+	It("returns NamespaceNotFoundException", func() {
+		// TODO(conformance:UpdateCluster): trigger NamespaceNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:UpdateCluster): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:UpdateCluster): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:UpdateCluster): scenario not written")
+	})
+})
@@ -0,0 +1,52 @@
+// Conformance scenario of UpdateContainerAgent, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:UpdateContainerAgent) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("UpdateContainerAgent", Label("conformance", "UpdateContainerAgent"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:UpdateContainerAgent): call UpdateContainerAgent with containerInstance
+		// and compare the response with AWS
+		Skip("TODO(conformance:UpdateContainerAgent): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:UpdateContainerAgent): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:UpdateContainerAgent): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:UpdateContainerAgent): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:UpdateContainerAgent): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:UpdateContainerAgent): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:UpdateContainerAgent): scenario not written")
+	})
+
+	It("returns MissingVersionException", func() {
+		// TODO(conformance:UpdateContainerAgent): trigger MissingVersionException and compare the error with AWS
+		Skip("TODO(conformance:UpdateContainerAgent): scenario not written")
+	})
+
+	It("returns NoUpdateAvailableException", func() {
+		// TODO(conformance:UpdateContainerAgent): trigger NoUpdateAvailableException and compare the error with AWS
+		Skip("TODO(conformance:UpdateContainerAgent): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:UpdateContainerAgent): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:UpdateContainerAgent): scenario not written")
+	})
+
+	It("returns UpdateInProgressException", func() {
+		// TODO(conformance:UpdateContainerAgent): trigger UpdateInProgressException and compare the error with AWS
+		Skip("TODO(conformance:UpdateContainerAgent): scenario not written")
+	})
+})
@@ -0,0 +1,37 @@
+// Conformance scenario of UpdateContainerInstancesState, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:UpdateContainerInstancesState) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("UpdateContainerInstancesState", Label("conformance", "UpdateContainerInstancesState"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:UpdateContainerInstancesState): call UpdateContainerInstancesState with containerInstances, status
+		// and compare the response with AWS
+		Skip("TODO(conformance:UpdateContainerInstancesState): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:UpdateContainerInstancesState): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:UpdateContainerInstancesState): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:UpdateContainerInstancesState): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:UpdateContainerInstancesState): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:UpdateContainerInstancesState): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:UpdateContainerInstancesState): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:UpdateContainerInstancesState): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:UpdateContainerInstancesState): scenario not written")
+	})
+})
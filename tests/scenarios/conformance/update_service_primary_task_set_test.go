@@ -0,0 +1,62 @@
+// Conformance scenario of UpdateServicePrimaryTaskSet, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:UpdateServicePrimaryTaskSet) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("UpdateServicePrimaryTaskSet", Label("conformance", "UpdateServicePrimaryTaskSet"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:UpdateServicePrimaryTaskSet): call UpdateServicePrimaryTaskSet with cluster, primaryTaskSet, service
+		// and compare the response with AWS
+		Skip("TODO(conformance:UpdateServicePrimaryTaskSet): scenario not written")
+	})
+
+	It("returns AccessDeniedException", func() {
+		// TODO(conformance:UpdateServicePrimaryTaskSet): trigger AccessDeniedException and compare the error with AWS
+		Skip("TODO(conformance:UpdateServicePrimaryTaskSet): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:UpdateServicePrimaryTaskSet): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:UpdateServicePrimaryTaskSet): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:UpdateServicePrimaryTaskSet): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:UpdateServicePrimaryTaskSet): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:UpdateServicePrimaryTaskSet): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:UpdateServicePrimaryTaskSet): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:UpdateServicePrimaryTaskSet): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:UpdateServicePrimaryTaskSet): scenario not written")
+	})
+
+	It("returns ServiceNotActiveException", func() {
+		// TODO(conformance:UpdateServicePrimaryTaskSet): trigger ServiceNotActiveException and compare the error with AWS
+		Skip("TODO(conformance:UpdateServicePrimaryTaskSet): scenario not written")
+	})
+
+	It("returns ServiceNotFoundException", func() {
+		// TODO(conformance:UpdateServicePrimaryTaskSet): trigger ServiceNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:UpdateServicePrimaryTaskSet): scenario not written")
+	})
+
+	It("returns TaskSetNotFoundException", func() {
+		// TODO(conformance:UpdateServicePrimaryTaskSet): trigger TaskSetNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:UpdateServicePrimaryTaskSet): scenario not written")
+	})
+
+	It("returns UnsupportedFeatureException", func() {
+		// TODO(conformance:UpdateServicePrimaryTaskSet): trigger UnsupportedFeatureException and compare the error with AWS
+		Skip("TODO(conformance:UpdateServicePrimaryTaskSet): scenario not written")
+	})
+})
@@ -0,0 +1,72 @@
+// Conformance scenario of UpdateService, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:UpdateService) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("UpdateService", Label("conformance", "UpdateService"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:UpdateService): call UpdateService with service
+		// and compare the response with AWS
+		Skip("TODO(conformance:UpdateService): scenario not written")
+	})
+
+	It("returns AccessDeniedException", func() {
+		// TODO(conformance:UpdateService): trigger AccessDeniedException and compare the error with AWS
+		Skip("TODO(conformance:UpdateService): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:UpdateService): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:UpdateService): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:UpdateService): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:UpdateService): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:UpdateService): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:UpdateService): scenario not written")
+	})
+
+	It("returns NamespaceNotFoundException", func() {
+		// TODO(conformance:UpdateService): trigger NamespaceNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:UpdateService): scenario not written")
+	})
+
+	It("returns PlatformTaskDefinitionIncompatibilityException", func() {
+		// TODO(conformance:UpdateService): trigger PlatformTaskDefinitionIncompatibilityException and compare the error with AWS
+		Skip("TODO(conformance:UpdateService): scenario not written")
+	})
+
+	It("returns PlatformUnknownException", func() {
+		// TODO(conformance:UpdateService): trigger PlatformUnknownException and compare the error with AWS
+		Skip("TODO(conformance:UpdateService): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:UpdateService): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:UpdateService): scenario not written")
+	})
+
+	It("returns ServiceNotActiveException", func() {
+		// TODO(conformance:UpdateService): trigger ServiceNotActiveException and compare the error with AWS
+		Skip("TODO(conformance:UpdateService): scenario not written")
+	})
+
+	It("returns ServiceNotFoundException", func() {
+		// TODO(conformance:UpdateService): trigger ServiceNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:UpdateService): scenario not written")
+	})
+
+	It("returns UnsupportedFeatureException", func() {
+		// TODO(conformance:UpdateService): trigger UnsupportedFeatureException and compare the error with AWS
+		Skip("TODO(conformance:UpdateService): scenario not written")
+	})
+})
@@ -0,0 +1,52 @@
+// Conformance scenario of UpdateTaskProtection, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:UpdateTaskProtection) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("UpdateTaskProtection", Label("conformance", "UpdateTaskProtection"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:UpdateTaskProtection): call UpdateTaskProtection with cluster, protectionEnabled, tasks
+		// and compare the response with AWS
+		Skip("TODO(conformance:UpdateTaskProtection): scenario not written")
+	})
+
+	It("returns AccessDeniedException", func() {
+		// TODO(conformance:UpdateTaskProtection): trigger AccessDeniedException and compare the error with AWS
+		Skip("TODO(conformance:UpdateTaskProtection): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:UpdateTaskProtection): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:UpdateTaskProtection): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:UpdateTaskProtection): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:UpdateTaskProtection): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:UpdateTaskProtection): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:UpdateTaskProtection): scenario not written")
+	})
+
+	It("returns ResourceNotFoundException", func() {
+		// TODO(conformance:UpdateTaskProtection): trigger ResourceNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:UpdateTaskProtection): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:UpdateTaskProtection): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:UpdateTaskProtection): scenario not written")
+	})
+
+	It("returns UnsupportedFeatureException", func() {
+		// TODO(conformance:UpdateTaskProtection): trigger UnsupportedFeatureException and compare the error with AWS
+		Skip("TODO(conformance:UpdateTaskProtection): scenario not written")
+	})
+})
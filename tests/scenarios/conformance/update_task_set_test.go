@@ -0,0 +1,62 @@
+// Conformance scenario of UpdateTaskSet, generated by cmd/conformance from
+// the ECS model. Replace the TODO(conformance:UpdateTaskSet) entries
+// with the scenario; the report counts it as written once none is left.
+
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("UpdateTaskSet", Label("conformance", "UpdateTaskSet"), func() {
+	It("succeeds with the required members", func() {
+		// TODO(conformance:UpdateTaskSet): call UpdateTaskSet with cluster, scale, service, taskSet
+		// and compare the response with AWS
+		Skip("TODO(conformance:UpdateTaskSet): scenario not written")
+	})
+
+	It("returns AccessDeniedException", func() {
+		// TODO(conformance:UpdateTaskSet): trigger AccessDeniedException and compare the error with AWS
+		Skip("TODO(conformance:UpdateTaskSet): scenario not written")
+	})
+
+	It("returns ClientException", func() {
+		// TODO(conformance:UpdateTaskSet): trigger ClientException and compare the error with AWS
+		Skip("TODO(conformance:UpdateTaskSet): scenario not written")
+	})
+
+	It("returns ClusterNotFoundException", func() {
+		// TODO(conformance:UpdateTaskSet): trigger ClusterNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:UpdateTaskSet): scenario not written")
+	})
+
+	It("returns InvalidParameterException", func() {
+		// TODO(conformance:UpdateTaskSet): trigger InvalidParameterException and compare the error with AWS
+		Skip("TODO(conformance:UpdateTaskSet): scenario not written")
+	})
+
+	It("returns ServerException", func() {
+		// TODO(conformance:UpdateTaskSet): trigger ServerException and compare the error with AWS
+		Skip("TODO(conformance:UpdateTaskSet): scenario not written")
+	})
+
+	It("returns ServiceNotActiveException", func() {
+		// TODO(conformance:UpdateTaskSet): trigger ServiceNotActiveException and compare the error with AWS
+		Skip("TODO(conformance:UpdateTaskSet): scenario not written")
+	})
+
+	It("returns ServiceNotFoundException", func() {
+		// TODO(conformance:UpdateTaskSet): trigger ServiceNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:UpdateTaskSet): scenario not written")
+	})
+
+	It("returns TaskSetNotFoundException", func() {
+		// TODO(conformance:UpdateTaskSet): trigger TaskSetNotFoundException and compare the error with AWS
+		Skip("TODO(conformance:UpdateTaskSet): scenario not written")
+	})
+
+	It("returns UnsupportedFeatureException", func() {
+		// TODO(conformance:UpdateTaskSet): trigger UnsupportedFeatureException and compare the error with AWS
+		Skip("TODO(conformance:UpdateTaskSet): scenario not written")
+	})
+})